@@ -0,0 +1,2033 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: api/omega/v1/omega.proto
+
+// Package omega.v1 is the gRPC management API of Pocket-Omega. It drives the
+// same agent runs as the web UI (POST /api/agent) and manages sessions and
+// tools of a running server. Enable it with GRPC_ADDR. The server also
+// answers the standard health checks (grpc.health.v1.Health), for the whole
+// server ("") and for omega.v1.OmegaService.
+//
+// Regenerate the Go code after editing (from the repository root):
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//	  api/omega/v1/omega.proto
+
+package omegav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RunTaskRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The task, like the message field of POST /api/agent.
+	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	// Session whose history the run continues and extends; empty = one-off run.
+	SessionId string `protobuf:"bytes,2,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Answer style profile; empty = default.
+	AnswerStyle string `protobuf:"bytes,3,opt,name=answer_style,json=answerStyle,proto3" json:"answer_style,omitempty"`
+	// Continue the session's interrupted run from its saved plan (like /resume
+	// in the web UI) instead of starting a new task; message is ignored.
+	// NOT_FOUND when the session has no interrupted run.
+	Resume        bool `protobuf:"varint,4,opt,name=resume,proto3" json:"resume,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunTaskRequest) Reset() {
+	*x = RunTaskRequest{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunTaskRequest) ProtoMessage() {}
+
+func (x *RunTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunTaskRequest.ProtoReflect.Descriptor instead.
+func (*RunTaskRequest) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RunTaskRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *RunTaskRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *RunTaskRequest) GetAnswerStyle() string {
+	if x != nil {
+		return x.AnswerStyle
+	}
+	return ""
+}
+
+func (x *RunTaskRequest) GetResume() bool {
+	if x != nil {
+		return x.Resume
+	}
+	return false
+}
+
+// RunEvent is one event of a running task; the cases mirror the SSE events
+// of POST /api/agent.
+type RunEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*RunEvent_Queued
+	//	*RunEvent_RunStarted
+	//	*RunEvent_Status
+	//	*RunEvent_Step
+	//	*RunEvent_Plan
+	//	*RunEvent_Chunk
+	//	*RunEvent_Notice
+	//	*RunEvent_EditorLink
+	//	*RunEvent_Done
+	Event         isRunEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunEvent) Reset() {
+	*x = RunEvent{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunEvent) ProtoMessage() {}
+
+func (x *RunEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunEvent.ProtoReflect.Descriptor instead.
+func (*RunEvent) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RunEvent) GetEvent() isRunEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *RunEvent) GetQueued() *Queued {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_Queued); ok {
+			return x.Queued
+		}
+	}
+	return nil
+}
+
+func (x *RunEvent) GetRunStarted() *RunStarted {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_RunStarted); ok {
+			return x.RunStarted
+		}
+	}
+	return nil
+}
+
+func (x *RunEvent) GetStatus() *Status {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_Status); ok {
+			return x.Status
+		}
+	}
+	return nil
+}
+
+func (x *RunEvent) GetStep() *Step {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_Step); ok {
+			return x.Step
+		}
+	}
+	return nil
+}
+
+func (x *RunEvent) GetPlan() *Plan {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_Plan); ok {
+			return x.Plan
+		}
+	}
+	return nil
+}
+
+func (x *RunEvent) GetChunk() *Chunk {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_Chunk); ok {
+			return x.Chunk
+		}
+	}
+	return nil
+}
+
+func (x *RunEvent) GetNotice() *Notice {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_Notice); ok {
+			return x.Notice
+		}
+	}
+	return nil
+}
+
+func (x *RunEvent) GetEditorLink() *EditorLink {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_EditorLink); ok {
+			return x.EditorLink
+		}
+	}
+	return nil
+}
+
+func (x *RunEvent) GetDone() *Done {
+	if x != nil {
+		if x, ok := x.Event.(*RunEvent_Done); ok {
+			return x.Done
+		}
+	}
+	return nil
+}
+
+type isRunEvent_Event interface {
+	isRunEvent_Event()
+}
+
+type RunEvent_Queued struct {
+	Queued *Queued `protobuf:"bytes,1,opt,name=queued,proto3,oneof"`
+}
+
+type RunEvent_RunStarted struct {
+	RunStarted *RunStarted `protobuf:"bytes,2,opt,name=run_started,json=runStarted,proto3,oneof"`
+}
+
+type RunEvent_Status struct {
+	Status *Status `protobuf:"bytes,3,opt,name=status,proto3,oneof"`
+}
+
+type RunEvent_Step struct {
+	Step *Step `protobuf:"bytes,4,opt,name=step,proto3,oneof"`
+}
+
+type RunEvent_Plan struct {
+	Plan *Plan `protobuf:"bytes,5,opt,name=plan,proto3,oneof"`
+}
+
+type RunEvent_Chunk struct {
+	Chunk *Chunk `protobuf:"bytes,6,opt,name=chunk,proto3,oneof"`
+}
+
+type RunEvent_Notice struct {
+	Notice *Notice `protobuf:"bytes,7,opt,name=notice,proto3,oneof"`
+}
+
+type RunEvent_EditorLink struct {
+	EditorLink *EditorLink `protobuf:"bytes,8,opt,name=editor_link,json=editorLink,proto3,oneof"`
+}
+
+type RunEvent_Done struct {
+	Done *Done `protobuf:"bytes,9,opt,name=done,proto3,oneof"`
+}
+
+func (*RunEvent_Queued) isRunEvent_Event() {}
+
+func (*RunEvent_RunStarted) isRunEvent_Event() {}
+
+func (*RunEvent_Status) isRunEvent_Event() {}
+
+func (*RunEvent_Step) isRunEvent_Event() {}
+
+func (*RunEvent_Plan) isRunEvent_Event() {}
+
+func (*RunEvent_Chunk) isRunEvent_Event() {}
+
+func (*RunEvent_Notice) isRunEvent_Event() {}
+
+func (*RunEvent_EditorLink) isRunEvent_Event() {}
+
+func (*RunEvent_Done) isRunEvent_Event() {}
+
+// Queued reports that the run waits: position is the 1-based place in the
+// run queue, or 0 while another run of the same session is still active.
+type Queued struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Position      int32                  `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Queued) Reset() {
+	*x = Queued{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Queued) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Queued) ProtoMessage() {}
+
+func (x *Queued) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Queued.ProtoReflect.Descriptor instead.
+func (*Queued) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Queued) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+func (x *Queued) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// RunStarted carries the run ID for CancelRun.
+type RunStarted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunStarted) Reset() {
+	*x = RunStarted{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunStarted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunStarted) ProtoMessage() {}
+
+func (x *RunStarted) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunStarted.ProtoReflect.Descriptor instead.
+func (*RunStarted) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RunStarted) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type Status struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Status) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Step is a completed agent step (decide, tool, think, compact, review or
+// answer).
+type Step struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StepNumber    int32                  `protobuf:"varint,1,opt,name=step_number,json=stepNumber,proto3" json:"step_number,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"`
+	ToolName      string                 `protobuf:"bytes,4,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	Input         string                 `protobuf:"bytes,5,opt,name=input,proto3" json:"input,omitempty"`
+	Output        string                 `protobuf:"bytes,6,opt,name=output,proto3" json:"output,omitempty"`
+	ToolCallId    string                 `protobuf:"bytes,7,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	IsError       bool                   `protobuf:"varint,8,opt,name=is_error,json=isError,proto3" json:"is_error,omitempty"`
+	DurationMs    int64                  `protobuf:"varint,9,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	OutputRef     string                 `protobuf:"bytes,10,opt,name=output_ref,json=outputRef,proto3" json:"output_ref,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Step) Reset() {
+	*x = Step{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Step) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Step) ProtoMessage() {}
+
+func (x *Step) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Step.ProtoReflect.Descriptor instead.
+func (*Step) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Step) GetStepNumber() int32 {
+	if x != nil {
+		return x.StepNumber
+	}
+	return 0
+}
+
+func (x *Step) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Step) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *Step) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *Step) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *Step) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *Step) GetToolCallId() string {
+	if x != nil {
+		return x.ToolCallId
+	}
+	return ""
+}
+
+func (x *Step) GetIsError() bool {
+	if x != nil {
+		return x.IsError
+	}
+	return false
+}
+
+func (x *Step) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *Step) GetOutputRef() string {
+	if x != nil {
+		return x.OutputRef
+	}
+	return ""
+}
+
+// Plan is the full execution plan after each change.
+type Plan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Steps         []*PlanStep            `protobuf:"bytes,1,rep,name=steps,proto3" json:"steps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Plan) Reset() {
+	*x = Plan{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plan) ProtoMessage() {}
+
+func (x *Plan) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plan.ProtoReflect.Descriptor instead.
+func (*Plan) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Plan) GetSteps() []*PlanStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+type PlanStep struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	// "pending", "in_progress", "done", "error" or "skipped".
+	Status        string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Detail        string `protobuf:"bytes,4,opt,name=detail,proto3" json:"detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanStep) Reset() {
+	*x = PlanStep{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanStep) ProtoMessage() {}
+
+func (x *PlanStep) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanStep.ProtoReflect.Descriptor instead.
+func (*PlanStep) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PlanStep) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlanStep) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *PlanStep) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *PlanStep) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+// Chunk is a piece of the streamed answer.
+type Chunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Chunk) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// Notice is a one-off run notice, e.g. a model downshift or an applied
+// correction.
+type Notice struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Notice) Reset() {
+	*x = Notice{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Notice) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Notice) ProtoMessage() {}
+
+func (x *Notice) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Notice.ProtoReflect.Descriptor instead.
+func (*Notice) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Notice) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Notice) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// EditorLink points at the workspace file a tool step touched.
+type EditorLink struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StepNumber    int32                  `protobuf:"varint,1,opt,name=step_number,json=stepNumber,proto3" json:"step_number,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Line          int32                  `protobuf:"varint,3,opt,name=line,proto3" json:"line,omitempty"`
+	Url           string                 `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EditorLink) Reset() {
+	*x = EditorLink{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EditorLink) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EditorLink) ProtoMessage() {}
+
+func (x *EditorLink) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EditorLink.ProtoReflect.Descriptor instead.
+func (*EditorLink) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EditorLink) GetStepNumber() int32 {
+	if x != nil {
+		return x.StepNumber
+	}
+	return 0
+}
+
+func (x *EditorLink) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *EditorLink) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *EditorLink) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+// Done ends the run with its answer.
+type Done struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Solution      string                 `protobuf:"bytes,1,opt,name=solution,proto3" json:"solution,omitempty"`
+	Stats         *RunStats              `protobuf:"bytes,2,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Done) Reset() {
+	*x = Done{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Done) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Done) ProtoMessage() {}
+
+func (x *Done) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Done.ProtoReflect.Descriptor instead.
+func (*Done) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Done) GetSolution() string {
+	if x != nil {
+		return x.Solution
+	}
+	return ""
+}
+
+func (x *Done) GetStats() *RunStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+type RunStats struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Steps     int32                  `protobuf:"varint,1,opt,name=steps,proto3" json:"steps,omitempty"`
+	ToolCalls int32                  `protobuf:"varint,2,opt,name=tool_calls,json=toolCalls,proto3" json:"tool_calls,omitempty"`
+	ElapsedMs int64                  `protobuf:"varint,3,opt,name=elapsed_ms,json=elapsedMs,proto3" json:"elapsed_ms,omitempty"`
+	// 0 when no token budget is configured.
+	TokensUsed int64 `protobuf:"varint,4,opt,name=tokens_used,json=tokensUsed,proto3" json:"tokens_used,omitempty"`
+	// "success", "partial" or "failure".
+	Outcome string `protobuf:"bytes,5,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	// Set when decide steps switched to the downshift model.
+	Downshift     *Downshift `protobuf:"bytes,6,opt,name=downshift,proto3" json:"downshift,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunStats) Reset() {
+	*x = RunStats{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunStats) ProtoMessage() {}
+
+func (x *RunStats) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunStats.ProtoReflect.Descriptor instead.
+func (*RunStats) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RunStats) GetSteps() int32 {
+	if x != nil {
+		return x.Steps
+	}
+	return 0
+}
+
+func (x *RunStats) GetToolCalls() int32 {
+	if x != nil {
+		return x.ToolCalls
+	}
+	return 0
+}
+
+func (x *RunStats) GetElapsedMs() int64 {
+	if x != nil {
+		return x.ElapsedMs
+	}
+	return 0
+}
+
+func (x *RunStats) GetTokensUsed() int64 {
+	if x != nil {
+		return x.TokensUsed
+	}
+	return 0
+}
+
+func (x *RunStats) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *RunStats) GetDownshift() *Downshift {
+	if x != nil {
+		return x.Downshift
+	}
+	return nil
+}
+
+type Downshift struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromModel     string                 `protobuf:"bytes,1,opt,name=from_model,json=fromModel,proto3" json:"from_model,omitempty"`
+	ToModel       string                 `protobuf:"bytes,2,opt,name=to_model,json=toModel,proto3" json:"to_model,omitempty"`
+	Step          int32                  `protobuf:"varint,3,opt,name=step,proto3" json:"step,omitempty"`
+	UsedTokens    int64                  `protobuf:"varint,4,opt,name=used_tokens,json=usedTokens,proto3" json:"used_tokens,omitempty"`
+	MaxTokens     int64                  `protobuf:"varint,5,opt,name=max_tokens,json=maxTokens,proto3" json:"max_tokens,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Downshift) Reset() {
+	*x = Downshift{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Downshift) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Downshift) ProtoMessage() {}
+
+func (x *Downshift) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Downshift.ProtoReflect.Descriptor instead.
+func (*Downshift) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Downshift) GetFromModel() string {
+	if x != nil {
+		return x.FromModel
+	}
+	return ""
+}
+
+func (x *Downshift) GetToModel() string {
+	if x != nil {
+		return x.ToModel
+	}
+	return ""
+}
+
+func (x *Downshift) GetStep() int32 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
+func (x *Downshift) GetUsedTokens() int64 {
+	if x != nil {
+		return x.UsedTokens
+	}
+	return 0
+}
+
+func (x *Downshift) GetMaxTokens() int64 {
+	if x != nil {
+		return x.MaxTokens
+	}
+	return 0
+}
+
+type CancelRunRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RunId         string                 `protobuf:"bytes,1,opt,name=run_id,json=runId,proto3" json:"run_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRunRequest) Reset() {
+	*x = CancelRunRequest{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRunRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRunRequest) ProtoMessage() {}
+
+func (x *CancelRunRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRunRequest.ProtoReflect.Descriptor instead.
+func (*CancelRunRequest) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CancelRunRequest) GetRunId() string {
+	if x != nil {
+		return x.RunId
+	}
+	return ""
+}
+
+type CancelRunResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelRunResponse) Reset() {
+	*x = CancelRunResponse{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelRunResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelRunResponse) ProtoMessage() {}
+
+func (x *CancelRunResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelRunResponse.ProtoReflect.Descriptor instead.
+func (*CancelRunResponse) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{15}
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{16}
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*SessionInfo         `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*SessionInfo {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+type SessionInfo struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Turns          int32                  `protobuf:"varint,2,opt,name=turns,proto3" json:"turns,omitempty"`
+	HasSummary     bool                   `protobuf:"varint,3,opt,name=has_summary,json=hasSummary,proto3" json:"has_summary,omitempty"`
+	LastUsedUnixMs int64                  `protobuf:"varint,4,opt,name=last_used_unix_ms,json=lastUsedUnixMs,proto3" json:"last_used_unix_ms,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SessionInfo) Reset() {
+	*x = SessionInfo{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInfo) ProtoMessage() {}
+
+func (x *SessionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInfo.ProtoReflect.Descriptor instead.
+func (*SessionInfo) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SessionInfo) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetTurns() int32 {
+	if x != nil {
+		return x.Turns
+	}
+	return 0
+}
+
+func (x *SessionInfo) GetHasSummary() bool {
+	if x != nil {
+		return x.HasSummary
+	}
+	return false
+}
+
+func (x *SessionInfo) GetLastUsedUnixMs() int64 {
+	if x != nil {
+		return x.LastUsedUnixMs
+	}
+	return 0
+}
+
+type GetSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSessionRequest) Reset() {
+	*x = GetSessionRequest{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSessionRequest) ProtoMessage() {}
+
+func (x *GetSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSessionRequest.ProtoReflect.Descriptor instead.
+func (*GetSessionRequest) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetSessionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Session struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Turns         []*Turn                `protobuf:"bytes,2,rep,name=turns,proto3" json:"turns,omitempty"`
+	Summary       string                 `protobuf:"bytes,3,opt,name=summary,proto3" json:"summary,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Session) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Session) GetTurns() []*Turn {
+	if x != nil {
+		return x.Turns
+	}
+	return nil
+}
+
+func (x *Session) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+type Turn struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserMessage   string                 `protobuf:"bytes,1,opt,name=user_message,json=userMessage,proto3" json:"user_message,omitempty"`
+	Assistant     string                 `protobuf:"bytes,2,opt,name=assistant,proto3" json:"assistant,omitempty"`
+	IsAgent       bool                   `protobuf:"varint,3,opt,name=is_agent,json=isAgent,proto3" json:"is_agent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Turn) Reset() {
+	*x = Turn{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Turn) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Turn) ProtoMessage() {}
+
+func (x *Turn) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Turn.ProtoReflect.Descriptor instead.
+func (*Turn) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *Turn) GetUserMessage() string {
+	if x != nil {
+		return x.UserMessage
+	}
+	return ""
+}
+
+func (x *Turn) GetAssistant() string {
+	if x != nil {
+		return x.Assistant
+	}
+	return ""
+}
+
+func (x *Turn) GetIsAgent() bool {
+	if x != nil {
+		return x.IsAgent
+	}
+	return false
+}
+
+type DeleteSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSessionRequest) Reset() {
+	*x = DeleteSessionRequest{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSessionRequest) ProtoMessage() {}
+
+func (x *DeleteSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSessionRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSessionRequest) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeleteSessionRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSessionResponse) Reset() {
+	*x = DeleteSessionResponse{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSessionResponse) ProtoMessage() {}
+
+func (x *DeleteSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSessionResponse.ProtoReflect.Descriptor instead.
+func (*DeleteSessionResponse) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{23}
+}
+
+type ListToolsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolsRequest) Reset() {
+	*x = ListToolsRequest{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolsRequest) ProtoMessage() {}
+
+func (x *ListToolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolsRequest.ProtoReflect.Descriptor instead.
+func (*ListToolsRequest) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{24}
+}
+
+type ListToolsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tools         []*Tool                `protobuf:"bytes,1,rep,name=tools,proto3" json:"tools,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolsResponse) Reset() {
+	*x = ListToolsResponse{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolsResponse) ProtoMessage() {}
+
+func (x *ListToolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolsResponse.ProtoReflect.Descriptor instead.
+func (*ListToolsResponse) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListToolsResponse) GetTools() []*Tool {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+type Tool struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// JSON Schema of the tool's arguments.
+	InputSchema   string `protobuf:"bytes,3,opt,name=input_schema,json=inputSchema,proto3" json:"input_schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tool) Reset() {
+	*x = Tool{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tool) ProtoMessage() {}
+
+func (x *Tool) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tool.ProtoReflect.Descriptor instead.
+func (*Tool) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Tool) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tool) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Tool) GetInputSchema() string {
+	if x != nil {
+		return x.InputSchema
+	}
+	return ""
+}
+
+type GetWorkspaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetWorkspaceRequest) Reset() {
+	*x = GetWorkspaceRequest{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetWorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetWorkspaceRequest) ProtoMessage() {}
+
+func (x *GetWorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetWorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*GetWorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{27}
+}
+
+type Workspace struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Dir           string                 `protobuf:"bytes,1,opt,name=dir,proto3" json:"dir,omitempty"`
+	ReadOnly      bool                   `protobuf:"varint,2,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	Model         string                 `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	AnswerStyles  []string               `protobuf:"bytes,4,rep,name=answer_styles,json=answerStyles,proto3" json:"answer_styles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Workspace) Reset() {
+	*x = Workspace{}
+	mi := &file_api_omega_v1_omega_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Workspace) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Workspace) ProtoMessage() {}
+
+func (x *Workspace) ProtoReflect() protoreflect.Message {
+	mi := &file_api_omega_v1_omega_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Workspace.ProtoReflect.Descriptor instead.
+func (*Workspace) Descriptor() ([]byte, []int) {
+	return file_api_omega_v1_omega_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *Workspace) GetDir() string {
+	if x != nil {
+		return x.Dir
+	}
+	return ""
+}
+
+func (x *Workspace) GetReadOnly() bool {
+	if x != nil {
+		return x.ReadOnly
+	}
+	return false
+}
+
+func (x *Workspace) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Workspace) GetAnswerStyles() []string {
+	if x != nil {
+		return x.AnswerStyles
+	}
+	return nil
+}
+
+var File_api_omega_v1_omega_proto protoreflect.FileDescriptor
+
+const file_api_omega_v1_omega_proto_rawDesc = "" +
+	"\n" +
+	"\x18api/omega/v1/omega.proto\x12\bomega.v1\"\x84\x01\n" +
+	"\x0eRunTaskRequest\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x02 \x01(\tR\tsessionId\x12!\n" +
+	"\fanswer_style\x18\x03 \x01(\tR\vanswerStyle\x12\x16\n" +
+	"\x06resume\x18\x04 \x01(\bR\x06resume\"\xa4\x03\n" +
+	"\bRunEvent\x12*\n" +
+	"\x06queued\x18\x01 \x01(\v2\x10.omega.v1.QueuedH\x00R\x06queued\x127\n" +
+	"\vrun_started\x18\x02 \x01(\v2\x14.omega.v1.RunStartedH\x00R\n" +
+	"runStarted\x12*\n" +
+	"\x06status\x18\x03 \x01(\v2\x10.omega.v1.StatusH\x00R\x06status\x12$\n" +
+	"\x04step\x18\x04 \x01(\v2\x0e.omega.v1.StepH\x00R\x04step\x12$\n" +
+	"\x04plan\x18\x05 \x01(\v2\x0e.omega.v1.PlanH\x00R\x04plan\x12'\n" +
+	"\x05chunk\x18\x06 \x01(\v2\x0f.omega.v1.ChunkH\x00R\x05chunk\x12*\n" +
+	"\x06notice\x18\a \x01(\v2\x10.omega.v1.NoticeH\x00R\x06notice\x127\n" +
+	"\veditor_link\x18\b \x01(\v2\x14.omega.v1.EditorLinkH\x00R\n" +
+	"editorLink\x12$\n" +
+	"\x04done\x18\t \x01(\v2\x0e.omega.v1.DoneH\x00R\x04doneB\a\n" +
+	"\x05event\">\n" +
+	"\x06Queued\x12\x1a\n" +
+	"\bposition\x18\x01 \x01(\x05R\bposition\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"#\n" +
+	"\n" +
+	"RunStarted\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\"\"\n" +
+	"\x06Status\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"\x9b\x02\n" +
+	"\x04Step\x12\x1f\n" +
+	"\vstep_number\x18\x01 \x01(\x05R\n" +
+	"stepNumber\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\x12\x1b\n" +
+	"\ttool_name\x18\x04 \x01(\tR\btoolName\x12\x14\n" +
+	"\x05input\x18\x05 \x01(\tR\x05input\x12\x16\n" +
+	"\x06output\x18\x06 \x01(\tR\x06output\x12 \n" +
+	"\ftool_call_id\x18\a \x01(\tR\n" +
+	"toolCallId\x12\x19\n" +
+	"\bis_error\x18\b \x01(\bR\aisError\x12\x1f\n" +
+	"\vduration_ms\x18\t \x01(\x03R\n" +
+	"durationMs\x12\x1d\n" +
+	"\n" +
+	"output_ref\x18\n" +
+	" \x01(\tR\toutputRef\"0\n" +
+	"\x04Plan\x12(\n" +
+	"\x05steps\x18\x01 \x03(\v2\x12.omega.v1.PlanStepR\x05steps\"`\n" +
+	"\bPlanStep\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x16\n" +
+	"\x06detail\x18\x04 \x01(\tR\x06detail\"\x1b\n" +
+	"\x05Chunk\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"6\n" +
+	"\x06Notice\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"g\n" +
+	"\n" +
+	"EditorLink\x12\x1f\n" +
+	"\vstep_number\x18\x01 \x01(\x05R\n" +
+	"stepNumber\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04line\x18\x03 \x01(\x05R\x04line\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\"L\n" +
+	"\x04Done\x12\x1a\n" +
+	"\bsolution\x18\x01 \x01(\tR\bsolution\x12(\n" +
+	"\x05stats\x18\x02 \x01(\v2\x12.omega.v1.RunStatsR\x05stats\"\xcc\x01\n" +
+	"\bRunStats\x12\x14\n" +
+	"\x05steps\x18\x01 \x01(\x05R\x05steps\x12\x1d\n" +
+	"\n" +
+	"tool_calls\x18\x02 \x01(\x05R\ttoolCalls\x12\x1d\n" +
+	"\n" +
+	"elapsed_ms\x18\x03 \x01(\x03R\telapsedMs\x12\x1f\n" +
+	"\vtokens_used\x18\x04 \x01(\x03R\n" +
+	"tokensUsed\x12\x18\n" +
+	"\aoutcome\x18\x05 \x01(\tR\aoutcome\x121\n" +
+	"\tdownshift\x18\x06 \x01(\v2\x13.omega.v1.DownshiftR\tdownshift\"\x99\x01\n" +
+	"\tDownshift\x12\x1d\n" +
+	"\n" +
+	"from_model\x18\x01 \x01(\tR\tfromModel\x12\x19\n" +
+	"\bto_model\x18\x02 \x01(\tR\atoModel\x12\x12\n" +
+	"\x04step\x18\x03 \x01(\x05R\x04step\x12\x1f\n" +
+	"\vused_tokens\x18\x04 \x01(\x03R\n" +
+	"usedTokens\x12\x1d\n" +
+	"\n" +
+	"max_tokens\x18\x05 \x01(\x03R\tmaxTokens\")\n" +
+	"\x10CancelRunRequest\x12\x15\n" +
+	"\x06run_id\x18\x01 \x01(\tR\x05runId\"\x13\n" +
+	"\x11CancelRunResponse\"\x15\n" +
+	"\x13ListSessionsRequest\"I\n" +
+	"\x14ListSessionsResponse\x121\n" +
+	"\bsessions\x18\x01 \x03(\v2\x15.omega.v1.SessionInfoR\bsessions\"\x7f\n" +
+	"\vSessionInfo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05turns\x18\x02 \x01(\x05R\x05turns\x12\x1f\n" +
+	"\vhas_summary\x18\x03 \x01(\bR\n" +
+	"hasSummary\x12)\n" +
+	"\x11last_used_unix_ms\x18\x04 \x01(\x03R\x0elastUsedUnixMs\"#\n" +
+	"\x11GetSessionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"Y\n" +
+	"\aSession\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12$\n" +
+	"\x05turns\x18\x02 \x03(\v2\x0e.omega.v1.TurnR\x05turns\x12\x18\n" +
+	"\asummary\x18\x03 \x01(\tR\asummary\"b\n" +
+	"\x04Turn\x12!\n" +
+	"\fuser_message\x18\x01 \x01(\tR\vuserMessage\x12\x1c\n" +
+	"\tassistant\x18\x02 \x01(\tR\tassistant\x12\x19\n" +
+	"\bis_agent\x18\x03 \x01(\bR\aisAgent\"&\n" +
+	"\x14DeleteSessionRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x17\n" +
+	"\x15DeleteSessionResponse\"\x12\n" +
+	"\x10ListToolsRequest\"9\n" +
+	"\x11ListToolsResponse\x12$\n" +
+	"\x05tools\x18\x01 \x03(\v2\x0e.omega.v1.ToolR\x05tools\"_\n" +
+	"\x04Tool\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12!\n" +
+	"\finput_schema\x18\x03 \x01(\tR\vinputSchema\"\x15\n" +
+	"\x13GetWorkspaceRequest\"u\n" +
+	"\tWorkspace\x12\x10\n" +
+	"\x03dir\x18\x01 \x01(\tR\x03dir\x12\x1b\n" +
+	"\tread_only\x18\x02 \x01(\bR\breadOnly\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\x12#\n" +
+	"\ranswer_styles\x18\x04 \x03(\tR\fanswerStyles2\xf8\x03\n" +
+	"\fOmegaService\x129\n" +
+	"\aRunTask\x12\x18.omega.v1.RunTaskRequest\x1a\x12.omega.v1.RunEvent0\x01\x12D\n" +
+	"\tCancelRun\x12\x1a.omega.v1.CancelRunRequest\x1a\x1b.omega.v1.CancelRunResponse\x12M\n" +
+	"\fListSessions\x12\x1d.omega.v1.ListSessionsRequest\x1a\x1e.omega.v1.ListSessionsResponse\x12<\n" +
+	"\n" +
+	"GetSession\x12\x1b.omega.v1.GetSessionRequest\x1a\x11.omega.v1.Session\x12P\n" +
+	"\rDeleteSession\x12\x1e.omega.v1.DeleteSessionRequest\x1a\x1f.omega.v1.DeleteSessionResponse\x12D\n" +
+	"\tListTools\x12\x1a.omega.v1.ListToolsRequest\x1a\x1b.omega.v1.ListToolsResponse\x12B\n" +
+	"\fGetWorkspace\x12\x1d.omega.v1.GetWorkspaceRequest\x1a\x13.omega.v1.WorkspaceB:Z8github.com/pocketomega/pocket-omega/api/omega/v1;omegav1b\x06proto3"
+
+var (
+	file_api_omega_v1_omega_proto_rawDescOnce sync.Once
+	file_api_omega_v1_omega_proto_rawDescData []byte
+)
+
+func file_api_omega_v1_omega_proto_rawDescGZIP() []byte {
+	file_api_omega_v1_omega_proto_rawDescOnce.Do(func() {
+		file_api_omega_v1_omega_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_omega_v1_omega_proto_rawDesc), len(file_api_omega_v1_omega_proto_rawDesc)))
+	})
+	return file_api_omega_v1_omega_proto_rawDescData
+}
+
+var file_api_omega_v1_omega_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
+var file_api_omega_v1_omega_proto_goTypes = []any{
+	(*RunTaskRequest)(nil),        // 0: omega.v1.RunTaskRequest
+	(*RunEvent)(nil),              // 1: omega.v1.RunEvent
+	(*Queued)(nil),                // 2: omega.v1.Queued
+	(*RunStarted)(nil),            // 3: omega.v1.RunStarted
+	(*Status)(nil),                // 4: omega.v1.Status
+	(*Step)(nil),                  // 5: omega.v1.Step
+	(*Plan)(nil),                  // 6: omega.v1.Plan
+	(*PlanStep)(nil),              // 7: omega.v1.PlanStep
+	(*Chunk)(nil),                 // 8: omega.v1.Chunk
+	(*Notice)(nil),                // 9: omega.v1.Notice
+	(*EditorLink)(nil),            // 10: omega.v1.EditorLink
+	(*Done)(nil),                  // 11: omega.v1.Done
+	(*RunStats)(nil),              // 12: omega.v1.RunStats
+	(*Downshift)(nil),             // 13: omega.v1.Downshift
+	(*CancelRunRequest)(nil),      // 14: omega.v1.CancelRunRequest
+	(*CancelRunResponse)(nil),     // 15: omega.v1.CancelRunResponse
+	(*ListSessionsRequest)(nil),   // 16: omega.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),  // 17: omega.v1.ListSessionsResponse
+	(*SessionInfo)(nil),           // 18: omega.v1.SessionInfo
+	(*GetSessionRequest)(nil),     // 19: omega.v1.GetSessionRequest
+	(*Session)(nil),               // 20: omega.v1.Session
+	(*Turn)(nil),                  // 21: omega.v1.Turn
+	(*DeleteSessionRequest)(nil),  // 22: omega.v1.DeleteSessionRequest
+	(*DeleteSessionResponse)(nil), // 23: omega.v1.DeleteSessionResponse
+	(*ListToolsRequest)(nil),      // 24: omega.v1.ListToolsRequest
+	(*ListToolsResponse)(nil),     // 25: omega.v1.ListToolsResponse
+	(*Tool)(nil),                  // 26: omega.v1.Tool
+	(*GetWorkspaceRequest)(nil),   // 27: omega.v1.GetWorkspaceRequest
+	(*Workspace)(nil),             // 28: omega.v1.Workspace
+}
+var file_api_omega_v1_omega_proto_depIdxs = []int32{
+	2,  // 0: omega.v1.RunEvent.queued:type_name -> omega.v1.Queued
+	3,  // 1: omega.v1.RunEvent.run_started:type_name -> omega.v1.RunStarted
+	4,  // 2: omega.v1.RunEvent.status:type_name -> omega.v1.Status
+	5,  // 3: omega.v1.RunEvent.step:type_name -> omega.v1.Step
+	6,  // 4: omega.v1.RunEvent.plan:type_name -> omega.v1.Plan
+	8,  // 5: omega.v1.RunEvent.chunk:type_name -> omega.v1.Chunk
+	9,  // 6: omega.v1.RunEvent.notice:type_name -> omega.v1.Notice
+	10, // 7: omega.v1.RunEvent.editor_link:type_name -> omega.v1.EditorLink
+	11, // 8: omega.v1.RunEvent.done:type_name -> omega.v1.Done
+	7,  // 9: omega.v1.Plan.steps:type_name -> omega.v1.PlanStep
+	12, // 10: omega.v1.Done.stats:type_name -> omega.v1.RunStats
+	13, // 11: omega.v1.RunStats.downshift:type_name -> omega.v1.Downshift
+	18, // 12: omega.v1.ListSessionsResponse.sessions:type_name -> omega.v1.SessionInfo
+	21, // 13: omega.v1.Session.turns:type_name -> omega.v1.Turn
+	26, // 14: omega.v1.ListToolsResponse.tools:type_name -> omega.v1.Tool
+	0,  // 15: omega.v1.OmegaService.RunTask:input_type -> omega.v1.RunTaskRequest
+	14, // 16: omega.v1.OmegaService.CancelRun:input_type -> omega.v1.CancelRunRequest
+	16, // 17: omega.v1.OmegaService.ListSessions:input_type -> omega.v1.ListSessionsRequest
+	19, // 18: omega.v1.OmegaService.GetSession:input_type -> omega.v1.GetSessionRequest
+	22, // 19: omega.v1.OmegaService.DeleteSession:input_type -> omega.v1.DeleteSessionRequest
+	24, // 20: omega.v1.OmegaService.ListTools:input_type -> omega.v1.ListToolsRequest
+	27, // 21: omega.v1.OmegaService.GetWorkspace:input_type -> omega.v1.GetWorkspaceRequest
+	1,  // 22: omega.v1.OmegaService.RunTask:output_type -> omega.v1.RunEvent
+	15, // 23: omega.v1.OmegaService.CancelRun:output_type -> omega.v1.CancelRunResponse
+	17, // 24: omega.v1.OmegaService.ListSessions:output_type -> omega.v1.ListSessionsResponse
+	20, // 25: omega.v1.OmegaService.GetSession:output_type -> omega.v1.Session
+	23, // 26: omega.v1.OmegaService.DeleteSession:output_type -> omega.v1.DeleteSessionResponse
+	25, // 27: omega.v1.OmegaService.ListTools:output_type -> omega.v1.ListToolsResponse
+	28, // 28: omega.v1.OmegaService.GetWorkspace:output_type -> omega.v1.Workspace
+	22, // [22:29] is the sub-list for method output_type
+	15, // [15:22] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
+}
+
+func init() { file_api_omega_v1_omega_proto_init() }
+func file_api_omega_v1_omega_proto_init() {
+	if File_api_omega_v1_omega_proto != nil {
+		return
+	}
+	file_api_omega_v1_omega_proto_msgTypes[1].OneofWrappers = []any{
+		(*RunEvent_Queued)(nil),
+		(*RunEvent_RunStarted)(nil),
+		(*RunEvent_Status)(nil),
+		(*RunEvent_Step)(nil),
+		(*RunEvent_Plan)(nil),
+		(*RunEvent_Chunk)(nil),
+		(*RunEvent_Notice)(nil),
+		(*RunEvent_EditorLink)(nil),
+		(*RunEvent_Done)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_omega_v1_omega_proto_rawDesc), len(file_api_omega_v1_omega_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   29,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_omega_v1_omega_proto_goTypes,
+		DependencyIndexes: file_api_omega_v1_omega_proto_depIdxs,
+		MessageInfos:      file_api_omega_v1_omega_proto_msgTypes,
+	}.Build()
+	File_api_omega_v1_omega_proto = out.File
+	file_api_omega_v1_omega_proto_goTypes = nil
+	file_api_omega_v1_omega_proto_depIdxs = nil
+}
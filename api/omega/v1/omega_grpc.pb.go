@@ -0,0 +1,387 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/omega/v1/omega.proto
+
+// Package omega.v1 is the gRPC management API of Pocket-Omega. It drives the
+// same agent runs as the web UI (POST /api/agent) and manages sessions and
+// tools of a running server. Enable it with GRPC_ADDR. The server also
+// answers the standard health checks (grpc.health.v1.Health), for the whole
+// server ("") and for omega.v1.OmegaService.
+//
+// Regenerate the Go code after editing (from the repository root):
+//
+//	protoc --go_out=. --go_opt=paths=source_relative \
+//	  --go-grpc_out=. --go-grpc_opt=paths=source_relative \
+//	  api/omega/v1/omega.proto
+
+package omegav1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OmegaService_RunTask_FullMethodName       = "/omega.v1.OmegaService/RunTask"
+	OmegaService_CancelRun_FullMethodName     = "/omega.v1.OmegaService/CancelRun"
+	OmegaService_ListSessions_FullMethodName  = "/omega.v1.OmegaService/ListSessions"
+	OmegaService_GetSession_FullMethodName    = "/omega.v1.OmegaService/GetSession"
+	OmegaService_DeleteSession_FullMethodName = "/omega.v1.OmegaService/DeleteSession"
+	OmegaService_ListTools_FullMethodName     = "/omega.v1.OmegaService/ListTools"
+	OmegaService_GetWorkspace_FullMethodName  = "/omega.v1.OmegaService/GetWorkspace"
+)
+
+// OmegaServiceClient is the client API for OmegaService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// OmegaService runs agent tasks and manages sessions, tools and the workspace.
+type OmegaServiceClient interface {
+	// RunTask runs one agent task and streams its events. The first event is
+	// RunStarted (after any Queued events), the last one Done. Closing the
+	// stream aborts the run.
+	RunTask(ctx context.Context, in *RunTaskRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunEvent], error)
+	// CancelRun aborts a running task; NOT_FOUND when no such run is active.
+	CancelRun(ctx context.Context, in *CancelRunRequest, opts ...grpc.CallOption) (*CancelRunResponse, error)
+	// ListSessions lists the conversation sessions held in memory.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// GetSession returns the turns and compact summary of one session.
+	GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*Session, error)
+	// DeleteSession forgets a session's history (the web UI's "clear chat").
+	DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error)
+	// ListTools lists the tools available to agent runs.
+	ListTools(ctx context.Context, in *ListToolsRequest, opts ...grpc.CallOption) (*ListToolsResponse, error)
+	// GetWorkspace describes the workspace and agent configuration.
+	GetWorkspace(ctx context.Context, in *GetWorkspaceRequest, opts ...grpc.CallOption) (*Workspace, error)
+}
+
+type omegaServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOmegaServiceClient(cc grpc.ClientConnInterface) OmegaServiceClient {
+	return &omegaServiceClient{cc}
+}
+
+func (c *omegaServiceClient) RunTask(ctx context.Context, in *RunTaskRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RunEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OmegaService_ServiceDesc.Streams[0], OmegaService_RunTask_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RunTaskRequest, RunEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OmegaService_RunTaskClient = grpc.ServerStreamingClient[RunEvent]
+
+func (c *omegaServiceClient) CancelRun(ctx context.Context, in *CancelRunRequest, opts ...grpc.CallOption) (*CancelRunResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelRunResponse)
+	err := c.cc.Invoke(ctx, OmegaService_CancelRun_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *omegaServiceClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, OmegaService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *omegaServiceClient) GetSession(ctx context.Context, in *GetSessionRequest, opts ...grpc.CallOption) (*Session, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Session)
+	err := c.cc.Invoke(ctx, OmegaService_GetSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *omegaServiceClient) DeleteSession(ctx context.Context, in *DeleteSessionRequest, opts ...grpc.CallOption) (*DeleteSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteSessionResponse)
+	err := c.cc.Invoke(ctx, OmegaService_DeleteSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *omegaServiceClient) ListTools(ctx context.Context, in *ListToolsRequest, opts ...grpc.CallOption) (*ListToolsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListToolsResponse)
+	err := c.cc.Invoke(ctx, OmegaService_ListTools_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *omegaServiceClient) GetWorkspace(ctx context.Context, in *GetWorkspaceRequest, opts ...grpc.CallOption) (*Workspace, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Workspace)
+	err := c.cc.Invoke(ctx, OmegaService_GetWorkspace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OmegaServiceServer is the server API for OmegaService service.
+// All implementations must embed UnimplementedOmegaServiceServer
+// for forward compatibility.
+//
+// OmegaService runs agent tasks and manages sessions, tools and the workspace.
+type OmegaServiceServer interface {
+	// RunTask runs one agent task and streams its events. The first event is
+	// RunStarted (after any Queued events), the last one Done. Closing the
+	// stream aborts the run.
+	RunTask(*RunTaskRequest, grpc.ServerStreamingServer[RunEvent]) error
+	// CancelRun aborts a running task; NOT_FOUND when no such run is active.
+	CancelRun(context.Context, *CancelRunRequest) (*CancelRunResponse, error)
+	// ListSessions lists the conversation sessions held in memory.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// GetSession returns the turns and compact summary of one session.
+	GetSession(context.Context, *GetSessionRequest) (*Session, error)
+	// DeleteSession forgets a session's history (the web UI's "clear chat").
+	DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error)
+	// ListTools lists the tools available to agent runs.
+	ListTools(context.Context, *ListToolsRequest) (*ListToolsResponse, error)
+	// GetWorkspace describes the workspace and agent configuration.
+	GetWorkspace(context.Context, *GetWorkspaceRequest) (*Workspace, error)
+	mustEmbedUnimplementedOmegaServiceServer()
+}
+
+// UnimplementedOmegaServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOmegaServiceServer struct{}
+
+func (UnimplementedOmegaServiceServer) RunTask(*RunTaskRequest, grpc.ServerStreamingServer[RunEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method RunTask not implemented")
+}
+func (UnimplementedOmegaServiceServer) CancelRun(context.Context, *CancelRunRequest) (*CancelRunResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelRun not implemented")
+}
+func (UnimplementedOmegaServiceServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedOmegaServiceServer) GetSession(context.Context, *GetSessionRequest) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSession not implemented")
+}
+func (UnimplementedOmegaServiceServer) DeleteSession(context.Context, *DeleteSessionRequest) (*DeleteSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSession not implemented")
+}
+func (UnimplementedOmegaServiceServer) ListTools(context.Context, *ListToolsRequest) (*ListToolsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTools not implemented")
+}
+func (UnimplementedOmegaServiceServer) GetWorkspace(context.Context, *GetWorkspaceRequest) (*Workspace, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWorkspace not implemented")
+}
+func (UnimplementedOmegaServiceServer) mustEmbedUnimplementedOmegaServiceServer() {}
+func (UnimplementedOmegaServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeOmegaServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OmegaServiceServer will
+// result in compilation errors.
+type UnsafeOmegaServiceServer interface {
+	mustEmbedUnimplementedOmegaServiceServer()
+}
+
+func RegisterOmegaServiceServer(s grpc.ServiceRegistrar, srv OmegaServiceServer) {
+	// If the following call pancis, it indicates UnimplementedOmegaServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OmegaService_ServiceDesc, srv)
+}
+
+func _OmegaService_RunTask_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(RunTaskRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OmegaServiceServer).RunTask(m, &grpc.GenericServerStream[RunTaskRequest, RunEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OmegaService_RunTaskServer = grpc.ServerStreamingServer[RunEvent]
+
+func _OmegaService_CancelRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OmegaServiceServer).CancelRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OmegaService_CancelRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OmegaServiceServer).CancelRun(ctx, req.(*CancelRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OmegaService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OmegaServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OmegaService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OmegaServiceServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OmegaService_GetSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OmegaServiceServer).GetSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OmegaService_GetSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OmegaServiceServer).GetSession(ctx, req.(*GetSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OmegaService_DeleteSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OmegaServiceServer).DeleteSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OmegaService_DeleteSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OmegaServiceServer).DeleteSession(ctx, req.(*DeleteSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OmegaService_ListTools_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListToolsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OmegaServiceServer).ListTools(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OmegaService_ListTools_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OmegaServiceServer).ListTools(ctx, req.(*ListToolsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OmegaService_GetWorkspace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWorkspaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OmegaServiceServer).GetWorkspace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OmegaService_GetWorkspace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OmegaServiceServer).GetWorkspace(ctx, req.(*GetWorkspaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OmegaService_ServiceDesc is the grpc.ServiceDesc for OmegaService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OmegaService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "omega.v1.OmegaService",
+	HandlerType: (*OmegaServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CancelRun",
+			Handler:    _OmegaService_CancelRun_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _OmegaService_ListSessions_Handler,
+		},
+		{
+			MethodName: "GetSession",
+			Handler:    _OmegaService_GetSession_Handler,
+		},
+		{
+			MethodName: "DeleteSession",
+			Handler:    _OmegaService_DeleteSession_Handler,
+		},
+		{
+			MethodName: "ListTools",
+			Handler:    _OmegaService_ListTools_Handler,
+		},
+		{
+			MethodName: "GetWorkspace",
+			Handler:    _OmegaService_GetWorkspace_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "RunTask",
+			Handler:       _OmegaService_RunTask_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "api/omega/v1/omega.proto",
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/batch"
+	"github.com/pocketomega/pocket-omega/internal/llm/openai"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+)
+
+// runBatch implements `omega batch [flags] <workspace>...`: runs the same
+// prompt (or playbook file) in every workspace, sequentially or with bounded
+// parallelism, and prints a consolidated report with each workspace's
+// answer, changed files and git diff. Exit code 2 means some workspace failed.
+func runBatch(args []string) int {
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	promptText := fs.String("prompt", "", "task given to the agent in every workspace")
+	playbook := fs.String("file", "", "read the task from this file (e.g. a markdown playbook) instead of -prompt")
+	list := fs.String("list", "", "file with one workspace path per line (# comments allowed), in addition to the arguments")
+	parallel := fs.Int("parallel", 1, "workspaces run at the same time")
+	timeout := fs.Duration("timeout", 10*time.Minute, "per-workspace timeout")
+	model := fs.String("model", "", "model name (default: LLM_MODEL)")
+	style := fs.String("style", "", "answer style profile (default: the playbook's answer_style frontmatter, else default)")
+	mdOut := fs.String("md", "", "also write the markdown report to this file")
+	jsonOut := fs.String("json", "", "also write the full report as JSON to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: omega batch [flags] <workspace>...")
+		fmt.Fprintln(fs.Output(), "  e.g. omega batch -prompt '把 go.mod 的 go 版本升级到 1.24 并修复编译' ~/src/svc-*")
+		fmt.Fprintln(fs.Output(), "       omega batch -file playbooks/bump-deps.md -list repos.txt -parallel 4 -md report.md")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	task := *promptText
+	if *playbook != "" {
+		data, err := os.ReadFile(*playbook)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		task = string(data)
+	}
+	workspaces := fs.Args()
+	if *list != "" {
+		listed, err := readWorkspaceList(*list)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		workspaces = append(workspaces, listed...)
+	}
+	if strings.TrimSpace(task) == "" || len(workspaces) == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	agentCfg, err := newBatchAgent(*model)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	runner := batch.NewRunner(batch.Options{
+		Agent:    agentCfg,
+		Tools:    headlessTools(os.Getenv("TOOL_SHELL_ENABLED") != "false", true),
+		Parallel: *parallel,
+		Timeout:  *timeout,
+	})
+
+	// Ctrl+C stops starting new workspaces; running ones are cancelled and
+	// still reported with their diffs.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("🏁 Running in %d workspace(s), parallel %d, model %s\n\n", len(workspaces), *parallel, agentCfg.ModelName)
+	report, err := runner.Run(ctx, batch.Job{Prompt: task, Workspaces: workspaces, AnswerStyle: *style}, func(r batch.Result) {
+		mark := "✅"
+		if !r.OK {
+			mark = "❌ " + r.Error
+		}
+		fmt.Printf("%s %s: %d steps, %d changed file(s), %.1fs\n",
+			mark, r.Workspace, r.Steps, len(r.ChangedFiles), float64(r.DurationMs)/1000)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Batch aborted: %v\n", err)
+		return 1
+	}
+
+	fmt.Println()
+	report.WriteMarkdown(os.Stdout)
+
+	if *mdOut != "" {
+		f, err := os.Create(*mdOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write markdown report: %v\n", err)
+			return 1
+		}
+		report.WriteMarkdown(f)
+		f.Close()
+		fmt.Printf("\n📄 Markdown report: %s\n", *mdOut)
+	}
+	if *jsonOut != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*jsonOut, data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write JSON report: %v\n", err)
+			return 1
+		}
+		fmt.Printf("\n📄 JSON report: %s\n", *jsonOut)
+	}
+
+	if report.Failed() > 0 {
+		return 2
+	}
+	return 0
+}
+
+// readWorkspaceList reads one path per line, skipping blanks and # comments.
+func readWorkspaceList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			out = append(out, line)
+		}
+	}
+	return out, sc.Err()
+}
+
+// newBatchAgent builds the agent configuration from the LLM_* environment.
+// Prompts honour PROMPTS_DIR, USER_RULES_PATH and SOUL_PATH when set; there
+// is no single workspace to default them to.
+func newBatchAgent(model string) (batch.Agent, error) {
+	cfg, err := openai.NewConfigFromEnv()
+	if err != nil {
+		return batch.Agent{}, err
+	}
+	if model != "" {
+		cfg.Model = model
+	}
+	client, err := openai.NewClient(cfg)
+	if err != nil {
+		return batch.Agent{}, err
+	}
+
+	loader := prompt.NewPromptLoader(os.Getenv("PROMPTS_DIR"), os.Getenv("USER_RULES_PATH"), os.Getenv("SOUL_PATH"))
+	osName, shellCmd := patchHostPlatform(loader)
+
+	return batch.Agent{
+		Provider:      client,
+		Loader:        loader,
+		ModelName:     cfg.Model,
+		ThinkingMode:  client.GetConfig().ResolveThinkingMode(),
+		ToolCallMode:  client.GetConfig().ToolCallMode,
+		ContextWindow: client.GetConfig().ResolveContextWindow(),
+		OSName:        osName,
+		ShellCmd:      shellCmd,
+		SelfReview:    loadSelfReviewRetries(),
+	}, nil
+}
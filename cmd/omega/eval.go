@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/eval"
+	"github.com/pocketomega/pocket-omega/internal/llm/openai"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+)
+
+// runEval implements `omega eval [flags] <fixtures-dir>`: runs every task
+// fixture against two agent configurations (A = baseline, B = candidate)
+// that differ in L2 prompt directory and/or model, then prints a comparison
+// report. Exit code 2 means B passes fewer runs than A on some task, so the
+// command can gate edits to decide_common.md and friends.
+func runEval(args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	aPrompts := fs.String("a-prompts", os.Getenv("PROMPTS_DIR"), "variant A: L2 prompts override dir (empty = embedded defaults)")
+	bPrompts := fs.String("b-prompts", "", "variant B: L2 prompts override dir (default: same as A)")
+	aModel := fs.String("a-model", "", "variant A: model name (default: LLM_MODEL)")
+	bModel := fs.String("b-model", "", "variant B: model name (default: same as A)")
+	aStyle := fs.String("a-style", "", "variant A: answer style profile (default: default)")
+	bStyle := fs.String("b-style", "", "variant B: answer style profile (default: same as A)")
+	aName := fs.String("a-name", "A", "variant A label in the report")
+	bName := fs.String("b-name", "B", "variant B label in the report")
+	runs := fs.Int("runs", 1, "repeat each task N times per variant")
+	timeout := fs.Duration("timeout", 5*time.Minute, "per-run timeout")
+	keep := fs.Bool("keep", false, "keep per-run temp workspaces for inspection")
+	jsonOut := fs.String("json", "", "also write the full report as JSON to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: omega eval [flags] <fixtures-dir>")
+		fmt.Fprintln(fs.Output(), "  e.g. omega eval -b-prompts ./prompts-next evals/")
+		fmt.Fprintln(fs.Output(), "       omega eval -a-model gpt-4o -b-model gpt-4o-mini evals/")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *bPrompts == "" {
+		*bPrompts = *aPrompts
+	}
+	if *aModel == "" {
+		*aModel = os.Getenv("LLM_MODEL")
+	}
+	if *bModel == "" {
+		*bModel = *aModel
+	}
+	if *bStyle == "" {
+		*bStyle = *aStyle
+	}
+	if *aPrompts == *bPrompts && *aModel == *bModel && *aStyle == *bStyle {
+		fmt.Println("⚠️ Variants A and B are identical — this measures run-to-run noise only")
+	}
+
+	tasks, err := eval.LoadTasks(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	var variants []eval.Variant
+	for _, spec := range []struct{ name, prompts, model, style string }{
+		{*aName, *aPrompts, *aModel, *aStyle},
+		{*bName, *bPrompts, *bModel, *bStyle},
+	} {
+		v, err := newEvalVariant(spec.name, spec.prompts, spec.model, spec.style)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Variant %s: %v\n", spec.name, err)
+			return 1
+		}
+		fmt.Printf("🧪 Variant %s: model=%s prompts=%s style=%s\n", spec.name, spec.model, orDefault(spec.prompts, "(embedded)"), orDefault(spec.style, prompt.DefaultAnswerStyle))
+		variants = append(variants, v)
+	}
+
+	shellEnabled := os.Getenv("TOOL_SHELL_ENABLED") != "false"
+	runner := eval.NewRunner(eval.Options{
+		Tools:          headlessTools(shellEnabled, false),
+		Runs:           *runs,
+		Timeout:        *timeout,
+		KeepWorkspaces: *keep,
+		Progress: func(o eval.Outcome) {
+			mark := "✅"
+			if !o.Passed {
+				mark = "❌"
+			}
+			fmt.Printf("%s %s / %s (run %d): %d steps, ~%d tokens, %.1fs\n",
+				mark, o.Task, o.Variant, o.Run, o.Steps, o.Tokens, float64(o.DurationMs)/1000)
+		},
+	})
+
+	fmt.Printf("🏁 Running %d task(s) × %d run(s) × %d variant(s)\n\n", len(tasks), *runs, len(variants))
+	report, err := runner.Run(context.Background(), tasks, variants)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Eval aborted: %v\n", err)
+		return 1
+	}
+
+	fmt.Println()
+	report.WriteMarkdown(os.Stdout)
+
+	if *jsonOut != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*jsonOut, data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write JSON report: %v\n", err)
+			return 1
+		}
+		fmt.Printf("\n📄 JSON report: %s\n", *jsonOut)
+	}
+
+	if len(report.Regressions(*aName, *bName)) > 0 {
+		return 2
+	}
+	return 0
+}
+
+// newEvalVariant builds an LLM client for model and a prompt loader for
+// promptsDir. L3 rules and soul are left empty so only L2 prompts (and the
+// answer style profile) differ.
+func newEvalVariant(name, promptsDir, model, style string) (eval.Variant, error) {
+	cfg, err := openai.NewConfigFromEnv()
+	if err != nil {
+		return eval.Variant{}, err
+	}
+	if model != "" {
+		cfg.Model = model
+	}
+	client, err := openai.NewClient(cfg)
+	if err != nil {
+		return eval.Variant{}, err
+	}
+	if promptsDir != "" {
+		if info, err := os.Stat(promptsDir); err != nil || !info.IsDir() {
+			return eval.Variant{}, fmt.Errorf("prompts dir %q does not exist", promptsDir)
+		}
+	}
+
+	loader := prompt.NewPromptLoader(promptsDir, "", "")
+	osName, shellCmd := patchHostPlatform(loader)
+	if !loader.HasAnswerStyle(style) {
+		return eval.Variant{}, fmt.Errorf("unknown answer style %q (available: %s)", style, strings.Join(loader.AnswerStyles(), ", "))
+	}
+
+	return eval.Variant{
+		Name:          name,
+		AnswerStyle:   style,
+		Provider:      client,
+		Loader:        loader,
+		ModelName:     cfg.Model,
+		ThinkingMode:  client.GetConfig().ResolveThinkingMode(),
+		ToolCallMode:  client.GetConfig().ToolCallMode,
+		ContextWindow: client.GetConfig().ResolveContextWindow(),
+		OSName:        osName,
+		ShellCmd:      shellCmd,
+	}, nil
+}
+
+// headlessTools returns a builder for the file/shell tool set used by
+// non-interactive runs (eval, batch, explore), rooted at the given workspace. With
+// sandboxed, shell_exec runs in the SANDBOX_* container configured for that
+// workspace (if any); a sandbox configuration error disables the shell.
+func headlessTools(shellEnabled, sandboxed bool) func(ws string) *tool.Registry {
+	return func(ws string) *tool.Registry {
+		shell := builtin.NewShellTool(ws, shellEnabled).WithBackend(windowsShell())
+		if sandboxed && shellEnabled {
+			sb, err := sandbox.LoadFromEnv(ws)
+			if err != nil {
+				log.Printf("[Batch] %s: sandbox unavailable, shell disabled: %v", ws, err)
+				shell = builtin.NewShellTool(ws, false)
+			} else {
+				shell = shell.WithSandbox(sb)
+			}
+		}
+		pageStore := builtin.NewPageStore()
+		reg := tool.NewRegistry()
+		reg.Register(shell)
+		reg.Register(builtin.NewFileReadTool(ws))
+		reg.Register(builtin.NewFileWriteTool(ws))
+		reg.Register(builtin.NewFileListTool(ws).WithPageStore(pageStore))
+		reg.Register(builtin.NewFileGrepTool(ws).WithPageStore(pageStore))
+		reg.Register(builtin.NewFileFindTool(ws))
+		reg.Register(builtin.NewFetchMoreTool(pageStore))
+		return reg
+	}
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
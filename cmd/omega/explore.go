@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/explore"
+)
+
+// approachFlags collects repeated -approach flags.
+type approachFlags []string
+
+func (a *approachFlags) String() string     { return strings.Join(*a, "; ") }
+func (a *approachFlags) Set(v string) error { *a = append(*a, v); return nil }
+
+// runExplore implements `omega explore [flags] <workspace>`: tries two (or
+// more) approaches to one task in parallel, each in its own fork of the
+// workspace, prints both answers and diffs, and applies the one picked by
+// the user (-pick, or an interactive prompt) or by the judge pass. The
+// workspace is only written when a candidate is applied. Exit code 2 means
+// every approach failed.
+func runExplore(args []string) int {
+	fs := flag.NewFlagSet("explore", flag.ContinueOnError)
+	promptText := fs.String("prompt", "", "task to explore")
+	taskFile := fs.String("file", "", "read the task from this file instead of -prompt")
+	var approaches approachFlags
+	fs.Var(&approaches, "approach", "an approach to try (repeat for each; default: the model proposes two)")
+	judge := fs.Bool("judge", false, "have the model compare the candidates and pick one")
+	pick := fs.String("pick", "", `candidate to apply: a number, "judge" (the judge's pick) or "none"; default: ask when interactive`)
+	timeout := fs.Duration("timeout", 10*time.Minute, "per-approach timeout")
+	maxTokens := fs.Int64("max-tokens", 0, "per-approach token budget (0 = none)")
+	model := fs.String("model", "", "model name (default: LLM_MODEL)")
+	mdOut := fs.String("md", "", "also write the markdown report to this file")
+	jsonOut := fs.String("json", "", "also write the full report as JSON to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: omega explore [flags] <workspace>")
+		fmt.Fprintln(fs.Output(), "  e.g. omega explore -prompt '给 HTTP 客户端加重试' -approach '用中间件包装 Transport' -approach '在调用点逐个加重试' ./svc")
+		fmt.Fprintln(fs.Output(), "       omega explore -file task.md -judge -pick judge ./svc")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	task := *promptText
+	if *taskFile != "" {
+		data, err := os.ReadFile(*taskFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		task = string(data)
+	}
+	if strings.TrimSpace(task) == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *pick == "judge" {
+		*judge = true
+	}
+	ws, err := filepath.Abs(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	agentCfg, err := newBatchAgent(*model)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	runner := explore.NewRunner(explore.Options{
+		Agent:     agentCfg,
+		Tools:     headlessTools(os.Getenv("TOOL_SHELL_ENABLED") != "false", true),
+		Timeout:   *timeout,
+		MaxTokens: *maxTokens,
+	})
+
+	// Ctrl+C cancels the running approaches; they are still reported with
+	// their diffs.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("🔀 Exploring %s, model %s\n\n", ws, agentCfg.ModelName)
+	report, err := runner.Run(ctx, explore.Job{Task: task, Workspace: ws, Approaches: approaches, Judge: *judge}, func(c explore.Candidate) {
+		mark := "✅"
+		if !c.OK {
+			mark = "❌ " + c.Error
+		}
+		fmt.Printf("%s approach %d: %d steps, %d changed file(s), %.1fs\n",
+			mark, c.Index, c.Steps, len(c.ChangedFiles), float64(c.DurationMs)/1000)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Exploration aborted: %v\n", err)
+		return 1
+	}
+
+	// Keep the patches, so a candidate can be applied later with git apply.
+	patchDir := filepath.Join(ws, ".omega", "explore", report.Started.Format("20060102-150405"))
+	if err := report.SavePatches(patchDir); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Failed to save patches: %v\n", err)
+	}
+
+	fmt.Println()
+	report.WriteMarkdown(os.Stdout)
+
+	if *mdOut != "" {
+		f, err := os.Create(*mdOut)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write markdown report: %v\n", err)
+			return 1
+		}
+		report.WriteMarkdown(f)
+		f.Close()
+		fmt.Printf("\n📄 Markdown report: %s\n", *mdOut)
+	}
+	if *jsonOut != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*jsonOut, data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Failed to write JSON report: %v\n", err)
+			return 1
+		}
+		fmt.Printf("\n📄 JSON report: %s\n", *jsonOut)
+	}
+
+	if report.Failed() {
+		return 2
+	}
+	choice, ok := pickCandidate(report, *pick)
+	if !ok {
+		return 1
+	}
+	if choice == 0 {
+		fmt.Println("\nNo candidate applied; the workspace is unchanged.")
+		return 0
+	}
+	c := report.Candidate(choice)
+	if err := explore.Apply(context.Background(), ws, *c); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	fmt.Printf("\n✅ Applied approach %d (%d changed file(s)) to %s\n", choice, len(c.ChangedFiles), ws)
+	return 0
+}
+
+// pickCandidate resolves -pick, asking on the terminal when it is unset.
+// Returns 0 for "apply nothing"; ok is false for an invalid choice.
+func pickCandidate(report *explore.Report, pick string) (int, bool) {
+	if pick == "" {
+		if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return 0, true // not interactive: leave the workspace alone
+		}
+		def := "none"
+		if v := report.Verdict; v != nil && v.Choice > 0 {
+			def = strconv.Itoa(v.Choice)
+		}
+		fmt.Printf("\nApply which approach? [1-%d/none] (%s): ", len(report.Candidates), def)
+		line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if pick = strings.TrimSpace(line); pick == "" {
+			pick = def
+		}
+	}
+	switch pick {
+	case "none":
+		return 0, true
+	case "judge":
+		if report.Verdict == nil {
+			fmt.Fprintf(os.Stderr, "❌ The judge gave no verdict: %s\n", report.JudgeError)
+			return 0, false
+		}
+		return report.Verdict.Choice, true
+	}
+	n, err := strconv.Atoi(pick)
+	if err != nil || report.Candidate(n) == nil {
+		fmt.Fprintf(os.Stderr, "❌ Invalid choice %q\n", pick)
+		return 0, false
+	}
+	return n, true
+}
@@ -12,22 +12,62 @@ import (
 	"time"
 
 	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/audio"
+	"github.com/pocketomega/pocket-omega/internal/batch"
 	"github.com/pocketomega/pocket-omega/internal/config"
+	"github.com/pocketomega/pocket-omega/internal/editor"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
+	"github.com/pocketomega/pocket-omega/internal/journal"
+	"github.com/pocketomega/pocket-omega/internal/llm"
 	"github.com/pocketomega/pocket-omega/internal/llm/openai"
 	"github.com/pocketomega/pocket-omega/internal/mcp"
 	"github.com/pocketomega/pocket-omega/internal/plan"
 	"github.com/pocketomega/pocket-omega/internal/prompt"
 	"github.com/pocketomega/pocket-omega/internal/runtime"
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
 	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/storage"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+	"github.com/pocketomega/pocket-omega/internal/util"
 	"github.com/pocketomega/pocket-omega/internal/walkthrough"
+	"github.com/pocketomega/pocket-omega/internal/watch"
 	"github.com/pocketomega/pocket-omega/internal/web"
+	"github.com/pocketomega/pocket-omega/internal/workspace"
 )
 
 func main() {
 	// Load .env file
 	config.LoadEnv()
+	// Optional omega.yaml: fills in the settings the environment and .env
+	// leave unset. An invalid file stops startup rather than running on defaults.
+	configFile, err := config.LoadFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	// Subcommand: `omega replay <file>` re-renders a recorded agent run and exits.
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+	// Subcommand: `omega eval <fixtures>` runs a prompt/model A/B experiment and exits.
+	if len(os.Args) > 1 && os.Args[1] == "eval" {
+		os.Exit(runEval(os.Args[2:]))
+	}
+	// Subcommand: `omega run <task>` executes one agent run in the terminal and exits.
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runRun(os.Args[2:]))
+	}
+	// Subcommand: `omega batch <workspace>...` runs one task across many workspaces and exits.
+	if len(os.Args) > 1 && os.Args[1] == "batch" {
+		os.Exit(runBatch(os.Args[2:]))
+	}
+	// Subcommand: `omega explore <workspace>` tries alternative approaches in forks of the workspace and exits.
+	if len(os.Args) > 1 && os.Args[1] == "explore" {
+		os.Exit(runExplore(os.Args[2:]))
+	}
 
 	// Probe Node.js / tsx runtime availability.
 	// tsx auto-install starts in the background if node is present but tsx is absent.
@@ -45,6 +85,10 @@ func main() {
 	fmt.Println(`         ║  CoT + Tools · Go+HTMX   ║`)
 	fmt.Println(`         ╚═══════════════════════════╝`)
 
+	if configFile != nil {
+		fmt.Printf("🗂️  Config: %s (%d settings; environment overrides, GET /api/config)\n", configFile.Path, configFile.Applied())
+	}
+
 	// Initialize LLM client
 	llmClient, err := openai.NewClientFromEnv()
 	if err != nil {
@@ -55,6 +99,52 @@ func main() {
 	baseURL := os.Getenv("LLM_BASE_URL")
 	fmt.Printf("🤖 LLM: %s @ %s (timeout=%ds)\n", model, baseURL, llmClient.GetConfig().HTTPTimeout)
 
+	// Optional OpenTelemetry tracing: spans for agent nodes, LLM calls, MCP
+	// round-trips and HTTP requests, exported via OTLP/HTTP
+	tracing := false
+	if shutdownTracing, err := telemetry.Setup(context.Background(), "pocket-omega", "0.2"); err != nil {
+		log.Printf("⚠️ Tracing disabled: %v", err)
+	} else if telemetry.Enabled() {
+		tracing = true
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				log.Printf("⚠️ Tracing shutdown: %v", err)
+			}
+		}()
+		endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+		if endpoint == "" {
+			endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		}
+		fmt.Printf("📡 Tracing: OTLP → %s\n", endpoint)
+	}
+
+	// Token counting for the budget guards (LLM_TOKENIZER)
+	setupTokenizer(llmClient.GetConfig().Model, os.Stdout)
+
+	// Optional per-role models (LLM_MODEL_DECIDE, LLM_MODEL_SUMMARIZE, ...)
+	provider := newModelRouter(llmClient, llmClient.GetConfig().Model, os.Stdout)
+	// Optional secondary endpoint for when the primary stays unavailable
+	provider = newFailover(provider, os.Stdout)
+	// Swapped for rebuilt clients by a config reload (/reload, SIGHUP)
+	llmBase := llm.NewSwappable(provider, llmClient.GetConfig().Model)
+	provider = llmBase
+
+	// Optional LLM scheduler: caps concurrent provider calls and queues the rest
+	// by priority (interactive > background) with per-session round-robin.
+	// It wraps the router so that one limit covers every model.
+	var llmScheduler *llm.Scheduler
+	if v := os.Getenv("LLM_MAX_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			llmScheduler = llm.NewScheduler(provider, n)
+			provider = llmScheduler
+			fmt.Printf("🚦 LLM scheduler: max %d concurrent call(s)\n", n)
+		} else {
+			log.Printf("⚠️ Invalid LLM_MAX_CONCURRENCY=%q, scheduler disabled", v)
+		}
+	}
+
 	// Initialize tool registry with built-in tools
 	registry := tool.NewRegistry()
 	workspaceDir := os.Getenv("WORKSPACE_DIR")
@@ -67,96 +157,116 @@ func main() {
 	}
 	fmt.Printf("📂 Workspace: %s\n", workspaceDir)
 
-	shellEnabled := os.Getenv("TOOL_SHELL_ENABLED") != "false"
-	registry.Register(builtin.NewShellTool(workspaceDir, shellEnabled))
-	registry.Register(builtin.NewFileReadTool(workspaceDir))
-	registry.Register(builtin.NewFileWriteTool(workspaceDir))
-	registry.Register(builtin.NewFileListTool(workspaceDir))
-	registry.Register(builtin.NewFileFindTool(workspaceDir))
-	registry.Register(builtin.NewTimeTool())
-	registry.Register(builtin.NewWebReaderTool())
-
-	// P1 — core file operations (unconditional)
-	registry.Register(builtin.NewFileGrepTool(workspaceDir))
-	registry.Register(builtin.NewFileMoveTool(workspaceDir))
-	registry.Register(builtin.NewFileOpenTool(workspaceDir))
-
-	// P2 — extended file operations (unconditional)
-	registry.Register(builtin.NewFileDeleteTool(workspaceDir))
-	registry.Register(builtin.NewFilePatchTool(workspaceDir))
-	registry.Register(builtin.NewGitInfoTool(workspaceDir))
-
-	// Config edit tool — allows agent to modify config files outside workspace sandbox.
-	// Uses an allowlist so only explicitly named files are accessible.
-	if envPath := config.EnvFilePath(); envPath != "" && !strings.HasPrefix(envPath, "(") {
-		configAllowed := map[string]string{".env": envPath}
-		registry.Register(builtin.NewConfigEditTool(configAllowed))
-		fmt.Printf("⚙️  Config edit tool: %s\n", envPath)
-	}
-
-	// P2 — HTTP request tool (enabled by default, disable via TOOL_HTTP_ENABLED=false)
-	if os.Getenv("TOOL_HTTP_ENABLED") != "false" {
-		allowInternal := os.Getenv("TOOL_HTTP_ALLOW_INTERNAL") == "true"
-		registry.Register(builtin.NewHTTPRequestTool(allowInternal))
-		if allowInternal {
-			fmt.Println("🌐 HTTP request tool enabled (internal addresses allowed)")
-		} else {
-			fmt.Println("🌐 HTTP request tool enabled")
-		}
+	// Read-only mirror mode: mutating tools dry-run, state-changing commands are
+	// refused and the UI shows a banner — for demos and audits of past runs
+	readOnly := os.Getenv("OMEGA_READ_ONLY") == "true"
+	if readOnly {
+		registry.SetReadOnly(true)
+		fmt.Println("🔒 Read-only mode: mutating tools are dry-run")
+	}
+
+	// Upgrade .omega metadata to the current schema (checked only in read-only mode)
+	if err := prepareWorkspace(workspaceDir, readOnly, os.Stdout); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	// Optional response cache for chat and temperature-0 decisions; outside the
+	// scheduler so that hits do not wait for a slot
+	llmCache := newResponseCache(workspaceDir, os.Stdout)
+	provider = llm.NewCachingProvider(provider, llmCache, llmClient.GetConfig().Model, llmClient.GetConfig().Sampling())
+	if tracing {
+		provider = telemetry.WrapLLM(provider, model)
+	}
+
+	// Optional container sandbox for shell_exec and opted-in stdio skills
+	shellSandbox, err := sandbox.LoadFromEnv(workspaceDir)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if shellSandbox != nil {
+		fmt.Printf("📦 Sandbox: %s\n", shellSandbox)
 	}
 
-	// Conditional search tools — auto-enable when API key is configured
-	if key := os.Getenv("TAVILY_API_KEY"); key != "" {
-		registry.Register(builtin.NewTavilySearchTool(key))
-		fmt.Println("🔍 Tavily web search enabled")
+	// Optional editor integration: file_open editor=true and "open in editor" links
+	codeEditor, err := editor.LoadFromEnv()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
 	}
-	if key := os.Getenv("BRAVE_API_KEY"); key != "" {
-		registry.Register(builtin.NewBraveSearchTool(key))
-		fmt.Println("🔍 Brave search enabled")
+	if codeEditor != nil && readOnly && codeEditor.Kind == editor.KindCommand {
+		log.Printf("⚠️ EDITOR_INTEGRATION=command is disabled in read-only mode")
+		codeEditor = nil
+	}
+	if codeEditor != nil {
+		fmt.Printf("📝 Editor: %s\n", codeEditor)
 	}
 
-	if err := registry.InitAll(context.Background()); err != nil {
-		log.Fatalf("❌ Failed to initialize tools: %v", err)
+	shellEnabled := os.Getenv("TOOL_SHELL_ENABLED") != "false"
+
+	// Tool output post-processing: outputs above AGENT_OUTPUT_SUMMARY_THRESHOLD runes
+	// are archived under .omega/tool_outputs and replaced by a summary + ref ID
+	outputSummaryThreshold := loadOutputSummaryThreshold()
+	toolOutputsDir := filepath.Join(workspace.Dir(workspaceDir), "tool_outputs")
+	toolOpts := builtinToolOptions{
+		workspaceDir: workspaceDir,
+		sandbox:      shellSandbox,
+		editor:       codeEditor,
+		vision:       llmClient.GetConfig().ResolveVision(),
+		out:          os.Stdout,
+	}
+	if outputSummaryThreshold > 0 {
+		toolOpts.outputsDir = toolOutputsDir
+	}
+	codeIndex, err := registerBuiltinTools(registry, toolOpts)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
 	}
+
+	reportToolHealth(registry.InitAll(context.Background()), os.Stdout)
 	defer registry.CloseAll()
 
+	// The code_search index is kept current in the background from fsnotify
+	// events, so searches no longer rescan the workspace; CODE_SEARCH_WATCH=false
+	// leaves the refresh to each search
+	if codeIndex != nil && os.Getenv("CODE_SEARCH_WATCH") != "false" {
+		indexCtx, stopIndex := context.WithCancel(context.Background())
+		defer stopIndex()
+		if err := codeIndex.Watch(indexCtx); err != nil {
+			log.Printf("⚠️ %v; the index is refreshed by each search", err)
+		} else {
+			fmt.Println("🧭 Code index: watching the workspace, progress in /api/health")
+		}
+	}
+
 	fmt.Printf("🛠️  Tools: %d registered\n", len(registry.List()))
 
 	// Initialize the three-layer prompt loader (L2 embed defaults + L3 user rules).
 	// Created before MCP so that mcpMgr.SetPromptLoader can wire Reload integration.
-	promptsDir := os.Getenv("PROMPTS_DIR")
-	if promptsDir == "" {
-		promptsDir = filepath.Join(workspaceDir, "prompts")
-	}
-	rulesPath := os.Getenv("USER_RULES_PATH")
-	if rulesPath == "" {
-		rulesPath = filepath.Join(workspaceDir, "rules.md")
-	}
-	soulPath := os.Getenv("SOUL_PATH")
-	if soulPath == "" {
-		soulPath = filepath.Join(workspaceDir, "soul.md")
-	}
-	promptLoader := prompt.NewPromptLoader(promptsDir, rulesPath, soulPath)
-	fmt.Printf("📋 Prompt loader: L2=%s L3=%s Soul=%s\n", promptsDir, rulesPath, soulPath)
-
-	// Inject runtime OS/Shell into prompt templates so agents know the
-	// platform-correct shell commands and environment constraints.
-	osName := stdruntime.GOOS // "windows" / "linux" / "darwin"
-	shellCmd := "sh -c"
-	if osName == "windows" {
-		osName = "Windows"
-		shellCmd = "cmd.exe /c"
-	} else if osName == "darwin" {
-		osName = "macOS"
-	} else {
-		osName = "Linux"
+	promptLoader, osName, shellCmd := newWorkspacePromptLoader(workspaceDir, os.Stdout)
+
+	// After an upgrade, flag prompt overrides whose built-in default changed
+	// so the user can merge the fixes (GET /api/prompts/drift)
+	if !readOnly {
+		if drifted, err := promptLoader.SyncDefaults(); err != nil {
+			log.Printf("⚠️  Prompt default check failed: %v", err)
+		} else if len(drifted) > 0 {
+			fmt.Printf("⚠️  Built-in defaults changed for overridden prompts: %s — review at /api/prompts/drift\n", strings.Join(drifted, ", "))
+		}
+	}
+
+	// Optional hot-reload: edits to prompts/rules/soul apply without /reload
+	if os.Getenv("PROMPTS_WATCH") == "true" {
+		if stopWatch, err := promptLoader.Watch(); err != nil {
+			log.Printf("⚠️  Prompt hot-reload disabled: %v", err)
+		} else {
+			defer stopWatch()
+			fmt.Println("🔄 Prompt hot-reload: enabled")
+		}
 	}
-	promptLoader.PatchFile("knowledge.md", "{{OS}}", osName)
-	promptLoader.PatchFile("knowledge.md", "{{SHELL_CMD}}", shellCmd)
 
 	// Initialize MCP client manager (optional — only when mcp.json exists)
-	var mcpReloadFn func() // captured from MCP block for /reload command
-	var mcpServerCount int // captured from MCP block for /api/health
+	var mcpReloadFn func()               // captured from MCP block for /reload command
+	var mcpServerCount int               // captured from MCP block for /api/health
+	var mcpPrompts *web.MCPPromptHandler // captured from MCP block for the prompt picker
 	mcpConfigPath := os.Getenv("MCP_CONFIG")
 	if mcpConfigPath == "" {
 		mcpConfigPath = filepath.Join(workspaceDir, "mcp.json")
@@ -175,16 +285,31 @@ func main() {
 		// Wire prompt cache invalidation into mcp_reload so hot-reloading
 		// prompts and MCP config both happen with a single tool call.
 		mcpMgr.SetPromptLoader(promptLoader)
+		mcpMgr.SetSandbox(shellSandbox)
+		// Path-like MCP tool params are confined to the workspace unless a
+		// server sets "path_policy": "off" or lists extra "allowed_paths".
+		mcpMgr.SetWorkspace(workspaceDir)
 		// Always register the reload tool so the agent can fix connection issues
 		// even if the initial ConnectAll fails partially or completely.
 		registry.Register(mcp.NewReloadTool(mcpMgr, registry))
+		// Skills are snapshotted in skills/<name>/.versions when they start;
+		// a broken edit is undone with skill_rollback.
+		registry.Register(mcp.NewRollbackTool(mcpMgr, registry))
+		// Server resources are read on demand; prompts are offered in the web UI.
+		registry.Register(mcp.NewResourceReadTool(mcpMgr))
+		mcpPrompts = web.NewMCPPromptHandler(mcpMgr)
 
 		// Phase B: MCP server management tools — always available so the agent
 		// can add/remove/list servers and then call mcp_reload in one session.
 		registry.Register(builtin.NewMCPServerAddTool(mcpConfigPath))
 		registry.Register(builtin.NewMCPServerRemoveTool(mcpConfigPath))
 		registry.Register(builtin.NewMCPServerListTool(mcpConfigPath))
-		fmt.Println("🔧 MCP management tools registered (mcp_server_add/remove/list)")
+		// Shared skills: cloned or downloaded into skills/, scanned, registered
+		// and loaded in one call.
+		registry.Register(builtin.NewSkillInstallTool(filepath.Join(workspaceDir, "skills"), mcpConfigPath).
+			WithAllowInternal(os.Getenv("TOOL_HTTP_ALLOW_INTERNAL") == "true").
+			WithReload(func(ctx context.Context) (string, error) { return mcpMgr.Reload(ctx, registry) }))
+		fmt.Println("🔧 MCP management tools registered (mcp_server_add/remove/list, skill_install)")
 
 		n, mcpErrs := mcpMgr.ConnectAll(context.Background())
 		for _, e := range mcpErrs {
@@ -224,6 +349,35 @@ func main() {
 		fmt.Printf("📝 Exec log: logs/agent_exec.md\n")
 	}
 
+	// Structured per-run JSONL records for `omega replay` (disable via AGENT_REPLAY_LOG=false)
+	var replayRecorder *agent.ReplayRecorder
+	var replayDir string
+	if os.Getenv("AGENT_REPLAY_LOG") != "false" {
+		if rec, err := agent.NewReplayRecorder(filepath.Join(logDir, "replay")); err != nil {
+			log.Printf("⚠️ Replay recorder disabled: %v", err)
+		} else {
+			replayRecorder = rec
+			replayDir = filepath.Join(logDir, "replay")
+			fmt.Printf("🎞️  Replay log: logs/replay/ (omega replay <file>)\n")
+		}
+	}
+
+	// Run outcome records for the /stats page (disable via AGENT_OUTCOME_LOG=false);
+	// AGENT_OUTCOME_JUDGE=true adds an LLM check of runs the rules call successful
+	var outcomeLog *agent.OutcomeLog
+	var outcomeJudge *agent.OutcomeJudge
+	if os.Getenv("AGENT_OUTCOME_LOG") != "false" {
+		if l, err := agent.NewOutcomeLog(filepath.Join(logDir, "outcomes.jsonl")); err != nil {
+			log.Printf("⚠️ Outcome log disabled: %v", err)
+		} else {
+			outcomeLog = l
+			if os.Getenv("AGENT_OUTCOME_JUDGE") == "true" {
+				outcomeJudge = agent.NewOutcomeJudge(provider)
+			}
+			fmt.Printf("📊 Run outcomes: logs/outcomes.jsonl (judge: %v, page: /stats)\n", outcomeJudge != nil)
+		}
+	}
+
 	// Initialize session store for multi-turn conversation
 	sessionTTL := 30 * time.Minute
 	sessionMaxTurns := 10
@@ -243,78 +397,444 @@ func main() {
 	}
 	sessionStore := session.NewStore(sessionTTL, sessionMaxTurns)
 	defer sessionStore.Close()
+	// New sessions are titled after their first message by the summarize
+	// model (SESSION_TITLES=off keeps the truncated message, no LLM call)
+	titleProvider := provider
+	if os.Getenv("SESSION_TITLES") == "off" {
+		titleProvider = nil
+	}
+	sessionStore.OnNewSession(web.NewSessionTitler(sessionStore, titleProvider).Title)
 	fmt.Printf("💬 Session: TTL=%v MaxTurns=%d\n", sessionTTL, sessionMaxTurns)
 
 	// Initialize plan store for structured task tracking
 	planStore := plan.NewPlanStore()
 
+	// Running sessions persist their plan, steps and cost counters under
+	// .omega/checkpoints, so an interrupted run can be continued with /resume
+	// (disable via AGENT_CHECKPOINTS=false). Runs still marked running were
+	// cut off by a crash of the previous process.
+	var checkpoints *agent.CheckpointStore
+	if os.Getenv("AGENT_CHECKPOINTS") != "false" && !readOnly {
+		if cs, err := agent.NewCheckpointStore(filepath.Join(workspace.Dir(workspaceDir), "checkpoints")); err != nil {
+			log.Printf("⚠️ Checkpoints disabled: %v", err)
+		} else {
+			checkpoints = cs
+			fmt.Printf("💾 Checkpoints: .omega/checkpoints/ (/resume)\n")
+			crashed, err := cs.Recover()
+			if err != nil {
+				log.Printf("⚠️ Checkpoint recovery: %v", err)
+			}
+			for _, cp := range crashed {
+				log.Printf("[Checkpoint] Interrupted run of session %s (%d steps, %s): %s",
+					cp.SessionID, len(cp.Steps), cp.UpdatedAt.Format("01-02 15:04"), util.TruncateRunes(cp.Problem, 60))
+			}
+			if len(crashed) > 0 {
+				fmt.Printf("♻️ Interrupted runs: %d, continue with /resume in their session\n", len(crashed))
+			}
+		}
+	}
+
+	// Daily notes: one line per agent run plus journal_append entries in
+	// notes/YYYY-MM-DD.md, summarized daily at JOURNAL_SUMMARY_AT (enable via JOURNAL_ENABLED=true)
+	var dailyNotes *journal.Journal
+	if os.Getenv("JOURNAL_ENABLED") == "true" && !readOnly {
+		dir := os.Getenv("JOURNAL_DIR")
+		if dir == "" {
+			dir = "notes"
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workspaceDir, dir)
+		}
+		dailyNotes = journal.New(dir)
+		registry.Register(builtin.NewJournalAppendTool(dailyNotes))
+		summaryAt := os.Getenv("JOURNAL_SUMMARY_AT")
+		if summaryAt == "" {
+			summaryAt = "23:30"
+		}
+		if summaryAt != "off" {
+			if at, err := journal.ParseClock(summaryAt); err != nil {
+				log.Printf("⚠️ %v, daily summaries disabled", err)
+				summaryAt = "off"
+			} else {
+				summaryCtx, stopSummaries := context.WithCancel(context.Background())
+				defer stopSummaries()
+				go journal.NewSummarizer(dailyNotes, provider, at).Run(summaryCtx)
+			}
+		}
+		fmt.Printf("📓 Journal: %s (daily summary: %s, /journal)\n", dir, summaryAt)
+	}
+
+	// Generated files (replays, archived outputs, backups, …) are kept within
+	// STORAGE_QUOTAS, oldest first; runs warn below STORAGE_MIN_FREE_MB
+	storageManager, gcInterval, err := loadStorage(workspaceDir)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if !readOnly {
+		gcCtx, stopGC := context.WithCancel(context.Background())
+		defer stopGC()
+		go storageManager.Run(gcCtx, gcInterval)
+	}
+	fmt.Printf("💽 Storage: quotas %s, min free %s (/api/storage)\n", orDefault(os.Getenv("STORAGE_QUOTAS"), defaultStorageQuotas), storage.FormatSize(storageManager.MinFree()))
+
+	// Tool profiles: runs can be limited to a subset of the tools
+	profilesPath := orDefault(os.Getenv("TOOL_PROFILES_PATH"), filepath.Join(workspaceDir, "profiles.yaml"))
+	toolProfiles, err := tool.LoadProfiles(profilesPath)
+	if err != nil {
+		log.Fatalf("❌ TOOL_PROFILES_PATH: %v", err)
+	}
+	fmt.Printf("🧰 Tool profiles: %s\n", strings.Join(toolProfiles.Names(), ", "))
+
+	// Tool permissions: the role of the requesting user limits each run's tools
+	toolPermissions, userOf, err := loadPermissions(os.Stdout)
+	if err != nil {
+		log.Fatalf("❌ TOOL_PERMISSIONS_PATH: %v", err)
+	}
+
+	// Audit log: every tool call of every run, for /api/audit
+	auditLog := openAuditLog(workspaceDir, os.Stdout)
+	defer auditLog.Close()
+
 	// Initialize walkthrough store for agent memo tracking
 	walkthroughStore := walkthrough.NewStore()
 
+	// Memos of finished runs are archived under .omega/walkthroughs for
+	// /walkthrough and /api/walkthroughs (disable via WALKTHROUGH_ARCHIVE=false)
+	var walkthroughArchive, walkthroughSaves *walkthrough.Archive
+	if os.Getenv("WALKTHROUGH_ARCHIVE") != "false" {
+		walkthroughArchive = walkthrough.NewArchive(filepath.Join(workspace.Dir(workspaceDir), "walkthroughs"))
+		if !readOnly {
+			walkthroughSaves = walkthroughArchive
+		}
+		fmt.Printf("🧭 Walkthroughs: .omega/walkthroughs/ (/walkthrough)\n")
+	}
+
 	// Create handlers
 	thinkingMode := llmClient.GetConfig().ResolveThinkingMode()
-	toolCallMode := llmClient.GetConfig().ToolCallMode // raw value: "auto", "fc", or "yaml"
+	toolCallMode := llmClient.GetConfig().ToolCallMode // raw value: "auto", "fc", "yaml", or "json"
 	contextWindow := llmClient.GetConfig().ResolveContextWindow()
-	chatHandler := web.NewChatHandler(llmClient, 3, contextWindow, sessionStore, promptLoader)
+	chatHandler := web.NewChatHandler(provider, 3, contextWindow, sessionStore, promptLoader)
 	// CostGuard configuration
-	var maxAgentTokens int64
-	if v := os.Getenv("AGENT_MAX_TOKENS"); v != "" {
-		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
-			maxAgentTokens = n
+	maxAgentTokens, maxAgentDuration := loadCostLimits()
+
+	// Self-review: answers are checked by the model and sent back on failure
+	selfReviewRetries := loadSelfReviewRetries()
+	askTimeout := loadAskTimeout()
+	// Plan preview: new plans wait for the user's confirmation (web runs only)
+	planConfirm := os.Getenv("AGENT_PLAN_CONFIRM") == "true"
+	if planConfirm {
+		fmt.Println("📝 Plan confirmation: new plans wait for review at /api/plan/{sid}")
+	}
+
+	// Auto-compaction threshold as a fraction of the context window
+	compactRatio := agent.DefaultCompactRatio
+	if v := os.Getenv("AGENT_COMPACT_RATIO"); v != "" {
+		if r, err := strconv.ParseFloat(v, 64); err == nil && r > 0 && r < 1 {
+			compactRatio = r
+		} else {
+			log.Printf("⚠️ Invalid AGENT_COMPACT_RATIO=%q, using %.2f", v, compactRatio)
 		}
 	}
-	var maxAgentDuration time.Duration
-	if v := os.Getenv("AGENT_MAX_DURATION_MINUTES"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			maxAgentDuration = time.Duration(n) * time.Minute
+
+	// Model downshift: when a run nears AGENT_MAX_TOKENS, remaining decide steps
+	// switch to LLM_DOWNSHIFT_MODEL (same endpoint/key) instead of hitting the limit.
+	var downshiftProvider llm.LLMProvider
+	downshiftModel := os.Getenv("LLM_DOWNSHIFT_MODEL")
+	downshiftRatio := 0.8
+	if v := os.Getenv("AGENT_DOWNSHIFT_RATIO"); v != "" {
+		if r, err := strconv.ParseFloat(v, 64); err == nil && r > 0 && r < 1 {
+			downshiftRatio = r
+		} else {
+			log.Printf("⚠️ Invalid AGENT_DOWNSHIFT_RATIO=%q, using %.2f", v, downshiftRatio)
+		}
+	}
+	if downshiftModel != "" && maxAgentTokens > 0 {
+		// Fresh config: resolved FC/thinking modes are cached per model.
+		cfg, err := openai.NewConfigFromEnv()
+		if err == nil {
+			cfg.Model = downshiftModel
+		}
+		var c *openai.Client
+		if err == nil {
+			c, err = openai.NewClient(cfg)
+		}
+		if err != nil {
+			log.Printf("⚠️ Downshift model disabled: %v", err)
+		} else {
+			downshiftProvider = c
+			if tracing {
+				downshiftProvider = telemetry.WrapLLM(c, downshiftModel)
+			}
+			fmt.Printf("⬇️  Downshift: %s at %.0f%% of %d tokens\n", downshiftModel, downshiftRatio*100, maxAgentTokens)
+		}
+	} else if downshiftModel != "" {
+		log.Printf("⚠️ LLM_DOWNSHIFT_MODEL is set but AGENT_MAX_TOKENS is not; downshift disabled")
+	}
+
+	// Working-language translation: disabled when AGENT_WORKING_LANGUAGE is empty
+	translator := i18n.NewTranslator(provider, os.Getenv("AGENT_WORKING_LANGUAGE"))
+	uiLocale := i18n.NormalizeLocale(os.Getenv("UI_LOCALE"))
+	if translator != nil {
+		fmt.Printf("🌐 WorkingLanguage: %s (UI: %s)\n", translator.Target(), uiLocale)
+	}
+
+	// Summarizers: extractive by default; AGENT_OUTPUT_SUMMARIZER=llm uses the
+	// model for prose-heavy tools (web pages, HTTP bodies)
+	outputProcessor := newOutputProcessor(toolOutputsDir, outputSummaryThreshold, provider, os.Stdout)
+
+	// Agent run backpressure: runs beyond AGENT_MAX_CONCURRENT_RUNS queue (position
+	// reported over SSE); beyond AGENT_MAX_QUEUED_RUNS they get 503. 0 = unlimited.
+	maxConcurrentRuns := 4
+	if v := os.Getenv("AGENT_MAX_CONCURRENT_RUNS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxConcurrentRuns = n
+		} else {
+			log.Printf("⚠️ Invalid AGENT_MAX_CONCURRENT_RUNS=%q, using %d", v, maxConcurrentRuns)
+		}
+	}
+	maxQueuedRuns := 16
+	if v := os.Getenv("AGENT_MAX_QUEUED_RUNS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxQueuedRuns = n
+		} else {
+			log.Printf("⚠️ Invalid AGENT_MAX_QUEUED_RUNS=%q, using %d", v, maxQueuedRuns)
+		}
+	}
+
+	// File watches: watch_add/list/remove tools notify the originating session
+	// (page inbox + session history) and optionally a webhook / the desktop
+	var watchManager *watch.Manager
+	var notifications *web.NotificationHandler
+	if os.Getenv("TOOL_WATCH_ENABLED") != "false" {
+		maxWatches := 20
+		if v := os.Getenv("WATCH_MAX"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				maxWatches = n
+			} else {
+				log.Printf("⚠️ Invalid WATCH_MAX=%q, using default 20", v)
+			}
+		}
+		webhookURL := os.Getenv("WATCH_WEBHOOK_URL")
+		desktop := os.Getenv("WATCH_DESKTOP_NOTIFY") == "true"
+		inbox := web.NewNotificationHandler()
+		mgr, err := watch.NewManager(watch.Options{
+			MaxWatches: maxWatches,
+			Cooldown:   time.Minute,
+			OnTrigger: func(ev watch.Event) {
+				msg := fmt.Sprintf(i18n.T(uiLocale, "watch.triggered"),
+					ev.Watch.ID, ev.Watch.Display, ev.Watch.Pattern, ev.Count, util.TruncateRunes(ev.Line, 300))
+				log.Printf("[Watch] %s triggered (session=%s): %s", ev.Watch.ID, ev.Watch.SessionID, ev.Line)
+				inbox.Push(ev.Watch.SessionID, web.Notification{Kind: "watch", Message: msg, Time: ev.Time})
+				// Also record it in the session so the next agent turn knows about it
+				sessionStore.AppendTurn(ev.Watch.SessionID, session.Turn{UserMsg: "[文件监控通知]", Assistant: msg, IsAgent: true})
+				if webhookURL != "" {
+					go func() {
+						if err := watch.PostWebhook(context.Background(), webhookURL, ev, msg); err != nil {
+							log.Printf("[Watch] %v", err)
+						}
+					}()
+				}
+				if desktop {
+					go func() {
+						if err := watch.DesktopNotify("Pocket-Omega", msg); err != nil {
+							log.Printf("[Watch] %v", err)
+						}
+					}()
+				}
+			},
+		})
+		if err != nil {
+			log.Printf("⚠️ File watches disabled: %v", err)
+		} else {
+			defer mgr.Close()
+			watchManager, notifications = mgr, inbox
+			fmt.Printf("👀 File watches: max %d (webhook=%t desktop=%t)\n", maxWatches, webhookURL != "", desktop)
 		}
 	}
 
 	agentHandler := web.NewAgentHandler(web.AgentHandlerOptions{
-		Provider:            llmClient,
+		Provider:            provider,
 		Registry:            registry,
 		WorkspaceDir:        workspaceDir,
 		ExecLogger:          execLogger,
+		ReplayRecorder:      replayRecorder,
 		ThinkingMode:        thinkingMode,
 		ToolCallMode:        toolCallMode,
 		ContextWindowTokens: contextWindow,
+		CompactRatio:        compactRatio,
 		Store:               sessionStore,
 		Loader:              promptLoader,
 		OSName:              osName,
 		ShellCmd:            shellCmd,
 		ModelName:           llmClient.GetConfig().Model,
 		PlanStore:           planStore,
+		PlanConfirm:         planConfirm,
 		MaxAgentTokens:      maxAgentTokens,
 		MaxAgentDuration:    maxAgentDuration,
+		DownshiftProvider:   downshiftProvider,
+		DownshiftModel:      downshiftModel,
+		DownshiftRatio:      downshiftRatio,
 		WalkthroughStore:    walkthroughStore,
+		Translator:          translator,
+		OutputProcessor:     outputProcessor,
+		MaxConcurrentRuns:   maxConcurrentRuns,
+		MaxQueuedRuns:       maxQueuedRuns,
+		WatchManager:        watchManager,
+		OutcomeLog:          outcomeLog,
+		OutcomeJudge:        outcomeJudge,
+		BriefKeeper:         agent.NewBriefKeeper(provider, promptLoader),
+		Editor:              codeEditor,
+		SelfReviewRetries:   selfReviewRetries,
+		ChangeSummary:       loadChangeSummary(),
+		Verify:              loadVerify(os.Stdout),
+		Checkpoints:         checkpoints,
+		Journal:             dailyNotes,
+		Storage:             storageManager,
+		Profiles:            toolProfiles,
+		WalkthroughArchive:  walkthroughSaves,
+		AskTimeout:          askTimeout,
+		Permissions:         toolPermissions,
+		Audit:               auditLog,
+		UserOf:              userOf,
+		UILocale:            uiLocale,
 	})
 	fmt.Printf("🧠 Thinking: %s\n", thinkingMode)
 	fmt.Printf("🔧 ToolCall: %s (resolved: %s)\n", toolCallMode, llmClient.GetConfig().ResolveToolCallMode())
 	fmt.Printf("📐 ContextWindow: %d tokens\n", contextWindow)
+	fmt.Printf("🖼️ Vision: %v\n", llmClient.GetConfig().ResolveVision())
+	if selfReviewRetries > 0 {
+		fmt.Printf("🔍 Self-review: max %d retries\n", selfReviewRetries)
+	}
+	if askTimeout > 0 {
+		fmt.Printf("❓ Clarifying questions: reply within %v\n", askTimeout)
+	}
+	if maxConcurrentRuns > 0 {
+		fmt.Printf("🚥 Agent runs: max %d concurrent, %d queued\n", maxConcurrentRuns, maxQueuedRuns)
+	}
+
+	// Config reload: .env and omega.yaml edits reach new runs without a restart
+	reloader := &configReloader{
+		loaded:   configFile,
+		llm:      llmBase,
+		model:    llmClient.GetConfig().Model,
+		registry: registry,
+	}
 
 	// Create slash command handler (/compact needs LLM for summary generation)
 	commandHandler := web.NewCommandHandler(web.CommandHandlerOptions{
 		Loader:       promptLoader,
 		MCPReload:    mcpReloadFn, // nil-safe: cmdReload checks for nil
 		Store:        sessionStore,
-		LLMProvider:  llmClient,
+		LLMProvider:  provider,
 		ToolRegistry: registry,
 		ModelName:    model,
 		ThinkingMode: thinkingMode,
 		ToolCallMode: toolCallMode,
+		ReplayDir:    replayDir,
+		ReadOnly:     readOnly,
+		Checkpoints:  checkpoints,
+		Journal:      dailyNotes,
+		Walkthroughs: walkthroughArchive,
+		WorkspaceDir: workspaceDir,
+		Fork:         agentHandler.ForkSession,
+		Config:       configFile,
+		ConfigReload: reloader.reload,
+
+		MaxAgentTokens:    maxAgentTokens,
+		MaxAgentDuration:  maxAgentDuration,
+		MaxConcurrentRuns: maxConcurrentRuns,
+		Permissions:       toolPermissions,
+		UserOf:            userOf,
 	})
 
+	// Optional batch API: one task across many workspaces under BATCH_ROOTS
+	var batchHandler *web.BatchHandler
+	if roots := os.Getenv("BATCH_ROOTS"); roots != "" {
+		maxParallel := 4
+		if v := os.Getenv("BATCH_MAX_PARALLEL"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				maxParallel = n
+			}
+		}
+		batchHandler = web.NewBatchHandler(web.BatchHandlerOptions{
+			Runner: batch.NewRunner(batch.Options{
+				Agent: batch.Agent{
+					Provider:      provider,
+					Loader:        promptLoader,
+					ModelName:     model,
+					ThinkingMode:  thinkingMode,
+					ToolCallMode:  toolCallMode,
+					ContextWindow: contextWindow,
+					OSName:        osName,
+					ShellCmd:      shellCmd,
+					SelfReview:    selfReviewRetries,
+				},
+				Tools: headlessTools(shellEnabled, true),
+			}),
+			Roots:       strings.Split(roots, ","),
+			MaxParallel: maxParallel,
+			ReadOnly:    readOnly,
+			Permissions: toolPermissions,
+			UserOf:      userOf,
+		})
+		fmt.Printf("🗃️  Batch API: roots=%s, max parallel %d\n", roots, maxParallel)
+	}
+
+	// Prompt editing API: list/read/write prompts, rules and soul, preview the system prompt
+	promptsHandler := web.NewPromptsHandler(web.PromptsHandlerOptions{
+		Loader:      promptLoader,
+		ReadOnly:    readOnly,
+		Preview:     agentHandler,
+		Permissions: toolPermissions,
+		UserOf:      userOf,
+	})
+
+	// Voice input / spoken answers (AUDIO_ENABLED=true)
+	var audioHandler *web.AudioHandler
+	audioBackends, err := audio.LoadFromEnv()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	if audioBackends != nil {
+		audioHandler = web.NewAudioHandler(audioBackends.STT, audioBackends.TTS)
+		fmt.Printf("🎙️ Audio: %s\n", audioBackends)
+	}
+
 	// Create and start web server
-	server, err := web.NewServer(chatHandler, agentHandler, commandHandler, web.HealthInfo{
+	server, err := web.NewServer(chatHandler, agentHandler, commandHandler, notifications, batchHandler, mcpPrompts, promptsHandler, audioHandler, web.HealthInfo{
 		LLMModel:       model,
 		ToolCount:      len(registry.List()),
+		ToolHealth:     registry.Health,
 		MCPServerCount: mcpServerCount,
 		SessionCount:   sessionStore.Count,
+		LLMScheduler:   llmScheduler,
+		LLMCache:       llmCache,
+		AgentRuns:      agentHandler.RunStats,
+		CodeIndex:      codeIndex,
+		ReadOnly:       readOnly,
 	})
 	if err != nil {
 		log.Fatalf("❌ Failed to create web server: %v", err)
 	}
 
+	// Optional gRPC management API, served next to the web UI
+	if addr := os.Getenv("GRPC_ADDR"); addr != "" {
+		server.EnableGRPC(addr)
+	}
+	server.EnableStorage(web.NewStorageHandler(storageManager, readOnly))
+	server.EnableFiles(web.NewFilesHandler(workspaceDir, readOnly).WithPermissions(toolPermissions, userOf))
+	server.EnableSessions(web.NewSessionsHandler(sessionStore, readOnly))
+	configHandler := web.NewConfigHandler(configFile)
+	server.EnableConfig(configHandler)
+	reloader.apply = []func(web.ReloadResult){agentHandler.ApplyReload, commandHandler.ApplyReload, configHandler.ApplyReload}
+	reloader.handleSignals()
+	if auditLog != nil {
+		server.EnableAudit(web.NewAuditHandler(auditLog))
+	}
+	if walkthroughArchive != nil {
+		server.EnableWalkthroughs(web.NewWalkthroughHandler(walkthroughArchive))
+	}
+
 	if err := server.Start(); err != nil {
 		log.Fatalf("❌ Server error: %v", err)
 	}
@@ -339,3 +859,53 @@ func injectRuntimeEnv(pl *prompt.PromptLoader, status string) {
 	// if the method doesn't exist yet the compiler will flag it and we can add it.
 	pl.PatchFile("mcp_server_guide.md", "{{RUNTIME_ENV}}", status)
 }
+
+// hostPlatform returns the display OS name and shell prefix injected into
+// prompt templates so agents use platform-correct shell commands.
+func hostPlatform() (osName, shellCmd string) {
+	switch stdruntime.GOOS {
+	case "windows":
+		return "Windows", windowsShell().Invocation()
+	case "darwin":
+		return "macOS", "sh -c"
+	default:
+		return "Linux", "sh -c"
+	}
+}
+
+// windowsShell returns the shell_exec backend for Windows hosts selected by
+// TOOL_SHELL_WINDOWS (cmd, powershell or pwsh; default cmd).
+func windowsShell() builtin.ShellBackend {
+	b, err := builtin.ParseShellBackend(os.Getenv("TOOL_SHELL_WINDOWS"))
+	if err != nil {
+		log.Printf("⚠️ TOOL_SHELL_WINDOWS: %v, using cmd", err)
+		return builtin.ShellCmd
+	}
+	return b
+}
+
+// shellNotes returns the syntax hints for the {{SHELL_NOTES}} placeholder
+// in knowledge.md, matching the shell commands run with.
+func shellNotes() string {
+	if stdruntime.GOOS != "windows" {
+		return "使用 POSIX sh 语法：`&&`/`||` 串联命令，环境变量写作 `$VAR`。"
+	}
+	const psCommon = "环境变量写作 `$env:NAME`；含 `$` 的字符串用单引号；路径分隔符用 `\\`；常用命令：`Get-ChildItem`（`ls`）、`Get-Content`（`cat`）、`Copy-Item`、`Move-Item`、`Remove-Item`、`Select-String`（代替 grep）。"
+	switch windowsShell() {
+	case builtin.ShellPowerShell:
+		return "Windows PowerShell 5.1 语法：不支持 `&&`/`||`，用 `;` 或 `if ($?) { … }` 串联命令；" + psCommon
+	case builtin.ShellPwsh:
+		return "PowerShell 7 语法：支持 `&&`/`||` 串联命令；" + psCommon
+	default:
+		return "cmd.exe 语法：用 `&&` 串联命令，环境变量写作 `%NAME%`；路径分隔符用 `\\`；常用命令对照：`dir`（非 `ls`）、`type`（非 `cat`）、`copy`（非 `cp`）、`move`（非 `mv`）、`del`（非 `rm`）。需要 PowerShell 时用 `powershell -NoProfile -Command \"…\"`。"
+	}
+}
+
+// patchHostPlatform fills the OS and shell placeholders of knowledge.md.
+func patchHostPlatform(loader *prompt.PromptLoader) (osName, shellCmd string) {
+	osName, shellCmd = hostPlatform()
+	loader.PatchFile("knowledge.md", "{{OS}}", osName)
+	loader.PatchFile("knowledge.md", "{{SHELL_CMD}}", shellCmd)
+	loader.PatchFile("knowledge.md", "{{SHELL_NOTES}}", shellNotes())
+	return osName, shellCmd
+}
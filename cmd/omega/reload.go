@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/pocketomega/pocket-omega/internal/config"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/llm/openai"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/web"
+)
+
+// configReloader applies edits of .env and omega.yaml to the running server
+// (/reload and SIGHUP): it rebuilds the LLM clients, updates the agent
+// budgets and re-registers the search tools whose API keys changed. Runs in
+// progress finish with what they started with. Settings read once at
+// startup (port, workspace, thinking and tool-call modes, context window,
+// …) still need a restart.
+type configReloader struct {
+	mu       sync.Mutex // one reload at a time
+	loaded   *config.Loaded
+	llm      *llm.Swappable
+	model    string
+	registry *tool.Registry
+	apply    []func(web.ReloadResult) // handlers showing or using the settings
+}
+
+// reload re-reads the config files and swaps in the rebuilt components.
+// An invalid config file is an error and changes nothing.
+func (r *configReloader) reload() (web.ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, changed, err := config.Reload(r.loaded)
+	if err != nil {
+		return web.ReloadResult{}, err
+	}
+	r.loaded = loaded
+	res := web.ReloadResult{Config: loaded, Changed: changed}
+
+	// LLM clients: unusable settings keep the current clients serving
+	if client, err := openai.NewClientFromEnv(); err != nil {
+		log.Printf("⚠️ Reload: LLM client unchanged: %v", err)
+		res.Warnings = append(res.Warnings, fmt.Sprintf("LLM 客户端未更新：%v", err))
+	} else {
+		r.model = client.GetConfig().Model
+		r.llm.Swap(newFailover(newModelRouter(client, r.model, os.Stdout), os.Stdout), r.model)
+	}
+	res.ModelName = r.model
+
+	res.MaxAgentTokens, res.MaxAgentDuration = loadCostLimits()
+
+	// Search tools follow their API keys; swapped in one step so that no
+	// decision sees a half-updated tool list
+	before := map[string]bool{}
+	for _, name := range searchToolNames {
+		if _, ok := r.registry.Get(name); ok {
+			before[name] = true
+		}
+	}
+	tools := searchTools(io.Discard)
+	r.registry.Replace(searchToolNames, tools...)
+	after := map[string]bool{}
+	for _, t := range tools {
+		after[t.Name()] = true
+		if !before[t.Name()] {
+			res.ToolsAdded = append(res.ToolsAdded, t.Name())
+		}
+	}
+	for _, name := range searchToolNames {
+		if before[name] && !after[name] {
+			res.ToolsRemoved = append(res.ToolsRemoved, name)
+		}
+	}
+
+	for _, apply := range r.apply {
+		apply(res)
+	}
+	log.Printf("[Config] Reload applied: model=%s, %d settings changed, tools +%d/-%d",
+		res.ModelName, len(res.Changed), len(res.ToolsAdded), len(res.ToolsRemoved))
+	return res, nil
+}
+
+// handleSignals reloads the config on every SIGHUP.
+func (r *configReloader) handleSignals() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	go func() {
+		for range ch {
+			res, err := r.reload()
+			if err != nil {
+				log.Printf("⚠️ SIGHUP reload failed: %v", err)
+				continue
+			}
+			log.Printf("[Config] SIGHUP reload: %s", strings.ReplaceAll(res.Summary(), "\n", " "))
+		}
+	}()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+)
+
+// runReplay implements `omega replay [-exec] [-step] <file>`: re-renders a
+// recorded agent run from logs/replay step by step. With -exec, recorded
+// read-only tool calls are executed again against the current workspace and
+// divergent outputs are reported (exit code 2), which helps bisect regressions.
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	reexec := fs.Bool("exec", false, "re-execute read-only tools (file_read/file_list/file_grep/find/git_info/project_map) and compare outputs")
+	step := fs.Bool("step", false, "pause for Enter before each step")
+	maxRunes := fs.Int("max-output", 1000, "truncate recorded outputs to N characters (0 = default)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: omega replay [-exec] [-step] <run.jsonl>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+
+	events, err := agent.LoadReplay(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load replay: %v\n", err)
+		return 1
+	}
+
+	opts := agent.ReplayOptions{MaxOutputRunes: *maxRunes}
+	if *step {
+		opts.Pause = os.Stdin
+	}
+	if *reexec {
+		workspaceDir := os.Getenv("WORKSPACE_DIR")
+		if workspaceDir == "" {
+			workspaceDir, _ = os.Getwd()
+		}
+		// Same pagination setup as the server so page boundaries match the recording.
+		pageStore := builtin.NewPageStore()
+		reg := tool.NewRegistry()
+		reg.Register(builtin.NewFileReadTool(workspaceDir))
+		reg.Register(builtin.NewFileListTool(workspaceDir).WithPageStore(pageStore))
+		reg.Register(builtin.NewFileGrepTool(workspaceDir).WithPageStore(pageStore))
+		reg.Register(builtin.NewFileFindTool(workspaceDir))
+		reg.Register(builtin.NewGitInfoTool(workspaceDir))
+		reg.Register(builtin.NewProjectMapTool(workspaceDir))
+		opts.Registry = reg
+		fmt.Printf("📂 Re-executing read-only tools in %s\n", workspaceDir)
+	}
+
+	diverged, err := agent.RenderReplay(context.Background(), os.Stdout, events, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Replay aborted: %v\n", err)
+		return 1
+	}
+	if diverged > 0 {
+		fmt.Printf("⚠️ %d re-executed tool output(s) differ from the recording\n", diverged)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/llm/openai"
+	"github.com/pocketomega/pocket-omega/internal/mcp"
+	"github.com/pocketomega/pocket-omega/internal/plan"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+	"github.com/pocketomega/pocket-omega/internal/walkthrough"
+	"github.com/pocketomega/pocket-omega/internal/workspace"
+)
+
+// runSessionID names the plan/walkthrough session of a headless run.
+const runSessionID = "cli"
+
+// runRun implements `omega run [flags] <task>`: one agent run in the terminal
+// with the same environment configuration as the server (LLM_*, TOOL_*,
+// WORKSPACE_DIR, sandbox, MCP, cost limits). Steps stream to stdout, setup
+// lines and -v logs go to stderr. Exit code 2 means the run failed (no
+// answer, timeout, interrupted) — or, with -strict, only partially succeeded.
+func runRun(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	wsFlag := fs.String("workspace", "", "workspace directory (default: WORKSPACE_DIR, else the current directory)")
+	model := fs.String("model", "", "model name (default: LLM_MODEL)")
+	style := fs.String("style", "", "answer style profile (default: the task's answer_style frontmatter, else default)")
+	timeout := fs.Duration("timeout", 30*time.Minute, "run timeout (0 = none)")
+	maxOutput := fs.Int("max-output", 1000, "truncate step outputs to N characters")
+	quiet := fs.Bool("quiet", false, "print only the final answer")
+	strict := fs.Bool("strict", false, "exit 2 on partial outcomes too (max steps, budget, dominating tool errors)")
+	verbose := fs.Bool("v", false, "show agent logs on stderr")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: omega run [flags] <task>   (task \"-\" reads it from stdin)")
+		fmt.Fprintln(fs.Output(), "  e.g. omega run '运行 go test ./... 并修复失败的测试'")
+		fmt.Fprintln(fs.Output(), "       omega run -quiet -strict -workspace ./svc - < playbooks/release.md")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 1
+	}
+
+	task := strings.Join(fs.Args(), " ")
+	if task == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return 1
+		}
+		task = string(data)
+	}
+	meta, problem := prompt.SplitFrontmatter(task)
+	if strings.TrimSpace(problem) == "" {
+		fs.Usage()
+		return 1
+	}
+	answerStyle := orDefault(*style, meta["answer_style"])
+	if answerStyle == prompt.DefaultAnswerStyle {
+		answerStyle = ""
+	}
+
+	// stdout carries the run itself; setup lines go to stderr
+	setupOut := io.Writer(os.Stderr)
+	if *quiet {
+		setupOut = io.Discard
+	}
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	workspaceDir := orDefault(*wsFlag, os.Getenv("WORKSPACE_DIR"))
+	if workspaceDir == "" {
+		workspaceDir, _ = os.Getwd()
+	}
+	if abs, err := filepath.Abs(workspaceDir); err == nil {
+		workspaceDir = abs
+	}
+	if info, err := os.Stat(workspaceDir); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "❌ Workspace %q does not exist or is not a directory\n", workspaceDir)
+		return 1
+	}
+
+	cfg, err := openai.NewConfigFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	if *model != "" {
+		cfg.Model = *model
+	}
+	client, err := openai.NewClient(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	setupTokenizer(cfg.Model, setupOut)
+	provider := newFailover(newModelRouter(client, cfg.Model, setupOut), setupOut)
+	tracing := false
+	if shutdownTracing, err := telemetry.Setup(context.Background(), "pocket-omega", "0.2"); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ Tracing disabled: %v\n", err)
+	} else if telemetry.Enabled() {
+		tracing = true
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			shutdownTracing(ctx)
+		}()
+	}
+	fmt.Fprintf(setupOut, "🤖 LLM: %s @ %s\n📂 Workspace: %s\n", cfg.Model, cfg.BaseURL, workspaceDir)
+
+	readOnly := os.Getenv("OMEGA_READ_ONLY") == "true"
+	if err := prepareWorkspace(workspaceDir, readOnly, setupOut); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	provider = llm.NewCachingProvider(provider, newResponseCache(workspaceDir, setupOut), cfg.Model, cfg.Sampling())
+	if tracing {
+		provider = telemetry.WrapLLM(provider, cfg.Model)
+	}
+	shellSandbox, err := sandbox.LoadFromEnv(workspaceDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+
+	registry := tool.NewRegistry()
+	registry.SetReadOnly(readOnly)
+	outputSummaryThreshold := loadOutputSummaryThreshold()
+	toolOutputsDir := filepath.Join(workspace.Dir(workspaceDir), "tool_outputs")
+	toolOpts := builtinToolOptions{
+		workspaceDir: workspaceDir,
+		sandbox:      shellSandbox,
+		vision:       client.GetConfig().ResolveVision(),
+		out:          setupOut,
+	}
+	if outputSummaryThreshold > 0 {
+		toolOpts.outputsDir = toolOutputsDir
+	}
+	if _, err := registerBuiltinTools(registry, toolOpts); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	reportToolHealth(registry.InitAll(context.Background()), setupOut)
+	defer registry.CloseAll()
+
+	loader, osName, shellCmd := newWorkspacePromptLoader(workspaceDir, setupOut)
+	if answerStyle != "" && !loader.HasAnswerStyle(answerStyle) {
+		fmt.Fprintf(os.Stderr, "❌ Unknown answer style %q\n", answerStyle)
+		return 1
+	}
+	if closeMCP := connectRunMCP(workspaceDir, registry, loader, shellSandbox, setupOut); closeMCP != nil {
+		defer closeMCP()
+	}
+	outputProcessor := newOutputProcessor(toolOutputsDir, outputSummaryThreshold, provider, setupOut)
+	fmt.Fprintf(setupOut, "🛠️  Tools: %d registered\n\n", len(registry.List()))
+
+	// Per-run plan and walkthrough, as the web handler does per request
+	planStore := plan.NewPlanStore()
+	walkthroughStore := walkthrough.NewStore()
+	out := os.Stdout
+	printPlan := func([]plan.PlanStep) {
+		if !*quiet {
+			fmt.Fprintf(out, "📋 %s\n", strings.TrimSpace(planStore.Render(runSessionID)))
+		}
+	}
+	runRegistry := registry.WithExtra(
+		builtin.NewUpdatePlanTool(planStore, runSessionID, printPlan),
+		builtin.NewWalkthroughTool(walkthroughStore, runSessionID),
+	)
+
+	thinkingMode := client.GetConfig().ResolveThinkingMode()
+	var replayRun *agent.ReplayRun
+	logDir := filepath.Join(workspaceDir, "logs")
+	if os.Getenv("AGENT_REPLAY_LOG") != "false" {
+		if rec, err := agent.NewReplayRecorder(filepath.Join(logDir, "replay")); err == nil {
+			replayRun = rec.StartRun(runSessionID, problem,
+				fmt.Sprintf("thinking=%s toolcall=%s style=%s", thinkingMode, cfg.ToolCallMode,
+					orDefault(answerStyle, prompt.DefaultAnswerStyle)))
+		}
+	}
+
+	auditLog := openAuditLog(workspaceDir, setupOut)
+	defer auditLog.Close()
+
+	state := &agent.AgentState{
+		Problem:             problem,
+		AnswerStyle:         answerStyle,
+		WorkspaceDir:        workspaceDir,
+		ToolRegistry:        runRegistry,
+		ThinkingMode:        thinkingMode,
+		ToolCallMode:        cfg.ToolCallMode,
+		ContextWindowTokens: client.GetConfig().ResolveContextWindow(),
+		OSName:              osName,
+		ShellCmd:            shellCmd,
+		ModelName:           cfg.Model,
+		WalkthroughStore:    walkthroughStore,
+		WalkthroughSID:      runSessionID,
+		PlanStore:           planStore,
+		PlanSID:             runSessionID,
+		ReadCache:           agent.NewReadCache(),
+		SearchHistory:       tool.NewSearchHistory(),
+		Translator:          i18n.NewTranslator(provider, os.Getenv("AGENT_WORKING_LANGUAGE")),
+		OutputProcessor:     outputProcessor,
+		Replay:              replayRun,
+		SelfReviewRetries:   loadSelfReviewRetries(),
+		Changes:             agent.NewChangeTracker(workspaceDir, loadChangeSummary()),
+		Audit:               auditLog.Run(runSessionID, "", ""),
+		Scratchpad:          agent.NewScratchpad(),
+		Verify:              agent.NewVerification(loadVerify(setupOut), workspaceDir),
+		OnStepComplete: func(step agent.StepRecord) {
+			replayRun.RecordStep(step)
+			// The answer is printed in full once the run ends
+			if !*quiet && step.Type != "answer" {
+				agent.RenderStep(out, step, *maxOutput)
+			}
+		},
+		OnPlanUpdate: printPlan,
+	}
+	if maxTokens, maxDuration := loadCostLimits(); maxTokens > 0 || maxDuration > 0 {
+		state.CostGuard = agent.NewCostGuard(maxTokens, maxDuration)
+	}
+
+	// Ctrl+C cancels the run; it still ends with an outcome and exit code
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	agent.BuildAgentFlow(provider, runRegistry, thinkingMode, loader).Run(ctx, state)
+
+	outcome := agent.ClassifyRun(state, ctx.Err())
+	state.Outcome = &outcome
+	replayRun.End(state)
+	if os.Getenv("AGENT_OUTCOME_LOG") != "false" {
+		if l, err := agent.NewOutcomeLog(filepath.Join(logDir, "outcomes.jsonl")); err == nil {
+			rec := agent.NewRunRecord(state, outcome)
+			rec.SessionID, rec.ElapsedMs = runSessionID, time.Since(start).Milliseconds()
+			l.Append(rec)
+		}
+	}
+
+	solution := strings.TrimSpace(state.Solution)
+	if !*quiet {
+		fmt.Fprintf(out, "\n■ 结束: %d 步, %.1fs, 结果: %s", len(state.StepHistory), time.Since(start).Seconds(), outcome.Outcome)
+		if len(outcome.Reasons) > 0 {
+			fmt.Fprintf(out, " (%s)", strings.Join(outcome.Reasons, ", "))
+		}
+		fmt.Fprintln(out)
+		fmt.Fprintln(out)
+	}
+	if solution != "" {
+		fmt.Fprintln(out, solution)
+	}
+	agent.NewBriefKeeper(provider, loader).UpdateLogged(context.Background(), state, outcome)
+
+	return runExitCode(outcome, *strict)
+}
+
+// runExitCode maps a run outcome to the process exit code of `omega run`.
+func runExitCode(o agent.RunOutcome, strict bool) int {
+	switch {
+	case o.Outcome == agent.OutcomeFailure:
+		return 2
+	case o.Outcome == agent.OutcomePartial && strict:
+		return 2
+	}
+	return 0
+}
+
+// connectRunMCP connects the workspace's MCP servers (MCP_CONFIG, else
+// mcp.json — never auto-created here) and registers their tools. Returns the
+// cleanup function, or nil when there is no MCP config.
+func connectRunMCP(workspaceDir string, registry *tool.Registry, loader *prompt.PromptLoader, sb *sandbox.Container, out io.Writer) func() {
+	configPath := orDefault(os.Getenv("MCP_CONFIG"), filepath.Join(workspaceDir, "mcp.json"))
+	if _, err := os.Stat(configPath); err != nil {
+		return nil
+	}
+	mgr := mcp.NewManager(configPath)
+	mgr.SetPromptLoader(loader)
+	mgr.SetSandbox(sb)
+	mgr.SetWorkspace(workspaceDir)
+	registry.Register(mcp.NewReloadTool(mgr, registry))
+	registry.Register(mcp.NewRollbackTool(mgr, registry))
+	registry.Register(mcp.NewResourceReadTool(mgr))
+
+	n, errs := mgr.ConnectAll(context.Background())
+	for _, e := range errs {
+		fmt.Fprintf(out, "⚠️  MCP connect: %v\n", e)
+	}
+	if n > 0 {
+		if err := mgr.RegisterTools(context.Background(), registry); err != nil {
+			fmt.Fprintf(out, "⚠️  MCP register tools: %v\n", err)
+		}
+		fmt.Fprintf(out, "🔌 MCP: %d server(s) connected\n", n)
+	}
+	return mgr.CloseAll
+}
@@ -0,0 +1,772 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/audit"
+	"github.com/pocketomega/pocket-omega/internal/config"
+	"github.com/pocketomega/pocket-omega/internal/editor"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/llm/openai"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
+	"github.com/pocketomega/pocket-omega/internal/storage"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+	"github.com/pocketomega/pocket-omega/internal/workspace"
+
+	"github.com/pocketomega/pocket-omega/internal/tokenizer"
+)
+
+// builtinToolOptions carries what registerBuiltinTools needs beyond the
+// TOOL_* environment; the server and `omega run` share one tool setup.
+type builtinToolOptions struct {
+	workspaceDir string
+	sandbox      *sandbox.Container // nil = shell/python run on the host
+	editor       *editor.Editor     // nil = file_open without editor support
+	outputsDir   string             // archived tool outputs; "" = no output_read
+	vision       bool               // register image_read
+	out          io.Writer          // startup lines
+}
+
+// registerBuiltinTools registers the built-in tools enabled by the
+// environment and applies TOOL_RATE_LIMITS. It returns the index of
+// code_search, nil unless CODE_SEARCH_ENABLED.
+func registerBuiltinTools(registry *tool.Registry, o builtinToolOptions) (*builtin.CodeIndex, error) {
+	shellEnabled := os.Getenv("TOOL_SHELL_ENABLED") != "false"
+	registry.Register(builtin.NewShellTool(o.workspaceDir, shellEnabled).WithSandbox(o.sandbox).WithBackend(windowsShell()))
+	registry.Register(builtin.NewFileReadTool(o.workspaceDir))
+	registry.Register(builtin.NewFileWriteTool(o.workspaceDir))
+
+	// Python execution tool — restricted subprocess (file access limited to the
+	// workspace, no network, child processes or ctypes, CPU/memory/time limits;
+	// best effort on the host, isolated in the container sandbox). Disable via
+	// TOOL_PYTHON_ENABLED=false.
+	if os.Getenv("TOOL_PYTHON_ENABLED") != "false" {
+		py := builtin.DetectPython()
+		pyTool := builtin.NewPythonExecTool(o.workspaceDir, py).
+			WithNetwork(os.Getenv("TOOL_PYTHON_ALLOW_NETWORK") == "true")
+		if v := os.Getenv("TOOL_PYTHON_MEMORY_MB"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				pyTool.WithMemoryLimit(n)
+			} else {
+				log.Printf("⚠️ Invalid TOOL_PYTHON_MEMORY_MB=%q, using default", v)
+			}
+		}
+		if o.sandbox != nil {
+			// Same container limits as shell_exec, but an image that ships python3.
+			pySandbox := *o.sandbox
+			pySandbox.Image = os.Getenv("TOOL_PYTHON_IMAGE")
+			if pySandbox.Image == "" {
+				pySandbox.Image = "python:3-slim"
+			}
+			pyTool.WithSandbox(&pySandbox)
+			registry.Register(pyTool)
+			fmt.Fprintf(o.out, "🐍 Python exec tool enabled (container: %s)\n", pySandbox.Image)
+		} else if py != "" {
+			registry.Register(pyTool)
+			fmt.Fprintf(o.out, "🐍 Python exec tool enabled (%s)\n", py)
+		}
+	}
+	// Paginated tools share one PageStore; fetch_more returns subsequent pages
+	pageStore := builtin.NewPageStore()
+	registry.Register(builtin.NewFileListTool(o.workspaceDir).WithPageStore(pageStore))
+	registry.Register(builtin.NewFileFindTool(o.workspaceDir))
+	registry.Register(builtin.NewTimeTool())
+	registry.Register(webReaderTool(o.out).WithPageStore(pageStore))
+	registry.Register(builtin.NewFetchMoreTool(pageStore))
+
+	// output_read serves outputs archived by the output processor
+	if o.outputsDir != "" {
+		registry.Register(builtin.NewOutputReadTool(o.outputsDir))
+	}
+
+	// P1 — core file operations (unconditional)
+	registry.Register(builtin.NewFileGrepTool(o.workspaceDir).WithPageStore(pageStore))
+	registry.Register(builtin.NewFileMoveTool(o.workspaceDir))
+	registry.Register(builtin.NewFileOpenTool(o.workspaceDir).WithEditor(o.editor))
+
+	// P2 — extended file operations (unconditional)
+	registry.Register(builtin.NewFileDeleteTool(o.workspaceDir))
+	registry.Register(builtin.NewFilePatchTool(o.workspaceDir))
+	registry.Register(builtin.NewArchiveTool(o.workspaceDir))
+	registry.Register(builtin.NewGitInfoTool(o.workspaceDir))
+	registry.Register(builtin.NewTodoScanTool(o.workspaceDir))
+	registry.Register(builtin.NewProjectMapTool(o.workspaceDir))
+	registry.Register(builtin.NewCSVQueryTool(o.workspaceDir))
+
+	// Document text (PDF, DOCX, EPUB); scanned PDFs need an OCR command
+	ocrCommand := strings.TrimSpace(os.Getenv("TOOL_DOC_OCR_COMMAND"))
+	registry.Register(builtin.NewDocReadTool(o.workspaceDir).WithPageStore(pageStore).WithOCR(ocrCommand))
+	if ocrCommand != "" {
+		fmt.Fprintf(o.out, "🔎 doc_read OCR enabled (%s)\n", ocrCommand)
+	}
+
+	// Image input — only useful when the model can see the images
+	if o.vision {
+		registry.Register(builtin.NewImageReadTool(o.workspaceDir))
+	}
+
+	// Git write tool — local branch/add/commit/stash; push and force ops opt-in.
+	if os.Getenv("TOOL_GIT_OPS_ENABLED") != "false" {
+		gitOps := builtin.NewGitOpsTool(o.workspaceDir).
+			WithPush(os.Getenv("TOOL_GIT_ALLOW_PUSH") == "true").
+			WithForce(os.Getenv("TOOL_GIT_ALLOW_FORCE") == "true")
+		if v := os.Getenv("TOOL_GIT_PROTECTED_BRANCHES"); v != "" {
+			gitOps.WithProtectedBranches(strings.Split(v, ","))
+		}
+		registry.Register(gitOps)
+	}
+
+	// Config edit tool — allows agent to modify config files outside workspace sandbox.
+	// Uses an allowlist so only explicitly named files are accessible.
+	if envPath := config.EnvFilePath(); envPath != "" && !strings.HasPrefix(envPath, "(") {
+		configAllowed := map[string]string{".env": envPath}
+		registry.Register(builtin.NewConfigEditTool(configAllowed))
+		fmt.Fprintf(o.out, "⚙️  Config edit tool: %s\n", envPath)
+
+		// env_set — allowlisted variables for MCP servers and skills
+		if os.Getenv("TOOL_ENV_SET_ENABLED") != "false" {
+			registry.Register(builtin.NewEnvSetTool(envPath, loadEnvSetAllow()).WithReservedKeys(startupEnvKeys).WithRestartKeys(restartEnvKeys))
+		}
+	}
+
+	// P2 — HTTP request tool (enabled by default, disable via TOOL_HTTP_ENABLED=false)
+	if os.Getenv("TOOL_HTTP_ENABLED") != "false" {
+		allowInternal := os.Getenv("TOOL_HTTP_ALLOW_INTERNAL") == "true"
+		registry.Register(builtin.NewHTTPRequestTool(allowInternal))
+		if allowInternal {
+			fmt.Fprintln(o.out, "🌐 HTTP request tool enabled (internal addresses allowed)")
+		} else {
+			fmt.Fprintln(o.out, "🌐 HTTP request tool enabled")
+		}
+	}
+
+	// Conditional search tools — auto-enable when API key is configured
+	for _, t := range searchTools(o.out) {
+		registry.Register(t)
+	}
+
+	// Semantic code search — opt-in, since indexing sends the workspace's
+	// source to the embeddings API (unless CODE_SEARCH_BACKEND=hash).
+	// CODE_SEARCH_EXCLUDE lists extra gitignore-style patterns to leave out.
+	var codeIndex *builtin.CodeIndex
+	if os.Getenv("CODE_SEARCH_ENABLED") == "true" {
+		embedder, err := newCodeSearchEmbedder()
+		if err != nil {
+			return nil, fmt.Errorf("CODE_SEARCH_BACKEND: %w", err)
+		}
+		codeSearch := builtin.NewCodeSearchTool(o.workspaceDir, embedder)
+		codeIndex = codeSearch.Index()
+		if v := os.Getenv("CODE_SEARCH_EXCLUDE"); v != "" {
+			codeIndex.WithExclude(strings.Split(v, ",")...)
+		}
+		registry.Register(codeSearch)
+		fmt.Fprintf(o.out, "🧭 Code search enabled (%s, index in .omega/index)\n", embedder.Name())
+	}
+
+	// Per-tool rate limits protect external APIs when the agent loops.
+	// TOOL_RATE_LIMITS overrides the defaults; "off" disables limiting.
+	rateSpec := os.Getenv("TOOL_RATE_LIMITS")
+	if rateSpec == "" {
+		rateSpec = "web_search=20:2,brave_search=20:2,http_request=60:4,web_reader=30:4"
+	}
+	if rateSpec != "off" {
+		limits, err := tool.ParseRateLimits(rateSpec)
+		if err != nil {
+			return nil, fmt.Errorf("TOOL_RATE_LIMITS: %w", err)
+		}
+		for name, l := range limits {
+			registry.SetRateLimit(name, l)
+		}
+		fmt.Fprintf(o.out, "⏱️  Tool rate limits: %s\n", rateSpec)
+	}
+
+	// Per-tool timeouts stop a hung tool (MCP server, long command) from
+	// freezing the loop. TOOL_TIMEOUTS entries override the defaults in
+	// tool.DefaultTimeouts; "off" disables them.
+	if timeoutSpec := os.Getenv("TOOL_TIMEOUTS"); timeoutSpec == "off" {
+		registry.SetTimeouts(map[string]time.Duration{})
+		fmt.Fprintln(o.out, "⏱️  Tool timeouts: off")
+	} else if timeoutSpec != "" {
+		overrides, err := tool.ParseTimeouts(timeoutSpec)
+		if err != nil {
+			return nil, fmt.Errorf("TOOL_TIMEOUTS: %w", err)
+		}
+		timeouts := maps.Clone(tool.DefaultTimeouts)
+		maps.Copy(timeouts, overrides)
+		registry.SetTimeouts(timeouts)
+		fmt.Fprintf(o.out, "⏱️  Tool timeouts: %s (other tools: defaults)\n", timeoutSpec)
+	}
+
+	// Results of read-only tools are cached across runs; a changed file
+	// (mtime/size) or page (ETag/Last-Modified) misses the cache.
+	// TOOL_CACHE_TTLS overrides the defaults; "off" disables caching.
+	cacheSpec := os.Getenv("TOOL_CACHE_TTLS")
+	if cacheSpec == "" {
+		cacheSpec = "file_read=10m,web_reader=5m"
+	}
+	if cacheSpec != "off" {
+		ttls, err := tool.ParseCacheTTLs(cacheSpec)
+		if err != nil {
+			return nil, fmt.Errorf("TOOL_CACHE_TTLS: %w", err)
+		}
+		maxEntries := 256
+		if v := os.Getenv("TOOL_CACHE_MAX_ENTRIES"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("TOOL_CACHE_MAX_ENTRIES: invalid value %q", v)
+			}
+			maxEntries = n
+		}
+		registry.SetResultCache(tool.NewResultCache(maxEntries, ttls))
+		fmt.Fprintf(o.out, "🗃️  Tool cache: %s (max %d entries)\n", cacheSpec, maxEntries)
+	}
+	return codeIndex, nil
+}
+
+// reportToolHealth prints the tools whose Init failed or degraded. Failed
+// tools are left out of the prompts; the rest of the agent starts anyway.
+func reportToolHealth(report map[string]tool.ToolHealth, out io.Writer) {
+	for _, name := range slices.Sorted(maps.Keys(report)) {
+		switch h := report[name]; h.Status {
+		case tool.HealthFailed:
+			fmt.Fprintf(out, "⚠️ Tool %s unavailable: %s\n", name, h.Error)
+		case tool.HealthDegraded:
+			fmt.Fprintf(out, "⚠️ Tool %s degraded: %s\n", name, h.Error)
+		}
+	}
+}
+
+// loadPermissions reads TOOL_PERMISSIONS_PATH, the roles and users that
+// limit each run's tools, and TOOL_PERMISSIONS_USER_HEADER, the request
+// header naming the user. There is no login of our own: the header must be
+// set by an authenticating reverse proxy, and requests without it get the
+// default role. Returns nil permissions when TOOL_PERMISSIONS_PATH is unset.
+func loadPermissions(out io.Writer) (*tool.Permissions, func(*http.Request) string, error) {
+	filePath := os.Getenv("TOOL_PERMISSIONS_PATH")
+	if filePath == "" {
+		return nil, nil, nil
+	}
+	perms, err := tool.LoadPermissions(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var userOf func(*http.Request) string
+	if header := os.Getenv("TOOL_PERMISSIONS_USER_HEADER"); header != "" {
+		userOf = func(r *http.Request) string { return strings.TrimSpace(r.Header.Get(header)) }
+		fmt.Fprintf(out, "🔐 Tool permissions: %d users, default role %s (user from %s)\n", len(perms.Users), perms.DefaultRole, header)
+	} else {
+		fmt.Fprintf(out, "🔐 Tool permissions: every request has role %s (TOOL_PERMISSIONS_USER_HEADER unset)\n", perms.DefaultRole)
+	}
+	return perms, userOf, nil
+}
+
+// searchToolNames are the tools searchTools may return; a config reload
+// replaces all of them.
+var searchToolNames = []string{"web_search", "brave_search"}
+
+// startupEnvKeys are the Omega settings read once at startup. env_set
+// refuses them even when TOOL_ENV_SET_ALLOW matches, so the agent cannot
+// repoint MCP_CONFIG or the model endpoint. Most variables it may set (of
+// MCP servers and skills) are read when used and take effect on /reload;
+// restartEnvKeys lists the exceptions.
+var startupEnvKeys = []string{
+	"AGENT_*", "AUDIO_*", "AUDIT_*", "BATCH_*", "CODE_SEARCH_*", "EDITOR*", "GRPC_ADDR", "JOURNAL_*",
+	"LLM_*", "LOOP_SIMILARITY", "MCP_CONFIG", "MCP_PER_CALL_IDLE_SECONDS", "MCP_SKILL_VERSIONS", "OMEGA_*", "OTEL_*",
+	"PROJECT_BRIEF_PATH", "PROMPTS_*", "REDACT_SECRETS", "SESSION_*", "SOUL_PATH", "STORAGE_*", "TOOL_*", "UI_LOCALE",
+	"USER_RULES_PATH", "WATCH_*", "WEB_*", "WORKSPACE_DIR",
+}
+
+// restartEnvKeys are the variables outside startupEnvKeys that are still
+// read only once, so env_set marks them as needing a restart: the
+// walkthrough archive and whisper.cpp switches, and the proxy settings Go
+// caches on first use.
+var restartEnvKeys = []string{
+	"WALKTHROUGH_ARCHIVE", "WHISPER_CPP_*",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+}
+
+// loadEnvSetAllow reads TOOL_ENV_SET_ALLOW, the comma-separated path.Match
+// patterns of the variables env_set may write; nil = the default allowlist.
+func loadEnvSetAllow() []string {
+	v := os.Getenv("TOOL_ENV_SET_ALLOW")
+	if v == "" {
+		return nil
+	}
+	allow := []string{}
+	for _, p := range strings.Split(v, ",") {
+		p = strings.TrimSpace(p)
+		if _, err := path.Match(p, ""); err != nil || p == "" {
+			log.Printf("⚠️ Invalid TOOL_ENV_SET_ALLOW entry %q, skipped", p)
+			continue
+		}
+		allow = append(allow, p)
+	}
+	return allow
+}
+
+// searchTools returns the web search tools whose API key is set
+// (TAVILY_API_KEY, BRAVE_API_KEY).
+func searchTools(out io.Writer) []tool.Tool {
+	var tools []tool.Tool
+	if key := os.Getenv("TAVILY_API_KEY"); key != "" {
+		tools = append(tools, builtin.NewTavilySearchTool(key))
+		fmt.Fprintln(out, "🔍 Tavily web search enabled")
+	}
+	if key := os.Getenv("BRAVE_API_KEY"); key != "" {
+		tools = append(tools, builtin.NewBraveSearchTool(key))
+		fmt.Fprintln(out, "🔍 Brave search enabled")
+	}
+	return tools
+}
+
+// webReaderTool returns web_reader with the crawl etiquette of
+// TOOL_WEB_READER_USER_AGENT, TOOL_WEB_READER_ROBOTS (false skips
+// robots.txt), TOOL_WEB_READER_HOST_INTERVAL_MS (gap between requests to
+// one host, default 1000) and TOOL_WEB_READER_MAX_KB (page size cap).
+// TOOL_WEB_READER_BROWSER ("auto" or the path of Chrome/Chromium) enables
+// rendering JavaScript pages in a headless browser, at most
+// TOOL_WEB_READER_BROWSER_POOL (default 2) at a time.
+func webReaderTool(out io.Writer) *builtin.WebReaderTool {
+	ua := os.Getenv("TOOL_WEB_READER_USER_AGENT")
+	wr := builtin.NewWebReaderTool().
+		WithUserAgent(ua).
+		WithRobots(os.Getenv("TOOL_WEB_READER_ROBOTS") != "false")
+	if browser := strings.TrimSpace(os.Getenv("TOOL_WEB_READER_BROWSER")); browser != "" && browser != "false" {
+		if browser == "auto" || browser == "true" {
+			browser = builtin.FindBrowser()
+		}
+		if browser == "" {
+			log.Printf("⚠️ TOOL_WEB_READER_BROWSER=auto but no Chrome/Chromium was found; JavaScript rendering disabled")
+		} else {
+			size := 2
+			if v := os.Getenv("TOOL_WEB_READER_BROWSER_POOL"); v != "" {
+				if n, err := strconv.Atoi(v); err == nil && n > 0 {
+					size = n
+				} else {
+					log.Printf("⚠️ Invalid TOOL_WEB_READER_BROWSER_POOL=%q, using %d", v, size)
+				}
+			}
+			wr.WithRenderer(builtin.NewBrowserPool(browser, size).
+				WithUserAgent(ua).
+				WithNoSandbox(os.Getenv("TOOL_WEB_READER_BROWSER_NO_SANDBOX") == "true"))
+			fmt.Fprintf(out, "🌐 web_reader JavaScript rendering enabled (%s, %d pages at a time)\n", browser, size)
+		}
+	}
+	if v := os.Getenv("TOOL_WEB_READER_HOST_INTERVAL_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			wr.WithHostInterval(time.Duration(n) * time.Millisecond)
+		} else {
+			log.Printf("⚠️ Invalid TOOL_WEB_READER_HOST_INTERVAL_MS=%q, using default", v)
+		}
+	}
+	if v := os.Getenv("TOOL_WEB_READER_MAX_KB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			wr.WithMaxBody(int64(n) << 10)
+		} else {
+			log.Printf("⚠️ Invalid TOOL_WEB_READER_MAX_KB=%q, using default", v)
+		}
+	}
+	return wr
+}
+
+// newCodeSearchEmbedder returns the embedder selected by CODE_SEARCH_BACKEND:
+// "openai" (OpenAI-compatible embeddings API, default) or "hash" (offline).
+func newCodeSearchEmbedder() (builtin.Embedder, error) {
+	switch backend := orDefault(os.Getenv("CODE_SEARCH_BACKEND"), "openai"); backend {
+	case "openai":
+		key := orDefault(os.Getenv("CODE_SEARCH_API_KEY"), os.Getenv("LLM_API_KEY"))
+		if key == "" {
+			return nil, fmt.Errorf("CODE_SEARCH_API_KEY or LLM_API_KEY is required for the openai backend")
+		}
+		return builtin.NewOpenAIEmbedder(
+			orDefault(os.Getenv("CODE_SEARCH_BASE_URL"), orDefault(os.Getenv("LLM_BASE_URL"), "https://api.openai.com/v1")),
+			key,
+			orDefault(os.Getenv("CODE_SEARCH_MODEL"), "text-embedding-3-small"),
+		), nil
+	case "hash":
+		return builtin.NewHashEmbedder(), nil
+	default:
+		return nil, fmt.Errorf("unknown backend %q (supported: openai, hash)", backend)
+	}
+}
+
+// loadOutputSummaryThreshold reads AGENT_OUTPUT_SUMMARY_THRESHOLD: tool outputs
+// above this many runes are archived and replaced by a summary (0 = off).
+func loadOutputSummaryThreshold() int {
+	threshold := 12000
+	if v := os.Getenv("AGENT_OUTPUT_SUMMARY_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			threshold = n
+		} else {
+			log.Printf("⚠️ Invalid AGENT_OUTPUT_SUMMARY_THRESHOLD=%q, using %d", v, threshold)
+		}
+	}
+	return threshold
+}
+
+// newWorkspacePromptLoader creates the three-layer prompt loader for a
+// workspace (PROMPTS_DIR, USER_RULES_PATH and SOUL_PATH override the
+// workspace defaults) and injects the host OS/shell into knowledge.md so
+// agents use platform-correct shell commands. The project brief lives at
+// PROJECT_BRIEF_PATH (default PROJECT_BRIEF.md in the workspace) unless
+// AGENT_PROJECT_BRIEF=false.
+func newWorkspacePromptLoader(workspaceDir string, out io.Writer) (loader *prompt.PromptLoader, osName, shellCmd string) {
+	promptsDir := orDefault(os.Getenv("PROMPTS_DIR"), filepath.Join(workspaceDir, "prompts"))
+	rulesPath := orDefault(os.Getenv("USER_RULES_PATH"), filepath.Join(workspaceDir, "rules.md"))
+	soulPath := orDefault(os.Getenv("SOUL_PATH"), filepath.Join(workspaceDir, "soul.md"))
+	loader = prompt.NewPromptLoader(promptsDir, rulesPath, soulPath)
+	fmt.Fprintf(out, "📋 Prompt loader: L2=%s L3=%s Soul=%s\n", promptsDir, rulesPath, soulPath)
+	if os.Getenv("AGENT_PROJECT_BRIEF") != "false" {
+		briefPath := orDefault(os.Getenv("PROJECT_BRIEF_PATH"), filepath.Join(workspaceDir, prompt.BriefName))
+		loader.WithBrief(briefPath)
+		fmt.Fprintf(out, "📒 Project brief: %s\n", briefPath)
+	}
+
+	osName, shellCmd = patchHostPlatform(loader)
+	return loader, osName, shellCmd
+}
+
+// loadCostLimits reads the CostGuard limits AGENT_MAX_TOKENS and
+// AGENT_MAX_DURATION_MINUTES; zero means unlimited.
+func loadCostLimits() (maxTokens int64, maxDuration time.Duration) {
+	if v := os.Getenv("AGENT_MAX_TOKENS"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			maxTokens = n
+		}
+	}
+	if v := os.Getenv("AGENT_MAX_DURATION_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxDuration = time.Duration(n) * time.Minute
+		}
+	}
+	return maxTokens, maxDuration
+}
+
+// prepareWorkspace upgrades the workspace's .omega metadata to the current
+// schema (backing up old data first). Read-only mode never writes: it only
+// refuses schemas from newer releases.
+func prepareWorkspace(workspaceDir string, readOnly bool, out io.Writer) error {
+	if readOnly {
+		res, err := workspace.Check(workspaceDir)
+		if err != nil {
+			return err
+		}
+		if res.From < workspace.CurrentVersion {
+			log.Printf("⚠️ Workspace schema v%d is older than v%d; not migrating in read-only mode", res.From, workspace.CurrentVersion)
+		}
+		return nil
+	}
+	res, err := workspace.Migrate(workspaceDir)
+	if err != nil {
+		return err
+	}
+	if len(res.Applied) > 0 {
+		fmt.Fprintf(out, "🗂️ Workspace schema migrated v%d → v%d (backup: %s)\n", res.From, res.To, orDefault(res.Backup, "none"))
+	}
+	return nil
+}
+
+// newOutputProcessor archives tool outputs above threshold runes in dir
+// (nil when threshold is 0). AGENT_OUTPUT_SUMMARIZER=llm summarizes web pages
+// and HTTP bodies with the model instead of extractively.
+func newOutputProcessor(dir string, threshold int, provider llm.LLMProvider, out io.Writer) *agent.OutputProcessor {
+	p := agent.NewOutputProcessor(dir, threshold)
+	if p == nil {
+		return nil
+	}
+	mode := "extractive"
+	if os.Getenv("AGENT_OUTPUT_SUMMARIZER") == "llm" {
+		mode = "llm (web_reader, http_request)"
+		llmSummarizer := agent.NewLLMSummarizer(provider)
+		p.Register("web_reader", llmSummarizer).
+			Register("http_request", llmSummarizer)
+	}
+	fmt.Fprintf(out, "🗜️ Output summaries: >%d chars, %s\n", threshold, mode)
+	return p
+}
+
+// loadChangeSummary reads AGENT_CHANGE_SUMMARY: the "files changed"
+// section appended to answers ("stats" by default, "diff" adds inline
+// diffs, "off" disables tracking).
+func loadChangeSummary() agent.ChangeMode {
+	mode, err := agent.ParseChangeMode(os.Getenv("AGENT_CHANGE_SUMMARY"))
+	if err != nil {
+		log.Printf("⚠️ %v; using stats", err)
+		return agent.ChangesStats
+	}
+	return mode
+}
+
+// loadVerify reads AGENT_VERIFY, AGENT_VERIFY_COMMAND and
+// AGENT_VERIFY_PATTERNS: whether answers of runs that edited code wait for
+// the project's checks ("hint" or "run"; "off" by default), the check
+// command (default: detected from each run's workspace) and the edited
+// files that call for it (comma-separated globs).
+func loadVerify(out io.Writer) agent.VerifyConfig {
+	mode, err := agent.ParseVerifyMode(os.Getenv("AGENT_VERIFY"))
+	if err != nil {
+		log.Printf("⚠️ %v; verification disabled", err)
+	}
+	cfg := agent.VerifyConfig{Mode: mode, Command: strings.TrimSpace(os.Getenv("AGENT_VERIFY_COMMAND"))}
+	if mode == agent.VerifyOff {
+		return cfg
+	}
+	if v := os.Getenv("AGENT_VERIFY_PATTERNS"); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			p = strings.TrimSpace(p)
+			if _, err := path.Match(p, ""); err != nil || p == "" {
+				log.Printf("⚠️ Invalid AGENT_VERIFY_PATTERNS entry %q, skipped", p)
+				continue
+			}
+			cfg.Patterns = append(cfg.Patterns, p)
+		}
+	}
+	command := orDefault(cfg.Command, "detected from go.mod, package.json or Makefile")
+	fmt.Fprintf(out, "🧪 Verification: %s (%s)\n", strings.ToLower(strings.TrimSpace(os.Getenv("AGENT_VERIFY"))), command)
+	return cfg
+}
+
+// defaultStorageQuotas applies when STORAGE_QUOTAS is unset.
+const defaultStorageQuotas = "replay=200MB,tool_outputs=200MB,explore=200MB,backups=500MB"
+
+// loadStorage reads STORAGE_QUOTAS, STORAGE_MIN_FREE_MB and
+// STORAGE_GC_INTERVAL_MINUTES: per-category quotas of the generated files
+// in the workspace ("off" = report only), the free space below which runs
+// warn (0 = no check) and how often quotas are enforced.
+func loadStorage(workspaceDir string) (*storage.Manager, time.Duration, error) {
+	spec := orDefault(os.Getenv("STORAGE_QUOTAS"), defaultStorageQuotas)
+	quotas := map[string]int64{}
+	if spec != "off" {
+		var err error
+		if quotas, err = storage.ParseQuotas(spec); err != nil {
+			return nil, 0, fmt.Errorf("STORAGE_QUOTAS: %w", err)
+		}
+	}
+	categories := storage.DefaultCategories(workspaceDir, quotas)
+	for name := range quotas {
+		if !slices.ContainsFunc(categories, func(c storage.Category) bool { return c.Name == name }) {
+			return nil, 0, fmt.Errorf("STORAGE_QUOTAS: unknown category %q", name)
+		}
+	}
+	minFreeMB := 1024
+	if v := os.Getenv("STORAGE_MIN_FREE_MB"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, 0, fmt.Errorf("STORAGE_MIN_FREE_MB: invalid value %q", v)
+		}
+		minFreeMB = n
+	}
+	interval := time.Hour
+	if v := os.Getenv("STORAGE_GC_INTERVAL_MINUTES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return nil, 0, fmt.Errorf("STORAGE_GC_INTERVAL_MINUTES: invalid value %q", v)
+		}
+		interval = time.Duration(n) * time.Minute
+	}
+	return storage.New(workspaceDir, categories, int64(minFreeMB)<<20), interval, nil
+}
+
+// loadSelfReviewRetries reads AGENT_SELF_REVIEW and
+// AGENT_SELF_REVIEW_MAX_RETRIES: how many failed answer reviews may send a
+// run back to work (0 = self-review off).
+func loadSelfReviewRetries() int {
+	if os.Getenv("AGENT_SELF_REVIEW") != "true" {
+		return 0
+	}
+	retries := 1
+	if v := os.Getenv("AGENT_SELF_REVIEW_MAX_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 1 && n <= 5 {
+			retries = n
+		} else {
+			log.Printf("⚠️ Invalid AGENT_SELF_REVIEW_MAX_RETRIES=%q (must be 1-5), using %d", v, retries)
+		}
+	}
+	return retries
+}
+
+// loadAskTimeout reads AGENT_ASK_TIMEOUT_SECONDS: how long a run paused on
+// a clarifying question waits for the user's reply (default 300; 0 = the
+// agent never asks).
+func loadAskTimeout() time.Duration {
+	seconds := 300
+	if v := os.Getenv("AGENT_ASK_TIMEOUT_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			seconds = n
+		} else {
+			log.Printf("⚠️ Invalid AGENT_ASK_TIMEOUT_SECONDS=%q, using %d", v, seconds)
+		}
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// newModelRouter sends the roles configured with LLM_MODEL_<ROLE> (e.g.
+// LLM_MODEL_SUMMARIZE=gpt-4o-mini) to their own clients; LLM_BASE_URL_<ROLE>
+// and LLM_API_KEY_<ROLE> point a role at another provider. It returns def
+// when no role is routed.
+func newModelRouter(def llm.LLMProvider, model string, out io.Writer) llm.LLMProvider {
+	router := llm.NewRouter(def, model)
+	for _, role := range llm.ModelRoles {
+		suffix := "_" + strings.ToUpper(string(role))
+		roleModel := os.Getenv("LLM_MODEL" + suffix)
+		baseURL := os.Getenv("LLM_BASE_URL" + suffix)
+		apiKey := os.Getenv("LLM_API_KEY" + suffix)
+		if roleModel == "" && baseURL == "" && apiKey == "" {
+			continue
+		}
+		// Fresh config: resolved FC/thinking modes are cached per model.
+		cfg, err := openai.NewConfigFromEnv()
+		if err != nil {
+			log.Printf("⚠️ Model route %s disabled: %v", role, err)
+			continue
+		}
+		cfg.Model = orDefault(roleModel, model)
+		cfg.BaseURL = orDefault(baseURL, cfg.BaseURL)
+		cfg.APIKey = orDefault(apiKey, cfg.APIKey)
+		c, err := openai.NewClient(cfg)
+		if err != nil {
+			log.Printf("⚠️ Model route %s disabled: %v", role, err)
+			continue
+		}
+		router.Route(role, c, cfg.Model)
+	}
+	if !router.Routed() {
+		return def
+	}
+	fmt.Fprintf(out, "🔀 Model routes: %s (default %s)\n", router, model)
+	return router
+}
+
+// newResponseCache reads LLM_CACHE, LLM_CACHE_TTL_HOURS and LLM_CACHE_DIR:
+// whether identical chat prompts and temperature-0 decisions reuse stored
+// responses, for how long, and where they are kept (default
+// .omega/llm_cache). It returns nil when the cache is off.
+func newResponseCache(workspaceDir string, out io.Writer) *llm.ResponseCache {
+	if os.Getenv("LLM_CACHE") != "true" {
+		return nil
+	}
+	ttlHours := 24
+	if v := os.Getenv("LLM_CACHE_TTL_HOURS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			ttlHours = n
+		} else {
+			log.Printf("⚠️ Invalid LLM_CACHE_TTL_HOURS=%q, using %d", v, ttlHours)
+		}
+	}
+	dir := orDefault(os.Getenv("LLM_CACHE_DIR"), filepath.Join(workspace.Dir(workspaceDir), "llm_cache"))
+	cache, err := llm.NewResponseCache(dir, time.Duration(ttlHours)*time.Hour)
+	if err != nil {
+		log.Printf("⚠️ LLM cache disabled: %v", err)
+		return nil
+	}
+	if n, err := cache.Prune(); err != nil {
+		log.Printf("⚠️ LLM cache prune: %v", err)
+	} else if n > 0 {
+		log.Printf("[LLMCache] Pruned %d expired entries", n)
+	}
+	ttl := "no expiry"
+	if ttlHours > 0 {
+		ttl = fmt.Sprintf("TTL %dh", ttlHours)
+	}
+	fmt.Fprintf(out, "💾 LLM cache: %s (%s)\n", dir, ttl)
+	return cache
+}
+
+// openAuditLog reads AUDIT_LOG, AUDIT_DIR, AUDIT_MAX_SIZE_MB and
+// AUDIT_MAX_FILES: whether every tool call is recorded (default on), where
+// (default .omega/audit), the size at which the file is rotated and how many
+// rotated files are kept (0 = all). It returns nil when the log is off or
+// cannot be opened.
+func openAuditLog(workspaceDir string, out io.Writer) *audit.Log {
+	if os.Getenv("AUDIT_LOG") == "false" {
+		return nil
+	}
+	maxMB := 10
+	if v := os.Getenv("AUDIT_MAX_SIZE_MB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			maxMB = n
+		} else {
+			log.Printf("⚠️ Invalid AUDIT_MAX_SIZE_MB=%q, using %d", v, maxMB)
+		}
+	}
+	keep := 20
+	if v := os.Getenv("AUDIT_MAX_FILES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			keep = n
+		} else {
+			log.Printf("⚠️ Invalid AUDIT_MAX_FILES=%q, using %d", v, keep)
+		}
+	}
+	dir := orDefault(os.Getenv("AUDIT_DIR"), filepath.Join(workspace.Dir(workspaceDir), "audit"))
+	l, err := audit.Open(dir, int64(maxMB)<<20, keep)
+	if err != nil {
+		log.Printf("⚠️ Audit log disabled: %v", err)
+		return nil
+	}
+	fmt.Fprintf(out, "📜 Audit log: %s (rotate at %d MB, keep %d, /api/audit)\n", dir, maxMB, keep)
+	return l
+}
+
+// newFailover backs primary with the secondary endpoint configured by
+// LLM_FALLBACK_MODEL, LLM_FALLBACK_BASE_URL and LLM_FALLBACK_API_KEY
+// (defaulting to the primary's endpoint and key): calls that still fail
+// transiently after their retries go there for
+// LLM_FAILOVER_COOLDOWN_SECONDS. It returns primary when no fallback is set.
+func newFailover(primary llm.LLMProvider, out io.Writer) llm.LLMProvider {
+	model := os.Getenv("LLM_FALLBACK_MODEL")
+	baseURL := os.Getenv("LLM_FALLBACK_BASE_URL")
+	if model == "" && baseURL == "" {
+		return primary
+	}
+	cooldown := 60
+	if v := os.Getenv("LLM_FAILOVER_COOLDOWN_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cooldown = n
+		} else {
+			log.Printf("⚠️ Invalid LLM_FAILOVER_COOLDOWN_SECONDS=%q, using %d", v, cooldown)
+		}
+	}
+	// Fresh config: resolved FC/thinking modes are cached per model.
+	cfg, err := openai.NewConfigFromEnv()
+	if err != nil {
+		log.Printf("⚠️ LLM failover disabled: %v", err)
+		return primary
+	}
+	cfg.Model = orDefault(model, cfg.Model)
+	cfg.BaseURL = orDefault(baseURL, cfg.BaseURL)
+	cfg.APIKey = orDefault(os.Getenv("LLM_FALLBACK_API_KEY"), cfg.APIKey)
+	secondary, err := openai.NewClient(cfg)
+	if err != nil {
+		log.Printf("⚠️ LLM failover disabled: %v", err)
+		return primary
+	}
+	fmt.Fprintf(out, "🛟 LLM failover: %s @ %s (cooldown %ds)\n", cfg.Model, cfg.BaseURL, cooldown)
+	return openai.NewFailover(primary, secondary, cfg.Model, time.Duration(cooldown)*time.Second)
+}
+
+// setupTokenizer selects the token counter of the budget guards from
+// LLM_TOKENIZER: "auto" (default, the BPE vocabulary of model), "heuristic"
+// (chars/token estimate) or a tiktoken encoding name such as "cl100k_base".
+func setupTokenizer(model string, out io.Writer) {
+	spec := orDefault(os.Getenv("LLM_TOKENIZER"), "auto")
+	tok, err := tokenizer.Load(spec, model)
+	if err != nil {
+		log.Printf("⚠️ Invalid LLM_TOKENIZER=%q, using %s: %v", spec, tok.Name(), err)
+	}
+	tokenizer.SetDefault(tok)
+	fmt.Fprintf(out, "🔢 Tokenizer: %s\n", tok.Name())
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// maxCorrections caps the user corrections kept in the decide prompt; older
+// ones are dropped first.
+const maxCorrections = 8
+
+// StepAnnotation is a user's note on one step of a running agent
+// ("this file was the wrong one"). It steers the run without cancelling it.
+type StepAnnotation struct {
+	Step int    `json:"step"` // StepNumber being annotated; 0 = the run as a whole
+	Note string `json:"note"`
+}
+
+// AnnotationQueue hands annotations from the UI to the running agent.
+// Add is called from HTTP handlers, so it is goroutine-safe, unlike AgentState.
+type AnnotationQueue struct {
+	mu      sync.Mutex
+	pending []StepAnnotation
+}
+
+// NewAnnotationQueue creates an empty queue.
+func NewAnnotationQueue() *AnnotationQueue {
+	return &AnnotationQueue{}
+}
+
+// Add queues an annotation for the next decide step.
+func (q *AnnotationQueue) Add(a StepAnnotation) {
+	q.mu.Lock()
+	q.pending = append(q.pending, a)
+	q.mu.Unlock()
+}
+
+// take returns and clears the pending annotations. Safe on a nil queue.
+func (q *AnnotationQueue) take() []StepAnnotation {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := q.pending
+	q.pending = nil
+	return out
+}
+
+// consumeAnnotations moves newly arrived annotations into state.Corrections,
+// records them in the replay log and reports them through OnCorrections.
+func consumeAnnotations(state *AgentState) {
+	fresh := state.Annotations.take()
+	if len(fresh) == 0 {
+		return
+	}
+	for _, a := range fresh {
+		state.Replay.RecordCorrection(a)
+	}
+	state.Corrections = append(state.Corrections, fresh...)
+	if over := len(state.Corrections) - maxCorrections; over > 0 {
+		state.Corrections = state.Corrections[over:]
+	}
+	if state.OnCorrections != nil {
+		state.OnCorrections(fresh)
+	}
+}
+
+// renderCorrections formats the user corrections for the decide prompt,
+// naming the annotated step so the model knows what the note refers to.
+func renderCorrections(corrections []StepAnnotation, steps []StepRecord) string {
+	if len(corrections) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("⚠️ 用户在执行过程中的纠正（优先级最高，与之前的判断冲突时以此为准）：\n")
+	for _, c := range corrections {
+		switch target := annotatedStep(steps, c.Step); {
+		case c.Step <= 0:
+			sb.WriteString(fmt.Sprintf("- %s\n", c.Note))
+		case target != nil && target.ToolName != "":
+			sb.WriteString(fmt.Sprintf("- 针对步骤 %d（%s）：%s\n", c.Step, target.ToolName, c.Note))
+		default:
+			sb.WriteString(fmt.Sprintf("- 针对步骤 %d：%s\n", c.Step, c.Note))
+		}
+	}
+	return sb.String()
+}
+
+func annotatedStep(steps []StepRecord, n int) *StepRecord {
+	for i := range steps {
+		if steps[i].StepNumber == n {
+			return &steps[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestAnnotationsInjectedIntoNextDecide(t *testing.T) {
+	reg := tool.NewRegistry()
+	reg.Register(&mockTool{"file_read", "Read files"})
+
+	queue := NewAnnotationQueue()
+	var notified []StepAnnotation
+	state := &AgentState{
+		Problem:      "fix the config",
+		ToolCallMode: "fc",
+		ToolRegistry: reg,
+		StepHistory: []StepRecord{
+			{StepNumber: 1, Type: "decide", Action: "tool"},
+			{StepNumber: 2, Type: "tool", ToolName: "file_read", Output: "dev config"},
+		},
+		Annotations:   queue,
+		OnCorrections: func(a []StepAnnotation) { notified = append(notified, a...) },
+	}
+	node := NewDecideNode(&mockLLMProvider{}, nil)
+
+	if prep := node.Prep(state)[0]; prep.Corrections != "" {
+		t.Fatalf("no annotation yet, got %q", prep.Corrections)
+	}
+
+	queue.Add(StepAnnotation{Step: 2, Note: "wrong file, read config/prod.yaml"})
+	prep := node.Prep(state)[0]
+	if !strings.Contains(prep.Corrections, "步骤 2（file_read）：wrong file") {
+		t.Errorf("correction should name the annotated step, got %q", prep.Corrections)
+	}
+	if !strings.Contains(buildDecidePromptFC(prep), "wrong file") {
+		t.Error("correction missing from the FC decide prompt")
+	}
+	if len(notified) != 1 {
+		t.Errorf("OnCorrections should fire once, got %d", len(notified))
+	}
+
+	// Consumed once, but kept in every later decision.
+	prep = node.Prep(state)[0]
+	if !strings.Contains(prep.Corrections, "wrong file") || len(notified) != 1 {
+		t.Errorf("correction should persist without re-notifying: %q, %d", prep.Corrections, len(notified))
+	}
+}
+
+func TestAnnotationsCapped(t *testing.T) {
+	queue := NewAnnotationQueue()
+	state := &AgentState{Annotations: queue}
+	for i := 0; i < maxCorrections+3; i++ {
+		queue.Add(StepAnnotation{Note: strings.Repeat("x", i+1)})
+	}
+	consumeAnnotations(state)
+	if len(state.Corrections) != maxCorrections {
+		t.Fatalf("got %d corrections, want %d", len(state.Corrections), maxCorrections)
+	}
+	if state.Corrections[0].Note != "xxxx" {
+		t.Errorf("oldest corrections should be dropped first, got %q", state.Corrections[0].Note)
+	}
+}
+
+func TestAnnotationQueueNil(t *testing.T) {
+	state := &AgentState{}
+	consumeAnnotations(state) // must not panic
+	if state.Corrections != nil {
+		t.Error("nil queue should yield no corrections")
+	}
+}
@@ -39,6 +39,7 @@ func (n *AnswerNodeImpl) Prep(state *AgentState) []AnswerPrep {
 			Problem:     state.Problem,
 			FullContext: state.LastDecision.Answer,
 			HasToolUse:  false,
+			AnswerStyle: state.AnswerStyle,
 			StreamChunk: state.OnStreamChunk,
 		}}
 	}
@@ -47,11 +48,22 @@ func (n *AnswerNodeImpl) Prep(state *AgentState) []AnswerPrep {
 	if state.LastDecision != nil && state.LastDecision.Answer != "" {
 		fullContext = fmt.Sprintf("[初步分析]:\n%s\n\n%s", state.LastDecision.Answer, fullContext)
 	}
+	// Self-review: the rewritten answer must address the previous critique
+	if state.ReviewCritique != "" {
+		fullContext = fmt.Sprintf("%s\n[审查意见]:\n%s", fullContext, state.ReviewCritique)
+	}
+	// Web research: number the pages so the answer can cite them
+	if len(state.Sources) > 0 {
+		fullContext = fmt.Sprintf("%s\n%s", fullContext, formatCitableSources(state.Sources))
+	}
 
 	return []AnswerPrep{{
 		Problem:     state.Problem,
 		FullContext: fullContext,
 		HasToolUse:  hasTools,
+		AnswerStyle: state.AnswerStyle,
+		Images:      state.Images,
+		Sources:     state.Sources,
 		StreamChunk: state.OnStreamChunk,
 	}}
 }
@@ -60,14 +72,19 @@ func (n *AnswerNodeImpl) Prep(state *AgentState) []AnswerPrep {
 func (n *AnswerNodeImpl) Exec(ctx context.Context, prep AnswerPrep) (AnswerResult, error) {
 	// Short direct answers without tool use can skip the synthesis LLM call
 	if utf8.RuneCountInString(prep.FullContext) < directAnswerMaxRunes && !prep.HasToolUse {
-		return AnswerResult{Answer: prep.FullContext}, nil
+		return AnswerResult{Answer: prep.FullContext, Direct: true}, nil
 	}
+	ctx = llm.WithModelRole(ctx, llm.ModelAnswer)
 
-	userPrompt := fmt.Sprintf("用户问题：%s\n\n以下是收集到的信息和分析：\n%s\n\n请综合以上信息，给出简洁明了的最终回答：", prep.Problem, prep.FullContext)
+	citeRule := ""
+	if len(prep.Sources) > 0 {
+		citeRule = "\n使用[可引用来源]中网页的信息时，在相应句末用 [n] 标注来源（n 为该列表中的编号，不是单次搜索结果里的序号），多个来源写作 [1][3]；不要编造编号，也不要在结尾另列来源清单。"
+	}
+	userPrompt := fmt.Sprintf("用户问题：%s\n\n以下是收集到的信息和分析：\n%s\n\n请综合以上信息，给出简洁明了的最终回答：%s", prep.Problem, prep.FullContext, citeRule)
 
 	msgs := []llm.Message{
-		{Role: llm.RoleSystem, Content: n.buildSystemPrompt()},
-		{Role: llm.RoleUser, Content: userPrompt},
+		{Role: llm.RoleSystem, Content: n.buildSystemPrompt(prep.AnswerStyle)},
+		{Role: llm.RoleUser, Content: userPrompt, Parts: prep.Images},
 	}
 
 	// Use streaming when callback is available
@@ -90,13 +107,28 @@ func (n *AnswerNodeImpl) Exec(ctx context.Context, prep AnswerPrep) (AnswerResul
 
 // ExecFallback returns an error answer.
 func (n *AnswerNodeImpl) ExecFallback(err error) AnswerResult {
-	return AnswerResult{Answer: fmt.Sprintf("抱歉，生成答案时出错：%v", err)}
+	return AnswerResult{Answer: fmt.Sprintf("抱歉，生成答案时出错：%v", err), LLMError: err.Error()}
 }
 
-// Post writes the solution to AgentState and ends the flow.
+// Post writes the solution, followed by its cited sources and the files
+// changed during the run, to AgentState and ends the flow, or hands the
+// answer to ReviewNode when self-review is enabled.
 func (n *AnswerNodeImpl) Post(state *AgentState, prep []AnswerPrep, results ...AnswerResult) core.Action {
 	if len(results) > 0 {
 		state.Solution = results[0].Answer
+		if results[0].LLMError != "" {
+			state.LLMError = results[0].LLMError
+		}
+	}
+	// Sources of web research, so the answer's claims can be checked
+	if len(results) > 0 && results[0].LLMError == "" {
+		if section := sourcesSection(state.Solution, state.Sources); section != "" {
+			state.Solution = strings.TrimRight(state.Solution, "\n") + "\n\n" + section
+		}
+	}
+	// Files changed by tools, so the user can audit what the run did
+	if section := state.Changes.Summary(); section != "" {
+		state.Solution = strings.TrimRight(state.Solution, "\n") + "\n\n" + section
 	}
 
 	step := StepRecord{
@@ -104,6 +136,9 @@ func (n *AnswerNodeImpl) Post(state *AgentState, prep []AnswerPrep, results ...A
 		Type:       "answer",
 		Output:     state.Solution,
 	}
+	if len(results) > 0 && !results[0].Direct {
+		step.Model = stepModel(state, n.llmProvider, llm.ModelAnswer)
+	}
 	state.StepHistory = append(state.StepHistory, step)
 
 	if state.OnStepComplete != nil {
@@ -112,11 +147,16 @@ func (n *AnswerNodeImpl) Post(state *AgentState, prep []AnswerPrep, results ...A
 
 	log.Printf("[AnswerNode] Final answer generated: %s", truncate(state.Solution, 100))
 
+	state.ReviewCritique = "" // consumed by this answer
+	if needsReview(state) {
+		return core.ActionReview
+	}
 	return core.ActionEnd
 }
 
-// buildSystemPrompt assembles the answer L2 style rules and optional L3 user rules.
-func (n *AnswerNodeImpl) buildSystemPrompt() string {
+// buildSystemPrompt assembles the answer L2 style rules of the given profile
+// and optional L3 user rules.
+func (n *AnswerNodeImpl) buildSystemPrompt(style string) string {
 	const answerL1Default = "你是一个高效的助手。根据收集到的信息直接回答用户问题。\n根据已有信息直接作答，不要添加\"以下是答案\"之类的前缀。"
 
 	if n.loader == nil {
@@ -135,8 +175,8 @@ func (n *AnswerNodeImpl) buildSystemPrompt() string {
 	}
 
 	// L2: answer style rules
-	if style := n.loader.Load("answer_style.md"); style != "" {
-		sb.WriteString(style)
+	if rules := n.loader.LoadAnswerStyle(style); rules != "" {
+		sb.WriteString(rules)
 	}
 
 	// L3: user custom rules
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/core"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// AskFunc poses a clarifying question to the user and blocks until the
+// reply arrives or ctx ends. Injected by the handler layer
+// (AgentState.AskUser); runs without a user to ask leave it nil.
+type AskFunc func(ctx context.Context, question string) (string, error)
+
+// maxAsksPerRun caps the clarifying questions of one run, so a model that
+// keeps asking cannot stall the task.
+const maxAsksPerRun = 3
+
+// askUserToolName is the function through which FC-mode models ask.
+const askUserToolName = "ask_user"
+
+// askUserToolDef describes ask_user for function calling.
+var askUserToolDef = llm.ToolDefinition{
+	Name:        askUserToolName,
+	Description: "向用户提出一个澄清问题并暂停，等待用户回复后继续。仅在需求存在关键歧义、且无法通过其他工具查明时使用。",
+	Parameters:  []byte(`{"type":"object","properties":{"question":{"type":"string","description":"简短具体的澄清问题"}},"required":["question"]}`),
+}
+
+// canAsk reports whether the run may pause for another question.
+func canAsk(state *AgentState) bool {
+	return state.AskUser != nil && state.Asks < maxAsksPerRun
+}
+
+// AskNodeImpl implements BaseNode[AgentState, AskPrep, AskResult].
+// It pauses the run on the question of an "ask" decision and adds the
+// user's reply to the conversation history before the next decision.
+type AskNodeImpl struct{}
+
+func NewAskNode() *AskNodeImpl {
+	return &AskNodeImpl{}
+}
+
+// Prep reads the question from LastDecision.
+func (n *AskNodeImpl) Prep(state *AgentState) []AskPrep {
+	if state.LastDecision == nil || state.AskUser == nil {
+		return nil
+	}
+	return []AskPrep{{Question: strings.TrimSpace(state.LastDecision.Question), Ask: state.AskUser}}
+}
+
+// Exec waits for the user's reply.
+func (n *AskNodeImpl) Exec(ctx context.Context, prep AskPrep) (AskResult, error) {
+	reply, err := prep.Ask(ctx, prep.Question)
+	if err != nil {
+		return AskResult{}, err
+	}
+	if reply = strings.TrimSpace(reply); reply == "" {
+		return AskResult{}, errors.New("empty reply")
+	}
+	return AskResult{Reply: reply}, nil
+}
+
+// ExecFallback records why no reply arrived (timeout, cancel).
+func (n *AskNodeImpl) ExecFallback(err error) AskResult {
+	return AskResult{Err: err.Error()}
+}
+
+// Post records the exchange and routes back to DecideNode. A reply joins
+// ConversationHistory, so every later prompt sees it; without one the run
+// continues on its own and asks no more.
+func (n *AskNodeImpl) Post(state *AgentState, prep []AskPrep, results ...AskResult) core.Action {
+	if len(prep) == 0 || len(results) == 0 {
+		return core.ActionDefault
+	}
+	question, result := prep[0].Question, results[0]
+	state.Asks++
+
+	step := StepRecord{
+		StepNumber: len(state.StepHistory) + 1,
+		Type:       "ask",
+		Input:      question,
+		Output:     result.Reply,
+	}
+	if result.Err != "" {
+		step.IsError = true
+		step.Output = "用户未回复（" + result.Err + "），请根据已有信息自行判断并继续"
+		state.Asks = maxAsksPerRun
+		log.Printf("[AskNode] No reply: %s", result.Err)
+	} else {
+		state.ConversationHistory += fmt.Sprintf("[澄清问答]\n助手提问：%s\n用户回复：%s\n\n", question, result.Reply)
+		log.Printf("[AskNode] Reply received: %s", truncate(result.Reply, 100))
+	}
+	state.StepHistory = append(state.StepHistory, step)
+
+	if state.OnStepComplete != nil {
+		state.OnStepComplete(step)
+	}
+	return compactRoute(state)
+}
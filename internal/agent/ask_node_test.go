@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/core"
+)
+
+func TestParseDecision_Ask(t *testing.T) {
+	d, err := parseDecision("```yaml\naction: ask\nreason: 需求有歧义\nquestion: 要修改哪个配置文件？\n```")
+	if err != nil || d.Action != "ask" || d.Question != "要修改哪个配置文件？" {
+		t.Fatalf("parseDecision = %+v, %v", d, err)
+	}
+	if _, err := parseDecisionJSON(`{"action": "ask", "reason": "缺问题"}`, nil); err == nil {
+		t.Error("ask without a question should be rejected")
+	}
+}
+
+func TestDecidePost_AskRouting(t *testing.T) {
+	n := NewDecideNode(nil, nil)
+	ask := Decision{Action: "ask", Reason: "r", Question: "用哪个分支？"}
+
+	headless := &AgentState{}
+	if got := n.Post(headless, nil, ask); got != core.ActionAnswer {
+		t.Errorf("headless: route = %s, want answer", got)
+	}
+	if headless.LastDecision.Answer != "用哪个分支？" {
+		t.Errorf("headless: answer = %q, want the question", headless.LastDecision.Answer)
+	}
+
+	stub := func(context.Context, string) (string, error) { return "main", nil }
+	interactive := &AgentState{AskUser: stub}
+	if got := n.Post(interactive, nil, ask); got != core.ActionAsk {
+		t.Errorf("interactive: route = %s, want ask", got)
+	}
+
+	limited := &AgentState{AskUser: stub, Asks: maxAsksPerRun}
+	if got := n.Post(limited, nil, ask); got != core.ActionAnswer {
+		t.Errorf("limit reached: route = %s, want answer", got)
+	}
+}
+
+func TestAskNode(t *testing.T) {
+	n := NewAskNode()
+	var asked string
+	state := &AgentState{
+		LastDecision: &Decision{Action: "ask", Question: " 用哪个分支？ "},
+		AskUser: func(_ context.Context, q string) (string, error) {
+			asked = q
+			return " main ", nil
+		},
+	}
+	prep := n.Prep(state)
+	res, err := n.Exec(context.Background(), prep[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Post(state, prep, res)
+	if asked != "用哪个分支？" || state.Asks != 1 {
+		t.Errorf("asked %q, Asks = %d", asked, state.Asks)
+	}
+	if !strings.Contains(state.ConversationHistory, "用户回复：main") {
+		t.Errorf("reply not in history: %q", state.ConversationHistory)
+	}
+	if last := state.StepHistory[len(state.StepHistory)-1]; last.Type != "ask" || last.Output != "main" {
+		t.Errorf("step = %+v", last)
+	}
+
+	// No reply: the run continues on its own and stops asking.
+	state.AskUser = func(context.Context, string) (string, error) { return "", errors.New("no reply in time") }
+	prep = n.Prep(state)
+	if _, err := n.Exec(context.Background(), prep[0]); err == nil {
+		t.Fatal("Exec should fail without a reply")
+	}
+	n.Post(state, prep, n.ExecFallback(errors.New("no reply in time")))
+	if last := state.StepHistory[len(state.StepHistory)-1]; !last.IsError {
+		t.Errorf("timeout step not marked as error: %+v", last)
+	}
+	if canAsk(state) {
+		t.Error("canAsk after an unanswered question")
+	}
+}
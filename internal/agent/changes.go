@@ -0,0 +1,406 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChangeMode selects the "files changed" section appended to answers
+// (AGENT_CHANGE_SUMMARY).
+type ChangeMode int
+
+const (
+	ChangesOff   ChangeMode = iota // no tracking
+	ChangesStats                   // per-file line counts
+	ChangesDiff                    // line counts plus inline diffs
+)
+
+// ParseChangeMode parses AGENT_CHANGE_SUMMARY: "stats" (default), "diff"
+// or "off".
+func ParseChangeMode(s string) (ChangeMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "stats":
+		return ChangesStats, nil
+	case "diff":
+		return ChangesDiff, nil
+	case "off", "false":
+		return ChangesOff, nil
+	}
+	return ChangesOff, fmt.Errorf("invalid change summary mode %q (want stats, diff or off)", s)
+}
+
+// Limits of the change tracker.
+const (
+	changeMaxFileBytes   = 1 << 20 // larger originals are not kept; no line counts
+	changeMaxDirFiles    = 200     // files recorded under a directory argument
+	changeMaxScanFiles   = 5000    // workspace files stamped around shell commands
+	changeDiffFileLines  = 80      // inline diff lines per file
+	changeDiffTotalLines = 400     // inline diff lines per answer
+)
+
+// pathWriteTools change the files named by their path arguments.
+var pathWriteTools = map[string][]string{
+	"file_write":  {"path"},
+	"file_patch":  {"path"},
+	"file_delete": {"path"},
+	"file_move":   {"source", "destination"},
+}
+
+// shellWriteTools may change any workspace file; the workspace is stamped
+// (mtime and size) before and after each call to find what they changed.
+var shellWriteTools = map[string]bool{
+	"shell_exec":   true,
+	"python_exec":  true,
+	"git_ops":      true,
+	"archive_tool": true, // extract and create write files not named by a single argument
+}
+
+// ChangeTracker records the workspace files created, modified or deleted by
+// tools during a run, keeping each file's content from before its first
+// change so the answer can list per-file diff stats. Safe for concurrent
+// use.
+type ChangeTracker struct {
+	workspace string
+	mode      ChangeMode
+
+	mu      sync.Mutex
+	files   map[string]*trackedFile // absolute path → state before the run changed it
+	stamps  map[string]fileStamp    // workspace stamps taken before a shell tool
+	noScan  bool                    // workspace too large to stamp
+	scanned bool                    // stamps is from BeforeTool of the running shell tool
+}
+
+type trackedFile struct {
+	existed bool
+	content []byte    // original content; nil when unknown
+	known   bool      // content is the original (false: changed by a shell tool, or too large)
+	stamp   fileStamp // mtime and size before the change, compared when content is unknown
+	tool    string    // first tool that changed the file
+}
+
+type fileStamp struct {
+	mtime time.Time
+	size  int64
+}
+
+func stampOf(info fs.FileInfo) fileStamp {
+	return fileStamp{mtime: info.ModTime(), size: info.Size()}
+}
+
+func (s fileStamp) equal(o fileStamp) bool {
+	return s.size == o.size && s.mtime.Equal(o.mtime)
+}
+
+// NewChangeTracker creates a tracker for the workspace; nil when mode is
+// ChangesOff. All methods are nil-safe.
+func NewChangeTracker(workspaceDir string, mode ChangeMode) *ChangeTracker {
+	if mode == ChangesOff || workspaceDir == "" {
+		return nil
+	}
+	return &ChangeTracker{workspace: workspaceDir, mode: mode, files: make(map[string]*trackedFile)}
+}
+
+// BeforeTool records the files toolName may change with args, before it runs.
+func (c *ChangeTracker) BeforeTool(toolName string, args []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if keys, ok := pathWriteTools[toolName]; ok {
+		var params map[string]any
+		if json.Unmarshal(args, &params) != nil {
+			return
+		}
+		for _, key := range keys {
+			if p, _ := params[key].(string); p != "" {
+				c.recordPath(c.abs(p), toolName)
+			}
+		}
+		return
+	}
+	if shellWriteTools[toolName] && !c.noScan {
+		c.stamps, c.scanned = c.stampWorkspace(), true
+	}
+}
+
+// AfterTool records the files a shell tool changed since BeforeTool.
+func (c *ChangeTracker) AfterTool(toolName string) {
+	if c == nil || !shellWriteTools[toolName] {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.scanned || c.noScan {
+		return
+	}
+	before := c.stamps
+	after := c.stampWorkspace()
+	c.stamps, c.scanned = nil, false
+	if c.noScan {
+		return
+	}
+	for path, st := range after {
+		if old, ok := before[path]; !ok || !old.equal(st) {
+			c.recordShell(path, ok, old, toolName)
+		}
+	}
+	for path, old := range before {
+		if _, ok := after[path]; !ok {
+			c.recordShell(path, true, old, toolName)
+		}
+	}
+}
+
+func (c *ChangeTracker) abs(p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(c.workspace, p)
+}
+
+// recordPath keeps the current content of path (every file under it for a
+// directory) unless it is already tracked; c.mu must be held.
+func (c *ChangeTracker) recordPath(path, toolName string) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		c.recordFile(path, toolName)
+		return
+	}
+	n := 0
+	filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if n++; n > changeMaxDirFiles {
+			return filepath.SkipAll
+		}
+		c.recordFile(p, toolName)
+		return nil
+	})
+}
+
+func (c *ChangeTracker) recordFile(path, toolName string) {
+	if _, ok := c.files[path]; ok {
+		return
+	}
+	f := &trackedFile{tool: toolName}
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		f.existed = true
+		f.stamp = stampOf(info)
+		if info.Size() <= changeMaxFileBytes {
+			if data, err := os.ReadFile(path); err == nil {
+				f.content, f.known = data, true
+			}
+		}
+	} else if err != nil {
+		f.known = true // absent: the original is "no file"
+	}
+	c.files[path] = f
+}
+
+// recordShell tracks a file changed by a shell tool, whose original content
+// is unknown unless a file tool recorded it earlier; c.mu must be held.
+func (c *ChangeTracker) recordShell(path string, existed bool, stamp fileStamp, toolName string) {
+	if _, ok := c.files[path]; ok {
+		return
+	}
+	c.files[path] = &trackedFile{existed: existed, known: !existed, stamp: stamp, tool: toolName}
+}
+
+// stampWorkspace returns the mtime and size of the workspace files, skipping
+// hidden and dependency directories. Sets noScan when the workspace has
+// more than changeMaxScanFiles files.
+func (c *ChangeTracker) stampWorkspace() map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	err := filepath.WalkDir(c.workspace, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if p != c.workspace && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" || d.Name() == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if len(stamps) >= changeMaxScanFiles {
+			return filepath.SkipAll
+		}
+		if info, err := d.Info(); err == nil {
+			stamps[p] = stampOf(info)
+		}
+		return nil
+	})
+	if err == nil && len(stamps) >= changeMaxScanFiles {
+		log.Printf("[Changes] Workspace has over %d files; shell command changes are not tracked", changeMaxScanFiles)
+		c.noScan = true
+	}
+	return stamps
+}
+
+// FileChange is one file changed during the run.
+type FileChange struct {
+	Path    string // relative to the workspace when inside it
+	Kind    string // "created", "modified" or "deleted"
+	Added   int    // lines; -1 = unknown
+	Removed int
+	Binary  bool
+	Tool    string // first tool that changed the file
+	diff    []diffOp
+}
+
+// Changes returns the tracked files that differ from their state before
+// the run, sorted by path. Files restored to their original are omitted.
+func (c *ChangeTracker) Changes() []FileChange {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	current := make(map[string]*trackedFile, len(c.files))
+	for path, f := range c.files {
+		current[path] = f
+		// A directory created by a tracked call (e.g. file_move destination):
+		// its files are new
+		if !f.existed {
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				delete(current, path)
+				n := 0
+				filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+					if err == nil && !d.IsDir() && n < changeMaxDirFiles {
+						n++
+						if _, tracked := c.files[p]; !tracked {
+							current[p] = &trackedFile{known: true, tool: f.tool}
+						}
+					}
+					return nil
+				})
+			}
+		}
+	}
+
+	var changes []FileChange
+	for path, f := range current {
+		if ch, ok := f.change(path); ok {
+			ch.Path = c.rel(path)
+			changes = append(changes, ch)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// change compares the original with the file on disk now.
+func (f *trackedFile) change(path string) (FileChange, bool) {
+	ch := FileChange{Tool: f.tool, Added: -1, Removed: -1}
+	info, err := os.Stat(path)
+	exists := err == nil && !info.IsDir()
+	var now []byte
+	readable := false
+	if exists && info.Size() <= changeMaxFileBytes {
+		if data, err := os.ReadFile(path); err == nil {
+			now, readable = data, true
+		}
+	}
+	switch {
+	case !f.existed && !exists:
+		return ch, false
+	case !f.existed:
+		ch.Kind = "created"
+	case !exists:
+		ch.Kind = "deleted"
+	default:
+		if f.known && readable && bytes.Equal(f.content, now) ||
+			!f.known && stampOf(info).equal(f.stamp) {
+			return ch, false
+		}
+		ch.Kind = "modified"
+	}
+	if isBinary(f.content) || isBinary(now) {
+		ch.Binary = true
+		return ch, true
+	}
+	if f.known && (readable || !exists) {
+		ch.diff = diffLines(splitFileLines(f.content), splitFileLines(now))
+		ch.Added, ch.Removed = diffStats(ch.diff)
+	}
+	return ch, true
+}
+
+func (c *ChangeTracker) rel(path string) string {
+	if rel, err := filepath.Rel(c.workspace, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+func splitFileLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+}
+
+// changeKindLabels are the labels of FileChange.Kind in the answer section.
+var changeKindLabels = map[string]string{"created": "新建", "modified": "修改", "deleted": "删除"}
+
+// Summary renders the changed files as a markdown section for the end of
+// the answer, with inline diffs in ChangesDiff mode; "" when nothing
+// changed.
+func (c *ChangeTracker) Summary() string {
+	changes := c.Changes()
+	if len(changes) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### 📝 文件变更 (%d)\n\n", len(changes))
+	for _, ch := range changes {
+		fmt.Fprintf(&sb, "- `%s` — %s", ch.Path, changeKindLabels[ch.Kind])
+		switch {
+		case ch.Binary:
+			sb.WriteString("（二进制）")
+		case ch.Added >= 0:
+			fmt.Fprintf(&sb, " (+%d −%d)", ch.Added, ch.Removed)
+		}
+		if shellWriteTools[ch.Tool] {
+			fmt.Fprintf(&sb, "，由 %s", ch.Tool)
+		}
+		sb.WriteString("\n")
+	}
+	if c.mode != ChangesDiff {
+		return sb.String()
+	}
+	budget := changeDiffTotalLines
+	for _, ch := range changes {
+		if len(ch.diff) == 0 || budget <= 0 {
+			continue
+		}
+		hunks, truncated := unifiedHunks(ch.diff, 3, min(changeDiffFileLines, budget))
+		if hunks == "" {
+			continue
+		}
+		budget -= strings.Count(hunks, "\n")
+		fmt.Fprintf(&sb, "\n`%s`\n```diff\n%s", ch.Path, hunks)
+		if truncated {
+			sb.WriteString("... (diff truncated)\n")
+		}
+		sb.WriteString("```\n")
+	}
+	return sb.String()
+}
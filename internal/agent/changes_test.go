@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChangeTracker_FileTools(t *testing.T) {
+	ws := t.TempDir()
+	write := func(name, content string) {
+		os.MkdirAll(filepath.Dir(filepath.Join(ws, name)), 0o755)
+		os.WriteFile(filepath.Join(ws, name), []byte(content), 0o644)
+	}
+	write("main.go", "package main\n\nfunc main() {\n}\n")
+	write("old.txt", "a\nb\n")
+	write("same.txt", "unchanged\n")
+	write("docs/a.md", "doc\n")
+
+	c := NewChangeTracker(ws, ChangesDiff)
+	c.BeforeTool("file_patch", []byte(`{"path":"main.go"}`))
+	write("main.go", "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n")
+	c.BeforeTool("file_write", []byte(`{"path":"new/x.txt"}`))
+	write("new/x.txt", "1\n2\n3\n")
+	c.BeforeTool("file_delete", []byte(`{"path":"old.txt"}`))
+	os.Remove(filepath.Join(ws, "old.txt"))
+	c.BeforeTool("file_write", []byte(`{"path":"same.txt"}`)) // failed or no-op write
+	c.BeforeTool("file_move", []byte(`{"source":"docs","destination":"manual"}`))
+	os.Rename(filepath.Join(ws, "docs"), filepath.Join(ws, "manual"))
+	c.BeforeTool("file_read", []byte(`{"path":"main.go"}`))
+
+	got := c.Changes()
+	want := []string{"docs/a.md deleted 0+1-", "main.go modified 1+0-", "manual/a.md created 1+0-", "new/x.txt created 3+0-", "old.txt deleted 0+2-"}
+	if len(got) != len(want) {
+		t.Fatalf("Changes = %+v", got)
+	}
+	for i, ch := range got {
+		if s := strings.Join([]string{ch.Path, ch.Kind, strconv.Itoa(ch.Added) + "+" + strconv.Itoa(ch.Removed) + "-"}, " "); s != want[i] {
+			t.Errorf("change %d = %q, want %q", i, s, want[i])
+		}
+	}
+
+	summary := c.Summary()
+	for _, s := range []string{"### 📝 文件变更 (5)", "- `main.go` — 修改 (+1 −0)", "- `new/x.txt` — 新建 (+3 −0)", "```diff\n@@ -1,4 +1,5 @@\n package main\n \n func main() {\n+\tprintln(\"hi\")\n }\n```"} {
+		if !strings.Contains(summary, s) {
+			t.Errorf("summary lacks %q:\n%s", s, summary)
+		}
+	}
+	if stats := NewChangeTracker(ws, ChangesStats); stats.Summary() != "" {
+		t.Error("summary without changes")
+	}
+	if NewChangeTracker(ws, ChangesOff) != nil {
+		t.Error("tracker created with ChangesOff")
+	}
+}
+
+func TestChangeTracker_ShellTools(t *testing.T) {
+	ws := t.TempDir()
+	os.WriteFile(filepath.Join(ws, "keep.txt"), []byte("x\n"), 0o644)
+	os.WriteFile(filepath.Join(ws, "edit.txt"), []byte("x\n"), 0o644)
+	os.WriteFile(filepath.Join(ws, "gone.txt"), []byte("x\n"), 0o644)
+	os.MkdirAll(filepath.Join(ws, ".git"), 0o755)
+
+	c := NewChangeTracker(ws, ChangesStats)
+	c.BeforeTool("shell_exec", []byte(`{"command":"..."}`))
+	os.WriteFile(filepath.Join(ws, "edit.txt"), []byte("x\ny\n"), 0o644)
+	os.Chtimes(filepath.Join(ws, "edit.txt"), time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	os.Remove(filepath.Join(ws, "gone.txt"))
+	os.WriteFile(filepath.Join(ws, "built.out"), []byte("1\n2\n"), 0o644)
+	os.WriteFile(filepath.Join(ws, ".git", "index"), []byte("ignored"), 0o644)
+	c.AfterTool("shell_exec")
+
+	summary := c.Summary()
+	for _, s := range []string{"(3)", "- `built.out` — 新建 (+2 −0)，由 shell_exec", "- `edit.txt` — 修改，由 shell_exec", "- `gone.txt` — 删除，由 shell_exec"} {
+		if !strings.Contains(summary, s) {
+			t.Errorf("summary lacks %q:\n%s", s, summary)
+		}
+	}
+}
+
+func TestUnifiedHunks(t *testing.T) {
+	a := strings.Split("1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20", " ")
+	b := append([]string{}, a...)
+	b[1], b[17] = "two", "eighteen"
+	ops := diffLines(a, b)
+	if added, removed := diffStats(ops); added != 2 || removed != 2 {
+		t.Fatalf("stats = +%d -%d", added, removed)
+	}
+	out, truncated := unifiedHunks(ops, 3, 100)
+	if truncated || strings.Count(out, "@@ -") != 2 || !strings.HasPrefix(out, "@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n") ||
+		!strings.Contains(out, "@@ -15,6 +15,6 @@\n") {
+		t.Errorf("hunks:\n%s", out)
+	}
+	if out, truncated := unifiedHunks(ops, 3, 4); !truncated || strings.Count(out, "\n") != 5 {
+		t.Errorf("truncated hunks:\n%s", out)
+	}
+}
+
+func TestParseChangeMode(t *testing.T) {
+	for in, want := range map[string]ChangeMode{"": ChangesStats, "diff": ChangesDiff, "OFF": ChangesOff} {
+		if got, err := ParseChangeMode(in); got != want || err != nil {
+			t.Errorf("ParseChangeMode(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseChangeMode("full"); err == nil {
+		t.Error("invalid mode accepted")
+	}
+}
+
+func TestAnswerNode_AppendsChangedFiles(t *testing.T) {
+	ws := t.TempDir()
+	state := &AgentState{Changes: NewChangeTracker(ws, ChangesStats)}
+	state.Changes.BeforeTool("file_write", []byte(`{"path":"notes.md"}`))
+	os.WriteFile(filepath.Join(ws, "notes.md"), []byte("hello\n"), 0o644)
+
+	(&AnswerNodeImpl{}).Post(state, nil, AnswerResult{Answer: "已写入笔记。\n"})
+	want := "已写入笔记。\n\n### 📝 文件变更 (1)\n\n- `notes.md` — 新建 (+1 −0)\n"
+	if state.Solution != want {
+		t.Errorf("Solution = %q, want %q", state.Solution, want)
+	}
+}
@@ -0,0 +1,223 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/plan"
+)
+
+// Checkpoint is the persisted state of a run: the plan, the step history,
+// the scratchpad and the cost counters, saved after every step. It outlives
+// the process, so a run that was interrupted (timeout, cancel, restart or
+// crash) can be resumed with /resume.
+type Checkpoint struct {
+	SessionID   string          `json:"session_id"`
+	Problem     string          `json:"problem"`
+	AnswerStyle string          `json:"answer_style,omitempty"`
+	ToolProfile string          `json:"tool_profile,omitempty"`
+	Plan        []plan.PlanStep `json:"plan"`
+	Steps       []StepRecord    `json:"steps"`
+	Scratchpad  []ScratchEntry  `json:"scratchpad,omitempty"`
+	UsedTokens  int64           `json:"used_tokens,omitempty"` // CostGuard counters at the last step
+	ElapsedMs   int64           `json:"elapsed_ms,omitempty"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+
+	// Running is set on the checkpoints a live run saves after each step.
+	// A checkpoint still marked running when the process starts was left by
+	// a crash; CheckpointStore.Recover turns it into a Crashed one.
+	Running bool `json:"running,omitempty"`
+	Crashed bool `json:"crashed,omitempty"`
+}
+
+// NewCheckpoint captures the plan, step history and cost counters of a
+// running state. Returns nil when the run has neither a plan nor steps:
+// there is nothing to resume.
+func NewCheckpoint(sessionID string, state *AgentState) *Checkpoint {
+	var steps []plan.PlanStep
+	if state.PlanStore != nil && state.PlanSID != "" {
+		steps = state.PlanStore.Get(state.PlanSID)
+	}
+	if len(steps) == 0 && len(state.StepHistory) == 0 {
+		return nil
+	}
+	c := &Checkpoint{
+		SessionID:   sessionID,
+		Problem:     state.Problem,
+		AnswerStyle: state.AnswerStyle,
+		ToolProfile: state.ToolProfile,
+		Plan:        steps,
+		Steps:       append([]StepRecord(nil), state.StepHistory...),
+		Scratchpad:  state.Scratchpad.Entries(),
+		UpdatedAt:   time.Now(),
+	}
+	if state.CostGuard != nil {
+		c.UsedTokens = state.CostGuard.UsedTokens()
+		c.ElapsedMs = state.CostGuard.Elapsed().Milliseconds()
+	}
+	return c
+}
+
+// Unfinished reports whether the run can be continued: it crashed, or plan
+// steps are still pending or in progress.
+func (c *Checkpoint) Unfinished() bool {
+	if c.Crashed {
+		return true
+	}
+	for _, s := range c.Plan {
+		if s.Status == "pending" || s.Status == "in_progress" {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore prepares a new run of state to continue the checkpoint: the
+// original problem, the plan with open steps reconciled against the
+// workspace, the scratchpad, and a resume note summarizing the completed work. The note is
+// shown with the plan in every decide prompt. The step history itself is not
+// replayed, so the resumed run gets a full step budget; see RestoreCost for
+// the token and time budgets.
+func (c *Checkpoint) Restore(state *AgentState) {
+	state.Problem = c.Problem
+	state.AnswerStyle = c.AnswerStyle
+	state.ToolProfile = c.ToolProfile
+	if state.PlanStore != nil && state.PlanSID != "" && len(c.Plan) > 0 {
+		state.PlanStore.Set(state.PlanSID, c.Plan)
+		state.PlanStore.Reconcile(state.PlanSID, workspaceFileReader(state.WorkspaceDir), nil)
+	}
+	state.Scratchpad.Add(c.Scratchpad...)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[恢复运行] 这是一次中断运行（%s，共 %d 步）的继续。计划中标记为 done 的步骤已完成，不要重复；从第一个未完成的步骤继续。\n",
+		c.UpdatedAt.Format("2006-01-02 15:04"), len(c.Steps))
+	n := 0
+	for _, s := range c.Steps {
+		if s.Type != "tool" || skipAutoSummaryTools[s.ToolName] {
+			continue
+		}
+		n++
+		status := "ok"
+		if s.IsError {
+			status = "error"
+		}
+		fmt.Fprintf(&sb, "- 已执行 %s (%s): %s\n", s.ToolName, status, truncate(s.Output, 200))
+	}
+	if n == 0 {
+		sb.WriteString("- 中断前尚未执行任何工具\n")
+	}
+	state.ResumeNote = sb.String()
+}
+
+// RestoreCost carries the cost counters of a crashed run over to the
+// resumed run's guard, so that a crash does not reset its token and time
+// budgets. A run that stopped on its own (timeout, cancel) starts with
+// fresh budgets: it typically stopped because they ran out.
+func (c *Checkpoint) RestoreCost(g *CostGuard) {
+	if g == nil || !c.Crashed {
+		return
+	}
+	g.Resume(c.UsedTokens, time.Duration(c.ElapsedMs)*time.Millisecond)
+}
+
+// CheckpointStore keeps one checkpoint file per session in a directory
+// (.omega/checkpoints).
+type CheckpointStore struct {
+	dir string
+}
+
+// NewCheckpointStore creates the store, creating dir if needed.
+func NewCheckpointStore(dir string) (*CheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("checkpoint: create %s: %w", dir, err)
+	}
+	return &CheckpointStore{dir: dir}, nil
+}
+
+// path maps a session ID to its file. Session IDs come from clients, so the
+// name is a hash rather than the ID itself.
+func (s *CheckpointStore) path(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// Save writes the checkpoint of its session, replacing the previous one.
+// The file is replaced atomically so a crash never leaves a torn checkpoint.
+func (s *CheckpointStore) Save(c *Checkpoint) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	p := s.path(c.SessionID)
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("checkpoint: write: %w", err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("checkpoint: write: %w", err)
+	}
+	return nil
+}
+
+// Load returns the session's checkpoint, or (nil, nil) when there is none.
+func (s *CheckpointStore) Load(sessionID string) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.path(sessionID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint: read: %w", err)
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("checkpoint: parse: %w", err)
+	}
+	if c.SessionID != sessionID { // hash collision
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// Recover marks the checkpoints that are still flagged running as crashed
+// and returns them. Call it once at startup, before any run saves: a
+// running checkpoint then can only have been left by a process that died
+// mid-run.
+func (s *CheckpointStore) Recover() ([]*Checkpoint, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint: list: %w", err)
+	}
+	var crashed []*Checkpoint
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var c Checkpoint
+		if json.Unmarshal(data, &c) != nil || !c.Running {
+			continue
+		}
+		c.Running, c.Crashed = false, true
+		if err := s.Save(&c); err != nil {
+			return crashed, err
+		}
+		crashed = append(crashed, &c)
+	}
+	return crashed, nil
+}
+
+// Delete removes the session's checkpoint, if any.
+func (s *CheckpointStore) Delete(sessionID string) {
+	os.Remove(s.path(sessionID))
+}
@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/plan"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestCheckpointStore_SaveLoadDelete(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "checkpoints")
+	store, err := NewCheckpointStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp, err := store.Load("s1"); cp != nil || err != nil {
+		t.Fatalf("missing checkpoint: got %+v, %v", cp, err)
+	}
+
+	want := &Checkpoint{
+		SessionID: "../s1", // client-supplied IDs never become paths
+		Problem:   "build it",
+		Plan:      []plan.PlanStep{{ID: "a", Title: "A", Status: "done"}, {ID: "b", Title: "B", Status: "pending"}},
+		Steps:     []StepRecord{{StepNumber: 1, Type: "tool", ToolName: "file_write", Output: "ok"}},
+	}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || !strings.HasSuffix(entries[0].Name(), ".json") {
+		t.Fatalf("checkpoint files = %v", entries)
+	}
+	got, err := store.Load("../s1")
+	if err != nil || got == nil {
+		t.Fatalf("Load = %+v, %v", got, err)
+	}
+	if got.Problem != want.Problem || len(got.Plan) != 2 || len(got.Steps) != 1 || !got.Unfinished() {
+		t.Errorf("Load = %+v", got)
+	}
+	if cp, _ := store.Load("s2"); cp != nil {
+		t.Errorf("other session got %+v", cp)
+	}
+
+	store.Delete("../s1")
+	if cp, _ := store.Load("../s1"); cp != nil {
+		t.Errorf("after Delete: %+v", cp)
+	}
+}
+
+func TestNewCheckpoint(t *testing.T) {
+	store := plan.NewPlanStore()
+	state := &AgentState{Problem: "p", PlanStore: store, PlanSID: "s1"}
+	if cp := NewCheckpoint("s1", state); cp != nil {
+		t.Fatalf("run without plan or steps: got %+v", cp)
+	}
+	state.StepHistory = []StepRecord{{StepNumber: 1, Type: "decide"}}
+	if cp := NewCheckpoint("s1", state); cp == nil || len(cp.Plan) != 0 || len(cp.Steps) != 1 || cp.Unfinished() {
+		t.Fatalf("run without plan: got %+v", cp)
+	}
+	store.Set("s1", []plan.PlanStep{{ID: "a", Title: "A"}})
+	state.CostGuard = NewCostGuard(1000, 0)
+	state.CostGuard.RecordTokens(120)
+	cp := NewCheckpoint("s1", state)
+	if cp == nil || cp.Problem != "p" || len(cp.Plan) != 1 || len(cp.Steps) != 1 || cp.UsedTokens != 120 {
+		t.Fatalf("NewCheckpoint = %+v", cp)
+	}
+	state.StepHistory[0].Type = "tool"
+	if cp.Steps[0].Type != "decide" {
+		t.Error("checkpoint shares the step history slice")
+	}
+}
+
+func TestCheckpointStore_RecoverCrashedRuns(t *testing.T) {
+	store, _ := NewCheckpointStore(t.TempDir())
+	store.Save(&Checkpoint{SessionID: "live", Problem: "cut off", Running: true, UsedTokens: 500, ElapsedMs: 60_000,
+		Steps: []StepRecord{{StepNumber: 1, Type: "tool", ToolName: "shell_exec", Output: "ok"}}})
+	store.Save(&Checkpoint{SessionID: "kept", Problem: "timed out", Plan: []plan.PlanStep{{ID: "a", Status: "pending"}}})
+
+	crashed, err := store.Recover()
+	if err != nil || len(crashed) != 1 || crashed[0].SessionID != "live" {
+		t.Fatalf("Recover = %+v, %v", crashed, err)
+	}
+	cp, _ := store.Load("live")
+	if cp == nil || cp.Running || !cp.Crashed || !cp.Unfinished() {
+		t.Fatalf("recovered checkpoint = %+v", cp)
+	}
+	if again, _ := store.Recover(); len(again) != 0 {
+		t.Errorf("second Recover = %+v", again)
+	}
+	if kept, _ := store.Load("kept"); kept == nil || kept.Crashed {
+		t.Errorf("interrupted run = %+v, want untouched", kept)
+	}
+
+	// The resumed run continues the crashed run's budgets...
+	g := NewCostGuard(1000, time.Hour)
+	cp.RestoreCost(g)
+	if g.UsedTokens() != 500 || g.Elapsed() < time.Minute {
+		t.Errorf("restored guard: %d tokens, %v elapsed", g.UsedTokens(), g.Elapsed())
+	}
+	// ...while one resumed after a timeout or cancel starts afresh.
+	g = NewCostGuard(1000, time.Hour)
+	(&Checkpoint{UsedTokens: 900}).RestoreCost(g)
+	if g.UsedTokens() != 0 {
+		t.Errorf("interrupted run restored %d tokens", g.UsedTokens())
+	}
+}
+
+func TestCheckpointRestore(t *testing.T) {
+	ws := t.TempDir()
+	os.WriteFile(filepath.Join(ws, "out.txt"), []byte("x"), 0o644)
+	cp := &Checkpoint{
+		SessionID:   "s1",
+		Problem:     "write out.txt, then test",
+		AnswerStyle: "concise",
+		Plan: []plan.PlanStep{
+			{ID: "write", Title: "Write out.txt", Status: "in_progress", Check: &plan.StepCheck{FileExists: "out.txt"}},
+			{ID: "test", Title: "Run tests", Status: "pending"},
+		},
+		Steps: []StepRecord{
+			{StepNumber: 1, Type: "tool", ToolName: "update_plan", Output: "plan set"},
+			{StepNumber: 2, Type: "tool", ToolName: "file_write", Output: "wrote out.txt"},
+		},
+	}
+	store := plan.NewPlanStore()
+	state := &AgentState{WorkspaceDir: ws, PlanStore: store, PlanSID: "s1", ToolRegistry: tool.NewRegistry()}
+	cp.Restore(state)
+
+	if state.Problem != cp.Problem || state.AnswerStyle != "concise" || len(state.StepHistory) != 0 {
+		t.Errorf("state = problem %q style %q steps %d", state.Problem, state.AnswerStyle, len(state.StepHistory))
+	}
+	steps := store.Get("s1")
+	if steps[0].Status != "done" || steps[1].Status != "pending" {
+		t.Errorf("restored plan = %+v, want write done (file exists), test pending", steps)
+	}
+	if !strings.Contains(state.ResumeNote, "file_write (ok): wrote out.txt") || strings.Contains(state.ResumeNote, "update_plan") {
+		t.Errorf("ResumeNote = %q", state.ResumeNote)
+	}
+
+	prep := NewDecideNode(&mockLLMProvider{}, nil).Prep(state)
+	if len(prep) != 1 || !strings.Contains(prep[0].PlanText, "[恢复运行]") {
+		t.Errorf("decide prompt lacks the resume note: %+v", prep)
+	}
+}
@@ -0,0 +1,124 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/pocketomega/pocket-omega/internal/core"
+)
+
+// DefaultCompactRatio is the fraction of ContextWindowTokens that
+// ConversationHistory plus the step summary may fill before the oldest
+// conversation turns are compacted.
+const DefaultCompactRatio = 0.6
+
+// CompactFunc folds the oldest conversation turns of the session into its
+// summary and returns the rebuilt conversation history together with the
+// number of turns folded (0 = nothing to compact).
+type CompactFunc func(ctx context.Context) (history string, turns int, err error)
+
+// CompactNodeImpl implements BaseNode[AgentState, CompactPrep, CompactResult].
+// It folds the oldest conversation turns into an LLM summary (via the
+// handler's OnContextOverflow callback, which owns the session store) and
+// records a "compact" marker step. Tool and think steps route here with
+// ActionCompact only when needed; the flow also starts here, so a long
+// history is compacted before the first decision.
+type CompactNodeImpl struct{}
+
+func NewCompactNode() *CompactNodeImpl { return &CompactNodeImpl{} }
+
+// Prep returns no work unless the context needs compacting.
+func (n *CompactNodeImpl) Prep(state *AgentState) []CompactPrep {
+	if !needsCompact(state) {
+		return nil
+	}
+	return []CompactPrep{{
+		Tokens:  contextTokens(state),
+		Compact: state.OnContextOverflow,
+	}}
+}
+
+// Exec runs the compaction callback.
+func (n *CompactNodeImpl) Exec(ctx context.Context, prep CompactPrep) (CompactResult, error) {
+	history, turns, err := prep.Compact(ctx)
+	if err != nil {
+		return CompactResult{}, err
+	}
+	return CompactResult{History: history, Turns: turns}, nil
+}
+
+// ExecFallback records the failure; compaction is best-effort.
+func (n *CompactNodeImpl) ExecFallback(err error) CompactResult {
+	return CompactResult{Err: err}
+}
+
+// Post swaps in the compacted history, records the marker step and routes
+// to DecideNode. Compaction is attempted at most once per run: the session
+// turns do not change while the run is in progress.
+func (n *CompactNodeImpl) Post(state *AgentState, prep []CompactPrep, results ...CompactResult) core.Action {
+	if len(results) == 0 {
+		return core.ActionDefault
+	}
+	state.compactTried = true
+	state.pendingCompact = false
+
+	result := results[0]
+	if result.Err != nil {
+		log.Printf("[ContextGuard] Auto-compact failed: %v", result.Err)
+		return core.ActionDefault
+	}
+	if result.Turns == 0 {
+		log.Printf("[ContextGuard] Nothing to compact (~%d tokens)", prep[0].Tokens)
+		return core.ActionDefault
+	}
+
+	state.ConversationHistory = result.History
+	step := StepRecord{
+		StepNumber: len(state.StepHistory) + 1,
+		Type:       "compact",
+		Action:     "compacted",
+		Output: fmt.Sprintf("已将 %d 轮较早对话压缩为摘要（上下文约 %d → %d tokens）",
+			result.Turns, prep[0].Tokens, contextTokens(state)),
+	}
+	state.StepHistory = append(state.StepHistory, step)
+	if state.OnStepComplete != nil {
+		state.OnStepComplete(step)
+	}
+	log.Printf("[ContextGuard] %s", step.Output)
+	return core.ActionDefault // Back to DecideNode
+}
+
+// compactRoute is the action of a node returning to DecideNode: through
+// CompactNode when the context needs compacting first.
+func compactRoute(state *AgentState) core.Action {
+	if needsCompact(state) {
+		return core.ActionCompact
+	}
+	return core.ActionDefault
+}
+
+// needsCompact reports whether ConversationHistory plus the step summary
+// has reached CompactRatio of the context window, or DecideNode's full
+// prompt check scheduled a compaction.
+func needsCompact(state *AgentState) bool {
+	if state.OnContextOverflow == nil || state.compactTried {
+		return false
+	}
+	if state.pendingCompact {
+		return true
+	}
+	if state.ContextWindowTokens <= 0 || state.ConversationHistory == "" {
+		return false
+	}
+	ratio := state.CompactRatio
+	if ratio <= 0 || ratio >= 1 {
+		ratio = DefaultCompactRatio
+	}
+	return float64(contextTokens(state)) >= ratio*float64(state.ContextWindowTokens)
+}
+
+// contextTokens estimates the tokens of ConversationHistory plus the step summary.
+func contextTokens(state *AgentState) int {
+	return estimateTokens(state.ConversationHistory + buildStepSummary(state.StepHistory, state.ContextWindowTokens))
+}
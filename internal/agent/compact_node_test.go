@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/core"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestCompactNode_CompactsBeforeFirstDecision(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{
+		"```yaml\naction: \"answer\"\nreason: \"done\"\nanswer: \"ok\"\n```",
+		"ok",
+	}}
+	reg := tool.NewRegistry()
+	var calls int
+	var steps []StepRecord
+	state := &AgentState{
+		Problem:             "x",
+		ToolCallMode:        "yaml",
+		ThinkingMode:        "native",
+		ToolRegistry:        reg,
+		ContextWindowTokens: 2000,
+		ConversationHistory: strings.Repeat("很早以前的对话", 500), // ~1750 tokens ≥ 0.6 × 2000
+		OnContextOverflow: func(context.Context) (string, int, error) {
+			calls++
+			return "[摘要] 早先讨论了部署", 4, nil
+		},
+		OnStepComplete: func(s StepRecord) { steps = append(steps, s) },
+	}
+	BuildAgentFlow(mock, reg, "native", nil).Run(context.Background(), state)
+
+	if calls != 1 {
+		t.Fatalf("compaction calls = %d, want 1", calls)
+	}
+	if state.ConversationHistory != "[摘要] 早先讨论了部署" {
+		t.Errorf("history not replaced: %q", state.ConversationHistory)
+	}
+	if len(steps) == 0 || steps[0].Type != "compact" || steps[0].Action != "compacted" || !strings.Contains(steps[0].Output, "4 轮") {
+		t.Fatalf("first step = %+v, want compact marker", steps)
+	}
+	prompt := mock.calls[0][len(mock.calls[0])-1].Content
+	if strings.Contains(prompt, "很早以前的对话") || !strings.Contains(prompt, "[摘要]") {
+		t.Errorf("decide prompt still carries the old history:\n%s", prompt)
+	}
+}
+
+func TestCompactNode_Triggers(t *testing.T) {
+	noop := func(context.Context) (string, int, error) { return "", 0, nil }
+	history := strings.Repeat("对话", 1000) // ~1000 tokens
+
+	for _, tc := range []struct {
+		name  string
+		state AgentState
+		want  core.Action
+	}{
+		{"no callback", AgentState{ContextWindowTokens: 1000, ConversationHistory: history}, core.ActionDefault},
+		{"over ratio", AgentState{ContextWindowTokens: 1500, ConversationHistory: history, OnContextOverflow: noop}, core.ActionCompact},
+		{"custom ratio", AgentState{ContextWindowTokens: 1500, CompactRatio: 0.9, ConversationHistory: history, OnContextOverflow: noop}, core.ActionDefault},
+		{"decide scheduled", AgentState{pendingCompact: true, OnContextOverflow: noop}, core.ActionCompact},
+		{"already tried", AgentState{compactTried: true, pendingCompact: true, OnContextOverflow: noop}, core.ActionDefault},
+	} {
+		if got := compactRoute(&tc.state); got != tc.want {
+			t.Errorf("%s: compactRoute = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	// Nothing to compact: no marker step, and no retry later in the run.
+	state := &AgentState{pendingCompact: true, OnContextOverflow: noop, ConversationHistory: "h"}
+	node := core.NewNode[AgentState, CompactPrep, CompactResult](NewCompactNode(), 0)
+	node.Run(context.Background(), state)
+	if len(state.StepHistory) != 0 || state.ConversationHistory != "h" || needsCompact(state) {
+		t.Errorf("state after empty compaction = %+v", state)
+	}
+}
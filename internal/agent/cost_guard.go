@@ -15,6 +15,12 @@ type CostGuard struct {
 	usedTokens  atomic.Int64
 	startTime   time.Time
 	exceeded    bool // single-goroutine: set by Exec/Prep, read by Post
+
+	// Downshift: once usedTokens reaches downshiftAt, TakeDownshift reports
+	// true exactly once so the loop can switch to a cheaper decide model.
+	downshiftAt  int64 // 0 = disabled
+	downshiftDue bool  // single-goroutine: set by Exec, consumed by Post
+	downshifted  bool
 }
 
 // NewCostGuard creates a cost guard with optional token and duration limits.
@@ -35,6 +41,9 @@ func (g *CostGuard) RecordTokens(n int) error {
 		return nil
 	}
 	total := g.usedTokens.Add(int64(n))
+	if g.downshiftAt > 0 && !g.downshifted && total >= g.downshiftAt {
+		g.downshiftDue = true
+	}
 	if total > g.maxTokens {
 		g.exceeded = true
 		return fmt.Errorf("token budget exceeded: used %d / limit %d", total, g.maxTokens)
@@ -59,5 +68,55 @@ func (g *CostGuard) CheckDuration() error {
 // IsExceeded returns true if any budget/duration limit has been exceeded.
 func (g *CostGuard) IsExceeded() bool { return g.exceeded }
 
+// HasHeadroom reports whether less than ratio of the token and duration
+// budgets is used and no limit has been exceeded. Disabled limits always
+// have headroom.
+func (g *CostGuard) HasHeadroom(ratio float64) bool {
+	if g.exceeded {
+		return false
+	}
+	if g.maxTokens > 0 && float64(g.UsedTokens()) >= ratio*float64(g.maxTokens) {
+		return false
+	}
+	if g.maxDuration > 0 && time.Since(g.startTime) >= time.Duration(ratio*float64(g.maxDuration)) {
+		return false
+	}
+	return true
+}
+
+// EnableDownshift arms the downshift threshold at ratio × maxTokens
+// (e.g. 0.8). No-op when the token budget is disabled or ratio is not in (0, 1).
+func (g *CostGuard) EnableDownshift(ratio float64) {
+	if g.maxTokens <= 0 || ratio <= 0 || ratio >= 1 {
+		return
+	}
+	g.downshiftAt = int64(float64(g.maxTokens) * ratio)
+}
+
+// TakeDownshift reports whether the downshift threshold has been crossed.
+// Returns true at most once per guard; later calls return false.
+func (g *CostGuard) TakeDownshift() bool {
+	if !g.downshiftDue || g.downshifted {
+		return false
+	}
+	g.downshiftDue = false
+	g.downshifted = true
+	return true
+}
+
+// Elapsed returns the run time counted against the duration limit.
+func (g *CostGuard) Elapsed() time.Duration { return time.Since(g.startTime) }
+
+// Resume continues the counters of an earlier run of the same task: its
+// tokens count against the budget and its run time against the limit.
+// Call before the run starts.
+func (g *CostGuard) Resume(usedTokens int64, elapsed time.Duration) {
+	g.usedTokens.Add(usedTokens)
+	g.startTime = g.startTime.Add(-elapsed)
+}
+
+// MaxTokens returns the token budget (0 = disabled).
+func (g *CostGuard) MaxTokens() int64 { return g.maxTokens }
+
 // UsedTokens returns the total tokens consumed so far.
 func (g *CostGuard) UsedTokens() int64 { return g.usedTokens.Load() }
@@ -3,11 +3,11 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/pocketomega/pocket-omega/internal/core"
 	"github.com/pocketomega/pocket-omega/internal/llm"
@@ -25,9 +25,22 @@ func NewDecideNode(provider llm.LLMProvider, loader *prompt.PromptLoader) *Decid
 	return &DecideNode{llmProvider: provider, loader: loader}
 }
 
+// provider returns the LLM used for this decision: the downshift model when
+// prep carries one, otherwise the node's configured provider.
+func (n *DecideNode) provider(prep DecidePrep) llm.LLMProvider {
+	if prep.Provider != nil {
+		return prep.Provider
+	}
+	return n.llmProvider
+}
+
 // Prep reads the current AgentState and builds context for LLM decision.
 func (n *DecideNode) Prep(state *AgentState) []DecidePrep {
-	stepSummary := buildStepSummary(state.StepHistory, state.ContextWindowTokens)
+	summaryWindow := state.ContextWindowTokens
+	if state.Downshift != nil {
+		summaryWindow = downshiftSummaryWindow(summaryWindow)
+	}
+	stepSummary := buildStepSummary(state.StepHistory, summaryWindow)
 
 	// Only compute what's needed for the selected tool-call mode.
 	var toolsPrompt string
@@ -35,8 +48,14 @@ func (n *DecideNode) Prep(state *AgentState) []DecidePrep {
 	switch state.ToolCallMode {
 	case "fc":
 		toolDefs = state.ToolRegistry.GenerateToolDefinitions()
-	case "yaml":
+		if canAsk(state) {
+			toolDefs = append(toolDefs, askUserToolDef)
+		}
+	case "yaml", "json":
+		// Definitions are not sent to the model; they validate tool_name
+		// and tool_params.
 		toolsPrompt = state.ToolRegistry.GenerateToolsPrompt()
+		toolDefs = state.ToolRegistry.GenerateToolDefinitions()
 	default: // "auto" — might need either
 		toolsPrompt = state.ToolRegistry.GenerateToolsPrompt()
 		toolDefs = state.ToolRegistry.GenerateToolDefinitions()
@@ -85,6 +104,7 @@ func (n *DecideNode) Prep(state *AgentState) []DecidePrep {
 		ToolsPrompt:         toolsPrompt,
 		ToolDefinitions:     toolDefs,
 		StepCount:           len(state.StepHistory),
+		StepBudget:          state.StepBudget(),
 		ThinkingMode:        state.ThinkingMode,
 		ToolCallMode:        state.ToolCallMode,
 		ConversationHistory: state.ConversationHistory,
@@ -93,8 +113,22 @@ func (n *DecideNode) Prep(state *AgentState) []DecidePrep {
 		HasMCPIntent:        hasMCPIntent,
 		ContextWindowTokens: state.ContextWindowTokens,
 		LoopDetected:        (&LoopDetector{}).Check(state.StepHistory),
-		ExplorationDetected: (&ExplorationDetector{}).Check(state.StepHistory, MaxAgentSteps),
+		ExplorationDetected: (&ExplorationDetector{}).Check(state.StepHistory, state.StepBudget()),
 		CostGuard:           state.CostGuard, // pointer shared for Exec to record tokens
+		AnswerStyle:         state.AnswerStyle,
+		Images:              state.Images,
+		CanAsk:              canAsk(state),
+	}
+	if state.Downshift != nil {
+		prep.Provider = state.DownshiftProvider
+	}
+	if toolsPrompt != "" {
+		prep.YAMLParseFailures = state.YAMLParseFailures
+		lastTool := ""
+		if last := lastToolStep(state.StepHistory); last != nil {
+			lastTool = last.ToolName
+		}
+		prep.DecisionExamples = selectDecisionExamples(state.ToolRegistry, lastTool)
 	}
 
 	// Read walkthrough memo for prompt injection
@@ -102,9 +136,15 @@ func (n *DecideNode) Prep(state *AgentState) []DecidePrep {
 		prep.WalkthroughText = state.WalkthroughStore.Render(state.WalkthroughSID)
 	}
 
+	// Think artifacts, shown verbatim
+	prep.ScratchpadText = state.Scratchpad.Render()
+
 	// Read plan status for prompt injection
 	if state.PlanStore != nil && state.PlanSID != "" {
 		prep.PlanText = state.PlanStore.Render(state.PlanSID)
+		if state.ResumeNote != "" {
+			prep.PlanText += "\n" + state.ResumeNote
+		}
 	}
 
 	// MetaToolGuard redirect: consume the redirect message set by Post and
@@ -115,15 +155,26 @@ func (n *DecideNode) Prep(state *AgentState) []DecidePrep {
 		state.MetaToolRedirectMsg = ""
 	}
 
+	// Step annotations sent from the UI while the run is ongoing
+	consumeAnnotations(state)
+	prep.Corrections = renderCorrections(state.Corrections, state.StepHistory)
+	// A failed self-review of the last answer: fix it before answering again
+	if state.Verify != nil && state.Verify.Feedback != "" {
+		prep.Corrections += state.Verify.Feedback
+	}
+	if state.ReviewCritique != "" {
+		prep.Corrections += state.ReviewCritique
+	}
+
 	// Estimate system prompt size for CostGuard + ContextGuard accuracy.
 	// buildSystemPrompt needs the full prep, so we compute after construction.
 	// Use the mode that will be used in Exec ("fc" for FC, thinkingMode for YAML).
 	mode := state.ThinkingMode
-	isFC := state.ToolCallMode == "fc" || (state.ToolCallMode == "auto" && n.llmProvider.IsToolCallingEnabled())
+	isFC := state.ToolCallMode == "fc" || (state.ToolCallMode == "auto" && n.provider(prep).IsToolCallingEnabled())
 	if isFC {
 		mode = "fc"
 	}
-	prep.SystemPromptEst = estimateTokens(n.buildSystemPrompt(mode, prep))
+	prep.SystemPromptEst = estimateTokens(n.buildSystemPrompt(mode, prep)) + len(prep.Images)*imageTokenEstimate
 
 	// FC mode: tool definitions are sent as structured JSON alongside messages,
 	// adding ~5-15% to actual token usage. Estimate from serialized form.
@@ -141,9 +192,13 @@ func (n *DecideNode) Prep(state *AgentState) []DecidePrep {
 //   - "fc":   forced FC, failure returns error (no downgrade)
 //   - "auto": detect capability, FC with auto-downgrade to YAML on failure
 //   - "yaml": forced YAML (original behavior)
+//   - "json": fenced JSON object with strict schema validation
 func (n *DecideNode) Exec(ctx context.Context, prep DecidePrep) (Decision, error) {
 	var decision Decision
 	var err error
+	// Identical decision contexts may reuse a cached response when the model
+	// is deterministic (LLM_CACHE with temperature 0)
+	ctx = llm.WithCachePolicy(llm.WithModelRole(ctx, llm.ModelDecide), llm.CacheDeterministic)
 
 	switch prep.ToolCallMode {
 	case "fc":
@@ -151,7 +206,7 @@ func (n *DecideNode) Exec(ctx context.Context, prep DecidePrep) (Decision, error
 		decision, err = n.execWithFC(ctx, prep)
 
 	case "auto":
-		if n.llmProvider.IsToolCallingEnabled() {
+		if n.provider(prep).IsToolCallingEnabled() {
 			log.Printf("[Decide] Using FC path (auto-detected)")
 			decision, err = n.execWithFC(ctx, prep)
 			if err != nil {
@@ -163,6 +218,10 @@ func (n *DecideNode) Exec(ctx context.Context, prep DecidePrep) (Decision, error
 			decision, err = n.execWithYAML(ctx, prep)
 		}
 
+	case "json":
+		log.Printf("[Decide] Using JSON path")
+		decision, err = n.execWithJSON(ctx, prep)
+
 	default: // explicit "yaml" or any unrecognised value
 		if prep.ToolCallMode != "yaml" {
 			log.Printf("[Decide] WARNING: unrecognised ToolCallMode %q, falling back to YAML", prep.ToolCallMode)
@@ -192,7 +251,7 @@ func (n *DecideNode) Exec(ctx context.Context, prep DecidePrep) (Decision, error
 		// Include SystemPromptEst to avoid underestimating by ~20-25%
 		contentTokens := prep.SystemPromptEst +
 			estimateTokens(prep.StepSummary+prep.ToolsPrompt+prep.ConversationHistory+
-				prep.Problem+prep.ToolingSummary+prep.WalkthroughText+prep.PlanText)
+				prep.Problem+prep.ToolingSummary+prep.WalkthroughText+prep.ScratchpadText+prep.PlanText+prep.Corrections)
 		switch guard.CheckTokens(contentTokens) {
 		case ContextWarning:
 			log.Printf("[ContextGuard] Context at ~70%%, consider /compact")
@@ -209,9 +268,9 @@ func (n *DecideNode) Exec(ctx context.Context, prep DecidePrep) (Decision, error
 func (n *DecideNode) execWithFC(ctx context.Context, prep DecidePrep) (Decision, error) {
 	prompt := buildDecidePromptFC(prep)
 
-	resp, err := n.llmProvider.CallLLMWithTools(ctx, []llm.Message{
+	resp, err := n.provider(prep).CallLLMWithTools(ctx, []llm.Message{
 		{Role: llm.RoleSystem, Content: n.buildSystemPrompt("fc", prep)},
-		{Role: llm.RoleUser, Content: prompt},
+		{Role: llm.RoleUser, Content: prompt, Parts: prep.Images},
 	}, prep.ToolDefinitions)
 	if err != nil {
 		return Decision{}, fmt.Errorf("FC call failed: %w", err)
@@ -242,6 +301,15 @@ func (n *DecideNode) execWithFC(ctx context.Context, prep DecidePrep) (Decision,
 			return Decision{}, fmt.Errorf("invalid tool params from FC: %w", err)
 		}
 
+		// ask_user is not a registered tool: it is the FC form of action=ask
+		if tc.Name == askUserToolName {
+			question, _ := params["question"].(string)
+			if strings.TrimSpace(question) == "" {
+				return Decision{}, fmt.Errorf("FC %s call without a question", askUserToolName)
+			}
+			return Decision{Action: "ask", Reason: truncate(strings.TrimSpace(resp.Content), 200), Question: question}, nil
+		}
+
 		// Extract reasoning from Content if model provided it alongside tool calls
 		reason := strings.TrimSpace(resp.Content)
 		if reason == "" {
@@ -279,42 +347,160 @@ func (n *DecideNode) execWithFC(ctx context.Context, prep DecidePrep) (Decision,
 }
 
 // execWithYAML uses the original YAML text parsing to extract decisions.
+// Once the run has accumulated yamlRepairThreshold parse failures, a failed
+// parse gets one repair round with few-shot examples before the output is
+// taken as a direct answer. A decision that parses but breaks the schema
+// (validateDecision) always gets one repair round with the errors; a
+// second invalid decision fails the call so ExecFallback answers.
 func (n *DecideNode) execWithYAML(ctx context.Context, prep DecidePrep) (Decision, error) {
-	userPrompt := buildDecidePrompt(prep)
-
-	resp, err := n.llmProvider.CallLLM(ctx, []llm.Message{
+	messages := []llm.Message{
 		{Role: llm.RoleSystem, Content: n.buildSystemPrompt(prep.ThinkingMode, prep)},
-		{Role: llm.RoleUser, Content: userPrompt},
-	})
+		{Role: llm.RoleUser, Content: buildDecidePrompt(prep), Parts: prep.Images},
+	}
+
+	resp, err := n.provider(prep).CallLLM(ctx, messages)
 	if err != nil {
 		return Decision{}, fmt.Errorf("decide LLM call failed: %w", err)
 	}
 
 	decision, err := parseDecision(resp.Content)
-	if err != nil {
-		content := strings.TrimSpace(resp.Content)
-
-		// Model returned native FC tokens (e.g. K2.5's <|tool_calls_section_begin|>)
-		// Strip the FC tokens and use the natural language portion as answer
-		if strings.Contains(content, "<|tool_calls_section_begin|>") {
-			parts := strings.SplitN(content, "<|tool_calls_section_begin|>", 2)
-			cleaned := strings.TrimSpace(parts[0])
-			if len(cleaned) > 0 {
-				log.Printf("[Decide] Stripped native FC tokens, using text as answer: %s", truncate(cleaned, 80))
-				return Decision{Action: "answer", Answer: cleaned}, nil
-			}
-			log.Printf("[Decide] Native FC tokens with no text content, falling back")
-			return Decision{}, fmt.Errorf("parse decision failed: model returned native FC tokens without text")
+	if err == nil {
+		if err = validateDecision(decision, prep.ToolDefinitions); err == nil {
+			return decision, nil
+		}
+		log.Printf("[Decide] YAML decision breaks the schema, requesting repair: %v", err)
+		messages = append(messages,
+			llm.Message{Role: llm.RoleAssistant, Content: resp.Content},
+			llm.Message{Role: llm.RoleUser, Content: buildYAMLSchemaRepairPrompt(err, prep.DecisionExamples)},
+		)
+		repaired, callErr := n.provider(prep).CallLLM(ctx, messages)
+		if callErr != nil {
+			return Decision{}, fmt.Errorf("decide LLM repair call failed: %w", callErr)
 		}
+		if decision, err = parseDecision(repaired.Content); err == nil {
+			err = validateDecision(decision, prep.ToolDefinitions)
+		}
+		if err != nil {
+			return Decision{}, fmt.Errorf("YAML decision still invalid after repair: %w", err)
+		}
+		log.Printf("[Decide] YAML schema repair succeeded")
+		return decision, nil
+	}
+	failures := 1
+	content := strings.TrimSpace(resp.Content)
+
+	if prep.YAMLParseFailures+failures >= yamlRepairThreshold && len(prep.DecisionExamples) > 0 {
+		log.Printf("[Decide] YAML parse failed (%d this run), requesting repair with examples: %v",
+			prep.YAMLParseFailures+failures, err)
+		messages = append(messages,
+			llm.Message{Role: llm.RoleAssistant, Content: resp.Content},
+			llm.Message{Role: llm.RoleUser, Content: buildYAMLRepairPrompt(err, prep.DecisionExamples)},
+		)
+		repaired, callErr := n.provider(prep).CallLLM(ctx, messages)
+		if callErr != nil {
+			return Decision{}, fmt.Errorf("decide LLM repair call failed: %w", callErr)
+		}
+		if decision, err = parseDecision(repaired.Content); err == nil {
+			log.Printf("[Decide] YAML repair succeeded")
+			decision.ParseFailures = failures
+			return decision, nil
+		}
+		failures++
+		if c := strings.TrimSpace(repaired.Content); c != "" {
+			content = c
+		}
+	}
 
-		// If LLM returned natural language instead of YAML, treat it as a direct answer
-		if len(content) > 0 && !strings.HasPrefix(content, "```") {
-			log.Printf("[Decide] YAML parse failed, treating as direct answer: %s", truncate(content, 80))
-			return Decision{Action: "answer", Answer: content}, nil
+	// Model returned native FC tokens (e.g. K2.5's <|tool_calls_section_begin|>)
+	// Strip the FC tokens and use the natural language portion as answer
+	if strings.Contains(content, "<|tool_calls_section_begin|>") {
+		parts := strings.SplitN(content, "<|tool_calls_section_begin|>", 2)
+		cleaned := strings.TrimSpace(parts[0])
+		if len(cleaned) > 0 {
+			log.Printf("[Decide] Stripped native FC tokens, using text as answer: %s", truncate(cleaned, 80))
+			return Decision{Action: "answer", Answer: cleaned, ParseFailures: failures}, nil
 		}
-		return Decision{}, fmt.Errorf("parse decision failed: %w", err)
+		log.Printf("[Decide] Native FC tokens with no text content, falling back")
+		return Decision{}, fmt.Errorf("parse decision failed: model returned native FC tokens without text")
+	}
+
+	// If LLM returned natural language instead of YAML, treat it as a direct answer
+	if len(content) > 0 && !strings.HasPrefix(content, "```") {
+		log.Printf("[Decide] YAML parse failed, treating as direct answer: %s", truncate(content, 80))
+		return Decision{Action: "answer", Answer: content, ParseFailures: failures}, nil
+	}
+	return Decision{}, fmt.Errorf("parse decision failed: %w", err)
+}
+
+// buildYAMLRepairPrompt asks the model to re-emit its decision as valid YAML,
+// showing known-good decisions for the active tools.
+func buildYAMLRepairPrompt(parseErr error, examples []string) string {
+	return fmt.Sprintf("上一条回复无法解析为 YAML 决策：%v\n"+
+		"请参考以下格式正确的示例，只输出修正后的 ```yaml 代码块，不要输出其他内容。"+
+		"如果你要给出最终回答，请使用 action: \"answer\" 并把回答放在 answer 字段中。\n\n%s",
+		parseErr, formatDecisionExamples(examples))
+}
+
+// buildYAMLSchemaRepairPrompt feeds the schema errors of a decision back
+// to the model, with known-good decisions when there are any.
+func buildYAMLSchemaRepairPrompt(schemaErr error, examples []string) string {
+	var sb strings.Builder
+	sb.WriteString("上一条 YAML 决策不符合格式要求，错误如下（field 为出错字段，hint 为修改建议）：\n")
+	var se *decisionSchemaError
+	if errors.As(schemaErr, &se) {
+		b, _ := json.MarshalIndent(se.Issues, "", "  ")
+		sb.WriteString("```json\n" + string(b) + "\n```\n")
+	} else {
+		sb.WriteString(schemaErr.Error() + "\n")
+	}
+	sb.WriteString("请修正以上问题，只输出修正后的 ```yaml 代码块，不要输出其他内容。")
+	if len(examples) > 0 {
+		sb.WriteString("格式正确的示例：\n\n")
+		sb.WriteString(formatDecisionExamples(examples))
+	}
+	return sb.String()
+}
+
+// execWithJSON asks for a fenced JSON decision and validates it strictly.
+// On a schema or syntax error the model gets one repair round with the exact
+// error, instead of the YAML path's silent fallback to a direct answer.
+func (n *DecideNode) execWithJSON(ctx context.Context, prep DecidePrep) (Decision, error) {
+	messages := []llm.Message{
+		{Role: llm.RoleSystem, Content: n.buildSystemPrompt(prep.ThinkingMode, prep)},
+		{Role: llm.RoleUser, Content: buildDecidePrompt(prep), Parts: prep.Images},
 	}
 
+	resp, err := n.provider(prep).CallLLM(ctx, messages)
+	if err != nil {
+		return Decision{}, fmt.Errorf("decide LLM call failed: %w", err)
+	}
+
+	decision, err := parseDecisionJSON(resp.Content, prep.ToolDefinitions)
+	if err == nil {
+		return decision, nil
+	}
+
+	// Plain prose without any JSON object: treat as a direct answer (same as YAML path).
+	content := strings.TrimSpace(resp.Content)
+	if len(content) > 0 && !strings.Contains(content, "{") && !strings.HasPrefix(content, "```") {
+		log.Printf("[Decide] No JSON object, treating as direct answer: %s", truncate(content, 80))
+		return Decision{Action: "answer", Answer: content}, nil
+	}
+
+	log.Printf("[Decide] JSON decision invalid, requesting repair: %v", err)
+	messages = append(messages,
+		llm.Message{Role: llm.RoleAssistant, Content: resp.Content},
+		llm.Message{Role: llm.RoleUser, Content: fmt.Sprintf(
+			"上一条回复的 JSON 决策无效：%v\n请只输出修正后的 JSON 对象（放在 ```json 代码块中），不要输出其他内容。", err)},
+	)
+	resp, err = n.provider(prep).CallLLM(ctx, messages)
+	if err != nil {
+		return Decision{}, fmt.Errorf("decide LLM repair call failed: %w", err)
+	}
+	decision, err = parseDecisionJSON(resp.Content, prep.ToolDefinitions)
+	if err != nil {
+		return Decision{}, fmt.Errorf("parse JSON decision failed after repair: %w", err)
+	}
 	return decision, nil
 }
 
@@ -328,6 +514,10 @@ func (n *DecideNode) Post(state *AgentState, prep []DecidePrep, results ...Decis
 
 	// Write transient field for downstream nodes
 	state.LastDecision = &decision
+	state.YAMLParseFailures += decision.ParseFailures
+	if decision.LLMError != "" {
+		state.LLMError = decision.LLMError
+	}
 
 	// Record step
 	step := StepRecord{
@@ -335,8 +525,13 @@ func (n *DecideNode) Post(state *AgentState, prep []DecidePrep, results ...Decis
 		Type:       "decide",
 		Action:     decision.Action,
 		Input:      decision.Reason,
+		Model:      stepModel(state, n.llmProvider, llm.ModelDecide),
+	}
+	if len(prep) > 0 && prep[0].Provider != nil && state.DownshiftModel != "" {
+		step.Model = state.DownshiftModel
 	}
 	state.StepHistory = append(state.StepHistory, step)
+	state.Replay.RecordDecision(step.StepNumber, decision)
 
 	if state.OnStepComplete != nil {
 		state.OnStepComplete(step)
@@ -361,31 +556,36 @@ func (n *DecideNode) Post(state *AgentState, prep []DecidePrep, results ...Decis
 		}
 	}
 
+	// Step budget: a planned run whose plan outgrows the remaining steps
+	// gets one extension while CostGuard has headroom
+	maybeExtendStepBudget(state, step.StepNumber)
+
 	// Force termination if too many steps
-	if len(state.StepHistory) >= MaxAgentSteps {
-		log.Printf("[Decide] Max steps reached (%d), forcing answer", MaxAgentSteps)
+	if budget := state.StepBudget(); len(state.StepHistory) >= budget {
+		log.Printf("[Decide] Max steps reached (%d), forcing answer", budget)
+		state.ForcedAnswer = ReasonMaxSteps
 		return core.ActionAnswer
 	}
 
+	// CostGuard: near the token budget, switch remaining decide steps to the
+	// cheaper downshift model instead of running into the hard limit.
+	if state.CostGuard != nil && state.DownshiftProvider != nil && state.Downshift == nil &&
+		state.CostGuard.TakeDownshift() {
+		applyDownshift(state, step.StepNumber)
+	}
+
 	// CostGuard: force answer if budget/duration exceeded (highest priority)
 	if state.CostGuard != nil && state.CostGuard.IsExceeded() {
 		log.Printf("[CostGuard] Budget/duration exceeded, forcing answer")
+		state.ForcedAnswer = ReasonBudget
 		return core.ActionAnswer
 	}
 
-	// ContextGuard: transfer Decision.ContextStatus → state.pendingCompact,
-	// then execute auto-compact if needed (non-fatal on failure).
+	// ContextGuard: transfer Decision.ContextStatus → state.pendingCompact;
+	// the next tool/think step routes through CompactNode.
 	if decision.ContextStatus == ContextCritical {
 		state.pendingCompact = true
 	}
-	if state.pendingCompact && state.OnContextOverflow != nil {
-		state.pendingCompact = false
-		compactCtx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-		defer cancel()
-		if err := state.OnContextOverflow(compactCtx); err != nil {
-			log.Printf("[ContextGuard] Auto-compact failed: %v", err)
-		}
-	}
 
 	switch decision.Action {
 	case "tool":
@@ -398,6 +598,7 @@ func (n *DecideNode) Post(state *AgentState, prep []DecidePrep, results ...Decis
 			if consecMeta >= 4 {
 				log.Printf("[MetaToolGuard] Hard limit: %d consecutive meta-tool calls (%s), forcing answer",
 					consecMeta, decision.ToolName)
+				state.ForcedAnswer = ReasonMetaToolLoop
 				return core.ActionAnswer
 			}
 			if consecMeta >= 2 {
@@ -447,7 +648,21 @@ func (n *DecideNode) Post(state *AgentState, prep []DecidePrep, results ...Decis
 		}
 		return core.ActionThink
 	case "answer":
+		if needsVerify(state) {
+			return core.ActionVerify
+		}
 		return core.ActionAnswer
+	case "ask":
+		// Without a user to ask (headless run, question limit reached) the
+		// question becomes the answer, so the run ends by asking it.
+		if !canAsk(state) || strings.TrimSpace(decision.Question) == "" {
+			log.Printf("[Decide] Cannot ask the user, answering with the question")
+			if decision.Answer == "" {
+				decision.Answer = decision.Question
+			}
+			return core.ActionAnswer
+		}
+		return core.ActionAsk
 	default:
 		log.Printf("[Decide] Unknown action %q, defaulting to answer", decision.Action)
 		return core.ActionAnswer
@@ -458,9 +673,10 @@ func (n *DecideNode) Post(state *AgentState, prep []DecidePrep, results ...Decis
 func (n *DecideNode) ExecFallback(err error) Decision {
 	log.Printf("[Decide] ExecFallback triggered: %v", err)
 	return Decision{
-		Action: "answer",
-		Reason: fmt.Sprintf("Decision failed: %v", err),
-		Answer: "抱歉，处理过程中遇到问题，请稍后重试。",
+		Action:   "answer",
+		Reason:   fmt.Sprintf("Decision failed: %v", err),
+		Answer:   "抱歉，处理过程中遇到问题，请稍后重试。",
+		LLMError: err.Error(),
 	}
 }
 
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// yamlRepairThreshold is the number of YAML decision parse failures in a run
+// after which decide prompts carry few-shot examples and a failed parse gets
+// one repair round instead of being taken as a direct answer.
+const yamlRepairThreshold = 2
+
+// maxDecisionExamples caps the few-shot examples injected per prompt.
+const maxDecisionExamples = 2
+
+// decisionExample is a known-good YAML decision for one tool ("" = answer).
+type decisionExample struct {
+	Tool string
+	YAML string
+}
+
+// decisionExamples is the library of known-good decisions, in preference
+// order. Each exercises a formatting rule models commonly get wrong:
+// quoting, Windows paths, nested tool_params and block scalars.
+var decisionExamples = []decisionExample{
+	{Tool: "file_read", YAML: `action: "tool"
+reason: "读取配置文件确认端口设置"
+tool_name: "file_read"
+tool_params:
+  path: "config/app.yaml"`},
+	{Tool: "file_grep", YAML: `action: "tool"
+reason: "查找 TODO 注释所在位置"
+tool_name: "file_grep"
+tool_params:
+  pattern: "TODO\\(.*\\)"
+  file_glob: "*.go"`},
+	{Tool: "shell_exec", YAML: `action: "tool"
+reason: "列出 D 盘项目目录内容"
+tool_name: "shell_exec"
+tool_params:
+  command: 'dir "D:\Projects\demo"'`},
+	{Tool: "file_write", YAML: `action: "tool"
+reason: "写入说明文件"
+tool_name: "file_write"
+tool_params:
+  path: "docs/NOTES.md"
+  content: |
+    # 说明
+    - 第一项: 使用冒号也无需转义
+    - 第二项`},
+	{Tool: "file_list", YAML: `action: "tool"
+reason: "查看项目根目录结构"
+tool_name: "file_list"
+tool_params:
+  path: "."`},
+	{Tool: "web_search", YAML: `action: "tool"
+reason: "搜索最新版本发布说明"
+tool_name: "web_search"
+tool_params:
+  query: "Go 1.24 release notes"`},
+	{Tool: "", YAML: `action: "answer"
+reason: "已获得所需信息"
+answer: |
+  配置文件中端口为 8080: 见 config/app.yaml 第 3 行。`},
+}
+
+// selectDecisionExamples picks up to maxDecisionExamples examples for the
+// tools in reg: one tool call (preferring lastTool, the tool the model was
+// last working with) and one answer.
+func selectDecisionExamples(reg *tool.Registry, lastTool string) []string {
+	var toolExample, answerExample string
+	for _, ex := range decisionExamples {
+		switch {
+		case ex.Tool == "":
+			answerExample = ex.YAML
+		case reg == nil:
+		case toolExample == "" || ex.Tool == lastTool:
+			if _, ok := reg.Get(ex.Tool); ok {
+				toolExample = ex.YAML
+			}
+		}
+	}
+	var out []string
+	for _, ex := range []string{toolExample, answerExample} {
+		if ex != "" && len(out) < maxDecisionExamples {
+			out = append(out, ex)
+		}
+	}
+	return out
+}
+
+// formatDecisionExamples renders examples as fenced YAML blocks.
+func formatDecisionExamples(examples []string) string {
+	var sb strings.Builder
+	for i, ex := range examples {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("```yaml\n" + ex + "\n```\n")
+	}
+	return sb.String()
+}
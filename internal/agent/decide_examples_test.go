@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestDecisionExamples_AllParse(t *testing.T) {
+	for _, ex := range decisionExamples {
+		d, err := parseDecision("```yaml\n" + ex.YAML + "\n```")
+		if err != nil {
+			t.Errorf("example for %q does not parse: %v", ex.Tool, err)
+			continue
+		}
+		if ex.Tool == "" && d.Action != "answer" || ex.Tool != "" && d.ToolName != ex.Tool {
+			t.Errorf("example for %q parsed as %+v", ex.Tool, d)
+		}
+	}
+}
+
+func TestSelectDecisionExamples(t *testing.T) {
+	reg := tool.NewRegistry()
+	reg.Register(&mockTool{"shell_exec", "run"})
+	reg.Register(&mockTool{"web_search", "search"})
+
+	got := selectDecisionExamples(reg, "")
+	if len(got) != 2 || !strings.Contains(got[0], `tool_name: "shell_exec"`) || !strings.Contains(got[1], `action: "answer"`) {
+		t.Errorf("default selection = %q, want first registered tool + answer", got)
+	}
+	got = selectDecisionExamples(reg, "web_search")
+	if !strings.Contains(got[0], `tool_name: "web_search"`) {
+		t.Errorf("last used tool should be preferred, got %q", got[0])
+	}
+	if got := selectDecisionExamples(tool.NewRegistry(), ""); len(got) != 1 {
+		t.Errorf("no registered tools: want only the answer example, got %d", len(got))
+	}
+}
+
+func TestExecWithYAML_FirstFailureIsDirectAnswer(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{"Just some prose."}}
+	node := NewDecideNode(mock, nil)
+
+	d, err := node.Exec(context.Background(), DecidePrep{
+		Problem: "x", ToolCallMode: "yaml", DecisionExamples: []string{decisionExamples[0].YAML},
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	if d.Action != "answer" || d.ParseFailures != 1 || len(mock.calls) != 1 {
+		t.Errorf("decision = %+v, calls = %d; want direct answer without repair", d, len(mock.calls))
+	}
+}
+
+func TestExecWithYAML_RepairWithExamples(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{
+		"```yaml\naction: tool\ntool_params: [unclosed\n```",
+		"```yaml\naction: \"tool\"\ntool_name: \"file_read\"\ntool_params:\n  path: \"a.go\"\n```",
+	}}
+	node := NewDecideNode(mock, nil)
+
+	d, err := node.Exec(context.Background(), DecidePrep{
+		Problem: "read a.go", ToolCallMode: "yaml", YAMLParseFailures: 1,
+		DecisionExamples: []string{decisionExamples[0].YAML},
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	if d.Action != "tool" || d.ToolName != "file_read" || d.ParseFailures != 1 {
+		t.Errorf("decision = %+v, want repaired file_read call", d)
+	}
+	if len(mock.calls) != 2 {
+		t.Fatalf("LLM calls = %d, want 2 (initial + repair)", len(mock.calls))
+	}
+	repair := mock.calls[1][len(mock.calls[1])-1].Content
+	if !strings.Contains(repair, `path: "config/app.yaml"`) {
+		t.Errorf("repair prompt should carry the examples, got %q", repair)
+	}
+}
+
+func TestExecWithYAML_RepairFailsFallsBackToAnswer(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{"prose one", "prose two"}}
+	node := NewDecideNode(mock, nil)
+
+	d, err := node.Exec(context.Background(), DecidePrep{
+		Problem: "x", ToolCallMode: "yaml", YAMLParseFailures: 3,
+		DecisionExamples: []string{decisionExamples[0].YAML},
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	if d.Action != "answer" || d.Answer != "prose two" || d.ParseFailures != 2 {
+		t.Errorf("decision = %+v, want repaired-round prose as answer", d)
+	}
+}
+
+func TestBuildDecidePrompt_FewShotAfterFailures(t *testing.T) {
+	prep := DecidePrep{Problem: "x", DecisionExamples: []string{"action: \"answer\"\nanswer: ok"}}
+	if strings.Contains(buildDecidePrompt(prep), "answer: ok") {
+		t.Error("examples should not be injected before any parse failure")
+	}
+	prep.YAMLParseFailures = yamlRepairThreshold
+	if !strings.Contains(buildDecidePrompt(prep), "```yaml\naction: \"answer\"\nanswer: ok\n```") {
+		t.Error("examples should be injected after repeated parse failures")
+	}
+}
+
+func TestDecidePost_AccumulatesParseFailures(t *testing.T) {
+	node := NewDecideNode(nil, nil)
+	state := &AgentState{Problem: "x"}
+	node.Post(state, nil, Decision{Action: "answer", ParseFailures: 1})
+	node.Post(state, nil, Decision{Action: "answer", ParseFailures: 2})
+	if state.YAMLParseFailures != 3 {
+		t.Errorf("YAMLParseFailures = %d, want 3", state.YAMLParseFailures)
+	}
+
+	reg := tool.NewRegistry()
+	reg.Register(&mockTool{"file_read", "read"})
+	state = &AgentState{Problem: "x", ToolCallMode: "yaml", ToolRegistry: reg, YAMLParseFailures: 2}
+	prep := node.Prep(state)[0]
+	if prep.YAMLParseFailures != 2 || len(prep.DecisionExamples) != 2 {
+		t.Errorf("Prep should carry failures and examples, got %d / %d", prep.YAMLParseFailures, len(prep.DecisionExamples))
+	}
+}
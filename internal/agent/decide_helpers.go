@@ -90,6 +90,143 @@ func fixBackslashes(s string) string {
 
 func truncate(s string, maxLen int) string { return util.TruncateRunes(s, maxLen) }
 
+// ── JSON parsing ──
+
+// decisionActions is the set of valid Decision.Action values.
+var decisionActions = map[string]bool{"tool": true, "think": true, "answer": true, "ask": true}
+
+// parseDecisionJSON parses a JSON-mode decision: a single JSON object, usually
+// inside a ```json fence. Decoding is strict (unknown fields are rejected) and
+// the result is validated against the decision schema. validTools, when
+// non-empty, restricts tool_name to registered tools.
+//
+// Recovery mirrors parseDecision: common LLM slips — trailing commas and
+// unescaped Windows path backslashes — are repaired before a second strict
+// decode. Returned errors are phrased so they can be fed back
+// to the model verbatim for a repair round.
+func parseDecisionJSON(raw string, validTools []llm.ToolDefinition) (Decision, error) {
+	obj, err := extractJSONObject(raw)
+	if err != nil {
+		return Decision{}, err
+	}
+
+	decision, err := decodeDecisionJSON(obj)
+	if err != nil {
+		fixed := repairJSON(obj)
+		if fixed == obj {
+			return Decision{}, err
+		}
+		d2, err2 := decodeDecisionJSON(fixed)
+		if err2 != nil {
+			return Decision{}, err
+		}
+		log.Printf("[Decide] Recovered from malformed JSON decision")
+		decision = d2
+	}
+
+	if err := validateDecision(decision, validTools); err != nil {
+		return Decision{}, err
+	}
+	return decision, nil
+}
+
+// extractJSONObject returns the decision object from LLM output.
+// Order: ```json fence → generic ``` fence → first balanced {...} in the text.
+func extractJSONObject(content string) (string, error) {
+	for _, open := range []string{"```json", "```"} {
+		if idx := strings.Index(content, open); idx >= 0 {
+			rest := content[idx+len(open):]
+			if end := strings.Index(rest, "```"); end >= 0 {
+				content = rest[:end]
+				break
+			}
+			return "", fmt.Errorf("unclosed %s code block", open)
+		}
+	}
+	start := strings.Index(content, "{")
+	if start < 0 {
+		return "", fmt.Errorf("no JSON object found")
+	}
+	if end := matchBrace(content, start); end >= 0 {
+		return content[start : end+1], nil
+	}
+	return "", fmt.Errorf("unbalanced braces in JSON object")
+}
+
+// matchBrace returns the index of the '}' closing the '{' at start,
+// skipping braces inside string literals. Returns -1 when unbalanced.
+func matchBrace(s string, start int) int {
+	depth := 0
+	inStr, esc := false, false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case esc:
+			esc = false
+		case inStr && c == '\\':
+			esc = true
+		case c == '"':
+			inStr = !inStr
+		case inStr:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func decodeDecisionJSON(obj string) (Decision, error) {
+	dec := json.NewDecoder(strings.NewReader(obj))
+	dec.DisallowUnknownFields()
+	var d Decision
+	if err := dec.Decode(&d); err != nil {
+		return Decision{}, fmt.Errorf("JSON parse error: %w", err)
+	}
+	return d, nil
+}
+
+var jsonTrailingComma = regexp.MustCompile(`,(\s*[}\]])`)
+
+// repairJSON applies best-effort fixes for common LLM JSON mistakes.
+func repairJSON(s string) string {
+	s = jsonTrailingComma.ReplaceAllString(s, "$1")
+	return escapeStrayBackslashes(s)
+}
+
+// escapeStrayBackslashes doubles backslashes inside string literals that do
+// not start a valid JSON escape, so "E:\AI\docs" decodes as a Windows path
+// instead of failing. \n, \t etc. are left untouched.
+func escapeStrayBackslashes(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + 8)
+	inStr := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '"' {
+			inStr = !inStr
+			sb.WriteByte(c)
+			continue
+		}
+		if c != '\\' || !inStr {
+			sb.WriteByte(c)
+			continue
+		}
+		if i+1 < len(s) && strings.IndexByte(`"\/bfnrtu`, s[i+1]) >= 0 {
+			sb.WriteByte(c)
+			sb.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		sb.WriteString(`\\`)
+	}
+	return sb.String()
+}
+
 // ── MetaToolGuard helpers ──
 
 // countTrailingMetaTools counts how many consecutive meta-tool steps are at the
@@ -238,16 +375,16 @@ func parseNativeFCContent(content string, toolDefs []llm.ToolDefinition) (Decisi
 
 // coreToolOrder defines display priority for core tools (most used first).
 var coreToolOrder = []string{
-	"file_read", "file_write", "file_grep", "file_find", "file_list",
+	"file_read", "file_write", "file_grep", "find", "file_list", "project_map",
 	"file_patch", "file_move", "file_delete", "file_open",
 	"shell_exec",
-	"web_reader", "search_tavily", "search_brave", "http_request",
-	"time_get", "config_edit",
+	"web_reader", "web_search", "brave_search", "http_request",
+	"get_time", "config_edit", "env_set",
 }
 
 // mgmtToolOrder defines display priority for management tools.
 var mgmtToolOrder = []string{
-	"mcp_server_add", "mcp_server_remove", "mcp_server_list", "mcp_reload",
+	"mcp_server_add", "mcp_server_remove", "mcp_server_list", "mcp_reload", "skill_install", "skill_rollback",
 }
 
 // buildToolingSection generates a compact tool summary section from Registry.
@@ -0,0 +1,257 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// ── Decision schema validation ──
+
+// maxToolNameHint caps the tool names listed in an unknown-tool hint.
+const maxToolNameHint = 30
+
+// decisionIssue is one schema violation of a decision.
+type decisionIssue struct {
+	Field    string `json:"field"`              // "action", "tool_name", "tool_params.path", ...
+	Code     string `json:"code"`               // missing, invalid, unknown_tool, wrong_type
+	Message  string `json:"message"`            // what is wrong
+	Expected string `json:"expected,omitempty"` // the allowed values or type
+	Got      string `json:"got,omitempty"`      // the value or type received
+	Hint     string `json:"hint,omitempty"`     // how to fix it
+}
+
+// decisionSchemaError lists every schema violation of a decision. Its
+// message is machine-readable JSON so the model can be shown it verbatim
+// in a repair prompt.
+type decisionSchemaError struct {
+	Issues []decisionIssue
+}
+
+func (e *decisionSchemaError) Error() string {
+	b, _ := json.Marshal(map[string][]decisionIssue{"schema_errors": e.Issues})
+	return "decision schema invalid: " + string(b)
+}
+
+// validateDecision checks a parsed decision against the decision schema:
+// a known action with its required field, a registered tool_name and
+// tool_params matching the tool's parameter schema. validTools, when
+// non-empty, enables the tool checks. Returns a *decisionSchemaError.
+func validateDecision(d Decision, validTools []llm.ToolDefinition) error {
+	var issues []decisionIssue
+	switch {
+	case d.Action == "":
+		issues = append(issues, decisionIssue{Field: "action", Code: "missing", Message: "decision missing 'action' field",
+			Expected: "tool | think | answer | ask"})
+	case !decisionActions[d.Action]:
+		issues = append(issues, decisionIssue{Field: "action", Code: "invalid",
+			Message:  fmt.Sprintf("invalid action %q: must be \"tool\", \"think\", \"answer\" or \"ask\"", d.Action),
+			Expected: "tool | think | answer | ask", Got: d.Action,
+			Hint: "调用工具用 tool，推理用 think，给出最终回答用 answer"})
+	}
+
+	required := func(field, value string) {
+		if strings.TrimSpace(value) == "" {
+			issues = append(issues, decisionIssue{Field: field, Code: "missing",
+				Message: fmt.Sprintf("action %q requires non-empty '%s'", d.Action, field),
+				Hint:    fmt.Sprintf("action: %s 时必须填写 %s 字段", d.Action, field)})
+		}
+	}
+	switch d.Action {
+	case "tool":
+		required("tool_name", d.ToolName)
+		if d.ToolName != "" && len(validTools) > 0 {
+			if def := findToolDefinition(validTools, d.ToolName); def == nil {
+				issues = append(issues, decisionIssue{Field: "tool_name", Code: "unknown_tool",
+					Message: fmt.Sprintf("unknown tool_name %q: use one of the listed tools", d.ToolName),
+					Got:     d.ToolName, Hint: "可用工具：" + toolNameList(validTools)})
+			} else {
+				issues = append(issues, toolParamIssues(def, d.ToolParams)...)
+			}
+		}
+	case "think":
+		required("thinking", d.Thinking)
+	case "answer":
+		required("answer", d.Answer)
+	case "ask":
+		required("question", d.Question)
+	}
+	if d.PlanStatus != "" && d.PlanStatus != "in_progress" && d.PlanStatus != "done" {
+		issues = append(issues, decisionIssue{Field: "plan_status", Code: "invalid",
+			Message:  fmt.Sprintf("invalid plan_status %q: must be \"in_progress\" or \"done\"", d.PlanStatus),
+			Expected: "in_progress | done", Got: d.PlanStatus})
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	return &decisionSchemaError{Issues: issues}
+}
+
+func findToolDefinition(defs []llm.ToolDefinition, name string) *llm.ToolDefinition {
+	for i := range defs {
+		if defs[i].Name == name {
+			return &defs[i]
+		}
+	}
+	return nil
+}
+
+// toolNameList lists the tool names for a hint, sorted and capped.
+func toolNameList(defs []llm.ToolDefinition) string {
+	names := make([]string, 0, len(defs))
+	for _, d := range defs {
+		names = append(names, d.Name)
+	}
+	sort.Strings(names)
+	if len(names) > maxToolNameHint {
+		return strings.Join(names[:maxToolNameHint], ", ") + fmt.Sprintf(" 等 %d 个", len(names))
+	}
+	return strings.Join(names, ", ")
+}
+
+// toolParamIssues checks params against the tool's JSON Schema: required
+// parameters are present and values have the declared type. Parameters
+// the schema does not declare are left to the tool.
+func toolParamIssues(def *llm.ToolDefinition, params map[string]any) []decisionIssue {
+	var schema struct {
+		Properties map[string]struct {
+			Type any `json:"type"` // "string" or ["string", "null"]
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if len(def.Parameters) == 0 || json.Unmarshal(def.Parameters, &schema) != nil {
+		return nil
+	}
+	var issues []decisionIssue
+	for _, name := range schema.Required {
+		if v, ok := params[name]; !ok || v == nil {
+			issues = append(issues, decisionIssue{Field: "tool_params." + name, Code: "missing",
+				Message:  fmt.Sprintf("tool %q requires parameter '%s'", def.Name, name),
+				Expected: schemaTypes(schema.Properties[name].Type),
+				Hint:     fmt.Sprintf("在 tool_params 中提供 %s", name)})
+		}
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, ok := schema.Properties[name]
+		if !ok || params[name] == nil {
+			continue
+		}
+		want := schemaTypes(prop.Type)
+		if want == "" || valueMatchesTypes(params[name], want) {
+			continue
+		}
+		got := valueType(params[name])
+		issues = append(issues, decisionIssue{Field: "tool_params." + name, Code: "wrong_type",
+			Message:  fmt.Sprintf("parameter '%s' of tool %q must be %s, got %s", name, def.Name, want, got),
+			Expected: want, Got: got, Hint: typeHint(name, want)})
+	}
+	return issues
+}
+
+// schemaTypes renders a JSON Schema "type" as "string" or "string|null";
+// "" when no type is declared.
+func schemaTypes(t any) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case []any:
+		var types []string
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return strings.Join(types, "|")
+	}
+	return ""
+}
+
+func valueMatchesTypes(v any, types string) bool {
+	for _, t := range strings.Split(types, "|") {
+		if valueMatchesType(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueMatchesType reports whether a decoded YAML or JSON value has the
+// JSON Schema type t. Unknown types match anything.
+func valueMatchesType(v any, t string) bool {
+	switch t {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "integer":
+		switch n := v.(type) {
+		case int, int64, uint64:
+			return true
+		case float64:
+			return n == math.Trunc(n)
+		}
+		return false
+	case "number":
+		switch v.(type) {
+		case int, int64, uint64, float64:
+			return true
+		}
+		return false
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+// valueType names the JSON type of a decoded value.
+func valueType(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// typeHint shows how a value of the wanted type is written in YAML.
+func typeHint(name, want string) string {
+	examples := map[string]string{
+		"string":  `"文本"（数字或路径也要加引号）`,
+		"integer": "10（不加引号）",
+		"number":  "1.5（不加引号）",
+		"boolean": "true 或 false（不加引号）",
+		"array":   `["a", "b"]`,
+		"object":  `{key: "value"}`,
+	}
+	first, _, _ := strings.Cut(want, "|")
+	if ex, ok := examples[first]; ok {
+		return fmt.Sprintf("写成 %s: %s", name, ex)
+	}
+	return ""
+}
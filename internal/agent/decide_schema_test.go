@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+var schemaTestTools = []llm.ToolDefinition{
+	{Name: "file_read", Parameters: json.RawMessage(`{"type":"object","properties":{"path":{"type":"string"},"offset":{"type":"integer"},"raw":{"type":["boolean","null"]}},"required":["path"]}`)},
+	{Name: "time_now"},
+}
+
+func schemaIssues(t *testing.T, err error) []decisionIssue {
+	t.Helper()
+	var se *decisionSchemaError
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v, want *decisionSchemaError", err)
+	}
+	return se.Issues
+}
+
+func TestValidateDecision_Issues(t *testing.T) {
+	tests := []struct {
+		name  string
+		yaml  string
+		field string
+		code  string
+	}{
+		{"unknown action", "action: run", "action", "invalid"},
+		{"tool without tool_name", "action: tool", "tool_name", "missing"},
+		{"unknown tool", "action: tool\ntool_name: rm_rf", "tool_name", "unknown_tool"},
+		{"answer without text", "action: answer\nreason: done", "answer", "missing"},
+		{"think without text", "action: think", "thinking", "missing"},
+		{"missing required param", "action: tool\ntool_name: file_read", "tool_params.path", "missing"},
+		{"string param as number", "action: tool\ntool_name: file_read\ntool_params:\n  path: 123", "tool_params.path", "wrong_type"},
+		{"integer param quoted", "action: tool\ntool_name: file_read\ntool_params:\n  path: a.go\n  offset: \"10\"", "tool_params.offset", "wrong_type"},
+		{"bad plan_status", "action: answer\nanswer: ok\nplan_status: finished", "plan_status", "invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parseDecision(tt.yaml)
+			if err != nil {
+				t.Fatalf("parseDecision: %v", err)
+			}
+			issues := schemaIssues(t, validateDecision(d, schemaTestTools))
+			if len(issues) != 1 || issues[0].Field != tt.field || issues[0].Code != tt.code {
+				t.Errorf("issues = %+v, want one %s/%s", issues, tt.field, tt.code)
+			}
+		})
+	}
+}
+
+func TestValidateDecision_Valid(t *testing.T) {
+	for _, y := range []string{
+		"action: tool\ntool_name: file_read\ntool_params:\n  path: a.go\n  offset: 10\n  raw: null",
+		"action: tool\ntool_name: time_now",                                          // no parameter schema
+		"action: tool\ntool_name: file_read\ntool_params:\n  path: a.go\n  extra: 1", // undeclared params are the tool's business
+		"action: answer\nanswer: ok",
+	} {
+		d, err := parseDecision(y)
+		if err != nil {
+			t.Fatalf("parseDecision(%q): %v", y, err)
+		}
+		if err := validateDecision(d, schemaTestTools); err != nil {
+			t.Errorf("validateDecision(%q) = %v", y, err)
+		}
+	}
+	// Without tool definitions the tool checks are skipped
+	if err := validateDecision(Decision{Action: "tool", ToolName: "anything"}, nil); err != nil {
+		t.Errorf("no tool definitions: %v", err)
+	}
+}
+
+func TestValidateDecision_ReportsAllIssuesAsJSON(t *testing.T) {
+	d := Decision{Action: "tool", ToolName: "file_read", ToolParams: map[string]any{"offset": 1.5}, PlanStatus: "x"}
+	err := validateDecision(d, schemaTestTools)
+	issues := schemaIssues(t, err)
+	if len(issues) != 3 {
+		t.Fatalf("issues = %+v, want missing path, wrong offset type and bad plan_status", issues)
+	}
+	_, payload, _ := strings.Cut(err.Error(), ": ")
+	var decoded struct {
+		SchemaErrors []decisionIssue `json:"schema_errors"`
+	}
+	if json.Unmarshal([]byte(payload), &decoded) != nil || len(decoded.SchemaErrors) != 3 {
+		t.Errorf("error is not machine-readable: %s", err)
+	}
+	if got := issues[1]; got.Expected != "integer" || got.Got != "number" || got.Hint == "" {
+		t.Errorf("type issue = %+v", got)
+	}
+}
+
+func TestExecWithYAML_SchemaRepair(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{
+		"```yaml\naction: tool\ntool_name: file_read\ntool_params:\n  file: a.go\n```",
+		"```yaml\naction: tool\ntool_name: file_read\ntool_params:\n  path: a.go\n```",
+	}}
+	d, err := NewDecideNode(mock, nil).Exec(context.Background(), DecidePrep{
+		Problem: "read a.go", ToolCallMode: "yaml", ToolDefinitions: schemaTestTools,
+	})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	if d.ToolName != "file_read" || d.ToolParams["path"] != "a.go" || d.ParseFailures != 0 {
+		t.Errorf("decision = %+v, want the repaired call", d)
+	}
+	if len(mock.calls) != 2 {
+		t.Fatalf("LLM calls = %d, want 2 (initial + repair)", len(mock.calls))
+	}
+	repair := mock.calls[1][len(mock.calls[1])-1].Content
+	if !strings.Contains(repair, `"field": "tool_params.path"`) || !strings.Contains(repair, `"code": "missing"`) {
+		t.Errorf("repair prompt should carry the schema errors, got %q", repair)
+	}
+}
+
+func TestExecWithYAML_SchemaRepairFailsToFallback(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{
+		"```yaml\naction: answer\n```",
+		"```yaml\naction: answer\nreason: still empty\n```",
+	}}
+	node := NewDecideNode(mock, nil)
+	_, err := node.Exec(context.Background(), DecidePrep{Problem: "x", ToolCallMode: "yaml"})
+	if err == nil || !strings.Contains(err.Error(), "after repair") {
+		t.Fatalf("err = %v, want failure after one repair", err)
+	}
+	if len(mock.calls) != 2 {
+		t.Errorf("LLM calls = %d, want exactly one repair", len(mock.calls))
+	}
+	if d := node.ExecFallback(err); d.Action != "answer" || d.LLMError == "" {
+		t.Errorf("fallback = %+v", d)
+	}
+}
@@ -121,6 +121,79 @@ func TestParseDecisionInvalid(t *testing.T) {
 	}
 }
 
+// ── JSON mode parsing ──
+
+func TestParseDecisionJSONValid(t *testing.T) {
+	tools := []llm.ToolDefinition{{Name: "file_read"}}
+	tests := []struct {
+		name   string
+		input  string
+		action string
+	}{
+		{"fenced tool", "```json\n{\"action\": \"tool\", \"reason\": \"read\", \"tool_name\": \"file_read\", \"tool_params\": {\"path\": \"a.go\"}}\n```", "tool"},
+		{"bare object with prose", "好的，决策如下：{\"action\": \"answer\", \"answer\": \"done {ok}\"} 完毕", "answer"},
+		{"think", "```\n{\"action\": \"think\", \"thinking\": \"step by step\"}\n```", "think"},
+		{"plan sideband", `{"action": "answer", "answer": "x", "plan_step": "s1", "plan_status": "done"}`, "answer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := parseDecisionJSON(tt.input, tools)
+			if err != nil {
+				t.Fatalf("parseDecisionJSON() error: %v", err)
+			}
+			if d.Action != tt.action {
+				t.Errorf("Action = %q, want %q", d.Action, tt.action)
+			}
+		})
+	}
+}
+
+func TestParseDecisionJSONRecovery(t *testing.T) {
+	input := "```json\n{\"action\": \"tool\", \"tool_name\": \"file_list\", \"tool_params\": {\"path\": \"E:\\AI\\Pocket-Omega\\docs\",},}\n```"
+	d, err := parseDecisionJSON(input, nil)
+	if err != nil {
+		t.Fatalf("parseDecisionJSON() should recover: %v", err)
+	}
+	if got := d.ToolParams["path"]; got != `E:\AI\Pocket-Omega\docs` {
+		t.Errorf("path = %q, want Windows path preserved", got)
+	}
+}
+
+func TestParseDecisionJSONInvalid(t *testing.T) {
+	tools := []llm.ToolDefinition{{Name: "file_read"}}
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"no object", "just prose", "no JSON object"},
+		{"unclosed fence", "```json\n{\"action\": \"answer\"", "unclosed"},
+		{"unknown field", `{"action": "answer", "answer": "x", "confidence": 0.9}`, "unknown field"},
+		{"missing action", `{"reason": "r"}`, "missing 'action'"},
+		{"bad action", `{"action": "run"}`, "invalid action"},
+		{"missing tool_name", `{"action": "tool"}`, "tool_name"},
+		{"unknown tool", `{"action": "tool", "tool_name": "rm_rf"}`, "unknown tool_name"},
+		{"empty answer", `{"action": "answer", "answer": " "}`, "'answer'"},
+		{"tool_params not object", `{"action": "tool", "tool_name": "file_read", "tool_params": "a.go"}`, "JSON parse error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseDecisionJSON(tt.input, tools)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEscapeStrayBackslashes(t *testing.T) {
+	in := `{"a": "C:\Users\n\"q\"", "b": "x\y"}`
+	want := `{"a": "C:\\Users\n\"q\"", "b": "x\\y"}`
+	if got := escapeStrayBackslashes(in); got != want {
+		t.Errorf("escapeStrayBackslashes() =\n  %s\nwant:\n  %s", got, want)
+	}
+}
+
 func TestTruncateUTF8Safe(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -470,6 +543,95 @@ func (m *mockTool) Execute(_ context.Context, _ json.RawMessage) (tool.ToolResul
 func (m *mockTool) Init(_ context.Context) error { return nil }
 func (m *mockTool) Close() error                 { return nil }
 
+// ── JSON path tests ──
+
+// seqLLMProvider returns CallLLM responses in order and records the messages.
+type seqLLMProvider struct {
+	mockLLMProvider
+	responses []string
+	calls     [][]llm.Message
+}
+
+func (m *seqLLMProvider) CallLLM(_ context.Context, msgs []llm.Message) (llm.Message, error) {
+	m.calls = append(m.calls, msgs)
+	i := len(m.calls) - 1
+	if i >= len(m.responses) {
+		i = len(m.responses) - 1
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: m.responses[i]}, nil
+}
+
+func TestExecWithJSON_RepairRound(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{
+		"```json\n{\"action\": \"tool\"}\n```",
+		"```json\n{\"action\": \"tool\", \"tool_name\": \"file_read\", \"tool_params\": {\"path\": \"a.go\"}}\n```",
+	}}
+	node := NewDecideNode(mock, nil)
+	prep := DecidePrep{
+		Problem:         "read a.go",
+		ToolCallMode:    "json",
+		ToolDefinitions: []llm.ToolDefinition{{Name: "file_read"}},
+	}
+
+	d, err := node.Exec(context.Background(), prep)
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	if d.Action != "tool" || d.ToolName != "file_read" {
+		t.Errorf("decision = %+v, want tool file_read", d)
+	}
+	if len(mock.calls) != 2 {
+		t.Fatalf("LLM calls = %d, want 2 (initial + repair)", len(mock.calls))
+	}
+	repair := mock.calls[1]
+	if last := repair[len(repair)-1]; !strings.Contains(last.Content, "tool_name") {
+		t.Errorf("repair prompt should carry the validation error, got %q", last.Content)
+	}
+}
+
+func TestExecWithJSON_RepairFails(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{`{"action": "run"}`}}
+	node := NewDecideNode(mock, nil)
+
+	_, err := node.Exec(context.Background(), DecidePrep{Problem: "x", ToolCallMode: "json"})
+	if err == nil {
+		t.Fatal("Exec() should fail when repair round is still invalid")
+	}
+	if len(mock.calls) != 2 {
+		t.Errorf("LLM calls = %d, want 2", len(mock.calls))
+	}
+}
+
+func TestExecWithJSON_ProseIsAnswer(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{"The answer is 42."}}
+	node := NewDecideNode(mock, nil)
+
+	d, err := node.Exec(context.Background(), DecidePrep{Problem: "6*7", ToolCallMode: "json"})
+	if err != nil {
+		t.Fatalf("Exec() error: %v", err)
+	}
+	if d.Action != "answer" || d.Answer != "The answer is 42." {
+		t.Errorf("decision = %+v, want direct answer", d)
+	}
+	if len(mock.calls) != 1 {
+		t.Errorf("LLM calls = %d, want 1 (no repair for prose)", len(mock.calls))
+	}
+}
+
+func TestBuildDecidePrompt_JSONTemplate(t *testing.T) {
+	app := buildDecidePrompt(DecidePrep{Problem: "p", ToolCallMode: "json", ThinkingMode: "app"})
+	if !strings.Contains(app, "```json") || strings.Contains(app, "```yaml") {
+		t.Errorf("json mode should use JSON template only:\n%s", app)
+	}
+	if !strings.Contains(app, `"thinking"`) {
+		t.Error("app thinking mode should offer the think action")
+	}
+	native := buildDecidePrompt(DecidePrep{Problem: "p", ToolCallMode: "json", ThinkingMode: "native"})
+	if strings.Contains(native, `"thinking"`) {
+		t.Error("native thinking mode should not offer the think action")
+	}
+}
+
 // ── buildRuntimeLine tests ──
 
 func TestBuildRuntimeLine_AllFields(t *testing.T) {
@@ -640,16 +802,15 @@ func TestTokenBudgetGuard_TruncatesAtThreshold(t *testing.T) {
 	// Build a loader-less DecideNode (loader nil is safe — buildSystemPrompt guards it)
 	node := NewDecideNode(&mockLLMProvider{}, nil)
 
-	// ContextWindowTokens=100 → maxChars = 100 * 2 * 25 / 100 = 50
+	// ContextWindowTokens=100 → maxTokens = 100 * 25 / 100 = 25
 	prep := DecidePrep{
 		ContextWindowTokens: 100,
 		ToolCallMode:        "yaml",
 		ThinkingMode:        "app",
 	}
 	result := node.buildSystemPrompt("app", prep)
-	maxChars := 100 * charsPerToken * 25 / 100 // 50
-	if len([]rune(result)) > maxChars {
-		t.Errorf("token budget guard: result has %d runes, want <= %d", len([]rune(result)), maxChars)
+	if got := estimateTokens(result); got > 25 {
+		t.Errorf("token budget guard: result has %d tokens, want <= 25", got)
 	}
 }
 
@@ -0,0 +1,50 @@
+package agent
+
+import "log"
+
+// downshiftHistoryDivisor shrinks the context window used to size step
+// summaries after a downshift: cheaper models usually have smaller windows,
+// and the remaining token budget is small, so recent tool outputs are cut harder.
+const downshiftHistoryDivisor = 4
+
+// downshiftFallbackWindow is assumed when no context window is configured
+// (perStepOutputBudget would otherwise fall back to its 4000-token default).
+const downshiftFallbackWindow = 64000
+
+// DownshiftInfo records an automatic model downshift for run metadata
+// (replay log, exec log, SSE done stats).
+type DownshiftInfo struct {
+	FromModel  string `json:"from_model"`
+	ToModel    string `json:"to_model"`
+	Step       int    `json:"step"`        // decide step after which the switch happened
+	UsedTokens int64  `json:"used_tokens"` // CostGuard usage at the switch
+	MaxTokens  int64  `json:"max_tokens"`  // CostGuard budget
+}
+
+// downshiftSummaryWindow returns the reduced window used by buildStepSummary
+// once a run has downshifted.
+func downshiftSummaryWindow(contextWindowTokens int) int {
+	if contextWindowTokens <= 0 {
+		contextWindowTokens = downshiftFallbackWindow
+	}
+	return contextWindowTokens / downshiftHistoryDivisor
+}
+
+// applyDownshift switches the remaining decide steps to state.DownshiftProvider,
+// records the event and notifies the UI. Called once from DecideNode.Post.
+func applyDownshift(state *AgentState, step int) {
+	info := DownshiftInfo{
+		FromModel:  state.ModelName,
+		ToModel:    state.DownshiftModel,
+		Step:       step,
+		UsedTokens: state.CostGuard.UsedTokens(),
+		MaxTokens:  state.CostGuard.MaxTokens(),
+	}
+	state.Downshift = &info
+	log.Printf("[CostGuard] Downshift at step %d: %d/%d tokens used, decide model %s → %s",
+		step, info.UsedTokens, info.MaxTokens, info.FromModel, info.ToModel)
+	state.Replay.RecordDownshift(info)
+	if state.OnDownshift != nil {
+		state.OnDownshift(info)
+	}
+}
@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/core"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestCostGuard_Downshift(t *testing.T) {
+	g := NewCostGuard(100, 0)
+	g.EnableDownshift(0.8)
+
+	_ = g.RecordTokens(70)
+	if g.TakeDownshift() {
+		t.Fatal("below threshold: no downshift expected")
+	}
+	if err := g.RecordTokens(15); err != nil {
+		t.Fatalf("85/100 should not exceed the budget: %v", err)
+	}
+	if !g.TakeDownshift() {
+		t.Fatal("crossing 80% should trigger a downshift")
+	}
+	if g.TakeDownshift() {
+		t.Error("downshift must be reported only once")
+	}
+}
+
+func TestCostGuard_Downshift_Disabled(t *testing.T) {
+	for _, g := range []*CostGuard{NewCostGuard(0, 0), NewCostGuard(100, 0)} {
+		g.EnableDownshift(1.5) // invalid ratio is ignored
+		_ = g.RecordTokens(99)
+		if g.TakeDownshift() {
+			t.Error("downshift should stay disabled")
+		}
+	}
+}
+
+func TestDecide_DownshiftSwitchesProvider(t *testing.T) {
+	main := &mockLLMProvider{callLLMResp: llm.Message{Content: `{"action":"answer","reason":"r","answer":"from main"}`}}
+	cheap := &mockLLMProvider{callLLMResp: llm.Message{Content: `{"action":"answer","reason":"r","answer":"from cheap"}`}}
+	node := NewDecideNode(main, nil)
+
+	state := &AgentState{
+		Problem:           "q",
+		ToolRegistry:      tool.NewRegistry(),
+		ToolCallMode:      "json",
+		ModelName:         "big-model",
+		CostGuard:         NewCostGuard(100, 0),
+		DownshiftProvider: cheap,
+		DownshiftModel:    "small-model",
+	}
+	state.CostGuard.EnableDownshift(0.5)
+	var notified *DownshiftInfo
+	state.OnDownshift = func(d DownshiftInfo) { notified = &d }
+
+	_ = state.CostGuard.RecordTokens(60)
+	action := node.Post(state, nil, Decision{Action: "tool", ToolName: "file_read"})
+	if action != core.ActionTool {
+		t.Errorf("downshift must not abort the run, got action=%s", action)
+	}
+	if state.Downshift == nil || notified == nil {
+		t.Fatal("downshift should be recorded and notified")
+	}
+	if notified.FromModel != "big-model" || notified.ToModel != "small-model" || notified.UsedTokens != 60 {
+		t.Errorf("DownshiftInfo = %+v", *notified)
+	}
+
+	prep := node.Prep(state)[0]
+	if prep.Provider != cheap {
+		t.Fatal("Prep should route decisions to the downshift provider")
+	}
+	decision, err := node.Exec(context.Background(), prep)
+	if err != nil {
+		t.Fatalf("Exec: %v", err)
+	}
+	if decision.Answer != "from cheap" {
+		t.Errorf("decision came from the wrong provider: %q", decision.Answer)
+	}
+}
+
+func TestDownshiftSummaryWindow(t *testing.T) {
+	if got := downshiftSummaryWindow(128000); got != 32000 {
+		t.Errorf("downshiftSummaryWindow(128000) = %d, want 32000", got)
+	}
+	if got := downshiftSummaryWindow(0); got <= 0 {
+		t.Errorf("unconfigured window should still produce a positive budget, got %d", got)
+	}
+	if perStepOutputBudget(downshiftSummaryWindow(0), recentWindowSize) >= perStepOutputBudget(0, recentWindowSize) {
+		t.Error("downshifted summaries should be tighter than the default budget")
+	}
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/redact"
+)
+
+// editDiffTools are the file tools whose successful calls carry the diff of
+// the edited file (StepRecord.Diff); their output only says that the file
+// was written.
+var editDiffTools = map[string]bool{
+	"file_write": true,
+	"file_patch": true,
+}
+
+// editDiffMaxLines bounds the hunks of one FileDiff.
+const editDiffMaxLines = 200
+
+// FileDiff is the change one tool call made to a file.
+type FileDiff struct {
+	Path      string `json:"path"` // relative to the workspace when inside it
+	Created   bool   `json:"created,omitempty"`
+	Added     int    `json:"added"`
+	Removed   int    `json:"removed"`
+	Hunks     string `json:"hunks"` // unified diff hunks, "@@ -a,b +c,d @@" headers
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// editSnapshot is the content of the file an edit tool is about to change.
+type editSnapshot struct {
+	path    string // absolute
+	rel     string
+	existed bool
+	content []byte
+}
+
+// snapshotEdit reads the file named by the path argument of an edit tool
+// before it runs; nil for other tools, binary files and files over
+// changeMaxFileBytes.
+func snapshotEdit(toolName string, args []byte, workspaceDir string) *editSnapshot {
+	if !editDiffTools[toolName] {
+		return nil
+	}
+	var a struct {
+		Path string `json:"path"`
+	}
+	if json.Unmarshal(args, &a) != nil || a.Path == "" {
+		return nil
+	}
+	s := &editSnapshot{path: a.Path, rel: filepath.ToSlash(a.Path)}
+	if !filepath.IsAbs(a.Path) && workspaceDir != "" {
+		s.path = filepath.Join(workspaceDir, a.Path)
+	}
+	if rel, err := filepath.Rel(workspaceDir, s.path); err == nil && workspaceDir != "" && !strings.HasPrefix(rel, "..") {
+		s.rel = filepath.ToSlash(rel)
+	}
+	info, err := os.Stat(s.path)
+	switch {
+	case err != nil:
+		return s // new file
+	case info.IsDir() || info.Size() > changeMaxFileBytes:
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil || isBinary(data) {
+		return nil
+	}
+	s.existed, s.content = true, data
+	return s
+}
+
+// diff compares the snapshot with the file now; nil when it is unchanged,
+// gone, too large or binary.
+func (s *editSnapshot) diff() *FileDiff {
+	if s == nil {
+		return nil
+	}
+	info, err := os.Stat(s.path)
+	if err != nil || info.IsDir() || info.Size() > changeMaxFileBytes {
+		return nil
+	}
+	now, err := os.ReadFile(s.path)
+	if err != nil || isBinary(now) || s.existed && bytes.Equal(s.content, now) {
+		return nil
+	}
+	ops := diffLines(splitFileLines(s.content), splitFileLines(now))
+	d := &FileDiff{Path: s.rel, Created: !s.existed}
+	d.Added, d.Removed = diffStats(ops)
+	d.Hunks, d.Truncated = unifiedHunks(ops, 3, editDiffMaxLines)
+	d.Hunks = redact.String(d.Hunks)
+	return d
+}
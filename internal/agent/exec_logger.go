@@ -2,11 +2,14 @@ package agent
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/redact"
 )
 
 // execLogOutputMaxRunes is the maximum rune count for a single tool output written
@@ -90,10 +93,13 @@ func (l *ExecLogger) LogStep(step StepRecord) {
 			l.writef("\n> %s\n\n", strings.ReplaceAll(step.Output, "\n", "\n> "))
 		}
 
-	case "answer":
+	case "answer", "compact":
 		if step.Output != "" {
 			l.writef("\n%s\n\n", step.Output)
 		}
+
+	case "ask":
+		l.writef("\n> ❓ %s\n\n%s\n\n", step.Input, step.Output)
 	}
 
 	l.writef("---\n\n")
@@ -107,6 +113,9 @@ func (l *ExecLogger) EndSession(state *AgentState) {
 	l.writef("## 结果摘要\n\n")
 	l.writef("- **总步数**: %d\n", len(state.StepHistory))
 	l.writef("- **回答长度**: %d 字符\n", len([]rune(state.Solution)))
+	if d := state.Downshift; d != nil {
+		l.writef("- **模型降级**: 步骤 %d 起 %s → %s（%d/%d tokens）\n", d.Step, d.FromModel, d.ToModel, d.UsedTokens, d.MaxTokens)
+	}
 	l.writef("- **完成时间**: %s\n", time.Now().Format("2006-01-02 15:04:05"))
 }
 
@@ -120,8 +129,9 @@ func (l *ExecLogger) Close() error {
 	return nil
 }
 
+// writef writes to the log with secrets masked (see package redact).
 func (l *ExecLogger) writef(format string, args ...interface{}) {
-	if _, err := fmt.Fprintf(l.file, format, args...); err != nil {
+	if _, err := io.WriteString(l.file, redact.String(fmt.Sprintf(format, args...))); err != nil {
 		log.Printf("[ExecLogger] write failed: %v", err)
 	}
 }
@@ -136,6 +146,14 @@ func stepTypeLabel(t string) string {
 		return "🧠 推理"
 	case "answer":
 		return "✅ 回答"
+	case "compact":
+		return "🗜️ 上下文压缩"
+	case "review":
+		return "🔍 自我审查"
+	case "ask":
+		return "❓ 提问"
+	case "verify":
+		return "🧪 验证"
 	default:
 		return t
 	}
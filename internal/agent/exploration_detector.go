@@ -44,7 +44,7 @@ func (d *ExplorationDetector) Check(steps []StepRecord, maxSteps int) Exploratio
 // isInfoGatheringTool returns true for read-only information gathering tools.
 func isInfoGatheringTool(s StepRecord) bool {
 	switch s.ToolName {
-	case "file_read", "file_list", "file_grep", "file_find":
+	case "file_read", "file_list", "file_grep", "file_find", "project_map", "code_search":
 		return true
 	case "shell_exec":
 		return isReadOnlyShellCommand(extractParam(s.Input, "command"))
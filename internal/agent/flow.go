@@ -1,9 +1,14 @@
 package agent
 
 import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/pocketomega/pocket-omega/internal/core"
 	"github.com/pocketomega/pocket-omega/internal/llm"
 	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
@@ -13,25 +18,50 @@ import (
 //
 //	DecideNode ──┬── ActionTool   → ToolNode   ──→ DecideNode
 //	             ├── ActionThink  → ThinkNode  ──→ DecideNode
+//	             ├── ActionAsk    → AskNode    ──→ DecideNode
 //	             └── ActionAnswer → AnswerNode ──→ End
 //
 // native mode (model handles thinking):
 //
 //	DecideNode ──┬── ActionTool   → ToolNode   ──→ DecideNode
+//	             ├── ActionAsk    → AskNode    ──→ DecideNode
 //	             └── ActionAnswer → AnswerNode ──→ End
 //
+// AskNode waits for the user's reply to a clarifying question; DecideNode
+// only routes there when AgentState.AskUser is set.
+//
+// With verification (AgentState.Verify), DecideNode routes the answer of a
+// run that edited code through VerifyNode first (ActionVerify), which goes
+// on to AnswerNode or back to DecideNode.
+//
+// With self-review (AgentState.SelfReviewRetries > 0) AnswerNode routes
+// ActionReview → ReviewNode, which ends the flow or, on a failed review,
+// returns to DecideNode with the critique.
+//
 // loader is optional (nil is valid); when nil nodes fall back to hardcoded defaults.
 func BuildAgentFlow(provider llm.LLMProvider, registry *tool.Registry, thinkingMode string, loader *prompt.PromptLoader) core.Workflow[AgentState] {
-	// Create nodes
-	decideNode := core.NewNode[AgentState, DecidePrep, Decision](
+	// Create nodes (each wrapped in a tracing span; no-op unless OTEL is configured)
+	decideNode := traced("decide", core.NewNode[AgentState, DecidePrep, Decision](
 		NewDecideNode(provider, loader), 1,
-	)
-	toolNode := core.NewNode[AgentState, ToolPrep, ToolExecResult](
+	))
+	toolNode := traced("tool", core.NewNode[AgentState, ToolPrep, ToolExecResult](
 		NewToolNode(registry), 0,
-	)
-	answerNode := core.NewNode[AgentState, AnswerPrep, AnswerResult](
+	))
+	answerNode := traced("answer", core.NewNode[AgentState, AnswerPrep, AnswerResult](
 		NewAnswerNode(provider, loader), 1,
-	)
+	))
+	reviewNode := traced("review", core.NewNode[AgentState, ReviewPrep, ReviewResult](
+		NewReviewNode(provider), 1,
+	))
+	compactNode := traced("compact", core.NewNode[AgentState, CompactPrep, CompactResult](
+		NewCompactNode(), 0,
+	))
+	askNode := traced("ask", core.NewNode[AgentState, AskPrep, AskResult](
+		NewAskNode(), 0,
+	))
+	verifyNode := traced("verify", core.NewNode[AgentState, VerifyPrep, VerifyResult](
+		NewVerifyNode(), 0,
+	))
 
 	// Wire the decision loop
 	decideNode.AddSuccessor(toolNode, core.ActionTool)
@@ -39,19 +69,76 @@ func BuildAgentFlow(provider llm.LLMProvider, registry *tool.Registry, thinkingM
 
 	// Only register ThinkNode in app mode
 	if thinkingMode == "app" {
-		thinkNode := core.NewNode[AgentState, ThinkPrep, ThinkResult](
+		thinkNode := traced("think", core.NewNode[AgentState, ThinkPrep, ThinkResult](
 			NewThinkNode(provider, loader), 1,
-		)
+		))
 		decideNode.AddSuccessor(thinkNode, core.ActionThink)
 		thinkNode.AddSuccessor(decideNode) // ActionDefault → DecideNode
+		thinkNode.AddSuccessor(compactNode, core.ActionCompact)
 	}
 
+	// AskNode resumes the loop once the user has replied
+	decideNode.AddSuccessor(askNode, core.ActionAsk)
+	askNode.AddSuccessor(decideNode) // ActionDefault → DecideNode
+	askNode.AddSuccessor(compactNode, core.ActionCompact)
+
 	// ToolNode loops back to DecideNode
 	toolNode.AddSuccessor(decideNode) // ActionDefault → DecideNode
+	toolNode.AddSuccessor(compactNode, core.ActionCompact)
+
+	// CompactNode folds old conversation turns when the context fills up,
+	// then hands over to DecideNode. The flow starts here so that a long
+	// history is compacted before the first decision.
+	compactNode.AddSuccessor(decideNode)
+
+	// VerifyNode checks code edits before an answer: a pass goes on to
+	// AnswerNode, a failure or a hint back to DecideNode
+	decideNode.AddSuccessor(verifyNode, core.ActionVerify)
+	verifyNode.AddSuccessor(answerNode, core.ActionAnswer)
+	verifyNode.AddSuccessor(decideNode) // ActionDefault → DecideNode
+	verifyNode.AddSuccessor(compactNode, core.ActionCompact)
 
-	// AnswerNode ends the flow (ActionEnd has no successor)
+	// AnswerNode ends the flow (ActionEnd has no successor) unless the
+	// answer goes to self-review; a failed review loops back
+	answerNode.AddSuccessor(reviewNode, core.ActionReview)
+	reviewNode.AddSuccessor(decideNode) // ActionDefault → DecideNode
+	reviewNode.AddSuccessor(compactNode, core.ActionCompact)
 
 	// Wrap in a Flow to enable successor chaining.
-	flow := core.NewFlow[AgentState](decideNode)
+	flow := core.NewFlow[AgentState](compactNode)
 	return flow
 }
+
+// tracedNode runs a node inside an "agent.<name>" span annotated with the
+// step it recorded, so traces show where a run spends its time.
+type tracedNode struct {
+	core.Workflow[AgentState]
+	name string
+}
+
+func traced(name string, node core.Workflow[AgentState]) *tracedNode {
+	return &tracedNode{Workflow: node, name: name}
+}
+
+// Run implements core.Workflow.
+func (t *tracedNode) Run(ctx context.Context, state *AgentState) core.Action {
+	ctx, span := telemetry.Start(ctx, "agent."+t.name, attribute.Int("agent.step", len(state.StepHistory)+1))
+	action := t.Workflow.Run(ctx, state)
+
+	span.SetAttributes(attribute.String("agent.next_action", string(action)))
+	if n := len(state.StepHistory); n > 0 {
+		last := state.StepHistory[n-1]
+		if last.Action != "" {
+			span.SetAttributes(attribute.String("agent.decision", last.Action))
+		}
+		if last.ToolName != "" {
+			span.SetAttributes(
+				attribute.String("agent.tool", last.ToolName),
+				attribute.Bool("agent.tool_error", last.IsError),
+				attribute.Int64("agent.tool_duration_ms", last.DurationMs),
+			)
+		}
+	}
+	span.End()
+	return action
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pocketomega/pocket-omega/internal/core"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestBuildAgentFlow_TracesEachNode(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	mock := &seqLLMProvider{responses: []string{
+		"```yaml\naction: \"tool\"\nreason: \"list\"\ntool_name: \"file_list\"\ntool_params:\n  path: \".\"\n```",
+		"```yaml\naction: \"answer\"\nreason: \"done\"\nanswer: \"ok\"\n```",
+		"ok",
+	}}
+	reg := tool.NewRegistry()
+	reg.Register(&mockTool{"file_list", "list files"})
+	flow := BuildAgentFlow(mock, reg, "native", nil)
+	flow.Run(context.Background(), &AgentState{Problem: "x", ToolCallMode: "yaml", ThinkingMode: "native", ToolRegistry: reg})
+
+	var names []string
+	for _, s := range rec.Ended() {
+		names = append(names, s.Name())
+	}
+	// The flow enters through CompactNode (a no-op here); later loops skip it
+	want := []string{"agent.compact", "agent.decide", "agent.tool", "agent.decide", "agent.answer"}
+	if len(names) != len(want) {
+		t.Fatalf("spans = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("spans = %v, want %v", names, want)
+		}
+	}
+	toolSpan := rec.Ended()[2]
+	for _, kv := range toolSpan.Attributes() {
+		if kv.Key == "agent.tool" && kv.Value.AsString() != "file_list" {
+			t.Errorf("agent.tool = %s, want file_list", kv.Value.AsString())
+		}
+		if kv.Key == "agent.next_action" && core.Action(kv.Value.AsString()) != core.ActionDefault {
+			t.Errorf("tool node next_action = %s", kv.Value.AsString())
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// lineDiffMaxCells bounds the LCS table of the changed middle part of two
+// files; beyond it every middle line counts as removed and added.
+const lineDiffMaxCells = 1 << 20
+
+// diffOp is one line of a line diff: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines returns the line diff turning a into b. Common leading and
+// trailing lines are matched first, the rest by longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ops = append(ops, diffMiddle(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > lineDiffMaxCells {
+		for _, l := range a {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range b {
+			ops = append(ops, diffOp{'+', l})
+		}
+		return ops
+	}
+	// dp[i][j] = LCS length of a[i:] and b[j:]
+	dp := make([][]int32, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int32, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// diffStats counts the added and removed lines of ops.
+func diffStats(ops []diffOp) (added, removed int) {
+	for _, op := range ops {
+		switch op.kind {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// unifiedHunks renders ops as unified diff hunks with context lines around
+// each change, at most maxLines lines; truncated reports a cut.
+func unifiedHunks(ops []diffOp, context, maxLines int) (out string, truncated bool) {
+	var sb strings.Builder
+	lines := 0
+	// line numbers (1-based) of op k in the old and new file
+	oldNo, newNo := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	oldNo[0], newNo[0] = 1, 1
+	for k, op := range ops {
+		oldNo[k+1], newNo[k+1] = oldNo[k], newNo[k]
+		if op.kind != '+' {
+			oldNo[k+1]++
+		}
+		if op.kind != '-' {
+			newNo[k+1]++
+		}
+	}
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+		// hunk: from context lines before this change to context lines
+		// after the last change closer than 2*context
+		start := max(0, k-context)
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*context {
+				end = min(len(ops), end+context)
+				break
+			}
+			end = next
+		}
+		oldStart, oldLen := oldNo[start], oldNo[end]-oldNo[start]
+		newStart, newLen := newNo[start], newNo[end]-newNo[start]
+		if oldLen == 0 {
+			oldStart-- // empty range: the line before it, as diff -u does
+		}
+		if newLen == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+		for _, op := range ops[start:end] {
+			if lines >= maxLines {
+				return sb.String(), true
+			}
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			sb.WriteByte('\n')
+			lines++
+		}
+		k = end
+	}
+	return sb.String(), false
+}
@@ -29,13 +29,26 @@ var paramDedupTools = mergeToolKeyParams(map[string]string{
 	"update_plan": "step_id",
 })
 
+// loopExemptTools are excluded from loop detection entirely.
+// fetch_more is legitimately called repeatedly with the same continuation
+// token while paging through a large result; its run length is bounded by
+// the page count, so it cannot loop indefinitely.
+var loopExemptTools = map[string]bool{
+	"fetch_more": true,
+}
+
 // toolCallKey returns the deduplication key for Rule 1.
 // Whitelist tools (paramDedupTools): use the specified semantic param as key.
 // All other tools: use full-params MD5 hash (new tools benefit automatically,
 // no whitelist maintenance needed).
 func toolCallKey(s StepRecord) struct{ name, key string } {
 	if paramKey, ok := paramDedupTools[s.ToolName]; ok {
-		return struct{ name, key string }{s.ToolName, extractParam(s.Input, paramKey)}
+		key := extractParam(s.Input, paramKey)
+		if s.ToolName == "file_read" && key != "" {
+			// Paging through a large file reads one path many times
+			key += fileReadVariant(s.Input)
+		}
+		return struct{ name, key string }{s.ToolName, key}
 	}
 	// #nosec G401 -- MD5 used only for deduplication, not security
 	h := md5.Sum([]byte(s.Input))
@@ -49,7 +62,7 @@ type LoopDetector struct{}
 // DetectionResult describes a detected loop pattern.
 type DetectionResult struct {
 	Detected    bool   // whether a loop was detected
-	Rule        string // which rule triggered: "same_tool_freq", "similar_params", "consecutive_errors"
+	Rule        string // which rule triggered: "same_tool_freq", "similar_params", "consecutive_errors", "normalized_similarity"
 	Description string // human-readable description for prompt injection
 	ToolName    string // the tool that triggered the detection (for self-correction check)
 }
@@ -59,7 +72,12 @@ type DetectionResult struct {
 // Meta-tools (update_plan, walkthrough) are excluded — their repeated calls
 // are harmless bookkeeping and should not trigger loop detection.
 func (d *LoopDetector) Check(steps []StepRecord) DetectionResult {
-	toolSteps := filterNonMetaToolSteps(steps)
+	var toolSteps []StepRecord
+	for _, s := range filterNonMetaToolSteps(steps) {
+		if !loopExemptTools[s.ToolName] {
+			toolSteps = append(toolSteps, s)
+		}
+	}
 	if len(toolSteps) < 2 {
 		return DetectionResult{}
 	}
@@ -79,6 +97,11 @@ func (d *LoopDetector) Check(steps []StepRecord) DetectionResult {
 		return r
 	}
 
+	// Rule 4: near-duplicate calls after normalizing params (loop_similarity.go)
+	if r := d.checkNormalizedSimilarity(toolSteps); r.Detected {
+		return r
+	}
+
 	return DetectionResult{}
 }
 
@@ -141,8 +164,8 @@ func (d *LoopDetector) checkSimilarParams(toolSteps []StepRecord) DetectionResul
 			similar = jaccardSimilarity(bigrams(q1), bigrams(q2)) > loopSimilarityThreshold
 		}
 	case paramDedupTools[last.ToolName] == "path":
-		p1 := extractParam(prev.Input, "path")
-		p2 := extractParam(last.Input, "path")
+		p1 := toolCallKey(prev).key
+		p2 := toolCallKey(last).key
 		similar = p1 != "" && p1 == p2
 	default:
 		similar = prev.Input == last.Input
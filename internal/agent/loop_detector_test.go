@@ -25,6 +25,20 @@ func TestCheck_SameToolFrequency_Triggered(t *testing.T) {
 	}
 }
 
+func TestCheck_FetchMoreExempt(t *testing.T) {
+	// Paging through a large result repeats the same continuation token
+	steps := []StepRecord{
+		{Type: "tool", ToolName: "file_grep", Input: `{"pattern":"TODO"}`, StepNumber: 1},
+		{Type: "tool", ToolName: "fetch_more", Input: `{"token":"pabc"}`, StepNumber: 2},
+		{Type: "tool", ToolName: "fetch_more", Input: `{"token":"pabc"}`, StepNumber: 3},
+		{Type: "tool", ToolName: "fetch_more", Input: `{"token":"pabc"}`, StepNumber: 4},
+	}
+	d := LoopDetector{}
+	if r := d.Check(steps); r.Detected {
+		t.Fatalf("fetch_more paging should not trigger, got rule=%s", r.Rule)
+	}
+}
+
 func TestCheck_SameToolFrequency_HashDedup_DiffParams(t *testing.T) {
 	// Non-whitelist tool with DIFFERENT full params → hash differs → no trigger
 	steps := []StepRecord{
@@ -122,6 +136,21 @@ func TestCheck_SameToolFrequency_FileToolSamePath(t *testing.T) {
 	}
 }
 
+func TestCheck_SameToolFrequency_FileReadPaging(t *testing.T) {
+	steps := []StepRecord{
+		{Type: "tool", ToolName: "file_read", Input: `{"path":"big.go","outline":true}`, StepNumber: 1},
+		{Type: "tool", ToolName: "file_read", Input: `{"path":"big.go","start_line":1,"end_line":200}`, StepNumber: 2},
+		{Type: "tool", ToolName: "file_read", Input: `{"path":"big.go","start_line":201,"end_line":400}`, StepNumber: 3},
+	}
+	if r := (&LoopDetector{}).Check(steps); r.Detected {
+		t.Fatalf("paging through one file flagged: %+v", r)
+	}
+	steps = append(steps, StepRecord{Type: "tool", ToolName: "file_read", Input: `{"path":"big.go","start_line":201,"end_line":400}`, StepNumber: 4})
+	if r := (&LoopDetector{}).Check(steps); !r.Detected || r.Rule != "similar_params" {
+		t.Fatalf("repeated range read: got %+v, want similar_params", r)
+	}
+}
+
 func TestCheck_SameToolFrequency_ShellExecDiffCommands(t *testing.T) {
 	steps := []StepRecord{
 		{Type: "tool", ToolName: "shell_exec", Input: `{"command":"go build"}`, StepNumber: 1},
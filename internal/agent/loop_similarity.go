@@ -0,0 +1,247 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ── Rule 4: normalized parameter similarity ──
+//
+// Rules 1 and 2 compare parameters exactly (or by one key param), so a
+// useless call repeated with slight variations — a different path casing,
+// extra whitespace, "error handling" vs "handle errors" — escapes them.
+// Rule 4 normalizes every parameter into a token set and flags a loop when
+// enough recent calls of the same tool are near-duplicates of the last one.
+
+// loopSimilarity configures Rule 4 for one tool: the last call and at
+// least MinCalls-1 earlier calls in the window must reach Threshold
+// (Jaccard similarity of the normalized parameter tokens).
+type loopSimilarity struct {
+	Threshold float64
+	MinCalls  int
+}
+
+// defaultLoopSimilarity applies to tools without their own setting.
+var defaultLoopSimilarity = loopSimilarity{Threshold: 0.8, MinCalls: 3}
+
+// builtinLoopSimilarity are per-tool defaults, overridable via LOOP_SIMILARITY.
+// Search queries are stemmed and stop-word free, so rephrasings of the same
+// question already score high; a lower threshold catches them.
+var builtinLoopSimilarity = map[string]loopSimilarity{
+	"web_search":   {Threshold: 0.6, MinCalls: 3},
+	"brave_search": {Threshold: 0.6, MinCalls: 3},
+}
+
+// loopSimilarityRules holds the effective Rule 4 settings: per-tool entries,
+// "*" for the default; a tool mapped to a zero Threshold is exempt. nil
+// disables the rule.
+var loopSimilarityRules = loadLoopSimilarityRules()
+
+// loadLoopSimilarityRules reads LOOP_SIMILARITY from the environment.
+func loadLoopSimilarityRules() map[string]loopSimilarity {
+	rules, err := parseLoopSimilarityRules(os.Getenv("LOOP_SIMILARITY"))
+	if err != nil {
+		log.Printf("[Config] WARNING: invalid LOOP_SIMILARITY: %v; using defaults", err)
+		rules, _ = parseLoopSimilarityRules("")
+	}
+	return rules
+}
+
+// parseLoopSimilarityRules parses "tool=threshold[:min_calls],…" on top of
+// the defaults. "*" sets the default for all tools, "tool=off" exempts a
+// tool, and a spec of just "off" disables the rule.
+func parseLoopSimilarityRules(spec string) (map[string]loopSimilarity, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "off" {
+		return nil, nil
+	}
+	rules := map[string]loopSimilarity{"*": defaultLoopSimilarity}
+	for name, r := range builtinLoopSimilarity {
+		rules[name] = r
+	}
+	if spec == "" {
+		return rules, nil
+	}
+	for _, item := range strings.Split(spec, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%q: want tool=threshold[:min_calls]", item)
+		}
+		if value == "off" {
+			rules[name] = loopSimilarity{}
+			continue
+		}
+		r := rules["*"]
+		th, calls, hasCalls := strings.Cut(value, ":")
+		t, err := strconv.ParseFloat(th, 64)
+		if err != nil || t <= 0 || t > 1 {
+			return nil, fmt.Errorf("%q: threshold must be in (0, 1]", item)
+		}
+		r.Threshold = t
+		if hasCalls {
+			n, err := strconv.Atoi(calls)
+			if err != nil || n < 2 || n > loopWindowSize {
+				return nil, fmt.Errorf("%q: min_calls must be 2-%d", item, loopWindowSize)
+			}
+			r.MinCalls = n
+		}
+		rules[name] = r
+	}
+	return rules, nil
+}
+
+// similarityFor returns the Rule 4 setting of a tool; ok is false when the
+// tool is exempt or the rule is disabled.
+func similarityFor(rules map[string]loopSimilarity, toolName string) (loopSimilarity, bool) {
+	r, found := rules[toolName]
+	if !found {
+		r, found = rules["*"]
+	}
+	return r, found && r.Threshold > 0
+}
+
+func (d *LoopDetector) checkNormalizedSimilarity(toolSteps []StepRecord) DetectionResult {
+	window := recentWindow(toolSteps, loopWindowSize)
+	last := window[len(window)-1]
+	rule, ok := similarityFor(loopSimilarityRules, last.ToolName)
+	if !ok {
+		return DetectionResult{}
+	}
+	lastTokens := normalizedParamTokens(last.Input)
+	similar := 1
+	for _, s := range window[:len(window)-1] {
+		if s.ToolName == last.ToolName && jaccardSimilarity(lastTokens, normalizedParamTokens(s.Input)) >= rule.Threshold {
+			similar++
+		}
+	}
+	if similar < rule.MinCalls {
+		return DetectionResult{}
+	}
+	return DetectionResult{
+		Detected: true,
+		Rule:     "normalized_similarity",
+		Description: fmt.Sprintf("%s 最近 %d 次调用的参数几乎相同（归一化后相似度 ≥ %d%%）",
+			last.ToolName, similar, int(rule.Threshold*100)),
+		ToolName: last.ToolName,
+	}
+}
+
+// normalizedParamTokens turns a JSON tool input into a set of "key:token"
+// strings that ignore cosmetic differences: path-like values are cleaned
+// and lowercased as a whole, other text is lowercased, split into words,
+// stemmed and stripped of stop words (Han text becomes rune bigrams).
+// Non-JSON input is treated as one text value.
+func normalizedParamTokens(input string) map[string]bool {
+	tokens := make(map[string]bool)
+	var params any
+	if err := json.Unmarshal([]byte(input), &params); err != nil {
+		params = input
+	}
+	addParamTokens(tokens, "", params)
+	return tokens
+}
+
+func addParamTokens(tokens map[string]bool, key string, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			addParamTokens(tokens, strings.TrimPrefix(key+"."+strings.ToLower(k), "."), v[k])
+		}
+	case []any:
+		for _, item := range v {
+			addParamTokens(tokens, key, item)
+		}
+	case string:
+		if p, ok := normalizePathValue(v); ok {
+			tokens[key+":"+p] = true
+			return
+		}
+		for _, w := range textTokens(v) {
+			tokens[key+":"+w] = true
+		}
+	case nil:
+	default:
+		tokens[fmt.Sprintf("%s=%v", key, v)] = true
+	}
+}
+
+// normalizePathValue cleans a value that looks like a file path or URL (no
+// whitespace, contains a separator): slashes unified, "./" and trailing
+// "/" dropped, lowercased.
+func normalizePathValue(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, " \t\n") || !strings.ContainsAny(s, `/\`) {
+		return "", false
+	}
+	p := path.Clean(strings.ReplaceAll(s, `\`, "/"))
+	if strings.Contains(s, "://") {
+		p = strings.TrimSuffix(strings.ReplaceAll(s, `\`, "/"), "/")
+	}
+	return strings.ToLower(strings.TrimPrefix(p, "./")), true
+}
+
+// similarityStopWords are dropped from text values: they differ between
+// rephrasings without changing the request.
+var similarityStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "to": true, "in": true, "on": true,
+	"for": true, "and": true, "or": true, "with": true, "how": true, "what": true,
+	"is": true, "are": true, "do": true, "does": true, "by": true, "about": true,
+}
+
+// textTokens lowercases s and splits it into stemmed words without stop
+// words; runs of Han characters become rune bigrams.
+func textTokens(s string) []string {
+	var out []string
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if strings.IndexFunc(w, func(r rune) bool { return unicode.Is(unicode.Han, r) }) >= 0 {
+			runes := []rune(w)
+			if len(runes) == 1 {
+				out = append(out, w)
+			}
+			for i := 0; i+1 < len(runes); i++ {
+				out = append(out, string(runes[i:i+2]))
+			}
+			continue
+		}
+		if !similarityStopWords[w] {
+			out = append(out, stemWord(w))
+		}
+	}
+	return out
+}
+
+// stemWord strips common English inflections so that "handles",
+// "handling", "handled" and "handle" all become "handl". Short words are
+// kept as is.
+func stemWord(w string) string {
+	switch {
+	case len(w) > 5 && strings.HasSuffix(w, "ies"):
+		w = strings.TrimSuffix(w, "ies") + "y"
+	case len(w) > 5 && strings.HasSuffix(w, "ing"):
+		w = strings.TrimSuffix(w, "ing")
+	case len(w) > 4 && strings.HasSuffix(w, "ed"):
+		w = strings.TrimSuffix(w, "ed")
+	case len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss"):
+		w = strings.TrimSuffix(w, "s")
+	}
+	if len(w) > 3 && strings.HasSuffix(w, "e") {
+		w = strings.TrimSuffix(w, "e")
+	}
+	return w
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"testing"
+)
+
+// ── Rule 4: Normalized Similarity ──
+
+func TestCheck_NormalizedSimilarity_PathVariants(t *testing.T) {
+	// Same grep with cosmetic variations: Rule 1 (raw path key) and Rule 2
+	// (consecutive calls only) miss it
+	steps := []StepRecord{
+		{Type: "tool", ToolName: "file_grep", Input: `{"pattern":"TODO","path":"./Src/"}`, StepNumber: 1},
+		{Type: "tool", ToolName: "file_list", Input: `{"path":"docs"}`, StepNumber: 2},
+		{Type: "tool", ToolName: "file_grep", Input: `{"path":"src","pattern":" todo "}`, StepNumber: 3},
+		{Type: "tool", ToolName: "get_time", Input: `{"timezone":"UTC"}`, StepNumber: 4},
+		{Type: "tool", ToolName: "file_grep", Input: `{"pattern":"Todo","path":"SRC\\"}`, StepNumber: 5},
+	}
+	r := (&LoopDetector{}).Check(steps)
+	if !r.Detected || r.Rule != "normalized_similarity" || r.ToolName != "file_grep" {
+		t.Fatalf("got %+v, want normalized_similarity on file_grep", r)
+	}
+}
+
+func TestCheck_NormalizedSimilarity_RephrasedQueries(t *testing.T) {
+	steps := []StepRecord{
+		{Type: "tool", ToolName: "web_search", Input: `{"query":"golang error handling best practices"}`, StepNumber: 1},
+		{Type: "tool", ToolName: "web_reader", Input: `{"url":"https://go.dev/blog/errors"}`, StepNumber: 2},
+		{Type: "tool", ToolName: "web_search", Input: `{"query":"Golang handle errors best practice"}`, StepNumber: 3},
+		{Type: "tool", ToolName: "web_reader", Input: `{"url":"https://example.com/go"}`, StepNumber: 4},
+		{Type: "tool", ToolName: "web_search", Input: `{"query":"best practices for handling errors in Golang"}`, StepNumber: 5},
+	}
+	if r := (&LoopDetector{}).Check(steps); !r.Detected || r.Rule != "normalized_similarity" {
+		t.Fatalf("got %+v, want normalized_similarity", r)
+	}
+}
+
+func TestCheck_NormalizedSimilarity_DistinctCallsNotFlagged(t *testing.T) {
+	steps := []StepRecord{
+		{Type: "tool", ToolName: "shell_exec", Input: `{"command":"go test ./pkg/a"}`, StepNumber: 1},
+		{Type: "tool", ToolName: "file_read", Input: `{"path":"pkg/a/a.go"}`, StepNumber: 2},
+		{Type: "tool", ToolName: "shell_exec", Input: `{"command":"go test ./pkg/b"}`, StepNumber: 3},
+		{Type: "tool", ToolName: "file_read", Input: `{"path":"pkg/b/b.go"}`, StepNumber: 4},
+		{Type: "tool", ToolName: "shell_exec", Input: `{"command":"go vet ./pkg/c"}`, StepNumber: 5},
+	}
+	if r := (&LoopDetector{}).Check(steps); r.Detected {
+		t.Fatalf("distinct calls flagged: %+v", r)
+	}
+}
+
+func TestCheck_NormalizedSimilarity_PerToolOff(t *testing.T) {
+	saved := loopSimilarityRules
+	defer func() { loopSimilarityRules = saved }()
+	rules, err := parseLoopSimilarityRules("file_grep=off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	loopSimilarityRules = rules
+
+	steps := []StepRecord{
+		{Type: "tool", ToolName: "file_grep", Input: `{"pattern":"TODO","path":"./Src/"}`, StepNumber: 1},
+		{Type: "tool", ToolName: "file_list", Input: `{"path":"docs"}`, StepNumber: 2},
+		{Type: "tool", ToolName: "file_grep", Input: `{"path":"src","pattern":" todo "}`, StepNumber: 3},
+		{Type: "tool", ToolName: "get_time", Input: `{}`, StepNumber: 4},
+		{Type: "tool", ToolName: "file_grep", Input: `{"pattern":"Todo","path":"SRC\\"}`, StepNumber: 5},
+	}
+	if r := (&LoopDetector{}).Check(steps); r.Detected {
+		t.Fatalf("exempt tool flagged: %+v", r)
+	}
+}
+
+func TestParseLoopSimilarityRules(t *testing.T) {
+	rules, err := parseLoopSimilarityRules("*=0.9:4, web_search=0.5, shell_exec=off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, ok := similarityFor(rules, "file_read"); !ok || r != (loopSimilarity{Threshold: 0.9, MinCalls: 4}) {
+		t.Errorf("default = %+v, %v", r, ok)
+	}
+	if r, ok := similarityFor(rules, "web_search"); !ok || r.Threshold != 0.5 {
+		t.Errorf("web_search = %+v, %v", r, ok)
+	}
+	if r, ok := similarityFor(rules, "brave_search"); !ok || r.Threshold != 0.6 {
+		t.Errorf("built-in brave_search = %+v, %v", r, ok)
+	}
+	if _, ok := similarityFor(rules, "shell_exec"); ok {
+		t.Error("shell_exec should be exempt")
+	}
+
+	off, err := parseLoopSimilarityRules("off")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := similarityFor(off, "file_read"); ok {
+		t.Error(`"off" should disable the rule`)
+	}
+
+	for _, bad := range []string{"web_search", "x=1.5", "x=0", "x=0.5:1", "x=0.5:99", "=0.5"} {
+		if _, err := parseLoopSimilarityRules(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestStemWord(t *testing.T) {
+	for _, words := range [][]string{
+		{"handle", "handles", "handled", "handling"},
+		{"practice", "practices"},
+		{"library", "libraries"},
+		{"process", "processes"},
+	} {
+		want := stemWord(words[0])
+		for _, w := range words[1:] {
+			if got := stemWord(w); got != want {
+				t.Errorf("stemWord(%q) = %q, want %q", w, got, want)
+			}
+		}
+	}
+}
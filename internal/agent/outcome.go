@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// Outcome is the post-run label of an agent run.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success" // answered without hitting a limit
+	OutcomePartial Outcome = "partial" // answered, but forced or hampered
+	OutcomeFailure Outcome = "failure" // no usable answer
+)
+
+// Outcome reasons, stable identifiers aggregated on the stats page.
+const (
+	ReasonCancelled     = "user_cancelled"
+	ReasonTimeout       = "timeout"
+	ReasonNoAnswer      = "no_answer"
+	ReasonLLMError      = "llm_error"
+	ReasonMaxSteps      = "max_steps"
+	ReasonBudget        = "budget_exceeded"
+	ReasonMetaToolLoop  = "meta_tool_loop"
+	ReasonToolErrors    = "tool_errors_dominated"
+	ReasonJudgeRejected = "judge_rejected"
+)
+
+// toolErrorMinSteps and toolErrorRatio define "tool errors dominated": at
+// least this many tool steps, of which at least this fraction failed.
+const (
+	toolErrorMinSteps = 3
+	toolErrorRatio    = 0.5
+)
+
+// RunOutcome is the classification of one run.
+type RunOutcome struct {
+	Outcome Outcome  `json:"outcome"`
+	Reasons []string `json:"reasons,omitempty"`
+	Judge   string   `json:"judge,omitempty"` // LLM judge's one-line verdict, when it ran
+}
+
+// worse returns the more severe of two outcomes.
+func worse(a, b Outcome) Outcome {
+	rank := map[Outcome]int{OutcomeSuccess: 0, OutcomePartial: 1, OutcomeFailure: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// ClassifyRun labels a finished run from its state alone. runErr is the
+// cause of the run context (nil when the flow ended by itself): a deadline
+// is a timeout, any other cancellation is the user's.
+func ClassifyRun(state *AgentState, runErr error) RunOutcome {
+	o := RunOutcome{Outcome: OutcomeSuccess}
+	flag := func(reason string, outcome Outcome) {
+		o.Reasons = append(o.Reasons, reason)
+		o.Outcome = worse(o.Outcome, outcome)
+	}
+
+	switch {
+	case errors.Is(runErr, context.DeadlineExceeded):
+		flag(ReasonTimeout, OutcomeFailure)
+	case runErr != nil:
+		flag(ReasonCancelled, OutcomeFailure)
+	}
+	if strings.TrimSpace(state.Solution) == "" {
+		flag(ReasonNoAnswer, OutcomeFailure)
+	}
+	// The answer is a canned apology when the model could not be reached
+	// (a cancelled run's aborted call is already covered by its reason)
+	if state.LLMError != "" && runErr == nil {
+		flag(ReasonLLMError, OutcomeFailure)
+	}
+	// ForcedAnswer holds one of ReasonMaxSteps, ReasonBudget, ReasonMetaToolLoop
+	if state.ForcedAnswer != "" {
+		flag(state.ForcedAnswer, OutcomePartial)
+	}
+	tools, failed := 0, 0
+	for _, s := range state.StepHistory {
+		if s.Type == "tool" {
+			tools++
+			if s.IsError {
+				failed++
+			}
+		}
+	}
+	if tools >= toolErrorMinSteps && float64(failed) >= toolErrorRatio*float64(tools) {
+		flag(ReasonToolErrors, OutcomePartial)
+	}
+	return o
+}
+
+// OutcomeJudge asks the model whether a run's answer actually resolves the
+// problem. It can only downgrade the rule-based outcome: the rules see hard
+// limits, the judge sees answer quality.
+type OutcomeJudge struct {
+	provider llm.LLMProvider
+	timeout  time.Duration
+}
+
+// NewOutcomeJudge creates a judge using provider; nil provider = nil judge.
+func NewOutcomeJudge(provider llm.LLMProvider) *OutcomeJudge {
+	if provider == nil {
+		return nil
+	}
+	return &OutcomeJudge{provider: provider, timeout: 30 * time.Second}
+}
+
+// judgeVerdict is the JSON the judge is asked to return.
+type judgeVerdict struct {
+	Outcome Outcome `json:"outcome"`
+	Reason  string  `json:"reason"`
+}
+
+// Judge refines o for a run that the rules consider successful; other
+// outcomes are returned unchanged. Judge errors keep the rule outcome.
+// Nil-safe.
+func (j *OutcomeJudge) Judge(ctx context.Context, state *AgentState, o RunOutcome) RunOutcome {
+	if j == nil || o.Outcome != OutcomeSuccess {
+		return o
+	}
+
+	var sb strings.Builder
+	sb.WriteString("你是代理运行结果的评审。根据用户问题、执行步骤和最终回答，判断回答是否真正解决了问题。\n")
+	sb.WriteString(`只输出一个 JSON 对象：{"outcome": "success|partial|failure", "reason": "一句话理由"}` + "\n")
+	sb.WriteString("success = 完整解决；partial = 部分解决或有明显遗漏；failure = 未解决、答非所问或只是在道歉。\n\n")
+	fmt.Fprintf(&sb, "## 用户问题\n%s\n\n", truncate(state.Problem, 2000))
+	sb.WriteString("## 执行步骤\n")
+	for _, s := range state.StepHistory {
+		switch s.Type {
+		case "tool":
+			status := "ok"
+			if s.IsError {
+				status = "error"
+			}
+			fmt.Fprintf(&sb, "- %d. tool %s (%s): %s\n", s.StepNumber, s.ToolName, status, truncate(s.Output, 200))
+		case "think":
+			fmt.Fprintf(&sb, "- %d. think: %s\n", s.StepNumber, truncate(s.Output, 200))
+		}
+	}
+	fmt.Fprintf(&sb, "\n## 最终回答\n%s\n", truncate(state.Solution, 4000))
+
+	// The run has already answered: the verdict may wait for interactive calls
+	jctx, cancel := context.WithTimeout(llm.WithPriority(ctx, llm.PriorityBackground), j.timeout)
+	defer cancel()
+	resp, err := j.provider.CallLLM(jctx, []llm.Message{{Role: llm.RoleUser, Content: sb.String()}})
+	if err != nil {
+		log.Printf("[Outcome] Judge failed: %v", err)
+		return o
+	}
+	v, ok := parseJudgeVerdict(resp.Content)
+	if !ok {
+		log.Printf("[Outcome] Judge returned no verdict: %s", truncate(resp.Content, 200))
+		return o
+	}
+	o.Judge = v.Reason
+	if v.Outcome != OutcomeSuccess {
+		o.Reasons = append(o.Reasons, ReasonJudgeRejected)
+		o.Outcome = worse(o.Outcome, v.Outcome)
+	}
+	return o
+}
+
+// parseJudgeVerdict extracts the verdict object, tolerating code fences and
+// surrounding prose.
+func parseJudgeVerdict(s string) (judgeVerdict, bool) {
+	start, end := strings.Index(s, "{"), strings.LastIndex(s, "}")
+	if start < 0 || end < start {
+		return judgeVerdict{}, false
+	}
+	var v judgeVerdict
+	if err := json.Unmarshal([]byte(s[start:end+1]), &v); err != nil {
+		return judgeVerdict{}, false
+	}
+	v.Outcome = Outcome(strings.ToLower(strings.TrimSpace(string(v.Outcome))))
+	switch v.Outcome {
+	case OutcomeSuccess, OutcomePartial, OutcomeFailure:
+		return v, true
+	}
+	return judgeVerdict{}, false
+}
@@ -0,0 +1,220 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// outcomeStatsWindow is the number of most recent runs OutcomeLog.Stats
+// aggregates, so the page reflects current behavior and stays cheap.
+const outcomeStatsWindow = 1000
+
+// RunRecord is the metadata of one finished run, one JSON line in the
+// outcome log. Unlike replay files it is small and never pruned.
+type RunRecord struct {
+	Time       time.Time      `json:"time"`
+	RunID      string         `json:"run_id,omitempty"`
+	SessionID  string         `json:"session_id,omitempty"`
+	Problem    string         `json:"problem"` // truncated
+	Steps      int            `json:"steps"`
+	ToolCalls  int            `json:"tool_calls"`
+	ToolErrors map[string]int `json:"tool_errors,omitempty"` // tool name → failed calls
+	TokensUsed int64          `json:"tokens_used,omitempty"`
+	ElapsedMs  int64          `json:"elapsed_ms"`
+	Replay     string         `json:"replay,omitempty"` // replay file name, if recorded
+	RunOutcome
+}
+
+// NewRunRecord builds the record of a finished run from its state.
+func NewRunRecord(state *AgentState, o RunOutcome) RunRecord {
+	rec := RunRecord{
+		Time:       time.Now(),
+		Problem:    truncate(state.Problem, 200),
+		Steps:      len(state.StepHistory),
+		RunOutcome: o,
+	}
+	for _, s := range state.StepHistory {
+		if s.Type != "tool" {
+			continue
+		}
+		rec.ToolCalls++
+		if s.IsError {
+			if rec.ToolErrors == nil {
+				rec.ToolErrors = make(map[string]int)
+			}
+			rec.ToolErrors[s.ToolName]++
+		}
+	}
+	if state.CostGuard != nil {
+		rec.TokensUsed = state.CostGuard.UsedTokens()
+	}
+	if p := state.Replay.Path(); p != "" {
+		rec.Replay = filepath.Base(p)
+	}
+	return rec
+}
+
+// OutcomeLog appends run records to a JSONL file. Thread-safe, nil-safe.
+type OutcomeLog struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewOutcomeLog creates a log writing to path, creating its directory.
+func NewOutcomeLog(path string) (*OutcomeLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create outcome log dir: %w", err)
+	}
+	return &OutcomeLog{path: path}, nil
+}
+
+// Append writes one record.
+func (l *OutcomeLog) Append(rec RunRecord) error {
+	if l == nil {
+		return nil
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Recent returns up to n of the most recent records, oldest first.
+// Malformed lines (e.g. a torn write) are skipped.
+func (l *OutcomeLog) Recent(n int) ([]RunRecord, error) {
+	if l == nil {
+		return nil, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []RunRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		var rec RunRecord
+		if json.Unmarshal(sc.Bytes(), &rec) != nil {
+			continue
+		}
+		recs = append(recs, rec)
+		if len(recs) > 2*n {
+			recs = append(recs[:0], recs[len(recs)-n:]...)
+		}
+	}
+	if len(recs) > n {
+		recs = recs[len(recs)-n:]
+	}
+	return recs, sc.Err()
+}
+
+// Count is a name with a number of runs or calls.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// OutcomeStats aggregates recent run records.
+type OutcomeStats struct {
+	Runs         int            `json:"runs"`
+	Since        time.Time      `json:"since,omitempty"` // time of the oldest aggregated run
+	Outcomes     map[string]int `json:"outcomes"`        // outcome → runs
+	Reasons      []Count        `json:"reasons"`         // reason → runs, most frequent first
+	ToolErrors   []Count        `json:"tool_errors"`     // tool → failed calls, most frequent first
+	AvgSteps     float64        `json:"avg_steps"`
+	AvgElapsedMs float64        `json:"avg_elapsed_ms"`
+	Recent       []RunRecord    `json:"recent"` // latest non-successful runs, newest first
+}
+
+// SuccessRate returns the fraction of successful runs (0 without runs).
+func (s OutcomeStats) SuccessRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Outcomes[string(OutcomeSuccess)]) / float64(s.Runs)
+}
+
+// Stats aggregates the last outcomeStatsWindow runs.
+func (l *OutcomeLog) Stats() (OutcomeStats, error) {
+	recs, err := l.Recent(outcomeStatsWindow)
+	if err != nil {
+		return OutcomeStats{}, err
+	}
+	return AggregateOutcomes(recs, 20), nil
+}
+
+// AggregateOutcomes summarizes recs (oldest first), keeping up to
+// recentFailures of the latest non-successful runs for inspection.
+func AggregateOutcomes(recs []RunRecord, recentFailures int) OutcomeStats {
+	s := OutcomeStats{
+		Runs: len(recs),
+		Outcomes: map[string]int{
+			string(OutcomeSuccess): 0, string(OutcomePartial): 0, string(OutcomeFailure): 0,
+		},
+	}
+	if len(recs) == 0 {
+		return s
+	}
+	s.Since = recs[0].Time
+	reasons := make(map[string]int)
+	toolErrors := make(map[string]int)
+	var steps, elapsed int64
+	for _, r := range recs {
+		s.Outcomes[string(r.Outcome)]++
+		for _, reason := range r.Reasons {
+			reasons[reason]++
+		}
+		for name, n := range r.ToolErrors {
+			toolErrors[name] += n
+		}
+		steps += int64(r.Steps)
+		elapsed += r.ElapsedMs
+	}
+	s.AvgSteps = float64(steps) / float64(len(recs))
+	s.AvgElapsedMs = float64(elapsed) / float64(len(recs))
+	s.Reasons = sortedCounts(reasons)
+	s.ToolErrors = sortedCounts(toolErrors)
+	for i := len(recs) - 1; i >= 0 && len(s.Recent) < recentFailures; i-- {
+		if recs[i].Outcome != OutcomeSuccess {
+			s.Recent = append(s.Recent, recs[i])
+		}
+	}
+	return s
+}
+
+// sortedCounts orders m by count descending, then name.
+func sortedCounts(m map[string]int) []Count {
+	out := make([]Count, 0, len(m))
+	for name, n := range m {
+		out = append(out, Count{Name: name, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
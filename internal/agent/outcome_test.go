@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+func toolSteps(errs ...bool) []StepRecord {
+	var steps []StepRecord
+	for i, isErr := range errs {
+		steps = append(steps, StepRecord{StepNumber: i + 1, Type: "tool", ToolName: "shell_exec", IsError: isErr})
+	}
+	return steps
+}
+
+func TestClassifyRun(t *testing.T) {
+	cases := []struct {
+		name    string
+		state   AgentState
+		runErr  error
+		want    Outcome
+		reasons []string
+	}{
+		{"clean answer", AgentState{Solution: "done", StepHistory: toolSteps(false, true)}, nil, OutcomeSuccess, nil},
+		{"no answer", AgentState{}, nil, OutcomeFailure, []string{ReasonNoAnswer}},
+		{"cancelled", AgentState{Solution: "已取消"}, errors.New("cancelled by user"), OutcomeFailure, []string{ReasonCancelled}},
+		{"timeout", AgentState{Solution: "partial"}, context.DeadlineExceeded, OutcomeFailure, []string{ReasonTimeout}},
+		{"max steps", AgentState{Solution: "best effort", ForcedAnswer: ReasonMaxSteps}, nil, OutcomePartial, []string{ReasonMaxSteps}},
+		{"tool errors dominated", AgentState{Solution: "x", StepHistory: toolSteps(true, false, true)}, nil, OutcomePartial, []string{ReasonToolErrors}},
+		{"few tool errors", AgentState{Solution: "x", StepHistory: toolSteps(true, true)}, nil, OutcomeSuccess, nil},
+		{"llm error", AgentState{Solution: "抱歉，处理过程中遇到问题，请稍后重试。", LLMError: "connection refused"}, nil, OutcomeFailure, []string{ReasonLLMError}},
+		{"budget and errors", AgentState{Solution: "x", ForcedAnswer: ReasonBudget, StepHistory: toolSteps(true, true, true)},
+			nil, OutcomePartial, []string{ReasonBudget, ReasonToolErrors}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := ClassifyRun(&tc.state, tc.runErr)
+			if got.Outcome != tc.want || !reflect.DeepEqual(got.Reasons, tc.reasons) {
+				t.Errorf("ClassifyRun = %s %v, want %s %v", got.Outcome, got.Reasons, tc.want, tc.reasons)
+			}
+		})
+	}
+}
+
+func TestDecideFallback_MarksLLMError(t *testing.T) {
+	state := &AgentState{}
+	n := &DecideNode{}
+	n.Post(state, nil, n.ExecFallback(errors.New("connection refused")))
+	if state.LLMError == "" {
+		t.Fatal("fallback decision should record the LLM error")
+	}
+	state.Solution = "x"
+	if got := ClassifyRun(state, nil); got.Outcome != OutcomeFailure {
+		t.Errorf("outcome = %s, want failure", got.Outcome)
+	}
+}
+
+func TestOutcomeJudge(t *testing.T) {
+	state := &AgentState{Problem: "列出 go.mod 的依赖", Solution: "抱歉，我无法完成。"}
+	success := RunOutcome{Outcome: OutcomeSuccess}
+
+	cases := []struct {
+		name    string
+		reply   string
+		err     error
+		want    Outcome
+		reasons []string
+		judge   string
+	}{
+		{"rejects", "```json\n{\"outcome\": \"Failure\", \"reason\": \"只是在道歉\"}\n```", nil,
+			OutcomeFailure, []string{ReasonJudgeRejected}, "只是在道歉"},
+		{"confirms", `{"outcome":"success","reason":"ok"}`, nil, OutcomeSuccess, nil, "ok"},
+		{"garbage keeps rules", "I think it is fine", nil, OutcomeSuccess, nil, ""},
+		{"unknown outcome keeps rules", `{"outcome":"great"}`, nil, OutcomeSuccess, nil, ""},
+		{"error keeps rules", "", errors.New("rate limited"), OutcomeSuccess, nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			j := NewOutcomeJudge(&mockLLMProvider{callLLMResp: llm.Message{Content: tc.reply}, callLLMErr: tc.err})
+			got := j.Judge(context.Background(), state, success)
+			if got.Outcome != tc.want || !reflect.DeepEqual(got.Reasons, tc.reasons) || got.Judge != tc.judge {
+				t.Errorf("Judge = %+v, want %s %v %q", got, tc.want, tc.reasons, tc.judge)
+			}
+		})
+	}
+
+	// Runs the rules already flagged are not sent to the judge
+	failed := RunOutcome{Outcome: OutcomeFailure, Reasons: []string{ReasonTimeout}}
+	j := NewOutcomeJudge(&mockLLMProvider{callLLMErr: errors.New("must not be called")})
+	if got := j.Judge(context.Background(), state, failed); !reflect.DeepEqual(got, failed) {
+		t.Errorf("Judge changed a failed outcome: %+v", got)
+	}
+	var nilJudge *OutcomeJudge
+	if got := nilJudge.Judge(context.Background(), state, success); !reflect.DeepEqual(got, success) {
+		t.Errorf("nil judge changed the outcome: %+v", got)
+	}
+}
+
+// gatedProvider blocks each call until release is closed and records the
+// priority of the calls in the order they started.
+type gatedProvider struct {
+	mockLLMProvider
+	mu      sync.Mutex
+	started []llm.Priority
+	release chan struct{}
+}
+
+func (p *gatedProvider) CallLLM(ctx context.Context, msgs []llm.Message) (llm.Message, error) {
+	p.mu.Lock()
+	p.started = append(p.started, llm.PriorityFrom(ctx))
+	p.mu.Unlock()
+	<-p.release
+	return llm.Message{Role: llm.RoleAssistant, Content: `{"outcome":"success","reason":"ok"}`}, nil
+}
+
+func TestOutcomeJudge_YieldsToInteractiveCalls(t *testing.T) {
+	p := &gatedProvider{release: make(chan struct{})}
+	s := llm.NewScheduler(p, 1)
+	waitFor := func(cond func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out")
+			}
+		}
+	}
+	queued := func(class string, n int) func() bool {
+		return func() bool { return s.Stats().Priorities[class].Queued == n }
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() { defer wg.Done(); s.CallLLM(context.Background(), nil) }() // holds the only slot
+	waitFor(func() bool { p.mu.Lock(); defer p.mu.Unlock(); return len(p.started) == 1 })
+	go func() {
+		defer wg.Done()
+		NewOutcomeJudge(s).Judge(context.Background(), &AgentState{Problem: "p", Solution: "s"}, RunOutcome{Outcome: OutcomeSuccess})
+	}()
+	waitFor(queued("background", 1))
+	go func() { defer wg.Done(); s.CallLLM(context.Background(), nil) }()
+	waitFor(queued("interactive", 1))
+
+	close(p.release)
+	wg.Wait()
+	want := []llm.Priority{llm.PriorityInteractive, llm.PriorityInteractive, llm.PriorityBackground}
+	if !reflect.DeepEqual(p.started, want) {
+		t.Errorf("started %v, want the judge after the later interactive call", p.started)
+	}
+}
+
+func TestOutcomeLog_AppendAndStats(t *testing.T) {
+	l, err := NewOutcomeLog(filepath.Join(t.TempDir(), "logs", "outcomes.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := l.Stats(); err != nil || s.Runs != 0 {
+		t.Fatalf("empty log stats = %+v, %v", s, err)
+	}
+
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []RunRecord{
+		{Time: base, Problem: "a", Steps: 4, ElapsedMs: 1000, RunOutcome: RunOutcome{Outcome: OutcomeSuccess}},
+		{Time: base.Add(time.Minute), Problem: "b", Steps: 10, ElapsedMs: 3000, ToolErrors: map[string]int{"shell_exec": 3, "file_read": 1},
+			RunOutcome: RunOutcome{Outcome: OutcomePartial, Reasons: []string{ReasonMaxSteps, ReasonToolErrors}}},
+		{Time: base.Add(2 * time.Minute), Problem: "c", Steps: 1, ElapsedMs: 2000, ToolErrors: map[string]int{"shell_exec": 1},
+			RunOutcome: RunOutcome{Outcome: OutcomeFailure, Reasons: []string{ReasonCancelled}}},
+	}
+	for _, r := range records {
+		if err := l.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A torn line must not hide the other records
+	f, _ := os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString(`{"time":"2026-01-02T03:0` + "\n")
+	f.Close()
+
+	s, err := l.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Runs != 3 || !s.Since.Equal(base) {
+		t.Errorf("runs = %d since %v", s.Runs, s.Since)
+	}
+	wantOutcomes := map[string]int{"success": 1, "partial": 1, "failure": 1}
+	if !reflect.DeepEqual(s.Outcomes, wantOutcomes) {
+		t.Errorf("outcomes = %v", s.Outcomes)
+	}
+	wantReasons := []Count{{ReasonMaxSteps, 1}, {ReasonToolErrors, 1}, {ReasonCancelled, 1}} // ties by name
+	if !reflect.DeepEqual(s.Reasons, wantReasons) {
+		t.Errorf("reasons = %v", s.Reasons)
+	}
+	if want := []Count{{"shell_exec", 4}, {"file_read", 1}}; !reflect.DeepEqual(s.ToolErrors, want) {
+		t.Errorf("tool errors = %v", s.ToolErrors)
+	}
+	if s.AvgSteps != 5 || s.AvgElapsedMs != 2000 {
+		t.Errorf("averages = %v steps, %v ms", s.AvgSteps, s.AvgElapsedMs)
+	}
+	if len(s.Recent) != 2 || s.Recent[0].Problem != "c" || s.Recent[1].Problem != "b" {
+		t.Errorf("recent = %+v", s.Recent)
+	}
+	if r := s.SuccessRate(); r < 0.33 || r > 0.34 {
+		t.Errorf("success rate = %v", r)
+	}
+}
+
+func TestOutcomeLog_RecentWindow(t *testing.T) {
+	l, _ := NewOutcomeLog(filepath.Join(t.TempDir(), "outcomes.jsonl"))
+	for i := range 25 {
+		l.Append(RunRecord{Steps: i, RunOutcome: RunOutcome{Outcome: OutcomeSuccess}})
+	}
+	recs, err := l.Recent(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 10 || recs[0].Steps != 15 || recs[9].Steps != 24 {
+		t.Errorf("recent = %d records, first %d last %d", len(recs), recs[0].Steps, recs[len(recs)-1].Steps)
+	}
+}
+
+func TestNewRunRecord(t *testing.T) {
+	state := &AgentState{
+		Problem:     "修复构建",
+		StepHistory: append(toolSteps(true, false, true), StepRecord{Type: "answer"}),
+	}
+	rec := NewRunRecord(state, RunOutcome{Outcome: OutcomePartial})
+	if rec.Steps != 4 || rec.ToolCalls != 3 || rec.ToolErrors["shell_exec"] != 2 || rec.Problem != "修复构建" {
+		t.Errorf("record = %+v", rec)
+	}
+}
@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+const (
+	// outputStoreMaxFiles caps the archived outputs kept on disk; the oldest
+	// files are pruned beyond this.
+	outputStoreMaxFiles = 200
+
+	// extractiveOutlineMax caps the outline lines kept from the middle part.
+	extractiveOutlineMax = 40
+
+	// llmSummaryMaxInputRunes bounds what LLMSummarizer sends to the model.
+	llmSummaryMaxInputRunes = 24000
+)
+
+// OutputRefPattern matches reference IDs issued by OutputProcessor.
+var OutputRefPattern = regexp.MustCompile(`^o[0-9a-f]{12}$`)
+
+// skipOutputProcessing lists tools whose output is already a bounded view of
+// a larger result; summarizing them again would make the full data unreachable.
+var skipOutputProcessing = map[string]bool{
+	"fetch_more":  true,
+	"output_read": true,
+	"walkthrough": true,
+	"update_plan": true,
+}
+
+// Summarizer condenses a large tool output into something that fits the
+// step summary budget. maxRunes is the target size of the result.
+type Summarizer interface {
+	Summarize(ctx context.Context, toolName, output string, maxRunes int) (string, error)
+}
+
+// OutputProcessor keeps oversized tool outputs out of StepHistory: the full
+// output is archived under dir and replaced by a summary plus a reference ID
+// that output_read can resolve. Summarizers are registered per tool; tools
+// without one use the extractive fallback. Safe for concurrent use after setup.
+type OutputProcessor struct {
+	dir       string
+	threshold int // runes; outputs at or below this pass through unchanged
+	fallback  Summarizer
+	perTool   map[string]Summarizer
+}
+
+// NewOutputProcessor creates a processor archiving into dir. Returns nil
+// (disabled) when thresholdRunes <= 0 or dir is empty.
+func NewOutputProcessor(dir string, thresholdRunes int) *OutputProcessor {
+	if thresholdRunes <= 0 || dir == "" {
+		return nil
+	}
+	return &OutputProcessor{
+		dir:       dir,
+		threshold: thresholdRunes,
+		fallback:  ExtractiveSummarizer{HeadLines: 60, TailLines: 20},
+		perTool:   make(map[string]Summarizer),
+	}
+}
+
+// Register sets the summarizer used for toolName's outputs.
+func (p *OutputProcessor) Register(toolName string, s Summarizer) *OutputProcessor {
+	p.perTool[toolName] = s
+	return p
+}
+
+// Threshold returns the size in runes above which outputs are summarized.
+func (p *OutputProcessor) Threshold() int { return p.threshold }
+
+// Process returns output unchanged when it is small enough (or p is nil).
+// Otherwise it archives output and returns the summary with a reference
+// footer, plus the reference ID. Archive or summarizer failures degrade to
+// the extractive summary or, as a last resort, the unchanged output.
+func (p *OutputProcessor) Process(ctx context.Context, toolName, output string) (string, string) {
+	if p == nil || skipOutputProcessing[toolName] {
+		return output, ""
+	}
+	total := len([]rune(output))
+	if total <= p.threshold {
+		return output, ""
+	}
+
+	ref, err := p.save(output)
+	if err != nil {
+		log.Printf("[OutputProcessor] Archive %s output failed: %v", toolName, err)
+		return output, ""
+	}
+
+	budget := p.threshold / 2
+	s := p.perTool[toolName]
+	if s == nil {
+		s = p.fallback
+	}
+	summary, err := s.Summarize(ctx, toolName, output, budget)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		if err != nil {
+			log.Printf("[OutputProcessor] Summarize %s failed, using extractive fallback: %v", toolName, err)
+		}
+		summary, _ = p.fallback.Summarize(ctx, toolName, output, budget)
+	}
+	log.Printf("[OutputProcessor] %s output %d runes → summary %d runes (ref=%s)", toolName, total, len([]rune(summary)), ref)
+	return summary + fmt.Sprintf("\n---\n[输出过长（%d 字符），以上为摘要；完整内容已保存 ref=%s，如需细节请调用 output_read(ref=%q)]", total, ref, ref), ref
+}
+
+// Path returns the archive file for ref, or "" when ref is malformed.
+func (p *OutputProcessor) Path(ref string) string {
+	return OutputRefPath(p.dir, ref)
+}
+
+// OutputRefPath returns the archive file for ref inside dir, or "" when ref
+// is malformed (which also rules out path traversal).
+func OutputRefPath(dir, ref string) string {
+	if !OutputRefPattern.MatchString(ref) {
+		return ""
+	}
+	return filepath.Join(dir, ref+".txt")
+}
+
+// save writes output to a new archive file and prunes old ones.
+func (p *OutputProcessor) save(output string) (string, error) {
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return "", err
+	}
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	ref := "o" + hex.EncodeToString(b)
+	if err := os.WriteFile(p.Path(ref), []byte(output), 0o644); err != nil {
+		return "", err
+	}
+	p.prune()
+	return ref, nil
+}
+
+// prune removes the oldest archives beyond outputStoreMaxFiles.
+func (p *OutputProcessor) prune() {
+	entries, err := os.ReadDir(p.dir)
+	if err != nil || len(entries) <= outputStoreMaxFiles {
+		return
+	}
+	type aged struct {
+		name string
+		mod  time.Time
+	}
+	var files []aged
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && !e.IsDir() {
+			files = append(files, aged{e.Name(), info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.Before(files[j].mod) })
+	for i := 0; i < len(files)-outputStoreMaxFiles; i++ {
+		os.Remove(filepath.Join(p.dir, files[i].name))
+	}
+}
+
+// ── Summarizers ──
+
+// ExtractiveSummarizer keeps the first HeadLines and last TailLines lines
+// plus outline lines (declarations, headings) from the middle, so the model
+// sees the structure of a large file or page without an LLM call.
+type ExtractiveSummarizer struct {
+	HeadLines int
+	TailLines int
+}
+
+// outlineLine matches declaration and heading lines worth keeping from the
+// omitted middle of an output.
+var outlineLine = regexp.MustCompile(`^\s*(func |type |class |def |async def |interface |struct |impl |fn |pub fn |export |#{1,3} |package |module )`)
+
+func (e ExtractiveSummarizer) Summarize(_ context.Context, _ string, output string, maxRunes int) (string, error) {
+	lines := strings.Split(output, "\n")
+	head, tail := e.HeadLines, e.TailLines
+	if head+tail >= len(lines) {
+		return truncate(output, maxRunes), nil
+	}
+
+	// Budget: tail and outline get up to a quarter each, the head the rest,
+	// so a few very long head lines cannot crowd out the end of the output.
+	tailText := strings.Join(lines[len(lines)-tail:], "\n")
+	if maxRunes > 0 {
+		tailText = truncateHead(tailText, maxRunes/4)
+	}
+
+	middle := lines[head : len(lines)-tail]
+	var outline strings.Builder
+	fmt.Fprintf(&outline, "\n\n… 省略第 %d-%d 行（共 %d 行）…\n", head+1, len(lines)-tail, len(lines))
+	kept := 0
+	for i, l := range middle {
+		if !outlineLine.MatchString(l) {
+			continue
+		}
+		entry := fmt.Sprintf("%6d: %s\n", head+i+1, truncate(strings.TrimRight(l, " \t"), 160))
+		if kept == 0 {
+			entry = "[省略部分的结构行]\n" + entry
+		}
+		if kept >= extractiveOutlineMax || (maxRunes > 0 && len([]rune(outline.String()+entry)) > maxRunes/4) {
+			outline.WriteString("…\n")
+			break
+		}
+		outline.WriteString(entry)
+		kept++
+	}
+	outline.WriteString("\n")
+
+	headText := strings.Join(lines[:head], "\n")
+	if maxRunes > 0 {
+		budget := maxRunes - len([]rune(tailText)) - len([]rune(outline.String()))
+		if budget < 0 {
+			budget = 0
+		}
+		if len([]rune(headText)) > budget {
+			headText = string([]rune(headText)[:budget]) + "..."
+		}
+	}
+	return headText + outline.String() + tailText, nil
+}
+
+// truncateHead keeps the last maxRunes runes of s.
+func truncateHead(s string, maxRunes int) string {
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	return "..." + string(runes[len(runes)-maxRunes:])
+}
+
+// LLMSummarizer asks the model for a dense summary. Suited to prose such as
+// web pages; code is better served by ExtractiveSummarizer.
+type LLMSummarizer struct {
+	provider llm.LLMProvider
+}
+
+// NewLLMSummarizer returns nil when provider is nil.
+func NewLLMSummarizer(provider llm.LLMProvider) *LLMSummarizer {
+	if provider == nil {
+		return nil
+	}
+	return &LLMSummarizer{provider: provider}
+}
+
+func (s *LLMSummarizer) Summarize(ctx context.Context, toolName, output string, maxRunes int) (string, error) {
+	input := output
+	if runes := []rune(input); len(runes) > llmSummaryMaxInputRunes {
+		input = string(runes[:llmSummaryMaxInputRunes]) + "\n…(truncated)"
+	}
+	resp, err := s.provider.CallLLM(llm.WithModelRole(ctx, llm.ModelSummarize), []llm.Message{
+		{Role: llm.RoleSystem, Content: fmt.Sprintf(
+			"Summarize the output of the %q tool for an agent that will decide its next step from it. "+
+				"Keep concrete facts: names, paths, numbers, URLs, error messages and code identifiers. "+
+				"Use the same language as the text. At most %d characters. Output only the summary.",
+			toolName, maxRunes)},
+		{Role: llm.RoleUser, Content: input},
+	})
+	if err != nil {
+		return "", fmt.Errorf("summarize: %w", err)
+	}
+	return truncate(strings.TrimSpace(resp.Content), maxRunes), nil
+}
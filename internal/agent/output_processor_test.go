@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func bigOutput(lines int) string {
+	var sb strings.Builder
+	for i := 1; i <= lines; i++ {
+		if i == 500 {
+			sb.WriteString("func middleDeclaration() {\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "line %d: some moderately long content to fill the output\n", i)
+	}
+	return sb.String()
+}
+
+func TestOutputProcessor_SmallOutputPassesThrough(t *testing.T) {
+	p := NewOutputProcessor(t.TempDir(), 1000)
+	out, ref := p.Process(context.Background(), "file_read", "short")
+	if out != "short" || ref != "" {
+		t.Errorf("got (%q, %q), want unchanged", out, ref)
+	}
+}
+
+func TestOutputProcessor_NilAndDisabled(t *testing.T) {
+	if NewOutputProcessor(t.TempDir(), 0) != nil {
+		t.Error("threshold 0 should disable the processor")
+	}
+	var p *OutputProcessor
+	if out, ref := p.Process(context.Background(), "file_read", bigOutput(1000)); ref != "" || len(out) < 1000 {
+		t.Error("nil processor must return output unchanged")
+	}
+}
+
+func TestOutputProcessor_ArchivesAndSummarizes(t *testing.T) {
+	dir := t.TempDir()
+	p := NewOutputProcessor(dir, 2000)
+	full := bigOutput(1000)
+
+	out, ref := p.Process(context.Background(), "file_read", full)
+	if !OutputRefPattern.MatchString(ref) {
+		t.Fatalf("ref = %q, want a valid reference ID", ref)
+	}
+	if len([]rune(out)) > 2000 {
+		t.Errorf("summary is %d runes, should fit the threshold", len([]rune(out)))
+	}
+	for _, want := range []string{"line 1:", "line 1000:", "middleDeclaration", "ref=" + ref, "output_read"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q", want)
+		}
+	}
+	data, err := os.ReadFile(p.Path(ref))
+	if err != nil || string(data) != full {
+		t.Errorf("archived output mismatch: err=%v", err)
+	}
+}
+
+func TestOutputProcessor_SkipsContinuationTools(t *testing.T) {
+	p := NewOutputProcessor(t.TempDir(), 100)
+	full := bigOutput(100)
+	for _, name := range []string{"output_read", "fetch_more"} {
+		if out, ref := p.Process(context.Background(), name, full); out != full || ref != "" {
+			t.Errorf("%s output must never be summarized", name)
+		}
+	}
+}
+
+func TestOutputProcessor_PerToolSummarizer(t *testing.T) {
+	p := NewOutputProcessor(t.TempDir(), 500)
+	p.Register("web_reader", NewLLMSummarizer(&mockLLMProvider{callLLMResp: llm.Message{Content: "LLM summary"}}))
+
+	out, _ := p.Process(context.Background(), "web_reader", bigOutput(100))
+	if !strings.HasPrefix(out, "LLM summary") {
+		t.Errorf("web_reader should use the registered summarizer, got %q", truncate(out, 80))
+	}
+	out, _ = p.Process(context.Background(), "file_read", bigOutput(100))
+	if !strings.HasPrefix(out, "line 1:") {
+		t.Errorf("file_read should use the extractive fallback, got %q", truncate(out, 80))
+	}
+}
+
+func TestOutputProcessor_SummarizerErrorFallsBack(t *testing.T) {
+	p := NewOutputProcessor(t.TempDir(), 500)
+	p.Register("web_reader", NewLLMSummarizer(&mockLLMProvider{callLLMErr: errors.New("rate limited")}))
+
+	out, ref := p.Process(context.Background(), "web_reader", bigOutput(100))
+	if ref == "" || !strings.HasPrefix(out, "line 1:") {
+		t.Errorf("LLM failure should fall back to extractive summary, got ref=%q out=%q", ref, truncate(out, 80))
+	}
+}
+
+func TestOutputRefPath_RejectsTraversal(t *testing.T) {
+	for _, ref := range []string{"../etc/passwd", "o123", "oABCDEF123456", ""} {
+		if got := OutputRefPath("/tmp", ref); got != "" {
+			t.Errorf("OutputRefPath(%q) = %q, want empty", ref, got)
+		}
+	}
+}
+
+// bigTool returns a fixed large output.
+type bigTool struct{ out string }
+
+func (b *bigTool) Name() string                 { return "file_read" }
+func (b *bigTool) Description() string          { return "" }
+func (b *bigTool) InputSchema() json.RawMessage { return json.RawMessage(`{}`) }
+func (b *bigTool) Execute(_ context.Context, _ json.RawMessage) (tool.ToolResult, error) {
+	return tool.ToolResult{Output: b.out}, nil
+}
+func (b *bigTool) Init(_ context.Context) error { return nil }
+func (b *bigTool) Close() error                 { return nil }
+
+func TestToolNode_StoresSummaryAndRefInStepHistory(t *testing.T) {
+	reg := tool.NewRegistry()
+	reg.Register(&bigTool{out: bigOutput(1000)})
+	state := &AgentState{
+		ToolRegistry:    reg,
+		LastDecision:    &Decision{Action: "tool", ToolName: "file_read", ToolParams: map[string]any{"path": "big.go"}},
+		OutputProcessor: NewOutputProcessor(t.TempDir(), 2000),
+	}
+	node := NewToolNode(reg)
+	prep := node.Prep(state)
+	res, err := node.Exec(context.Background(), prep[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Post(state, prep, res)
+
+	step := state.StepHistory[0]
+	if step.OutputRef == "" || !strings.Contains(step.Output, step.OutputRef) {
+		t.Errorf("step should carry the summary and its ref, got ref=%q", step.OutputRef)
+	}
+	if len([]rune(step.Output)) > 2000 {
+		t.Errorf("step output is %d runes, full output leaked into StepHistory", len([]rune(step.Output)))
+	}
+}
+
+func TestToolNode_RedactsSecretsInStepHistory(t *testing.T) {
+	reg := tool.NewRegistry()
+	reg.Register(&bigTool{out: "export OPENAI_API_KEY=sk-proj-AbCdEf0123456789XyZw\nok"})
+	state := &AgentState{
+		ToolRegistry: reg,
+		LastDecision: &Decision{Action: "tool", ToolName: "file_read", ToolParams: map[string]any{"path": ".env", "note": "Bearer abcdefghij0123456789"}},
+	}
+	node := NewToolNode(reg)
+	prep := node.Prep(state)
+	res, _ := node.Exec(context.Background(), prep[0])
+	node.Post(state, prep, res)
+
+	step := state.StepHistory[0]
+	if strings.Contains(step.Output, "sk-proj") || !strings.Contains(step.Output, "OPENAI_API_KEY=[REDACTED]\nok") {
+		t.Errorf("output not redacted: %q", step.Output)
+	}
+	if strings.Contains(step.Input, "abcdefghij0123456789") || !json.Valid([]byte(step.Input)) {
+		t.Errorf("input not redacted or no longer JSON: %q", step.Input)
+	}
+}
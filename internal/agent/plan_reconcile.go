@@ -0,0 +1,60 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/plan"
+)
+
+// maxPlanCheckRead caps how much of a file a plan "contains" check reads.
+const maxPlanCheckRead = 1 << 20
+
+// reconcilePlan re-evaluates the plan's step checks after a tool step and
+// pushes the plan to the UI when a status changed, so the plan view tracks
+// the workspace even when steps finish out of order or outside the agent.
+func reconcilePlan(state *AgentState, step StepRecord) {
+	if state.PlanStore == nil || state.PlanSID == "" || !state.PlanStore.HasChecks(state.PlanSID) {
+		return
+	}
+	var last *plan.ToolOutcome
+	if !skipAutoSummaryTools[step.ToolName] { // meta-tool output is never evidence
+		last = &plan.ToolOutcome{Tool: step.ToolName, Output: step.Output, Failed: step.IsError}
+	}
+	if !state.PlanStore.Reconcile(state.PlanSID, workspaceFileReader(state.WorkspaceDir), last) {
+		return
+	}
+	log.Printf("[PlanReconcile] Plan statuses updated after step %d (%s)", step.StepNumber, step.ToolName)
+	if state.OnPlanUpdate != nil {
+		state.OnPlanUpdate(state.PlanStore.Get(state.PlanSID))
+	}
+}
+
+// workspaceFileReader returns a plan.ReadFileFunc resolving paths against
+// the workspace. Paths escaping the workspace read as missing.
+func workspaceFileReader(ws string) plan.ReadFileFunc {
+	return func(path string) ([]byte, error) {
+		if ws == "" {
+			return nil, fmt.Errorf("no workspace")
+		}
+		abs := path
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(ws, abs)
+		}
+		abs = filepath.Clean(abs)
+		rel, err := filepath.Rel(filepath.Clean(ws), abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("path outside workspace: %s", path)
+		}
+		f, err := os.Open(abs)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return io.ReadAll(io.LimitReader(f, maxPlanCheckRead))
+	}
+}
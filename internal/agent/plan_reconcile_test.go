@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/plan"
+)
+
+func TestToolPost_ReconcilesPlan(t *testing.T) {
+	ws := t.TempDir()
+	store := plan.NewPlanStore()
+	store.Set("sid", []plan.PlanStep{
+		{ID: "write", Title: "Write out.txt", Check: &plan.StepCheck{FileExists: "out.txt"}},
+		{ID: "test", Title: "Run tests", Check: &plan.StepCheck{Marker: "PASS", Tool: "shell_exec"}},
+	})
+	var updates [][]plan.PlanStep
+	state := &AgentState{
+		WorkspaceDir: ws,
+		PlanStore:    store,
+		PlanSID:      "sid",
+		OnPlanUpdate: func(steps []plan.PlanStep) { updates = append(updates, steps) },
+	}
+	n := &ToolNodeImpl{}
+
+	// The file appears without the agent writing it (e.g. edited by hand).
+	if err := os.WriteFile(filepath.Join(ws, "out.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n.Post(state, []ToolPrep{{ToolName: "shell_exec", Args: []byte(`{"command":"go test"}`)}},
+		ToolExecResult{ToolName: "shell_exec", Output: "ok\nPASS"})
+
+	if len(updates) != 1 {
+		t.Fatalf("expected 1 plan update, got %d", len(updates))
+	}
+	for _, s := range updates[0] {
+		if s.Status != "done" {
+			t.Errorf("step %s = %s, want done", s.ID, s.Status)
+		}
+	}
+
+	// No change → no event.
+	n.Post(state, []ToolPrep{{ToolName: "file_list", Args: []byte(`{}`)}},
+		ToolExecResult{ToolName: "file_list", Output: "out.txt"})
+	if len(updates) != 1 {
+		t.Errorf("unchanged plan should not emit, got %d updates", len(updates))
+	}
+}
+
+func TestWorkspaceFileReader_StaysInWorkspace(t *testing.T) {
+	ws := t.TempDir()
+	outside := filepath.Join(filepath.Dir(ws), "outside.txt")
+	os.WriteFile(outside, []byte("secret"), 0o644)
+	defer os.Remove(outside)
+
+	read := workspaceFileReader(ws)
+	if _, err := read("../outside.txt"); err == nil {
+		t.Error("relative escape should fail")
+	}
+	if _, err := read(outside); err == nil {
+		t.Error("absolute path outside workspace should fail")
+	}
+	os.WriteFile(filepath.Join(ws, "in.txt"), []byte("hi"), 0o644)
+	if data, err := read("in.txt"); err != nil || string(data) != "hi" {
+		t.Errorf("read in.txt = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+// briefMinToolSteps is the number of tool calls a run needs before its
+// findings are worth merging into the brief: chat-only and one-shot runs
+// learn nothing durable about the project.
+const briefMinToolSteps = 2
+
+// briefMaxRunes caps the brief, which is sent with every decision.
+const briefMaxRunes = 3000
+
+// briefNoChange is the model's reply when the run taught nothing durable.
+const briefNoChange = "NO_CHANGE"
+
+const briefSystemPrompt = "你负责维护项目简报 PROJECT_BRIEF.md，它会注入到今后每次运行的系统提示中，帮助代理少走重复探索的弯路。\n" +
+	"根据刚完成的一次运行，把其中学到的**长期有效**的项目事实合并进现有简报：构建/测试/运行命令、目录结构与关键文件、架构与代码约定、依赖与环境要求、已知的坑。\n" +
+	"规则：\n" +
+	"- 只记录在执行步骤中被证实的事实，不要猜测，不要记录本次任务的内容、进度或结论\n" +
+	"- 与已有条目去重合并；新事实与旧条目矛盾时以新事实替换旧条目\n" +
+	"- 用简洁的 markdown 分节列表，每条一行，总长度不超过 %d 字\n" +
+	"- 不要写入密钥、令牌、密码等敏感信息\n" +
+	"如果没有值得记录的新事实，只输出 " + briefNoChange + "；否则只输出完整的新简报内容，不要任何解释。"
+
+// BriefKeeper maintains the project brief: after a successful run it asks
+// the summarize model to merge the durable facts the run established into
+// the brief file, which the decision prompt then includes.
+type BriefKeeper struct {
+	provider llm.LLMProvider
+	loader   *prompt.PromptLoader
+	timeout  time.Duration
+	mu       sync.Mutex // serializes read-merge-write of concurrent runs
+}
+
+// NewBriefKeeper creates a keeper writing through loader's brief file;
+// nil when provider is nil or the loader has no brief path.
+func NewBriefKeeper(provider llm.LLMProvider, loader *prompt.PromptLoader) *BriefKeeper {
+	if provider == nil || loader == nil || loader.BriefPath() == "" {
+		return nil
+	}
+	return &BriefKeeper{provider: provider, loader: loader, timeout: 60 * time.Second}
+}
+
+// Update merges what a finished run learned into the brief. It reports
+// false without calling the model for runs that are not successful or used
+// fewer than briefMinToolSteps tools, and when the model finds nothing new.
+// Concurrent updates are serialized. Nil-safe.
+func (k *BriefKeeper) Update(ctx context.Context, state *AgentState, o RunOutcome) (bool, error) {
+	if k == nil || o.Outcome != OutcomeSuccess || countSuccessfulToolSteps(state) < briefMinToolSteps {
+		return false, nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	current := k.loader.LoadBrief()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## 现有简报\n%s\n\n", cmp.Or(current, "（空）"))
+	fmt.Fprintf(&sb, "## 用户问题\n%s\n\n", truncate(state.Problem, 1000))
+	sb.WriteString("## 执行步骤\n")
+	for _, s := range state.StepHistory {
+		if s.Type != "tool" {
+			continue
+		}
+		status := "ok"
+		if s.IsError {
+			status = "error"
+		}
+		fmt.Fprintf(&sb, "- %d. %s %s (%s): %s\n", s.StepNumber, s.ToolName, truncate(s.Input, 200), status, truncate(s.Output, 400))
+	}
+	fmt.Fprintf(&sb, "\n## 最终回答\n%s\n", truncate(state.Solution, 2000))
+
+	// Nobody waits on the brief: yield to interactive calls
+	bctx := llm.WithPriority(llm.WithModelRole(ctx, llm.ModelSummarize), llm.PriorityBackground)
+	bctx, cancel := context.WithTimeout(bctx, k.timeout)
+	defer cancel()
+	resp, err := k.provider.CallLLM(bctx, []llm.Message{
+		{Role: llm.RoleSystem, Content: fmt.Sprintf(briefSystemPrompt, briefMaxRunes)},
+		{Role: llm.RoleUser, Content: sb.String()},
+	})
+	if err != nil {
+		return false, fmt.Errorf("project brief: %w", err)
+	}
+	brief := parseBrief(resp.Content)
+	if brief == "" || brief == strings.TrimSpace(current) {
+		return false, nil
+	}
+	if err := k.loader.WriteBrief(util.TruncateRunes(brief, briefMaxRunes)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UpdateLogged runs Update and logs its result.
+func (k *BriefKeeper) UpdateLogged(ctx context.Context, state *AgentState, o RunOutcome) {
+	ok, err := k.Update(ctx, state, o)
+	switch {
+	case err != nil:
+		log.Printf("[Brief] Update failed: %v", err)
+	case ok:
+		log.Printf("[Brief] Project brief updated: %s", k.loader.BriefPath())
+	}
+}
+
+// parseBrief returns the new brief from the model's reply, without a
+// wrapping code fence; "" for briefNoChange or an empty reply.
+func parseBrief(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") && strings.HasSuffix(s, "```") && len(s) >= 6 {
+		s = strings.TrimSuffix(s, "```")
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			s = s[i+1:]
+		} else {
+			s = ""
+		}
+		s = strings.TrimSpace(s)
+	}
+	if s == "" || strings.EqualFold(strings.Trim(s, "`*. "), briefNoChange) {
+		return ""
+	}
+	return s
+}
+
+// countSuccessfulToolSteps counts the tool calls of a run that succeeded.
+func countSuccessfulToolSteps(state *AgentState) int {
+	n := 0
+	for _, s := range state.StepHistory {
+		if s.Type == "tool" && !s.IsError {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+)
+
+func briefRun() *AgentState {
+	return &AgentState{
+		Problem:  "跑一下测试",
+		Solution: "测试全部通过",
+		StepHistory: []StepRecord{
+			{StepNumber: 1, Type: "tool", ToolName: "file_list", Output: "go.mod cmd/ internal/"},
+			{StepNumber: 2, Type: "tool", ToolName: "shell_exec", Input: "go test ./...", Output: "ok"},
+		},
+	}
+}
+
+// backgroundProvider fails calls that are not tagged as background work.
+type backgroundProvider struct{ mockLLMProvider }
+
+func (p *backgroundProvider) CallLLM(ctx context.Context, msgs []llm.Message) (llm.Message, error) {
+	if llm.PriorityFrom(ctx) != llm.PriorityBackground {
+		return llm.Message{}, errors.New("call not tagged as background work")
+	}
+	return p.mockLLMProvider.CallLLM(ctx, msgs)
+}
+
+func TestBriefKeeper_Update(t *testing.T) {
+	path := filepath.Join(t.TempDir(), prompt.BriefName)
+	loader := prompt.NewPromptLoader("", "", "").WithBrief(path)
+	success := RunOutcome{Outcome: OutcomeSuccess}
+
+	k := NewBriefKeeper(&backgroundProvider{mockLLMProvider{callLLMResp: llm.Message{Content: "```markdown\n## 命令\n- 测试: go test ./...\n```"}}}, loader)
+	if ok, err := k.Update(context.Background(), briefRun(), success); !ok || err != nil {
+		t.Fatalf("Update = %v, %v", ok, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "## 命令\n- 测试: go test ./...\n" {
+		t.Errorf("brief = %q", data)
+	}
+
+	// Nothing new, or the same brief again: no write
+	for _, reply := range []string{"NO_CHANGE", "## 命令\n- 测试: go test ./..."} {
+		k := NewBriefKeeper(&mockLLMProvider{callLLMResp: llm.Message{Content: reply}}, loader)
+		if ok, err := k.Update(context.Background(), briefRun(), success); ok || err != nil {
+			t.Errorf("reply %q: Update = %v, %v", reply, ok, err)
+		}
+	}
+
+	// Skipped without a model call
+	k = NewBriefKeeper(&mockLLMProvider{callLLMErr: errors.New("must not be called")}, loader)
+	short := briefRun()
+	short.StepHistory = short.StepHistory[:1]
+	for _, tc := range []struct {
+		state *AgentState
+		o     RunOutcome
+	}{
+		{briefRun(), RunOutcome{Outcome: OutcomePartial}},
+		{short, success},
+	} {
+		if ok, err := k.Update(context.Background(), tc.state, tc.o); ok || err != nil {
+			t.Errorf("skipped run: Update = %v, %v", ok, err)
+		}
+	}
+	if _, err := k.Update(context.Background(), briefRun(), success); err == nil {
+		t.Error("model errors should be returned")
+	}
+
+	if NewBriefKeeper(&mockLLMProvider{}, prompt.NewPromptLoader("", "", "")) != nil {
+		t.Error("keeper without a brief path should be nil")
+	}
+	var nilKeeper *BriefKeeper
+	if ok, _ := nilKeeper.Update(context.Background(), briefRun(), success); ok {
+		t.Error("nil keeper updated")
+	}
+}
+
+func TestBuildSystemPrompt_IncludesBrief(t *testing.T) {
+	path := filepath.Join(t.TempDir(), prompt.BriefName)
+	os.WriteFile(path, []byte("- 测试: go test ./...\n"), 0o644)
+	node := NewDecideNode(&mockLLMProvider{}, prompt.NewPromptLoader("", "", "").WithBrief(path))
+	if got := node.buildSystemPrompt("app", DecidePrep{}); !strings.Contains(got, "## 项目简报") || !strings.Contains(got, "- 测试: go test ./...") {
+		t.Error("system prompt misses the project brief")
+	}
+}
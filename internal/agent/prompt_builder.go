@@ -1,9 +1,14 @@
 package agent
 
 import (
+	"cmp"
 	"fmt"
 	"log"
 	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/tokenizer"
 )
 
 // ── Prompt construction ──
@@ -36,6 +41,10 @@ func (n *DecideNode) buildSystemPrompt(mode string, prep DecidePrep) string {
 
 	// #3 L1: hardcoded tool-call protocol (cannot be overridden)
 	sb.WriteString(decideL1Constraint(mode))
+	if prep.CanAsk {
+		sb.WriteString("\n\n")
+		sb.WriteString(decideAskGuide(mode))
+	}
 
 	// #4 Runtime Info: compact single line (Phase 1)
 	if prep.RuntimeLine != "" {
@@ -55,6 +64,11 @@ func (n *DecideNode) buildSystemPrompt(mode string, prep DecidePrep) string {
 			sb.WriteString("\n\n")
 			sb.WriteString(knowledge)
 		}
+		// Project brief: durable facts learned by earlier runs (BriefKeeper)
+		if brief := n.loader.LoadBrief(); brief != "" {
+			sb.WriteString("\n\n## 项目简报（以往运行中确认的项目事实，可直接使用，无需重新探索）\n")
+			sb.WriteString(brief)
+		}
 	}
 
 	// #7 Behavior Components
@@ -63,7 +77,7 @@ func (n *DecideNode) buildSystemPrompt(mode string, prep DecidePrep) string {
 			sb.WriteString("\n\n")
 			sb.WriteString(common)
 		}
-		if style := n.loader.Load("answer_style.md"); style != "" {
+		if style := n.loader.LoadAnswerStyle(prep.AnswerStyle); style != "" {
 			sb.WriteString("\n\n")
 			sb.WriteString(style)
 		}
@@ -96,24 +110,42 @@ func (n *DecideNode) buildSystemPrompt(mode string, prep DecidePrep) string {
 
 	result := sb.String()
 
-	// Phase 2: Token Budget Guard — temporary character truncation.
+	// Phase 2: Token Budget Guard — temporary truncation.
 	// If context window is known, cap system prompt at 25% of total token budget.
 	// This is a safety net; Phase 3 will replace with component-level removal.
-	//
-	// Rune-safe: use []rune slicing to avoid cutting in the middle of a
-	// multi-byte UTF-8 character (e.g. Chinese text is 3 bytes/char).
+	// The tokenizer cuts on rune boundaries, so multi-byte text stays valid.
 	if prep.ContextWindowTokens > 0 {
-		maxChars := prep.ContextWindowTokens * charsPerToken * 25 / 100
-		runes := []rune(result)
-		if len(runes) > maxChars {
-			log.Printf("[Decide] Token budget guard: system prompt %d chars exceeds %d limit, truncating", len(runes), maxChars)
-			result = string(runes[:maxChars])
+		maxTokens := prep.ContextWindowTokens * 25 / 100
+		if tokens := estimateTokens(result); tokens > maxTokens {
+			log.Printf("[Decide] Token budget guard: system prompt %d tokens exceeds %d limit, truncating", tokens, maxTokens)
+			result = tokenizer.Default().Truncate(result, maxTokens)
 		}
 	}
 
 	return result
 }
 
+// PreviewSystemPrompt returns the system prompt the first decision of a run
+// on state would send, without calling the model (used by the prompt editor).
+// state.Problem only selects the conditional guides; provider may be nil.
+func PreviewSystemPrompt(provider llm.LLMProvider, loader *prompt.PromptLoader, state *AgentState) string {
+	prep := DecidePrep{
+		Problem:             state.Problem,
+		ThinkingMode:        state.ThinkingMode,
+		ToolCallMode:        state.ToolCallMode,
+		ToolingSummary:      buildToolingSection(state.ToolRegistry),
+		RuntimeLine:         buildRuntimeLine(state),
+		HasMCPIntent:        containsMCPKeywords(state.Problem),
+		ContextWindowTokens: state.ContextWindowTokens,
+		AnswerStyle:         state.AnswerStyle,
+	}
+	mode := state.ThinkingMode
+	if state.ToolCallMode == "fc" || (state.ToolCallMode == "auto" && provider != nil && provider.IsToolCallingEnabled()) {
+		mode = "fc"
+	}
+	return NewDecideNode(provider, loader).buildSystemPrompt(mode, prep)
+}
+
 // decideL1Constraint returns the hardcoded L1 system prompt fragment for DecideNode.
 // These constraints define the tool-call protocol and cannot be overridden by L2/L3.
 func decideL1Constraint(mode string) string {
@@ -186,6 +218,11 @@ func buildDecidePromptFC(prep DecidePrep) string {
 		sb.WriteString("\n")
 	}
 
+	if prep.ScratchpadText != "" {
+		sb.WriteString(prep.ScratchpadText)
+		sb.WriteString("\n")
+	}
+
 	if prep.PlanText != "" {
 		sb.WriteString(prep.PlanText)
 		sb.WriteString("\n")
@@ -195,6 +232,12 @@ func buildDecidePromptFC(prep DecidePrep) string {
 		sb.WriteString(fmt.Sprintf("已完成步骤：\n%s\n\n", prep.StepSummary))
 	}
 
+	// User corrections come after the steps they refer to
+	if prep.Corrections != "" {
+		sb.WriteString(prep.Corrections)
+		sb.WriteString("\n")
+	}
+
 	// When task is long, remind LLM of available tool names
 	if prep.StepCount > 3 && len(prep.ToolDefinitions) > 0 {
 		sb.WriteString("可用工具：")
@@ -208,7 +251,7 @@ func buildDecidePromptFC(prep DecidePrep) string {
 	}
 
 	// Add urgency when step budget is running low
-	remaining := MaxAgentSteps - prep.StepCount
+	remaining := cmp.Or(prep.StepBudget, MaxAgentSteps) - prep.StepCount
 	if remaining <= 5 && prep.StepCount > 0 {
 		sb.WriteString(fmt.Sprintf("⚠️ 剩余步骤预算：%d。请尽快用已有信息给出回答。\n\n", remaining))
 	}
@@ -254,6 +297,12 @@ func buildDecidePrompt(prep DecidePrep) string {
 		sb.WriteString("\n")
 	}
 
+	if prep.ScratchpadText != "" {
+		sb.WriteString("\n")
+		sb.WriteString(prep.ScratchpadText)
+		sb.WriteString("\n")
+	}
+
 	if prep.PlanText != "" {
 		sb.WriteString("\n")
 		sb.WriteString(prep.PlanText)
@@ -264,8 +313,14 @@ func buildDecidePrompt(prep DecidePrep) string {
 		sb.WriteString(fmt.Sprintf("已完成步骤：\n%s\n\n", prep.StepSummary))
 	}
 
+	// User corrections come after the steps they refer to
+	if prep.Corrections != "" {
+		sb.WriteString(prep.Corrections)
+		sb.WriteString("\n")
+	}
+
 	// Add urgency when step budget is running low
-	remaining := MaxAgentSteps - prep.StepCount
+	remaining := cmp.Or(prep.StepBudget, MaxAgentSteps) - prep.StepCount
 	if remaining <= 5 && prep.StepCount > 0 {
 		sb.WriteString(fmt.Sprintf("⚠️ 剩余步骤预算：%d。请尽快用已有信息给出 answer。\n\n", remaining))
 	}
@@ -286,37 +341,91 @@ func buildDecidePrompt(prep DecidePrep) string {
 		))
 	}
 
+	if prep.ToolCallMode == "json" {
+		sb.WriteString(decideJSONTemplate(prep.ThinkingMode, prep.CanAsk))
+		return sb.String()
+	}
+
 	// Dynamic YAML template based on thinking mode
-	if prep.ThinkingMode == "native" {
-		sb.WriteString(`请以 YAML 格式回复你的决策：
-` + "```yaml" + `
-action: "tool"  # 或 "answer"
-reason: "本步具体做什么（不要重复之前说过的话）"
-tool_name: "工具名"       # action=tool 时必需
-tool_params:              # action=tool 时必需
-  param1: "value1"
-answer: |                 # action=answer 时
-  最终回答...
-` + "```")
-	} else {
-		sb.WriteString(`请以 YAML 格式回复你的决策：
+	sb.WriteString(decideYAMLTemplate(prep.ThinkingMode, prep.CanAsk))
+
+	// Few-shot repair: after repeated parse failures, show known-good decisions.
+	if prep.YAMLParseFailures >= yamlRepairThreshold && len(prep.DecisionExamples) > 0 {
+		sb.WriteString("\n\n⚠️ 之前的回复多次无法解析，请严格参照以下格式正确的示例：\n")
+		sb.WriteString(formatDecisionExamples(prep.DecisionExamples))
+	}
+
+	return sb.String()
+}
+
+// decideYAMLTemplate returns the response-format instructions for YAML mode.
+// Native thinking models reason internally, so "think" is omitted for them;
+// "ask" is offered only while the run can pause for the user (canAsk).
+func decideYAMLTemplate(thinkingMode string, canAsk bool) string {
+	actions := `"tool"  # 或 "think" 或 "answer"`
+	thinking := `
+thinking: |               # action=think 时必需
+  推理内容...`
+	if thinkingMode == "native" {
+		actions = `"tool"  # 或 "answer"`
+		thinking = ""
+	}
+	question := ""
+	if canAsk {
+		actions += ` 或 "ask"`
+		question = `
+question: "澄清问题"      # action=ask 时必需`
+	}
+	return `请以 YAML 格式回复你的决策：
 ` + "```yaml" + `
-action: "tool"  # 或 "think" 或 "answer"
+action: ` + actions + `
 reason: "本步具体做什么（不要重复之前说过的话）"
 tool_name: "工具名"       # action=tool 时必需
 tool_params:              # action=tool 时必需
-  param1: "value1"
-thinking: |               # action=think 时
-  推理内容...
-answer: |                 # action=answer 时
-  最终回答...
-` + "```")
-	}
+  param1: "value1"` + thinking + `
+answer: |                 # action=answer 时必需
+  最终回答...` + question + `
+` + "```"
+}
 
-	return sb.String()
+// decideJSONTemplate returns the response-format instructions for JSON mode.
+// Native thinking models reason internally, so "think" is omitted for them;
+// "ask" is offered only while the run can pause for the user (canAsk).
+func decideJSONTemplate(thinkingMode string, canAsk bool) string {
+	actions := `"tool" | "think" | "answer"`
+	thinking := `
+  "thinking": "推理内容（action=think 时必需）",`
+	if thinkingMode == "native" {
+		actions = `"tool" | "answer"`
+		thinking = ""
+	}
+	question := ""
+	if canAsk {
+		actions += ` | "ask"`
+		question = `,
+  "question": "澄清问题（action=ask 时必需）"`
+	}
+	return `请以单个 JSON 对象回复你的决策（放在 ` + "```json" + ` 代码块中，不要输出其他内容）：
+` + "```json" + `
+{
+  "action": ` + actions + `,
+  "reason": "本步具体做什么（不要重复之前说过的话）",
+  "tool_name": "工具名（action=tool 时必需）",
+  "tool_params": {"param1": "value1"},` + thinking + `
+  "answer": "最终回答（action=answer 时必需）"` + question + `
+}
+` + "```" + `
+规则：只使用上面列出的字段；不需要的字段直接省略；字符串中的反斜杠写成 \\（如 "E:\\docs"）或改用正斜杠；换行写成 \n。`
 }
 
-// charsPerToken is the approximate character-to-token ratio for mixed Chinese/English.
-// Chinese text averages ~1.5 chars/token; ASCII text averages ~4 chars/token.
-// 2 is a conservative middle ground that avoids underestimating token cost.
-const charsPerToken = 2
+// decideAskGuide tells the model when to pause for a clarifying question.
+// In FC mode the question goes through the ask_user function.
+func decideAskGuide(mode string) string {
+	how := "选择 ask 行动，在 question 中写出问题"
+	if mode == "fc" {
+		how = "调用 " + askUserToolName + " 函数提出问题"
+	}
+	return `## 向用户提问
+需求存在关键歧义、且无法通过工具自行查明时（如要修改的目标、运行环境或取舍不明确），可以` + how +
+		`：运行会暂停，用户的回复加入对话历史后继续。问题要简短具体，一次只问一个；能自行查明或合理假设的信息不要问。`
+}
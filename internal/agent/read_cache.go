@@ -2,7 +2,9 @@ package agent
 
 import (
 	"crypto/md5"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 )
 
@@ -40,11 +42,17 @@ func (c *ReadCache) Put(key string, entry ReadCacheEntry) {
 	c.cache[key] = entry
 }
 
-// Invalidate removes the cached entry for the given key.
+// Invalidate removes the cached entry for the given key, along with its
+// variants ("<key>#…", e.g. line-range and outline reads of a file).
 func (c *ReadCache) Invalidate(key string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.cache, key)
+	for k := range c.cache {
+		if strings.HasPrefix(k, key+"#") {
+			delete(c.cache, k)
+		}
+	}
 }
 
 // cacheableTools defines tools whose results can be cached.
@@ -64,13 +72,14 @@ func isCacheable(toolName string) bool {
 }
 
 // CacheKey builds the cache key for a tool invocation.
-// file_read: uses "file_read:<path>" for precise write-invalidation.
+// file_read: uses "file_read:<path>" (plus the range/outline variant) for
+// precise write-invalidation.
 // Others: uses "tool:<name>:<md5(args)>" for general dedup.
 func CacheKey(toolName, argsJSON string) string {
 	if toolName == "file_read" {
 		path := extractParam(argsJSON, "path")
 		if path != "" {
-			return "file_read:" + path
+			return "file_read:" + path + fileReadVariant(argsJSON)
 		}
 	}
 	// #nosec G401 -- MD5 used only for deduplication, not security
@@ -84,6 +93,27 @@ func FileReadCacheKey(path string) string {
 	return "file_read:" + path
 }
 
+// fileReadVariant returns the suffix telling partial file_read calls of a
+// path apart: "#outline" and/or "#lines=<start>-<end>", "" for a full read.
+func fileReadVariant(argsJSON string) string {
+	var a struct {
+		StartLine int  `json:"start_line"`
+		EndLine   int  `json:"end_line"`
+		Outline   bool `json:"outline"`
+	}
+	if json.Unmarshal([]byte(argsJSON), &a) != nil {
+		return ""
+	}
+	var v string
+	if a.Outline {
+		v = "#outline"
+	}
+	if a.StartLine > 0 || a.EndLine > 0 {
+		v += fmt.Sprintf("#lines=%d-%d", a.StartLine, a.EndLine)
+	}
+	return v
+}
+
 // isWriteTool returns true for tools that modify files (cache invalidation triggers).
 func isWriteTool(toolName string) bool {
 	switch toolName {
@@ -78,6 +78,30 @@ func TestReadCache_DifferentPathNotAffected(t *testing.T) {
 	}
 }
 
+func TestReadCache_FileReadVariants(t *testing.T) {
+	c := NewReadCache()
+	full := CacheKey("file_read", `{"path":"a.go"}`)
+	outline := CacheKey("file_read", `{"path":"a.go","outline":true}`)
+	lines := CacheKey("file_read", `{"path":"a.go","start_line":10,"end_line":20}`)
+	if full == outline || full == lines || outline == lines {
+		t.Fatalf("variants share a key: %q %q %q", full, outline, lines)
+	}
+	other := CacheKey("file_read", `{"path":"a.go.bak","outline":true}`)
+	for _, k := range []string{full, outline, lines, other} {
+		c.Put(k, ReadCacheEntry{StepNumber: 1, Output: k})
+	}
+
+	c.Invalidate(FileReadCacheKey("a.go"))
+	for _, k := range []string{full, outline, lines} {
+		if _, ok := c.Get(k); ok {
+			t.Errorf("%q should be invalidated", k)
+		}
+	}
+	if _, ok := c.Get(other); !ok {
+		t.Error("a.go.bak should still be cached")
+	}
+}
+
 func TestReadCache_ConcurrentAccess(t *testing.T) {
 	c := NewReadCache()
 	var wg sync.WaitGroup
@@ -0,0 +1,421 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// replayMaxRuns is the number of run files kept in the replay directory;
+// older runs are pruned when a new one starts.
+const replayMaxRuns = 50
+
+// Replay event types, one JSON object per line in a run file.
+const (
+	ReplayEventStart      = "start"      // run header: problem, session, modes
+	ReplayEventDecision   = "decision"   // full Decision produced by DecideNode
+	ReplayEventStep       = "step"       // StepRecord (tool input/output, think, answer)
+	ReplayEventDownshift  = "downshift"  // decide model switched near the token budget
+	ReplayEventCorrection = "correction" // user step annotation picked up mid-run
+	ReplayEventEnd        = "end"        // final solution and step count
+)
+
+// ReplayEvent is a single line of a replay log.
+type ReplayEvent struct {
+	Type      string         `json:"type"`
+	Time      time.Time      `json:"time"`
+	Step      int            `json:"step,omitempty"`
+	SessionID string         `json:"session_id,omitempty"` // start only
+	Problem   string         `json:"problem,omitempty"`    // start only
+	Modes     string         `json:"modes,omitempty"`      // start only, e.g. "thinking=app toolcall=fc"
+	Decision  *Decision      `json:"decision,omitempty"`
+	Record    *StepRecord    `json:"record,omitempty"`
+	Downshift *DownshiftInfo `json:"downshift,omitempty"` // downshift only
+	Solution  string         `json:"solution,omitempty"`  // end only
+	Steps     int            `json:"steps,omitempty"`     // end only
+	Outcome   *RunOutcome    `json:"outcome,omitempty"`   // end only
+
+	Correction *StepAnnotation `json:"correction,omitempty"` // correction only
+}
+
+// ReplayRecorder creates one structured JSONL file per agent run for later
+// replay with `omega replay <file>`. Unlike ExecLogger (a single markdown file
+// overwritten per session), runs are kept side by side and nothing is truncated.
+type ReplayRecorder struct {
+	dir string
+}
+
+// NewReplayRecorder creates a recorder writing run files into dir.
+func NewReplayRecorder(dir string) (*ReplayRecorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("cannot create replay dir: %w", err)
+	}
+	return &ReplayRecorder{dir: dir}, nil
+}
+
+// StartRun opens a new run file and writes the start event.
+// Returns nil (recording disabled for this run) if the file cannot be created;
+// all ReplayRun methods are nil-safe.
+func (r *ReplayRecorder) StartRun(sessionID, problem, modes string) *ReplayRun {
+	if r == nil {
+		return nil
+	}
+	r.prune()
+	name := fmt.Sprintf("run-%s.jsonl", time.Now().Format("20060102-150405.000"))
+	path := filepath.Join(r.dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		log.Printf("[Replay] cannot create run file: %v", err)
+		return nil
+	}
+	run := &ReplayRun{file: f, enc: json.NewEncoder(f), path: path}
+	run.write(ReplayEvent{Type: ReplayEventStart, SessionID: sessionID, Problem: problem, Modes: modes})
+	return run
+}
+
+// prune removes the oldest run files beyond replayMaxRuns-1,
+// leaving room for the run about to be created.
+func (r *ReplayRecorder) prune() {
+	files, _ := filepath.Glob(filepath.Join(r.dir, "run-*.jsonl"))
+	if len(files) < replayMaxRuns {
+		return
+	}
+	sort.Strings(files) // timestamped names sort chronologically
+	for _, f := range files[:len(files)-replayMaxRuns+1] {
+		if err := os.Remove(f); err != nil {
+			log.Printf("[Replay] prune %s: %v", f, err)
+		}
+	}
+}
+
+// ReplayRun records the events of a single agent run. Thread-safe, nil-safe.
+type ReplayRun struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+	path string
+}
+
+// Path returns the run file path ("" for a nil run).
+func (r *ReplayRun) Path() string {
+	if r == nil {
+		return ""
+	}
+	return r.path
+}
+
+// RecordDecision records the full decision taken at the given step.
+func (r *ReplayRun) RecordDecision(step int, d Decision) {
+	if r == nil {
+		return
+	}
+	r.write(ReplayEvent{Type: ReplayEventDecision, Step: step, Decision: &d})
+}
+
+// RecordStep records a completed step (tool input/output, think, answer).
+func (r *ReplayRun) RecordStep(s StepRecord) {
+	if r == nil {
+		return
+	}
+	r.write(ReplayEvent{Type: ReplayEventStep, Step: s.StepNumber, Record: &s})
+}
+
+// RecordDownshift records an automatic decide-model downshift.
+func (r *ReplayRun) RecordDownshift(info DownshiftInfo) {
+	if r == nil {
+		return
+	}
+	r.write(ReplayEvent{Type: ReplayEventDownshift, Step: info.Step, Downshift: &info})
+}
+
+// RecordCorrection records a user step annotation the agent picked up.
+func (r *ReplayRun) RecordCorrection(a StepAnnotation) {
+	if r == nil {
+		return
+	}
+	r.write(ReplayEvent{Type: ReplayEventCorrection, Step: a.Step, Correction: &a})
+}
+
+// End writes the end event and closes the file.
+func (r *ReplayRun) End(state *AgentState) {
+	if r == nil {
+		return
+	}
+	r.write(ReplayEvent{Type: ReplayEventEnd, Solution: state.Solution, Steps: len(state.StepHistory), Outcome: state.Outcome})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+func (r *ReplayRun) write(ev ReplayEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	ev.Time = time.Now()
+	if err := r.enc.Encode(ev); err != nil {
+		log.Printf("[Replay] write failed: %v", err)
+	}
+}
+
+// ── Replay reader ──
+
+// LoadReplay reads all events from a run file.
+func LoadReplay(path string) ([]ReplayEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []ReplayEvent
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 1<<20), 16<<20)
+	line := 0
+	for sc.Scan() {
+		line++
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var ev ReplayEvent
+		if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		events = append(events, ev)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ReplaySummary describes one recorded run in a replay directory.
+type ReplaySummary struct {
+	Name      string    `json:"name"` // file name, e.g. run-20260102-150405.000.jsonl
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id,omitempty"`
+	Problem   string    `json:"problem"`
+}
+
+// ListReplays returns the runs in dir, newest first. Only the start event of
+// each file is read, so listing stays cheap for long runs.
+func ListReplays(dir string) ([]ReplaySummary, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "run-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files))) // timestamped names sort chronologically
+	var out []ReplaySummary
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64<<10), 1<<20)
+		var ev ReplayEvent
+		if sc.Scan() && json.Unmarshal(sc.Bytes(), &ev) == nil && ev.Type == ReplayEventStart {
+			out = append(out, ReplaySummary{Name: filepath.Base(path), Time: ev.Time, SessionID: ev.SessionID, Problem: ev.Problem})
+		}
+		f.Close()
+	}
+	return out, nil
+}
+
+// replayReadOnlyTools are tools safe to re-execute during replay:
+// they neither modify the workspace nor reach the network.
+var replayReadOnlyTools = map[string]bool{
+	"file_read":   true,
+	"file_list":   true,
+	"file_grep":   true,
+	"find":        true,
+	"git_info":    true,
+	"project_map": true,
+}
+
+// ReplayOptions controls RenderReplay.
+type ReplayOptions struct {
+	// Registry, when set, re-executes recorded read-only tool calls and
+	// reports whether the output still matches the recording.
+	Registry *tool.Registry
+	// Pause, when set, waits for a line on this reader before each step.
+	Pause io.Reader
+	// MaxOutputRunes truncates recorded outputs in the rendering (0 = 1000).
+	MaxOutputRunes int
+}
+
+// RenderReplay renders a recorded run step by step to w.
+// Returns the number of re-executed tools whose output diverged.
+func RenderReplay(ctx context.Context, w io.Writer, events []ReplayEvent, opts ReplayOptions) (int, error) {
+	maxRunes := opts.MaxOutputRunes
+	if maxRunes <= 0 {
+		maxRunes = 1000
+	}
+	var pause *bufio.Reader
+	if opts.Pause != nil {
+		pause = bufio.NewReader(opts.Pause)
+	}
+
+	diverged := 0
+	for _, ev := range events {
+		if err := ctx.Err(); err != nil {
+			return diverged, err
+		}
+		switch ev.Type {
+		case ReplayEventStart:
+			fmt.Fprintf(w, "▶ Run %s  session=%s  %s\n", ev.Time.Format("2006-01-02 15:04:05"), ev.SessionID, ev.Modes)
+			fmt.Fprintf(w, "  问题: %s\n\n", ev.Problem)
+
+		case ReplayEventDecision:
+			if pause != nil {
+				fmt.Fprint(w, "  [Enter] 下一步 …")
+				if _, err := pause.ReadString('\n'); err != nil && err != io.EOF {
+					return diverged, err
+				}
+			}
+			d := ev.Decision
+			if d == nil {
+				continue
+			}
+			fmt.Fprintf(w, "── Step %d 🧭 %s", ev.Step, d.Action)
+			if d.ToolName != "" {
+				fmt.Fprintf(w, " → %s", d.ToolName)
+			}
+			fmt.Fprintln(w)
+			if d.Reason != "" {
+				fmt.Fprintf(w, "  理由: %s\n", d.Reason)
+			}
+			if len(d.ToolParams) > 0 {
+				params, _ := json.Marshal(d.ToolParams)
+				fmt.Fprintf(w, "  参数: %s\n", params)
+			}
+
+		case ReplayEventStep:
+			s := ev.Record
+			if s == nil || s.Type == "decide" {
+				continue // decide steps are rendered from the decision event
+			}
+			renderStepOutput(w, s, maxRunes, false)
+			// Cache hits (⚠️ prefix) were never executed; nothing to compare.
+			if s.Type == "tool" && opts.Registry != nil && replayReadOnlyTools[s.ToolName] &&
+				!strings.HasPrefix(s.Output, "⚠️") {
+				if !replayReexec(ctx, w, opts.Registry, s) {
+					diverged++
+				}
+			}
+
+		case ReplayEventDownshift:
+			if d := ev.Downshift; d != nil {
+				fmt.Fprintf(w, "⬇ 模型降级: %s → %s（已用 %d/%d tokens）\n", d.FromModel, d.ToModel, d.UsedTokens, d.MaxTokens)
+			}
+
+		case ReplayEventCorrection:
+			if c := ev.Correction; c != nil {
+				fmt.Fprintf(w, "📝 用户纠正（步骤 %d）: %s\n", c.Step, c.Note)
+			}
+
+		case ReplayEventEnd:
+			fmt.Fprintf(w, "\n■ 结束: %d 步\n%s\n", ev.Steps, indent(ev.Solution))
+			if o := ev.Outcome; o != nil {
+				fmt.Fprintf(w, "  结果: %s", o.Outcome)
+				if len(o.Reasons) > 0 {
+					fmt.Fprintf(w, " (%s)", strings.Join(o.Reasons, ", "))
+				}
+				if o.Judge != "" {
+					fmt.Fprintf(w, " — %s", o.Judge)
+				}
+				fmt.Fprintln(w)
+			}
+		}
+	}
+	return diverged, nil
+}
+
+// RenderStep renders one step of a live run in the same layout as
+// RenderReplay: decide steps with their reason, tool steps with their
+// arguments and output. maxRunes truncates outputs (0 = 1000).
+func RenderStep(w io.Writer, s StepRecord, maxRunes int) {
+	if maxRunes <= 0 {
+		maxRunes = 1000
+	}
+	if s.Type == "decide" {
+		fmt.Fprintf(w, "── Step %d 🧭 %s\n", s.StepNumber, s.Action)
+		if s.Input != "" {
+			fmt.Fprintf(w, "  理由: %s\n", s.Input)
+		}
+		return
+	}
+	renderStepOutput(w, &s, maxRunes, true)
+}
+
+// renderStepOutput renders a non-decide step's header and indented output;
+// withArgs adds tool arguments (a replay shows them with the decision).
+func renderStepOutput(w io.Writer, s *StepRecord, maxRunes int, withArgs bool) {
+	label := stepTypeLabel(s.Type)
+	if s.Type == "tool" {
+		fmt.Fprintf(w, "── Step %d %s %s (%dms)\n", s.StepNumber, label, s.ToolName, s.DurationMs)
+		if withArgs && s.Input != "" {
+			fmt.Fprintf(w, "  参数: %s\n", truncate(s.Input, maxRunes))
+		}
+	} else {
+		fmt.Fprintf(w, "── Step %d %s\n", s.StepNumber, label)
+	}
+	fmt.Fprintf(w, "%s\n", indent(truncate(s.Output, maxRunes)))
+}
+
+// replayReexec re-runs a recorded read-only tool call and reports whether its
+// output matches the recording. Returns false on divergence.
+func replayReexec(ctx context.Context, w io.Writer, reg *tool.Registry, s *StepRecord) bool {
+	t, ok := reg.Get(s.ToolName)
+	if !ok {
+		fmt.Fprintf(w, "  ↻ 重放跳过: 工具 %s 未注册\n", s.ToolName)
+		return true
+	}
+	res, err := t.Execute(ctx, json.RawMessage(s.Input))
+	if err != nil {
+		fmt.Fprintf(w, "  ↻ 重放失败: %v\n", err)
+		return false
+	}
+	out := res.Output
+	if res.Error != "" {
+		if out != "" {
+			out = fmt.Sprintf("%s\n\n错误: %s", out, res.Error)
+		} else {
+			out = fmt.Sprintf("错误: %s", res.Error)
+		}
+	}
+	if normalizePageToken(out) == normalizePageToken(s.Output) {
+		fmt.Fprintln(w, "  ↻ 重放结果一致")
+		return true
+	}
+	fmt.Fprintf(w, "  ↻ 重放结果不一致（记录 %d 字符，当前 %d 字符）:\n%s\n",
+		len([]rune(s.Output)), len([]rune(out)), indent(truncate(out, 500)))
+	return false
+}
+
+// pageTokenPattern matches fetch_more continuation tokens, which are random per call.
+var pageTokenPattern = regexp.MustCompile(`token="p[0-9a-f]+"`)
+
+func normalizePageToken(s string) string {
+	return pageTokenPattern.ReplaceAllString(s, `token="…"`)
+}
+
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n    ")
+}
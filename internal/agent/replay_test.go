@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/mcp"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+)
+
+// replayEchoTool returns a fixed output; used to simulate re-execution.
+type replayEchoTool struct {
+	name   string
+	output string
+}
+
+func (t *replayEchoTool) Name() string                 { return t.name }
+func (t *replayEchoTool) Description() string          { return "" }
+func (t *replayEchoTool) InputSchema() json.RawMessage { return nil }
+func (t *replayEchoTool) Execute(_ context.Context, _ json.RawMessage) (tool.ToolResult, error) {
+	return tool.ToolResult{Output: t.output}, nil
+}
+func (t *replayEchoTool) Init(_ context.Context) error { return nil }
+func (t *replayEchoTool) Close() error                 { return nil }
+
+func recordSampleRun(t *testing.T, dir string) string {
+	t.Helper()
+	rec, err := NewReplayRecorder(dir)
+	if err != nil {
+		t.Fatalf("NewReplayRecorder: %v", err)
+	}
+	run := rec.StartRun("s1", "读取 main.go", "thinking=app toolcall=yaml")
+	if run == nil {
+		t.Fatal("StartRun returned nil")
+	}
+	run.RecordDecision(1, Decision{Action: "tool", Reason: "read", ToolName: "file_read",
+		ToolParams: map[string]any{"path": "main.go"}})
+	run.RecordStep(StepRecord{StepNumber: 1, Type: "decide", Action: "tool", Input: "read"})
+	run.RecordStep(StepRecord{StepNumber: 2, Type: "tool", ToolName: "file_read",
+		Input: `{"path":"main.go"}`, Output: "package main"})
+	run.RecordDecision(3, Decision{Action: "answer", Answer: "done"})
+	run.End(&AgentState{Solution: "done", StepHistory: make([]StepRecord, 4)})
+	return run.Path()
+}
+
+func TestReplayRecorder_RoundTrip(t *testing.T) {
+	path := recordSampleRun(t, t.TempDir())
+
+	events, err := LoadReplay(path)
+	if err != nil {
+		t.Fatalf("LoadReplay: %v", err)
+	}
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	want := "start,decision,step,step,decision,end"
+	if got := strings.Join(types, ","); got != want {
+		t.Fatalf("event types = %s, want %s", got, want)
+	}
+	if events[0].Problem != "读取 main.go" || events[0].SessionID != "s1" {
+		t.Errorf("start event = %+v", events[0])
+	}
+	if d := events[1].Decision; d == nil || d.ToolParams["path"] != "main.go" {
+		t.Errorf("decision tool_params not preserved: %+v", d)
+	}
+	if events[5].Steps != 4 || events[5].Solution != "done" {
+		t.Errorf("end event = %+v", events[5])
+	}
+}
+
+func TestReplayRun_NilSafe(t *testing.T) {
+	var rec *ReplayRecorder
+	run := rec.StartRun("s", "p", "")
+	run.RecordDecision(1, Decision{Action: "answer"})
+	run.RecordStep(StepRecord{StepNumber: 1})
+	run.End(&AgentState{})
+	if run.Path() != "" {
+		t.Error("nil run should have empty path")
+	}
+}
+
+func TestReplayRecorder_Prune(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < replayMaxRuns+5; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("run-20260101-0000%02d.000.jsonl", i))
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec, _ := NewReplayRecorder(dir)
+	rec.StartRun("s", "p", "").End(&AgentState{})
+
+	files, _ := filepath.Glob(filepath.Join(dir, "run-*.jsonl"))
+	if len(files) != replayMaxRuns {
+		t.Errorf("files after prune = %d, want %d", len(files), replayMaxRuns)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "run-20260101-000000.000.jsonl")); !os.IsNotExist(err) {
+		t.Error("oldest run should have been pruned")
+	}
+}
+
+func TestRenderReplay_Reexec(t *testing.T) {
+	events, err := LoadReplay(recordSampleRun(t, t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		output   string
+		diverged int
+		marker   string
+	}{
+		{"package main", 0, "重放结果一致"},
+		{"package changed", 1, "重放结果不一致"},
+	} {
+		reg := tool.NewRegistry()
+		reg.Register(&replayEchoTool{name: "file_read", output: tc.output})
+
+		var buf bytes.Buffer
+		n, err := RenderReplay(context.Background(), &buf, events, ReplayOptions{Registry: reg})
+		if err != nil {
+			t.Fatalf("RenderReplay: %v", err)
+		}
+		if n != tc.diverged {
+			t.Errorf("diverged = %d, want %d", n, tc.diverged)
+		}
+		out := buf.String()
+		for _, want := range []string{"读取 main.go", "Step 1 🧭 tool → file_read", `{"path":"main.go"}`, tc.marker, "结束: 4 步"} {
+			if !strings.Contains(out, want) {
+				t.Errorf("output missing %q:\n%s", want, out)
+			}
+		}
+	}
+}
+
+func TestRenderReplay_NoReexecWithoutRegistry(t *testing.T) {
+	events, _ := LoadReplay(recordSampleRun(t, t.TempDir()))
+	var buf bytes.Buffer
+	if _, err := RenderReplay(context.Background(), &buf, events, ReplayOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "重放") {
+		t.Error("tools should not be re-executed without a registry")
+	}
+}
+
+// The name tables must use the names the builtin tools register under,
+// or the entries silently never match.
+func TestToolNameTables_MatchRegistry(t *testing.T) {
+	dir := t.TempDir()
+	reg := tool.NewRegistry()
+	for _, tl := range []tool.Tool{
+		builtin.NewFileReadTool(dir), builtin.NewFileListTool(dir), builtin.NewFileGrepTool(dir),
+		builtin.NewFileFindTool(dir), builtin.NewShellTool(dir, false), builtin.NewPythonExecTool(dir, ""),
+		builtin.NewGitInfoTool(dir), builtin.NewGitOpsTool(dir), builtin.NewHTTPRequestTool(false),
+		builtin.NewWebReaderTool(), builtin.NewFetchMoreTool(nil), builtin.NewOutputReadTool(dir),
+		builtin.NewTodoScanTool(dir), builtin.NewProjectMapTool(dir), builtin.NewCodeSearchTool(dir, nil),
+		builtin.NewCSVQueryTool(dir), builtin.NewDocReadTool(dir), builtin.NewArchiveTool(dir),
+		builtin.NewFileWriteTool(dir), builtin.NewFilePatchTool(dir), builtin.NewFileMoveTool(dir),
+		builtin.NewFileDeleteTool(dir), builtin.NewFileOpenTool(dir), builtin.NewTavilySearchTool(""),
+		builtin.NewBraveSearchTool(""), builtin.NewTimeTool(), builtin.NewConfigEditTool(nil),
+		builtin.NewEnvSetTool("", nil), builtin.NewMCPServerAddTool(""), builtin.NewMCPServerRemoveTool(""),
+		builtin.NewMCPServerListTool(""), builtin.NewSkillInstallTool("", ""),
+		mcp.NewReloadTool(nil, reg), mcp.NewRollbackTool(nil, reg),
+	} {
+		reg.Register(tl)
+	}
+	registered := map[string]bool{}
+	for _, tl := range reg.List() {
+		registered[tl.Name()] = true
+	}
+	for table, names := range map[string]map[string]bool{
+		"replayReadOnlyTools": replayReadOnlyTools,
+		"verbatimOutputTools": verbatimOutputTools,
+		"coreToolOrder":       toolSet(coreToolOrder),
+		"mgmtToolOrder":       toolSet(mgmtToolOrder),
+	} {
+		for name := range names {
+			if !registered[name] {
+				t.Errorf("%s lists %q, which no builtin tool registers", table, name)
+			}
+		}
+	}
+}
+
+func toolSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+func TestRenderStep(t *testing.T) {
+	var buf bytes.Buffer
+	RenderStep(&buf, StepRecord{StepNumber: 1, Type: "decide", Action: "tool", Input: "先看目录"}, 0)
+	RenderStep(&buf, StepRecord{StepNumber: 2, Type: "tool", ToolName: "file_list", Input: `{"path":"."}`, Output: "a.go\nb.go", DurationMs: 3}, 0)
+	RenderStep(&buf, StepRecord{StepNumber: 3, Type: "think", Output: strings.Repeat("长", 20)}, 5)
+	want := "── Step 1 🧭 tool\n  理由: 先看目录\n" +
+		"── Step 2 🔧 工具 file_list (3ms)\n  参数: {\"path\":\".\"}\n    a.go\n    b.go\n" +
+		"── Step 3 🧠 推理\n"
+	if got := buf.String(); !strings.HasPrefix(got, want) {
+		t.Errorf("RenderStep output:\n%s\nwant prefix:\n%s", got, want)
+	}
+	if strings.Contains(buf.String(), strings.Repeat("长", 6)) {
+		t.Error("output should be truncated to maxRunes")
+	}
+}
+
+func TestNormalizePageToken(t *testing.T) {
+	a := `x` + pageFooterForTest("p0123abcd")
+	b := `x` + pageFooterForTest("pdeadbeef")
+	if normalizePageToken(a) != normalizePageToken(b) {
+		t.Error("outputs differing only by page token should compare equal")
+	}
+}
+
+func pageFooterForTest(token string) string {
+	return fmt.Sprintf("\n---\n[第 1/2 页] 输出较长，如需后续内容请调用 fetch_more(token=%q)", token)
+}
@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/core"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// ReviewNodeImpl implements BaseNode[AgentState, ReviewPrep, ReviewResult].
+// With AGENT_SELF_REVIEW it checks the final answer against the problem, the
+// plan and the collected evidence. A failed review sends the run back to
+// DecideNode with the critique (at most SelfReviewRetries times); a passed
+// review, or one that cannot be completed, ends the flow.
+type ReviewNodeImpl struct {
+	llmProvider llm.LLMProvider
+}
+
+func NewReviewNode(provider llm.LLMProvider) *ReviewNodeImpl {
+	return &ReviewNodeImpl{llmProvider: provider}
+}
+
+// Prep collects the problem, the answer, the plan and condensed evidence.
+func (n *ReviewNodeImpl) Prep(state *AgentState) []ReviewPrep {
+	prep := ReviewPrep{
+		Problem: state.Problem,
+		Answer:  state.Solution,
+	}
+	if state.PlanStore != nil && state.PlanSID != "" {
+		prep.PlanText = state.PlanStore.Render(state.PlanSID)
+	}
+	var sb strings.Builder
+	for _, s := range state.StepHistory {
+		switch s.Type {
+		case "tool":
+			status := "ok"
+			if s.IsError {
+				status = "error"
+			}
+			fmt.Fprintf(&sb, "- %d. tool %s (%s): %s\n", s.StepNumber, s.ToolName, status, truncate(s.Output, 300))
+		case "think":
+			fmt.Fprintf(&sb, "- %d. think: %s\n", s.StepNumber, truncate(s.Output, 300))
+		}
+	}
+	prep.Evidence = sb.String()
+	return []ReviewPrep{prep}
+}
+
+// Exec asks the model for a pass/fail verdict.
+func (n *ReviewNodeImpl) Exec(ctx context.Context, prep ReviewPrep) (ReviewResult, error) {
+	var sb strings.Builder
+	sb.WriteString("你是回答质量审查员。检查最终回答是否完整、准确地解决了用户问题：是否与执行步骤中的证据一致，计划中的步骤是否都已完成。\n")
+	sb.WriteString(`只输出一个 JSON 对象：{"verdict": "pass|fail", "critique": "不通过的具体原因", "corrections": "重新回答前需要补做或修正的内容"}` + "\n")
+	sb.WriteString("措辞、格式等小问题判 pass；只有遗漏、错误、与证据矛盾或任务未完成才判 fail。\n\n")
+	fmt.Fprintf(&sb, "## 用户问题\n%s\n\n", truncate(prep.Problem, 2000))
+	if prep.PlanText != "" {
+		fmt.Fprintf(&sb, "## 计划\n%s\n", prep.PlanText)
+	}
+	if prep.Evidence != "" {
+		fmt.Fprintf(&sb, "## 执行步骤\n%s\n", prep.Evidence)
+	}
+	fmt.Fprintf(&sb, "## 最终回答\n%s\n", truncate(prep.Answer, 6000))
+
+	resp, err := n.llmProvider.CallLLM(llm.WithModelRole(ctx, llm.ModelReview), []llm.Message{{Role: llm.RoleUser, Content: sb.String()}})
+	if err != nil {
+		return ReviewResult{}, fmt.Errorf("review LLM call failed: %w", err)
+	}
+	result, ok := parseReviewVerdict(resp.Content)
+	if !ok {
+		return ReviewResult{}, fmt.Errorf("review returned no verdict: %s", truncate(resp.Content, 200))
+	}
+	return result, nil
+}
+
+// ExecFallback lets the answer through: a broken review must not block it.
+func (n *ReviewNodeImpl) ExecFallback(err error) ReviewResult {
+	return ReviewResult{Pass: true, Err: err}
+}
+
+// Post records the review step. A failed review with retries left stores
+// the critique and routes back to DecideNode; otherwise the flow ends.
+func (n *ReviewNodeImpl) Post(state *AgentState, prep []ReviewPrep, results ...ReviewResult) core.Action {
+	if len(results) == 0 {
+		return core.ActionEnd
+	}
+	result := results[0]
+	if result.Err != nil {
+		log.Printf("[Review] Skipped: %v", result.Err)
+		return core.ActionEnd
+	}
+
+	step := StepRecord{
+		StepNumber: len(state.StepHistory) + 1,
+		Type:       "review",
+		Action:     "pass",
+		Output:     "回答通过自我审查",
+		Model:      stepModel(state, n.llmProvider, llm.ModelReview),
+	}
+	if !result.Pass {
+		state.SelfReviews++
+		step.Action = "fail"
+		step.Output = formatReviewCritique(result)
+		state.ReviewCritique = fmt.Sprintf("⚠️ 回答未通过自我审查（第 %d/%d 次），请先补做或修正，再重新回答：\n%s\n",
+			state.SelfReviews, state.SelfReviewRetries, step.Output)
+	}
+	state.StepHistory = append(state.StepHistory, step)
+	if state.OnStepComplete != nil {
+		state.OnStepComplete(step)
+	}
+	log.Printf("[Review] %s: %s", step.Action, truncate(step.Output, 100))
+
+	if result.Pass {
+		return core.ActionEnd
+	}
+	return compactRoute(state) // Back to DecideNode with the critique
+}
+
+// needsReview reports whether AnswerNode should hand the answer to
+// ReviewNode. Direct answers without tools or plan, canned answers after an
+// LLM failure and forced answers (step/budget limits) are not reviewed.
+func needsReview(state *AgentState) bool {
+	if state.SelfReviews >= state.SelfReviewRetries || state.LLMError != "" || state.ForcedAnswer != "" {
+		return false
+	}
+	if strings.TrimSpace(state.Solution) == "" {
+		return false
+	}
+	return hasToolSteps(state) || (state.PlanStore != nil && state.PlanSID != "" && state.PlanStore.Get(state.PlanSID) != nil)
+}
+
+// reviewVerdict is the JSON the reviewer is asked to return.
+type reviewVerdict struct {
+	Verdict     string `json:"verdict"`
+	Critique    string `json:"critique"`
+	Corrections string `json:"corrections"`
+}
+
+// parseReviewVerdict extracts the verdict object, tolerating code fences and
+// surrounding prose.
+func parseReviewVerdict(s string) (ReviewResult, bool) {
+	start, end := strings.Index(s, "{"), strings.LastIndex(s, "}")
+	if start < 0 || end < start {
+		return ReviewResult{}, false
+	}
+	var v reviewVerdict
+	if err := json.Unmarshal([]byte(s[start:end+1]), &v); err != nil {
+		return ReviewResult{}, false
+	}
+	switch strings.ToLower(strings.TrimSpace(v.Verdict)) {
+	case "pass":
+		return ReviewResult{Pass: true}, true
+	case "fail":
+		return ReviewResult{Critique: strings.TrimSpace(v.Critique), Corrections: strings.TrimSpace(v.Corrections)}, true
+	}
+	return ReviewResult{}, false
+}
+
+// formatReviewCritique renders a failed verdict for the step log and prompts.
+func formatReviewCritique(r ReviewResult) string {
+	critique := r.Critique
+	if critique == "" {
+		critique = "（未说明原因）"
+	}
+	out := "- 问题: " + critique
+	if r.Corrections != "" {
+		out += "\n- 需修正: " + r.Corrections
+	}
+	return out
+}
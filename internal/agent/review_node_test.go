@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/core"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/plan"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestParseReviewVerdict(t *testing.T) {
+	cases := []struct {
+		in   string
+		ok   bool
+		pass bool
+	}{
+		{`{"verdict": "pass"}`, true, true},
+		{"```json\n{\"verdict\": \"FAIL\", \"critique\": \"漏了文件\"}\n```", true, false},
+		{`审查结果：{"verdict": "maybe"}`, false, false},
+		{`no json`, false, false},
+	}
+	for _, tc := range cases {
+		got, ok := parseReviewVerdict(tc.in)
+		if ok != tc.ok || (ok && got.Pass != tc.pass) {
+			t.Errorf("parseReviewVerdict(%q) = %+v, %v", tc.in, got, ok)
+		}
+	}
+}
+
+func TestNeedsReview(t *testing.T) {
+	withTool := []StepRecord{{Type: "tool", ToolName: "file_list"}}
+	planned := plan.NewPlanStore()
+	planned.Set("s", []plan.PlanStep{{ID: "a", Title: "A"}})
+	cases := []struct {
+		name  string
+		state AgentState
+		want  bool
+	}{
+		{"disabled", AgentState{Solution: "x", StepHistory: withTool}, false},
+		{"tool run", AgentState{Solution: "x", StepHistory: withTool, SelfReviewRetries: 1}, true},
+		{"planned run", AgentState{Solution: "x", PlanStore: planned, PlanSID: "s", SelfReviewRetries: 1}, true},
+		{"direct answer", AgentState{Solution: "x", SelfReviewRetries: 1}, false},
+		{"retries used", AgentState{Solution: "x", StepHistory: withTool, SelfReviewRetries: 1, SelfReviews: 1}, false},
+		{"forced answer", AgentState{Solution: "x", StepHistory: withTool, SelfReviewRetries: 1, ForcedAnswer: ReasonMaxSteps}, false},
+		{"llm error", AgentState{Solution: "x", StepHistory: withTool, SelfReviewRetries: 1, LLMError: "down"}, false},
+	}
+	for _, tc := range cases {
+		if got := needsReview(&tc.state); got != tc.want {
+			t.Errorf("%s: needsReview = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestReviewPost(t *testing.T) {
+	n := NewReviewNode(nil)
+	state := &AgentState{SelfReviewRetries: 2}
+	if got := n.Post(state, nil, ReviewResult{Pass: true}); got != core.ActionEnd {
+		t.Errorf("pass → %s, want end", got)
+	}
+	if got := n.Post(state, nil, ReviewResult{Critique: "漏了 b.go", Corrections: "读取 b.go"}); got != core.ActionDefault {
+		t.Errorf("fail → %s, want default", got)
+	}
+	if state.SelfReviews != 1 || !strings.Contains(state.ReviewCritique, "漏了 b.go") || !strings.Contains(state.ReviewCritique, "1/2") {
+		t.Errorf("state after fail: reviews=%d critique=%q", state.SelfReviews, state.ReviewCritique)
+	}
+	if len(state.StepHistory) != 2 || state.StepHistory[1].Type != "review" || state.StepHistory[1].Action != "fail" {
+		t.Errorf("steps = %+v", state.StepHistory)
+	}
+	// A broken review lets the answer through without recording a step
+	if got := n.Post(state, nil, n.ExecFallback(errors.New("timeout"))); got != core.ActionEnd || len(state.StepHistory) != 2 {
+		t.Errorf("fallback → %s with %d steps", got, len(state.StepHistory))
+	}
+}
+
+func TestBuildAgentFlow_SelfReviewLoop(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{
+		"```yaml\naction: \"tool\"\nreason: \"list\"\ntool_name: \"file_list\"\ntool_params:\n  path: \".\"\n```",
+		"```yaml\naction: \"answer\"\nreason: \"done\"\nanswer: \"有文件\"\n```",
+		"有一些文件。",
+		`{"verdict": "fail", "critique": "没有列出文件名", "corrections": "逐个列出文件名"}`,
+		"```yaml\naction: \"answer\"\nreason: \"fix\"\nanswer: \"a.go\"\n```",
+		"文件：a.go",
+	}}
+	reg := tool.NewRegistry()
+	reg.Register(&mockTool{"file_list", "list files"})
+	state := &AgentState{Problem: "列出文件", ToolCallMode: "yaml", ThinkingMode: "native", ToolRegistry: reg, SelfReviewRetries: 1}
+	BuildAgentFlow(mock, reg, "native", nil).Run(context.Background(), state)
+
+	if state.Solution != "文件：a.go" {
+		t.Errorf("solution = %q, want the revised answer", state.Solution)
+	}
+	if state.SelfReviews != 1 || state.ReviewCritique != "" {
+		t.Errorf("reviews=%d critique=%q", state.SelfReviews, state.ReviewCritique)
+	}
+	if len(mock.calls) != 6 {
+		t.Fatalf("LLM calls = %d, want 6 (retry budget used up, no second review)", len(mock.calls))
+	}
+	if !strings.Contains(lastUserContent(mock.calls[4]), "没有列出文件名") {
+		t.Error("decide prompt after a failed review should carry the critique")
+	}
+	if !strings.Contains(lastUserContent(mock.calls[5]), "逐个列出文件名") {
+		t.Error("answer prompt after a failed review should carry the critique")
+	}
+}
+
+func lastUserContent(msgs []llm.Message) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == llm.RoleUser {
+			return msgs[i].Content
+		}
+	}
+	return ""
+}
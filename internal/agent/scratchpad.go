@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Kinds of scratchpad entries.
+const (
+	ScratchHypothesis = "hypothesis"
+	ScratchDecision   = "decision"
+	ScratchQuestion   = "question"
+)
+
+// maxScratchEntries caps the scratchpad; the oldest entries are dropped
+// first, so the decide prompt cannot grow without bound on long runs.
+const maxScratchEntries = 40
+
+// ScratchEntry is one structured artifact of a think step.
+type ScratchEntry struct {
+	Step int    `json:"step"` // StepNumber of the think step that wrote it
+	Kind string `json:"kind"` // ScratchHypothesis, ScratchDecision or ScratchQuestion
+	Text string `json:"text"`
+}
+
+// Scratchpad accumulates the hypotheses, decisions and open questions of a
+// run's think steps. Unlike the think output, which only survives as a step
+// summary, the entries are shown verbatim in every later decide prompt.
+// The web layer serves it while the run is going, so it is goroutine-safe,
+// unlike AgentState.
+type Scratchpad struct {
+	mu      sync.Mutex
+	entries []ScratchEntry
+}
+
+// NewScratchpad creates an empty scratchpad.
+func NewScratchpad() *Scratchpad {
+	return &Scratchpad{}
+}
+
+// Add appends the entries, skipping ones already present with the same
+// kind and text. Safe on a nil scratchpad.
+func (p *Scratchpad) Add(entries ...ScratchEntry) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range entries {
+		dup := false
+		for _, have := range p.entries {
+			if have.Kind == e.Kind && have.Text == e.Text {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			p.entries = append(p.entries, e)
+		}
+	}
+	if over := len(p.entries) - maxScratchEntries; over > 0 {
+		p.entries = append([]ScratchEntry(nil), p.entries[over:]...)
+	}
+}
+
+// Entries returns a copy of the entries in the order they were added.
+func (p *Scratchpad) Entries() []ScratchEntry {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ScratchEntry(nil), p.entries...)
+}
+
+// scratchLabels are the line prefixes of each kind in think output and in
+// the rendered scratchpad; the first one is used for rendering.
+var scratchLabels = []struct {
+	kind     string
+	prefixes []string
+}{
+	{ScratchHypothesis, []string{"假设", "hypothesis"}},
+	{ScratchDecision, []string{"决定", "decision"}},
+	{ScratchQuestion, []string{"待解问题", "问题", "open question", "question"}},
+}
+
+// scratchFormat asks the think model for the lines parseScratch reads.
+const scratchFormat = "最后另起几行，按以下格式列出本轮的结构化记录（每行一条，没有的可省略）：\n" +
+	"假设：<尚待验证的推测>\n决定：<已确定的做法或结论>\n待解问题：<还需要查明的问题>"
+
+// parseScratch extracts the scratchpad entries of a think output: lines
+// (optionally list items) starting with a kind label and a colon.
+func parseScratch(step int, text string) []ScratchEntry {
+	var out []ScratchEntry
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "-*• ")
+		line = strings.Trim(line, "*") // **假设**：…
+		for _, l := range scratchLabels {
+			if body, ok := cutLabel(line, l.prefixes); ok {
+				if body != "" {
+					out = append(out, ScratchEntry{Step: step, Kind: l.kind, Text: body})
+				}
+				break
+			}
+		}
+	}
+	return out
+}
+
+// cutLabel returns the rest of line after one of the prefixes (matched
+// case-insensitively) followed by a colon.
+func cutLabel(line string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if len(line) < len(prefix) || !strings.EqualFold(line[:len(prefix)], prefix) {
+			continue
+		}
+		rest := strings.TrimLeft(line[len(prefix):], "* ")
+		for _, colon := range []string{"：", ":"} {
+			if body, ok := strings.CutPrefix(rest, colon); ok {
+				return strings.TrimSpace(body), true
+			}
+		}
+	}
+	return "", false
+}
+
+// Render formats the scratchpad for the decide prompt; "" when it is empty.
+func (p *Scratchpad) Render() string {
+	entries := p.Entries()
+	if len(entries) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## 思考记录（此前推理得出的假设、决定和待解问题）\n")
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "- [步骤 %d] %s：%s\n", e.Step, scratchLabel(e.Kind), e.Text)
+	}
+	return sb.String()
+}
+
+// Markdown formats the scratchpad as a document for download, grouped by
+// kind.
+func (p *Scratchpad) Markdown(problem string) string {
+	entries := p.Entries()
+	var sb strings.Builder
+	sb.WriteString("# Scratchpad\n\n")
+	if problem != "" {
+		fmt.Fprintf(&sb, "> %s\n\n", strings.ReplaceAll(problem, "\n", "\n> "))
+	}
+	for _, l := range scratchLabels {
+		fmt.Fprintf(&sb, "## %s\n\n", l.prefixes[0])
+		n := 0
+		for _, e := range entries {
+			if e.Kind == l.kind {
+				fmt.Fprintf(&sb, "- %s _(step %d)_\n", e.Text, e.Step)
+				n++
+			}
+		}
+		if n == 0 {
+			sb.WriteString("_(none)_\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func scratchLabel(kind string) string {
+	for _, l := range scratchLabels {
+		if l.kind == kind {
+			return l.prefixes[0]
+		}
+	}
+	return kind
+}
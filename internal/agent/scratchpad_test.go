@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestParseScratch(t *testing.T) {
+	out := "日志显示连接在 30 秒后超时。\n\n" +
+		"假设：代理没有转发 WebSocket 升级请求\n" +
+		"- **决定**：先检查 nginx 配置\n" +
+		"Open question: is the timeout configurable?\n" +
+		"问题在于超时来自哪一层\n" + // no colon: prose, not an entry
+		"待解问题：\n" // empty
+	got := parseScratch(4, out)
+	want := []ScratchEntry{
+		{Step: 4, Kind: ScratchHypothesis, Text: "代理没有转发 WebSocket 升级请求"},
+		{Step: 4, Kind: ScratchDecision, Text: "先检查 nginx 配置"},
+		{Step: 4, Kind: ScratchQuestion, Text: "is the timeout configurable?"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("entries = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScratchpad_AddDedupeCap(t *testing.T) {
+	p := NewScratchpad()
+	p.Add(ScratchEntry{Step: 1, Kind: ScratchDecision, Text: "d"}, ScratchEntry{Step: 2, Kind: ScratchDecision, Text: "d"})
+	if n := len(p.Entries()); n != 1 {
+		t.Errorf("duplicate kept: %d entries", n)
+	}
+	for i := 0; i < maxScratchEntries+5; i++ {
+		p.Add(ScratchEntry{Step: i, Kind: ScratchQuestion, Text: strings.Repeat("q", i+1)})
+	}
+	entries := p.Entries()
+	if len(entries) != maxScratchEntries || entries[0].Kind != ScratchQuestion {
+		t.Errorf("cap: %d entries, first %+v", len(entries), entries[0])
+	}
+
+	var nilPad *Scratchpad
+	nilPad.Add(ScratchEntry{Kind: ScratchDecision, Text: "x"})
+	if nilPad.Render() != "" {
+		t.Error("nil scratchpad should render empty")
+	}
+}
+
+func TestScratchpad_ThinkToDecide(t *testing.T) {
+	state := &AgentState{Problem: "修复 WebSocket 断线", ToolRegistry: tool.NewRegistry(), Scratchpad: NewScratchpad()}
+	think := NewThinkNode(&mockLLMProvider{}, nil)
+	if prep := think.Prep(state); !prep[0].Scratchpad {
+		t.Error("think prep should ask for scratchpad entries")
+	}
+	think.Post(state, nil, ThinkResult{Thinking: "分析……\n假设：代理超时\n决定：查看 nginx 配置"})
+
+	prep := NewDecideNode(&mockLLMProvider{}, nil).Prep(state)[0]
+	for _, prompt := range []string{buildDecidePrompt(prep), buildDecidePromptFC(prep)} {
+		if !strings.Contains(prompt, "- [步骤 1] 假设：代理超时\n- [步骤 1] 决定：查看 nginx 配置") {
+			t.Errorf("decide prompt lacks the scratchpad:\n%s", prompt)
+		}
+	}
+
+	md := state.Scratchpad.Markdown(state.Problem)
+	if !strings.Contains(md, "## 决定\n\n- 查看 nginx 配置 _(step 1)_") || !strings.Contains(md, "## 待解问题\n\n_(none)_") {
+		t.Errorf("markdown:\n%s", md)
+	}
+
+	cp := NewCheckpoint("s1", state)
+	resumed := &AgentState{Scratchpad: NewScratchpad()}
+	cp.Restore(resumed)
+	if len(resumed.Scratchpad.Entries()) != 2 {
+		t.Errorf("restored scratchpad = %+v", resumed.Scratchpad.Entries())
+	}
+}
@@ -0,0 +1,192 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+// maxCitableSources caps the sources offered to the answer for citation;
+// later ones stay in the tool results but get no number.
+const maxCitableSources = 50
+
+// Source is a web page the run found or read, numbered in order of first
+// appearance so the answer can cite it inline as [N].
+type Source struct {
+	N     int    `json:"n"`
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+	Read  bool   `json:"read,omitempty"` // fetched with web_reader, not only listed by a search
+}
+
+// searchSourceTools list their results as "[i] title" followed by the URL
+// on the next line (builtin formatSearchResults).
+var searchSourceTools = map[string]bool{
+	"web_search":   true,
+	"brave_search": true,
+}
+
+// stepSources extracts the pages a tool step found (search results) or
+// read (web_reader's url argument); nil for other tools and failed calls.
+func stepSources(toolName, args, output string, isError bool) []Source {
+	if isError {
+		return nil
+	}
+	switch {
+	case searchSourceTools[toolName]:
+		return searchSources(output)
+	case toolName == "web_reader":
+		url := strings.TrimSpace(extractParam(args, "url"))
+		if !isSourceURL(url) {
+			return nil
+		}
+		title := ""
+		for _, line := range strings.SplitN(output, "\n", 4) {
+			if t, ok := strings.CutPrefix(strings.TrimSpace(line), "📄 标题："); ok {
+				title = strings.TrimSpace(t)
+				break
+			}
+		}
+		return []Source{{URL: url, Title: title, Read: true}}
+	}
+	return nil
+}
+
+// searchSources parses the result list of a search tool's output.
+func searchSources(output string) []Source {
+	var out []Source
+	title := ""
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if rest, ok := cutResultIndex(line); ok {
+			title = rest
+			continue
+		}
+		if title != "" && isSourceURL(line) {
+			out = append(out, Source{URL: line, Title: title})
+		}
+		title = ""
+	}
+	return out
+}
+
+// cutResultIndex strips the "[i] " prefix of a search result title line.
+func cutResultIndex(line string) (string, bool) {
+	if !strings.HasPrefix(line, "[") {
+		return "", false
+	}
+	end := strings.Index(line, "] ")
+	if end < 2 {
+		return "", false
+	}
+	if _, err := strconv.Atoi(line[1:end]); err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(line[end+2:]), true
+}
+
+func isSourceURL(s string) bool {
+	return !strings.ContainsAny(s, " \t") && (strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://"))
+}
+
+// addSources numbers the sources of a step in state.Sources, reusing the
+// number of a URL seen before, and returns their URLs for the StepRecord.
+func (s *AgentState) addSources(found []Source) []string {
+	var urls []string
+	for _, src := range found {
+		urls = append(urls, src.URL)
+		if i := s.sourceIndex(src.URL); i >= 0 {
+			if src.Read {
+				s.Sources[i].Read = true
+			}
+			if s.Sources[i].Title == "" {
+				s.Sources[i].Title = src.Title
+			}
+			continue
+		}
+		if len(s.Sources) >= maxCitableSources {
+			continue
+		}
+		src.N = len(s.Sources) + 1
+		s.Sources = append(s.Sources, src)
+	}
+	return urls
+}
+
+func (s *AgentState) sourceIndex(url string) int {
+	for i, src := range s.Sources {
+		if src.URL == url {
+			return i
+		}
+	}
+	return -1
+}
+
+// formatCitableSources lists the numbered sources for the answer prompt.
+func formatCitableSources(sources []Source) string {
+	var sb strings.Builder
+	sb.WriteString("[可引用来源]:\n")
+	for _, src := range sources {
+		title := src.Title
+		if title == "" {
+			title = src.URL
+		}
+		fmt.Fprintf(&sb, "[%d] %s — %s\n", src.N, util.TruncateRunes(title, 100), src.URL)
+	}
+	return sb.String()
+}
+
+// citationPattern matches inline citations: [3], [1,4], [2、5].
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*[,，、]\s*\d+)*)\]`)
+
+// citedSources returns the sources the answer cites, by number.
+func citedSources(answer string, sources []Source) []Source {
+	cited := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		for _, f := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == '，' || r == '、' || r == ' ' }) {
+			if n, err := strconv.Atoi(f); err == nil && n >= 1 && n <= len(sources) {
+				cited[n] = true
+			}
+		}
+	}
+	var out []Source
+	for _, src := range sources {
+		if cited[src.N] {
+			out = append(out, src)
+		}
+	}
+	return out
+}
+
+// sourcesSection renders the sources list appended to the answer: the
+// cited sources, or — when the answer cites none — the pages read with
+// web_reader, so research output can always be checked. "" when there is
+// nothing to list.
+func sourcesSection(answer string, sources []Source) string {
+	list, heading := citedSources(answer, sources), "🔗 来源"
+	if len(list) == 0 {
+		heading = "🔗 参考来源"
+		for _, src := range sources {
+			if src.Read {
+				list = append(list, src)
+			}
+		}
+	}
+	if len(list) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "### %s (%d)\n\n", heading, len(list))
+	for _, src := range list {
+		title := strings.NewReplacer("[", "(", "]", ")", "\n", " ").Replace(util.TruncateRunes(src.Title, 100))
+		if title == "" {
+			fmt.Fprintf(&sb, "- [%d] <%s>\n", src.N, src.URL)
+		} else {
+			fmt.Fprintf(&sb, "- [%d] [%s](%s)\n", src.N, title, src.URL)
+		}
+	}
+	return sb.String()
+}
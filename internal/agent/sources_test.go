@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+const testSearchOutput = "找到 2 条结果：\n\n" +
+	"[1] Go 1.24 Release Notes（2025-02-11）\n    https://go.dev/doc/go1.24\n    The latest Go release...\n\n" +
+	"[2] Go Blog\n    https://go.dev/blog/\n    News from the Go team\n\n"
+
+func TestStepSources(t *testing.T) {
+	got := stepSources("web_search", `{"query":"go 1.24"}`, testSearchOutput, false)
+	if len(got) != 2 || got[0].URL != "https://go.dev/doc/go1.24" || got[0].Title != "Go 1.24 Release Notes（2025-02-11）" || got[1].URL != "https://go.dev/blog/" {
+		t.Errorf("search sources = %+v", got)
+	}
+
+	got = stepSources("web_reader", `{"url":" https://go.dev/blog/ "}`, "📄 标题：The Go Blog\n\n正文", false)
+	if len(got) != 1 || got[0].URL != "https://go.dev/blog/" || got[0].Title != "The Go Blog" || !got[0].Read {
+		t.Errorf("web_reader sources = %+v", got)
+	}
+
+	for _, tc := range []struct{ tool, args, output string }{
+		{"web_reader", `{"url":"ftp://x"}`, ""},
+		{"file_read", `{"path":"a.go"}`, testSearchOutput},
+		{"brave_search", `{"query":"x"}`, "未找到相关结果。"},
+	} {
+		if got := stepSources(tc.tool, tc.args, tc.output, false); got != nil {
+			t.Errorf("stepSources(%s, %s) = %+v, want nil", tc.tool, tc.args, got)
+		}
+	}
+	if got := stepSources("web_search", `{}`, testSearchOutput, true); got != nil {
+		t.Errorf("failed step sources = %+v", got)
+	}
+}
+
+func TestAddSources_NumbersByFirstAppearance(t *testing.T) {
+	state := &AgentState{}
+	state.addSources(stepSources("web_search", "", testSearchOutput, false))
+	urls := state.addSources(stepSources("web_reader", `{"url":"https://go.dev/blog/"}`, "📄 标题：The Go Blog", false))
+	if len(urls) != 1 || urls[0] != "https://go.dev/blog/" {
+		t.Errorf("step URLs = %v", urls)
+	}
+	state.addSources([]Source{{URL: "https://example.com/"}})
+
+	if len(state.Sources) != 3 {
+		t.Fatalf("Sources = %+v", state.Sources)
+	}
+	blog := state.Sources[1]
+	if blog.N != 2 || !blog.Read || blog.Title != "Go Blog" {
+		t.Errorf("re-read source = %+v, want number 2 kept and marked read", blog)
+	}
+	if state.Sources[2].N != 3 {
+		t.Errorf("new source number = %d, want 3", state.Sources[2].N)
+	}
+}
+
+func TestSourcesSection(t *testing.T) {
+	sources := []Source{
+		{N: 1, URL: "https://a.example/", Title: "A [draft]"},
+		{N: 2, URL: "https://b.example/", Read: true},
+		{N: 3, URL: "https://c.example/", Title: "C"},
+	}
+
+	got := sourcesSection("A 成立 [1]，C 也是 [3, 9]。", sources)
+	want := "### 🔗 来源 (2)\n\n- [1] [A (draft)](https://a.example/)\n- [3] [C](https://c.example/)\n"
+	if got != want {
+		t.Errorf("cited section = %q, want %q", got, want)
+	}
+
+	// No citations: fall back to the pages that were read
+	got = sourcesSection("没有引用。", sources)
+	if want := "### 🔗 参考来源 (1)\n\n- [2] <https://b.example/>\n"; got != want {
+		t.Errorf("fallback section = %q, want %q", got, want)
+	}
+
+	if got := sourcesSection("没有引用。", sources[:1]); got != "" {
+		t.Errorf("section without citations or reads = %q", got)
+	}
+}
+
+func TestAnswerNode_CitesSources(t *testing.T) {
+	state := &AgentState{Problem: "Go 1.24 有什么新特性？"}
+	(&ToolNodeImpl{}).Post(state, []ToolPrep{{ToolName: "web_search", Args: []byte(`{"query":"go 1.24"}`)}}, ToolExecResult{Output: testSearchOutput})
+	if got := state.StepHistory[0].Sources; len(got) != 2 {
+		t.Fatalf("step sources = %v", got)
+	}
+
+	prep := (&AnswerNodeImpl{}).Prep(state)[0]
+	if !strings.Contains(prep.FullContext, "[可引用来源]:\n[1] Go 1.24 Release Notes（2025-02-11） — https://go.dev/doc/go1.24\n[2] Go Blog — https://go.dev/blog/") {
+		t.Errorf("answer context lacks the numbered sources:\n%s", prep.FullContext)
+	}
+
+	(&AnswerNodeImpl{}).Post(state, nil, AnswerResult{Answer: "Go 1.24 支持泛型类型别名 [1]。"})
+	if !strings.HasSuffix(state.Solution, "### 🔗 来源 (1)\n\n- [1] [Go 1.24 Release Notes（2025-02-11）](https://go.dev/doc/go1.24)\n") {
+		t.Errorf("Solution = %q", state.Solution)
+	}
+}
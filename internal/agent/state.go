@@ -1,11 +1,13 @@
 package agent
 
 import (
-	"context"
 	"log"
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/pocketomega/pocket-omega/internal/audit"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
 	"github.com/pocketomega/pocket-omega/internal/llm"
 	"github.com/pocketomega/pocket-omega/internal/plan"
 	"github.com/pocketomega/pocket-omega/internal/tool"
@@ -25,9 +27,11 @@ type AgentState struct {
 	Solution string // Final answer
 
 	ThinkingMode        string // "native" or "app" — controls DecideNode prompt options
-	ToolCallMode        string // "auto", "fc", or "yaml" — may be raw unresolved value
+	ToolCallMode        string // "auto", "fc", "yaml", or "json" — may be raw unresolved value
 	ContextWindowTokens int    // model context window in tokens; 0 = use safe fallback
 	ConversationHistory string // formatted conversation prefix, populated by Handler layer
+	AnswerStyle         string // answer style profile (prompt.LoadAnswerStyle); "" = default
+	ToolProfile         string // tool profile ToolRegistry is filtered by (tool.Profiles); "" = all tools
 
 	// Runtime environment info — injected by AgentHandler from AgentHandlerOptions.
 	OSName    string // e.g. "Windows", "Linux", "macOS"
@@ -39,17 +43,51 @@ type AgentState struct {
 	LastDecision *Decision `json:"-"`
 
 	// Guardrail fields
-	LoopDetectionStreak int                             `json:"-"` // consecutive loop detections without self-correction
-	CostGuard           *CostGuard                      `json:"-"` // nil = disabled; enforces token/duration limits
-	pendingCompact      bool                            // single-goroutine: set by Post (from Decision.ContextStatus), consumed in Post
-	OnContextOverflow   func(ctx context.Context) error `json:"-"` // injected by AgentHandler
-	WalkthroughStore    *walkthrough.Store              `json:"-"` // nil = disabled
-	WalkthroughSID      string                          `json:"-"` // session ID for walkthrough
-	PlanStore           *plan.PlanStore                 `json:"-"` // nil = disabled; plan status prompt injection
-	PlanSID             string                          `json:"-"` // session ID for plan status
-	ReadCache           *ReadCache                      `json:"-"` // nil = disabled; session-level file_read cache
-	MetaToolRedirectMsg string                          `json:"-"` // set by MetaToolGuard in Post, consumed by Prep
-	SuppressMetaTools   bool                            `json:"-"` // when true, Prep filters meta-tools from ToolDefinitions
+	LoopDetectionStreak int                    `json:"-"` // consecutive loop detections without self-correction
+	CostGuard           *CostGuard             `json:"-"` // nil = disabled; enforces token/duration limits
+	pendingCompact      bool                   // single-goroutine: set by DecideNode.Post (from Decision.ContextStatus), consumed by CompactNode
+	compactTried        bool                   // CompactNode ran this run; it is not retried
+	CompactRatio        float64                `json:"-"` // fraction of ContextWindowTokens that triggers compaction; 0 = DefaultCompactRatio
+	OnContextOverflow   CompactFunc            `json:"-"` // injected by AgentHandler; nil = no automatic compaction
+	WalkthroughStore    *walkthrough.Store     `json:"-"` // nil = disabled
+	WalkthroughSID      string                 `json:"-"` // session ID for walkthrough
+	PlanStore           *plan.PlanStore        `json:"-"` // nil = disabled; plan status prompt injection
+	PlanSID             string                 `json:"-"` // session ID for plan status
+	ReadCache           *ReadCache             `json:"-"` // nil = disabled; session-level file_read cache
+	SearchHistory       *tool.SearchHistory    `json:"-"` // nil = disabled; search results already shown this run, dropped from later searches
+	Changes             *ChangeTracker         `json:"-"` // nil = disabled; files changed by tools, listed at the end of the answer
+	Translator          *i18n.Translator       `json:"-"` // nil = disabled; normalises tool results into the working language
+	OutputProcessor     *OutputProcessor       `json:"-"` // nil = disabled; archives oversized tool outputs and keeps a summary
+	Replay              *ReplayRun             `json:"-"` // nil = disabled; structured JSONL run record for `omega replay`
+	DownshiftProvider   llm.LLMProvider        `json:"-"` // nil = disabled; cheaper decide model used once CostGuard nears its budget
+	DownshiftModel      string                 `json:"-"` // display name of DownshiftProvider's model
+	Downshift           *DownshiftInfo         // set once decide steps have switched to DownshiftProvider
+	OnDownshift         func(DownshiftInfo)    `json:"-"` // SSE notice callback
+	MetaToolRedirectMsg string                 `json:"-"` // set by MetaToolGuard in Post, consumed by Prep
+	SuppressMetaTools   bool                   `json:"-"` // when true, Prep filters meta-tools from ToolDefinitions
+	YAMLParseFailures   int                    `json:"-"` // YAML decisions that failed to parse this run; enables few-shot repair
+	Annotations         *AnnotationQueue       `json:"-"` // nil = disabled; user step annotations received while running
+	Corrections         []StepAnnotation       `json:"-"` // annotations consumed so far; shown in every later decide prompt
+	OnCorrections       func([]StepAnnotation) `json:"-"` // called when Prep picks up new annotations
+	ForcedAnswer        string                 `json:"-"` // why DecideNode forced the answer: ReasonMaxSteps, ReasonBudget or ReasonMetaToolLoop
+	Outcome             *RunOutcome            `json:"-"` // post-run classification, set by the caller before Replay.End
+	LLMError            string                 `json:"-"` // model call failure that ended the run with a canned answer
+	SelfReviewRetries   int                    `json:"-"` // AGENT_SELF_REVIEW: failed reviews that may send the answer back to DecideNode; 0 = no review
+	SelfReviews         int                    `json:"-"` // failed self-reviews so far this run
+	ReviewCritique      string                 `json:"-"` // last failed review, shown to DecideNode and AnswerNode until the next answer
+	ResumeNote          string                 `json:"-"` // set by Checkpoint.Restore: the work done before the interruption, shown with the plan
+	AskUser             AskFunc                `json:"-"` // nil = disabled (headless runs); poses an "ask" decision's question and waits for the reply
+	Asks                int                    `json:"-"` // clarifying questions asked so far this run
+	Images              []llm.ContentPart      `json:"-"` // user uploads and image_read results, attached to the user message; newest maxContextImages kept
+	Audit               *audit.Run             `json:"-"` // nil = disabled; every tool call is recorded in the audit log
+	Scratchpad          *Scratchpad            `json:"-"` // nil = disabled; hypotheses, decisions and open questions of think steps
+	Verify              *Verification          `json:"-"` // nil = disabled; code edits whose checks must pass before answering
+	Sources             []Source               `json:"-"` // web pages found or read by tools, numbered for the answer's [n] citations
+
+	// Step budget: MaxSteps per run, extended once by maybeExtendStepBudget
+	MaxSteps        int                       `json:"-"` // 0 = MaxAgentSteps (see StepBudget)
+	StepsExtended   *StepBudgetExtension      `json:"-"` // set once the step budget has been extended
+	OnStepsExtended func(StepBudgetExtension) `json:"-"` // SSE notice callback
 
 	// SSE callbacks
 	OnStepComplete func(StepRecord)            `json:"-"`
@@ -59,15 +97,28 @@ type AgentState struct {
 
 // StepRecord records a single step execution.
 type StepRecord struct {
-	StepNumber int    `json:"step_number"`
-	Type       string `json:"type"`                   // "decide", "tool", "think", "answer"
-	Action     string `json:"action"`                 // Decision action
-	ToolName   string `json:"tool_name"`              // Tool name (when type=tool)
-	Input      string `json:"input"`                  // Input content
-	Output     string `json:"output"`                 // Output result
-	ToolCallID string `json:"tool_call_id,omitempty"` // FC only: correlates with model's tool call
-	IsError    bool   `json:"is_error,omitempty"`     // true when tool returned an error
-	DurationMs int64  `json:"duration_ms,omitempty"`  // tool execution time in ms; only type=tool
+	StepNumber int       `json:"step_number"`
+	Type       string    `json:"type"`                   // "decide", "tool", "think", "answer", "compact", "review", "ask", "verify"
+	Action     string    `json:"action"`                 // Decision action
+	ToolName   string    `json:"tool_name"`              // Tool name (when type=tool)
+	Input      string    `json:"input"`                  // Input content
+	Output     string    `json:"output"`                 // Output result
+	ToolCallID string    `json:"tool_call_id,omitempty"` // FC only: correlates with model's tool call
+	IsError    bool      `json:"is_error,omitempty"`     // true when tool returned an error
+	DurationMs int64     `json:"duration_ms,omitempty"`  // tool execution time in ms; only type=tool
+	OutputRef  string    `json:"output_ref,omitempty"`   // archived full output when Output is a summary (see output_read)
+	Model      string    `json:"model,omitempty"`        // model that served the step's LLM call (decide/think/answer/review)
+	Diff       *FileDiff `json:"-"`                      // edit tools: the change to the file; the web UI sends it as its own event
+	Sources    []string  `json:"sources,omitempty"`      // search/web_reader: URLs of the pages the step found or read (see AgentState.Sources)
+}
+
+// stepModel names the model serving role for a StepRecord: the routed
+// model when p can tell (llm.Router), otherwise the session's model.
+func stepModel(state *AgentState, p llm.LLMProvider, role llm.ModelRole) string {
+	if m := llm.ModelFor(p, role); m != "" {
+		return m
+	}
+	return state.ModelName
 }
 
 // MaxAgentSteps prevents infinite decision loops.
@@ -101,8 +152,9 @@ type DecidePrep struct {
 	ToolsPrompt         string               // Available tools description (YAML path)
 	ToolDefinitions     []llm.ToolDefinition // Tool definitions (FC path)
 	StepCount           int                  // Current step count (for forced termination)
+	StepBudget          int                  // step budget of the run (AgentState.StepBudget)
 	ThinkingMode        string               // "native" or "app"
-	ToolCallMode        string               // "auto", "fc", or "yaml" — may be raw unresolved value
+	ToolCallMode        string               // "auto", "fc", "yaml", or "json" — may be raw unresolved value
 	ConversationHistory string               // formatted conversation prefix from previous turns
 	ToolingSummary      string               // Phase 1: auto-generated tool summary from Registry
 	RuntimeLine         string               // Phase 1: compact runtime info line
@@ -114,26 +166,37 @@ type DecidePrep struct {
 	SystemPromptEst     int                  // estimated system prompt tokens (computed in Prep)
 	WalkthroughText     string               // Render output, injected into prompt
 	PlanText            string               // PlanStore.Render output, injected into prompt
+	ScratchpadText      string               // Scratchpad.Render output, injected into prompt
+	Provider            llm.LLMProvider      // non-nil after a downshift; overrides the node's provider
+	YAMLParseFailures   int                  // YAML parse failures so far this run
+	DecisionExamples    []string             // known-good YAML decisions for the active tools (few-shot repair)
+	Corrections         string               // rendered user step annotations, highest priority
+	AnswerStyle         string               // answer style profile name; "" = default
+	Images              []llm.ContentPart    // attached to the user message
+	CanAsk              bool                 // the "ask" action is offered (canAsk)
 }
 
 // Decision is the LLM's decision output.
 // In YAML mode: parsed from YAML text. In FC mode: extracted from tool_calls.
 // ToolParams uses map[string]any; converted to json.RawMessage before calling Tool.Execute().
 type Decision struct {
-	Action        string         `yaml:"action"`      // "tool", "think", "answer"
-	Reason        string         `yaml:"reason"`      // Reasoning for this decision
-	ToolName      string         `yaml:"tool_name"`   // Required when action=tool
-	ToolParams    map[string]any `yaml:"tool_params"` // YAML-friendly, json.Marshal before tool call
-	Thinking      string         `yaml:"thinking"`    // Used when action=think
-	Answer        string         `yaml:"answer"`      // Used when action=answer
-	ToolCallID    string         `yaml:"-"`           // FC only: tool call ID for result correlation
-	ContextStatus ContextStatus  `yaml:"-"`           // set by Exec when context window is filling up
+	Action        string         `yaml:"action" json:"action"`           // "tool", "think", "answer", "ask"
+	Reason        string         `yaml:"reason" json:"reason"`           // Reasoning for this decision
+	ToolName      string         `yaml:"tool_name" json:"tool_name"`     // Required when action=tool
+	ToolParams    map[string]any `yaml:"tool_params" json:"tool_params"` // YAML-friendly, json.Marshal before tool call
+	Thinking      string         `yaml:"thinking" json:"thinking"`       // Used when action=think
+	Answer        string         `yaml:"answer" json:"answer"`           // Used when action=answer
+	Question      string         `yaml:"question" json:"question"`       // Used when action=ask
+	ToolCallID    string         `yaml:"-" json:"-"`                     // FC only: tool call ID for result correlation
+	ContextStatus ContextStatus  `yaml:"-" json:"-"`                     // set by Exec when context window is filling up
+	ParseFailures int            `yaml:"-" json:"-"`                     // YAML parse failures during this Exec, added to AgentState by Post
+	LLMError      string         `yaml:"-" json:"-"`                     // set by ExecFallback: the model call failed, Answer is a canned apology
 
 	// Plan sideband — plan status update piggybacked on Decision.
-	// YAML mode: auto-parsed via yaml tags.
+	// YAML/JSON mode: auto-parsed via struct tags.
 	// FC mode: parsed from reason text via [plan:step_id:status] marker.
-	PlanStep   string `yaml:"plan_step,omitempty"`   // e.g. "create_server"
-	PlanStatus string `yaml:"plan_status,omitempty"` // "in_progress" | "done"
+	PlanStep   string `yaml:"plan_step,omitempty" json:"plan_step,omitempty"`     // e.g. "create_server"
+	PlanStatus string `yaml:"plan_status,omitempty" json:"plan_status,omitempty"` // "in_progress" | "done"
 }
 
 // ── ToolNode generic types ──
@@ -141,11 +204,17 @@ type Decision struct {
 
 // ToolPrep is prepared by reading LastDecision and converting ToolParams.
 type ToolPrep struct {
-	ToolName     string
-	Args         []byte     // json.RawMessage from json.Marshal(Decision.ToolParams)
-	ToolCallID   string     // FC only: correlates tool result with the model's tool call
-	ResolvedTool tool.Tool  // resolved in Prep from state.ToolRegistry; nil = not found
-	ReadCache    *ReadCache // nil = disabled; for duplicate read interception
+	ToolName        string
+	Args            []byte              // json.RawMessage from json.Marshal(Decision.ToolParams)
+	ToolCallID      string              // FC only: correlates tool result with the model's tool call
+	ResolvedTool    tool.Tool           // resolved in Prep from state.ToolRegistry; nil = not found
+	Timeout         time.Duration       // per-tool deadline from the registry; 0 = none
+	ReadCache       *ReadCache          // nil = disabled; for duplicate read interception
+	SearchHistory   *tool.SearchHistory // nil = disabled; passed to search tools via the context
+	Changes         *ChangeTracker      // nil = disabled; records the files the tool changes
+	Translator      *i18n.Translator    // nil = disabled; translates tool results for the model
+	OutputProcessor *OutputProcessor    // nil = disabled; summarizes oversized outputs
+	WorkspaceDir    string              // resolves the path of edit tools for their diff
 }
 
 // ToolExecResult is the result of executing a tool.
@@ -155,6 +224,10 @@ type ToolExecResult struct {
 	Error      string
 	ToolCallID string // FC only: passed through for multi-turn conversation history
 	DurationMs int64  // execution time in milliseconds
+	Code       string // classifies a failure, e.g. tool.CodeTimeout
+	OutputRef  string // set when Output was summarized; the full output is archived under this ID
+	Images     []llm.ContentPart
+	Diff       *FileDiff // edit tools: the change to the file
 }
 
 // ── ThinkNode generic types ──
@@ -162,8 +235,9 @@ type ToolExecResult struct {
 
 // ThinkPrep provides context for reasoning.
 type ThinkPrep struct {
-	Problem string
-	Context string // Accumulated context from steps
+	Problem    string
+	Context    string // Accumulated context from steps
+	Scratchpad bool   // ask for structured entries (scratchFormat)
 }
 
 // ThinkResult holds the reasoning output.
@@ -171,6 +245,37 @@ type ThinkResult struct {
 	Thinking string
 }
 
+// ── AskNode generic types ──
+// BaseNode[AgentState, AskPrep, AskResult]
+
+// AskPrep carries the clarifying question of an "ask" decision.
+type AskPrep struct {
+	Question string
+	Ask      AskFunc
+}
+
+// AskResult holds the user's reply, or why none arrived.
+type AskResult struct {
+	Reply string
+	Err   string
+}
+
+// ── CompactNode generic types ──
+// BaseNode[AgentState, CompactPrep, CompactResult]
+
+// CompactPrep carries the compaction callback and the context size that triggered it.
+type CompactPrep struct {
+	Tokens  int // ConversationHistory + step summary estimate
+	Compact CompactFunc
+}
+
+// CompactResult holds the rebuilt conversation history.
+type CompactResult struct {
+	History string
+	Turns   int   // turns folded into the summary; 0 = nothing compacted
+	Err     error // set by ExecFallback
+}
+
 // ── AnswerNode generic types ──
 // BaseNode[AgentState, AnswerPrep, AnswerResult]
 
@@ -179,12 +284,54 @@ type AnswerPrep struct {
 	Problem     string
 	FullContext string             // Complete context from all steps
 	HasToolUse  bool               // Whether any tool was used (skip shortcut if true)
+	AnswerStyle string             // answer style profile name; "" = default
+	Images      []llm.ContentPart  // attached to the user message
+	Sources     []Source           // numbered sources the answer may cite as [n]
 	StreamChunk func(chunk string) `json:"-"` // Optional streaming callback
 }
 
 // AnswerResult holds the final answer.
 type AnswerResult struct {
-	Answer string
+	Answer   string
+	LLMError string // set by ExecFallback: the model call failed
+	Direct   bool   // short answer passed through without an LLM call
+}
+
+// ── ReviewNode generic types ──
+// BaseNode[AgentState, ReviewPrep, ReviewResult]
+
+// ReviewPrep carries what the self-review checks the answer against.
+type ReviewPrep struct {
+	Problem  string
+	Answer   string
+	PlanText string // PlanStore.Render output; "" = no plan
+	Evidence string // condensed tool/think steps
+}
+
+// ReviewResult is the reviewer's verdict.
+type ReviewResult struct {
+	Pass        bool
+	Critique    string // what is wrong with the answer (fail only)
+	Corrections string // what to redo or fix before answering again (fail only)
+	Err         error  // set by ExecFallback; the answer passes unreviewed
+}
+
+// ── VerifyNode generic types ──
+// BaseNode[AgentState, VerifyPrep, VerifyResult]
+
+// VerifyPrep carries the check of a run's code edits.
+type VerifyPrep struct {
+	Command string
+	Edited  []string  // workspace-relative files edited since the last passing check
+	Shell   tool.Tool // nil = hint only (VerifyHint, or shell_exec unavailable)
+}
+
+// VerifyResult is the outcome of running the check command.
+type VerifyResult struct {
+	Ran        bool // false = hint only
+	Passed     bool
+	Output     string // tail of the command output
+	DurationMs int64
 }
 
 // hasToolSteps checks if any step in the history is a tool execution.
@@ -196,3 +343,16 @@ func hasToolSteps(state *AgentState) bool {
 	}
 	return false
 }
+
+// maxContextImages caps the images attached to each LLM request; older
+// images are dropped first since every step resends them.
+const maxContextImages = 4
+
+// appendImages adds images, keeping only the newest maxContextImages.
+func appendImages(images []llm.ContentPart, add ...llm.ContentPart) []llm.ContentPart {
+	images = append(images, add...)
+	if len(images) > maxContextImages {
+		images = append([]llm.ContentPart(nil), images[len(images)-maxContextImages:]...)
+	}
+	return images
+}
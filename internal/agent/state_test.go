@@ -1,8 +1,11 @@
 package agent
 
 import (
+	"fmt"
 	"os"
 	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
 )
 
 func TestLoadMaxSteps_Default(t *testing.T) {
@@ -43,3 +46,46 @@ func TestLoadMaxSteps_Invalid(t *testing.T) {
 		t.Errorf("expected fallback 64, got %d", got)
 	}
 }
+
+func TestAppendImages_KeepsNewest(t *testing.T) {
+	var images []llm.ContentPart
+	for i := range maxContextImages + 2 {
+		images = appendImages(images, llm.ContentPart{Type: llm.ContentPartImage, ImageURL: fmt.Sprint(i)})
+	}
+	if len(images) != maxContextImages || images[0].ImageURL != "2" || images[len(images)-1].ImageURL != fmt.Sprint(maxContextImages+1) {
+		t.Errorf("images = %v", images)
+	}
+}
+
+func TestToolPost_CollectsImages(t *testing.T) {
+	state := &AgentState{Images: []llm.ContentPart{{Type: llm.ContentPartImage, ImageURL: "upload"}}}
+	n := &ToolNodeImpl{}
+	n.Post(state, []ToolPrep{{ToolName: "image_read", Args: []byte(`{"path":"a.png"}`)}},
+		ToolExecResult{ToolName: "image_read", Output: "已加载图片 a.png", Images: []llm.ContentPart{{Type: llm.ContentPartImage, ImageURL: "a.png"}}})
+	if len(state.Images) != 2 || state.Images[1].ImageURL != "a.png" {
+		t.Errorf("images = %v", state.Images)
+	}
+}
+
+func TestStepRecord_Model(t *testing.T) {
+	router := llm.NewRouter(&mockLLMProvider{}, "gpt-4o").
+		Route(llm.ModelDecide, &mockLLMProvider{}, "o3").
+		Route(llm.ModelThink, &mockLLMProvider{}, "deepseek-reasoner")
+	state := &AgentState{ModelName: "gpt-4o", DownshiftModel: "gpt-4o-mini"}
+
+	NewDecideNode(router, nil).Post(state, []DecidePrep{{}}, Decision{Action: "think"})
+	NewThinkNode(router, nil).Post(state, nil, ThinkResult{Thinking: "..."})
+	NewDecideNode(router, nil).Post(state, []DecidePrep{{Provider: &mockLLMProvider{}}}, Decision{Action: "think"})
+	(&AnswerNodeImpl{llmProvider: router}).Post(state, nil, AnswerResult{Answer: "ok"})
+	(&AnswerNodeImpl{llmProvider: router}).Post(state, nil, AnswerResult{Answer: "hi", Direct: true})
+
+	want := []string{"o3", "deepseek-reasoner", "gpt-4o-mini", "gpt-4o", ""}
+	if len(state.StepHistory) != len(want) {
+		t.Fatalf("steps = %d, want %d", len(state.StepHistory), len(want))
+	}
+	for i, step := range state.StepHistory {
+		if step.Model != want[i] {
+			t.Errorf("step %d (%s): model %q, want %q", i+1, step.Type, step.Model, want[i])
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+// MaxStepsLimit bounds the step budget of a run, including an extension.
+const MaxStepsLimit = 200
+
+// stepExtensionHeadroom is the share of the CostGuard token and duration
+// budgets a run may have used and still be granted a step extension.
+const stepExtensionHeadroom = 0.7
+
+// StepExtension is the number of steps a planned run may add once when its
+// plan outgrows the step budget. Configurable via AGENT_STEP_EXTENSION
+// (default: 16, 0 = never extend).
+var StepExtension = loadStepExtension()
+
+// loadStepExtension reads AGENT_STEP_EXTENSION from the environment.
+func loadStepExtension() int {
+	const defaultExtension = 16
+	v := os.Getenv("AGENT_STEP_EXTENSION")
+	if v == "" {
+		return defaultExtension
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || n > MaxStepsLimit {
+		log.Printf("[Config] WARNING: invalid AGENT_STEP_EXTENSION=%q (must be 0-%d), using default %d", v, MaxStepsLimit, defaultExtension)
+		return defaultExtension
+	}
+	return n
+}
+
+// StepBudgetExtension records the one-time extension of a run's step budget.
+type StepBudgetExtension struct {
+	Step          int `json:"step"`           // decide step at which the budget was extended
+	From          int `json:"from"`           // budget before
+	To            int `json:"to"`             // budget after
+	RemainingPlan int `json:"remaining_plan"` // unfinished plan steps at the time
+}
+
+// StepBudget returns the step budget of the run: MaxSteps (or the global
+// MaxAgentSteps) plus the extension, if one was granted.
+func (s *AgentState) StepBudget() int {
+	budget := s.MaxSteps
+	if budget <= 0 {
+		budget = MaxAgentSteps
+	}
+	if s.StepsExtended != nil {
+		budget = s.StepsExtended.To
+	}
+	return budget
+}
+
+// maybeExtendStepBudget grants the run StepExtension more steps, once, when
+// its plan has more unfinished steps than the run has steps left and the
+// CostGuard budgets leave room for them. Reports whether it extended.
+func maybeExtendStepBudget(state *AgentState, step int) bool {
+	if StepExtension <= 0 || state.StepsExtended != nil || state.PlanStore == nil || state.PlanSID == "" {
+		return false
+	}
+	budget := state.StepBudget()
+	if budget >= MaxStepsLimit {
+		return false
+	}
+	remainingPlan := 0
+	for _, s := range state.PlanStore.Get(state.PlanSID) {
+		if s.Status == "pending" || s.Status == "in_progress" {
+			remainingPlan++
+		}
+	}
+	if remainingPlan == 0 || remainingPlan <= budget-len(state.StepHistory) {
+		return false
+	}
+	if g := state.CostGuard; g != nil && !g.HasHeadroom(stepExtensionHeadroom) {
+		log.Printf("[StepBudget] Plan needs more steps (%d left in plan, %d in budget), but CostGuard has no headroom",
+			remainingPlan, budget-len(state.StepHistory))
+		return false
+	}
+	ext := StepBudgetExtension{Step: step, From: budget, To: min(budget+StepExtension, MaxStepsLimit), RemainingPlan: remainingPlan}
+	state.StepsExtended = &ext
+	log.Printf("[StepBudget] Extended at step %d: %d → %d steps (%d plan steps left)", step, ext.From, ext.To, remainingPlan)
+	if state.OnStepsExtended != nil {
+		state.OnStepsExtended(ext)
+	}
+	return true
+}
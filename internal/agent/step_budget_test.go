@@ -0,0 +1,94 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/plan"
+)
+
+func TestStepBudget(t *testing.T) {
+	if got := (&AgentState{}).StepBudget(); got != MaxAgentSteps {
+		t.Errorf("default budget = %d, want MaxAgentSteps %d", got, MaxAgentSteps)
+	}
+	s := &AgentState{MaxSteps: 10}
+	if got := s.StepBudget(); got != 10 {
+		t.Errorf("budget = %d, want 10", got)
+	}
+	s.StepsExtended = &StepBudgetExtension{From: 10, To: 26}
+	if got := s.StepBudget(); got != 26 {
+		t.Errorf("extended budget = %d, want 26", got)
+	}
+}
+
+func TestMaybeExtendStepBudget(t *testing.T) {
+	saved := StepExtension
+	defer func() { StepExtension = saved }()
+	StepExtension = 16
+
+	newState := func(history int, statuses ...string) *AgentState {
+		store := plan.NewPlanStore()
+		var steps []plan.PlanStep
+		for i, st := range statuses {
+			steps = append(steps, plan.PlanStep{ID: string(rune('a' + i)), Title: "step", Status: st})
+		}
+		store.Set("s1", steps)
+		return &AgentState{MaxSteps: 10, PlanStore: store, PlanSID: "s1", StepHistory: make([]StepRecord, history)}
+	}
+
+	// 3 open plan steps, 2 steps left: extend once
+	s := newState(8, "done", "in_progress", "pending", "pending")
+	var notified *StepBudgetExtension
+	s.OnStepsExtended = func(e StepBudgetExtension) { notified = &e }
+	if !maybeExtendStepBudget(s, 8) || s.StepBudget() != 26 {
+		t.Fatalf("not extended: budget %d", s.StepBudget())
+	}
+	if notified == nil || *notified != (StepBudgetExtension{Step: 8, From: 10, To: 26, RemainingPlan: 3}) {
+		t.Errorf("notice = %+v", notified)
+	}
+	s.StepHistory = make([]StepRecord, 25)
+	if maybeExtendStepBudget(s, 25) || s.StepBudget() != 26 {
+		t.Error("extended twice")
+	}
+
+	// Enough steps left for the plan
+	if maybeExtendStepBudget(newState(5, "pending", "pending"), 5) {
+		t.Error("extended although the plan fits the budget")
+	}
+	// No plan
+	if maybeExtendStepBudget(&AgentState{MaxSteps: 10, StepHistory: make([]StepRecord, 10)}, 10) {
+		t.Error("extended without a plan")
+	}
+	// CostGuard nearly spent
+	s = newState(9, "pending", "pending")
+	s.CostGuard = NewCostGuard(1000, 0)
+	s.CostGuard.RecordTokens(800)
+	if maybeExtendStepBudget(s, 9) {
+		t.Error("extended without CostGuard headroom")
+	}
+	// Disabled
+	StepExtension = 0
+	if maybeExtendStepBudget(newState(9, "pending", "pending"), 9) {
+		t.Error("extended with AGENT_STEP_EXTENSION=0")
+	}
+}
+
+func TestCostGuard_HasHeadroom(t *testing.T) {
+	if !NewCostGuard(0, 0).HasHeadroom(0.7) {
+		t.Error("disabled guard should have headroom")
+	}
+	g := NewCostGuard(1000, time.Hour)
+	g.RecordTokens(600)
+	if !g.HasHeadroom(0.7) {
+		t.Error("60% used should leave headroom at 0.7")
+	}
+	g.RecordTokens(100)
+	if g.HasHeadroom(0.7) {
+		t.Error("70% used should leave no headroom at 0.7")
+	}
+	g = NewCostGuard(0, time.Minute)
+	g.startTime = time.Now().Add(-time.Minute)
+	if g.HasHeadroom(0.7) {
+		t.Error("expired duration should leave no headroom")
+	}
+}
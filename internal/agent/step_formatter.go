@@ -18,22 +18,21 @@ func recentWindowForSteps(toolCount int) int {
 	return recentWindowSize
 }
 
-// perStepOutputBudget computes the max characters per recent tool step in the decision
+// perStepOutputBudget computes the max tokens per recent tool step in the decision
 // prompt. Allocates toolOutputBudgetPct% of the context window to tool outputs and
 // divides evenly across windowSize steps.
-// Falls back to 8000 when contextWindowTokens is 0 (unconfigured), preserving
-// existing behaviour.
+// Falls back to 4000 when contextWindowTokens is 0 (unconfigured).
 func perStepOutputBudget(contextWindowTokens int, windowSize int) int {
 	if contextWindowTokens <= 0 {
-		return 8000 // backward-compatible default
+		return 4000 // default when the window is unknown
 	}
 	if windowSize <= 0 {
 		windowSize = recentWindowSize
 	}
 	const toolOutputBudgetPct = 40 // percent of context window reserved for tool outputs
-	budget := contextWindowTokens * charsPerToken * toolOutputBudgetPct / 100 / windowSize
-	if budget < 1000 {
-		budget = 1000 // floor: keep outputs useful even on tiny context windows
+	budget := contextWindowTokens * toolOutputBudgetPct / 100 / windowSize
+	if budget < 500 {
+		budget = 500 // floor: keep outputs useful even on tiny context windows
 	}
 	return budget
 }
@@ -87,6 +86,10 @@ func buildStepSummary(steps []StepRecord, contextWindowTokens int) string {
 				sb.WriteString(fmt.Sprintf("  步骤 %d [推理]: %s\n", s.StepNumber, truncate(s.Output, 200)))
 			case "answer":
 				sb.WriteString(fmt.Sprintf("  步骤 %d [回答]: %s\n", s.StepNumber, truncate(s.Output, 200)))
+			case "ask":
+				sb.WriteString(fmt.Sprintf("  步骤 %d [提问]: %s → %s\n", s.StepNumber, truncate(s.Input, 120), truncate(s.Output, 200)))
+			case "verify":
+				sb.WriteString(fmt.Sprintf("  步骤 %d [验证 %s]: %s\n", s.StepNumber, s.Action, truncate(s.Output, 200)))
 			}
 		}
 		return sb.String()
@@ -124,7 +127,7 @@ func buildStepSummary(steps []StepRecord, contextWindowTokens int) string {
 		s := zoneASteps[i]
 		dup := buildDupWarning(s, seen)
 		sb.WriteString(fmt.Sprintf("  步骤 %d [工具 %s]: %s%s\n",
-			s.StepNumber, s.ToolName, truncate(s.Output, budget), dup))
+			s.StepNumber, s.ToolName, truncateTokens(s.Output, budget), dup))
 	}
 
 	// Zone B: older steps (chronological, compressed)
@@ -145,13 +148,17 @@ func buildStepSummary(steps []StepRecord, contextWindowTokens int) string {
 		}
 	}
 
-	// Think/Answer steps (rare, append at end)
+	// Think/Answer/Ask/Verify steps (rare, append at end)
 	for _, s := range steps {
 		switch s.Type {
 		case "think":
 			sb.WriteString(fmt.Sprintf("  步骤 %d [推理]: %s\n", s.StepNumber, truncate(s.Output, 200)))
 		case "answer":
 			sb.WriteString(fmt.Sprintf("  步骤 %d [回答]: %s\n", s.StepNumber, truncate(s.Output, 200)))
+		case "ask":
+			sb.WriteString(fmt.Sprintf("  步骤 %d [提问]: %s → %s\n", s.StepNumber, truncate(s.Input, 120), truncate(s.Output, 200)))
+		case "verify":
+			sb.WriteString(fmt.Sprintf("  步骤 %d [验证 %s]: %s\n", s.StepNumber, s.Action, truncate(s.Output, 200)))
 		}
 	}
 
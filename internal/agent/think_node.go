@@ -26,16 +26,20 @@ func NewThinkNode(provider llm.LLMProvider, loader *prompt.PromptLoader) *ThinkN
 func (n *ThinkNodeImpl) Prep(state *AgentState) []ThinkPrep {
 	ctxText := buildThinkContext(state)
 	return []ThinkPrep{{
-		Problem: state.Problem,
-		Context: ctxText,
+		Problem:    state.Problem,
+		Context:    ctxText,
+		Scratchpad: state.Scratchpad != nil,
 	}}
 }
 
 // Exec calls LLM for reasoning.
 func (n *ThinkNodeImpl) Exec(ctx context.Context, prep ThinkPrep) (ThinkResult, error) {
 	userPrompt := fmt.Sprintf("用户问题：%s\n\n已有上下文：\n%s\n\n请分析以上信息并给出你的推理：", prep.Problem, prep.Context)
+	if prep.Scratchpad {
+		userPrompt = strings.TrimSuffix(userPrompt, "：") + "。" + scratchFormat
+	}
 
-	resp, err := n.llmProvider.CallLLM(ctx, []llm.Message{
+	resp, err := n.llmProvider.CallLLM(llm.WithModelRole(ctx, llm.ModelThink), []llm.Message{
 		{Role: llm.RoleSystem, Content: n.buildSystemPrompt()},
 		{Role: llm.RoleUser, Content: userPrompt},
 	})
@@ -63,8 +67,10 @@ func (n *ThinkNodeImpl) Post(state *AgentState, prep []ThinkPrep, results ...Thi
 		StepNumber: len(state.StepHistory) + 1,
 		Type:       "think",
 		Output:     result.Thinking,
+		Model:      stepModel(state, n.llmProvider, llm.ModelThink),
 	}
 	state.StepHistory = append(state.StepHistory, step)
+	state.Scratchpad.Add(parseScratch(step.StepNumber, result.Thinking)...)
 
 	if state.OnStepComplete != nil {
 		state.OnStepComplete(step)
@@ -72,7 +78,7 @@ func (n *ThinkNodeImpl) Post(state *AgentState, prep []ThinkPrep, results ...Thi
 
 	log.Printf("[ThinkNode] Reasoning complete: %s", truncate(result.Thinking, 100))
 
-	return core.ActionDefault // Back to DecideNode
+	return compactRoute(state) // Back to DecideNode (via CompactNode if needed)
 }
 
 // buildThinkContext summarizes step history for reasoning context.
@@ -81,7 +87,7 @@ func buildThinkContext(state *AgentState) string {
 	for _, s := range state.StepHistory {
 		switch s.Type {
 		case "tool":
-			sb.WriteString(fmt.Sprintf("[工具 %s 结果]: %s\n", s.ToolName, truncate(s.Output, perStepOutputBudget(state.ContextWindowTokens, recentWindowSize))))
+			sb.WriteString(fmt.Sprintf("[工具 %s 结果]: %s\n", s.ToolName, truncateTokens(s.Output, perStepOutputBudget(state.ContextWindowTokens, recentWindowSize))))
 		case "think":
 			sb.WriteString(fmt.Sprintf("[推理]: %s\n", s.Output))
 		case "decide":
@@ -89,6 +95,11 @@ func buildThinkContext(state *AgentState) string {
 		}
 	}
 
+	// Earlier think artifacts, so that this round can build on them
+	if text := state.Scratchpad.Render(); text != "" {
+		sb.WriteString(text)
+	}
+
 	// Also include LastDecision thinking if available
 	if state.LastDecision != nil && state.LastDecision.Thinking != "" {
 		sb.WriteString(fmt.Sprintf("[当前分析方向]: %s\n", state.LastDecision.Thinking))
@@ -1,20 +1,32 @@
 package agent
 
-// estimateTokens estimates token count using character-based heuristics.
-// CJK Unified Ideographs (U+4E00–U+9FFF): ~2 chars/token.
-// ASCII and other characters: ~4 chars/token.
-//
-// Precision: ±20–30% for mixed content. Sufficient for threshold-based guards
-// (CostGuard budget, ContextGuard window monitoring).
-// Does NOT cover CJK Extension A/B or CJK punctuation (U+3000–U+303F, U+FF00–U+FFEF).
+import "github.com/pocketomega/pocket-omega/internal/tokenizer"
+
+// estimateTokens counts tokens with the process-wide tokenizer (see
+// tokenizer.SetDefault): the configured model's BPE vocabulary, or the
+// character heuristic (CJK ~2 chars/token, other ~4 chars/token, ±20–30%)
+// when none is configured. Used by the threshold guards (CostGuard budget,
+// ContextGuard window monitoring, system prompt and tool output budgets).
 func estimateTokens(text string) int {
-	var cjk, other int
-	for _, r := range text {
-		if r >= 0x4E00 && r <= 0x9FFF {
-			cjk++
-		} else {
-			other++
-		}
+	return tokenizer.Default().Count(text)
+}
+
+// truncateTokens cuts text to at most maxTokens tokens, appending "..."
+// when anything was removed (like truncate does for runes).
+func truncateTokens(text string, maxTokens int) string {
+	cut := tokenizer.Default().Truncate(text, maxTokens)
+	if len(cut) == len(text) {
+		return text
 	}
-	return cjk/2 + other/4 + 1 // +1 avoids zero for short strings
+	return cut + "..."
+}
+
+// imageTokenEstimate is the rough prompt cost of one attached image
+// (a mid-size image at auto detail on common vision models).
+const imageTokenEstimate = 800
+
+// EstimateTokens exposes the token estimate for callers outside the agent
+// loop (e.g. the eval harness) so their numbers match CostGuard's.
+func EstimateTokens(text string) int {
+	return estimateTokens(text)
 }
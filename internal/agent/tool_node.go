@@ -3,12 +3,16 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
+	"github.com/pocketomega/pocket-omega/internal/audit"
 	"github.com/pocketomega/pocket-omega/internal/core"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
+	"github.com/pocketomega/pocket-omega/internal/redact"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 	"github.com/pocketomega/pocket-omega/internal/walkthrough"
 )
@@ -45,13 +49,20 @@ func (n *ToolNodeImpl) Prep(state *AgentState) []ToolPrep {
 		reg = n.registry
 	}
 	resolved, _ := reg.Get(state.LastDecision.ToolName)
+	timeout := reg.Timeout(state.LastDecision.ToolName)
 
 	return []ToolPrep{{
-		ToolName:     state.LastDecision.ToolName,
-		Args:         argsJSON,
-		ToolCallID:   state.LastDecision.ToolCallID,
-		ResolvedTool: resolved,
-		ReadCache:    state.ReadCache,
+		ToolName:        state.LastDecision.ToolName,
+		Args:            argsJSON,
+		ToolCallID:      state.LastDecision.ToolCallID,
+		ResolvedTool:    resolved,
+		Timeout:         timeout,
+		ReadCache:       state.ReadCache,
+		SearchHistory:   state.SearchHistory,
+		Changes:         state.Changes,
+		Translator:      state.Translator,
+		OutputProcessor: state.OutputProcessor,
+		WorkspaceDir:    state.WorkspaceDir,
 	}}
 }
 
@@ -65,6 +76,7 @@ func (n *ToolNodeImpl) Exec(ctx context.Context, prep ToolPrep) (ToolExecResult,
 			Error:      fmt.Sprintf("工具 %q 未找到", prep.ToolName),
 			ToolCallID: prep.ToolCallID,
 			DurationMs: time.Since(start).Milliseconds(),
+			Code:       tool.CodeNotFound,
 		}, nil
 	}
 
@@ -81,7 +93,13 @@ func (n *ToolNodeImpl) Exec(ctx context.Context, prep ToolPrep) (ToolExecResult,
 		}
 	}
 
-	result, err := prep.ResolvedTool.Execute(ctx, json.RawMessage(prep.Args))
+	if prep.SearchHistory != nil {
+		ctx = tool.WithSearchHistory(ctx, prep.SearchHistory)
+	}
+	prep.Changes.BeforeTool(prep.ToolName, prep.Args)
+	snapshot := snapshotEdit(prep.ToolName, prep.Args, prep.WorkspaceDir)
+	result, err := executeWithTimeout(ctx, prep.ResolvedTool, json.RawMessage(prep.Args), prep.Timeout)
+	prep.Changes.AfterTool(prep.ToolName)
 	elapsed := time.Since(start).Milliseconds()
 	if err != nil {
 		return ToolExecResult{
@@ -92,15 +110,153 @@ func (n *ToolNodeImpl) Exec(ctx context.Context, prep ToolPrep) (ToolExecResult,
 		}, nil // Don't propagate as error; record the failure
 	}
 
+	output, errMsg := result.Output, result.Error
+	if prep.Translator != nil {
+		output, errMsg = translateToolResult(ctx, prep.Translator, prep.ToolName, output, errMsg)
+	}
+	output, ref := prep.OutputProcessor.Process(ctx, prep.ToolName, output)
+	var diff *FileDiff
+	if errMsg == "" {
+		diff = snapshot.diff()
+	}
+
 	return ToolExecResult{
 		ToolName:   prep.ToolName,
-		Output:     result.Output,
-		Error:      result.Error,
+		Output:     output,
+		Error:      errMsg,
 		ToolCallID: prep.ToolCallID,
 		DurationMs: elapsed,
+		Code:       result.Code,
+		OutputRef:  ref,
+		Images:     result.Images,
+		Diff:       diff,
 	}, nil
 }
 
+// errToolTimeout is the cause of a call's own deadline, telling it apart
+// from the run being cancelled.
+var errToolTimeout = errors.New("tool call timed out")
+
+// toolTimeoutGrace is how long a timed-out call may still take to return
+// its partial output.
+const toolTimeoutGrace = 100 * time.Millisecond
+
+// executeWithTimeout runs the tool under its per-tool deadline. The call
+// runs in its own goroutine, so a tool that ignores ctx (a hung MCP server,
+// a blocking read) still returns control to the agent at the deadline; the
+// abandoned call finishes in the background.
+func executeWithTimeout(ctx context.Context, t tool.Tool, args json.RawMessage, timeout time.Duration) (tool.ToolResult, error) {
+	if timeout <= 0 {
+		return t.Execute(ctx, args)
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, errToolTimeout)
+	defer cancel()
+
+	type outcome struct {
+		result tool.ToolResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := t.Execute(ctx, args)
+		done <- outcome{result, err}
+	}()
+
+	select {
+	case o := <-done:
+		// A tool that honours ctx fails on its own at the deadline; report
+		// that as a timeout too, keeping any partial output.
+		if (o.err != nil || o.result.Error != "") && errors.Is(context.Cause(ctx), errToolTimeout) {
+			timedOut := timeoutResult(t.Name(), timeout)
+			timedOut.Output = o.result.Output
+			return timedOut, nil
+		}
+		return o.result, o.err
+	case <-ctx.Done():
+		if !errors.Is(context.Cause(ctx), errToolTimeout) {
+			return tool.ToolResult{Error: "调用被取消"}, nil
+		}
+		log.Printf("[ToolNode] %s timed out after %v", t.Name(), timeout)
+		timedOut := timeoutResult(t.Name(), timeout)
+		// A tool that honours ctx returns right after the deadline: give it
+		// a moment to hand over its partial output.
+		select {
+		case o := <-done:
+			timedOut.Output = o.result.Output
+		case <-time.After(toolTimeoutGrace):
+		}
+		return timedOut, nil
+	}
+}
+
+// timeoutResult builds the TIMEOUT-coded ToolResult of an abandoned call.
+func timeoutResult(name string, timeout time.Duration) tool.ToolResult {
+	return tool.ToolResult{
+		Error: fmt.Sprintf("TIMEOUT: %s 超过 %v 未完成，已放弃本次调用。不要原样重试；请缩小操作范围，或改用其他工具/方法",
+			name, timeout),
+		Code: tool.CodeTimeout,
+	}
+}
+
+// verbatimOutputTools return raw workspace/network data whose Output must reach
+// the model unchanged (code, logs, listings). Their Error is still translated.
+var verbatimOutputTools = map[string]bool{
+	"file_read":    true,
+	"file_list":    true,
+	"file_grep":    true,
+	"find":         true,
+	"shell_exec":   true,
+	"python_exec":  true,
+	"git_info":     true,
+	"git_ops":      true,
+	"http_request": true,
+	"web_reader":   true,
+	"fetch_more":   true,
+	"output_read":  true,
+	"todo_scan":    true,
+	"project_map":  true,
+	"code_search":  true,
+	"csv_query":    true,
+	"doc_read":     true,
+	"archive_tool": true,
+}
+
+// translateToolResult normalises a tool result into the translator's working
+// language. Failures are logged and the original text is kept — translation
+// must never turn a successful tool call into a failed one.
+func translateToolResult(ctx context.Context, tr *i18n.Translator, toolName, output, errMsg string) (string, string) {
+	if errMsg != "" {
+		translated, err := tr.Translate(ctx, errMsg)
+		if err != nil {
+			log.Printf("[ToolNode] Translate error for %s failed: %v", toolName, err)
+		}
+		errMsg = translated
+	}
+	if output != "" && !verbatimOutputTools[toolName] {
+		translated, err := tr.Translate(ctx, output)
+		if err != nil {
+			log.Printf("[ToolNode] Translate output for %s failed: %v", toolName, err)
+		}
+		output = translated
+	}
+	return output, errMsg
+}
+
+// auditOutcome classifies a tool call for the audit log.
+func auditOutcome(r ToolExecResult, cacheHit bool) string {
+	switch {
+	case r.Code == tool.CodeNotFound:
+		return audit.OutcomeNotFound
+	case r.Code == tool.CodeTimeout:
+		return audit.OutcomeTimeout
+	case r.Error != "":
+		return audit.OutcomeError
+	case cacheHit:
+		return audit.OutcomeCached
+	}
+	return audit.OutcomeOK
+}
+
 // ExecFallback returns an error result.
 func (n *ToolNodeImpl) ExecFallback(err error) ToolExecResult {
 	return ToolExecResult{
@@ -127,17 +283,26 @@ func (n *ToolNodeImpl) Post(state *AgentState, prep []ToolPrep, results ...ToolE
 		}
 	}
 
+	// Secrets (API keys from .env, tokens in command output) are masked
+	// before the step reaches the history, and with it the model, the SSE
+	// stream and the logs.
 	step := StepRecord{
 		StepNumber: len(state.StepHistory) + 1,
 		Type:       "tool",
 		ToolName:   p.ToolName,
-		Input:      string(p.Args),
-		Output:     output,
+		Input:      redact.String(string(p.Args)),
+		Output:     redact.String(output),
 		ToolCallID: p.ToolCallID,
 		IsError:    result.Error != "",
 		DurationMs: result.DurationMs,
+		OutputRef:  result.OutputRef,
+		Diff:       result.Diff,
 	}
+	step.Sources = state.addSources(stepSources(p.ToolName, step.Input, step.Output, step.IsError))
 	state.StepHistory = append(state.StepHistory, step)
+	if len(result.Images) > 0 {
+		state.Images = appendImages(state.Images, result.Images...)
+	}
 
 	// ReadCache: cache results for cacheable tools + invalidate on writes
 	isCacheHit := false
@@ -163,6 +328,9 @@ func (n *ToolNodeImpl) Post(state *AgentState, prep []ToolPrep, results ...ToolE
 		}
 	}
 
+	state.Audit.Record(p.ToolName, p.Args, auditOutcome(result, isCacheHit), result.DurationMs)
+	state.Verify.noteTool(p.ToolName, p.Args, state.WorkspaceDir, result.Error != "")
+
 	// Auto-write walkthrough entry (skip for cache hits — avoids memo noise)
 	if !isCacheHit && state.WalkthroughStore != nil && state.WalkthroughSID != "" {
 		if summary := buildAutoSummary(p.ToolName, string(p.Args), output, result.Error != ""); summary != "" {
@@ -178,9 +346,11 @@ func (n *ToolNodeImpl) Post(state *AgentState, prep []ToolPrep, results ...ToolE
 		state.OnStepComplete(step)
 	}
 
+	reconcilePlan(state, step)
+
 	log.Printf("[ToolNode] Executed %s: %s", p.ToolName, truncate(output, 100))
 
-	return core.ActionDefault // Back to DecideNode
+	return compactRoute(state) // Back to DecideNode (via CompactNode if needed)
 }
 
 // skipAutoSummaryTools are meta-tools whose execution is not worth recording.
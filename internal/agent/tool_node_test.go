@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/audit"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// slowTool sleeps before answering; honourCtx makes it stop at ctx's end.
+type slowTool struct {
+	mockTool
+	delay     time.Duration
+	honourCtx bool
+}
+
+func (s *slowTool) Execute(ctx context.Context, _ json.RawMessage) (tool.ToolResult, error) {
+	if !s.honourCtx {
+		time.Sleep(s.delay)
+		return tool.ToolResult{Output: "late"}, nil
+	}
+	select {
+	case <-time.After(s.delay):
+		return tool.ToolResult{Output: "late"}, nil
+	case <-ctx.Done():
+		return tool.ToolResult{Output: "partial", Error: ctx.Err().Error()}, nil
+	}
+}
+
+func TestExecuteWithTimeout(t *testing.T) {
+	hung := &slowTool{mockTool: mockTool{name: "mcp_srv__hang"}, delay: time.Second}
+	start := time.Now()
+	res, err := executeWithTimeout(context.Background(), hung, nil, 20*time.Millisecond)
+	if err != nil || res.Code != tool.CodeTimeout || !strings.HasPrefix(res.Error, "TIMEOUT:") {
+		t.Fatalf("hung tool: %+v, %v", res, err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("a tool ignoring ctx held the loop for %v", time.Since(start))
+	}
+
+	polite := &slowTool{mockTool: mockTool{name: "shell_exec"}, delay: time.Second, honourCtx: true}
+	res, _ = executeWithTimeout(context.Background(), polite, nil, 20*time.Millisecond)
+	if res.Code != tool.CodeTimeout || res.Output != "partial" {
+		t.Errorf("ctx-aware tool: %+v, want TIMEOUT with the partial output", res)
+	}
+
+	fast := &slowTool{mockTool: mockTool{name: "file_read"}, delay: time.Millisecond}
+	if res, _ = executeWithTimeout(context.Background(), fast, nil, time.Second); res.Output != "late" || res.Code != "" {
+		t.Errorf("fast tool: %+v", res)
+	}
+
+	// Cancelling the run is not a timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if res, _ = executeWithTimeout(ctx, hung, nil, time.Second); res.Code != "" || res.Error == "" {
+		t.Errorf("cancelled run: %+v", res)
+	}
+}
+
+func TestToolNode_Timeout(t *testing.T) {
+	reg := tool.NewRegistry()
+	reg.Register(&slowTool{mockTool: mockTool{name: "mcp_srv__hang"}, delay: time.Second})
+	reg.SetTimeouts(map[string]time.Duration{"mcp_*": 20 * time.Millisecond})
+	state := &AgentState{
+		ToolRegistry: reg,
+		LastDecision: &Decision{Action: "tool", ToolName: "mcp_srv__hang"},
+	}
+	node := NewToolNode(reg)
+	prep := node.Prep(state)
+	res, _ := node.Exec(context.Background(), prep[0])
+	node.Post(state, prep, res)
+
+	if step := state.StepHistory[0]; !step.IsError || !strings.Contains(step.Output, "TIMEOUT") {
+		t.Errorf("step = %+v, want a TIMEOUT error", step)
+	}
+}
+
+func TestToolNode_Audit(t *testing.T) {
+	log, err := audit.Open(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	reg := tool.NewRegistry()
+	reg.Register(&slowTool{mockTool: mockTool{name: "mcp_srv__hang"}, delay: time.Second})
+	reg.Register(&slowTool{mockTool: mockTool{name: "file_read"}, delay: time.Millisecond})
+	reg.SetTimeouts(map[string]time.Duration{"mcp_*": 20 * time.Millisecond})
+	state := &AgentState{ToolRegistry: reg, Audit: log.Run("s1", "r1", "alice")}
+	node := NewToolNode(reg)
+	for _, name := range []string{"file_read", "mcp_srv__hang", "shell_exec"} {
+		state.LastDecision = &Decision{Action: "tool", ToolName: name, ToolParams: map[string]any{"path": "a.go"}}
+		prep := node.Prep(state)
+		res, _ := node.Exec(context.Background(), prep[0])
+		node.Post(state, prep, res)
+	}
+
+	entries, _ := log.Query(audit.Filter{})
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Tool+"="+e.Outcome)
+		if e.User != "alice" || e.SessionID != "s1" || e.RunID != "r1" || !strings.HasPrefix(e.ParamsHash, "sha256:") {
+			t.Errorf("entry = %+v", e)
+		}
+	}
+	if want := "shell_exec=not_found,mcp_srv__hang=timeout,file_read=ok"; strings.Join(got, ",") != want {
+		t.Errorf("audit = %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+// writeTool writes its content argument to its path argument.
+type writeTool struct{ mockTool }
+
+func (w *writeTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a struct{ Path, Content string }
+	json.Unmarshal(args, &a)
+	if a.Content == "" {
+		return tool.ToolResult{Error: "content 不能为空"}, nil
+	}
+	return tool.ToolResult{Output: "已写入 " + a.Path}, os.WriteFile(a.Path, []byte(a.Content), 0o644)
+}
+
+func TestToolNode_EditDiff(t *testing.T) {
+	ws := t.TempDir()
+	os.WriteFile(filepath.Join(ws, "a.txt"), []byte("one\ntwo\nthree\n"), 0o644)
+	reg := tool.NewRegistry()
+	reg.Register(&writeTool{mockTool{name: "file_write"}})
+	reg.Register(&writeTool{mockTool{name: "shell_exec"}})
+	state := &AgentState{ToolRegistry: reg, WorkspaceDir: ws}
+	node := NewToolNode(reg)
+	run := func(name, path, content string) StepRecord {
+		state.LastDecision = &Decision{Action: "tool", ToolName: name, ToolParams: map[string]any{"path": path, "content": content}}
+		prep := node.Prep(state)
+		res, _ := node.Exec(context.Background(), prep[0])
+		node.Post(state, prep, res)
+		return state.StepHistory[len(state.StepHistory)-1]
+	}
+
+	d := run("file_write", filepath.Join(ws, "a.txt"), "one\n2\nthree\n").Diff
+	if d == nil || d.Path != "a.txt" || d.Created || d.Added != 1 || d.Removed != 1 ||
+		d.Hunks != "@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n" {
+		t.Fatalf("diff = %+v", d)
+	}
+	if d := run("file_write", filepath.Join(ws, "new.txt"), "x\n").Diff; d == nil || !d.Created || d.Added != 1 {
+		t.Errorf("new file diff = %+v", d)
+	}
+	if d := run("file_write", filepath.Join(ws, "a.txt"), "one\n2\nthree\n").Diff; d != nil {
+		t.Errorf("unchanged file: diff = %+v, want nil", d)
+	}
+	if d := run("file_write", filepath.Join(ws, "a.txt"), "").Diff; d != nil {
+		t.Errorf("failed call: diff = %+v, want nil", d)
+	}
+	if d := run("shell_exec", filepath.Join(ws, "b.txt"), "y").Diff; d != nil {
+		t.Errorf("shell_exec: diff = %+v, want nil", d)
+	}
+}
@@ -7,15 +7,16 @@ package agent
 // ⚠️ When adding new tools with a clear "key parameter", update this map
 // so both loop detection and walkthrough auto-summary benefit automatically.
 var baseToolKeyParams = map[string]string{
-	"file_read":   "path",
-	"file_write":  "path",
-	"file_patch":  "path",
-	"file_list":   "path",
-	"file_move":   "path",
-	"file_delete": "path",
-	"file_grep":   "path",
-	"shell_exec":  "command",
-	"config_edit": "key",
+	"file_read":    "path",
+	"file_write":   "path",
+	"file_patch":   "path",
+	"file_list":    "path",
+	"file_move":    "path",
+	"file_delete":  "path",
+	"file_grep":    "path",
+	"shell_exec":   "command",
+	"config_edit":  "key",
+	"archive_tool": "archive",
 }
 
 // mergeToolKeyParams creates a new map from baseToolKeyParams + extras.
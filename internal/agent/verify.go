@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// VerifyMode selects what happens when a run that edited code wants to
+// answer (AGENT_VERIFY).
+type VerifyMode int
+
+const (
+	VerifyOff  VerifyMode = iota // no verification
+	VerifyHint                   // send the run back once with a hint to run the project's checks
+	VerifyRun                    // run the checks through shell_exec and feed back the result
+)
+
+// ParseVerifyMode parses AGENT_VERIFY: "off" (default), "hint" or "run".
+func ParseVerifyMode(s string) (VerifyMode, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "off", "false":
+		return VerifyOff, nil
+	case "hint":
+		return VerifyHint, nil
+	case "run":
+		return VerifyRun, nil
+	}
+	return VerifyOff, fmt.Errorf("invalid verify mode %q (want off, hint or run)", s)
+}
+
+// DefaultVerifyPatterns are the edited files that call for verification:
+// source files and build manifests.
+var DefaultVerifyPatterns = []string{
+	"*.go", "go.mod", "*.py", "*.js", "*.jsx", "*.ts", "*.tsx", "*.mjs", "*.vue", "package.json",
+	"*.rs", "*.java", "*.kt", "*.c", "*.cc", "*.cpp", "*.h", "*.hpp", "*.cs", "*.rb", "*.php", "*.swift",
+	"Makefile",
+}
+
+// VerifyConfig is the verification setup shared by all runs (AGENT_VERIFY,
+// AGENT_VERIFY_COMMAND, AGENT_VERIFY_PATTERNS).
+type VerifyConfig struct {
+	Mode     VerifyMode
+	Command  string   // "" = DetectVerifyCommand of the workspace
+	Patterns []string // path.Match patterns; nil = DefaultVerifyPatterns
+}
+
+// Verification attempts per run. A hint is given once; failed checks send
+// the run back up to maxVerifyRuns times before the answer is let through.
+const (
+	maxVerifyHints = 1
+	maxVerifyRuns  = 3
+)
+
+// verifyOutputRunes is the tail of the check output kept in the verify step
+// and the feedback.
+const verifyOutputRunes = 2000
+
+// Verification tracks the code edits of one run that still need their
+// checks run before the run may answer. Single goroutine, like AgentState.
+type Verification struct {
+	Mode     VerifyMode
+	Command  string
+	patterns []string
+	edited   []string // workspace-relative files edited since the last passing check
+	attempts int      // verify steps so far this run
+	Feedback string   // last hint or failure, shown to DecideNode until the checks pass
+}
+
+// NewVerification prepares the verification of a run in workspaceDir; nil
+// (nil-safe, no verification) when the mode is off or no command is
+// configured or detected.
+func NewVerification(cfg VerifyConfig, workspaceDir string) *Verification {
+	if cfg.Mode == VerifyOff {
+		return nil
+	}
+	command := cfg.Command
+	if command == "" {
+		command = DetectVerifyCommand(workspaceDir)
+	}
+	if command == "" {
+		return nil
+	}
+	patterns := cfg.Patterns
+	if patterns == nil {
+		patterns = DefaultVerifyPatterns
+	}
+	return &Verification{Mode: cfg.Mode, Command: command, patterns: patterns}
+}
+
+// makeTestTarget matches a "test:" target at the start of a Makefile line.
+var makeTestTarget = regexp.MustCompile(`(?m)^test\s*:`)
+
+// DetectVerifyCommand returns the test/build command of the project in dir,
+// from go.mod, package.json (its test script) or a Makefile with a test
+// target, in that order; "" when none applies.
+func DetectVerifyCommand(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		return "go build ./... && go test ./..."
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			// npm init's placeholder test script always fails
+			if test := pkg.Scripts["test"]; test != "" && !strings.Contains(test, "no test specified") {
+				return "npm test"
+			}
+			if pkg.Scripts["build"] != "" {
+				return "npm run build"
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "Makefile")); err == nil && makeTestTarget.Match(data) {
+		return "make test"
+	}
+	return ""
+}
+
+// matches reports whether a workspace-relative path is covered by the
+// patterns: patterns without a slash match the base name.
+func (v *Verification) matches(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range v.patterns {
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// noteTool updates the pending edits after a tool call: successful file
+// edits matching the patterns need verification, and a successful shell
+// command that runs the check command (the agent following a hint)
+// verifies them.
+func (v *Verification) noteTool(toolName string, args []byte, workspaceDir string, failed bool) {
+	if v == nil || failed {
+		return
+	}
+	if toolName == "shell_exec" {
+		if strings.Contains(normalizeSpace(extractParam(string(args), "command")), normalizeSpace(v.Command)) {
+			v.edited, v.Feedback = nil, ""
+		}
+		return
+	}
+	keys, ok := pathWriteTools[toolName]
+	if !ok {
+		return
+	}
+	var params map[string]any
+	if json.Unmarshal(args, &params) != nil {
+		return
+	}
+	for _, key := range keys {
+		p, _ := params[key].(string)
+		if p == "" {
+			continue
+		}
+		if filepath.IsAbs(p) {
+			if rel, err := filepath.Rel(workspaceDir, p); err == nil {
+				p = rel
+			}
+		}
+		if v.matches(p) && !slices.Contains(v.edited, p) {
+			v.edited = append(v.edited, p)
+		}
+	}
+}
+
+// needsVerify reports whether an answer decision must go to VerifyNode
+// first: code was edited since the last passing check and attempts remain.
+// Canned answers after an LLM failure are not held back.
+func needsVerify(state *AgentState) bool {
+	v := state.Verify
+	if v == nil || len(v.edited) == 0 || state.LLMError != "" {
+		return false
+	}
+	if v.Mode == VerifyHint {
+		return v.attempts < maxVerifyHints
+	}
+	return v.attempts < maxVerifyRuns
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
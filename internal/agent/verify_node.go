@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/audit"
+	"github.com/pocketomega/pocket-omega/internal/core"
+)
+
+// VerifyNodeImpl implements BaseNode[AgentState, VerifyPrep, VerifyResult].
+// With AGENT_VERIFY, an answer decision of a run that edited code comes
+// here first (see needsVerify). In run mode the project's check command is
+// run through shell_exec: a pass lets the answer through, a failure sends
+// the run back to DecideNode with the output. In hint mode, or when
+// shell_exec is unavailable, the run goes back once with the instruction
+// to run the command itself.
+type VerifyNodeImpl struct{}
+
+func NewVerifyNode() *VerifyNodeImpl {
+	return &VerifyNodeImpl{}
+}
+
+// Prep picks the check command and, in run mode, the shell tool to run it.
+func (n *VerifyNodeImpl) Prep(state *AgentState) []VerifyPrep {
+	v := state.Verify
+	prep := VerifyPrep{Command: v.Command, Edited: append([]string(nil), v.edited...)}
+	if v.Mode == VerifyRun && state.ToolRegistry != nil {
+		if t, ok := state.ToolRegistry.Get("shell_exec"); ok {
+			prep.Shell = t
+		}
+	}
+	return []VerifyPrep{prep}
+}
+
+// Exec runs the check command; without a shell it only reports a hint.
+func (n *VerifyNodeImpl) Exec(ctx context.Context, prep VerifyPrep) (VerifyResult, error) {
+	if prep.Shell == nil {
+		return VerifyResult{}, nil
+	}
+	args, _ := json.Marshal(map[string]string{"command": prep.Command})
+	start := time.Now()
+	res, err := prep.Shell.Execute(ctx, args)
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("verify command failed to run: %w", err)
+	}
+	out := strings.TrimSpace(res.Output)
+	if res.Error != "" {
+		out = strings.TrimSpace(out + "\n" + res.Error)
+	}
+	return VerifyResult{
+		Ran:        true,
+		Passed:     res.Error == "",
+		Output:     tailRunes(out, verifyOutputRunes),
+		DurationMs: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// ExecFallback falls back to a hint: the command could not be run here, so
+// the agent is asked to run it.
+func (n *VerifyNodeImpl) ExecFallback(err error) VerifyResult {
+	log.Printf("[Verify] %v", err)
+	return VerifyResult{}
+}
+
+// Post records the verify step. A pass routes on to AnswerNode with the
+// pending answer decision; a failure or a hint goes back to DecideNode.
+func (n *VerifyNodeImpl) Post(state *AgentState, prep []VerifyPrep, results ...VerifyResult) core.Action {
+	if len(results) == 0 || len(prep) == 0 {
+		return core.ActionAnswer
+	}
+	v, p, result := state.Verify, prep[0], results[0]
+	v.attempts++
+
+	step := StepRecord{
+		StepNumber: len(state.StepHistory) + 1,
+		Type:       "verify",
+		Input:      p.Command,
+		DurationMs: result.DurationMs,
+	}
+	edited := strings.Join(p.Edited, ", ")
+	switch {
+	case !result.Ran:
+		step.Action = "hint"
+		step.Output = fmt.Sprintf("已修改代码文件（%s），提示先运行 %s 验证", edited, p.Command)
+		v.Feedback = fmt.Sprintf("⚠️ 你修改了代码文件（%s），但还没有验证。回答之前必须先用 shell_exec 运行 `%s`，确认通过；失败则先修复问题。\n",
+			edited, p.Command)
+	case result.Passed:
+		step.Action = "pass"
+		step.Output = fmt.Sprintf("验证通过：%s\n%s", p.Command, result.Output)
+		v.edited, v.Feedback = nil, ""
+	default:
+		step.Action = "fail"
+		step.IsError = true
+		step.Output = fmt.Sprintf("验证失败：%s\n%s", p.Command, result.Output)
+		v.Feedback = fmt.Sprintf("⚠️ 代码修改后的验证未通过（第 %d/%d 次）：`%s` 失败。请先修复，再重新回答：\n%s\n",
+			v.attempts, maxVerifyRuns, p.Command, result.Output)
+	}
+	state.StepHistory = append(state.StepHistory, step)
+	if result.Ran {
+		args, _ := json.Marshal(map[string]string{"command": p.Command})
+		outcome := audit.OutcomeOK
+		if !result.Passed {
+			outcome = audit.OutcomeError
+		}
+		state.Audit.Record("shell_exec", args, outcome, result.DurationMs)
+	}
+	if state.OnStepComplete != nil {
+		state.OnStepComplete(step)
+	}
+	log.Printf("[Verify] %s: %s (%s)", step.Action, p.Command, edited)
+
+	if step.Action == "pass" {
+		return core.ActionAnswer
+	}
+	return compactRoute(state) // Back to DecideNode with the feedback
+}
+
+// tailRunes keeps the last maxRunes runes of s: test and build failures
+// are summarized at the end of their output.
+func tailRunes(s string, maxRunes int) string {
+	r := []rune(s)
+	if len(r) <= maxRunes {
+		return s
+	}
+	return "…" + string(r[len(r)-maxRunes:])
+}
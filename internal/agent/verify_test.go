@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestDetectVerifyCommand(t *testing.T) {
+	cases := []struct {
+		files map[string]string
+		want  string
+	}{
+		{map[string]string{"go.mod": "module x\n", "Makefile": "test:\n\tgo test\n"}, "go build ./... && go test ./..."},
+		{map[string]string{"package.json": `{"scripts": {"test": "vitest run"}}`}, "npm test"},
+		{map[string]string{"package.json": `{"scripts": {"test": "echo \"Error: no test specified\" && exit 1", "build": "tsc"}}`}, "npm run build"},
+		{map[string]string{"Makefile": "build:\n\tcc main.c\n\ntest: build\n\t./run-tests\n"}, "make test"},
+		{map[string]string{"Makefile": "build:\n\tcc main.c\n", "README.md": "x"}, ""},
+	}
+	for i, tc := range cases {
+		dir := t.TempDir()
+		for name, content := range tc.files {
+			os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		}
+		if got := DetectVerifyCommand(dir); got != tc.want {
+			t.Errorf("case %d: DetectVerifyCommand = %q, want %q", i, got, tc.want)
+		}
+	}
+	if NewVerification(VerifyConfig{Mode: VerifyRun}, t.TempDir()) != nil {
+		t.Error("no command detected: verification should be off")
+	}
+}
+
+func TestVerification_NoteTool(t *testing.T) {
+	ws := t.TempDir()
+	v := NewVerification(VerifyConfig{Mode: VerifyHint, Command: "go test ./...", Patterns: []string{"*.go", "cmd/*"}}, ws)
+	state := &AgentState{Verify: v}
+	v.noteTool("file_write", []byte(`{"path":"README.md"}`), ws, false)
+	v.noteTool("file_write", []byte(`{"path":"b.go"}`), ws, true) // failed edit
+	if needsVerify(state) {
+		t.Fatal("no code edited yet")
+	}
+	v.noteTool("file_patch", []byte(`{"path":"`+filepath.ToSlash(filepath.Join(ws, "pkg", "a.go"))+`"}`), ws, false)
+	v.noteTool("file_move", []byte(`{"source":"x.txt","destination":"cmd/run"}`), ws, false)
+	if got := strings.Join(v.edited, ","); got != filepath.Join("pkg", "a.go")+",cmd/run" {
+		t.Errorf("edited = %s", got)
+	}
+	if !needsVerify(state) {
+		t.Error("code edited: answer should be verified")
+	}
+	v.noteTool("shell_exec", []byte(`{"command":"cd . &&  go test  ./... -count=1"}`), ws, false)
+	if needsVerify(state) || v.edited != nil {
+		t.Errorf("running the check command should verify the edits: %v", v.edited)
+	}
+}
+
+// seqShellTool returns its results in order, repeating the last one.
+type seqShellTool struct {
+	mockTool
+	results  []tool.ToolResult
+	commands []string
+}
+
+func (s *seqShellTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	s.commands = append(s.commands, extractParam(string(args), "command"))
+	return s.results[min(len(s.commands), len(s.results))-1], nil
+}
+
+func TestBuildAgentFlow_VerifyRun(t *testing.T) {
+	write := "```yaml\naction: \"tool\"\nreason: \"edit\"\ntool_name: \"file_write\"\ntool_params:\n  path: \"a.go\"\n  content: \"package a\"\n```"
+	answer := "```yaml\naction: \"answer\"\nreason: \"done\"\nanswer: \"改好了\"\n```"
+	mock := &seqLLMProvider{responses: []string{write, answer, write, answer, "已修复 a.go。"}}
+	shell := &seqShellTool{mockTool: mockTool{name: "shell_exec"}, results: []tool.ToolResult{
+		{Output: "a.go:1: undefined: x", Error: "命令退出错误: exit status 1"},
+		{Output: "ok  	a	0.01s"},
+	}}
+	reg := tool.NewRegistry()
+	reg.Register(&mockTool{"file_write", "write"})
+	reg.Register(shell)
+	ws := t.TempDir()
+	state := &AgentState{Problem: "修复 a.go", WorkspaceDir: ws, ToolCallMode: "yaml", ThinkingMode: "native", ToolRegistry: reg,
+		Verify: NewVerification(VerifyConfig{Mode: VerifyRun, Command: "go test ./..."}, ws)}
+	BuildAgentFlow(mock, reg, "native", nil).Run(context.Background(), state)
+
+	if state.Solution != "已修复 a.go。" || len(shell.commands) != 2 || shell.commands[0] != "go test ./..." {
+		t.Fatalf("solution=%q, shell commands=%v", state.Solution, shell.commands)
+	}
+	var verify []string
+	for _, s := range state.StepHistory {
+		if s.Type == "verify" {
+			verify = append(verify, s.Action)
+		}
+	}
+	if strings.Join(verify, ",") != "fail,pass" {
+		t.Errorf("verify steps = %v", verify)
+	}
+	if !strings.Contains(lastUserContent(mock.calls[2]), "undefined: x") {
+		t.Error("decide prompt after a failed check should carry its output")
+	}
+}
+
+func TestBuildAgentFlow_VerifyHint(t *testing.T) {
+	mock := &seqLLMProvider{responses: []string{
+		"```yaml\naction: \"tool\"\nreason: \"edit\"\ntool_name: \"file_write\"\ntool_params:\n  path: \"a.go\"\n```",
+		"```yaml\naction: \"answer\"\nreason: \"done\"\nanswer: \"改好了\"\n```",
+		"```yaml\naction: \"tool\"\nreason: \"check\"\ntool_name: \"shell_exec\"\ntool_params:\n  command: \"go test ./...\"\n```",
+		"```yaml\naction: \"answer\"\nreason: \"done\"\nanswer: \"改好了\"\n```",
+		"改好了，测试通过。",
+	}}
+	reg := tool.NewRegistry()
+	reg.Register(&mockTool{"file_write", "write"})
+	reg.Register(&mockTool{"shell_exec", "shell"})
+	ws := t.TempDir()
+	state := &AgentState{Problem: "修复 a.go", WorkspaceDir: ws, ToolCallMode: "yaml", ThinkingMode: "native", ToolRegistry: reg,
+		Verify: NewVerification(VerifyConfig{Mode: VerifyHint, Command: "go test ./..."}, ws)}
+	BuildAgentFlow(mock, reg, "native", nil).Run(context.Background(), state)
+
+	if state.Solution != "改好了，测试通过。" {
+		t.Fatalf("solution = %q", state.Solution)
+	}
+	if !strings.Contains(lastUserContent(mock.calls[2]), "必须先用 shell_exec 运行 `go test ./...`") {
+		t.Error("decide prompt after the hint should ask for the check")
+	}
+	if state.Verify.Feedback != "" || state.Verify.attempts != 1 {
+		t.Errorf("feedback=%q attempts=%d", state.Verify.Feedback, state.Verify.attempts)
+	}
+}
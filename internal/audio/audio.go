@@ -0,0 +1,130 @@
+// Package audio provides the optional speech backends behind /api/stt and
+// /api/tts: an OpenAI-compatible audio API for both directions, or a local
+// whisper.cpp binary for transcription.
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Transcriber converts recorded speech to text.
+type Transcriber interface {
+	// Transcribe returns the text spoken in data; filename carries the
+	// container format (e.g. "voice.webm").
+	Transcribe(ctx context.Context, data []byte, filename string) (string, error)
+}
+
+// Synthesizer converts text to speech.
+type Synthesizer interface {
+	Synthesize(ctx context.Context, text string) (Speech, error)
+}
+
+// Speech is synthesized audio.
+type Speech struct {
+	Data     []byte
+	MIMEType string
+}
+
+// Defaults for the OpenAI-compatible backend. Overridable via AUDIO_* env vars.
+const (
+	DefaultSTTModel = "whisper-1"
+	DefaultTTSModel = "tts-1"
+	DefaultTTSVoice = "alloy"
+)
+
+// Backends holds the configured speech backends; either may be nil.
+type Backends struct {
+	STT Transcriber
+	TTS Synthesizer
+}
+
+// LoadFromEnv returns the backends configured from the environment, or nil
+// when AUDIO_ENABLED is not "true".
+//
+//	AUDIO_ENABLED=true      enable /api/stt and /api/tts
+//	AUDIO_STT_BACKEND       openai | whisper | off (default: openai)
+//	AUDIO_TTS_BACKEND       openai | off (default: openai)
+//	AUDIO_BASE_URL          audio API base URL (default: LLM_BASE_URL)
+//	AUDIO_API_KEY           audio API key (default: LLM_API_KEY)
+//	AUDIO_STT_MODEL         transcription model (default: whisper-1)
+//	AUDIO_TTS_MODEL         speech model (default: tts-1)
+//	AUDIO_TTS_VOICE         voice (default: alloy)
+//	AUDIO_LANGUAGE          ISO-639-1 hint for transcription, e.g. zh (default: detect)
+//	WHISPER_CPP_BIN         whisper.cpp CLI (default: whisper-cli)
+//	WHISPER_CPP_MODEL       ggml model path, required for the whisper backend
+func LoadFromEnv() (*Backends, error) {
+	if os.Getenv("AUDIO_ENABLED") != "true" {
+		return nil, nil
+	}
+	language := strings.TrimSpace(os.Getenv("AUDIO_LANGUAGE"))
+	var api *OpenAI
+	openAI := func() (*OpenAI, error) {
+		if api != nil {
+			return api, nil
+		}
+		key := envOr("AUDIO_API_KEY", os.Getenv("LLM_API_KEY"))
+		if key == "" {
+			return nil, fmt.Errorf("audio: AUDIO_API_KEY or LLM_API_KEY is required for the openai backend")
+		}
+		api = NewOpenAI(
+			envOr("AUDIO_BASE_URL", envOr("LLM_BASE_URL", "https://api.openai.com/v1")), key,
+			envOr("AUDIO_STT_MODEL", DefaultSTTModel),
+			envOr("AUDIO_TTS_MODEL", DefaultTTSModel),
+			envOr("AUDIO_TTS_VOICE", DefaultTTSVoice),
+			language,
+		)
+		return api, nil
+	}
+
+	b := &Backends{}
+	switch mode := strings.ToLower(envOr("AUDIO_STT_BACKEND", "openai")); mode {
+	case "off":
+	case "openai":
+		o, err := openAI()
+		if err != nil {
+			return nil, err
+		}
+		b.STT = o
+	case "whisper":
+		w, err := NewWhisperCPP(envOr("WHISPER_CPP_BIN", "whisper-cli"), os.Getenv("WHISPER_CPP_MODEL"), language)
+		if err != nil {
+			return nil, err
+		}
+		b.STT = w
+	default:
+		return nil, fmt.Errorf("audio: unknown AUDIO_STT_BACKEND=%q (supported: openai, whisper, off)", mode)
+	}
+	switch mode := strings.ToLower(envOr("AUDIO_TTS_BACKEND", "openai")); mode {
+	case "off":
+	case "openai":
+		o, err := openAI()
+		if err != nil {
+			return nil, err
+		}
+		b.TTS = o
+	default:
+		return nil, fmt.Errorf("audio: unknown AUDIO_TTS_BACKEND=%q (supported: openai, off)", mode)
+	}
+	return b, nil
+}
+
+// String returns a short human-readable description for startup logs.
+func (b *Backends) String() string {
+	name := func(v any) string {
+		if s, ok := v.(fmt.Stringer); ok {
+			return s.String()
+		}
+		return "off"
+	}
+	return fmt.Sprintf("stt=%s tts=%s", name(b.STT), name(b.TTS))
+}
+
+func envOr(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
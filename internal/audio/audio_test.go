@@ -0,0 +1,120 @@
+package audio
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv("AUDIO_ENABLED", "")
+	if b, err := LoadFromEnv(); b != nil || err != nil {
+		t.Fatalf("disabled: %v, %v", b, err)
+	}
+
+	t.Setenv("AUDIO_ENABLED", "true")
+	t.Setenv("LLM_API_KEY", "sk-test")
+	b, err := LoadFromEnv()
+	if err != nil || b.STT == nil || b.TTS == nil || b.String() != "stt=openai tts=openai" {
+		t.Fatalf("default: %v, %v", b, err)
+	}
+
+	t.Setenv("AUDIO_TTS_BACKEND", "off")
+	if b, err := LoadFromEnv(); err != nil || b.TTS != nil || b.String() != "stt=openai tts=off" {
+		t.Errorf("tts off: %v, %v", b, err)
+	}
+
+	t.Setenv("AUDIO_STT_BACKEND", "whisper")
+	t.Setenv("WHISPER_CPP_BIN", "no-such-whisper-binary")
+	if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "WHISPER_CPP_BIN") {
+		t.Errorf("missing whisper binary: err = %v", err)
+	}
+
+	t.Setenv("AUDIO_STT_BACKEND", "dragon")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("unknown backend accepted")
+	}
+
+	t.Setenv("AUDIO_STT_BACKEND", "")
+	t.Setenv("LLM_API_KEY", "")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("openai backend without an API key accepted")
+	}
+}
+
+func TestOpenAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/audio/transcriptions":
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			f, fh, _ := r.FormFile("file")
+			data, _ := io.ReadAll(f)
+			if r.FormValue("model") != "whisper-1" || r.FormValue("language") != "zh" || fh.Filename != "voice.webm" || string(data) != "webm" {
+				http.Error(w, "unexpected form", http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"text": " 你好 "})
+		case "/v1/audio/speech":
+			var req map[string]any
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["model"] != "tts-1" || req["voice"] != "nova" || req["input"] != "hello" {
+				http.Error(w, "unexpected request", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Write([]byte("ID3mp3"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	o := NewOpenAI(srv.URL+"/v1", "sk-test", "whisper-1", "tts-1", "nova", "zh")
+	text, err := o.Transcribe(context.Background(), []byte("webm"), "voice.webm")
+	if err != nil || text != "你好" {
+		t.Errorf("Transcribe = %q, %v", text, err)
+	}
+	speech, err := o.Synthesize(context.Background(), "hello")
+	if err != nil || string(speech.Data) != "ID3mp3" || speech.MIMEType != "audio/mpeg" {
+		t.Errorf("Synthesize = %q %q, %v", speech.Data, speech.MIMEType, err)
+	}
+}
+
+func TestWhisperCPP(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the whisper.cpp binary")
+	}
+	dir := t.TempDir()
+	model := filepath.Join(dir, "ggml-base.bin")
+	os.WriteFile(model, []byte("model"), 0o644)
+	// Fake CLI: prints its arguments and the input file name as two segments
+	bin := filepath.Join(dir, "whisper-cli")
+	os.WriteFile(bin, []byte("#!/bin/sh\necho \" $*\"\necho\necho \" done\"\n"), 0o755)
+
+	if _, err := NewWhisperCPP(bin, "", ""); err == nil {
+		t.Error("missing model accepted")
+	}
+	w, err := NewWhisperCPP(bin, model, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.ffmpeg = "" // pass input through unconverted
+
+	text, err := w.Transcribe(context.Background(), []byte("RIFF"), "voice.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(text, "-m "+model+" -f ") || !strings.HasSuffix(text, "input.wav -l auto -nt -np done") {
+		t.Errorf("transcript = %q", text)
+	}
+}
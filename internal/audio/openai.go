@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	openailib "github.com/sashabaranov/go-openai"
+)
+
+// maxSpeechBytes caps a synthesized answer (about 10 minutes of mp3).
+const maxSpeechBytes = 10 << 20
+
+// OpenAI is an OpenAI-compatible /audio/transcriptions and /audio/speech
+// backend.
+type OpenAI struct {
+	client   *openailib.Client
+	sttModel string
+	ttsModel string
+	voice    string
+	language string
+}
+
+// NewOpenAI creates a backend for the API at baseURL.
+func NewOpenAI(baseURL, apiKey, sttModel, ttsModel, voice, language string) *OpenAI {
+	cfg := openailib.DefaultConfig(apiKey)
+	cfg.BaseURL = baseURL
+	return &OpenAI{
+		client:   openailib.NewClientWithConfig(cfg),
+		sttModel: sttModel,
+		ttsModel: ttsModel,
+		voice:    voice,
+		language: language,
+	}
+}
+
+// Transcribe implements Transcriber.
+func (o *OpenAI) Transcribe(ctx context.Context, data []byte, filename string) (string, error) {
+	resp, err := o.client.CreateTranscription(ctx, openailib.AudioRequest{
+		Model:    o.sttModel,
+		FilePath: filename,
+		Reader:   bytes.NewReader(data),
+		Language: o.language,
+		Format:   openailib.AudioResponseFormatJSON,
+	})
+	if err != nil {
+		return "", fmt.Errorf("transcription failed: %w", err)
+	}
+	return strings.TrimSpace(resp.Text), nil
+}
+
+// Synthesize implements Synthesizer.
+func (o *OpenAI) Synthesize(ctx context.Context, text string) (Speech, error) {
+	resp, err := o.client.CreateSpeech(ctx, openailib.CreateSpeechRequest{
+		Model:          openailib.SpeechModel(o.ttsModel),
+		Input:          text,
+		Voice:          openailib.SpeechVoice(o.voice),
+		ResponseFormat: openailib.SpeechResponseFormatMp3,
+	})
+	if err != nil {
+		return Speech{}, fmt.Errorf("speech synthesis failed: %w", err)
+	}
+	defer resp.Close()
+	data, err := io.ReadAll(io.LimitReader(resp, maxSpeechBytes))
+	if err != nil {
+		return Speech{}, fmt.Errorf("speech synthesis failed: %w", err)
+	}
+	return Speech{Data: data, MIMEType: "audio/mpeg"}, nil
+}
+
+// String returns a short description for startup logs.
+func (o *OpenAI) String() string { return "openai" }
@@ -0,0 +1,103 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// WhisperCPP transcribes with a local whisper.cpp CLI. The CLI reads WAV
+// input; other formats (browser recordings are webm/ogg) are converted with
+// ffmpeg when it is in PATH.
+type WhisperCPP struct {
+	bin      string
+	model    string
+	language string
+	ffmpeg   string // "" = not found; non-WAV input is passed through as is
+}
+
+// NewWhisperCPP checks that bin and model exist.
+func NewWhisperCPP(bin, model, language string) (*WhisperCPP, error) {
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return nil, fmt.Errorf("audio: whisper.cpp binary %q not found (set WHISPER_CPP_BIN)", bin)
+	}
+	if model == "" {
+		return nil, fmt.Errorf("audio: WHISPER_CPP_MODEL is required for the whisper backend")
+	}
+	if _, err := os.Stat(model); err != nil {
+		return nil, fmt.Errorf("audio: whisper.cpp model: %w", err)
+	}
+	w := &WhisperCPP{bin: path, model: model, language: language}
+	w.ffmpeg, _ = exec.LookPath("ffmpeg")
+	return w, nil
+}
+
+// Transcribe implements Transcriber.
+func (w *WhisperCPP) Transcribe(ctx context.Context, data []byte, filename string) (string, error) {
+	dir, err := os.MkdirTemp("", "omega-stt-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		ext = ".webm"
+	}
+	input := filepath.Join(dir, "input"+ext)
+	if err := os.WriteFile(input, data, 0o600); err != nil {
+		return "", err
+	}
+	if ext != ".wav" && w.ffmpeg != "" {
+		wav := filepath.Join(dir, "input.wav")
+		cmd := exec.CommandContext(ctx, w.ffmpeg, "-nostdin", "-loglevel", "error", "-i", input, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wav)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("ffmpeg conversion failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		input = wav
+	}
+
+	cmd := exec.CommandContext(ctx, w.bin, w.args(input)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("whisper.cpp failed: %v: %s", err, lastLine(stderr.String()))
+	}
+	return joinTranscript(stdout.String()), nil
+}
+
+// args builds the CLI arguments: no timestamps, no progress output.
+func (w *WhisperCPP) args(input string) []string {
+	lang := w.language
+	if lang == "" {
+		lang = "auto"
+	}
+	return []string{"-m", w.model, "-f", input, "-l", lang, "-nt", "-np"}
+}
+
+// joinTranscript joins the CLI's per-segment output lines into one text.
+func joinTranscript(out string) string {
+	var parts []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func lastLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
+
+// String returns a short description for startup logs.
+func (w *WhisperCPP) String() string { return "whisper.cpp" }
@@ -0,0 +1,294 @@
+// Package audit keeps an append-only record of every tool call: who ran
+// which tool in which session, a hash of its arguments, the outcome and
+// the duration. Unlike the exec log it holds no tool input or output, so
+// it can be kept for long and shown to operators.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outcomes of a tool call.
+const (
+	OutcomeOK       = "ok"
+	OutcomeError    = "error"
+	OutcomeTimeout  = "timeout"
+	OutcomeNotFound = "not_found" // unknown tool, or hidden by a profile or the user's role
+	OutcomeCached   = "cached"    // served from the run's read cache, not executed
+)
+
+// Entry is one tool call, one JSON line in the audit log.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	SessionID  string    `json:"session_id,omitempty"`
+	RunID      string    `json:"run_id,omitempty"`
+	User       string    `json:"user,omitempty"`
+	Tool       string    `json:"tool"`
+	ParamsHash string    `json:"params_hash"` // see HashParams
+	Outcome    string    `json:"outcome"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// HashParams returns "sha256:<hex>" of the JSON arguments of a call, so
+// that identical calls can be matched without storing their content.
+func HashParams(args []byte) string {
+	sum := sha256.Sum256(args)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// fileName is the file entries are appended to; rotated files are named
+// audit-<UTC time>.jsonl.
+const fileName = "audit.jsonl"
+
+// Log appends entries to dir/audit.jsonl, rotating it once it reaches
+// maxBytes. Thread-safe, nil-safe.
+type Log struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64    // 0 = never rotate
+	keep     int      // rotated files kept; 0 = all
+	f        *os.File // nil after a failed rotation: reopened by the next Append
+	size     int64
+	closed   bool
+	now      func() time.Time
+}
+
+// Open opens (creating) the audit log in dir. Files are private to the
+// owner: they name users and sessions.
+func Open(dir string, maxBytes int64, keep int) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("cannot create audit log dir: %w", err)
+	}
+	l := &Log{dir: dir, maxBytes: maxBytes, keep: keep, now: time.Now}
+	if err := l.openLocked(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Dir returns the directory of the log files.
+func (l *Log) Dir() string { return l.dir }
+
+func (l *Log) openLocked() error {
+	f, err := os.OpenFile(filepath.Join(l.dir, fileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+// Append writes e, filling in its time when unset.
+func (l *Log) Append(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if l.closed {
+		return fmt.Errorf("audit log is closed")
+	}
+	if l.f == nil {
+		if err := l.openLocked(); err != nil {
+			return err
+		}
+	}
+	if l.maxBytes > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxBytes {
+		if err := l.rotateLocked(); err != nil {
+			return fmt.Errorf("rotate audit log: %w", err)
+		}
+	}
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// rotateLocked renames the current file and starts a new one, removing
+// the oldest rotated files beyond keep.
+func (l *Log) rotateLocked() error {
+	l.f.Close()
+	l.f = nil
+	rotated := filepath.Join(l.dir, "audit-"+l.now().UTC().Format("20060102T150405.000")+".jsonl")
+	if err := os.Rename(filepath.Join(l.dir, fileName), rotated); err != nil {
+		return err
+	}
+	if l.keep > 0 {
+		files, err := l.rotatedFiles()
+		if err != nil {
+			return err
+		}
+		for len(files) > l.keep {
+			if err := os.Remove(files[0]); err != nil {
+				log.Printf("[Audit] Failed to remove %s: %v", files[0], err)
+			}
+			files = files[1:]
+		}
+	}
+	return l.openLocked()
+}
+
+// rotatedFiles lists the rotated files, oldest first.
+func (l *Log) rotatedFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(l.dir, "audit-*.jsonl"))
+	sort.Strings(files)
+	return files, err
+}
+
+// Close closes the current file.
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// Filter selects entries for Query; zero fields match everything.
+type Filter struct {
+	SessionID string
+	User      string
+	Tool      string
+	Outcome   string
+	Since     time.Time
+	Until     time.Time
+	Limit     int // most recent entries returned; 0 = DefaultLimit
+}
+
+// Query limits.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+func (f Filter) match(e Entry) bool {
+	return (f.SessionID == "" || e.SessionID == f.SessionID) &&
+		(f.User == "" || e.User == f.User) &&
+		(f.Tool == "" || e.Tool == f.Tool) &&
+		(f.Outcome == "" || e.Outcome == f.Outcome) &&
+		(f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || e.Time.Before(f.Until))
+}
+
+// Query returns the most recent entries matching f, newest first, across
+// the current and the rotated files. Malformed lines are skipped.
+func (l *Log) Query(f Filter) ([]Entry, error) {
+	if l == nil {
+		return nil, nil
+	}
+	if f.Limit <= 0 {
+		f.Limit = DefaultLimit
+	}
+	f.Limit = min(f.Limit, MaxLimit)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	files, err := l.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, filepath.Join(l.dir, fileName))
+
+	var out []Entry
+	for i := len(files) - 1; i >= 0 && len(out) < f.Limit; i-- {
+		entries, err := readEntries(files[i])
+		if err != nil {
+			return nil, err
+		}
+		for j := len(entries) - 1; j >= 0 && len(out) < f.Limit; j-- {
+			if f.match(entries[j]) {
+				out = append(out, entries[j])
+			}
+		}
+	}
+	return out, nil
+}
+
+// readEntries reads the entries of one file in order.
+func readEntries(path string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var entries []Entry
+	sc := bufio.NewScanner(file)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		var e Entry
+		if json.Unmarshal(sc.Bytes(), &e) != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// Run records the tool calls of one agent run. Nil-safe: a nil Run (no
+// audit log) records nothing.
+type Run struct {
+	log       *Log
+	sessionID string
+	runID     string
+	user      string
+}
+
+// Run returns the recorder of one run; nil when l is nil.
+func (l *Log) Run(sessionID, runID, user string) *Run {
+	if l == nil {
+		return nil
+	}
+	return &Run{log: l, sessionID: sessionID, runID: runID, user: user}
+}
+
+// Record appends one tool call. Failures are logged, never returned: the
+// run goes on without its audit entry rather than failing.
+func (r *Run) Record(tool string, args []byte, outcome string, durationMs int64) {
+	if r == nil {
+		return
+	}
+	err := r.log.Append(Entry{
+		SessionID:  r.sessionID,
+		RunID:      r.runID,
+		User:       r.user,
+		Tool:       tool,
+		ParamsHash: HashParams(args),
+		Outcome:    outcome,
+		DurationMs: durationMs,
+	})
+	if err != nil {
+		log.Printf("[Audit] Failed to record %s: %v", tool, err)
+	}
+}
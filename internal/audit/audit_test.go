@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLog_AppendQueryRotate(t *testing.T) {
+	dir := t.TempDir()
+	l, err := Open(dir, 1000, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	base := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	tick := 0
+	l.now = func() time.Time { tick++; return base.Add(time.Duration(tick) * time.Second) }
+
+	alice := l.Run("s1", "r1", "alice")
+	for i := 0; i < 10; i++ {
+		alice.Record("shell_exec", []byte(`{"command":"ls"}`), OutcomeOK, 12)
+	}
+	l.Run("s2", "r2", "bob").Record("file_write", []byte(`{"path":"a"}`), OutcomeError, 3)
+
+	rotated, _ := filepath.Glob(filepath.Join(dir, "audit-*.jsonl"))
+	if len(rotated) != 1 {
+		t.Errorf("rotated files = %d, want 1 (keep)", len(rotated))
+	}
+	if info, err := os.Stat(filepath.Join(dir, fileName)); err != nil || info.Size() > 1000 {
+		t.Errorf("current file: %v, size %d", err, info.Size())
+	}
+
+	got, err := l.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || got[0].User != "bob" || got[0].Outcome != OutcomeError {
+		t.Fatalf("newest entry = %+v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Time.After(got[i-1].Time) {
+			t.Fatal("entries should be newest first across files")
+		}
+	}
+	if got[1].ParamsHash != HashParams([]byte(`{"command":"ls"}`)) || got[1].SessionID != "s1" || got[1].RunID != "r1" {
+		t.Errorf("entry = %+v", got[1])
+	}
+
+	shell, _ := l.Query(Filter{User: "alice", Tool: "shell_exec", Limit: 3})
+	if len(shell) != 3 {
+		t.Errorf("filtered query = %d entries, want 3", len(shell))
+	}
+	late, _ := l.Query(Filter{Since: got[0].Time})
+	if len(late) != 1 {
+		t.Errorf("since query = %d entries, want 1", len(late))
+	}
+}
+
+func TestRun_NilSafe(t *testing.T) {
+	var l *Log
+	l.Run("s", "r", "u").Record("file_read", nil, OutcomeOK, 1)
+	if got, err := l.Query(Filter{}); got != nil || err != nil {
+		t.Errorf("nil log Query = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,272 @@
+// Package batch runs one task (a prompt or playbook file) across many
+// workspaces — e.g. the same migration in dozens of similar repos — and
+// collects per-workspace answers, git diffs and failures into one report.
+//
+// Workspaces run sequentially or with bounded parallelism; each gets its
+// own tool registry rooted at that workspace and a fresh agent state.
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	defaultTimeout      = 10 * time.Minute
+	defaultMaxDiffBytes = 64 << 10
+)
+
+// Agent is the agent configuration every workspace runs with.
+type Agent struct {
+	Provider      llm.LLMProvider
+	Loader        *prompt.PromptLoader // nil = hardcoded prompt defaults
+	ModelName     string
+	ThinkingMode  string // "app" or "native"
+	ToolCallMode  string // "auto", "fc", "yaml" or "json"
+	ContextWindow int    // tokens; 0 = agent fallback
+	OSName        string
+	ShellCmd      string
+	SelfReview    int // failed answer reviews that send the run back; 0 = off
+}
+
+// Options configures a Runner.
+type Options struct {
+	Agent Agent
+	// Tools builds the tool registry for one workspace. Required.
+	Tools func(workspaceDir string) *tool.Registry
+	// Parallel bounds concurrently running workspaces (default 1 = sequential).
+	Parallel int
+	// Timeout bounds the agent run of a single workspace (default 10m).
+	Timeout time.Duration
+	// MaxDiffBytes truncates each workspace's diff in the report (default 64 KiB).
+	MaxDiffBytes int
+}
+
+// Job is one batch: the same prompt for every workspace.
+//
+// A playbook prompt may start with frontmatter; its answer_style field
+// selects the answer style profile unless AnswerStyle is set:
+//
+//	---
+//	answer_style: concise
+//	---
+type Job struct {
+	Prompt      string   `json:"prompt"`
+	Workspaces  []string `json:"workspaces"`
+	Parallel    int      `json:"parallel,omitempty"`     // 0 = Options.Parallel
+	AnswerStyle string   `json:"answer_style,omitempty"` // answer style profile; "" = playbook's or default
+	// Tools limits every workspace's tools to these names or path.Match
+	// patterns (see tool.Registry.WithOnly), e.g. by the role of the user
+	// who submitted the job; nil = no limit. Not settable from JSON.
+	Tools []string `json:"-"`
+}
+
+// Result is the outcome for one workspace.
+type Result struct {
+	Workspace  string `json:"workspace"`
+	OK         bool   `json:"ok"`
+	Error      string `json:"error,omitempty"` // why the workspace failed
+	Answer     string `json:"answer,omitempty"`
+	Steps      int    `json:"steps"`
+	ToolCalls  int    `json:"tool_calls"`
+	DurationMs int64  `json:"duration_ms"`
+
+	// Changes are only tracked in git work trees (Git = true): the diff is
+	// taken against HEAD at the start of the run, so commits made by the
+	// agent are included. DirtyBefore warns that uncommitted changes
+	// predating the run are part of the diff as well.
+	Git           bool     `json:"git"`
+	DirtyBefore   bool     `json:"dirty_before,omitempty"`
+	ChangedFiles  []string `json:"changed_files,omitempty"`
+	Diff          string   `json:"diff,omitempty"`
+	DiffTruncated bool     `json:"diff_truncated,omitempty"`
+}
+
+// Runner executes batch jobs.
+type Runner struct {
+	opts Options
+}
+
+// NewRunner creates a Runner, applying defaults to zero-valued options.
+func NewRunner(opts Options) *Runner {
+	if opts.Parallel <= 0 {
+		opts.Parallel = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxDiffBytes <= 0 {
+		opts.MaxDiffBytes = defaultMaxDiffBytes
+	}
+	return &Runner{opts: opts}
+}
+
+// Run executes job and returns the report with results in workspace order.
+// Duplicate workspaces are run once. When ctx is cancelled, workspaces that
+// have not started are reported as failed with the cancellation error.
+//
+// progress, when non-nil, is called after each workspace completes, from the
+// worker goroutine (concurrently when running in parallel).
+func (r *Runner) Run(ctx context.Context, job Job, progress func(Result)) (*Report, error) {
+	if r.opts.Tools == nil || r.opts.Agent.Provider == nil {
+		return nil, fmt.Errorf("batch: Options.Tools and Options.Agent.Provider are required")
+	}
+	meta, problem := prompt.SplitFrontmatter(job.Prompt)
+	if strings.TrimSpace(problem) == "" {
+		return nil, fmt.Errorf("batch: empty prompt")
+	}
+	style := job.AnswerStyle
+	if style == "" {
+		style = meta["answer_style"]
+	}
+	if style == prompt.DefaultAnswerStyle {
+		style = ""
+	}
+	if style != "" && (r.opts.Agent.Loader == nil || !r.opts.Agent.Loader.HasAnswerStyle(style)) {
+		return nil, fmt.Errorf("batch: unknown answer style %q", style)
+	}
+	workspaces := dedupe(job.Workspaces)
+	if len(workspaces) == 0 {
+		return nil, fmt.Errorf("batch: no workspaces")
+	}
+	parallel := job.Parallel
+	if parallel <= 0 {
+		parallel = r.opts.Parallel
+	}
+
+	rep := &Report{
+		Prompt:      problem,
+		AnswerStyle: style,
+		Parallel:    parallel,
+		Started:     time.Now(),
+		Results:     make([]Result, len(workspaces)),
+	}
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, ws := range workspaces {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			rep.Results[i] = Result{Workspace: ws, Error: fmt.Sprintf("not started: %v", ctx.Err())}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			res := r.runOne(ctx, ws, problem, style, job.Tools)
+			rep.Results[i] = res
+			if progress != nil {
+				progress(res)
+			}
+		}()
+	}
+	wg.Wait()
+	rep.DurationMs = time.Since(rep.Started).Milliseconds()
+	return rep, nil
+}
+
+// runOne runs the prompt in a single workspace and captures its changes.
+func (r *Runner) runOne(ctx context.Context, ws, problem, style string, only []string) Result {
+	res := Result{Workspace: ws}
+	if info, err := os.Stat(ws); err != nil || !info.IsDir() {
+		res.Error = "workspace is not a directory"
+		return res
+	}
+
+	base, dirty, isGit := gitSnapshot(ctx, ws)
+	res.Git, res.DirtyBefore = isGit, dirty
+
+	tools := r.opts.Tools(ws)
+	registry := tools
+	if only != nil {
+		registry = tools.WithOnly(only)
+	}
+	a := r.opts.Agent
+	flow := agent.BuildAgentFlow(a.Provider, registry, a.ThinkingMode, a.Loader)
+	state := &agent.AgentState{
+		Problem:             problem,
+		AnswerStyle:         style,
+		WorkspaceDir:        ws,
+		ToolRegistry:        registry,
+		ThinkingMode:        a.ThinkingMode,
+		ToolCallMode:        a.ToolCallMode,
+		ContextWindowTokens: a.ContextWindow,
+		OSName:              a.OSName,
+		ShellCmd:            a.ShellCmd,
+		ModelName:           a.ModelName,
+		ReadCache:           agent.NewReadCache(),
+		SearchHistory:       tool.NewSearchHistory(),
+		SelfReviewRetries:   a.SelfReview,
+	}
+
+	// Batch runs yield to interactive chats on a shared scheduler, and the
+	// workspaces of a parallel batch share it round-robin.
+	runCtx, cancel := context.WithTimeout(llm.WithPriority(llm.WithSession(ctx, "batch:"+ws), llm.PriorityBackground), r.opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	flow.Run(runCtx, state)
+	res.DurationMs = time.Since(start).Milliseconds()
+	tools.CloseAll() // a WithOnly view holds no tools of its own
+
+	res.Steps = len(state.StepHistory)
+	for _, s := range state.StepHistory {
+		if s.Type == "tool" {
+			res.ToolCalls++
+		}
+	}
+	res.Answer = strings.TrimSpace(state.Solution)
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+		res.Error = fmt.Sprintf("timeout after %v", r.opts.Timeout)
+	case ctx.Err() != nil:
+		res.Error = fmt.Sprintf("cancelled: %v", ctx.Err())
+	case res.Answer == "":
+		res.Error = "agent produced no answer"
+	}
+
+	if isGit {
+		// Use a fresh context: the diff is still wanted after a timeout.
+		gitCtx, gitCancel := context.WithTimeout(context.Background(), gitTimeout)
+		res.ChangedFiles, res.Diff, res.DiffTruncated = gitChanges(gitCtx, ws, base, r.opts.MaxDiffBytes)
+		gitCancel()
+	}
+
+	res.OK = res.Error == ""
+	log.Printf("[Batch] %s: ok=%v steps=%d changed=%d", ws, res.OK, res.Steps, len(res.ChangedFiles))
+	return res
+}
+
+// dedupe cleans workspace paths and drops empty entries and duplicates,
+// keeping the first occurrence so that no two workers share a directory.
+func dedupe(paths []string) []string {
+	seen := make(map[string]bool, len(paths))
+	out := make([]string, 0, len(paths))
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(p); err == nil {
+			p = abs
+		}
+		if !seen[p] {
+			seen[p] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// answerProvider answers every decide call directly.
+type answerProvider struct{}
+
+func (answerProvider) reply() llm.Message {
+	return llm.Message{Role: llm.RoleAssistant, Content: `{"action":"answer","reason":"完成","answer":"done"}`}
+}
+func (p answerProvider) CallLLM(context.Context, []llm.Message) (llm.Message, error) {
+	return p.reply(), nil
+}
+func (p answerProvider) CallLLMStream(context.Context, []llm.Message, llm.StreamCallback) (llm.Message, error) {
+	return p.reply(), nil
+}
+func (p answerProvider) CallLLMWithTools(context.Context, []llm.Message, []llm.ToolDefinition) (llm.Message, error) {
+	return p.reply(), nil
+}
+func (answerProvider) IsToolCallingEnabled() bool { return false }
+
+func gitInit(t *testing.T, dir string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+func TestRunner_CollectsResultsAndDiffs(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("original\n"), 0o644)
+	gitInit(t, repo)
+	plain := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	var toolBuilds atomic.Int32
+	runner := NewRunner(Options{
+		Agent: Agent{Provider: answerProvider{}, ThinkingMode: "native", ToolCallMode: "json"},
+		// The scripted agent does not edit files, so simulate its edits here:
+		// Tools runs after the diff base has been recorded.
+		Tools: func(ws string) *tool.Registry {
+			toolBuilds.Add(1)
+			if ws == repo {
+				os.WriteFile(filepath.Join(ws, "a.txt"), []byte("changed\n"), 0o644)
+				os.WriteFile(filepath.Join(ws, "new.txt"), []byte("x"), 0o644)
+			}
+			return tool.NewRegistry()
+		},
+		Parallel: 2,
+	})
+
+	var progressed atomic.Int32
+	rep, err := runner.Run(context.Background(), Job{
+		Prompt:     "升级依赖",
+		Workspaces: []string{repo, plain, missing, repo + "/"},
+	}, func(Result) { progressed.Add(1) })
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(rep.Results) != 3 || toolBuilds.Load() != 2 || progressed.Load() != 3 {
+		t.Fatalf("results = %d, tool builds = %d, progress = %d", len(rep.Results), toolBuilds.Load(), progressed.Load())
+	}
+
+	r := rep.Results[0]
+	if !r.OK || r.Workspace != repo || !r.Git || r.DirtyBefore {
+		t.Errorf("repo result = %+v", r)
+	}
+	if !reflect.DeepEqual(r.ChangedFiles, []string{"a.txt", "new.txt"}) {
+		t.Errorf("ChangedFiles = %v", r.ChangedFiles)
+	}
+	if !strings.Contains(r.Diff, "+changed") || !strings.Contains(r.Diff, "# new.txt") {
+		t.Errorf("Diff = %q", r.Diff)
+	}
+	if r := rep.Results[1]; !r.OK || r.Git || len(r.ChangedFiles) != 0 {
+		t.Errorf("plain result = %+v", r)
+	}
+	if r := rep.Results[2]; r.OK || r.Error == "" {
+		t.Errorf("missing workspace should fail: %+v", r)
+	}
+	if rep.Failed() != 1 {
+		t.Errorf("Failed() = %d", rep.Failed())
+	}
+
+	var buf bytes.Buffer
+	rep.WriteMarkdown(&buf)
+	out := buf.String()
+	for _, want := range []string{"2 succeeded, 1 failed", "n/a (not git)", "```diff", missing + ": workspace is not a directory"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRunner_CancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runner := NewRunner(Options{
+		Agent: Agent{Provider: answerProvider{}, ThinkingMode: "native", ToolCallMode: "json"},
+		Tools: func(string) *tool.Registry { t.Error("no workspace should start"); return tool.NewRegistry() },
+	})
+	rep, err := runner.Run(ctx, Job{Prompt: "p", Workspaces: []string{t.TempDir(), t.TempDir()}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Failed() != 2 || !strings.Contains(rep.Results[0].Error, "not started") {
+		t.Errorf("results = %+v", rep.Results)
+	}
+}
+
+// systemRecorder answers directly and records the system prompts it receives.
+type systemRecorder struct {
+	answerProvider
+	systems chan string
+}
+
+func (p systemRecorder) CallLLM(_ context.Context, msgs []llm.Message) (llm.Message, error) {
+	if len(msgs) > 0 && msgs[0].Role == llm.RoleSystem {
+		select {
+		case p.systems <- msgs[0].Content:
+		default:
+		}
+	}
+	return p.reply(), nil
+}
+
+func TestRunner_PlaybookAnswerStyle(t *testing.T) {
+	rec := systemRecorder{systems: make(chan string, 16)}
+	runner := NewRunner(Options{
+		Agent: Agent{Provider: rec, Loader: prompt.NewPromptLoader("", "", ""), ThinkingMode: "native", ToolCallMode: "json"},
+		Tools: func(string) *tool.Registry { return tool.NewRegistry() },
+	})
+	playbook := "---\nanswer_style: concise\n---\n升级依赖"
+	rep, err := runner.Run(context.Background(), Job{Prompt: playbook, Workspaces: []string{t.TempDir()}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.AnswerStyle != "concise" || rep.Prompt != "升级依赖" {
+		t.Errorf("report style = %q, prompt = %q", rep.AnswerStyle, rep.Prompt)
+	}
+	if sys := <-rec.systems; !strings.Contains(sys, "简洁工程师") {
+		t.Errorf("system prompt lacks the concise profile:\n%s", sys)
+	}
+
+	// The job's own style wins over the playbook's; unknown styles are rejected.
+	if _, err := runner.Run(context.Background(), Job{Prompt: playbook, AnswerStyle: "nope", Workspaces: []string{t.TempDir()}}, nil); err == nil {
+		t.Error("unknown answer style accepted")
+	}
+}
+
+func TestGitChanges_TruncatesDiff(t *testing.T) {
+	repo := t.TempDir()
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte("x\n"), 0o644)
+	gitInit(t, repo)
+	base, dirty, isGit := gitSnapshot(context.Background(), repo)
+	if !isGit || dirty || base == "" {
+		t.Fatalf("snapshot = %q %v %v", base, dirty, isGit)
+	}
+	os.WriteFile(filepath.Join(repo, "a.txt"), []byte(strings.Repeat("长行内容\n", 200)), 0o644)
+	_, diff, truncated := gitChanges(context.Background(), repo, base, 100)
+	if !truncated || len(diff) > 100 || !strings.HasPrefix(diff, "diff --git") {
+		t.Errorf("diff = %q truncated=%v", diff, truncated)
+	}
+}
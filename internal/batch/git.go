@@ -0,0 +1,69 @@
+package batch
+
+import (
+	"context"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// gitTimeout bounds each git command used to snapshot and diff a workspace.
+const gitTimeout = 30 * time.Second
+
+// emptyTree is git's well-known empty tree object, used as the diff base of
+// a repository without commits.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// git runs a git command in dir and returns its trimmed stdout.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.Output()
+	return strings.TrimRight(string(out), "\n"), err
+}
+
+// gitSnapshot records the diff base of ws before the run. isGit is false
+// when ws is not inside a git work tree (or git is unavailable).
+func gitSnapshot(ctx context.Context, ws string) (base string, dirty, isGit bool) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	if out, err := git(ctx, ws, "rev-parse", "--is-inside-work-tree"); err != nil || out != "true" {
+		return "", false, false
+	}
+	base, err := git(ctx, ws, "rev-parse", "HEAD")
+	if err != nil {
+		base = emptyTree
+	}
+	status, _ := git(ctx, ws, "status", "--porcelain")
+	return base, status != "", true
+}
+
+// gitChanges lists the files changed since base (tracked and untracked) and
+// returns the diff of tracked files, truncated to maxBytes.
+func gitChanges(ctx context.Context, ws, base string, maxBytes int) (files []string, diff string, truncated bool) {
+	seen := make(map[string]bool)
+	names, _ := git(ctx, ws, "diff", "--name-only", base, "--")
+	untracked, _ := git(ctx, ws, "ls-files", "--others", "--exclude-standard")
+	for _, f := range strings.Split(names+"\n"+untracked, "\n") {
+		if f != "" && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	sort.Strings(files)
+
+	diff, _ = git(ctx, ws, "diff", base, "--")
+	if untracked != "" {
+		diff += "\n# untracked files (not diffed):\n# " + strings.ReplaceAll(untracked, "\n", "\n# ")
+	}
+	diff = strings.TrimLeft(diff, "\n")
+	if len(diff) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(diff[cut]) {
+			cut--
+		}
+		diff, truncated = diff[:cut], true
+	}
+	return files, diff, truncated
+}
@@ -0,0 +1,97 @@
+package batch
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+// Report collects the results of one batch job.
+type Report struct {
+	Prompt      string    `json:"prompt"`
+	AnswerStyle string    `json:"answer_style,omitempty"` // "" = default profile
+	Parallel    int       `json:"parallel"`
+	Started     time.Time `json:"started"`
+	DurationMs  int64     `json:"duration_ms"`
+	Results     []Result  `json:"results"`
+}
+
+// Failed returns the number of workspaces that did not complete successfully.
+func (r *Report) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if !res.OK {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteMarkdown renders the consolidated report: a summary table, the
+// failures, then each workspace's answer and diff.
+func (r *Report) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Batch run\n\n")
+	fmt.Fprintf(w, "%d workspace(s), parallel %d, started %s, took %.1fs",
+		len(r.Results), r.Parallel, r.Started.Format(time.DateTime), float64(r.DurationMs)/1000)
+	if r.AnswerStyle != "" {
+		fmt.Fprintf(w, ", answer style %s", r.AnswerStyle)
+	}
+	fmt.Fprint(w, "\n\n")
+	fmt.Fprintf(w, "> %s\n\n", strings.ReplaceAll(util.TruncateRunes(strings.TrimSpace(r.Prompt), 500), "\n", "\n> "))
+
+	fmt.Fprintln(w, "## Summary")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| workspace | result | steps | changed files | time |")
+	fmt.Fprintln(w, "|---|---|---|---|---|")
+	for _, res := range r.Results {
+		mark := "✅"
+		if !res.OK {
+			mark = "❌"
+		}
+		changed := "n/a (not git)"
+		if res.Git {
+			changed = fmt.Sprintf("%d", len(res.ChangedFiles))
+			if res.DirtyBefore {
+				changed += " (dirty before)"
+			}
+		}
+		fmt.Fprintf(w, "| %s | %s | %d | %s | %.1fs |\n",
+			res.Workspace, mark, res.Steps, changed, float64(res.DurationMs)/1000)
+	}
+	fmt.Fprintf(w, "\n%d succeeded, %d failed\n", len(r.Results)-r.Failed(), r.Failed())
+
+	if r.Failed() > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "## Failures")
+		fmt.Fprintln(w)
+		for _, res := range r.Results {
+			if !res.OK {
+				fmt.Fprintf(w, "- %s: %s\n", res.Workspace, res.Error)
+			}
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "## Workspaces")
+	for _, res := range r.Results {
+		fmt.Fprintf(w, "\n### %s\n\n", res.Workspace)
+		if res.Answer != "" {
+			fmt.Fprintln(w, res.Answer)
+			fmt.Fprintln(w)
+		}
+		if len(res.ChangedFiles) > 0 {
+			fmt.Fprintf(w, "Changed: %s\n\n", strings.Join(res.ChangedFiles, ", "))
+		}
+		if res.Diff != "" {
+			fmt.Fprintln(w, "```diff")
+			fmt.Fprintln(w, res.Diff)
+			if res.DiffTruncated {
+				fmt.Fprintln(w, "# ... diff truncated")
+			}
+			fmt.Fprintln(w, "```")
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/joho/godotenv"
 )
@@ -29,7 +30,7 @@ func LoadEnv(paths ...string) {
 	candidates := resolveEnvCandidates()
 	for _, p := range candidates {
 		if _, err := os.Stat(p); err == nil {
-			if err := godotenv.Load(p); err != nil {
+			if err := loadDotenv(p); err != nil {
 				log.Printf("[Config] Failed to load .env from %s: %v", p, err)
 			} else {
 				log.Printf("[Config] Loaded .env from %s", p)
@@ -41,9 +42,45 @@ func LoadEnv(paths ...string) {
 	log.Printf("[Config] No .env file found (searched: %v), using system environment variables", candidates)
 }
 
+// dotenv remembers the .env file LoadEnv applied and the variables it set,
+// so that Reload can tell them from variables of the process environment.
+var dotenv struct {
+	sync.Mutex
+	path string
+	keys map[string]bool
+}
+
+// loadDotenv sets the variables of the .env file at path that are not
+// already set, like godotenv.Load, and records them.
+func loadDotenv(path string) error {
+	vars, err := godotenv.Read(path)
+	if err != nil {
+		return err
+	}
+	dotenv.Lock()
+	defer dotenv.Unlock()
+	dotenv.path = path
+	dotenv.keys = map[string]bool{}
+	for k, v := range vars {
+		if _, set := os.LookupEnv(k); set {
+			continue
+		}
+		os.Setenv(k, v)
+		dotenv.keys[k] = true
+	}
+	return nil
+}
+
 // resolveEnvCandidates returns the ordered list of .env paths to probe.
 // Exported so tests can verify path resolution without side-effects.
 func resolveEnvCandidates() []string {
+	return resolveCandidates(".env")
+}
+
+// resolveCandidates returns the ordered list of paths to probe for a
+// config file: next to the executable (and up to 3 parents), then the
+// current working directory.
+func resolveCandidates(name string) []string {
 	var candidates []string
 	seen := map[string]bool{}
 
@@ -65,7 +102,7 @@ func resolveEnvCandidates() []string {
 		}
 		dir := filepath.Dir(exe)
 		for i := 0; i <= 3; i++ {
-			add(filepath.Join(dir, ".env"))
+			add(filepath.Join(dir, name))
 			parent := filepath.Dir(dir)
 			if parent == dir {
 				break // reached filesystem root
@@ -76,7 +113,7 @@ func resolveEnvCandidates() []string {
 
 	// 2. Current working directory — fallback for `go run ./cmd/omega`.
 	if cwd, err := os.Getwd(); err == nil {
-		add(filepath.Join(cwd, ".env"))
+		add(filepath.Join(cwd, name))
 	}
 
 	return candidates
@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the config file looked up next to the executable and in the
+// working directory; OMEGA_CONFIG names another path.
+const FileName = "omega.yaml"
+
+// File is the schema of omega.yaml. Every setting is the YAML form of one
+// environment variable (its env tag): loading the file exports the
+// settings the environment does not already set, so the rest of the
+// program keeps reading os.Getenv, and a variable set in the environment
+// or in .env overrides the file. Unset fields keep the program's defaults.
+//
+// A check tag validates the value at startup: "lo..hi" is a numeric range
+// (either bound may be omitted), "a|b|c" lists the allowed strings.
+type File struct {
+	LLM      LLMSection      `yaml:"llm"`
+	Budgets  BudgetsSection  `yaml:"budgets"`
+	Sessions SessionsSection `yaml:"sessions"`
+	Tools    ToolsSection    `yaml:"tools"`
+	MCP      MCPSection      `yaml:"mcp"`
+	Prompts  PromptsSection  `yaml:"prompts"`
+	Web      WebSection      `yaml:"web"`
+}
+
+// LLMSection configures the model endpoint (LLM_*).
+type LLMSection struct {
+	BaseURL         string              `yaml:"base_url" env:"LLM_BASE_URL"`
+	APIKey          string              `yaml:"api_key" env:"LLM_API_KEY" secret:"true"`
+	Model           string              `yaml:"model" env:"LLM_MODEL"`
+	Temperature     *float64            `yaml:"temperature" env:"LLM_TEMPERATURE" check:"0..2"`
+	TopP            *float64            `yaml:"top_p" env:"LLM_TOP_P" check:"0..1"`
+	MaxTokens       *int                `yaml:"max_tokens" env:"LLM_MAX_TOKENS" check:"1.."`
+	ContextWindow   *int                `yaml:"context_window" env:"LLM_CONTEXT_WINDOW" check:"1.."`
+	HTTPTimeout     *int                `yaml:"http_timeout_seconds" env:"LLM_HTTP_TIMEOUT" check:"1.."`
+	MaxRetries      *int                `yaml:"max_retries" env:"LLM_MAX_RETRIES" check:"0.."`
+	RetryBaseMs     *int                `yaml:"retry_base_ms" env:"LLM_RETRY_BASE_MS" check:"1.."`
+	RetryMaxDelay   *int                `yaml:"retry_max_delay_seconds" env:"LLM_RETRY_MAX_DELAY_SECONDS" check:"1.."`
+	MaxConcurrency  *int                `yaml:"max_concurrency" env:"LLM_MAX_CONCURRENCY" check:"0.."`
+	ThinkingMode    string              `yaml:"thinking_mode" env:"LLM_THINKING_MODE" check:"auto|native|app"`
+	ToolCallMode    string              `yaml:"tool_call_mode" env:"LLM_TOOL_CALL_MODE" check:"auto|fc|yaml|json"`
+	ReasoningEffort string              `yaml:"reasoning_effort" env:"LLM_REASONING_EFFORT" check:"low|medium|high"`
+	Vision          string              `yaml:"vision" env:"LLM_VISION" check:"auto|on|off"`
+	Tokenizer       string              `yaml:"tokenizer" env:"LLM_TOKENIZER"`
+	Cache           *bool               `yaml:"cache" env:"LLM_CACHE"`
+	DownshiftModel  string              `yaml:"downshift_model" env:"LLM_DOWNSHIFT_MODEL"`
+	Fallback        LLMFallbackSection  `yaml:"fallback"`
+	Roles           map[string]LLMRoute `yaml:"roles"` // decide, think, answer, summarize, review
+}
+
+// LLMFallbackSection configures the secondary endpoint (LLM_FALLBACK_*).
+type LLMFallbackSection struct {
+	BaseURL         string `yaml:"base_url" env:"LLM_FALLBACK_BASE_URL"`
+	APIKey          string `yaml:"api_key" env:"LLM_FALLBACK_API_KEY" secret:"true"`
+	Model           string `yaml:"model" env:"LLM_FALLBACK_MODEL"`
+	CooldownSeconds *int   `yaml:"cooldown_seconds" env:"LLM_FAILOVER_COOLDOWN_SECONDS" check:"0.."`
+}
+
+// LLMRoute routes one model role (LLM_MODEL_<ROLE>, LLM_BASE_URL_<ROLE>,
+// LLM_API_KEY_<ROLE>) and sets its sampling (LLM_TEMPERATURE_<ROLE>,
+// LLM_TOP_P_<ROLE>).
+type LLMRoute struct {
+	Model       string   `yaml:"model" env:"LLM_MODEL"`
+	BaseURL     string   `yaml:"base_url" env:"LLM_BASE_URL"`
+	APIKey      string   `yaml:"api_key" env:"LLM_API_KEY" secret:"true"`
+	Temperature *float64 `yaml:"temperature" env:"LLM_TEMPERATURE" check:"0..2"`
+	TopP        *float64 `yaml:"top_p" env:"LLM_TOP_P" check:"0..1"`
+}
+
+// llmRoles are the keys of llm.roles (see llm.ModelRoles).
+var llmRoles = []string{"decide", "think", "answer", "summarize", "review"}
+
+// BudgetsSection bounds agent runs (AGENT_*).
+type BudgetsSection struct {
+	MaxSteps           *int     `yaml:"max_steps" env:"AGENT_MAX_STEPS" check:"5..200"`
+	MaxTokens          *int     `yaml:"max_tokens" env:"AGENT_MAX_TOKENS" check:"0.."`
+	MaxDurationMinutes *int     `yaml:"max_duration_minutes" env:"AGENT_MAX_DURATION_MINUTES" check:"0.."`
+	TimeoutMinutes     *int     `yaml:"timeout_minutes" env:"AGENT_TIMEOUT_MINUTES" check:"1..30"`
+	MaxConcurrentRuns  *int     `yaml:"max_concurrent_runs" env:"AGENT_MAX_CONCURRENT_RUNS" check:"0.."`
+	MaxQueuedRuns      *int     `yaml:"max_queued_runs" env:"AGENT_MAX_QUEUED_RUNS" check:"0.."`
+	CompactRatio       *float64 `yaml:"compact_ratio" env:"AGENT_COMPACT_RATIO" check:"0..1"`
+	DownshiftRatio     *float64 `yaml:"downshift_ratio" env:"AGENT_DOWNSHIFT_RATIO" check:"0..1"`
+	AskTimeoutSeconds  *int     `yaml:"ask_timeout_seconds" env:"AGENT_ASK_TIMEOUT_SECONDS" check:"0.."`
+	PlanConfirm        *bool    `yaml:"plan_confirm" env:"AGENT_PLAN_CONFIRM"`
+}
+
+// SessionsSection configures conversation memory and run checkpoints.
+type SessionsSection struct {
+	TTLMinutes  *int  `yaml:"ttl_minutes" env:"SESSION_TTL_MINUTES" check:"1.."`
+	MaxTurns    *int  `yaml:"max_turns" env:"SESSION_MAX_TURNS" check:"1.."`
+	Checkpoints *bool `yaml:"checkpoints" env:"AGENT_CHECKPOINTS"`
+}
+
+// ToolsSection configures the built-in tools (TOOL_*) and search APIs.
+type ToolsSection struct {
+	ShellEnabled    *bool  `yaml:"shell_enabled" env:"TOOL_SHELL_ENABLED"`
+	HTTPEnabled     *bool  `yaml:"http_enabled" env:"TOOL_HTTP_ENABLED"`
+	PythonEnabled   *bool  `yaml:"python_enabled" env:"TOOL_PYTHON_ENABLED"`
+	GitOpsEnabled   *bool  `yaml:"git_ops_enabled" env:"TOOL_GIT_OPS_ENABLED"`
+	EnvSetEnabled   *bool  `yaml:"env_set_enabled" env:"TOOL_ENV_SET_ENABLED"`
+	EnvSetAllow     string `yaml:"env_set_allow" env:"TOOL_ENV_SET_ALLOW"`
+	RateLimits      string `yaml:"rate_limits" env:"TOOL_RATE_LIMITS"`
+	Timeouts        string `yaml:"timeouts" env:"TOOL_TIMEOUTS"`
+	CacheTTLs       string `yaml:"cache_ttls" env:"TOOL_CACHE_TTLS"`
+	CacheMaxEntries *int   `yaml:"cache_max_entries" env:"TOOL_CACHE_MAX_ENTRIES" check:"1.."`
+	ProfilesPath    string `yaml:"profiles_path" env:"TOOL_PROFILES_PATH"`
+	PermissionsPath string `yaml:"permissions_path" env:"TOOL_PERMISSIONS_PATH"`
+	UserHeader      string `yaml:"permissions_user_header" env:"TOOL_PERMISSIONS_USER_HEADER"`
+	AuditLog        *bool  `yaml:"audit_log" env:"AUDIT_LOG"`
+	AuditDir        string `yaml:"audit_dir" env:"AUDIT_DIR"`
+	AuditMaxSizeMB  *int   `yaml:"audit_max_size_mb" env:"AUDIT_MAX_SIZE_MB" check:"0.."`
+	AuditMaxFiles   *int   `yaml:"audit_max_files" env:"AUDIT_MAX_FILES" check:"0.."`
+	DocOCRCommand   string `yaml:"doc_ocr_command" env:"TOOL_DOC_OCR_COMMAND"`
+	WebUserAgent    string `yaml:"web_reader_user_agent" env:"TOOL_WEB_READER_USER_AGENT"`
+	WebRobots       *bool  `yaml:"web_reader_robots" env:"TOOL_WEB_READER_ROBOTS"`
+	WebHostInterval *int   `yaml:"web_reader_host_interval_ms" env:"TOOL_WEB_READER_HOST_INTERVAL_MS" check:"0.."`
+	WebMaxKB        *int   `yaml:"web_reader_max_kb" env:"TOOL_WEB_READER_MAX_KB" check:"1.."`
+	WebBrowser      string `yaml:"web_reader_browser" env:"TOOL_WEB_READER_BROWSER"`
+	WebBrowserPool  *int   `yaml:"web_reader_browser_pool" env:"TOOL_WEB_READER_BROWSER_POOL" check:"1.."`
+	WebNoSandbox    *bool  `yaml:"web_reader_browser_no_sandbox" env:"TOOL_WEB_READER_BROWSER_NO_SANDBOX"`
+	TavilyAPIKey    string `yaml:"tavily_api_key" env:"TAVILY_API_KEY" secret:"true"`
+	BraveAPIKey     string `yaml:"brave_api_key" env:"BRAVE_API_KEY" secret:"true"`
+}
+
+// MCPSection configures MCP servers.
+type MCPSection struct {
+	Config             string `yaml:"config" env:"MCP_CONFIG"`
+	PerCallIdleSeconds *int   `yaml:"per_call_idle_seconds" env:"MCP_PER_CALL_IDLE_SECONDS" check:"0.."`
+	SkillVersions      *int   `yaml:"skill_versions" env:"MCP_SKILL_VERSIONS" check:"0.."`
+}
+
+// PromptsSection configures prompt files.
+type PromptsSection struct {
+	Dir           string `yaml:"dir" env:"PROMPTS_DIR"`
+	Watch         *bool  `yaml:"watch" env:"PROMPTS_WATCH"`
+	SoulPath      string `yaml:"soul_path" env:"SOUL_PATH"`
+	UserRulesPath string `yaml:"user_rules_path" env:"USER_RULES_PATH"`
+	Brief         *bool  `yaml:"project_brief" env:"AGENT_PROJECT_BRIEF"`
+	BriefPath     string `yaml:"project_brief_path" env:"PROJECT_BRIEF_PATH"`
+}
+
+// WebSection configures the server.
+type WebSection struct {
+	Host         string `yaml:"host" env:"WEB_HOST"`
+	Port         *int   `yaml:"port" env:"WEB_PORT" check:"1..65535"`
+	ReadOnly     *bool  `yaml:"read_only" env:"OMEGA_READ_ONLY"`
+	UILocale     string `yaml:"ui_locale" env:"UI_LOCALE"`
+	WorkspaceDir string `yaml:"workspace_dir" env:"WORKSPACE_DIR"`
+	GRPCAddr     string `yaml:"grpc_addr" env:"GRPC_ADDR"`
+}
+
+// setting is one leaf of the schema.
+type setting struct {
+	key    string        // YAML path, e.g. "llm.model"
+	env    string        // environment variable
+	secret bool          // redacted by Effective
+	check  string        // check tag
+	value  reflect.Value // the field
+}
+
+// settings flattens f into its leaves, in schema order; the role routes
+// of llm.roles are listed for every role in llmRoles order.
+func (f *File) settings() []setting {
+	var out []setting
+	var walk func(prefix string, v reflect.Value, envSuffix string)
+	walk = func(prefix string, v reflect.Value, envSuffix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+			switch {
+			case field.Type.Kind() == reflect.Struct:
+				walk(key+".", v.Field(i), envSuffix)
+			case field.Type.Kind() == reflect.Map:
+				routes := v.Field(i).Interface().(map[string]LLMRoute)
+				for _, role := range llmRoles {
+					route := routes[role]
+					walk(key+"."+role+".", reflect.ValueOf(&route).Elem(), "_"+strings.ToUpper(role))
+				}
+			default:
+				out = append(out, setting{
+					key:    key,
+					env:    field.Tag.Get("env") + envSuffix,
+					secret: field.Tag.Get("secret") == "true",
+					check:  field.Tag.Get("check"),
+					value:  v.Field(i),
+				})
+			}
+		}
+	}
+	walk("", reflect.ValueOf(f).Elem(), "")
+	return out
+}
+
+// String renders the value as an environment variable; "" when unset.
+func (s setting) String() string {
+	v := s.value
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// validate applies the check tag to a set value.
+func (s setting) validate() error {
+	val := s.String()
+	if val == "" || s.check == "" {
+		return nil
+	}
+	if lo, hi, ok := strings.Cut(s.check, ".."); ok {
+		n, _ := strconv.ParseFloat(val, 64)
+		if lo != "" {
+			if min, _ := strconv.ParseFloat(lo, 64); n < min {
+				return fmt.Errorf("%s: %s is below the minimum %s", s.key, val, lo)
+			}
+		}
+		if hi != "" {
+			if max, _ := strconv.ParseFloat(hi, 64); n > max {
+				return fmt.Errorf("%s: %s is above the maximum %s", s.key, val, hi)
+			}
+		}
+		return nil
+	}
+	for _, allowed := range strings.Split(s.check, "|") {
+		if val == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %q is not one of %s", s.key, val, strings.ReplaceAll(s.check, "|", ", "))
+}
+
+// Validate checks every set value against the schema and reports all
+// problems at once.
+func (f *File) Validate() error {
+	var errs []error
+	for role := range f.LLM.Roles {
+		if !slices.Contains(llmRoles, role) {
+			errs = append(errs, fmt.Errorf("llm.roles: unknown role %q (want one of %s)", role, strings.Join(llmRoles, ", ")))
+		}
+	}
+	for _, s := range f.settings() {
+		if err := s.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Parse decodes and validates an omega.yaml document. Unknown keys are
+// errors, so a typo does not silently fall back to a default.
+func Parse(data []byte) (*File, error) {
+	f := &File{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Loaded is a config file applied to the environment.
+type Loaded struct {
+	Path    string
+	File    *File
+	applied map[string]bool // env vars set from the file
+}
+
+// LoadFile finds omega.yaml (OMEGA_CONFIG, or FileName next to the
+// executable or in the working directory), validates it and exports its
+// settings to the environment. Variables already set — in the environment
+// or by .env, so call LoadEnv first — are left alone. Returns nil without
+// error when there is no config file; an invalid file is an error.
+func LoadFile() (*Loaded, error) {
+	path := findFile()
+	if path == "" {
+		return nil, nil
+	}
+	f, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &Loaded{Path: path, File: f, applied: map[string]bool{}}
+	for _, s := range f.settings() {
+		val := s.String()
+		if val == "" {
+			continue
+		}
+		if _, set := os.LookupEnv(s.env); set {
+			log.Printf("[Config] %s: %s is set in the environment and overrides the file", s.key, s.env)
+			continue
+		}
+		os.Setenv(s.env, val)
+		l.applied[s.env] = true
+	}
+	log.Printf("[Config] Loaded %s (%d settings applied)", path, len(l.applied))
+	return l, nil
+}
+
+// findFile returns the path of the config file: OMEGA_CONFIG, or the
+// first FileName candidate that exists ("" when there is none).
+func findFile() string {
+	if path := os.Getenv("OMEGA_CONFIG"); path != "" {
+		return path
+	}
+	for _, p := range resolveCandidates(FileName) {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// readFile reads and validates the config file at path.
+func readFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return f, nil
+}
+
+// Applied returns the number of settings the file exported.
+func (l *Loaded) Applied() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.applied)
+}
+
+// Setting is the effective value of one schema setting, as served by
+// /api/config. Secrets are redacted.
+type Setting struct {
+	Key    string `json:"key"`
+	Env    string `json:"env"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // "file", "env" or "default" (unset)
+}
+
+// Effective lists every schema setting with its current value and where
+// it came from. l may be nil (no config file).
+func Effective(l *Loaded) []Setting {
+	var out []Setting
+	for _, s := range (&File{}).settings() {
+		st := Setting{Key: s.key, Env: s.env, Value: os.Getenv(s.env), Source: "env"}
+		switch {
+		case st.Value == "":
+			st.Source = "default"
+		case l != nil && l.applied[s.env]:
+			st.Source = "file"
+		}
+		if s.secret && st.Value != "" {
+			st.Value = "[REDACTED]"
+		}
+		out = append(out, st)
+	}
+	return out
+}
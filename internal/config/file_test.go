@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse_Validation(t *testing.T) {
+	f, err := Parse([]byte("llm:\n  model: gpt-4o\n  temperature: 0.2\n  roles:\n    summarize:\n      model: gpt-4o-mini\nbudgets:\n  max_steps: 40\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.LLM.Model != "gpt-4o" || *f.LLM.Temperature != 0.2 || *f.Budgets.MaxSteps != 40 {
+		t.Errorf("parsed %+v", f)
+	}
+
+	for name, doc := range map[string]string{
+		"unknown key":  "llm:\n  modle: gpt-4o\n",
+		"wrong type":   "budgets:\n  max_steps: many\n",
+		"below range":  "budgets:\n  max_steps: 2\n",
+		"above range":  "llm:\n  temperature: 3\n",
+		"not in enum":  "llm:\n  tool_call_mode: xml\n",
+		"unknown role": "llm:\n  roles:\n    planner:\n      model: x\n",
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Errorf("%s: Parse should fail", name)
+		}
+	}
+
+	// All problems are reported at once.
+	_, err = Parse([]byte("budgets:\n  max_steps: 2\n  timeout_minutes: 99\n"))
+	if err == nil || !strings.Contains(err.Error(), "budgets.max_steps") || !strings.Contains(err.Error(), "budgets.timeout_minutes") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParse_ExampleFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "omega.example.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(data); err != nil {
+		t.Errorf("omega.example.yaml: %v", err)
+	}
+}
+
+func TestLoadFile_EnvOverridesAndEffective(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "omega.yaml")
+	doc := "llm:\n  model: from-file\n  api_key: sk-secret\n  roles:\n    review:\n      model: reviewer\nsessions:\n  max_turns: 7\n  checkpoints: false\n"
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("OMEGA_CONFIG", path)
+	t.Setenv("LLM_MODEL", "from-env")
+	for _, name := range []string{"LLM_API_KEY", "LLM_MODEL_REVIEW", "SESSION_MAX_TURNS", "AGENT_CHECKPOINTS", "AGENT_MAX_STEPS"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	l, err := LoadFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"LLM_MODEL":         "from-env", // the environment wins
+		"LLM_MODEL_REVIEW":  "reviewer",
+		"SESSION_MAX_TURNS": "7",
+		"AGENT_CHECKPOINTS": "false",
+	} {
+		if got := os.Getenv(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if l.Applied() != 4 {
+		t.Errorf("Applied = %d, want 4", l.Applied())
+	}
+
+	sources := map[string]Setting{}
+	for _, s := range Effective(l) {
+		sources[s.Key] = s
+	}
+	for key, want := range map[string]string{
+		"llm.model":              "env",
+		"llm.api_key":            "file",
+		"llm.roles.review.model": "file",
+		"budgets.max_steps":      "default",
+	} {
+		if got := sources[key].Source; got != want {
+			t.Errorf("%s source = %q, want %q", key, got, want)
+		}
+	}
+	if v := sources["llm.api_key"].Value; v != "[REDACTED]" {
+		t.Errorf("api_key value = %q, want redacted", v)
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "omega.yaml")
+	os.WriteFile(path, []byte("web:\n  port: 0\n"), 0o644)
+	t.Setenv("OMEGA_CONFIG", path)
+	if _, err := LoadFile(); err == nil || !strings.Contains(err.Error(), "web.port") {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/joho/godotenv"
+)
+
+// Reload re-reads the .env file applied by LoadEnv and the config file and
+// brings the environment in line with them: changed values are updated and
+// variables removed from both files are unset. prev is the result of
+// LoadFile or the previous Reload (nil when there was no config file).
+// Variables of the process environment keep precedence, as at startup.
+//
+// Both files are read and validated before anything changes, so an invalid
+// file leaves the environment as it was. Returns the new Loaded (nil when
+// there is no config file) and the sorted names of the changed variables.
+func Reload(prev *Loaded) (*Loaded, []string, error) {
+	dotenv.Lock()
+	defer dotenv.Unlock()
+
+	var vars map[string]string
+	if dotenv.path != "" {
+		var err error
+		vars, err = godotenv.Read(dotenv.path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, err
+		}
+	}
+	var l *Loaded
+	if path := findFile(); path != "" {
+		f, err := readFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		l = &Loaded{Path: path, File: f, applied: map[string]bool{}}
+	}
+
+	// Variables set by either file last time are ours to change or unset.
+	managed := map[string]bool{}
+	for k := range dotenv.keys {
+		managed[k] = true
+	}
+	if prev != nil {
+		for k := range prev.applied {
+			managed[k] = true
+		}
+	}
+	external := func(k string) bool {
+		_, set := os.LookupEnv(k)
+		return set && !managed[k]
+	}
+
+	want := map[string]string{}
+	keys := map[string]bool{}
+	for k, v := range vars {
+		if external(k) {
+			continue
+		}
+		want[k] = v
+		keys[k] = true
+	}
+	if l != nil {
+		for _, s := range l.File.settings() {
+			val := s.String()
+			if val == "" {
+				continue
+			}
+			if _, ok := want[s.env]; ok || external(s.env) {
+				continue // .env and the environment override the file
+			}
+			want[s.env] = val
+			l.applied[s.env] = true
+		}
+	}
+
+	var changed []string
+	for k, v := range want {
+		if cur, set := os.LookupEnv(k); !set || cur != v {
+			os.Setenv(k, v)
+			changed = append(changed, k)
+		}
+	}
+	for k := range managed {
+		if _, ok := want[k]; !ok {
+			os.Unsetenv(k)
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	dotenv.keys = keys
+	log.Printf("[Config] Reloaded (%d variables changed)", len(changed))
+	return l, changed, nil
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	cfgPath := filepath.Join(dir, "omega.yaml")
+	write := func(path, doc string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(envPath, "LLM_MODEL=gpt-4o\nBRAVE_API_KEY=bk-1\n")
+	write(cfgPath, "budgets:\n  max_tokens: 1000\n  max_steps: 30\n")
+	t.Setenv("OMEGA_CONFIG", cfgPath)
+	t.Setenv("AGENT_MAX_STEPS", "50") // process environment: never touched
+	for _, name := range []string{"LLM_MODEL", "BRAVE_API_KEY", "TAVILY_API_KEY", "AGENT_MAX_TOKENS"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Cleanup(func() { dotenv.path, dotenv.keys = "", nil })
+
+	if err := loadDotenv(envPath); err != nil {
+		t.Fatal(err)
+	}
+	l, err := LoadFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write(envPath, "LLM_MODEL=gpt-4.1\nTAVILY_API_KEY=tv-1\n")
+	write(cfgPath, "budgets:\n  max_tokens: 2000\n  max_steps: 30\n")
+	l, changed, err := Reload(l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"AGENT_MAX_TOKENS", "BRAVE_API_KEY", "LLM_MODEL", "TAVILY_API_KEY"}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	for name, want := range map[string]string{
+		"LLM_MODEL":        "gpt-4.1",
+		"TAVILY_API_KEY":   "tv-1",
+		"AGENT_MAX_TOKENS": "2000",
+		"AGENT_MAX_STEPS":  "50",
+	} {
+		if got := os.Getenv(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if _, set := os.LookupEnv("BRAVE_API_KEY"); set {
+		t.Error("BRAVE_API_KEY removed from .env but still set")
+	}
+
+	// An invalid file changes nothing.
+	write(envPath, "LLM_MODEL=other\n")
+	write(cfgPath, "budgets:\n  max_tokens: -1\n")
+	if _, _, err := Reload(l); err == nil {
+		t.Fatal("Reload of an invalid file should fail")
+	}
+	if got := os.Getenv("LLM_MODEL"); got != "gpt-4.1" {
+		t.Errorf("LLM_MODEL = %q after a failed reload", got)
+	}
+}
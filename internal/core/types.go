@@ -12,7 +12,11 @@ const (
 	ActionDefault  Action = "default"
 
 	// Agent routing actions (Phase 2)
-	ActionTool   Action = "tool"
-	ActionThink  Action = "think"
-	ActionAnswer Action = "answer"
+	ActionTool    Action = "tool"
+	ActionThink   Action = "think"
+	ActionAnswer  Action = "answer"
+	ActionCompact Action = "compact"
+	ActionReview  Action = "review"
+	ActionAsk     Action = "ask"
+	ActionVerify  Action = "verify"
 )
@@ -0,0 +1,132 @@
+// Package editor links workspace files to the user's editor, either as a
+// URL the web UI opens (VS Code / Cursor URL scheme, the JetBrains built-in
+// server) or as a local command started on the server host ($EDITOR or an
+// explicit launcher such as `code -g`).
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Kind selects how files are opened.
+type Kind string
+
+const (
+	KindVSCode    Kind = "vscode"    // vscode://file/<path>:<line>
+	KindCursor    Kind = "cursor"    // cursor://file/<path>:<line>
+	KindJetBrains Kind = "jetbrains" // http://localhost:63342/api/file?file=<path>&line=<line>
+	KindCommand   Kind = "command"   // local command, see Editor.Command
+)
+
+// jetBrainsURL is the built-in web server of JetBrains IDEs (default port).
+const jetBrainsURL = "http://localhost:63342/api/file"
+
+// ErrNotLocal is returned by Open for URL editors: the link must be opened
+// by the browser, which runs on the user's machine.
+var ErrNotLocal = errors.New("editor opens through a URL, not a local command")
+
+// Editor is the configured editor integration.
+type Editor struct {
+	Kind Kind
+	// Command is the argv template for KindCommand; "{file}" and "{line}"
+	// are substituted in each argument.
+	Command []string
+}
+
+// LoadFromEnv returns the editor configured from the environment, or nil
+// when EDITOR_INTEGRATION is unset or "off".
+//
+//	EDITOR_INTEGRATION   vscode | cursor | jetbrains | command | off (default: off)
+//	EDITOR_COMMAND       argv template for "command", e.g. `code -g {file}:{line}`
+//	                     (default: `$VISUAL +{line} {file}`, or $EDITOR)
+func LoadFromEnv() (*Editor, error) {
+	kind := Kind(strings.ToLower(strings.TrimSpace(os.Getenv("EDITOR_INTEGRATION"))))
+	switch kind {
+	case "", "off":
+		return nil, nil
+	case KindVSCode, KindCursor, KindJetBrains:
+		return &Editor{Kind: kind}, nil
+	case KindCommand:
+	default:
+		return nil, fmt.Errorf("editor: unknown EDITOR_INTEGRATION=%q (supported: vscode, cursor, jetbrains, command)", kind)
+	}
+
+	tmpl := strings.Fields(os.Getenv("EDITOR_COMMAND"))
+	if len(tmpl) == 0 {
+		ed := strings.TrimSpace(os.Getenv("VISUAL"))
+		if ed == "" {
+			ed = strings.TrimSpace(os.Getenv("EDITOR"))
+		}
+		if ed == "" {
+			return nil, fmt.Errorf("editor: EDITOR_INTEGRATION=command needs EDITOR_COMMAND or $EDITOR")
+		}
+		tmpl = append(strings.Fields(ed), "+{line}", "{file}")
+	}
+	if _, err := exec.LookPath(tmpl[0]); err != nil {
+		return nil, fmt.Errorf("editor: command %q not found", tmpl[0])
+	}
+	return &Editor{Kind: KindCommand, Command: tmpl}, nil
+}
+
+// URL returns the link that opens absPath at line (1-based; < 1 = top) in
+// the editor, or "" for KindCommand.
+func (e *Editor) URL(absPath string, line int) string {
+	if line < 1 {
+		line = 1
+	}
+	p := filepath.ToSlash(absPath)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows drive path: vscode://file/C:/...
+	}
+	switch e.Kind {
+	case KindVSCode, KindCursor:
+		u := url.URL{Scheme: string(e.Kind), Host: "file", Path: p + ":" + strconv.Itoa(line)}
+		return u.String()
+	case KindJetBrains:
+		return jetBrainsURL + "?" + url.Values{"file": {filepath.ToSlash(absPath)}, "line": {strconv.Itoa(line)}}.Encode()
+	}
+	return ""
+}
+
+// Open starts the editor command on absPath at line. URL editors return
+// ErrNotLocal.
+func (e *Editor) Open(absPath string, line int) error {
+	if e.Kind != KindCommand {
+		return ErrNotLocal
+	}
+	name, args := e.argv(absPath, line)
+	cmd := exec.Command(name, args...)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }() // reap the child; GUI editors outlive the request
+	return nil
+}
+
+// argv expands the command template.
+func (e *Editor) argv(absPath string, line int) (string, []string) {
+	if line < 1 {
+		line = 1
+	}
+	r := strings.NewReplacer("{file}", absPath, "{line}", strconv.Itoa(line))
+	out := make([]string, len(e.Command))
+	for i, a := range e.Command {
+		out[i] = r.Replace(a)
+	}
+	return out[0], out[1:]
+}
+
+// String returns a short description for startup logs.
+func (e *Editor) String() string {
+	if e.Kind == KindCommand {
+		return "command: " + strings.Join(e.Command, " ")
+	}
+	return string(e.Kind)
+}
@@ -0,0 +1,75 @@
+package editor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditor_URL(t *testing.T) {
+	cases := []struct {
+		kind Kind
+		path string
+		line int
+		want string
+	}{
+		{KindVSCode, "/work/src/main.go", 42, "vscode://file/work/src/main.go:42"},
+		{KindCursor, "/work/main.go", 0, "cursor://file/work/main.go:1"},
+		{KindVSCode, "/work/my notes.md", 3, "vscode://file/work/my%20notes.md:3"},
+		{KindJetBrains, "/work/src/main.go", 7, "http://localhost:63342/api/file?file=%2Fwork%2Fsrc%2Fmain.go&line=7"},
+		{KindCommand, "/work/main.go", 1, ""},
+	}
+	for _, tc := range cases {
+		if got := (&Editor{Kind: tc.kind}).URL(tc.path, tc.line); got != tc.want {
+			t.Errorf("%s URL(%q, %d) = %q, want %q", tc.kind, tc.path, tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestEditor_Argv(t *testing.T) {
+	e := &Editor{Kind: KindCommand, Command: []string{"code", "-g", "{file}:{line}"}}
+	name, args := e.argv("/work/main.go", 9)
+	if name != "code" || !reflect.DeepEqual(args, []string{"-g", "/work/main.go:9"}) {
+		t.Errorf("argv = %s %v", name, args)
+	}
+	if err := (&Editor{Kind: KindVSCode}).Open("/work/main.go", 1); err != ErrNotLocal {
+		t.Errorf("URL editor Open err = %v, want ErrNotLocal", err)
+	}
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv("EDITOR_INTEGRATION", "")
+	if e, err := LoadFromEnv(); e != nil || err != nil {
+		t.Fatalf("disabled: %v, %v", e, err)
+	}
+
+	t.Setenv("EDITOR_INTEGRATION", "VSCode")
+	if e, err := LoadFromEnv(); err != nil || e.Kind != KindVSCode {
+		t.Errorf("vscode: %v, %v", e, err)
+	}
+
+	t.Setenv("EDITOR_INTEGRATION", "command")
+	t.Setenv("EDITOR_COMMAND", "")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "sh -e")
+	e, err := LoadFromEnv()
+	if err != nil || !reflect.DeepEqual(e.Command, []string{"sh", "-e", "+{line}", "{file}"}) {
+		t.Errorf("$EDITOR: %v, %v", e, err)
+	}
+
+	t.Setenv("EDITOR_COMMAND", "no-such-editor-binary {file}")
+	if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("missing command: err = %v", err)
+	}
+
+	t.Setenv("EDITOR_COMMAND", "")
+	t.Setenv("EDITOR", "")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("command without EDITOR_COMMAND or $EDITOR accepted")
+	}
+
+	t.Setenv("EDITOR_INTEGRATION", "emacs-server")
+	if _, err := LoadFromEnv(); err == nil {
+		t.Error("unknown integration accepted")
+	}
+}
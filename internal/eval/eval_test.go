@@ -0,0 +1,158 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+)
+
+// scriptedProvider replays canned responses in order; the last one repeats.
+type scriptedProvider struct {
+	mu        sync.Mutex
+	responses []string
+	i         int
+}
+
+func (p *scriptedProvider) next() llm.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r := p.responses[min(p.i, len(p.responses)-1)]
+	p.i++
+	return llm.Message{Role: llm.RoleAssistant, Content: r}
+}
+
+func (p *scriptedProvider) CallLLM(_ context.Context, _ []llm.Message) (llm.Message, error) {
+	return p.next(), nil
+}
+func (p *scriptedProvider) CallLLMStream(_ context.Context, _ []llm.Message, _ llm.StreamCallback) (llm.Message, error) {
+	return p.next(), nil
+}
+func (p *scriptedProvider) CallLLMWithTools(_ context.Context, _ []llm.Message, _ []llm.ToolDefinition) (llm.Message, error) {
+	return p.next(), nil
+}
+func (p *scriptedProvider) IsToolCallingEnabled() bool { return false }
+
+func writeFixture(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTasks(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "b.yaml", "prompt: 读取 a.txt\nfiles:\n  a.txt: hello\nexpect:\n  answer_contains: [hello]\n")
+	writeFixture(t, dir, "a.json", `{"name":"alpha","prompt":"hi"}`)
+	writeFixture(t, dir, "notes.md", "ignored")
+
+	tasks, err := LoadTasks(dir)
+	if err != nil {
+		t.Fatalf("LoadTasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].Name != "alpha" || tasks[1].Name != "b" {
+		t.Fatalf("tasks = %+v", tasks)
+	}
+	if tasks[1].Files["a.txt"] != "hello" || tasks[1].Expect.AnswerContains[0] != "hello" {
+		t.Errorf("fixture fields not parsed: %+v", tasks[1])
+	}
+}
+
+func TestLoadTask_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"noprompt.yaml": "name: x\n",
+		"escape.yaml":   "prompt: p\nfiles:\n  ../evil.txt: x\n",
+		"badre.yaml":    "prompt: p\nexpect:\n  answer_matches: \"(\"\n",
+	} {
+		writeFixture(t, dir, name, content)
+		if _, err := LoadTask(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestExpect_Check(t *testing.T) {
+	ws := t.TempDir()
+	writeFixture(t, ws, "out.txt", "result=42")
+	steps := []agent.StepRecord{{Type: "tool", ToolName: "file_read"}, {Type: "answer"}}
+
+	e := Expect{
+		AnswerContains:    []string{"FORTY"},
+		AnswerNotContains: []string{"error"},
+		AnswerMatches:     `\d+`,
+		ToolsUsed:         []string{"file_read"},
+		ToolsNotUsed:      []string{"shell_exec"},
+		MaxSteps:          2,
+		FilesContain:      map[string]string{"out.txt": "42"},
+	}
+	if f := e.check("forty-two = 42", steps, ws); len(f) != 0 {
+		t.Errorf("expected pass, got %v", f)
+	}
+
+	e = Expect{ToolsUsed: []string{"file_write"}, MaxSteps: 1, FilesContain: map[string]string{"missing.txt": "x"}}
+	f := e.check("ok", steps, ws)
+	if len(f) != 3 {
+		t.Errorf("expected 3 failures, got %v", f)
+	}
+}
+
+func TestRunner_ComparesVariants(t *testing.T) {
+	task := Task{
+		Name:   "read",
+		Prompt: "a.txt 里写了什么？",
+		Files:  map[string]string{"a.txt": "hello eval"},
+		Expect: Expect{AnswerContains: []string{"hello eval"}, ToolsUsed: []string{"file_read"}},
+	}
+	readThenAnswer := func(answer string) *scriptedProvider {
+		return &scriptedProvider{responses: []string{
+			`{"action":"tool","reason":"读取文件","tool_name":"file_read","tool_params":{"path":"a.txt"}}`,
+			`{"action":"answer","reason":"完成","answer":"` + answer + `"}`,
+			answer, // AnswerNode synthesis call after tool use
+		}}
+	}
+	variants := []Variant{
+		{Name: "A", Provider: readThenAnswer("文件内容是 hello eval"), ThinkingMode: "native", ToolCallMode: "json"},
+		{Name: "B", Provider: readThenAnswer("不知道"), ThinkingMode: "native", ToolCallMode: "json"},
+	}
+	runner := NewRunner(Options{Tools: func(ws string) *tool.Registry {
+		reg := tool.NewRegistry()
+		reg.Register(builtin.NewFileReadTool(ws))
+		return reg
+	}})
+
+	rep, err := runner.Run(context.Background(), []Task{task}, variants)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(rep.Outcomes) != 2 {
+		t.Fatalf("outcomes = %d, want 2", len(rep.Outcomes))
+	}
+	a, b := rep.Outcomes[0], rep.Outcomes[1]
+	if !a.Passed || a.ToolCalls != 1 || a.Tokens == 0 || a.LLMCalls != 3 {
+		t.Errorf("A outcome = %+v", a)
+	}
+	if b.Passed {
+		t.Errorf("B should fail its assertion: %+v", b)
+	}
+	if reg := rep.Regressions("A", "B"); len(reg) != 1 || reg[0] != "read" {
+		t.Errorf("Regressions = %v", reg)
+	}
+
+	var buf bytes.Buffer
+	rep.WriteMarkdown(&buf)
+	out := buf.String()
+	for _, want := range []string{"| A | 1/1 (100%)", "| B | 0/1 (0%)", "answer missing \"hello eval\"", "B regresses vs A on: read"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report collects all outcomes of an experiment.
+type Report struct {
+	Variants []string  `json:"variants"`
+	Tasks    []string  `json:"tasks"`
+	Runs     int       `json:"runs"`
+	Outcomes []Outcome `json:"outcomes"`
+}
+
+// Stats aggregates the runs of one task (or all tasks) for one variant.
+type Stats struct {
+	Runs      int     `json:"runs"`
+	Passed    int     `json:"passed"`
+	AvgSteps  float64 `json:"avg_steps"`
+	AvgTokens float64 `json:"avg_tokens"`
+	AvgMs     float64 `json:"avg_ms"`
+}
+
+// PassRate returns Passed/Runs (0 when there are no runs).
+func (s Stats) PassRate() float64 {
+	if s.Runs == 0 {
+		return 0
+	}
+	return float64(s.Passed) / float64(s.Runs)
+}
+
+// Stats aggregates outcomes for a variant; task == "" aggregates all tasks.
+func (r *Report) Stats(variant, task string) Stats {
+	var s Stats
+	var steps, tokens, ms int64
+	for _, o := range r.Outcomes {
+		if o.Variant != variant || (task != "" && o.Task != task) {
+			continue
+		}
+		s.Runs++
+		if o.Passed {
+			s.Passed++
+		}
+		steps += int64(o.Steps)
+		tokens += int64(o.Tokens)
+		ms += o.DurationMs
+	}
+	if s.Runs > 0 {
+		s.AvgSteps = float64(steps) / float64(s.Runs)
+		s.AvgTokens = float64(tokens) / float64(s.Runs)
+		s.AvgMs = float64(ms) / float64(s.Runs)
+	}
+	return s
+}
+
+// Regressions returns tasks whose pass count under variant b is lower than
+// under variant a.
+func (r *Report) Regressions(a, b string) []string {
+	var out []string
+	for _, t := range r.Tasks {
+		if r.Stats(b, t).Passed < r.Stats(a, t).Passed {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// WriteMarkdown renders the comparison report. The first variant is the
+// baseline; deltas for the others are relative to it.
+func (r *Report) WriteMarkdown(w io.Writer) {
+	if len(r.Variants) == 0 {
+		return
+	}
+	base := r.Variants[0]
+
+	fmt.Fprintf(w, "# Prompt A/B eval\n\n")
+	fmt.Fprintf(w, "%d task(s) × %d run(s), baseline: **%s**\n\n", len(r.Tasks), r.Runs, base)
+
+	// Summary table: one row per variant.
+	fmt.Fprintln(w, "## Summary")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| variant | pass | avg steps | avg tokens | avg time |")
+	fmt.Fprintln(w, "|---|---|---|---|---|")
+	baseAll := r.Stats(base, "")
+	for _, v := range r.Variants {
+		s := r.Stats(v, "")
+		row := fmt.Sprintf("| %s | %d/%d (%.0f%%) | %.1f | %.0f | %.1fs |",
+			v, s.Passed, s.Runs, s.PassRate()*100, s.AvgSteps, s.AvgTokens, s.AvgMs/1000)
+		if v != base {
+			row = fmt.Sprintf("| %s | %d/%d (%.0f%%) | %.1f (%s) | %.0f (%s) | %.1fs |",
+				v, s.Passed, s.Runs, s.PassRate()*100,
+				s.AvgSteps, delta(s.AvgSteps, baseAll.AvgSteps),
+				s.AvgTokens, delta(s.AvgTokens, baseAll.AvgTokens), s.AvgMs/1000)
+		}
+		fmt.Fprintln(w, row)
+	}
+
+	// Per-task table: pass/steps/tokens per variant side by side.
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "## Tasks")
+	fmt.Fprintln(w)
+	header := "| task |"
+	sep := "|---|"
+	for _, v := range r.Variants {
+		header += fmt.Sprintf(" %s pass | %s steps | %s tokens |", v, v, v)
+		sep += "---|---|---|"
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, sep)
+	for _, t := range r.Tasks {
+		row := "| " + t + " |"
+		for _, v := range r.Variants {
+			s := r.Stats(v, t)
+			row += fmt.Sprintf(" %d/%d | %.1f | %.0f |", s.Passed, s.Runs, s.AvgSteps, s.AvgTokens)
+		}
+		fmt.Fprintln(w, row)
+	}
+
+	// Failure details, grouped by task.
+	var failures []string
+	for _, o := range r.Outcomes {
+		if o.Passed {
+			continue
+		}
+		reasons := o.Failures
+		if o.Error != "" {
+			reasons = append([]string{o.Error}, reasons...)
+		}
+		failures = append(failures, fmt.Sprintf("- %s / %s (run %d): %s", o.Task, o.Variant, o.Run, strings.Join(reasons, "; ")))
+	}
+	if len(failures) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "## Failures")
+		fmt.Fprintln(w)
+		for _, f := range failures {
+			fmt.Fprintln(w, f)
+		}
+	}
+
+	for _, v := range r.Variants[1:] {
+		if reg := r.Regressions(base, v); len(reg) > 0 {
+			fmt.Fprintf(w, "\n⚠️ %s regresses vs %s on: %s\n", v, base, strings.Join(reg, ", "))
+		}
+	}
+}
+
+// delta formats the relative change of v against base, e.g. "-12%".
+func delta(v, base float64) string {
+	if base == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.0f%%", (v-base)/base*100)
+}
@@ -0,0 +1,221 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// Variant is one side of an A/B experiment: a provider (model) plus a
+// prompt-loader configuration and the agent modes to run with.
+type Variant struct {
+	Name          string
+	Provider      llm.LLMProvider
+	Loader        *prompt.PromptLoader // nil = hardcoded prompt defaults
+	ModelName     string
+	ThinkingMode  string // "app" or "native"
+	ToolCallMode  string // "auto", "fc", "yaml" or "json"
+	ContextWindow int    // tokens; 0 = agent fallback
+	OSName        string
+	ShellCmd      string
+	AnswerStyle   string // answer style profile; "" = default
+}
+
+// Options configures a Runner.
+type Options struct {
+	// Tools builds the tool registry for a fresh task workspace. Required.
+	Tools func(workspaceDir string) *tool.Registry
+	// Runs repeats every task×variant pair to smooth out sampling noise (default 1).
+	Runs int
+	// Timeout bounds a single agent run (default 5m).
+	Timeout time.Duration
+	// KeepWorkspaces leaves the temp workspaces on disk for inspection.
+	KeepWorkspaces bool
+	// Progress, when set, is called after each run completes.
+	Progress func(Outcome)
+}
+
+// Outcome is the result of one task run against one variant.
+type Outcome struct {
+	Task       string   `json:"task"`
+	Variant    string   `json:"variant"`
+	Run        int      `json:"run"`
+	Passed     bool     `json:"passed"`
+	Failures   []string `json:"failures,omitempty"`
+	Steps      int      `json:"steps"`
+	ToolCalls  int      `json:"tool_calls"`
+	Tokens     int      `json:"tokens"` // estimated input+output tokens across all LLM calls
+	LLMCalls   int      `json:"llm_calls"`
+	DurationMs int64    `json:"duration_ms"`
+	Answer     string   `json:"answer,omitempty"`
+	Workspace  string   `json:"workspace,omitempty"` // set when workspaces are kept
+	Error      string   `json:"error,omitempty"`     // infrastructure failure (not an assertion)
+}
+
+// Runner executes tasks against variants.
+type Runner struct {
+	opts Options
+}
+
+// NewRunner creates a Runner, applying defaults to zero-valued options.
+func NewRunner(opts Options) *Runner {
+	if opts.Runs <= 0 {
+		opts.Runs = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	return &Runner{opts: opts}
+}
+
+// Run executes every task against every variant (interleaved per task so
+// drift in the provider affects both sides equally) and returns the report.
+func (r *Runner) Run(ctx context.Context, tasks []Task, variants []Variant) (*Report, error) {
+	if r.opts.Tools == nil {
+		return nil, fmt.Errorf("eval: Options.Tools is required")
+	}
+	if len(variants) == 0 {
+		return nil, fmt.Errorf("eval: no variants")
+	}
+	rep := &Report{Runs: r.opts.Runs}
+	for _, v := range variants {
+		rep.Variants = append(rep.Variants, v.Name)
+	}
+	for _, t := range tasks {
+		rep.Tasks = append(rep.Tasks, t.Name)
+	}
+
+	for _, t := range tasks {
+		for run := 1; run <= r.opts.Runs; run++ {
+			for _, v := range variants {
+				if ctx.Err() != nil {
+					return rep, ctx.Err()
+				}
+				o := r.runOne(ctx, t, v, run)
+				rep.Outcomes = append(rep.Outcomes, o)
+				if r.opts.Progress != nil {
+					r.opts.Progress(o)
+				}
+			}
+		}
+	}
+	return rep, nil
+}
+
+// runOne runs a single task against a single variant in a fresh workspace.
+func (r *Runner) runOne(ctx context.Context, t Task, v Variant, run int) Outcome {
+	o := Outcome{Task: t.Name, Variant: v.Name, Run: run}
+
+	ws, err := os.MkdirTemp("", "omega-eval-")
+	if err != nil {
+		o.Error = fmt.Sprintf("create workspace: %v", err)
+		return o
+	}
+	if r.opts.KeepWorkspaces {
+		o.Workspace = ws
+	} else {
+		defer os.RemoveAll(ws)
+	}
+	if err := t.seed(ws); err != nil {
+		o.Error = fmt.Sprintf("seed workspace: %v", err)
+		return o
+	}
+
+	counter := &countingProvider{LLMProvider: v.Provider}
+	registry := r.opts.Tools(ws)
+	flow := agent.BuildAgentFlow(counter, registry, v.ThinkingMode, v.Loader)
+	state := &agent.AgentState{
+		Problem:             t.Prompt,
+		WorkspaceDir:        ws,
+		ToolRegistry:        registry,
+		ThinkingMode:        v.ThinkingMode,
+		ToolCallMode:        v.ToolCallMode,
+		ContextWindowTokens: v.ContextWindow,
+		OSName:              v.OSName,
+		ShellCmd:            v.ShellCmd,
+		AnswerStyle:         v.AnswerStyle,
+		ModelName:           v.ModelName,
+		ReadCache:           agent.NewReadCache(),
+	}
+
+	// Eval runs are background work: they yield to interactive chats on a
+	// shared scheduler.
+	runCtx, cancel := context.WithTimeout(llm.WithPriority(ctx, llm.PriorityBackground), r.opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	flow.Run(runCtx, state)
+	o.DurationMs = time.Since(start).Milliseconds()
+	registry.CloseAll()
+
+	o.Steps = len(state.StepHistory)
+	for _, s := range state.StepHistory {
+		if s.Type == "tool" {
+			o.ToolCalls++
+		}
+	}
+	o.Tokens = int(counter.tokens.Load())
+	o.LLMCalls = int(counter.calls.Load())
+	o.Answer = strings.TrimSpace(state.Solution)
+	if runCtx.Err() == context.DeadlineExceeded {
+		o.Error = fmt.Sprintf("timeout after %v", r.opts.Timeout)
+	}
+
+	o.Failures = t.Expect.check(o.Answer, state.StepHistory, ws)
+	o.Passed = o.Error == "" && len(o.Failures) == 0
+	log.Printf("[Eval] %s/%s run %d: passed=%v steps=%d tokens=%d", t.Name, v.Name, run, o.Passed, o.Steps, o.Tokens)
+	return o
+}
+
+// countingProvider wraps an LLMProvider and accumulates estimated token
+// usage and call counts. Estimates use the same heuristic as CostGuard.
+type countingProvider struct {
+	llm.LLMProvider
+	tokens atomic.Int64
+	calls  atomic.Int64
+}
+
+func (p *countingProvider) record(in []llm.Message, defs []llm.ToolDefinition, out llm.Message) {
+	n := 0
+	for _, m := range in {
+		n += agent.EstimateTokens(m.Content)
+		for _, tc := range m.ToolCalls {
+			n += agent.EstimateTokens(string(tc.Arguments))
+		}
+	}
+	for _, d := range defs {
+		n += agent.EstimateTokens(d.Name + d.Description + string(d.Parameters))
+	}
+	n += agent.EstimateTokens(out.Content)
+	for _, tc := range out.ToolCalls {
+		n += agent.EstimateTokens(tc.Name + string(tc.Arguments))
+	}
+	p.tokens.Add(int64(n))
+	p.calls.Add(1)
+}
+
+func (p *countingProvider) CallLLM(ctx context.Context, messages []llm.Message) (llm.Message, error) {
+	resp, err := p.LLMProvider.CallLLM(ctx, messages)
+	p.record(messages, nil, resp)
+	return resp, err
+}
+
+func (p *countingProvider) CallLLMStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamCallback) (llm.Message, error) {
+	resp, err := p.LLMProvider.CallLLMStream(ctx, messages, onChunk)
+	p.record(messages, nil, resp)
+	return resp, err
+}
+
+func (p *countingProvider) CallLLMWithTools(ctx context.Context, messages []llm.Message, tools []llm.ToolDefinition) (llm.Message, error) {
+	resp, err := p.LLMProvider.CallLLMWithTools(ctx, messages, tools)
+	p.record(messages, tools, resp)
+	return resp, err
+}
@@ -0,0 +1,193 @@
+// Package eval runs prompt/model A/B experiments: a directory of task
+// fixtures is executed against two agent configurations and the per-task
+// step counts, token usage and assertion results are compared.
+//
+// A fixture is a YAML (or JSON) file:
+//
+//	name: count-go-files
+//	prompt: 统计工作区里有多少个 .go 文件
+//	files:                       # seeded into a fresh temp workspace
+//	  a.go: "package a"
+//	  b/b.go: "package b"
+//	expect:
+//	  answer_contains: ["2"]
+//	  tools_used: [file_find]
+//	  max_steps: 8
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+)
+
+// Task is a single eval fixture.
+type Task struct {
+	Name   string            `yaml:"name" json:"name"`     // defaults to the file name without extension
+	Prompt string            `yaml:"prompt" json:"prompt"` // user message given to the agent
+	Files  map[string]string `yaml:"files" json:"files"`   // workspace-relative path → content
+	Expect Expect            `yaml:"expect" json:"expect"`
+}
+
+// Expect holds the assertions evaluated after a run. All are optional;
+// a task with no assertions passes whenever the agent produces an answer.
+type Expect struct {
+	AnswerContains    []string          `yaml:"answer_contains" json:"answer_contains"`         // case-insensitive substrings
+	AnswerNotContains []string          `yaml:"answer_not_contains" json:"answer_not_contains"` // case-insensitive substrings
+	AnswerMatches     string            `yaml:"answer_matches" json:"answer_matches"`           // regexp
+	ToolsUsed         []string          `yaml:"tools_used" json:"tools_used"`                   // each must be called at least once
+	ToolsNotUsed      []string          `yaml:"tools_not_used" json:"tools_not_used"`           // none may be called
+	MaxSteps          int               `yaml:"max_steps" json:"max_steps"`                     // 0 = no limit
+	FilesContain      map[string]string `yaml:"files_contain" json:"files_contain"`             // path → substring after the run
+}
+
+// LoadTasks reads every *.yaml / *.yml / *.json fixture in dir, sorted by name.
+func LoadTasks(dir string) ([]Task, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("eval: read fixtures dir: %w", err)
+	}
+	var tasks []Task
+	seen := make(map[string]string)
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		t, err := LoadTask(path)
+		if err != nil {
+			return nil, err
+		}
+		if prev, dup := seen[t.Name]; dup {
+			return nil, fmt.Errorf("eval: duplicate task name %q in %s and %s", t.Name, prev, e.Name())
+		}
+		seen[t.Name] = e.Name()
+		tasks = append(tasks, t)
+	}
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("eval: no fixtures (*.yaml, *.yml, *.json) in %s", dir)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].Name < tasks[j].Name })
+	return tasks, nil
+}
+
+// LoadTask reads and validates a single fixture file.
+func LoadTask(path string) (Task, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Task{}, fmt.Errorf("eval: read %s: %w", path, err)
+	}
+	var t Task
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return Task{}, fmt.Errorf("eval: parse %s: %w", path, err)
+	}
+	if t.Name == "" {
+		t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if strings.TrimSpace(t.Prompt) == "" {
+		return Task{}, fmt.Errorf("eval: %s: prompt is required", path)
+	}
+	if t.Expect.AnswerMatches != "" {
+		if _, err := regexp.Compile(t.Expect.AnswerMatches); err != nil {
+			return Task{}, fmt.Errorf("eval: %s: invalid answer_matches: %w", path, err)
+		}
+	}
+	for name := range t.Files {
+		if !isLocalPath(name) {
+			return Task{}, fmt.Errorf("eval: %s: file %q must be a relative path inside the workspace", path, name)
+		}
+	}
+	for name := range t.Expect.FilesContain {
+		if !isLocalPath(name) {
+			return Task{}, fmt.Errorf("eval: %s: files_contain %q must be a relative path inside the workspace", path, name)
+		}
+	}
+	return t, nil
+}
+
+// isLocalPath reports whether p stays inside the directory it is joined to.
+func isLocalPath(p string) bool {
+	return p != "" && filepath.IsLocal(filepath.FromSlash(p))
+}
+
+// seed writes the task's files into workspaceDir.
+func (t Task) seed(workspaceDir string) error {
+	for name, content := range t.Files {
+		path := filepath.Join(workspaceDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check evaluates the assertions against a finished run and returns the
+// failed ones (empty = pass).
+func (e Expect) check(answer string, steps []agent.StepRecord, workspaceDir string) []string {
+	var failures []string
+	lower := strings.ToLower(answer)
+	if strings.TrimSpace(answer) == "" {
+		failures = append(failures, "empty answer")
+	}
+	for _, s := range e.AnswerContains {
+		if !strings.Contains(lower, strings.ToLower(s)) {
+			failures = append(failures, fmt.Sprintf("answer missing %q", s))
+		}
+	}
+	for _, s := range e.AnswerNotContains {
+		if strings.Contains(lower, strings.ToLower(s)) {
+			failures = append(failures, fmt.Sprintf("answer contains %q", s))
+		}
+	}
+	if e.AnswerMatches != "" {
+		if re, err := regexp.Compile(e.AnswerMatches); err == nil && !re.MatchString(answer) {
+			failures = append(failures, fmt.Sprintf("answer does not match /%s/", e.AnswerMatches))
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, s := range steps {
+		if s.Type == "tool" {
+			used[s.ToolName] = true
+		}
+	}
+	for _, name := range e.ToolsUsed {
+		if !used[name] {
+			failures = append(failures, fmt.Sprintf("tool %s not used", name))
+		}
+	}
+	for _, name := range e.ToolsNotUsed {
+		if used[name] {
+			failures = append(failures, fmt.Sprintf("tool %s used", name))
+		}
+	}
+	if e.MaxSteps > 0 && len(steps) > e.MaxSteps {
+		failures = append(failures, fmt.Sprintf("%d steps > max_steps %d", len(steps), e.MaxSteps))
+	}
+
+	names := make([]string, 0, len(e.FilesContain))
+	for name := range e.FilesContain {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(workspaceDir, filepath.FromSlash(name)))
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("file %s not found", name))
+		} else if !strings.Contains(string(data), e.FilesContain[name]) {
+			failures = append(failures, fmt.Sprintf("file %s missing %q", name, e.FilesContain[name]))
+		}
+	}
+	return failures
+}
@@ -0,0 +1,345 @@
+// Package explore tries alternative approaches to one task side by side.
+// Each approach runs as a bounded agent sub-run in its own fork of the
+// workspace (a git worktree, or a copy), so the runs cannot see or break
+// each other's edits and the original workspace stays untouched. The report
+// holds each candidate's answer and diff; the user — or a judge pass by the
+// model — picks one, and Apply replays that candidate's patch on the
+// workspace.
+package explore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/batch"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+const (
+	defaultTimeout      = 10 * time.Minute
+	defaultMaxDiffBytes = 64 << 10
+
+	// approaches is how many candidates the model proposes when the job
+	// names none.
+	approaches = 2
+)
+
+// Options configures a Runner.
+type Options struct {
+	Agent batch.Agent
+	// Tools builds the tool registry for one fork. Required.
+	Tools func(workspaceDir string) *tool.Registry
+	// Timeout bounds each approach's sub-run (default 10m).
+	Timeout time.Duration
+	// MaxTokens bounds each sub-run's token use through a cost guard; 0 = no limit.
+	MaxTokens int64
+	// MaxDiffBytes truncates each candidate's readable diff (default 64 KiB).
+	// The patch used by Apply is never truncated.
+	MaxDiffBytes int
+}
+
+// Job is one exploration: a task and the approaches to try.
+type Job struct {
+	Task      string `json:"task"`
+	Workspace string `json:"workspace"`
+	// Approaches to try, one sub-run each. Empty = the model proposes two.
+	Approaches []string `json:"approaches,omitempty"`
+	// Judge asks the model to compare the candidates and pick one.
+	Judge bool `json:"judge,omitempty"`
+}
+
+// Candidate is the outcome of one approach.
+type Candidate struct {
+	Index         int      `json:"index"` // 1-based, as shown to the user
+	Approach      string   `json:"approach"`
+	OK            bool     `json:"ok"`
+	Error         string   `json:"error,omitempty"`
+	Answer        string   `json:"answer,omitempty"`
+	Steps         int      `json:"steps"`
+	ToolCalls     int      `json:"tool_calls"`
+	TokensUsed    int64    `json:"tokens_used,omitempty"`
+	DurationMs    int64    `json:"duration_ms"`
+	ChangedFiles  []string `json:"changed_files,omitempty"`
+	Diff          string   `json:"diff,omitempty"`
+	DiffTruncated bool     `json:"diff_truncated,omitempty"`
+	Patch         string   `json:"-"`                    // full binary patch, replayed by Apply
+	PatchFile     string   `json:"patch_file,omitempty"` // set by Report.SavePatches
+}
+
+// Verdict is the judge pass's pick.
+type Verdict struct {
+	Choice int    `json:"choice"` // Candidate.Index; 0 = neither is acceptable
+	Reason string `json:"reason"`
+}
+
+// Runner executes explorations.
+type Runner struct {
+	opts Options
+}
+
+// NewRunner creates a Runner, applying defaults to zero-valued options.
+func NewRunner(opts Options) *Runner {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxDiffBytes <= 0 {
+		opts.MaxDiffBytes = defaultMaxDiffBytes
+	}
+	return &Runner{opts: opts}
+}
+
+// Run forks the workspace once per approach, runs the approaches in
+// parallel and returns the report with candidates in approach order. The
+// forks are removed before Run returns; the workspace itself is never
+// written. When ctx is cancelled, running sub-runs stop and are reported
+// with whatever they changed so far.
+//
+// progress, when non-nil, is called after each candidate completes, from
+// its goroutine; calls are serialized, so progress needs no locking.
+func (r *Runner) Run(ctx context.Context, job Job, progress func(Candidate)) (*Report, error) {
+	if r.opts.Tools == nil || r.opts.Agent.Provider == nil {
+		return nil, fmt.Errorf("explore: Options.Tools and Options.Agent.Provider are required")
+	}
+	task := strings.TrimSpace(job.Task)
+	if task == "" {
+		return nil, fmt.Errorf("explore: empty task")
+	}
+	ws, err := filepath.Abs(job.Workspace)
+	if err != nil {
+		return nil, fmt.Errorf("explore: %w", err)
+	}
+	if info, err := os.Stat(ws); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("explore: %s is not a directory", ws)
+	}
+
+	rep := &Report{Task: task, Workspace: ws, Started: time.Now()}
+	plans := cleanApproaches(job.Approaches)
+	if len(plans) == 0 {
+		if plans, err = r.propose(ctx, task); err != nil {
+			return nil, err
+		}
+		rep.Proposed = true
+	}
+	if len(plans) < 2 {
+		return nil, fmt.Errorf("explore: need at least two approaches, got %d", len(plans))
+	}
+
+	tmp, err := os.MkdirTemp("", "omega-explore-*")
+	if err != nil {
+		return nil, fmt.Errorf("explore: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	forks := make([]*fork, len(plans))
+	defer func() {
+		for _, f := range forks {
+			if f != nil {
+				f.remove()
+			}
+		}
+	}()
+	for i := range plans {
+		f, err := newFork(ctx, ws, filepath.Join(tmp, fmt.Sprintf("approach-%d", i+1)))
+		if err != nil {
+			return nil, fmt.Errorf("explore: fork workspace: %w", err)
+		}
+		forks[i] = f
+	}
+	rep.Fork = "copy"
+	if forks[0].worktree {
+		rep.Fork = "worktree"
+	}
+
+	rep.Candidates = make([]Candidate, len(plans))
+	var (
+		wg         sync.WaitGroup
+		progressMu sync.Mutex
+	)
+	for i, approach := range plans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := r.runOne(ctx, forks[i], task, approach, i+1, len(plans))
+			rep.Candidates[i] = c
+			if progress != nil {
+				progressMu.Lock()
+				progress(c)
+				progressMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if job.Judge && ctx.Err() == nil {
+		v, err := r.judge(ctx, task, rep.Candidates)
+		if err != nil {
+			log.Printf("[Explore] Judge failed: %v", err)
+			rep.JudgeError = err.Error()
+		} else {
+			rep.Verdict = v
+		}
+	}
+	rep.DurationMs = time.Since(rep.Started).Milliseconds()
+	return rep, nil
+}
+
+// runOne runs one approach in its fork and captures the fork's changes.
+func (r *Runner) runOne(ctx context.Context, f *fork, task, approach string, n, total int) Candidate {
+	c := Candidate{Index: n, Approach: approach}
+	registry := r.opts.Tools(f.dir)
+	a := r.opts.Agent
+	flow := agent.BuildAgentFlow(a.Provider, registry, a.ThinkingMode, a.Loader)
+	state := &agent.AgentState{
+		Problem:             subTask(task, approach, n, total),
+		WorkspaceDir:        f.dir,
+		ToolRegistry:        registry,
+		ThinkingMode:        a.ThinkingMode,
+		ToolCallMode:        a.ToolCallMode,
+		ContextWindowTokens: a.ContextWindow,
+		OSName:              a.OSName,
+		ShellCmd:            a.ShellCmd,
+		ModelName:           a.ModelName,
+		ReadCache:           agent.NewReadCache(),
+		SelfReviewRetries:   a.SelfReview,
+	}
+	if r.opts.MaxTokens > 0 {
+		state.CostGuard = agent.NewCostGuard(r.opts.MaxTokens, 0)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	flow.Run(runCtx, state)
+	c.DurationMs = time.Since(start).Milliseconds()
+	registry.CloseAll()
+
+	c.Steps = len(state.StepHistory)
+	for _, s := range state.StepHistory {
+		if s.Type == "tool" {
+			c.ToolCalls++
+		}
+	}
+	if state.CostGuard != nil {
+		c.TokensUsed = state.CostGuard.UsedTokens()
+	}
+	c.Answer = strings.TrimSpace(state.Solution)
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+		c.Error = fmt.Sprintf("timeout after %v", r.opts.Timeout)
+	case ctx.Err() != nil:
+		c.Error = fmt.Sprintf("cancelled: %v", ctx.Err())
+	case state.CostGuard != nil && state.CostGuard.IsExceeded():
+		c.Error = fmt.Sprintf("token budget of %d exceeded", r.opts.MaxTokens)
+	case c.Answer == "":
+		c.Error = "agent produced no answer"
+	}
+
+	// Use a fresh context: the changes are still wanted after a timeout.
+	files, diff, truncated, patch, err := f.changes(context.Background(), r.opts.MaxDiffBytes)
+	if err != nil && c.Error == "" {
+		c.Error = fmt.Sprintf("collect changes: %v", err)
+	}
+	c.ChangedFiles, c.Diff, c.DiffTruncated, c.Patch = files, diff, truncated, patch
+
+	c.OK = c.Error == ""
+	log.Printf("[Explore] Approach %d: ok=%v steps=%d changed=%d", n, c.OK, c.Steps, len(c.ChangedFiles))
+	return c
+}
+
+// subTask is the problem given to one sub-run: the task, pinned to one approach.
+func subTask(task, approach string, n, total int) string {
+	return fmt.Sprintf("%s\n\n[探索方案 %d/%d] 按以下方案完成任务，不要改用其他方案：\n%s\n"+
+		"你在工作区的独立副本中运行，其他方案在各自的副本中并行尝试；完成后总结所做的修改及其取舍。",
+		task, n, total, approach)
+}
+
+// propose asks the model for two distinct approaches to task.
+func (r *Runner) propose(ctx context.Context, task string) ([]string, error) {
+	msgs := []llm.Message{
+		{Role: llm.RoleSystem, Content: fmt.Sprintf(
+			"你是资深工程师。为用户的任务提出 %d 个思路明显不同、都可行的实现方案，每个方案用一两句话说明做法。"+
+				`只输出 JSON：{"approaches":["方案一","方案二"]}`, approaches)},
+		{Role: llm.RoleUser, Content: task},
+	}
+	resp, err := r.opts.Agent.Provider.CallLLM(ctx, msgs)
+	if err != nil {
+		return nil, fmt.Errorf("explore: propose approaches: %w", err)
+	}
+	var out struct {
+		Approaches []string `json:"approaches"`
+	}
+	if err := decodeJSON(resp.Content, &out); err != nil {
+		return nil, fmt.Errorf("explore: propose approaches: %w", err)
+	}
+	plans := cleanApproaches(out.Approaches)
+	if len(plans) > approaches {
+		plans = plans[:approaches]
+	}
+	return plans, nil
+}
+
+// judge asks the model to compare the candidates and pick the better one.
+func (r *Runner) judge(ctx context.Context, task string, cands []Candidate) (*Verdict, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "任务：\n%s\n", task)
+	for _, c := range cands {
+		fmt.Fprintf(&sb, "\n## 方案 %d\n%s\n", c.Index, c.Approach)
+		if c.Error != "" {
+			fmt.Fprintf(&sb, "运行失败：%s\n", c.Error)
+		}
+		fmt.Fprintf(&sb, "回答：\n%s\n", util.TruncateRunes(c.Answer, 2000))
+		if c.Diff == "" {
+			sb.WriteString("（未修改任何文件）\n")
+		} else {
+			fmt.Fprintf(&sb, "```diff\n%s\n```\n", util.TruncateRunes(c.Diff, 8000))
+		}
+	}
+	msgs := []llm.Message{
+		{Role: llm.RoleSystem, Content: "你是代码评审。比较同一任务的几个候选方案的回答与改动，选出正确性最好、改动最合理的一个；都不可接受时 choice 为 0。" +
+			`只输出 JSON：{"choice":1,"reason":"理由"}`},
+		{Role: llm.RoleUser, Content: sb.String()},
+	}
+	resp, err := r.opts.Agent.Provider.CallLLM(ctx, msgs)
+	if err != nil {
+		return nil, err
+	}
+	var v Verdict
+	if err := decodeJSON(resp.Content, &v); err != nil {
+		return nil, err
+	}
+	if v.Choice < 0 || v.Choice > len(cands) {
+		return nil, fmt.Errorf("choice %d out of range", v.Choice)
+	}
+	return &v, nil
+}
+
+// decodeJSON decodes the first JSON object in a model reply, tolerating
+// code fences and surrounding prose.
+func decodeJSON(content string, v any) error {
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return fmt.Errorf("no JSON object in reply: %q", util.TruncateRunes(content, 200))
+	}
+	return json.Unmarshal([]byte(content[start:end+1]), v)
+}
+
+func cleanApproaches(in []string) []string {
+	var out []string
+	for _, a := range in {
+		if a = strings.TrimSpace(a); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package explore
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/batch"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// scriptedProvider answers every decide call directly, proposes two
+// approaches and has the judge pick the second.
+type scriptedProvider struct{}
+
+func (scriptedProvider) reply(msgs []llm.Message) llm.Message {
+	content := `{"action":"answer","reason":"完成","answer":"done"}`
+	if len(msgs) > 0 && msgs[0].Role == llm.RoleSystem {
+		switch {
+		case strings.Contains(msgs[0].Content, `{"approaches"`):
+			content = "```json\n{\"approaches\":[\"改 a.txt\",\"新建 b.txt\",\"第三个\"]}\n```"
+		case strings.Contains(msgs[0].Content, `{"choice"`):
+			content = `{"choice":2,"reason":"改动更小"}`
+		}
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: content}
+}
+func (p scriptedProvider) CallLLM(_ context.Context, msgs []llm.Message) (llm.Message, error) {
+	return p.reply(msgs), nil
+}
+func (p scriptedProvider) CallLLMStream(_ context.Context, msgs []llm.Message, _ llm.StreamCallback) (llm.Message, error) {
+	return p.reply(msgs), nil
+}
+func (p scriptedProvider) CallLLMWithTools(_ context.Context, msgs []llm.Message, _ []llm.ToolDefinition) (llm.Message, error) {
+	return p.reply(msgs), nil
+}
+func (scriptedProvider) IsToolCallingEnabled() bool { return false }
+
+func gitInit(t *testing.T, dir string) {
+	t.Helper()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-qm", "init"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+}
+
+// newTestRunner simulates the agent's edits in Tools, which runs inside the
+// fork before the sub-run: approach 1 edits a.txt, approach 2 adds b.txt.
+func newTestRunner(t *testing.T) *Runner {
+	return NewRunner(Options{
+		Agent: batch.Agent{Provider: scriptedProvider{}, ThinkingMode: "native", ToolCallMode: "json"},
+		Tools: func(ws string) *tool.Registry {
+			switch filepath.Base(ws) {
+			case "approach-1":
+				os.WriteFile(filepath.Join(ws, "a.txt"), []byte("changed\n"), 0o644)
+			case "approach-2":
+				os.WriteFile(filepath.Join(ws, "b.txt"), []byte("new\n"), 0o644)
+			default:
+				t.Errorf("sub-run in %s, not a fork", ws)
+			}
+			os.MkdirAll(filepath.Join(ws, ".omega"), 0o755)
+			os.WriteFile(filepath.Join(ws, ".omega", "log"), []byte("x"), 0o644)
+			return tool.NewRegistry()
+		},
+	})
+}
+
+func TestRunner_ForksRunsAndJudges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	for _, isGit := range []bool{true, false} {
+		ws := t.TempDir()
+		os.WriteFile(filepath.Join(ws, "a.txt"), []byte("original\n"), 0o644)
+		wantFork := "copy"
+		if isGit {
+			gitInit(t, ws)
+			wantFork = "worktree"
+		}
+
+		var progressed int
+		rep, err := newTestRunner(t).Run(context.Background(), Job{Task: "整理文件", Workspace: ws, Judge: true},
+			func(Candidate) { progressed++ })
+		if err != nil {
+			t.Fatalf("git=%v: Run: %v", isGit, err)
+		}
+		if rep.Fork != wantFork || !rep.Proposed || len(rep.Candidates) != 2 || progressed != 2 {
+			t.Fatalf("git=%v: fork %q proposed %v candidates %d progress %d", isGit, rep.Fork, rep.Proposed, len(rep.Candidates), progressed)
+		}
+		c1, c2 := rep.Candidates[0], rep.Candidates[1]
+		if !c1.OK || c1.Approach != "改 a.txt" || !reflect.DeepEqual(c1.ChangedFiles, []string{"a.txt"}) || !strings.Contains(c1.Diff, "+changed") {
+			t.Errorf("git=%v: candidate 1 = %+v", isGit, c1)
+		}
+		if !c2.OK || !reflect.DeepEqual(c2.ChangedFiles, []string{"b.txt"}) {
+			t.Errorf("git=%v: candidate 2 = %+v", isGit, c2)
+		}
+		if rep.Verdict == nil || rep.Verdict.Choice != 2 {
+			t.Errorf("git=%v: verdict = %+v (%s)", isGit, rep.Verdict, rep.JudgeError)
+		}
+
+		// The workspace is untouched and the forks are gone.
+		if data, _ := os.ReadFile(filepath.Join(ws, "a.txt")); string(data) != "original\n" {
+			t.Errorf("git=%v: workspace modified: %q", isGit, data)
+		}
+		if isGit {
+			out, _ := exec.Command("git", "-C", ws, "worktree", "list").Output()
+			if n := strings.Count(string(out), "\n"); n != 1 {
+				t.Errorf("worktrees left behind:\n%s", out)
+			}
+		}
+
+		if err := Apply(context.Background(), ws, c1); err != nil {
+			t.Fatalf("git=%v: Apply: %v", isGit, err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(ws, "a.txt")); string(data) != "changed\n" {
+			t.Errorf("git=%v: after Apply a.txt = %q", isGit, data)
+		}
+		if _, err := os.Stat(filepath.Join(ws, ".omega", "log")); err == nil {
+			t.Errorf("git=%v: .omega was part of the patch", isGit)
+		}
+	}
+}
+
+func TestRunner_ExplicitApproachesAndReport(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	// A subdirectory of a repository is copied, and Apply maps the patch
+	// paths back into the subdirectory.
+	repo := t.TempDir()
+	ws := filepath.Join(repo, "sub")
+	os.Mkdir(ws, 0o755)
+	os.WriteFile(filepath.Join(ws, "a.txt"), []byte("original\n"), 0o644)
+	gitInit(t, repo)
+	runner := newTestRunner(t)
+
+	if _, err := runner.Run(context.Background(), Job{Task: "t", Workspace: ws, Approaches: []string{"only one", " "}}, nil); err == nil {
+		t.Error("a single approach was accepted")
+	}
+	rep, err := runner.Run(context.Background(), Job{Task: "t", Workspace: ws, Approaches: []string{"A | x", "B"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Proposed || rep.Verdict != nil || rep.Failed() || rep.Candidate(2).Approach != "B" || rep.Candidate(3) != nil {
+		t.Fatalf("report = %+v", rep)
+	}
+
+	dir := filepath.Join(t.TempDir(), "patches")
+	if err := rep.SavePatches(dir); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "approach-2.patch")); err != nil || !strings.Contains(string(data), "b.txt") {
+		t.Errorf("approach-2.patch = %q, %v", data, err)
+	}
+	if err := Apply(context.Background(), ws, *rep.Candidate(1)); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "a.txt")); string(data) != "changed\n" {
+		t.Errorf("after Apply sub/a.txt = %q", data)
+	}
+
+	var buf bytes.Buffer
+	rep.WriteMarkdown(&buf)
+	for _, want := range []string{"in copy forks", `| 1 | A \| x | ✅`, "## Approach 2", "Patch: " + dir, "```diff"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, buf.String())
+		}
+	}
+}
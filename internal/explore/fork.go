@@ -0,0 +1,214 @@
+package explore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// gitTimeout bounds each git command used to fork, diff and apply.
+const gitTimeout = 2 * time.Minute
+
+// excludeMeta keeps the workspace's .omega directory out of diffs and patches.
+const excludeMeta = ":(exclude).omega"
+
+// git runs a git command in dir and returns its trimmed stdout; the error
+// includes stderr.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// fork is an isolated copy of the workspace that one approach runs in.
+type fork struct {
+	dir      string
+	base     string // commit the diff is taken against
+	origin   string // workspace a worktree belongs to; "" for copies
+	worktree bool
+}
+
+// newFork creates the isolated copy at dir. A clean git work tree gets a
+// detached git worktree of HEAD (cheap, shares the object store; ignored
+// files such as build outputs are absent). Anything else — a dirty tree or
+// a plain directory — is copied without .git and .omega, and the copy gets
+// its own repository with one baseline commit to diff against.
+func newFork(ctx context.Context, ws, dir string) (*fork, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	if clean, head := cleanHead(ctx, ws); clean {
+		if _, err := git(ctx, ws, "worktree", "add", "--detach", dir, head); err != nil {
+			return nil, err
+		}
+		return &fork{dir: dir, base: head, origin: ws, worktree: true}, nil
+	}
+
+	if err := copyTree(ws, dir); err != nil {
+		return nil, fmt.Errorf("copy workspace: %w", err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=omega", "-c", "user.email=omega@localhost", "commit", "-q", "--allow-empty", "--no-verify", "-m", "explore baseline"},
+	} {
+		if _, err := git(ctx, dir, args...); err != nil {
+			return nil, err
+		}
+	}
+	head, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return &fork{dir: dir, base: head}, nil
+}
+
+// cleanHead reports whether ws is the top level of a git work tree with a
+// commit and no uncommitted changes outside .omega, and returns HEAD.
+// Subdirectories of a repository are copied instead: a worktree would hold
+// the whole repository.
+func cleanHead(ctx context.Context, ws string) (bool, string) {
+	if prefix, err := git(ctx, ws, "rev-parse", "--show-prefix"); err != nil || prefix != "" {
+		return false, ""
+	}
+	head, err := git(ctx, ws, "rev-parse", "HEAD")
+	if err != nil {
+		return false, ""
+	}
+	status, err := git(ctx, ws, "status", "--porcelain", "--", ".", excludeMeta)
+	if err != nil || status != "" {
+		return false, ""
+	}
+	return true, head
+}
+
+// changes stages everything in the fork and returns the changed files, the
+// readable diff truncated to maxBytes, and the full binary patch that Apply
+// replays on the original workspace.
+func (f *fork) changes(ctx context.Context, maxBytes int) (files []string, diff string, truncated bool, patch string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	if _, err := git(ctx, f.dir, "add", "-A", "--", ".", excludeMeta); err != nil {
+		return nil, "", false, "", err
+	}
+	names, err := git(ctx, f.dir, "diff", "--cached", "--name-only", f.base, "--", ".", excludeMeta)
+	if err != nil {
+		return nil, "", false, "", err
+	}
+	if names != "" {
+		files = strings.Split(names, "\n")
+	}
+	if patch, err = git(ctx, f.dir, "diff", "--cached", "--binary", f.base, "--", ".", excludeMeta); err != nil {
+		return nil, "", false, "", err
+	}
+	if patch != "" {
+		patch += "\n"
+	}
+	diff, _ = git(ctx, f.dir, "diff", "--cached", f.base, "--", ".", excludeMeta)
+	if len(diff) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(diff[cut]) {
+			cut--
+		}
+		diff, truncated = diff[:cut], true
+	}
+	return files, diff, truncated, patch, nil
+}
+
+// remove deletes the fork (and unregisters a worktree).
+func (f *fork) remove() {
+	if f.worktree {
+		ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+		defer cancel()
+		git(ctx, f.origin, "worktree", "remove", "--force", f.dir)
+	}
+	os.RemoveAll(f.dir)
+}
+
+// copyTree copies src to dst, skipping .git and .omega. Symlinks are
+// recreated, not followed.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == ".git" || d.Name() == ".omega") && rel != "." {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil // sockets, devices: skipped
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Apply replays a candidate's patch on the original workspace with git
+// apply (which also works outside a git repository). Nothing is applied
+// when the patch does not apply cleanly.
+//
+// Patch paths are relative to the workspace; when the workspace is a
+// subdirectory of a repository, git apply reads them relative to the
+// repository root, so they get the workspace's prefix.
+func Apply(ctx context.Context, workspace string, c Candidate) error {
+	if c.Patch == "" {
+		return fmt.Errorf("explore: approach %d changed nothing", c.Index)
+	}
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	args := []string{"-C", workspace, "apply", "--whitespace=nowarn"}
+	if prefix, err := git(ctx, workspace, "rev-parse", "--show-prefix"); err == nil && prefix != "" {
+		args = append(args, "--directory="+strings.TrimSuffix(prefix, "/"))
+	}
+	cmd := exec.CommandContext(ctx, "git", append(args, "-")...)
+	cmd.Stdin = strings.NewReader(c.Patch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("explore: apply approach %d: %v: %s", c.Index, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package explore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+// Report collects the candidates of one exploration.
+type Report struct {
+	Task       string      `json:"task"`
+	Workspace  string      `json:"workspace"`
+	Fork       string      `json:"fork"`               // "worktree" or "copy"
+	Proposed   bool        `json:"proposed,omitempty"` // approaches were proposed by the model
+	Started    time.Time   `json:"started"`
+	DurationMs int64       `json:"duration_ms"`
+	Candidates []Candidate `json:"candidates"`
+	Verdict    *Verdict    `json:"verdict,omitempty"`
+	JudgeError string      `json:"judge_error,omitempty"`
+}
+
+// Candidate returns the candidate with the 1-based index n, or nil.
+func (r *Report) Candidate(n int) *Candidate {
+	if n < 1 || n > len(r.Candidates) {
+		return nil
+	}
+	return &r.Candidates[n-1]
+}
+
+// Failed reports whether no candidate completed successfully.
+func (r *Report) Failed() bool {
+	for _, c := range r.Candidates {
+		if c.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// SavePatches writes each candidate's patch to dir as approach-N.patch and
+// records the paths, so a candidate can also be applied later with git apply.
+func (r *Report) SavePatches(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for i := range r.Candidates {
+		c := &r.Candidates[i]
+		if c.Patch == "" {
+			continue
+		}
+		p := filepath.Join(dir, fmt.Sprintf("approach-%d.patch", c.Index))
+		if err := os.WriteFile(p, []byte(c.Patch), 0o644); err != nil {
+			return err
+		}
+		c.PatchFile = p
+	}
+	return nil
+}
+
+// WriteMarkdown renders the report: a comparison table, the judge's
+// verdict, then each candidate's approach, answer and diff.
+func (r *Report) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Exploration\n\n")
+	fmt.Fprintf(w, "%s, %d approach(es) in %s forks, started %s, took %.1fs\n\n",
+		r.Workspace, len(r.Candidates), r.Fork, r.Started.Format(time.DateTime), float64(r.DurationMs)/1000)
+	fmt.Fprintf(w, "> %s\n\n", strings.ReplaceAll(util.TruncateRunes(r.Task, 500), "\n", "\n> "))
+
+	fmt.Fprintln(w, "## Summary")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| # | approach | result | steps | changed files | tokens | time |")
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|")
+	for _, c := range r.Candidates {
+		mark := "✅"
+		if !c.OK {
+			mark = "❌ " + c.Error
+		}
+		fmt.Fprintf(w, "| %d | %s | %s | %d | %d | %d | %.1fs |\n",
+			c.Index, strings.ReplaceAll(util.TruncateRunes(c.Approach, 80), "|", "\\|"), mark,
+			c.Steps, len(c.ChangedFiles), c.TokensUsed, float64(c.DurationMs)/1000)
+	}
+
+	switch {
+	case r.Verdict != nil && r.Verdict.Choice > 0:
+		fmt.Fprintf(w, "\n**Judge:** approach %d — %s\n", r.Verdict.Choice, r.Verdict.Reason)
+	case r.Verdict != nil:
+		fmt.Fprintf(w, "\n**Judge:** neither approach is acceptable — %s\n", r.Verdict.Reason)
+	case r.JudgeError != "":
+		fmt.Fprintf(w, "\n**Judge failed:** %s\n", r.JudgeError)
+	}
+
+	for _, c := range r.Candidates {
+		fmt.Fprintf(w, "\n## Approach %d\n\n%s\n\n", c.Index, c.Approach)
+		if c.Answer != "" {
+			fmt.Fprintln(w, c.Answer)
+			fmt.Fprintln(w)
+		}
+		if len(c.ChangedFiles) > 0 {
+			fmt.Fprintf(w, "Changed: %s\n\n", strings.Join(c.ChangedFiles, ", "))
+		}
+		if c.PatchFile != "" {
+			fmt.Fprintf(w, "Patch: %s\n\n", c.PatchFile)
+		}
+		if c.Diff != "" {
+			fmt.Fprintln(w, "```diff")
+			fmt.Fprintln(w, c.Diff)
+			if c.DiffTruncated {
+				fmt.Fprintln(w, "# ... diff truncated")
+			}
+			fmt.Fprintln(w, "```")
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package i18n provides UI-locale message lookup and an optional LLM-backed
+// translator that normalises tool output into the agent's working language.
+//
+// The two concerns are deliberately separate:
+//   - Catalog (T): static, user-facing strings rendered per UI locale (UI_LOCALE).
+//   - Translator:  dynamic tool results presented to the model in the configured
+//     working language (AGENT_WORKING_LANGUAGE), cached to avoid repeat LLM calls.
+package i18n
+
+import "strings"
+
+// Supported UI locales.
+const (
+	LocaleZH = "zh"
+	LocaleEN = "en"
+)
+
+// DefaultLocale is used when UI_LOCALE is unset or unrecognised.
+const DefaultLocale = LocaleZH
+
+// messages maps message keys to their per-locale renderings.
+// ⚠️ Every key must have a LocaleZH entry — it is the fallback for all locales.
+var messages = map[string]map[string]string{
+	"agent.analyzing": {
+		LocaleZH: "🤔 正在分析问题...",
+		LocaleEN: "🤔 Analyzing your question...",
+	},
+	"agent.busy": {
+		LocaleZH: "当前任务过多，请稍后重试",
+		LocaleEN: "Too many agent runs in progress, please retry shortly",
+	},
+	"agent.cancelled": {
+		LocaleZH: "⏹️ 已按用户要求取消本次运行。",
+		LocaleEN: "⏹️ Run cancelled by user.",
+	},
+	"agent.timed_out": {
+		LocaleZH: "⌛ 运行超时，已停止",
+		LocaleEN: "⌛ The run timed out and was stopped",
+	},
+	"agent.budget_exceeded": {
+		LocaleZH: "💰 已达到运行预算（token 或时长），已停止",
+		LocaleEN: "💰 The run reached its token or time budget and was stopped",
+	},
+	"agent.correction_applied": {
+		LocaleZH: "📝 已采纳纠正，后续决策将据此调整：%s",
+		LocaleEN: "📝 Correction received; next decisions will take it into account: %s",
+	},
+	"agent.low_disk": {
+		LocaleZH: "💽 工作区磁盘剩余空间不足（%s），写入文件可能失败。可在 /api/storage 查看占用或清理旧文件",
+		LocaleEN: "💽 The workspace volume is low on space (%s free); file writes may fail. See /api/storage for what uses it",
+	},
+	"agent.checkpoint_kept": {
+		LocaleZH: "💾 运行中断，计划未完成，已保存进度。输入 /resume 可从中断处继续",
+		LocaleEN: "💾 Run interrupted with the plan unfinished; progress is saved. Send /resume to continue where it stopped",
+	},
+	"agent.downshift": {
+		LocaleZH: "⬇️ 已接近 token 预算（%d/%d），后续决策切换到 %s 并压缩历史",
+		LocaleEN: "⬇️ Nearing the token budget (%d/%d); remaining decisions use %s with compressed history",
+	},
+	"agent.steps_extended": {
+		LocaleZH: "📈 计划还有 %d 步未完成，步骤预算从 %d 延长到 %d（每次运行仅延长一次）",
+		LocaleEN: "📈 %d plan steps are still open; the step budget was extended from %d to %d (once per run)",
+	},
+	"agent.no_answer": {
+		LocaleZH: "抱歉，未能生成回答。请重试。",
+		LocaleEN: "Sorry, no answer could be generated. Please try again.",
+	},
+	"agent.prompts_reloaded": {
+		LocaleZH: "🔄 提示词已热更新：%s",
+		LocaleEN: "🔄 Prompts hot-reloaded: %s",
+	},
+	"agent.queued": {
+		LocaleZH: "⏳ 排队中（第 %d 位）...",
+		LocaleEN: "⏳ Queued (position %d)...",
+	},
+	"agent.crashed_run": {
+		LocaleZH: "♻️ 检测到上次进程退出时中断的运行，进度已保存。输入 /resume 可从中断处继续",
+		LocaleEN: "♻️ A run of this session was cut off when the process stopped; its progress is saved. Send /resume to continue where it stopped",
+	},
+	"agent.resume_none": {
+		LocaleZH: "没有可恢复的中断运行。",
+		LocaleEN: "There is no interrupted run to resume.",
+	},
+	"agent.resumed": {
+		LocaleZH: "♻️ 继续中断的运行（计划已完成 %d/%d 步）",
+		LocaleEN: "♻️ Resuming the interrupted run (%d/%d plan steps done)",
+	},
+	"agent.resumed_steps": {
+		LocaleZH: "♻️ 继续中断的运行（中断前已执行 %d 步）",
+		LocaleEN: "♻️ Resuming the interrupted run (%d steps done before it stopped)",
+	},
+	"agent.session_busy": {
+		LocaleZH: "⏳ 本会话已有任务在运行，等待其完成...",
+		LocaleEN: "⏳ Another run in this session is in progress, waiting for it to finish...",
+	},
+	"llm.failover": {
+		LocaleZH: "🔀 模型服务持续不可用，已切换到备用模型 %s",
+		LocaleEN: "🔀 The model service stays unavailable; switched to the fallback model %s",
+	},
+	"llm.retrying": {
+		LocaleZH: "🔁 模型服务暂时不可用，%s 后重试（%d/%d）",
+		LocaleEN: "🔁 The model service is temporarily unavailable; retrying in %s (%d/%d)",
+	},
+	"watch.triggered": {
+		LocaleZH: "🔔 文件监控 %s 已触发：%s 出现 %q（%d 处）\n%s",
+		LocaleEN: "🔔 Watch %s triggered: %s contains %q (%d match(es))\n%s",
+	},
+}
+
+// NormalizeLocale maps a raw locale string (e.g. "en-US", "zh_CN") onto a
+// supported locale. Unknown values fall back to DefaultLocale.
+func NormalizeLocale(raw string) string {
+	lower := strings.ToLower(strings.TrimSpace(raw))
+	switch {
+	case strings.HasPrefix(lower, "en"):
+		return LocaleEN
+	case strings.HasPrefix(lower, "zh"):
+		return LocaleZH
+	default:
+		return DefaultLocale
+	}
+}
+
+// T returns the message for key in the given locale.
+// Falls back to the Chinese rendering, then to the key itself when unknown.
+func T(locale, key string) string {
+	entry, ok := messages[key]
+	if !ok {
+		return key
+	}
+	if msg, ok := entry[NormalizeLocale(locale)]; ok {
+		return msg
+	}
+	return entry[LocaleZH]
+}
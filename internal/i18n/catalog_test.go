@@ -0,0 +1,39 @@
+package i18n
+
+import "testing"
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := map[string]string{
+		"":      LocaleZH,
+		"en":    LocaleEN,
+		"en-US": LocaleEN,
+		"EN_gb": LocaleEN,
+		"zh_CN": LocaleZH,
+		"fr":    LocaleZH,
+	}
+	for in, want := range tests {
+		if got := NormalizeLocale(in); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestT_Fallbacks(t *testing.T) {
+	if got := T("en", "agent.no_answer"); got != messages["agent.no_answer"][LocaleEN] {
+		t.Errorf("en lookup = %q", got)
+	}
+	if got := T("fr", "agent.no_answer"); got != messages["agent.no_answer"][LocaleZH] {
+		t.Errorf("unknown locale should fall back to zh, got %q", got)
+	}
+	if got := T("en", "no.such.key"); got != "no.such.key" {
+		t.Errorf("unknown key should return key, got %q", got)
+	}
+}
+
+func TestMessages_AllHaveZH(t *testing.T) {
+	for key, entry := range messages {
+		if entry[LocaleZH] == "" {
+			t.Errorf("message %q missing zh fallback", key)
+		}
+	}
+}
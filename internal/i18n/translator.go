@@ -0,0 +1,147 @@
+package i18n
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// translatorCacheSize caps the number of cached translations (FIFO eviction).
+const translatorCacheSize = 256
+
+// maxTranslateRunes is the largest text the translator will send to the LLM.
+// Longer texts (e.g. full file contents) are returned unchanged: translating
+// them costs more than the mixed-language noise they introduce.
+const maxTranslateRunes = 4000
+
+// languageNames maps working-language codes to the name used in the prompt.
+var languageNames = map[string]string{
+	"en": "English",
+	"zh": "Simplified Chinese",
+	"ja": "Japanese",
+}
+
+// Translator normalises text into a target working language via the LLM.
+// Results are cached by content hash so repeated tool errors (which are mostly
+// fixed strings) cost at most one LLM call each. Safe for concurrent use.
+type Translator struct {
+	provider llm.LLMProvider
+	target   string
+
+	mu    sync.Mutex
+	cache map[string]string
+	order []string // insertion order for FIFO eviction
+}
+
+// NewTranslator creates a translator targeting the given language code
+// (e.g. "en"). Returns nil when target is empty, which disables translation.
+func NewTranslator(provider llm.LLMProvider, target string) *Translator {
+	target = strings.ToLower(strings.TrimSpace(target))
+	if target == "" || provider == nil {
+		return nil
+	}
+	return &Translator{
+		provider: provider,
+		target:   target,
+		cache:    make(map[string]string),
+	}
+}
+
+// Target returns the working-language code.
+func (t *Translator) Target() string { return t.target }
+
+// Translate returns text rendered in the target language.
+// Text that already appears to be in the target language, is empty, or is
+// longer than maxTranslateRunes is returned unchanged without an LLM call.
+// On LLM failure the original text is returned together with the error.
+func (t *Translator) Translate(ctx context.Context, text string) (string, error) {
+	if t == nil || !NeedsTranslation(text, t.target) {
+		return text, nil
+	}
+	if len([]rune(text)) > maxTranslateRunes {
+		return text, nil
+	}
+
+	key := t.cacheKey(text)
+	t.mu.Lock()
+	if cached, ok := t.cache[key]; ok {
+		t.mu.Unlock()
+		return cached, nil
+	}
+	t.mu.Unlock()
+
+	langName := languageNames[t.target]
+	if langName == "" {
+		langName = t.target
+	}
+	resp, err := t.provider.CallLLM(ctx, []llm.Message{
+		{Role: llm.RoleSystem, Content: fmt.Sprintf(
+			"Translate the user's text into %s. Preserve file paths, code, "+
+				"identifiers, numbers and formatting exactly. Output only the translation.", langName)},
+		{Role: llm.RoleUser, Content: text},
+	})
+	if err != nil {
+		return text, fmt.Errorf("translate: %w", err)
+	}
+	translated := strings.TrimSpace(resp.Content)
+	if translated == "" {
+		return text, nil
+	}
+
+	t.mu.Lock()
+	if _, ok := t.cache[key]; !ok {
+		if len(t.order) >= translatorCacheSize {
+			delete(t.cache, t.order[0])
+			t.order = t.order[1:]
+		}
+		t.order = append(t.order, key)
+	}
+	t.cache[key] = translated
+	t.mu.Unlock()
+
+	return translated, nil
+}
+
+// CacheLen returns the number of cached translations.
+func (t *Translator) CacheLen() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.cache)
+}
+
+func (t *Translator) cacheKey(text string) string {
+	h := sha256.Sum256([]byte(t.target + "\x00" + text))
+	return fmt.Sprintf("%x", h)
+}
+
+// NeedsTranslation reports whether text appears to be in a language other
+// than target. Detection is a cheap script heuristic: Han characters mark
+// Chinese text, Latin letters mark English text. Other targets always
+// translate when the text contains letters.
+func NeedsTranslation(text, target string) bool {
+	var han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	if han == 0 && latin == 0 {
+		return false
+	}
+	switch target {
+	case "en":
+		return han > 0
+	case "zh":
+		return han == 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// countingProvider implements llm.LLMProvider and counts CallLLM invocations.
+type countingProvider struct {
+	response string
+	err      error
+	calls    int
+}
+
+func (p *countingProvider) CallLLM(_ context.Context, _ []llm.Message) (llm.Message, error) {
+	p.calls++
+	return llm.Message{Role: llm.RoleAssistant, Content: p.response}, p.err
+}
+func (p *countingProvider) CallLLMStream(ctx context.Context, msgs []llm.Message, _ llm.StreamCallback) (llm.Message, error) {
+	return p.CallLLM(ctx, msgs)
+}
+func (p *countingProvider) CallLLMWithTools(ctx context.Context, msgs []llm.Message, _ []llm.ToolDefinition) (llm.Message, error) {
+	return p.CallLLM(ctx, msgs)
+}
+func (p *countingProvider) IsToolCallingEnabled() bool { return false }
+
+func TestNewTranslator_EmptyTargetDisabled(t *testing.T) {
+	if tr := NewTranslator(&countingProvider{}, "  "); tr != nil {
+		t.Fatal("expected nil translator for empty target")
+	}
+	// nil translator must be safe to call
+	var tr *Translator
+	got, err := tr.Translate(context.Background(), "文件不存在")
+	if err != nil || got != "文件不存在" {
+		t.Errorf("nil Translate = %q, %v", got, err)
+	}
+}
+
+func TestTranslate_CachesResult(t *testing.T) {
+	p := &countingProvider{response: "file not found"}
+	tr := NewTranslator(p, "en")
+
+	for i := 0; i < 3; i++ {
+		got, err := tr.Translate(context.Background(), "文件不存在")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "file not found" {
+			t.Errorf("got %q, want %q", got, "file not found")
+		}
+	}
+	if p.calls != 1 {
+		t.Errorf("expected 1 LLM call, got %d", p.calls)
+	}
+	if tr.CacheLen() != 1 {
+		t.Errorf("expected cache len 1, got %d", tr.CacheLen())
+	}
+}
+
+func TestTranslate_SkipsTargetLanguage(t *testing.T) {
+	p := &countingProvider{response: "unused"}
+	tr := NewTranslator(p, "en")
+
+	got, _ := tr.Translate(context.Background(), "permission denied: /etc/passwd")
+	if got != "permission denied: /etc/passwd" || p.calls != 0 {
+		t.Errorf("English text should pass through untouched, got %q calls=%d", got, p.calls)
+	}
+}
+
+func TestTranslate_LLMErrorKeepsOriginal(t *testing.T) {
+	p := &countingProvider{err: errors.New("boom")}
+	tr := NewTranslator(p, "en")
+
+	got, err := tr.Translate(context.Background(), "参数解析失败")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got != "参数解析失败" {
+		t.Errorf("expected original text on failure, got %q", got)
+	}
+	if tr.CacheLen() != 0 {
+		t.Error("failed translation must not be cached")
+	}
+}
+
+func TestTranslate_CacheEviction(t *testing.T) {
+	p := &countingProvider{response: "x"}
+	tr := NewTranslator(p, "en")
+	for i := 0; i < translatorCacheSize+10; i++ {
+		tr.Translate(context.Background(), "错误"+string(rune('a'+i%26))+string(rune('a'+i/26)))
+	}
+	if tr.CacheLen() != translatorCacheSize {
+		t.Errorf("cache len = %d, want %d", tr.CacheLen(), translatorCacheSize)
+	}
+}
+
+func TestNeedsTranslation(t *testing.T) {
+	tests := []struct {
+		text, target string
+		want         bool
+	}{
+		{"文件不存在", "en", true},
+		{"file not found", "en", false},
+		{"错误: file.go", "en", true},
+		{"file not found", "zh", true},
+		{"文件不存在", "zh", false},
+		{"12345 ---", "en", false},
+		{"", "en", false},
+	}
+	for _, tt := range tests {
+		if got := NeedsTranslation(tt.text, tt.target); got != tt.want {
+			t.Errorf("NeedsTranslation(%q, %q) = %v, want %v", tt.text, tt.target, got, tt.want)
+		}
+	}
+}
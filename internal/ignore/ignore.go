@@ -0,0 +1,215 @@
+// Package ignore implements the workspace .omegaignore file: gitignore-style
+// patterns for paths that discovery tools (file_list, find, file_grep,
+// todo_scan...) skip, so vendored and build directories stop filling
+// listings and wasting tokens.
+//
+// Supported syntax follows gitignore: blank lines and "#" comments, "!" to
+// re-include, a leading "/" (or any inner "/") to anchor to the workspace
+// root, a trailing "/" to match directories only, and "*", "?", "[...]" and
+// "**" wildcards. A path is ignored when it or one of its parent
+// directories is.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileName is the ignore file looked up in the workspace root.
+const FileName = ".omegaignore"
+
+// rule is one compiled pattern line.
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher holds the rules of one ignore file. The zero value and nil ignore
+// nothing.
+type Matcher struct {
+	rules []rule
+}
+
+// Parse compiles ignore file content. Invalid patterns are skipped.
+func Parse(data []byte) *Matcher {
+	m := &Matcher{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r rule
+		if strings.HasPrefix(line, "!") {
+			r.negate, line = true, line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:] // "\#" and "\!" escape a literal first character
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if anchored {
+			expr = "^" + expr + "$"
+		} else {
+			expr = "^(?:.*/)?" + expr + "$"
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			continue
+		}
+		r.re = re
+		m.rules = append(m.rules, r)
+	}
+	return m
+}
+
+// globToRegexp translates one gitignore glob into a regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(?:.*/)?") // zero or more directories
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			sb.WriteString("/.*") // everything inside
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+// Match reports whether rel (slash-separated, relative to the workspace
+// root) is ignored. isDir tells whether rel itself is a directory.
+func (m *Matcher) Match(rel string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+	rel = strings.Trim(filepath.ToSlash(rel), "/")
+	if rel == "" || rel == "." {
+		return false
+	}
+	// A path inside an ignored directory is ignored, like in git.
+	for i := strings.IndexByte(rel, '/'); i >= 0; i = nextSlash(rel, i) {
+		if m.matchOne(rel[:i], true) {
+			return true
+		}
+	}
+	return m.matchOne(rel, isDir)
+}
+
+func nextSlash(s string, i int) int {
+	j := strings.IndexByte(s[i+1:], '/')
+	if j < 0 {
+		return -1
+	}
+	return i + 1 + j
+}
+
+// matchOne applies the rules to a single path; the last matching rule wins.
+func (m *Matcher) matchOne(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// Empty reports whether m ignores nothing.
+func (m *Matcher) Empty() bool { return m == nil || len(m.rules) == 0 }
+
+// cacheEntry is a parsed ignore file and the stat it was parsed from.
+type cacheEntry struct {
+	modTime time.Time
+	size    int64
+	m       *Matcher
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cacheEntry)
+)
+
+// ForWorkspace returns the Matcher of workspaceDir/.omegaignore. The file is
+// parsed once and re-parsed only when it changes; a missing file yields a
+// Matcher that ignores nothing.
+func ForWorkspace(workspaceDir string) *Matcher {
+	if workspaceDir == "" {
+		return nil
+	}
+	path := filepath.Join(workspaceDir, FileName)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if e, ok := cache[path]; ok && e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+		return e.m
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	m := Parse(data)
+	cache[path] = cacheEntry{modTime: info.ModTime(), size: info.Size(), m: m}
+	return m
+}
+
+// Rel returns path relative to workspaceDir in slash form, or "" when path
+// is outside the workspace.
+func Rel(workspaceDir, path string) string {
+	if filepath.IsAbs(workspaceDir) != filepath.IsAbs(path) {
+		workspaceDir, _ = filepath.Abs(workspaceDir)
+		path, _ = filepath.Abs(path)
+	}
+	rel, err := filepath.Rel(workspaceDir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,102 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	m := Parse([]byte(`
+# build output
+dist/
+*.log
+!keep.log
+/root-only.txt
+docs/**/*.png
+\#hash
+`))
+	cases := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"dist", true, true},
+		{"dist/app.js", false, true},     // inside an ignored directory
+		{"web/dist/app.js", false, true}, // unanchored pattern matches at any depth
+		{"dist", false, false},           // trailing slash: directories only
+		{"server.log", false, true},
+		{"logs/a/server.log", false, true},
+		{"keep.log", false, false}, // negated
+		{"root-only.txt", false, true},
+		{"sub/root-only.txt", false, false}, // anchored to the root
+		{"docs/img.png", false, true},
+		{"docs/a/b/img.png", false, true},
+		{"img.png", false, false},
+		{"#hash", false, true},
+		{"main.go", false, false},
+		{".", true, false},
+	}
+	for _, c := range cases {
+		if got := m.Match(c.path, c.isDir); got != c.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", c.path, c.isDir, got, c.want)
+		}
+	}
+}
+
+func TestMatchCharClass(t *testing.T) {
+	m := Parse([]byte("file[0-9].txt\nv[!0-9]*"))
+	if !m.Match("file3.txt", false) || m.Match("filex.txt", false) {
+		t.Error("[0-9] class not honoured")
+	}
+	if !m.Match("vendor", true) || m.Match("v1", true) {
+		t.Error("[!...] negated class not honoured")
+	}
+}
+
+func TestNilMatcher(t *testing.T) {
+	var m *Matcher
+	if m.Match("anything", false) || !m.Empty() {
+		t.Error("nil matcher must ignore nothing")
+	}
+}
+
+func TestForWorkspaceCachesAndReloads(t *testing.T) {
+	dir := t.TempDir()
+	if m := ForWorkspace(dir); !m.Empty() {
+		t.Fatal("missing file should ignore nothing")
+	}
+
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte("build/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	first := ForWorkspace(dir)
+	if !first.Match("build", true) {
+		t.Fatal("build/ should be ignored")
+	}
+	if ForWorkspace(dir) != first {
+		t.Error("unchanged file should be served from the cache")
+	}
+
+	if err := os.WriteFile(path, []byte("out/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(2 * time.Second)
+	os.Chtimes(path, later, later)
+	m := ForWorkspace(dir)
+	if m.Match("build", true) || !m.Match("out", true) {
+		t.Error("changed file should be re-parsed")
+	}
+}
+
+func TestRel(t *testing.T) {
+	ws := t.TempDir()
+	if got := Rel(ws, filepath.Join(ws, "a", "b.go")); got != "a/b.go" {
+		t.Errorf("Rel = %q", got)
+	}
+	if got := Rel(ws, filepath.Dir(ws)); got != "" {
+		t.Errorf("outside path should give empty rel, got %q", got)
+	}
+}
@@ -0,0 +1,187 @@
+// Package journal keeps date-stamped daily notes in the workspace
+// (notes/YYYY-MM-DD.md): one line per agent run and per journal_append
+// call, plus an end-of-day summary written by the Summarizer. The notes are
+// plain markdown, so they double as a work log the user can read, edit and
+// commit.
+package journal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DayLayout is the date format of note file names and /journal arguments.
+const DayLayout = "2006-01-02"
+
+// SummaryHeading starts the end-of-day summary section of a note.
+const SummaryHeading = "## 当日总结"
+
+// Journal appends to and reads the daily notes in one directory. Safe for
+// concurrent use.
+type Journal struct {
+	mu  sync.Mutex
+	dir string
+	now func() time.Time // overridden in tests
+}
+
+// New creates a journal writing to dir. The directory is created on the
+// first append.
+func New(dir string) *Journal {
+	return &Journal{dir: dir, now: time.Now}
+}
+
+// Dir returns the notes directory.
+func (j *Journal) Dir() string { return j.dir }
+
+// Path returns the note file of day.
+func (j *Journal) Path(day time.Time) string {
+	return filepath.Join(j.dir, day.Format(DayLayout)+".md")
+}
+
+// Append adds a timestamped entry to today's note, creating the note with a
+// date heading. Continuation lines of a multi-line entry are indented so
+// the entry stays one list item.
+func (j *Journal) Append(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("journal: empty entry")
+	}
+	now := j.now()
+	line := fmt.Sprintf("- %s %s\n", now.Format("15:04"), strings.ReplaceAll(text, "\n", "\n  "))
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := os.MkdirAll(j.dir, 0o755); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	path := j.Path(now)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() == 0 {
+		line = fmt.Sprintf("# %s\n\n", now.Format(DayLayout)) + line
+	}
+	if _, err := f.WriteString(line); err != nil {
+		return fmt.Errorf("journal: %w", err)
+	}
+	return nil
+}
+
+// Read returns the note of day, or "" when there is none.
+func (j *Journal) Read(day time.Time) (string, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	data, err := os.ReadFile(j.Path(day))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("journal: %w", err)
+	}
+	return string(data), nil
+}
+
+// AddSummary appends the end-of-day summary section to day's note. It
+// reports false without writing when the note already has one.
+func (j *Journal) AddSummary(day time.Time, summary string) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	path := j.Path(day)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("journal: %w", err)
+	}
+	if hasSummary(string(data)) {
+		return false, nil
+	}
+	if err := os.MkdirAll(j.dir, 0o755); err != nil {
+		return false, fmt.Errorf("journal: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return false, fmt.Errorf("journal: %w", err)
+	}
+	defer f.Close()
+	section := fmt.Sprintf("\n%s\n\n%s\n", SummaryHeading, strings.TrimSpace(summary))
+	if len(data) == 0 {
+		section = fmt.Sprintf("# %s\n", day.Format(DayLayout)) + section
+	}
+	if _, err := f.WriteString(section); err != nil {
+		return false, fmt.Errorf("journal: %w", err)
+	}
+	return true, nil
+}
+
+func hasSummary(note string) bool {
+	return strings.Contains(note, "\n"+SummaryHeading+"\n")
+}
+
+// Days returns the dates that have a note, newest first.
+func (j *Journal) Days() ([]time.Time, error) {
+	entries, err := os.ReadDir(j.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("journal: %w", err)
+	}
+	var days []time.Time
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".md")
+		if !ok || e.IsDir() {
+			continue
+		}
+		if day, err := time.ParseInLocation(DayLayout, name, time.Local); err == nil {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(a, b int) bool { return days[a].After(days[b]) })
+	return days, nil
+}
+
+// Hit is a note line matching a search.
+type Hit struct {
+	Day  time.Time
+	Line string
+}
+
+// Search returns the lines containing query (case-insensitive), newest day
+// first, at most max hits.
+func (j *Journal) Search(query string, max int) ([]Hit, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, nil
+	}
+	days, err := j.Days()
+	if err != nil {
+		return nil, err
+	}
+	var hits []Hit
+	for _, day := range days {
+		f, err := os.Open(j.Path(day))
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if strings.Contains(strings.ToLower(sc.Text()), query) {
+				hits = append(hits, Hit{Day: day, Line: strings.TrimSpace(sc.Text())})
+				if len(hits) >= max {
+					f.Close()
+					return hits, nil
+				}
+			}
+		}
+		f.Close()
+	}
+	return hits, nil
+}
@@ -0,0 +1,108 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+func at(day string, hm string) time.Time {
+	t, _ := time.ParseInLocation(DayLayout+" 15:04", day+" "+hm, time.Local)
+	return t
+}
+
+func TestJournal_AppendReadDaysSearch(t *testing.T) {
+	j := New(filepath.Join(t.TempDir(), "notes"))
+	j.now = func() time.Time { return at("2026-10-16", "09:05") }
+	if err := j.Append("修复登录 bug"); err != nil {
+		t.Fatal(err)
+	}
+	j.now = func() time.Time { return at("2026-10-17", "14:30") }
+	j.Append("第一行\n第二行")
+	j.Append("Deploy staging")
+	if err := j.Append("  "); err == nil {
+		t.Error("empty entry accepted")
+	}
+
+	note, err := j.Read(at("2026-10-17", "00:00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# 2026-10-17\n\n- 14:30 第一行\n  第二行\n- 14:30 Deploy staging\n"
+	if note != want {
+		t.Errorf("note = %q, want %q", note, want)
+	}
+	if note, _ := j.Read(at("2026-10-01", "00:00")); note != "" {
+		t.Errorf("missing day = %q", note)
+	}
+
+	os.WriteFile(filepath.Join(j.Dir(), "README.md"), []byte("x"), 0o644)
+	days, err := j.Days()
+	if err != nil || len(days) != 2 || days[0].Format(DayLayout) != "2026-10-17" {
+		t.Fatalf("Days = %v, %v", days, err)
+	}
+
+	hits, _ := j.Search("DEPLOY", 10)
+	if len(hits) != 1 || hits[0].Line != "- 14:30 Deploy staging" || hits[0].Day.Format(DayLayout) != "2026-10-17" {
+		t.Errorf("Search = %+v", hits)
+	}
+	if hits, _ := j.Search("行", 1); len(hits) != 1 {
+		t.Errorf("Search max = %+v", hits)
+	}
+}
+
+// summaryProvider returns a fixed summary and counts calls.
+type summaryProvider struct{ calls *int }
+
+func (p summaryProvider) CallLLM(ctx context.Context, msgs []llm.Message) (llm.Message, error) {
+	if llm.PriorityFrom(ctx) != llm.PriorityBackground {
+		return llm.Message{}, fmt.Errorf("summary call not tagged as background work")
+	}
+	*p.calls++
+	return llm.Message{Role: llm.RoleAssistant, Content: "- 修复了登录 bug\n"}, nil
+}
+func (p summaryProvider) CallLLMStream(ctx context.Context, msgs []llm.Message, _ llm.StreamCallback) (llm.Message, error) {
+	return p.CallLLM(ctx, msgs)
+}
+func (p summaryProvider) CallLLMWithTools(ctx context.Context, msgs []llm.Message, _ []llm.ToolDefinition) (llm.Message, error) {
+	return p.CallLLM(ctx, msgs)
+}
+func (summaryProvider) IsToolCallingEnabled() bool { return false }
+
+func TestSummarizer_SummarizesOnce(t *testing.T) {
+	j := New(t.TempDir())
+	day := at("2026-10-17", "00:00")
+	var calls int
+	s := NewSummarizer(j, summaryProvider{&calls}, 23*time.Hour)
+
+	if ok, err := s.Summarize(context.Background(), day); ok || err != nil || calls != 0 {
+		t.Fatalf("empty day: ok=%v err=%v calls=%d", ok, err, calls)
+	}
+	j.now = func() time.Time { return at("2026-10-17", "10:00") }
+	j.Append("✅ 修复登录 bug")
+	if ok, err := s.Summarize(context.Background(), day); !ok || err != nil {
+		t.Fatalf("Summarize = %v, %v", ok, err)
+	}
+	if ok, _ := s.Summarize(context.Background(), day); ok || calls != 1 {
+		t.Errorf("second summary: ok=%v calls=%d", ok, calls)
+	}
+	note, _ := j.Read(day)
+	if !strings.HasSuffix(note, "\n"+SummaryHeading+"\n\n- 修复了登录 bug\n") {
+		t.Errorf("note = %q", note)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	if d, err := ParseClock("23:30"); err != nil || d != 23*time.Hour+30*time.Minute {
+		t.Errorf("ParseClock = %v, %v", d, err)
+	}
+	if _, err := ParseClock("25:00"); err == nil {
+		t.Error("invalid time accepted")
+	}
+}
@@ -0,0 +1,110 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+// summaryInputRunes caps the note text sent to the model.
+const summaryInputRunes = 12000
+
+const summarySystemPrompt = "你是工作日志助手。根据用户给出的当天工作日志（每行是一次智能体运行或一条手动记录）写当日总结：" +
+	"完成了什么、失败或未完成的事项、值得明天跟进的问题。用简洁的 markdown 列表，不超过 10 条，不要复述时间戳，不要编造日志中没有的内容。"
+
+// Summarizer writes the end-of-day summary of the journal's notes.
+type Summarizer struct {
+	journal  *Journal
+	provider llm.LLMProvider
+	at       time.Duration // time of day, from midnight
+}
+
+// NewSummarizer creates a summarizer that runs daily at the time of day at
+// (e.g. 23h30m).
+func NewSummarizer(j *Journal, provider llm.LLMProvider, at time.Duration) *Summarizer {
+	return &Summarizer{journal: j, provider: provider, at: at}
+}
+
+// ParseClock parses a "HH:MM" time of day.
+func ParseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("journal: invalid time of day %q, want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Summarize writes the summary of day's note. It reports false without
+// calling the model when the note is missing, empty or already summarized.
+func (s *Summarizer) Summarize(ctx context.Context, day time.Time) (bool, error) {
+	note, err := s.journal.Read(day)
+	if err != nil {
+		return false, err
+	}
+	if hasSummary(note) || !strings.Contains(note, "\n- ") {
+		return false, nil
+	}
+	// Summaries are scheduled work nobody waits on: yield to interactive calls
+	ctx = llm.WithPriority(llm.WithSession(ctx, "journal"), llm.PriorityBackground)
+	resp, err := s.provider.CallLLM(llm.WithModelRole(ctx, llm.ModelSummarize), []llm.Message{
+		{Role: llm.RoleSystem, Content: summarySystemPrompt},
+		{Role: llm.RoleUser, Content: util.TruncateRunes(note, summaryInputRunes)},
+	})
+	if err != nil {
+		return false, fmt.Errorf("journal: summarize %s: %w", day.Format(DayLayout), err)
+	}
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return false, fmt.Errorf("journal: summarize %s: empty summary", day.Format(DayLayout))
+	}
+	return s.journal.AddSummary(day, summary)
+}
+
+// Run summarizes each day at the configured time until ctx is done. On
+// start it catches up on yesterday (and on today when started after the
+// summary time), so a server that was down at the summary time still
+// writes it.
+func (s *Summarizer) Run(ctx context.Context) {
+	now := time.Now()
+	today := midnight(now)
+	s.summarizeLogged(ctx, today.AddDate(0, 0, -1))
+	if now.Sub(today) >= s.at {
+		s.summarizeLogged(ctx, today)
+	}
+	for {
+		now := time.Now()
+		next := midnight(now).Add(s.at)
+		if !next.After(now) {
+			next = midnight(now).AddDate(0, 0, 1).Add(s.at)
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.summarizeLogged(ctx, midnight(next))
+	}
+}
+
+func (s *Summarizer) summarizeLogged(ctx context.Context, day time.Time) {
+	ok, err := s.Summarize(ctx, day)
+	switch {
+	case err != nil:
+		log.Printf("[Journal] %v", err)
+	case ok:
+		log.Printf("[Journal] Summary written to %s", s.journal.Path(day))
+	}
+}
+
+// midnight returns the start of t's day in its location.
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
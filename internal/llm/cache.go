@@ -0,0 +1,257 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CachePolicy says whether the response to a call may come from a
+// ResponseCache. Calls are never cached unless their context opts in.
+type CachePolicy int
+
+const (
+	CacheNever         CachePolicy = iota
+	CacheAlways                    // identical prompts may reuse a stored response (chat)
+	CacheDeterministic             // only when the model runs at temperature 0 (agent decisions)
+)
+
+// WithCachePolicy tags ctx with the cache policy of the calls made with it.
+func WithCachePolicy(ctx context.Context, p CachePolicy) context.Context {
+	return context.WithValue(ctx, ctxKeyCachePolicy, p)
+}
+
+func cachePolicyFrom(ctx context.Context) CachePolicy {
+	p, _ := ctx.Value(ctxKeyCachePolicy).(CachePolicy)
+	return p
+}
+
+// ResponseCache stores LLM responses on disk, one JSON file per request
+// hash (model + sampling + messages + tools), and serves them until
+// they are older than the TTL. Safe for concurrent use; concurrent misses
+// for the same request both call the model and the last write wins.
+type ResponseCache struct {
+	dir    string
+	ttl    time.Duration
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// CacheStats is a snapshot of cache effectiveness since startup.
+type CacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+}
+
+// cacheEntry is the on-disk form of a cached response.
+type cacheEntry struct {
+	Created  time.Time `json:"created"`
+	Model    string    `json:"model"`
+	Response Message   `json:"response"`
+}
+
+// NewResponseCache opens (creating if needed) a cache in dir whose entries
+// expire after ttl.
+func NewResponseCache(dir string, ttl time.Duration) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &ResponseCache{dir: dir, ttl: ttl}, nil
+}
+
+// Stats returns the hit and miss counts.
+func (c *ResponseCache) Stats() CacheStats {
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+}
+
+// cacheKey hashes everything that determines the response.
+func cacheKey(model string, sampling Sampling, messages []Message, tools []ToolDefinition) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	enc.Encode(model)                //nolint:errcheck // hash.Hash writes never fail
+	enc.Encode(sampling.Temperature) //nolint:errcheck
+	if sampling.TopP != nil {
+		// Only when set, so entries stored before top_p existed stay valid.
+		enc.Encode(sampling.TopP) //nolint:errcheck
+	}
+	enc.Encode(messages) //nolint:errcheck
+	enc.Encode(tools)    //nolint:errcheck
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path shards entries by the first two hex digits to keep directories small.
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get returns the live entry for key. Expired and unreadable entries are
+// removed.
+func (c *ResponseCache) get(key string) (Message, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Message{}, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || c.expired(e.Created) {
+		os.Remove(path)
+		return Message{}, false
+	}
+	return e.Response, true
+}
+
+// put stores resp under key, writing through a temp file so readers never
+// see a partial entry.
+func (c *ResponseCache) put(key, model string, resp Message) {
+	data, err := json.Marshal(cacheEntry{Created: time.Now(), Model: model, Response: resp})
+	if err != nil {
+		return
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		log.Printf("[LLMCache] write failed: %v", err)
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		log.Printf("[LLMCache] write failed: %v", err)
+		return
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		log.Printf("[LLMCache] write failed: %v", err)
+	}
+}
+
+func (c *ResponseCache) expired(created time.Time) bool {
+	return c.ttl > 0 && time.Since(created) > c.ttl
+}
+
+// Prune removes expired entries and leftover temp files and returns how
+// many files it removed.
+func (c *ResponseCache) Prune() (int, error) {
+	removed := 0
+	err := filepath.WalkDir(c.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		// An entry is written once, so its mtime is its creation time.
+		if strings.HasSuffix(path, ".tmp") || c.expired(info.ModTime()) {
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// CachingProvider serves calls whose context opts in (see WithCachePolicy)
+// from a ResponseCache and stores fresh responses in it. Place it outside
+// the Scheduler so that hits do not wait for a slot.
+type CachingProvider struct {
+	inner    LLMProvider
+	cache    *ResponseCache
+	model    string        // default model, for providers that cannot name theirs
+	sampling SamplingTable // sampling of each role; a nil temperature is not deterministic
+}
+
+// NewCachingProvider returns inner wrapped with cache, or inner itself when
+// cache is nil.
+func NewCachingProvider(inner LLMProvider, cache *ResponseCache, model string, sampling SamplingTable) LLMProvider {
+	if cache == nil {
+		return inner
+	}
+	return &CachingProvider{inner: inner, cache: cache, model: model, sampling: sampling}
+}
+
+// lookup returns the cache key for the call in ctx ("" = not cacheable)
+// and the cached response, if any.
+func (p *CachingProvider) lookup(ctx context.Context, messages []Message, tools []ToolDefinition) (key, model string, resp Message, hit bool) {
+	role := ModelRoleFrom(ctx)
+	sampling := p.sampling.For(role)
+	switch cachePolicyFrom(ctx) {
+	case CacheAlways:
+	case CacheDeterministic:
+		if sampling.Temperature == nil || *sampling.Temperature != 0 {
+			return "", "", Message{}, false
+		}
+	default:
+		return "", "", Message{}, false
+	}
+	model = ModelFor(p.inner, role)
+	if model == "" {
+		model = p.model
+	}
+	key = cacheKey(model, sampling, messages, tools)
+	if resp, hit = p.cache.get(key); hit {
+		p.cache.hits.Add(1)
+		log.Printf("[LLMCache] Hit %s (%s)", key[:12], model)
+	} else {
+		p.cache.misses.Add(1)
+	}
+	return key, model, resp, hit
+}
+
+// store caches a successful, non-empty response.
+func (p *CachingProvider) store(key, model string, resp Message, err error) {
+	if key == "" || err != nil || (strings.TrimSpace(resp.Content) == "" && len(resp.ToolCalls) == 0) {
+		return
+	}
+	p.cache.put(key, model, resp)
+}
+
+func (p *CachingProvider) CallLLM(ctx context.Context, messages []Message) (Message, error) {
+	key, model, resp, hit := p.lookup(ctx, messages, nil)
+	if hit {
+		return resp, nil
+	}
+	resp, err := p.inner.CallLLM(ctx, messages)
+	p.store(key, model, resp, err)
+	return resp, err
+}
+
+// CallLLMStream replays a cached response as a single chunk.
+func (p *CachingProvider) CallLLMStream(ctx context.Context, messages []Message, onChunk StreamCallback) (Message, error) {
+	key, model, resp, hit := p.lookup(ctx, messages, nil)
+	if hit {
+		if onChunk != nil && resp.Content != "" {
+			onChunk(resp.Content)
+		}
+		return resp, nil
+	}
+	resp, err := p.inner.CallLLMStream(ctx, messages, onChunk)
+	p.store(key, model, resp, err)
+	return resp, err
+}
+
+func (p *CachingProvider) CallLLMWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	key, model, resp, hit := p.lookup(ctx, messages, tools)
+	if hit {
+		return resp, nil
+	}
+	resp, err := p.inner.CallLLMWithTools(ctx, messages, tools)
+	p.store(key, model, resp, err)
+	return resp, err
+}
+
+func (p *CachingProvider) IsToolCallingEnabled() bool  { return p.inner.IsToolCallingEnabled() }
+func (p *CachingProvider) SupportsVision() bool        { return SupportsVision(p.inner) }
+func (p *CachingProvider) ModelFor(r ModelRole) string { return ModelFor(p.inner, r) }
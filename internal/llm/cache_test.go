@@ -0,0 +1,137 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingProvider answers with a fixed message and counts calls.
+type countingProvider struct {
+	calls int
+	resp  Message
+}
+
+func (p *countingProvider) CallLLM(context.Context, []Message) (Message, error) {
+	p.calls++
+	return p.resp, nil
+}
+func (p *countingProvider) CallLLMStream(ctx context.Context, m []Message, onChunk StreamCallback) (Message, error) {
+	resp, err := p.CallLLM(ctx, m)
+	onChunk(resp.Content)
+	return resp, err
+}
+func (p *countingProvider) CallLLMWithTools(ctx context.Context, m []Message, _ []ToolDefinition) (Message, error) {
+	return p.CallLLM(ctx, m)
+}
+func (p *countingProvider) IsToolCallingEnabled() bool { return true }
+
+func TestCachingProvider_Policies(t *testing.T) {
+	cache, err := NewResponseCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner := &countingProvider{resp: Message{Role: RoleAssistant, Content: "42"}}
+	zero, warm := float32(0), float32(0.7)
+	msgs := []Message{{Role: RoleUser, Content: "answer?"}}
+	bg := context.Background()
+
+	for _, tc := range []struct {
+		name        string
+		ctx         context.Context
+		temperature *float32
+		wantCalls   int // inner calls for two identical requests
+	}{
+		{"untagged", bg, &zero, 2},
+		{"always", WithCachePolicy(bg, CacheAlways), &warm, 1},
+		{"deterministic at temperature 0", WithCachePolicy(bg, CacheDeterministic), &zero, 1},
+		{"deterministic at temperature 0.7", WithCachePolicy(bg, CacheDeterministic), &warm, 2},
+		{"deterministic at API default", WithCachePolicy(bg, CacheDeterministic), nil, 2},
+		{"deterministic at decide temperature 0", WithModelRole(WithCachePolicy(bg, CacheDeterministic), ModelDecide), &warm, 1},
+		{"deterministic at think temperature 0.7", WithModelRole(WithCachePolicy(bg, CacheDeterministic), ModelThink), &warm, 2},
+	} {
+		inner.calls = 0
+		// A fresh model name per case keeps the cases' keys apart.
+		// Decide runs at temperature 0 whatever the default.
+		sampling := SamplingTable{
+			Default: Sampling{Temperature: tc.temperature},
+			Roles:   map[ModelRole]Sampling{ModelDecide: {Temperature: &zero}},
+		}
+		p := NewCachingProvider(inner, cache, "model-"+tc.name, sampling)
+		for i := 0; i < 2; i++ {
+			if resp, err := p.CallLLM(tc.ctx, msgs); err != nil || resp.Content != "42" {
+				t.Fatalf("%s: call %d = %+v, %v", tc.name, i, resp, err)
+			}
+		}
+		if inner.calls != tc.wantCalls {
+			t.Errorf("%s: inner calls = %d, want %d", tc.name, inner.calls, tc.wantCalls)
+		}
+	}
+	if st := cache.Stats(); st.Hits != 3 || st.Misses != 3 {
+		t.Errorf("stats = %+v, want 3 hits and 3 misses", st)
+	}
+}
+
+func TestCachingProvider_KeyAndPersistence(t *testing.T) {
+	dir := t.TempDir()
+	ctx := WithCachePolicy(context.Background(), CacheAlways)
+	inner := &countingProvider{resp: Message{Role: RoleAssistant, Content: "cached"}}
+	msgs := []Message{{Role: RoleUser, Content: "q"}}
+
+	cache, _ := NewResponseCache(dir, time.Hour)
+	p := NewCachingProvider(inner, cache, "m", SamplingTable{})
+	p.CallLLM(ctx, msgs)
+	p.CallLLMWithTools(ctx, msgs, []ToolDefinition{{Name: "t"}}) // tools are part of the key
+	p.CallLLM(ctx, append(msgs, Message{Role: RoleUser, Content: "more"}))
+	if inner.calls != 3 {
+		t.Fatalf("inner calls = %d, want 3 distinct requests", inner.calls)
+	}
+
+	// A new cache over the same directory (e.g. after a restart) serves the
+	// stored response, also to a streaming call.
+	reopened, _ := NewResponseCache(dir, time.Hour)
+	var chunks []string
+	resp, err := NewCachingProvider(inner, reopened, "m", SamplingTable{}).CallLLMStream(ctx, msgs, func(c string) { chunks = append(chunks, c) })
+	if err != nil || resp.Content != "cached" || inner.calls != 3 || len(chunks) != 1 || chunks[0] != "cached" {
+		t.Errorf("after reopen: %+v, %v, inner calls %d, chunks %q", resp, err, inner.calls, chunks)
+	}
+
+	// Empty responses are not cached.
+	inner.resp = Message{Role: RoleAssistant}
+	p.CallLLM(ctx, []Message{{Role: RoleUser, Content: "empty"}})
+	p.CallLLM(ctx, []Message{{Role: RoleUser, Content: "empty"}})
+	if inner.calls != 5 {
+		t.Errorf("inner calls = %d, want empty response fetched twice", inner.calls)
+	}
+}
+
+func TestResponseCache_TTL(t *testing.T) {
+	dir := t.TempDir()
+	cache, _ := NewResponseCache(dir, time.Hour)
+	key := cacheKey("m", Sampling{}, []Message{{Role: RoleUser, Content: "q"}}, nil)
+	cache.put(key, "m", Message{Content: "old"})
+	if _, ok := cache.get(key); !ok {
+		t.Fatal("fresh entry missed")
+	}
+
+	// Age the entry past the TTL: get drops it, Prune removes it.
+	stale := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(cache.path(key), stale, stale)
+	if n, err := cache.Prune(); err != nil || n != 1 {
+		t.Errorf("Prune = %d, %v; want 1 removed", n, err)
+	}
+	cache.put(key, "m", Message{Content: "old"})
+	cache.ttl = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, ok := cache.get(key); ok {
+		t.Error("expired entry served")
+	}
+	if _, err := os.Stat(cache.path(key)); !os.IsNotExist(err) {
+		t.Error("expired entry left on disk")
+	}
+	if entries, _ := filepath.Glob(filepath.Join(dir, "*", "*.tmp")); len(entries) != 0 {
+		t.Errorf("temp files left: %v", entries)
+	}
+}
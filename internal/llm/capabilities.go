@@ -157,3 +157,43 @@ func DetectToolCallingCapability(modelName string) bool {
 	// Default: assume FC support (most modern models do)
 	return true
 }
+
+// DetectVisionCapability determines if a model accepts image input, from
+// known model families and vision keywords. Unknown models are assumed
+// text-only: sending images to them fails the whole request.
+func DetectVisionCapability(modelName string) bool {
+	baseName := normalizeModelName(modelName)
+
+	// Known multimodal families
+	knownVisionModels := []string{
+		// OpenAI
+		"gpt-4o", "gpt-4.1", "gpt-4-turbo", "gpt-4-vision", "gpt-5",
+		"o1-2024", "o3", "o4-mini",
+		// Anthropic
+		"claude-3", "claude-sonnet", "claude-opus", "claude-haiku",
+		// Google
+		"gemini",
+		// Alibaba Qwen
+		"qwen-vl", "qvq",
+		// Moonshot Kimi
+		"kimi-vl", "kimi-latest",
+		// Open models
+		"llava", "pixtral", "minicpm-v", "internvl", "llama-3.2-11b-vision", "llama-3.2-90b-vision", "llama-4",
+	}
+	if baseName == "o1" {
+		return true // o1-mini and o1-preview are text-only
+	}
+	for _, known := range knownVisionModels {
+		if strings.HasPrefix(baseName, known) {
+			return true
+		}
+	}
+
+	// Keyword-based detection, e.g. qwen2.5-vl-72b, glm-4v-plus, doubao-vision-pro
+	for _, kw := range []string{"vision", "-vl", "4v", "4.5v", "-omni"} {
+		if strings.Contains(baseName, kw) {
+			return true
+		}
+	}
+	return false
+}
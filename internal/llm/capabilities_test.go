@@ -89,3 +89,33 @@ func TestDetectToolCallingCapability(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectVisionCapability(t *testing.T) {
+	tests := []struct {
+		modelName   string
+		wantSupport bool
+	}{
+		{"gpt-4o", true},
+		{"gpt-4o-mini", true},
+		{"gpt-4.1-nano", true},
+		{"o1", true},
+		{"o1-mini", false},
+		{"claude-sonnet-4-20250514", true},
+		{"gemini-2.5-pro", true},
+		{"Qwen/Qwen2.5-VL-72B-Instruct", true},
+		{"glm-4v-plus", true},
+		{"doubao-1.5-vision-pro", true},
+		{"deepseek-chat", false},
+		{"gpt-3.5-turbo", false},
+		{"qwen-2.5-72b-instruct", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.modelName, func(t *testing.T) {
+			if got := DetectVisionCapability(tt.modelName); got != tt.wantSupport {
+				t.Errorf("DetectVisionCapability(%q) = %v, want %v", tt.modelName, got, tt.wantSupport)
+			}
+		})
+	}
+}
@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
 	openailib "github.com/sashabaranov/go-openai"
 )
 
@@ -44,12 +46,16 @@ func NewClient(config *Config) (*Client, error) {
 	// Timeout is configurable via LLM_HTTP_TIMEOUT (seconds); default 300s to
 	// accommodate slow reasoning models (e.g. Kimi-K2.5, DeepSeek-R1).
 	httpTimeout := time.Duration(config.HTTPTimeout) * time.Second
-	clientConfig.HTTPClient = &http.Client{Timeout: httpTimeout}
+	clientConfig.HTTPClient = &http.Client{
+		Timeout:   httpTimeout,
+		Transport: retryAfterTransport{base: http.DefaultTransport},
+	}
 
 	// Eagerly resolve and cache auto-detected modes so that per-call methods
 	// can use the cached fields directly without repeated detection + log noise.
 	config.ResolveThinkingMode()
 	config.ResolveToolCallMode()
+	config.ResolveVision()
 
 	return &Client{
 		client: openailib.NewClientWithConfig(clientConfig),
@@ -75,10 +81,8 @@ func (c *Client) CallLLM(ctx context.Context, messages []llm.Message) (llm.Messa
 	// Convert to OpenAI format
 	openaiMsgs := make([]openailib.ChatCompletionMessage, len(messages))
 	for i, msg := range messages {
-		openaiMsgs[i] = openailib.ChatCompletionMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
+		openaiMsgs[i] = openailib.ChatCompletionMessage{Role: msg.Role}
+		c.setContent(&openaiMsgs[i], msg)
 	}
 
 	// Build request
@@ -86,9 +90,7 @@ func (c *Client) CallLLM(ctx context.Context, messages []llm.Message) (llm.Messa
 		Model:    c.config.Model,
 		Messages: openaiMsgs,
 	}
-	if c.config.Temperature != nil {
-		req.Temperature = *c.config.Temperature
-	}
+	c.applySampling(ctx, &req)
 	if c.config.MaxTokens > 0 {
 		req.MaxTokens = c.config.MaxTokens
 	}
@@ -99,31 +101,21 @@ func (c *Client) CallLLM(ctx context.Context, messages []llm.Message) (llm.Messa
 
 	// Execute with retries
 	var resp openailib.ChatCompletionResponse
-	var lastErr error
-
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
-		resp, lastErr = c.client.CreateChatCompletion(ctx, req)
-		if lastErr == nil {
-			break
-		}
-		if attempt < c.config.MaxRetries {
-			wait := time.Duration(attempt+1) * time.Second
-			log.Printf("[LLM] Retry %d/%d after %v, error: %v", attempt+1, c.config.MaxRetries, wait, lastErr)
-			select {
-			case <-time.After(wait):
-			case <-ctx.Done():
-				return llm.Message{}, ctx.Err()
-			}
+	err := c.withRetry(ctx, "Retry", func(ctx context.Context) (err error) {
+		resp, err = c.client.CreateChatCompletion(ctx, req)
+		return err
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return llm.Message{}, ctx.Err()
 		}
-	}
-
-	if lastErr != nil {
-		return llm.Message{}, fmt.Errorf("LLM call failed after %d retries: %w", c.config.MaxRetries, lastErr)
+		return llm.Message{}, fmt.Errorf("LLM call failed after %d retries: %w", c.config.MaxRetries, err)
 	}
 
 	if len(resp.Choices) == 0 {
 		return llm.Message{}, fmt.Errorf("no choices returned from LLM")
 	}
+	telemetry.RecordTokenUsage(ctx, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 
 	return llm.Message{
 		Role:    llm.RoleAssistant,
@@ -147,10 +139,8 @@ func (c *Client) CallLLMStream(ctx context.Context, messages []llm.Message, onCh
 	// Convert to OpenAI format
 	openaiMsgs := make([]openailib.ChatCompletionMessage, len(messages))
 	for i, msg := range messages {
-		openaiMsgs[i] = openailib.ChatCompletionMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
+		openaiMsgs[i] = openailib.ChatCompletionMessage{Role: msg.Role}
+		c.setContent(&openaiMsgs[i], msg)
 	}
 
 	req := openailib.ChatCompletionRequest{
@@ -158,9 +148,7 @@ func (c *Client) CallLLMStream(ctx context.Context, messages []llm.Message, onCh
 		Messages: openaiMsgs,
 		Stream:   true,
 	}
-	if c.config.Temperature != nil {
-		req.Temperature = *c.config.Temperature
-	}
+	c.applySampling(ctx, &req)
 	if c.config.MaxTokens > 0 {
 		req.MaxTokens = c.config.MaxTokens
 	}
@@ -178,6 +166,8 @@ func (c *Client) CallLLMStream(ctx context.Context, messages []llm.Message, onCh
 	defer stream.Close()
 
 	var sb strings.Builder
+	var calls streamToolCalls
+	var usage *openailib.Usage
 	for {
 		chunkResp, err := stream.Recv()
 		if errors.Is(err, io.EOF) {
@@ -192,18 +182,29 @@ func (c *Client) CallLLMStream(ctx context.Context, messages []llm.Message, onCh
 			return llm.Message{}, fmt.Errorf("stream recv error: %w", err)
 		}
 
+		// Gateways (one-api, OpenRouter, DeepSeek) send usage in the last
+		// chunk, often with an empty choices array
+		if chunkResp.Usage != nil {
+			usage = chunkResp.Usage
+		}
 		if len(chunkResp.Choices) > 0 {
 			// Collect normal content
 			if delta := chunkResp.Choices[0].Delta.Content; delta != "" {
 				sb.WriteString(delta)
 				onChunk(delta)
 			}
+			// Tool calls arrive as argument fragments keyed by index
+			calls.add(chunkResp.Choices[0].Delta.ToolCalls)
 		}
 	}
+	if usage != nil {
+		telemetry.RecordTokenUsage(ctx, usage.PromptTokens, usage.CompletionTokens)
+	}
 
 	return llm.Message{
-		Role:    llm.RoleAssistant,
-		Content: sb.String(),
+		Role:      llm.RoleAssistant,
+		Content:   sb.String(),
+		ToolCalls: calls.result(),
 	}, nil
 }
 
@@ -217,10 +218,8 @@ func (c *Client) CallLLMWithTools(ctx context.Context, messages []llm.Message, t
 	// Convert messages to OpenAI format
 	openaiMsgs := make([]openailib.ChatCompletionMessage, len(messages))
 	for i, msg := range messages {
-		openaiMsgs[i] = openailib.ChatCompletionMessage{
-			Role:    msg.Role,
-			Content: msg.Content,
-		}
+		openaiMsgs[i] = openailib.ChatCompletionMessage{Role: msg.Role}
+		c.setContent(&openaiMsgs[i], msg)
 		// Handle tool result messages (role="tool")
 		if msg.Role == llm.RoleTool && msg.ToolCallID != "" {
 			openaiMsgs[i].ToolCallID = msg.ToolCallID
@@ -264,9 +263,7 @@ func (c *Client) CallLLMWithTools(ctx context.Context, messages []llm.Message, t
 		Messages: openaiMsgs,
 		Tools:    openaiTools,
 	}
-	if c.config.Temperature != nil {
-		req.Temperature = *c.config.Temperature
-	}
+	c.applySampling(ctx, &req)
 	if c.config.MaxTokens > 0 {
 		req.MaxTokens = c.config.MaxTokens
 	}
@@ -277,31 +274,21 @@ func (c *Client) CallLLMWithTools(ctx context.Context, messages []llm.Message, t
 
 	// Execute with retries
 	var resp openailib.ChatCompletionResponse
-	var lastErr error
-
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
-		resp, lastErr = c.client.CreateChatCompletion(ctx, req)
-		if lastErr == nil {
-			break
-		}
-		if attempt < c.config.MaxRetries {
-			wait := time.Duration(attempt+1) * time.Second
-			log.Printf("[LLM] FC retry %d/%d after %v, error: %v", attempt+1, c.config.MaxRetries, wait, lastErr)
-			select {
-			case <-time.After(wait):
-			case <-ctx.Done():
-				return llm.Message{}, ctx.Err()
-			}
+	err := c.withRetry(ctx, "FC retry", func(ctx context.Context) (err error) {
+		resp, err = c.client.CreateChatCompletion(ctx, req)
+		return err
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return llm.Message{}, ctx.Err()
 		}
-	}
-
-	if lastErr != nil {
-		return llm.Message{}, fmt.Errorf("FC call failed after %d retries: %w", c.config.MaxRetries, lastErr)
+		return llm.Message{}, fmt.Errorf("FC call failed after %d retries: %w", c.config.MaxRetries, err)
 	}
 
 	if len(resp.Choices) == 0 {
 		return llm.Message{}, fmt.Errorf("no choices returned from LLM (FC)")
 	}
+	telemetry.RecordTokenUsage(ctx, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
 
 	choice := resp.Choices[0].Message
 
@@ -318,7 +305,7 @@ func (c *Client) CallLLMWithTools(ctx context.Context, messages []llm.Message, t
 			result.ToolCalls[i] = llm.ToolCall{
 				ID:        tc.ID,
 				Name:      tc.Function.Name,
-				Arguments: json.RawMessage(tc.Function.Arguments),
+				Arguments: toolArguments(tc.Function.Arguments),
 			}
 		}
 		names := make([]string, len(result.ToolCalls))
@@ -331,8 +318,116 @@ func (c *Client) CallLLMWithTools(ctx context.Context, messages []llm.Message, t
 	return result, nil
 }
 
+// SupportsVision reports whether image parts are sent to the model.
+func (c *Client) SupportsVision() bool {
+	return c.config.ResolveVision()
+}
+
+// applySampling sets the temperature and top_p of the role ctx is tagged
+// with (see Config.RoleSampling).
+func (c *Client) applySampling(ctx context.Context, req *openailib.ChatCompletionRequest) {
+	s := c.config.Sampling().For(llm.ModelRoleFrom(ctx))
+	if s.Temperature != nil {
+		req.Temperature = explicitZero(*s.Temperature)
+	}
+	if s.TopP != nil {
+		req.TopP = explicitZero(*s.TopP)
+	}
+}
+
+// explicitZero keeps a configured 0 in the request: go-openai omits zero
+// sampling fields, which would leave the provider's default in effect.
+func explicitZero(v float32) float32 {
+	if v == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+	return v
+}
+
+// setContent fills the content of m from msg. Image parts become a
+// multi-part content for vision models; for other models they are dropped
+// with a note, so the model knows an image was there instead of failing
+// the whole request.
+func (c *Client) setContent(m *openailib.ChatCompletionMessage, msg llm.Message) {
+	if len(msg.Parts) == 0 {
+		m.Content = msg.Content
+		return
+	}
+	if !c.config.ResolveVision() {
+		m.Content = msg.Content + fmt.Sprintf("\n\n[%d 张图片已省略：当前模型不支持图像输入]", len(msg.Parts))
+		return
+	}
+	if msg.Content != "" {
+		m.MultiContent = append(m.MultiContent, openailib.ChatMessagePart{
+			Type: openailib.ChatMessagePartTypeText,
+			Text: msg.Content,
+		})
+	}
+	for _, p := range msg.Parts {
+		m.MultiContent = append(m.MultiContent, openailib.ChatMessagePart{
+			Type: openailib.ChatMessagePartTypeImageURL,
+			ImageURL: &openailib.ChatMessageImageURL{
+				URL:    p.ImageURL,
+				Detail: openailib.ImageURLDetail(p.Detail),
+			},
+		})
+	}
+}
+
 // IsToolCallingEnabled reports whether Function Calling is enabled for this client.
 func (c *Client) IsToolCallingEnabled() bool {
 	mode := c.config.ResolveToolCallMode()
 	return mode == "fc"
 }
+
+// streamToolCalls assembles streamed tool calls. The first delta of a call
+// carries its id and name; later deltas with the same index append argument
+// fragments, which may split anywhere (including inside a JSON string).
+type streamToolCalls struct {
+	calls []llm.ToolCall
+	args  []string
+	index map[int]int // delta index → position in calls
+}
+
+func (s *streamToolCalls) add(deltas []openailib.ToolCall) {
+	for _, d := range deltas {
+		// Some gateways omit the index when only one call is streamed
+		idx := 0
+		if d.Index != nil {
+			idx = *d.Index
+		}
+		if s.index == nil {
+			s.index = make(map[int]int)
+		}
+		pos, ok := s.index[idx]
+		if !ok {
+			pos = len(s.calls)
+			s.index[idx] = pos
+			s.calls = append(s.calls, llm.ToolCall{})
+			s.args = append(s.args, "")
+		}
+		if d.ID != "" {
+			s.calls[pos].ID = d.ID
+		}
+		if d.Function.Name != "" {
+			s.calls[pos].Name = d.Function.Name
+		}
+		s.args[pos] += d.Function.Arguments
+	}
+}
+
+func (s *streamToolCalls) result() []llm.ToolCall {
+	for i := range s.calls {
+		s.calls[i].Arguments = toolArguments(s.args[i])
+	}
+	return s.calls
+}
+
+// toolArguments normalizes the arguments of a call to a tool without
+// parameters, which some providers send as "" instead of "{}".
+func toolArguments(args string) json.RawMessage {
+	if strings.TrimSpace(args) == "" {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(args)
+}
@@ -0,0 +1,662 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
+)
+
+// Contract tests against a fake OpenAI-compatible server. Each case mirrors
+// a quirk seen from a real provider or gateway.
+
+// newTestClient points a client at handler, with one retry and fixed modes.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(&Config{
+		APIKey:          "test-key",
+		BaseURL:         srv.URL + "/v1",
+		Model:           "gpt-4o",
+		MaxRetries:      1,
+		HTTPTimeout:     10,
+		ThinkingMode:    "app",
+		ToolCallMode:    "fc",
+		ReasoningEffort: "medium",
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+// writeSSE streams raw wire bytes in the given pieces, flushing after each,
+// so a piece boundary can fall anywhere — inside a JSON string or a rune.
+func writeSSE(w http.ResponseWriter, pieces ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	flusher := w.(http.Flusher)
+	for _, p := range pieces {
+		_, _ = w.Write([]byte(p))
+		flusher.Flush()
+	}
+}
+
+// sseData renders one SSE event carrying v as JSON.
+func sseData(v any) string {
+	b, _ := json.Marshal(v)
+	return "data: " + string(b) + "\n\n"
+}
+
+// chunk is a chat.completion.chunk with a single choice.
+func chunk(delta map[string]any, finish any) map[string]any {
+	return map[string]any{
+		"id":      "chatcmpl-1",
+		"object":  "chat.completion.chunk",
+		"created": 1700000000,
+		"model":   "gpt-4o",
+		"choices": []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finish}},
+	}
+}
+
+// completion is a non-streaming chat.completion with a single choice.
+func completion(message map[string]any, finish any, usage any) map[string]any {
+	resp := map[string]any{
+		"id":      "chatcmpl-1",
+		"object":  "chat.completion",
+		"created": 1700000000,
+		"model":   "gpt-4o",
+		"choices": []any{map[string]any{"index": 0, "message": message, "finish_reason": finish}},
+	}
+	if usage != nil {
+		resp["usage"] = usage
+	}
+	return resp
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// recordSpans installs an in-memory tracer provider for the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+// usageAttrs returns the token counts recorded on the only ended span.
+func usageAttrs(t *testing.T, rec *tracetest.SpanRecorder) (in, out int64, ok bool) {
+	t.Helper()
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range spans[0].Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	inV, ok := m["gen_ai.usage.input_tokens"]
+	if !ok {
+		return 0, 0, false
+	}
+	return inV.AsInt64(), m["gen_ai.usage.output_tokens"].AsInt64(), true
+}
+
+var userMsg = []llm.Message{{Role: llm.RoleUser, Content: "hi"}}
+
+func TestCallLLMStream_SplitsMidRune(t *testing.T) {
+	// "你好🌍" split inside both the 3-byte 好 and the 4-byte 🌍
+	event := sseData(chunk(map[string]any{"content": "你好🌍"}, nil))
+	cut1 := strings.Index(event, "好") + 1
+	cut2 := strings.Index(event, "🌍") + 2
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			sseData(chunk(map[string]any{"role": "assistant", "content": ""}, nil)),
+			event[:cut1], event[cut1:cut2], event[cut2:],
+			sseData(chunk(map[string]any{"content": "！"}, "stop")),
+			"data: [DONE]\n\n",
+		)
+	})
+
+	var chunks []string
+	msg, err := c.CallLLMStream(context.Background(), userMsg, func(s string) { chunks = append(chunks, s) })
+	if err != nil {
+		t.Fatalf("CallLLMStream: %v", err)
+	}
+	if msg.Content != "你好🌍！" {
+		t.Errorf("content = %q, want %q", msg.Content, "你好🌍！")
+	}
+	for _, s := range chunks {
+		if !utf8.ValidString(s) {
+			t.Errorf("chunk %q is not valid UTF-8", s)
+		}
+	}
+	if got := strings.Join(chunks, ""); got != msg.Content {
+		t.Errorf("chunks joined = %q, want %q", got, msg.Content)
+	}
+}
+
+func TestCallLLMStream_SplitsMidEvent(t *testing.T) {
+	// Event boundaries, "data:" prefixes and keep-alive comments split at odd places
+	a := sseData(chunk(map[string]any{"content": "Hello"}, nil))
+	b := sseData(chunk(map[string]any{"content": ", world"}, "stop"))
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, ": keep-alive\n\n", a[:3], a[3:len(a)-1], a[len(a)-1:]+b[:10], b[10:], "data: [DONE]\n\n")
+	})
+
+	msg, err := c.CallLLMStream(context.Background(), userMsg, func(string) {})
+	if err != nil {
+		t.Fatalf("CallLLMStream: %v", err)
+	}
+	if msg.Content != "Hello, world" {
+		t.Errorf("content = %q, want %q", msg.Content, "Hello, world")
+	}
+}
+
+func TestCallLLMStream_ToolCallDeltas(t *testing.T) {
+	tc := func(i int, fields map[string]any) map[string]any {
+		fields["index"] = i
+		return map[string]any{"tool_calls": []any{fields}}
+	}
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			sseData(chunk(map[string]any{"role": "assistant", "content": nil}, nil)),
+			sseData(chunk(tc(0, map[string]any{"id": "call_a", "type": "function",
+				"function": map[string]any{"name": "file_read", "arguments": ""}}), nil)),
+			sseData(chunk(tc(0, map[string]any{"function": map[string]any{"arguments": `{"pa`}}), nil)),
+			// Second call starts before the first one's arguments are complete
+			sseData(chunk(tc(1, map[string]any{"id": "call_b", "type": "function",
+				"function": map[string]any{"name": "list_dir", "arguments": ""}}), nil)),
+			sseData(chunk(tc(0, map[string]any{"function": map[string]any{"arguments": `th":"说明.md"}`}}), nil)),
+			sseData(chunk(map[string]any{}, "tool_calls")),
+			"data: [DONE]\n\n",
+		)
+	})
+
+	msg, err := c.CallLLMStream(context.Background(), userMsg, func(string) {})
+	if err != nil {
+		t.Fatalf("CallLLMStream: %v", err)
+	}
+	if len(msg.ToolCalls) != 2 {
+		t.Fatalf("got %d tool calls, want 2: %+v", len(msg.ToolCalls), msg.ToolCalls)
+	}
+	first, second := msg.ToolCalls[0], msg.ToolCalls[1]
+	if first.ID != "call_a" || first.Name != "file_read" || string(first.Arguments) != `{"path":"说明.md"}` {
+		t.Errorf("first call = %+v (args %s)", first, first.Arguments)
+	}
+	// A parameterless call streamed with "" arguments still yields valid JSON
+	if second.ID != "call_b" || second.Name != "list_dir" || string(second.Arguments) != "{}" {
+		t.Errorf("second call = %+v (args %s)", second, second.Arguments)
+	}
+}
+
+func TestCallLLMStream_PartialOnMidStreamError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w,
+			sseData(chunk(map[string]any{"content": "partial answer"}, nil)),
+			`data: {"error":{"message":"upstream overloaded","type":"server_error"}}`+"\n\n",
+		)
+	})
+
+	msg, err := c.CallLLMStream(context.Background(), userMsg, func(string) {})
+	if err != nil {
+		t.Fatalf("CallLLMStream: %v", err)
+	}
+	if msg.Content != "partial answer" {
+		t.Errorf("content = %q, want the partial content", msg.Content)
+	}
+}
+
+func TestCallLLMStream_FallsBackToSyncOnStreamRejection(t *testing.T) {
+	// Some gateways reject stream=true for certain models
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Stream {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": map[string]any{"message": "stream not supported"}})
+			return
+		}
+		writeJSON(w, http.StatusOK, completion(map[string]any{"role": "assistant", "content": "sync answer"}, "stop", nil))
+	})
+
+	msg, err := c.CallLLMStream(context.Background(), userMsg, func(string) {})
+	if err != nil {
+		t.Fatalf("CallLLMStream: %v", err)
+	}
+	if msg.Content != "sync answer" {
+		t.Errorf("content = %q, want %q", msg.Content, "sync answer")
+	}
+}
+
+func TestRetry_HonorsRetryAfter(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		header string
+	}{
+		{"429 seconds", http.StatusTooManyRequests, "0"},
+		{"429 fractional", http.StatusTooManyRequests, "0.05"},
+		{"503 http-date", http.StatusServiceUnavailable, time.Now().Add(-time.Second).UTC().Format(http.TimeFormat)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					w.Header().Set("Retry-After", tc.header)
+					writeJSON(w, tc.status, map[string]any{"error": map[string]any{
+						"message": "Rate limit reached", "type": "requests", "code": "rate_limit_exceeded"}})
+					return
+				}
+				writeJSON(w, http.StatusOK, completion(map[string]any{"role": "assistant", "content": "ok"}, "stop", nil))
+			})
+
+			start := time.Now()
+			msg, err := c.CallLLM(context.Background(), userMsg)
+			if err != nil {
+				t.Fatalf("CallLLM: %v", err)
+			}
+			if msg.Content != "ok" || calls.Load() != 2 {
+				t.Errorf("content = %q after %d calls, want %q after 2", msg.Content, calls.Load(), "ok")
+			}
+			// The default backoff would be 1s; Retry-After must override it
+			if elapsed := time.Since(start); elapsed > 800*time.Millisecond {
+				t.Errorf("retry took %v, Retry-After was not honored", elapsed)
+			}
+		})
+	}
+}
+
+func TestRetry_NonTransientNotRetried(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound} {
+		t.Run(fmt.Sprint(status), func(t *testing.T) {
+			var calls atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				writeJSON(w, status, map[string]any{"error": map[string]any{"message": "nope", "type": "invalid_request_error"}})
+			})
+
+			_, err := c.CallLLMWithTools(context.Background(), userMsg, nil)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if calls.Load() != 1 {
+				t.Errorf("server called %d times, want 1", calls.Load())
+			}
+		})
+	}
+}
+
+func TestRetry_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "0")
+		writeJSON(w, http.StatusBadGateway, map[string]any{"error": map[string]any{"message": "bad gateway"}})
+	})
+
+	_, err := c.CallLLM(context.Background(), userMsg)
+	if err == nil || !strings.Contains(err.Error(), "after 1 retries") {
+		t.Errorf("err = %v, want a give-up error", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("server called %d times, want 2", calls.Load())
+	}
+}
+
+func TestRetry_CancelDuringBackoff(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "20")
+		writeJSON(w, http.StatusTooManyRequests, map[string]any{"error": map[string]any{"message": "slow down"}})
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.CallLLM(ctx, userMsg)
+	if err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("cancel took %v", elapsed)
+	}
+}
+
+func TestRetry_ExponentialBackoffWithNotices(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]any{"error": map[string]any{"message": "overloaded"}})
+			return
+		}
+		writeJSON(w, http.StatusOK, completion(map[string]any{"role": "assistant", "content": "ok"}, "stop", nil))
+	})
+	c.config.MaxRetries = 3
+	c.config.RetryBaseDelay = 20
+
+	var notices []llm.RetryNotice
+	ctx := llm.WithRetryNotifier(context.Background(), func(n llm.RetryNotice) { notices = append(notices, n) })
+	if msg, err := c.CallLLM(ctx, userMsg); err != nil || msg.Content != "ok" {
+		t.Fatalf("CallLLM = %q, %v", msg.Content, err)
+	}
+	if len(notices) != 2 {
+		t.Fatalf("notices = %+v, want 2", notices)
+	}
+	for i, n := range notices {
+		base := 20 * time.Millisecond << i
+		if n.Attempt != i+1 || n.MaxRetries != 3 || n.Wait < base/2 || n.Wait > base || !strings.Contains(n.Err, "overloaded") {
+			t.Errorf("notice %d = %+v, want attempt %d waiting %v-%v", i, n, i+1, base/2, base)
+		}
+	}
+}
+
+func TestBackoff_CappedWithJitter(t *testing.T) {
+	c := &Client{config: &Config{RetryBaseDelay: 1000, RetryMaxDelay: 5}}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		for i := 0; i < 20; i++ {
+			if d := c.backoff(attempt); d < want/2 || d > want {
+				t.Fatalf("backoff(%d) = %v, want %v-%v", attempt, d, want/2, want)
+			}
+		}
+	}
+	if d := c.backoff(100); d > 5*time.Second {
+		t.Errorf("backoff(100) = %v overflowed the cap", d)
+	}
+	if d := (&Client{config: &Config{}}).backoff(0); d < 500*time.Millisecond || d > time.Second {
+		t.Errorf("default backoff(0) = %v, want 0.5s-1s", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	cases := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"1.5", 1500 * time.Millisecond, true},
+		{"-1", 0, false},
+		{"3600", maxRetryAfter, true},
+		{"soon", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true}, // in the past
+	}
+	for _, tc := range cases {
+		got, ok := parseRetryAfter(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestCallLLMWithTools_FinishReasons(t *testing.T) {
+	toolCall := []any{map[string]any{"id": "call_1", "type": "function",
+		"function": map[string]any{"name": "web_search", "arguments": `{"query":"go"}`}}}
+	cases := []struct {
+		name      string
+		finish    any
+		toolCalls any
+		wantCalls int
+	}{
+		{"null finish reason", nil, nil, 0},
+		{"empty finish reason", "", nil, 0},
+		{"unknown finish reason", "eos_token", nil, 0},
+		{"uppercase stop", "STOP", nil, 0},
+		{"length", "length", nil, 0},
+		{"stop with tool calls", "stop", toolCall, 1},                   // DeepSeek, some one-api channels
+		{"function_call with tool calls", "function_call", toolCall, 1}, // legacy gateways
+		{"tool_calls without calls", "tool_calls", nil, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				message := map[string]any{"role": "assistant", "content": "answer"}
+				if tc.toolCalls != nil {
+					message["tool_calls"] = tc.toolCalls
+				}
+				writeJSON(w, http.StatusOK, completion(message, tc.finish, nil))
+			})
+
+			msg, err := c.CallLLMWithTools(context.Background(), userMsg, nil)
+			if err != nil {
+				t.Fatalf("CallLLMWithTools: %v", err)
+			}
+			if msg.Content != "answer" {
+				t.Errorf("content = %q, want %q", msg.Content, "answer")
+			}
+			if len(msg.ToolCalls) != tc.wantCalls {
+				t.Fatalf("got %d tool calls, want %d", len(msg.ToolCalls), tc.wantCalls)
+			}
+			if tc.wantCalls > 0 && (msg.ToolCalls[0].Name != "web_search" || string(msg.ToolCalls[0].Arguments) != `{"query":"go"}`) {
+				t.Errorf("tool call = %+v", msg.ToolCalls[0])
+			}
+		})
+	}
+}
+
+func TestCallLLMWithTools_EmptyChoices(t *testing.T) {
+	// Content-filtered responses from some gateways carry no choices at all
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"id": "x", "object": "chat.completion", "choices": []any{}})
+	})
+
+	if _, err := c.CallLLMWithTools(context.Background(), userMsg, nil); err == nil {
+		t.Error("expected an error for empty choices")
+	}
+}
+
+// Usage shapes reported by popular providers and gateways.
+var usageVariants = []struct {
+	name    string
+	usage   any
+	in, out int64
+	present bool
+}{
+	{"openai", map[string]any{"prompt_tokens": 12, "completion_tokens": 5, "total_tokens": 17,
+		"prompt_tokens_details":     map[string]any{"cached_tokens": 0},
+		"completion_tokens_details": map[string]any{"reasoning_tokens": 0}}, 12, 5, true},
+	{"one-api", map[string]any{"prompt_tokens": 30, "completion_tokens": 8, "total_tokens": 38}, 30, 8, true},
+	{"openrouter", map[string]any{"prompt_tokens": 40, "completion_tokens": 9, "total_tokens": 49,
+		"cost": 0.00012, "is_byok": false,
+		"prompt_tokens_details":     map[string]any{"cached_tokens": 32, "audio_tokens": 0},
+		"completion_tokens_details": map[string]any{"reasoning_tokens": 4, "image_tokens": 0}}, 40, 9, true},
+	{"deepseek", map[string]any{"prompt_tokens": 64, "completion_tokens": 20, "total_tokens": 84,
+		"prompt_cache_hit_tokens": 60, "prompt_cache_miss_tokens": 4,
+		"completion_tokens_details": map[string]any{"reasoning_tokens": 15}}, 64, 20, true},
+	{"total only", map[string]any{"total_tokens": 50}, 0, 0, true},
+}
+
+func TestCallLLM_UsageVariants(t *testing.T) {
+	for _, tc := range usageVariants {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := recordSpans(t)
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(w, http.StatusOK, completion(map[string]any{"role": "assistant", "content": "ok"}, "stop", tc.usage))
+			})
+
+			if _, err := telemetry.WrapLLM(c, "gpt-4o").CallLLM(context.Background(), userMsg); err != nil {
+				t.Fatalf("CallLLM: %v", err)
+			}
+			in, out, ok := usageAttrs(t, rec)
+			if !ok || in != tc.in || out != tc.out {
+				t.Errorf("usage = %d/%d (recorded %v), want %d/%d", in, out, ok, tc.in, tc.out)
+			}
+		})
+	}
+}
+
+func TestCallLLMStream_UsageVariants(t *testing.T) {
+	for _, tc := range usageVariants {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := recordSpans(t)
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				// Usage arrives in a trailing chunk with an empty choices array
+				final := map[string]any{"id": "chatcmpl-1", "object": "chat.completion.chunk",
+					"model": "gpt-4o", "choices": []any{}, "usage": tc.usage}
+				writeSSE(w,
+					sseData(chunk(map[string]any{"content": "ok"}, nil)),
+					sseData(chunk(map[string]any{}, "stop")),
+					sseData(final),
+					"data: [DONE]\n\n",
+				)
+			})
+
+			msg, err := telemetry.WrapLLM(c, "gpt-4o").CallLLMStream(context.Background(), userMsg, func(string) {})
+			if err != nil {
+				t.Fatalf("CallLLMStream: %v", err)
+			}
+			if msg.Content != "ok" {
+				t.Errorf("content = %q, want %q", msg.Content, "ok")
+			}
+			in, out, ok := usageAttrs(t, rec)
+			if !ok || in != tc.in || out != tc.out {
+				t.Errorf("usage = %d/%d (recorded %v), want %d/%d", in, out, ok, tc.in, tc.out)
+			}
+		})
+	}
+}
+
+func TestCallLLMStream_NoUsage(t *testing.T) {
+	rec := recordSpans(t)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeSSE(w, sseData(chunk(map[string]any{"content": "ok"}, "stop")), "data: [DONE]\n\n")
+	})
+
+	if _, err := telemetry.WrapLLM(c, "gpt-4o").CallLLMStream(context.Background(), userMsg, func(string) {}); err != nil {
+		t.Fatalf("CallLLMStream: %v", err)
+	}
+	if _, _, ok := usageAttrs(t, rec); ok {
+		t.Error("usage recorded for a stream that reported none")
+	}
+}
+
+func TestCallLLM_ImageParts(t *testing.T) {
+	var body struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		writeJSON(w, http.StatusOK, completion(map[string]any{"role": "assistant", "content": "a cat"}, "stop", nil))
+	}
+	msgs := []llm.Message{{
+		Role:    llm.RoleUser,
+		Content: "what is this?",
+		Parts:   []llm.ContentPart{llm.NewImagePart("image/png", []byte("png"))},
+	}}
+
+	c := newTestClient(t, handler)
+	if !c.SupportsVision() {
+		t.Fatal("gpt-4o should support vision")
+	}
+	if _, err := c.CallLLM(context.Background(), msgs); err != nil {
+		t.Fatalf("CallLLM: %v", err)
+	}
+	var parts []map[string]any
+	if err := json.Unmarshal(body.Messages[0].Content, &parts); err != nil {
+		t.Fatalf("content is not a part list: %s", body.Messages[0].Content)
+	}
+	if len(parts) != 2 || parts[0]["text"] != "what is this?" || parts[1]["type"] != "image_url" {
+		t.Errorf("parts = %v", parts)
+	}
+	if url, _ := parts[1]["image_url"].(map[string]any)["url"].(string); url != "data:image/png;base64,cG5n" {
+		t.Errorf("image url = %q", url)
+	}
+
+	// Text-only model: images are dropped with a note, not sent
+	c = newTestClient(t, handler)
+	c.config.Model, c.config.resolvedVision = "deepseek-chat", ""
+	if c.SupportsVision() {
+		t.Fatal("deepseek-chat should not support vision")
+	}
+	if _, err := c.CallLLM(context.Background(), msgs); err != nil {
+		t.Fatalf("CallLLM: %v", err)
+	}
+	var text string
+	if err := json.Unmarshal(body.Messages[0].Content, &text); err != nil {
+		t.Fatalf("content is not a string: %s", body.Messages[0].Content)
+	}
+	if !strings.HasPrefix(text, "what is this?") || !strings.Contains(text, "1 张图片已省略") {
+		t.Errorf("content = %q", text)
+	}
+}
+
+func TestCallLLM_RoleSampling(t *testing.T) {
+	var body map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		writeJSON(w, http.StatusOK, completion(map[string]any{"role": "assistant", "content": "ok"}, "stop", nil))
+	})
+	warm, cool, zero, topP := float32(0.8), float32(0.2), float32(0), float32(0.9)
+	c.config.Temperature, c.config.TopP = &warm, &topP
+	c.config.RoleSampling = map[llm.ModelRole]llm.Sampling{llm.ModelDecide: {Temperature: &cool}, llm.ModelReview: {Temperature: &zero}}
+
+	for _, tc := range []struct {
+		role        llm.ModelRole
+		temperature float32
+	}{
+		{llm.ModelDecide, cool},
+		{llm.ModelAnswer, warm},
+		{llm.ModelReview, zero},
+		{"", warm},
+	} {
+		ctx := llm.WithModelRole(context.Background(), tc.role)
+		if _, err := c.CallLLMWithTools(ctx, []llm.Message{{Role: llm.RoleUser, Content: "hi"}}, nil); err != nil {
+			t.Fatalf("%q: %v", tc.role, err)
+		}
+		// A configured 0 must be sent, not omitted as the zero value
+		temperature, ok := body["temperature"].(float64)
+		if got, _ := body["top_p"].(float64); !ok || float32(temperature) != explicitZero(tc.temperature) || float32(got) != topP {
+			t.Errorf("%q: temperature=%v top_p=%v, want %v and %v", tc.role, body["temperature"], body["top_p"], tc.temperature, topP)
+		}
+	}
+}
+
+func TestNewConfigFromEnv_RoleSampling(t *testing.T) {
+	t.Setenv("LLM_API_KEY", "k")
+	t.Setenv("LLM_TEMPERATURE", "0.7")
+	t.Setenv("LLM_TOP_P_ANSWER", "0.95")
+	t.Setenv("LLM_TEMPERATURE_DECIDE", "0")
+	cfg, err := NewConfigFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := cfg.Sampling().For(llm.ModelDecide); s.Temperature == nil || *s.Temperature != 0 || s.TopP != nil {
+		t.Errorf("decide sampling = %+v", s)
+	}
+	if s := cfg.Sampling().For(llm.ModelAnswer); *s.Temperature != 0.7 || s.TopP == nil || *s.TopP != 0.95 {
+		t.Errorf("answer sampling = %+v", s)
+	}
+	if len(cfg.RoleSampling) != 2 {
+		t.Errorf("RoleSampling = %v, want decide and answer only", cfg.RoleSampling)
+	}
+
+	t.Setenv("LLM_TOP_P_THINK", "1.5")
+	if _, err := NewConfigFromEnv(); err == nil || !strings.Contains(err.Error(), "LLM_TOP_P_THINK") {
+		t.Errorf("out-of-range top_p: err = %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/pocketomega/pocket-omega/internal/llm"
 )
@@ -15,34 +16,61 @@ type Config struct {
 	BaseURL         string   // Base URL (default: https://api.openai.com/v1)
 	Model           string   // Model name (default: gpt-4o)
 	Temperature     *float32 // Response creativity 0.0-2.0 (nil = API default)
+	TopP            *float32 // Nucleus sampling 0.0-1.0 (nil = API default)
 	MaxTokens       int      // Max tokens in response, 0 = no limit
 	MaxRetries      int      // HTTP-level retry for transient errors only (default: 1)
+	RetryBaseDelay  int      // first retry delay in ms, doubled per attempt with jitter (0 = 1000)
+	RetryMaxDelay   int      // cap of the retry delay in seconds (0 = 30)
 	HTTPTimeout     int      // HTTP client timeout in seconds (default: 300)
 	ThinkingMode    string   // "auto", "native", or "app" (default: "auto")
-	ToolCallMode    string   // "auto", "fc", or "yaml" (default: "auto")
+	ToolCallMode    string   // "auto", "fc", "yaml", or "json" (default: "auto")
 	ContextWindow   int      // context window in tokens (0 = auto-detect from model name)
 	ReasoningEffort string   // "low", "medium", or "high" (default: "medium"); only used in native thinking mode
+	Vision          string   // "auto", "on", or "off" (default: "auto") — image input support
+
+	// RoleSampling overrides Temperature/TopP for calls tagged with a model
+	// role (LLM_TEMPERATURE_<ROLE>, LLM_TOP_P_<ROLE>); unset fields fall back.
+	RoleSampling map[llm.ModelRole]llm.Sampling
 
 	// Cached resolved values — populated once by Resolve() to avoid repeated detection + log noise.
 	resolvedThinkingMode string
 	resolvedToolCallMode string
+	resolvedVision       string // "on" or "off"
 }
 
 // NewConfigFromEnv creates Config from environment variables.
-// Expected env vars: LLM_API_KEY, LLM_BASE_URL, LLM_MODEL, LLM_TEMPERATURE, LLM_MAX_TOKENS, LLM_MAX_RETRIES, LLM_THINKING_MODE, LLM_REASONING_EFFORT, LLM_TOOL_CALL_MODE
+// Expected env vars: LLM_API_KEY, LLM_BASE_URL, LLM_MODEL, LLM_TEMPERATURE, LLM_TOP_P, LLM_TEMPERATURE_<ROLE>, LLM_TOP_P_<ROLE>, LLM_MAX_TOKENS, LLM_MAX_RETRIES, LLM_RETRY_BASE_MS, LLM_RETRY_MAX_DELAY_SECONDS, LLM_THINKING_MODE, LLM_REASONING_EFFORT, LLM_TOOL_CALL_MODE, LLM_VISION
 func NewConfigFromEnv() (*Config, error) {
 	config := &Config{
 		APIKey:          getEnvOrDefault("LLM_API_KEY", ""),
 		BaseURL:         getEnvOrDefault("LLM_BASE_URL", "https://api.openai.com/v1"),
 		Model:           getEnvOrDefault("LLM_MODEL", "gpt-4o"),
 		Temperature:     getEnvFloat32Ptr("LLM_TEMPERATURE"),
+		TopP:            getEnvFloat32Ptr("LLM_TOP_P"),
 		MaxTokens:       getEnvIntOrDefault("LLM_MAX_TOKENS", 0),
 		MaxRetries:      getEnvIntOrDefault("LLM_MAX_RETRIES", 1),
+		RetryBaseDelay:  getEnvIntOrDefault("LLM_RETRY_BASE_MS", 1000),
+		RetryMaxDelay:   getEnvIntOrDefault("LLM_RETRY_MAX_DELAY_SECONDS", 30),
 		HTTPTimeout:     getEnvIntOrDefault("LLM_HTTP_TIMEOUT", 300),
 		ThinkingMode:    getEnvOrDefault("LLM_THINKING_MODE", "auto"),
 		ToolCallMode:    getEnvOrDefault("LLM_TOOL_CALL_MODE", "auto"),
 		ContextWindow:   getEnvIntOrDefault("LLM_CONTEXT_WINDOW", 0),
 		ReasoningEffort: getEnvOrDefault("LLM_REASONING_EFFORT", "medium"),
+		Vision:          getEnvOrDefault("LLM_VISION", "auto"),
+	}
+	for _, role := range llm.ModelRoles {
+		suffix := "_" + strings.ToUpper(string(role))
+		s := llm.Sampling{
+			Temperature: getEnvFloat32Ptr("LLM_TEMPERATURE" + suffix),
+			TopP:        getEnvFloat32Ptr("LLM_TOP_P" + suffix),
+		}
+		if s.Temperature == nil && s.TopP == nil {
+			continue
+		}
+		if config.RoleSampling == nil {
+			config.RoleSampling = make(map[llm.ModelRole]llm.Sampling)
+		}
+		config.RoleSampling[role] = s
 	}
 
 	if err := config.Validate(); err != nil {
@@ -59,24 +87,58 @@ func (c *Config) Validate() error {
 	if c.Model == "" {
 		return fmt.Errorf("LLM_MODEL cannot be empty")
 	}
-	if c.Temperature != nil && (*c.Temperature < 0.0 || *c.Temperature > 2.0) {
-		return fmt.Errorf("LLM_TEMPERATURE must be between 0.0 and 2.0, got %f", *c.Temperature)
+	if err := validateSampling(llm.Sampling{Temperature: c.Temperature, TopP: c.TopP}, ""); err != nil {
+		return err
+	}
+	for role, s := range c.RoleSampling {
+		if err := validateSampling(s, "_"+strings.ToUpper(string(role))); err != nil {
+			return err
+		}
 	}
 	if c.MaxRetries < 0 {
 		return fmt.Errorf("LLM_MAX_RETRIES cannot be negative, got %d", c.MaxRetries)
 	}
+	if c.RetryBaseDelay < 0 {
+		return fmt.Errorf("LLM_RETRY_BASE_MS cannot be negative, got %d", c.RetryBaseDelay)
+	}
+	if c.RetryMaxDelay < 0 {
+		return fmt.Errorf("LLM_RETRY_MAX_DELAY_SECONDS cannot be negative, got %d", c.RetryMaxDelay)
+	}
 	if c.ThinkingMode != "auto" && c.ThinkingMode != "native" && c.ThinkingMode != "app" {
 		return fmt.Errorf("LLM_THINKING_MODE must be 'auto', 'native', or 'app', got %q", c.ThinkingMode)
 	}
-	if c.ToolCallMode != "auto" && c.ToolCallMode != "fc" && c.ToolCallMode != "yaml" {
-		return fmt.Errorf("LLM_TOOL_CALL_MODE must be 'auto', 'fc', or 'yaml', got %q", c.ToolCallMode)
+	if c.ToolCallMode != "auto" && c.ToolCallMode != "fc" && c.ToolCallMode != "yaml" && c.ToolCallMode != "json" {
+		return fmt.Errorf("LLM_TOOL_CALL_MODE must be 'auto', 'fc', 'yaml', or 'json', got %q", c.ToolCallMode)
 	}
 	if c.ReasoningEffort != "low" && c.ReasoningEffort != "medium" && c.ReasoningEffort != "high" {
 		return fmt.Errorf("LLM_REASONING_EFFORT must be 'low', 'medium', or 'high', got %q", c.ReasoningEffort)
 	}
+	if c.Vision != "" && c.Vision != "auto" && c.Vision != "on" && c.Vision != "off" {
+		return fmt.Errorf("LLM_VISION must be 'auto', 'on', or 'off', got %q", c.Vision)
+	}
 	return nil
 }
 
+// validateSampling checks the ranges of one sampling; suffix names the role
+// of its env vars ("" for the defaults).
+func validateSampling(s llm.Sampling, suffix string) error {
+	if s.Temperature != nil && (*s.Temperature < 0.0 || *s.Temperature > 2.0) {
+		return fmt.Errorf("LLM_TEMPERATURE%s must be between 0.0 and 2.0, got %f", suffix, *s.Temperature)
+	}
+	if s.TopP != nil && (*s.TopP < 0.0 || *s.TopP > 1.0) {
+		return fmt.Errorf("LLM_TOP_P%s must be between 0.0 and 1.0, got %f", suffix, *s.TopP)
+	}
+	return nil
+}
+
+// Sampling returns the sampling table of the configured roles.
+func (c *Config) Sampling() llm.SamplingTable {
+	return llm.SamplingTable{
+		Default: llm.Sampling{Temperature: c.Temperature, TopP: c.TopP},
+		Roles:   c.RoleSampling,
+	}
+}
+
 // ResolveThinkingMode returns the effective thinking mode.
 // When set to "auto", it detects based on the model name.
 // Result is cached after first call to avoid repeated detection and log noise.
@@ -107,7 +169,7 @@ func (c *Config) ResolveToolCallMode() string {
 	if c.resolvedToolCallMode != "" {
 		return c.resolvedToolCallMode
 	}
-	if c.ToolCallMode == "fc" || c.ToolCallMode == "yaml" {
+	if c.ToolCallMode == "fc" || c.ToolCallMode == "yaml" || c.ToolCallMode == "json" {
 		c.resolvedToolCallMode = c.ToolCallMode
 		return c.resolvedToolCallMode
 	}
@@ -122,6 +184,26 @@ func (c *Config) ResolveToolCallMode() string {
 	return c.resolvedToolCallMode
 }
 
+// ResolveVision reports whether the model accepts image input.
+// When set to "auto" (or empty), it detects based on the model name.
+// Result is cached after first call to avoid repeated detection and log noise.
+func (c *Config) ResolveVision() bool {
+	if c.resolvedVision != "" {
+		return c.resolvedVision == "on"
+	}
+	switch {
+	case c.Vision == "on" || c.Vision == "off":
+		c.resolvedVision = c.Vision
+	case llm.DetectVisionCapability(c.Model):
+		log.Printf("[Config] Auto-detected vision support for model %q", c.Model)
+		c.resolvedVision = "on"
+	default:
+		log.Printf("[Config] Model %q does not support vision, image input disabled", c.Model)
+		c.resolvedVision = "off"
+	}
+	return c.resolvedVision == "on"
+}
+
 // ResolveContextWindow returns the effective context window in tokens.
 // Priority: explicit LLM_CONTEXT_WINDOW > auto-detect from model name > 32K safe default.
 func (c *Config) ResolveContextWindow() int {
@@ -0,0 +1,88 @@
+package openai
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// Failover sends calls to a secondary provider when the primary fails with
+// a transient error after its own retries (outage, persistent 429/5xx).
+// Once failed over it stays on the secondary for a cooldown before giving
+// the primary another chance, so a run does not pay the primary's retries
+// on every step. Errors that the secondary would repeat (bad request,
+// auth) and cancellations are returned as is.
+type Failover struct {
+	primary        llm.LLMProvider
+	secondary      llm.LLMProvider
+	secondaryModel string
+	cooldown       time.Duration
+
+	mu    sync.Mutex
+	until time.Time // primary is skipped until then
+}
+
+// NewFailover returns primary backed by secondary, which serves
+// secondaryModel.
+func NewFailover(primary, secondary llm.LLMProvider, secondaryModel string, cooldown time.Duration) *Failover {
+	return &Failover{primary: primary, secondary: secondary, secondaryModel: secondaryModel, cooldown: cooldown}
+}
+
+// active reports whether calls currently go to the secondary.
+func (f *Failover) active() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return time.Now().Before(f.until)
+}
+
+// call runs do against the primary, or the secondary while failed over or
+// when the primary fails transiently.
+func (f *Failover) call(ctx context.Context, do func(p llm.LLMProvider) (llm.Message, error)) (llm.Message, error) {
+	if f.active() {
+		return do(f.secondary)
+	}
+	resp, err := do(f.primary)
+	if err == nil || ctx.Err() != nil || !isTransient(err) {
+		return resp, err
+	}
+	f.mu.Lock()
+	f.until = time.Now().Add(f.cooldown)
+	f.mu.Unlock()
+	log.Printf("[LLM] Primary unavailable, failing over to %s for %v: %v", f.secondaryModel, f.cooldown, err)
+	llm.NotifyRetry(ctx, llm.RetryNotice{Err: err.Error(), Failover: f.secondaryModel})
+	return do(f.secondary)
+}
+
+func (f *Failover) CallLLM(ctx context.Context, messages []llm.Message) (llm.Message, error) {
+	return f.call(ctx, func(p llm.LLMProvider) (llm.Message, error) {
+		return p.CallLLM(ctx, messages)
+	})
+}
+
+func (f *Failover) CallLLMStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamCallback) (llm.Message, error) {
+	return f.call(ctx, func(p llm.LLMProvider) (llm.Message, error) {
+		return p.CallLLMStream(ctx, messages, onChunk)
+	})
+}
+
+func (f *Failover) CallLLMWithTools(ctx context.Context, messages []llm.Message, tools []llm.ToolDefinition) (llm.Message, error) {
+	return f.call(ctx, func(p llm.LLMProvider) (llm.Message, error) {
+		return p.CallLLMWithTools(ctx, messages, tools)
+	})
+}
+
+// IsToolCallingEnabled and SupportsVision report the primary's settings:
+// prompts are built for it, and the secondary is a stand-in.
+func (f *Failover) IsToolCallingEnabled() bool { return f.primary.IsToolCallingEnabled() }
+func (f *Failover) SupportsVision() bool       { return llm.SupportsVision(f.primary) }
+
+// ModelFor names the secondary model while failed over.
+func (f *Failover) ModelFor(r llm.ModelRole) string {
+	if f.active() {
+		return f.secondaryModel
+	}
+	return llm.ModelFor(f.primary, r)
+}
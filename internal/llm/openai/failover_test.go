@@ -0,0 +1,77 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	openailib "github.com/sashabaranov/go-openai"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// stubProvider answers with its name, or fails with err.
+type stubProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *stubProvider) CallLLM(context.Context, []llm.Message) (llm.Message, error) {
+	p.calls++
+	if p.err != nil {
+		return llm.Message{}, p.err
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: p.name}, nil
+}
+func (p *stubProvider) CallLLMStream(ctx context.Context, m []llm.Message, _ llm.StreamCallback) (llm.Message, error) {
+	return p.CallLLM(ctx, m)
+}
+func (p *stubProvider) CallLLMWithTools(ctx context.Context, m []llm.Message, _ []llm.ToolDefinition) (llm.Message, error) {
+	return p.CallLLM(ctx, m)
+}
+func (p *stubProvider) IsToolCallingEnabled() bool { return true }
+
+func TestFailover_SwitchesOnTransientErrors(t *testing.T) {
+	primary := &stubProvider{name: "primary", err: &openailib.APIError{HTTPStatusCode: 503, Message: "down"}}
+	secondary := &stubProvider{name: "secondary"}
+	f := NewFailover(primary, secondary, "backup-model", time.Minute)
+
+	var notices []llm.RetryNotice
+	ctx := llm.WithRetryNotifier(context.Background(), func(n llm.RetryNotice) { notices = append(notices, n) })
+	resp, err := f.CallLLM(ctx, userMsg)
+	if err != nil || resp.Content != "secondary" {
+		t.Fatalf("CallLLM = %q, %v; want the secondary's answer", resp.Content, err)
+	}
+	if len(notices) != 1 || notices[0].Failover != "backup-model" || !strings.Contains(notices[0].Err, "down") {
+		t.Errorf("notices = %+v", notices)
+	}
+
+	// During the cooldown the primary is not tried again.
+	f.CallLLMWithTools(ctx, userMsg, nil)
+	if primary.calls != 1 || secondary.calls != 2 || f.ModelFor(llm.ModelDecide) != "backup-model" {
+		t.Errorf("calls = %d/%d, model %q", primary.calls, secondary.calls, f.ModelFor(llm.ModelDecide))
+	}
+
+	// After it, the recovered primary serves again.
+	f.until = time.Now().Add(-time.Second)
+	primary.err = nil
+	if resp, _ := f.CallLLMStream(ctx, userMsg, nil); resp.Content != "primary" {
+		t.Errorf("after cooldown: %q, want primary", resp.Content)
+	}
+}
+
+func TestFailover_KeepsPermanentErrors(t *testing.T) {
+	for _, err := range []error{
+		&openailib.APIError{HTTPStatusCode: 400, Message: "context too long"},
+		context.Canceled,
+	} {
+		secondary := &stubProvider{name: "secondary"}
+		f := NewFailover(&stubProvider{err: err}, secondary, "backup-model", time.Minute)
+		if _, got := f.CallLLM(context.Background(), userMsg); !errors.Is(got, err) || secondary.calls != 0 {
+			t.Errorf("%v: got %v with %d secondary calls, want the primary's error", err, got, secondary.calls)
+		}
+	}
+}
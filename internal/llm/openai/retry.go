@@ -0,0 +1,147 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	openailib "github.com/sashabaranov/go-openai"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// maxRetryAfter caps a server-provided Retry-After so a misbehaving gateway
+// cannot stall a run for minutes.
+const maxRetryAfter = 30 * time.Second
+
+// retryAfterKey carries a *retryAfterHint in the request context.
+type retryAfterKey struct{}
+
+// retryAfterHint receives the Retry-After of the last failed response.
+// go-openai errors do not expose response headers, so retryAfterTransport
+// records it on the way through.
+type retryAfterHint struct {
+	wait time.Duration
+	set  bool
+}
+
+// retryAfterTransport records the Retry-After header of non-2xx responses
+// into the request's retryAfterHint, if any.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 300 {
+		return resp, err
+	}
+	if hint, ok := req.Context().Value(retryAfterKey{}).(*retryAfterHint); ok {
+		hint.wait, hint.set = parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+	return resp, err
+}
+
+// parseRetryAfter accepts delay-seconds (integer or, as some gateways send,
+// fractional) and HTTP-dates.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return min(time.Duration(secs*float64(time.Second)), maxRetryAfter), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return min(max(time.Until(t), 0), maxRetryAfter), true
+	}
+	return 0, false
+}
+
+// withRetry runs call up to MaxRetries+1 times. Only transient failures are
+// retried; the wait is the server's Retry-After when given, else an
+// exponential backoff with jitter. Each retry is reported to the ctx's
+// retry notifier (see llm.WithRetryNotifier).
+func (c *Client) withRetry(ctx context.Context, label string, call func(ctx context.Context) error) error {
+	hint := &retryAfterHint{}
+	callCtx := context.WithValue(ctx, retryAfterKey{}, hint)
+
+	var err error
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		*hint = retryAfterHint{}
+		if err = call(callCtx); err == nil {
+			return nil
+		}
+		if attempt == c.config.MaxRetries || !isTransient(err) || ctx.Err() != nil {
+			return err
+		}
+		wait := c.backoff(attempt)
+		if hint.set {
+			wait = hint.wait
+		}
+		log.Printf("[LLM] %s %d/%d after %v, error: %v", label, attempt+1, c.config.MaxRetries, wait, err)
+		llm.NotifyRetry(ctx, llm.RetryNotice{
+			Attempt:    attempt + 1,
+			MaxRetries: c.config.MaxRetries,
+			Wait:       wait,
+			Err:        err.Error(),
+		})
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// backoff returns the delay before retry attempt+1: RetryBaseDelay doubled
+// per attempt, capped at RetryMaxDelay, with "equal jitter" (a random
+// delay in [d/2, d]) so that clients hit by the same outage do not retry
+// in lockstep.
+func (c *Client) backoff(attempt int) time.Duration {
+	base := time.Duration(c.config.RetryBaseDelay) * time.Millisecond
+	if base <= 0 {
+		base = time.Second
+	}
+	maxDelay := time.Duration(c.config.RetryMaxDelay) * time.Second
+	if maxDelay <= 0 {
+		maxDelay = maxRetryAfter
+	}
+	d := maxDelay
+	if attempt < 30 && base<<attempt < maxDelay {
+		d = base << attempt
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// isTransient reports whether err is worth retrying: network failures,
+// timeouts, rate limits and server errors. Other 4xx (bad request, auth,
+// unknown model) fail the same way every time.
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	status := 0
+	var apiErr *openailib.APIError
+	var reqErr *openailib.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	}
+	if status == 0 {
+		return true
+	}
+	return status == http.StatusRequestTimeout || status == http.StatusConflict ||
+		status == http.StatusTooManyRequests || status >= 500
+}
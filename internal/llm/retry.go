@@ -0,0 +1,30 @@
+package llm
+
+import (
+	"context"
+	"time"
+)
+
+// RetryNotice describes an LLM call that failed transiently and is about
+// to be retried, or that moved to the failover provider.
+type RetryNotice struct {
+	Attempt    int           // 1-based retry number (0 for a failover)
+	MaxRetries int           // retries allowed for the call
+	Wait       time.Duration // delay before the retry
+	Err        string        // the failure being retried
+	Failover   string        // model now serving the call; "" = same provider
+}
+
+// WithRetryNotifier tags ctx with fn, called for every retry and failover
+// of the calls made with it (e.g. to show a "retrying" notice in the UI).
+// fn runs on the calling goroutine and must not block.
+func WithRetryNotifier(ctx context.Context, fn func(RetryNotice)) context.Context {
+	return context.WithValue(ctx, ctxKeyRetryNotifier, fn)
+}
+
+// NotifyRetry reports n to the notifier in ctx, if any.
+func NotifyRetry(ctx context.Context, n RetryNotice) {
+	if fn, ok := ctx.Value(ctxKeyRetryNotifier).(func(RetryNotice)); ok && fn != nil {
+		fn(n)
+	}
+}
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ModelRole is the kind of work an LLM call does. A Router sends each role
+// to its own model, e.g. a cheap model for summaries and a strong one for
+// decisions.
+type ModelRole string
+
+const (
+	ModelDecide    ModelRole = "decide"    // next-action decisions (DecideNode)
+	ModelThink     ModelRole = "think"     // explicit reasoning steps (ThinkNode)
+	ModelAnswer    ModelRole = "answer"    // final answers (AnswerNode)
+	ModelSummarize ModelRole = "summarize" // history compaction, tool output and journal summaries, session titles
+	ModelReview    ModelRole = "review"    // self-review of answers (ReviewNode)
+)
+
+// ModelRoles lists every role, in configuration order.
+var ModelRoles = []ModelRole{ModelDecide, ModelThink, ModelAnswer, ModelSummarize, ModelReview}
+
+// WithModelRole tags ctx with the role of the calls made with it.
+func WithModelRole(ctx context.Context, r ModelRole) context.Context {
+	return context.WithValue(ctx, ctxKeyModelRole, r)
+}
+
+// ModelRoleFrom returns the role ctx was tagged with, or "" (default model).
+func ModelRoleFrom(ctx context.Context) ModelRole {
+	r, _ := ctx.Value(ctxKeyModelRole).(ModelRole)
+	return r
+}
+
+// ModelNamer is implemented by providers that can name the model serving a
+// role. Wrappers (scheduler, tracing) forward it.
+type ModelNamer interface {
+	ModelFor(r ModelRole) string
+}
+
+// ModelFor returns the model p uses for role r, or "" when p cannot tell.
+func ModelFor(p LLMProvider, r ModelRole) string {
+	if n, ok := p.(ModelNamer); ok {
+		return n.ModelFor(r)
+	}
+	return ""
+}
+
+// Router is an LLMProvider that dispatches every call to the provider
+// configured for the role in its context (see WithModelRole); untagged
+// calls and unconfigured roles go to the default provider. Wrap the Router
+// (not its providers) in a Scheduler so that one concurrency limit covers
+// all models.
+type Router struct {
+	def       LLMProvider
+	defModel  string
+	providers map[ModelRole]LLMProvider
+	models    map[ModelRole]string
+}
+
+// NewRouter returns a Router sending every role to def, which serves model.
+func NewRouter(def LLMProvider, model string) *Router {
+	return &Router{
+		def:       def,
+		defModel:  model,
+		providers: make(map[ModelRole]LLMProvider),
+		models:    make(map[ModelRole]string),
+	}
+}
+
+// Route sends role r to p, which serves model. Not safe for use
+// concurrently with calls; configure the Router at startup.
+func (r *Router) Route(role ModelRole, p LLMProvider, model string) *Router {
+	r.providers[role] = p
+	r.models[role] = model
+	return r
+}
+
+// Routed reports whether any role has its own provider.
+func (r *Router) Routed() bool { return len(r.providers) > 0 }
+
+// provider returns the provider for role.
+func (r *Router) provider(role ModelRole) LLMProvider {
+	if p, ok := r.providers[role]; ok {
+		return p
+	}
+	return r.def
+}
+
+// ModelFor returns the model serving role.
+func (r *Router) ModelFor(role ModelRole) string {
+	if m, ok := r.models[role]; ok {
+		return m
+	}
+	return r.defModel
+}
+
+// String describes the routes, e.g. "decide=gpt-4o, summarize=gpt-4o-mini".
+func (r *Router) String() string {
+	var parts []string
+	for _, role := range ModelRoles {
+		if m, ok := r.models[role]; ok {
+			parts = append(parts, fmt.Sprintf("%s=%s", role, m))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (r *Router) CallLLM(ctx context.Context, messages []Message) (Message, error) {
+	return r.provider(ModelRoleFrom(ctx)).CallLLM(ctx, messages)
+}
+
+func (r *Router) CallLLMStream(ctx context.Context, messages []Message, onChunk StreamCallback) (Message, error) {
+	return r.provider(ModelRoleFrom(ctx)).CallLLMStream(ctx, messages, onChunk)
+}
+
+func (r *Router) CallLLMWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	return r.provider(ModelRoleFrom(ctx)).CallLLMWithTools(ctx, messages, tools)
+}
+
+// IsToolCallingEnabled reports the decide model's setting: only decisions
+// are made with Function Calling.
+func (r *Router) IsToolCallingEnabled() bool {
+	return r.provider(ModelDecide).IsToolCallingEnabled()
+}
+
+// SupportsVision reports the decide model's capability, as images are
+// attached to the decision context.
+func (r *Router) SupportsVision() bool { return SupportsVision(r.provider(ModelDecide)) }
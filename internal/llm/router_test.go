@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+// namedProvider answers every call with its own name.
+type namedProvider struct {
+	name   string
+	tools  bool
+	vision bool
+}
+
+func (p *namedProvider) CallLLM(context.Context, []Message) (Message, error) {
+	return Message{Role: RoleAssistant, Content: p.name}, nil
+}
+func (p *namedProvider) CallLLMStream(ctx context.Context, m []Message, _ StreamCallback) (Message, error) {
+	return p.CallLLM(ctx, m)
+}
+func (p *namedProvider) CallLLMWithTools(ctx context.Context, m []Message, _ []ToolDefinition) (Message, error) {
+	return p.CallLLM(ctx, m)
+}
+func (p *namedProvider) IsToolCallingEnabled() bool { return p.tools }
+func (p *namedProvider) SupportsVision() bool       { return p.vision }
+
+func TestRouter_DispatchesByRole(t *testing.T) {
+	strong := &namedProvider{name: "strong", tools: true, vision: true}
+	cheap := &namedProvider{name: "cheap"}
+	r := NewRouter(&namedProvider{name: "default"}, "gpt-4o").
+		Route(ModelDecide, strong, "o3").
+		Route(ModelSummarize, cheap, "gpt-4o-mini")
+
+	ctx := context.Background()
+	for _, tc := range []struct {
+		ctx   context.Context
+		want  string
+		model string
+	}{
+		{ctx, "default", "gpt-4o"},
+		{WithModelRole(ctx, ModelDecide), "strong", "o3"},
+		{WithModelRole(ctx, ModelSummarize), "cheap", "gpt-4o-mini"},
+		{WithModelRole(ctx, ModelReview), "default", "gpt-4o"},
+	} {
+		role := ModelRoleFrom(tc.ctx)
+		resp, _ := r.CallLLM(tc.ctx, nil)
+		streamed, _ := r.CallLLMStream(tc.ctx, nil, nil)
+		withTools, _ := r.CallLLMWithTools(tc.ctx, nil, nil)
+		if resp.Content != tc.want || streamed.Content != tc.want || withTools.Content != tc.want {
+			t.Errorf("role %q: got %q/%q/%q, want %q", role, resp.Content, streamed.Content, withTools.Content, tc.want)
+		}
+		if m := r.ModelFor(role); m != tc.model {
+			t.Errorf("ModelFor(%q) = %q, want %q", role, m, tc.model)
+		}
+	}
+
+	if !r.IsToolCallingEnabled() || !SupportsVision(r) {
+		t.Error("capabilities should come from the decide provider")
+	}
+	if got := r.String(); got != "decide=o3, summarize=gpt-4o-mini" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestModelFor_ThroughScheduler(t *testing.T) {
+	r := NewRouter(&namedProvider{name: "default"}, "gpt-4o").Route(ModelThink, &namedProvider{name: "think"}, "o3")
+	s := NewScheduler(r, 2)
+	if m := ModelFor(s, ModelThink); m != "o3" {
+		t.Errorf("ModelFor(scheduler, think) = %q, want o3", m)
+	}
+	resp, err := s.CallLLM(WithModelRole(context.Background(), ModelThink), nil)
+	if err != nil || resp.Content != "think" {
+		t.Errorf("scheduled call = %q, %v; want think", resp.Content, err)
+	}
+	if m := ModelFor(&namedProvider{}, ModelThink); m != "" {
+		t.Errorf("plain provider ModelFor = %q, want empty", m)
+	}
+	if NewRouter(&namedProvider{}, "m").Routed() {
+		t.Error("router without routes reports Routed")
+	}
+}
+
+func TestSamplingTable_For(t *testing.T) {
+	warm, zero, topP := float32(0.7), float32(0), float32(0.9)
+	table := SamplingTable{
+		Default: Sampling{Temperature: &warm, TopP: &topP},
+		Roles:   map[ModelRole]Sampling{ModelDecide: {Temperature: &zero}},
+	}
+	if s := table.For(ModelDecide); s.Temperature != &zero || s.TopP != &topP {
+		t.Errorf("decide = %+v, want its own temperature and the default top_p", s)
+	}
+	for _, r := range []ModelRole{"", ModelAnswer} {
+		if s := table.For(r); s.Temperature != &warm || s.TopP != &topP {
+			t.Errorf("role %q = %+v, want the default", r, s)
+		}
+	}
+	if s := (SamplingTable{}).For(ModelThink); s.Temperature != nil || s.TopP != nil {
+		t.Errorf("empty table = %+v, want API defaults", s)
+	}
+}
@@ -0,0 +1,30 @@
+package llm
+
+// Sampling holds the sampling parameters of a call; nil fields leave the
+// API default.
+type Sampling struct {
+	Temperature *float32 // 0.0-2.0
+	TopP        *float32 // 0.0-1.0 nucleus sampling
+}
+
+// SamplingTable is the sampling of every role: Default applies to calls
+// without a role, and to the fields a role does not set itself, e.g. a
+// decide temperature of 0 with the default top_p.
+type SamplingTable struct {
+	Default Sampling
+	Roles   map[ModelRole]Sampling
+}
+
+// For returns the sampling of role r.
+func (t SamplingTable) For(r ModelRole) Sampling {
+	s := t.Default
+	if rs, ok := t.Roles[r]; ok {
+		if rs.Temperature != nil {
+			s.Temperature = rs.Temperature
+		}
+		if rs.TopP != nil {
+			s.TopP = rs.TopP
+		}
+	}
+	return s
+}
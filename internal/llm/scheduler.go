@@ -0,0 +1,336 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Priority is the scheduling class of an LLM call.
+// Lower values are served first.
+type Priority int
+
+const (
+	// PriorityInteractive is used for calls a user is actively waiting on
+	// (chat, agent runs). This is the default when no priority is set.
+	PriorityInteractive Priority = iota
+	// PriorityBackground is used for batch/scheduled work that may wait.
+	PriorityBackground
+
+	numPriorities = 2
+)
+
+// String returns the metrics label of the priority class.
+func (p Priority) String() string {
+	if p == PriorityBackground {
+		return "background"
+	}
+	return "interactive"
+}
+
+// backgroundMaxWait bounds how long a background call can be starved by
+// interactive traffic; once exceeded it is served ahead of interactive calls.
+const backgroundMaxWait = 30 * time.Second
+
+type ctxKey int
+
+const (
+	ctxKeySession ctxKey = iota
+	ctxKeyPriority
+	ctxKeyModelRole
+	ctxKeyCachePolicy
+	ctxKeyRetryNotifier
+)
+
+// WithSession tags ctx with a session ID used for per-session fairness.
+func WithSession(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, ctxKeySession, sessionID)
+}
+
+// WithPriority tags ctx with a scheduling priority class.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, ctxKeyPriority, p)
+}
+
+func sessionFrom(ctx context.Context) string {
+	s, _ := ctx.Value(ctxKeySession).(string)
+	return s
+}
+
+// PriorityFrom returns the priority class ctx was tagged with; untagged
+// calls are interactive.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(ctxKeyPriority).(Priority); ok && p >= 0 && p < numPriorities {
+		return p
+	}
+	return PriorityInteractive
+}
+
+// waiter is a queued LLM call waiting for a slot.
+type waiter struct {
+	session  string
+	enqueued time.Time
+	ready    chan struct{} // closed when a slot is granted
+	granted  bool          // guarded by Scheduler.mu
+}
+
+// fairQueue holds waiters of one priority class, served round-robin across
+// sessions so one busy session cannot starve the others.
+type fairQueue struct {
+	order   []string             // sessions with pending waiters, in service order
+	waiters map[string][]*waiter // session → FIFO waiters
+	size    int
+}
+
+func newFairQueue() *fairQueue {
+	return &fairQueue{waiters: make(map[string][]*waiter)}
+}
+
+func (q *fairQueue) push(w *waiter) {
+	if len(q.waiters[w.session]) == 0 {
+		q.order = append(q.order, w.session)
+	}
+	q.waiters[w.session] = append(q.waiters[w.session], w)
+	q.size++
+}
+
+// pop removes the head waiter of the next session in round-robin order.
+func (q *fairQueue) pop() *waiter {
+	if q.size == 0 {
+		return nil
+	}
+	sess := q.order[0]
+	q.order = q.order[1:]
+	list := q.waiters[sess]
+	w := list[0]
+	if len(list) > 1 {
+		q.waiters[sess] = list[1:]
+		q.order = append(q.order, sess) // rotate to the back
+	} else {
+		delete(q.waiters, sess)
+	}
+	q.size--
+	return w
+}
+
+// oldest returns the earliest enqueue time among all waiters (zero if empty).
+func (q *fairQueue) oldest() time.Time {
+	var t time.Time
+	for _, list := range q.waiters {
+		if len(list) > 0 && (t.IsZero() || list[0].enqueued.Before(t)) {
+			t = list[0].enqueued
+		}
+	}
+	return t
+}
+
+// remove deletes w from the queue (used when its context is cancelled).
+func (q *fairQueue) remove(w *waiter) {
+	list := q.waiters[w.session]
+	for i, x := range list {
+		if x != w {
+			continue
+		}
+		list = append(list[:i], list[i+1:]...)
+		q.size--
+		if len(list) == 0 {
+			delete(q.waiters, w.session)
+			for j, s := range q.order {
+				if s == w.session {
+					q.order = append(q.order[:j], q.order[j+1:]...)
+					break
+				}
+			}
+		} else {
+			q.waiters[w.session] = list
+		}
+		return
+	}
+}
+
+// PriorityStats holds queue-time metrics for one priority class.
+type PriorityStats struct {
+	Queued    int   `json:"queued"`      // calls currently waiting
+	Served    int64 `json:"served"`      // calls that obtained a slot
+	AvgWaitMs int64 `json:"avg_wait_ms"` // mean queue time of served calls
+	MaxWaitMs int64 `json:"max_wait_ms"` // longest queue time observed
+}
+
+// SchedulerStats is a snapshot of scheduler state and queue-time metrics.
+type SchedulerStats struct {
+	MaxConcurrent int                      `json:"max_concurrent"`
+	Active        int                      `json:"active"`
+	Priorities    map[string]PriorityStats `json:"priorities"`
+}
+
+type waitMetrics struct {
+	served    int64
+	totalWait time.Duration
+	maxWait   time.Duration
+}
+
+// Scheduler is an LLMProvider decorator that limits concurrent calls to the
+// wrapped provider and queues the rest by priority class, serving sessions
+// within a class round-robin. Background calls waiting longer than
+// backgroundMaxWait are promoted so they are never starved indefinitely.
+//
+// Session and priority are read from the call context (WithSession /
+// WithPriority); untagged calls are interactive and share one anonymous session.
+type Scheduler struct {
+	inner         LLMProvider
+	maxConcurrent int
+
+	mu      sync.Mutex
+	active  int
+	queues  [numPriorities]*fairQueue
+	metrics [numPriorities]waitMetrics
+	now     func() time.Time
+}
+
+// NewScheduler wraps inner with a scheduler allowing maxConcurrent in-flight
+// calls. maxConcurrent < 1 is treated as 1.
+func NewScheduler(inner LLMProvider, maxConcurrent int) *Scheduler {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	s := &Scheduler{inner: inner, maxConcurrent: maxConcurrent, now: time.Now}
+	for i := range s.queues {
+		s.queues[i] = newFairQueue()
+	}
+	return s
+}
+
+// acquire blocks until a slot is available or ctx is done.
+func (s *Scheduler) acquire(ctx context.Context) error {
+	prio := PriorityFrom(ctx)
+
+	s.mu.Lock()
+	if s.active < s.maxConcurrent && s.queuedLocked() == 0 {
+		s.active++
+		s.recordWaitLocked(prio, 0)
+		s.mu.Unlock()
+		return nil
+	}
+	w := &waiter{session: sessionFrom(ctx), enqueued: s.now(), ready: make(chan struct{})}
+	s.queues[prio].push(w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.granted {
+			// Slot was handed over concurrently with cancellation: give it back.
+			s.mu.Unlock()
+			s.release()
+		} else {
+			s.queues[prio].remove(w)
+			s.mu.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees a slot and hands it to the next eligible waiter.
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	for s.active < s.maxConcurrent {
+		prio, w := s.nextLocked()
+		if w == nil {
+			return
+		}
+		w.granted = true
+		s.active++
+		s.recordWaitLocked(prio, s.now().Sub(w.enqueued))
+		close(w.ready)
+	}
+}
+
+// nextLocked picks the next waiter: interactive first, unless the oldest
+// background waiter has exceeded backgroundMaxWait.
+func (s *Scheduler) nextLocked() (Priority, *waiter) {
+	bg := s.queues[PriorityBackground]
+	if bg.size > 0 && s.now().Sub(bg.oldest()) > backgroundMaxWait {
+		return PriorityBackground, bg.pop()
+	}
+	for p := Priority(0); p < numPriorities; p++ {
+		if w := s.queues[p].pop(); w != nil {
+			return p, w
+		}
+	}
+	return 0, nil
+}
+
+func (s *Scheduler) queuedLocked() int {
+	n := 0
+	for _, q := range s.queues {
+		n += q.size
+	}
+	return n
+}
+
+func (s *Scheduler) recordWaitLocked(p Priority, wait time.Duration) {
+	m := &s.metrics[p]
+	m.served++
+	m.totalWait += wait
+	if wait > m.maxWait {
+		m.maxWait = wait
+	}
+}
+
+// Stats returns a snapshot of scheduler state and queue-time metrics.
+func (s *Scheduler) Stats() SchedulerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := SchedulerStats{
+		MaxConcurrent: s.maxConcurrent,
+		Active:        s.active,
+		Priorities:    make(map[string]PriorityStats, numPriorities),
+	}
+	for p := Priority(0); p < numPriorities; p++ {
+		m := s.metrics[p]
+		ps := PriorityStats{
+			Queued:    s.queues[p].size,
+			Served:    m.served,
+			MaxWaitMs: m.maxWait.Milliseconds(),
+		}
+		if m.served > 0 {
+			ps.AvgWaitMs = (m.totalWait / time.Duration(m.served)).Milliseconds()
+		}
+		st.Priorities[p.String()] = ps
+	}
+	return st
+}
+
+// ── LLMProvider ──
+
+func (s *Scheduler) CallLLM(ctx context.Context, messages []Message) (Message, error) {
+	if err := s.acquire(ctx); err != nil {
+		return Message{}, err
+	}
+	defer s.release()
+	return s.inner.CallLLM(ctx, messages)
+}
+
+func (s *Scheduler) CallLLMStream(ctx context.Context, messages []Message, onChunk StreamCallback) (Message, error) {
+	if err := s.acquire(ctx); err != nil {
+		return Message{}, err
+	}
+	defer s.release()
+	return s.inner.CallLLMStream(ctx, messages, onChunk)
+}
+
+func (s *Scheduler) CallLLMWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	if err := s.acquire(ctx); err != nil {
+		return Message{}, err
+	}
+	defer s.release()
+	return s.inner.CallLLMWithTools(ctx, messages, tools)
+}
+
+func (s *Scheduler) IsToolCallingEnabled() bool  { return s.inner.IsToolCallingEnabled() }
+func (s *Scheduler) SupportsVision() bool        { return SupportsVision(s.inner) }
+func (s *Scheduler) ModelFor(r ModelRole) string { return ModelFor(s.inner, r) }
@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingProvider blocks each call until release is closed and records
+// the session order in which calls started.
+type blockingProvider struct {
+	mu      sync.Mutex
+	started []string
+	release chan struct{}
+}
+
+func (p *blockingProvider) CallLLM(ctx context.Context, _ []Message) (Message, error) {
+	p.mu.Lock()
+	p.started = append(p.started, sessionFrom(ctx))
+	p.mu.Unlock()
+	<-p.release
+	return Message{Role: RoleAssistant, Content: "ok"}, nil
+}
+func (p *blockingProvider) CallLLMStream(ctx context.Context, m []Message, _ StreamCallback) (Message, error) {
+	return p.CallLLM(ctx, m)
+}
+func (p *blockingProvider) CallLLMWithTools(ctx context.Context, m []Message, _ []ToolDefinition) (Message, error) {
+	return p.CallLLM(ctx, m)
+}
+func (p *blockingProvider) IsToolCallingEnabled() bool { return true }
+
+// waitQueued polls until the scheduler has n queued calls.
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		q := s.queuedLocked()
+		s.mu.Unlock()
+		if q == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d queued calls", n)
+}
+
+func TestFairQueue_RoundRobin(t *testing.T) {
+	q := newFairQueue()
+	for _, s := range []string{"a", "a", "a", "b", "c"} {
+		q.push(&waiter{session: s})
+	}
+	var got []string
+	for w := q.pop(); w != nil; w = q.pop() {
+		got = append(got, w.session)
+	}
+	want := []string{"a", "b", "c", "a", "a"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestScheduler_PriorityAndFairness(t *testing.T) {
+	p := &blockingProvider{release: make(chan struct{})}
+	s := NewScheduler(p, 1)
+
+	var wg sync.WaitGroup
+	call := func(ctx context.Context) {
+		defer wg.Done()
+		s.CallLLM(ctx, nil)
+	}
+
+	// Occupy the single slot
+	wg.Add(1)
+	go call(WithSession(context.Background(), "holder"))
+	waitQueued(t, s, 0)
+	for {
+		p.mu.Lock()
+		n := len(p.started)
+		p.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Queue: background first, then a burst from session A, then session B
+	bg := WithPriority(WithSession(context.Background(), "batch"), PriorityBackground)
+	wg.Add(1)
+	go call(bg)
+	waitQueued(t, s, 1)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go call(WithSession(context.Background(), "A"))
+		waitQueued(t, s, 2+i)
+	}
+	wg.Add(1)
+	go call(WithSession(context.Background(), "B"))
+	waitQueued(t, s, 4)
+
+	close(p.release)
+	wg.Wait()
+
+	want := []string{"holder", "A", "B", "A", "batch"}
+	if len(p.started) != len(want) {
+		t.Fatalf("started %v, want %v", p.started, want)
+	}
+	for i := range want {
+		if p.started[i] != want[i] {
+			t.Fatalf("started %v, want %v", p.started, want)
+		}
+	}
+
+	st := s.Stats()
+	if st.Priorities["interactive"].Served != 4 || st.Priorities["background"].Served != 1 {
+		t.Errorf("unexpected served counts: %+v", st.Priorities)
+	}
+	if st.Active != 0 {
+		t.Errorf("Active = %d after all calls finished", st.Active)
+	}
+}
+
+func TestScheduler_InteractiveAheadOfQueuedBackground(t *testing.T) {
+	p := &blockingProvider{release: make(chan struct{})}
+	s := NewScheduler(p, 1)
+	done := make(chan struct{}, 3)
+	call := func(ctx context.Context) {
+		s.CallLLM(ctx, nil)
+		done <- struct{}{}
+	}
+
+	go call(WithSession(context.Background(), "holder"))
+	for {
+		p.mu.Lock()
+		n := len(p.started)
+		p.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// The background call has waited longer, but an interactive request
+	// arriving after it still gets the next slot.
+	go call(WithPriority(WithSession(context.Background(), "journal"), PriorityBackground))
+	waitQueued(t, s, 1)
+	go call(WithSession(context.Background(), "chat"))
+	waitQueued(t, s, 2)
+
+	close(p.release)
+	for range 3 {
+		<-done
+	}
+	if len(p.started) != 3 || p.started[1] != "chat" || p.started[2] != "journal" {
+		t.Errorf("started %v, want the chat call before the queued background call", p.started)
+	}
+}
+
+func TestPriorityFrom(t *testing.T) {
+	if p := PriorityFrom(context.Background()); p != PriorityInteractive {
+		t.Errorf("untagged = %v, want interactive", p)
+	}
+	if p := PriorityFrom(WithPriority(context.Background(), PriorityBackground)); p != PriorityBackground {
+		t.Errorf("tagged = %v, want background", p)
+	}
+}
+
+func TestScheduler_BackgroundAging(t *testing.T) {
+	s := NewScheduler(&blockingProvider{}, 1)
+	base := time.Now()
+	s.now = func() time.Time { return base }
+
+	s.queues[PriorityBackground].push(&waiter{session: "batch", enqueued: base.Add(-backgroundMaxWait - time.Second)})
+	s.queues[PriorityInteractive].push(&waiter{session: "user", enqueued: base})
+
+	prio, w := s.nextLocked()
+	if prio != PriorityBackground || w.session != "batch" {
+		t.Errorf("starved background call should be promoted, got %v/%s", prio, w.session)
+	}
+}
+
+func TestScheduler_CancelWhileQueued(t *testing.T) {
+	p := &blockingProvider{release: make(chan struct{})}
+	s := NewScheduler(p, 1)
+
+	done := make(chan struct{})
+	go func() {
+		s.CallLLM(context.Background(), nil)
+		close(done)
+	}()
+	for {
+		p.mu.Lock()
+		n := len(p.started)
+		p.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := s.CallLLM(ctx, nil)
+		errCh <- err
+	}()
+	waitQueued(t, s, 1)
+	cancel()
+	if err := <-errCh; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	waitQueued(t, s, 0)
+
+	close(p.release)
+	<-done
+	if st := s.Stats(); st.Active != 0 {
+		t.Errorf("Active = %d, want 0", st.Active)
+	}
+}
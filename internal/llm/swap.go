@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Swappable is an LLMProvider whose backing provider can be replaced while
+// calls are in flight (config reload). Calls already running finish on the
+// provider they started with; new calls use the latest one.
+type Swappable struct {
+	cur atomic.Pointer[swapTarget]
+}
+
+type swapTarget struct {
+	p     LLMProvider
+	model string // default model, for providers that cannot name theirs
+}
+
+// NewSwappable returns a Swappable backed by p, which serves model.
+func NewSwappable(p LLMProvider, model string) *Swappable {
+	s := &Swappable{}
+	s.Swap(p, model)
+	return s
+}
+
+// Swap makes p, which serves model, the provider for subsequent calls.
+func (s *Swappable) Swap(p LLMProvider, model string) {
+	s.cur.Store(&swapTarget{p: p, model: model})
+}
+
+// Current returns the provider calls are sent to.
+func (s *Swappable) Current() LLMProvider { return s.cur.Load().p }
+
+func (s *Swappable) CallLLM(ctx context.Context, messages []Message) (Message, error) {
+	return s.Current().CallLLM(ctx, messages)
+}
+
+func (s *Swappable) CallLLMStream(ctx context.Context, messages []Message, onChunk StreamCallback) (Message, error) {
+	return s.Current().CallLLMStream(ctx, messages, onChunk)
+}
+
+func (s *Swappable) CallLLMWithTools(ctx context.Context, messages []Message, tools []ToolDefinition) (Message, error) {
+	return s.Current().CallLLMWithTools(ctx, messages, tools)
+}
+
+func (s *Swappable) IsToolCallingEnabled() bool { return s.Current().IsToolCallingEnabled() }
+func (s *Swappable) SupportsVision() bool       { return SupportsVision(s.Current()) }
+
+// ModelFor names the model serving r, falling back to the model given to
+// Swap so that caches keyed by model follow a reload.
+func (s *Swappable) ModelFor(r ModelRole) string {
+	t := s.cur.Load()
+	if m := ModelFor(t.p, r); m != "" {
+		return m
+	}
+	return t.model
+}
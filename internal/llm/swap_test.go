@@ -0,0 +1,32 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSwappable(t *testing.T) {
+	ctx := context.Background()
+	s := NewSwappable(&namedProvider{name: "old"}, "gpt-4o")
+	if resp, _ := s.CallLLM(ctx, nil); resp.Content != "old" {
+		t.Errorf("before swap: %q", resp.Content)
+	}
+	if m := ModelFor(s, ModelDecide); m != "gpt-4o" {
+		t.Errorf("ModelFor = %q, want the Swap model", m)
+	}
+
+	s.Swap(NewRouter(&namedProvider{name: "new", tools: true, vision: true}, "o3").
+		Route(ModelSummarize, &namedProvider{name: "cheap"}, "gpt-4o-mini"), "o3")
+	if resp, _ := s.CallLLMStream(ctx, nil, nil); resp.Content != "new" {
+		t.Errorf("after swap: %q", resp.Content)
+	}
+	if resp, _ := s.CallLLMWithTools(WithModelRole(ctx, ModelSummarize), nil, nil); resp.Content != "cheap" {
+		t.Errorf("routed call after swap: %q", resp.Content)
+	}
+	if m := ModelFor(s, ModelSummarize); m != "gpt-4o-mini" {
+		t.Errorf("ModelFor(summarize) = %q, want the router's model", m)
+	}
+	if !s.IsToolCallingEnabled() || !SupportsVision(s) {
+		t.Error("capabilities should follow the swapped provider")
+	}
+}
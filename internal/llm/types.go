@@ -2,16 +2,37 @@ package llm
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"net/http"
 )
 
 // Message represents a chat message for LLM communication.
 type Message struct {
-	Role       string     `json:"role"`                   // "user", "assistant", "system", "tool"
-	Content    string     `json:"content"`                // The message text
-	Name       string     `json:"name,omitempty"`         // FC: function name when role="tool"
-	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // FC: tool calls returned by model
-	ToolCallID string     `json:"tool_call_id,omitempty"` // FC: when role="tool", the ID of the call this responds to
+	Role       string        `json:"role"`                   // "user", "assistant", "system", "tool"
+	Content    string        `json:"content"`                // The message text
+	Name       string        `json:"name,omitempty"`         // FC: function name when role="tool"
+	ToolCalls  []ToolCall    `json:"tool_calls,omitempty"`   // FC: tool calls returned by model
+	ToolCallID string        `json:"tool_call_id,omitempty"` // FC: when role="tool", the ID of the call this responds to
+	Parts      []ContentPart `json:"parts,omitempty"`        // multimodal: images sent after Content (vision models only)
+}
+
+// ContentPart is a non-text block of a multimodal message.
+type ContentPart struct {
+	Type     string `json:"type"`             // ContentPartImage
+	ImageURL string `json:"image_url"`        // https:// or data: URL
+	Detail   string `json:"detail,omitempty"` // "low", "high" or "auto" (default)
+}
+
+// ContentPartImage is the ContentPart type of an image.
+const ContentPartImage = "image"
+
+// NewImagePart embeds image bytes as a data: URL part.
+func NewImagePart(mimeType string, data []byte) ContentPart {
+	return ContentPart{
+		Type:     ContentPartImage,
+		ImageURL: "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data),
+	}
 }
 
 // ToolDefinition describes a tool for Function Calling.
@@ -57,6 +78,29 @@ type LLMProvider interface {
 	IsToolCallingEnabled() bool
 }
 
+// DetectImageType sniffs data and returns its MIME type when it is an image
+// format accepted by vision APIs (PNG, JPEG, GIF, WebP).
+func DetectImageType(data []byte) (string, bool) {
+	switch mime := http.DetectContentType(data); mime {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return mime, true
+	default:
+		return mime, false
+	}
+}
+
+// VisionProvider is implemented by providers that know whether their model
+// accepts image input. Wrappers (scheduler, tracing) forward it.
+type VisionProvider interface {
+	SupportsVision() bool
+}
+
+// SupportsVision reports whether p accepts Message.Parts images.
+func SupportsVision(p LLMProvider) bool {
+	v, ok := p.(VisionProvider)
+	return ok && v.SupportsVision()
+}
+
 // Role constants.
 const (
 	RoleSystem    = "system"
@@ -3,7 +3,9 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/pocketomega/pocket-omega/internal/tool"
@@ -28,10 +30,14 @@ type MCPToolAdapter struct {
 	serverName string
 	info       ToolInfo
 	// client is the shared persistent connection. For per_call lifecycle it is
-	// nil — Execute() creates a fresh Client per invocation using cfg.
+	// nil — Execute() takes a warm connection from pool or creates one using cfg.
 	client    *Client
 	cfg       ServerConfig // used by per_call Execute to rebuild the connection
 	lifecycle string       // "persistent" (default) | "per_call"
+	guard     *pathGuard   // nil = path-like params are not validated
+	schema    *argSchema   // nil = params are not validated against InputSchema
+	pool      *clientPool  // set by the Manager; nil = one process per per_call Execute
+	version   int          // skill snapshot number set by the Manager; 0 = not versioned
 }
 
 // NewMCPToolAdapter creates an adapter for a single MCP tool.
@@ -48,6 +54,8 @@ func NewMCPToolAdapter(serverName string, info ToolInfo, client *Client, cfg Ser
 		client:     client,
 		cfg:        cfg,
 		lifecycle:  lc,
+		guard:      newPathGuard(cfg),
+		schema:     compileSchema(info.InputSchema),
 	}
 }
 
@@ -57,8 +65,12 @@ func (a *MCPToolAdapter) Name() string {
 	return fmt.Sprintf("mcp_%s__%s", a.serverName, a.info.Name)
 }
 
-// Description returns the tool description from the MCP server.
+// Description returns the tool description from the MCP server, with the
+// version of a versioned skill so the agent knows which one it is running.
 func (a *MCPToolAdapter) Description() string {
+	if a.version > 0 {
+		return fmt.Sprintf("%s [skill v%d]", a.info.Description, a.version)
+	}
 	return a.info.Description
 }
 
@@ -73,8 +85,14 @@ func (a *MCPToolAdapter) InputSchema() json.RawMessage {
 // Execute deserialises the JSON args and delegates to the MCP server.
 //
 // For persistent lifecycle: reuses the shared client connection.
-// For per_call lifecycle: creates a fresh Client, runs the tool, then
-// closes the process. This guarantees no residual processes are left running.
+// For per_call lifecycle: reuses the server's pooled connection, starting
+// the server if it is not running; the pool stops it after an idle timeout.
+// Without a pool a fresh Client is created and closed for every call.
+//
+// Params are first checked against the tool's input schema, so a mismatch
+// comes back as an error naming the parameter instead of a cryptic server
+// error, and path-like parameters are validated against the server's path
+// policy.
 //
 // Infrastructure errors and MCP tool-level errors are both returned as
 // a ToolResult.Error (nil Go error) so the agent can react gracefully.
@@ -88,12 +106,35 @@ func (a *MCPToolAdapter) Execute(ctx context.Context, args json.RawMessage) (too
 		}
 	}
 
+	if errs := a.schema.validate(params); len(errs) > 0 {
+		return tool.ToolResult{Error: a.schemaError(errs)}, nil
+	}
+
+	// Third-party servers must not become a way around the workspace sandbox.
+	if err := a.guard.check(params); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("%s: %v", a.Name(), err)}, nil
+	}
+
 	if a.lifecycle == "per_call" {
 		return a.executePerCall(ctx, params)
 	}
 	return a.executePersistent(ctx, params)
 }
 
+// schemaError formats validation errors with a summary of the expected
+// parameters, so the agent can fix the call in one step.
+func (a *MCPToolAdapter) schemaError(errs []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s 参数校验失败，工具未执行：\n", a.Name())
+	for _, e := range errs {
+		sb.WriteString("- " + e + "\n")
+	}
+	if usage := a.schema.usage(); usage != "" {
+		sb.WriteString("参数要求: " + usage)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
 // executePersistent delegates to the long-lived shared client.
 // A per-call timeout (mcpToolTimeout) is applied so that a hung MCP server
 // does not consume the entire agent budget; the error is returned promptly
@@ -114,6 +155,9 @@ func (a *MCPToolAdapter) executePersistent(ctx context.Context, params map[strin
 func (a *MCPToolAdapter) executePerCall(ctx context.Context, params map[string]any) (tool.ToolResult, error) {
 	callCtx, cancel := context.WithTimeout(ctx, mcpToolTimeout)
 	defer cancel()
+	if a.pool != nil {
+		return a.executePooled(callCtx, params)
+	}
 	c := NewClient(a.cfg)
 	if err := c.Connect(callCtx); err != nil {
 		return tool.ToolResult{
@@ -129,6 +173,31 @@ func (a *MCPToolAdapter) executePerCall(ctx context.Context, params map[string]a
 	return tool.ToolResult{Output: text}, nil
 }
 
+// executePooled runs the call on the server's pooled connection. A warm
+// connection that fails at the transport level (e.g. the server exited while
+// idle) is dropped and the call retried once on a fresh one.
+func (a *MCPToolAdapter) executePooled(ctx context.Context, params map[string]any) (tool.ToolResult, error) {
+	for {
+		e, warm, err := a.pool.acquire(ctx, a.cfg)
+		if err != nil {
+			return tool.ToolResult{
+				Error: fmt.Sprintf("mcp per_call: connect to %q: %v", a.cfg.Name, err),
+			}, nil
+		}
+		text, err := e.cli.CallTool(ctx, a.info.Name, params)
+		var te *toolError
+		broken := err != nil && !errors.As(err, &te)
+		a.pool.release(e, broken)
+		if broken && warm && ctx.Err() == nil {
+			continue
+		}
+		if err != nil {
+			return tool.ToolResult{Error: err.Error()}, nil
+		}
+		return tool.ToolResult{Output: text}, nil
+	}
+}
+
 // Init satisfies the tool.Tool interface. MCP connections are managed by the
 // Manager; individual adapters have no additional initialisation.
 func (a *MCPToolAdapter) Init(_ context.Context) error {
@@ -137,7 +206,7 @@ func (a *MCPToolAdapter) Init(_ context.Context) error {
 
 // Close satisfies the tool.Tool interface. Connection lifecycle is managed
 // by the Manager; adapters do not close the shared client.
-// For per_call adapters, there is no persistent connection to close.
+// For per_call adapters, pooled connections are closed by the Manager too.
 func (a *MCPToolAdapter) Close() error {
 	return nil
 }
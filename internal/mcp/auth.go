@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Auth carries the credentials of a remote (sse/http) server. Values may
+// reference environment variables as ${VAR} so that secrets stay in .env
+// rather than in mcp.json:
+//
+//	"auth": {"bearer_token": "${GITHUB_TOKEN}", "headers": {"X-Api-Key": "${SEARCH_KEY}"}}
+//
+// BearerToken is sent as "Authorization: Bearer <token>"; an explicit
+// Authorization header takes precedence.
+type Auth struct {
+	BearerToken string            `json:"bearer_token,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// envRefRe matches a ${VAR} environment reference.
+var envRefRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+func (a *Auth) empty() bool {
+	return a == nil || (a.BearerToken == "" && len(a.Headers) == 0)
+}
+
+func (a *Auth) equal(o *Auth) bool {
+	if a.empty() || o.empty() {
+		return a.empty() == o.empty()
+	}
+	return a.BearerToken == o.BearerToken && maps.Equal(a.Headers, o.Headers)
+}
+
+// httpHeaders resolves the auth block into request headers. A reference to
+// an unset or empty variable is an error: connecting without the secret
+// would only fail later with a less helpful 401.
+func (a *Auth) httpHeaders() (map[string]string, error) {
+	if a.empty() {
+		return nil, nil
+	}
+	headers := make(map[string]string, len(a.Headers)+1)
+	if a.BearerToken != "" {
+		token, err := expandEnvRefs(a.BearerToken)
+		if err != nil {
+			return nil, fmt.Errorf("bearer_token: %w", err)
+		}
+		headers["Authorization"] = "Bearer " + token
+	}
+	for k, v := range a.Headers {
+		value, err := expandEnvRefs(v)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", k, err)
+		}
+		if http.CanonicalHeaderKey(k) == "Authorization" {
+			delete(headers, "Authorization")
+		}
+		headers[k] = value
+	}
+	return headers, nil
+}
+
+// expandEnvRefs replaces every ${VAR} in s with the variable's value.
+func expandEnvRefs(s string) (string, error) {
+	var missing []string
+	out := envRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envRefRe.FindStringSubmatch(ref)[1]
+		v := os.Getenv(name)
+		if v == "" {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
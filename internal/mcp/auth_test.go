@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestAuth_HTTPHeaders(t *testing.T) {
+	t.Setenv("MCP_TEST_TOKEN", "s3cret")
+	a := &Auth{BearerToken: "${MCP_TEST_TOKEN}", Headers: map[string]string{"X-Api-Key": "k-${MCP_TEST_TOKEN}"}}
+	h, err := a.httpHeaders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h["Authorization"] != "Bearer s3cret" || h["X-Api-Key"] != "k-s3cret" {
+		t.Errorf("headers = %v", h)
+	}
+
+	// An explicit Authorization header wins over bearer_token.
+	a = &Auth{BearerToken: "tok", Headers: map[string]string{"authorization": "Basic abc"}}
+	if h, _ := a.httpHeaders(); len(h) != 1 || h["authorization"] != "Basic abc" {
+		t.Errorf("headers = %v", h)
+	}
+
+	a = &Auth{BearerToken: "${MCP_TEST_UNSET_VAR}"}
+	if _, err := a.httpHeaders(); err == nil || !strings.Contains(err.Error(), "MCP_TEST_UNSET_VAR") {
+		t.Errorf("err = %v, want unset variable error", err)
+	}
+	if h, err := (*Auth)(nil).httpHeaders(); h != nil || err != nil {
+		t.Errorf("nil auth = %v, %v", h, err)
+	}
+}
+
+func TestAuth_Equal(t *testing.T) {
+	a := &Auth{BearerToken: "x", Headers: map[string]string{"K": "v"}}
+	if !a.equal(&Auth{BearerToken: "x", Headers: map[string]string{"K": "v"}}) || !(*Auth)(nil).equal(&Auth{}) {
+		t.Error("equal auth reported different")
+	}
+	if a.equal(&Auth{BearerToken: "y", Headers: map[string]string{"K": "v"}}) || a.equal(nil) {
+		t.Error("different auth reported equal")
+	}
+}
+
+func TestClient_HTTPTransportSendsAuth(t *testing.T) {
+	t.Setenv("MCP_TEST_TOKEN", "s3cret")
+	mcpHandler := server.NewStreamableHTTPServer(docsServer())
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mcpHandler.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	c := NewClient(ServerConfig{Name: "remote", Transport: "http", URL: ts.URL + "/mcp",
+		Auth: &Auth{BearerToken: "${MCP_TEST_TOKEN}"}})
+	if err := c.Connect(ctx); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Close()
+	if prompts, err := c.ListPrompts(ctx); err != nil || len(prompts) != 1 {
+		t.Errorf("ListPrompts = %v, %v", prompts, err)
+	}
+
+	noAuth := NewClient(ServerConfig{Name: "remote", Transport: "http", URL: ts.URL + "/mcp"})
+	if err := noAuth.Connect(ctx); err == nil {
+		noAuth.Close()
+		t.Error("Connect without credentials succeeded")
+	}
+}
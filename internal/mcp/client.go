@@ -5,11 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 
 	sdk_client "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	sdk_mcp "github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
 )
 
 // mcpConfigFile mirrors the top-level structure of mcp.json.
@@ -47,17 +53,43 @@ func LoadConfig(path string) (map[string]ServerConfig, error) {
 // The Name field is populated from the map key in mcp.json, not from a JSON field.
 type ServerConfig struct {
 	Name      string   // derived from the map key in mcp.json
-	Transport string   `json:"transport"`         // "stdio" | "sse"
+	Transport string   `json:"transport"`         // "stdio" | "sse" | "http" (streamable HTTP)
 	Command   string   `json:"command,omitempty"` // stdio: executable path
 	Args      []string `json:"args,omitempty"`    // stdio: command arguments
-	URL       string   `json:"url,omitempty"`     // sse: base URL
+	URL       string   `json:"url,omitempty"`     // sse/http: server URL
 	Env       []string `json:"env,omitempty"`     // stdio: extra environment variables
+	// Auth holds the credentials sent to sse/http servers (see Auth).
+	Auth *Auth `json:"auth,omitempty"`
 	// Lifecycle controls how the stdio process is managed.
 	// "persistent" (default, empty string treated as persistent): process stays alive,
 	// connection is reused across calls.
 	// "per_call": a new process is started for each tool invocation and terminated
 	// immediately after. Suitable for stateless tools where cold-start is acceptable.
 	Lifecycle string `json:"lifecycle,omitempty"` // "persistent" | "per_call"
+	// Sandbox opts a stdio server into the container backend when one is
+	// configured (TOOL_SHELL_SANDBOX=container). Ignored otherwise.
+	Sandbox bool `json:"sandbox,omitempty"`
+	// Container is attached by Manager when Sandbox is set and a container
+	// backend is configured; nil = run the process on the host.
+	Container *sandbox.Container `json:"-"`
+	// PathPolicy controls validation of path-like tool parameters:
+	// "workspace" (default, empty string treated as workspace) rejects calls
+	// whose paths resolve outside the workspace; "off" disables the check
+	// for trusted servers.
+	PathPolicy string `json:"path_policy,omitempty"` // "workspace" | "off"
+	// AllowedPaths lists extra roots (absolute, or relative to the workspace)
+	// that path-like parameters may point into under the workspace policy.
+	AllowedPaths []string `json:"allowed_paths,omitempty"`
+	// Workspace is attached by Manager; empty = no path validation.
+	Workspace string `json:"-"`
+	// Dependencies are installed by Manager into a per-server environment
+	// before the first start (see Dependencies).
+	Dependencies *Dependencies `json:"dependencies,omitempty"`
+	// DepsPython and DepsNodePath are attached by Manager once Dependencies
+	// are installed: the virtualenv interpreter replaces a python command,
+	// the node_modules directory is exported as NODE_PATH.
+	DepsPython   string `json:"-"`
+	DepsNodePath string `json:"-"`
 }
 
 // ToolInfo captures the metadata of a single tool exposed by an MCP server.
@@ -73,6 +105,7 @@ type Client struct {
 	mu    sync.RWMutex
 	cfg   ServerConfig
 	inner sdk_client.MCPClient
+	caps  sdk_mcp.ServerCapabilities // announced in the initialize handshake
 }
 
 // NewClient creates an uninitialised Client for the given server config.
@@ -84,18 +117,44 @@ func NewClient(cfg ServerConfig) *Client {
 // Connect establishes the transport connection and performs the MCP
 // initialize handshake. It must be called before ListTools or CallTool.
 func (c *Client) Connect(ctx context.Context) error {
+	ctx, span := telemetry.Start(ctx, "mcp.connect",
+		attribute.String("mcp.server", c.cfg.Name),
+		attribute.String("mcp.transport", c.cfg.Transport))
+	err := c.connect(ctx)
+	telemetry.End(span, err)
+	return err
+}
+
+func (c *Client) connect(ctx context.Context) error {
 	var inner sdk_client.MCPClient
 
 	switch c.cfg.Transport {
 	case "stdio":
-		cli, err := sdk_client.NewStdioMCPClient(c.cfg.Command, c.cfg.Env, c.cfg.Args...)
+		command, env, args := c.cfg.Command, c.cfg.Env, c.cfg.Args
+		if c.cfg.DepsPython != "" && isPythonCommand(command) {
+			command = c.cfg.DepsPython
+		}
+		if c.cfg.DepsNodePath != "" {
+			env = append(slices.Clip(env), "NODE_PATH="+c.cfg.DepsNodePath)
+		}
+		if c.cfg.Container != nil {
+			// Env is forwarded into the container via -e; the runtime CLI itself
+			// gets no extra variables.
+			command, args = c.cfg.Container.Wrap(c.cfg.Command, c.cfg.Args, c.cfg.Env, true)
+			env = nil
+		}
+		cli, err := sdk_client.NewStdioMCPClient(command, env, args...)
 		if err != nil {
 			return fmt.Errorf("mcp: start stdio server %q: %w", c.cfg.Name, err)
 		}
 		inner = cli
 
 	case "sse":
-		cli, err := sdk_client.NewSSEMCPClient(c.cfg.URL)
+		headers, err := c.cfg.Auth.httpHeaders()
+		if err != nil {
+			return fmt.Errorf("mcp: auth for server %q: %w", c.cfg.Name, err)
+		}
+		cli, err := sdk_client.NewSSEMCPClient(c.cfg.URL, transport.WithHeaders(headers))
 		if err != nil {
 			return fmt.Errorf("mcp: create SSE client %q: %w", c.cfg.Name, err)
 		}
@@ -104,12 +163,32 @@ func (c *Client) Connect(ctx context.Context) error {
 		}
 		inner = cli
 
+	case "http":
+		headers, err := c.cfg.Auth.httpHeaders()
+		if err != nil {
+			return fmt.Errorf("mcp: auth for server %q: %w", c.cfg.Name, err)
+		}
+		cli, err := sdk_client.NewStreamableHttpClient(c.cfg.URL, transport.WithHTTPHeaders(headers))
+		if err != nil {
+			return fmt.Errorf("mcp: create HTTP client %q: %w", c.cfg.Name, err)
+		}
+		if err := cli.Start(ctx); err != nil {
+			return fmt.Errorf("mcp: start HTTP client %q: %w", c.cfg.Name, err)
+		}
+		inner = cli
+
 	default:
 		return fmt.Errorf("mcp: unknown transport %q for server %q", c.cfg.Transport, c.cfg.Name)
 	}
 
-	// MCP initialize handshake; clean up if it fails.
-	_, err := inner.Initialize(ctx, sdk_mcp.InitializeRequest{
+	return c.initialize(ctx, inner)
+}
+
+// initialize performs the MCP initialize handshake on a started transport
+// and, on success, makes inner the client's connection. inner is closed if
+// the handshake fails.
+func (c *Client) initialize(ctx context.Context, inner sdk_client.MCPClient) error {
+	res, err := inner.Initialize(ctx, sdk_mcp.InitializeRequest{
 		Params: sdk_mcp.InitializeParams{
 			ProtocolVersion: sdk_mcp.LATEST_PROTOCOL_VERSION,
 			ClientInfo: sdk_mcp.Implementation{
@@ -125,6 +204,7 @@ func (c *Client) Connect(ctx context.Context) error {
 
 	c.mu.Lock()
 	c.inner = inner
+	c.caps = res.Capabilities
 	c.mu.Unlock()
 	return nil
 }
@@ -167,6 +247,17 @@ func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
 // the server-supplied message so callers can distinguish tool errors from
 // infrastructure errors.
 func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (string, error) {
+	ctx, span := telemetry.Start(ctx, "mcp.call_tool",
+		attribute.String("mcp.server", c.cfg.Name),
+		attribute.String("mcp.transport", c.cfg.Transport),
+		attribute.String("mcp.tool", name))
+	text, err := c.callTool(ctx, name, args)
+	span.SetAttributes(attribute.Int("mcp.response_chars", len(text)))
+	telemetry.End(span, err)
+	return text, err
+}
+
+func (c *Client) callTool(ctx context.Context, name string, args map[string]any) (string, error) {
 	c.mu.RLock()
 	inner := c.inner
 	c.mu.RUnlock()
@@ -194,11 +285,21 @@ func (c *Client) CallTool(ctx context.Context, name string, args map[string]any)
 	text := strings.Join(parts, "\n")
 
 	if result.IsError {
-		return "", fmt.Errorf("mcp: tool %q returned error: %s", name, text)
+		return "", &toolError{tool: name, msg: text}
 	}
 	return text, nil
 }
 
+// toolError is a failure reported by the tool itself (IsError=true), as
+// opposed to a transport failure: the connection is still usable.
+type toolError struct {
+	tool, msg string
+}
+
+func (e *toolError) Error() string {
+	return fmt.Sprintf("mcp: tool %q returned error: %s", e.tool, e.msg)
+}
+
 // Close terminates the connection to the MCP server and releases resources.
 func (c *Client) Close() error {
 	c.mu.Lock()
@@ -0,0 +1,190 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// depsStampFile records which Dependencies an environment was built for, so
+// later loads reuse it and only a changed declaration triggers a reinstall.
+const depsStampFile = ".omega-deps.json"
+
+// depsInstallTimeout bounds the installation of one server's dependencies.
+const depsInstallTimeout = 10 * time.Minute
+
+// Dependencies declares the packages a stdio server (skill) needs. The
+// Manager installs them into an environment private to the server, next to
+// its script: a virtualenv in .venv for Python, node_modules for Node.
+//
+//	"dependencies": {"python": ["pandas>=2", "openpyxl"], "node": ["zod@3"]}
+type Dependencies struct {
+	Python []string `json:"python,omitempty"` // pip requirement specifiers
+	Node   []string `json:"node,omitempty"`   // npm package specs
+}
+
+func (d *Dependencies) empty() bool {
+	return d == nil || (len(d.Python) == 0 && len(d.Node) == 0)
+}
+
+func (d *Dependencies) equal(o *Dependencies) bool {
+	if d.empty() || o.empty() {
+		return d.empty() == o.empty()
+	}
+	return slices.Equal(d.Python, o.Python) && slices.Equal(d.Node, o.Node)
+}
+
+// runInstaller runs one installer command in dir and returns its combined
+// output. A variable so tests can record commands instead of running pip/npm.
+var runInstaller = func(ctx context.Context, dir, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// prepareDeps makes sure cfg's declared dependencies are installed and
+// points cfg at the environment (DepsPython / DepsNodePath). The returned
+// note describes what happened for the reload summary ("" when there is
+// nothing to report). An error means the server cannot start.
+func prepareDeps(ctx context.Context, cfg ServerConfig) (ServerConfig, string, error) {
+	if cfg.Transport != "stdio" || cfg.Dependencies.empty() {
+		return cfg, "", nil
+	}
+	if cfg.Container != nil {
+		// The environment would be built for the host, not the container image.
+		return cfg, fmt.Sprintf("[DEPS] %q: sandboxed server — dependencies must be provided by the container image", cfg.Name), nil
+	}
+	script := findScriptFile(cfg)
+	if script == "" {
+		return cfg, "", fmt.Errorf("dependencies need a script path (.py/.ts/.js) in command or args to locate the environment")
+	}
+	dir, err := filepath.Abs(filepath.Dir(script))
+	if err != nil {
+		return cfg, "", err
+	}
+	deps := cfg.Dependencies
+
+	if len(deps.Python) > 0 {
+		cfg.DepsPython = venvPython(dir)
+	}
+	if len(deps.Node) > 0 {
+		cfg.DepsNodePath = filepath.Join(dir, "node_modules")
+	}
+	if depsReady(dir, cfg) {
+		return cfg, "", nil // reuse — the common case after the first load
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, depsInstallTimeout)
+	defer cancel()
+	start := time.Now()
+	log.Printf("[MCP] Installing dependencies for %q in %s", cfg.Name, dir)
+	var steps []string
+	if len(deps.Python) > 0 {
+		if _, err := os.Stat(cfg.DepsPython); err != nil {
+			base := cfg.Command
+			if !isPythonCommand(base) {
+				base = hostPython()
+			}
+			if out, err := runInstaller(ctx, dir, base, "-m", "venv", ".venv"); err != nil {
+				return cfg, "", fmt.Errorf("create virtualenv: %w: %s", err, tailOutput(out))
+			}
+			steps = append(steps, "created .venv")
+		}
+		args := append([]string{"-m", "pip", "install", "--disable-pip-version-check", "-q"}, deps.Python...)
+		if out, err := runInstaller(ctx, dir, cfg.DepsPython, args...); err != nil {
+			return cfg, "", fmt.Errorf("pip install: %w: %s", err, tailOutput(out))
+		}
+		steps = append(steps, fmt.Sprintf("installed %d python package(s)", len(deps.Python)))
+	}
+	if len(deps.Node) > 0 {
+		args := append([]string{"install", "--no-audit", "--no-fund", "--prefix", dir}, deps.Node...)
+		if out, err := runInstaller(ctx, dir, npmCommand(), args...); err != nil {
+			return cfg, "", fmt.Errorf("npm install: %w: %s", err, tailOutput(out))
+		}
+		steps = append(steps, fmt.Sprintf("installed %d node package(s)", len(deps.Node)))
+	}
+
+	if data, err := json.Marshal(deps); err == nil {
+		if err := os.WriteFile(filepath.Join(dir, depsStampFile), data, 0o644); err != nil {
+			log.Printf("[MCP] WARNING: write %s for %q: %v", depsStampFile, cfg.Name, err)
+		}
+	}
+	note := fmt.Sprintf("[DEPS] %q: %s (%.1fs)", cfg.Name, strings.Join(steps, ", "), time.Since(start).Seconds())
+	log.Printf("[MCP] %s", note)
+	return cfg, note, nil
+}
+
+// depsReady reports whether dir holds an environment built for exactly
+// cfg.Dependencies.
+func depsReady(dir string, cfg ServerConfig) bool {
+	data, err := os.ReadFile(filepath.Join(dir, depsStampFile))
+	if err != nil {
+		return false
+	}
+	var stamped Dependencies
+	if json.Unmarshal(data, &stamped) != nil || !stamped.equal(cfg.Dependencies) {
+		return false
+	}
+	for _, p := range []string{cfg.DepsPython, cfg.DepsNodePath} {
+		if p == "" {
+			continue
+		}
+		if _, err := os.Stat(p); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// venvPython is the interpreter of the virtualenv in dir/.venv.
+func venvPython(dir string) string {
+	if goruntime.GOOS == "windows" {
+		return filepath.Join(dir, ".venv", "Scripts", "python.exe")
+	}
+	return filepath.Join(dir, ".venv", "bin", "python")
+}
+
+// isPythonCommand reports whether command runs a Python interpreter
+// ("python", "python3.12", "/usr/bin/python3", "python.exe"...).
+func isPythonCommand(command string) bool {
+	// Split on both separators: mcp.json may hold Windows paths.
+	base := command[strings.LastIndexAny(command, `/\`)+1:]
+	base = strings.TrimSuffix(strings.ToLower(base), ".exe")
+	return strings.HasPrefix(base, "python")
+}
+
+// hostPython returns the interpreter used to create virtualenvs when the
+// server command is not itself a Python interpreter.
+func hostPython() string {
+	for _, name := range []string{"python3", "python"} {
+		if p, err := exec.LookPath(name); err == nil {
+			return p
+		}
+	}
+	return "python3"
+}
+
+func npmCommand() string {
+	if goruntime.GOOS == "windows" {
+		return "npm.cmd"
+	}
+	return "npm"
+}
+
+// tailOutput keeps the end of installer output, where the error usually is.
+func tailOutput(out string) string {
+	out = strings.TrimSpace(out)
+	if r := []rune(out); len(r) > 500 {
+		out = "..." + string(r[len(r)-500:])
+	}
+	return out
+}
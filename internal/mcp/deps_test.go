@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
+)
+
+// fakeInstaller replaces runInstaller for the duration of a test, records
+// the commands and creates what the real installers would.
+func fakeInstaller(t *testing.T) *[]string {
+	t.Helper()
+	var calls []string
+	orig := runInstaller
+	runInstaller = func(_ context.Context, dir, name string, args ...string) (string, error) {
+		calls = append(calls, filepath.Base(name)+" "+strings.Join(args, " "))
+		switch {
+		case len(args) > 1 && args[1] == "venv":
+			py := venvPython(dir)
+			os.MkdirAll(filepath.Dir(py), 0o755)
+			os.WriteFile(py, nil, 0o755)
+		case len(args) > 0 && args[0] == "install":
+			os.MkdirAll(filepath.Join(dir, "node_modules"), 0o755)
+		}
+		return "", nil
+	}
+	t.Cleanup(func() { runInstaller = orig })
+	return &calls
+}
+
+func TestPrepareDeps_InstallsOnceThenReuses(t *testing.T) {
+	calls := fakeInstaller(t)
+	dir := t.TempDir()
+	cfg := ServerConfig{
+		Name:         "excel",
+		Transport:    "stdio",
+		Command:      "python3",
+		Args:         []string{filepath.Join(dir, "server.py")},
+		Dependencies: &Dependencies{Python: []string{"pandas"}, Node: []string{"zod@3"}},
+	}
+
+	got, note, err := prepareDeps(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.DepsPython != venvPython(dir) || got.DepsNodePath != filepath.Join(dir, "node_modules") {
+		t.Errorf("environment not attached: %+v", got)
+	}
+	if len(*calls) != 3 || !strings.Contains(note, "created .venv") || !strings.Contains(note, "1 node package(s)") {
+		t.Errorf("first load: calls=%v note=%q", *calls, note)
+	}
+
+	*calls = nil
+	got, note, err = prepareDeps(context.Background(), cfg)
+	if err != nil || note != "" || len(*calls) != 0 || got.DepsPython == "" {
+		t.Errorf("second load should reuse: calls=%v note=%q err=%v", *calls, note, err)
+	}
+
+	// A changed declaration reinstalls into the existing virtualenv.
+	cfg.Dependencies = &Dependencies{Python: []string{"pandas", "openpyxl"}}
+	*calls = nil
+	if _, _, err := prepareDeps(context.Background(), cfg); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 1 || !strings.Contains((*calls)[0], "pip install") || !strings.Contains((*calls)[0], "openpyxl") {
+		t.Errorf("changed deps: calls=%v", *calls)
+	}
+}
+
+func TestPrepareDeps_SkippedCases(t *testing.T) {
+	calls := fakeInstaller(t)
+	deps := &Dependencies{Python: []string{"requests"}}
+
+	// No dependencies declared: untouched.
+	if got, note, err := prepareDeps(context.Background(), ServerConfig{Transport: "stdio", Command: "python3"}); err != nil || note != "" || got.DepsPython != "" {
+		t.Errorf("no deps: %+v %q %v", got, note, err)
+	}
+	// Sandboxed: the host environment would not exist in the container.
+	sb := &sandbox.Container{}
+	if _, note, err := prepareDeps(context.Background(), ServerConfig{Name: "s", Transport: "stdio", Command: "x.py", Dependencies: deps, Container: sb}); err != nil || !strings.Contains(note, "container image") {
+		t.Errorf("sandboxed: %q %v", note, err)
+	}
+	// No script to anchor the environment.
+	if _, _, err := prepareDeps(context.Background(), ServerConfig{Name: "b", Transport: "stdio", Command: "server", Dependencies: deps}); err == nil {
+		t.Error("expected error without a script path")
+	}
+	if len(*calls) != 0 {
+		t.Errorf("no installer should run, got %v", *calls)
+	}
+}
+
+func TestConfigEqual_Dependencies(t *testing.T) {
+	a := ServerConfig{Transport: "stdio", Command: "python3", Dependencies: &Dependencies{Python: []string{"pandas"}}}
+	b := a
+	b.DepsPython = "/ws/skills/x/.venv/bin/python" // attached by Manager, not part of the config
+	if !configEqual(a, b) {
+		t.Error("attached environment must not count as a config change")
+	}
+	b.Dependencies = &Dependencies{Python: []string{"pandas", "numpy"}}
+	if configEqual(a, b) {
+		t.Error("changed dependencies must trigger a reconnect")
+	}
+	if !configEqual(ServerConfig{Dependencies: &Dependencies{}}, ServerConfig{}) {
+		t.Error("empty and absent dependencies are equal")
+	}
+}
+
+func TestIsPythonCommand(t *testing.T) {
+	for cmd, want := range map[string]bool{
+		"python": true, "python3.12": true, "/usr/bin/python3": true, `C:\Python\python.exe`: true,
+		"node": false, "uv": false,
+	} {
+		if got := isPythonCommand(cmd); got != want {
+			t.Errorf("isPythonCommand(%q) = %v, want %v", cmd, got, want)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
@@ -33,8 +34,13 @@ type Manager struct {
 	clients          map[string]*Client      // active connections keyed by server name
 	serverTools      map[string][]string     // server name → registered tool names
 	perCallToolInfos map[string][]ToolInfo   // tool discovery cache for per_call servers (ConnectAll → RegisterTools)
+	pool             *clientPool             // warm connections of per_call servers; nil = disabled
 	promptLoader     *prompt.PromptLoader    // optional; when set, Reload also clears prompt cache
 	reloadHooks      []ReloadHook            // optional hooks fired at end of every Reload
+	sandbox          *sandbox.Container      // optional; stdio servers with "sandbox": true run inside it
+	workspaceDir     string                  // optional; enables path_policy checks on tool params
+	keepVersions     int                     // snapshots kept per skill (MCP_SKILL_VERSIONS); 0 = off
+	versions         map[string]skillVersion // skill server name → version it was started from
 }
 
 // NewManager creates a Manager for the given mcp.json path.
@@ -46,9 +52,29 @@ func NewManager(configPath string) *Manager {
 		clients:          make(map[string]*Client),
 		serverTools:      make(map[string][]string),
 		perCallToolInfos: make(map[string][]ToolInfo),
+		pool:             newClientPool(perCallIdle),
+		keepVersions:     loadSkillVersions(),
+		versions:         make(map[string]skillVersion),
 	}
 }
 
+// recordVersion snapshots the skill of a server that just started (see
+// recordSkillVersion). Servers that are not skills get the zero version.
+func (m *Manager) recordVersion(cfg ServerConfig) skillVersion {
+	dir := skillDir(cfg)
+	if dir == "" {
+		return skillVersion{}
+	}
+	m.mu.Lock()
+	keep := m.keepVersions
+	m.mu.Unlock()
+	v, err := recordSkillVersion(dir, keep)
+	if err != nil {
+		log.Printf("[MCP] WARNING: snapshot of skill %q failed: %v", cfg.Name, err)
+	}
+	return v
+}
+
 // SetPromptLoader registers a PromptLoader so that Reload also invalidates
 // the prompt cache.  Must be called before the first Reload invocation.
 // Safe for concurrent use.
@@ -58,6 +84,59 @@ func (m *Manager) SetPromptLoader(l *prompt.PromptLoader) {
 	m.mu.Unlock()
 }
 
+// SetSandbox configures the container backend used for stdio servers that
+// opt in with "sandbox": true. Must be called before ConnectAll.
+func (m *Manager) SetSandbox(c *sandbox.Container) {
+	m.mu.Lock()
+	m.sandbox = c
+	m.mu.Unlock()
+}
+
+// SetWorkspace sets the workspace that path-like MCP tool parameters are
+// validated against (see ServerConfig.PathPolicy). Must be called before ConnectAll.
+func (m *Manager) SetWorkspace(dir string) {
+	m.mu.Lock()
+	m.workspaceDir = dir
+	m.mu.Unlock()
+}
+
+// attachWorkspace sets Workspace on every config so adapters can enforce
+// the server's path policy.
+func (m *Manager) attachWorkspace(configs map[string]ServerConfig) {
+	m.mu.Lock()
+	ws := m.workspaceDir
+	m.mu.Unlock()
+
+	for name, cfg := range configs {
+		if cfg.PathPolicy != "" && cfg.PathPolicy != PathPolicyWorkspace && cfg.PathPolicy != PathPolicyOff {
+			log.Printf("[MCP] WARNING: server %q has unknown path_policy %q; using %q", name, cfg.PathPolicy, PathPolicyWorkspace)
+		}
+		cfg.Workspace = ws
+		configs[name] = cfg
+	}
+}
+
+// attachSandbox sets Container on every stdio config that opts into the
+// sandbox. Servers requesting a sandbox when none is configured run on the
+// host with a warning.
+func (m *Manager) attachSandbox(configs map[string]ServerConfig) {
+	m.mu.Lock()
+	sb := m.sandbox
+	m.mu.Unlock()
+
+	for name, cfg := range configs {
+		if !cfg.Sandbox || cfg.Transport != "stdio" {
+			continue
+		}
+		if sb == nil {
+			log.Printf("[MCP] WARNING: server %q requests sandbox but TOOL_SHELL_SANDBOX is not \"container\"; running on host", name)
+			continue
+		}
+		cfg.Container = sb
+		configs[name] = cfg
+	}
+}
+
 // AddReloadHook registers a function that is called at the end of every Reload.
 // Hooks are invoked in registration order. Each hook's non-empty return value
 // is appended to the reload summary. Safe for concurrent use.
@@ -78,6 +157,8 @@ func (m *Manager) ConnectAll(ctx context.Context) (int, []error) {
 	if err != nil {
 		return 0, []error{fmt.Errorf("mcp: load config: %w", err)}
 	}
+	m.attachSandbox(configs)
+	m.attachWorkspace(configs)
 
 	// Establish connections outside the lock.
 	// per_call servers: connect temporarily to discover tools, then close immediately.
@@ -88,10 +169,18 @@ func (m *Manager) ConnectAll(ctx context.Context) (int, []error) {
 		cfg   ServerConfig
 		cli   *Client // nil for per_call after tool discovery
 		tools []ToolInfo
+		ver   skillVersion
 		err   error
 	}
 	results := make([]connResult, 0, len(configs))
 	for name, cfg := range configs {
+		// Install declared dependencies on first load; reused afterwards.
+		cfg, _, err := prepareDeps(ctx, cfg)
+		if err != nil {
+			results = append(results, connResult{name: name, err: fmt.Errorf("dependencies: %w", err)})
+			log.Printf("[MCP] Dependencies failed: %s: %v", name, err)
+			continue
+		}
 		if cfg.Lifecycle == "per_call" {
 			// Temporary connection: discover tools then close.
 			tmp := NewClient(cfg)
@@ -107,7 +196,7 @@ func (m *Manager) ConnectAll(ctx context.Context) (int, []error) {
 				log.Printf("[MCP] per_call list tools failed: %s: %v", name, err)
 				continue
 			}
-			results = append(results, connResult{name: name, cfg: cfg, cli: nil, tools: tools})
+			results = append(results, connResult{name: name, cfg: cfg, cli: nil, tools: tools, ver: m.recordVersion(cfg)})
 			log.Printf("[MCP] per_call discovered: %s (%d tool(s))", name, len(tools))
 		} else {
 			cli := NewClient(cfg)
@@ -115,7 +204,7 @@ func (m *Manager) ConnectAll(ctx context.Context) (int, []error) {
 				results = append(results, connResult{name: name, err: err})
 				log.Printf("[MCP] Connect failed: %s: %v", name, err)
 			} else {
-				results = append(results, connResult{name: name, cfg: cfg, cli: cli})
+				results = append(results, connResult{name: name, cfg: cfg, cli: cli, ver: m.recordVersion(cfg)})
 				log.Printf("[MCP] Connected: %s (%s)", name, cfg.Transport)
 			}
 		}
@@ -134,6 +223,7 @@ func (m *Manager) ConnectAll(ctx context.Context) (int, []error) {
 		}
 		m.clients[r.name] = r.cli // nil for per_call
 		m.configs[r.name] = r.cfg
+		m.versions[r.name] = r.ver
 		// Cache per_call tool infos so RegisterTools can register adapters
 		// without a redundant network round-trip. Persistent servers discover
 		// their tools during the RegisterTools call itself.
@@ -198,6 +288,8 @@ func (m *Manager) RegisterTools(ctx context.Context, registry *tool.Registry) er
 		var toolNames []string
 		for _, ti := range r.tools {
 			adapter := NewMCPToolAdapter(r.name, ti, m.clients[r.name], r.cfg)
+			adapter.pool = m.pool
+			adapter.version = m.versions[r.name].n
 			registry.Register(adapter)
 			toolNames = append(toolNames, adapter.Name())
 		}
@@ -210,6 +302,8 @@ func (m *Manager) RegisterTools(ctx context.Context, registry *tool.Registry) er
 // Reload re-reads mcp.json and applies a diff:
 //   - Added servers: security-scanned (stdio .py), connected, tools registered.
 //   - Removed servers: tools unregistered, connections closed.
+//   - Skills whose files changed since they started: restarted like a
+//     changed config, and snapshotted as a new version once they start.
 //   - Unchanged servers: left untouched.
 //
 // Network I/O is performed outside the lock. Returns a human-readable summary
@@ -221,6 +315,18 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 	if err != nil {
 		return "", fmt.Errorf("mcp reload: load config: %w", err)
 	}
+	m.attachSandbox(newConfigs)
+	m.attachWorkspace(newConfigs)
+
+	// Hash the skill directories before taking the lock (file I/O).
+	skillHashes := make(map[string]string)
+	for name, cfg := range newConfigs {
+		if dir := skillDir(cfg); dir != "" {
+			if h, err := hashSkill(dir); err == nil {
+				skillHashes[name] = h
+			}
+		}
+	}
 
 	// Step 2: Compute diff under the lock.
 	m.mu.Lock()
@@ -237,8 +343,8 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 	for name, cfg := range newConfigs {
 		if oldCfg, exists := m.configs[name]; !exists {
 			toAdd = append(toAdd, cfg)
-		} else if !configEqual(oldCfg, cfg) {
-			// Config changed: schedule remove + re-add (reconnect with new config).
+		} else if !configEqual(oldCfg, cfg) || skillChanged(m.versions[name], skillHashes[name]) {
+			// Config or skill files changed: schedule remove + re-add (reconnect with new config).
 			toRemove = append(toRemove, name)
 			toAdd = append(toAdd, cfg)
 			toModify = append(toModify, name)
@@ -257,11 +363,13 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 		delete(m.serverTools, name)
 		delete(m.clients, name)
 		delete(m.configs, name)
+		delete(m.versions, name)
 		m.mu.Unlock()
 
 		for _, toolName := range toolNames {
 			registry.Unregister(toolName)
 		}
+		m.pool.evict(name)
 		if cli != nil {
 			if err := cli.Close(); err != nil {
 				log.Printf("[MCP] Close error for %q: %v", name, err)
@@ -277,6 +385,7 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 		cfg     ServerConfig
 		cli     *Client
 		tools   []ToolInfo
+		ver     skillVersion
 		blocked bool
 		notice  string
 		err     error
@@ -288,9 +397,9 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 
 		// Security scan for stdio scripts. Persists scan_result + scanned_at to mcp.json _meta.
 		if cfg.Transport == "stdio" {
-			pyScript := findScriptFile(cfg)
-			if pyScript != "" {
-				findings, scanErr := ScanScript(pyScript)
+			script := findScriptFile(cfg)
+			if script != "" {
+				findings, scanErr := ScanScript(script)
 				today := time.Now().Format("2006-01-02")
 				if scanErr != nil {
 					res.notice = fmt.Sprintf("[WARNING] scan error for %q: %v", cfg.Name, scanErr)
@@ -298,7 +407,7 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 				} else if HasCritical(findings) {
 					LogFindings(cfg.Name, findings)
 					var lines []string
-					lines = append(lines, fmt.Sprintf("[BLOCKED] server %q: critical security findings in %s", cfg.Name, pyScript))
+					lines = append(lines, fmt.Sprintf("[BLOCKED] server %q: critical security findings in %s", cfg.Name, script))
 					for _, f := range findings {
 						if f.Severity == SeverityCritical {
 							lines = append(lines, fmt.Sprintf("  [%s] line %d: %s", f.Rule, f.Line, f.Snippet))
@@ -326,6 +435,21 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 			}
 		}
 
+		// Install declared dependencies into the server's own environment
+		// (first load or changed declaration). The note reports the progress
+		// in the reload summary.
+		cfg, depsNote, err := prepareDeps(ctx, cfg)
+		if depsNote != "" {
+			res.notice = strings.TrimPrefix(res.notice+"\n"+depsNote, "\n")
+		}
+		if err != nil {
+			res.err = err
+			res.notice = strings.TrimPrefix(res.notice+"\n"+fmt.Sprintf("[WARNING] dependencies %q: %v", cfg.Name, err), "\n")
+			addResults = append(addResults, res)
+			continue
+		}
+		res.cfg = cfg
+
 		// Connect and list tools (per_call: ephemeral connection; persistent: kept alive).
 		if cfg.Lifecycle == "per_call" {
 			tmp := NewClient(cfg)
@@ -364,6 +488,10 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 			res.cli = cli
 			res.tools = tools
 		}
+		res.ver = m.recordVersion(cfg)
+		if res.ver.n > 0 {
+			res.notice = strings.TrimPrefix(res.notice+"\n"+fmt.Sprintf("[SKILL] %q: version v%d", cfg.Name, res.ver.n), "\n")
+		}
 		addResults = append(addResults, res)
 	}
 
@@ -383,12 +511,15 @@ func (m *Manager) Reload(ctx context.Context, registry *tool.Registry) (string,
 		var toolNames []string
 		for _, ti := range res.tools {
 			adapter := NewMCPToolAdapter(res.name, ti, res.cli, res.cfg)
+			adapter.pool = m.pool
+			adapter.version = res.ver.n
 			registry.Register(adapter)
 			toolNames = append(toolNames, adapter.Name())
 		}
 		m.mu.Lock()
 		m.clients[res.name] = res.cli // nil for per_call
 		m.configs[res.name] = res.cfg
+		m.versions[res.name] = res.ver
 		m.serverTools[res.name] = toolNames
 		m.mu.Unlock()
 
@@ -452,12 +583,13 @@ func (m *Manager) CloseAll() {
 
 	for name, cli := range clients {
 		if cli == nil {
-			continue // per_call servers have no persistent connection to close
+			continue // per_call servers: pooled connections are closed below
 		}
 		if err := cli.Close(); err != nil {
 			log.Printf("[MCP] Close error for %q: %v", name, err)
 		}
 	}
+	m.pool.closeAll()
 	log.Printf("[MCP] All connections closed")
 }
 
@@ -503,12 +635,28 @@ func updateServerMeta(configPath, serverName string, updates map[string]string)
 	}
 }
 
+// skillChanged reports whether the files of a running skill differ from
+// the version it was started from. hash is "" when unknown.
+func skillChanged(running skillVersion, hash string) bool {
+	return running.hash != "" && hash != "" && running.hash != hash
+}
+
 // configEqual reports whether two ServerConfig values are functionally identical.
 // Only fields that affect runtime behaviour are compared; Name and _meta are excluded.
 func configEqual(a, b ServerConfig) bool {
-	if a.Transport != b.Transport || a.Command != b.Command || a.URL != b.URL || a.Lifecycle != b.Lifecycle {
+	if a.Transport != b.Transport || a.Command != b.Command || a.URL != b.URL || a.Lifecycle != b.Lifecycle ||
+		a.Sandbox != b.Sandbox || a.PathPolicy != b.PathPolicy || a.Workspace != b.Workspace ||
+		!a.Dependencies.equal(b.Dependencies) || !a.Auth.equal(b.Auth) {
+		return false
+	}
+	if len(a.AllowedPaths) != len(b.AllowedPaths) {
 		return false
 	}
+	for i := range a.AllowedPaths {
+		if a.AllowedPaths[i] != b.AllowedPaths[i] {
+			return false
+		}
+	}
 	if len(a.Args) != len(b.Args) {
 		return false
 	}
@@ -528,11 +676,10 @@ func configEqual(a, b ServerConfig) bool {
 	return true
 }
 
-// findScriptFile returns the first scannable script (.py/.ts/.js) in a ServerConfig,
-// checking the command itself and then the argument list.
+// findScriptFile returns the first scannable script (see scriptExts) in a
+// ServerConfig, checking the command itself and then the argument list.
 func findScriptFile(cfg ServerConfig) string {
-	exts := []string{".py", ".ts", ".js"}
-	for _, ext := range exts {
+	for _, ext := range scriptExts {
 		if strings.HasSuffix(cfg.Command, ext) {
 			return cfg.Command
 		}
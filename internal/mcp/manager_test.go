@@ -8,6 +8,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
@@ -53,6 +54,14 @@ func TestFindScriptFile_ArgIsJS(t *testing.T) {
 	}
 }
 
+func TestFindScriptFile_ModuleExtension(t *testing.T) {
+	cfg := ServerConfig{Command: "node", Args: []string{"--enable-source-maps", "skills/server.mjs"}}
+	got := findScriptFile(cfg)
+	if got != "skills/server.mjs" {
+		t.Errorf("findScriptFile() = %q, want %q", got, "skills/server.mjs")
+	}
+}
+
 // ── Manager construction and error paths ──────────────────────────────────
 
 func TestNewManager_CreatesEmptyState(t *testing.T) {
@@ -463,3 +472,70 @@ func TestReloadTool_Execute_EmptyConfig(t *testing.T) {
 		t.Error("expected non-empty Output for successful reload")
 	}
 }
+
+// ── Sandbox ──
+
+func TestAttachSandbox(t *testing.T) {
+	m := NewManager("unused.json")
+	configs := map[string]ServerConfig{
+		"boxed":  {Name: "boxed", Transport: "stdio", Command: "python", Sandbox: true},
+		"host":   {Name: "host", Transport: "stdio", Command: "python"},
+		"remote": {Name: "remote", Transport: "sse", URL: "http://x", Sandbox: true},
+	}
+
+	// No sandbox configured: opt-in servers fall back to host
+	m.attachSandbox(configs)
+	if configs["boxed"].Container != nil {
+		t.Error("no sandbox configured: Container should stay nil")
+	}
+
+	sb := &sandbox.Container{Runtime: "docker", Image: "alpine:3"}
+	m.SetSandbox(sb)
+	m.attachSandbox(configs)
+	if configs["boxed"].Container != sb {
+		t.Error("opted-in stdio server should get the sandbox")
+	}
+	if configs["host"].Container != nil {
+		t.Error("server without sandbox flag must run on host")
+	}
+	if configs["remote"].Container != nil {
+		t.Error("sse server must never be sandboxed")
+	}
+}
+
+func TestConfigEqual_SandboxFlag(t *testing.T) {
+	a := ServerConfig{Transport: "stdio", Command: "python"}
+	b := a
+	b.Sandbox = true
+	if configEqual(a, b) {
+		t.Error("toggling sandbox should be treated as a config change")
+	}
+}
+
+// ── Path policy ──
+
+func TestAttachWorkspace(t *testing.T) {
+	m := NewManager("unused.json")
+	m.SetWorkspace("/work")
+	configs := map[string]ServerConfig{
+		"fs": {Name: "fs", Transport: "stdio", Command: "node"},
+	}
+	m.attachWorkspace(configs)
+	if configs["fs"].Workspace != "/work" {
+		t.Errorf("Workspace = %q, want /work", configs["fs"].Workspace)
+	}
+}
+
+func TestConfigEqual_PathPolicy(t *testing.T) {
+	a := ServerConfig{Transport: "stdio", Command: "node"}
+	b := a
+	b.PathPolicy = PathPolicyOff
+	if configEqual(a, b) {
+		t.Error("changing path_policy should be treated as a config change")
+	}
+	c := a
+	c.AllowedPaths = []string{"/data"}
+	if configEqual(a, c) {
+		t.Error("changing allowed_paths should be treated as a config change")
+	}
+}
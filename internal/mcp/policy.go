@@ -0,0 +1,217 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Path policies for ServerConfig.PathPolicy.
+const (
+	PathPolicyWorkspace = "workspace" // default: path-like params must stay inside the workspace
+	PathPolicyOff       = "off"       // trusted server: params are passed through unchecked
+)
+
+// pathKeyWords are the key words that mark a parameter as path-like.
+// Keys are split on "_", "-" and camelCase boundaries before matching,
+// so "file_path", "outputDir" and "target-folder" all qualify.
+var pathKeyWords = map[string]bool{
+	"path": true, "paths": true, "filepath": true, "filename": true,
+	"file": true, "files": true, "dir": true, "dirs": true,
+	"directory": true, "directories": true, "folder": true,
+	"cwd": true, "root": true,
+}
+
+// winAbsPath matches Windows drive paths ("C:\x", "C:/x") so they are
+// treated as absolute on every host OS.
+var winAbsPath = regexp.MustCompile(`^[A-Za-z]:[\\/]`)
+
+// pathGuard validates path-like MCP tool parameters against the workspace
+// sandbox. A nil guard allows everything.
+type pathGuard struct {
+	roots []string // cleaned absolute roots; the workspace is always first
+}
+
+// newPathGuard builds the guard for a server config, or nil when no check
+// applies (policy "off" or no workspace attached).
+func newPathGuard(cfg ServerConfig) *pathGuard {
+	if cfg.PathPolicy == PathPolicyOff || cfg.Workspace == "" {
+		return nil
+	}
+	ws := realPath(filepath.Clean(cfg.Workspace))
+	g := &pathGuard{roots: []string{ws}}
+	for _, p := range cfg.AllowedPaths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(ws, p)
+		}
+		g.roots = append(g.roots, realPath(filepath.Clean(p)))
+	}
+	return g
+}
+
+// check walks params (including nested objects and arrays) and returns an
+// error for the first path-like value that resolves outside the allowed roots.
+// Keys are visited in sorted order so the reported violation is deterministic.
+func (g *pathGuard) check(params map[string]any) error {
+	if g == nil {
+		return nil
+	}
+	return g.checkMap("", params)
+}
+
+func (g *pathGuard) checkMap(prefix string, m map[string]any) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := k
+		if prefix != "" {
+			name = prefix + "." + k
+		}
+		if err := g.checkValue(name, isPathKey(k), m[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *pathGuard) checkValue(name string, pathKey bool, v any) error {
+	switch val := v.(type) {
+	case string:
+		return g.checkString(name, pathKey, val)
+	case map[string]any:
+		return g.checkMap(name, val)
+	case []any:
+		for i, item := range val {
+			if err := g.checkValue(fmt.Sprintf("%s[%d]", name, i), pathKey, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkString validates a single value. Values under path-like keys are
+// always checked; any other value is checked only when it is a file:// URL,
+// since that is unambiguously a filesystem reference.
+func (g *pathGuard) checkString(name string, pathKey bool, s string) error {
+	s = strings.TrimSpace(s)
+	if rest, ok := cutPrefixFold(s, "file://"); ok {
+		s = rest
+	} else if !pathKey {
+		return nil
+	}
+	if s == "" || strings.ContainsAny(s, "\n\x00") {
+		return nil
+	}
+	resolved, ok := g.resolve(s)
+	if ok && g.allowed(resolved) {
+		return nil
+	}
+	return fmt.Errorf("参数 %s 的路径 %q 超出工作区，已拒绝（如确需访问，请在 mcp.json 中为该服务器配置 allowed_paths，或设置 path_policy 为 \"off\"）", name, s)
+}
+
+// resolve turns a parameter value into a cleaned absolute path. Relative
+// paths are taken relative to the workspace. ok=false means the value
+// cannot be mapped onto this host (e.g. a "~" or foreign drive path).
+func (g *pathGuard) resolve(p string) (string, bool) {
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		p = filepath.Join(home, p[1:])
+	}
+	if winAbsPath.MatchString(p) && filepath.VolumeName(p) == "" {
+		return "", false // Windows drive path on a non-Windows host
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(g.roots[0], p)
+	}
+	return realPath(filepath.Clean(p)), true
+}
+
+// allowed reports whether p is one of the roots or lies beneath one.
+func (g *pathGuard) allowed(p string) bool {
+	for _, root := range g.roots {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
+
+// realPath resolves symlinks in the longest existing prefix of p, so a
+// symlink inside the workspace cannot be used to point outside it.
+// Non-existent trailing components are re-appended unchanged.
+func realPath(p string) string {
+	var tail []string
+	cur := p
+	for {
+		if r, err := filepath.EvalSymlinks(cur); err == nil {
+			return filepath.Join(append([]string{r}, tail...)...)
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return p
+		}
+		tail = append([]string{filepath.Base(cur)}, tail...)
+		cur = parent
+	}
+}
+
+// isPathKey reports whether a parameter name looks like it carries a path.
+func isPathKey(key string) bool {
+	for _, w := range splitKeyWords(key) {
+		if pathKeyWords[w] {
+			return true
+		}
+	}
+	return false
+}
+
+// splitKeyWords splits "outputFilePath" / "output_file-path" into lowercase words.
+func splitKeyWords(key string) []string {
+	var words []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, strings.ToLower(string(cur)))
+			cur = cur[:0]
+		}
+	}
+	for _, r := range key {
+		switch {
+		case r == '_' || r == '-' || r == '.' || unicode.IsSpace(r):
+			flush()
+		case unicode.IsUpper(r) && len(cur) > 0 && !unicode.IsUpper(cur[len(cur)-1]):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	return words
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+		return s[len(prefix):], true
+	}
+	return s, false
+}
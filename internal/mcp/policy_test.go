@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPathGuard_Check(t *testing.T) {
+	ws := t.TempDir()
+	extra := t.TempDir()
+	g := newPathGuard(ServerConfig{Workspace: ws, AllowedPaths: []string{extra}})
+
+	tests := []struct {
+		name   string
+		params map[string]any
+		ok     bool
+	}{
+		{"relative inside", map[string]any{"path": "data/a.csv"}, true},
+		{"absolute inside", map[string]any{"file_path": filepath.Join(ws, "a.txt")}, true},
+		{"workspace root", map[string]any{"dir": ws}, true},
+		{"allowed extra root", map[string]any{"outputDir": filepath.Join(extra, "out")}, true},
+		{"non-path key ignored", map[string]any{"query": "/etc/passwd"}, true},
+		{"traversal", map[string]any{"path": "../../etc/passwd"}, false},
+		{"absolute outside", map[string]any{"filename": "/etc/passwd"}, false},
+		{"camelCase key", map[string]any{"sourceFile": "/etc/hosts"}, false},
+		{"home dir", map[string]any{"cwd": "~/.ssh"}, false},
+		{"windows drive", map[string]any{"folder": `C:\Windows`}, false},
+		{"file url under any key", map[string]any{"uri": "file:///etc/shadow"}, false},
+		{"nested object", map[string]any{"opts": map[string]any{"root": "/"}}, false},
+		{"array of paths", map[string]any{"paths": []any{"ok.txt", "/etc/passwd"}}, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := g.check(tc.params)
+			if (err == nil) != tc.ok {
+				t.Errorf("check(%v) error = %v, want ok=%v", tc.params, err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestPathGuard_SymlinkEscape(t *testing.T) {
+	ws := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(ws, "link")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	g := newPathGuard(ServerConfig{Workspace: ws})
+	if err := g.check(map[string]any{"path": "link/secret.txt"}); err == nil {
+		t.Error("symlink pointing outside the workspace should be rejected")
+	}
+}
+
+func TestPathGuard_Disabled(t *testing.T) {
+	if newPathGuard(ServerConfig{Workspace: t.TempDir(), PathPolicy: PathPolicyOff}) != nil {
+		t.Error(`path_policy "off" should disable the guard`)
+	}
+	if newPathGuard(ServerConfig{}) != nil {
+		t.Error("no workspace attached: guard should be nil")
+	}
+	var g *pathGuard
+	if err := g.check(map[string]any{"path": "/etc/passwd"}); err != nil {
+		t.Errorf("nil guard should allow everything, got %v", err)
+	}
+}
+
+func TestMCPToolAdapter_Execute_PathPolicyRejects(t *testing.T) {
+	// client is nil: a rejected call must never reach the server.
+	adapter := NewMCPToolAdapter("fs", ToolInfo{Name: "read_file"}, nil,
+		ServerConfig{Workspace: t.TempDir()})
+	result, err := adapter.Execute(context.Background(), json.RawMessage(`{"path":"/etc/passwd"}`))
+	if err != nil {
+		t.Fatalf("unexpected Go error: %v", err)
+	}
+	if !strings.Contains(result.Error, "超出工作区") || !strings.Contains(result.Error, "mcp_fs__read_file") {
+		t.Errorf("expected path policy error, got %q", result.Error)
+	}
+}
+
+func TestIsPathKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"path": true, "file_path": true, "outputDir": true, "target-folder": true,
+		"FILE": true, "root": true, "query": false, "profile": false, "content": false,
+	} {
+		if got := isPathKey(key); got != want {
+			t.Errorf("isPathKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package mcp
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultPerCallIdle = 60 * time.Second
+
+// perCallIdle is how long a per_call server is kept running after its last
+// tool call (MCP_PER_CALL_IDLE_SECONDS). 0 disables pooling: every call
+// starts and stops its own server process, as before.
+var perCallIdle = loadPerCallIdle()
+
+func loadPerCallIdle() time.Duration {
+	v := os.Getenv("MCP_PER_CALL_IDLE_SECONDS")
+	if v == "" {
+		return defaultPerCallIdle
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("[Config] WARNING: invalid MCP_PER_CALL_IDLE_SECONDS=%q (must be >= 0), using default %d", v, int(defaultPerCallIdle/time.Second))
+		return defaultPerCallIdle
+	}
+	return time.Duration(n) * time.Second
+}
+
+// clientPool keeps per_call servers warm between tool calls. The first call
+// starts the server; calls within the idle timeout reuse its connection;
+// once idle for longer the server is closed again. At most one connection
+// per server exists — Client is safe for concurrent calls.
+//
+// A nil *clientPool is valid and means pooling is disabled.
+type clientPool struct {
+	idle    time.Duration
+	mu      sync.Mutex
+	entries map[string]*poolEntry // keyed by server name
+}
+
+// poolEntry is one pooled connection. It stays in clientPool.entries while
+// reusable; an entry that was evicted, broke or was reaped is closed as
+// soon as no call is using it.
+type poolEntry struct {
+	cfg      ServerConfig
+	ready    chan struct{} // closed when connecting finished
+	cli      *Client       // set before ready is closed; nil if err != nil
+	err      error
+	cancel   context.CancelFunc // ends the connection's context
+	active   int                // calls in flight
+	lastUsed time.Time
+}
+
+// newClientPool returns a pool reaping connections after idle, or nil
+// (pooling disabled) when idle is 0.
+func newClientPool(idle time.Duration) *clientPool {
+	if idle <= 0 {
+		return nil
+	}
+	return &clientPool{idle: idle, entries: make(map[string]*poolEntry)}
+}
+
+// acquire returns a connection to cfg's server, starting it if no warm one
+// exists. warm reports whether the connection was reused from an earlier
+// call. The caller must call release with the returned entry when done.
+func (p *clientPool) acquire(ctx context.Context, cfg ServerConfig) (e *poolEntry, warm bool, err error) {
+	p.mu.Lock()
+	var stale *poolEntry
+	e = p.entries[cfg.Name]
+	if e != nil && !configEqual(e.cfg, cfg) {
+		// The server was reconfigured since this connection was made.
+		stale = p.removeLocked(cfg.Name, e)
+		e = nil
+	}
+	warm = e != nil
+	if e == nil {
+		e = p.startLocked(ctx, cfg)
+	}
+	e.active++
+	p.mu.Unlock()
+	stale.close()
+
+	select {
+	case <-e.ready:
+	case <-ctx.Done():
+		p.release(e, false)
+		return nil, false, ctx.Err()
+	}
+	if e.err != nil {
+		p.release(e, true)
+		return nil, false, e.err
+	}
+	return e, warm, nil
+}
+
+// startLocked registers a new entry for cfg and connects it in the
+// background. The connection gets its own context (keeping ctx's values),
+// since SSE streams die with the context they were started with; a
+// watchdog cancels it if connecting takes longer than mcpToolTimeout.
+func (p *clientPool) startLocked(ctx context.Context, cfg ServerConfig) *poolEntry {
+	connCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	e := &poolEntry{cfg: cfg, ready: make(chan struct{}), cancel: cancel}
+	p.entries[cfg.Name] = e
+	go func() {
+		watchdog := time.AfterFunc(mcpToolTimeout, cancel)
+		cli := NewClient(cfg)
+		err := cli.Connect(connCtx)
+		watchdog.Stop()
+		if err != nil {
+			cli = nil
+			log.Printf("[MCP] per_call start failed: %s: %v", cfg.Name, err)
+		} else {
+			log.Printf("[MCP] per_call started: %s (idle timeout %s)", cfg.Name, p.idle)
+		}
+		e.cli, e.err = cli, err
+		close(e.ready)
+	}()
+	return e
+}
+
+// release ends a call on e. A broken connection is dropped from the pool;
+// otherwise the idle timer starts once no call is using it.
+func (p *clientPool) release(e *poolEntry, broken bool) {
+	name := e.cfg.Name
+	p.mu.Lock()
+	e.active--
+	e.lastUsed = time.Now()
+	if broken && p.entries[name] == e {
+		delete(p.entries, name)
+	}
+	var done *poolEntry
+	if e.active == 0 {
+		if p.entries[name] != e {
+			done = e // removed while in use
+		} else {
+			time.AfterFunc(p.idle, func() { p.reap(e) })
+		}
+	}
+	p.mu.Unlock()
+	done.close()
+}
+
+// reap closes e if it has not been used for the idle timeout. Timers of
+// earlier idle periods find e in use or recently used and do nothing.
+func (p *clientPool) reap(e *poolEntry) {
+	name := e.cfg.Name
+	p.mu.Lock()
+	if p.entries[name] != e || e.active > 0 || time.Since(e.lastUsed) < p.idle {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.entries, name)
+	p.mu.Unlock()
+	e.close()
+	log.Printf("[MCP] per_call idle: stopped %s", name)
+}
+
+// evict drops the connection of server (on reload or removal). A call still
+// using it finishes first.
+func (p *clientPool) evict(server string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	e := p.removeLocked(server, p.entries[server])
+	p.mu.Unlock()
+	e.close()
+}
+
+// closeAll drops every pooled connection.
+func (p *clientPool) closeAll() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	var idle []*poolEntry
+	for name, e := range p.entries {
+		if e := p.removeLocked(name, e); e != nil {
+			idle = append(idle, e)
+		}
+	}
+	p.mu.Unlock()
+	for _, e := range idle {
+		e.close()
+	}
+}
+
+// removeLocked removes e from the pool and returns it if it can be closed
+// right away; an entry in use is closed by its last release instead.
+func (p *clientPool) removeLocked(name string, e *poolEntry) *poolEntry {
+	if e == nil || p.entries[name] != e {
+		return nil
+	}
+	delete(p.entries, name)
+	if e.active > 0 {
+		return nil
+	}
+	return e
+}
+
+// size returns the number of pooled connections.
+func (p *clientPool) size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// close terminates the entry's connection, aborting it if still connecting.
+// Nil-safe.
+func (e *poolEntry) close() {
+	if e == nil {
+		return
+	}
+	e.cancel()
+	<-e.ready
+	if e.cli != nil {
+		if err := e.cli.Close(); err != nil {
+			log.Printf("[MCP] Close error for %q: %v", e.cfg.Name, err)
+		}
+	}
+}
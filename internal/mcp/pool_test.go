@@ -0,0 +1,142 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdk_mcp "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// countingServer serves an MCP server with an "echo" tool and a "fail" tool
+// over streamable HTTP and counts initialize handshakes (= connections).
+func countingServer(t *testing.T) (url string, inits *atomic.Int32) {
+	t.Helper()
+	srv := server.NewMCPServer("echo", "1.0")
+	srv.AddTool(sdk_mcp.NewTool("echo", sdk_mcp.WithString("text")),
+		func(_ context.Context, req sdk_mcp.CallToolRequest) (*sdk_mcp.CallToolResult, error) {
+			return sdk_mcp.NewToolResultText("echo: " + req.GetString("text", "")), nil
+		})
+	srv.AddTool(sdk_mcp.NewTool("fail"),
+		func(context.Context, sdk_mcp.CallToolRequest) (*sdk_mcp.CallToolResult, error) {
+			return sdk_mcp.NewToolResultError("no such record"), nil
+		})
+	handler := server.NewStreamableHTTPServer(srv)
+	inits = new(atomic.Int32)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if bytes.Contains(body, []byte(`"method":"initialize"`)) {
+			inits.Add(1)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts.URL + "/mcp", inits
+}
+
+func pooledAdapter(pool *clientPool, toolName string, cfg ServerConfig) *MCPToolAdapter {
+	a := NewMCPToolAdapter(cfg.Name, ToolInfo{Name: toolName}, nil, cfg)
+	a.pool = pool
+	return a
+}
+
+func TestClientPool_ReusesAndReapsConnection(t *testing.T) {
+	url, inits := countingServer(t)
+	cfg := ServerConfig{Name: "echo", Transport: "http", URL: url, Lifecycle: "per_call", PathPolicy: PathPolicyOff}
+	pool := newClientPool(100 * time.Millisecond)
+	defer pool.closeAll()
+	echo := pooledAdapter(pool, "echo", cfg)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		res, _ := echo.Execute(ctx, json.RawMessage(`{"text":"hi"}`))
+		if res.Output != "echo: hi" {
+			t.Fatalf("call %d: %+v", i, res)
+		}
+	}
+	if n := inits.Load(); n != 1 {
+		t.Fatalf("connections = %d, want 1 for consecutive calls", n)
+	}
+
+	// A tool-level error leaves the connection in the pool.
+	res, _ := pooledAdapter(pool, "fail", cfg).Execute(ctx, nil)
+	if !strings.Contains(res.Error, "no such record") || pool.size() != 1 {
+		t.Fatalf("tool error: %+v, pool size %d", res, pool.size())
+	}
+
+	// Idle past the timeout: the connection is closed, the next call reconnects.
+	deadline := time.Now().Add(2 * time.Second)
+	for pool.size() != 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if pool.size() != 0 {
+		t.Fatal("idle connection was not reaped")
+	}
+	if res, _ := echo.Execute(ctx, json.RawMessage(`{"text":"again"}`)); res.Output != "echo: again" || inits.Load() != 2 {
+		t.Errorf("after reap: %+v, connections = %d", res, inits.Load())
+	}
+}
+
+func TestClientPool_ReconnectsBrokenOrChangedServer(t *testing.T) {
+	url, inits := countingServer(t)
+	cfg := ServerConfig{Name: "echo", Transport: "http", URL: url, Lifecycle: "per_call", PathPolicy: PathPolicyOff}
+	pool := newClientPool(time.Minute)
+	defer pool.closeAll()
+
+	ctx := context.Background()
+	echo := pooledAdapter(pool, "echo", cfg)
+	echo.Execute(ctx, json.RawMessage(`{"text":"a"}`))
+
+	// The warm connection died while idle: the call is retried on a new one.
+	pool.mu.Lock()
+	pool.entries["echo"].cli.Close()
+	pool.mu.Unlock()
+	if res, _ := echo.Execute(ctx, json.RawMessage(`{"text":"b"}`)); res.Output != "echo: b" || inits.Load() != 2 {
+		t.Fatalf("after broken connection: %+v, connections = %d", res, inits.Load())
+	}
+
+	// A reconfigured server (e.g. after mcp_reload) gets a fresh connection.
+	changed := cfg
+	changed.PathPolicy = PathPolicyWorkspace
+	if res, _ := pooledAdapter(pool, "echo", changed).Execute(ctx, json.RawMessage(`{"text":"c"}`)); res.Output != "echo: c" || inits.Load() != 3 {
+		t.Fatalf("after config change: %+v, connections = %d", res, inits.Load())
+	}
+
+	pool.evict("echo")
+	if pool.size() != 0 {
+		t.Error("evict left the connection pooled")
+	}
+}
+
+func TestLoadPerCallIdle(t *testing.T) {
+	for _, tc := range []struct {
+		env  string
+		want time.Duration
+	}{
+		{"", defaultPerCallIdle},
+		{"15", 15 * time.Second},
+		{"0", 0},
+		{"-3", defaultPerCallIdle},
+		{"soon", defaultPerCallIdle},
+	} {
+		t.Setenv("MCP_PER_CALL_IDLE_SECONDS", tc.env)
+		if got := loadPerCallIdle(); got != tc.want {
+			t.Errorf("MCP_PER_CALL_IDLE_SECONDS=%q: got %v, want %v", tc.env, got, tc.want)
+		}
+	}
+	if newClientPool(0) != nil {
+		t.Error("idle 0 should disable pooling")
+	}
+	var disabled *clientPool
+	disabled.evict("x") // nil pool is a no-op
+	disabled.closeAll()
+}
@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// ResourceReadTool implements tool.Tool and exposes the "mcp_resource_read"
+// built-in command: it reads resources (files, database schemas, docs...)
+// that connected MCP servers publish via resources/list. Without a uri it
+// lists what is available.
+type ResourceReadTool struct {
+	manager *Manager
+}
+
+// NewResourceReadTool creates a ResourceReadTool backed by manager.
+func NewResourceReadTool(manager *Manager) *ResourceReadTool {
+	return &ResourceReadTool{manager: manager}
+}
+
+func (t *ResourceReadTool) Name() string { return "mcp_resource_read" }
+
+func (t *ResourceReadTool) Description() string {
+	return "读取已连接 MCP server 发布的资源（resources）。不传 uri 时列出可用资源（server、uri、名称）；" +
+		"传 server + uri 时返回资源内容。"
+}
+
+func (t *ResourceReadTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "server", Type: "string", Description: "MCP server 名称（读取时必填；列出时可选，用于过滤）"},
+		tool.SchemaParam{Name: "uri", Type: "string", Description: "资源 URI（来自列表）；留空则列出可用资源"},
+	)
+}
+
+func (t *ResourceReadTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a struct {
+		Server string `json:"server"`
+		URI    string `json:"uri"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+		}
+	}
+	a.Server, a.URI = strings.TrimSpace(a.Server), strings.TrimSpace(a.URI)
+
+	if a.URI == "" {
+		return t.list(ctx, a.Server), nil
+	}
+	if a.Server == "" {
+		return tool.ToolResult{Error: "读取资源需要 server 参数（先不带 uri 调用以列出资源及其 server）"}, nil
+	}
+	text, err := t.manager.ReadResource(ctx, a.Server, a.URI)
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("读取资源失败: %v", err)}, nil
+	}
+	if text == "" {
+		return tool.ToolResult{Output: "（资源内容为空）"}, nil
+	}
+	return tool.ToolResult{Output: text}, nil
+}
+
+// list renders the available resources, optionally of one server only.
+// Per-server failures are appended so a partial list is still useful.
+func (t *ResourceReadTool) list(ctx context.Context, server string) tool.ToolResult {
+	resources, err := t.manager.ListResources(ctx)
+	var sb strings.Builder
+	count := 0
+	for _, r := range resources {
+		if server != "" && r.Server != server {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- [%s] %s", r.Server, r.URI))
+		if r.Name != "" && r.Name != r.URI {
+			sb.WriteString(" — " + r.Name)
+		}
+		if r.MIMEType != "" {
+			sb.WriteString(" (" + r.MIMEType + ")")
+		}
+		if r.Description != "" {
+			sb.WriteString(": " + r.Description)
+		}
+		sb.WriteString("\n")
+		count++
+	}
+	if count == 0 {
+		sb.WriteString("没有可用的 MCP 资源。\n")
+	}
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("⚠️ 部分 server 列出失败: %v\n", err))
+	}
+	return tool.ToolResult{Output: sb.String()}
+}
+
+// Init is a no-op; ResourceReadTool has no additional initialisation requirements.
+func (t *ResourceReadTool) Init(_ context.Context) error { return nil }
+
+// Close is a no-op; lifecycle is managed by Manager.
+func (t *ResourceReadTool) Close() error { return nil }
@@ -0,0 +1,305 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	sdk_client "github.com/mark3labs/mcp-go/client"
+	sdk_mcp "github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
+)
+
+// PromptInfo describes a prompt template offered by an MCP server
+// (prompts/list). Prompts are user-selected: the web UI renders them into
+// the message box, the agent does not call them.
+type PromptInfo struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is one template argument of a PromptInfo.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// ResourceInfo describes a resource an MCP server exposes for reading
+// (resources/list).
+type ResourceInfo struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mime_type,omitempty"`
+}
+
+// ServerPrompt is a PromptInfo tagged with the server offering it.
+type ServerPrompt struct {
+	Server string `json:"server"`
+	PromptInfo
+}
+
+// ServerResource is a ResourceInfo tagged with the server offering it.
+type ServerResource struct {
+	Server string `json:"server"`
+	ResourceInfo
+}
+
+// connected returns the live connection and the capabilities the server
+// announced during initialize.
+func (c *Client) connected() (sdk_client.MCPClient, sdk_mcp.ServerCapabilities, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.inner == nil {
+		return nil, c.caps, fmt.Errorf("mcp: client %q not connected", c.cfg.Name)
+	}
+	return c.inner, c.caps, nil
+}
+
+// ListPrompts returns the prompt templates of this server. Servers that do
+// not announce the prompts capability yield an empty list.
+func (c *Client) ListPrompts(ctx context.Context) ([]PromptInfo, error) {
+	inner, caps, err := c.connected()
+	if err != nil {
+		return nil, err
+	}
+	if caps.Prompts == nil {
+		return nil, nil
+	}
+	result, err := inner.ListPrompts(ctx, sdk_mcp.ListPromptsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("mcp: list prompts %q: %w", c.cfg.Name, err)
+	}
+	prompts := make([]PromptInfo, 0, len(result.Prompts))
+	for _, p := range result.Prompts {
+		info := PromptInfo{Name: p.Name, Description: p.Description}
+		for _, a := range p.Arguments {
+			info.Arguments = append(info.Arguments, PromptArgument{Name: a.Name, Description: a.Description, Required: a.Required})
+		}
+		prompts = append(prompts, info)
+	}
+	return prompts, nil
+}
+
+// GetPrompt renders the named prompt with args (prompts/get) and returns the
+// text of its messages. Non-text content is described in brackets.
+func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) (string, error) {
+	ctx, span := telemetry.Start(ctx, "mcp.get_prompt",
+		attribute.String("mcp.server", c.cfg.Name),
+		attribute.String("mcp.prompt", name))
+	text, err := c.getPrompt(ctx, name, args)
+	telemetry.End(span, err)
+	return text, err
+}
+
+func (c *Client) getPrompt(ctx context.Context, name string, args map[string]string) (string, error) {
+	inner, _, err := c.connected()
+	if err != nil {
+		return "", err
+	}
+	req := sdk_mcp.GetPromptRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	result, err := inner.GetPrompt(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("mcp: get prompt %q on %q: %w", name, c.cfg.Name, err)
+	}
+	parts := make([]string, 0, len(result.Messages))
+	for _, msg := range result.Messages {
+		if text := contentText(msg.Content); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// ListResources returns the resources of this server. Servers that do not
+// announce the resources capability yield an empty list.
+func (c *Client) ListResources(ctx context.Context) ([]ResourceInfo, error) {
+	inner, caps, err := c.connected()
+	if err != nil {
+		return nil, err
+	}
+	if caps.Resources == nil {
+		return nil, nil
+	}
+	result, err := inner.ListResources(ctx, sdk_mcp.ListResourcesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("mcp: list resources %q: %w", c.cfg.Name, err)
+	}
+	resources := make([]ResourceInfo, 0, len(result.Resources))
+	for _, r := range result.Resources {
+		resources = append(resources, ResourceInfo{URI: r.URI, Name: r.Name, Description: r.Description, MIMEType: r.MIMEType})
+	}
+	return resources, nil
+}
+
+// ReadResource reads uri (resources/read) and returns its text contents.
+// Binary contents are described rather than returned.
+func (c *Client) ReadResource(ctx context.Context, uri string) (string, error) {
+	ctx, span := telemetry.Start(ctx, "mcp.read_resource",
+		attribute.String("mcp.server", c.cfg.Name),
+		attribute.String("mcp.resource", uri))
+	text, err := c.readResource(ctx, uri)
+	span.SetAttributes(attribute.Int("mcp.response_chars", len(text)))
+	telemetry.End(span, err)
+	return text, err
+}
+
+func (c *Client) readResource(ctx context.Context, uri string) (string, error) {
+	inner, _, err := c.connected()
+	if err != nil {
+		return "", err
+	}
+	req := sdk_mcp.ReadResourceRequest{}
+	req.Params.URI = uri
+	result, err := inner.ReadResource(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("mcp: read resource %q on %q: %w", uri, c.cfg.Name, err)
+	}
+	parts := make([]string, 0, len(result.Contents))
+	for _, rc := range result.Contents {
+		parts = append(parts, resourceText(rc))
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// contentText extracts the text of one prompt message content.
+func contentText(content sdk_mcp.Content) string {
+	switch ct := content.(type) {
+	case sdk_mcp.TextContent:
+		return ct.Text
+	case sdk_mcp.EmbeddedResource:
+		return resourceText(ct.Resource)
+	case sdk_mcp.ImageContent:
+		return fmt.Sprintf("[image %s]", ct.MIMEType)
+	case sdk_mcp.AudioContent:
+		return fmt.Sprintf("[audio %s]", ct.MIMEType)
+	}
+	return ""
+}
+
+// resourceText returns text resource contents as-is and a placeholder for
+// binary ones, which the model cannot use.
+func resourceText(rc sdk_mcp.ResourceContents) string {
+	switch r := rc.(type) {
+	case sdk_mcp.TextResourceContents:
+		return r.Text
+	case sdk_mcp.BlobResourceContents:
+		return fmt.Sprintf("[binary resource %s, %s, %d bytes base64]", r.URI, r.MIMEType, len(r.Blob))
+	}
+	return ""
+}
+
+// withClient runs fn with a connection to server: the live client of a
+// persistent server, or the pooled (or else a temporary) one for per_call
+// servers.
+func (m *Manager) withClient(ctx context.Context, server string, fn func(*Client) error) error {
+	m.mu.Lock()
+	cli, ok := m.clients[server]
+	cfg := m.configs[server]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("mcp: server %q is not connected", server)
+	}
+	if cli == nil && m.pool != nil {
+		e, _, err := m.pool.acquire(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		err = fn(e.cli)
+		var te *toolError
+		m.pool.release(e, err != nil && !errors.As(err, &te))
+		return err
+	}
+	if cli == nil {
+		tmp := NewClient(cfg)
+		if err := tmp.Connect(ctx); err != nil {
+			return err
+		}
+		defer tmp.Close()
+		cli = tmp
+	}
+	return fn(cli)
+}
+
+// servers returns the connected server names, sorted.
+func (m *Manager) servers() []string {
+	m.mu.Lock()
+	names := make([]string, 0, len(m.clients))
+	for name := range m.clients {
+		names = append(names, name)
+	}
+	m.mu.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+// ListPrompts collects the prompt templates of all connected servers.
+// Servers that fail are skipped and reported in the joined error, so a
+// partial list may come with a non-nil error.
+func (m *Manager) ListPrompts(ctx context.Context) ([]ServerPrompt, error) {
+	var out []ServerPrompt
+	var errs []error
+	for _, name := range m.servers() {
+		err := m.withClient(ctx, name, func(c *Client) error {
+			prompts, err := c.ListPrompts(ctx)
+			for _, p := range prompts {
+				out = append(out, ServerPrompt{Server: name, PromptInfo: p})
+			}
+			return err
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// GetPrompt renders a prompt template of server.
+func (m *Manager) GetPrompt(ctx context.Context, server, name string, args map[string]string) (string, error) {
+	var text string
+	err := m.withClient(ctx, server, func(c *Client) error {
+		var err error
+		text, err = c.GetPrompt(ctx, name, args)
+		return err
+	})
+	return text, err
+}
+
+// ListResources collects the resources of all connected servers, with the
+// same partial-failure behaviour as ListPrompts.
+func (m *Manager) ListResources(ctx context.Context) ([]ServerResource, error) {
+	var out []ServerResource
+	var errs []error
+	for _, name := range m.servers() {
+		err := m.withClient(ctx, name, func(c *Client) error {
+			resources, err := c.ListResources(ctx)
+			for _, r := range resources {
+				out = append(out, ServerResource{Server: name, ResourceInfo: r})
+			}
+			return err
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return out, errors.Join(errs...)
+}
+
+// ReadResource reads uri from server.
+func (m *Manager) ReadResource(ctx context.Context, server, uri string) (string, error) {
+	var text string
+	err := m.withClient(ctx, server, func(c *Client) error {
+		var err error
+		text, err = c.ReadResource(ctx, uri)
+		return err
+	})
+	return text, err
+}
@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	sdk_client "github.com/mark3labs/mcp-go/client"
+	sdk_mcp "github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// inProcessClient connects a Client to an in-process MCP server.
+func inProcessClient(t *testing.T, name string, srv *server.MCPServer) *Client {
+	t.Helper()
+	inner, err := sdk_client.NewInProcessClient(srv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(ServerConfig{Name: name, Transport: "stdio"})
+	if err := c.initialize(context.Background(), inner); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// docsServer offers one prompt template and one text resource.
+func docsServer() *server.MCPServer {
+	srv := server.NewMCPServer("docs", "1.0",
+		server.WithPromptCapabilities(false), server.WithResourceCapabilities(false, false))
+	srv.AddPrompt(sdk_mcp.NewPrompt("review",
+		sdk_mcp.WithPromptDescription("Review a file"),
+		sdk_mcp.WithArgument("file", sdk_mcp.RequiredArgument()),
+	), func(_ context.Context, req sdk_mcp.GetPromptRequest) (*sdk_mcp.GetPromptResult, error) {
+		return &sdk_mcp.GetPromptResult{Messages: []sdk_mcp.PromptMessage{
+			sdk_mcp.NewPromptMessage(sdk_mcp.RoleUser, sdk_mcp.NewTextContent("Review "+req.Params.Arguments["file"])),
+			sdk_mcp.NewPromptMessage(sdk_mcp.RoleUser, sdk_mcp.NewTextContent("Focus on error handling.")),
+		}}, nil
+	})
+	srv.AddResource(sdk_mcp.NewResource("docs://schema", "schema", sdk_mcp.WithMIMEType("text/plain")),
+		func(_ context.Context, req sdk_mcp.ReadResourceRequest) ([]sdk_mcp.ResourceContents, error) {
+			return []sdk_mcp.ResourceContents{
+				sdk_mcp.TextResourceContents{URI: req.Params.URI, MIMEType: "text/plain", Text: "CREATE TABLE users"},
+			}, nil
+		})
+	return srv
+}
+
+func TestClient_PromptsAndResources(t *testing.T) {
+	ctx := context.Background()
+	c := inProcessClient(t, "docs", docsServer())
+
+	prompts, err := c.ListPrompts(ctx)
+	if err != nil {
+		t.Fatalf("ListPrompts: %v", err)
+	}
+	if len(prompts) != 1 || prompts[0].Name != "review" || len(prompts[0].Arguments) != 1 || !prompts[0].Arguments[0].Required {
+		t.Fatalf("prompts = %+v", prompts)
+	}
+	text, err := c.GetPrompt(ctx, "review", map[string]string{"file": "main.go"})
+	if err != nil {
+		t.Fatalf("GetPrompt: %v", err)
+	}
+	if text != "Review main.go\n\nFocus on error handling." {
+		t.Errorf("GetPrompt = %q", text)
+	}
+
+	resources, err := c.ListResources(ctx)
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	if len(resources) != 1 || resources[0].URI != "docs://schema" || resources[0].MIMEType != "text/plain" {
+		t.Fatalf("resources = %+v", resources)
+	}
+	if text, err := c.ReadResource(ctx, "docs://schema"); err != nil || text != "CREATE TABLE users" {
+		t.Errorf("ReadResource = %q, %v", text, err)
+	}
+}
+
+func TestClient_NoCapabilities(t *testing.T) {
+	c := inProcessClient(t, "tools-only", server.NewMCPServer("tools-only", "1.0"))
+	if prompts, err := c.ListPrompts(context.Background()); err != nil || len(prompts) != 0 {
+		t.Errorf("ListPrompts = %v, %v; want empty", prompts, err)
+	}
+	if resources, err := c.ListResources(context.Background()); err != nil || len(resources) != 0 {
+		t.Errorf("ListResources = %v, %v; want empty", resources, err)
+	}
+}
+
+func TestResourceReadTool(t *testing.T) {
+	m := NewManager("")
+	m.clients["docs"] = inProcessClient(t, "docs", docsServer())
+	m.clients["plain"] = inProcessClient(t, "plain", server.NewMCPServer("plain", "1.0"))
+	rt := NewResourceReadTool(m)
+	ctx := context.Background()
+
+	res, _ := rt.Execute(ctx, json.RawMessage(`{}`))
+	if !strings.Contains(res.Output, "[docs] docs://schema") {
+		t.Errorf("list output = %q", res.Output)
+	}
+
+	res, _ = rt.Execute(ctx, json.RawMessage(`{"server":"docs","uri":"docs://schema"}`))
+	if res.Output != "CREATE TABLE users" {
+		t.Errorf("read = %+v", res)
+	}
+
+	res, _ = rt.Execute(ctx, json.RawMessage(`{"uri":"docs://schema"}`))
+	if res.Error == "" {
+		t.Error("reading without server should fail")
+	}
+	res, _ = rt.Execute(ctx, json.RawMessage(`{"server":"missing","uri":"docs://schema"}`))
+	if res.Error == "" {
+		t.Error("unknown server should fail")
+	}
+
+	prompts, err := m.ListPrompts(ctx)
+	if err != nil || len(prompts) != 1 || prompts[0].Server != "docs" {
+		t.Errorf("Manager.ListPrompts = %+v, %v", prompts, err)
+	}
+}
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// RollbackTool implements tool.Tool and exposes the "skill_rollback" built-in
+// command. It restores a skill server (skills/<name>/) to one of the versions
+// the Manager snapshotted when it started successfully, then reloads so the
+// server restarts on the restored files.
+type RollbackTool struct {
+	manager  *Manager
+	registry *tool.Registry
+}
+
+// NewRollbackTool creates a RollbackTool wired to the given manager and registry.
+func NewRollbackTool(manager *Manager, registry *tool.Registry) *RollbackTool {
+	return &RollbackTool{manager: manager, registry: registry}
+}
+
+func (t *RollbackTool) Name() string { return "skill_rollback" }
+
+func (t *RollbackTool) Description() string {
+	return "Rolls a skill MCP server (skills/<name>/) back to an earlier version and reloads it. " +
+		"A version is kept each time a skill starts successfully; the running version is shown as [skill vN] in its tool descriptions. " +
+		"Use it when an edit broke the skill. Without version, restores the version before the current one."
+}
+
+func (t *RollbackTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "server", Type: "string", Description: "Server name in mcp.json", Required: true},
+		tool.SchemaParam{Name: "version", Type: "integer", Description: "Version number to restore (N of vN); omit for the previous version"},
+	)
+}
+
+type rollbackArgs struct {
+	Server  string `json:"server"`
+	Version int    `json:"version"`
+}
+
+// Execute restores the version and returns the reload summary.
+func (t *RollbackTool) Execute(ctx context.Context, raw json.RawMessage) (tool.ToolResult, error) {
+	var a rollbackArgs
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("invalid arguments: %v", err)}, nil
+	}
+	if a.Server == "" {
+		return tool.ToolResult{Error: "server is required"}, nil
+	}
+	if a.Version < 0 {
+		return tool.ToolResult{Error: "version must be positive"}, nil
+	}
+	summary, err := t.manager.Rollback(ctx, t.registry, a.Server, a.Version)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	return tool.ToolResult{Output: summary}, nil
+}
+
+// Init is a no-op; RollbackTool has no additional initialisation requirements.
+func (t *RollbackTool) Init(_ context.Context) error { return nil }
+
+// Close is a no-op; lifecycle is managed by Manager.
+func (t *RollbackTool) Close() error { return nil }
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -97,19 +99,44 @@ var sourceRules = []sourceRule{
 
 // ── TypeScript / JavaScript rules ──
 
+// tsNetwork matches outbound network I/O in Node/Deno/Bun scripts: fetch,
+// the http/https/net/dgram modules, common HTTP clients and WebSockets.
+var tsNetwork = regexp.MustCompile(`\b(fetch\s*\(|https?\.(request|get)\b|net\.(connect|createConnection|Socket)\b|dgram\b|axios\b|node-fetch|undici|got\s*\(|WebSocket\s*\(|XMLHttpRequest)`)
+
 // tsLineRules are applied to each line of TypeScript/JavaScript scripts.
 var tsLineRules = []lineRule{
 	{
 		name:     "dangerous-exec",
 		severity: SeverityCritical,
-		// child_process spawn/exec, execSync — dynamic process execution.
-		pattern: regexp.MustCompile(`\b(child_process|execSync|execFileSync|spawnSync)\b`),
+		// child_process spawn/exec, execSync; Deno/Bun process APIs; native
+		// addon loading — dynamic process execution.
+		pattern: regexp.MustCompile(`\b(child_process|execSync|execFileSync|spawnSync|Deno\.(run|Command)|Bun\.(spawn|spawnSync|\$)|process\.(binding|dlopen))\b`),
 	},
 	{
 		name:     "dynamic-code",
 		severity: SeverityCritical,
-		// eval, Function constructor, vm.runInNewContext — dynamic code execution.
-		pattern: regexp.MustCompile(`\b(eval\s*\(|new\s+Function\s*\(|vm\.run)`),
+		// eval, Function constructor, the vm module, string timers — dynamic
+		// code execution.
+		pattern: regexp.MustCompile(`\b(eval\s*\(|new\s+Function\s*\(|vm\.(run|Script|compileFunction)|set(Timeout|Interval)\s*\(\s*['"\x60])`),
+	},
+	{
+		name:     "dynamic-import",
+		severity: SeverityCritical,
+		// require()/import() of a computed module name loads arbitrary code
+		// at runtime; string literals are fine.
+		pattern: regexp.MustCompile(`\b(require|import)\s*\(\s*[^'"\x60\s)]`),
+	},
+	{
+		name:     "destructive-fs",
+		severity: SeverityCritical,
+		// Recursive deletion (fs.rm / rmdir with recursive: true, rimraf).
+		pattern: regexp.MustCompile(`\b(rm|rmSync|rmdir|rmdirSync)\s*\([^)]*recursive\s*:\s*true|\brimraf\b`),
+	},
+	{
+		name:     "file-deletion",
+		severity: SeverityWarn,
+		// Single-file deletion is often legitimate cleanup but worth a look.
+		pattern: regexp.MustCompile(`\bfs(\.promises)?\.(rm|rmSync|unlink|unlinkSync)\s*\(`),
 	},
 }
 
@@ -118,21 +145,43 @@ var tsSourceRules = []sourceRule{
 	{
 		name:     "env-harvesting",
 		severity: SeverityCritical,
-		// process.env access combined with network I/O (fetch/http/https/axios).
+		// process.env access combined with network I/O.
 		pattern:        regexp.MustCompile(`process\.env`),
-		contextPattern: regexp.MustCompile(`\b(fetch\s*\(|https?\.|axios\.|node-fetch)`),
+		contextPattern: tsNetwork,
 	},
 	{
 		name:     "potential-exfil",
 		severity: SeverityWarn,
-		// fs.readFile combined with outbound network call.
-		pattern:        regexp.MustCompile(`\bfs\.(readFile|readFileSync|createReadStream)\b`),
-		contextPattern: regexp.MustCompile(`\b(fetch\s*\(|https?\.|axios\.|node-fetch)`),
+		// File reads combined with an outbound network call.
+		pattern:        regexp.MustCompile(`\b(readFile|readFileSync|createReadStream|readdirSync)\s*\(`),
+		contextPattern: tsNetwork,
 	},
+	{
+		name:     "obfuscated-code",
+		severity: SeverityWarn,
+		// base64 decoding combined with dynamic execution.
+		pattern:        regexp.MustCompile(`\batob\s*\(|['"]base64['"]`),
+		contextPattern: regexp.MustCompile(`\b(eval\s*\(|new\s+Function\s*\(|vm\.run)`),
+	},
+}
+
+// scriptExts are the script types ScanScript understands, in the order
+// findScriptFile prefers them.
+var scriptExts = []string{".py", ".ts", ".js", ".mts", ".cts", ".mjs", ".cjs", ".tsx", ".jsx"}
+
+// IsScannable reports whether ScanScript understands the file's type.
+func IsScannable(filePath string) bool {
+	for _, ext := range scriptExts {
+		if strings.HasSuffix(filePath, ext) {
+			return true
+		}
+	}
+	return false
 }
 
 // ScanScript performs a static security scan on a script file.
-// Supports .py, .ts, and .js files; other file types return (nil, nil).
+// Supports Python (.py) and TypeScript/JavaScript (.ts, .js and their
+// module/JSX variants) files; other file types return (nil, nil).
 //
 // Critical findings should block script activation.
 // Warn findings are logged but allow activation to continue.
@@ -141,11 +190,11 @@ func ScanScript(filePath string) ([]ScanFinding, error) {
 	var sRules []sourceRule
 	var isPython bool
 
-	switch {
-	case strings.HasSuffix(filePath, ".py"):
+	switch ext := filepath.Ext(filePath); {
+	case ext == ".py":
 		lRules, sRules = lineRules, sourceRules
 		isPython = true
-	case strings.HasSuffix(filePath, ".ts"), strings.HasSuffix(filePath, ".js"):
+	case slices.Contains(scriptExts, ext):
 		lRules, sRules = tsLineRules, tsSourceRules
 		isPython = false
 	default:
@@ -168,10 +217,11 @@ func ScanScript(filePath string) ([]ScanFinding, error) {
 		line := scanner.Text()
 
 		// Skip comment-only lines (language-aware prefix to avoid false skips:
-		// Python uses `#`, JS/TS uses `//`).
+		// Python uses `#`, JS/TS uses `//` and `/* ... */` blocks, whose
+		// continuation lines conventionally start with `*`).
 		stripped := strings.TrimSpace(line)
 		if (isPython && strings.HasPrefix(stripped, "#")) ||
-			(!isPython && strings.HasPrefix(stripped, "//")) {
+			(!isPython && (strings.HasPrefix(stripped, "//") || strings.HasPrefix(stripped, "/*") || strings.HasPrefix(stripped, "*"))) {
 			continue
 		}
 
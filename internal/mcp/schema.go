@@ -0,0 +1,419 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxSchemaErrors caps the violations reported for one call; the agent
+// fixes the first few and the rest are usually consequences.
+const maxSchemaErrors = 8
+
+// argSchema is the subset of JSON Schema that MCP tool input schemas use
+// in practice. Keywords it does not know ($ref, pattern, format, allOf...)
+// are ignored, so a schema the validator cannot fully interpret never
+// blocks a call the server would accept.
+type argSchema struct {
+	types      []string // empty = any type
+	properties map[string]*argSchema
+	required   []string
+	closed     bool       // additionalProperties: false
+	additional *argSchema // additionalProperties as a schema
+	items      *argSchema
+	enum       []any
+	anyOf      []*argSchema // anyOf and oneOf: at least one must match
+	min, max   *float64
+	minLen     *int
+	maxLen     *int
+	minItems   *int
+	maxItems   *int
+}
+
+// compileSchema parses a tool input schema. It returns nil (no validation)
+// for an empty or malformed schema.
+func compileSchema(raw json.RawMessage) *argSchema {
+	if len(raw) == 0 {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil
+	}
+	return compileNode(m)
+}
+
+func compileNode(m map[string]any) *argSchema {
+	s := &argSchema{}
+	switch t := m["type"].(type) {
+	case string:
+		s.types = []string{t}
+	case []any:
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				s.types = append(s.types, name)
+			}
+		}
+	}
+	if props, ok := m["properties"].(map[string]any); ok {
+		s.properties = make(map[string]*argSchema, len(props))
+		for name, p := range props {
+			if pm, ok := p.(map[string]any); ok {
+				s.properties[name] = compileNode(pm)
+			} else {
+				s.properties[name] = &argSchema{}
+			}
+		}
+	}
+	if req, ok := m["required"].([]any); ok {
+		for _, v := range req {
+			if name, ok := v.(string); ok {
+				s.required = append(s.required, name)
+			}
+		}
+	}
+	switch ap := m["additionalProperties"].(type) {
+	case bool:
+		s.closed = !ap
+	case map[string]any:
+		s.additional = compileNode(ap)
+	}
+	if items, ok := m["items"].(map[string]any); ok { // tuple forms are ignored
+		s.items = compileNode(items)
+	}
+	if enum, ok := m["enum"].([]any); ok {
+		s.enum = enum
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if alts, ok := m[key].([]any); ok {
+			for _, alt := range alts {
+				if am, ok := alt.(map[string]any); ok {
+					s.anyOf = append(s.anyOf, compileNode(am))
+				}
+			}
+		}
+	}
+	if m["nullable"] == true && len(s.types) > 0 { // OpenAPI-style
+		s.types = append(s.types, "null")
+	}
+	s.min, s.max = schemaFloat(m["minimum"]), schemaFloat(m["maximum"])
+	s.minLen, s.maxLen = schemaInt(m["minLength"]), schemaInt(m["maxLength"])
+	s.minItems, s.maxItems = schemaInt(m["minItems"]), schemaInt(m["maxItems"])
+	return s
+}
+
+func schemaFloat(v any) *float64 {
+	if f, ok := v.(float64); ok {
+		return &f
+	}
+	return nil
+}
+
+func schemaInt(v any) *int {
+	if f, ok := v.(float64); ok {
+		n := int(f)
+		return &n
+	}
+	return nil
+}
+
+// validate checks tool params against the schema and returns the
+// violations, each naming the offending parameter. Harmless mismatches
+// are repaired in place instead of reported: numeric and boolean values
+// sent as strings are converted, and null for an optional parameter is
+// dropped. A nil schema accepts everything.
+func (s *argSchema) validate(params map[string]any) []string {
+	if s == nil {
+		return nil
+	}
+	v := &schemaCheck{}
+	if params == nil {
+		// No arguments: only the required check applies.
+		v.checkObject("", s, map[string]any{})
+	} else {
+		v.checkObject("", s, params)
+	}
+	return v.errs
+}
+
+// schemaCheck collects violations while walking a value.
+type schemaCheck struct {
+	errs []string
+}
+
+func (c *schemaCheck) addf(format string, args ...any) {
+	if len(c.errs) < maxSchemaErrors {
+		c.errs = append(c.errs, fmt.Sprintf(format, args...))
+	}
+}
+
+// check validates v at path and returns it, possibly converted.
+func (c *schemaCheck) check(path string, s *argSchema, v any) any {
+	if len(s.anyOf) > 0 {
+		for _, alt := range s.anyOf {
+			if len((&schemaCheck{}).probe(path, alt, v)) == 0 {
+				return c.check(path, alt, v)
+			}
+		}
+		c.addf("参数 %s 的值 %s 不符合任何允许的形式", path, describeValue(v))
+		return v
+	}
+	if len(s.types) > 0 && !matchesType(s.types, v) {
+		converted, ok := convertScalar(s.types, v)
+		if !ok {
+			c.addf("参数 %s 应为 %s，实际为 %s", path, strings.Join(s.types, " 或 "), describeValue(v))
+			return v
+		}
+		v = converted
+	}
+	if len(s.enum) > 0 && !inEnum(s.enum, v) {
+		c.addf("参数 %s 的值 %s 不在允许范围内，可选值: %s", path, describeValue(v), formatEnum(s.enum))
+		return v
+	}
+
+	switch val := v.(type) {
+	case string:
+		n := len([]rune(val))
+		if s.minLen != nil && n < *s.minLen {
+			c.addf("参数 %s 至少需要 %d 个字符，实际 %d 个", path, *s.minLen, n)
+		}
+		if s.maxLen != nil && n > *s.maxLen {
+			c.addf("参数 %s 最多允许 %d 个字符，实际 %d 个", path, *s.maxLen, n)
+		}
+	case float64:
+		if s.min != nil && val < *s.min {
+			c.addf("参数 %s 不能小于 %v，实际为 %v", path, *s.min, val)
+		}
+		if s.max != nil && val > *s.max {
+			c.addf("参数 %s 不能大于 %v，实际为 %v", path, *s.max, val)
+		}
+	case []any:
+		if s.minItems != nil && len(val) < *s.minItems {
+			c.addf("参数 %s 至少需要 %d 项，实际 %d 项", path, *s.minItems, len(val))
+		}
+		if s.maxItems != nil && len(val) > *s.maxItems {
+			c.addf("参数 %s 最多允许 %d 项，实际 %d 项", path, *s.maxItems, len(val))
+		}
+		if s.items != nil {
+			for i, item := range val {
+				val[i] = c.check(fmt.Sprintf("%s[%d]", path, i), s.items, item)
+			}
+		}
+	case map[string]any:
+		c.checkObject(path, s, val)
+	}
+	return v
+}
+
+// probe reports the violations of v without modifying it.
+func (c *schemaCheck) probe(path string, s *argSchema, v any) []string {
+	var copied any
+	data, _ := json.Marshal(v)
+	json.Unmarshal(data, &copied) //nolint:errcheck // round-trip of a decoded value
+	c.check(path, s, copied)
+	return c.errs
+}
+
+func (c *schemaCheck) checkObject(path string, s *argSchema, obj map[string]any) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	for _, name := range s.required {
+		v, ok := obj[name]
+		if prop := s.properties[name]; !ok || (v == nil && prop != nil && !prop.allowsNull()) {
+			c.addf("缺少必填参数 %s", join(name))
+		}
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := obj[k]
+		prop, known := s.properties[k]
+		if !known {
+			switch {
+			case s.additional != nil:
+				obj[k] = c.check(join(k), s.additional, v)
+			case s.closed:
+				c.addf("未知参数 %s（可用参数: %s）", join(k), strings.Join(sortedKeys(s.properties), ", "))
+			}
+			continue
+		}
+		if v == nil && !prop.allowsNull() {
+			if !slices.Contains(s.required, k) {
+				delete(obj, k) // null for an optional parameter means "not set"
+			}
+			continue // missing required parameters are reported above
+		}
+		obj[k] = c.check(join(k), prop, v)
+	}
+}
+
+func (s *argSchema) allowsNull() bool {
+	if len(s.types) == 0 && len(s.anyOf) == 0 {
+		return true
+	}
+	for _, alt := range s.anyOf {
+		if alt.allowsNull() {
+			return true
+		}
+	}
+	return slices.Contains(s.types, "null")
+}
+
+// matchesType reports whether v (as decoded by encoding/json) has one of types.
+func matchesType(types []string, v any) bool {
+	for _, t := range types {
+		switch val := v.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && val == math.Trunc(val)) {
+				return true
+			}
+		case []any:
+			if t == "array" {
+				return true
+			}
+		case map[string]any:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// convertScalar converts a string holding a number or boolean into the
+// expected type — models often quote numbers.
+func convertScalar(types []string, v any) (any, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, false
+	}
+	s = strings.TrimSpace(s)
+	for _, t := range types {
+		switch t {
+		case "integer":
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				return float64(n), true
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return f, true
+			}
+		case "boolean":
+			if s == "true" || s == "false" {
+				return s == "true", true
+			}
+		}
+	}
+	return nil, false
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []any) string {
+	parts := make([]string, len(enum))
+	for i, e := range enum {
+		data, _ := json.Marshal(e)
+		parts[i] = string(data)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeValue renders a value with its JSON type for error messages.
+func describeValue(v any) string {
+	var typ string
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		typ = "string"
+	case bool:
+		typ = "boolean"
+	case float64:
+		typ = "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		typ = fmt.Sprintf("%T", v)
+	}
+	data, _ := json.Marshal(v)
+	text := string(data)
+	if r := []rune(text); len(r) > 40 {
+		text = string(r[:40]) + "…"
+	}
+	return typ + " " + text
+}
+
+// usage summarizes the top-level parameters, e.g.
+// "query (string, 必填), limit (integer)", to go with validation errors.
+func (s *argSchema) usage() string {
+	if s == nil || len(s.properties) == 0 {
+		return ""
+	}
+	names := sortedKeys(s.properties)
+	// Required parameters first, each group alphabetical.
+	sort.SliceStable(names, func(i, j int) bool {
+		return slices.Contains(s.required, names[i]) && !slices.Contains(s.required, names[j])
+	})
+	parts := make([]string, len(names))
+	for i, name := range names {
+		p := s.properties[name]
+		var attrs []string
+		if len(p.types) > 0 {
+			attrs = append(attrs, strings.Join(p.types, "|"))
+		}
+		if len(p.enum) > 0 {
+			attrs = append(attrs, "可选 "+formatEnum(p.enum))
+		}
+		if slices.Contains(s.required, name) {
+			attrs = append(attrs, "必填")
+		}
+		parts[i] = name
+		if len(attrs) > 0 {
+			parts[i] += " (" + strings.Join(attrs, ", ") + ")"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys(m map[string]*argSchema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
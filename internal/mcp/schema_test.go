@@ -0,0 +1,103 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const searchSchema = `{
+	"type": "object",
+	"properties": {
+		"query":   {"type": "string", "minLength": 1},
+		"limit":   {"type": "integer", "minimum": 1, "maximum": 50},
+		"exact":   {"type": "boolean"},
+		"sort":    {"type": "string", "enum": ["relevance", "date"]},
+		"since":   {"anyOf": [{"type": "string"}, {"type": "null"}]},
+		"filters": {"type": "object", "properties": {"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 3}}, "additionalProperties": false}
+	},
+	"required": ["query"],
+	"additionalProperties": false
+}`
+
+func decodeParams(t *testing.T, s string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestArgSchema_Validate(t *testing.T) {
+	schema := compileSchema(json.RawMessage(searchSchema))
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{`{"query":"go","limit":10,"sort":"date","filters":{"tags":["a"]}}`, nil},
+		{`{"query":"go","since":null}`, nil},
+		{`{}`, []string{"缺少必填参数 query"}},
+		{`{"query":"go","limit":2.5}`, []string{`参数 limit 应为 integer，实际为 number 2.5`}},
+		{`{"query":"go","limit":99}`, []string{"参数 limit 不能大于 50，实际为 99"}},
+		{`{"query":"go","sort":"stars"}`, []string{`参数 sort 的值 string "stars" 不在允许范围内，可选值: "relevance", "date"`}},
+		{`{"query":"","max":3}`, []string{"未知参数 max（可用参数: exact, filters, limit, query, since, sort）", "参数 query 至少需要 1 个字符，实际 0 个"}},
+		{`{"query":"go","filters":{"tags":["a",2,"c","d"]}}`, []string{"参数 filters.tags 最多允许 3 项，实际 4 项", "参数 filters.tags[1] 应为 string，实际为 number 2"}},
+		{`{"query":"go","since":5}`, []string{"参数 since 的值 number 5 不符合任何允许的形式"}},
+	}
+	for _, tc := range tests {
+		got := schema.validate(decodeParams(t, tc.args))
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("validate(%s)\n got: %q\nwant: %q", tc.args, got, tc.want)
+		}
+	}
+	if errs := schema.validate(nil); len(errs) != 1 {
+		t.Errorf("validate(nil) = %q, want missing query", errs)
+	}
+}
+
+func TestArgSchema_RepairsHarmlessMismatches(t *testing.T) {
+	schema := compileSchema(json.RawMessage(searchSchema))
+	params := decodeParams(t, `{"query":"go","limit":"20","exact":"true","sort":null}`)
+	if errs := schema.validate(params); errs != nil {
+		t.Fatalf("errors = %q", errs)
+	}
+	want := map[string]any{"query": "go", "limit": float64(20), "exact": true}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("params = %v, want %v", params, want)
+	}
+	if errs := schema.validate(decodeParams(t, `{"query":"go","limit":"ten"}`)); len(errs) != 1 || !strings.Contains(errs[0], `string "ten"`) {
+		t.Errorf("unconvertible string: %q", errs)
+	}
+}
+
+func TestArgSchema_PermissiveSchemas(t *testing.T) {
+	for _, raw := range []string{"", "not json", `{}`, `{"type":"object"}`, `{"type":"object","properties":{"x":{"$ref":"#/defs/x"}}}`} {
+		schema := compileSchema(json.RawMessage(raw))
+		if errs := schema.validate(decodeParams(t, `{"x":{"any":[1,"two"]},"y":null}`)); errs != nil {
+			t.Errorf("schema %q rejected args: %q", raw, errs)
+		}
+	}
+}
+
+func TestMCPToolAdapter_Execute_SchemaError(t *testing.T) {
+	info := ToolInfo{Name: "search", InputSchema: json.RawMessage(searchSchema)}
+	// No client: a call that reached the server would fail differently.
+	adapter := NewMCPToolAdapter("docs", info, NewClient(ServerConfig{}), ServerConfig{})
+	res, err := adapter.Execute(context.Background(), json.RawMessage(`{"limit":"many"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"mcp_docs__search 参数校验失败，工具未执行",
+		"- 缺少必填参数 query",
+		`- 参数 limit 应为 integer，实际为 string "many"`,
+		`参数要求: query (string, 必填), exact (boolean), filters (object), limit (integer), since, sort (string, 可选 "relevance", "date")`,
+	} {
+		if !strings.Contains(res.Error, want) {
+			t.Errorf("error missing %q:\n%s", want, res.Error)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// skillVersionsDir holds the snapshots of a skill, inside its directory:
+// skills/<name>/.versions/v1, v2, …
+const skillVersionsDir = ".versions"
+
+const defaultSkillVersions = 5
+
+// loadSkillVersions reads MCP_SKILL_VERSIONS, the number of snapshots kept
+// per skill; 0 disables versioning.
+func loadSkillVersions() int {
+	v := os.Getenv("MCP_SKILL_VERSIONS")
+	if v == "" {
+		return defaultSkillVersions
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("[Config] WARNING: invalid MCP_SKILL_VERSIONS=%q (must be >= 0), using default %d", v, defaultSkillVersions)
+		return defaultSkillVersions
+	}
+	return n
+}
+
+// skillVersion identifies the content a skill server was started from.
+type skillVersion struct {
+	dir  string // skill directory
+	n    int    // snapshot number; 0 = not snapshotted (versioning off)
+	hash string // content hash of dir
+}
+
+// skillSkip lists what a snapshot leaves out: the snapshots themselves and
+// the installed environment, which is rebuilt from the declaration.
+var skillSkip = map[string]bool{
+	skillVersionsDir: true, ".venv": true, "node_modules": true, "__pycache__": true, depsStampFile: true,
+}
+
+// skillDir returns the directory of a skill server: the skills/<name>
+// directory holding its script or binary, "" for other servers. Only
+// skills are versioned, never a whole project directory.
+func skillDir(cfg ServerConfig) string {
+	if cfg.Transport != "stdio" {
+		return ""
+	}
+	script := findScriptFile(cfg)
+	if script == "" {
+		script = cfg.Command
+	}
+	if !strings.ContainsAny(script, `/\`) {
+		return "" // an executable on PATH
+	}
+	dir, err := filepath.Abs(filepath.Dir(script))
+	if err != nil || filepath.Base(filepath.Dir(dir)) != "skills" {
+		return ""
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// hashSkill hashes the files of a skill directory (paths and contents),
+// without the parts skillSkip leaves out.
+func hashSkill(dir string) (string, error) {
+	h := sha256.New()
+	err := walkSkill(dir, func(rel, path string) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		h.Write([]byte{0})
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// walkSkill calls fn for every regular file of a skill directory in
+// lexical order, with its path relative to dir.
+func walkSkill(dir string, fn func(rel, path string) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if skillSkip[d.Name()] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil // directories are walked; symlinks are not followed
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		return fn(rel, path)
+	})
+}
+
+// copySkill copies the files of a skill directory into dst.
+func copySkill(src, dst string) error {
+	return walkSkill(src, func(rel, path string) error {
+		target := filepath.Join(dst, rel)
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		fi, err := in.Stat()
+		if err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, fi.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// skillSnapshots returns the snapshot numbers of a skill, ascending.
+func skillSnapshots(dir string) []int {
+	entries, _ := os.ReadDir(filepath.Join(dir, skillVersionsDir))
+	var ns []int
+	for _, e := range entries {
+		if n, err := strconv.Atoi(strings.TrimPrefix(e.Name(), "v")); err == nil && e.IsDir() && strings.HasPrefix(e.Name(), "v") {
+			ns = append(ns, n)
+		}
+	}
+	sort.Ints(ns)
+	return ns
+}
+
+func snapshotPath(dir string, n int) string {
+	return filepath.Join(dir, skillVersionsDir, fmt.Sprintf("v%d", n))
+}
+
+// recordSkillVersion returns the version of the skill in dir, which just
+// started successfully. Content matching a snapshot (e.g. after a rollback)
+// keeps that snapshot's number; new content becomes the next snapshot, and
+// snapshots beyond the newest keep are removed. keep 0 only hashes.
+func recordSkillVersion(dir string, keep int) (skillVersion, error) {
+	hash, err := hashSkill(dir)
+	if err != nil {
+		return skillVersion{}, err
+	}
+	v := skillVersion{dir: dir, hash: hash}
+	if keep <= 0 {
+		return v, nil
+	}
+	ns := skillSnapshots(dir)
+	for i := len(ns) - 1; i >= 0; i-- {
+		if h, err := hashSkill(snapshotPath(dir, ns[i])); err == nil && h == hash {
+			v.n = ns[i]
+			return v, nil
+		}
+	}
+	v.n = 1
+	if len(ns) > 0 {
+		v.n = ns[len(ns)-1] + 1
+	}
+	if err := copySkill(dir, snapshotPath(dir, v.n)); err != nil {
+		os.RemoveAll(snapshotPath(dir, v.n))
+		return skillVersion{dir: dir, hash: hash}, err
+	}
+	ns = append(ns, v.n)
+	for len(ns) > keep {
+		os.RemoveAll(snapshotPath(dir, ns[0]))
+		ns = ns[1:]
+	}
+	return v, nil
+}
+
+// restoreSkill replaces the files of a skill directory with snapshot n.
+// The snapshots and the installed environment stay.
+func restoreSkill(dir string, n int) error {
+	snap := snapshotPath(dir, n)
+	if _, err := os.Stat(snap); err != nil {
+		return fmt.Errorf("version v%d not found", n)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if skillSkip[e.Name()] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return copySkill(snap, dir)
+}
+
+// Rollback restores a skill server's files to snapshot version and reloads,
+// which restarts the server on the restored files. version 0 means the
+// version before the running one or, when the server is not running (the
+// bad version failed to start), the newest snapshot that differs from the
+// current files. Returns a summary including the reload summary.
+func (m *Manager) Rollback(ctx context.Context, registry *tool.Registry, server string, version int) (string, error) {
+	configs, err := LoadConfig(m.configPath)
+	if err != nil {
+		return "", fmt.Errorf("skill rollback: load config: %w", err)
+	}
+	cfg, ok := configs[server]
+	if !ok {
+		return "", fmt.Errorf("skill rollback: unknown server %q", server)
+	}
+	dir := skillDir(cfg)
+	if dir == "" {
+		return "", fmt.Errorf("skill rollback: server %q is not a skill (skills/<name>/)", server)
+	}
+	ns := skillSnapshots(dir)
+	if len(ns) == 0 {
+		return "", fmt.Errorf("skill rollback: no versions of %q in %s", server, filepath.Join(dir, skillVersionsDir))
+	}
+
+	if version == 0 {
+		m.mu.Lock()
+		running := m.versions[server].n
+		m.mu.Unlock()
+		version = previousSkillVersion(dir, ns, running)
+		if version == 0 {
+			return "", fmt.Errorf("skill rollback: no version of %q before the current one (versions: %s)", server, formatVersions(ns))
+		}
+	} else if !slices.Contains(ns, version) {
+		return "", fmt.Errorf("skill rollback: version v%d of %q not found (versions: %s)", version, server, formatVersions(ns))
+	}
+
+	if err := restoreSkill(dir, version); err != nil {
+		return "", fmt.Errorf("skill rollback: restore v%d: %w", version, err)
+	}
+	log.Printf("[MCP] Skill %q rolled back to v%d", server, version)
+	summary, err := m.Reload(ctx, registry)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Skill %q restored to v%d (versions: %s)\n%s", server, version, formatVersions(ns), summary), nil
+}
+
+// previousSkillVersion picks the rollback target: the newest snapshot
+// before running, or when running is 0 the newest one whose files differ
+// from dir. 0 = none.
+func previousSkillVersion(dir string, ns []int, running int) int {
+	current, _ := hashSkill(dir)
+	for i := len(ns) - 1; i >= 0; i-- {
+		if running > 0 {
+			if ns[i] < running {
+				return ns[i]
+			}
+			continue
+		}
+		if h, err := hashSkill(snapshotPath(dir, ns[i])); err == nil && h != current {
+			return ns[i]
+		}
+	}
+	return 0
+}
+
+func formatVersions(ns []int) string {
+	vs := make([]string, len(ns))
+	for i, n := range ns {
+		vs[i] = fmt.Sprintf("v%d", n)
+	}
+	return strings.Join(vs, ", ")
+}
@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// newSkill creates skills/demo with a server script and an installed
+// environment, and returns the skill directory.
+func newSkill(t *testing.T, script string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "skills", "demo")
+	for name, content := range map[string]string{
+		"server.py":                 script,
+		"lib/util.py":               "X = 1\n",
+		"node_modules/pkg/index.js": "big dependency\n",
+	} {
+		p := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestSkillDir(t *testing.T) {
+	dir := newSkill(t, "print(1)\n")
+	if got := skillDir(ServerConfig{Transport: "stdio", Command: "python3", Args: []string{filepath.Join(dir, "server.py")}}); got != dir {
+		t.Errorf("skillDir = %q, want %q", got, dir)
+	}
+	for _, cfg := range []ServerConfig{
+		{Transport: "stdio", Command: "python3", Args: []string{filepath.Join(filepath.Dir(dir), "tool.py")}}, // skills/tool.py
+		{Transport: "stdio", Command: "npx", Args: []string{"-y", "some-server"}},                             // from PATH
+		{Transport: "http", URL: "http://localhost"},
+	} {
+		if got := skillDir(cfg); got != "" {
+			t.Errorf("skillDir(%+v) = %q, want none", cfg, got)
+		}
+	}
+}
+
+func TestRecordSkillVersion(t *testing.T) {
+	dir := newSkill(t, "v1\n")
+	v, err := recordSkillVersion(dir, 2)
+	if err != nil || v.n != 1 {
+		t.Fatalf("first version = %+v, %v", v, err)
+	}
+	if _, err := os.Stat(filepath.Join(snapshotPath(dir, 1), "node_modules")); !os.IsNotExist(err) {
+		t.Error("snapshot should leave out the installed environment")
+	}
+	if readFile(t, filepath.Join(snapshotPath(dir, 1), "lib", "util.py")) != "X = 1\n" {
+		t.Error("snapshot misses nested files")
+	}
+	if again, _ := recordSkillVersion(dir, 2); again.n != 1 || again.hash != v.hash {
+		t.Errorf("unchanged skill = %+v, want v1 again", again)
+	}
+
+	for i, content := range []string{"v2\n", "v3\n"} {
+		os.WriteFile(filepath.Join(dir, "server.py"), []byte(content), 0o644)
+		if v, _ := recordSkillVersion(dir, 2); v.n != i+2 {
+			t.Errorf("after edit %d: version %d, want %d", i+1, v.n, i+2)
+		}
+	}
+	if ns := skillSnapshots(dir); len(ns) != 2 || ns[0] != 2 || ns[1] != 3 {
+		t.Errorf("snapshots = %v, want the newest 2 (v2, v3)", ns)
+	}
+
+	// Restored content keeps its number
+	if err := restoreSkill(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	if readFile(t, filepath.Join(dir, "server.py")) != "v2\n" {
+		t.Error("restore did not bring back v2")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "node_modules", "pkg", "index.js")); err != nil {
+		t.Error("restore should keep the installed environment")
+	}
+	if v, _ := recordSkillVersion(dir, 2); v.n != 2 {
+		t.Errorf("restored skill = v%d, want v2", v.n)
+	}
+}
+
+func TestPreviousSkillVersion(t *testing.T) {
+	dir := newSkill(t, "good\n")
+	recordSkillVersion(dir, 5)
+	os.WriteFile(filepath.Join(dir, "server.py"), []byte("works but wrong\n"), 0o644)
+	recordSkillVersion(dir, 5)
+	ns := skillSnapshots(dir)
+
+	if got := previousSkillVersion(dir, ns, 2); got != 1 {
+		t.Errorf("running v2: target v%d, want v1", got)
+	}
+	// The edit failed to start, so nothing runs: the newest differing snapshot
+	os.WriteFile(filepath.Join(dir, "server.py"), []byte("syntax error\n"), 0o644)
+	if got := previousSkillVersion(dir, ns, 0); got != 2 {
+		t.Errorf("not running: target v%d, want v2", got)
+	}
+	if got := previousSkillVersion(dir, ns, 1); got != 0 {
+		t.Errorf("running the oldest: target v%d, want none", got)
+	}
+}
+
+func TestRollbackTool_RestoresAndReloads(t *testing.T) {
+	dir := newSkill(t, "def main(): pass\n")
+	recordSkillVersion(dir, 5)
+	os.WriteFile(filepath.Join(dir, "server.py"), []byte("def main(:\n"), 0o644)
+
+	cfgPath := filepath.Join(t.TempDir(), "mcp.json")
+	cfg := map[string]any{"mcpServers": map[string]any{
+		"demo": map[string]any{"transport": "stdio", "command": "omega-no-such-python", "args": []string{filepath.Join(dir, "server.py")}},
+		"web":  map[string]any{"transport": "http", "url": "http://127.0.0.1:1/mcp"},
+	}}
+	data, _ := json.Marshal(cfg)
+	os.WriteFile(cfgPath, data, 0o644)
+
+	m := NewManager(cfgPath)
+	rt := NewRollbackTool(m, tool.NewRegistry())
+	result, _ := rt.Execute(context.Background(), json.RawMessage(`{"server":"demo"}`))
+	if result.Error != "" || !strings.Contains(result.Output, `restored to v1`) || !strings.Contains(result.Output, "MCP reload") {
+		t.Fatalf("rollback = %+v", result)
+	}
+	if readFile(t, filepath.Join(dir, "server.py")) != "def main(): pass\n" {
+		t.Error("files not restored")
+	}
+
+	for args, want := range map[string]string{
+		`{"server":"demo","version":7}`: "v7 of",
+		`{"server":"web"}`:              "not a skill",
+		`{"server":"nope"}`:             "unknown server",
+		`{}`:                            "server is required",
+	} {
+		if result, _ := rt.Execute(context.Background(), json.RawMessage(args)); !strings.Contains(result.Error, want) {
+			t.Errorf("%s: error = %q, want %q", args, result.Error, want)
+		}
+	}
+}
+
+func TestSkillChanged(t *testing.T) {
+	running := skillVersion{n: 2, hash: "aaa"}
+	if !skillChanged(running, "bbb") || skillChanged(running, "aaa") || skillChanged(running, "") || skillChanged(skillVersion{}, "bbb") {
+		t.Error("skillChanged should only report a known, different hash")
+	}
+}
+
+func TestAdapterDescriptionShowsSkillVersion(t *testing.T) {
+	a := NewMCPToolAdapter("demo", ToolInfo{Name: "run", Description: "Runs it"}, nil, ServerConfig{})
+	if a.Description() != "Runs it" {
+		t.Errorf("unversioned: %q", a.Description())
+	}
+	a.version = 3
+	if a.Description() != "Runs it [skill v3]" {
+		t.Errorf("versioned: %q", a.Description())
+	}
+}
@@ -0,0 +1,49 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxSteps bounds a plan edited by the user.
+const MaxSteps = 50
+
+// ErrRejected is returned by a ConfirmFunc when the user turns the plan
+// down; the error text carries the user's reason.
+var ErrRejected = errors.New("plan rejected")
+
+// ConfirmFunc shows a new plan to the user and waits until it is confirmed.
+// It returns the steps to execute — the user may have reordered, deleted or
+// edited them — or ErrRejected, a timeout or the context's cause.
+type ConfirmFunc func(ctx context.Context, steps []PlanStep) ([]PlanStep, error)
+
+// ValidateSteps checks a user-edited plan: 1 to MaxSteps steps with unique,
+// non-empty IDs and non-empty titles. Statuses are reset to pending, since
+// an edited plan has not started.
+func ValidateSteps(steps []PlanStep) ([]PlanStep, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("plan has no steps")
+	}
+	if len(steps) > MaxSteps {
+		return nil, fmt.Errorf("plan has %d steps (max %d)", len(steps), MaxSteps)
+	}
+	seen := make(map[string]bool, len(steps))
+	out := make([]PlanStep, len(steps))
+	for i, s := range steps {
+		s.ID, s.Title = strings.TrimSpace(s.ID), strings.TrimSpace(s.Title)
+		switch {
+		case s.ID == "":
+			return nil, fmt.Errorf("step %d has no id", i+1)
+		case s.Title == "":
+			return nil, fmt.Errorf("step %s has no title", s.ID)
+		case seen[s.ID]:
+			return nil, fmt.Errorf("duplicate step id %s", s.ID)
+		}
+		seen[s.ID] = true
+		s.Status, s.Detail = "pending", ""
+		out[i] = s
+	}
+	return out, nil
+}
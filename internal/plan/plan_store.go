@@ -8,10 +8,11 @@ import (
 
 // PlanStep represents a single step in an agent execution plan.
 type PlanStep struct {
-	ID     string `json:"id"`               // Unique identifier, e.g. "step1", "read_config"
-	Title  string `json:"title"`            // Step description
-	Status string `json:"status"`           // "pending" | "in_progress" | "done" | "error" | "skipped"
-	Detail string `json:"detail,omitempty"` // Optional detail/error message
+	ID     string     `json:"id"`               // Unique identifier, e.g. "step1", "read_config"
+	Title  string     `json:"title"`            // Step description
+	Status string     `json:"status"`           // "pending" | "in_progress" | "done" | "error" | "skipped"
+	Detail string     `json:"detail,omitempty"` // Optional detail/error message
+	Check  *StepCheck `json:"check,omitempty"`  // Optional evidence check, see Reconcile
 }
 
 // PlanStore manages execution plans per session.
@@ -113,7 +114,11 @@ func (ps *PlanStore) Render(sessionID string) string {
 		if icon == "" {
 			icon = "[ ]"
 		}
-		sb.WriteString(fmt.Sprintf("- %s %s: %s\n", icon, s.ID, s.Title))
+		sb.WriteString(fmt.Sprintf("- %s %s: %s", icon, s.ID, s.Title))
+		if s.Check != nil && s.Detail != "" {
+			sb.WriteString("（" + s.Detail + "）") // why reconciliation changed the status
+		}
+		sb.WriteString("\n")
 		if s.Status == "done" {
 			done++
 		}
@@ -238,3 +238,21 @@ func TestPlanStore_RenderUnknownStatus(t *testing.T) {
 		t.Errorf("expected fallback [ ] for unknown status, got: %s", rendered)
 	}
 }
+
+func TestValidateSteps(t *testing.T) {
+	steps, err := ValidateSteps([]PlanStep{{ID: " a ", Title: " 读配置 ", Status: "done", Detail: "x"}, {ID: "b", Title: "改代码"}})
+	if err != nil || steps[0].ID != "a" || steps[0].Title != "读配置" || steps[0].Status != "pending" || steps[0].Detail != "" {
+		t.Errorf("ValidateSteps = %+v, %v", steps, err)
+	}
+	for name, bad := range map[string][]PlanStep{
+		"empty":     nil,
+		"no id":     {{Title: "x"}},
+		"no title":  {{ID: "a", Title: " "}},
+		"duplicate": {{ID: "a", Title: "x"}, {ID: "a", Title: "y"}},
+		"too many":  make([]PlanStep, MaxSteps+1),
+	} {
+		if _, err := ValidateSteps(bad); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StepCheck declares how to verify a step from workspace evidence, so the
+// plan follows reality when steps finish out of order or outside the agent.
+// A file check is re-evaluated after every tool step; a marker is matched
+// against each tool result as it arrives.
+type StepCheck struct {
+	FileExists string `json:"file_exists,omitempty"` // workspace path that exists once the step is done
+	Contains   string `json:"contains,omitempty"`    // with FileExists: text the file contains once done
+	Marker     string `json:"marker,omitempty"`      // text in a successful tool output that completes the step, e.g. "PASS"
+	Tool       string `json:"tool,omitempty"`        // with Marker: only this tool's output counts, e.g. "shell_exec"
+}
+
+// ToolOutcome is the tool result a reconciliation pass checks markers against.
+type ToolOutcome struct {
+	Tool   string
+	Output string
+	Failed bool
+}
+
+// ReadFileFunc reads a workspace file for file checks; an error means the
+// file does not exist (or is outside the workspace).
+type ReadFileFunc func(path string) ([]byte, error)
+
+// Reconcile re-evaluates the checks of a session's plan against the
+// workspace and the latest tool result (may be nil):
+//   - an open step whose check holds becomes done;
+//   - a done step whose file check no longer holds reopens as pending.
+//
+// Steps without a check and skipped steps are left alone. Returns whether
+// any status changed.
+func (ps *PlanStore) Reconcile(sessionID string, readFile ReadFileFunc, last *ToolOutcome) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	steps := ps.plans[sessionID]
+	changed := false
+	for i := range steps {
+		s := &steps[i]
+		c := s.Check
+		if c == nil || s.Status == "skipped" {
+			continue
+		}
+		if c.FileExists != "" {
+			ok, why := c.fileHolds(readFile)
+			switch {
+			case ok && s.Status != "done":
+				s.Status, s.Detail = "done", "自动核对: "+why
+				changed = true
+			case !ok && s.Status == "done":
+				s.Status, s.Detail = "pending", "自动核对: "+why+"，需重新执行"
+				changed = true
+			}
+			continue
+		}
+		if c.Marker != "" && s.Status != "done" && c.markerSeen(last) {
+			s.Status, s.Detail = "done", fmt.Sprintf("自动核对: %s 输出包含 %q", last.Tool, c.Marker)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// fileHolds evaluates the file check and explains the result.
+func (c *StepCheck) fileHolds(readFile ReadFileFunc) (bool, string) {
+	data, err := readFile(c.FileExists)
+	if err != nil {
+		return false, fmt.Sprintf("文件 %s 不存在", c.FileExists)
+	}
+	if c.Contains != "" && !strings.Contains(string(data), c.Contains) {
+		return false, fmt.Sprintf("文件 %s 不包含 %q", c.FileExists, c.Contains)
+	}
+	if c.Contains != "" {
+		return true, fmt.Sprintf("文件 %s 包含 %q", c.FileExists, c.Contains)
+	}
+	return true, fmt.Sprintf("文件 %s 已存在", c.FileExists)
+}
+
+// markerSeen reports whether the tool result completes a marker check.
+func (c *StepCheck) markerSeen(last *ToolOutcome) bool {
+	if last == nil || last.Failed {
+		return false
+	}
+	if c.Tool != "" && c.Tool != last.Tool {
+		return false
+	}
+	return strings.Contains(last.Output, c.Marker)
+}
+
+// HasChecks reports whether any step of the session's plan has a check,
+// letting callers skip reconciliation (and its file reads) otherwise.
+func (ps *PlanStore) HasChecks(sessionID string) bool {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	for _, s := range ps.plans[sessionID] {
+		if s.Check != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package plan
+
+import (
+	"errors"
+	"testing"
+)
+
+func fakeFS(files map[string]string) ReadFileFunc {
+	return func(path string) ([]byte, error) {
+		if s, ok := files[path]; ok {
+			return []byte(s), nil
+		}
+		return nil, errors.New("not found")
+	}
+}
+
+func TestReconcile_FileChecks(t *testing.T) {
+	ps := NewPlanStore()
+	ps.Set("s", []PlanStep{
+		{ID: "create", Title: "Create main.go", Check: &StepCheck{FileExists: "main.go"}},
+		{ID: "route", Title: "Add route", Check: &StepCheck{FileExists: "main.go", Contains: "/health"}},
+		{ID: "docs", Title: "Write docs"},
+	})
+	files := map[string]string{}
+
+	if ps.Reconcile("s", fakeFS(files), nil) {
+		t.Fatal("nothing should change while main.go is missing")
+	}
+
+	files["main.go"] = "package main"
+	if !ps.Reconcile("s", fakeFS(files), nil) {
+		t.Fatal("expected a change once main.go exists")
+	}
+	got := ps.Get("s")
+	if got[0].Status != "done" || got[1].Status != "pending" || got[2].Status != "pending" {
+		t.Fatalf("statuses = %s/%s/%s", got[0].Status, got[1].Status, got[2].Status)
+	}
+
+	files["main.go"] = `mux.HandleFunc("/health", h)`
+	ps.Reconcile("s", fakeFS(files), nil)
+	if got := ps.Get("s"); got[1].Status != "done" {
+		t.Fatalf("route status = %s, want done", got[1].Status)
+	}
+
+	// External deletion reopens the done steps.
+	delete(files, "main.go")
+	if !ps.Reconcile("s", fakeFS(files), nil) {
+		t.Fatal("expected a change after deletion")
+	}
+	got = ps.Get("s")
+	if got[0].Status != "pending" || got[1].Status != "pending" {
+		t.Fatalf("statuses after delete = %s/%s", got[0].Status, got[1].Status)
+	}
+	if got[0].Detail == "" {
+		t.Error("reopened step should explain why")
+	}
+}
+
+func TestReconcile_Marker(t *testing.T) {
+	ps := NewPlanStore()
+	ps.Set("s", []PlanStep{
+		{ID: "test", Title: "Run tests", Check: &StepCheck{Marker: "PASS", Tool: "shell_exec"}},
+		{ID: "any", Title: "Anything ok", Check: &StepCheck{Marker: "ok"}},
+	})
+	fs := fakeFS(nil)
+
+	if ps.Reconcile("s", fs, &ToolOutcome{Tool: "file_read", Output: "PASS"}) {
+		t.Fatal("marker from another tool must not count")
+	}
+	if ps.Reconcile("s", fs, &ToolOutcome{Tool: "shell_exec", Output: "PASS ok", Failed: true}) {
+		t.Fatal("failed tool output must not count")
+	}
+	if !ps.Reconcile("s", fs, &ToolOutcome{Tool: "shell_exec", Output: "ok  \tpkg\nPASS"}) {
+		t.Fatal("expected marker to complete steps")
+	}
+	got := ps.Get("s")
+	if got[0].Status != "done" || got[1].Status != "done" {
+		t.Fatalf("statuses = %s/%s", got[0].Status, got[1].Status)
+	}
+	// Marker steps are not reopened by later outputs.
+	if ps.Reconcile("s", fs, &ToolOutcome{Tool: "shell_exec", Output: "FAIL"}) {
+		t.Error("done marker step should stay done")
+	}
+}
+
+func TestReconcile_SkippedUntouched(t *testing.T) {
+	ps := NewPlanStore()
+	ps.Set("s", []PlanStep{{ID: "a", Title: "A", Status: "skipped", Check: &StepCheck{FileExists: "a.txt"}}})
+	if ps.Reconcile("s", fakeFS(map[string]string{"a.txt": ""}), nil) {
+		t.Error("skipped step must not change")
+	}
+}
+
+func TestHasChecks(t *testing.T) {
+	ps := NewPlanStore()
+	if ps.HasChecks("s") {
+		t.Error("no plan should have no checks")
+	}
+	ps.Set("s", []PlanStep{{ID: "a", Title: "A"}})
+	if ps.HasChecks("s") {
+		t.Error("plan without checks")
+	}
+	ps.Set("s", []PlanStep{{ID: "a", Title: "A"}, {ID: "b", Title: "B", Check: &StepCheck{Marker: "x"}}})
+	if !ps.HasChecks("s") {
+		t.Error("expected HasChecks")
+	}
+}
@@ -0,0 +1,98 @@
+package prompt
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BriefName is the default file name of the project brief in the workspace.
+const BriefName = "PROJECT_BRIEF.md"
+
+// WithBrief sets the path of the project brief: durable facts about the
+// workspace (build commands, layout, conventions) kept across runs and
+// injected into the decision prompt. An empty path disables the brief.
+func (l *PromptLoader) WithBrief(path string) *PromptLoader {
+	l.mu.Lock()
+	l.briefPath = path
+	delete(l.cache, "brief")
+	l.mu.Unlock()
+	return l
+}
+
+// BriefPath returns the configured project brief path ("" = disabled).
+func (l *PromptLoader) BriefPath() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.briefPath
+}
+
+// LoadBrief returns the project brief, or "" when it is disabled or absent.
+// The brief is partly model-written, so it goes through the same injection
+// filter as the user rules.
+func (l *PromptLoader) LoadBrief() string {
+	l.mu.RLock()
+	if val, ok := l.cache["brief"]; ok {
+		l.mu.RUnlock()
+		return val
+	}
+	path := l.briefPath
+	l.mu.RUnlock()
+
+	content := ""
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			content = strings.TrimSpace(filterDangerousLines(stripFrontmatter(string(data))))
+		case !os.IsNotExist(err):
+			log.Printf("[Prompt] Warning: read project brief %q failed: %v", path, err)
+		}
+	}
+
+	l.mu.Lock()
+	if val, ok := l.cache["brief"]; ok {
+		l.mu.Unlock()
+		return val
+	}
+	l.cache["brief"] = content
+	l.mu.Unlock()
+	return content
+}
+
+// WriteBrief replaces the project brief atomically (temp file + rename) and
+// refreshes the cached copy.
+func (l *PromptLoader) WriteBrief(content string) error {
+	path := l.BriefPath()
+	if path == "" {
+		return fmt.Errorf("prompt: project brief not configured")
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("prompt: write brief: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".brief-*")
+	if err != nil {
+		return fmt.Errorf("prompt: write brief: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strings.TrimSpace(content) + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("prompt: write brief: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("prompt: write brief: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("prompt: write brief: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("prompt: write brief: %w", err)
+	}
+	l.mu.Lock()
+	delete(l.cache, "brief")
+	l.mu.Unlock()
+	return nil
+}
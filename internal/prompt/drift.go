@@ -0,0 +1,229 @@
+package prompt
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// defaultsManifestName is the file in the prompts override directory that
+// records the embedded L2 defaults as of the last start: after an upgrade
+// it holds the old defaults the user's overrides were based on.
+const defaultsManifestName = ".defaults.json"
+
+// ErrNoDrift is returned by ResolveDrift for a file without pending drift.
+var ErrNoDrift = errors.New("prompt: file has no pending default changes")
+
+type defaultsManifest struct {
+	Files map[string]manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	SHA256  string `json:"sha256"`
+	Content string `json:"content"`
+}
+
+// Drift is an overridden L2 file whose embedded default changed since the
+// override was last reconciled with it, as a three-way merge view.
+type Drift struct {
+	Name       string `json:"name"`
+	OldDefault string `json:"old_default"` // the default the override was based on
+	NewDefault string `json:"new_default"` // the default shipped with this binary
+	User       string `json:"user"`        // the override on disk
+	Merged     string `json:"merged"`      // Merge3 result; conflict blocks when Conflicts > 0
+	Conflicts  int    `json:"conflicts"`
+}
+
+// DefaultHashes returns the sha256 of every embedded L2 default by file name.
+func DefaultHashes() map[string]string {
+	hashes := make(map[string]string)
+	for name, content := range embeddedDefaults() {
+		hashes[name] = hashContent(content)
+	}
+	return hashes
+}
+
+func embeddedDefaults() map[string]string {
+	defaults := make(map[string]string)
+	entries, _ := fs.ReadDir(defaultPrompts, "prompts")
+	for _, e := range entries {
+		if e.Name() == soulName {
+			continue // the persona is the user's own; its default is no fix to miss
+		}
+		if data, err := fs.ReadFile(defaultPrompts, "prompts/"+e.Name()); err == nil {
+			defaults[e.Name()] = string(data)
+		}
+	}
+	return defaults
+}
+
+func hashContent(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// SyncDefaults compares the embedded defaults with the manifest of the
+// previous start and returns the overridden files whose default changed
+// (sorted). Their old defaults stay in the manifest until ResolveDrift;
+// every other entry is brought up to date. Overrides first seen by this
+// check are assumed to be based on the current default. Does nothing
+// without an existing override directory.
+func (l *PromptLoader) SyncDefaults() ([]string, error) {
+	if l.promptsDir == "" {
+		return nil, nil
+	}
+	if info, err := os.Stat(l.promptsDir); err != nil || !info.IsDir() {
+		return nil, nil
+	}
+	l.defaultsMu.Lock()
+	defer l.defaultsMu.Unlock()
+	m, err := l.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	var drifted []string
+	for name, content := range embeddedDefaults() {
+		hash := hashContent(content)
+		if old, ok := m.Files[name]; ok && old.SHA256 != hash && l.hasOverride(name) {
+			drifted = append(drifted, name)
+			continue
+		}
+		m.Files[name] = manifestEntry{SHA256: hash, Content: content}
+	}
+	sort.Strings(drifted)
+	return drifted, l.writeManifest(m)
+}
+
+// Drifts returns the three-way view of every overridden file with pending
+// default changes, sorted by name.
+func (l *PromptLoader) Drifts() ([]Drift, error) {
+	l.defaultsMu.Lock()
+	defer l.defaultsMu.Unlock()
+	m, err := l.readManifest()
+	if err != nil {
+		return nil, err
+	}
+	defaults := embeddedDefaults()
+	var drifts []Drift
+	for name, old := range m.Files {
+		current, ok := defaults[name]
+		if !ok || old.SHA256 == hashContent(current) {
+			continue
+		}
+		user, err := os.ReadFile(filepath.Join(l.promptsDir, name))
+		if err != nil {
+			continue // override removed: the new default applies
+		}
+		merged, conflicts := Merge3(old.Content, string(user), current)
+		drifts = append(drifts, Drift{Name: name, OldDefault: old.Content, NewDefault: current,
+			User: string(user), Merged: merged, Conflicts: conflicts})
+	}
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].Name < drifts[j].Name })
+	return drifts, nil
+}
+
+// ResolveDrift records that the override of name has been reconciled with
+// the current default, so it is no longer reported by Drifts.
+func (l *PromptLoader) ResolveDrift(name string) error {
+	if _, _, err := l.resolve(name); err != nil {
+		return err
+	}
+	l.defaultsMu.Lock()
+	defer l.defaultsMu.Unlock()
+	m, err := l.readManifest()
+	if err != nil {
+		return err
+	}
+	current, ok := embeddedDefaults()[name]
+	if old, tracked := m.Files[name]; !ok || !tracked || old.SHA256 == hashContent(current) {
+		return fmt.Errorf("%w: %s", ErrNoDrift, name)
+	}
+	m.Files[name] = manifestEntry{SHA256: hashContent(current), Content: current}
+	return l.writeManifest(m)
+}
+
+// RemoveOverride deletes the override of the L2 file name, so the current
+// embedded default applies, and resolves its pending default changes.
+func (l *PromptLoader) RemoveOverride(name string) error {
+	layer, path, err := l.resolve(name)
+	if err != nil {
+		return err
+	}
+	if layer != LayerL2 || path == "" {
+		return fmt.Errorf("%w: %q has no L2 override", ErrInvalidName, name)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("prompt: remove %s: %w", path, err)
+	}
+	l.markReconciled(name)
+	l.Reload()
+	return nil
+}
+
+// markReconciled updates the manifest entry of name after the override was
+// written, which implies the user saw the current default.
+func (l *PromptLoader) markReconciled(name string) {
+	l.defaultsMu.Lock()
+	defer l.defaultsMu.Unlock()
+	current, ok := embeddedDefaults()[name]
+	if !ok {
+		return
+	}
+	m, err := l.readManifest()
+	if err != nil || m.Files[name].SHA256 == hashContent(current) {
+		return
+	}
+	m.Files[name] = manifestEntry{SHA256: hashContent(current), Content: current}
+	l.writeManifest(m)
+}
+
+func (l *PromptLoader) hasOverride(name string) bool {
+	info, err := os.Stat(filepath.Join(l.promptsDir, name))
+	return err == nil && !info.IsDir()
+}
+
+// readManifest loads the manifest; defaultsMu must be held.
+func (l *PromptLoader) readManifest() (defaultsManifest, error) {
+	m := defaultsManifest{Files: make(map[string]manifestEntry)}
+	if l.promptsDir == "" {
+		return m, nil
+	}
+	data, err := os.ReadFile(filepath.Join(l.promptsDir, defaultsManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("prompt: read defaults manifest: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("prompt: parse defaults manifest: %w", err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]manifestEntry)
+	}
+	return m, nil
+}
+
+// writeManifest saves the manifest atomically; defaultsMu must be held.
+func (l *PromptLoader) writeManifest(m defaultsManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(l.promptsDir, defaultsManifestName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("prompt: write defaults manifest: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("prompt: write defaults manifest: %w", err)
+	}
+	return nil
+}
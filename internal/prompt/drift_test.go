@@ -0,0 +1,115 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// simulateUpgrade records oldDefault as the default of name at the previous
+// start, as if the embedded default changed since.
+func simulateUpgrade(t *testing.T, dir, name, oldDefault string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, defaultsManifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m defaultsManifest
+	json.Unmarshal(data, &m)
+	m.Files[name] = manifestEntry{SHA256: hashContent(oldDefault), Content: oldDefault}
+	data, _ = json.Marshal(m)
+	os.WriteFile(filepath.Join(dir, defaultsManifestName), data, 0o644)
+}
+
+func TestSyncDefaults_DetectsChangedDefaults(t *testing.T) {
+	const name = "answer_style_concise.md"
+	current := embeddedDefaults()[name]
+	if current == "" || DefaultHashes()[name] != hashContent(current) {
+		t.Fatalf("embedded %s missing", name)
+	}
+	lines := strings.Split(current, "\n")
+	oldDefault := strings.Join(append([]string{"旧的第一行"}, lines[1:]...), "\n")
+	user := strings.Join(append([]string{"旧的第一行"}, lines[1:]...), "\n") + "\n我的补充规则"
+
+	dir := t.TempDir()
+	if drifted, err := NewPromptLoader(filepath.Join(dir, "missing"), "", "").SyncDefaults(); drifted != nil || err != nil {
+		t.Errorf("no override dir: %v, %v", drifted, err)
+	}
+	os.WriteFile(filepath.Join(dir, name), []byte(user), 0o644)
+	l := NewPromptLoader(dir, "", "")
+	if drifted, err := l.SyncDefaults(); len(drifted) != 0 || err != nil {
+		t.Fatalf("first start: %v, %v (overrides are baselined)", drifted, err)
+	}
+
+	simulateUpgrade(t, dir, name, oldDefault)
+	simulateUpgrade(t, dir, "think_guide.md", "no override, not reported")
+	drifted, err := l.SyncDefaults()
+	if err != nil || !reflect.DeepEqual(drifted, []string{name}) {
+		t.Fatalf("after upgrade: %v, %v", drifted, err)
+	}
+	if again, _ := l.SyncDefaults(); !reflect.DeepEqual(again, drifted) {
+		t.Errorf("drift not kept until resolved: %v", again)
+	}
+
+	drifts, err := l.Drifts()
+	if err != nil || len(drifts) != 1 {
+		t.Fatalf("Drifts = %+v, %v", drifts, err)
+	}
+	d := drifts[0]
+	if d.OldDefault != oldDefault || d.NewDefault != current || d.User != user || d.Conflicts != 0 {
+		t.Errorf("drift = %+v", d)
+	}
+	if d.Merged != current+"\n我的补充规则" {
+		t.Errorf("merged = %q", d.Merged)
+	}
+
+	if _, err := l.WriteFile(name, d.Merged); err != nil {
+		t.Fatal(err)
+	}
+	if drifts, _ := l.Drifts(); len(drifts) != 0 {
+		t.Errorf("drift after writing the merge: %+v", drifts)
+	}
+	if err := l.ResolveDrift(name); !errors.Is(err, ErrNoDrift) {
+		t.Errorf("ResolveDrift without drift = %v", err)
+	}
+}
+
+func TestResolveDriftAndRemoveOverride(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"think_guide.md", "rule_guide.md"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("custom"), 0o644)
+	}
+	l := NewPromptLoader(dir, "", "")
+	l.SyncDefaults()
+	simulateUpgrade(t, dir, "think_guide.md", "old")
+	simulateUpgrade(t, dir, "rule_guide.md", "old")
+	if drifted, _ := l.SyncDefaults(); len(drifted) != 2 {
+		t.Fatalf("drifted = %v", drifted)
+	}
+	if drifts, _ := l.Drifts(); len(drifts) != 2 || drifts[0].Conflicts != 1 {
+		t.Errorf("Drifts = %+v, want a conflict for rewritten files", drifts)
+	}
+
+	if err := l.ResolveDrift("think_guide.md"); err != nil {
+		t.Fatal(err)
+	}
+	if got := l.Load("think_guide.md"); got != "custom" {
+		t.Errorf("kept override = %q", got)
+	}
+	if err := l.RemoveOverride("rule_guide.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, info, _ := l.ReadFile("rule_guide.md"); info.Source != "embedded" {
+		t.Errorf("source after reset = %s", info.Source)
+	}
+	if drifts, _ := l.Drifts(); len(drifts) != 0 {
+		t.Errorf("Drifts after resolving = %+v", drifts)
+	}
+	if err := l.RemoveOverride("rules.md"); !errors.Is(err, ErrInvalidName) {
+		t.Errorf("RemoveOverride(rules.md) = %v", err)
+	}
+}
@@ -4,6 +4,10 @@
 //   - L2: Project behaviour rules in prompts/*.md (embedded by default, overridable at runtime)
 //   - L3: User custom rules in rules.md (runtime only, never committed)
 //
+// Any of these files may start with a "---" frontmatter block of "key: value"
+// lines (e.g. a description for the prompt editor); it is stripped before the
+// content reaches the model.
+//
 // The PromptLoader is safe for concurrent use.
 package prompt
 
@@ -45,9 +49,13 @@ type PromptLoader struct {
 	promptsDir string // runtime override directory (may be empty)
 	rulesPath  string // path to L3 rules.md
 	soulPath   string // path to user soul.md (workspace root)
+	briefPath  string // path to PROJECT_BRIEF.md (see WithBrief; may be empty)
 	cache      map[string]string
 	patchHooks []patchEntry // recorded PatchFile calls, reapplied after Reload
-	mu         sync.RWMutex
+	// hotReloaded holds the files reloaded by Watch, drained by TakeHotReloaded.
+	hotReloaded map[string]bool
+	mu          sync.RWMutex
+	defaultsMu  sync.Mutex // guards the defaults manifest (see SyncDefaults)
 }
 
 // patchEntry records a single PatchFile call for reapplication after Reload.
@@ -117,7 +125,7 @@ func (l *PromptLoader) loadUncached(name string) string {
 		diskPath := filepath.Join(l.promptsDir, name)
 		data, err := os.ReadFile(diskPath)
 		if err == nil {
-			return string(data)
+			return stripFrontmatter(string(data))
 		}
 		if !os.IsNotExist(err) {
 			// File exists but unreadable — warn and fall through to embed
@@ -129,7 +137,7 @@ func (l *PromptLoader) loadUncached(name string) string {
 	// Try embedded default
 	data, err := fs.ReadFile(defaultPrompts, embedPath)
 	if err == nil {
-		return string(data)
+		return stripFrontmatter(string(data))
 	}
 
 	// Neither disk nor embed — return empty string silently
@@ -180,7 +188,7 @@ func (l *PromptLoader) loadUserRulesUncached() string {
 		return ""
 	}
 
-	raw := string(data)
+	raw := stripFrontmatter(string(data))
 	filtered := filterDangerousLines(raw)
 	return filtered
 }
@@ -220,7 +228,7 @@ func (l *PromptLoader) loadSoulUncached() string {
 		data, err := os.ReadFile(l.soulPath)
 		if err == nil {
 			if trimmed := strings.TrimSpace(string(data)); trimmed != "" {
-				return string(data)
+				return stripFrontmatter(string(data))
 			}
 			// Empty file: fall through to embedded default
 		} else if !os.IsNotExist(err) {
@@ -238,33 +246,39 @@ func filterDangerousLines(content string) string {
 	lines := strings.Split(content, "\n")
 	safe := make([]string, 0, len(lines))
 	for _, line := range lines {
-		lower := strings.ToLower(line)
-		dropped := false
-		for _, pattern := range promptInjectionPatterns {
-			if strings.Contains(lower, pattern) {
-				log.Printf("[Prompt] Warning: user rules line dropped (injection pattern %q detected): %q", pattern, line)
-				dropped = true
-				break
-			}
-		}
-		if !dropped {
-			safe = append(safe, line)
+		if pattern := injectionPattern(line); pattern != "" {
+			log.Printf("[Prompt] Warning: user rules line dropped (injection pattern %q detected): %q", pattern, line)
+			continue
 		}
+		safe = append(safe, line)
 	}
 	return strings.Join(safe, "\n")
 }
 
+// injectionPattern returns the first prompt-injection pattern line contains
+// (case-insensitive), or "".
+func injectionPattern(line string) string {
+	lower := strings.ToLower(line)
+	for _, pattern := range promptInjectionPatterns {
+		if strings.Contains(lower, pattern) {
+			return pattern
+		}
+	}
+	return ""
+}
+
 // Reload clears the internal cache so that subsequent Load and LoadUserRules
 // calls re-read files from disk.  Safe for concurrent use.
-// Typically triggered by mcp_reload or a /reload command.
+// Typically triggered by mcp_reload, a /reload command or Watch.
 func (l *PromptLoader) Reload() {
 	l.mu.Lock()
 	l.cache = make(map[string]string)
+	hooks := append([]patchEntry(nil), l.patchHooks...)
 	l.mu.Unlock()
 
 	// Reapply all recorded patches so template variables survive hot-reloads.
 	// Uses reapplyPatch (not PatchFile) to avoid re-recording duplicates.
-	for _, p := range l.patchHooks {
+	for _, p := range hooks {
 		l.reapplyPatch(p)
 	}
 }
@@ -309,11 +323,10 @@ func (l *PromptLoader) PatchFile(name, oldStr, newStr string) {
 	// Apply the string replacement.
 	patched := strings.ReplaceAll(content, oldStr, newStr)
 
-	// Store the patched version, overwriting any previously cached entry.
+	// Store the patched version, overwriting any previously cached entry,
+	// and record the patch for reapplication after Reload.
 	l.mu.Lock()
 	l.cache[cacheKey] = patched
-	l.mu.Unlock()
-
-	// Record for reapplication after Reload.
 	l.patchHooks = append(l.patchHooks, patchEntry{Name: name, OldStr: oldStr, NewStr: newStr})
+	l.mu.Unlock()
 }
@@ -0,0 +1,303 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Layer names reported in FileInfo.
+const (
+	LayerSoul  = "soul"  // agent persona: soul.md in the workspace, else the L2 default
+	LayerRules = "rules" // L3 user rules
+	LayerL2    = "l2"    // project behaviour rules in prompts/*.md
+)
+
+// Logical names of the soul and rules files, whatever their configured paths.
+const (
+	soulName  = "soul.md"
+	rulesName = "rules.md"
+)
+
+// Issue severities. Files with SeverityError issues are not written.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ErrInvalidName is returned for names that are not a plain *.md file name.
+var ErrInvalidName = errors.New("prompt: invalid file name")
+
+// FileInfo describes one prompt file as the loader sees it.
+type FileInfo struct {
+	Name        string `json:"name"`                  // "soul.md", "rules.md" or a prompts/ file name
+	Layer       string `json:"layer"`                 // LayerSoul, LayerRules or LayerL2
+	Source      string `json:"source"`                // "disk", "embedded" or "missing"
+	Path        string `json:"path,omitempty"`        // disk file written by WriteFile ("" = not configured)
+	Description string `json:"description,omitempty"` // frontmatter "description" field
+	Bytes       int    `json:"bytes"`
+	Runes       int    `json:"runes"`
+}
+
+// Issue is one validation finding; Line is 1-based (0 = whole file).
+type Issue struct {
+	Severity string `json:"severity"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// placeholderRe matches template variables such as {{OS}}.
+var placeholderRe = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Files lists the soul file, the rules file and every L2 prompt file
+// (embedded defaults plus *.md files in the override directory), in that order.
+func (l *PromptLoader) Files() []FileInfo {
+	names := make(map[string]bool)
+	if entries, err := fs.ReadDir(defaultPrompts, "prompts"); err == nil {
+		for _, e := range entries {
+			names[e.Name()] = true
+		}
+	}
+	if l.promptsDir != "" {
+		if entries, err := os.ReadDir(l.promptsDir); err == nil {
+			for _, e := range entries {
+				if !e.IsDir() && strings.HasSuffix(e.Name(), ".md") {
+					names[e.Name()] = true
+				}
+			}
+		}
+	}
+	delete(names, soulName)
+	delete(names, rulesName)
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+
+	files := make([]FileInfo, 0, len(sorted)+2)
+	for _, name := range append([]string{soulName, rulesName}, sorted...) {
+		_, info, err := l.ReadFile(name)
+		if err == nil {
+			files = append(files, info)
+		}
+	}
+	return files
+}
+
+// ReadFile returns the raw content of a prompt file — before frontmatter
+// stripping, PatchFile replacements and rules filtering — and its FileInfo.
+// A file that exists nowhere yields "" with Source "missing".
+func (l *PromptLoader) ReadFile(name string) (string, FileInfo, error) {
+	layer, path, err := l.resolve(name)
+	if err != nil {
+		return "", FileInfo{}, err
+	}
+	info := FileInfo{Name: name, Layer: layer, Path: path, Source: "missing"}
+	content, found := "", false
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil && (layer != LayerSoul || strings.TrimSpace(string(data)) != ""):
+			content, found, info.Source = string(data), true, "disk"
+		case err != nil && !os.IsNotExist(err):
+			return "", info, fmt.Errorf("prompt: read %s: %w", path, err)
+		}
+	}
+	// The soul falls back to the L2 soul.md (override directory, then embedded).
+	if !found && layer == LayerSoul && l.promptsDir != "" && path != filepath.Join(l.promptsDir, soulName) {
+		if data, err := os.ReadFile(filepath.Join(l.promptsDir, soulName)); err == nil {
+			content, found, info.Source = string(data), true, "disk"
+		}
+	}
+	if !found && layer != LayerRules {
+		if data, err := fs.ReadFile(defaultPrompts, "prompts/"+name); err == nil {
+			content, info.Source = string(data), "embedded"
+		}
+	}
+
+	meta, _, _ := parseFrontmatter(content)
+	info.Description = meta["description"]
+	info.Bytes = len(content)
+	info.Runes = utf8.RuneCountInString(content)
+	return content, info, nil
+}
+
+// WriteFile validates content and writes it to the disk location of name
+// (creating the override directory if needed), then reloads the cache.
+// Writing an L2 file resolves its pending default changes (see Drifts).
+// Nothing is written when validation reports an error; the issues are
+// returned either way.
+func (l *PromptLoader) WriteFile(name, content string) ([]Issue, error) {
+	layer, path, err := l.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	issues := l.Validate(name, content)
+	if HasErrors(issues) {
+		return issues, nil
+	}
+	if path == "" {
+		return issues, fmt.Errorf("prompt: no disk location configured for %s", name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return issues, fmt.Errorf("prompt: create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return issues, fmt.Errorf("prompt: write %s: %w", path, err)
+	}
+	if layer == LayerL2 {
+		l.markReconciled(name)
+	}
+	l.Reload()
+	return issues, nil
+}
+
+// Validate checks content intended for the named file: frontmatter syntax,
+// template placeholders against the PatchFile replacements registered for
+// the file, and (for the rules file) lines the injection filter would drop.
+func (l *PromptLoader) Validate(name, content string) []Issue {
+	layer, _, err := l.resolve(name)
+	if err != nil {
+		return []Issue{{Severity: SeverityError, Message: err.Error()}}
+	}
+	_, _, issues := parseFrontmatter(content)
+
+	// Placeholders filled at runtime for this file, e.g. {{OS}} in knowledge.md.
+	known := make(map[string]bool)
+	l.mu.RLock()
+	for _, p := range l.patchHooks {
+		if p.Name == name {
+			known[p.OldStr] = true
+		}
+	}
+	l.mu.RUnlock()
+
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		for _, m := range placeholderRe.FindAllString(line, -1) {
+			if !known[m] {
+				issues = append(issues, Issue{Severity: SeverityWarning, Line: i + 1,
+					Message: fmt.Sprintf("placeholder %s is not filled at runtime and will reach the model verbatim", m)})
+			}
+		}
+		if layer == LayerRules {
+			if pattern := injectionPattern(line); pattern != "" {
+				issues = append(issues, Issue{Severity: SeverityWarning, Line: i + 1,
+					Message: fmt.Sprintf("line will be dropped by the injection filter (matches %q)", pattern)})
+			}
+		}
+	}
+	placeholders := make([]string, 0, len(known))
+	for p := range known {
+		placeholders = append(placeholders, p)
+	}
+	sort.Strings(placeholders)
+	for _, p := range placeholders {
+		if !strings.Contains(content, p) {
+			issues = append(issues, Issue{Severity: SeverityWarning,
+				Message: fmt.Sprintf("placeholder %s was removed; its runtime value will not be injected", p)})
+		}
+	}
+
+	if layer == LayerSoul && strings.TrimSpace(content) == "" {
+		issues = append(issues, Issue{Severity: SeverityWarning,
+			Message: "empty soul file: the built-in persona is used instead"})
+	}
+	return issues
+}
+
+// HasErrors reports whether issues contains a SeverityError entry.
+func HasErrors(issues []Issue) bool {
+	for _, is := range issues {
+		if is.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve maps a logical file name to its layer and disk path.
+func (l *PromptLoader) resolve(name string) (layer, path string, err error) {
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) ||
+		!strings.HasSuffix(name, ".md") || strings.HasPrefix(name, ".") {
+		return "", "", fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	switch name {
+	case soulName:
+		if l.soulPath != "" {
+			return LayerSoul, l.soulPath, nil
+		}
+		if l.promptsDir != "" {
+			return LayerSoul, filepath.Join(l.promptsDir, name), nil
+		}
+		return LayerSoul, "", nil
+	case rulesName:
+		return LayerRules, l.rulesPath, nil
+	}
+	if l.promptsDir != "" {
+		return LayerL2, filepath.Join(l.promptsDir, name), nil
+	}
+	return LayerL2, "", nil
+}
+
+// parseFrontmatter splits an optional leading "---" block of "key: value"
+// lines from content. Malformed frontmatter yields error issues and is left
+// in the body.
+func parseFrontmatter(content string) (meta map[string]string, body string, issues []Issue) {
+	meta = make(map[string]string)
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return meta, content, nil
+	}
+	lines := strings.Split(normalized, "\n")
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return meta, content, []Issue{{Severity: SeverityError, Line: 1, Message: "frontmatter is not closed by a \"---\" line"}}
+	}
+	for i := 1; i < end; i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) == "" {
+			issues = append(issues, Issue{Severity: SeverityError, Line: i + 1,
+				Message: fmt.Sprintf("frontmatter line is not \"key: value\": %q", line)})
+			continue
+		}
+		meta[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	if len(issues) > 0 {
+		return map[string]string{}, content, issues
+	}
+	return meta, strings.TrimLeft(strings.Join(lines[end+1:], "\n"), "\n"), nil
+}
+
+// SplitFrontmatter separates a leading "---" block of "key: value" lines
+// from content, e.g. the settings of a playbook. Content without (or with
+// malformed) frontmatter is returned unchanged with empty metadata.
+func SplitFrontmatter(content string) (meta map[string]string, body string) {
+	meta, body, _ = parseFrontmatter(content)
+	return meta, body
+}
+
+// stripFrontmatter returns content without a well-formed frontmatter block;
+// the metadata is for editors and never reaches the model.
+func stripFrontmatter(content string) string {
+	_, body, _ := parseFrontmatter(content)
+	return body
+}
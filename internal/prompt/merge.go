@@ -0,0 +1,108 @@
+package prompt
+
+import "strings"
+
+// Conflict markers written by Merge3 around lines changed on both sides.
+const (
+	conflictStart = "<<<<<<< yours"
+	conflictBase  = "||||||| old default"
+	conflictSep   = "======="
+	conflictEnd   = ">>>>>>> new default"
+)
+
+// Merge3 merges two edits of base line by line (diff3): lines changed only
+// in yours or only in theirs are taken from that side; regions changed
+// differently on both sides are kept as conflict blocks with all three
+// versions. It returns the merged text and the number of conflicts.
+func Merge3(base, yours, theirs string) (merged string, conflicts int) {
+	b, y, t := splitLines(base), splitLines(yours), splitLines(theirs)
+	my, mt := lcsMatch(b, y), lcsMatch(b, t)
+
+	var out []string
+	i, a, c := 0, 0, 0 // next line of base, yours, theirs
+	emit := func(bEnd, yEnd, tEnd int) {
+		bc, yc, tc := b[i:bEnd], y[a:yEnd], t[c:tEnd]
+		switch {
+		case equalLines(yc, bc):
+			out = append(out, tc...)
+		case equalLines(tc, bc), equalLines(yc, tc):
+			out = append(out, yc...)
+		default:
+			conflicts++
+			out = append(out, conflictStart)
+			out = append(out, yc...)
+			out = append(out, conflictBase)
+			out = append(out, bc...)
+			out = append(out, conflictSep)
+			out = append(out, tc...)
+			out = append(out, conflictEnd)
+		}
+	}
+	for j := range b {
+		if my[j] < 0 || mt[j] < 0 {
+			continue
+		}
+		// base line j is unchanged in both: everything before it is one chunk
+		emit(j, my[j], mt[j])
+		out = append(out, b[j])
+		i, a, c = j+1, my[j]+1, mt[j]+1
+	}
+	emit(len(b), len(y), len(t))
+	return strings.Join(out, "\n"), conflicts
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
+
+// lcsMatch returns, for each line of a, the index of the line of b it is
+// paired with in a longest common subsequence, or -1.
+func lcsMatch(a, b []string) []int {
+	n, m := len(a), len(b)
+	// dp[i][j] = LCS length of a[i:] and b[j:]
+	dp := make([][]int, n+1)
+	for i := range dp {
+		dp[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	match := make([]int, n)
+	for i := range match {
+		match[i] = -1
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case a[i] == b[j]:
+			match[i] = j
+			i++
+			j++
+		case dp[i+1][j] >= dp[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\ne"
+	tests := []struct {
+		name, yours, theirs, want string
+		conflicts                 int
+	}{
+		{"only theirs changed", base, "a\nB\nc\nd\ne", "a\nB\nc\nd\ne", 0},
+		{"only yours changed", "a\nb\nc\nd\ne\nf", base, "a\nb\nc\nd\ne\nf", 0},
+		{"disjoint edits", "A\nb\nc\nd\ne", "a\nb\nc\nd\nE\nF", "A\nb\nc\nd\nE\nF", 0},
+		{"same edit", "a\nX\nc\nd\ne", "a\nX\nc\nd\ne", "a\nX\nc\nd\ne", 0},
+		{"deletion and edit", "a\nc\nd\ne", "a\nb\nc\nD\ne", "a\nc\nD\ne", 0},
+		{"conflict", "a\nmine\nc\nd\ne", "a\ntheirs\nc\nd\ne",
+			"a\n" + conflictStart + "\nmine\n" + conflictBase + "\nb\n" + conflictSep + "\ntheirs\n" + conflictEnd + "\nc\nd\ne", 1},
+	}
+	for _, tt := range tests {
+		got, n := Merge3(base, tt.yours, tt.theirs)
+		if got != tt.want || n != tt.conflicts {
+			t.Errorf("%s: Merge3 = %q (%d conflicts), want %q (%d)", tt.name, got, n, tt.want, tt.conflicts)
+		}
+	}
+	if got, _ := Merge3("", "", "new"); got != "new" {
+		t.Errorf("empty base and yours = %q", got)
+	}
+	if got, n := Merge3("x\r\ny", "x\r\ny", "x\r\nz"); got != "x\nz" || n != 0 || strings.Contains(got, "\r") {
+		t.Errorf("CRLF = %q, %d", got, n)
+	}
+}
@@ -0,0 +1,68 @@
+package prompt
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultAnswerStyle names the profile backed by answer_style.md.
+const DefaultAnswerStyle = "default"
+
+// answerStylePrefix is the file name prefix of named answer style profiles:
+// prompts/answer_style_<name>.md. Users add profiles by dropping such a file
+// into the prompts override directory.
+const answerStylePrefix = "answer_style_"
+
+// AnswerStyles returns the available answer style profile names: "default"
+// first, then the embedded and override-directory profiles, sorted.
+func (l *PromptLoader) AnswerStyles() []string {
+	names := make(map[string]bool)
+	for _, f := range l.Files() {
+		if name, ok := styleName(f.Name); ok && f.Source != "missing" {
+			names[name] = true
+		}
+	}
+	styles := make([]string, 0, len(names))
+	for n := range names {
+		styles = append(styles, n)
+	}
+	sort.Strings(styles)
+	return append([]string{DefaultAnswerStyle}, styles...)
+}
+
+// HasAnswerStyle reports whether name is an available profile.
+// The empty name stands for the default profile.
+func (l *PromptLoader) HasAnswerStyle(name string) bool {
+	if name == "" || name == DefaultAnswerStyle {
+		return true
+	}
+	if _, ok := styleName(answerStylePrefix + name + ".md"); !ok {
+		return false
+	}
+	_, info, err := l.ReadFile(answerStylePrefix + name + ".md")
+	return err == nil && info.Source != "missing"
+}
+
+// LoadAnswerStyle returns the answer format rules of the named profile,
+// falling back to answer_style.md for "", "default" and unknown names.
+func (l *PromptLoader) LoadAnswerStyle(name string) string {
+	if name != "" && name != DefaultAnswerStyle && l.HasAnswerStyle(name) {
+		if style := l.Load(answerStylePrefix + name + ".md"); strings.TrimSpace(style) != "" {
+			return style
+		}
+	}
+	return l.Load("answer_style.md")
+}
+
+// styleName extracts the profile name from an answer_style_<name>.md file name.
+func styleName(file string) (string, bool) {
+	name, ok := strings.CutPrefix(file, answerStylePrefix)
+	if !ok {
+		return "", false
+	}
+	name, ok = strings.CutSuffix(name, ".md")
+	if !ok || name == "" || name == DefaultAnswerStyle || strings.ContainsAny(name, `/\.`) {
+		return "", false
+	}
+	return name, true
+}
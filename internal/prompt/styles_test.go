@@ -0,0 +1,39 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnswerStyles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "answer_style_terse.md"), []byte("terse rules"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	l := NewPromptLoader(dir, "", "")
+
+	want := []string{"default", "bilingual", "concise", "terse", "tutorial"}
+	if got := l.AnswerStyles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AnswerStyles() = %v, want %v", got, want)
+	}
+	if got := l.LoadAnswerStyle("terse"); got != "terse rules" {
+		t.Errorf("LoadAnswerStyle(terse) = %q", got)
+	}
+	if got := l.LoadAnswerStyle("concise"); !strings.Contains(got, "简洁工程师") || strings.Contains(got, "description:") {
+		t.Errorf("LoadAnswerStyle(concise) = %q, want profile without frontmatter", got)
+	}
+	def := l.Load("answer_style.md")
+	for _, name := range []string{"", "default", "missing", "../answer_style"} {
+		if got := l.LoadAnswerStyle(name); got != def {
+			t.Errorf("LoadAnswerStyle(%q) did not fall back to answer_style.md", name)
+		}
+	}
+	for name, want := range map[string]bool{"": true, "default": true, "concise": true, "missing": false, "a/b": false, "x.y": false} {
+		if got := l.HasAnswerStyle(name); got != want {
+			t.Errorf("HasAnswerStyle(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// templatesDir is the subdirectory of the prompts override directory that
+// holds task templates: prompts/templates/<name>.md. Unlike the L2 files they
+// are never part of the system prompt; they are expanded into user messages.
+const templatesDir = "templates"
+
+// templateVarPattern matches a placeholder: {{name}} or {{name|default}}.
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*(?:\|([^{}]*))?\}\}`)
+
+// ErrTemplateNotFound is returned by Template for an unknown name.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// Template is a saved task prompt with variable placeholders. The optional
+// frontmatter "description" is shown in listings.
+type Template struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Vars        []TemplateVar `json:"vars"`
+	Body        string        `json:"body"`
+}
+
+// TemplateVar is one placeholder of a template, in order of first use.
+type TemplateVar struct {
+	Name     string `json:"name"`
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required"` // no default value
+}
+
+// Templates returns the task templates under prompts/templates/, sorted by
+// name; nil without a prompts override directory.
+func (l *PromptLoader) Templates() []Template {
+	if l.promptsDir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(l.promptsDir, templatesDir))
+	if err != nil {
+		return nil
+	}
+	var templates []Template
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".md")
+		if e.IsDir() || !ok || !validTemplateName(name) {
+			continue
+		}
+		if t, err := l.Template(name); err == nil {
+			templates = append(templates, t)
+		}
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates
+}
+
+// Template reads the named task template.
+func (l *PromptLoader) Template(name string) (Template, error) {
+	if l.promptsDir == "" || !validTemplateName(name) {
+		return Template{}, ErrTemplateNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(l.promptsDir, templatesDir, name+".md"))
+	if errors.Is(err, os.ErrNotExist) {
+		return Template{}, ErrTemplateNotFound
+	}
+	if err != nil {
+		return Template{}, err
+	}
+	meta, body := SplitFrontmatter(string(data))
+	t := Template{Name: name, Description: meta["description"], Body: strings.TrimSpace(body)}
+	seen := make(map[string]int)
+	for _, m := range templateVarPattern.FindAllStringSubmatch(t.Body, -1) {
+		def, hasDefault := strings.TrimSpace(m[2]), strings.Contains(m[0], "|")
+		if i, ok := seen[m[1]]; ok {
+			// A later default fills a placeholder first used without one
+			if hasDefault && t.Vars[i].Required {
+				t.Vars[i].Default, t.Vars[i].Required = def, false
+			}
+			continue
+		}
+		seen[m[1]] = len(t.Vars)
+		t.Vars = append(t.Vars, TemplateVar{Name: m[1], Default: def, Required: !hasDefault})
+	}
+	return t, nil
+}
+
+// Expand substitutes vars into the template body. Placeholders without a
+// value take their default; a required one without a value is an error, as
+// is a value for a variable the template does not use.
+func (t Template) Expand(vars map[string]string) (string, error) {
+	known := make(map[string]TemplateVar, len(t.Vars))
+	var missing []string
+	for _, v := range t.Vars {
+		known[v.Name] = v
+		if _, ok := vars[v.Name]; !ok && v.Required {
+			missing = append(missing, v.Name)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing variables: %s", strings.Join(missing, ", "))
+	}
+	for name := range vars {
+		if _, ok := known[name]; !ok {
+			return "", fmt.Errorf("unknown variable %q", name)
+		}
+	}
+	return templateVarPattern.ReplaceAllStringFunc(t.Body, func(p string) string {
+		name := templateVarPattern.FindStringSubmatch(p)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		return known[name].Default
+	}), nil
+}
+
+// validTemplateName keeps template names to plain file names.
+func validTemplateName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, `/\.`) && !strings.HasPrefix(name, "-")
+}
@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, promptsDir, name, content string) {
+	t.Helper()
+	dir := filepath.Join(promptsDir, templatesDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "review.md", "---\ndescription: 代码评审\n---\n评审 {{ path }} 的改动，关注 {{focus|安全}}。再看一遍 {{path}}。\n")
+	writeTemplate(t, dir, "bump.md", "把 {{dep}} 升级到 {{version|latest}}")
+	writeTemplate(t, dir, "notes.txt", "ignored")
+	writeTemplate(t, dir, ".hidden.md", "ignored")
+	l := NewPromptLoader(dir, "", "")
+
+	templates := l.Templates()
+	var names []string
+	for _, tp := range templates {
+		names = append(names, tp.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"bump", "review"}) {
+		t.Fatalf("Templates() names = %v", names)
+	}
+	review := templates[1]
+	if review.Description != "代码评审" || strings.Contains(review.Body, "description:") {
+		t.Errorf("review = %+v", review)
+	}
+	wantVars := []TemplateVar{{Name: "path", Required: true}, {Name: "focus", Default: "安全"}}
+	if !reflect.DeepEqual(review.Vars, wantVars) {
+		t.Errorf("review.Vars = %+v, want %+v", review.Vars, wantVars)
+	}
+
+	got, err := review.Expand(map[string]string{"path": "api/"})
+	if err != nil || got != "评审 api/ 的改动，关注 安全。再看一遍 api/。" {
+		t.Errorf("Expand = %q, %v", got, err)
+	}
+	if got, _ := review.Expand(map[string]string{"path": "x", "focus": ""}); !strings.Contains(got, "关注 。") {
+		t.Errorf("an explicit empty value should override the default: %q", got)
+	}
+	if _, err := review.Expand(nil); err == nil || !strings.Contains(err.Error(), "path") {
+		t.Errorf("missing variable error = %v", err)
+	}
+	if _, err := review.Expand(map[string]string{"path": "x", "typo": "y"}); err == nil || !strings.Contains(err.Error(), "typo") {
+		t.Errorf("unknown variable error = %v", err)
+	}
+}
+
+func TestTemplate_NotFound(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "a.md", "x")
+	l := NewPromptLoader(dir, "", "")
+	for _, name := range []string{"missing", "../a", "a.md", ""} {
+		if _, err := l.Template(name); !errors.Is(err, ErrTemplateNotFound) {
+			t.Errorf("Template(%q) err = %v, want ErrTemplateNotFound", name, err)
+		}
+	}
+	if got := NewPromptLoader("", "", "").Templates(); got != nil {
+		t.Errorf("Templates() without a prompts dir = %v", got)
+	}
+}
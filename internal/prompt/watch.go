@@ -0,0 +1,162 @@
+package prompt
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce coalesces the burst of events an editor save produces
+// (truncate + write, or write temp + rename) into one Reload.
+const watchDebounce = 200 * time.Millisecond
+
+// Watch starts reloading the cache automatically when a prompts/*.md file,
+// the rules file, the soul file or the project brief changes. Changed file names are recorded
+// for TakeHotReloaded. Call the returned stop function to end watching.
+//
+// Directories are watched non-recursively, so editors that save via
+// rename and files created after Watch are both picked up.
+func (l *PromptLoader) Watch() (stop func() error, err error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("prompt watch: %w", err)
+	}
+
+	dirs := make(map[string]bool)
+	if l.promptsDir != "" {
+		dirs[filepath.Clean(l.promptsDir)] = true
+	}
+	for _, p := range []string{l.rulesPath, l.soulPath, l.BriefPath()} {
+		if p != "" {
+			dirs[filepath.Dir(filepath.Clean(p))] = true
+		}
+	}
+	watched := 0
+	for dir := range dirs {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue // e.g. no prompts/ override directory — nothing to watch
+		}
+		if err := fsw.Add(dir); err != nil {
+			fsw.Close()
+			return nil, fmt.Errorf("prompt watch %s: %w", dir, err)
+		}
+		watched++
+	}
+	if watched == 0 {
+		fsw.Close()
+		return nil, fmt.Errorf("prompt watch: no existing directory to watch")
+	}
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		pending := make(map[string]bool)
+		var timer *time.Timer
+		var fire <-chan time.Time
+		for {
+			select {
+			case <-done:
+				return
+			case ev, ok := <-fsw.Events:
+				if !ok {
+					return
+				}
+				name := l.watchedName(ev.Name)
+				if name == "" || ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Write) {
+					continue
+				}
+				pending[name] = true
+				if timer == nil {
+					timer = time.NewTimer(watchDebounce)
+				} else {
+					timer.Reset(watchDebounce)
+				}
+				fire = timer.C
+			case err, ok := <-fsw.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("[Prompt] Watch error: %v", err)
+			case <-fire:
+				fire = nil
+				names := make([]string, 0, len(pending))
+				for n := range pending {
+					names = append(names, n)
+				}
+				pending = make(map[string]bool)
+				l.hotReload(names)
+			}
+		}
+	}()
+
+	return func() error {
+		select {
+		case <-done:
+			return nil
+		default:
+		}
+		close(done)
+		err := fsw.Close()
+		<-exited
+		return err
+	}, nil
+}
+
+// watchedName maps an event path to the name reported by TakeHotReloaded
+// ("decide_common.md", "rules.md", "soul.md", "PROJECT_BRIEF.md"), or ""
+// for unrelated files.
+func (l *PromptLoader) watchedName(path string) string {
+	path = filepath.Clean(path)
+	if l.rulesPath != "" && path == filepath.Clean(l.rulesPath) {
+		return filepath.Base(path)
+	}
+	if l.soulPath != "" && path == filepath.Clean(l.soulPath) {
+		return filepath.Base(path)
+	}
+	if brief := l.BriefPath(); brief != "" && path == filepath.Clean(brief) {
+		return filepath.Base(path)
+	}
+	if l.promptsDir != "" && filepath.Dir(path) == filepath.Clean(l.promptsDir) &&
+		strings.HasSuffix(path, ".md") {
+		return filepath.Base(path)
+	}
+	return ""
+}
+
+// hotReload invalidates the cache and records names for the next TakeHotReloaded.
+func (l *PromptLoader) hotReload(names []string) {
+	l.Reload()
+	l.mu.Lock()
+	if l.hotReloaded == nil {
+		l.hotReloaded = make(map[string]bool)
+	}
+	for _, n := range names {
+		l.hotReloaded[n] = true
+	}
+	l.mu.Unlock()
+	log.Printf("[Prompt] Hot-reloaded after change: %s", strings.Join(names, ", "))
+}
+
+// TakeHotReloaded returns the files hot-reloaded by Watch since the last
+// call (sorted) and clears the list. Manual Reload calls are not recorded.
+func (l *PromptLoader) TakeHotReloaded() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.hotReloaded) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(l.hotReloaded))
+	for n := range l.hotReloaded {
+		names = append(names, n)
+	}
+	l.hotReloaded = nil
+	sort.Strings(names)
+	return names
+}
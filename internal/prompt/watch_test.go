@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds or the deadline passes.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
+
+func TestWatch_ReloadsChangedPrompts(t *testing.T) {
+	ws := t.TempDir()
+	promptsDir := filepath.Join(ws, "prompts")
+	os.Mkdir(promptsDir, 0o755)
+	os.WriteFile(filepath.Join(promptsDir, "answer_style.md"), []byte("v1"), 0o644)
+	rulesPath := filepath.Join(ws, "rules.md")
+
+	l := NewPromptLoader(promptsDir, rulesPath, filepath.Join(ws, "soul.md"))
+	l.PatchFile("knowledge.md", "{{OS}}", "plan9")
+	stop, err := l.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	if got := l.Load("answer_style.md"); got != "v1" {
+		t.Fatalf("Load = %q", got)
+	}
+	os.WriteFile(filepath.Join(promptsDir, "answer_style.md"), []byte("v2"), 0o644)
+	os.WriteFile(rulesPath, []byte("be brief"), 0o644)
+	os.WriteFile(filepath.Join(ws, "notes.txt"), []byte("unrelated"), 0o644)
+
+	waitFor(t, func() bool { return l.Load("answer_style.md") == "v2" && l.LoadUserRules() == "be brief" })
+	var changed []string
+	waitFor(t, func() bool {
+		changed = append(changed, l.TakeHotReloaded()...)
+		return len(changed) >= 2
+	})
+	sort.Strings(changed)
+	if !reflect.DeepEqual(changed, []string{"answer_style.md", "rules.md"}) {
+		t.Errorf("TakeHotReloaded = %v", changed)
+	}
+	if again := l.TakeHotReloaded(); again != nil {
+		t.Errorf("second TakeHotReloaded = %v, want nil", again)
+	}
+	if got := l.Load("knowledge.md"); strings.Contains(got, "{{OS}}") {
+		t.Error("patches must survive a hot-reload")
+	}
+}
+
+func TestWatch_ManualReloadNotRecorded(t *testing.T) {
+	l := NewPromptLoader(t.TempDir(), "", "")
+	l.Reload()
+	if got := l.TakeHotReloaded(); got != nil {
+		t.Errorf("TakeHotReloaded after manual Reload = %v", got)
+	}
+}
+
+func TestWatch_NoDirectory(t *testing.T) {
+	l := NewPromptLoader(filepath.Join(t.TempDir(), "missing"), "", "")
+	if _, err := l.Watch(); err == nil {
+		t.Error("Watch without any existing directory should fail")
+	}
+}
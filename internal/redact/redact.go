@@ -0,0 +1,159 @@
+// Package redact masks secrets in text that leaves the agent: the values of
+// credential environment variables (API keys from .env) and common token
+// formats found in tool output. It is applied to the execution log, SSE
+// events and the step history the model sees.
+package redact
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Mask replaces a secret that matched a token pattern.
+const Mask = "[REDACTED]"
+
+// minSecretLen is the shortest env value treated as a secret; shorter
+// values ("true", port numbers) would mask ordinary text.
+const minSecretLen = 8
+
+// secretNameWords mark an environment variable as a credential when they
+// appear as a "_"-separated word of its name (LLM_API_KEY, GITHUB_TOKEN).
+var secretNameWords = map[string]bool{
+	"KEY": true, "APIKEY": true, "TOKEN": true, "SECRET": true,
+	"PASSWORD": true, "PASSWD": true, "CREDENTIAL": true, "CREDENTIALS": true,
+	"AUTH": true,
+}
+
+// tokenPatterns match well-known credential formats regardless of where
+// they come from.
+var tokenPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),                                            // OpenAI / Anthropic / DeepSeek
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{30,}`),                                       // GitHub tokens
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{30,}`),                                     // GitHub fine-grained PAT
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),                                    // Slack
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),                                               // AWS access key ID
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),                                            // Google API key
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), // JWT
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+}
+
+// bearerPattern keeps the scheme and masks the credential of an
+// Authorization header value.
+var bearerPattern = regexp.MustCompile(`(?i)\b(bearer)(\s+)[A-Za-z0-9._~+/=-]{16,}`)
+
+// assignmentPattern matches "api_key: value" / "password=value" style
+// assignments; the value is masked only if it looks like a secret, so code
+// such as `apiKey = os.Getenv("X")` stays readable.
+var assignmentPattern = regexp.MustCompile(`(?i)\b([A-Za-z0-9_-]*(?:api[_-]?key|secret|token|password|passwd))(["']?\s*[:=]\s*["']?)([A-Za-z0-9._~+/=-]{12,})`)
+
+// Redactor masks a fixed set of secret values and the token patterns.
+// A nil *Redactor leaves text unchanged.
+type Redactor struct {
+	secrets []secret // longest first, so a secret containing another wins
+}
+
+type secret struct {
+	name, value string
+}
+
+// New returns a Redactor for the credential variables in environ
+// ("NAME=value" entries, as from os.Environ).
+func New(environ []string) *Redactor {
+	r := &Redactor{}
+	seen := make(map[string]bool)
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		value = strings.TrimSpace(value)
+		if !ok || !isSecretName(name) || !isSecretValue(value) || seen[value] {
+			continue
+		}
+		seen[value] = true
+		r.secrets = append(r.secrets, secret{name: name, value: value})
+	}
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i].value) > len(r.secrets[j].value)
+	})
+	return r
+}
+
+func isSecretName(name string) bool {
+	for _, word := range strings.Split(strings.ToUpper(name), "_") {
+		if secretNameWords[word] {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretValue rejects values that are too short or are paths (e.g.
+// SSH_AUTH_SOCK), which are configuration rather than credentials.
+func isSecretValue(v string) bool {
+	if len(v) < minSecretLen || strings.ContainsAny(v, " \t\n") {
+		return false
+	}
+	return !strings.HasPrefix(v, "/") && !strings.HasPrefix(v, ".") && !(len(v) > 2 && v[1] == ':' && (v[2] == '\\' || v[2] == '/'))
+}
+
+// String returns s with every known secret value replaced by
+// "[REDACTED:NAME]" and every token pattern match by Mask.
+func (r *Redactor) String(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, sec := range r.secrets {
+		if strings.Contains(s, sec.value) {
+			s = strings.ReplaceAll(s, sec.value, "[REDACTED:"+sec.name+"]")
+		}
+	}
+	for _, re := range tokenPatterns {
+		s = re.ReplaceAllString(s, Mask)
+	}
+	s = bearerPattern.ReplaceAllString(s, "${1}${2}"+Mask)
+	return assignmentPattern.ReplaceAllStringFunc(s, func(m string) string {
+		sub := assignmentPattern.FindStringSubmatch(m)
+		if !looksRandom(sub[3]) {
+			return m
+		}
+		return sub[1] + sub[2] + Mask
+	})
+}
+
+// looksRandom reports whether v mixes letters and digits, as generated
+// keys do and identifiers and prose mostly do not.
+func looksRandom(v string) bool {
+	var letter, digit bool
+	for _, r := range v {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return letter && digit
+}
+
+var (
+	defaultOnce sync.Once
+	defaultR    *Redactor
+)
+
+// Default returns the process-wide Redactor, built from the environment on
+// first use (after .env has been loaded). REDACT_SECRETS=false disables
+// redaction; Default then returns nil.
+func Default() *Redactor {
+	defaultOnce.Do(func() {
+		if os.Getenv("REDACT_SECRETS") == "false" {
+			log.Printf("[Config] REDACT_SECRETS=false: secrets are not masked in logs and step history")
+			return
+		}
+		defaultR = New(os.Environ())
+	})
+	return defaultR
+}
+
+// String redacts s with the Default Redactor.
+func String(s string) string {
+	return Default().String(s)
+}
@@ -0,0 +1,133 @@
+// Package sandbox provides an optional container execution backend for
+// shell commands and stdio skills. Commands are wrapped in a `docker run`
+// (or `podman run`) invocation that bind-mounts the workspace directory,
+// applies CPU/memory limits and disables networking by default.
+package sandbox
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Defaults for container execution. Overridable via TOOL_SANDBOX_* env vars.
+const (
+	DefaultImage   = "alpine:3"
+	DefaultCPUs    = "1"
+	DefaultMemory  = "512m"
+	DefaultNetwork = "none"
+)
+
+// Container describes how commands are run inside a container.
+// The workspace is mounted at the same absolute path as on the host so that
+// paths produced by file tools remain valid inside the container.
+type Container struct {
+	Runtime      string // container CLI: "docker" or "podman"
+	Image        string // image to run, e.g. "alpine:3"
+	CPUs         string // --cpus value, e.g. "1"; empty = no limit
+	Memory       string // --memory value, e.g. "512m"; empty = no limit
+	Network      string // --network value; "none" disables networking
+	WorkspaceDir string // host directory bind-mounted read-write
+}
+
+// LoadFromEnv returns a Container configured from the environment, or nil
+// when TOOL_SHELL_SANDBOX is not "container".
+//
+//	TOOL_SHELL_SANDBOX=container   enable the container backend
+//	TOOL_SANDBOX_RUNTIME           docker | podman (default: first found in PATH)
+//	TOOL_SANDBOX_IMAGE             image (default: alpine:3)
+//	TOOL_SANDBOX_CPUS              --cpus (default: 1)
+//	TOOL_SANDBOX_MEMORY            --memory (default: 512m)
+//	TOOL_SANDBOX_NETWORK           --network (default: none)
+func LoadFromEnv(workspaceDir string) (*Container, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("TOOL_SHELL_SANDBOX")))
+	switch mode {
+	case "", "none", "host":
+		return nil, nil
+	case "container":
+	default:
+		return nil, fmt.Errorf("sandbox: unknown TOOL_SHELL_SANDBOX=%q (supported: container)", mode)
+	}
+
+	runtime := strings.TrimSpace(os.Getenv("TOOL_SANDBOX_RUNTIME"))
+	if runtime == "" {
+		runtime = detectRuntime()
+	}
+	if runtime == "" {
+		return nil, fmt.Errorf("sandbox: no container runtime found (install docker or podman, or set TOOL_SANDBOX_RUNTIME)")
+	}
+	if runtime != "docker" && runtime != "podman" {
+		log.Printf("[Config] WARNING: TOOL_SANDBOX_RUNTIME=%q is neither docker nor podman; assuming a compatible CLI", runtime)
+	}
+
+	abs, err := filepath.Abs(workspaceDir)
+	if err != nil {
+		return nil, fmt.Errorf("sandbox: resolve workspace: %w", err)
+	}
+
+	return &Container{
+		Runtime:      runtime,
+		Image:        envOr("TOOL_SANDBOX_IMAGE", DefaultImage),
+		CPUs:         envOr("TOOL_SANDBOX_CPUS", DefaultCPUs),
+		Memory:       envOr("TOOL_SANDBOX_MEMORY", DefaultMemory),
+		Network:      envOr("TOOL_SANDBOX_NETWORK", DefaultNetwork),
+		WorkspaceDir: abs,
+	}, nil
+}
+
+// Wrap returns the runtime executable and argv that run command with args
+// inside the container. env entries ("KEY=VALUE") are forwarded with -e;
+// the host environment is never inherited by the container.
+// interactive keeps stdin open (-i), required for stdio MCP servers.
+func (c *Container) Wrap(command string, args, env []string, interactive bool) (string, []string) {
+	argv := []string{"run", "--rm"}
+	if interactive {
+		argv = append(argv, "-i")
+	}
+	if c.Network != "" {
+		argv = append(argv, "--network", c.Network)
+	}
+	if c.CPUs != "" {
+		argv = append(argv, "--cpus", c.CPUs)
+	}
+	if c.Memory != "" {
+		argv = append(argv, "--memory", c.Memory)
+	}
+	if c.WorkspaceDir != "" {
+		argv = append(argv,
+			"-v", c.WorkspaceDir+":"+c.WorkspaceDir,
+			"-w", c.WorkspaceDir,
+		)
+	}
+	for _, e := range env {
+		argv = append(argv, "-e", e)
+	}
+	argv = append(argv, c.Image, command)
+	argv = append(argv, args...)
+	return c.Runtime, argv
+}
+
+// String returns a short human-readable description for startup logs.
+func (c *Container) String() string {
+	return fmt.Sprintf("%s image=%s cpus=%s memory=%s network=%s", c.Runtime, c.Image, c.CPUs, c.Memory, c.Network)
+}
+
+// detectRuntime returns the first container CLI found in PATH.
+func detectRuntime() string {
+	for _, name := range []string{"docker", "podman"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+func envOr(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
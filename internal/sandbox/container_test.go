@@ -0,0 +1,78 @@
+package sandbox
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrap_DefaultFlags(t *testing.T) {
+	c := &Container{
+		Runtime:      "docker",
+		Image:        "alpine:3",
+		CPUs:         "1",
+		Memory:       "512m",
+		Network:      "none",
+		WorkspaceDir: "/work",
+	}
+	name, argv := c.Wrap("sh", []string{"-c", "ls -la"}, nil, false)
+	if name != "docker" {
+		t.Errorf("runtime = %q, want docker", name)
+	}
+	got := strings.Join(argv, " ")
+	want := "run --rm --network none --cpus 1 --memory 512m -v /work:/work -w /work alpine:3 sh -c ls -la"
+	if got != want {
+		t.Errorf("argv =\n  %s\nwant\n  %s", got, want)
+	}
+}
+
+func TestWrap_InteractiveWithEnv(t *testing.T) {
+	c := &Container{Runtime: "podman", Image: "python:3.12-slim"}
+	_, argv := c.Wrap("python", []string{"skill.py"}, []string{"API_BASE=http://x"}, true)
+	got := strings.Join(argv, " ")
+	if !strings.HasPrefix(got, "run --rm -i ") {
+		t.Errorf("expected interactive flag, got %q", got)
+	}
+	if !strings.Contains(got, "-e API_BASE=http://x python:3.12-slim python skill.py") {
+		t.Errorf("env/image/command order wrong: %q", got)
+	}
+	// Empty limits must not emit flags
+	if strings.Contains(got, "--cpus") || strings.Contains(got, "--memory") || strings.Contains(got, "--network") {
+		t.Errorf("unexpected limit flags: %q", got)
+	}
+}
+
+func TestLoadFromEnv_Disabled(t *testing.T) {
+	for _, v := range []string{"", "none", "host"} {
+		t.Setenv("TOOL_SHELL_SANDBOX", v)
+		c, err := LoadFromEnv(t.TempDir())
+		if err != nil || c != nil {
+			t.Errorf("TOOL_SHELL_SANDBOX=%q: got %v, %v; want nil, nil", v, c, err)
+		}
+	}
+}
+
+func TestLoadFromEnv_Unknown(t *testing.T) {
+	t.Setenv("TOOL_SHELL_SANDBOX", "vm")
+	if _, err := LoadFromEnv(t.TempDir()); err == nil {
+		t.Error("expected error for unknown sandbox mode")
+	}
+}
+
+func TestLoadFromEnv_Container(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TOOL_SHELL_SANDBOX", "container")
+	t.Setenv("TOOL_SANDBOX_RUNTIME", "podman")
+	t.Setenv("TOOL_SANDBOX_IMAGE", "")
+	t.Setenv("TOOL_SANDBOX_MEMORY", "1g")
+	t.Setenv("TOOL_SANDBOX_CPUS", "")
+	t.Setenv("TOOL_SANDBOX_NETWORK", "")
+
+	c, err := LoadFromEnv(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Runtime != "podman" || c.Image != DefaultImage || c.Memory != "1g" ||
+		c.CPUs != DefaultCPUs || c.Network != DefaultNetwork || c.WorkspaceDir != dir {
+		t.Errorf("unexpected config: %+v", c)
+	}
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"sort"
 	"sync"
 	"time"
 )
@@ -18,6 +19,7 @@ type Turn struct {
 // Session holds all state for a single browser tab session.
 type Session struct {
 	ID       string
+	Title    string // short name for session lists; "" until set (see OnNewSession)
 	History  []Turn
 	Summary  string // compact summary of older turns (accumulated across multiple /compact calls)
 	LastUsed time.Time
@@ -32,6 +34,7 @@ type Store struct {
 	ttl      time.Duration // inactivity TTL, e.g. 30 minutes
 	maxTurns int           // max turns retained per session, e.g. 10
 	done     chan struct{} // closed by Close() to stop the cleanup goroutine
+	onNew    func(id, firstMsg string)
 }
 
 // NewStore creates a new Store with the given TTL and maxTurns limit.
@@ -51,6 +54,19 @@ func NewStore(ttl time.Duration, maxTurns int) *Store {
 	return s
 }
 
+// OnNewSession registers fn to be called, in its own goroutine, with the
+// ID and first user message of each session AppendTurn creates — e.g. to
+// give it a title. Call before the store is used.
+func (s *Store) OnNewSession(fn func(id, firstMsg string)) {
+	s.onNew = fn
+}
+
+// TTL returns the inactivity timeout after which sessions are evicted.
+func (s *Store) TTL() time.Duration { return s.ttl }
+
+// MaxTurns returns the number of turns retained per session.
+func (s *Store) MaxTurns() int { return s.maxTurns }
+
 // AppendTurn adds a completed exchange to the session, enforcing maxTurns.
 // If the session does not yet exist it is created automatically, so callers
 // do not need to call GetOrCreate separately before the first AppendTurn.
@@ -62,6 +78,9 @@ func (s *Store) AppendTurn(id string, turn Turn) {
 		// Auto-create on first write so the initial turn is never silently dropped.
 		sess = &Session{ID: id, LastUsed: time.Now()}
 		s.sessions[id] = sess
+		if s.onNew != nil {
+			go s.onNew(id, turn.UserMsg)
+		}
 	}
 	sess.History = append(sess.History, turn)
 	// Trim oldest turns to stay within maxTurns
@@ -102,6 +121,50 @@ func (s *Store) Compact(id string, summary string, keepN int) (compacted int) {
 	return compacted
 }
 
+// Fork copies the turns and summary of session id into a new session
+// newID, replacing any session already stored under newID. Returns the
+// number of turns copied and false when id does not exist.
+func (s *Store) Fork(id, newID string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return 0, false
+	}
+	s.sessions[newID] = &Session{
+		ID:       newID,
+		Title:    sess.Title,
+		History:  append([]Turn(nil), sess.History...),
+		Summary:  sess.Summary,
+		LastUsed: time.Now(),
+	}
+	return len(sess.History), true
+}
+
+// SetTitle names session id; false when it does not exist.
+func (s *Store) SetTitle(id, title string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if ok {
+		sess.Title = title
+	}
+	return ok
+}
+
+// Get returns a copy of session id.
+func (s *Store) Get(id string) (Session, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	cp := *sess
+	cp.History = append([]Turn(nil), sess.History...)
+	return cp, true
+}
+
 // Delete explicitly removes a session (e.g., user clicks "Clear Chat").
 func (s *Store) Delete(id string) {
 	s.mu.Lock()
@@ -116,6 +179,33 @@ func (s *Store) Count() int {
 	return len(s.sessions)
 }
 
+// Info summarizes a session for listings.
+type Info struct {
+	ID         string
+	Title      string
+	Turns      int
+	HasSummary bool
+	LastUsed   time.Time
+}
+
+// List returns all active sessions, most recently used first.
+func (s *Store) List() []Info {
+	s.mu.RLock()
+	infos := make([]Info, 0, len(s.sessions))
+	for _, sess := range s.sessions {
+		infos = append(infos, Info{
+			ID:         sess.ID,
+			Title:      sess.Title,
+			Turns:      len(sess.History),
+			HasSummary: sess.Summary != "",
+			LastUsed:   sess.LastUsed,
+		})
+	}
+	s.mu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].LastUsed.After(infos[j].LastUsed) })
+	return infos
+}
+
 // Close stops the background cleanup goroutine. Safe to call multiple times.
 func (s *Store) Close() {
 	select {
@@ -206,3 +206,91 @@ func TestGetSessionContext_Unknown(t *testing.T) {
 		t.Errorf("expected empty summary, got %q", summary)
 	}
 }
+
+func TestList_MostRecentFirst(t *testing.T) {
+	s := NewStore(time.Minute, 10)
+	defer s.Close()
+	s.AppendTurn("a", Turn{UserMsg: "q1", Assistant: "a1"})
+	s.AppendTurn("a", Turn{UserMsg: "q2", Assistant: "a2"})
+	time.Sleep(2 * time.Millisecond)
+	s.AppendTurn("b", Turn{UserMsg: "q", Assistant: "a"})
+	s.Compact("a", "summary", 1)
+
+	infos := s.List()
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(infos))
+	}
+	if infos[0].ID != "a" || infos[1].ID != "b" {
+		t.Errorf("order = %s, %s; want a (compacted last), b", infos[0].ID, infos[1].ID)
+	}
+	if infos[0].Turns != 1 || !infos[0].HasSummary {
+		t.Errorf("session a = %+v, want 1 turn with summary", infos[0])
+	}
+	if infos[1].Turns != 1 || infos[1].HasSummary {
+		t.Errorf("session b = %+v, want 1 turn without summary", infos[1])
+	}
+}
+
+func TestFork(t *testing.T) {
+	s := NewStore(time.Minute, 10)
+	if _, ok := s.Fork("missing", "b"); ok {
+		t.Error("Fork of an unknown session should fail")
+	}
+	s.AppendTurn("a", Turn{UserMsg: "q1", Assistant: "a1"})
+	s.AppendTurn("a", Turn{UserMsg: "q2", Assistant: "a2"})
+	s.Compact("a", "summary", 1)
+
+	if n, ok := s.Fork("a", "b"); !ok || n != 1 {
+		t.Fatalf("Fork = %d, %v", n, ok)
+	}
+	s.AppendTurn("b", Turn{UserMsg: "approach B"})
+
+	orig, origSummary := s.GetSessionContext("a")
+	fork, forkSummary := s.GetSessionContext("b")
+	if len(orig) != 1 || len(fork) != 2 || fork[1].UserMsg != "approach B" {
+		t.Errorf("original %d turns, fork %d turns: the fork must not share history", len(orig), len(fork))
+	}
+	if origSummary != "summary" || forkSummary != "summary" {
+		t.Errorf("summaries = %q, %q", origSummary, forkSummary)
+	}
+}
+
+func TestOnNewSession_AndTitles(t *testing.T) {
+	s := NewStore(time.Minute, 10)
+	defer s.Close()
+	created := make(chan [2]string, 4)
+	s.OnNewSession(func(id, firstMsg string) { created <- [2]string{id, firstMsg} })
+
+	if s.SetTitle("a", "x") {
+		t.Error("SetTitle of an unknown session should fail")
+	}
+	s.AppendTurn("a", Turn{UserMsg: "deploy the app"})
+	s.AppendTurn("a", Turn{UserMsg: "again"})
+	select {
+	case got := <-created:
+		if got != [2]string{"a", "deploy the app"} {
+			t.Errorf("OnNewSession got %v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnNewSession was not called")
+	}
+	select {
+	case got := <-created:
+		t.Errorf("OnNewSession called again for an existing session: %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if !s.SetTitle("a", "Deploy") {
+		t.Fatal("SetTitle failed")
+	}
+	s.Fork("a", "b")
+	if sess, ok := s.Get("b"); !ok || sess.Title != "Deploy" || len(sess.History) != 2 {
+		t.Errorf("Get(fork) = %+v, %v", sess, ok)
+	}
+	if infos := s.List(); len(infos) != 2 || infos[0].Title != "Deploy" {
+		t.Errorf("List = %+v", infos)
+	}
+	if _, ok := s.Get("missing"); ok {
+		t.Error("Get of an unknown session should fail")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package storage
+
+import "errors"
+
+func diskSpace(path string) (free, total uint64, err error) {
+	return 0, 0, errors.New("storage: disk space not supported on this platform")
+}
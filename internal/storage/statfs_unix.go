@@ -0,0 +1,16 @@
+//go:build unix
+
+package storage
+
+import "syscall"
+
+// diskSpace returns the bytes available to unprivileged users and the
+// total size of the volume holding path.
+func diskSpace(path string) (free, total uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, err
+	}
+	bsize := uint64(st.Bsize)
+	return uint64(st.Bavail) * bsize, uint64(st.Blocks) * bsize, nil
+}
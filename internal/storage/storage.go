@@ -0,0 +1,326 @@
+// Package storage keeps the files the agent accumulates in the workspace
+// (run replays, archived tool outputs, exploration patches, migration
+// backups, …) within per-category quotas and watches the free space of the
+// workspace volume, so a long-running instance neither fills its disk nor
+// fails mid-run on a write.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Category is one directory of generated files. Its top-level entries
+// (files or directories) are the unit of garbage collection.
+type Category struct {
+	Name  string
+	Dir   string
+	Quota int64 // bytes; 0 = reported only, never collected
+}
+
+// CategoryUsage is the disk usage of one category.
+type CategoryUsage struct {
+	Name      string `json:"name"`
+	Dir       string `json:"dir"`
+	Bytes     int64  `json:"bytes"`
+	Entries   int    `json:"entries"`
+	Quota     int64  `json:"quota,omitempty"`
+	OverQuota bool   `json:"over_quota,omitempty"`
+}
+
+// DiskUsage is the capacity of the volume holding the workspace.
+type DiskUsage struct {
+	Free  uint64 `json:"free"` // available to this process
+	Total uint64 `json:"total"`
+}
+
+// GCResult summarizes one garbage collection pass.
+type GCResult struct {
+	Time    time.Time `json:"time"`
+	Removed int       `json:"removed"`
+	Freed   int64     `json:"freed"`
+}
+
+// Report is the storage overview served by /api/storage.
+type Report struct {
+	Disk       *DiskUsage      `json:"disk,omitempty"` // nil when the platform cannot tell
+	MinFree    int64           `json:"min_free"`
+	LowSpace   bool            `json:"low_space"`
+	Categories []CategoryUsage `json:"categories"`
+	LastGC     *GCResult       `json:"last_gc,omitempty"`
+}
+
+// Manager reports and collects the storage categories of one workspace.
+// Safe for concurrent use.
+type Manager struct {
+	root       string
+	categories []Category
+	minFree    int64
+
+	mu     sync.Mutex // serializes GC passes
+	lastGC *GCResult
+}
+
+// New creates a manager for the categories under the workspace root. A
+// low-space warning is raised when the volume of root has less than
+// minFree bytes available (0 disables it).
+func New(root string, categories []Category, minFree int64) *Manager {
+	return &Manager{root: root, categories: categories, minFree: minFree}
+}
+
+// MinFree returns the free-space minimum in bytes (0 = no check).
+func (m *Manager) MinFree() int64 { return m.minFree }
+
+// Categories returns the managed categories.
+func (m *Manager) Categories() []Category { return m.categories }
+
+// DefaultCategories returns the generated-file directories of a workspace:
+// run replays and other logs, archived tool outputs, exploration patches,
+// migration backups, run checkpoints and archived walkthrough memos.
+// quotas maps category names to byte limits; unlisted categories are
+// reported only.
+func DefaultCategories(workspaceDir string, quotas map[string]int64) []Category {
+	meta := filepath.Join(workspaceDir, ".omega")
+	cats := []Category{
+		{Name: "replay", Dir: filepath.Join(workspaceDir, "logs", "replay")},
+		{Name: "logs", Dir: filepath.Join(workspaceDir, "logs")},
+		{Name: "tool_outputs", Dir: filepath.Join(meta, "tool_outputs")},
+		{Name: "explore", Dir: filepath.Join(meta, "explore")},
+		{Name: "backups", Dir: filepath.Join(meta, "backups")},
+		{Name: "checkpoints", Dir: filepath.Join(meta, "checkpoints")},
+		{Name: "walkthroughs", Dir: filepath.Join(meta, "walkthroughs")},
+		{Name: "llm_cache", Dir: filepath.Join(meta, "llm_cache")},
+	}
+	for i := range cats {
+		cats[i].Quota = quotas[cats[i].Name]
+	}
+	return cats
+}
+
+// entry is one top-level entry of a category directory.
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time // newest modification below the entry
+}
+
+// scan lists the top-level entries of c. Directories of other categories
+// nested in c.Dir (logs/replay inside logs) belong to those categories and
+// are skipped.
+func (m *Manager) scan(c Category) ([]entry, error) {
+	dirents, err := os.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []entry
+	for _, d := range dirents {
+		path := filepath.Join(c.Dir, d.Name())
+		if m.isCategoryDir(path) {
+			continue
+		}
+		e := entry{path: path}
+		filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if !d.IsDir() {
+				e.size += info.Size()
+			}
+			if info.ModTime().After(e.modTime) {
+				e.modTime = info.ModTime()
+			}
+			return nil
+		})
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (m *Manager) isCategoryDir(path string) bool {
+	for _, c := range m.categories {
+		if filepath.Clean(c.Dir) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Report measures every category and the free space of the workspace volume.
+func (m *Manager) Report() Report {
+	r := Report{MinFree: m.minFree, Categories: make([]CategoryUsage, 0, len(m.categories))}
+	for _, c := range m.categories {
+		u := CategoryUsage{Name: c.Name, Dir: m.relative(c.Dir), Quota: c.Quota}
+		entries, err := m.scan(c)
+		if err != nil {
+			log.Printf("[Storage] scan %s: %v", c.Name, err)
+		}
+		for _, e := range entries {
+			u.Bytes += e.size
+		}
+		u.Entries = len(entries)
+		u.OverQuota = c.Quota > 0 && u.Bytes > c.Quota
+		r.Categories = append(r.Categories, u)
+	}
+	if free, total, err := diskSpace(m.root); err == nil {
+		r.Disk = &DiskUsage{Free: free, Total: total}
+		r.LowSpace = m.minFree > 0 && free < uint64(m.minFree)
+	}
+	m.mu.Lock()
+	if m.lastGC != nil {
+		gc := *m.lastGC
+		r.LastGC = &gc
+	}
+	m.mu.Unlock()
+	return r
+}
+
+func (m *Manager) relative(dir string) string {
+	if rel, err := filepath.Rel(m.root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return dir
+}
+
+// LowSpace reports whether the workspace volume is below the free-space
+// minimum, with the free byte count. False when unknown or disabled.
+func (m *Manager) LowSpace() (bool, uint64) {
+	if m.minFree <= 0 {
+		return false, 0
+	}
+	free, _, err := diskSpace(m.root)
+	if err != nil {
+		return false, 0
+	}
+	return free < uint64(m.minFree), free
+}
+
+// GC removes the least recently modified entries of every category over
+// its quota until it fits. The newest entry of a category is always kept:
+// it may still be written to (the replay of the running agent).
+func (m *Manager) GC() GCResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := GCResult{Time: time.Now()}
+	for _, c := range m.categories {
+		if c.Quota <= 0 {
+			continue
+		}
+		entries, err := m.scan(c)
+		if err != nil {
+			log.Printf("[Storage] scan %s: %v", c.Name, err)
+			continue
+		}
+		var total int64
+		for _, e := range entries {
+			total += e.size
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+		for _, e := range entries[:max(0, len(entries)-1)] {
+			if total <= c.Quota {
+				break
+			}
+			if err := os.RemoveAll(e.path); err != nil {
+				log.Printf("[Storage] remove %s: %v", e.path, err)
+				continue
+			}
+			total -= e.size
+			res.Removed++
+			res.Freed += e.size
+		}
+	}
+	m.lastGC = &res
+	return res
+}
+
+// Run collects garbage every interval until ctx is done, logging what was
+// freed and a warning while the volume is low on space.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if res := m.GC(); res.Removed > 0 {
+			log.Printf("[Storage] GC removed %d entries, freed %s", res.Removed, FormatSize(res.Freed))
+		}
+		if low, free := m.LowSpace(); low {
+			log.Printf("[Storage] ⚠️ workspace volume has only %s free (minimum %s)", FormatSize(int64(free)), FormatSize(m.minFree))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"T", 1 << 40},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// ParseSize parses a byte count with an optional binary unit suffix:
+// "512", "64KB", "200MB", "1.5GB" (case-insensitive).
+func ParseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	factor := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			v, factor = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("storage: invalid size %q", s)
+	}
+	return int64(n * float64(factor)), nil
+}
+
+// FormatSize renders n bytes with a binary unit, e.g. "1.5 GB".
+func FormatSize(n int64) string {
+	for _, u := range sizeUnits[:4] {
+		if n >= u.factor {
+			return fmt.Sprintf("%.1f %s", float64(n)/float64(u.factor), u.suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// ParseQuotas parses a comma-separated "category=size" list, e.g.
+// "replay=200MB,backups=1GB".
+func ParseQuotas(spec string) (map[string]int64, error) {
+	quotas := make(map[string]int64)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, size, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("storage: invalid quota %q (want category=size)", part)
+		}
+		n, err := ParseSize(size)
+		if err != nil {
+			return nil, err
+		}
+		quotas[strings.TrimSpace(name)] = n
+	}
+	return quotas, nil
+}
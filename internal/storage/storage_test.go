@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeAged creates path with size bytes, modified age ago.
+func writeAged(t *testing.T, path string, size int, age time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mt := time.Now().Add(-age)
+	if err := os.Chtimes(path, mt, mt); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestManager_GCRemovesOldestOverQuota(t *testing.T) {
+	ws := t.TempDir()
+	m := New(ws, DefaultCategories(ws, map[string]int64{"replay": 150, "tool_outputs": 100}), 0)
+	replay := filepath.Join(ws, "logs", "replay")
+	writeAged(t, filepath.Join(replay, "a.jsonl"), 100, 3*time.Hour)
+	writeAged(t, filepath.Join(replay, "b.jsonl"), 100, 2*time.Hour)
+	writeAged(t, filepath.Join(replay, "c.jsonl"), 100, time.Hour)
+	// a directory entry counts all its files and ages by its newest one
+	writeAged(t, filepath.Join(replay, "d", "old.jsonl"), 10, 5*time.Hour)
+	writeAged(t, filepath.Join(replay, "d", "new.jsonl"), 10, time.Minute)
+	// the newest entry survives even alone over quota
+	writeAged(t, filepath.Join(ws, ".omega", "tool_outputs", "big.txt"), 500, time.Hour)
+	// logs has no quota: never collected, and logs/replay is not counted twice
+	writeAged(t, filepath.Join(ws, "logs", "agent_exec.md"), 1000, 10*time.Hour)
+
+	res := m.GC()
+	if res.Removed != 2 || res.Freed != 200 {
+		t.Errorf("GC = %+v, want 2 entries / 200 bytes", res)
+	}
+	for name, want := range map[string]bool{
+		"logs/replay/a.jsonl":         false,
+		"logs/replay/b.jsonl":         false,
+		"logs/replay/c.jsonl":         true,
+		"logs/replay/d":               true,
+		".omega/tool_outputs/big.txt": true,
+		"logs/agent_exec.md":          true,
+	} {
+		if got := exists(filepath.Join(ws, name)); got != want {
+			t.Errorf("%s exists = %v, want %v", name, got, want)
+		}
+	}
+
+	r := m.Report()
+	usage := make(map[string]CategoryUsage)
+	for _, u := range r.Categories {
+		usage[u.Name] = u
+	}
+	if u := usage["replay"]; u.Bytes != 120 || u.Entries != 2 || u.OverQuota || u.Dir != "logs/replay" {
+		t.Errorf("replay usage = %+v", u)
+	}
+	if u := usage["logs"]; u.Bytes != 1000 || u.Entries != 1 {
+		t.Errorf("logs usage = %+v, want only agent_exec.md", u)
+	}
+	if u := usage["tool_outputs"]; !u.OverQuota {
+		t.Errorf("tool_outputs usage = %+v, want over quota", u)
+	}
+	if u := usage["backups"]; u.Bytes != 0 || u.Entries != 0 {
+		t.Errorf("missing backups dir usage = %+v", u)
+	}
+	if r.LastGC == nil || r.LastGC.Removed != 2 {
+		t.Errorf("LastGC = %+v", r.LastGC)
+	}
+}
+
+func TestManager_LowSpace(t *testing.T) {
+	ws := t.TempDir()
+	if low, _ := New(ws, nil, 0).LowSpace(); low {
+		t.Error("LowSpace with the check disabled")
+	}
+	r := New(ws, nil, 1<<62).Report()
+	if r.Disk == nil {
+		t.Skip("disk space not available on this platform")
+	}
+	if !r.LowSpace || r.Disk.Total == 0 {
+		t.Errorf("report = %+v, want low space", r)
+	}
+	if low, free := New(ws, nil, 1).LowSpace(); low || free == 0 {
+		t.Errorf("LowSpace(1 byte) = %v, %d", low, free)
+	}
+}
+
+func TestParseSizeAndQuotas(t *testing.T) {
+	for in, want := range map[string]int64{"512": 512, "64KB": 64 << 10, "200mb": 200 << 20, "1.5G": 3 << 29, " 2 GB ": 2 << 30} {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "MB", "-1MB", "ten"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("ParseSize(%q) accepted", bad)
+		}
+	}
+	q, err := ParseQuotas("replay=200MB, backups=1GB,")
+	if err != nil || q["replay"] != 200<<20 || q["backups"] != 1<<30 || len(q) != 2 {
+		t.Errorf("ParseQuotas = %v, %v", q, err)
+	}
+	if _, err := ParseQuotas("replay"); err == nil {
+		t.Error("quota without size accepted")
+	}
+	if got := FormatSize(3 << 29); got != "1.5 GB" {
+		t.Errorf("FormatSize = %q", got)
+	}
+}
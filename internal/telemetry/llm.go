@@ -0,0 +1,109 @@
+package telemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// tracedProvider wraps an LLMProvider with one client span per call.
+// Providers that know the real token usage (see openai.Client) add it to
+// the span found in ctx.
+type tracedProvider struct {
+	inner llm.LLMProvider
+	model string
+}
+
+// WrapLLM returns p instrumented with spans named "llm.<method>".
+func WrapLLM(p llm.LLMProvider, model string) llm.LLMProvider {
+	if p == nil {
+		return nil
+	}
+	return &tracedProvider{inner: p, model: model}
+}
+
+func (t *tracedProvider) start(ctx context.Context, method string, messages []llm.Message, tools int) (context.Context, trace.Span) {
+	promptChars := 0
+	for _, m := range messages {
+		promptChars += len(m.Content)
+	}
+	model := t.model
+	if m := llm.ModelFor(t.inner, llm.ModelRoleFrom(ctx)); m != "" {
+		model = m // routed: the model serving this call's role
+	}
+	return Tracer().Start(ctx, "llm."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.String("gen_ai.request.model", model),
+			attribute.Int("llm.messages", len(messages)),
+			attribute.Int("llm.prompt_chars", promptChars),
+			attribute.Int("llm.tools", tools),
+		))
+}
+
+func finish(span trace.Span, resp llm.Message, err error) {
+	if err == nil {
+		span.SetAttributes(
+			attribute.Int("llm.response_chars", len(resp.Content)),
+			attribute.Int("llm.tool_calls", len(resp.ToolCalls)),
+		)
+	}
+	End(span, err)
+}
+
+func (t *tracedProvider) CallLLM(ctx context.Context, messages []llm.Message) (llm.Message, error) {
+	ctx, span := t.start(ctx, "call", messages, 0)
+	resp, err := t.inner.CallLLM(ctx, messages)
+	finish(span, resp, err)
+	return resp, err
+}
+
+func (t *tracedProvider) CallLLMStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamCallback) (llm.Message, error) {
+	ctx, span := t.start(ctx, "stream", messages, 0)
+	first := true
+	wrapped := onChunk
+	if onChunk != nil {
+		wrapped = func(chunk string) {
+			if first {
+				first = false
+				span.AddEvent("first_token")
+			}
+			onChunk(chunk)
+		}
+	}
+	resp, err := t.inner.CallLLMStream(ctx, messages, wrapped)
+	finish(span, resp, err)
+	return resp, err
+}
+
+func (t *tracedProvider) CallLLMWithTools(ctx context.Context, messages []llm.Message, tools []llm.ToolDefinition) (llm.Message, error) {
+	ctx, span := t.start(ctx, "call_with_tools", messages, len(tools))
+	resp, err := t.inner.CallLLMWithTools(ctx, messages, tools)
+	finish(span, resp, err)
+	return resp, err
+}
+
+func (t *tracedProvider) IsToolCallingEnabled() bool { return t.inner.IsToolCallingEnabled() }
+func (t *tracedProvider) SupportsVision() bool       { return llm.SupportsVision(t.inner) }
+
+// ModelFor names the traced model unless the wrapped provider routes roles
+// to other models.
+func (t *tracedProvider) ModelFor(r llm.ModelRole) string {
+	if m := llm.ModelFor(t.inner, r); m != "" {
+		return m
+	}
+	return t.model
+}
+
+// RecordTokenUsage adds the provider-reported token counts to the LLM span
+// in ctx (no-op without one).
+func RecordTokenUsage(ctx context.Context, promptTokens, completionTokens int) {
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", promptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", completionTokens),
+	)
+}
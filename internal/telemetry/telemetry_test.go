@@ -0,0 +1,119 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// recordSpans installs an in-memory tracer provider for the test.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+func attrs(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range s.Attributes() {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+type stubProvider struct {
+	resp llm.Message
+	err  error
+}
+
+func (s *stubProvider) CallLLM(ctx context.Context, _ []llm.Message) (llm.Message, error) {
+	RecordTokenUsage(ctx, 120, 30)
+	return s.resp, s.err
+}
+func (s *stubProvider) CallLLMStream(_ context.Context, _ []llm.Message, onChunk llm.StreamCallback) (llm.Message, error) {
+	onChunk("a")
+	onChunk("b")
+	return s.resp, s.err
+}
+func (s *stubProvider) CallLLMWithTools(_ context.Context, _ []llm.Message, _ []llm.ToolDefinition) (llm.Message, error) {
+	return s.resp, s.err
+}
+func (s *stubProvider) IsToolCallingEnabled() bool { return true }
+
+func TestSetup_DisabledWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	if Enabled() {
+		t.Fatal("Enabled() = true without endpoint")
+	}
+	shutdown, err := Setup(context.Background(), "test", "0")
+	if err != nil || shutdown(context.Background()) != nil {
+		t.Fatalf("Setup() should be a no-op, err = %v", err)
+	}
+}
+
+func TestWrapLLM_RecordsCallSpan(t *testing.T) {
+	rec := recordSpans(t)
+	p := WrapLLM(&stubProvider{resp: llm.Message{Content: "hello"}}, "gpt-test")
+
+	if _, err := p.CallLLM(context.Background(), []llm.Message{{Content: "abcd"}}); err != nil {
+		t.Fatal(err)
+	}
+	spans := rec.Ended()
+	if len(spans) != 1 || spans[0].Name() != "llm.call" {
+		t.Fatalf("spans = %v, want one llm.call", spans)
+	}
+	a := attrs(spans[0])
+	if a["gen_ai.request.model"].AsString() != "gpt-test" || a["llm.prompt_chars"].AsInt64() != 4 ||
+		a["llm.response_chars"].AsInt64() != 5 || a["gen_ai.usage.input_tokens"].AsInt64() != 120 ||
+		a["gen_ai.usage.output_tokens"].AsInt64() != 30 {
+		t.Errorf("unexpected attributes: %v", spans[0].Attributes())
+	}
+}
+
+func TestWrapLLM_StreamAndError(t *testing.T) {
+	rec := recordSpans(t)
+	p := WrapLLM(&stubProvider{err: errors.New("boom")}, "m")
+
+	var chunks string
+	_, err := p.CallLLMStream(context.Background(), nil, func(c string) { chunks += c })
+	if err == nil || chunks != "ab" {
+		t.Fatalf("err = %v, chunks = %q", err, chunks)
+	}
+	s := rec.Ended()[0]
+	if s.Status().Code != codes.Error {
+		t.Errorf("status = %v, want Error", s.Status())
+	}
+	if ev := s.Events(); len(ev) == 0 || ev[0].Name != "first_token" {
+		t.Errorf("events = %v, want first_token", ev)
+	}
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	rec := recordSpans(t)
+	h := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("wrapped writer must keep http.Flusher for SSE")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/agent", nil))
+
+	s := rec.Ended()[0]
+	if s.Name() != "POST /api/agent" || attrs(s)["http.response.status_code"].AsInt64() != 503 || s.Status().Code != codes.Error {
+		t.Errorf("span = %s %v %v", s.Name(), s.Attributes(), s.Status())
+	}
+}
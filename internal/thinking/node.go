@@ -62,6 +62,7 @@ func (n *ChainOfThoughtNode) Prep(state *ThinkingState) []PrepData {
 	return []PrepData{{
 		Problem:             state.Problem,
 		ConversationHistory: state.ConversationHistory,
+		Images:              state.Images,
 		ThoughtsText:        thoughtsText,
 		LastPlanText:        lastPlanText,
 		CurrentThoughtNo:    state.CurrentThoughtNum,
@@ -75,7 +76,7 @@ func (n *ChainOfThoughtNode) Exec(ctx context.Context, prep PrepData) (ThoughtDa
 
 	messages := make([]llm.Message, 0, len(prep.ConversationHistory)+1)
 	messages = append(messages, prep.ConversationHistory...)
-	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: prompt})
+	messages = append(messages, llm.Message{Role: llm.RoleUser, Content: prompt, Parts: prep.Images})
 	resp, err := n.llmProvider.CallLLM(ctx, messages)
 	if err != nil {
 		return ThoughtData{}, fmt.Errorf("LLM call failed: %w", err)
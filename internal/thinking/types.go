@@ -27,11 +27,12 @@ var rejectPatterns = []*regexp.Regexp{
 
 // ThinkingState is the shared state for the Chain of Thought flow.
 type ThinkingState struct {
-	Problem             string        `json:"problem"`
-	ConversationHistory []llm.Message `json:"-"` // injected multi-turn history, populated by Handler layer
-	Thoughts            []ThoughtData `json:"thoughts"`
-	CurrentThoughtNum   int           `json:"current_thought_num"`
-	Solution            string        `json:"solution"`
+	Problem             string            `json:"problem"`
+	ConversationHistory []llm.Message     `json:"-"` // injected multi-turn history, populated by Handler layer
+	Images              []llm.ContentPart `json:"-"` // uploaded images, attached to every thought's user message
+	Thoughts            []ThoughtData     `json:"thoughts"`
+	CurrentThoughtNum   int               `json:"current_thought_num"`
+	Solution            string            `json:"solution"`
 
 	// OnThoughtComplete is called after each thought step completes.
 	// Used for SSE streaming to push thoughts to the client in real-time.
@@ -62,6 +63,7 @@ type PlanStep struct {
 type PrepData struct {
 	Problem             string
 	ConversationHistory []llm.Message // passed through from ThinkingState
+	Images              []llm.ContentPart
 	ThoughtsText        string
 	LastPlanText        string
 	CurrentThoughtNo    int
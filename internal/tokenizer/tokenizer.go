@@ -0,0 +1,180 @@
+// Package tokenizer counts prompt tokens for the agent's budget guards
+// (CostGuard, ContextGuard, the system prompt and tool output budgets).
+// BPE counts the tiktoken vocabulary of the configured model; Heuristic, the
+// character-based estimate, is the fallback when no vocabulary applies or
+// loading one fails.
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// Vocabularies are embedded: no download from openaipublic at runtime.
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// Tokenizer counts the tokens of text for one vocabulary.
+type Tokenizer interface {
+	// Count returns the number of tokens in text (at least 1).
+	Count(text string) int
+	// Truncate returns the longest prefix of text within maxTokens.
+	Truncate(text string, maxTokens int) string
+	// Name identifies the vocabulary, e.g. "o200k_base" or "heuristic".
+	Name() string
+}
+
+// Heuristic estimates tokens from characters: CJK Unified Ideographs
+// (U+4E00–U+9FFF) ~2 chars/token, everything else ~4 chars/token.
+// Precision is ±20–30% for mixed content.
+type Heuristic struct{}
+
+func (Heuristic) Name() string { return "heuristic" }
+
+func (Heuristic) Count(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk/2 + other/4 + 1 // +1 avoids zero for short strings
+}
+
+func (h Heuristic) Truncate(text string, maxTokens int) string {
+	var cjk, other int
+	for i, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			other++
+		}
+		if cjk/2+other/4+1 > maxTokens {
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func isCJK(r rune) bool { return r >= 0x4E00 && r <= 0x9FFF }
+
+// BPE counts tokens with a tiktoken byte-pair encoding.
+type BPE struct {
+	name string
+	enc  *tiktoken.Tiktoken
+}
+
+func (b *BPE) Name() string { return b.name }
+
+func (b *BPE) Count(text string) int {
+	return max(len(b.enc.EncodeOrdinary(text)), 1)
+}
+
+func (b *BPE) Truncate(text string, maxTokens int) string {
+	tokens := b.enc.EncodeOrdinary(text)
+	if len(tokens) <= maxTokens {
+		return text
+	}
+	prefix := b.enc.Decode(tokens[:max(maxTokens, 0)])
+	// A token boundary can split a multi-byte rune; drop the partial rune.
+	for len(prefix) > 0 {
+		r, size := utf8.DecodeLastRuneInString(prefix)
+		if r != utf8.RuneError || size > 1 {
+			break
+		}
+		prefix = prefix[:len(prefix)-size]
+	}
+	return prefix
+}
+
+var (
+	encMu     sync.Mutex
+	encodings = map[string]*BPE{}
+)
+
+// Encoding returns the named tiktoken vocabulary ("o200k_base",
+// "cl100k_base", "p50k_base", "r50k_base"). Vocabularies are loaded once.
+func Encoding(name string) (*BPE, error) {
+	encMu.Lock()
+	defer encMu.Unlock()
+	if b, ok := encodings[name]; ok {
+		return b, nil
+	}
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, fmt.Errorf("tokenizer %s: %w", name, err)
+	}
+	b := &BPE{name: name, enc: enc}
+	encodings[name] = b
+	return b, nil
+}
+
+// EncodingForModel names the vocabulary used for model. OpenAI's older
+// chat models use cl100k_base; the GPT-4o generation and later, and every
+// other model family (whose own vocabularies are not public in tiktoken
+// form), use o200k_base — the closest modern multilingual vocabulary.
+func EncodingForModel(model string) string {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:] // "openai/gpt-4" → "gpt-4"
+	}
+	for _, prefix := range []string{"gpt-4o", "chatgpt-4o", "gpt-4.1", "gpt-4.5"} {
+		if strings.HasPrefix(name, prefix) {
+			return "o200k_base"
+		}
+	}
+	for _, prefix := range []string{"gpt-4", "gpt-3.5", "text-embedding-3", "text-embedding-ada"} {
+		if strings.HasPrefix(name, prefix) {
+			return "cl100k_base"
+		}
+	}
+	return "o200k_base"
+}
+
+// Load returns the tokenizer selected by spec: "" or "auto" (by model),
+// "heuristic", or a vocabulary name. A vocabulary that cannot be loaded
+// falls back to Heuristic with the error.
+func Load(spec, model string) (Tokenizer, error) {
+	switch spec = strings.TrimSpace(spec); spec {
+	case "heuristic":
+		return Heuristic{}, nil
+	case "", "auto":
+		spec = EncodingForModel(model)
+	}
+	b, err := Encoding(spec)
+	if err != nil {
+		return Heuristic{}, err
+	}
+	return b, nil
+}
+
+// defaultTok holds the process-wide tokenizer (a tokenizerBox).
+var defaultTok atomic.Value
+
+type tokenizerBox struct{ t Tokenizer }
+
+// Default returns the process-wide tokenizer: Heuristic until SetDefault
+// is called at startup.
+func Default() Tokenizer {
+	if box, ok := defaultTok.Load().(tokenizerBox); ok {
+		return box.t
+	}
+	return Heuristic{}
+}
+
+// SetDefault replaces the process-wide tokenizer; nil restores Heuristic.
+func SetDefault(t Tokenizer) {
+	if t == nil {
+		t = Heuristic{}
+	}
+	defaultTok.Store(tokenizerBox{t})
+}
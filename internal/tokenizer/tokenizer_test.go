@@ -0,0 +1,91 @@
+package tokenizer
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEncodingForModel(t *testing.T) {
+	for model, want := range map[string]string{
+		"gpt-4o-mini":            "o200k_base",
+		"openai/gpt-4.1":         "o200k_base",
+		"GPT-4-turbo":            "cl100k_base",
+		"gpt-3.5-turbo":          "cl100k_base",
+		"text-embedding-3-small": "cl100k_base",
+		"o3-mini":                "o200k_base",
+		"deepseek-chat":          "o200k_base",
+		"":                       "o200k_base",
+	} {
+		if got := EncodingForModel(model); got != want {
+			t.Errorf("EncodingForModel(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
+
+func TestBPE_CountAndTruncate(t *testing.T) {
+	tok, err := Load("cl100k_base", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := tok.Count("hello world"); n != 2 {
+		t.Errorf("cl100k Count(hello world) = %d, want 2", n)
+	}
+	// English prose runs ~4 chars/token, far from the old chars/2 estimate.
+	prose := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 50)
+	if n := tok.Count(prose); n > len(prose)/3 {
+		t.Errorf("Count(prose) = %d tokens for %d chars", n, len(prose))
+	}
+
+	if got := tok.Truncate(prose, 5); tok.Count(got) > 5 || !strings.HasPrefix(prose, got) || got == "" {
+		t.Errorf("Truncate(prose, 5) = %q", got)
+	}
+	if got := tok.Truncate("short", 10); got != "short" {
+		t.Errorf("Truncate within budget = %q", got)
+	}
+	cjk := strings.Repeat("上下文窗口的令牌预算", 20)
+	for n := 1; n < 20; n++ {
+		if got := tok.Truncate(cjk, n); !utf8.ValidString(got) || !strings.HasPrefix(cjk, got) {
+			t.Fatalf("Truncate(cjk, %d) = %q: split a rune", n, got)
+		}
+	}
+}
+
+func TestHeuristic(t *testing.T) {
+	var h Heuristic
+	if n := h.Count(""); n != 1 {
+		t.Errorf("Count(\"\") = %d, want 1", n)
+	}
+	if n := h.Count("中文中文abcdefgh"); n != 2+2+1 {
+		t.Errorf("Count(mixed) = %d, want 5", n)
+	}
+	text := strings.Repeat("abcd", 100)
+	if got := h.Truncate(text, 10); h.Count(got) > 10 || !strings.HasPrefix(text, got) {
+		t.Errorf("Truncate = %q (%d tokens)", got, h.Count(got))
+	}
+}
+
+func TestLoad_FallbackAndDefault(t *testing.T) {
+	if tok, err := Load("heuristic", "gpt-4o"); err != nil || tok.Name() != "heuristic" {
+		t.Errorf("Load(heuristic) = %v, %v", tok, err)
+	}
+	if tok, err := Load("auto", "gpt-4o"); err != nil || tok.Name() != "o200k_base" {
+		t.Errorf("Load(auto, gpt-4o) = %v, %v", tok, err)
+	}
+	if tok, err := Load("no_such_base", ""); err == nil || tok.Name() != "heuristic" {
+		t.Errorf("Load(unknown) = %v, %v; want heuristic fallback with error", tok, err)
+	}
+
+	if Default().Name() != "heuristic" {
+		t.Errorf("initial Default = %s", Default().Name())
+	}
+	bpe, _ := Load("o200k_base", "")
+	SetDefault(bpe)
+	if Default().Name() != "o200k_base" {
+		t.Errorf("Default after SetDefault = %s", Default().Name())
+	}
+	SetDefault(nil)
+	if Default().Name() != "heuristic" {
+		t.Errorf("Default after SetDefault(nil) = %s", Default().Name())
+	}
+}
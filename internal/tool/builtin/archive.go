@@ -0,0 +1,589 @@
+package builtin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/storage"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	archiveMaxEntries    = 10000
+	archiveMaxTotalBytes = 512 << 20 // uncompressed bytes extracted or packed per call
+	archiveListLimit     = 200       // entries shown by list
+	archiveShowExtracted = 20        // extracted paths shown in the result
+)
+
+// Kinds of archive entries; links and special files are never extracted.
+const (
+	archiveFile = iota
+	archiveDir
+	archiveLink
+	archiveOther
+)
+
+// archiveEntry is one member of a zip or tar archive.
+type archiveEntry struct {
+	name    string // as stored, forward slashes
+	kind    int
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// ── archive_tool ──
+
+// ArchiveTool lists, extracts and creates zip and tar(.gz) archives inside
+// the workspace, the same on every platform. Extraction checks every entry
+// before writing anything: paths leaving the destination, links, existing
+// files (unless overwrite) and the entry/size limits.
+type ArchiveTool struct {
+	workspaceDir string
+}
+
+func NewArchiveTool(workspaceDir string) *ArchiveTool {
+	return &ArchiveTool{workspaceDir: workspaceDir}
+}
+
+func (t *ArchiveTool) Name() string { return "archive_tool" }
+func (t *ArchiveTool) Description() string {
+	return "压缩包操作：list（列出内容）、extract（解压到工作区目录）、create（把文件或目录打包）。支持 .zip、.tar.gz/.tgz、.tar。" +
+		"解压前检查所有条目，拒绝越出目标目录的路径，不解压符号链接，并限制条目数和总大小。各平台行为一致，不必用 shell 调 unzip/tar。"
+}
+
+func (t *ArchiveTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "action", Type: "string", Description: "操作类型", Required: true, Enum: []string{"list", "extract", "create"}},
+		tool.SchemaParam{Name: "archive", Type: "string", Description: "压缩包路径（.zip / .tar.gz / .tgz / .tar）", Required: true},
+		tool.SchemaParam{Name: "dest", Type: "string", Description: "extract: 解压到的目录（默认与压缩包同名的目录）", Required: false},
+		tool.SchemaParam{Name: "paths", Type: "string", Description: "create: 空白分隔的要打包的文件或目录（工作区相对路径）", Required: false},
+		tool.SchemaParam{Name: "overwrite", Type: "boolean", Description: "extract: 覆盖已存在的文件；create: 覆盖已存在的压缩包（默认 false）", Required: false},
+	)
+}
+
+func (t *ArchiveTool) Init(_ context.Context) error { return nil }
+func (t *ArchiveTool) Close() error                 { return nil }
+
+type archiveArgs struct {
+	Action    string `json:"action"`
+	Archive   string `json:"archive"`
+	Dest      string `json:"dest"`
+	Paths     string `json:"paths"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+// ReadOnlyCall implements tool.ReadOnlyCaller: list runs in read-only mode.
+func (t *ArchiveTool) ReadOnlyCall(args json.RawMessage) bool {
+	var a archiveArgs
+	if json.Unmarshal(args, &a) != nil {
+		return false
+	}
+	return a.Action == "list"
+}
+
+func (t *ArchiveTool) ReadOnlyHint() string { return "仅 list 可执行" }
+
+func (t *ArchiveTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a archiveArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	if strings.TrimSpace(a.Archive) == "" {
+		return tool.ToolResult{Error: "archive 不能为空"}, nil
+	}
+	format, err := archiveFormat(a.Archive)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	archivePath, err := safeResolvePath(a.Archive, t.workspaceDir)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+
+	var out string
+	switch a.Action {
+	case "list":
+		out, err = t.list(a.Archive, archivePath, format)
+	case "extract":
+		out, err = t.extract(ctx, a, archivePath, format)
+	case "create":
+		out, err = t.create(ctx, a, archivePath, format)
+	default:
+		return tool.ToolResult{Error: fmt.Sprintf("未知操作 %q（可用 list、extract、create）", a.Action)}, nil
+	}
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	return tool.ToolResult{Output: out}, nil
+}
+
+// archiveFormat returns "zip", "tar.gz" or "tar" by file extension.
+func archiveFormat(name string) (string, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return "zip", nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return "tar.gz", nil
+	case strings.HasSuffix(lower, ".tar"):
+		return "tar", nil
+	}
+	return "", fmt.Errorf("不支持的压缩包格式 %q（支持 .zip、.tar.gz、.tgz、.tar）", filepath.Base(name))
+}
+
+// walkArchive calls fn for each entry; r reads a file entry's content.
+func walkArchive(archivePath, format string, fn func(e archiveEntry, r io.Reader) error) error {
+	if format == "zip" {
+		zr, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return fmt.Errorf("无法打开 zip: %v", err)
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			e := archiveEntry{name: strings.ReplaceAll(f.Name, `\`, "/"), size: int64(f.UncompressedSize64), mode: f.Mode(), modTime: f.Modified}
+			switch {
+			case f.Mode().IsDir() || strings.HasSuffix(e.name, "/"):
+				e.kind = archiveDir
+			case f.Mode()&fs.ModeSymlink != 0:
+				e.kind = archiveLink
+			case !f.Mode().IsRegular():
+				e.kind = archiveOther
+			}
+			if e.kind != archiveFile {
+				if err := fn(e, nil); err != nil {
+					return err
+				}
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("无法读取 %s: %v", e.name, err)
+			}
+			err = fn(e, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("无法打开压缩包: %v", err)
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if format == "tar.gz" {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("不是有效的 gzip 文件: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("解析 tar 失败: %v", err)
+		}
+		e := archiveEntry{name: hdr.Name, size: hdr.Size, mode: hdr.FileInfo().Mode(), modTime: hdr.ModTime}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+		case tar.TypeDir:
+			e.kind = archiveDir
+		case tar.TypeSymlink, tar.TypeLink:
+			e.kind = archiveLink
+		case tar.TypeXGlobalHeader, tar.TypeXHeader:
+			continue
+		default:
+			e.kind = archiveOther
+		}
+		if err := fn(e, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *ArchiveTool) list(display, archivePath, format string) (string, error) {
+	var entries []archiveEntry
+	var count int
+	var total int64
+	err := walkArchive(archivePath, format, func(e archiveEntry, _ io.Reader) error {
+		count++
+		total += e.size
+		if len(entries) < archiveListLimit {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s（%s，%d 个条目，解压后 %s）\n", display, format, count, storage.FormatSize(total))
+	for _, e := range entries {
+		size := storage.FormatSize(e.size)
+		switch e.kind {
+		case archiveDir:
+			size = "-"
+		case archiveLink:
+			size = "链接"
+		}
+		fmt.Fprintf(&sb, "%10s  %s  %s\n", size, e.modTime.Local().Format("2006-01-02 15:04"), e.name)
+	}
+	if count > len(entries) {
+		fmt.Fprintf(&sb, "…（还有 %d 个条目未列出）\n", count-len(entries))
+	}
+	return sb.String(), nil
+}
+
+// entryPath validates an entry name and returns it cleaned, relative to the
+// destination; "" for the root entry. Absolute paths, drive letters and ".."
+// components are rejected (zip slip).
+func entryPath(name string) (string, error) {
+	clean := path.Clean(name)
+	if path.IsAbs(name) || len(name) >= 2 && name[1] == ':' || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("压缩包包含不安全的路径 %q，已拒绝解压", name)
+	}
+	if clean == "." {
+		return "", nil
+	}
+	return clean, nil
+}
+
+func (t *ArchiveTool) extract(ctx context.Context, a archiveArgs, archivePath, format string) (string, error) {
+	if _, err := os.Stat(archivePath); err != nil {
+		return "", fmt.Errorf("压缩包不存在: %s", a.Archive)
+	}
+	dest := a.Dest
+	if strings.TrimSpace(dest) == "" {
+		dest = strings.TrimSuffix(a.Archive, filepath.Ext(a.Archive))
+		dest = strings.TrimSuffix(dest, ".tar") // x.tar.gz → x
+	}
+	destPath, err := safeResolvePath(dest, t.workspaceDir)
+	if err != nil {
+		return "", fmt.Errorf("目标目录无效: %v", err)
+	}
+	if info, err := os.Stat(destPath); err == nil && !info.IsDir() {
+		return "", fmt.Errorf("目标 %s 已存在且不是目录", dest)
+	}
+
+	// Pass 1: check every entry before writing anything.
+	var count, skipped int
+	var total int64
+	var conflicts []string
+	err = walkArchive(archivePath, format, func(e archiveEntry, _ io.Reader) error {
+		if count++; count > archiveMaxEntries {
+			return fmt.Errorf("条目超过 %d 个，已拒绝解压", archiveMaxEntries)
+		}
+		if total += e.size; total > archiveMaxTotalBytes {
+			return fmt.Errorf("解压后超过 %s，已拒绝解压", storage.FormatSize(archiveMaxTotalBytes))
+		}
+		rel, err := entryPath(e.name)
+		if err != nil {
+			return err
+		}
+		if e.kind == archiveLink || e.kind == archiveOther {
+			skipped++
+			return nil
+		}
+		if rel == "" {
+			return nil
+		}
+		target, err := t.entryTarget(destPath, rel)
+		if err != nil {
+			return err
+		}
+		info, err := os.Lstat(target)
+		switch {
+		case err != nil:
+		case e.kind == archiveDir && !info.IsDir(), e.kind == archiveFile && info.IsDir():
+			return fmt.Errorf("%s 已存在且类型不同，无法解压", filepath.ToSlash(filepath.Join(dest, rel)))
+		case e.kind == archiveFile && !a.Overwrite:
+			conflicts = append(conflicts, filepath.ToSlash(filepath.Join(dest, rel)))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(conflicts) > 0 {
+		shown := conflicts[:min(len(conflicts), 10)]
+		return "", fmt.Errorf("%d 个文件已存在（%s），未解压；确认覆盖请传 overwrite=true", len(conflicts), strings.Join(shown, ", "))
+	}
+
+	// Pass 2: extract.
+	var written []string
+	var files int
+	var remaining int64 = archiveMaxTotalBytes
+	err = walkArchive(archivePath, format, func(e archiveEntry, r io.Reader) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, _ := entryPath(e.name)
+		if rel == "" || e.kind == archiveLink || e.kind == archiveOther {
+			return nil
+		}
+		target, err := t.entryTarget(destPath, rel)
+		if err != nil {
+			return err
+		}
+		if e.kind == archiveDir {
+			return os.MkdirAll(target, 0o755)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644|e.mode.Perm()&0o111)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(f, io.LimitReader(r, remaining+1))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("解压 %s 失败: %v", rel, err)
+		}
+		if remaining -= n; remaining < 0 { // sizes in the headers understated
+			return fmt.Errorf("解压后超过 %s，已中止", storage.FormatSize(archiveMaxTotalBytes))
+		}
+		if !e.modTime.IsZero() {
+			os.Chtimes(target, e.modTime, e.modTime)
+		}
+		files++
+		if len(written) < archiveShowExtracted {
+			written = append(written, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("%v（已解压 %d 个文件到 %s）", err, files, dest)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ 已解压 %d 个文件（%s）到 %s/\n", files, storage.FormatSize(archiveMaxTotalBytes-remaining), filepath.ToSlash(filepath.Clean(dest)))
+	for _, w := range written {
+		sb.WriteString("  " + w + "\n")
+	}
+	if files > len(written) {
+		fmt.Fprintf(&sb, "  …（另有 %d 个文件）\n", files-len(written))
+	}
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "⚠️ 跳过了 %d 个符号链接或特殊文件\n", skipped)
+	}
+	return sb.String(), nil
+}
+
+// entryTarget returns where an entry is written: inside destPath, through
+// no symlink that leaves the workspace, and not a protected file.
+func (t *ArchiveTool) entryTarget(destPath, rel string) (string, error) {
+	target := filepath.Join(destPath, filepath.FromSlash(rel))
+	if r, err := filepath.Rel(destPath, target); err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("压缩包包含不安全的路径 %q，已拒绝解压", rel)
+	}
+	if _, err := safeResolvePath(target, t.workspaceDir); err != nil {
+		return "", err
+	}
+	if msg := checkProtectedFile(target, t.workspaceDir); msg != "" {
+		return "", errors.New(msg)
+	}
+	return target, nil
+}
+
+func (t *ArchiveTool) create(ctx context.Context, a archiveArgs, archivePath, format string) (string, error) {
+	sources := strings.Fields(a.Paths)
+	if len(sources) == 0 {
+		return "", fmt.Errorf("create 需要 paths（要打包的文件或目录）")
+	}
+	if _, err := os.Stat(archivePath); err == nil && !a.Overwrite {
+		return "", fmt.Errorf("%s 已存在；确认覆盖请传 overwrite=true", a.Archive)
+	}
+	if msg := checkProtectedFile(archivePath, t.workspaceDir); msg != "" {
+		return "", errors.New(msg)
+	}
+	root, _ := filepath.Abs(t.workspaceDir)
+	archiveAbs, _ := filepath.Abs(archivePath)
+
+	// Collect the entries first so the limits hold before anything is written.
+	type member struct {
+		abs  string
+		name string // workspace-relative, forward slashes
+		info fs.FileInfo
+	}
+	var members []member
+	var total int64
+	var skipped int
+	for _, src := range sources {
+		srcPath, err := safeResolvePath(src, t.workspaceDir)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Lstat(srcPath); err != nil {
+			return "", fmt.Errorf("路径不存在: %s", src)
+		}
+		srcAbs, _ := filepath.Abs(srcPath)
+		err = filepath.WalkDir(srcAbs, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p == archiveAbs {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && !info.Mode().IsRegular() {
+				skipped++ // symlinks are not followed, special files not packed
+				return nil
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil || rel == "." {
+				return nil
+			}
+			if len(members) >= archiveMaxEntries {
+				return fmt.Errorf("条目超过 %d 个", archiveMaxEntries)
+			}
+			if !info.IsDir() {
+				if total += info.Size(); total > archiveMaxTotalBytes {
+					return fmt.Errorf("总大小超过 %s", storage.FormatSize(archiveMaxTotalBytes))
+				}
+			}
+			members = append(members, member{abs: p, name: filepath.ToSlash(rel), info: info})
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("打包失败: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(archivePath), 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(archivePath), ".archive-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+
+	var files int
+	var add func(m member) error
+	var finish func() error
+	switch format {
+	case "zip":
+		zw := zip.NewWriter(tmp)
+		add = func(m member) error {
+			hdr, err := zip.FileInfoHeader(m.info)
+			if err != nil {
+				return err
+			}
+			hdr.Name = m.name
+			if m.info.IsDir() {
+				hdr.Name += "/"
+			} else {
+				hdr.Method = zip.Deflate
+			}
+			w, err := zw.CreateHeader(hdr)
+			if err != nil || m.info.IsDir() {
+				return err
+			}
+			return copyFileTo(w, m.abs)
+		}
+		finish = zw.Close
+	default:
+		var w io.Writer = tmp
+		var gz *gzip.Writer
+		if format == "tar.gz" {
+			gz = gzip.NewWriter(tmp)
+			w = gz
+		}
+		tw := tar.NewWriter(w)
+		add = func(m member) error {
+			hdr, err := tar.FileInfoHeader(m.info, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = m.name
+			if m.info.IsDir() {
+				hdr.Name += "/"
+			}
+			hdr.Uname, hdr.Gname = "", "" // no host user names in shared archives
+			if err := tw.WriteHeader(hdr); err != nil || m.info.IsDir() {
+				return err
+			}
+			return copyFileTo(tw, m.abs)
+		}
+		finish = func() error {
+			if err := tw.Close(); err != nil {
+				return err
+			}
+			if gz != nil {
+				return gz.Close()
+			}
+			return nil
+		}
+	}
+	for _, m := range members {
+		if err := ctx.Err(); err != nil {
+			tmp.Close()
+			return "", err
+		}
+		if err := add(m); err != nil {
+			tmp.Close()
+			return "", fmt.Errorf("打包 %s 失败: %v", m.name, err)
+		}
+		if !m.info.IsDir() {
+			files++
+		}
+	}
+	if err := finish(); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("写入压缩包失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("写入压缩包失败: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), archivePath); err != nil {
+		return "", fmt.Errorf("写入压缩包失败: %v", err)
+	}
+	size := int64(0)
+	if info, err := os.Stat(archivePath); err == nil {
+		size = info.Size()
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ 已创建 %s：%d 个文件，原始 %s，压缩包 %s\n", a.Archive, files, storage.FormatSize(total), storage.FormatSize(size))
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "⚠️ 跳过了 %d 个符号链接或特殊文件\n", skipped)
+	}
+	return sb.String(), nil
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
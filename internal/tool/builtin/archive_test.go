@@ -0,0 +1,152 @@
+package builtin
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runArchive(t *testing.T, dir, args string) (string, string) {
+	t.Helper()
+	res, err := NewArchiveTool(dir).Execute(context.Background(), json.RawMessage(args))
+	if err != nil {
+		t.Fatalf("Execute(%s) err = %v", args, err)
+	}
+	return res.Output, res.Error
+}
+
+func TestArchive_CreateListExtractRoundTrip(t *testing.T) {
+	for _, name := range []string{"out.zip", "out.tar.gz", "out.tar"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.MkdirAll(filepath.Join(dir, "src", "sub"), 0o755)
+			os.WriteFile(filepath.Join(dir, "src", "a.txt"), []byte("alpha"), 0o644)
+			os.WriteFile(filepath.Join(dir, "src", "sub", "b.sh"), []byte("#!/bin/sh\n"), 0o755)
+			os.Symlink("/etc/passwd", filepath.Join(dir, "src", "link"))
+
+			out, errMsg := runArchive(t, dir, `{"action":"create","archive":"`+name+`","paths":"src"}`)
+			if errMsg != "" || !strings.Contains(out, "2 个文件") || !strings.Contains(out, "跳过了 1 个") {
+				t.Fatalf("create: out=%q err=%q", out, errMsg)
+			}
+
+			out, errMsg = runArchive(t, dir, `{"action":"list","archive":"`+name+`"}`)
+			if errMsg != "" || !strings.Contains(out, "src/a.txt") || !strings.Contains(out, "src/sub/b.sh") || strings.Contains(out, "link") {
+				t.Fatalf("list: out=%q err=%q", out, errMsg)
+			}
+
+			out, errMsg = runArchive(t, dir, `{"action":"extract","archive":"`+name+`","dest":"x"}`)
+			if errMsg != "" || !strings.Contains(out, "已解压 2 个文件") {
+				t.Fatalf("extract: out=%q err=%q", out, errMsg)
+			}
+			if data, _ := os.ReadFile(filepath.Join(dir, "x", "src", "a.txt")); string(data) != "alpha" {
+				t.Errorf("extracted a.txt = %q", data)
+			}
+			if info, err := os.Stat(filepath.Join(dir, "x", "src", "sub", "b.sh")); err != nil || info.Mode().Perm()&0o100 == 0 {
+				t.Errorf("b.sh should keep its exec bit: %v %v", info, err)
+			}
+		})
+	}
+}
+
+func TestArchive_ExtractDefaultDestAndOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("v1"), 0o644)
+	runArchive(t, dir, `{"action":"create","archive":"pkg.tar.gz","paths":"a.txt"}`)
+
+	if _, errMsg := runArchive(t, dir, `{"action":"extract","archive":"pkg.tar.gz"}`); errMsg != "" {
+		t.Fatalf("extract: %s", errMsg)
+	}
+	target := filepath.Join(dir, "pkg", "a.txt")
+	os.WriteFile(target, []byte("edited"), 0o644)
+
+	if _, errMsg := runArchive(t, dir, `{"action":"extract","archive":"pkg.tar.gz"}`); !strings.Contains(errMsg, "overwrite=true") {
+		t.Errorf("second extract error = %q, want overwrite refusal", errMsg)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "edited" {
+		t.Errorf("refused extract must not touch files, got %q", data)
+	}
+	if _, errMsg := runArchive(t, dir, `{"action":"extract","archive":"pkg.tar.gz","overwrite":true}`); errMsg != "" {
+		t.Fatalf("overwrite extract: %s", errMsg)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "v1" {
+		t.Errorf("overwritten a.txt = %q", data)
+	}
+
+	if _, errMsg := runArchive(t, dir, `{"action":"create","archive":"pkg.tar.gz","paths":"a.txt"}`); !strings.Contains(errMsg, "已存在") {
+		t.Errorf("create over existing archive error = %q", errMsg)
+	}
+}
+
+func TestArchive_RejectsTraversal(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "ok/../../evil.txt", "/abs/evil.txt", `..\evil.txt`} {
+		dir := t.TempDir()
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, _ := zw.Create("fine.txt")
+		w.Write([]byte("ok"))
+		w, _ = zw.Create(name)
+		w.Write([]byte("pwned"))
+		zw.Close()
+		os.WriteFile(filepath.Join(dir, "bad.zip"), buf.Bytes(), 0o644)
+
+		_, errMsg := runArchive(t, dir, `{"action":"extract","archive":"bad.zip","dest":"out"}`)
+		if !strings.Contains(errMsg, "不安全的路径") {
+			t.Errorf("%q: error = %q, want traversal refusal", name, errMsg)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "out", "fine.txt")); err == nil {
+			t.Errorf("%q: nothing should be extracted from an unsafe archive", name)
+		}
+	}
+}
+
+func TestArchive_SkipsLinkEntries(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: "/etc"})
+	tw.WriteHeader(&tar.Header{Name: "escape/passwd", Typeflag: tar.TypeReg, Size: 1, Mode: 0o644})
+	tw.Write([]byte("x"))
+	tw.Close()
+	os.WriteFile(filepath.Join(dir, "l.tar"), buf.Bytes(), 0o644)
+
+	out, errMsg := runArchive(t, dir, `{"action":"extract","archive":"l.tar"}`)
+	if errMsg != "" || !strings.Contains(out, "跳过了 1 个符号链接") {
+		t.Fatalf("out=%q err=%q", out, errMsg)
+	}
+	info, err := os.Lstat(filepath.Join(dir, "l", "escape"))
+	if err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("escape should be a plain directory: %v %v", info, err)
+	}
+}
+
+func TestArchive_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for args, want := range map[string]string{
+		`{"action":"list","archive":"a.rar"}`:               "不支持的压缩包格式",
+		`{"action":"list","archive":"missing.zip"}`:         "无法打开 zip",
+		`{"action":"extract","archive":"../up.zip"}`:        "超出工作目录",
+		`{"action":"create","archive":"a.zip"}`:             "需要 paths",
+		`{"action":"create","archive":"a.zip","paths":"x"}`: "路径不存在",
+		`{"action":"remove","archive":"a.zip"}`:             "未知操作",
+	} {
+		if _, errMsg := runArchive(t, dir, args); !strings.Contains(errMsg, want) {
+			t.Errorf("Execute(%s) error = %q, want %q", args, errMsg, want)
+		}
+	}
+}
+
+func TestArchive_ReadOnlyCall(t *testing.T) {
+	tl := NewArchiveTool(t.TempDir())
+	if !tl.ReadOnlyCall(json.RawMessage(`{"action":"list","archive":"a.zip"}`)) {
+		t.Error("list should be read-only")
+	}
+	if tl.ReadOnlyCall(json.RawMessage(`{"action":"extract","archive":"a.zip"}`)) {
+		t.Error("extract should not be read-only")
+	}
+}
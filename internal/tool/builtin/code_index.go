@@ -0,0 +1,696 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/pocketomega/pocket-omega/internal/ignore"
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+const (
+	codeIndexFile        = "code.gob"
+	codeIndexMaxFiles    = 5000
+	codeIndexMaxFileSize = 256 << 10 // larger files are not indexed
+	codeChunkLines       = 60
+	codeChunkOverlap     = 10
+	codeChunkMaxChars    = 6000 // embedding input per chunk
+	codeEmbedBatch       = 32   // chunks per embedding request
+	embedHTTPTimeout     = 60 * time.Second
+	embedMaxBody         = 64 << 20
+	embedErrBodyShow     = 200
+	hashEmbedDims        = 1024
+)
+
+// Embedder turns texts into vectors for semantic search. Vectors of one
+// embedder are comparable with each other only; Name identifies the
+// embedder so an index built with another one is discarded.
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// ── OpenAI-compatible embeddings API ──
+
+// OpenAIEmbedder calls the /embeddings endpoint of an OpenAI-compatible API.
+type OpenAIEmbedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+// String returns a log-safe representation with the API key omitted.
+func (e *OpenAIEmbedder) String() string {
+	return fmt.Sprintf("OpenAIEmbedder{baseURL: %q, model: %q}", e.baseURL, e.model)
+}
+
+func NewOpenAIEmbedder(baseURL, apiKey, model string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{},
+	}
+}
+
+func (e *OpenAIEmbedder) Name() string { return "openai:" + e.model }
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": e.model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, embedHTTPTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		return nil, fmt.Errorf("embeddings API HTTP %d: %s", resp.StatusCode, util.TruncateRunes(strings.TrimSpace(string(msg)), embedErrBodyShow))
+	}
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, embedMaxBody)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("embeddings API response: %w", err)
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index >= 0 && d.Index < len(vectors) {
+			vectors[d.Index] = normalizeVector(d.Embedding)
+		}
+	}
+	for i, v := range vectors {
+		if len(v) == 0 {
+			return nil, fmt.Errorf("embeddings API response: no vector for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// ── Offline hashing embedder ──
+
+// HashEmbedder embeds text without a model: identifiers are split into
+// words (camelCase and snake_case too) and hashed into a fixed number of
+// dimensions. It only matches shared vocabulary, but needs no API and
+// keeps code on the machine.
+type HashEmbedder struct{}
+
+func NewHashEmbedder() *HashEmbedder { return &HashEmbedder{} }
+
+func (HashEmbedder) Name() string { return fmt.Sprintf("hash-%d", hashEmbedDims) }
+
+func (HashEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		counts := make(map[string]int)
+		for _, w := range codeWords(text) {
+			counts[w]++
+		}
+		v := make([]float32, hashEmbedDims)
+		for w, n := range counts {
+			h := fnv.New32a()
+			h.Write([]byte(w))
+			sum := h.Sum32()
+			weight := float32(1 + math.Log(float64(n)))
+			if sum&(1<<31) != 0 {
+				weight = -weight
+			}
+			v[sum%hashEmbedDims] += weight
+		}
+		vectors[i] = normalizeVector(v)
+	}
+	return vectors, nil
+}
+
+// codeStopWords are keywords too common in code to tell chunks apart.
+var codeStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "if": true, "else": true, "return": true, "func": true,
+	"var": true, "let": true, "const": true, "nil": true, "null": true, "err": true, "in": true,
+	"of": true, "to": true, "is": true, "a": true, "an": true, "def": true, "self": true, "this": true,
+}
+
+// codeWords splits text into lowercase words, breaking identifiers at
+// case changes, digits and underscores, and drops stop words.
+func codeWords(text string) []string {
+	var words []string
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		runes := []rune(field)
+		start := 0
+		for i := 1; i <= len(runes); i++ {
+			if i < len(runes) && !(unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1])) {
+				continue
+			}
+			w := strings.ToLower(string(runes[start:i]))
+			start = i
+			if len([]rune(w)) < 2 || codeStopWords[w] {
+				continue
+			}
+			words = append(words, strings.TrimSuffix(w, "s"))
+		}
+	}
+	return words
+}
+
+func normalizeVector(v []float32) []float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= norm
+	}
+	return v
+}
+
+func dotProduct(a, b []float32) float64 {
+	var sum float64
+	for i := range min(len(a), len(b)) {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+// ── Index ──
+
+// CodeIndex is an embedding index of the workspace's source files, stored
+// under .omega/index. Refresh re-embeds only files whose mtime or size
+// changed since the last refresh and drops deleted ones, so keeping it
+// current costs little after the first build. While Watch runs, refreshes
+// only re-stat the paths fsnotify reported instead of walking the tree.
+type CodeIndex struct {
+	workspaceDir string
+	embedder     Embedder
+	exclude      *ignore.Matcher // CODE_SEARCH_EXCLUDE, on top of .omegaignore
+
+	mu   sync.Mutex
+	data *codeIndexData // nil until loaded
+
+	// Guarded by stateMu, not mu: a refresh holds mu while embedding
+	stateMu  sync.Mutex
+	watching bool            // Watch reports changes: refreshes rescan only dirty paths
+	fullScan bool            // the next refresh walks the whole workspace
+	dirty    map[string]bool // workspace-relative paths changed since the last refresh
+	progress CodeIndexProgress
+}
+
+// codeIndexData is the persisted index (gob: vectors as JSON would be
+// several times larger and slower to load).
+type codeIndexData struct {
+	Embedder string
+	Files    map[string]*indexedFile // workspace-relative, forward slashes
+}
+
+type indexedFile struct {
+	ModTime int64
+	Size    int64
+	Chunks  []indexedChunk
+}
+
+type indexedChunk struct {
+	StartLine int
+	EndLine   int
+	Vector    []float32
+}
+
+// CodeIndexStats reports what a Refresh did.
+type CodeIndexStats struct {
+	Files    int  // files in the index
+	Updated  int  // files (re-)embedded by this refresh
+	Removed  int  // files dropped because they were deleted or ignored
+	Complete bool // false when the refresh stopped early (timeout, API error, file cap)
+}
+
+// CodeIndexProgress is the state of the index reported by /api/health.
+type CodeIndexProgress struct {
+	State       string    `json:"state"` // "idle", "scanning" or "indexing"
+	Watching    bool      `json:"watching"`
+	Files       int       `json:"files"`   // files in the index after the last refresh
+	Pending     int       `json:"pending"` // changed paths waiting for the next refresh
+	Done        int       `json:"done"`    // files embedded by the running refresh
+	Total       int       `json:"total"`   // files the running refresh embeds
+	Complete    bool      `json:"complete"`
+	LastRefresh time.Time `json:"last_refresh"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// CodeMatch is one search hit.
+type CodeMatch struct {
+	Path      string
+	StartLine int
+	EndLine   int
+	Score     float64
+}
+
+func NewCodeIndex(workspaceDir string, embedder Embedder) *CodeIndex {
+	return &CodeIndex{workspaceDir: workspaceDir, embedder: embedder}
+}
+
+// WithExclude leaves paths matching the gitignore-style patterns out of the
+// index, in addition to .omegaignore.
+func (x *CodeIndex) WithExclude(patterns ...string) *CodeIndex {
+	var lines []string
+	for _, p := range patterns {
+		lines = append(lines, strings.TrimSpace(p))
+	}
+	x.exclude = ignore.Parse([]byte(strings.Join(lines, "\n")))
+	return x
+}
+
+// Progress returns the current state of the index.
+func (x *CodeIndex) Progress() CodeIndexProgress {
+	x.stateMu.Lock()
+	defer x.stateMu.Unlock()
+	p := x.progress
+	p.Watching, p.Pending = x.watching, len(x.dirty)
+	if p.State == "" {
+		p.State = "idle"
+	}
+	return p
+}
+
+// setProgress updates the progress under stateMu.
+func (x *CodeIndex) setProgress(update func(p *CodeIndexProgress)) {
+	x.stateMu.Lock()
+	update(&x.progress)
+	x.stateMu.Unlock()
+}
+
+// takeDirty returns the paths to rescan, or nil with full=true when the
+// whole workspace has to be walked, and resets them.
+func (x *CodeIndex) takeDirty() (dirty []string, full bool) {
+	x.stateMu.Lock()
+	defer x.stateMu.Unlock()
+	full = !x.watching || x.fullScan
+	if !full {
+		for rel := range x.dirty {
+			dirty = append(dirty, rel)
+		}
+		sort.Strings(dirty)
+	}
+	x.dirty, x.fullScan = nil, false
+	return dirty, full
+}
+
+// Embedder returns the embedder the index is built with.
+func (x *CodeIndex) Embedder() Embedder { return x.embedder }
+
+func (x *CodeIndex) path() string {
+	return filepath.Join(x.workspaceDir, ".omega", "index", codeIndexFile)
+}
+
+// load reads the persisted index once; a missing, unreadable or foreign
+// (other embedder) index starts empty.
+func (x *CodeIndex) load() {
+	if x.data != nil {
+		return
+	}
+	x.data = &codeIndexData{Embedder: x.embedder.Name(), Files: make(map[string]*indexedFile)}
+	f, err := os.Open(x.path())
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var data codeIndexData
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		log.Printf("[CodeIndex] Ignoring unreadable index %s: %v", x.path(), err)
+		return
+	}
+	if data.Embedder != x.embedder.Name() || data.Files == nil {
+		log.Printf("[CodeIndex] Index was built with %q, rebuilding with %q", data.Embedder, x.embedder.Name())
+		return
+	}
+	x.data = &data
+}
+
+func (x *CodeIndex) save() error {
+	if err := os.MkdirAll(filepath.Dir(x.path()), 0o755); err != nil {
+		return err
+	}
+	tmp := x.path() + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(x.data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, x.path())
+}
+
+// pendingChunk is a chunk waiting to be embedded.
+type pendingChunk struct {
+	file       string
+	start, end int
+	text       string
+}
+
+// Refresh brings the index up to date with the workspace. Progress made
+// before an error or timeout is kept and saved, so an interrupted first
+// build continues on the next call.
+func (x *CodeIndex) Refresh(ctx context.Context) (CodeIndexStats, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.load()
+
+	x.setProgress(func(p *CodeIndexProgress) { p.State, p.Done, p.Total = "scanning", 0, 0 })
+	dirty, full := x.takeDirty()
+	var current map[string]os.FileInfo
+	var covered func(rel string) bool // indexed files the scan looked at
+	var complete bool
+	if full {
+		current, complete = x.scan(ctx)
+		covered = func(string) bool { return true }
+	} else {
+		current, complete = x.scanDirty(ctx, dirty)
+		covered = func(rel string) bool { return coveredBy(rel, dirty) }
+	}
+	stats := CodeIndexStats{Complete: complete}
+	for rel := range x.data.Files {
+		if _, ok := current[rel]; !ok && covered(rel) {
+			delete(x.data.Files, rel)
+			stats.Removed++
+		}
+	}
+
+	var changed []string
+	for rel, info := range current {
+		if old, ok := x.data.Files[rel]; !ok || old.ModTime != info.ModTime().UnixNano() || old.Size != info.Size() {
+			changed = append(changed, rel)
+		}
+	}
+	sort.Strings(changed)
+	x.setProgress(func(p *CodeIndexProgress) { p.State, p.Total = "indexing", len(changed) })
+
+	var embedErr error
+	var pending []pendingChunk
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		texts := make([]string, len(pending))
+		for i, c := range pending {
+			texts[i] = c.text
+		}
+		vectors, err := x.embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+		for i, c := range pending {
+			f := x.data.Files[c.file]
+			f.Chunks = append(f.Chunks, indexedChunk{StartLine: c.start, EndLine: c.end, Vector: vectors[i]})
+		}
+		pending = pending[:0]
+		return nil
+	}
+	// A file enters the index only once all its chunks are embedded;
+	// until then it is held here and retried on the next refresh.
+	var inFlight []string
+	commit := func() {
+		stats.Updated += len(inFlight)
+		inFlight = inFlight[:0]
+		x.setProgress(func(p *CodeIndexProgress) { p.Done = stats.Updated })
+	}
+	for _, rel := range changed {
+		if ctx.Err() != nil {
+			embedErr = ctx.Err()
+			break
+		}
+		info := current[rel]
+		data, err := os.ReadFile(filepath.Join(x.workspaceDir, filepath.FromSlash(rel)))
+		if err != nil {
+			delete(x.data.Files, rel)
+			continue
+		}
+		x.data.Files[rel] = &indexedFile{ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+		inFlight = append(inFlight, rel)
+		for _, c := range chunkCode(rel, string(data)) {
+			pending = append(pending, c)
+			if len(pending) >= codeEmbedBatch {
+				if embedErr = flush(); embedErr != nil {
+					break
+				}
+			}
+		}
+		if embedErr != nil {
+			break
+		}
+		if len(pending) == 0 {
+			commit()
+		}
+	}
+	if embedErr == nil {
+		embedErr = flush()
+	}
+	if embedErr == nil {
+		commit()
+	} else {
+		// Drop partially embedded files so the next refresh redoes them
+		for _, rel := range inFlight {
+			delete(x.data.Files, rel)
+		}
+		stats.Complete = false
+	}
+
+	stats.Files = len(x.data.Files)
+	if stats.Updated > 0 || stats.Removed > 0 {
+		if err := x.save(); err != nil {
+			log.Printf("[CodeIndex] Saving index failed: %v", err)
+		}
+	}
+	x.stateMu.Lock()
+	if !stats.Complete {
+		// Skipped files are not dirty anymore: find them by mtime next time
+		x.fullScan = true
+	}
+	x.progress = CodeIndexProgress{State: "idle", Files: stats.Files, Done: stats.Updated, Total: len(changed), Complete: stats.Complete, LastRefresh: time.Now()}
+	if embedErr != nil {
+		x.progress.LastError = embedErr.Error()
+	}
+	x.stateMu.Unlock()
+	if embedErr != nil && stats.Files == 0 {
+		return stats, embedErr
+	}
+	if embedErr != nil {
+		log.Printf("[CodeIndex] Refresh stopped early: %v", embedErr)
+	}
+	return stats, nil
+}
+
+// scan lists the indexable files of the workspace: known source and text
+// types within the size limit, honoring skipDirs, the ignore file and the
+// exclude patterns.
+func (x *CodeIndex) scan(ctx context.Context) (map[string]os.FileInfo, bool) {
+	files := make(map[string]os.FileInfo)
+	complete := x.walk(ctx, x.workspaceDir, x.skipper(), files)
+	return files, complete
+}
+
+// scanDirty lists the indexable files at or under the dirty paths; indexed
+// files they cover and that are not listed were deleted.
+func (x *CodeIndex) scanDirty(ctx context.Context, dirty []string) (map[string]os.FileInfo, bool) {
+	files := make(map[string]os.FileInfo)
+	skip := x.skipper()
+	complete := true
+	for _, rel := range dirty {
+		p := filepath.Join(x.workspaceDir, filepath.FromSlash(rel))
+		info, err := os.Lstat(p)
+		if err != nil || x.skippedDir(rel) {
+			continue // deleted, or inside a skipped directory
+		}
+		if info.IsDir() {
+			if !skip(p, true) && !x.walk(ctx, p, skip, files) {
+				complete = false
+			}
+			continue
+		}
+		if !x.indexable(p, info, skip) {
+			continue
+		}
+		if _, indexed := x.data.Files[rel]; !indexed && len(x.data.Files)+len(files) >= codeIndexMaxFiles {
+			complete = false
+			continue
+		}
+		files[rel] = info
+	}
+	return files, complete
+}
+
+// walk adds the indexable files under root to files; false when it stopped
+// early.
+func (x *CodeIndex) walk(ctx context.Context, root string, skip func(string, bool) bool, files map[string]os.FileInfo) bool {
+	complete := true
+	_ = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			complete = false
+			return ctx.Err()
+		}
+		if err != nil || p == root {
+			return nil
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] || d.Name() == ".omega" || skip(p, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !x.indexable(p, info, skip) {
+			return nil
+		}
+		if len(files) >= codeIndexMaxFiles {
+			complete = false
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(x.workspaceDir, p)
+		files[filepath.ToSlash(rel)] = info
+		return nil
+	})
+	return complete
+}
+
+// indexable reports whether the file at p is a known source or text type
+// within the size limit and not ignored.
+func (x *CodeIndex) indexable(p string, info os.FileInfo, skip func(string, bool) bool) bool {
+	return projectLanguages[strings.ToLower(filepath.Ext(p))] != "" && info.Size() > 0 && info.Size() <= codeIndexMaxFileSize && !skip(p, false)
+}
+
+// skipper combines the ignore file with the exclude patterns.
+func (x *CodeIndex) skipper() func(path string, isDir bool) bool {
+	ignored := omegaIgnore(x.workspaceDir, x.workspaceDir)
+	return func(p string, isDir bool) bool {
+		return ignored(p, isDir) || x.exclude.Match(ignore.Rel(x.workspaceDir, p), isDir)
+	}
+}
+
+// skippedDir reports whether rel is or lies in a directory walk skips by
+// name (skipDirs, .omega).
+func (x *CodeIndex) skippedDir(rel string) bool {
+	for _, dir := range strings.Split(rel, "/") {
+		if skipDirs[dir] || dir == ".omega" {
+			return true
+		}
+	}
+	return false
+}
+
+// coveredBy reports whether rel is one of the paths or inside one of them.
+func coveredBy(rel string, paths []string) bool {
+	for _, p := range paths {
+		if rel == p || strings.HasPrefix(rel, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkCode splits a file into overlapping windows of codeChunkLines lines.
+// Each chunk's text starts with the path so the file name contributes to
+// the match.
+func chunkCode(rel, content string) []pendingChunk {
+	if isGrepBinary([]byte(content[:min(len(content), 512)])) {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	var chunks []pendingChunk
+	for start := 0; start < len(lines); start += codeChunkLines - codeChunkOverlap {
+		end := min(start+codeChunkLines, len(lines))
+		text := rel + "\n" + strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(text) != rel {
+			chunks = append(chunks, pendingChunk{file: rel, start: start + 1, end: end, text: util.TruncateRunes(text, codeChunkMaxChars)})
+		}
+		if end == len(lines) {
+			break
+		}
+	}
+	return chunks
+}
+
+// Search returns the k chunks most similar to query, optionally limited to
+// paths under prefix. The index must have been refreshed.
+func (x *CodeIndex) Search(ctx context.Context, query, prefix string, k int) ([]CodeMatch, error) {
+	vectors, err := x.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	q := vectors[0]
+	prefix = strings.TrimSuffix(strings.TrimPrefix(filepath.ToSlash(prefix), "./"), "/")
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.load()
+	var matches []CodeMatch
+	for rel, f := range x.data.Files {
+		if prefix != "" && prefix != "." && rel != prefix && !strings.HasPrefix(rel, prefix+"/") {
+			continue
+		}
+		for _, c := range f.Chunks {
+			matches = append(matches, CodeMatch{Path: rel, StartLine: c.StartLine, EndLine: c.EndLine, Score: dotProduct(q, c.Vector)})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].Path != matches[j].Path {
+			return matches[i].Path < matches[j].Path
+		}
+		return matches[i].StartLine < matches[j].StartLine
+	})
+	// Overlapping windows of one file often both match; keep the better one
+	var out []CodeMatch
+	for _, m := range matches {
+		if len(out) >= k {
+			break
+		}
+		overlaps := false
+		for _, o := range out {
+			if o.Path == m.Path && m.StartLine <= o.EndLine && o.StartLine <= m.EndLine {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
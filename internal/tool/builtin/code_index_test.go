@@ -0,0 +1,221 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCodeFiles(t *testing.T, workspace string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(workspace, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCodeIndex_RefreshIsIncremental(t *testing.T) {
+	workspace := t.TempDir()
+	writeCodeFiles(t, workspace, map[string]string{
+		"llm/retry.go":   "package llm\n\n// withRetry retries a request with exponential backoff.\nfunc withRetry(attempts int, backoff time.Duration) error {\n\treturn nil\n}\n",
+		"web/server.go":  "package web\n\n// Server serves the HTTP API and the chat UI.\ntype Server struct{ mux *http.ServeMux }\n",
+		"notes.txt":      "not indexed\n",
+		"vendor/x/x.go":  "package x\n",
+		"README.md":      "# demo\n",
+		"assets/big.css": strings.Repeat("a{}\n", codeIndexMaxFileSize/4+1),
+	})
+	ctx := context.Background()
+	idx := NewCodeIndex(workspace, NewHashEmbedder())
+	stats, err := idx.Refresh(ctx)
+	if err != nil || stats != (CodeIndexStats{Files: 3, Updated: 3, Complete: true}) {
+		t.Fatalf("first refresh = %+v, %v", stats, err)
+	}
+
+	matches, err := idx.Search(ctx, "retry with backoff", "", 2)
+	if err != nil || len(matches) == 0 || matches[0].Path != "llm/retry.go" || matches[0].StartLine != 1 || matches[0].EndLine != 6 {
+		t.Fatalf("search = %+v, %v", matches, err)
+	}
+	if matches, _ := idx.Search(ctx, "retry with backoff", "web", 5); len(matches) != 1 || matches[0].Path != "web/server.go" {
+		t.Errorf("search under web/ = %+v", matches)
+	}
+
+	// Unchanged workspace, fresh instance loaded from disk: nothing to embed
+	idx = NewCodeIndex(workspace, NewHashEmbedder())
+	if stats, _ := idx.Refresh(ctx); stats.Updated != 0 || stats.Files != 3 {
+		t.Errorf("reloaded refresh = %+v", stats)
+	}
+
+	later := time.Now().Add(time.Minute)
+	os.WriteFile(filepath.Join(workspace, "web/server.go"), []byte("package web\n\nfunc retryHandler() {}\n"), 0644)
+	os.Chtimes(filepath.Join(workspace, "web/server.go"), later, later)
+	os.Remove(filepath.Join(workspace, "README.md"))
+	if stats, _ := idx.Refresh(ctx); stats != (CodeIndexStats{Files: 2, Updated: 1, Removed: 1, Complete: true}) {
+		t.Errorf("incremental refresh = %+v", stats)
+	}
+}
+
+type failingEmbedder struct {
+	HashEmbedder
+	calls, failAt int
+}
+
+func (e *failingEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls++
+	if e.calls >= e.failAt {
+		return nil, errors.New("quota exceeded")
+	}
+	return e.HashEmbedder.Embed(ctx, texts)
+}
+
+type otherEmbedder struct{ HashEmbedder }
+
+func (otherEmbedder) Name() string { return "other" }
+
+func TestCodeIndex_KeepsProgressOnEmbedError(t *testing.T) {
+	workspace := t.TempDir()
+	files := map[string]string{}
+	for _, name := range []string{"a", "b", "c"} {
+		files[name+".go"] = strings.Repeat("package "+name+"\n", codeEmbedBatch*(codeChunkLines-codeChunkOverlap))
+	}
+	writeCodeFiles(t, workspace, files)
+
+	// Each file fills exactly one batch; the third request fails
+	e := &failingEmbedder{failAt: 3}
+	stats, err := NewCodeIndex(workspace, e).Refresh(context.Background())
+	if err != nil || stats.Files != 2 || stats.Complete {
+		t.Fatalf("partial refresh = %+v, %v", stats, err)
+	}
+	e.failAt = 100
+	if stats, _ := NewCodeIndex(workspace, e).Refresh(context.Background()); stats.Updated != 1 || stats.Files != 3 || !stats.Complete {
+		t.Errorf("resumed refresh = %+v", stats)
+	}
+
+	// A different embedder rebuilds the index
+	if stats, _ := NewCodeIndex(workspace, otherEmbedder{}).Refresh(context.Background()); stats.Updated != 3 {
+		t.Errorf("foreign index reused: %+v", stats)
+	}
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, `{"error":"bad request"}`, http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "embed-small" || len(req.Input) != 2 {
+			http.Error(w, "unexpected body", http.StatusBadRequest)
+			return
+		}
+		// Out of order, as the API allows
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,2]},{"index":0,"embedding":[3,4]}]}`))
+	}))
+	defer srv.Close()
+
+	e := NewOpenAIEmbedder(srv.URL+"/v1/", "sk-test", "embed-small")
+	vectors, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vectors[0][0] != 0.6 || vectors[0][1] != 0.8 || vectors[1][1] != 1 {
+		t.Errorf("vectors = %v, want normalized and in input order", vectors)
+	}
+	if _, err := NewOpenAIEmbedder(srv.URL+"/v1", "wrong", "embed-small").Embed(context.Background(), []string{"a"}); err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("bad key: err = %v", err)
+	}
+}
+
+func TestCodeSearchTool(t *testing.T) {
+	workspace := t.TempDir()
+	writeCodeFiles(t, workspace, map[string]string{
+		"llm/retry.go": "package llm\n\n// withRetry retries a request with exponential backoff.\nfunc withRetry() error {\n\treturn nil\n}\n",
+		"web/ui.ts":    "export function renderChat() {}\n",
+	})
+	tool := NewCodeSearchTool(workspace, NewHashEmbedder())
+	result, err := tool.Execute(context.Background(), json.RawMessage(`{"query":"where is the retry backoff"}`))
+	if err != nil || result.Error != "" {
+		t.Fatalf("Execute: %v %s", err, result.Error)
+	}
+	for _, want := range []string{"索引 2 个文件，本次更新 2 个", "1. llm/retry.go:1-6", "// withRetry retries a request"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, result.Output)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(workspace, ".omega", "index", codeIndexFile)); err != nil {
+		t.Errorf("index not saved: %v", err)
+	}
+	if result, _ := tool.Execute(context.Background(), json.RawMessage(`{"query":" "}`)); result.Error == "" {
+		t.Error("expected error for empty query")
+	}
+}
+
+func TestCodeIndex_ExcludePatterns(t *testing.T) {
+	workspace := t.TempDir()
+	writeCodeFiles(t, workspace, map[string]string{
+		"src/app.go":          "package src\n",
+		"gen/api.pb.go":       "package gen\n",
+		"src/app_mock.go":     "package src\n",
+		"third_party/lib.py":  "x = 1\n",
+		"third_party/keep.md": "# kept\n",
+	})
+	idx := NewCodeIndex(workspace, NewHashEmbedder()).WithExclude("gen/", " *_mock.go", "third_party/*.py")
+	if stats, err := idx.Refresh(context.Background()); err != nil || stats.Files != 2 {
+		t.Fatalf("refresh = %+v, %v", stats, err)
+	}
+	for _, rel := range []string{"src/app.go", "third_party/keep.md"} {
+		if idx.data.Files[rel] == nil {
+			t.Errorf("%s should be indexed", rel)
+		}
+	}
+}
+
+func TestCodeIndex_DirtyRefreshOnlyStatsChangedPaths(t *testing.T) {
+	workspace := t.TempDir()
+	writeCodeFiles(t, workspace, map[string]string{"a.go": "package a\n", "lib/b.go": "package lib\n", "lib/c.go": "package lib\n"})
+	ctx := context.Background()
+	idx := NewCodeIndex(workspace, NewHashEmbedder())
+	idx.watching, idx.fullScan = true, true
+	if stats, _ := idx.Refresh(ctx); stats.Files != 3 {
+		t.Fatalf("full refresh = %+v", stats)
+	}
+
+	// Not reported: an incremental refresh does not notice it
+	writeCodeFiles(t, workspace, map[string]string{"unseen.go": "package a\n"})
+	// Reported: a new directory, a deleted directory and a changed file
+	writeCodeFiles(t, workspace, map[string]string{"pkg/d.go": "package pkg\n"})
+	os.RemoveAll(filepath.Join(workspace, "lib"))
+	idx.dirty = map[string]bool{"pkg": true, "lib": true}
+	if p := idx.Progress(); p.Pending != 2 || !p.Watching {
+		t.Errorf("progress before refresh = %+v", p)
+	}
+	stats, _ := idx.Refresh(ctx)
+	if stats != (CodeIndexStats{Files: 2, Updated: 1, Removed: 2, Complete: true}) {
+		t.Errorf("dirty refresh = %+v", stats)
+	}
+	if idx.data.Files["unseen.go"] != nil || idx.data.Files["pkg/d.go"] == nil {
+		t.Errorf("indexed files = %v", idx.data.Files)
+	}
+	if p := idx.Progress(); p.State != "idle" || p.Files != 2 || p.Pending != 0 || p.LastRefresh.IsZero() {
+		t.Errorf("progress after refresh = %+v", p)
+	}
+
+	// The periodic rescan finds what events missed
+	idx.fullScan = true
+	if stats, _ := idx.Refresh(ctx); stats.Updated != 1 || idx.data.Files["unseen.go"] == nil {
+		t.Errorf("full rescan = %+v", stats)
+	}
+}
@@ -0,0 +1,151 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/pocketomega/pocket-omega/internal/ignore"
+)
+
+const (
+	// codeIndexDebounce batches the events of one edit (or a checkout) into
+	// a single refresh.
+	codeIndexDebounce = 2 * time.Second
+
+	// codeIndexRescanInterval walks the whole workspace in case fsnotify
+	// dropped events (queue overflow, network filesystems).
+	codeIndexRescanInterval = 30 * time.Minute
+)
+
+// Watch keeps the index current in the background until ctx is done: it
+// builds the index, then refreshes the paths fsnotify reports changed and
+// rescans everything by mtime every codeIndexRescanInterval. Each refresh
+// is bounded by codeSearchRefreshTimeout so searches never wait long for
+// the index lock; a first build continues over several rounds. When the
+// workspace has more directories than the platform lets us watch (the
+// inotify limit), refreshes fall back to full rescans.
+func (x *CodeIndex) Watch(ctx context.Context) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("code index: %w", err)
+	}
+	x.stateMu.Lock()
+	x.watching, x.fullScan = true, true
+	x.stateMu.Unlock()
+	go x.watchLoop(ctx, fsw)
+	return nil
+}
+
+func (x *CodeIndex) watchLoop(ctx context.Context, fsw *fsnotify.Watcher) {
+	defer fsw.Close()
+	x.addWatches(fsw, x.workspaceDir)
+
+	refresh := time.NewTimer(0)
+	defer refresh.Stop()
+	scheduled := true
+	schedule := func(d time.Duration) {
+		if !scheduled {
+			refresh.Reset(d)
+			scheduled = true
+		}
+	}
+	rescan := time.NewTicker(codeIndexRescanInterval)
+	defer rescan.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			if x.handleEvent(fsw, ev) {
+				schedule(codeIndexDebounce)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[CodeIndex] fsnotify error, rescanning: %v", err)
+			x.stateMu.Lock()
+			x.fullScan = true
+			x.stateMu.Unlock()
+			schedule(codeIndexDebounce)
+		case <-rescan.C:
+			x.stateMu.Lock()
+			x.fullScan = true
+			x.stateMu.Unlock()
+			schedule(0)
+		case <-refresh.C:
+			scheduled = false
+			roundCtx, cancel := context.WithTimeout(ctx, codeSearchRefreshTimeout)
+			stats, err := x.Refresh(roundCtx)
+			cancel()
+			switch {
+			case err != nil:
+				log.Printf("[CodeIndex] Background refresh failed: %v", err)
+			case !stats.Complete && stats.Updated > 0:
+				schedule(0) // made progress: continue the build
+			case stats.Updated > 0 || stats.Removed > 0:
+				log.Printf("[CodeIndex] %d files indexed (%d updated, %d removed)", stats.Files, stats.Updated, stats.Removed)
+			}
+		}
+	}
+}
+
+// handleEvent marks the path of an event dirty and watches new
+// directories; false for events on skipped paths and when refreshes are
+// full rescans anyway (left to the periodic rescan).
+func (x *CodeIndex) handleEvent(fsw *fsnotify.Watcher, ev fsnotify.Event) bool {
+	x.stateMu.Lock()
+	watching := x.watching
+	x.stateMu.Unlock()
+	if !watching || ev.Op == fsnotify.Chmod {
+		return false
+	}
+	rel := ignore.Rel(x.workspaceDir, ev.Name)
+	if rel == "" || rel == "." || x.skippedDir(rel) {
+		return false
+	}
+	if ev.Has(fsnotify.Create) {
+		if info, err := os.Lstat(ev.Name); err == nil && info.IsDir() {
+			x.addWatches(fsw, ev.Name)
+		}
+	}
+	x.stateMu.Lock()
+	if x.dirty == nil {
+		x.dirty = make(map[string]bool)
+	}
+	x.dirty[rel] = true
+	x.stateMu.Unlock()
+	return true
+}
+
+// addWatches watches root and the directories below it that the index
+// walks. Hitting the watch limit stops incremental refreshes.
+func (x *CodeIndex) addWatches(fsw *fsnotify.Watcher, root string) {
+	skip := x.skipper()
+	_ = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if p != x.workspaceDir && (skipDirs[d.Name()] || d.Name() == ".omega" || skip(p, true)) {
+			return filepath.SkipDir
+		}
+		if err := fsw.Add(p); err != nil {
+			log.Printf("[CodeIndex] Cannot watch %s, falling back to full rescans: %v", p, err)
+			x.stateMu.Lock()
+			x.watching = false
+			x.stateMu.Unlock()
+			return filepath.SkipAll
+		}
+		return nil
+	})
+}
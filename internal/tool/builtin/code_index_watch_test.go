@@ -0,0 +1,49 @@
+package builtin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCodeIndex_WatchIndexesChanges(t *testing.T) {
+	workspace := t.TempDir()
+	writeCodeFiles(t, workspace, map[string]string{"main.go": "package main\n"})
+	idx := NewCodeIndex(workspace, NewHashEmbedder())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := idx.Watch(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor := func(what string, cond func(CodeIndexProgress) bool) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for !cond(idx.Progress()) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s: %+v", what, idx.Progress())
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	waitFor("initial build", func(p CodeIndexProgress) bool { return p.Files == 1 && p.Complete })
+	if !idx.Progress().Watching {
+		t.Fatal("index should be watching")
+	}
+
+	// A file in a directory created after Watch started
+	writeCodeFiles(t, workspace, map[string]string{"pkg/util.go": "package pkg\n"})
+	waitFor("new file", func(p CodeIndexProgress) bool { return p.Files == 2 })
+
+	os.Remove(filepath.Join(workspace, "main.go"))
+	waitFor("deletion", func(p CodeIndexProgress) bool { return p.Files == 1 })
+
+	// Skipped directories do not trigger refreshes
+	writeCodeFiles(t, workspace, map[string]string{"node_modules/x/index.js": "module.exports = 1\n"})
+	time.Sleep(codeIndexDebounce + 500*time.Millisecond)
+	if p := idx.Progress(); p.Files != 1 {
+		t.Errorf("node_modules should not be indexed: %+v", p)
+	}
+}
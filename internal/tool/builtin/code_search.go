@@ -0,0 +1,138 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	codeSearchRefreshTimeout = 90 * time.Second
+	codeSearchDefaultK       = 8
+	codeSearchMaxK           = 20
+	codeSnippetLines         = 6
+)
+
+// ── code_search ──
+
+// CodeSearchTool answers semantic questions about the workspace ("where is
+// retry logic implemented") from a CodeIndex, so the agent does not have to
+// guess grep patterns. The index is refreshed before every search; that is
+// cheap when CodeIndex.Watch tracks the changed files.
+type CodeSearchTool struct {
+	workspaceDir string
+	index        *CodeIndex
+}
+
+func NewCodeSearchTool(workspaceDir string, embedder Embedder) *CodeSearchTool {
+	return &CodeSearchTool{workspaceDir: workspaceDir, index: NewCodeIndex(workspaceDir, embedder)}
+}
+
+// Index returns the tool's code index, e.g. to keep it current with Watch.
+func (t *CodeSearchTool) Index() *CodeIndex { return t.index }
+
+func (t *CodeSearchTool) Name() string { return "code_search" }
+func (t *CodeSearchTool) Description() string {
+	return "按语义搜索工作区代码（如“重试逻辑在哪里实现”），返回最相关的代码片段（文件、行号、相似度）。不知道确切的函数名或关键字时使用；已知关键字时用 file_grep 更精确。首次使用会建立索引，可能较慢。"
+}
+
+func (t *CodeSearchTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "query", Type: "string", Description: "用自然语言描述要找的代码", Required: true},
+		tool.SchemaParam{Name: "path", Type: "string", Description: "只搜索此目录下的文件（默认整个工作区）", Required: false},
+		tool.SchemaParam{Name: "top_k", Type: "integer", Description: fmt.Sprintf("返回结果数（默认 %d，上限 %d）", codeSearchDefaultK, codeSearchMaxK), Required: false},
+	)
+}
+
+func (t *CodeSearchTool) Init(_ context.Context) error { return nil }
+func (t *CodeSearchTool) Close() error                 { return nil }
+
+type codeSearchArgs struct {
+	Query string `json:"query"`
+	Path  string `json:"path"`
+	TopK  int    `json:"top_k"`
+}
+
+func (t *CodeSearchTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a codeSearchArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	a.Query = strings.TrimSpace(a.Query)
+	if a.Query == "" {
+		return tool.ToolResult{Error: "query 不能为空"}, nil
+	}
+	k := a.TopK
+	if k <= 0 {
+		k = codeSearchDefaultK
+	}
+	k = min(k, codeSearchMaxK)
+
+	prefix := ""
+	if a.Path != "" {
+		resolved, err := safeResolvePath(a.Path, t.workspaceDir)
+		if err != nil {
+			return tool.ToolResult{Error: err.Error()}, nil
+		}
+		prefix, _ = filepath.Rel(t.workspaceDir, resolved)
+	}
+
+	refreshCtx, cancel := context.WithTimeout(ctx, codeSearchRefreshTimeout)
+	stats, err := t.index.Refresh(refreshCtx)
+	cancel()
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("建立代码索引失败: %v", err)}, nil
+	}
+	matches, err := t.index.Search(ctx, a.Query, prefix, k)
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("语义搜索失败: %v", err)}, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "「%s」的语义搜索结果（索引 %d 个文件", a.Query, stats.Files)
+	if stats.Updated > 0 {
+		fmt.Fprintf(&sb, "，本次更新 %d 个", stats.Updated)
+	}
+	sb.WriteString("）\n")
+	if !stats.Complete {
+		sb.WriteString("（索引尚未完整：文件过多、超时或嵌入接口出错，稍后再次搜索会继续补全）\n")
+	}
+	if len(matches) == 0 {
+		sb.WriteString("未找到相关代码。可换个描述，或用 file_grep 按关键字搜索。\n")
+		return tool.ToolResult{Output: sb.String()}, nil
+	}
+	for i, m := range matches {
+		fmt.Fprintf(&sb, "\n%d. %s:%d-%d（相似度 %.2f）\n", i+1, m.Path, m.StartLine, m.EndLine, m.Score)
+		for _, line := range t.snippet(m) {
+			sb.WriteString("   " + line + "\n")
+		}
+	}
+	sb.WriteString("\n用 file_read 的 start_line/end_line 查看完整片段。")
+	return tool.ToolResult{Output: sb.String()}, nil
+}
+
+// snippet returns the first non-blank lines of a match, read from the file
+// as it is now.
+func (t *CodeSearchTool) snippet(m CodeMatch) []string {
+	f, err := os.Open(filepath.Join(t.workspaceDir, filepath.FromSlash(m.Path)))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; sc.Scan() && n <= m.EndLine && len(lines) < codeSnippetLines; n++ {
+		if n >= m.StartLine && strings.TrimSpace(sc.Text()) != "" {
+			lines = append(lines, truncateLine(strings.ReplaceAll(sc.Text(), "\t", "  "), 160))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,755 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	csvMaxFileBytes   = 50 << 20 // CSV/TSV/XLSX files larger than 50MB are refused
+	csvMaxRows        = 200000   // rows loaded; the rest of the file is ignored
+	csvDefaultRows    = 20       // rows shown when neither head nor tail is given
+	csvMaxShownRows   = 200      // upper bound for head/tail
+	csvSampleRows     = 5        // rows shown with the column overview
+	csvMaxOutputChars = 8000
+	csvCellMaxRunes   = 60
+)
+
+// ── csv_query ──
+
+// CSVQueryTool answers questions about tabular files in the workspace
+// (CSV, TSV, XLSX) without a throwaway script: column overview, selection,
+// filters, group-by aggregation, sorting and head/tail sampling, with the
+// output bounded to what fits in the context.
+type CSVQueryTool struct {
+	workspaceDir string
+}
+
+func NewCSVQueryTool(workspaceDir string) *CSVQueryTool {
+	return &CSVQueryTool{workspaceDir: workspaceDir}
+}
+
+func (t *CSVQueryTool) Name() string { return "csv_query" }
+func (t *CSVQueryTool) Description() string {
+	return "查询工作区中的 CSV/TSV/XLSX 表格。只传 path 时返回各列的类型、非空数、取值范围和前几行；" +
+		"可选择列（columns）、过滤（where）、分组聚合（group_by + agg）、排序（sort）、取前/后 N 行（head/tail）。输出有大小上限。" +
+		"分析表格数据时优先用它，不必写临时脚本。"
+}
+
+func (t *CSVQueryTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "path", Type: "string", Description: "表格文件路径（.csv / .tsv / .xlsx）", Required: true},
+		tool.SchemaParam{Name: "sheet", Type: "string", Description: "XLSX 工作表名或序号（从 1 开始），默认第一个", Required: false},
+		tool.SchemaParam{Name: "delimiter", Type: "string", Description: "分隔符，默认按扩展名和首行自动识别；制表符写 \\t", Required: false},
+		tool.SchemaParam{Name: "columns", Type: "string", Description: "输出的列，逗号分隔，默认全部", Required: false},
+		tool.SchemaParam{Name: "where", Type: "string", Description: "过滤条件，多个用 AND 连接，如 \"city = 北京 AND amount >= 100\"；运算符 = != > >= < <= ~（包含，不区分大小写）", Required: false},
+		tool.SchemaParam{Name: "group_by", Type: "string", Description: "分组列，逗号分隔；与 agg 一起使用", Required: false},
+		tool.SchemaParam{Name: "agg", Type: "string", Description: "聚合，逗号分隔：count、sum:列、avg:列、min:列、max:列、distinct:列；不带 group_by 时对过滤后的全部行聚合", Required: false},
+		tool.SchemaParam{Name: "sort", Type: "string", Description: "排序列（可以是聚合列如 sum(amount)），前缀 - 表示降序，如 -amount", Required: false},
+		tool.SchemaParam{Name: "head", Type: "integer", Description: "取前 N 行（默认 20，最多 200）", Required: false},
+		tool.SchemaParam{Name: "tail", Type: "integer", Description: "取后 N 行（最多 200）", Required: false},
+	)
+}
+
+func (t *CSVQueryTool) Init(_ context.Context) error { return nil }
+func (t *CSVQueryTool) Close() error                 { return nil }
+
+type csvQueryArgs struct {
+	Path      string `json:"path"`
+	Sheet     string `json:"sheet"`
+	Delimiter string `json:"delimiter"`
+	Columns   string `json:"columns"`
+	Where     string `json:"where"`
+	GroupBy   string `json:"group_by"`
+	Agg       string `json:"agg"`
+	Sort      string `json:"sort"`
+	Head      int    `json:"head"`
+	Tail      int    `json:"tail"`
+}
+
+// CacheFingerprint implements tool.Cacheable like file_read: the same query
+// on an unchanged file returns the cached result.
+func (t *CSVQueryTool) CacheFingerprint(_ context.Context, args json.RawMessage) (string, bool) {
+	var a csvQueryArgs
+	if json.Unmarshal(args, &a) != nil {
+		return "", false
+	}
+	path, err := safeResolvePath(a.Path, t.workspaceDir)
+	if err != nil {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || time.Since(info.ModTime()) < racyWindow {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%d:%d", path, info.ModTime().UnixNano(), info.Size()), true
+}
+
+func (t *CSVQueryTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a csvQueryArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	if a.Path == "" {
+		return tool.ToolResult{Error: "path 不能为空"}, nil
+	}
+	if a.Head < 0 || a.Tail < 0 {
+		return tool.ToolResult{Error: "head/tail 不能为负数"}, nil
+	}
+	if a.Head > 0 && a.Tail > 0 {
+		return tool.ToolResult{Error: "head 和 tail 只能指定一个"}, nil
+	}
+	path, err := safeResolvePath(a.Path, t.workspaceDir)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("文件不存在: %s", a.Path)}, nil
+	}
+	if info.IsDir() {
+		return tool.ToolResult{Error: fmt.Sprintf("%s 是目录", a.Path)}, nil
+	}
+	if info.Size() > csvMaxFileBytes {
+		return tool.ToolResult{Error: fmt.Sprintf("文件过大（%d MB，上限 %d MB）", info.Size()>>20, csvMaxFileBytes>>20)}, nil
+	}
+
+	tbl, err := loadTable(path, a.Sheet, a.Delimiter)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s：%d 行 × %d 列", a.Path, len(tbl.rows), len(tbl.header))
+	if tbl.truncated {
+		fmt.Fprintf(&sb, "（只读取了前 %d 行）", csvMaxRows)
+	}
+	sb.WriteString("\n")
+
+	if a.Columns == "" && a.Where == "" && a.GroupBy == "" && a.Agg == "" && a.Sort == "" && a.Head == 0 && a.Tail == 0 {
+		sb.WriteString(tbl.overview())
+		fmt.Fprintf(&sb, "\n前 %d 行：\n", min(csvSampleRows, len(tbl.rows)))
+		sb.WriteString(renderTable(tbl.header, tbl.rows[:min(csvSampleRows, len(tbl.rows))], len(tbl.rows)))
+		return tool.ToolResult{Output: sb.String()}, nil
+	}
+
+	out, err := tbl.query(a)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	if a.Where != "" {
+		fmt.Fprintf(&sb, "过滤后 %d 行\n", out.matched)
+	}
+	total := len(out.rows)
+	rows := out.rows
+	switch {
+	case a.Tail > 0:
+		rows = rows[max(0, len(rows)-min(a.Tail, csvMaxShownRows)):]
+	case a.Head > 0:
+		rows = rows[:min(len(rows), a.Head, csvMaxShownRows)]
+	default:
+		rows = rows[:min(len(rows), csvDefaultRows)]
+	}
+	sb.WriteString(renderTable(out.header, rows, total))
+	return tool.ToolResult{Output: sb.String()}, nil
+}
+
+// csvTable is a loaded table: a header row and data rows padded to its width.
+type csvTable struct {
+	header    []string
+	rows      [][]string
+	truncated bool // the file had more than csvMaxRows rows
+}
+
+// loadTable reads a CSV, TSV or XLSX file; the first non-empty row is the
+// header.
+func loadTable(path, sheet, delimiter string) (*csvTable, error) {
+	var records [][]string
+	var truncated bool
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx", ".xlsm":
+		var err error
+		records, truncated, err = readXLSX(path, sheet, csvMaxRows+1)
+		if err != nil {
+			return nil, err
+		}
+	case ".xls":
+		return nil, fmt.Errorf("不支持旧版 .xls 格式，请另存为 .xlsx 或 .csv")
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %v", err)
+		}
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")) // UTF-8 BOM written by Excel
+		comma, err := csvDelimiter(path, delimiter, data)
+		if err != nil {
+			return nil, err
+		}
+		r := csv.NewReader(bytes.NewReader(data))
+		r.Comma = comma
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("解析 CSV 失败: %v", err)
+			}
+			if len(rec) == 1 && strings.TrimSpace(rec[0]) == "" {
+				continue
+			}
+			if len(records) == csvMaxRows+1 {
+				truncated = true
+				break
+			}
+			records = append(records, rec)
+		}
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("表格为空")
+	}
+
+	tbl := &csvTable{header: csvHeader(records[0]), truncated: truncated}
+	for _, rec := range records[1:] {
+		if len(rec) > len(tbl.header) {
+			for i := len(tbl.header); i < len(rec); i++ {
+				tbl.header = append(tbl.header, fmt.Sprintf("col%d", i+1))
+			}
+		}
+		tbl.rows = append(tbl.rows, rec)
+	}
+	for i, rec := range tbl.rows {
+		for len(rec) < len(tbl.header) {
+			rec = append(rec, "")
+		}
+		tbl.rows[i] = rec
+	}
+	return tbl, nil
+}
+
+// csvDelimiter picks the field separator: the delimiter argument, tab for
+// .tsv, otherwise the most frequent of , ; tab | on the first line.
+func csvDelimiter(path, delimiter string, data []byte) (rune, error) {
+	switch delimiter {
+	case "":
+	case `\t`, "tab", "\t":
+		return '\t', nil
+	default:
+		r := []rune(delimiter)
+		if len(r) != 1 || r[0] == '"' || r[0] == '\n' || r[0] == '\r' {
+			return 0, fmt.Errorf("无效的分隔符 %q", delimiter)
+		}
+		return r[0], nil
+	}
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		return '\t', nil
+	}
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	best, bestN := ',', 0
+	for _, c := range []rune{',', ';', '\t', '|'} {
+		if n := bytes.Count(first, []byte(string(c))); n > bestN {
+			best, bestN = c, n
+		}
+	}
+	return best, nil
+}
+
+// csvHeader trims the header names, names blank columns colN and makes
+// duplicates unique with a _N suffix.
+func csvHeader(rec []string) []string {
+	header := make([]string, len(rec))
+	seen := make(map[string]int, len(rec))
+	for i, name := range rec {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			name = fmt.Sprintf("col%d", i+1)
+		}
+		seen[strings.ToLower(name)]++
+		if n := seen[strings.ToLower(name)]; n > 1 {
+			name = fmt.Sprintf("%s_%d", name, n)
+		}
+		header[i] = name
+	}
+	return header
+}
+
+// column returns the index of a header name (case-insensitive).
+func (tbl *csvTable) column(name string) (int, error) {
+	return columnIndex(tbl.header, name)
+}
+
+func columnIndex(header []string, name string) (int, error) {
+	name = strings.TrimSpace(name)
+	for i, h := range header {
+		if h == name {
+			return i, nil
+		}
+	}
+	for i, h := range header {
+		if strings.EqualFold(h, name) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("列 %q 不存在，可用的列：%s", name, strings.Join(header, ", "))
+}
+
+// overview describes each column: inferred type, non-empty count, distinct
+// values and range (numbers) or samples (text).
+func (tbl *csvTable) overview() string {
+	var sb strings.Builder
+	sb.WriteString("列：\n")
+	for c, name := range tbl.header {
+		nonEmpty, numeric := 0, 0
+		minV, maxV := 0.0, 0.0
+		distinct := make(map[string]bool)
+		var samples []string
+		for _, row := range tbl.rows {
+			v := strings.TrimSpace(row[c])
+			if v == "" {
+				continue
+			}
+			nonEmpty++
+			if f, ok := parseNumber(v); ok {
+				if numeric == 0 || f < minV {
+					minV = f
+				}
+				if numeric == 0 || f > maxV {
+					maxV = f
+				}
+				numeric++
+			}
+			if len(distinct) <= 1000 && !distinct[v] {
+				distinct[v] = true
+				if len(samples) < 3 {
+					samples = append(samples, truncateRunes(v, 30))
+				}
+			}
+		}
+		distinctText := strconv.Itoa(len(distinct))
+		if len(distinct) > 1000 {
+			distinctText = ">1000"
+		}
+		fmt.Fprintf(&sb, "- %s：", name)
+		switch {
+		case nonEmpty == 0:
+			sb.WriteString("全部为空\n")
+			continue
+		case numeric == nonEmpty:
+			fmt.Fprintf(&sb, "数值，非空 %d，不同值 %s，范围 %s ~ %s\n", nonEmpty, distinctText, formatNumber(minV), formatNumber(maxV))
+		default:
+			fmt.Fprintf(&sb, "文本，非空 %d，不同值 %s，例如 %s\n", nonEmpty, distinctText, strings.Join(samples, " / "))
+		}
+	}
+	return sb.String()
+}
+
+// csvResult is the table a query produces before head/tail.
+type csvResult struct {
+	header  []string
+	rows    [][]string
+	matched int // rows passing the filter
+}
+
+// query runs filter → aggregation or column selection → sort.
+func (tbl *csvTable) query(a csvQueryArgs) (*csvResult, error) {
+	conds, err := tbl.parseWhere(a.Where)
+	if err != nil {
+		return nil, err
+	}
+	var rows [][]string
+	for _, row := range tbl.rows {
+		if matchAll(conds, row) {
+			rows = append(rows, row)
+		}
+	}
+	res := &csvResult{matched: len(rows)}
+
+	switch {
+	case a.GroupBy != "" || a.Agg != "":
+		if a.Columns != "" {
+			return nil, fmt.Errorf("columns 不能与 group_by/agg 同时使用")
+		}
+		res.header, res.rows, err = tbl.aggregate(rows, a.GroupBy, a.Agg)
+		if err != nil {
+			return nil, err
+		}
+	case a.Columns != "":
+		var cols []int
+		for _, name := range splitList(a.Columns) {
+			c, err := tbl.column(name)
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, c)
+			res.header = append(res.header, tbl.header[c])
+		}
+		for _, row := range rows {
+			out := make([]string, len(cols))
+			for i, c := range cols {
+				out[i] = row[c]
+			}
+			res.rows = append(res.rows, out)
+		}
+	default:
+		res.header, res.rows = tbl.header, rows
+	}
+
+	if a.Sort != "" {
+		name, desc := strings.TrimSpace(a.Sort), false
+		if rest, ok := strings.CutPrefix(name, "-"); ok {
+			name, desc = rest, true
+		}
+		c, err := columnIndex(res.header, name)
+		if err != nil {
+			return nil, err
+		}
+		sorted := append([][]string(nil), res.rows...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			x, y := strings.TrimSpace(sorted[i][c]), strings.TrimSpace(sorted[j][c])
+			if x == "" || y == "" { // empty cells last either way
+				return x != "" && y == ""
+			}
+			if desc {
+				return compareCells(y, x) < 0
+			}
+			return compareCells(x, y) < 0
+		})
+		res.rows = sorted
+	}
+	return res, nil
+}
+
+// csvCond is one where condition.
+type csvCond struct {
+	col   int
+	op    string
+	value string
+}
+
+var (
+	whereSplit = regexp.MustCompile(`(?i)\s+and\s+|\s*&&\s*`)
+	whereCond  = regexp.MustCompile(`^(.+?)\s*(!=|>=|<=|=|>|<|~)\s*(.*)$`)
+)
+
+func (tbl *csvTable) parseWhere(where string) ([]csvCond, error) {
+	if strings.TrimSpace(where) == "" {
+		return nil, nil
+	}
+	var conds []csvCond
+	for _, part := range whereSplit.Split(strings.TrimSpace(where), -1) {
+		m := whereCond.FindStringSubmatch(strings.TrimSpace(part))
+		if m == nil {
+			return nil, fmt.Errorf("无法解析过滤条件 %q（格式：列 运算符 值）", part)
+		}
+		c, err := tbl.column(unquote(m[1]))
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, csvCond{col: c, op: m[2], value: unquote(m[3])})
+	}
+	return conds, nil
+}
+
+func matchAll(conds []csvCond, row []string) bool {
+	for _, c := range conds {
+		if !c.match(row[c.col]) {
+			return false
+		}
+	}
+	return true
+}
+
+// match compares numerically when both sides are numbers, otherwise as
+// text (= and != ignore case; < and > are lexical, which suits ISO dates).
+func (c csvCond) match(cell string) bool {
+	cell = strings.TrimSpace(cell)
+	if c.op == "~" {
+		return strings.Contains(strings.ToLower(cell), strings.ToLower(c.value))
+	}
+	var cmp int
+	x, okX := parseNumber(cell)
+	y, okY := parseNumber(c.value)
+	switch {
+	case okX && okY:
+		cmp = compareFloat(x, y)
+	case c.op == "=" || c.op == "!=":
+		if strings.EqualFold(cell, c.value) {
+			cmp = 0
+		} else {
+			cmp = 1
+		}
+	default:
+		if cell == "" { // missing values never satisfy a range condition
+			return false
+		}
+		cmp = strings.Compare(cell, c.value)
+	}
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	default: // <=
+		return cmp <= 0
+	}
+}
+
+// csvAgg is one aggregate of the agg argument.
+type csvAgg struct {
+	fn   string // count, sum, avg, min, max, distinct
+	col  int
+	name string // output column: count or fn(col)
+}
+
+func (tbl *csvTable) aggregate(rows [][]string, groupBy, agg string) ([]string, [][]string, error) {
+	var keys []int
+	var header []string
+	for _, name := range splitList(groupBy) {
+		c, err := tbl.column(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, c)
+		header = append(header, tbl.header[c])
+	}
+	specs := splitList(agg)
+	if len(specs) == 0 {
+		specs = []string{"count"}
+	}
+	var aggs []csvAgg
+	for _, spec := range specs {
+		fn, colName, hasCol := strings.Cut(spec, ":")
+		fn = strings.ToLower(strings.TrimSpace(fn))
+		switch {
+		case fn == "count" && !hasCol:
+			aggs = append(aggs, csvAgg{fn: fn, col: -1, name: "count"})
+			continue
+		case fn == "count", fn == "sum", fn == "avg", fn == "min", fn == "max", fn == "distinct":
+			if !hasCol {
+				return nil, nil, fmt.Errorf("聚合 %s 需要指定列，如 %s:列名", fn, fn)
+			}
+		default:
+			return nil, nil, fmt.Errorf("不支持的聚合 %q（可用 count、sum、avg、min、max、distinct）", spec)
+		}
+		c, err := tbl.column(colName)
+		if err != nil {
+			return nil, nil, err
+		}
+		aggs = append(aggs, csvAgg{fn: fn, col: c, name: fmt.Sprintf("%s(%s)", fn, tbl.header[c])})
+	}
+	for _, ag := range aggs {
+		header = append(header, ag.name)
+	}
+
+	// Groups keep the order of their first row.
+	groups := make(map[string][][]string)
+	var order []string
+	for _, row := range rows {
+		parts := make([]string, len(keys))
+		for i, c := range keys {
+			parts[i] = row[c]
+		}
+		key := strings.Join(parts, "\x00")
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+	if len(keys) == 0 && len(order) == 0 {
+		order = []string{""} // aggregate over no rows: count 0
+	}
+
+	out := make([][]string, 0, len(order))
+	for _, key := range order {
+		members := groups[key]
+		var row []string
+		if len(keys) > 0 {
+			row = strings.Split(key, "\x00")
+		}
+		for _, ag := range aggs {
+			row = append(row, ag.apply(members))
+		}
+		out = append(out, row)
+	}
+	return header, out, nil
+}
+
+// apply computes the aggregate over the rows of a group. sum and avg skip
+// non-numeric cells; min and max fall back to text order when a column has
+// no numbers.
+func (ag csvAgg) apply(rows [][]string) string {
+	if ag.col < 0 {
+		return strconv.Itoa(len(rows))
+	}
+	var nums []float64
+	var texts []string
+	distinct := make(map[string]bool)
+	for _, row := range rows {
+		v := strings.TrimSpace(row[ag.col])
+		if v == "" {
+			continue
+		}
+		texts = append(texts, v)
+		distinct[v] = true
+		if f, ok := parseNumber(v); ok {
+			nums = append(nums, f)
+		}
+	}
+	switch ag.fn {
+	case "count":
+		return strconv.Itoa(len(texts))
+	case "distinct":
+		return strconv.Itoa(len(distinct))
+	case "sum", "avg":
+		if len(nums) == 0 {
+			return ""
+		}
+		sum := 0.0
+		for _, f := range nums {
+			sum += f
+		}
+		if ag.fn == "avg" {
+			sum /= float64(len(nums))
+		}
+		return formatNumber(sum)
+	}
+	if len(nums) > 0 {
+		best := nums[0]
+		for _, f := range nums[1:] {
+			if ag.fn == "min" && f < best || ag.fn == "max" && f > best {
+				best = f
+			}
+		}
+		return formatNumber(best)
+	}
+	if len(texts) == 0 {
+		return ""
+	}
+	best := texts[0]
+	for _, s := range texts[1:] {
+		if ag.fn == "min" && s < best || ag.fn == "max" && s > best {
+			best = s
+		}
+	}
+	return best
+}
+
+// parseNumber parses a cell as a number, allowing thousands separators.
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if strings.Contains(s, ",") {
+		s = strings.ReplaceAll(s, ",", "")
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	return f, err == nil
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func compareFloat(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// compareCells orders numbers before text, numbers numerically and text
+// lexically.
+func compareCells(a, b string) int {
+	x, okX := parseNumber(a)
+	y, okY := parseNumber(b)
+	switch {
+	case okX && okY:
+		return compareFloat(x, y)
+	case okX:
+		return -1
+	case okY:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func unquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// renderTable formats rows as a Markdown table, stopping at
+// csvMaxOutputChars; total is the row count before head/tail, reported
+// when not all rows are shown.
+func renderTable(header []string, rows [][]string, total int) string {
+	var sb strings.Builder
+	writeRow := func(b *strings.Builder, cells []string) {
+		b.WriteString("|")
+		for _, c := range cells {
+			c = strings.NewReplacer("\r\n", " ", "\n", " ", "|", `\|`).Replace(c)
+			fmt.Fprintf(b, " %s |", truncateRunes(c, csvCellMaxRunes))
+		}
+		b.WriteString("\n")
+	}
+	writeRow(&sb, header)
+	sb.WriteString("|" + strings.Repeat(" --- |", len(header)) + "\n")
+	shown := 0
+	for _, row := range rows {
+		var line strings.Builder
+		writeRow(&line, row)
+		if sb.Len()+line.Len() > csvMaxOutputChars {
+			break
+		}
+		sb.WriteString(line.String())
+		shown++
+	}
+	if shown < total {
+		fmt.Fprintf(&sb, "（共 %d 行，显示 %d 行；可用 head/tail、columns、where 或 group_by 缩小范围）\n", total, shown)
+	}
+	return sb.String()
+}
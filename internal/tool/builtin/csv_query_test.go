@@ -0,0 +1,187 @@
+package builtin
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const salesCSV = "city,product,amount\n" +
+	"北京,apple,100\n" +
+	"上海,pear,\"1,250\"\n" +
+	"北京,pear,30\n" +
+	"广州,apple,75.5\n"
+
+func runCSVQuery(t *testing.T, dir, args string) string {
+	t.Helper()
+	res, err := NewCSVQueryTool(dir).Execute(context.Background(), json.RawMessage(args))
+	if err != nil || res.Error != "" {
+		t.Fatalf("Execute(%s) err=%v result.Error=%q", args, err, res.Error)
+	}
+	return res.Output
+}
+
+func TestCSVQuery_Overview(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sales.csv"), []byte("\xef\xbb\xbf"+salesCSV), 0o644)
+
+	out := runCSVQuery(t, dir, `{"path":"sales.csv"}`)
+	for _, want := range []string{"4 行 × 3 列", "- city：文本，非空 4，不同值 3", "- amount：数值，非空 4，不同值 4，范围 30 ~ 1250", "| 广州 | apple | 75.5 |"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCSVQuery_FilterSelectSort(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sales.csv"), []byte(salesCSV), 0o644)
+
+	out := runCSVQuery(t, dir, `{"path":"sales.csv","where":"amount >= 75 AND product ~ APP","columns":"City, amount","sort":"-amount"}`)
+	want := "过滤后 2 行\n| city | amount |\n| --- | --- |\n| 北京 | 100 |\n| 广州 | 75.5 |\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output = %q, want it to contain %q", out, want)
+	}
+
+	out = runCSVQuery(t, dir, `{"path":"sales.csv","where":"city != 北京","tail":1}`)
+	if !strings.Contains(out, "| 广州 | apple | 75.5 |\n（共 2 行，显示 1 行") || strings.Contains(out, "上海") {
+		t.Errorf("tail output = %q", out)
+	}
+}
+
+func TestCSVQuery_GroupBy(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sales.csv"), []byte(salesCSV), 0o644)
+
+	out := runCSVQuery(t, dir, `{"path":"sales.csv","group_by":"city","agg":"count,sum:amount,max:product","sort":"-sum(amount)"}`)
+	want := "| city | count | sum(amount) | max(product) |\n| --- | --- | --- | --- |\n" +
+		"| 上海 | 1 | 1250 | pear |\n| 北京 | 2 | 130 | pear |\n| 广州 | 1 | 75.5 | apple |\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("output = %q, want it to contain %q", out, want)
+	}
+
+	out = runCSVQuery(t, dir, `{"path":"sales.csv","agg":"avg:amount,distinct:city","where":"amount > 1000000"}`)
+	if !strings.Contains(out, "| avg(amount) | distinct(city) |\n| --- | --- |\n|  | 0 |") {
+		t.Errorf("empty aggregate output = %q", out)
+	}
+}
+
+func TestCSVQuery_Errors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "sales.csv"), []byte(salesCSV), 0o644)
+	tl := NewCSVQueryTool(dir)
+
+	for args, want := range map[string]string{
+		`{"path":"sales.csv","columns":"price"}`:          "列 \"price\" 不存在，可用的列：city, product, amount",
+		`{"path":"sales.csv","where":"amount"}`:           "无法解析过滤条件",
+		`{"path":"sales.csv","agg":"median:amount"}`:      "不支持的聚合",
+		`{"path":"sales.csv","head":5,"tail":5}`:          "head 和 tail 只能指定一个",
+		`{"path":"../outside.csv"}`:                       "",
+		`{"path":"missing.csv"}`:                          "文件不存在",
+		`{"path":"sales.csv","sort":"-nope"}`:             "不存在",
+		`{"path":"sales.csv","delimiter":"ab"}`:           "无效的分隔符",
+		`{"path":"sales.csv","agg":"sum","group_by":"x"}`: "不存在",
+	} {
+		res, err := tl.Execute(context.Background(), json.RawMessage(args))
+		if err != nil {
+			t.Fatalf("Execute(%s) err = %v", args, err)
+		}
+		if res.Error == "" || !strings.Contains(res.Error, want) {
+			t.Errorf("Execute(%s) error = %q, want %q", args, res.Error, want)
+		}
+	}
+}
+
+func TestCSVQuery_TSVAndDelimiterSniffing(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "data.tsv"), []byte("name\tscore\na, b\t3\nc\t5\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "semi.txt"), []byte("name;score\nx;1\n"), 0o644)
+
+	if out := runCSVQuery(t, dir, `{"path":"data.tsv","agg":"sum:score"}`); !strings.Contains(out, "| 8 |") {
+		t.Errorf("tsv output = %q", out)
+	}
+	if out := runCSVQuery(t, dir, `{"path":"semi.txt","head":5}`); !strings.Contains(out, "| x | 1 |") {
+		t.Errorf("semicolon output = %q", out)
+	}
+}
+
+func TestCSVQuery_OutputBounded(t *testing.T) {
+	dir := t.TempDir()
+	var sb strings.Builder
+	sb.WriteString("id,text\n")
+	for i := 0; i < 500; i++ {
+		sb.WriteString("1," + strings.Repeat("x", 100) + "\n")
+	}
+	os.WriteFile(filepath.Join(dir, "big.csv"), []byte(sb.String()), 0o644)
+
+	out := runCSVQuery(t, dir, `{"path":"big.csv","head":1000}`)
+	if len(out) > csvMaxOutputChars+500 {
+		t.Errorf("output length = %d, want about %d", len(out), csvMaxOutputChars)
+	}
+	if !strings.Contains(out, "（共 500 行，显示 ") || !strings.Contains(out, "…") {
+		t.Errorf("output should note truncation and shorten long cells:\n%s", out[len(out)-200:])
+	}
+}
+
+// writeXLSX writes a minimal workbook with a shared string table, an
+// inline string and a second sheet.
+func writeXLSX(t *testing.T, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	parts := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sales" sheetId="1" r:id="rId1"/><sheet name="Other" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0"?><sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>region</t></si><si><t>total</t></si><si><r><t>No</t></r><r><t>rth</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+			`<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" t="b"><v>1</v></c><c r="C2"><v>12.5</v></c></row>` +
+			`<row r="3"><c r="A3" t="inlineStr"><is><t>South</t></is></c><c r="C3"><f>SUM(1,2)</f><v>3</v></c></row>` +
+			`<row r="4"></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<?xml version="1.0"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="inlineStr"><is><t>k</t></is></c></row><row r="2"><c r="A2"><v>7</v></c></row></sheetData></worksheet>`,
+	}
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+}
+
+func TestCSVQuery_XLSX(t *testing.T) {
+	dir := t.TempDir()
+	writeXLSX(t, filepath.Join(dir, "book.xlsx"))
+
+	out := runCSVQuery(t, dir, `{"path":"book.xlsx","sort":"total"}`)
+	want := "| region | col2 | total |\n| --- | --- | --- |\n| South |  | 3 |\n| North | TRUE | 12.5 |\n"
+	if !strings.Contains(out, "2 行 × 3 列") || !strings.Contains(out, want) {
+		t.Errorf("output = %q, want it to contain %q", out, want)
+	}
+	if out := runCSVQuery(t, dir, `{"path":"book.xlsx","sheet":"other","agg":"sum:k"}`); !strings.Contains(out, "| 7 |") {
+		t.Errorf("sheet by name output = %q", out)
+	}
+	if out := runCSVQuery(t, dir, `{"path":"book.xlsx","sheet":"2","head":1}`); !strings.Contains(out, "| 7 |") {
+		t.Errorf("sheet by number output = %q", out)
+	}
+
+	res, _ := NewCSVQueryTool(dir).Execute(context.Background(), json.RawMessage(`{"path":"book.xlsx","sheet":"Missing"}`))
+	if !strings.Contains(res.Error, "可选：Sales, Other") {
+		t.Errorf("missing sheet error = %q", res.Error)
+	}
+}
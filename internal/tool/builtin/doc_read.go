@@ -0,0 +1,633 @@
+package builtin
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	docMaxFileBytes  = 100 << 20 // documents larger than 100MB are refused
+	docMaxPartBytes  = 64 << 20  // uncompressed size limit of one DOCX/EPUB part
+	docOCRTimeout    = 5 * time.Minute
+	docOCRMaxOutput  = 4 << 20
+	docMaxSelectable = 10000 // page/chapter numbers accepted in a pages range
+)
+
+// ── doc_read ──
+
+// DocReadTool extracts the text of PDF, DOCX and EPUB files in the
+// workspace, with page (PDF), heading (DOCX) and chapter (EPUB) markers.
+// PDFs without a text layer (scans) go through the OCR command when one is
+// configured (TOOL_DOC_OCR_COMMAND).
+type DocReadTool struct {
+	workspaceDir string
+	pages        *PageStore // nil = truncate to webReaderMaxRunes; otherwise paginate via fetch_more
+	ocrCommand   string     // "" = no OCR
+}
+
+func NewDocReadTool(workspaceDir string) *DocReadTool {
+	return &DocReadTool{workspaceDir: workspaceDir}
+}
+
+// WithPageStore enables paginated document text instead of truncation.
+func (t *DocReadTool) WithPageStore(pages *PageStore) *DocReadTool {
+	t.pages = pages
+	return t
+}
+
+// WithOCR sets the OCR command for scanned PDFs: its arguments are split on
+// spaces, {file} is replaced by the PDF's path (appended when absent), and
+// its standard output is the text, pages separated by form feeds as
+// tesseract and pdftotext write them.
+func (t *DocReadTool) WithOCR(command string) *DocReadTool {
+	t.ocrCommand = strings.TrimSpace(command)
+	return t
+}
+
+func (t *DocReadTool) Name() string { return "doc_read" }
+func (t *DocReadTool) Description() string {
+	return "提取工作区中 PDF、DOCX、EPUB 文档的文字，带页码（PDF）、标题（DOCX）或章节（EPUB）标记。可用 pages 只读部分页/章节。扫描版 PDF 在配置了 OCR 时自动识别。"
+}
+
+func (t *DocReadTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "path", Type: "string", Description: "文档路径（.pdf / .docx / .epub）", Required: true},
+		tool.SchemaParam{Name: "pages", Type: "string", Description: "只读这些页（PDF）或章节（EPUB），如 \"3\"、\"1-5\"、\"2,7-9\"；默认全部", Required: false},
+		tool.SchemaParam{Name: "ocr", Type: "boolean", Description: "强制用 OCR 识别 PDF（默认仅在提取不到文字时使用）", Required: false},
+	)
+}
+
+func (t *DocReadTool) Init(_ context.Context) error { return nil }
+func (t *DocReadTool) Close() error                 { return nil }
+
+type docReadArgs struct {
+	Path  string `json:"path"`
+	Pages string `json:"pages"`
+	OCR   bool   `json:"ocr"`
+}
+
+// docSection is one page or chapter of extracted text.
+type docSection struct {
+	marker string // "第 3 页", "第 2 章 · 标题"; "" = no marker (DOCX)
+	text   string
+}
+
+// CacheFingerprint implements tool.Cacheable like file_read; extraction
+// (and OCR above all) is worth caching for an unchanged file.
+func (t *DocReadTool) CacheFingerprint(_ context.Context, args json.RawMessage) (string, bool) {
+	var a docReadArgs
+	if json.Unmarshal(args, &a) != nil {
+		return "", false
+	}
+	path, err := safeResolvePath(a.Path, t.workspaceDir)
+	if err != nil {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || time.Since(info.ModTime()) < racyWindow {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%d:%d", path, info.ModTime().UnixNano(), info.Size()), true
+}
+
+func (t *DocReadTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a docReadArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	if a.Path == "" {
+		return tool.ToolResult{Error: "path 不能为空"}, nil
+	}
+	path, err := safeResolvePath(a.Path, t.workspaceDir)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("文件不存在: %s", a.Path)}, nil
+	}
+	if info.IsDir() {
+		return tool.ToolResult{Error: fmt.Sprintf("%s 是目录", a.Path)}, nil
+	}
+	if info.Size() > docMaxFileBytes {
+		return tool.ToolResult{Error: fmt.Sprintf("文件过大（%d MB，上限 %d MB）", info.Size()>>20, docMaxFileBytes>>20)}, nil
+	}
+	selected, err := parsePageSelection(a.Pages)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+
+	var kind, unit, note string
+	var sections []docSection
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".pdf":
+		kind, unit = "PDF", "页"
+		if a.OCR && t.ocrCommand == "" {
+			return tool.ToolResult{Error: "未配置 OCR 命令（TOOL_DOC_OCR_COMMAND）"}, nil
+		}
+		if !a.OCR {
+			sections, err = extractPDF(path)
+			if err != nil {
+				return tool.ToolResult{Error: err.Error()}, nil
+			}
+		}
+		if a.OCR || !hasText(sections, selected) {
+			switch {
+			case t.ocrCommand != "":
+				ocr, err := t.runOCR(ctx, path)
+				if err != nil {
+					return tool.ToolResult{Error: err.Error()}, nil
+				}
+				sections, note = ocr, "（OCR 识别结果）"
+			case len(sections) > 0:
+				note = "（未提取到文字，可能是扫描件；配置 TOOL_DOC_OCR_COMMAND 后可用 OCR 识别）"
+			}
+		}
+	case ".docx":
+		kind = "DOCX"
+		if selected != nil {
+			return tool.ToolResult{Error: "pages 只适用于 PDF 和 EPUB"}, nil
+		}
+		text, err := extractDOCX(path)
+		if err != nil {
+			return tool.ToolResult{Error: err.Error()}, nil
+		}
+		sections = []docSection{{text: text}}
+	case ".epub":
+		kind, unit = "EPUB", "章"
+		sections, err = extractEPUB(path)
+		if err != nil {
+			return tool.ToolResult{Error: err.Error()}, nil
+		}
+	case ".doc":
+		return tool.ToolResult{Error: "不支持旧版 .doc 格式，请另存为 .docx 或 PDF"}, nil
+	default:
+		return tool.ToolResult{Error: fmt.Sprintf("不支持的文档类型 %q（支持 .pdf、.docx、.epub；纯文本请用 file_read）", ext)}, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📄 %s（%s", a.Path, kind)
+	if unit != "" {
+		fmt.Fprintf(&sb, "，共 %d %s", len(sections), unit)
+		if selected != nil {
+			fmt.Fprintf(&sb, "，显示 %s", a.Pages)
+		}
+	}
+	fmt.Fprintf(&sb, "）%s\n", note)
+	shown := 0
+	for i, s := range sections {
+		if selected != nil && !selected[i+1] {
+			continue
+		}
+		shown++
+		sb.WriteString("\n")
+		if s.marker != "" {
+			fmt.Fprintf(&sb, "--- %s ---\n", s.marker)
+		}
+		if s.text == "" {
+			sb.WriteString("（无文字）\n")
+			continue
+		}
+		sb.WriteString(s.text + "\n")
+	}
+	if shown == 0 {
+		fmt.Fprintf(&sb, "\n（所选范围 %s 超出文档，共 %d %s）\n", a.Pages, len(sections), unit)
+	}
+	return tool.ToolResult{Output: t.limitContent(sb.String())}, nil
+}
+
+// limitContent paginates the text when a PageStore is configured,
+// otherwise truncates it like web_reader.
+func (t *DocReadTool) limitContent(content string) string {
+	if t.pages != nil {
+		return t.pages.Paginate(t.Name(), content)
+	}
+	return truncateContent(content)
+}
+
+// parsePageSelection parses "3", "1-5" or "2,7-9" into 1-based numbers;
+// nil selects everything.
+func parsePageSelection(s string) (map[int]bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	sel := make(map[int]bool)
+	for _, part := range splitList(s) {
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		to, err2 := from, error(nil)
+		if isRange {
+			to, err2 = strconv.Atoi(strings.TrimSpace(hi))
+		}
+		if err1 != nil || err2 != nil || from < 1 || to < from || to-from >= docMaxSelectable {
+			return nil, fmt.Errorf("无效的 pages %q（示例：3、1-5、2,7-9）", s)
+		}
+		for n := from; n <= to; n++ {
+			sel[n] = true
+		}
+	}
+	return sel, nil
+}
+
+// hasText reports whether any selected section has text.
+func hasText(sections []docSection, selected map[int]bool) bool {
+	for i, s := range sections {
+		if (selected == nil || selected[i+1]) && s.text != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// runOCR runs the OCR command on a PDF; form feeds in its output separate
+// pages.
+func (t *DocReadTool) runOCR(ctx context.Context, pdfPath string) ([]docSection, error) {
+	fields := strings.Fields(t.ocrCommand)
+	hasFile := false
+	for i, f := range fields {
+		if strings.Contains(f, "{file}") {
+			fields[i] = strings.ReplaceAll(f, "{file}", pdfPath)
+			hasFile = true
+		}
+	}
+	if !hasFile {
+		fields = append(fields, pdfPath)
+	}
+	ctx, cancel := context.WithTimeout(ctx, docOCRTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	cmd.Dir = t.workspaceDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if ctx.Err() == context.DeadlineExceeded {
+			msg = fmt.Sprintf("超时（%v）", docOCRTimeout)
+		}
+		return nil, fmt.Errorf("OCR 失败: %v %s", err, truncateRunes(msg, 500))
+	}
+	out := stdout.String()
+	if len(out) > docOCRMaxOutput {
+		out = out[:docOCRMaxOutput]
+	}
+	pages := strings.Split(strings.TrimRight(out, "\f\n "), "\f")
+	sections := make([]docSection, len(pages))
+	for i, p := range pages {
+		sections[i] = docSection{marker: fmt.Sprintf("第 %d 页", i+1), text: strings.TrimSpace(collapseBlankLines(p))}
+	}
+	return sections, nil
+}
+
+// extractPDF returns the text of each page.
+func extractPDF(path string) ([]docSection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+	pages := doc.pages()
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("PDF 中找不到页面")
+	}
+	sections := make([]docSection, len(pages))
+	for i, p := range pages {
+		sections[i] = docSection{marker: fmt.Sprintf("第 %d 页", i+1), text: doc.pageText(p)}
+	}
+	return sections, nil
+}
+
+// ── DOCX ──
+
+// headingStyle matches the names (or IDs) of Word's heading styles.
+var headingStyle = regexp.MustCompile(`(?i)^(?:heading|标题)\s*([1-9])$`)
+
+// extractDOCX returns the text of word/document.xml: headings as Markdown
+// headings, table rows as | cells |, page breaks as a marker line.
+func extractDOCX(path string) (string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return "", fmt.Errorf("不是有效的 DOCX 文件: %v", err)
+	}
+	defer zr.Close()
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+	body := parts["word/document.xml"]
+	if body == nil {
+		return "", fmt.Errorf("不是有效的 DOCX 文件：缺少 word/document.xml")
+	}
+
+	// Heading levels by style ID, from the style names in styles.xml
+	levels := make(map[string]int)
+	if f := parts["word/styles.xml"]; f != nil {
+		var styles struct {
+			Styles []struct {
+				ID   string `xml:"styleId,attr"`
+				Name struct {
+					Val string `xml:"val,attr"`
+				} `xml:"name"`
+			} `xml:"style"`
+		}
+		if err := decodeZipXML(f, &styles); err == nil {
+			for _, s := range styles.Styles {
+				if lvl := headingLevel(s.Name.Val); lvl > 0 {
+					levels[s.ID] = lvl
+				}
+			}
+		}
+	}
+
+	rc, err := openZipPart(body)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	var out, para strings.Builder
+	var cell, row []string
+	level, tableDepth := 0, 0
+	inText := false
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("解析 DOCX 失败: %v", err)
+		}
+		switch e := tok.(type) {
+		case xml.StartElement:
+			switch e.Name.Local {
+			case "p":
+				para.Reset()
+				level = 0
+			case "pStyle":
+				if lvl, ok := levels[xmlAttr(e, "val")]; ok {
+					level = lvl
+				} else {
+					level = headingLevel(xmlAttr(e, "val"))
+				}
+			case "outlineLvl":
+				if n, err := strconv.Atoi(xmlAttr(e, "val")); err == nil && n < 9 && level == 0 {
+					level = n + 1
+				}
+			case "t":
+				inText = true
+			case "tab":
+				para.WriteString("\t")
+			case "br", "cr":
+				if xmlAttr(e, "type") == "page" {
+					out.WriteString("\n--- 分页 ---\n")
+				} else {
+					para.WriteString("\n")
+				}
+			case "tbl":
+				tableDepth++
+			case "tr":
+				row = nil
+			case "tc":
+				cell = nil
+			}
+		case xml.CharData:
+			if inText {
+				para.Write(e)
+			}
+		case xml.EndElement:
+			switch e.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text := strings.TrimSpace(para.String())
+				switch {
+				case tableDepth > 0:
+					if text != "" {
+						cell = append(cell, text)
+					}
+				case text == "":
+					out.WriteString("\n")
+				case level > 0:
+					fmt.Fprintf(&out, "\n%s %s\n\n", strings.Repeat("#", min(level, 6)), text)
+				default:
+					out.WriteString(text + "\n")
+				}
+			case "tc":
+				row = append(row, strings.ReplaceAll(strings.Join(cell, " "), "|", `\|`))
+			case "tr":
+				if tableDepth > 0 {
+					out.WriteString("| " + strings.Join(row, " | ") + " |\n")
+				}
+			case "tbl":
+				tableDepth--
+				out.WriteString("\n")
+			}
+		}
+	}
+	return strings.TrimSpace(collapseBlankLines(out.String())), nil
+}
+
+// headingLevel returns the level of a heading style name ("heading 2",
+// "Heading2", "标题 2"); "Title" is level 1; 0 for other styles.
+func headingLevel(name string) int {
+	if strings.EqualFold(name, "title") {
+		return 1
+	}
+	if m := headingStyle.FindStringSubmatch(strings.TrimSpace(name)); m != nil {
+		return int(m[1][0] - '0')
+	}
+	return 0
+}
+
+func xmlAttr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func openZipPart(f *zip.File) (io.ReadCloser, error) {
+	if f.UncompressedSize64 > docMaxPartBytes {
+		return nil, fmt.Errorf("%s 过大（%d MB）", f.Name, f.UncompressedSize64>>20)
+	}
+	return f.Open()
+}
+
+func decodeZipXML(f *zip.File, v any) error {
+	rc, err := openZipPart(f)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %v", f.Name, err)
+	}
+	return nil
+}
+
+// ── EPUB ──
+
+// extractEPUB returns the chapters of the spine in reading order.
+func extractEPUB(filePath string) ([]docSection, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("不是有效的 EPUB 文件: %v", err)
+	}
+	defer zr.Close()
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	cf := parts["META-INF/container.xml"]
+	if cf == nil {
+		return nil, fmt.Errorf("不是有效的 EPUB 文件：缺少 META-INF/container.xml")
+	}
+	if err := decodeZipXML(cf, &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 || parts[container.Rootfiles[0].FullPath] == nil {
+		return nil, fmt.Errorf("EPUB 中找不到 OPF 文件")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var opf struct {
+		Items []struct {
+			ID   string `xml:"id,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"manifest>item"`
+		Spine []struct {
+			IDRef string `xml:"idref,attr"`
+		} `xml:"spine>itemref"`
+	}
+	if err := decodeZipXML(parts[opfPath], &opf); err != nil {
+		return nil, err
+	}
+	hrefs := make(map[string]string, len(opf.Items))
+	for _, it := range opf.Items {
+		hrefs[it.ID] = it.Href
+	}
+
+	var sections []docSection
+	for _, ref := range opf.Spine {
+		href := hrefs[ref.IDRef]
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		f := parts[path.Join(path.Dir(opfPath), href)]
+		if href == "" || f == nil {
+			continue
+		}
+		rc, err := openZipPart(f)
+		if err != nil {
+			return nil, err
+		}
+		title, text := htmlToText(rc)
+		rc.Close()
+		marker := fmt.Sprintf("第 %d 章", len(sections)+1)
+		if title != "" {
+			marker += " · " + title
+		}
+		sections = append(sections, docSection{marker: marker, text: text})
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("EPUB 中没有章节")
+	}
+	return sections, nil
+}
+
+// htmlToText returns the text of an XHTML chapter with its headings as
+// Markdown headings, and the chapter title: the first heading, else <title>.
+func htmlToText(r io.Reader) (title, text string) {
+	z := html.NewTokenizer(r)
+	var sb strings.Builder
+	var docTitle, heading string
+	skip, inTitle, headingLevel := 0, false, 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if title = heading; title == "" {
+				title = docTitle
+			}
+			return strings.TrimSpace(title), strings.TrimSpace(collapseBlankLines(sb.String()))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case tag == "script" || tag == "style":
+				skip++
+			case tag == "title":
+				inTitle = true
+			case len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+				headingLevel = int(tag[1] - '0')
+				sb.WriteString("\n\n" + strings.Repeat("#", headingLevel) + " ")
+			case isBlockElement(tag):
+				sb.WriteString("\n")
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case tag == "script" || tag == "style":
+				skip = max(0, skip-1)
+			case tag == "title":
+				inTitle = false
+			case len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+				headingLevel = 0
+				sb.WriteString("\n\n")
+			case isBlockElement(tag):
+				sb.WriteString("\n")
+			}
+		case html.TextToken:
+			raw := string(z.Text())
+			switch {
+			case inTitle:
+				docTitle += raw
+			case skip > 0:
+			default:
+				t := strings.Join(strings.Fields(raw), " ")
+				if t == "" {
+					continue
+				}
+				if headingLevel > 0 && heading == "" {
+					heading = t
+				}
+				if strings.HasSuffix(raw, " ") || strings.HasSuffix(raw, "\n") {
+					t += " "
+				}
+				if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\n")) && !strings.HasSuffix(sb.String(), "\n") {
+					t = " " + t
+				}
+				sb.WriteString(t)
+			}
+		}
+	}
+}
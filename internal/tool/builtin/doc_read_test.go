@@ -0,0 +1,236 @@
+package builtin
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// buildPDF assembles a PDF from numbered object bodies (1-based); streams
+// are given as "<<dict>>\x00content" and get their /Length filled in.
+func buildPDF(objs ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n%\xe2\xe3\xcf\xd3\n")
+	for i, body := range objs {
+		fmt.Fprintf(&b, "%d 0 obj\n", i+1)
+		if dict, content, ok := strings.Cut(body, "\x00"); ok {
+			fmt.Fprintf(&b, "%s /Length %d >>\nstream\n%s\nendstream\n", strings.TrimSuffix(dict, ">>"), len(content), content)
+		} else {
+			b.WriteString(body + "\n")
+		}
+		b.WriteString("endobj\n")
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func deflate(s string) string {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write([]byte(s))
+	w.Close()
+	return b.String()
+}
+
+func samplePDF() []byte {
+	cmap := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"2 beginbfchar <0001> <4F60> <0002> <597D> endbfchar\n" +
+		"1 beginbfrange <0010> <0012> <0041> endbfrange\n" +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	// Object 12 (the CJK font) lives in object stream 8.
+	font12 := "<< /Type /Font /Subtype /Type0 /BaseFont /SimSun /Encoding /Identity-H /ToUnicode 7 0 R >>"
+	objStm := "12 0 " + font12
+	return buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R 10 0 R] /Count 3 /Resources << /Font << /F1 5 0 R /F2 12 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 6 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents [9 0 R] >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< >>\x00BT /F1 12 Tf 72 700 Td (Hello \\(PDF\\)) Tj 0 -14 Td [(Wor) -20 (ld) -500 (again)] TJ ET",
+		"<< /Filter /FlateDecode >>\x00"+deflate(cmap),
+		"<< /Type /ObjStm /N 1 /First 4 /Filter /FlateDecode >>\x00"+deflate(objStm),
+		"<< /Filter /FlateDecode >>\x00"+deflate("BT /F2 10 Tf 1 0 0 1 72 700 Tm <00010002> Tj 1 0 0 1 72 680 Tm <001000110012> Tj ET"),
+		"<< /Type /Page /Parent 2 0 R /Contents 11 0 R >>",
+		"<< >>\x00q 100 0 0 100 0 0 cm BI /W 1 /H 1 /BPC 8 /CS /G ID \xff EI Q",
+	)
+}
+
+func runDocRead(t *testing.T, tl *DocReadTool, args string) string {
+	t.Helper()
+	res, err := tl.Execute(context.Background(), json.RawMessage(args))
+	if err != nil || res.Error != "" {
+		t.Fatalf("Execute(%s) err=%v result.Error=%q", args, err, res.Error)
+	}
+	return res.Output
+}
+
+func TestDocRead_PDF(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "report.pdf"), samplePDF(), 0o644)
+	tl := NewDocReadTool(dir)
+
+	out := runDocRead(t, tl, `{"path":"report.pdf"}`)
+	want := "📄 report.pdf（PDF，共 3 页）\n\n--- 第 1 页 ---\nHello (PDF)\nWorld again\n\n--- 第 2 页 ---\n你好\nABC\n\n--- 第 3 页 ---\n（无文字）\n"
+	if out != want {
+		t.Errorf("output =\n%q\nwant\n%q", out, want)
+	}
+
+	out = runDocRead(t, tl, `{"path":"report.pdf","pages":"2"}`)
+	if !strings.Contains(out, "显示 2") || strings.Contains(out, "Hello") || !strings.Contains(out, "你好") {
+		t.Errorf("pages=2 output = %q", out)
+	}
+}
+
+func TestDocRead_ScannedPDF(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("OCR test command uses sh")
+	}
+	dir := t.TempDir()
+	scan := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		"<< >>\x00q 612 0 0 792 0 0 cm /Im0 Do Q",
+	)
+	os.WriteFile(filepath.Join(dir, "scan.pdf"), scan, 0o644)
+	os.WriteFile(filepath.Join(dir, "ocr.sh"), []byte("#!/bin/sh\nprintf 'read from %s\\fsecond page\\f' \"$(basename \"$1\")\"\n"), 0o755)
+
+	out := runDocRead(t, NewDocReadTool(dir), `{"path":"scan.pdf"}`)
+	if !strings.Contains(out, "未提取到文字，可能是扫描件") {
+		t.Errorf("without OCR output = %q", out)
+	}
+
+	tl := NewDocReadTool(dir).WithOCR("sh ocr.sh {file}")
+	out = runDocRead(t, tl, `{"path":"scan.pdf"}`)
+	want := "📄 scan.pdf（PDF，共 2 页）（OCR 识别结果）\n\n--- 第 1 页 ---\nread from scan.pdf\n\n--- 第 2 页 ---\nsecond page\n"
+	if out != want {
+		t.Errorf("OCR output =\n%q\nwant\n%q", out, want)
+	}
+
+	// ocr=true forces OCR even for PDFs with text
+	os.WriteFile(filepath.Join(dir, "report.pdf"), samplePDF(), 0o644)
+	if out := runDocRead(t, tl, `{"path":"report.pdf","ocr":true}`); !strings.Contains(out, "read from report.pdf") {
+		t.Errorf("forced OCR output = %q", out)
+	}
+	res, _ := NewDocReadTool(dir).Execute(context.Background(), json.RawMessage(`{"path":"report.pdf","ocr":true}`))
+	if !strings.Contains(res.Error, "TOOL_DOC_OCR_COMMAND") {
+		t.Errorf("ocr without command error = %q", res.Error)
+	}
+}
+
+func writeZip(t *testing.T, path string, parts map[string]string) {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDocRead_DOCX(t *testing.T) {
+	dir := t.TempDir()
+	const ns = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"`
+	writeZip(t, filepath.Join(dir, "spec.docx"), map[string]string{
+		"word/styles.xml": `<w:styles ` + ns + `><w:style w:styleId="1"><w:name w:val="heading 1"/></w:style>` +
+			`<w:style w:styleId="Normal"><w:name w:val="Normal"/></w:style></w:styles>`,
+		"word/document.xml": `<w:document ` + ns + `><w:body>` +
+			`<w:p><w:pPr><w:pStyle w:val="1"/></w:pPr><w:r><w:t>概述</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:t xml:space="preserve">第一段 </w:t></w:r><w:r><w:t>文字</w:t><w:tab/><w:t>续</w:t></w:r></w:p>` +
+			`<w:tbl><w:tr><w:tc><w:p><w:r><w:t>名称</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>数量</w:t></w:r></w:p></w:tc></w:tr>` +
+			`<w:tr><w:tc><w:p><w:r><w:t>a|b</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>3</w:t></w:r></w:p></w:tc></w:tr></w:tbl>` +
+			`<w:p><w:r><w:br w:type="page"/></w:r></w:p>` +
+			`<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>细节</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:t>结尾</w:t></w:r></w:p></w:body></w:document>`,
+	})
+
+	out := runDocRead(t, NewDocReadTool(dir), `{"path":"spec.docx"}`)
+	want := "📄 spec.docx（DOCX）\n\n# 概述\n\n第一段 文字\t续\n| 名称 | 数量 |\n| a\\|b | 3 |\n\n--- 分页 ---\n\n## 细节\n\n结尾\n"
+	if out != want {
+		t.Errorf("output =\n%q\nwant\n%q", out, want)
+	}
+	res, _ := NewDocReadTool(dir).Execute(context.Background(), json.RawMessage(`{"path":"spec.docx","pages":"1"}`))
+	if !strings.Contains(res.Error, "pages 只适用于") {
+		t.Errorf("docx pages error = %q", res.Error)
+	}
+}
+
+func TestDocRead_EPUB(t *testing.T) {
+	dir := t.TempDir()
+	writeZip(t, filepath.Join(dir, "book.epub"), map[string]string{
+		"mimetype":               "application/epub+zip",
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`,
+		"OEBPS/content.opf": `<package xmlns="http://www.idpf.org/2007/opf"><manifest>` +
+			`<item id="c1" href="text/ch%201.xhtml"/><item id="c2" href="text/ch2.xhtml"/><item id="css" href="s.css"/></manifest>` +
+			`<spine><itemref idref="c2"/><itemref idref="c1"/></spine></package>`,
+		"OEBPS/text/ch 1.xhtml": `<html><head><title>Second</title><style>p{}</style></head><body><p>Body <b>two</b>.</p></body></html>`,
+		"OEBPS/text/ch2.xhtml":  `<html><head><title>x</title></head><body><h1>Opening</h1><p>First  line</p><p>Next</p><script>var x</script></body></html>`,
+	})
+
+	tl := NewDocReadTool(dir)
+	out := runDocRead(t, tl, `{"path":"book.epub"}`)
+	want := "📄 book.epub（EPUB，共 2 章）\n\n--- 第 1 章 · Opening ---\n# Opening\n\nFirst line\n\nNext\n\n--- 第 2 章 · Second ---\nBody two.\n"
+	if out != want {
+		t.Errorf("output =\n%q\nwant\n%q", out, want)
+	}
+	if out := runDocRead(t, tl, `{"path":"book.epub","pages":"5"}`); !strings.Contains(out, "所选范围 5 超出文档") {
+		t.Errorf("out of range output = %q", out)
+	}
+}
+
+func TestDocRead_Errors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hi"), 0o644)
+	os.WriteFile(filepath.Join(dir, "fake.pdf"), []byte("not a pdf"), 0o644)
+	tl := NewDocReadTool(dir)
+	for args, want := range map[string]string{
+		`{"path":"notes.txt"}`:              "不支持的文档类型",
+		`{"path":"fake.pdf"}`:               "不是有效的 PDF 文件",
+		`{"path":"missing.pdf"}`:            "文件不存在",
+		`{"path":"fake.pdf","pages":"3-1"}`: "无效的 pages",
+		`{"path":"../x.pdf"}`:               "",
+	} {
+		res, err := tl.Execute(context.Background(), json.RawMessage(args))
+		if err != nil {
+			t.Fatalf("Execute(%s) err = %v", args, err)
+		}
+		if res.Error == "" || !strings.Contains(res.Error, want) {
+			t.Errorf("Execute(%s) error = %q, want %q", args, res.Error, want)
+		}
+	}
+}
+
+func TestDocRead_Paginates(t *testing.T) {
+	dir := t.TempDir()
+	long := strings.Repeat("<p>"+strings.Repeat("word ", 200)+"</p>", 100)
+	writeZip(t, filepath.Join(dir, "long.epub"), map[string]string{
+		"META-INF/container.xml": `<container><rootfiles><rootfile full-path="c.opf"/></rootfiles></container>`,
+		"c.opf":                  `<package><manifest><item id="a" href="a.html"/></manifest><spine><itemref idref="a"/></spine></package>`,
+		"a.html":                 "<html><body>" + long + "</body></html>",
+	})
+	out := runDocRead(t, NewDocReadTool(dir).WithPageStore(NewPageStore()), `{"path":"long.epub"}`)
+	if !strings.Contains(out, "fetch_more") {
+		t.Errorf("long document should be paginated, got %d chars ending %q", len(out), out[len(out)-200:])
+	}
+	if out := runDocRead(t, NewDocReadTool(dir), `{"path":"long.epub"}`); !strings.HasSuffix(out, "...(内容截断)") {
+		t.Errorf("without a page store the text should be truncated")
+	}
+}
@@ -0,0 +1,246 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// env_set — 按白名单写入 .env 环境变量
+//
+// 创建 MCP 服务器或技能时常需要设置 API key 等环境变量。config_edit 只能
+// 按原始文本改 .env，本工具则只允许白名单内的键，一次写入多个变量，并以
+// 临时文件 + rename 原子替换 .env。Omega 自身的启动配置（MCP_CONFIG、
+// LLM_* 等）即使匹配白名单也拒绝写入，以免 agent 把 MCP 配置或模型端点
+// 改到它控制的地址。写入不改变运行中的进程：结果会标出每个变量是执行
+// /reload 后生效，还是需要重启服务。
+// ─────────────────────────────────────────────────────────────────────────────
+
+// DefaultEnvSetAllow is the allowlist used when TOOL_ENV_SET_ALLOW is unset:
+// the search API keys and the variables of MCP servers and skills.
+var DefaultEnvSetAllow = []string{"TAVILY_API_KEY", "BRAVE_API_KEY", "MCP_*", "SKILL_*"}
+
+// envKeyPattern is a portable environment variable name.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// EnvSetTool writes allowlisted variables to a .env file.
+type EnvSetTool struct {
+	envPath  string
+	allow    []string // path.Match patterns of settable keys
+	reserved []string // Omega's own settings, refused whatever allow says
+	restart  []string // keys read only at startup; the rest apply on /reload
+	mu       sync.Mutex
+}
+
+// NewEnvSetTool creates the env_set tool writing to the .env file at
+// envPath. allow lists the settable keys as path.Match patterns ("MCP_*");
+// nil = DefaultEnvSetAllow.
+func NewEnvSetTool(envPath string, allow []string) *EnvSetTool {
+	if allow == nil {
+		allow = DefaultEnvSetAllow
+	}
+	return &EnvSetTool{envPath: envPath, allow: allow}
+}
+
+// WithReservedKeys refuses the keys matching patterns (path.Match) even
+// when the allowlist matches them: Omega's own settings, so that a broad
+// allow pattern such as "MCP_*" cannot reach MCP_CONFIG.
+func (t *EnvSetTool) WithReservedKeys(patterns []string) *EnvSetTool {
+	t.reserved = patterns
+	return t
+}
+
+// WithRestartKeys marks the keys (path.Match patterns) that are read once
+// at startup and so need a restart. Other keys take effect on /reload.
+func (t *EnvSetTool) WithRestartKeys(patterns []string) *EnvSetTool {
+	t.restart = patterns
+	return t
+}
+
+func (t *EnvSetTool) Name() string { return "env_set" }
+func (t *EnvSetTool) Description() string {
+	return fmt.Sprintf(
+		"设置环境变量并写入 .env（如 MCP 服务器或技能需要的 API key）。只能设置白名单内的变量：%s（Omega 自身的配置项除外）。写入后结果会标出哪些变量执行 /reload 后生效、哪些需要重启服务",
+		strings.Join(t.allow, ", "),
+	)
+}
+
+func (t *EnvSetTool) InputSchema() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"vars": {
+				"type": "object",
+				"description": "要设置的变量，如 {\"MCP_GITHUB_TOKEN\": \"ghp_...\"}；值为空字符串表示清空",
+				"additionalProperties": {"type": "string"}
+			}
+		},
+		"required": ["vars"]
+	}`)
+}
+
+func (t *EnvSetTool) Init(_ context.Context) error { return nil }
+func (t *EnvSetTool) Close() error                 { return nil }
+
+type envSetArgs struct {
+	Vars map[string]string `json:"vars"`
+}
+
+func (t *EnvSetTool) Execute(_ context.Context, raw json.RawMessage) (tool.ToolResult, error) {
+	var a envSetArgs
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	if len(a.Vars) == 0 {
+		return tool.ToolResult{Error: "vars 不能为空"}, nil
+	}
+
+	keys := make([]string, 0, len(a.Vars))
+	for k := range a.Vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	// Validate everything first: one bad key writes nothing
+	for _, k := range keys {
+		if !envKeyPattern.MatchString(k) {
+			return tool.ToolResult{Error: fmt.Sprintf("变量名 %q 不合法（只能包含字母、数字和下划线，且不以数字开头）", k)}, nil
+		}
+		if !matchAnyKey(t.allow, k) {
+			return tool.ToolResult{Error: fmt.Sprintf("变量 %s 不在白名单中，未写入任何变量。允许的变量: %s", k, strings.Join(t.allow, ", "))}, nil
+		}
+		if matchAnyKey(t.reserved, k) {
+			return tool.ToolResult{Error: fmt.Sprintf("安全限制: %s 是 Omega 自身的配置项，不能通过 env_set 修改，未写入任何变量", k)}, nil
+		}
+		if strings.ContainsAny(a.Vars[k], "\r\n\x00") {
+			return tool.ToolResult{Error: fmt.Sprintf("变量 %s 的值不能包含换行", k)}, nil
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	added, err := writeEnvVars(t.envPath, a.Vars)
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("写入 .env 失败: %v", err)}, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "已写入 %s（%d 个变量）：\n", t.envPath, len(keys))
+	restart := 0
+	for _, k := range keys {
+		verb := "已更新"
+		if added[k] {
+			verb = "已新增"
+		}
+		effect := "执行 /reload 后生效"
+		if matchAnyKey(t.restart, k) {
+			effect = "需重启服务后生效"
+			restart++
+		}
+		fmt.Fprintf(&sb, "  • %s %s — %s\n", k, verb, effect)
+	}
+	switch {
+	case restart == 0:
+		sb.WriteString("提示：请用户执行 /reload 使变量生效。")
+	case restart < len(keys):
+		sb.WriteString("提示：请用户执行 /reload，标为需重启的变量在重启服务后生效。")
+	default:
+		sb.WriteString("提示：这些变量只在启动时读取，请用户重启服务。")
+	}
+	return tool.ToolResult{Output: sb.String()}, nil
+}
+
+func matchAnyKey(patterns []string, key string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writeEnvVars sets vars in the .env file at envPath, replacing existing
+// assignments in place and appending new ones, with comments and blank
+// lines preserved. The file is replaced atomically (temp file + rename) so
+// a crash never leaves a torn .env. Returns the keys that were appended.
+func writeEnvVars(envPath string, vars map[string]string) (map[string]bool, error) {
+	data, err := os.ReadFile(envPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	mode := os.FileMode(0o600) // a new .env holds secrets
+	if fi, err := os.Stat(envPath); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	var lines []string
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	}
+	done := map[string]bool{}
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		trimmed := strings.TrimPrefix(strings.TrimSpace(line), "export ")
+		eq := strings.Index(trimmed, "=")
+		if strings.HasPrefix(trimmed, "#") || eq < 0 {
+			continue
+		}
+		k := strings.TrimSpace(trimmed[:eq])
+		if v, ok := vars[k]; ok && !done[k] {
+			lines[i] = k + "=" + quoteEnvValue(v)
+			done[k] = true
+		}
+	}
+
+	added := map[string]bool{}
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		if !done[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		lines = append(lines, k+"="+quoteEnvValue(vars[k]))
+		added[k] = true
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(envPath), ".env-*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), envPath); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// quoteEnvValue double-quotes values that a .env parser would otherwise
+// cut or reinterpret (spaces, #, quotes, $).
+func quoteEnvValue(v string) string {
+	if v == "" || !strings.ContainsAny(v, " \t#\"'`$\\=") {
+		return v
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`).Replace(v) + `"`
+}
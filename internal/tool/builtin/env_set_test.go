@@ -0,0 +1,117 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/joho/godotenv"
+)
+
+func execEnvSet(t *testing.T, tl *EnvSetTool, vars map[string]string) (string, string) {
+	t.Helper()
+	raw, _ := json.Marshal(map[string]any{"vars": vars})
+	result, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	return result.Output, result.Error
+}
+
+func TestEnvSet_WritesAndPreserves(t *testing.T) {
+	path, _ := writeTempEnv(t, "# keys\nTAVILY_API_KEY=old\r\n\nLLM_MODEL=gpt\n")
+	tl := NewEnvSetTool(path, nil).WithReservedKeys([]string{"LLM_*", "MCP_CONFIG"})
+
+	out, errMsg := execEnvSet(t, tl, map[string]string{
+		"TAVILY_API_KEY":   "tvly-new",
+		"MCP_GITHUB_TOKEN": `ghp_a b#c"$HOME`,
+	})
+	if errMsg != "" {
+		t.Fatalf("error: %s", errMsg)
+	}
+	if !strings.Contains(out, "TAVILY_API_KEY 已更新 — 执行 /reload 后生效") || !strings.Contains(out, "MCP_GITHUB_TOKEN 已新增 — 执行 /reload 后生效") {
+		t.Errorf("output = %q", out)
+	}
+	if strings.Contains(out, "tvly-new") {
+		t.Error("output should not echo the values")
+	}
+
+	data, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# keys\nTAVILY_API_KEY=tvly-new\n\nLLM_MODEL=gpt\n") {
+		t.Errorf("comments or order lost:\n%s", data)
+	}
+	vars, err := godotenv.Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars["MCP_GITHUB_TOKEN"] != `ghp_a b#c"$HOME` || vars["LLM_MODEL"] != "gpt" {
+		t.Errorf("round trip = %v", vars)
+	}
+	if fi, _ := os.Stat(path); fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, want the original 0600", fi.Mode().Perm())
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".env-*")); len(tmps) != 0 {
+		t.Errorf("temp files left: %v", tmps)
+	}
+}
+
+func TestEnvSet_RejectsWholeBatch(t *testing.T) {
+	path, _ := writeTempEnv(t, "A=1\n")
+	tl := NewEnvSetTool(path, []string{"MCP_*", "LLM_MODEL"})
+	for _, vars := range []map[string]string{
+		{"MCP_TOKEN": "x", "LLM_BASE_URL": "http://evil"}, // not allowlisted
+		{"MCP_TOKEN": "x\nLLM_BASE_URL=http://evil"},      // newline injection
+		{"MCP-TOKEN": "x"}, // invalid name
+		{},
+	} {
+		if _, errMsg := execEnvSet(t, tl, vars); errMsg == "" {
+			t.Errorf("vars %v accepted", vars)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "A=1\n" {
+		t.Errorf("rejected call modified the file:\n%s", data)
+	}
+}
+
+func TestEnvSet_MarksRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	tl := NewEnvSetTool(path, []string{"*"}).WithReservedKeys([]string{"TOOL_*"}).WithRestartKeys([]string{"HTTPS_PROXY"})
+
+	out, errMsg := execEnvSet(t, tl, map[string]string{"HTTPS_PROXY": "http://proxy:3128"})
+	if errMsg != "" || !strings.Contains(out, "HTTPS_PROXY 已新增 — 需重启服务后生效") || !strings.Contains(out, "请用户重启服务") {
+		t.Errorf("out = %q, err = %q", out, errMsg)
+	}
+	out, errMsg = execEnvSet(t, tl, map[string]string{"HTTPS_PROXY": "", "MCP_GITHUB_TOKEN": "ghp_x"})
+	if errMsg != "" || !strings.Contains(out, "HTTPS_PROXY 已更新 — 需重启服务后生效") ||
+		!strings.Contains(out, "MCP_GITHUB_TOKEN 已新增 — 执行 /reload 后生效") || !strings.Contains(out, "标为需重启的变量") {
+		t.Errorf("mixed out = %q, err = %q", out, errMsg)
+	}
+}
+
+func TestEnvSet_RefusesReservedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env") // created on first write
+	tl := NewEnvSetTool(path, nil).WithReservedKeys([]string{"LLM_*", "MCP_CONFIG", "MCP_SKILL_VERSIONS", "TOOL_*"})
+
+	// The default "MCP_*" allow pattern does not reach Omega's own MCP settings
+	for _, vars := range []map[string]string{
+		{"MCP_CONFIG": "/tmp/evil_mcp.json"},
+		{"MCP_GITHUB_TOKEN": "ghp_x", "MCP_SKILL_VERSIONS": "0"},
+	} {
+		if _, errMsg := execEnvSet(t, tl, vars); !strings.Contains(errMsg, "Omega 自身的配置项") {
+			t.Errorf("vars %v: err = %q, want refused", vars, errMsg)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("refused calls wrote the file: %v", err)
+	}
+
+	if _, errMsg := execEnvSet(t, tl, map[string]string{"MCP_GITHUB_TOKEN": "ghp_x"}); errMsg != "" {
+		t.Fatalf("MCP server variable refused: %s", errMsg)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("new .env: %v %v", fi, err)
+	}
+}
@@ -0,0 +1,221 @@
+package builtin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketomega/pocket-omega/internal/tokenizer"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	// pageTokenBudget is the token budget of a single page, counted with the
+	// process-wide tokenizer (see tokenizer.SetDefault) like the agent's
+	// context budgets, so one page never crowds out the context window.
+	pageTokenBudget = 2000
+	// pageMaxTokenBytes bounds the text the tokenizer looks at per page to
+	// this many bytes per token: tokens are rarely longer, and paging a
+	// large output must not re-encode all of it for every page.
+	pageMaxTokenBytes = 16
+
+	pageStoreMaxEntries = 64               // oldest paged result evicted beyond this
+	pageStoreTTL        = 30 * time.Minute // unread pages expire after this
+)
+
+// pagedResult holds the remaining pages of one large tool output.
+type pagedResult struct {
+	toolName string
+	pages    []string
+	next     int // index of the next page to return
+	created  time.Time
+}
+
+// PageStore keeps continuation pages for paginated tool outputs.
+// Tools that produce large results return the first page plus a continuation
+// token; fetch_more retrieves subsequent pages on demand. Safe for concurrent use.
+type PageStore struct {
+	mu      sync.Mutex
+	entries map[string]*pagedResult
+	order   []string // insertion order for eviction
+	now     func() time.Time
+}
+
+// NewPageStore creates an empty PageStore.
+func NewPageStore() *PageStore {
+	return &PageStore{
+		entries: make(map[string]*pagedResult),
+		now:     time.Now,
+	}
+}
+
+// Paginate splits output into token-budgeted pages. When the output fits in a
+// single page it is returned unchanged. Otherwise the first page is returned
+// with a footer carrying a continuation token for fetch_more.
+// A nil store returns output unchanged; callers keep their own limits.
+func (s *PageStore) Paginate(toolName, output string) string {
+	if s == nil {
+		return output
+	}
+	pages := splitPages(output, pageTokenBudget)
+	if len(pages) <= 1 {
+		return output
+	}
+
+	token := newPageToken()
+	s.mu.Lock()
+	s.evictLocked()
+	s.entries[token] = &pagedResult{toolName: toolName, pages: pages, next: 1, created: s.now()}
+	s.order = append(s.order, token)
+	s.mu.Unlock()
+
+	return pages[0] + pageFooter(1, len(pages), token)
+}
+
+// Next returns the next page for token and advances the cursor.
+// The entry is dropped once its last page has been returned.
+func (s *PageStore) Next(token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[token]
+	if !ok || s.now().Sub(entry.created) > pageStoreTTL {
+		s.removeLocked(token)
+		return "", fmt.Errorf("续页令牌无效或已过期: %s — 请重新调用原工具", token)
+	}
+
+	idx := entry.next
+	page := entry.pages[idx]
+	entry.next++
+	if entry.next >= len(entry.pages) {
+		s.removeLocked(token)
+		return page + fmt.Sprintf("\n---\n[%s 第 %d/%d 页，已全部返回]", entry.toolName, idx+1, len(entry.pages)), nil
+	}
+	return page + pageFooter(idx+1, len(entry.pages), token), nil
+}
+
+// Len returns the number of outstanding paged results.
+func (s *PageStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// evictLocked drops expired entries and enforces pageStoreMaxEntries.
+func (s *PageStore) evictLocked() {
+	now := s.now()
+	for _, token := range append([]string(nil), s.order...) {
+		if e, ok := s.entries[token]; ok && now.Sub(e.created) > pageStoreTTL {
+			s.removeLocked(token)
+		}
+	}
+	for len(s.order) >= pageStoreMaxEntries {
+		s.removeLocked(s.order[0])
+	}
+}
+
+func (s *PageStore) removeLocked(token string) {
+	delete(s.entries, token)
+	for i, t := range s.order {
+		if t == token {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// pageFooter renders the continuation hint appended to non-final pages.
+func pageFooter(page, total int, token string) string {
+	return fmt.Sprintf("\n---\n[第 %d/%d 页] 输出较长，如需后续内容请调用 fetch_more(token=%q)", page, total, token)
+}
+
+// splitPages splits s into pages of at most maxTokens tokens, preferring
+// line boundaries. A single line longer than maxTokens is hard-split.
+func splitPages(s string, maxTokens int) []string {
+	tk := tokenizer.Default()
+	if tk.Count(s) <= maxTokens {
+		return []string{s}
+	}
+	window := maxTokens * pageMaxTokenBytes
+	var pages []string
+	for s != "" {
+		head := s
+		if len(head) > window {
+			end := window
+			for end > 0 && !utf8.RuneStart(s[end]) {
+				end--
+			}
+			head = s[:end]
+		}
+		page := tk.Truncate(head, maxTokens)
+		if len(page) < len(s) {
+			if i := strings.LastIndexByte(page, '\n'); i >= 0 {
+				page = page[:i+1]
+			} else if page == "" {
+				_, size := utf8.DecodeRuneInString(s) // always make progress
+				page = s[:size]
+			}
+		}
+		pages = append(pages, page)
+		s = s[len(page):]
+	}
+	return pages
+}
+
+// newPageToken returns a short random continuation token.
+func newPageToken() string {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("p%d", time.Now().UnixNano())
+	}
+	return "p" + hex.EncodeToString(b)
+}
+
+// ── fetch_more ──
+
+// FetchMoreTool returns the next page of a paginated tool output.
+type FetchMoreTool struct {
+	pages *PageStore
+}
+
+func NewFetchMoreTool(pages *PageStore) *FetchMoreTool {
+	return &FetchMoreTool{pages: pages}
+}
+
+func (t *FetchMoreTool) Name() string { return "fetch_more" }
+func (t *FetchMoreTool) Description() string {
+	return "获取分页工具输出的下一页。当 file_grep、file_list（递归）、web_reader、doc_read 的结果末尾出现续页令牌时使用。仅在确实需要后续内容时调用。"
+}
+
+func (t *FetchMoreTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "token", Type: "string", Description: "上一页末尾给出的续页令牌", Required: true},
+	)
+}
+
+func (t *FetchMoreTool) Init(_ context.Context) error { return nil }
+func (t *FetchMoreTool) Close() error                 { return nil }
+
+func (t *FetchMoreTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	token := strings.TrimSpace(a.Token)
+	if token == "" {
+		return tool.ToolResult{Error: "token 不能为空"}, nil
+	}
+	page, err := t.pages.Next(token)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	return tool.ToolResult{Output: page}, nil
+}
@@ -0,0 +1,244 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tokenizer"
+)
+
+var pageTokenRe = regexp.MustCompile(`fetch_more\(token="(p[0-9a-f]+)"\)`)
+
+// extractPageToken returns the continuation token from a page footer.
+func extractPageToken(t *testing.T, output string) string {
+	t.Helper()
+	m := pageTokenRe.FindStringSubmatch(output)
+	if m == nil {
+		t.Fatalf("no continuation token in output tail: %q", output[max(0, len(output)-200):])
+	}
+	return m[1]
+}
+
+func TestSplitPages_LineBoundaries(t *testing.T) {
+	line := strings.Repeat("a", 15) + "\n" // 4 heuristic tokens
+	s := strings.Repeat(line, 10)          // 160 runes, 41 tokens
+	pages := splitPages(s, 13)             // 3 lines per page (12 tokens + 1)
+	if len(pages) != 4 {
+		t.Fatalf("expected 4 pages, got %d", len(pages))
+	}
+	if strings.Join(pages, "") != s {
+		t.Error("pages must reassemble to the original")
+	}
+	for i, p := range pages {
+		if !strings.HasSuffix(p, "\n") {
+			t.Errorf("page %d should end on a line boundary: %q", i, p)
+		}
+	}
+}
+
+func TestSplitPages_LongLineHardSplit(t *testing.T) {
+	s := strings.Repeat("中", 45) // 2 CJK runes per heuristic token
+	pages := splitPages(s, 10)
+	if len(pages) != 3 || strings.Join(pages, "") != s {
+		t.Errorf("unexpected split: %d pages", len(pages))
+	}
+}
+
+// TestSplitPages_TokenizerBudget checks that pages follow the configured
+// tokenizer: CJK text is about one BPE token per rune, twice the heuristic.
+func TestSplitPages_TokenizerBudget(t *testing.T) {
+	bpe, err := tokenizer.Encoding("o200k_base")
+	if err != nil {
+		t.Skip(err)
+	}
+	tokenizer.SetDefault(bpe)
+	t.Cleanup(func() { tokenizer.SetDefault(nil) })
+
+	var sb strings.Builder
+	for i := range 400 {
+		fmt.Fprintf(&sb, "第%d行：分页按模型的词表计算令牌，中文正文每个字大约就是一个令牌。\n", i)
+	}
+	s := sb.String()
+	pages := splitPages(s, pageTokenBudget)
+	if len(pages) < 2 || strings.Join(pages, "") != s {
+		t.Fatalf("unexpected split: %d pages", len(pages))
+	}
+	for i, p := range pages {
+		if n := bpe.Count(p); n > pageTokenBudget {
+			t.Errorf("page %d: %d tokens, budget %d", i, n, pageTokenBudget)
+		}
+	}
+}
+
+func TestPageStore_SmallOutputUnchanged(t *testing.T) {
+	s := NewPageStore()
+	if got := s.Paginate("file_grep", "short"); got != "short" {
+		t.Errorf("got %q", got)
+	}
+	if s.Len() != 0 {
+		t.Error("small output must not be stored")
+	}
+}
+
+func TestPageStore_NilStorePassthrough(t *testing.T) {
+	var s *PageStore
+	big := strings.Repeat("x\n", 4*pageTokenBudget)
+	if got := s.Paginate("file_grep", big); got != big {
+		t.Error("nil store should return output unchanged")
+	}
+}
+
+func TestFetchMore_WalksAllPages(t *testing.T) {
+	s := NewPageStore()
+	var sb strings.Builder
+	for i := 0; i < 3000; i++ {
+		sb.WriteString(fmt.Sprintf("line-%04d\n", i))
+	}
+	full := sb.String()
+
+	first := s.Paginate("file_grep", full)
+	if !strings.Contains(first, "[第 1/") {
+		t.Fatalf("first page missing footer")
+	}
+	token := extractPageToken(t, first)
+
+	fm := NewFetchMoreTool(s)
+	args, _ := json.Marshal(map[string]string{"token": token})
+	var last string
+	for i := 0; i < 20; i++ {
+		res, err := fm.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if res.Error != "" {
+			t.Fatalf("unexpected tool error: %s", res.Error)
+		}
+		last = res.Output
+		if strings.Contains(last, "已全部返回") {
+			break
+		}
+	}
+	if !strings.Contains(last, "line-2999") {
+		t.Errorf("final page should contain the last line, got tail %q", last[max(0, len(last)-100):])
+	}
+
+	// Token is consumed after the final page
+	res, _ := fm.Execute(context.Background(), args)
+	if res.Error == "" {
+		t.Error("expected error for exhausted token")
+	}
+}
+
+func TestFetchMore_EmptyToken(t *testing.T) {
+	fm := NewFetchMoreTool(NewPageStore())
+	res, _ := fm.Execute(context.Background(), json.RawMessage(`{"token":" "}`))
+	if res.Error == "" {
+		t.Error("expected error for empty token")
+	}
+}
+
+func TestPageStore_ExpiredToken(t *testing.T) {
+	s := NewPageStore()
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	out := s.Paginate("web_reader", strings.Repeat("y\n", 4*pageTokenBudget))
+	token := extractPageToken(t, out)
+
+	s.now = func() time.Time { return now.Add(pageStoreTTL + time.Second) }
+	if _, err := s.Next(token); err == nil {
+		t.Error("expected expired token error")
+	}
+}
+
+func TestPageStore_EvictsOldest(t *testing.T) {
+	s := NewPageStore()
+	big := strings.Repeat("z\n", 4*pageTokenBudget)
+	first := extractPageToken(t, s.Paginate("file_list", big))
+	for i := 0; i < pageStoreMaxEntries; i++ {
+		s.Paginate("file_list", big)
+	}
+	if s.Len() != pageStoreMaxEntries {
+		t.Errorf("Len = %d, want %d", s.Len(), pageStoreMaxEntries)
+	}
+	if _, err := s.Next(first); err == nil {
+		t.Error("oldest entry should have been evicted")
+	}
+}
+
+func TestFileGrepTool_Paginated(t *testing.T) {
+	workspace := t.TempDir()
+	var sb strings.Builder
+	for i := 0; i < 600; i++ {
+		sb.WriteString(fmt.Sprintf("match line number %d with some padding text\n", i))
+	}
+	os.WriteFile(filepath.Join(workspace, "big.txt"), []byte(sb.String()), 0644)
+
+	pages := NewPageStore()
+	tool := NewFileGrepTool(workspace).WithPageStore(pages)
+	args, _ := json.Marshal(fileGrepArgs{Pattern: "match", MaxResults: 500})
+	result, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(result.Output, "fetch_more") {
+		t.Errorf("expected paginated output with fetch_more hint")
+	}
+	if n := tokenizer.Default().Count(result.Output); n > pageTokenBudget+100 {
+		t.Errorf("first page too large: %d tokens", n)
+	}
+	if pages.Len() != 1 {
+		t.Errorf("expected 1 stored paged result, got %d", pages.Len())
+	}
+}
+
+func TestFileListTool_RecursivePaginated(t *testing.T) {
+	workspace := t.TempDir()
+	for d := 0; d < 10; d++ {
+		dir := filepath.Join(workspace, fmt.Sprintf("dir%02d", d))
+		os.MkdirAll(dir, 0755)
+		for f := 0; f < 40; f++ {
+			os.WriteFile(filepath.Join(dir, fmt.Sprintf("file_with_long_name_%03d.txt", f)), nil, 0644)
+		}
+	}
+	os.MkdirAll(filepath.Join(workspace, "node_modules", "pkg"), 0755)
+
+	pages := NewPageStore()
+	tool := NewFileListTool(workspace).WithPageStore(pages)
+	args, _ := json.Marshal(fileListArgs{Path: ".", Recursive: true})
+	result, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Error != "" {
+		t.Fatalf("unexpected tool error: %s", result.Error)
+	}
+	if !strings.Contains(result.Output, "dir00/file_with_long_name_000.txt") {
+		t.Errorf("expected relative nested path in output")
+	}
+	if strings.Contains(result.Output, "node_modules") {
+		t.Error("skipDirs should be excluded from recursive listing")
+	}
+	if !strings.Contains(result.Output, "fetch_more") {
+		t.Error("expected continuation token for large recursive listing")
+	}
+}
+
+func TestFileListTool_RecursiveWithoutPagesCapped(t *testing.T) {
+	workspace := t.TempDir()
+	for i := 0; i <= maxListItems; i++ {
+		os.WriteFile(filepath.Join(workspace, fmt.Sprintf("f%03d.txt", i)), nil, 0644)
+	}
+	tool := NewFileListTool(workspace)
+	args, _ := json.Marshal(fileListArgs{Path: ".", Recursive: true})
+	result, _ := tool.Execute(context.Background(), args)
+	if !strings.Contains(result.Output, "已达上限") {
+		t.Errorf("expected cap notice, got tail %q", result.Output[max(0, len(result.Output)-100):])
+	}
+}
@@ -9,14 +9,18 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/pocketomega/pocket-omega/internal/ignore"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
 const (
-	maxFileSize    = 1 << 20 // 1MB — read limit
-	maxWriteSize   = 1 << 20 // 1MB — reject oversized content before filesystem access (C-3)
+	maxFileSize    = 1 << 20  // 1MB — read limit
+	maxScanSize    = 16 << 20 // 16MB — file size limit for file_read range and outline modes
+	maxWriteSize   = 1 << 20  // 1MB — reject oversized content before filesystem access (C-3)
 	maxListItems   = 100
+	maxListRecurse = 5000 // entry cap for recursive file_list (paginated via fetch_more)
 	maxFindResults = 50
 )
 
@@ -30,12 +34,17 @@ func NewFileReadTool(workspaceDir string) *FileReadTool {
 	return &FileReadTool{workspaceDir: workspaceDir}
 }
 
-func (t *FileReadTool) Name() string        { return "file_read" }
-func (t *FileReadTool) Description() string { return "读取指定文件的内容" }
+func (t *FileReadTool) Name() string { return "file_read" }
+func (t *FileReadTool) Description() string {
+	return "读取指定文件的内容。大文件可先用 outline=true 查看函数/类签名及行号，再用 start_line/end_line 只读取需要的行"
+}
 
 func (t *FileReadTool) InputSchema() json.RawMessage {
 	return tool.BuildSchema(
 		tool.SchemaParam{Name: "path", Type: "string", Description: "文件路径", Required: true},
+		tool.SchemaParam{Name: "start_line", Type: "integer", Description: "起始行号（从 1 开始，含）；省略则从第 1 行开始", Required: false},
+		tool.SchemaParam{Name: "end_line", Type: "integer", Description: "结束行号（含）；省略则读到文件末尾", Required: false},
+		tool.SchemaParam{Name: "outline", Type: "boolean", Description: "只返回函数/类等定义的签名及行号（默认 false）", Required: false},
 	)
 }
 
@@ -46,11 +55,25 @@ type filePathArgs struct {
 	Path string `json:"path"`
 }
 
+type fileReadArgs struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Outline   bool   `json:"outline"`
+}
+
 func (t *FileReadTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
-	var a filePathArgs
+	var a fileReadArgs
 	if err := json.Unmarshal(args, &a); err != nil {
 		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
 	}
+	if a.StartLine < 0 || a.EndLine < 0 {
+		return tool.ToolResult{Error: "start_line/end_line 必须为正整数"}, nil
+	}
+	if a.EndLine > 0 && a.StartLine > a.EndLine {
+		return tool.ToolResult{Error: fmt.Sprintf("start_line (%d) 不能大于 end_line (%d)", a.StartLine, a.EndLine)}, nil
+	}
+	partial := a.Outline || a.StartLine > 0 || a.EndLine > 0
 
 	path, err := safeResolvePath(a.Path, t.workspaceDir)
 	if err != nil {
@@ -73,16 +96,84 @@ func (t *FileReadTool) Execute(_ context.Context, args json.RawMessage) (tool.To
 	if info.IsDir() {
 		return tool.ToolResult{Error: "指定路径是目录，请使用 file_list"}, nil
 	}
-	if info.Size() > maxFileSize {
-		return tool.ToolResult{Error: fmt.Sprintf("文件过大 (%d bytes)，最大 %d bytes", info.Size(), maxFileSize)}, nil
+	// Range and outline output stays small, so they may scan larger files.
+	limit := int64(maxFileSize)
+	if partial {
+		limit = maxScanSize
+	}
+	if info.Size() > limit {
+		msg := fmt.Sprintf("文件过大 (%d bytes)，最大 %d bytes", info.Size(), limit)
+		if !partial {
+			msg += "。可用 outline=true 或 start_line/end_line 分段读取"
+		}
+		return tool.ToolResult{Error: msg}, nil
 	}
 
-	data, err := io.ReadAll(io.LimitReader(f, maxFileSize))
+	data, err := io.ReadAll(io.LimitReader(f, limit))
 	if err != nil {
 		return tool.ToolResult{Error: fmt.Sprintf("读取失败: %v", err)}, nil
 	}
+	if !partial {
+		return tool.ToolResult{Output: string(data)}, nil
+	}
+
+	lines := splitLines(string(data))
+	if len(lines) == 0 {
+		return tool.ToolResult{Output: fmt.Sprintf("[%s 是空文件]", a.Path)}, nil
+	}
+	if a.StartLine > len(lines) {
+		return tool.ToolResult{Error: fmt.Sprintf("start_line (%d) 超出文件行数 (%d)", a.StartLine, len(lines))}, nil
+	}
+	start, end := max(a.StartLine, 1), len(lines)
+	if a.EndLine > 0 {
+		end = min(a.EndLine, len(lines))
+	}
+	if a.Outline {
+		return outlineFile(a.Path, lines, start, end), nil
+	}
+	return readLineRange(a.Path, lines, start, end), nil
+}
+
+// readLineRange returns lines start..end (1-based, inclusive) under a header
+// naming the range, capped at maxFileSize. A footer tells where to continue
+// when lines remain.
+func readLineRange(path string, lines []string, start, end int) tool.ToolResult {
+	var body strings.Builder
+	shown := start - 1
+	for _, line := range lines[start-1 : end] {
+		if body.Len()+len(line) > maxFileSize && shown >= start {
+			break
+		}
+		body.WriteString(line)
+		shown++
+	}
+	out := fmt.Sprintf("[%s 第 %d-%d 行，共 %d 行]\n%s", path, start, shown, len(lines), strings.TrimSuffix(body.String(), "\n"))
+	if shown < len(lines) {
+		out += fmt.Sprintf("\n[后面还有 %d 行，可用 start_line=%d 继续读取]", len(lines)-shown, shown+1)
+	}
+	return tool.ToolResult{Output: out}
+}
+
+// racyWindow: a file modified this recently may change again within the
+// same mtime tick, so its mtime and size do not prove it unchanged.
+const racyWindow = 2 * time.Second
 
-	return tool.ToolResult{Output: string(data)}, nil
+// CacheFingerprint implements tool.Cacheable with the file's mtime and size,
+// so a file changed by any tool (or outside the agent) misses the cache.
+func (t *FileReadTool) CacheFingerprint(_ context.Context, args json.RawMessage) (string, bool) {
+	var a filePathArgs
+	if json.Unmarshal(args, &a) != nil {
+		return "", false
+	}
+	path, err := safeResolvePath(a.Path, t.workspaceDir)
+	if err != nil {
+		return "", false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || time.Since(info.ModTime()) < racyWindow {
+		return "", false
+	}
+	return fmt.Sprintf("%s:%d:%d", path, info.ModTime().UnixNano(), info.Size()), true
 }
 
 // ── file_write ──
@@ -154,26 +245,39 @@ func (t *FileWriteTool) Execute(_ context.Context, args json.RawMessage) (tool.T
 
 type FileListTool struct {
 	workspaceDir string
+	pages        *PageStore // nil = no pagination; recursive listing capped at maxListItems
 }
 
 func NewFileListTool(workspaceDir string) *FileListTool {
 	return &FileListTool{workspaceDir: workspaceDir}
 }
 
+// WithPageStore enables paginated recursive listings via fetch_more.
+func (t *FileListTool) WithPageStore(pages *PageStore) *FileListTool {
+	t.pages = pages
+	return t
+}
+
 func (t *FileListTool) Name() string        { return "file_list" }
 func (t *FileListTool) Description() string { return "列出指定目录下的文件和子目录" }
 
 func (t *FileListTool) InputSchema() json.RawMessage {
 	return tool.BuildSchema(
 		tool.SchemaParam{Name: "path", Type: "string", Description: "目录路径", Required: true},
+		tool.SchemaParam{Name: "recursive", Type: "boolean", Description: "是否递归列出子目录（默认 false，跳过 .git/node_modules 等）", Required: false},
 	)
 }
 
+type fileListArgs struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive"`
+}
+
 func (t *FileListTool) Init(_ context.Context) error { return nil }
 func (t *FileListTool) Close() error                 { return nil }
 
-func (t *FileListTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
-	var a filePathArgs
+func (t *FileListTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a fileListArgs
 	if err := json.Unmarshal(args, &a); err != nil {
 		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
 	}
@@ -183,14 +287,23 @@ func (t *FileListTool) Execute(_ context.Context, args json.RawMessage) (tool.To
 		return tool.ToolResult{Error: err.Error()}, nil
 	}
 
+	if a.Recursive {
+		return t.listRecursive(ctx, path)
+	}
+
 	entries, err := os.ReadDir(path)
 	if err != nil {
 		return tool.ToolResult{Error: fmt.Sprintf("目录不存在: %s。请确认路径是否正确，用 \".\" 表示工作目录，或提供完整的绝对路径。", path)}, nil
 	}
 
+	skip := omegaIgnore(t.workspaceDir, path)
 	var sb strings.Builder
-	count := 0
+	count, hidden := 0, 0
 	for _, entry := range entries {
+		if skip(filepath.Join(path, entry.Name()), entry.IsDir()) {
+			hidden++
+			continue
+		}
 		if count >= maxListItems {
 			sb.WriteString(fmt.Sprintf("... (共 %d 项，仅显示前 %d 项)\n", len(entries), maxListItems))
 			break
@@ -212,13 +325,76 @@ func (t *FileListTool) Execute(_ context.Context, args json.RawMessage) (tool.To
 		count++
 	}
 
-	if count == 0 {
+	if count == 0 && hidden == 0 {
 		return tool.ToolResult{Output: "（空目录）"}, nil
 	}
-
+	writeHiddenNote(&sb, hidden)
 	return tool.ToolResult{Output: sb.String()}, nil
 }
 
+// listRecursive walks root and lists every entry as a path relative to root.
+// With a PageStore the full listing (up to maxListRecurse) is paginated;
+// without one it is capped at maxListItems like the flat listing.
+func (t *FileListTool) listRecursive(ctx context.Context, root string) (tool.ToolResult, error) {
+	info, err := os.Stat(root)
+	if err != nil || !info.IsDir() {
+		return tool.ToolResult{Error: fmt.Sprintf("目录不存在: %s。请确认路径是否正确，用 \".\" 表示工作目录，或提供完整的绝对路径。", root)}, nil
+	}
+
+	limit := maxListItems
+	if t.pages != nil {
+		limit = maxListRecurse
+	}
+
+	skip := omegaIgnore(t.workspaceDir, root)
+	var sb strings.Builder
+	count, hidden := 0, 0
+	limitReached := false
+	_ = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || p == root {
+			return nil // skip inaccessible paths and the root itself
+		}
+		if d.IsDir() && skipDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if skip(p, d.IsDir()) {
+			hidden++
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if count >= limit {
+			limitReached = true
+			return filepath.SkipAll
+		}
+
+		rel, _ := filepath.Rel(root, p)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			sb.WriteString(fmt.Sprintf("📁 %s/\n", rel))
+		} else if fi, err := d.Info(); err == nil {
+			sb.WriteString(fmt.Sprintf("📄 %s (%d bytes)\n", rel, fi.Size()))
+		} else {
+			sb.WriteString(fmt.Sprintf("📄 %s (size unknown)\n", rel))
+		}
+		count++
+		return nil
+	})
+
+	if count == 0 && hidden == 0 {
+		return tool.ToolResult{Output: "（空目录）"}, nil
+	}
+	if limitReached {
+		sb.WriteString(fmt.Sprintf("... (已达上限 %d 项，请缩小目录范围)\n", limit))
+	}
+	writeHiddenNote(&sb, hidden)
+	return tool.ToolResult{Output: t.pages.Paginate(t.Name(), sb.String())}, nil
+}
+
 // ── file_find ──
 
 type FileFindTool struct {
@@ -267,6 +443,7 @@ func (t *FileFindTool) Execute(ctx context.Context, args json.RawMessage) (tool.
 		return tool.ToolResult{Error: "工作目录未设置"}, nil
 	}
 
+	skip := omegaIgnore(root, root)
 	var results []string
 	lowerPattern := strings.ToLower(pattern)
 	// Check if pattern contains glob characters
@@ -291,6 +468,12 @@ func (t *FileFindTool) Execute(ctx context.Context, args json.RawMessage) (tool.
 		if d.IsDir() && skipDirs[d.Name()] {
 			return filepath.SkipDir
 		}
+		if skip(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 
 		name := d.Name()
 		matched := false
@@ -339,6 +522,27 @@ func (t *FileFindTool) Execute(ctx context.Context, args json.RawMessage) (tool.
 
 // ── shared helpers ──
 
+// omegaIgnore returns a predicate reporting whether a path met while walking
+// root is excluded by the workspace .omegaignore. When root itself is
+// ignored nothing is skipped: an explicitly requested path wins.
+func omegaIgnore(workspaceDir, root string) func(path string, isDir bool) bool {
+	m := ignore.ForWorkspace(workspaceDir)
+	if m.Empty() || m.Match(ignore.Rel(workspaceDir, root), true) {
+		return func(string, bool) bool { return false }
+	}
+	return func(path string, isDir bool) bool {
+		return m.Match(ignore.Rel(workspaceDir, path), isDir)
+	}
+}
+
+// writeHiddenNote tells the model that entries were left out on purpose, so
+// it does not conclude they are missing.
+func writeHiddenNote(sb *strings.Builder, hidden int) {
+	if hidden > 0 {
+		sb.WriteString(fmt.Sprintf("（%d 项已按 %s 隐藏，可直接指定路径查看）\n", hidden, ignore.FileName))
+	}
+}
+
 // safeResolvePath resolves a file path and validates it stays within the workspace.
 // Prevents path traversal attacks (e.g. ../../etc/passwd), prefix collisions
 // (e.g. workspace="C:\project", path="C:\project-evil\attack.txt"), and
@@ -396,6 +600,12 @@ func safeResolvePath(path, workspaceDir string) (string, error) {
 	return resolved, nil
 }
 
+// SafeResolvePath is safeResolvePath for callers outside the tools (e.g. the
+// web file endpoints), so that they share the same sandbox.
+func SafeResolvePath(path, workspaceDir string) (string, error) {
+	return safeResolvePath(path, workspaceDir)
+}
+
 // resolveExisting resolves symlinks for an existing path, or for its parent
 // directory if the path itself does not yet exist (e.g. a new file to be written).
 // This prevents symlink-escape attacks where a symlink inside the workspace
@@ -418,6 +628,11 @@ var protectedFiles = map[string]string{
 	"mcp.json": "mcp_server_add/mcp_server_remove",
 }
 
+// CheckProtectedFile is checkProtectedFile for callers outside the tools.
+func CheckProtectedFile(resolvedPath, workspaceDir string) string {
+	return checkProtectedFile(resolvedPath, workspaceDir)
+}
+
 // checkProtectedFile returns a non-empty error message if resolvedPath points
 // to a protected file that must not be modified by generic file tools.
 func checkProtectedFile(resolvedPath, workspaceDir string) string {
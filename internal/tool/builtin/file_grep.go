@@ -22,18 +22,27 @@ const (
 	grepHardMax         = 200
 	grepMaxLineLen      = 200 // truncate long lines to keep output tidy
 	grepMaxContextLines = 3
+	grepPagedHardMax    = 1000 // upper bound when pagination is enabled
 )
 
 // ── file_grep ──
 
 type FileGrepTool struct {
 	workspaceDir string
+	pages        *PageStore // nil = no pagination; results capped at grepHardMax
 }
 
 func NewFileGrepTool(workspaceDir string) *FileGrepTool {
 	return &FileGrepTool{workspaceDir: workspaceDir}
 }
 
+// WithPageStore enables paginated output: up to grepPagedHardMax matches are
+// collected and returned page by page via fetch_more.
+func (t *FileGrepTool) WithPageStore(pages *PageStore) *FileGrepTool {
+	t.pages = pages
+	return t
+}
+
 func (t *FileGrepTool) Name() string { return "file_grep" }
 func (t *FileGrepTool) Description() string {
 	return "在工作区内按正则或字面量模式搜索文件内容，返回文件路径、行号和匹配行。支持文件名过滤和上下文行显示。"
@@ -46,7 +55,7 @@ func (t *FileGrepTool) InputSchema() json.RawMessage {
 		tool.SchemaParam{Name: "case_sensitive", Type: "boolean", Description: "是否大小写敏感（默认 false）", Required: false},
 		tool.SchemaParam{Name: "file_glob", Type: "string", Description: "文件名过滤，如 *.go 或 *.{ts,tsx}", Required: false},
 		tool.SchemaParam{Name: "context_lines", Type: "integer", Description: "匹配行前后各显示 N 行（默认 0，上限 3）", Required: false},
-		tool.SchemaParam{Name: "max_results", Type: "integer", Description: "最大返回条数（默认 50，上限 200）", Required: false},
+		tool.SchemaParam{Name: "max_results", Type: "integer", Description: "最大返回条数（默认 50，上限 200；启用分页时默认 200，上限 1000）", Required: false},
 	)
 }
 
@@ -83,12 +92,16 @@ func (t *FileGrepTool) Execute(ctx context.Context, args json.RawMessage) (tool.
 
 	// Clamp context_lines and max_results
 	contextLines := clamp(a.ContextLines, 0, grepMaxContextLines)
+	defaultMax, hardMax := grepDefaultMax, grepHardMax
+	if t.pages != nil {
+		defaultMax, hardMax = grepHardMax, grepPagedHardMax
+	}
 	maxResults := a.MaxResults
 	if maxResults <= 0 {
-		maxResults = grepDefaultMax
+		maxResults = defaultMax
 	}
-	if maxResults > grepHardMax {
-		maxResults = grepHardMax
+	if maxResults > hardMax {
+		maxResults = hardMax
 	}
 
 	// Compile regexp
@@ -120,6 +133,7 @@ func (t *FileGrepTool) Execute(ctx context.Context, args json.RawMessage) (tool.
 		return tool.ToolResult{Error: fmt.Sprintf("无法访问搜索路径: %v", err)}, nil
 	}
 
+	skip := omegaIgnore(t.workspaceDir, searchRoot)
 	var matches []grepMatch
 	limitReached := false
 
@@ -134,11 +148,14 @@ func (t *FileGrepTool) Execute(ctx context.Context, args json.RawMessage) (tool.
 			return nil // skip inaccessible paths
 		}
 		if d.IsDir() {
-			if skipDirs[d.Name()] {
+			if skipDirs[d.Name()] || skip(path, true) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
+		if skip(path, false) {
+			return nil
+		}
 
 		// File glob filter
 		if a.FileGlob != "" {
@@ -167,7 +184,7 @@ func (t *FileGrepTool) Execute(ctx context.Context, args json.RawMessage) (tool.
 	}
 
 	output := formatGrepResults(matches, t.workspaceDir, limitReached, maxResults)
-	return tool.ToolResult{Output: output}, nil
+	return tool.ToolResult{Output: t.pages.Paginate(t.Name(), output)}, nil
 }
 
 // buildGrepRegexp compiles the search pattern.
@@ -3,6 +3,7 @@ package builtin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -10,6 +11,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/pocketomega/pocket-omega/internal/editor"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
@@ -38,28 +40,49 @@ var blockedOpenExts = map[string]bool{
 
 type FileOpenTool struct {
 	workspaceDir string
+	editor       *editor.Editor // nil = no editor integration
 }
 
 func NewFileOpenTool(workspaceDir string) *FileOpenTool {
 	return &FileOpenTool{workspaceDir: workspaceDir}
 }
 
+// WithEditor enables editor=true: source files are opened in the user's
+// editor at a given line instead of with the system default program.
+func (t *FileOpenTool) WithEditor(e *editor.Editor) *FileOpenTool {
+	t.editor = e
+	return t
+}
+
 func (t *FileOpenTool) Name() string { return "file_open" }
 func (t *FileOpenTool) Description() string {
-	return "用系统默认程序打开文件（图片、音乐、视频、文档等），操作系统自动选择对应应用。仅支持媒体/文档类文件，禁止打开可执行或脚本文件。"
+	desc := "用系统默认程序打开文件（图片、音乐、视频、文档等），操作系统自动选择对应应用。仅支持媒体/文档类文件，禁止打开可执行或脚本文件。"
+	if t.editor != nil {
+		desc += "设置 editor=true 可在用户的编辑器中打开源码文件并定位到 line 行（任意文本文件均可）。"
+	}
+	return desc
 }
 
 func (t *FileOpenTool) InputSchema() json.RawMessage {
-	return tool.BuildSchema(
-		tool.SchemaParam{Name: "path", Type: "string", Description: "要打开的文件路径（相对于工作区）", Required: true},
-	)
+	params := []tool.SchemaParam{
+		{Name: "path", Type: "string", Description: "要打开的文件路径（相对于工作区）", Required: true},
+	}
+	if t.editor != nil {
+		params = append(params,
+			tool.SchemaParam{Name: "editor", Type: "boolean", Description: "在用户的编辑器中打开（默认 false）", Required: false},
+			tool.SchemaParam{Name: "line", Type: "integer", Description: "editor=true 时定位的行号（从 1 开始）", Required: false},
+		)
+	}
+	return tool.BuildSchema(params...)
 }
 
 func (t *FileOpenTool) Init(_ context.Context) error { return nil }
 func (t *FileOpenTool) Close() error                 { return nil }
 
 type fileOpenArgs struct {
-	Path string `json:"path"`
+	Path   string `json:"path"`
+	Editor bool   `json:"editor"`
+	Line   int    `json:"line"`
 }
 
 func (t *FileOpenTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
@@ -72,9 +95,13 @@ func (t *FileOpenTool) Execute(_ context.Context, args json.RawMessage) (tool.To
 		return tool.ToolResult{Error: "path 不能为空"}, nil
 	}
 
-	// 安全：阻止可执行/脚本类扩展名
+	if a.Editor && t.editor == nil {
+		return tool.ToolResult{Error: "未配置编辑器集成（EDITOR_INTEGRATION），请去掉 editor 参数"}, nil
+	}
+
+	// 安全：阻止可执行/脚本类扩展名（编辑器只打开不执行，不受此限制）
 	ext := strings.ToLower(filepath.Ext(a.Path))
-	if blockedOpenExts[ext] {
+	if !a.Editor && blockedOpenExts[ext] {
 		return tool.ToolResult{Error: fmt.Sprintf("安全限制: 不允许打开可执行或脚本文件 (%s)", ext)}, nil
 	}
 
@@ -94,6 +121,11 @@ func (t *FileOpenTool) Execute(_ context.Context, args json.RawMessage) (tool.To
 		return tool.ToolResult{Error: "指定路径是目录，file_open 仅支持文件"}, nil
 	}
 
+	relPath := relOrAbs(absPath, t.workspaceDir)
+	if a.Editor {
+		return t.openInEditor(absPath, relPath, a.Line), nil
+	}
+
 	cmd := openCmdFunc(absPath)
 	if err := cmd.Start(); err != nil {
 		return tool.ToolResult{Error: fmt.Sprintf("启动默认程序失败: %v", err)}, nil
@@ -101,10 +133,26 @@ func (t *FileOpenTool) Execute(_ context.Context, args json.RawMessage) (tool.To
 	// 异步回收子进程，避免产生僵尸进程（zombie）
 	go func() { _ = cmd.Wait() }()
 
-	relPath := relOrAbs(absPath, t.workspaceDir)
 	return tool.ToolResult{Output: fmt.Sprintf("已使用默认程序打开: %s", relPath)}, nil
 }
 
+// openInEditor 在编辑器中打开文件。URL 类编辑器（VS Code、JetBrains）必须由
+// 用户的浏览器打开：界面会在该步骤上显示"在编辑器中打开"链接。
+func (t *FileOpenTool) openInEditor(absPath, relPath string, line int) tool.ToolResult {
+	loc := relPath
+	if line > 0 {
+		loc = fmt.Sprintf("%s:%d", relPath, line)
+	}
+	err := t.editor.Open(absPath, line)
+	switch {
+	case errors.Is(err, editor.ErrNotLocal):
+		return tool.ToolResult{Output: fmt.Sprintf("已生成编辑器链接: %s（用户可在界面中点击打开）", loc)}
+	case err != nil:
+		return tool.ToolResult{Error: fmt.Sprintf("启动编辑器失败: %v", err)}
+	}
+	return tool.ToolResult{Output: fmt.Sprintf("已在编辑器中打开: %s", loc)}
+}
+
 // openCmdFunc 是实际构造"用默认程序打开"命令的函数。
 // 使用包级变量而非直接调用，使测试可以将其替换为 no-op 以避免弹出真实 GUI 窗口。
 var openCmdFunc = openCmd
@@ -9,6 +9,8 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/editor"
 )
 
 // ── FileOpenTool Execute tests ────────────────────────────────────────────────
@@ -135,6 +137,42 @@ func TestFileOpenTool_Success(t *testing.T) {
 	}
 }
 
+func TestFileOpenTool_Editor(t *testing.T) {
+	workspace := t.TempDir()
+	os.WriteFile(filepath.Join(workspace, "main.py"), []byte("print(1)\n"), 0644)
+	args, _ := json.Marshal(fileOpenArgs{Path: "main.py", Editor: true, Line: 12})
+
+	// 未配置编辑器：editor=true 报错，schema 中不出现 editor 参数
+	plain := NewFileOpenTool(workspace)
+	if strings.Contains(string(plain.InputSchema()), "editor") {
+		t.Error("schema should not offer editor without an integration")
+	}
+	if result, _ := plain.Execute(context.Background(), args); !strings.Contains(result.Error, "EDITOR_INTEGRATION") {
+		t.Errorf("expected missing integration error, got: %+v", result)
+	}
+
+	// URL 编辑器：不在服务端启动进程，由界面链接打开；脚本文件在编辑器中可以打开
+	vscode := NewFileOpenTool(workspace).WithEditor(&editor.Editor{Kind: editor.KindVSCode})
+	result, _ := vscode.Execute(context.Background(), args)
+	if result.Error != "" || !strings.Contains(result.Output, "已生成编辑器链接: main.py:12") {
+		t.Errorf("vscode: %+v", result)
+	}
+
+	// 命令编辑器：启动本地命令
+	if runtime.GOOS == "windows" {
+		return
+	}
+	cmd := NewFileOpenTool(workspace).WithEditor(&editor.Editor{Kind: editor.KindCommand, Command: []string{"true", "+{line}", "{file}"}})
+	result, _ = cmd.Execute(context.Background(), args)
+	if result.Error != "" || !strings.Contains(result.Output, "已在编辑器中打开: main.py:12") {
+		t.Errorf("command: %+v", result)
+	}
+	bad := NewFileOpenTool(workspace).WithEditor(&editor.Editor{Kind: editor.KindCommand, Command: []string{"no-such-editor-binary"}})
+	if result, _ := bad.Execute(context.Background(), args); !strings.Contains(result.Error, "启动编辑器失败") {
+		t.Errorf("missing command: %+v", result)
+	}
+}
+
 // ── openCmd unit test ─────────────────────────────────────────────────────────
 
 func TestOpenCmd_ReturnsCmd(t *testing.T) {
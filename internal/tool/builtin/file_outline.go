@@ -0,0 +1,126 @@
+package builtin
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// ── file_read outline mode ──
+//
+// The outline lists the definition lines of a source file (functions,
+// types, classes, …) with their line numbers, so the agent can find the
+// part of a large file it needs and read just that range. Matching is one
+// lightweight regex per language, not a parser: it favors recall on
+// conventionally formatted code over exactness.
+
+const maxOutlineItems = 400
+
+var (
+	outlineJS = regexp.MustCompile(`^\s*(export\s+)?(default\s+)?(declare\s+)?(abstract\s+)?(async\s+)?(function\*?|class|interface|enum|type|namespace)\s+[\w$]+` +
+		`|^\s*(export\s+)?(const|let|var)\s+[\w$]+\s*(:[^=]+)?=\s*(async\s+)?(function\b|\([^)]*\)\s*(:[^=]+)?=>|[\w$]+\s*=>)` +
+		`|^\s+((public|private|protected|static|readonly|async|get|set|override)\s+)*[\w$]+\s*(<[^>]*>)?\([^)]*\)\s*(:\s*[^{;]+)?\{\s*$`)
+	outlineJVM = regexp.MustCompile(`^\s*(@\w+\s+)*((public|private|protected|internal|static|final|abstract|sealed|open|override|suspend|data|inline|partial|virtual|async|readonly)\s+)*(class|interface|enum|record|struct|object|fun)\s+\w+` +
+		`|^\s*((public|private|protected|internal|static|final|abstract|override|virtual|async|synchronized)\s+)+[\w<>\[\],.?\s]+\s+\w+\s*\(`)
+	outlineC = regexp.MustCompile(`^\s*(class|struct|namespace|enum|union)\s+\w+[^;]*$` +
+		`|^[A-Za-z_][\w\s*&:<>,~]*[\s*&]+~?[\w:]+\s*\([^;]*$`)
+	outlineShell = regexp.MustCompile(`^\s*(function\s+[\w-]+|[\w-]+\s*\(\)\s*\{?\s*$)`)
+)
+
+// outlinePatterns maps file extensions to the regex matching their
+// definition lines.
+var outlinePatterns = map[string]*regexp.Regexp{
+	".go":   regexp.MustCompile(`^(func|type)\s`),
+	".py":   regexp.MustCompile(`^\s*(async\s+def|def|class)\s+\w+`),
+	".js":   outlineJS,
+	".jsx":  outlineJS,
+	".mjs":  outlineJS,
+	".cjs":  outlineJS,
+	".ts":   outlineJS,
+	".tsx":  outlineJS,
+	".java": outlineJVM,
+	".kt":   outlineJVM,
+	".cs":   outlineJVM,
+	".rs":   regexp.MustCompile(`^\s*(pub(\([^)]*\))?\s+)?((async|const|unsafe|extern\s+"[^"]*")\s+)*(fn|struct|enum|trait|impl|mod|type|macro_rules!)[\s<]`),
+	".c":    outlineC,
+	".h":    outlineC,
+	".cc":   outlineC,
+	".cpp":  outlineC,
+	".cxx":  outlineC,
+	".hpp":  outlineC,
+	".rb":   regexp.MustCompile(`^\s*(def|class|module)\s+`),
+	".php":  regexp.MustCompile(`^\s*((abstract|final|public|private|protected|static)\s+)*(function|class|interface|trait|enum)\s+\w+`),
+	".sh":   outlineShell,
+	".bash": outlineShell,
+	".md":   regexp.MustCompile(`^#{1,6}\s+\S`),
+}
+
+// outlineSkipWords are statements that the method patterns would otherwise
+// mistake for definitions ("if (x) {", "return foo(").
+var outlineSkipWords = map[string]bool{
+	"if": true, "else": true, "for": true, "foreach": true, "while": true, "do": true,
+	"switch": true, "case": true, "catch": true, "return": true, "throw": true,
+	"new": true, "using": true, "lock": true, "with": true, "elif": true, "sizeof": true,
+}
+
+// outlineFile lists the definition lines between start and end (1-based,
+// inclusive) as "line: signature".
+func outlineFile(path string, lines []string, start, end int) tool.ToolResult {
+	ext := strings.ToLower(filepath.Ext(path))
+	re, ok := outlinePatterns[ext]
+	if !ok {
+		exts := make([]string, 0, len(outlinePatterns))
+		for e := range outlinePatterns {
+			exts = append(exts, e)
+		}
+		sort.Strings(exts)
+		return tool.ToolResult{Error: fmt.Sprintf("不支持 %q 文件的大纲，支持: %s。可改用 file_grep 搜索定义", ext, strings.Join(exts, " "))}
+	}
+
+	var items []string
+	total := 0
+	inFence := false
+	for i := start; i <= end; i++ {
+		line := strings.TrimRight(lines[i-1], "\r\n")
+		if ext == ".md" && strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || !re.MatchString(line) || outlineSkipWords[firstWord(line)] {
+			continue
+		}
+		total++
+		if len(items) < maxOutlineItems {
+			sig := strings.TrimRight(strings.ReplaceAll(line, "\t", "  "), " {")
+			items = append(items, fmt.Sprintf("%d: %s", i, sig))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s 大纲，第 %d-%d 行，共 %d 行，%d 项]", path, start, end, len(lines), total)
+	if total == 0 {
+		b.WriteString("\n（未找到函数/类定义，可用 start_line/end_line 直接读取）")
+	}
+	for _, item := range items {
+		b.WriteString("\n" + item)
+	}
+	if total > len(items) {
+		fmt.Fprintf(&b, "\n[还有 %d 项未显示，可用 start_line/end_line 缩小范围]", total-len(items))
+	}
+	return tool.ToolResult{Output: b.String()}
+}
+
+// firstWord returns the first identifier of a line.
+func firstWord(line string) string {
+	line = strings.TrimSpace(line)
+	if i := strings.IndexFunc(line, func(r rune) bool {
+		return !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
@@ -0,0 +1,61 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileReadTool_Outline(t *testing.T) {
+	workspace := t.TempDir()
+	files := map[string]string{
+		"main.go": "package main\n\nimport \"fmt\"\n\ntype Server struct {\n\taddr string\n}\n\nfunc (s *Server) Start() error {\n\tif s.addr == \"\" {\n\t\treturn nil\n\t}\n\treturn nil\n}\n\nfunc main() {\n\tfmt.Println(1)\n}\n",
+		"app.py":  "import os\n\nclass Store:\n    def get(self, key):\n        if key:\n            return 1\n\nasync def fetch(url):\n    pass\n",
+		"ui.ts":   "export class Panel {\n  render(): void {\n    if (this.x) {\n      return;\n    }\n  }\n}\nexport const load = async (id: string) => {\n};\nfunction helper(a) {\n}\n",
+	}
+	for name, content := range files {
+		os.WriteFile(filepath.Join(workspace, name), []byte(content), 0644)
+	}
+	tool := NewFileReadTool(workspace)
+	outline := func(a fileReadArgs) string {
+		a.Outline = true
+		args, _ := json.Marshal(a)
+		result, err := tool.Execute(context.Background(), args)
+		if err != nil || result.Error != "" {
+			t.Fatalf("%s: %v %s", a.Path, err, result.Error)
+		}
+		return result.Output
+	}
+
+	for name, want := range map[string][]string{
+		"main.go": {"3 项]", "5: type Server struct", "9: func (s *Server) Start() error", "16: func main()"},
+		"app.py":  {"3: class Store:", "4:     def get(self, key):", "8: async def fetch(url):"},
+		"ui.ts":   {"1: export class Panel", "2:   render(): void", "8: export const load = async (id: string) =>", "10: function helper(a)"},
+	} {
+		out := outline(fileReadArgs{Path: name})
+		for _, w := range want {
+			if !strings.Contains(out, w) {
+				t.Errorf("%s outline missing %q:\n%s", name, w, out)
+			}
+		}
+		if strings.Contains(out, "if ") || strings.Contains(out, "return") {
+			t.Errorf("%s outline lists statements:\n%s", name, out)
+		}
+	}
+	if out := outline(fileReadArgs{Path: "main.go", StartLine: 8}); strings.Contains(out, "type Server") || !strings.Contains(out, "16: func main()") {
+		t.Errorf("outline of a range:\n%s", out)
+	}
+}
+
+func TestFileReadTool_OutlineUnsupported(t *testing.T) {
+	workspace := t.TempDir()
+	os.WriteFile(filepath.Join(workspace, "data.csv"), []byte("a,b\n1,2\n"), 0644)
+	args, _ := json.Marshal(fileReadArgs{Path: "data.csv", Outline: true})
+	result, _ := NewFileReadTool(workspace).Execute(context.Background(), args)
+	if !strings.Contains(result.Error, "不支持") || !strings.Contains(result.Error, ".go") {
+		t.Errorf("expected unsupported-type error, got: %+v", result)
+	}
+}
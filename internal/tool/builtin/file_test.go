@@ -9,6 +9,9 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
 // ── safeResolvePath unit tests ──────────────────────────────────────────────
@@ -177,6 +180,33 @@ func TestFileReadTool_Success(t *testing.T) {
 	}
 }
 
+func TestFileReadTool_CacheFingerprint(t *testing.T) {
+	workspace := t.TempDir()
+	path := filepath.Join(workspace, "test.txt")
+	os.WriteFile(path, []byte("v1"), 0644)
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(path, old, old)
+
+	tool := NewFileReadTool(workspace)
+	args, _ := json.Marshal(filePathArgs{Path: "test.txt"})
+	fp1, ok := tool.CacheFingerprint(context.Background(), args)
+	if !ok || fp1 == "" {
+		t.Fatalf("fingerprint = %q, %v", fp1, ok)
+	}
+	os.WriteFile(path, []byte("v2 longer"), 0644)
+	if _, ok := tool.CacheFingerprint(context.Background(), args); ok {
+		t.Error("just-modified file should skip the cache")
+	}
+	os.Chtimes(path, old, old)
+	if fp2, ok := tool.CacheFingerprint(context.Background(), args); !ok || fp2 == fp1 {
+		t.Errorf("changed file: fingerprint = %q, %v (was %q)", fp2, ok, fp1)
+	}
+	missing, _ := json.Marshal(filePathArgs{Path: "nope.txt"})
+	if _, ok := tool.CacheFingerprint(context.Background(), missing); ok {
+		t.Error("missing file should skip the cache")
+	}
+}
+
 func TestFileReadTool_FileNotFound(t *testing.T) {
 	workspace := t.TempDir()
 	tool := NewFileReadTool(workspace)
@@ -247,6 +277,56 @@ func TestFileReadTool_PathTraversal(t *testing.T) {
 	}
 }
 
+func TestFileReadTool_LineRange(t *testing.T) {
+	workspace := t.TempDir()
+	os.WriteFile(filepath.Join(workspace, "lines.txt"), []byte("one\ntwo\nthree\nfour\n"), 0644)
+	tool := NewFileReadTool(workspace)
+	read := func(a fileReadArgs) (string, string) {
+		a.Path = "lines.txt"
+		args, _ := json.Marshal(a)
+		result, err := tool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result.Output, result.Error
+	}
+
+	out, _ := read(fileReadArgs{StartLine: 2, EndLine: 3})
+	if want := "[lines.txt 第 2-3 行，共 4 行]\ntwo\nthree\n[后面还有 1 行，可用 start_line=4 继续读取]"; out != want {
+		t.Errorf("range output = %q, want %q", out, want)
+	}
+	if out, _ := read(fileReadArgs{StartLine: 3, EndLine: 99}); out != "[lines.txt 第 3-4 行，共 4 行]\nthree\nfour" {
+		t.Errorf("clamped range output = %q", out)
+	}
+	if out, _ := read(fileReadArgs{EndLine: 1}); out != "[lines.txt 第 1-1 行，共 4 行]\none\n[后面还有 3 行，可用 start_line=2 继续读取]" {
+		t.Errorf("end_line only output = %q", out)
+	}
+	for _, bad := range []fileReadArgs{{StartLine: 5}, {StartLine: 3, EndLine: 2}, {StartLine: -1}} {
+		if _, errMsg := read(bad); errMsg == "" {
+			t.Errorf("%+v: expected error", bad)
+		}
+	}
+}
+
+func TestFileReadTool_RangeOnLargeFile(t *testing.T) {
+	workspace := t.TempDir()
+	line := strings.Repeat("x", 99) + "\n"
+	data := strings.Repeat(line, maxFileSize/len(line)+10)
+	os.WriteFile(filepath.Join(workspace, "big.log"), []byte(data), 0644)
+
+	tool := NewFileReadTool(workspace)
+	full, _ := json.Marshal(filePathArgs{Path: "big.log"})
+	result, _ := tool.Execute(context.Background(), full)
+	if !strings.Contains(result.Error, "start_line/end_line") {
+		t.Errorf("full read error should suggest a range read, got: %+v", result)
+	}
+	ranged, _ := json.Marshal(fileReadArgs{Path: "big.log", StartLine: 10000, EndLine: 10001})
+	result, _ = tool.Execute(context.Background(), ranged)
+	if result.Error != "" || !strings.HasPrefix(result.Output, "[big.log 第 10000-10001 行") {
+		t.Errorf("range read of large file = %+v", result)
+	}
+}
+
 // ── FileWriteTool Execute tests ──────────────────────────────────────────────
 
 func TestFileWriteTool_Success(t *testing.T) {
@@ -746,3 +826,44 @@ func TestProtectedFile_NonProtectedAllowed(t *testing.T) {
 		t.Errorf("non-protected file should be writable, got error: %s", result.Error)
 	}
 }
+
+// ── .omegaignore ──
+
+func TestOmegaIgnore_HonouredByListFindGrep(t *testing.T) {
+	workspace := t.TempDir()
+	os.WriteFile(filepath.Join(workspace, ".omegaignore"), []byte("build/\n*.min.js\n"), 0644)
+	os.MkdirAll(filepath.Join(workspace, "build"), 0755)
+	os.WriteFile(filepath.Join(workspace, "build", "out.go"), []byte("needle"), 0644)
+	os.WriteFile(filepath.Join(workspace, "app.min.js"), []byte("needle"), 0644)
+	os.WriteFile(filepath.Join(workspace, "main.go"), []byte("needle"), 0644)
+
+	run := func(tl interface {
+		Execute(context.Context, json.RawMessage) (tool.ToolResult, error)
+	}, args string) string {
+		t.Helper()
+		res, err := tl.Execute(context.Background(), json.RawMessage(args))
+		if err != nil || res.Error != "" {
+			t.Fatalf("unexpected error: %v %s", err, res.Error)
+		}
+		return res.Output
+	}
+
+	for name, out := range map[string]string{
+		"file_list":           run(NewFileListTool(workspace), `{"path":"."}`),
+		"file_list recursive": run(NewFileListTool(workspace), `{"path":".","recursive":true}`),
+		"find":                run(NewFileFindTool(workspace), `{"pattern":"*"}`),
+		"file_grep":           run(NewFileGrepTool(workspace), `{"pattern":"needle"}`),
+	} {
+		if !strings.Contains(out, "main.go") {
+			t.Errorf("%s: main.go missing:\n%s", name, out)
+		}
+		if strings.Contains(out, "out.go") || strings.Contains(out, "app.min.js") {
+			t.Errorf("%s: ignored path leaked:\n%s", name, out)
+		}
+	}
+
+	// An explicitly requested ignored directory is still listed.
+	if out := run(NewFileListTool(workspace), `{"path":"build"}`); !strings.Contains(out, "out.go") {
+		t.Errorf("explicit path should override .omegaignore:\n%s", out)
+	}
+}
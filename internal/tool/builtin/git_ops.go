@@ -0,0 +1,430 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	gitOpsTimeout  = 30 * time.Second
+	gitPushTimeout = 60 * time.Second
+
+	// gitDefaultAuthor is used when the repo has no user.name/user.email,
+	// so agent commits never fail on a fresh machine.
+	gitDefaultAuthorName  = "Pocket Omega"
+	gitDefaultAuthorEmail = "omega@localhost"
+)
+
+// ── git_ops ──
+
+// GitOpsTool performs local Git write operations: branch create/switch,
+// add, commit, diff and stash. Push and force operations (force push,
+// branch -D, switch --discard-changes, stash drop/clear) are disabled
+// unless explicitly allowed, and pushes to protected branches are always
+// rejected, so the agent can land changes locally without touching remotes.
+type GitOpsTool struct {
+	workspaceDir string
+	allowPush    bool
+	allowForce   bool
+	protected    map[string]bool // branches that can never be pushed to
+}
+
+// NewGitOpsTool creates a git_ops tool scoped to the given workspace.
+// Push and force operations are disabled; main/master are protected.
+func NewGitOpsTool(workspaceDir string) *GitOpsTool {
+	return &GitOpsTool{
+		workspaceDir: workspaceDir,
+		protected:    map[string]bool{"main": true, "master": true},
+	}
+}
+
+// WithPush allows the push action (TOOL_GIT_ALLOW_PUSH=true).
+func (t *GitOpsTool) WithPush(allow bool) *GitOpsTool {
+	t.allowPush = allow
+	return t
+}
+
+// WithForce allows force operations (TOOL_GIT_ALLOW_FORCE=true).
+func (t *GitOpsTool) WithForce(allow bool) *GitOpsTool {
+	t.allowForce = allow
+	return t
+}
+
+// WithProtectedBranches replaces the set of branches that cannot be pushed to
+// (TOOL_GIT_PROTECTED_BRANCHES, comma-separated).
+func (t *GitOpsTool) WithProtectedBranches(names []string) *GitOpsTool {
+	t.protected = make(map[string]bool, len(names))
+	for _, n := range names {
+		if n = strings.TrimSpace(n); n != "" {
+			t.protected[n] = true
+		}
+	}
+	return t
+}
+
+func (t *GitOpsTool) Name() string { return "git_ops" }
+func (t *GitOpsTool) Description() string {
+	desc := "Git 写操作：branch（创建分支）、switch（切换分支）、add（暂存）、commit（提交）、diff（查看改动）、stash（暂存区管理）"
+	if t.allowPush {
+		desc += "、push（推送，受保护分支除外）"
+	} else {
+		desc += "。push 已禁用，只能在本地提交"
+	}
+	if !t.allowForce {
+		desc += "；强制操作（force push、删除未合并分支、丢弃改动、stash drop/clear）已禁用"
+	}
+	return desc + "。只读查询请用 git_info"
+}
+
+func (t *GitOpsTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "action", Type: "string", Description: "操作类型",
+			Required: true, Enum: []string{"branch", "switch", "add", "commit", "diff", "stash", "push"}},
+		tool.SchemaParam{Name: "name", Type: "string", Description: "branch/switch/push: 分支名（push 默认当前分支）", Required: false},
+		tool.SchemaParam{Name: "start_point", Type: "string", Description: "branch: 新分支起点（默认 HEAD）", Required: false},
+		tool.SchemaParam{Name: "create", Type: "boolean", Description: "switch: 分支不存在时创建（git switch -c）", Required: false},
+		tool.SchemaParam{Name: "delete", Type: "boolean", Description: "branch: 删除分支（仅已合并分支，除非 force）", Required: false},
+		tool.SchemaParam{Name: "paths", Type: "string", Description: "add/commit/diff: 空白分隔的工作区相对路径（add 默认全部）", Required: false},
+		tool.SchemaParam{Name: "message", Type: "string", Description: "commit: 提交说明（必填）；stash push: 说明", Required: false},
+		tool.SchemaParam{Name: "all", Type: "boolean", Description: "commit: 自动暂存所有已跟踪文件的修改（git commit -a）", Required: false},
+		tool.SchemaParam{Name: "staged", Type: "boolean", Description: "diff: 查看已暂存的改动", Required: false},
+		tool.SchemaParam{Name: "stash_op", Type: "string", Description: "stash: 子操作（默认 push）",
+			Required: false, Enum: []string{"push", "pop", "apply", "list", "show", "drop", "clear"}},
+		tool.SchemaParam{Name: "remote", Type: "string", Description: "push: 远程名（默认 origin）", Required: false},
+		tool.SchemaParam{Name: "force", Type: "boolean", Description: "强制执行（需配置允许）", Required: false},
+	)
+}
+
+func (t *GitOpsTool) Init(_ context.Context) error { return nil }
+func (t *GitOpsTool) Close() error                 { return nil }
+
+type gitOpsArgs struct {
+	Action     string `json:"action"`
+	Name       string `json:"name"`
+	StartPoint string `json:"start_point"`
+	Create     bool   `json:"create"`
+	Delete     bool   `json:"delete"`
+	Paths      string `json:"paths"`
+	Message    string `json:"message"`
+	All        bool   `json:"all"`
+	Staged     bool   `json:"staged"`
+	StashOp    string `json:"stash_op"`
+	Remote     string `json:"remote"`
+	Force      bool   `json:"force"`
+}
+
+// ReadOnlyCall implements tool.ReadOnlyCaller: diff and stash list/show run
+// in read-only mode.
+func (t *GitOpsTool) ReadOnlyCall(args json.RawMessage) bool {
+	var a gitOpsArgs
+	if json.Unmarshal(args, &a) != nil {
+		return false
+	}
+	return a.Action == "diff" || (a.Action == "stash" && (a.StashOp == "list" || a.StashOp == "show"))
+}
+
+func (t *GitOpsTool) ReadOnlyHint() string { return "仅 diff 和 stash list/show 可执行" }
+
+func (t *GitOpsTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a gitOpsArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+
+	if a.Force && !t.allowForce {
+		return tool.ToolResult{Error: "安全限制: 强制操作已禁用（可设置 TOOL_GIT_ALLOW_FORCE=true）"}, nil
+	}
+
+	var cmdArgs []string
+	var err error
+	timeout := gitOpsTimeout
+
+	switch a.Action {
+	case "branch":
+		cmdArgs, err = t.branchArgs(ctx, a)
+	case "switch":
+		cmdArgs, err = t.switchArgs(a)
+	case "add":
+		cmdArgs, err = t.addArgs(a)
+	case "commit":
+		cmdArgs, err = t.commitArgs(a)
+	case "diff":
+		cmdArgs, err = t.diffArgs(a)
+	case "stash":
+		cmdArgs, err = t.stashArgs(a)
+	case "push":
+		cmdArgs, err = t.pushArgs(ctx, a)
+		timeout = gitPushTimeout
+	default:
+		return tool.ToolResult{Error: fmt.Sprintf("不支持的操作 %q，允许: branch/switch/add/commit/diff/stash/push", a.Action)}, nil
+	}
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+
+	out, runErr := t.run(ctx, timeout, cmdArgs...)
+	header := "$ git " + strings.Join(cmdArgs, " ")
+	if runErr != nil {
+		if out != "" {
+			out = header + "\n" + out
+		}
+		return tool.ToolResult{Output: out, Error: runErr.Error()}, nil
+	}
+
+	if a.Action == "commit" {
+		// Append the resulting commit so the agent can reference it.
+		if summary, err := t.run(ctx, gitTimeout, "log", "-1", "--oneline"); err == nil {
+			out = strings.TrimSpace(out + "\n---\n" + summary)
+		}
+	}
+	if out == "" {
+		out = "（命令执行成功，无输出）"
+	}
+	return tool.ToolResult{Output: header + "\n" + out}, nil
+}
+
+func (t *GitOpsTool) branchArgs(ctx context.Context, a gitOpsArgs) ([]string, error) {
+	if err := t.checkRefName(ctx, a.Name); err != nil {
+		return nil, err
+	}
+	if a.Delete {
+		if a.Force {
+			return []string{"branch", "-D", a.Name}, nil
+		}
+		return []string{"branch", "-d", a.Name}, nil
+	}
+	args := []string{"branch", a.Name}
+	if sp := strings.TrimSpace(a.StartPoint); sp != "" {
+		if strings.HasPrefix(sp, "-") {
+			return nil, fmt.Errorf("无效的起点 %q", sp)
+		}
+		args = append(args, sp)
+	}
+	return args, nil
+}
+
+func (t *GitOpsTool) switchArgs(a gitOpsArgs) ([]string, error) {
+	name := strings.TrimSpace(a.Name)
+	if name == "" || strings.HasPrefix(name, "-") {
+		return nil, fmt.Errorf("switch 需要有效的分支名 name")
+	}
+	args := []string{"switch"}
+	if a.Force {
+		args = append(args, "--discard-changes")
+	}
+	if a.Create {
+		args = append(args, "-c")
+	}
+	return append(args, name), nil
+}
+
+func (t *GitOpsTool) addArgs(a gitOpsArgs) ([]string, error) {
+	paths, err := t.resolvePaths(a.Paths)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return []string{"add", "--all"}, nil
+	}
+	return append([]string{"add", "--"}, paths...), nil
+}
+
+func (t *GitOpsTool) commitArgs(a gitOpsArgs) ([]string, error) {
+	msg := strings.TrimSpace(a.Message)
+	if msg == "" {
+		return nil, fmt.Errorf("commit 需要提交说明 message")
+	}
+	paths, err := t.resolvePaths(a.Paths)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"commit"}
+	if a.All {
+		args = append(args, "-a")
+	}
+	args = append(args, "-m", msg)
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	return args, nil
+}
+
+func (t *GitOpsTool) diffArgs(a gitOpsArgs) ([]string, error) {
+	paths, err := t.resolvePaths(a.Paths)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"diff"}
+	if a.Staged {
+		args = append(args, "--staged")
+	}
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	return args, nil
+}
+
+func (t *GitOpsTool) stashArgs(a gitOpsArgs) ([]string, error) {
+	op := a.StashOp
+	if op == "" {
+		op = "push"
+	}
+	switch op {
+	case "push":
+		args := []string{"stash", "push"}
+		if msg := strings.TrimSpace(a.Message); msg != "" {
+			args = append(args, "-m", msg)
+		}
+		return args, nil
+	case "pop", "apply", "list", "show":
+		return []string{"stash", op}, nil
+	case "drop", "clear":
+		// Dropped stashes are unrecoverable without reflog spelunking.
+		if !t.allowForce {
+			return nil, fmt.Errorf("安全限制: stash %s 会永久丢弃改动，已禁用（可设置 TOOL_GIT_ALLOW_FORCE=true）", op)
+		}
+		return []string{"stash", op}, nil
+	default:
+		return nil, fmt.Errorf("不支持的 stash_op %q，允许: push/pop/apply/list/show/drop/clear", op)
+	}
+}
+
+func (t *GitOpsTool) pushArgs(ctx context.Context, a gitOpsArgs) ([]string, error) {
+	if !t.allowPush {
+		return nil, fmt.Errorf("安全限制: push 已禁用，改动只能在本地提交（可设置 TOOL_GIT_ALLOW_PUSH=true）")
+	}
+	remote := strings.TrimSpace(a.Remote)
+	if remote == "" {
+		remote = "origin"
+	}
+	if strings.HasPrefix(remote, "-") || strings.Contains(remote, "://") || strings.Contains(remote, ":") {
+		return nil, fmt.Errorf("remote 必须是已配置的远程名，而不是 URL: %q", remote)
+	}
+	branch := strings.TrimSpace(a.Name)
+	if branch == "" {
+		cur, err := t.run(ctx, gitTimeout, "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil || cur == "HEAD" {
+			return nil, fmt.Errorf("无法确定当前分支，请通过 name 指定")
+		}
+		branch = cur
+	}
+	// "heads/main" and "refs/heads/main" name main too: check the short
+	// name and push an explicit refspec, so git cannot resolve the name to
+	// another ref than the one checked.
+	branch = shortBranchName(branch)
+	if strings.HasPrefix(branch, "refs/") {
+		return nil, fmt.Errorf("只能推送分支，不能推送 %q", branch)
+	}
+	if err := t.checkRefName(ctx, branch); err != nil {
+		return nil, err
+	}
+	if t.protected[branch] {
+		return nil, fmt.Errorf("安全限制: 分支 %q 受保护，禁止推送", branch)
+	}
+	args := []string{"push"}
+	if a.Force {
+		args = append(args, "--force-with-lease")
+	}
+	ref := "refs/heads/" + branch
+	return append(args, remote, ref+":"+ref), nil
+}
+
+// shortBranchName strips the refs/heads/ or heads/ prefix of a branch name.
+func shortBranchName(name string) string {
+	if short, ok := strings.CutPrefix(name, "refs/heads/"); ok {
+		return short
+	}
+	if short, ok := strings.CutPrefix(name, "heads/"); ok {
+		return short
+	}
+	return name
+}
+
+// checkRefName rejects empty, option-like or malformed branch names.
+func (t *GitOpsTool) checkRefName(ctx context.Context, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("需要分支名 name")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("无效的分支名 %q", name)
+	}
+	if _, err := t.run(ctx, gitTimeout, "check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("无效的分支名 %q", name)
+	}
+	return nil
+}
+
+// resolvePaths splits a whitespace-separated path list and confines every
+// entry to the workspace. Returned paths are workspace-relative.
+func (t *GitOpsTool) resolvePaths(paths string) ([]string, error) {
+	var out []string
+	for _, p := range splitArgs(paths) {
+		resolved, err := safeResolvePath(p, t.workspaceDir)
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(t.workspaceDir, resolved)
+		if err != nil {
+			rel = resolved
+		}
+		out = append(out, filepath.ToSlash(rel))
+	}
+	return out, nil
+}
+
+// run executes git in the workspace and returns trimmed, truncated output.
+// The error (if any) is a Chinese summary suitable for ToolResult.Error.
+func (t *GitOpsTool) run(ctx context.Context, timeout time.Duration, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = t.workspaceDir
+	cmd.Env = append(filterEnv(os.Environ()),
+		"GIT_TERMINAL_PROMPT=0", // never block on credential prompts
+		"GIT_EDITOR=true",       // never open an editor (merge messages etc.)
+	)
+	if args[0] == "commit" {
+		cmd.Env = append(cmd.Env, t.authorEnv(ctx)...)
+	}
+
+	output, err := cmd.CombinedOutput()
+	outStr := safeRuneTruncate(strings.TrimSpace(string(output)), maxOutputChars)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return outStr, fmt.Errorf("git 命令超时 (%v)", timeout)
+		}
+		return outStr, fmt.Errorf("git 命令错误: %v", err)
+	}
+	return outStr, nil
+}
+
+// authorEnv supplies a fallback identity when the repo has none configured.
+func (t *GitOpsTool) authorEnv(ctx context.Context) []string {
+	var env []string
+	if name, _ := t.configValue(ctx, "user.name"); name == "" {
+		env = append(env, "GIT_AUTHOR_NAME="+gitDefaultAuthorName, "GIT_COMMITTER_NAME="+gitDefaultAuthorName)
+	}
+	if email, _ := t.configValue(ctx, "user.email"); email == "" {
+		env = append(env, "GIT_AUTHOR_EMAIL="+gitDefaultAuthorEmail, "GIT_COMMITTER_EMAIL="+gitDefaultAuthorEmail)
+	}
+	return env
+}
+
+func (t *GitOpsTool) configValue(ctx context.Context, key string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "config", "--get", key)
+	cmd.Dir = t.workspaceDir
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,163 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func execGitOps(t *testing.T, tool *GitOpsTool, argsJSON string) (string, string) {
+	t.Helper()
+	result, err := tool.Execute(context.Background(), json.RawMessage(argsJSON))
+	if err != nil {
+		t.Fatalf("Execute returned Go error: %v", err)
+	}
+	return result.Output, result.Error
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestGitOps_BranchAddCommit(t *testing.T) {
+	dir := setupTempRepo(t)
+	g := NewGitOpsTool(dir)
+
+	if _, errMsg := execGitOps(t, g, `{"action":"switch","name":"feature/x","create":true}`); errMsg != "" {
+		t.Fatalf("switch -c: %s", errMsg)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, errMsg := execGitOps(t, g, `{"action":"add","paths":"a.txt"}`); errMsg != "" {
+		t.Fatalf("add: %s", errMsg)
+	}
+	out, errMsg := execGitOps(t, g, `{"action":"diff","staged":true}`)
+	if errMsg != "" || !strings.Contains(out, "+hello") {
+		t.Fatalf("diff --staged: out=%q err=%s", out, errMsg)
+	}
+	out, errMsg = execGitOps(t, g, `{"action":"commit","message":"Add a.txt"}`)
+	if errMsg != "" {
+		t.Fatalf("commit: %s", errMsg)
+	}
+	if !strings.Contains(out, "Add a.txt") {
+		t.Errorf("commit output should include the new commit summary: %s", out)
+	}
+	if got := gitOutput(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != "feature/x" {
+		t.Errorf("current branch = %q, want feature/x", got)
+	}
+}
+
+func TestGitOps_CommitFallbackAuthor(t *testing.T) {
+	dir := t.TempDir()
+	gitOutput(t, dir, "init")
+	if err := os.WriteFile(filepath.Join(dir, "f.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g := NewGitOpsTool(dir)
+	execGitOps(t, g, `{"action":"add"}`)
+	// Isolate from the developer's global identity.
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	if _, errMsg := execGitOps(t, g, `{"action":"commit","message":"init"}`); errMsg != "" {
+		t.Fatalf("commit without identity should use fallback author: %s", errMsg)
+	}
+	if got := gitOutput(t, dir, "log", "-1", "--format=%an"); got != gitDefaultAuthorName {
+		t.Errorf("author = %q, want %q", got, gitDefaultAuthorName)
+	}
+}
+
+func TestGitOps_Stash(t *testing.T) {
+	dir := setupTempRepo(t)
+	if err := os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitOutput(t, dir, "add", "tracked.txt")
+	gitOutput(t, dir, "commit", "-m", "tracked")
+	if err := os.WriteFile(filepath.Join(dir, "tracked.txt"), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := NewGitOpsTool(dir)
+	if _, errMsg := execGitOps(t, g, `{"action":"stash","message":"wip"}`); errMsg != "" {
+		t.Fatalf("stash push: %s", errMsg)
+	}
+	out, _ := execGitOps(t, g, `{"action":"stash","stash_op":"list"}`)
+	if !strings.Contains(out, "wip") {
+		t.Errorf("stash list should show wip: %s", out)
+	}
+	if _, errMsg := execGitOps(t, g, `{"action":"stash","stash_op":"drop"}`); !strings.Contains(errMsg, "安全限制") {
+		t.Errorf("stash drop should be blocked by default, got %q", errMsg)
+	}
+	if _, errMsg := execGitOps(t, g, `{"action":"stash","stash_op":"pop"}`); errMsg != "" {
+		t.Fatalf("stash pop: %s", errMsg)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "tracked.txt")); string(data) != "v2" {
+		t.Errorf("stash pop should restore v2, got %q", data)
+	}
+}
+
+func TestGitOps_Protection(t *testing.T) {
+	dir := setupTempRepo(t)
+	g := NewGitOpsTool(dir)
+
+	tests := []struct {
+		name string
+		args string
+	}{
+		{"push disabled", `{"action":"push","name":"feature"}`},
+		{"force disabled", `{"action":"branch","name":"old","delete":true,"force":true}`},
+		{"discard disabled", `{"action":"switch","name":"main","force":true}`},
+		{"option injection", `{"action":"branch","name":"--help"}`},
+		{"path escape", `{"action":"add","paths":"../outside.txt"}`},
+		{"missing message", `{"action":"commit"}`},
+		{"unknown action", `{"action":"reset"}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, errMsg := execGitOps(t, g, tc.args); errMsg == "" {
+				t.Errorf("%s: expected error", tc.args)
+			}
+		})
+	}
+
+	// Even with push allowed, protected branches and URL remotes are rejected.
+	g.WithPush(true)
+	for _, name := range []string{"main", "heads/main", "refs/heads/main"} {
+		if _, errMsg := execGitOps(t, g, `{"action":"push","name":"`+name+`"}`); !strings.Contains(errMsg, "受保护") {
+			t.Errorf("push to %s should be blocked, got %q", name, errMsg)
+		}
+	}
+	if _, errMsg := execGitOps(t, g, `{"action":"push","name":"refs/tags/v1"}`); errMsg == "" {
+		t.Error("push of a non-branch ref should be rejected")
+	}
+	if _, errMsg := execGitOps(t, g, `{"action":"push","remote":"https://evil.example/x.git","name":"f"}`); errMsg == "" {
+		t.Error("push to a URL remote should be rejected")
+	}
+}
+
+func TestGitOps_PushRefspec(t *testing.T) {
+	dir := setupTempRepo(t)
+	remote := t.TempDir()
+	gitOutput(t, remote, "init", "--bare")
+	gitOutput(t, dir, "remote", "add", "origin", remote)
+	gitOutput(t, dir, "branch", "feature")
+	g := NewGitOpsTool(dir).WithPush(true)
+
+	if _, errMsg := execGitOps(t, g, `{"action":"push","name":"heads/feature"}`); errMsg != "" {
+		t.Fatalf("push heads/feature: %s", errMsg)
+	}
+	if refs := gitOutput(t, remote, "for-each-ref", "--format=%(refname)"); refs != "refs/heads/feature" {
+		t.Errorf("remote refs = %q, want only refs/heads/feature", refs)
+	}
+}
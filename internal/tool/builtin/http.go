@@ -8,6 +8,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	neturl "net/url"
 	"strings"
 	"time"
 	"unicode/utf8"
@@ -20,6 +21,7 @@ const (
 	httpMaxTimeout       = 30   // seconds, hard upper bound
 	httpDefaultTimeout   = 10   // seconds
 	httpMaxRedirects     = 3
+	httpMaxPages         = 10 // hard upper bound of max_pages
 )
 
 // privateNetworks lists all IPv4/IPv6 address ranges considered internal.
@@ -90,7 +92,7 @@ func NewHTTPRequestTool(allowInternal bool) *HTTPRequestTool {
 
 func (t *HTTPRequestTool) Name() string { return "http_request" }
 func (t *HTTPRequestTool) Description() string {
-	return "发送 HTTP 请求并返回响应，用于 API 调试、Webhook 测试、接口验证。默认禁止访问内网地址（可通过 TOOL_HTTP_ALLOW_INTERNAL=true 开启）。"
+	return "发送 HTTP 请求并返回响应，用于 API 调试、Webhook 测试、接口验证。可用 extract 按 JSONPath/CSS 选择器只取需要的字段，用 max_pages 自动翻页汇总多页结果。默认禁止访问内网地址（可通过 TOOL_HTTP_ALLOW_INTERNAL=true 开启）。"
 }
 
 func (t *HTTPRequestTool) InputSchema() json.RawMessage {
@@ -100,6 +102,10 @@ func (t *HTTPRequestTool) InputSchema() json.RawMessage {
 		tool.SchemaParam{Name: "headers", Type: "object", Description: "请求头键值对", Required: false},
 		tool.SchemaParam{Name: "body", Type: "string", Description: "请求体（POST/PUT 时使用）", Required: false},
 		tool.SchemaParam{Name: "timeout", Type: "integer", Description: "超时秒数（默认 10，上限 30）", Required: false},
+		tool.SchemaParam{Name: "extract", Type: "string", Description: "只返回提取结果：JSON 响应用 JSONPath（如 $.items[*].name，支持 .名称、[n]、[*]、..名称），HTML 响应用 CSS 选择器（如 a.title，末尾加 @href 取属性）", Required: false},
+		tool.SchemaParam{Name: "max_pages", Type: "integer", Description: "自动翻页的最大页数（仅 GET，默认 1 不翻页，上限 10）。按 Link rel=next 头或 JSON 中的 next/next_cursor 等字段取下一页", Required: false},
+		tool.SchemaParam{Name: "cursor_path", Type: "string", Description: "下一页 URL 或游标在 JSON 响应中的 JSONPath（如 $.meta.cursor），默认自动识别", Required: false},
+		tool.SchemaParam{Name: "cursor_param", Type: "string", Description: "传回游标的查询参数名（默认 cursor，自动识别 nextPageToken 等时随之推断）", Required: false},
 	)
 }
 
@@ -112,8 +118,29 @@ type httpRequestArgs struct {
 	Headers map[string]string `json:"headers"`
 	Body    string            `json:"body"`
 	Timeout int               `json:"timeout"`
+
+	Extract     string `json:"extract,omitempty"`
+	MaxPages    int    `json:"max_pages,omitempty"`
+	CursorPath  string `json:"cursor_path,omitempty"`
+	CursorParam string `json:"cursor_param,omitempty"`
+}
+
+// ReadOnlyCall implements tool.ReadOnlyCaller: only GET/HEAD/OPTIONS requests
+// run in read-only mode.
+func (t *HTTPRequestTool) ReadOnlyCall(args json.RawMessage) bool {
+	var a httpRequestArgs
+	if json.Unmarshal(args, &a) != nil {
+		return false
+	}
+	switch strings.ToUpper(strings.TrimSpace(a.Method)) {
+	case "", "GET", "HEAD", "OPTIONS":
+		return true
+	}
+	return false
 }
 
+func (t *HTTPRequestTool) ReadOnlyHint() string { return "仅 GET/HEAD/OPTIONS 请求可执行" }
+
 func (t *HTTPRequestTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
 	var a httpRequestArgs
 	if err := json.Unmarshal(args, &a); err != nil {
@@ -168,13 +195,11 @@ func (t *HTTPRequestTool) Execute(ctx context.Context, args json.RawMessage) (to
 		},
 	}
 
-	redirectsDone := 0
 	client := &http.Client{
 		Timeout:   timeout,
 		Transport: transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			redirectsDone++
-			if redirectsDone > httpMaxRedirects {
+			if len(via) > httpMaxRedirects {
 				return fmt.Errorf("超过最大重定向次数 %d", httpMaxRedirects)
 			}
 			// Second line of defense for redirect targets; DialContext also checks,
@@ -188,60 +213,99 @@ func (t *HTTPRequestTool) Execute(ctx context.Context, args json.RawMessage) (to
 		},
 	}
 
-	// Build request
-	var bodyReader io.Reader
-	if a.Body != "" {
-		bodyReader = strings.NewReader(a.Body)
-	}
-	req, err := http.NewRequestWithContext(ctx, method, a.URL, bodyReader)
-	if err != nil {
-		return tool.ToolResult{Error: fmt.Sprintf("创建请求失败: %v", err)}, nil
-	}
-	for k, v := range a.Headers {
-		req.Header.Set(k, v)
-	}
-
-	// Execute
-	start := time.Now()
-	resp, err := client.Do(req)
-	elapsed := time.Since(start)
-	if err != nil {
-		return tool.ToolResult{Error: fmt.Sprintf("请求失败: %v", err)}, nil
+	fetch := func(method, url, body string) (*httpPage, error) {
+		var bodyReader io.Reader
+		if body != "" {
+			bodyReader = strings.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+		if err != nil {
+			return nil, fmt.Errorf("创建请求失败: %v", err)
+		}
+		for k, v := range a.Headers {
+			req.Header.Set(k, v)
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("请求失败: %v", err)
+		}
+		defer resp.Body.Close()
+		// Read response body with a 1MB raw cap to prevent OOM
+		raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return nil, fmt.Errorf("读取响应体失败: %v", err)
+		}
+		return &httpPage{url: resp.Request.URL.String(), status: resp.Status, code: resp.StatusCode,
+			header: resp.Header, body: raw, elapsed: time.Since(start)}, nil
 	}
-	defer resp.Body.Close()
 
-	// Read response body with a 1MB raw cap to prevent OOM
-	rawBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	first, err := fetch(method, a.URL, a.Body)
 	if err != nil {
-		return tool.ToolResult{Error: fmt.Sprintf("读取响应体失败: %v", err)}, nil
+		return tool.ToolResult{Error: err.Error()}, nil
 	}
 
-	contentType := resp.Header.Get("Content-Type")
+	contentType := first.header.Get("Content-Type")
 
 	// Detect binary response
-	if isBinaryHTTPResponse(contentType, rawBody) {
+	if isBinaryHTTPResponse(contentType, first.body) {
 		return tool.ToolResult{
 			Output: fmt.Sprintf("状态: %s\n耗时: %dms\n\nContent-Type: %s\n响应体: 二进制内容 (%d bytes)，未显示",
-				resp.Status, elapsed.Milliseconds(), contentType, len(rawBody)),
+				first.status, first.elapsed.Milliseconds(), contentType, len(first.body)),
 		}, nil
 	}
 
-	bodyStr := string(rawBody)
-	truncated := false
-	if utf8.RuneCountInString(bodyStr) > httpMaxResponseChars {
-		runes := []rune(bodyStr)
-		bodyStr = string(runes[:httpMaxResponseChars])
-		truncated = true
+	// Pagination: follow Link rel="next" / next URLs or cursors in JSON
+	// bodies, GET only, while pages succeed and up to max_pages. Every page
+	// carries the caller's headers, so only same-origin pages are followed.
+	pages := []*httpPage{first}
+	maxPages := min(max(a.MaxPages, 1), httpMaxPages)
+	var pageNote string
+	if maxPages > 1 && method == "GET" {
+		visited := map[string]bool{first.url: true}
+		for last := first; ; {
+			if last.code < 200 || last.code > 299 {
+				break
+			}
+			next, err := nextPageURL(last, a.CursorPath, a.CursorParam)
+			if err != nil {
+				pageNote = fmt.Sprintf("（分页停止: %v）", err)
+				break
+			}
+			if next == "" || visited[next] {
+				break
+			}
+			if !sameOrigin(a.URL, next) {
+				pageNote = fmt.Sprintf("（下一页 %s 与请求不同源，为避免泄露请求头已停止分页）", next)
+				break
+			}
+			if len(pages) >= maxPages {
+				pageNote = fmt.Sprintf("（已达上限 %d 页，下一页: %s）", maxPages, next)
+				break
+			}
+			visited[next] = true
+			page, err := fetch("GET", next, "")
+			if err != nil {
+				pageNote = fmt.Sprintf("（第 %d 页失败: %v）", len(pages)+1, err)
+				break
+			}
+			pages = append(pages, page)
+			last = page
+		}
 	}
 
 	// Build formatted output
+	var elapsed time.Duration
+	for _, p := range pages {
+		elapsed += p.elapsed
+	}
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("状态: %s\n", resp.Status))
+	sb.WriteString(fmt.Sprintf("状态: %s\n", first.status))
 	sb.WriteString(fmt.Sprintf("耗时: %dms\n", elapsed.Milliseconds()))
 
 	// Emit only headers useful to the agent; skip Set-Cookie, auth tokens, etc.
 	var headerLines []string
-	for k, vs := range resp.Header {
+	for k, vs := range first.header {
 		if usefulResponseHeaders[http.CanonicalHeaderKey(k)] {
 			headerLines = append(headerLines, fmt.Sprintf("  %s: %s", k, strings.Join(vs, ", ")))
 		}
@@ -252,16 +316,243 @@ func (t *HTTPRequestTool) Execute(ctx context.Context, args json.RawMessage) (to
 			sb.WriteString(line + "\n")
 		}
 	}
+	if len(pages) > 1 || pageNote != "" {
+		sb.WriteString(fmt.Sprintf("\n分页: 共 %d 页%s\n", len(pages), pageNote))
+		for i, p := range pages[1:] {
+			if p.code < 200 || p.code > 299 {
+				sb.WriteString(fmt.Sprintf("  第 %d 页状态: %s\n", i+2, p.status))
+			}
+		}
+	}
+
+	if strings.TrimSpace(a.Extract) != "" {
+		var values []string
+		for _, p := range pages {
+			v, err := extractFromBody(p.body, a.Extract)
+			if err != nil {
+				return tool.ToolResult{Error: fmt.Sprintf("提取失败 (%s): %v", p.url, err)}, nil
+			}
+			values = append(values, v...)
+		}
+		writeExtracted(&sb, a.Extract, values)
+		return tool.ToolResult{Output: sb.String()}, nil
+	}
+
+	var bodyStr string
+	rawLen := 0
+	for i, p := range pages {
+		if len(pages) > 1 {
+			bodyStr += fmt.Sprintf("\n--- 第 %d 页: %s ---\n", i+1, p.url)
+		}
+		bodyStr += string(p.body)
+		rawLen += len(p.body)
+	}
+	truncated := false
+	if utf8.RuneCountInString(bodyStr) > httpMaxResponseChars {
+		runes := []rune(bodyStr)
+		bodyStr = string(runes[:httpMaxResponseChars])
+		truncated = true
+	}
 
 	sb.WriteString("\nBody:\n")
 	sb.WriteString(bodyStr)
 	if truncated {
-		sb.WriteString(fmt.Sprintf("\n...[响应体已截断，共 %d bytes]", len(rawBody)))
+		sb.WriteString(fmt.Sprintf("\n...[响应体已截断，共 %d bytes]", rawLen))
 	}
 
 	return tool.ToolResult{Output: sb.String()}, nil
 }
 
+// httpPage is one fetched response of http_request.
+type httpPage struct {
+	url     string // final URL after redirects
+	status  string
+	code    int
+	header  http.Header
+	body    []byte
+	elapsed time.Duration
+}
+
+// writeExtracted renders extracted values one per line, within
+// httpMaxExtractMatches values and httpMaxResponseChars runes.
+func writeExtracted(sb *strings.Builder, expr string, values []string) {
+	sb.WriteString(fmt.Sprintf("\n提取 %s: %d 项\n", expr, len(values)))
+	if len(values) == 0 {
+		sb.WriteString("（无匹配）")
+		return
+	}
+	chars := 0
+	for i, v := range values {
+		chars += utf8.RuneCountInString(v) + 1
+		if i >= httpMaxExtractMatches || chars > httpMaxResponseChars {
+			sb.WriteString(fmt.Sprintf("...[已截断，显示前 %d 项]", i))
+			return
+		}
+		sb.WriteString(v)
+		sb.WriteByte('\n')
+	}
+}
+
+// httpNextURLPaths locate a next-page URL in common JSON API shapes.
+var httpNextURLPaths = []string{
+	"$.next", "$.next_url", "$.nextUrl", "$.next_page_url",
+	"$.links.next", "$.paging.next", "$._links.next.href", "$.meta.next",
+}
+
+// httpCursorPaths locate a next-page cursor, with the query parameter it
+// is passed back in unless cursor_param is given.
+var httpCursorPaths = []struct{ path, param string }{
+	{"$.next_cursor", "cursor"},
+	{"$.nextCursor", "cursor"},
+	{"$.meta.next_cursor", "cursor"},
+	{"$.pagination.next_cursor", "cursor"},
+	{"$.response_metadata.next_cursor", "cursor"},
+	{"$.next_page_token", "page_token"},
+	{"$.nextPageToken", "pageToken"},
+}
+
+// nextPageURL returns the URL of the page after p, or "" on the last page.
+// A Link rel="next" header wins; otherwise the JSON body is searched with
+// cursorPath, or the common next URL and cursor fields. A cursor is set as
+// query parameter cursorParam of the current URL.
+func nextPageURL(p *httpPage, cursorPath, cursorParam string) (string, error) {
+	base, err := neturl.Parse(p.url)
+	if err != nil {
+		return "", err
+	}
+	if next := linkHeaderNext(p.header.Values("Link")); next != "" {
+		u, err := base.Parse(next)
+		if err != nil {
+			return "", fmt.Errorf("Link 头无效: %v", err)
+		}
+		return u.String(), nil
+	}
+	doc, err := decodeJSONBody(p.body)
+	if err != nil {
+		if cursorPath != "" {
+			return "", fmt.Errorf("响应体不是 JSON，无法读取 cursor_path")
+		}
+		return "", nil
+	}
+	if obj, ok := doc.(map[string]any); ok && obj["has_more"] == false {
+		return "", nil
+	}
+
+	value, param := "", ""
+	if cursorPath != "" {
+		values, err := evalJSONPath(doc, cursorPath)
+		if err != nil {
+			return "", err
+		}
+		if len(values) > 0 {
+			value = jsonScalar(values[0])
+		}
+		param = "cursor"
+	} else {
+		for _, path := range httpNextURLPaths {
+			if values, _ := evalJSONPath(doc, path); len(values) > 0 {
+				if v := jsonScalar(values[0]); isPageURL(v) {
+					value = v
+					break
+				}
+			}
+		}
+		for _, c := range httpCursorPaths {
+			if value != "" {
+				break
+			}
+			if values, _ := evalJSONPath(doc, c.path); len(values) > 0 {
+				value, param = jsonScalar(values[0]), c.param
+			}
+		}
+	}
+	if value == "" {
+		return "", nil
+	}
+	if isPageURL(value) {
+		u, err := base.Parse(value)
+		if err != nil {
+			return "", fmt.Errorf("下一页地址无效: %v", err)
+		}
+		return u.String(), nil
+	}
+	if cursorParam != "" {
+		param = cursorParam
+	}
+	q := base.Query()
+	q.Set(param, value)
+	base.RawQuery = q.Encode()
+	return base.String(), nil
+}
+
+// isPageURL tells a next-page URL (absolute, or relative to the current
+// page) from an opaque cursor.
+func isPageURL(v string) bool {
+	l := strings.ToLower(v)
+	return strings.HasPrefix(l, "http://") || strings.HasPrefix(l, "https://") ||
+		strings.HasPrefix(v, "/") || strings.HasPrefix(v, "?")
+}
+
+// sameOrigin reports whether two URLs share scheme, host and port, with
+// default ports made explicit.
+func sameOrigin(a, b string) bool {
+	ua, err := neturl.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := neturl.Parse(b)
+	if err != nil {
+		return false
+	}
+	port := func(u *neturl.URL) string {
+		if p := u.Port(); p != "" {
+			return p
+		}
+		if strings.EqualFold(u.Scheme, "https") {
+			return "443"
+		}
+		return "80"
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Hostname(), ub.Hostname()) && port(ua) == port(ub)
+}
+
+// jsonScalar renders a string or number value; "" for anything else.
+func jsonScalar(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	}
+	return ""
+}
+
+// linkHeaderNext returns the target of the rel="next" link of RFC 8288
+// Link header values.
+func linkHeaderNext(values []string) string {
+	for _, v := range values {
+		for _, link := range strings.Split(v, ",") {
+			target, params, _ := strings.Cut(link, ";")
+			target = strings.TrimSpace(target)
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				name, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
 // blockInternalHost resolves host to IPs and returns an error if any IP is internal.
 func blockInternalHost(host string) error {
 	ips, err := net.LookupHost(host)
@@ -0,0 +1,411 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ── http_request extraction: JSONPath for JSON bodies, CSS selectors for HTML ──
+
+// httpMaxExtractMatches caps the values returned by one extraction.
+const httpMaxExtractMatches = 500
+
+// jsonPathStep is one segment of a parsed JSONPath expression.
+type jsonPathStep struct {
+	key       string // member name; "" with index/wildcard
+	index     int
+	hasIndex  bool
+	wildcard  bool // .* or [*]
+	recursive bool // .. — applies to the node and all its descendants
+}
+
+// parseJSONPath parses the supported JSONPath subset: $, .name, ['name'],
+// [n] (negative counts from the end), [*], .* and ..name (recursive descent).
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	p := strings.TrimSpace(path)
+	if !strings.HasPrefix(p, "$") {
+		return nil, fmt.Errorf("JSONPath 必须以 $ 开头: %q", path)
+	}
+	p = p[1:]
+	var steps []jsonPathStep
+	for p != "" {
+		var step jsonPathStep
+		switch {
+		case strings.HasPrefix(p, ".."):
+			step.recursive = true
+			p = p[2:]
+			if strings.HasPrefix(p, "[") {
+				break
+			}
+			fallthrough
+		case strings.HasPrefix(p, "."):
+			p = strings.TrimPrefix(p, ".")
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			name := p[:end]
+			if name == "" {
+				return nil, fmt.Errorf("JSONPath 语法错误: %q", path)
+			}
+			step.key, step.wildcard = name, name == "*"
+			p = p[end:]
+			steps = append(steps, step)
+			continue
+		case !strings.HasPrefix(p, "["):
+			return nil, fmt.Errorf("JSONPath 语法错误: %q", path)
+		}
+		end := strings.Index(p, "]")
+		if end < 0 {
+			return nil, fmt.Errorf("JSONPath 缺少 ]: %q", path)
+		}
+		inner := strings.TrimSpace(p[1:end])
+		p = p[end+1:]
+		switch {
+		case inner == "*":
+			step.wildcard = true
+		case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+			step.key = inner[1 : len(inner)-1]
+		default:
+			n, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("JSONPath 不支持的下标 [%s]（支持数字、*、'名称'）", inner)
+			}
+			step.index, step.hasIndex = n, true
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// evalJSONPath returns the values of doc selected by path, in document order.
+func evalJSONPath(doc any, path string) ([]any, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []any{doc}
+	for _, step := range steps {
+		var next []any
+		for _, n := range nodes {
+			if step.recursive {
+				walkJSON(n, func(v any) { next = append(next, applyJSONStep(v, step)...) })
+			} else {
+				next = append(next, applyJSONStep(n, step)...)
+			}
+		}
+		nodes = next
+	}
+	return nodes, nil
+}
+
+func applyJSONStep(n any, step jsonPathStep) []any {
+	switch v := n.(type) {
+	case map[string]any:
+		if step.wildcard {
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			out := make([]any, 0, len(keys))
+			for _, k := range keys {
+				out = append(out, v[k])
+			}
+			return out
+		}
+		if child, ok := v[step.key]; ok && !step.hasIndex {
+			return []any{child}
+		}
+	case []any:
+		if step.wildcard {
+			return v
+		}
+		if step.hasIndex {
+			i := step.index
+			if i < 0 {
+				i += len(v)
+			}
+			if i >= 0 && i < len(v) {
+				return []any{v[i]}
+			}
+		}
+	}
+	return nil
+}
+
+// walkJSON calls fn for n and every value nested in it, depth first.
+func walkJSON(n any, fn func(any)) {
+	fn(n)
+	switch v := n.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkJSON(v[k], fn)
+		}
+	case []any:
+		for _, c := range v {
+			walkJSON(c, fn)
+		}
+	}
+}
+
+// decodeJSONBody parses a response body as JSON, keeping numbers exact.
+func decodeJSONBody(body []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// cssCompound is one compound selector: tag#id.class[attr=value]…
+type cssCompound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []cssAttr
+	child   bool // combinator to the previous compound is ">" instead of descendant
+}
+
+type cssAttr struct {
+	name, value string
+	hasValue    bool
+}
+
+// parseCSSSelector parses a selector group of the supported subset: tag,
+// #id, .class, [attr], [attr=value], descendant (space) and child (>)
+// combinators, and comma-separated alternatives.
+func parseCSSSelector(sel string) ([][]cssCompound, error) {
+	var groups [][]cssCompound
+	for _, alt := range strings.Split(sel, ",") {
+		alt = strings.ReplaceAll(alt, ">", " > ")
+		var chain []cssCompound
+		child := false
+		for _, tok := range strings.Fields(alt) {
+			if tok == ">" {
+				if len(chain) == 0 || child {
+					return nil, fmt.Errorf("CSS 选择器语法错误: %q", sel)
+				}
+				child = true
+				continue
+			}
+			c, err := parseCSSCompound(tok)
+			if err != nil {
+				return nil, err
+			}
+			c.child = child
+			child = false
+			chain = append(chain, c)
+		}
+		if len(chain) == 0 || child {
+			return nil, fmt.Errorf("CSS 选择器语法错误: %q", sel)
+		}
+		groups = append(groups, chain)
+	}
+	return groups, nil
+}
+
+func parseCSSCompound(tok string) (cssCompound, error) {
+	var c cssCompound
+	i := strings.IndexAny(tok, "#.[")
+	if i < 0 {
+		i = len(tok)
+	}
+	c.tag = strings.ToLower(tok[:i])
+	if c.tag == "*" {
+		c.tag = ""
+	}
+	rest := tok[i:]
+	for rest != "" {
+		switch rest[0] {
+		case '#', '.':
+			end := strings.IndexAny(rest[1:], "#.[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			name := rest[1 : end+1]
+			if name == "" {
+				return c, fmt.Errorf("CSS 选择器语法错误: %q", tok)
+			}
+			if rest[0] == '#' {
+				c.id = name
+			} else {
+				c.classes = append(c.classes, name)
+			}
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return c, fmt.Errorf("CSS 选择器缺少 ]: %q", tok)
+			}
+			name, value, hasValue := strings.Cut(rest[1:end], "=")
+			value = strings.Trim(value, `"'`)
+			c.attrs = append(c.attrs, cssAttr{name: strings.ToLower(strings.TrimSpace(name)), value: value, hasValue: hasValue})
+			rest = rest[end+1:]
+		default:
+			return c, fmt.Errorf("CSS 选择器语法错误: %q", tok)
+		}
+	}
+	return c, nil
+}
+
+func (c cssCompound) matches(n *html.Node) bool {
+	if n.Type != html.ElementNode || (c.tag != "" && n.Data != c.tag) {
+		return false
+	}
+	if c.id != "" && htmlAttr(n, "id") != c.id {
+		return false
+	}
+	if len(c.classes) > 0 {
+		have := strings.Fields(htmlAttr(n, "class"))
+		for _, want := range c.classes {
+			found := false
+			for _, h := range have {
+				found = found || h == want
+			}
+			if !found {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		v, ok := htmlAttrOK(n, a.name)
+		if !ok || (a.hasValue && v != a.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCSSChain reports whether n matches chain, right to left through
+// its ancestors.
+func matchCSSChain(n *html.Node, chain []cssCompound) bool {
+	last := chain[len(chain)-1]
+	if !last.matches(n) {
+		return false
+	}
+	if len(chain) == 1 {
+		return true
+	}
+	rest := chain[:len(chain)-1]
+	for p := n.Parent; p != nil; p = p.Parent {
+		if matchCSSChain(p, rest) {
+			return true
+		}
+		if last.child {
+			return false
+		}
+	}
+	return false
+}
+
+func htmlAttrOK(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func htmlAttr(n *html.Node, name string) string {
+	v, _ := htmlAttrOK(n, name)
+	return v
+}
+
+// htmlText returns the whitespace-collapsed text content of n.
+func htmlText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteByte(' ')
+		}
+		if n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style") {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// selectHTML returns the text (or, with a trailing "@attr", the attribute
+// value) of every element matching the CSS selector, in document order.
+func selectHTML(body []byte, selector string) ([]string, error) {
+	attr := ""
+	if i := strings.LastIndex(selector, "@"); i >= 0 {
+		attr = strings.ToLower(strings.TrimSpace(selector[i+1:]))
+		selector = selector[:i]
+		if attr == "" {
+			return nil, fmt.Errorf("@ 后缺少属性名")
+		}
+	}
+	groups, err := parseCSSSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("HTML 解析失败: %v", err)
+	}
+	var out []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for _, chain := range groups {
+			if !matchCSSChain(n, chain) {
+				continue
+			}
+			if attr == "" {
+				out = append(out, htmlText(n))
+			} else if v, ok := htmlAttrOK(n, attr); ok {
+				out = append(out, v)
+			}
+			break
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return out, nil
+}
+
+// extractFromBody applies an extract expression to one response body:
+// JSONPath when it starts with "$" (the body must be JSON), a CSS selector
+// otherwise. Each value is rendered on one line (JSON values compact).
+func extractFromBody(body []byte, expr string) ([]string, error) {
+	if strings.HasPrefix(strings.TrimSpace(expr), "$") {
+		doc, err := decodeJSONBody(body)
+		if err != nil {
+			return nil, fmt.Errorf("响应体不是 JSON，无法按 JSONPath 提取: %v", err)
+		}
+		values, err := evalJSONPath(doc, expr)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			b, _ := json.Marshal(v)
+			out = append(out, string(b))
+		}
+		return out, nil
+	}
+	return selectHTML(body, expr)
+}
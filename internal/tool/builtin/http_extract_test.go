@@ -0,0 +1,87 @@
+package builtin
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractFromBody_JSONPath(t *testing.T) {
+	body := []byte(`{"items":[{"name":"a","id":1,"tags":["x"]},{"name":"b","id":2}],
+		"meta":{"name":"m","total":12345678901234567890}}`)
+	cases := map[string]string{
+		"$.items[*].name":    `"a" "b"`,
+		"$.items[-1].id":     `2`,
+		"$['meta'].total":    `12345678901234567890`,
+		"$..name":            `"a" "b" "m"`,
+		"$.items[0].tags":    `["x"]`,
+		"$.meta.*":           `"m" 12345678901234567890`,
+		"$.missing[*]":       ``,
+		"$.items[5].name":    ``,
+		"$.items[0][\"id\"]": `1`,
+	}
+	for expr, want := range cases {
+		got, err := extractFromBody(body, expr)
+		if err != nil {
+			t.Errorf("%s: %v", expr, err)
+			continue
+		}
+		if strings.Join(got, " ") != want {
+			t.Errorf("%s = %q, want %q", expr, strings.Join(got, " "), want)
+		}
+	}
+	for _, bad := range []string{"$.items[", "$.items[1:2]", "$.", "items"} {
+		if _, err := evalJSONPath(nil, bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+	if _, err := extractFromBody([]byte("<html></html>"), "$.a"); err == nil {
+		t.Error("JSONPath on an HTML body accepted")
+	}
+}
+
+func TestExtractFromBody_CSS(t *testing.T) {
+	body := []byte(`<html><body>
+		<ul id="list">
+			<li class="item hot"><a href="/1">First <b>one</b></a></li>
+			<li class="item"><a href="/2" data-x="y">Second</a></li>
+		</ul>
+		<div><a href="/other">Other</a><script>var x;</script></div>
+	</body></html>`)
+	cases := map[string]string{
+		"li.item a":            "First one|Second",
+		"li.item.hot":          "First one",
+		"#list > li > a @href": "/1|/2",
+		"ul > a":               "",
+		"a[data-x=y]":          "Second",
+		"a[data-x] @data-x":    "y",
+		"div, b":               "one|Other",
+		"body > div":           "Other",
+	}
+	for sel, want := range cases {
+		got, err := extractFromBody(body, sel)
+		if err != nil {
+			t.Errorf("%s: %v", sel, err)
+			continue
+		}
+		if strings.Join(got, "|") != want {
+			t.Errorf("%s = %q, want %q", sel, strings.Join(got, "|"), want)
+		}
+	}
+	for _, bad := range []string{"> a", "a >", "a[href", "a @", "li..x"} {
+		if _, err := extractFromBody(body, bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func TestLinkHeaderNext(t *testing.T) {
+	got := linkHeaderNext([]string{
+		`<https://api.example.com/x?page=1>; rel="prev", <https://api.example.com/x?page=3>; rel="next last"`,
+	})
+	if got != "https://api.example.com/x?page=3" {
+		t.Errorf("next = %q", got)
+	}
+	if got := linkHeaderNext([]string{`<https://a/x>; rel="prev"`}); got != "" {
+		t.Errorf("no next: got %q", got)
+	}
+}
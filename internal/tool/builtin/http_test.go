@@ -436,3 +436,154 @@ func TestPrivateNetworksInitialized(t *testing.T) {
 		t.Error("192.168.1.1 should be in privateNetworks")
 	}
 }
+
+// ── extraction and pagination ────────────────────────────────────────────────
+
+func TestHTTPRequestTool_ExtractAndLinkPagination(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+		if page != "3" {
+			next := map[string]string{"1": "2", "2": "3"}[page]
+			w.Header().Set("Link", `<`+server.URL+`/items?page=`+next+`>; rel="next"`)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[{"name":"p` + page + `"}]}`))
+	}))
+	defer server.Close()
+
+	args, _ := json.Marshal(httpRequestArgs{URL: server.URL + "/items", Extract: "$.items[*].name", MaxPages: 2})
+	result, _ := NewHTTPRequestTool(true).Execute(context.Background(), args)
+	if result.Error != "" {
+		t.Fatalf("tool error: %s", result.Error)
+	}
+	for _, want := range []string{"分页: 共 2 页（已达上限 2 页，下一页: " + server.URL + "/items?page=3）", "提取 $.items[*].name: 2 项", "\"p1\"\n\"p2\"\n"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, result.Output)
+		}
+	}
+	if strings.Contains(result.Output, "Body:") {
+		t.Errorf("extraction should replace the body:\n%s", result.Output)
+	}
+}
+
+func TestHTTPRequestTool_CursorPagination(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		if r.Header.Get("Authorization") != "Bearer t" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Query().Get("pageToken") {
+		case "":
+			w.Write([]byte(`{"data":[1,2],"nextPageToken":"abc"}`))
+		case "abc":
+			w.Write([]byte(`{"data":[3],"nextPageToken":""}`))
+		}
+	}))
+	defer server.Close()
+
+	args, _ := json.Marshal(httpRequestArgs{URL: server.URL + "/?q=x", Headers: map[string]string{"Authorization": "Bearer t"},
+		Extract: "$.data[*]", MaxPages: 5})
+	result, _ := NewHTTPRequestTool(true).Execute(context.Background(), args)
+	if result.Error != "" {
+		t.Fatalf("tool error: %s", result.Error)
+	}
+	if !strings.Contains(result.Output, "分页: 共 2 页\n") || !strings.Contains(result.Output, "3 项\n1\n2\n3\n") {
+		t.Errorf("output:\n%s", result.Output)
+	}
+	if len(requests) != 2 || requests[1] != "pageToken=abc&q=x" {
+		t.Errorf("requests = %q", requests)
+	}
+}
+
+func TestHTTPRequestTool_PaginationStaysSameOrigin(t *testing.T) {
+	var leaked []string
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"data":[2]}`))
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `<`+other.URL+`/items?page=2>; rel="next"`)
+		w.Write([]byte(`{"data":[1]}`))
+	}))
+	defer server.Close()
+
+	args, _ := json.Marshal(httpRequestArgs{URL: server.URL + "/items", Headers: map[string]string{"Authorization": "Bearer secret"}, MaxPages: 3})
+	result, _ := NewHTTPRequestTool(true).Execute(context.Background(), args)
+	if result.Error != "" {
+		t.Fatalf("tool error: %s", result.Error)
+	}
+	if len(leaked) != 0 {
+		t.Errorf("cross-origin next page was fetched with Authorization %q", leaked)
+	}
+	if !strings.Contains(result.Output, "分页: 共 1 页（下一页 "+other.URL+"/items?page=2 与请求不同源") {
+		t.Errorf("output:\n%s", result.Output)
+	}
+}
+
+func TestSameOrigin(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://api.example.com/v1", "https://API.example.com:443/v1?page=2", true},
+		{"http://api.example.com/", "http://api.example.com:80/next", true},
+		{"https://api.example.com/", "http://api.example.com/", false},
+		{"https://api.example.com/", "https://evil.example.com/", false},
+		{"https://api.example.com/", "https://api.example.com:8443/", false},
+	}
+	for _, tc := range tests {
+		if got := sameOrigin(tc.a, tc.b); got != tc.want {
+			t.Errorf("sameOrigin(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestHTTPRequestTool_PaginationOnlyForGET(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"next":"/again","has_more":true}`))
+	}))
+	defer server.Close()
+
+	args, _ := json.Marshal(httpRequestArgs{URL: server.URL, Method: "POST", MaxPages: 3})
+	NewHTTPRequestTool(true).Execute(context.Background(), args)
+	if calls != 1 {
+		t.Errorf("POST followed pagination: %d calls", calls)
+	}
+
+	// GET without extract: pages are concatenated; a repeated URL ends the loop
+	calls = 0
+	args, _ = json.Marshal(httpRequestArgs{URL: server.URL + "/again", MaxPages: 3})
+	result, _ := NewHTTPRequestTool(true).Execute(context.Background(), args)
+	if calls != 1 || !strings.Contains(result.Output, `{"next":"/again","has_more":true}`) {
+		t.Errorf("calls = %d, output:\n%s", calls, result.Output)
+	}
+}
+
+func TestHTTPRequestTool_ExtractCSS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<h2 class="title"><a href="/a">A</a></h2><h2 class="title"><a href="/b">B</a></h2>`))
+	}))
+	defer server.Close()
+
+	args, _ := json.Marshal(httpRequestArgs{URL: server.URL, Extract: "h2.title a @href"})
+	result, _ := NewHTTPRequestTool(true).Execute(context.Background(), args)
+	if !strings.HasSuffix(result.Output, "2 项\n/a\n/b\n") {
+		t.Errorf("output:\n%s", result.Output)
+	}
+
+	args, _ = json.Marshal(httpRequestArgs{URL: server.URL, Extract: "$.items"})
+	result, _ = NewHTTPRequestTool(true).Execute(context.Background(), args)
+	if !strings.Contains(result.Error, "不是 JSON") {
+		t.Errorf("JSONPath on HTML: error = %q", result.Error)
+	}
+}
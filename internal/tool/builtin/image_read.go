@@ -0,0 +1,94 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// maxImageSize is the image_read limit; providers reject larger inline images.
+const maxImageSize = 5 << 20 // 5MB
+
+// ── image_read ──
+
+// ImageReadTool loads a workspace image (screenshot, diagram) into the
+// model's context. Only registered when the model supports vision.
+type ImageReadTool struct {
+	workspaceDir string
+}
+
+func NewImageReadTool(workspaceDir string) *ImageReadTool {
+	return &ImageReadTool{workspaceDir: workspaceDir}
+}
+
+func (t *ImageReadTool) Name() string { return "image_read" }
+func (t *ImageReadTool) Description() string {
+	return "读取工作区中的图片（PNG/JPEG/GIF/WebP，如截图、架构图），加载后可直接查看图片内容"
+}
+
+func (t *ImageReadTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "path", Type: "string", Description: "图片文件路径", Required: true},
+	)
+}
+
+func (t *ImageReadTool) Init(_ context.Context) error { return nil }
+func (t *ImageReadTool) Close() error                 { return nil }
+
+func (t *ImageReadTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a filePathArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+
+	path, err := safeResolvePath(a.Path, t.workspaceDir)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("文件不存在: %s", path)}, nil
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("读取文件信息失败: %v", err)}, nil
+	}
+	if info.IsDir() {
+		return tool.ToolResult{Error: "指定路径是目录，请使用 file_list"}, nil
+	}
+	if info.Size() > maxImageSize {
+		return tool.ToolResult{Error: fmt.Sprintf("图片过大 (%d bytes)，最大 %d bytes", info.Size(), maxImageSize)}, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, maxImageSize))
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("读取失败: %v", err)}, nil
+	}
+	mime, ok := llm.DetectImageType(data)
+	if !ok {
+		return tool.ToolResult{Error: fmt.Sprintf("不支持的图片格式 (%s)，仅支持 PNG/JPEG/GIF/WebP", mime)}, nil
+	}
+
+	desc := fmt.Sprintf("%s, %d bytes", mime, len(data))
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		desc = fmt.Sprintf("%dx%d, %s", cfg.Width, cfg.Height, desc)
+	}
+	return tool.ToolResult{
+		Output: fmt.Sprintf("已加载图片 %s (%s)，图片内容已附在下一条消息中。", filepath.Base(path), desc),
+		Images: []llm.ContentPart{llm.NewImagePart(mime, data)},
+	}, nil
+}
@@ -0,0 +1,51 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImageRead(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "shot.png"), buf.Bytes(), 0o644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("hello"), 0o644)
+	os.WriteFile(filepath.Join(dir, "huge.png"), make([]byte, maxImageSize+1), 0o644)
+	tl := NewImageReadTool(dir)
+
+	run := func(path string) (string, string, int) {
+		t.Helper()
+		raw, _ := json.Marshal(map[string]string{"path": path})
+		res, err := tl.Execute(context.Background(), raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Output, res.Error, len(res.Images)
+	}
+
+	out, errMsg, n := run("shot.png")
+	if errMsg != "" || n != 1 || !strings.Contains(out, "3x2") {
+		t.Fatalf("png: out=%q err=%q images=%d", out, errMsg, n)
+	}
+	raw, _ := json.Marshal(map[string]string{"path": "shot.png"})
+	res, _ := tl.Execute(context.Background(), raw)
+	if !strings.HasPrefix(res.Images[0].ImageURL, "data:image/png;base64,") {
+		t.Errorf("image url = %.40s", res.Images[0].ImageURL)
+	}
+
+	for _, path := range []string{"notes.txt", "huge.png", "missing.png", "../outside.png"} {
+		if _, errMsg, n := run(path); errMsg == "" || n != 0 {
+			t.Errorf("%s: err=%q images=%d, want error", path, errMsg, n)
+		}
+	}
+}
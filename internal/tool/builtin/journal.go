@@ -0,0 +1,58 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketomega/pocket-omega/internal/journal"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// maxJournalRunes caps one journal entry; the daily note is a log, not a document.
+const maxJournalRunes = 1000
+
+// JournalAppendTool lets the agent add an entry to today's daily note.
+type JournalAppendTool struct {
+	journal *journal.Journal
+}
+
+// NewJournalAppendTool creates the journal_append tool for j.
+func NewJournalAppendTool(j *journal.Journal) *JournalAppendTool {
+	return &JournalAppendTool{journal: j}
+}
+
+func (t *JournalAppendTool) Name() string { return "journal_append" }
+func (t *JournalAppendTool) Description() string {
+	return "向今天的工作日志（notes/YYYY-MM-DD.md）追加一条带时间戳的记录。用于记下决定、发现、待办或用户要求记录的事项；每次运行本身会自动记录，无需重复"
+}
+
+func (t *JournalAppendTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "content", Type: "string", Description: "记录内容（最多 1000 字符）", Required: true},
+	)
+}
+
+func (t *JournalAppendTool) Init(_ context.Context) error { return nil }
+func (t *JournalAppendTool) Close() error                 { return nil }
+
+type journalAppendArgs struct {
+	Content string `json:"content"`
+}
+
+func (t *JournalAppendTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a journalAppendArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	if utf8.RuneCountInString(a.Content) > maxJournalRunes {
+		return tool.ToolResult{Error: fmt.Sprintf("内容过长（%d 字符），最多 %d 字符", utf8.RuneCountInString(a.Content), maxJournalRunes)}, nil
+	}
+	if err := t.journal.Append(a.Content); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("写入日志失败: %v", err)}, nil
+	}
+	return tool.ToolResult{Output: "📓 已记录到 " + filepath.Base(t.journal.Path(time.Now()))}, nil
+}
@@ -0,0 +1,34 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/journal"
+)
+
+func TestJournalAppend(t *testing.T) {
+	j := journal.New(t.TempDir())
+	tl := NewJournalAppendTool(j)
+	args, _ := json.Marshal(journalAppendArgs{Content: "决定改用 sqlite"})
+	result, err := tl.Execute(context.Background(), args)
+	if err != nil || result.Error != "" {
+		t.Fatalf("Execute = %+v, %v", result, err)
+	}
+	if !strings.Contains(result.Output, time.Now().Format(journal.DayLayout)+".md") {
+		t.Errorf("output = %q", result.Output)
+	}
+	if note, _ := j.Read(time.Now()); !strings.Contains(note, "决定改用 sqlite") {
+		t.Errorf("note = %q", note)
+	}
+
+	for _, content := range []string{"", strings.Repeat("长", maxJournalRunes+1)} {
+		args, _ := json.Marshal(journalAppendArgs{Content: content})
+		if result, _ := tl.Execute(context.Background(), args); result.Error == "" {
+			t.Errorf("content of %d bytes accepted", len(content))
+		}
+	}
+}
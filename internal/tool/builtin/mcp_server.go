@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/pocketomega/pocket-omega/internal/tool"
@@ -28,6 +32,26 @@ type mcpServerEntry struct {
 	Env       []string          `json:"env,omitempty"`
 	Lifecycle string            `json:"lifecycle,omitempty"`
 	Meta      map[string]string `json:"_meta,omitempty"`
+
+	// Dependencies mirrors mcp.Dependencies: packages installed into the
+	// server's own .venv / node_modules on first load.
+	Dependencies *mcpDependencies `json:"dependencies,omitempty"`
+
+	// Auth mirrors mcp.Auth: credentials for sse/http servers, preferably as
+	// ${VAR} references to .env. Never printed unredacted.
+	Auth *mcpAuth `json:"auth,omitempty"`
+}
+
+// mcpAuth is the JSON representation of mcp.Auth.
+type mcpAuth struct {
+	BearerToken string            `json:"bearer_token,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// mcpDependencies is the JSON representation of mcp.Dependencies.
+type mcpDependencies struct {
+	Python []string `json:"python,omitempty"`
+	Node   []string `json:"node,omitempty"`
 }
 
 // readMCPConfig reads and parses mcp.json. Returns an empty MCPServers map if file
@@ -88,19 +112,23 @@ func (t *MCPServerAddTool) InputSchema() json.RawMessage {
 		tool.SchemaParam{Name: "name", Type: "string", Required: true,
 			Description: "Server 名称，全局唯一（mcp.json map key）。示例：excel-tool"},
 		tool.SchemaParam{Name: "transport", Type: "string", Required: true,
-			Description: `传输协议："stdio"（本地进程）或 "sse"（HTTP SSE）。示例：stdio`,
-			Enum:        []string{"stdio", "sse"}},
+			Description: `传输协议："stdio"（本地进程）、"sse"（HTTP SSE）或 "http"（Streamable HTTP）。示例：stdio`,
+			Enum:        []string{"stdio", "sse", "http"}},
 		tool.SchemaParam{Name: "command", Type: "string", Required: false,
 			Description: `stdio 专用：可执行程序路径或名称。示例：node`},
 		tool.SchemaParam{Name: "args", Type: "string", Required: false,
 			Description: `stdio 专用：命令行参数，JSON 数组格式字符串。示例：["--import","tsx","skills/excel/server.ts"]`},
 		tool.SchemaParam{Name: "url", Type: "string", Required: false,
-			Description: `sse 专用：SSE 服务器 URL。示例：http://localhost:8080`},
+			Description: `sse/http 专用：服务器 URL。示例：http://localhost:8080`},
 		tool.SchemaParam{Name: "env", Type: "string", Required: false,
 			Description: `stdio 专用：额外环境变量，JSON 数组格式字符串，形如 ["KEY=VALUE"]。示例：["API_KEY=abc123"]`},
 		tool.SchemaParam{Name: "lifecycle", Type: "string", Required: false,
 			Description: `生命周期："persistent"（默认，进程常驻）或 "per_call"（每次调用新起进程）。示例：persistent`,
 			Enum:        []string{"persistent", "per_call"}},
+		tool.SchemaParam{Name: "dependencies", Type: "string", Required: false,
+			Description: `stdio 专用：依赖包，JSON 对象字符串。mcp_reload 时自动安装到脚本所在目录的 .venv / node_modules（仅首次或依赖变化时安装），python 命令自动改用该 .venv。示例：{"python":["mcp","pandas"]}`},
+		tool.SchemaParam{Name: "auth", Type: "string", Required: false,
+			Description: `sse/http 专用：认证信息，JSON 对象字符串，含 bearer_token 和/或 headers。密钥请用 ${环境变量} 引用 .env 中的值，不要写明文。示例：{"bearer_token":"${GITHUB_TOKEN}"}`},
 	)
 }
 
@@ -112,6 +140,8 @@ type mcpServerAddArgs struct {
 	URL       string `json:"url"`
 	Env       string `json:"env"` // JSON-encoded []string
 	Lifecycle string `json:"lifecycle"`
+	Deps      string `json:"dependencies"` // JSON-encoded mcpDependencies
+	Auth      string `json:"auth"`         // JSON-encoded mcpAuth
 }
 
 func (t *MCPServerAddTool) Execute(_ context.Context, raw json.RawMessage) (tool.ToolResult, error) {
@@ -124,8 +154,8 @@ func (t *MCPServerAddTool) Execute(_ context.Context, raw json.RawMessage) (tool
 	if a.Name == "" {
 		return tool.ToolResult{Error: "name 不得为空"}, nil
 	}
-	if a.Transport != "stdio" && a.Transport != "sse" {
-		return tool.ToolResult{Error: `transport 必须为 "stdio" 或 "sse"，当前值: ` + a.Transport}, nil
+	if a.Transport != "stdio" && a.Transport != "sse" && a.Transport != "http" {
+		return tool.ToolResult{Error: `transport 必须为 "stdio"、"sse" 或 "http"，当前值: ` + a.Transport}, nil
 	}
 
 	// Parse optional JSON-array strings.
@@ -141,6 +171,22 @@ func (t *MCPServerAddTool) Execute(_ context.Context, raw json.RawMessage) (tool
 		}
 	}
 
+	var deps *mcpDependencies
+	if a.Deps != "" {
+		deps = &mcpDependencies{}
+		if err := json.Unmarshal([]byte(a.Deps), deps); err != nil {
+			return tool.ToolResult{Error: fmt.Sprintf(`dependencies 格式错误（需要 JSON 对象字符串，如 {"python":["pandas"]}）: %v`, err)}, nil
+		}
+	}
+
+	var auth *mcpAuth
+	if a.Auth != "" {
+		auth = &mcpAuth{}
+		if err := json.Unmarshal([]byte(a.Auth), auth); err != nil {
+			return tool.ToolResult{Error: fmt.Sprintf(`auth 格式错误（需要 JSON 对象字符串，如 {"bearer_token":"${TOKEN}"}）: %v`, err)}, nil
+		}
+	}
+
 	cfg, err := readMCPConfig(t.mcpConfigPath)
 	if err != nil {
 		return tool.ToolResult{Error: err.Error()}, nil
@@ -154,13 +200,15 @@ func (t *MCPServerAddTool) Execute(_ context.Context, raw json.RawMessage) (tool
 	}
 
 	entry := mcpServerEntry{
-		Transport: a.Transport,
-		Command:   a.Command,
-		Args:      args,
-		URL:       a.URL,
-		Env:       env,
-		Lifecycle: a.Lifecycle,
-		Meta:      map[string]string{"origin": "agent"},
+		Transport:    a.Transport,
+		Command:      a.Command,
+		Args:         args,
+		URL:          a.URL,
+		Env:          env,
+		Lifecycle:    a.Lifecycle,
+		Dependencies: deps,
+		Auth:         auth,
+		Meta:         map[string]string{"origin": "agent"},
 	}
 	cfg.MCPServers[a.Name] = entry
 
@@ -299,6 +347,7 @@ func (t *MCPServerListTool) Execute(_ context.Context, _ json.RawMessage) (tool.
 		scanRes   string
 		scannedAt string
 		command   string
+		auth      string
 	}
 	rows := make([]row, 0, len(cfg.MCPServers))
 	for name, e := range cfg.MCPServers {
@@ -324,7 +373,7 @@ func (t *MCPServerListTool) Execute(_ context.Context, _ json.RawMessage) (tool.
 			cmd += " " + string(argsBytes)
 		}
 		if e.URL != "" {
-			cmd = e.URL
+			cmd = redactURL(e.URL)
 		}
 		rows = append(rows, row{
 			name:      name,
@@ -334,6 +383,7 @@ func (t *MCPServerListTool) Execute(_ context.Context, _ json.RawMessage) (tool.
 			scanRes:   scanRes,
 			scannedAt: scannedAt,
 			command:   cmd,
+			auth:      describeAuth(e.Auth),
 		})
 	}
 
@@ -349,12 +399,76 @@ func (t *MCPServerListTool) Execute(_ context.Context, _ json.RawMessage) (tool.
 	out := fmt.Sprintf("mcp.json 已注册 %d 个 server（读取时间: %s）:\n\n",
 		len(rows), time.Now().Format("2006-01-02 15:04:05"))
 	for _, r := range rows {
-		out += fmt.Sprintf("▶ %s\n  transport=%s  lifecycle=%s  origin=%s  scan=%s(%s)\n  cmd: %s\n\n",
+		out += fmt.Sprintf("▶ %s\n  transport=%s  lifecycle=%s  origin=%s  scan=%s(%s)\n  cmd: %s\n",
 			r.name, r.transport, r.lifecycle, r.origin, r.scanRes, r.scannedAt, r.command)
+		if r.auth != "" {
+			out += "  auth: " + r.auth + "\n"
+		}
+		out += "\n"
 	}
 
 	return tool.ToolResult{Output: out}, nil
 }
 
+// envRefPattern matches a ${VAR} reference, which names a secret without
+// revealing it.
+var envRefPattern = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
+// secretQueryKeys are URL query parameter names whose values are masked.
+var secretQueryKeys = []string{"key", "token", "secret", "password", "auth", "sig"}
+
+// redactSecret masks a credential value. Values built from ${VAR}
+// references (plus a short literal such as "Bearer ") are shown as-is.
+func redactSecret(v string) string {
+	if envRefPattern.MatchString(v) && len(strings.TrimSpace(envRefPattern.ReplaceAllString(v, ""))) <= 10 {
+		return v
+	}
+	return "***"
+}
+
+// describeAuth renders an auth block for mcp_server_list with secrets masked.
+func describeAuth(a *mcpAuth) string {
+	if a == nil {
+		return ""
+	}
+	var parts []string
+	if a.BearerToken != "" {
+		parts = append(parts, "bearer="+redactSecret(a.BearerToken))
+	}
+	keys := make([]string, 0, len(a.Headers))
+	for k := range a.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, k+"="+redactSecret(a.Headers[k]))
+	}
+	return strings.Join(parts, "  ")
+}
+
+// redactURL masks the password and secret-looking query values of a URL.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	masked := false
+	for k := range q {
+		lower := strings.ToLower(k)
+		for _, s := range secretQueryKeys {
+			if strings.Contains(lower, s) {
+				q.Set(k, "xxxxx") // same mask as url.URL.Redacted
+				masked = true
+				break
+			}
+		}
+	}
+	if masked {
+		u.RawQuery = q.Encode()
+	}
+	return u.Redacted()
+}
+
 func (t *MCPServerListTool) Init(_ context.Context) error { return nil }
 func (t *MCPServerListTool) Close() error                 { return nil }
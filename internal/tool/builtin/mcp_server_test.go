@@ -566,3 +566,42 @@ func TestReadWriteMCPConfig_RoundTrip(t *testing.T) {
 		t.Errorf("args mismatch: %v", entry.Args)
 	}
 }
+
+func TestMCPServerList_RedactsAuth(t *testing.T) {
+	content := `{"mcpServers":{"remote":{"transport":"http","url":"https://user:pw@api.example.com/mcp?api_key=LEAK1&v=2",` +
+		`"auth":{"bearer_token":"LEAK2","headers":{"X-Api-Key":"${SEARCH_KEY}","X-Other":"Token LEAK3"}}}}}`
+	path := writeTempMCPFile(t, content)
+
+	result, err := NewMCPServerListTool(path).Execute(context.Background(), nil)
+	if err != nil || result.Error != "" {
+		t.Fatalf("Execute: %v %s", err, result.Error)
+	}
+	for _, leak := range []string{"LEAK1", "LEAK2", "LEAK3", ":pw@"} {
+		if strings.Contains(result.Output, leak) {
+			t.Errorf("output leaks %q: %s", leak, result.Output)
+		}
+	}
+	for _, want := range []string{"bearer=***", "X-Api-Key=${SEARCH_KEY}", "X-Other=***", "v=2"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q: %s", want, result.Output)
+		}
+	}
+}
+
+func TestMCPServerAdd_AuthRoundTrip(t *testing.T) {
+	path := writeTempMCPFile(t, `{"mcpServers":{"remote":{"transport":"sse","url":"http://x","auth":{"bearer_token":"${T}"}}}}`)
+	add := NewMCPServerAddTool(path)
+	raw, _ := json.Marshal(map[string]string{"name": "gh", "transport": "http", "url": "https://api.example.com/mcp",
+		"auth": `{"headers":{"X-Api-Key":"${GH_KEY}"}}`})
+	result, err := add.Execute(context.Background(), raw)
+	if err != nil || result.Error != "" {
+		t.Fatalf("Execute: %v %s", err, result.Error)
+	}
+	if e := readMCPEntry(t, path, "gh"); e.Auth == nil || e.Auth.Headers["X-Api-Key"] != "${GH_KEY}" {
+		t.Errorf("gh auth = %+v", e.Auth)
+	}
+	// Existing entries keep their auth block when mcp.json is rewritten.
+	if e := readMCPEntry(t, path, "remote"); e.Auth == nil || e.Auth.BearerToken != "${T}" {
+		t.Errorf("remote auth lost: %+v", e.Auth)
+	}
+}
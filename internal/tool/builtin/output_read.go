@@ -0,0 +1,106 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	outputReadDefaultLines = 200
+	outputReadMaxLines     = 1000
+)
+
+// outputRefPattern mirrors agent.OutputRefPattern (builtin must not import agent).
+var outputRefPattern = regexp.MustCompile(`^o[0-9a-f]{12}$`)
+
+// ── output_read ──
+
+// OutputReadTool returns a line range of a tool output that the agent's
+// output post-processor archived and replaced with a summary.
+type OutputReadTool struct {
+	dir string
+}
+
+func NewOutputReadTool(dir string) *OutputReadTool {
+	return &OutputReadTool{dir: dir}
+}
+
+func (t *OutputReadTool) Name() string { return "output_read" }
+func (t *OutputReadTool) Description() string {
+	return "读取被摘要的长工具输出的原文。当工具结果末尾出现 ref=... 时使用，可按行号分段读取。"
+}
+
+func (t *OutputReadTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "ref", Type: "string", Description: "摘要末尾给出的引用 ID", Required: true},
+		tool.SchemaParam{Name: "offset", Type: "integer", Description: "起始行号（从 1 开始，默认 1）"},
+		tool.SchemaParam{Name: "limit", Type: "integer", Description: fmt.Sprintf("读取行数（默认 %d，最大 %d）", outputReadDefaultLines, outputReadMaxLines)},
+	)
+}
+
+func (t *OutputReadTool) Init(_ context.Context) error { return nil }
+func (t *OutputReadTool) Close() error                 { return nil }
+
+func (t *OutputReadTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a struct {
+		Ref    string `json:"ref"`
+		Offset int    `json:"offset"`
+		Limit  int    `json:"limit"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	ref := strings.TrimSpace(a.Ref)
+	if !outputRefPattern.MatchString(ref) {
+		return tool.ToolResult{Error: fmt.Sprintf("无效的引用 ID: %q", a.Ref)}, nil
+	}
+	data, err := os.ReadFile(filepath.Join(t.dir, ref+".txt"))
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("引用 %s 不存在或已被清理 — 请重新调用原工具", ref)}, nil
+	}
+
+	lines := strings.Split(string(data), "\n")
+	start := a.Offset
+	if start < 1 {
+		start = 1
+	}
+	if start > len(lines) {
+		return tool.ToolResult{Error: fmt.Sprintf("offset %d 超出范围（共 %d 行）", start, len(lines))}, nil
+	}
+	limit := a.Limit
+	if limit <= 0 {
+		limit = outputReadDefaultLines
+	}
+	if limit > outputReadMaxLines {
+		limit = outputReadMaxLines
+	}
+	end := start - 1 + limit
+	if end > len(lines) {
+		end = len(lines)
+	}
+
+	body := strings.Join(lines[start-1:end], "\n")
+	if runes := []rune(body); len(runes) > maxOutputChars {
+		// Cut at the last complete line that fits so the next offset is exact.
+		cut := strings.LastIndexByte(string(runes[:maxOutputChars]), '\n')
+		if cut <= 0 {
+			body = string(runes[:maxOutputChars])
+		} else {
+			body = string(runes[:maxOutputChars])[:cut]
+		}
+		end = start - 1 + strings.Count(body, "\n") + 1
+	}
+
+	footer := fmt.Sprintf("\n---\n[%s 第 %d-%d 行，共 %d 行]", ref, start, end, len(lines))
+	if end < len(lines) {
+		footer += fmt.Sprintf(" 后续内容请调用 output_read(ref=%q, offset=%d)", ref, end+1)
+	}
+	return tool.ToolResult{Output: body + footer}, nil
+}
@@ -0,0 +1,70 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeArchive(t *testing.T, dir, ref string, lines int) {
+	t.Helper()
+	var sb strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&sb, "row %d\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ref+".txt"), []byte(strings.TrimSuffix(sb.String(), "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runOutputRead(t *testing.T, tl *OutputReadTool, args map[string]any) (string, string) {
+	t.Helper()
+	raw, _ := json.Marshal(args)
+	res, err := tl.Execute(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.Output, res.Error
+}
+
+func TestOutputRead_Ranges(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "o0123456789ab", 500)
+	tl := NewOutputReadTool(dir)
+
+	out, errMsg := runOutputRead(t, tl, map[string]any{"ref": "o0123456789ab"})
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	}
+	if !strings.HasPrefix(out, "row 1\n") || !strings.Contains(out, "row 200\n") || strings.Contains(out, "row 201") {
+		t.Errorf("default read should return lines 1-200")
+	}
+	if !strings.Contains(out, "offset=201") {
+		t.Errorf("footer should point at the next offset: %q", out[len(out)-120:])
+	}
+
+	out, _ = runOutputRead(t, tl, map[string]any{"ref": "o0123456789ab", "offset": 491, "limit": 50})
+	if !strings.HasPrefix(out, "row 491\n") || !strings.Contains(out, "row 500") || strings.Contains(out, "offset=") {
+		t.Errorf("tail read wrong: %q", out)
+	}
+}
+
+func TestOutputRead_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeArchive(t, dir, "o0123456789ab", 10)
+	tl := NewOutputReadTool(dir)
+
+	for _, args := range []map[string]any{
+		{"ref": "../secret"},
+		{"ref": "offffffffffff"},
+		{"ref": "o0123456789ab", "offset": 99},
+	} {
+		if _, errMsg := runOutputRead(t, tl, args); errMsg == "" {
+			t.Errorf("args %v should fail", args)
+		}
+	}
+}
@@ -0,0 +1,875 @@
+package builtin
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// A minimal PDF text extractor: enough of the object syntax, stream filters,
+// page tree, fonts (ToUnicode CMaps) and text operators to get the text of
+// ordinary generated PDFs. Objects are found by scanning for "N G obj"
+// rather than through the xref table, which also copes with damaged files
+// and incremental updates (later definitions win). Scanned PDFs have no text
+// to extract; doc_read hands them to the OCR command.
+
+const (
+	pdfMaxStreamBytes = 64 << 20 // decoded size limit of one stream
+	pdfMaxDepth       = 64       // nesting of arrays/dicts and of the page tree
+	pdfMaxFormDepth   = 4        // nested form XObjects
+)
+
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfDict    map[string]any
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		raw  []byte
+	}
+)
+
+// ── lexer ──
+
+type pdfLexer struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelim(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		if !isPDFSpace(c) {
+			return
+		}
+		l.pos++
+	}
+}
+
+// token returns the next number (float64), string ([]byte), name or
+// keyword; delimiters of arrays and dicts come back as keywords.
+func (l *pdfLexer) token() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+			l.pos++
+		}
+		return pdfName(decodePDFName(l.data[start:l.pos])), nil
+	case c == '(':
+		return l.literalString(), nil
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<"), nil
+		}
+		return l.hexString(), nil
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), nil
+		}
+		l.pos++
+		return pdfKeyword(">"), nil
+	case c == '[' || c == ']' || c == '{' || c == '}' || c == ')':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	case c >= '0' && c <= '9' || c == '+' || c == '-' || c == '.':
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.data) && (l.data[l.pos] >= '0' && l.data[l.pos] <= '9' || l.data[l.pos] == '.') {
+			l.pos++
+		}
+		f, err := strconv.ParseFloat(string(l.data[start:l.pos]), 64)
+		if err != nil {
+			return float64(0), nil // "-" or "." alone: treat as zero like most readers
+		}
+		return f, nil
+	}
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	return pdfKeyword(l.data[start:l.pos]), nil
+}
+
+func decodePDFName(b []byte) string {
+	if bytes.IndexByte(b, '#') < 0 {
+		return string(b)
+	}
+	var out []byte
+	for i := 0; i < len(b); i++ {
+		if b[i] == '#' && i+2 < len(b) {
+			if v, err := strconv.ParseUint(string(b[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(v))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, b[i])
+	}
+	return string(out)
+}
+
+func (l *pdfLexer) literalString() []byte {
+	l.pos++ // (
+	var out []byte
+	nesting := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			nesting++
+		case ')':
+			if nesting--; nesting == 0 {
+				return out
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e // \( \) \\ and unknown escapes
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (l *pdfLexer) hexString() []byte {
+	l.pos++ // <
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if c := l.data[l.pos]; !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++ // >
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	hex.Decode(out, digits)
+	return out
+}
+
+// object returns the next complete object: arrays and dicts are built and
+// "N G R" becomes a pdfRef. Keywords other than true/false/null are
+// returned as pdfKeyword (content stream operators, "stream", "endobj").
+func (l *pdfLexer) object() (any, error) {
+	tok, err := l.token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case pdfKeyword:
+		switch t {
+		case "true", "false":
+			return t == "true", nil
+		case "null":
+			return nil, nil
+		case "[":
+			if l.depth++; l.depth > pdfMaxDepth {
+				return nil, fmt.Errorf("PDF 结构嵌套过深")
+			}
+			defer func() { l.depth-- }()
+			var arr []any
+			for {
+				v, err := l.object()
+				if err != nil {
+					return arr, err
+				}
+				if k, ok := v.(pdfKeyword); ok && k == "]" {
+					return arr, nil
+				}
+				arr = append(arr, v)
+			}
+		case "<<":
+			if l.depth++; l.depth > pdfMaxDepth {
+				return nil, fmt.Errorf("PDF 结构嵌套过深")
+			}
+			defer func() { l.depth-- }()
+			dict := pdfDict{}
+			for {
+				k, err := l.object()
+				if err != nil {
+					return dict, err
+				}
+				if kw, ok := k.(pdfKeyword); ok && kw == ">>" {
+					return dict, nil
+				}
+				name, ok := k.(pdfName)
+				if !ok {
+					continue // malformed: skip the stray token
+				}
+				v, err := l.object()
+				if err != nil {
+					return dict, err
+				}
+				if kw, ok := v.(pdfKeyword); ok && kw == ">>" {
+					return dict, nil
+				}
+				dict[string(name)] = v
+			}
+		}
+		return t, nil
+	case float64:
+		if t < 0 || t != math.Trunc(t) {
+			return t, nil
+		}
+		save := l.pos
+		gen, err := l.token()
+		if g, ok := gen.(float64); err == nil && ok && g >= 0 && g == math.Trunc(g) {
+			if r, err := l.token(); err == nil && r == pdfKeyword("R") {
+				return pdfRef{int(t), int(g)}, nil
+			}
+		}
+		l.pos = save
+		return t, nil
+	}
+	return tok, nil
+}
+
+// ── document ──
+
+type pdfDoc struct {
+	objs  map[int]any
+	fonts map[pdfRef]*pdfFont
+}
+
+var (
+	pdfObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	pdfTrailer   = regexp.MustCompile(`trailer\s*<<`)
+)
+
+// parsePDF indexes the objects of a PDF file, including those packed in
+// object streams.
+func parsePDF(data []byte) (*pdfDoc, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, fmt.Errorf("不是有效的 PDF 文件")
+	}
+	doc := &pdfDoc{objs: make(map[int]any), fonts: make(map[pdfRef]*pdfFont)}
+	for _, m := range pdfObjHeader.FindAllSubmatchIndex(data, -1) {
+		num, _ := strconv.Atoi(string(data[m[2]:m[3]]))
+		l := &pdfLexer{data: data, pos: m[1]}
+		obj, err := l.object()
+		if err != nil {
+			continue
+		}
+		if dict, ok := obj.(pdfDict); ok {
+			save := l.pos
+			if kw, _ := l.token(); kw == pdfKeyword("stream") {
+				obj = &pdfStream{dict: dict, raw: streamData(data, l.pos, dict)}
+			} else {
+				l.pos = save
+			}
+		}
+		doc.objs[num] = obj
+	}
+	if len(doc.objs) == 0 {
+		return nil, fmt.Errorf("PDF 中没有可解析的对象")
+	}
+	if _, ok := doc.trailerValue("Encrypt", data); ok {
+		return nil, fmt.Errorf("加密的 PDF 暂不支持")
+	}
+
+	// Objects packed in object streams; direct definitions take precedence.
+	direct := make(map[int]bool, len(doc.objs))
+	for num := range doc.objs {
+		direct[num] = true
+	}
+	for _, num := range sortedKeys(doc.objs) {
+		s, ok := doc.objs[num].(*pdfStream)
+		if !ok || s.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		body, err := doc.decodeStream(s)
+		if err != nil {
+			continue
+		}
+		n, _ := doc.resolve(s.dict["N"]).(float64)
+		first, _ := doc.resolve(s.dict["First"]).(float64)
+		hdr := &pdfLexer{data: body}
+		for i := 0; i < int(n); i++ {
+			objNum, err1 := hdr.token()
+			off, err2 := hdr.token()
+			on, ok1 := objNum.(float64)
+			of, ok2 := off.(float64)
+			if err1 != nil || err2 != nil || !ok1 || !ok2 {
+				break
+			}
+			if direct[int(on)] || int(first+of) >= len(body) {
+				continue
+			}
+			l := &pdfLexer{data: body, pos: int(first + of)}
+			if obj, err := l.object(); err == nil {
+				doc.objs[int(on)] = obj
+			}
+		}
+	}
+	return doc, nil
+}
+
+// streamData returns the raw bytes of the stream starting at pos (just
+// after the "stream" keyword): /Length when it is direct and consistent,
+// otherwise everything up to "endstream".
+func streamData(data []byte, pos int, dict pdfDict) []byte {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+	if n, ok := dict["Length"].(float64); ok && n >= 0 && pos+int(n) <= len(data) {
+		rest := bytes.TrimLeft(data[pos+int(n):min(len(data), pos+int(n)+32)], "\r\n\t ")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			return data[pos : pos+int(n)]
+		}
+	}
+	end := bytes.Index(data[pos:], []byte("endstream"))
+	if end < 0 {
+		return data[pos:]
+	}
+	return bytes.TrimRight(data[pos:pos+end], "\r\n")
+}
+
+// trailerValue finds key in the trailer dictionaries or in a cross-reference
+// stream dictionary.
+func (d *pdfDoc) trailerValue(key string, data []byte) (any, bool) {
+	for _, idx := range pdfTrailer.FindAllIndex(data, -1) {
+		l := &pdfLexer{data: data, pos: idx[1] - 2}
+		if dict, ok := mustObject(l).(pdfDict); ok {
+			if v, ok := dict[key]; ok {
+				return v, true
+			}
+		}
+	}
+	for _, obj := range d.objs {
+		if s, ok := obj.(*pdfStream); ok && s.dict["Type"] == pdfName("XRef") {
+			if v, ok := s.dict[key]; ok {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func mustObject(l *pdfLexer) any {
+	obj, _ := l.object()
+	return obj
+}
+
+func sortedKeys(m map[int]any) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// resolve follows references; nil for missing objects.
+func (d *pdfDoc) resolve(v any) any {
+	for i := 0; i < 16; i++ {
+		r, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = d.objs[r.num]
+	}
+	return nil
+}
+
+func (d *pdfDoc) dict(v any) pdfDict {
+	switch t := d.resolve(v).(type) {
+	case pdfDict:
+		return t
+	case *pdfStream:
+		return t.dict
+	}
+	return nil
+}
+
+// decodeStream applies the stream's filters. Image filters (DCT, JBIG2…)
+// are not text and report an error.
+func (d *pdfDoc) decodeStream(s *pdfStream) ([]byte, error) {
+	var filters []any
+	switch f := d.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{f}
+	case []any:
+		filters = f
+	}
+	data := s.raw
+	for _, f := range filters {
+		var r io.Reader
+		switch d.resolve(f) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				r = flate.NewReader(bytes.NewReader(data)) // raw deflate without zlib header
+			} else {
+				r = zr
+			}
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			l := &pdfLexer{data: append(append([]byte("<"), bytes.TrimSuffix(bytes.TrimSpace(data), []byte(">"))...), '>')}
+			data = l.hexString()
+			continue
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			src := bytes.TrimSpace(data)
+			src = bytes.TrimPrefix(src, []byte("<~"))
+			if i := bytes.Index(src, []byte("~>")); i >= 0 {
+				src = src[:i]
+			}
+			r = ascii85.NewDecoder(bytes.NewReader(src))
+		default:
+			return nil, fmt.Errorf("unsupported filter %v", f)
+		}
+		out, err := io.ReadAll(io.LimitReader(r, pdfMaxStreamBytes))
+		if err != nil && len(out) == 0 {
+			return nil, err
+		}
+		data = out // a truncated deflate stream still yields its text so far
+	}
+	return data, nil
+}
+
+// pages returns the page dictionaries in order, each with its (possibly
+// inherited) resources.
+func (d *pdfDoc) pages() []pdfPage {
+	var root any
+	for _, num := range sortedKeys(d.objs) {
+		if dict := d.dict(d.objs[num]); dict != nil && dict["Type"] == pdfName("Catalog") {
+			root = dict["Pages"]
+			break
+		}
+	}
+	var out []pdfPage
+	if root != nil {
+		d.collectPages(root, nil, 0, make(map[pdfRef]bool), &out)
+	}
+	if len(out) == 0 { // no usable page tree: take the page objects in order
+		for _, num := range sortedKeys(d.objs) {
+			if dict := d.dict(d.objs[num]); dict != nil && dict["Type"] == pdfName("Page") {
+				out = append(out, pdfPage{dict: dict, resources: d.dict(dict["Resources"])})
+			}
+		}
+	}
+	return out
+}
+
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// collectPages walks the page tree; seen guards against reference cycles.
+func (d *pdfDoc) collectPages(v any, res pdfDict, depth int, seen map[pdfRef]bool, out *[]pdfPage) {
+	if ref, ok := v.(pdfRef); ok {
+		if seen[ref] {
+			return
+		}
+		seen[ref] = true
+	}
+	node := d.dict(v)
+	if node == nil || depth > pdfMaxDepth {
+		return
+	}
+	if r := d.dict(node["Resources"]); r != nil {
+		res = r
+	}
+	kids, isTree := d.resolve(node["Kids"]).([]any)
+	if !isTree || node["Type"] == pdfName("Page") {
+		*out = append(*out, pdfPage{dict: node, resources: res})
+		return
+	}
+	for _, kid := range kids {
+		d.collectPages(kid, res, depth+1, seen, out)
+	}
+}
+
+// pageText extracts the text of one page.
+func (d *pdfDoc) pageText(p pdfPage) string {
+	var content []byte
+	switch c := d.resolve(p.dict["Contents"]).(type) {
+	case *pdfStream:
+		content, _ = d.decodeStream(c)
+	case []any:
+		for _, part := range c {
+			if s, ok := d.resolve(part).(*pdfStream); ok {
+				b, _ := d.decodeStream(s)
+				content = append(append(content, b...), '\n')
+			}
+		}
+	}
+	w := &pdfTextWriter{}
+	d.runContent(content, p.resources, w, 0)
+	return w.String()
+}
+
+// ── text ──
+
+// pdfTextWriter assembles shown strings into lines: a change of the text
+// line's y position starts a new line, a horizontal move inserts a space.
+type pdfTextWriter struct {
+	sb             strings.Builder
+	y, lastY       float64
+	written        bool
+	space, newline bool
+}
+
+func (w *pdfTextWriter) show(s string) {
+	if s == "" {
+		return
+	}
+	if w.written {
+		switch {
+		case w.newline || math.Abs(w.y-w.lastY) > 0.5:
+			w.sb.WriteByte('\n')
+		case w.space && !strings.HasSuffix(w.sb.String(), " ") && !strings.HasPrefix(s, " "):
+			w.sb.WriteByte(' ')
+		}
+	}
+	w.sb.WriteString(s)
+	w.written, w.space, w.newline = true, false, false
+	w.lastY = w.y
+}
+
+func (w *pdfTextWriter) String() string {
+	lines := strings.Split(w.sb.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(collapseBlankLines(strings.Join(lines, "\n")))
+}
+
+// runContent interprets the text operators of a content stream.
+func (d *pdfDoc) runContent(content []byte, res pdfDict, w *pdfTextWriter, depth int) {
+	fonts := d.dict(res["Font"])
+	var font *pdfFont
+	var operands []any
+	l := &pdfLexer{data: content}
+	num := func(i int) float64 {
+		if i < len(operands) {
+			f, _ := operands[i].(float64)
+			return f
+		}
+		return 0
+	}
+	for {
+		obj, err := l.object()
+		if err != nil {
+			return
+		}
+		op, isOp := obj.(pdfKeyword)
+		if !isOp {
+			operands = append(operands, obj)
+			continue
+		}
+		switch op {
+		case "BT":
+			w.y = 0
+		case "Tf":
+			if len(operands) > 0 {
+				if name, ok := operands[0].(pdfName); ok {
+					font = d.font(fonts[string(name)])
+				}
+			}
+		case "Td", "TD":
+			if len(operands) >= 2 {
+				w.y += num(1)
+				if num(0) != 0 {
+					w.space = true
+				}
+			}
+		case "Tm":
+			if len(operands) >= 6 {
+				w.y = num(5)
+				w.space = true
+			}
+		case "T*":
+			w.newline = true
+		case "Tj", "'", "\"":
+			if op != "Tj" {
+				w.newline = true
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].([]byte); ok {
+					w.show(font.decode(s))
+				}
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				arr, _ := operands[0].([]any)
+				for _, e := range arr {
+					switch v := e.(type) {
+					case []byte:
+						w.show(font.decode(v))
+					case float64:
+						if v < -180 { // a gap wider than a typical space
+							w.space = true
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) > 0 && depth < pdfMaxFormDepth {
+				name, _ := operands[0].(pdfName)
+				xobj, ok := d.resolve(d.dict(res["XObject"])[string(name)]).(*pdfStream)
+				if ok && xobj.dict["Subtype"] == pdfName("Form") {
+					formRes := d.dict(xobj.dict["Resources"])
+					if formRes == nil {
+						formRes = res
+					}
+					if body, err := d.decodeStream(xobj); err == nil {
+						d.runContent(body, formRes, w, depth+1)
+					}
+				}
+			}
+		case "ID": // inline image data: skip to EI
+			end := bytes.Index(content[l.pos:], []byte("EI"))
+			for end >= 0 {
+				at := l.pos + end
+				if isPDFSpace(content[at-1]) && (at+2 == len(content) || isPDFSpace(content[at+2])) {
+					break
+				}
+				next := bytes.Index(content[at+2:], []byte("EI"))
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += 2 + next
+			}
+			if end < 0 {
+				return
+			}
+			l.pos += end + 2
+		}
+		operands = operands[:0]
+	}
+}
+
+// pdfFont decodes the strings shown with a font to Unicode.
+type pdfFont struct {
+	toUnicode map[uint32]string
+	codeLen   int  // bytes per character code
+	composite bool // Type0 font: 2-byte codes, undecodable without a CMap
+	ucs2      bool // Type0 font with a UCS-2 encoding such as UniGB-UCS2-H
+}
+
+func (d *pdfDoc) font(v any) *pdfFont {
+	ref, isRef := v.(pdfRef)
+	if f, ok := d.fonts[ref]; isRef && ok {
+		return f
+	}
+	dict := d.dict(v)
+	f := &pdfFont{codeLen: 1}
+	if dict != nil {
+		if dict["Subtype"] == pdfName("Type0") {
+			f.composite, f.codeLen = true, 2
+			if enc, ok := d.resolve(dict["Encoding"]).(pdfName); ok && (strings.Contains(string(enc), "UCS2") || strings.Contains(string(enc), "UTF16")) {
+				f.ucs2 = true
+			}
+		}
+		if s, ok := d.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+			if body, err := d.decodeStream(s); err == nil {
+				f.toUnicode, f.codeLen = parseToUnicode(body, f.codeLen)
+			}
+		}
+	}
+	if isRef {
+		d.fonts[ref] = f
+	}
+	return f
+}
+
+// decode maps a shown string to text. Without a font or CMap, simple fonts
+// are read as WinAnsi.
+func (f *pdfFont) decode(s []byte) string {
+	if f == nil {
+		return winAnsi(s)
+	}
+	if f.toUnicode == nil {
+		switch {
+		case f.ucs2:
+			return utf16BE(s)
+		case f.composite:
+			return ""
+		}
+		return winAnsi(s)
+	}
+	var sb strings.Builder
+	for i := 0; i+f.codeLen <= len(s); i += f.codeLen {
+		var code uint32
+		for _, b := range s[i : i+f.codeLen] {
+			code = code<<8 | uint32(b)
+		}
+		if u, ok := f.toUnicode[code]; ok {
+			sb.WriteString(u)
+		} else if f.codeLen == 1 {
+			sb.WriteString(winAnsi(s[i : i+1]))
+		}
+	}
+	return sb.String()
+}
+
+// parseToUnicode reads the bfchar and bfrange mappings of a ToUnicode CMap
+// and the code length of its codespace range.
+func parseToUnicode(body []byte, codeLen int) (map[uint32]string, int) {
+	m := make(map[uint32]string)
+	l := &pdfLexer{data: body}
+	var operands []any
+	code := func(b []byte) uint32 {
+		var c uint32
+		for _, x := range b {
+			c = c<<8 | uint32(x)
+		}
+		return c
+	}
+	for {
+		obj, err := l.object()
+		if err != nil {
+			return m, codeLen
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if b, ok := operands[0].([]byte); ok && len(b) > 0 {
+					codeLen = len(b)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].([]byte)
+				dst, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 {
+					m[code(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].([]byte)
+				hi, ok2 := operands[i+1].([]byte)
+				if !ok1 || !ok2 || code(hi) < code(lo) || code(hi)-code(lo) > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case []byte:
+					base := []rune(utf16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for c := code(lo); c <= code(hi); c++ {
+						r := append([]rune(nil), base...)
+						r[len(r)-1] += rune(c - code(lo))
+						m[c] = string(r)
+					}
+				case []any:
+					for j, e := range dst {
+						if b, ok := e.([]byte); ok {
+							m[code(lo)+uint32(j)] = utf16BE(b)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func utf16BE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
+
+// winAnsiHigh maps the 0x80–0x9F codes of WinAnsiEncoding; other bytes are
+// Latin-1.
+var winAnsiHigh = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ', 0x89: '‰',
+	0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•',
+	0x96: '–', 0x97: '—', 0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+func winAnsi(b []byte) string {
+	r := make([]rune, 0, len(b))
+	for _, c := range b {
+		if w, ok := winAnsiHigh[c]; ok {
+			r = append(r, w)
+		} else {
+			r = append(r, rune(c))
+		}
+	}
+	return string(r)
+}
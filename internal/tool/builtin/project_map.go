@@ -0,0 +1,304 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	projectMapTimeout      = 20 * time.Second
+	projectMapDefaultDepth = 3
+	projectMapMaxDepth     = 6
+	projectMapMaxFiles     = 20000   // walk stops after this many files
+	projectMapMaxLOCFile   = 2 << 20 // larger files count as files but not lines
+	projectMapFilesPerDir  = 8       // directories with more files show a count instead
+	projectMapMaxLines     = 400     // cap on tree lines
+)
+
+// projectLanguages maps file extensions to the language they are counted as.
+// Files with other extensions count towards file totals only.
+var projectLanguages = map[string]string{
+	".go": "Go", ".py": "Python", ".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript", ".java": "Java", ".kt": "Kotlin", ".cs": "C#", ".rs": "Rust",
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".cxx": "C++", ".hpp": "C++", ".rb": "Ruby", ".php": "PHP",
+	".swift": "Swift", ".sh": "Shell", ".bash": "Shell", ".ps1": "PowerShell", ".sql": "SQL", ".proto": "Protobuf",
+	".html": "HTML", ".css": "CSS", ".scss": "CSS", ".vue": "Vue", ".svelte": "Svelte",
+	".md": "Markdown", ".yaml": "YAML", ".yml": "YAML", ".json": "JSON", ".toml": "TOML",
+}
+
+// ── project_map ──
+
+// ProjectMapTool summarizes the workspace layout in one call: a directory
+// tree down to a depth limit with per-directory file and line counts and
+// dominant languages, plus language totals. It replaces the series of
+// file_list calls an agent otherwise makes at the start of a coding task.
+type ProjectMapTool struct {
+	workspaceDir string
+}
+
+func NewProjectMapTool(workspaceDir string) *ProjectMapTool {
+	return &ProjectMapTool{workspaceDir: workspaceDir}
+}
+
+func (t *ProjectMapTool) Name() string { return "project_map" }
+func (t *ProjectMapTool) Description() string {
+	return "一次生成项目结构概览：目录树（按深度截断，遵守 .omegaignore）、每个目录的文件数、代码行数和主要语言，以及全项目语言统计。开始熟悉一个代码库时优先使用，代替多次 file_list。"
+}
+
+func (t *ProjectMapTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "path", Type: "string", Description: "起始目录，默认工作区根目录", Required: false},
+		tool.SchemaParam{Name: "depth", Type: "integer", Description: fmt.Sprintf("目录树深度（默认 %d，上限 %d）", projectMapDefaultDepth, projectMapMaxDepth), Required: false},
+	)
+}
+
+func (t *ProjectMapTool) Init(_ context.Context) error { return nil }
+func (t *ProjectMapTool) Close() error                 { return nil }
+
+type projectMapArgs struct {
+	Path  string `json:"path"`
+	Depth int    `json:"depth"`
+}
+
+// mapDir aggregates one directory: its direct files and, recursively, the
+// file, line and per-language line counts of everything below it.
+type mapDir struct {
+	files    []string
+	children map[string]*mapDir
+	fileN    int
+	loc      int
+	langLOC  map[string]int
+	langN    map[string]int
+}
+
+func newMapDir() *mapDir {
+	return &mapDir{children: make(map[string]*mapDir), langLOC: make(map[string]int), langN: make(map[string]int)}
+}
+
+func (t *ProjectMapTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a projectMapArgs
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+		}
+	}
+	depth := a.Depth
+	if depth <= 0 {
+		depth = projectMapDefaultDepth
+	}
+	depth = min(depth, projectMapMaxDepth)
+
+	root := t.workspaceDir
+	if a.Path != "" {
+		resolved, err := safeResolvePath(a.Path, t.workspaceDir)
+		if err != nil {
+			return tool.ToolResult{Error: err.Error()}, nil
+		}
+		root = resolved
+	}
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return tool.ToolResult{Error: fmt.Sprintf("目录不存在: %s。请确认路径是否正确，用 \".\" 表示工作目录，或提供完整的绝对路径。", root)}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, projectMapTimeout)
+	defer cancel()
+	top, hidden, truncated := t.walk(ctx, root)
+	if top.fileN == 0 && len(top.children) == 0 {
+		return tool.ToolResult{Output: "（空目录）"}, nil
+	}
+
+	name := "."
+	if a.Path != "" {
+		name = filepath.ToSlash(a.Path)
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "项目结构 %s（%d 个文件，%d 行代码）\n", name, top.fileN, top.loc)
+	if langs := formatLanguages(top, 0); langs != "" {
+		fmt.Fprintf(&sb, "语言: %s\n", langs)
+	}
+	fmt.Fprintf(&sb, "\n%s/\n", strings.TrimSuffix(name, "/"))
+	lines := 0
+	renderMapDir(&sb, top, "", depth, &lines)
+	if lines >= projectMapMaxLines {
+		fmt.Fprintf(&sb, "... (目录树已达上限 %d 行，请用 path 或更小的 depth 缩小范围)\n", projectMapMaxLines)
+	}
+	if ctx.Err() != nil || truncated {
+		sb.WriteString("（扫描未完成：文件过多或超时，统计不完整）\n")
+	}
+	writeHiddenNote(&sb, hidden)
+	return tool.ToolResult{Output: sb.String()}, nil
+}
+
+// walk builds the directory aggregate for root, honoring skipDirs and the
+// workspace ignore file.
+func (t *ProjectMapTool) walk(ctx context.Context, root string) (top *mapDir, hidden int, truncated bool) {
+	top = newMapDir()
+	skip := omegaIgnore(t.workspaceDir, root)
+	_ = filepath.WalkDir(root, func(p string, d os.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || p == root {
+			return nil
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] || d.Name() == ".omega" {
+				return filepath.SkipDir
+			}
+			if skip(p, true) {
+				hidden++
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skip(p, false) {
+			hidden++
+			return nil
+		}
+		if top.fileN >= projectMapMaxFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+
+		rel, _ := filepath.Rel(root, p)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		lang := projectLanguages[strings.ToLower(filepath.Ext(p))]
+		loc := 0
+		if lang != "" {
+			loc = countFileLines(p)
+		}
+		dir := top
+		for i, part := range parts {
+			dir.fileN++
+			dir.loc += loc
+			if lang != "" {
+				dir.langLOC[lang] += loc
+				dir.langN[lang]++
+			}
+			if i == len(parts)-1 {
+				dir.files = append(dir.files, part)
+				break
+			}
+			child, ok := dir.children[part]
+			if !ok {
+				child = newMapDir()
+				dir.children[part] = child
+			}
+			dir = child
+		}
+		return nil
+	})
+	return top, hidden, truncated
+}
+
+// countFileLines returns the number of lines of a text file; binary and
+// oversized files count as zero.
+func countFileLines(path string) int {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err != nil || info.Size() > projectMapMaxLOCFile {
+		return 0
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || len(data) == 0 || isGrepBinary(data[:min(len(data), 512)]) {
+		return 0
+	}
+	n := bytes.Count(data, []byte{'\n'})
+	if data[len(data)-1] != '\n' {
+		n++
+	}
+	return n
+}
+
+// renderMapDir writes the children of dir as a tree, directories first, down
+// to depth levels; files are listed only for directories with few of them.
+func renderMapDir(sb *strings.Builder, dir *mapDir, indent string, depth int, lines *int) {
+	names := make([]string, 0, len(dir.children))
+	for name := range dir.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sort.Strings(dir.files)
+
+	entries := append([]string{}, names...)
+	showFiles := len(dir.files) <= projectMapFilesPerDir
+	if showFiles {
+		entries = append(entries, dir.files...)
+	}
+	for i, name := range entries {
+		if *lines >= projectMapMaxLines {
+			return
+		}
+		branch, next := "├── ", "│   "
+		if i == len(entries)-1 && showFiles {
+			branch, next = "└── ", "    "
+		}
+		*lines++
+		if i >= len(names) {
+			fmt.Fprintf(sb, "%s%s%s\n", indent, branch, name)
+			continue
+		}
+		child := dir.children[name]
+		fmt.Fprintf(sb, "%s%s%s/ (%d 个文件", indent, branch, name, child.fileN)
+		if child.loc > 0 {
+			fmt.Fprintf(sb, ", %d 行", child.loc)
+		}
+		if langs := formatLanguages(child, 2); langs != "" {
+			fmt.Fprintf(sb, ", %s", langs)
+		}
+		sb.WriteString(")\n")
+		if depth > 1 {
+			renderMapDir(sb, child, indent+next, depth-1, lines)
+		}
+	}
+	if !showFiles && *lines < projectMapMaxLines {
+		*lines++
+		fmt.Fprintf(sb, "%s└── … %d 个文件\n", indent, len(dir.files))
+	}
+}
+
+// formatLanguages lists the languages of dir by line count with their share,
+// at most limit of them (0 = all).
+func formatLanguages(dir *mapDir, limit int) string {
+	if dir.loc == 0 {
+		return ""
+	}
+	langs := make([]string, 0, len(dir.langLOC))
+	for lang, loc := range dir.langLOC {
+		if loc > 0 {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if dir.langLOC[langs[i]] != dir.langLOC[langs[j]] {
+			return dir.langLOC[langs[i]] > dir.langLOC[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	if limit > 0 && len(langs) > limit {
+		langs = langs[:limit]
+	}
+	parts := make([]string, len(langs))
+	for i, lang := range langs {
+		pct := dir.langLOC[lang] * 100 / dir.loc
+		if limit > 0 {
+			parts[i] = fmt.Sprintf("%s %d%%", lang, pct)
+		} else {
+			parts[i] = fmt.Sprintf("%s %d%%（%d 个文件，%d 行）", lang, pct, dir.langN[lang], dir.langLOC[lang])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,72 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectMapTool(t *testing.T) {
+	workspace := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(workspace, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	write("go.mod", "module x\n")
+	write("README.md", "# x\n\nabout\n")
+	write("cmd/app/main.go", "package main\n\nfunc main() {}\n")
+	write("internal/store/store.go", "package store\n\ntype Store struct{}\n\nfunc New() *Store {\n\treturn nil\n}\n")
+	write("internal/store/store_test.go", "package store\n")
+	write("web/app.ts", "export const x = 1;\nexport const y = 2;")
+	write("node_modules/lib/index.js", "module.exports = {};\n")
+	write("dist/bundle.js", "var a;\n")
+	write(".omegaignore", "dist/\n")
+	for i := 0; i < projectMapFilesPerDir+2; i++ {
+		write(fmt.Sprintf("assets/img%02d.png", i), "\x89PNG\x00\x00")
+	}
+
+	tool := NewProjectMapTool(workspace)
+	result, err := tool.Execute(context.Background(), json.RawMessage(`{}`))
+	if err != nil || result.Error != "" {
+		t.Fatalf("Execute: %v %s", err, result.Error)
+	}
+	out := result.Output
+	for _, want := range []string{
+		"项目结构 .（17 个文件，16 行代码）",
+		"语言: Go 68%（3 个文件，11 行）, Markdown 18%（1 个文件，3 行）",
+		"├── internal/ (2 个文件, 8 行, Go 100%)",
+		"│   └── store/ (2 个文件, 8 行, Go 100%)",
+		"│       ├── store.go",
+		"├── web/ (1 个文件, 2 行, TypeScript 100%)",
+		"├── assets/ (10 个文件)",
+		"│   └── … 10 个文件",
+		"└── go.mod",
+		"1 项已按 .omegaignore 隐藏",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "node_modules") || strings.Contains(out, "bundle.js") {
+		t.Errorf("skipped directories listed:\n%s", out)
+	}
+
+	// depth=1 shows top-level directories only
+	result, _ = tool.Execute(context.Background(), json.RawMessage(`{"depth":1}`))
+	if !strings.Contains(result.Output, "├── internal/") || strings.Contains(result.Output, "store/") {
+		t.Errorf("depth=1 output:\n%s", result.Output)
+	}
+
+	result, _ = tool.Execute(context.Background(), json.RawMessage(`{"path":"internal"}`))
+	if !strings.HasPrefix(result.Output, "项目结构 internal（2 个文件，8 行代码）") {
+		t.Errorf("subdirectory output:\n%s", result.Output)
+	}
+	if result, _ := tool.Execute(context.Background(), json.RawMessage(`{"path":"../.."}`)); result.Error == "" {
+		t.Error("expected error for path outside the workspace")
+	}
+}
@@ -0,0 +1,317 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	pythonDefaultTimeout = 30 * time.Second
+	pythonMaxTimeout     = 120 * time.Second
+	pythonDefaultMemMB   = 512
+	pythonMaxScript      = 64 << 10 // script size limit (bytes)
+	pythonMaxScanEntries = 20000    // generated-file scan budget
+	pythonMaxListedFiles = 20
+)
+
+// pythonPrelude runs before the user script (passed via stdin). It applies
+// rlimits (Unix), then installs an audit hook (Python 3.8+) that:
+//   - rejects writes outside the workspace and the temp dir, including
+//     os/shutil file operations (remove, rename, mkdir, chmod, link, ...),
+//   - rejects reads and chdir outside the workspace, temp dir and
+//     interpreter paths,
+//   - blocks subprocess/exec/fork (no shell escape),
+//   - blocks ctypes, which could call libc directly,
+//   - blocks sockets unless network is allowed.
+//
+// Audit hooks cannot be removed from Python code, but they are not a
+// sandbox: native extension modules written to the workspace run unchecked.
+// This is a best-effort restriction like the shell blocklist — use the
+// container backend for isolation.
+const pythonPrelude = `import sys, os, tempfile
+if not hasattr(sys, "addaudithook"):
+    sys.exit("python_exec requires Python 3.8+")
+_ws = os.path.realpath(sys.argv[1])
+_net = sys.argv[2] == "1"
+_mem, _cpu = int(sys.argv[3]), int(sys.argv[4])
+try:
+    import resource
+    if _mem > 0:
+        resource.setrlimit(resource.RLIMIT_AS, (_mem << 20, _mem << 20))
+    if _cpu > 0:
+        resource.setrlimit(resource.RLIMIT_CPU, (_cpu, _cpu + 1))
+except (ImportError, ValueError, OSError):
+    pass
+_src = sys.stdin.read()
+sys.stdin = open(os.devnull)
+# The hook and its tables live in _jail's scope: the script can reach the
+# prelude's globals through __main__, but not these.
+def _jail(_ws, _net):
+    # Bound now so that patching os.path from the script has no effect
+    _real, _fsd, _isabs, _join, _isdir, _sep = os.path.realpath, os.fsdecode, os.path.isabs, os.path.join, os.path.isdir, os.sep
+    _tmp = os.path.realpath(tempfile.gettempdir())
+    _wr = (_ws, _tmp)
+    _rd = _wr + tuple(os.path.realpath(p) for p in sys.path + [sys.prefix, sys.base_prefix, sys.exec_prefix] if p)
+    _WFLAGS = os.O_WRONLY | os.O_RDWR | os.O_CREAT | os.O_APPEND | os.O_TRUNC
+    _BLOCK = ("subprocess.Popen", "os.system", "os.exec", "os.spawn", "os.posix_spawn", "os.fork", "os.forkpty", "pty.spawn", "os.startfile")
+    _NET = ("socket.connect", "socket.bind", "socket.getaddrinfo", "socket.sendto")
+    _CTYPES = ("ctypes.dlsym", "ctypes.dlsym/handle", "ctypes.cdata", "ctypes.call_function")
+    # File operation events: (path arg, dir_fd arg or None, write access)
+    _FS = {
+        "os.remove": ((0, 1, True),), "os.rmdir": ((0, 1, True),), "os.mkdir": ((0, 2, True),),
+        "os.rename": ((0, 2, True), (1, 3, True)), "os.link": ((0, 2, True), (1, 3, True)),
+        "os.symlink": ((1, 2, True),), "os.chmod": ((0, 2, True),), "os.chown": ((0, 3, True),),
+        "os.truncate": ((0, None, True),), "os.utime": ((0, 3, True),), "os.chflags": ((0, None, True),),
+        "os.lchflags": ((0, None, True),), "os.chdir": ((0, None, False),),
+        "shutil.copyfile": ((0, None, False), (1, None, True)), "shutil.copymode": ((0, None, False), (1, None, True)),
+        "shutil.copystat": ((0, None, False), (1, None, True)), "shutil.copytree": ((0, None, False), (1, None, True)),
+        "shutil.move": ((0, None, True), (1, None, True)), "shutil.rmtree": ((0, 1, True),),
+        "shutil.chown": ((0, None, True),), "shutil.make_archive": ((0, None, True), (2, None, False)),
+        "shutil.unpack_archive": ((0, None, False), (1, None, True)),
+    }
+    def _under(p, roots):
+        return any(p == r or p.startswith(r.rstrip(_sep) + _sep) for r in roots)
+    def _check(path, w, dir_fd=None):
+        if path is None or isinstance(path, int):
+            return
+        path = _fsd(path)
+        if isinstance(dir_fd, int) and dir_fd >= 0 and not _isabs(path):
+            if not _isdir("/proc/self/fd"):
+                raise PermissionError("python_exec: dir_fd paths are not supported: " + path)
+            path = _join("/proc/self/fd/%d" % dir_fd, path)
+        p = _real(path)
+        if not _under(p, _wr if w else _rd):
+            raise PermissionError("python_exec: access outside workspace denied: " + p)
+    def _hook(ev, args):
+        if ev == "open":
+            path, mode, flags = args
+            _check(path, any(c in mode for c in "wax+") if isinstance(mode, str) else bool(flags & _WFLAGS))
+        elif ev in _FS:
+            for i, fd, w in _FS[ev]:
+                if i < len(args):
+                    _check(args[i], w, args[fd] if fd is not None and fd < len(args) else None)
+        elif ev in _BLOCK:
+            raise PermissionError("python_exec: process creation is disabled (" + ev + ")")
+        elif ev == "ctypes.dlopen":
+            raise ImportError("python_exec: ctypes is disabled")
+        elif ev in _CTYPES:
+            raise PermissionError("python_exec: ctypes is disabled (" + ev + ")")
+        elif not _net and ev in _NET:
+            raise PermissionError("python_exec: network access is disabled")
+    sys.addaudithook(_hook)
+_jail(_ws, _net)
+sys.argv = ["<python_exec>"]
+del _jail, _ws, _net, _mem, _cpu
+exec(compile(_src, "<python_exec>", "exec"), {"__name__": "__main__"})
+`
+
+// ── python_exec ──
+
+// PythonExecTool runs short Python scripts in a restricted subprocess:
+// file access limited to the workspace, no network or child processes by
+// default, CPU/memory/time limits (best-effort on the host, see
+// pythonPrelude). Returns stdout/stderr plus generated file paths.
+type PythonExecTool struct {
+	workspaceDir string
+	interpreter  string // python executable; empty = tool disabled
+	allowNetwork bool
+	memMB        int
+	sandbox      *sandbox.Container // nil = run on host
+}
+
+// NewPythonExecTool creates a python_exec tool using the given interpreter
+// (see DetectPython). Network is disabled and memory capped at 512MB.
+func NewPythonExecTool(workspaceDir, interpreter string) *PythonExecTool {
+	return &PythonExecTool{
+		workspaceDir: workspaceDir,
+		interpreter:  interpreter,
+		memMB:        pythonDefaultMemMB,
+	}
+}
+
+// WithNetwork allows socket access from scripts (TOOL_PYTHON_ALLOW_NETWORK=true).
+func (t *PythonExecTool) WithNetwork(allow bool) *PythonExecTool {
+	t.allowNetwork = allow
+	return t
+}
+
+// WithMemoryLimit sets the address-space limit in MB (0 = unlimited).
+func (t *PythonExecTool) WithMemoryLimit(mb int) *PythonExecTool {
+	t.memMB = mb
+	return t
+}
+
+// WithSandbox runs scripts inside the given container. The container image
+// must provide a python3 interpreter (see TOOL_PYTHON_IMAGE).
+func (t *PythonExecTool) WithSandbox(c *sandbox.Container) *PythonExecTool {
+	t.sandbox = c
+	return t
+}
+
+// DetectPython returns the Python interpreter to use: TOOL_PYTHON_PATH if set,
+// otherwise the first of python3/python found in PATH ("" if none).
+func DetectPython() string {
+	if p := strings.TrimSpace(os.Getenv("TOOL_PYTHON_PATH")); p != "" {
+		return p
+	}
+	for _, name := range []string{"python3", "python"} {
+		if p, err := exec.LookPath(name); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+func (t *PythonExecTool) Name() string { return "python_exec" }
+func (t *PythonExecTool) Description() string {
+	net := "无网络"
+	if t.allowNetwork {
+		net = "允许网络"
+	}
+	return fmt.Sprintf("运行一段 Python 脚本并返回 stdout/stderr 及新生成的文件路径。适合数据处理、计算、格式转换。"+
+		"受限环境：工作目录为工作区，只能读写工作区内文件，%s，不能启动子进程或使用 ctypes，限时 %v。", net, pythonDefaultTimeout)
+}
+
+func (t *PythonExecTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "code", Type: "string", Description: "要执行的 Python 代码（用 print 输出结果）", Required: true},
+		tool.SchemaParam{Name: "timeout", Type: "integer", Description: "超时秒数（默认 30，上限 120）", Required: false},
+	)
+}
+
+func (t *PythonExecTool) Init(_ context.Context) error { return nil }
+func (t *PythonExecTool) Close() error                 { return nil }
+
+type pythonExecArgs struct {
+	Code    string `json:"code"`
+	Timeout int    `json:"timeout"`
+}
+
+func (t *PythonExecTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	if t.interpreter == "" && t.sandbox == nil {
+		return tool.ToolResult{Error: "python_exec 不可用：未找到 Python 解释器（可设置 TOOL_PYTHON_PATH）"}, nil
+	}
+
+	var a pythonExecArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	if strings.TrimSpace(a.Code) == "" {
+		return tool.ToolResult{Error: "code 参数不能为空"}, nil
+	}
+	if len(a.Code) > pythonMaxScript {
+		return tool.ToolResult{Error: fmt.Sprintf("脚本过长（%d 字节，上限 %d），请拆分或写入文件后分步处理", len(a.Code), pythonMaxScript)}, nil
+	}
+
+	timeout := pythonDefaultTimeout
+	if a.Timeout > 0 {
+		timeout = time.Duration(a.Timeout) * time.Second
+		if timeout > pythonMaxTimeout {
+			timeout = pythonMaxTimeout
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	net := "0"
+	if t.allowNetwork {
+		net = "1"
+	}
+	pyArgs := []string{"-E", "-c", pythonPrelude, t.workspaceDir, net,
+		strconv.Itoa(t.memMB), strconv.Itoa(int(timeout.Seconds()))}
+
+	pyEnv := []string{"PYTHONIOENCODING=utf-8", "PYTHONDONTWRITEBYTECODE=1"}
+	var cmd *exec.Cmd
+	if t.sandbox != nil {
+		name, argv := t.sandbox.Wrap("python3", pyArgs, pyEnv, true)
+		cmd = exec.CommandContext(ctx, name, argv...)
+		cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+		cmd.WaitDelay = sandboxStopGrace
+		cmd.Env = filterEnv(os.Environ())
+	} else {
+		cmd = exec.CommandContext(ctx, t.interpreter, pyArgs...)
+		cmd.Env = append(filterEnv(os.Environ()), pyEnv...)
+	}
+	cmd.Dir = t.workspaceDir
+	cmd.Stdin = strings.NewReader(a.Code)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	start := time.Now()
+	err := cmd.Run()
+	outStr := strings.TrimSpace(safeRuneTruncate(out.String(), maxOutputChars))
+
+	if files := t.generatedFiles(start); len(files) > 0 {
+		outStr += "\n---\n生成的文件:\n" + strings.Join(files, "\n")
+	}
+
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return tool.ToolResult{Output: outStr, Error: fmt.Sprintf("脚本超时 (%v)", timeout)}, nil
+		}
+		if ctx.Err() == context.Canceled {
+			return tool.ToolResult{Output: outStr, Error: "脚本被取消"}, nil
+		}
+		return tool.ToolResult{Output: outStr, Error: fmt.Sprintf("脚本退出错误: %v", err)}, nil
+	}
+	if outStr == "" {
+		outStr = "（脚本执行成功，无输出）"
+	}
+	return tool.ToolResult{Output: outStr}, nil
+}
+
+// generatedFiles lists workspace files modified since start (relative paths,
+// sorted, capped at pythonMaxListedFiles). The walk is bounded so huge
+// workspaces do not stall the tool.
+func (t *PythonExecTool) generatedFiles(start time.Time) []string {
+	// Filesystem mtime granularity can be as coarse as one second.
+	since := start.Truncate(time.Second)
+	var files []string
+	scanned := 0
+	skip := omegaIgnore(t.workspaceDir, t.workspaceDir)
+	_ = filepath.WalkDir(t.workspaceDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		scanned++
+		if scanned > pythonMaxScanEntries {
+			return filepath.SkipAll
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] || skip(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skip(path, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || info.ModTime().Before(since) {
+			return nil
+		}
+		if rel, err := filepath.Rel(t.workspaceDir, path); err == nil {
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	if len(files) > pythonMaxListedFiles {
+		more := len(files) - pythonMaxListedFiles
+		files = append(files[:pythonMaxListedFiles], fmt.Sprintf("... 另有 %d 个文件", more))
+	}
+	return files
+}
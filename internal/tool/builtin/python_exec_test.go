@@ -0,0 +1,151 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestPythonTool(t *testing.T) (*PythonExecTool, string) {
+	t.Helper()
+	py := DetectPython()
+	if py == "" {
+		t.Skip("python not available")
+	}
+	dir := t.TempDir()
+	return NewPythonExecTool(dir, py), dir
+}
+
+func runPython(t *testing.T, tl *PythonExecTool, code string) (string, string) {
+	t.Helper()
+	args, _ := json.Marshal(pythonExecArgs{Code: code, Timeout: 10})
+	res, err := tl.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("Execute() error: %v", err)
+	}
+	return res.Output, res.Error
+}
+
+func TestPythonExec_Stdout(t *testing.T) {
+	tl, _ := newTestPythonTool(t)
+	out, errMsg := runPython(t, tl, "import json\nprint(json.dumps({'sum': sum(range(10))}))")
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s (%s)", errMsg, out)
+	}
+	if out != `{"sum": 45}` {
+		t.Errorf("output = %q", out)
+	}
+}
+
+func TestPythonExec_GeneratedFiles(t *testing.T) {
+	tl, dir := newTestPythonTool(t)
+	out, errMsg := runPython(t, tl, "import os\nos.makedirs('out', exist_ok=True)\nopen('out/result.csv', 'w').write('a,b\\n1,2\\n')")
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s (%s)", errMsg, out)
+	}
+	if !strings.Contains(out, "生成的文件:\nout/result.csv") {
+		t.Errorf("generated file not reported: %q", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "result.csv")); err != nil {
+		t.Errorf("file not written to workspace: %v", err)
+	}
+}
+
+func TestPythonExec_WorkspaceFileOps(t *testing.T) {
+	tl, dir := newTestPythonTool(t)
+	code := "import os, shutil, tempfile\nos.makedirs('a/b')\nopen('a/b/x.txt', 'w').write('x')\n" +
+		"shutil.copytree('a', 'c')\nos.rename('c/b/x.txt', 'c/y.txt')\nos.chmod('c/y.txt', 0o600)\n" +
+		"shutil.rmtree('a')\nwith tempfile.TemporaryDirectory() as d: open(os.path.join(d, 't'), 'w').close()\nprint(sorted(os.listdir('c')))"
+	out, errMsg := runPython(t, tl, code)
+	if errMsg != "" || !strings.HasPrefix(out, "['b', 'y.txt']") {
+		t.Fatalf("out = %q, err = %q", out, errMsg)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Errorf("rmtree inside the workspace failed: %v", err)
+	}
+}
+
+func TestPythonExec_Restrictions(t *testing.T) {
+	tl, _ := newTestPythonTool(t)
+	// The package directory lies outside both the workspace and the temp dir.
+	pkgDir, _ := os.Getwd()
+	outside := filepath.Join(pkgDir, "python_exec_outside.txt")
+	victimDir := t.TempDir() // outside the workspace, but under the temp dir
+	t.Setenv("TMPDIR", t.TempDir())
+	victim := filepath.Join(victimDir, "keep.txt")
+	if err := os.WriteFile(victim, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"write outside workspace", "open(" + pyQuote(outside) + ", 'w').write('x')", "outside workspace"},
+		{"subprocess", "import subprocess\nsubprocess.run(['echo', 'hi'])", "process creation is disabled"},
+		{"os.system", "import os\nos.system('echo hi')", "process creation is disabled"},
+		{"network", "import socket\nsocket.create_connection(('127.0.0.1', 9))", "network access is disabled"},
+		{"mkdir outside", "import os\nos.mkdir(" + pyQuote(outside) + ")", "outside workspace"},
+		{"rename out", "import os\nopen('a.txt', 'w').close()\nos.rename('a.txt', " + pyQuote(outside) + ")", "outside workspace"},
+		{"hard link in", "import os\nos.link(" + pyQuote(victim) + ", 'l.txt')", "outside workspace"},
+		{"chmod outside", "import os\nos.chmod(" + pyQuote(victim) + ", 0o777)", "outside workspace"},
+		{"remove outside", "import os\nos.remove(" + pyQuote(victim) + ")", "outside workspace"},
+		{"rmtree outside", "import shutil\nshutil.rmtree(" + pyQuote(victimDir) + ")", "outside workspace"},
+		{"copy outside", "import shutil\nopen('a.txt', 'w').close()\nshutil.copyfile('a.txt', " + pyQuote(outside) + ")", "outside workspace"},
+		{"chdir outside", "import os\nos.chdir('/')", "outside workspace"},
+		{"ctypes", "import ctypes\nctypes.CDLL(None).system(b'echo hi')", "ctypes is disabled"},
+		{"prelude globals", "import __main__\n__main__._wr = __main__._rd = ('/',)\nopen(" + pyQuote(outside) + ", 'w')", "outside workspace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errMsg := runPython(t, tl, tt.code)
+			if errMsg == "" || !strings.Contains(out, tt.want) {
+				t.Errorf("want blocked with %q, got out=%q err=%q", tt.want, out, errMsg)
+			}
+		})
+	}
+	if _, err := os.Stat(outside); err == nil {
+		os.RemoveAll(outside)
+		t.Error("file outside workspace must not be created")
+	}
+	if fi, err := os.Stat(victim); err != nil || fi.Mode().Perm() != 0o644 {
+		t.Errorf("file outside workspace was changed: %v %v", fi, err)
+	}
+}
+
+func TestPythonExec_Timeout(t *testing.T) {
+	tl, _ := newTestPythonTool(t)
+	args, _ := json.Marshal(pythonExecArgs{Code: "while True: pass", Timeout: 1})
+	res, _ := tl.Execute(context.Background(), args)
+	if !strings.Contains(res.Error, "超时") {
+		t.Errorf("want timeout error, got %q", res.Error)
+	}
+}
+
+func TestPythonExec_Validation(t *testing.T) {
+	tl := NewPythonExecTool(t.TempDir(), "")
+	res, _ := tl.Execute(context.Background(), json.RawMessage(`{"code":"print(1)"}`))
+	if !strings.Contains(res.Error, "未找到 Python") {
+		t.Errorf("missing interpreter: got %q", res.Error)
+	}
+
+	tl.interpreter = "python3"
+	res, _ = tl.Execute(context.Background(), json.RawMessage(`{"code":"  "}`))
+	if res.Error == "" {
+		t.Error("empty code should be rejected")
+	}
+	big, _ := json.Marshal(pythonExecArgs{Code: strings.Repeat("#", pythonMaxScript+1)})
+	res, _ = tl.Execute(context.Background(), big)
+	if !strings.Contains(res.Error, "脚本过长") {
+		t.Errorf("oversized script: got %q", res.Error)
+	}
+}
+
+// pyQuote returns s as a Python string literal.
+func pyQuote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
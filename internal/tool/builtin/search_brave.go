@@ -53,9 +53,7 @@ func (t *BraveSearchTool) Description() string {
 }
 
 func (t *BraveSearchTool) InputSchema() json.RawMessage {
-	return tool.BuildSchema(
-		tool.SchemaParam{Name: "query", Type: "string", Description: "搜索关键词", Required: true},
-	)
+	return tool.BuildSchema(searchSchemaParams...)
 }
 
 // Init validates that the API key is configured before the tool is used.
@@ -79,10 +77,15 @@ type braveResult struct {
 	Title       string `json:"title"`
 	URL         string `json:"url"`
 	Description string `json:"description"`
+	PageAge     string `json:"page_age"` // RFC 3339 publish time
+	Age         string `json:"age"`      // human-readable, e.g. "2 days ago"
 }
 
+// braveFreshness maps the freshness filter to Brave's freshness codes.
+var braveFreshness = map[string]string{"day": "pd", "week": "pw", "month": "pm", "year": "py"}
+
 func (t *BraveSearchTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
-	query, err := parseSearchQuery(args)
+	a, err := parseSearchArgs(args)
 	if err != nil {
 		return tool.ToolResult{Error: err.Error()}, nil
 	}
@@ -94,8 +97,11 @@ func (t *BraveSearchTool) Execute(ctx context.Context, args json.RawMessage) (to
 		return tool.ToolResult{Error: fmt.Sprintf("无效的请求地址: %v", err)}, nil
 	}
 	q := u.Query()
-	q.Set("q", query)
+	q.Set("q", a.Query)
 	q.Set("count", fmt.Sprintf("%d", braveMaxResults))
+	if a.Freshness != "" {
+		q.Set("freshness", braveFreshness[a.Freshness])
+	}
 	u.RawQuery = q.Encode()
 	requestURL := u.String()
 
@@ -134,8 +140,12 @@ func (t *BraveSearchTool) Execute(ctx context.Context, args json.RawMessage) (to
 
 	results := make([]searchResult, len(braveResp.Web.Results))
 	for i, r := range braveResp.Web.Results {
-		results[i] = searchResult{Title: r.Title, URL: r.URL, Description: r.Description}
+		published := searchDate(r.PageAge)
+		if published == "" {
+			published = r.Age
+		}
+		results[i] = searchResult{Title: r.Title, URL: r.URL, Description: r.Description, Published: published}
 	}
 
-	return tool.ToolResult{Output: formatSearchResults(results)}, nil
+	return tool.ToolResult{Output: searchOutput(ctx, results)}, nil
 }
@@ -8,6 +8,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
 // newTestBrave creates a BraveSearchTool pointed at a mock server for unit testing.
@@ -259,3 +261,37 @@ func TestBraveSearchTool_String_MasksAPIKey(t *testing.T) {
 		t.Errorf("String() %q should identify the type", s)
 	}
 }
+
+func TestBraveSearchTool_FreshnessAndRunDedup(t *testing.T) {
+	response := braveResponse{}
+	response.Web.Results = []braveResult{
+		{Title: "Go 1.24 Release Notes - The Go Programming Language", URL: "https://go.dev/doc/go1.24", PageAge: "2025-02-11T00:00:00"},
+		{Title: "Go 1.24 is released!", URL: "https://www.go.dev/blog/go1.24/?utm_source=x", Age: "3 days ago"},
+		{Title: "Go 1.24 is released!", URL: "https://go.dev/blog/go1.24#top"},
+	}
+	var freshness []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		freshness = append(freshness, r.URL.Query().Get("freshness"))
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	ctx := tool.WithSearchHistory(context.Background(), tool.NewSearchHistory())
+	brave := newTestBrave(server)
+	result, _ := brave.Execute(ctx, []byte(`{"query":"go 1.24","freshness":"week"}`))
+	if !strings.Contains(result.Output, "找到 2 条结果") || !strings.Contains(result.Output, "（2025-02-11）") || !strings.Contains(result.Output, "（3 days ago）") {
+		t.Errorf("first call output:\n%s", result.Output)
+	}
+	result, _ = brave.Execute(ctx, []byte(`{"query":"golang 1.24 release"}`))
+	if !strings.Contains(result.Output, "均已在之前的搜索中出现过") || !strings.Contains(result.Output, "（第 1 次搜索）") {
+		t.Errorf("repeat call output:\n%s", result.Output)
+	}
+	if len(freshness) != 2 || freshness[0] != "pw" || freshness[1] != "" {
+		t.Errorf("freshness params = %q", freshness)
+	}
+
+	result, _ = brave.Execute(ctx, []byte(`{"query":"go","freshness":"hour"}`))
+	if !strings.Contains(result.Error, "freshness") {
+		t.Errorf("invalid freshness: error = %q", result.Error)
+	}
+}
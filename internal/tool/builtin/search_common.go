@@ -1,10 +1,13 @@
 package builtin
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/pocketomega/pocket-omega/internal/tool"
 	"github.com/pocketomega/pocket-omega/internal/util"
 )
 
@@ -21,26 +24,112 @@ type searchResult struct {
 	Title       string
 	URL         string
 	Description string
+	Published   string // publish date as reported by the engine; "" = unknown
+}
+
+// searchFreshness lists the supported freshness filters.
+var searchFreshness = map[string]bool{"day": true, "week": true, "month": true, "year": true}
+
+// searchArgs are the arguments shared by the search tools.
+type searchArgs struct {
+	Query     string `json:"query"`
+	Freshness string `json:"freshness"` // "", day, week, month, year
+}
+
+// searchSchemaParams are the input parameters shared by the search tools.
+var searchSchemaParams = []tool.SchemaParam{
+	{Name: "query", Type: "string", Description: "搜索关键词", Required: true},
+	{Name: "freshness", Type: "string", Description: "只返回近期发布的结果：day、week、month、year（默认不限）", Required: false},
+}
+
+// parseSearchArgs parses a JSON args blob, trimming the query and
+// validating the freshness filter. Returns an error if the JSON is
+// malformed, the query is empty/whitespace or exceeds searchQueryMaxRunes
+// characters, or freshness is unknown.
+func parseSearchArgs(args json.RawMessage) (searchArgs, error) {
+	var a searchArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return a, fmt.Errorf("参数解析失败: %v", err)
+	}
+	a.Query = strings.TrimSpace(a.Query)
+	if a.Query == "" {
+		return a, fmt.Errorf("搜索关键词不能为空")
+	}
+	if len([]rune(a.Query)) > searchQueryMaxRunes {
+		return a, fmt.Errorf("搜索关键词过长（最多 %d 字符）", searchQueryMaxRunes)
+	}
+	a.Freshness = strings.ToLower(strings.TrimSpace(a.Freshness))
+	if a.Freshness != "" && !searchFreshness[a.Freshness] {
+		return a, fmt.Errorf("freshness 仅支持 day、week、month、year，收到 %q", a.Freshness)
+	}
+	return a, nil
 }
 
 // parseSearchQuery parses a JSON args blob and returns the trimmed query string.
-// Returns an error if the JSON is malformed, the query is empty/whitespace,
-// or the query exceeds searchQueryMaxRunes characters.
 func parseSearchQuery(args json.RawMessage) (string, error) {
-	var a struct {
-		Query string `json:"query"`
+	a, err := parseSearchArgs(args)
+	return a.Query, err
+}
+
+// searchDate shortens an RFC 3339 timestamp to its date; other values
+// ("3 days ago") are kept as they are.
+func searchDate(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", time.RFC1123, time.RFC1123Z} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format("2006-01-02")
+		}
 	}
-	if err := json.Unmarshal(args, &a); err != nil {
-		return "", fmt.Errorf("参数解析失败: %v", err)
+	return s
+}
+
+// searchRepeat is a result dropped because an earlier search call of the
+// run already showed it.
+type searchRepeat struct {
+	Title string
+	Call  int // the search call that first showed it
+}
+
+// dedupeSearchResults drops results whose URL or title repeats one shown
+// earlier in this call or, with a tool.SearchHistory in ctx, in an earlier
+// search call of the run. Results repeated from earlier calls are returned
+// as repeats so the output can say so.
+func dedupeSearchResults(ctx context.Context, results []searchResult) (kept []searchResult, repeats []searchRepeat) {
+	history := tool.SearchHistoryFrom(ctx)
+	if history == nil {
+		history = tool.NewSearchHistory() // still drop repeats within the call
 	}
-	q := strings.TrimSpace(a.Query)
-	if q == "" {
-		return "", fmt.Errorf("搜索关键词不能为空")
+	call := history.BeginCall()
+	for _, r := range results {
+		first, dup := history.Seen(call, r.URL, r.Title)
+		switch {
+		case !dup:
+			kept = append(kept, r)
+		case first != call:
+			repeats = append(repeats, searchRepeat{Title: r.Title, Call: first})
+		}
 	}
-	if len([]rune(q)) > searchQueryMaxRunes {
-		return "", fmt.Errorf("搜索关键词过长（最多 %d 字符）", searchQueryMaxRunes)
+	return kept, repeats
+}
+
+// searchOutput deduplicates results against the run's earlier searches and
+// formats them, noting the dropped repeats.
+func searchOutput(ctx context.Context, results []searchResult) string {
+	kept, repeats := dedupeSearchResults(ctx, results)
+	if len(repeats) == 0 {
+		return formatSearchResults(kept)
+	}
+	var sb strings.Builder
+	if len(kept) == 0 {
+		sb.WriteString(fmt.Sprintf("本次搜索的 %d 条结果均已在之前的搜索中出现过（已省略）。请直接使用已有结果，或换用明显不同的关键词/时间范围，不要重复搜索相近的说法。\n", len(repeats)))
+	} else {
+		sb.WriteString(formatSearchResults(kept))
+		sb.WriteString(fmt.Sprintf("另有 %d 条结果已在之前的搜索中出现过（已省略）：\n", len(repeats)))
+	}
+	for _, r := range repeats {
+		sb.WriteString(fmt.Sprintf("  - %s（第 %d 次搜索）\n", util.TruncateRunes(r.Title, 80), r.Call))
 	}
-	return q, nil
+	return sb.String()
 }
 
 // formatSearchResults formats a slice of searchResult into a human-readable string.
@@ -52,7 +141,11 @@ func formatSearchResults(results []searchResult) string {
 	sb.WriteString(fmt.Sprintf("找到 %d 条结果：\n\n", len(results)))
 	for i, r := range results {
 		desc := util.TruncateRunes(r.Description, searchDescMaxRunes)
-		sb.WriteString(fmt.Sprintf("[%d] %s\n    %s\n    %s\n\n", i+1, r.Title, r.URL, desc))
+		title := r.Title
+		if r.Published != "" {
+			title += "（" + r.Published + "）"
+		}
+		sb.WriteString(fmt.Sprintf("[%d] %s\n    %s\n    %s\n\n", i+1, title, r.URL, desc))
 	}
 	return sb.String()
 }
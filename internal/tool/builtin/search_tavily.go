@@ -53,9 +53,7 @@ func (t *TavilySearchTool) Description() string {
 }
 
 func (t *TavilySearchTool) InputSchema() json.RawMessage {
-	return tool.BuildSchema(
-		tool.SchemaParam{Name: "query", Type: "string", Description: "搜索关键词", Required: true},
-	)
+	return tool.BuildSchema(searchSchemaParams...)
 }
 
 // Init validates that the API key is configured before the tool is used.
@@ -73,6 +71,7 @@ type tavilyRequest struct {
 	APIKey     string `json:"api_key"`
 	Query      string `json:"query"`
 	MaxResults int    `json:"max_results"`
+	TimeRange  string `json:"time_range,omitempty"` // day, week, month, year
 }
 
 // String returns a log-safe representation with the API key masked,
@@ -88,13 +87,14 @@ type tavilyResponse struct {
 }
 
 type tavilyResult struct {
-	Title   string `json:"title"`
-	URL     string `json:"url"`
-	Content string `json:"content"`
+	Title         string `json:"title"`
+	URL           string `json:"url"`
+	Content       string `json:"content"`
+	PublishedDate string `json:"published_date,omitempty"`
 }
 
 func (t *TavilySearchTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
-	query, err := parseSearchQuery(args)
+	a, err := parseSearchArgs(args)
 	if err != nil {
 		return tool.ToolResult{Error: err.Error()}, nil
 	}
@@ -102,8 +102,9 @@ func (t *TavilySearchTool) Execute(ctx context.Context, args json.RawMessage) (t
 	// Build request body (API key goes in body per Tavily's API design).
 	reqBody := tavilyRequest{
 		APIKey:     t.apiKey,
-		Query:      query,
+		Query:      a.Query,
 		MaxResults: tavilyMaxResults,
+		TimeRange:  a.Freshness,
 	}
 	// SECURITY: bodyBytes contains the plaintext API key.
 	// Do NOT log or expose bodyBytes in error messages or debug output.
@@ -151,9 +152,9 @@ func (t *TavilySearchTool) Execute(ctx context.Context, args json.RawMessage) (t
 
 	results := make([]searchResult, len(tavilyResp.Results))
 	for i, r := range tavilyResp.Results {
-		results[i] = searchResult{Title: r.Title, URL: r.URL, Description: r.Content}
+		results[i] = searchResult{Title: r.Title, URL: r.URL, Description: r.Content, Published: searchDate(r.PublishedDate)}
 	}
-	sb.WriteString(formatSearchResults(results))
+	sb.WriteString(searchOutput(ctx, results))
 
 	return tool.ToolResult{Output: sb.String()}, nil
 }
@@ -295,3 +295,27 @@ func TestTavilySearchTool_String_MasksAPIKey(t *testing.T) {
 		t.Errorf("String() %q should identify the type", s)
 	}
 }
+
+func TestTavilySearchTool_TimeRangeAndPublishedDate(t *testing.T) {
+	response := tavilyResponse{
+		Results: []tavilyResult{
+			{Title: "Go 1.24 发布", URL: "https://go.dev/blog/go1.24", Content: "新版本", PublishedDate: "Tue, 11 Feb 2025 18:00:00 GMT"},
+		},
+	}
+	var timeRange string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body tavilyRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		timeRange = body.TimeRange
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	result, _ := newTestTavily(server).Execute(context.Background(), []byte(`{"query":"go 1.24","freshness":"Month"}`))
+	if timeRange != "month" {
+		t.Errorf("time_range = %q, want month", timeRange)
+	}
+	if !strings.Contains(result.Output, "Go 1.24 发布（2025-02-11）") {
+		t.Errorf("output %q should contain the publish date", result.Output)
+	}
+}
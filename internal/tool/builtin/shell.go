@@ -10,12 +10,17 @@ import (
 	"time"
 	"unicode/utf8"
 
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
 const (
 	shellTimeout   = 30 * time.Second
 	maxOutputChars = 8000
+
+	// shellStopGrace bounds the wait for output pipes after a cancelled
+	// command was killed.
+	shellStopGrace = 2 * time.Second
 )
 
 // dangerousShellCommands are command patterns that are blocked for safety.
@@ -67,6 +72,8 @@ var dangerousShellCommands = []string{
 type ShellTool struct {
 	workspaceDir string
 	enabled      bool
+	sandbox      *sandbox.Container // nil = run on host
+	backend      ShellBackend       // Windows host shell; "" = cmd
 }
 
 // NewShellTool creates a shell tool. Set enabled=false to disable execution.
@@ -77,8 +84,26 @@ func NewShellTool(workspaceDir string, enabled bool) *ShellTool {
 	}
 }
 
-func (t *ShellTool) Name() string        { return "shell_exec" }
-func (t *ShellTool) Description() string { return "执行 Shell 命令并返回输出" }
+// WithSandbox runs every command inside the given container instead of on
+// the host (TOOL_SHELL_SANDBOX=container).
+func (t *ShellTool) WithSandbox(c *sandbox.Container) *ShellTool {
+	t.sandbox = c
+	return t
+}
+
+// WithBackend selects the shell used on Windows hosts (TOOL_SHELL_WINDOWS).
+func (t *ShellTool) WithBackend(b ShellBackend) *ShellTool {
+	t.backend = b
+	return t
+}
+
+func (t *ShellTool) Name() string { return "shell_exec" }
+func (t *ShellTool) Description() string {
+	if t.sandbox != nil {
+		return "执行 Shell 命令并返回输出（在容器沙箱中执行：仅可访问工作区目录，默认无网络）"
+	}
+	return "执行 Shell 命令并返回输出"
+}
 
 func (t *ShellTool) InputSchema() json.RawMessage {
 	return tool.BuildSchema(
@@ -140,7 +165,11 @@ func (t *ShellTool) Execute(ctx context.Context, args json.RawMessage) (tool.Too
 	defer cancel()
 
 	var cmd *exec.Cmd
-	cmd = newShellCmd(ctx, a.Command)
+	if t.sandbox != nil {
+		cmd = newSandboxCmd(ctx, t.sandbox, a.Command)
+	} else {
+		cmd = newShellCmd(ctx, a.Command, t.backend)
+	}
 
 	if t.workspaceDir != "" {
 		cmd.Dir = t.workspaceDir
@@ -170,6 +199,21 @@ func (t *ShellTool) Execute(ctx context.Context, args json.RawMessage) (tool.Too
 	return tool.ToolResult{Output: outStr}, nil
 }
 
+// sandboxStopGrace is how long a timed-out container client is given to
+// forward the interrupt and remove the container before being killed.
+const sandboxStopGrace = 5 * time.Second
+
+// newSandboxCmd wraps command in a container run. On timeout the container CLI
+// receives an interrupt (proxied to the container) rather than SIGKILL, which
+// would orphan the running container.
+func newSandboxCmd(ctx context.Context, c *sandbox.Container, command string) *exec.Cmd {
+	name, argv := c.Wrap("sh", []string{"-c", command}, nil, false)
+	cmd := exec.CommandContext(ctx, name, argv...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = sandboxStopGrace
+	return cmd
+}
+
 // safeRuneTruncate truncates a string to maxRunes runes in a single pass,
 // preserving valid UTF-8 without extra allocations for non-truncated strings.
 func safeRuneTruncate(s string, maxRunes int) string {
@@ -0,0 +1,69 @@
+package builtin
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// ShellBackend is the interpreter shell_exec runs commands with on Windows.
+// Other platforms always use sh -c.
+type ShellBackend string
+
+const (
+	ShellCmd        ShellBackend = "cmd"        // cmd.exe /c
+	ShellPowerShell ShellBackend = "powershell" // Windows PowerShell 5.1
+	ShellPwsh       ShellBackend = "pwsh"       // PowerShell 7+
+)
+
+// ParseShellBackend parses a TOOL_SHELL_WINDOWS value; "" is ShellCmd.
+func ParseShellBackend(s string) (ShellBackend, error) {
+	switch b := ShellBackend(strings.ToLower(strings.TrimSpace(s))); b {
+	case "":
+		return ShellCmd, nil
+	case ShellCmd, ShellPowerShell, ShellPwsh:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown Windows shell %q (supported: cmd, powershell, pwsh)", s)
+	}
+}
+
+// IsPowerShell reports whether the backend is a PowerShell.
+func (b ShellBackend) IsPowerShell() bool { return b == ShellPowerShell || b == ShellPwsh }
+
+// Invocation describes how commands are run, for the prompt ({{SHELL_CMD}}).
+func (b ShellBackend) Invocation() string {
+	if b.IsPowerShell() {
+		return string(b) + " -NoProfile -Command"
+	}
+	return "cmd.exe /c"
+}
+
+// powerShellPrelude switches the console and pipeline encodings to UTF-8
+// without BOM, so output (including that of native programs) is not
+// garbled by the OEM code page, and hides progress bars, which PowerShell
+// would otherwise serialize into the captured output.
+const powerShellPrelude = "$ProgressPreference = 'SilentlyContinue'\n" +
+	"[Console]::OutputEncoding = $OutputEncoding = New-Object System.Text.UTF8Encoding $false\n"
+
+// powerShellEpilogue makes the process exit code reflect failure: the
+// native program's exit code if the last command was one, 1 if a cmdlet
+// failed. PowerShell would otherwise exit 0 after most errors.
+const powerShellEpilogue = "\nif (-not $?) { if ($LASTEXITCODE) { exit $LASTEXITCODE }; exit 1 }"
+
+// powerShellCommandLine returns the command line that runs command with a
+// PowerShell backend. The script is passed as -EncodedCommand (base64 of
+// UTF-16LE), which sidesteps Windows command-line quoting entirely: quotes,
+// $variables and non-ASCII text reach PowerShell unchanged.
+func powerShellCommandLine(b ShellBackend, command string) string {
+	script := powerShellPrelude + command + powerShellEpilogue
+	units := utf16.Encode([]rune(script))
+	buf := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[2*i:], u)
+	}
+	return string(b) + " -NoProfile -NonInteractive -ExecutionPolicy Bypass -EncodedCommand " +
+		base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,51 @@
+package builtin
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestParseShellBackend(t *testing.T) {
+	for in, want := range map[string]ShellBackend{"": ShellCmd, "cmd": ShellCmd, " PowerShell ": ShellPowerShell, "pwsh": ShellPwsh} {
+		if got, err := ParseShellBackend(in); err != nil || got != want {
+			t.Errorf("ParseShellBackend(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseShellBackend("bash"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+	if got := ShellPwsh.Invocation(); got != "pwsh -NoProfile -Command" {
+		t.Errorf("pwsh invocation = %q", got)
+	}
+	if got := ShellCmd.Invocation(); got != "cmd.exe /c" {
+		t.Errorf("cmd invocation = %q", got)
+	}
+}
+
+func TestPowerShellCommandLine(t *testing.T) {
+	command := `Write-Output "héllo 世界" | Select-String 'x$y'`
+	line := powerShellCommandLine(ShellPowerShell, command)
+	prefix := "powershell -NoProfile -NonInteractive -ExecutionPolicy Bypass -EncodedCommand "
+	if !strings.HasPrefix(line, prefix) {
+		t.Fatalf("command line = %q", line)
+	}
+	encoded := strings.TrimPrefix(line, prefix)
+	if strings.ContainsAny(encoded, ` "'$`) {
+		t.Errorf("encoded command needs quoting: %q", encoded)
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw)%2 != 0 {
+		t.Fatalf("decode: %v", err)
+	}
+	units := make([]uint16, len(raw)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(raw[2*i:])
+	}
+	script := string(utf16.Decode(units))
+	if !strings.Contains(script, "\n"+command+"\n") || !strings.Contains(script, "UTF8Encoding $false") || !strings.Contains(script, "exit $LASTEXITCODE") {
+		t.Errorf("script = %q", script)
+	}
+}
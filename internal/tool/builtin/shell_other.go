@@ -5,9 +5,18 @@ package builtin
 import (
 	"context"
 	"os/exec"
+	"syscall"
 )
 
 // newShellCmd creates a shell command for non-Windows platforms using sh -c.
-func newShellCmd(ctx context.Context, command string) *exec.Cmd {
-	return exec.CommandContext(ctx, "sh", "-c", command)
+// The shell runs in its own process group and cancellation kills the whole
+// group: killing only sh would leave children (e.g. "sleep 60 | cat")
+// running and holding the output pipe open until they exit. The Windows
+// shell backend does not apply here.
+func newShellCmd(ctx context.Context, command string, _ ShellBackend) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = shellStopGrace
+	return cmd
 }
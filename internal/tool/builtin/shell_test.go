@@ -3,9 +3,14 @@ package builtin
 import (
 	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/sandbox"
 )
 
 func TestDangerousPatternBlocking(t *testing.T) {
@@ -290,6 +295,57 @@ func TestExecute_NonZeroExit(t *testing.T) {
 	}
 }
 
+func TestExecute_CancelKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process groups are POSIX only")
+	}
+	// sleep keeps the output pipe open; killing only sh would block until it exits.
+	st := NewShellTool("", true)
+	args, _ := json.Marshal(shellArgs{Command: "sleep 20 | cat"})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	result, err := st.Execute(ctx, args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancelled command took %v", elapsed)
+	}
+	if !strings.Contains(result.Error, "取消") {
+		t.Errorf("expected cancellation error, got: %+v", result)
+	}
+}
+
+func TestExecute_Sandboxed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake container runtime is a POSIX shell script")
+	}
+	// Fake runtime that prints its argv instead of starting a container
+	dir := t.TempDir()
+	fake := filepath.Join(dir, "fake-runtime")
+	os.WriteFile(fake, []byte("#!/bin/sh\necho \"$@\"\n"), 0755)
+
+	sb := &sandbox.Container{Runtime: fake, Image: "alpine:3", Network: "none", WorkspaceDir: dir}
+	st := NewShellTool(dir, true).WithSandbox(sb)
+	if !strings.Contains(st.Description(), "容器") {
+		t.Errorf("description should mention the sandbox, got %q", st.Description())
+	}
+	args, _ := json.Marshal(shellArgs{Command: "echo hi"})
+	result, err := st.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Error != "" {
+		t.Fatalf("unexpected tool error: %s", result.Error)
+	}
+	want := "run --rm --network none -v " + dir + ":" + dir + " -w " + dir + " alpine:3 sh -c echo hi"
+	if result.Output != want {
+		t.Errorf("output =\n  %q\nwant\n  %q", result.Output, want)
+	}
+}
+
 func TestExecute_BadJSON(t *testing.T) {
 	st := NewShellTool("", true)
 	result, err := st.Execute(context.Background(), []byte(`not json`))
@@ -18,10 +18,24 @@ import (
 // command, so that PowerShell (and other tools) emit UTF-8 output instead of
 // the system OEM code page (e.g. GBK on Chinese Windows). Without this, Chinese
 // characters in PowerShell -Verbose / error messages appear as mojibake.
-func newShellCmd(ctx context.Context, command string) *exec.Cmd {
-	cmd := exec.CommandContext(ctx, "cmd")
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		CmdLine: "cmd /c chcp 65001 >nul & " + command,
+//
+// The PowerShell backends get the command as -EncodedCommand instead (see
+// powerShellCommandLine), which needs no quoting and sets UTF-8 itself.
+func newShellCmd(ctx context.Context, command string, backend ShellBackend) *exec.Cmd {
+	var cmd *exec.Cmd
+	if backend.IsPowerShell() {
+		cmd = exec.CommandContext(ctx, string(backend))
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			CmdLine: powerShellCommandLine(backend, command),
+		}
+	} else {
+		cmd = exec.CommandContext(ctx, "cmd")
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			CmdLine: "cmd /c chcp 65001 >nul & " + command,
+		}
 	}
+	// Killing the shell does not stop its children; don't wait for them to
+	// release the output pipe after cancellation.
+	cmd.WaitDelay = shellStopGrace
 	return cmd
 }
@@ -0,0 +1,629 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/mcp"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// ─────────────────────────────────────────────────────────────────────────────
+// skill_install — 从 git 仓库或压缩包安装 skill
+//
+// 把别人分享的 skill（stdio MCP server）装进 skills/<name>/：git clone 或下载
+// 压缩包 → 对所有脚本做安全扫描（有 critical 发现则拒绝安装）→ 按 skill.json
+// 或约定的入口文件写入 mcp.json → 重新加载（安装声明的依赖并连接）。
+// ─────────────────────────────────────────────────────────────────────────────
+
+const (
+	skillInstallTimeout  = 5 * time.Minute // clone or download
+	skillInstallMaxBytes = 50 << 20        // download size and extracted size
+	skillInstallMaxFiles = 5000
+	skillManifestFile    = "skill.json"
+	skillFindingsShown   = 10
+)
+
+// skillManifest is skill.json, the optional install manifest of a skill.
+// Args may use {dir} for the installed skill directory; the entry script is
+// the first "{dir}/..." argument (see checkSkillEntry). Without a manifest
+// the entry point and dependencies are inferred (see inferSkillManifest).
+type skillManifest struct {
+	Description  string           `json:"description,omitempty"`
+	Command      string           `json:"command"`
+	Args         []string         `json:"args,omitempty"`
+	Env          []string         `json:"env,omitempty"`
+	Lifecycle    string           `json:"lifecycle,omitempty"`
+	Dependencies *mcpDependencies `json:"dependencies,omitempty"`
+}
+
+// skillEntries are the entry points looked for without a manifest, in order.
+var skillEntries = []struct {
+	file    string
+	command string
+	args    []string // before the script path
+}{
+	{"server.py", "python3", nil},
+	{"main.py", "python3", nil},
+	{"server.ts", "node", []string{"--import", "tsx"}},
+	{"index.ts", "node", []string{"--import", "tsx"}},
+	{"server.js", "node", nil},
+	{"index.js", "node", nil},
+	{"server.mjs", "node", nil},
+}
+
+// skillLaunchers are the commands a skill may be started with, each with the
+// argument lists allowed before the entry script. Anything else (a shell,
+// python3 -c, node --eval, uv tool run) would run code the scanner never sees.
+var skillLaunchers = map[string][][]string{
+	"python3": {nil, {"-u"}},
+	"python":  {nil, {"-u"}},
+	"node":    {nil, {"--import", "tsx"}},
+	"uv":      {{"run"}},
+}
+
+// skillUnscannedDirs are the dependency directories scanSkill does not walk.
+var skillUnscannedDirs = map[string]bool{"node_modules": true, ".venv": true, ".git": true}
+
+var skillNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// scpLikeGit matches git's scp-like syntax: git@github.com:user/repo.git
+var scpLikeGit = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/-]`)
+
+// SkillInstallTool installs a skill from a git URL or an archive URL.
+type SkillInstallTool struct {
+	skillsDir     string
+	mcpConfigPath string
+	reload        func(ctx context.Context) (string, error) // nil = the agent calls mcp_reload
+	allowInternal bool
+}
+
+// NewSkillInstallTool creates the skill_install tool installing into
+// skillsDir (workspace/skills) and registering in the mcp.json at
+// mcpConfigPath.
+func NewSkillInstallTool(skillsDir, mcpConfigPath string) *SkillInstallTool {
+	return &SkillInstallTool{skillsDir: skillsDir, mcpConfigPath: mcpConfigPath}
+}
+
+// WithReload sets the MCP reload run after registering, which installs the
+// dependencies and connects the skill.
+func (t *SkillInstallTool) WithReload(fn func(ctx context.Context) (string, error)) *SkillInstallTool {
+	t.reload = fn
+	return t
+}
+
+// WithAllowInternal allows archive downloads from internal addresses
+// (TOOL_HTTP_ALLOW_INTERNAL).
+func (t *SkillInstallTool) WithAllowInternal(allow bool) *SkillInstallTool {
+	t.allowInternal = allow
+	return t
+}
+
+func (t *SkillInstallTool) Name() string { return "skill_install" }
+func (t *SkillInstallTool) Description() string {
+	return "从 git 仓库地址或压缩包（.zip/.tar.gz/.tgz/.tar）URL 安装别人分享的 skill 到 skills/<name>/：" +
+		"安全扫描全部脚本（发现高危代码则拒绝安装）、按 skill.json 或入口文件（server.py/server.ts/server.js 等）注册到 mcp.json，" +
+		"然后自动安装依赖并加载。"
+}
+
+func (t *SkillInstallTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "source", Type: "string", Description: "git 仓库地址（https/ssh）或压缩包 URL", Required: true},
+		tool.SchemaParam{Name: "name", Type: "string", Description: "skill 名称（目录名和 server 名），默认取自仓库或压缩包名"},
+		tool.SchemaParam{Name: "ref", Type: "string", Description: "git 分支或标签（仅 git）"},
+		tool.SchemaParam{Name: "subdir", Type: "string", Description: "skill 在仓库或压缩包中的子目录（多个 skill 放在一个仓库时使用）"},
+	)
+}
+
+func (t *SkillInstallTool) Init(_ context.Context) error { return nil }
+func (t *SkillInstallTool) Close() error                 { return nil }
+
+type skillInstallArgs struct {
+	Source string `json:"source"`
+	Name   string `json:"name"`
+	Ref    string `json:"ref"`
+	Subdir string `json:"subdir"`
+}
+
+func (t *SkillInstallTool) Execute(ctx context.Context, raw json.RawMessage) (tool.ToolResult, error) {
+	var a skillInstallArgs
+	if err := json.Unmarshal(raw, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	a.Source = strings.TrimSpace(a.Source)
+	kind, err := skillSourceKind(a.Source)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	if a.Ref != "" && (kind != "git" || strings.HasPrefix(a.Ref, "-")) {
+		return tool.ToolResult{Error: "ref 只能用于 git 来源，且不能以 - 开头"}, nil
+	}
+	name := a.Name
+	if name == "" {
+		name = skillNameFromSource(a.Source)
+	}
+	if !skillNamePattern.MatchString(name) {
+		return tool.ToolResult{Error: fmt.Sprintf("skill 名称 %q 不合法（字母、数字、- 和 _），请用 name 参数指定", name)}, nil
+	}
+
+	target := filepath.Join(t.skillsDir, name)
+	if _, err := os.Stat(target); err == nil {
+		return tool.ToolResult{Error: fmt.Sprintf("%s 已存在，请换一个 name 或先删除旧的 skill", target)}, nil
+	}
+	cfg, err := readMCPConfig(t.mcpConfigPath)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	if _, exists := cfg.MCPServers[name]; exists {
+		return tool.ToolResult{Error: fmt.Sprintf("server %q 已存在于 mcp.json，请换一个 name", name)}, nil
+	}
+
+	if err := os.MkdirAll(t.skillsDir, 0o755); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("创建 skills 目录失败: %v", err)}, nil
+	}
+	tmp, err := os.MkdirTemp(t.skillsDir, ".install-")
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("创建临时目录失败: %v", err)}, nil
+	}
+	defer os.RemoveAll(tmp)
+
+	fetchCtx, cancel := context.WithTimeout(ctx, skillInstallTimeout)
+	defer cancel()
+	src := filepath.Join(tmp, "src")
+	if kind == "git" {
+		err = gitCloneSkill(fetchCtx, a.Source, a.Ref, src)
+	} else {
+		err = t.downloadSkill(fetchCtx, a.Source, tmp, src)
+	}
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	os.RemoveAll(filepath.Join(src, ".git"))
+
+	root := src
+	if a.Subdir != "" {
+		rel, err := entryPath(a.Subdir)
+		if err != nil || rel == "" {
+			return tool.ToolResult{Error: fmt.Sprintf("subdir %q 不合法", a.Subdir)}, nil
+		}
+		root = filepath.Join(src, filepath.FromSlash(rel))
+		if fi, err := os.Stat(root); err != nil || !fi.IsDir() {
+			return tool.ToolResult{Error: fmt.Sprintf("来源中没有子目录 %s", a.Subdir)}, nil
+		}
+	}
+
+	manifest, err := loadSkillManifest(root)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	scan, err := scanSkill(root)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+
+	if err := os.Rename(root, target); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("移动到 %s 失败: %v", target, err)}, nil
+	}
+	absTarget, _ := filepath.Abs(target)
+	args := make([]string, len(manifest.Args))
+	for i, arg := range manifest.Args {
+		args[i] = strings.ReplaceAll(arg, "{dir}", absTarget)
+	}
+	cfg.MCPServers[name] = mcpServerEntry{
+		Transport:    "stdio",
+		Command:      manifest.Command,
+		Args:         args,
+		Env:          manifest.Env,
+		Lifecycle:    manifest.Lifecycle,
+		Dependencies: manifest.Dependencies,
+		Meta: map[string]string{
+			"origin":       "skill_install",
+			"source":       a.Source,
+			"installed_at": time.Now().Format("2006-01-02"),
+		},
+	}
+	if err := writeMCPConfig(t.mcpConfigPath, cfg); err != nil {
+		os.RemoveAll(target)
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ skill %q 已安装到 %s\n", name, target)
+	fmt.Fprintf(&sb, "启动命令：%s %s\n", manifest.Command, strings.Join(args, " "))
+	if deps := manifest.Dependencies; deps != nil {
+		fmt.Fprintf(&sb, "依赖：python %d 个，node %d 个（加载时安装到 skill 目录）\n", len(deps.Python), len(deps.Node))
+	}
+	if manifest.Description != "" {
+		fmt.Fprintf(&sb, "说明：%s\n", manifest.Description)
+	}
+	sb.WriteString(scan)
+	if t.reload == nil {
+		sb.WriteString("\n请调用 mcp_reload 安装依赖并加载。")
+		return tool.ToolResult{Output: sb.String()}, nil
+	}
+	summary, err := t.reload(ctx)
+	if err != nil {
+		fmt.Fprintf(&sb, "\n⚠️ 加载失败：%v（修正后调用 mcp_reload）", err)
+		return tool.ToolResult{Output: sb.String()}, nil
+	}
+	sb.WriteString("\n" + summary)
+	return tool.ToolResult{Output: sb.String()}, nil
+}
+
+// skillSourceKind classifies source as "git" or "archive". Local paths,
+// file:// and other transports are refused: a skill comes from the network.
+func skillSourceKind(source string) (string, error) {
+	if source == "" {
+		return "", fmt.Errorf("source 不得为空")
+	}
+	if strings.HasPrefix(source, "-") {
+		return "", fmt.Errorf("source 不能以 - 开头")
+	}
+	if scpLikeGit.MatchString(source) {
+		return "git", nil
+	}
+	u, err := neturl.Parse(source)
+	if err != nil || u.Host == "" {
+		return "", fmt.Errorf("source 必须是 git 仓库地址（https/ssh）或压缩包 URL，当前值: %s", source)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if _, err := archiveFormat(u.Path); err == nil {
+			return "archive", nil
+		}
+		return "git", nil
+	case "ssh", "git":
+		return "git", nil
+	}
+	return "", fmt.Errorf("不支持的来源协议 %q（支持 https、http、ssh、git）", u.Scheme)
+}
+
+// skillNameFromSource derives the skill name from the last path element
+// of the repository or archive, without .git or the archive extension.
+func skillNameFromSource(source string) string {
+	p := source
+	if u, err := neturl.Parse(source); err == nil && u.Host != "" {
+		p = u.Path
+	} else if i := strings.LastIndex(source, ":"); i >= 0 {
+		p = source[i+1:] // scp-like
+	}
+	base := path.Base(strings.TrimRight(p, "/"))
+	for _, ext := range []string{".git", ".tar.gz", ".tgz", ".tar", ".zip"} {
+		if strings.HasSuffix(strings.ToLower(base), ext) {
+			base = base[:len(base)-len(ext)]
+			break
+		}
+	}
+	return base
+}
+
+// gitCloneSkill shallow-clones url into dst without prompting for
+// credentials and without the local-file or ext transports.
+func gitCloneSkill(ctx context.Context, url, ref, dst string) error {
+	args := []string{"-c", "protocol.file.allow=never", "-c", "protocol.ext.allow=never", "clone", "--depth", "1", "--quiet"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, "--", url, dst)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("git clone 超时（%v）", skillInstallTimeout)
+		}
+		return fmt.Errorf("git clone 失败: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// downloadSkill downloads the archive at url into tmp and extracts it to
+// dst. A single top-level directory, as in GitHub archives, is stripped.
+func (t *SkillInstallTool) downloadSkill(ctx context.Context, url, tmp, dst string) error {
+	u, _ := neturl.Parse(url)
+	format, _ := archiveFormat(u.Path)
+	archivePath := filepath.Join(tmp, "archive."+format)
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				host, _, err := net.SplitHostPort(addr)
+				if err != nil {
+					host = addr
+				}
+				if !t.allowInternal {
+					if err := blockInternalHost(host); err != nil {
+						return nil, err
+					}
+				}
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("下载失败: %v", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("下载失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("下载失败: HTTP %d", resp.StatusCode)
+	}
+	f, err := os.Create(archivePath)
+	if err != nil {
+		return err
+	}
+	n, err := io.Copy(f, io.LimitReader(resp.Body, skillInstallMaxBytes+1))
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("下载失败: %v", err)
+	}
+	if n > skillInstallMaxBytes {
+		return fmt.Errorf("压缩包超过 %d MB 上限", skillInstallMaxBytes>>20)
+	}
+	if err := extractSkill(archivePath, format, dst); err != nil {
+		return err
+	}
+	return stripSingleDir(dst)
+}
+
+// extractSkill extracts the regular files and directories of an archive
+// into dst. Unsafe paths fail the install; links and special files are
+// skipped.
+func extractSkill(archivePath, format, dst string) error {
+	var files int
+	var total int64
+	return walkArchive(archivePath, format, func(e archiveEntry, r io.Reader) error {
+		rel, err := entryPath(e.name)
+		if err != nil || rel == "" || e.kind == archiveLink || e.kind == archiveOther {
+			return err
+		}
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+		if e.kind == archiveDir {
+			return os.MkdirAll(target, 0o755)
+		}
+		if files++; files > skillInstallMaxFiles {
+			return fmt.Errorf("压缩包文件数超过 %d 上限", skillInstallMaxFiles)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, e.mode.Perm()|0o600)
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(out, io.LimitReader(r, skillInstallMaxBytes-total+1))
+		out.Close()
+		if total += n; total > skillInstallMaxBytes {
+			return fmt.Errorf("解压后超过 %d MB 上限", skillInstallMaxBytes>>20)
+		}
+		return err
+	})
+}
+
+// stripSingleDir moves the contents of dir's only subdirectory up into dir.
+func stripSingleDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("压缩包为空")
+	}
+	if len(entries) != 1 || !entries[0].IsDir() {
+		return nil
+	}
+	inner := filepath.Join(dir, entries[0].Name())
+	moved := dir + ".strip"
+	if err := os.Rename(inner, moved); err != nil {
+		return err
+	}
+	if err := os.Remove(dir); err != nil {
+		return err
+	}
+	return os.Rename(moved, dir)
+}
+
+// loadSkillManifest reads skill.json in dir, or infers the manifest.
+func loadSkillManifest(dir string) (skillManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, skillManifestFile))
+	if os.IsNotExist(err) {
+		m, err := inferSkillManifest(dir)
+		if err != nil {
+			return skillManifest{}, err
+		}
+		return m, checkSkillEntry(dir, m)
+	}
+	if err != nil {
+		return skillManifest{}, err
+	}
+	var m skillManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return skillManifest{}, fmt.Errorf("解析 %s 失败: %v", skillManifestFile, err)
+	}
+	if m.Command == "" {
+		return skillManifest{}, fmt.Errorf("%s 缺少 command", skillManifestFile)
+	}
+	if m.Lifecycle != "" && m.Lifecycle != "persistent" && m.Lifecycle != "per_call" {
+		return skillManifest{}, fmt.Errorf("%s 的 lifecycle 必须为 persistent 或 per_call", skillManifestFile)
+	}
+	return m, checkSkillEntry(dir, m)
+}
+
+// checkSkillEntry makes sure the manifest starts an allowed launcher on a
+// script of the skill: the command is in skillLaunchers, only its allowed
+// arguments precede the entry script, and the entry script is a regular,
+// scannable file inside dir that scanSkill will walk. Arguments after the
+// entry script are passed to the script.
+func checkSkillEntry(dir string, m skillManifest) error {
+	prefixes, ok := skillLaunchers[m.Command]
+	if !ok {
+		names := make([]string, 0, len(skillLaunchers))
+		for name := range skillLaunchers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("%s 的 command %q 不被允许（仅支持 %s）", skillManifestFile, m.Command, strings.Join(names, ", "))
+	}
+	entry := slices.IndexFunc(m.Args, func(arg string) bool { return strings.HasPrefix(arg, "{dir}/") })
+	if entry < 0 {
+		return fmt.Errorf("%s 的 args 缺少入口脚本（{dir}/...）", skillManifestFile)
+	}
+	if !slices.ContainsFunc(prefixes, func(p []string) bool { return slices.Equal(p, m.Args[:entry]) }) {
+		return fmt.Errorf("%s 的入口脚本前不允许参数 %q", skillManifestFile, m.Args[:entry])
+	}
+	rel, err := entryPath(strings.TrimPrefix(m.Args[entry], "{dir}/"))
+	if err != nil || rel == "" {
+		return fmt.Errorf("%s 的入口脚本 %q 不在 skill 目录内", skillManifestFile, m.Args[entry])
+	}
+	for _, part := range strings.Split(rel, "/") {
+		if skillUnscannedDirs[part] {
+			return fmt.Errorf("入口脚本 %s 位于 %s 中，无法扫描", rel, part)
+		}
+	}
+	fi, err := os.Lstat(filepath.Join(dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return fmt.Errorf("入口脚本 %s 不存在", rel)
+	}
+	if !fi.Mode().IsRegular() || !mcp.IsScannable(rel) {
+		return fmt.Errorf("入口脚本 %s 必须是可扫描的脚本文件（.py、.js、.ts 等）", rel)
+	}
+	return nil
+}
+
+// inferSkillManifest finds the entry point among skillEntries and takes the
+// dependencies from requirements.txt (Python) or package.json (Node).
+func inferSkillManifest(dir string) (skillManifest, error) {
+	for _, e := range skillEntries {
+		if _, err := os.Stat(filepath.Join(dir, e.file)); err != nil {
+			continue
+		}
+		m := skillManifest{Command: e.command, Args: append(append([]string{}, e.args...), "{dir}/"+e.file)}
+		deps := &mcpDependencies{}
+		if e.command == "python3" {
+			deps.Python = readRequirements(filepath.Join(dir, "requirements.txt"))
+		} else {
+			deps.Node = readPackageDeps(filepath.Join(dir, "package.json"))
+		}
+		if len(deps.Python)+len(deps.Node) > 0 {
+			m.Dependencies = deps
+		}
+		return m, nil
+	}
+	names := make([]string, len(skillEntries))
+	for i, e := range skillEntries {
+		names[i] = e.file
+	}
+	return skillManifest{}, fmt.Errorf("未找到 %s 或入口文件（%s），无法确定启动方式", skillManifestFile, strings.Join(names, ", "))
+}
+
+// readRequirements returns the requirement lines of a requirements.txt,
+// without comments and pip options.
+func readRequirements(p string) []string {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+	var reqs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "-") {
+			reqs = append(reqs, line)
+		}
+	}
+	return reqs
+}
+
+// readPackageDeps returns the dependencies of a package.json as npm specs.
+func readPackageDeps(p string) []string {
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if json.Unmarshal(data, &pkg) != nil {
+		return nil
+	}
+	specs := make([]string, 0, len(pkg.Dependencies))
+	for name, version := range pkg.Dependencies {
+		specs = append(specs, name+"@"+version)
+	}
+	sort.Strings(specs)
+	return specs
+}
+
+// scanSkill runs the MCP security scanner over every script of a skill.
+// Critical findings and symlinks refuse the install; warnings are reported.
+func scanSkill(dir string) (string, error) {
+	var scanned int
+	var critical, warnings []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && skillUnscannedDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			rel, _ := filepath.Rel(dir, p)
+			return fmt.Errorf("skill 中包含符号链接 %s，已拒绝安装", filepath.ToSlash(rel))
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if !mcp.IsScannable(p) {
+			return nil
+		}
+		findings, err := mcp.ScanScript(p)
+		if err != nil {
+			return err
+		}
+		scanned++
+		rel, _ := filepath.Rel(dir, p)
+		for _, f := range findings {
+			line := fmt.Sprintf("  [%s] %s:%d %s", f.Rule, filepath.ToSlash(rel), f.Line, f.Snippet)
+			if f.Severity == mcp.SeverityCritical {
+				critical = append(critical, line)
+			} else {
+				warnings = append(warnings, line)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("安全扫描失败: %v", err)
+	}
+	if len(critical) > 0 {
+		return "", fmt.Errorf("安全扫描发现 %d 处高危代码，已拒绝安装：\n%s", len(critical), strings.Join(capLines(critical), "\n"))
+	}
+	if len(warnings) > 0 {
+		return fmt.Sprintf("安全扫描：%d 个脚本，%d 处警告（请确认后再使用）：\n%s", scanned, len(warnings), strings.Join(capLines(warnings), "\n")), nil
+	}
+	return fmt.Sprintf("安全扫描：%d 个脚本，未发现问题", scanned), nil
+}
+
+func capLines(lines []string) []string {
+	if len(lines) > skillFindingsShown {
+		return append(lines[:skillFindingsShown:skillFindingsShown], fmt.Sprintf("  …（另有 %d 处）", len(lines)-skillFindingsShown))
+	}
+	return lines
+}
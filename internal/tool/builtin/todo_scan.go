@@ -0,0 +1,427 @@
+package builtin
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+const (
+	todoScanTimeout   = 30 * time.Second
+	todoMaxFileSize   = 2 << 20 // skip files larger than 2MB
+	todoMaxItems      = 2000    // hard cap on collected items
+	todoMaxBlameFiles = 200     // git blame is run per file; bound the cost
+	todoListDefault   = 30      // items shown in the listing section
+	todoTextMaxRunes  = 120
+)
+
+// todoTagPattern matches a TODO/FIXME/HACK marker after a comment leader
+// (//, #, /*, *, <!--, --, ;), with an optional (owner) annotation.
+var todoTagPattern = regexp.MustCompile(`(?://|#|/\*|\*|<!--|--|;)\s*\b(TODO|FIXME|HACK)\b(?:\(([^)]*)\))?[:\s]*(.*)`)
+
+// TodoItem is one extracted TODO/FIXME/HACK comment.
+type TodoItem struct {
+	ID      string `json:"id"`   // stable key (file + tag + text), independent of line number
+	File    string `json:"file"` // workspace-relative, forward slashes
+	Line    int    `json:"line"`
+	Tag     string `json:"tag"`             // TODO | FIXME | HACK
+	Text    string `json:"text"`            // comment text after the tag
+	Owner   string `json:"owner,omitempty"` // from TODO(owner)
+	Author  string `json:"author,omitempty"`
+	AddedAt int64  `json:"added_at,omitempty"` // unix seconds from git blame author-time
+}
+
+// todoSnapshot is the persisted result of a scan, used to diff the next run.
+type todoSnapshot struct {
+	ScannedAt time.Time  `json:"scanned_at"`
+	Items     []TodoItem `json:"items"`
+}
+
+// ── todo_scan ──
+
+// TodoScanTool extracts TODO/FIXME/HACK comments across the workspace into a
+// structured list, enriches them with git blame author/age, and stores the
+// result in .omega/todos.json so the next scan reports new vs resolved items.
+type TodoScanTool struct {
+	workspaceDir string
+	now          func() time.Time
+}
+
+func NewTodoScanTool(workspaceDir string) *TodoScanTool {
+	return &TodoScanTool{workspaceDir: workspaceDir, now: time.Now}
+}
+
+func (t *TodoScanTool) Name() string { return "todo_scan" }
+func (t *TodoScanTool) Description() string {
+	return "扫描工作区中的 TODO/FIXME/HACK 注释，返回结构化列表（文件、行号、git blame 作者、存在天数），并与上次扫描对比列出新增和已解决的条目。适合回答“这个仓库还有什么没做完”。"
+}
+
+func (t *TodoScanTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "path", Type: "string", Description: "扫描目录，默认工作区根目录", Required: false},
+		tool.SchemaParam{Name: "tag", Type: "string", Description: "只显示某类标记", Required: false, Enum: []string{"TODO", "FIXME", "HACK"}},
+		tool.SchemaParam{Name: "blame", Type: "boolean", Description: "是否用 git blame 获取作者和时间（默认 true）", Required: false},
+		tool.SchemaParam{Name: "save", Type: "boolean", Description: "是否保存本次结果作为下次对比基线（默认 true；仅全量扫描时保存）", Required: false},
+	)
+}
+
+func (t *TodoScanTool) Init(_ context.Context) error { return nil }
+func (t *TodoScanTool) Close() error                 { return nil }
+
+type todoScanArgs struct {
+	Path  string `json:"path"`
+	Tag   string `json:"tag"`
+	Blame *bool  `json:"blame"`
+	Save  *bool  `json:"save"`
+}
+
+// ReadOnlyCall implements tool.ReadOnlyCaller: scans run in read-only mode
+// only when they do not save a baseline snapshot (save=false).
+func (t *TodoScanTool) ReadOnlyCall(args json.RawMessage) bool {
+	var a todoScanArgs
+	if len(args) > 0 && json.Unmarshal(args, &a) != nil {
+		return false
+	}
+	return a.Save != nil && !*a.Save
+}
+
+func (t *TodoScanTool) ReadOnlyHint() string { return "需传 save=false（不保存基线）" }
+
+func (t *TodoScanTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a todoScanArgs
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &a); err != nil {
+			return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+		}
+	}
+
+	root := t.workspaceDir
+	if a.Path != "" {
+		resolved, err := safeResolvePath(a.Path, t.workspaceDir)
+		if err != nil {
+			return tool.ToolResult{Error: err.Error()}, nil
+		}
+		root = resolved
+	}
+	if _, err := os.Stat(root); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("扫描路径不存在: %s", a.Path)}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, todoScanTimeout)
+	defer cancel()
+
+	items, truncated := t.scan(ctx, root)
+	if a.Blame == nil || *a.Blame {
+		t.blame(ctx, items)
+	}
+
+	// Only a full-workspace scan is a valid baseline; subdirectory scans would
+	// report everything outside the subtree as resolved.
+	fullScan := filepath.Clean(root) == filepath.Clean(t.workspaceDir)
+	var prev *todoSnapshot
+	if fullScan {
+		prev = t.loadSnapshot()
+		if a.Save == nil || *a.Save {
+			if err := t.saveSnapshot(items); err != nil {
+				return tool.ToolResult{Error: fmt.Sprintf("保存扫描结果失败: %v", err)}, nil
+			}
+		}
+	}
+
+	return tool.ToolResult{Output: t.format(items, prev, a.Tag, truncated)}, nil
+}
+
+// scan walks root and extracts tagged comments.
+func (t *TodoScanTool) scan(ctx context.Context, root string) ([]TodoItem, bool) {
+	var items []TodoItem
+	truncated := false
+	skip := omegaIgnore(t.workspaceDir, root)
+	_ = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if skipDirs[d.Name()] || d.Name() == ".omega" || skip(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skip(path, false) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > todoMaxFileSize {
+			return nil
+		}
+		rel, _ := filepath.Rel(t.workspaceDir, path)
+		found := scanTodoFile(path, filepath.ToSlash(rel))
+		if len(items)+len(found) > todoMaxItems {
+			found = found[:todoMaxItems-len(items)]
+			truncated = true
+		}
+		items = append(items, found...)
+		if truncated {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return items, truncated
+}
+
+// scanTodoFile extracts tagged comments from one file. Binary files are skipped.
+func scanTodoFile(path, rel string) []TodoItem {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	sample := make([]byte, 512)
+	n, _ := f.Read(sample)
+	if n == 0 || isGrepBinary(sample[:n]) {
+		return nil
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil
+	}
+
+	var items []TodoItem
+	seen := make(map[string]int) // duplicate texts in one file get distinct IDs
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		m := todoTagPattern.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[3]), "*/"))
+		text = strings.TrimSpace(strings.TrimSuffix(text, "-->"))
+		text = truncateLine(text, todoTextMaxRunes)
+		key := m[1] + "\x00" + text
+		seen[key]++
+		items = append(items, TodoItem{
+			ID:    todoID(rel, key, seen[key]),
+			File:  rel,
+			Line:  line,
+			Tag:   m[1],
+			Text:  text,
+			Owner: strings.TrimSpace(m[2]),
+		})
+	}
+	return items
+}
+
+func todoID(file, key string, occurrence int) string {
+	h := sha1.Sum([]byte(file + "\x00" + key + "\x00" + strconv.Itoa(occurrence)))
+	return hex.EncodeToString(h[:6])
+}
+
+// blame fills Author/AddedAt from git blame. Silently does nothing outside a
+// git repository or when git is unavailable.
+func (t *TodoScanTool) blame(ctx context.Context, items []TodoItem) {
+	byFile := make(map[string][]int)
+	var files []string
+	for i, it := range items {
+		if _, ok := byFile[it.File]; !ok {
+			files = append(files, it.File)
+		}
+		byFile[it.File] = append(byFile[it.File], i)
+	}
+	if len(files) == 0 {
+		return
+	}
+	check := exec.CommandContext(ctx, "git", "rev-parse", "--is-inside-work-tree")
+	check.Dir = t.workspaceDir
+	if out, err := check.Output(); err != nil || strings.TrimSpace(string(out)) != "true" {
+		return
+	}
+	if len(files) > todoMaxBlameFiles {
+		files = files[:todoMaxBlameFiles]
+	}
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		lines, ok := gitBlameLines(ctx, t.workspaceDir, file)
+		if !ok {
+			continue // e.g. untracked file
+		}
+		for _, i := range byFile[file] {
+			if b, ok := lines[items[i].Line]; ok {
+				items[i].Author = b.author
+				items[i].AddedAt = b.time
+			}
+		}
+	}
+}
+
+type blameInfo struct {
+	author string
+	time   int64
+}
+
+// gitBlameLines runs `git blame --line-porcelain` on file and returns
+// author/time per final line number. Uncommitted lines are omitted.
+func gitBlameLines(ctx context.Context, dir, file string) (map[int]blameInfo, bool) {
+	cmd := exec.CommandContext(ctx, "git", "blame", "--line-porcelain", "--", file)
+	cmd.Dir = dir
+	cmd.Env = filterEnv(os.Environ())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, false
+	}
+
+	result := make(map[int]blameInfo)
+	var cur blameInfo
+	line := 0
+	uncommitted := false
+	for _, l := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(l, "\t"):
+			if !uncommitted {
+				result[line] = cur
+			}
+		case strings.HasPrefix(l, "author "):
+			cur.author = strings.TrimPrefix(l, "author ")
+		case strings.HasPrefix(l, "author-time "):
+			cur.time, _ = strconv.ParseInt(strings.TrimPrefix(l, "author-time "), 10, 64)
+		default:
+			// Header: "<sha> <orig-line> <final-line> [<count>]"
+			if f := strings.Fields(l); len(f) >= 3 && len(f[0]) == 40 {
+				line, _ = strconv.Atoi(f[2])
+				uncommitted = strings.Trim(f[0], "0") == ""
+				cur = blameInfo{}
+			}
+		}
+	}
+	return result, true
+}
+
+func (t *TodoScanTool) snapshotPath() string {
+	return filepath.Join(t.workspaceDir, ".omega", "todos.json")
+}
+
+func (t *TodoScanTool) loadSnapshot() *todoSnapshot {
+	data, err := os.ReadFile(t.snapshotPath())
+	if err != nil {
+		return nil
+	}
+	var s todoSnapshot
+	if json.Unmarshal(data, &s) != nil {
+		return nil
+	}
+	return &s
+}
+
+func (t *TodoScanTool) saveSnapshot(items []TodoItem) error {
+	if err := os.MkdirAll(filepath.Dir(t.snapshotPath()), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(todoSnapshot{ScannedAt: t.now(), Items: items}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(t.snapshotPath(), data, 0o644)
+}
+
+// format renders counts, the diff against the previous snapshot, and the
+// oldest items first (longest-standing debt is usually the most relevant).
+func (t *TodoScanTool) format(items []TodoItem, prev *todoSnapshot, tagFilter string, truncated bool) string {
+	counts := map[string]int{}
+	for _, it := range items {
+		counts[it.Tag]++
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("共 %d 条（TODO %d / FIXME %d / HACK %d）", len(items), counts["TODO"], counts["FIXME"], counts["HACK"]))
+	if truncated {
+		sb.WriteString(fmt.Sprintf("，已达上限 %d 条", todoMaxItems))
+	}
+	sb.WriteString("\n")
+
+	if prev != nil {
+		prevIDs := make(map[string]bool, len(prev.Items))
+		for _, it := range prev.Items {
+			prevIDs[it.ID] = true
+		}
+		curIDs := make(map[string]bool, len(items))
+		var added []TodoItem
+		for _, it := range items {
+			curIDs[it.ID] = true
+			if !prevIDs[it.ID] {
+				added = append(added, it)
+			}
+		}
+		var resolved []TodoItem
+		for _, it := range prev.Items {
+			if !curIDs[it.ID] {
+				resolved = append(resolved, it)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("\n对比上次扫描（%s）：新增 %d，已解决 %d\n",
+			prev.ScannedAt.Format("2006-01-02 15:04"), len(added), len(resolved)))
+		t.writeItems(&sb, "新增", added, tagFilter, todoListDefault)
+		t.writeItems(&sb, "已解决", resolved, tagFilter, todoListDefault)
+	}
+
+	sorted := append([]TodoItem(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].AddedAt, sorted[j].AddedAt
+		if (a == 0) != (b == 0) {
+			return a != 0 // blamed items first
+		}
+		return a < b
+	})
+	t.writeItems(&sb, "列表（按存在时间从久到新）", sorted, tagFilter, todoListDefault)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func (t *TodoScanTool) writeItems(sb *strings.Builder, title string, items []TodoItem, tagFilter string, limit int) {
+	var shown []TodoItem
+	for _, it := range items {
+		if tagFilter == "" || it.Tag == tagFilter {
+			shown = append(shown, it)
+		}
+	}
+	if len(shown) == 0 {
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\n%s：\n", title))
+	for i, it := range shown {
+		if i >= limit {
+			sb.WriteString(fmt.Sprintf("  ... 另有 %d 条\n", len(shown)-limit))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  %s:%d [%s] %s", it.File, it.Line, it.Tag, it.Text))
+		var meta []string
+		if it.Owner != "" {
+			meta = append(meta, "owner="+it.Owner)
+		}
+		if it.Author != "" {
+			meta = append(meta, it.Author)
+		}
+		if it.AddedAt > 0 {
+			days := int(t.now().Sub(time.Unix(it.AddedAt, 0)).Hours() / 24)
+			meta = append(meta, fmt.Sprintf("%d 天", days))
+		}
+		if len(meta) > 0 {
+			sb.WriteString(" — " + strings.Join(meta, ", "))
+		}
+		sb.WriteString("\n")
+	}
+}
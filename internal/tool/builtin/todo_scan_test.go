@@ -0,0 +1,123 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTodoFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runTodoScan(t *testing.T, tl *TodoScanTool, args string) string {
+	t.Helper()
+	res, err := tl.Execute(context.Background(), json.RawMessage(args))
+	if err != nil || res.Error != "" {
+		t.Fatalf("Execute() err=%v result.Error=%q", err, res.Error)
+	}
+	return res.Output
+}
+
+func TestScanTodoFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTodoFile(t, dir, "a.go", "package a\n// TODO(alice): handle retries\nx := 1 // FIXME overflow\n/* HACK: temporary */\nvar todo = \"TODO not a comment\"\n")
+	writeTodoFile(t, dir, "b.py", "# TODO: port to v2\n")
+
+	items := scanTodoFile(filepath.Join(dir, "a.go"), "a.go")
+	if len(items) != 3 {
+		t.Fatalf("items = %d, want 3: %+v", len(items), items)
+	}
+	if items[0].Tag != "TODO" || items[0].Owner != "alice" || items[0].Text != "handle retries" || items[0].Line != 2 {
+		t.Errorf("item[0] = %+v", items[0])
+	}
+	if items[1].Tag != "FIXME" || items[1].Text != "overflow" {
+		t.Errorf("item[1] = %+v", items[1])
+	}
+	if items[2].Tag != "HACK" || items[2].Text != "temporary" {
+		t.Errorf("item[2] = %+v (comment closer should be stripped)", items[2])
+	}
+	if py := scanTodoFile(filepath.Join(dir, "b.py"), "b.py"); len(py) != 1 || py[0].Text != "port to v2" {
+		t.Errorf("python item = %+v", py)
+	}
+}
+
+func TestTodoScan_DiffAgainstPreviousRun(t *testing.T) {
+	dir := t.TempDir()
+	writeTodoFile(t, dir, "main.go", "// TODO: first\n// TODO: second\n")
+	writeTodoFile(t, dir, "node_modules/x.js", "// TODO: vendored, ignored\n")
+	tl := NewTodoScanTool(dir)
+
+	out := runTodoScan(t, tl, `{"blame": false}`)
+	if !strings.Contains(out, "共 2 条") || strings.Contains(out, "对比上次扫描") {
+		t.Errorf("first scan output:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".omega", "todos.json")); err != nil {
+		t.Fatalf("snapshot not saved: %v", err)
+	}
+
+	// Resolve "first", add "third"; shifting lines must not change IDs of the rest.
+	writeTodoFile(t, dir, "main.go", "package main\n\n// TODO: second\n// FIXME: third\n")
+	out = runTodoScan(t, tl, `{"blame": false}`)
+	for _, want := range []string{"新增 1，已解决 1", "main.go:4 [FIXME] third", "main.go:1 [TODO] first"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("second scan missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTodoScan_SubdirDoesNotSave(t *testing.T) {
+	dir := t.TempDir()
+	writeTodoFile(t, dir, "pkg/a.go", "// TODO: a\n")
+	out := runTodoScan(t, NewTodoScanTool(dir), `{"path": "pkg", "blame": false}`)
+	if !strings.Contains(out, "pkg/a.go:1 [TODO] a") {
+		t.Errorf("output:\n%s", out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".omega", "todos.json")); !os.IsNotExist(err) {
+		t.Error("subdirectory scans must not overwrite the baseline")
+	}
+}
+
+func TestTodoScan_GitBlame(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	writeTodoFile(t, dir, "a.go", "package a\n// TODO: blamed\n")
+	git := func(args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Alice", "GIT_AUTHOR_EMAIL=a@example.com",
+			"GIT_COMMITTER_NAME=Alice", "GIT_COMMITTER_EMAIL=a@example.com",
+			"GIT_AUTHOR_DATE=2026-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2026-01-01T00:00:00Z")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Skipf("git %v failed: %v %s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	writeTodoFile(t, dir, "b.go", "// TODO: untracked\n")
+
+	tl := NewTodoScanTool(dir)
+	tl.now = func() time.Time { return time.Date(2026, 1, 11, 0, 0, 0, 0, time.UTC) }
+	out := runTodoScan(t, tl, `{}`)
+	if !strings.Contains(out, "a.go:2 [TODO] blamed — Alice, 10 天") {
+		t.Errorf("blame info missing:\n%s", out)
+	}
+	if !strings.Contains(out, "b.go:1 [TODO] untracked\n") && !strings.HasSuffix(out, "b.go:1 [TODO] untracked") {
+		t.Errorf("untracked item should be listed without blame:\n%s", out)
+	}
+}
@@ -3,6 +3,7 @@ package builtin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -17,6 +18,7 @@ type UpdatePlanTool struct {
 	store     *plan.PlanStore
 	sessionID string
 	onUpdate  func(steps []plan.PlanStep)
+	confirm   plan.ConfirmFunc // nil = plans run without user review
 }
 
 // NewUpdatePlanTool creates a per-request instance with session context and SSE callback.
@@ -24,9 +26,20 @@ func NewUpdatePlanTool(store *plan.PlanStore, sessionID string, onUpdate func([]
 	return &UpdatePlanTool{store: store, sessionID: sessionID, onUpdate: onUpdate}
 }
 
+// WithConfirm makes every set wait for the user to confirm the plan
+// (AGENT_PLAN_CONFIRM); the user may reorder, delete or edit steps first.
+func (t *UpdatePlanTool) WithConfirm(confirm plan.ConfirmFunc) *UpdatePlanTool {
+	t.confirm = confirm
+	return t
+}
+
 func (t *UpdatePlanTool) Name() string { return "update_plan" }
 func (t *UpdatePlanTool) Description() string {
-	return "管理任务执行计划。set：设置完整计划；update：更新单步状态。多步任务(≥3步)应先 set 计划再执行"
+	desc := "管理任务执行计划。set：设置完整计划；update：更新单步状态。多步任务(≥3步)应先 set 计划再执行。步骤可附 check 完成判据（文件存在/包含文本、工具输出标记），系统会自动核对状态"
+	if t.confirm != nil {
+		desc += "。计划需用户确认：多步任务必须先 set 计划，用户确认（可能调整步骤）后再按返回的计划执行"
+	}
+	return desc
 }
 
 // InputSchema returns hand-crafted JSON Schema because BuildSchema doesn't support
@@ -47,7 +60,17 @@ func (t *UpdatePlanTool) InputSchema() json.RawMessage {
 					"type": "object",
 					"properties": {
 						"id":    {"type": "string", "description": "步骤唯一 ID"},
-						"title": {"type": "string", "description": "步骤描述"}
+						"title": {"type": "string", "description": "步骤描述"},
+						"check": {
+							"type": "object",
+							"description": "可选：完成判据，每次工具调用后自动核对并更新状态",
+							"properties": {
+								"file_exists": {"type": "string", "description": "步骤完成后应存在的文件路径"},
+								"contains":    {"type": "string", "description": "配合 file_exists：文件应包含的文本"},
+								"marker":      {"type": "string", "description": "成功的工具输出中出现即视为完成的文本，如 PASS"},
+								"tool":        {"type": "string", "description": "配合 marker：只看该工具的输出，如 shell_exec"}
+							}
+						}
 					},
 					"required": ["id", "title"]
 				}
@@ -78,7 +101,7 @@ type updatePlanArgs struct {
 	Detail    string          `json:"detail"`
 }
 
-func (t *UpdatePlanTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+func (t *UpdatePlanTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
 	var a updatePlanArgs
 	if err := json.Unmarshal(args, &a); err != nil {
 		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
@@ -89,6 +112,11 @@ func (t *UpdatePlanTool) Execute(_ context.Context, args json.RawMessage) (tool.
 		if len(a.Steps) == 0 {
 			return tool.ToolResult{Error: "set 操作需要非空 steps 列表"}, nil
 		}
+		for _, s := range a.Steps {
+			if s.Check != nil && s.Check.FileExists == "" && s.Check.Marker == "" {
+				return tool.ToolResult{Error: fmt.Sprintf("步骤 %s 的 check 需要 file_exists 或 marker", s.ID)}, nil
+			}
+		}
 		// Dedup: if the new plan is identical to the current plan, return a warning
 		// instead of positive feedback. This prevents the LLM from getting stuck in
 		// a loop of repeatedly setting the same plan.
@@ -96,6 +124,9 @@ func (t *UpdatePlanTool) Execute(_ context.Context, args json.RawMessage) (tool.
 			return tool.ToolResult{Output: "⚠️ 计划未变更（与当前计划相同）。请直接执行任务步骤，不要重复设置计划。"}, nil
 		}
 		t.store.Set(t.sessionID, a.Steps)
+		if t.confirm != nil {
+			return t.confirmPlan(ctx, a.Steps), nil
+		}
 		t.notifyUpdate()
 		return tool.ToolResult{Output: fmt.Sprintf("✅ 计划已设置，共 %d 步", len(a.Steps))}, nil
 
@@ -136,6 +167,33 @@ func (t *UpdatePlanTool) Execute(_ context.Context, args json.RawMessage) (tool.
 	}
 }
 
+// confirmPlan waits for the user to confirm the plan just set. The
+// confirmed plan replaces it; when the user changed it, the result lists
+// the steps to follow. A rejected or unconfirmed plan is removed, so the
+// agent does not work through steps the user has not approved.
+func (t *UpdatePlanTool) confirmPlan(ctx context.Context, proposed []plan.PlanStep) tool.ToolResult {
+	steps, err := t.confirm(ctx, t.store.Get(t.sessionID))
+	if err != nil {
+		t.store.Delete(t.sessionID)
+		t.notifyUpdate()
+		if errors.Is(err, plan.ErrRejected) {
+			return tool.ToolResult{Error: fmt.Sprintf("用户否决了该计划（%v）。请根据用户意见重新制定计划，或直接回答用户", err)}
+		}
+		return tool.ToolResult{Error: fmt.Sprintf("计划未获用户确认（%v）。不要执行计划中的步骤，请用 answer 列出计划并请用户确认后重新发起任务", err)}
+	}
+	t.store.Set(t.sessionID, steps)
+	t.notifyUpdate()
+	if plansEqual(steps, proposed) {
+		return tool.ToolResult{Output: fmt.Sprintf("✅ 用户已确认计划，共 %d 步，开始执行", len(steps))}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ 用户调整并确认了计划，共 %d 步。请严格按以下步骤执行（已删除的步骤不要再做）：\n", len(steps))
+	for _, s := range steps {
+		fmt.Fprintf(&sb, "- %s: %s\n", s.ID, s.Title)
+	}
+	return tool.ToolResult{Output: strings.TrimRight(sb.String(), "\n")}
+}
+
 func (t *UpdatePlanTool) notifyUpdate() {
 	if t.onUpdate != nil {
 		t.onUpdate(t.store.Get(t.sessionID))
@@ -3,6 +3,8 @@ package builtin
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
@@ -252,3 +254,73 @@ func TestUpdatePlan_UpdateDifferentStatusAllowed(t *testing.T) {
 		t.Errorf("expected done, got %q", steps[0].Status)
 	}
 }
+
+func TestUpdatePlan_SetWithCheck(t *testing.T) {
+	pt, store, _ := newTestPlanTool()
+	args := `{"operation":"set","steps":[{"id":"s1","title":"Create file","check":{"file_exists":"a.txt","contains":"hi"}}]}`
+	result, _ := pt.Execute(context.Background(), json.RawMessage(args))
+	if result.Error != "" {
+		t.Fatalf("unexpected error: %s", result.Error)
+	}
+	steps := store.Get("test-session")
+	if steps[0].Check == nil || steps[0].Check.FileExists != "a.txt" || steps[0].Check.Contains != "hi" {
+		t.Errorf("check not stored: %+v", steps[0].Check)
+	}
+
+	bad := `{"operation":"set","steps":[{"id":"s1","title":"X","check":{"tool":"shell_exec"}}]}`
+	result, _ = pt.Execute(context.Background(), json.RawMessage(bad))
+	if !strings.Contains(result.Error, "file_exists 或 marker") {
+		t.Errorf("expected check validation error, got %+v", result)
+	}
+}
+
+func TestUpdatePlan_Confirm(t *testing.T) {
+	set := json.RawMessage(`{"operation":"set","steps":[{"id":"a","title":"读配置"},{"id":"b","title":"改代码"},{"id":"c","title":"跑测试"}]}`)
+
+	// Edited: the confirmed steps replace the proposal and are listed
+	pt, store, _ := newTestPlanTool()
+	var shown []plan.PlanStep
+	pt.WithConfirm(func(_ context.Context, steps []plan.PlanStep) ([]plan.PlanStep, error) {
+		shown = steps
+		return []plan.PlanStep{{ID: "c", Title: "跑测试", Status: "pending"}, {ID: "b", Title: "改代码（只改 main.go）", Status: "pending"}}, nil
+	})
+	if !strings.Contains(pt.Description(), "计划需用户确认") {
+		t.Error("description should mention the confirmation")
+	}
+	result, _ := pt.Execute(context.Background(), set)
+	if len(shown) != 3 || result.Error != "" || !strings.Contains(result.Output, "用户调整并确认了计划，共 2 步") || !strings.Contains(result.Output, "- b: 改代码（只改 main.go）") {
+		t.Fatalf("edited plan: shown %d steps, result %+v", len(shown), result)
+	}
+	if steps := store.Get("test-session"); len(steps) != 2 || steps[0].ID != "c" {
+		t.Errorf("stored plan = %+v", steps)
+	}
+
+	// Confirmed unchanged
+	pt, _, _ = newTestPlanTool()
+	pt.WithConfirm(func(_ context.Context, steps []plan.PlanStep) ([]plan.PlanStep, error) { return steps, nil })
+	if result, _ := pt.Execute(context.Background(), set); !strings.Contains(result.Output, "用户已确认计划，共 3 步") {
+		t.Errorf("unchanged plan: %+v", result)
+	}
+
+	// Rejected: the plan is removed
+	pt, store, callbacks := newTestPlanTool()
+	pt.WithConfirm(func(context.Context, []plan.PlanStep) ([]plan.PlanStep, error) {
+		return nil, fmt.Errorf("%w: 先别改代码", plan.ErrRejected)
+	})
+	result, _ = pt.Execute(context.Background(), set)
+	if !strings.Contains(result.Error, "用户否决了该计划") || !strings.Contains(result.Error, "先别改代码") {
+		t.Errorf("rejected plan: %+v", result)
+	}
+	if store.Get("test-session") != nil || len(*callbacks) != 1 || len((*callbacks)[0]) != 0 {
+		t.Errorf("rejected plan kept: %v, callbacks %v", store.Get("test-session"), *callbacks)
+	}
+
+	// Not confirmed in time
+	pt, _, _ = newTestPlanTool()
+	pt.WithConfirm(func(context.Context, []plan.PlanStep) ([]plan.PlanStep, error) {
+		return nil, errors.New("no reply in time")
+	})
+	if result, _ := pt.Execute(context.Background(), set); !strings.Contains(result.Error, "计划未获用户确认") {
+		t.Errorf("unconfirmed plan: %+v", result)
+	}
+}
@@ -0,0 +1,160 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/util"
+	"github.com/pocketomega/pocket-omega/internal/watch"
+)
+
+// ── watch_add / watch_list / watch_remove ──
+
+// Watch tools are per-request (like update_plan): each instance is bound to
+// the session that will receive the notification.
+
+// NewWatchTools returns the three watch tools bound to sessionID.
+func NewWatchTools(mgr *watch.Manager, sessionID, workspaceDir string) []tool.Tool {
+	return []tool.Tool{
+		&WatchAddTool{mgr: mgr, sessionID: sessionID, workspaceDir: workspaceDir},
+		&WatchListTool{mgr: mgr, sessionID: sessionID},
+		&WatchRemoveTool{mgr: mgr, sessionID: sessionID},
+	}
+}
+
+// WatchAddTool registers a file content watch for the current session.
+type WatchAddTool struct {
+	mgr          *watch.Manager
+	sessionID    string
+	workspaceDir string
+}
+
+func (t *WatchAddTool) Name() string { return "watch_add" }
+func (t *WatchAddTool) Description() string {
+	return "监控文件新增内容，出现匹配的文本时通知当前会话（如“当 logs/error.log 出现 OOM 时告诉我”）。只匹配添加监控之后写入的内容；添加后即可直接回答用户，无需等待"
+}
+
+func (t *WatchAddTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "path", Type: "string", Description: "要监控的文件路径（文件可以暂不存在，但目录必须存在）", Required: true},
+		tool.SchemaParam{Name: "pattern", Type: "string", Description: "要匹配的文本（区分大小写）", Required: true},
+		tool.SchemaParam{Name: "regex", Type: "boolean", Description: "pattern 是否为正则表达式（默认 false）"},
+		tool.SchemaParam{Name: "repeat", Type: "boolean", Description: "是否持续通知（默认 false：首次触发后自动移除）"},
+	)
+}
+
+func (t *WatchAddTool) Init(_ context.Context) error { return nil }
+func (t *WatchAddTool) Close() error                 { return nil }
+
+func (t *WatchAddTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a struct {
+		Path    string `json:"path"`
+		Pattern string `json:"pattern"`
+		Regex   bool   `json:"regex"`
+		Repeat  bool   `json:"repeat"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	if strings.TrimSpace(a.Path) == "" || a.Pattern == "" {
+		return tool.ToolResult{Error: "path 和 pattern 不能为空"}, nil
+	}
+	resolved, err := safeResolvePath(a.Path, t.workspaceDir)
+	if err != nil {
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+
+	w, existing, err := t.mgr.Add(watch.Watch{
+		SessionID: t.sessionID,
+		Path:      resolved,
+		Display:   a.Path,
+		Pattern:   a.Pattern,
+		Regex:     a.Regex,
+		Repeat:    a.Repeat,
+	})
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("添加监控失败: %v", err)}, nil
+	}
+
+	mode := "首次触发后自动移除"
+	if w.Repeat {
+		mode = "持续通知"
+	}
+	out := fmt.Sprintf("✅ 已添加监控 %s：%s 出现 %q 时通知当前会话（%s）", w.ID, w.Display, w.Pattern, mode)
+	if existing != "" {
+		out += fmt.Sprintf("\n注意：文件中已有匹配内容（不会触发通知）：%s", util.TruncateRunes(existing, 200))
+	}
+	return tool.ToolResult{Output: out}, nil
+}
+
+// WatchListTool lists the current session's watches.
+type WatchListTool struct {
+	mgr       *watch.Manager
+	sessionID string
+}
+
+func (t *WatchListTool) Name() string                 { return "watch_list" }
+func (t *WatchListTool) Description() string          { return "列出当前会话的文件监控" }
+func (t *WatchListTool) InputSchema() json.RawMessage { return tool.BuildSchema() }
+func (t *WatchListTool) Init(_ context.Context) error { return nil }
+func (t *WatchListTool) Close() error                 { return nil }
+
+func (t *WatchListTool) Execute(_ context.Context, _ json.RawMessage) (tool.ToolResult, error) {
+	watches := t.mgr.List(t.sessionID)
+	if len(watches) == 0 {
+		return tool.ToolResult{Output: "当前会话没有文件监控"}, nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "当前会话共 %d 个文件监控：\n", len(watches))
+	for _, w := range watches {
+		kind := "文本"
+		if w.Regex {
+			kind = "正则"
+		}
+		mode := "一次"
+		if w.Repeat {
+			mode = "持续"
+		}
+		fmt.Fprintf(&sb, "- %s  %s  %s %q  %s  已触发 %d 次  创建于 %s\n",
+			w.ID, w.Display, kind, w.Pattern, mode, w.Triggers, w.CreatedAt.Format(time.DateTime))
+	}
+	return tool.ToolResult{Output: strings.TrimRight(sb.String(), "\n")}, nil
+}
+
+// WatchRemoveTool removes one of the current session's watches.
+type WatchRemoveTool struct {
+	mgr       *watch.Manager
+	sessionID string
+}
+
+func (t *WatchRemoveTool) Name() string        { return "watch_remove" }
+func (t *WatchRemoveTool) Description() string { return "移除当前会话的一个文件监控" }
+func (t *WatchRemoveTool) InputSchema() json.RawMessage {
+	return tool.BuildSchema(
+		tool.SchemaParam{Name: "id", Type: "string", Description: "监控 ID（见 watch_list，如 w1）", Required: true},
+	)
+}
+func (t *WatchRemoveTool) Init(_ context.Context) error { return nil }
+func (t *WatchRemoveTool) Close() error                 { return nil }
+
+func (t *WatchRemoveTool) Execute(_ context.Context, args json.RawMessage) (tool.ToolResult, error) {
+	var a struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(args, &a); err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
+	}
+	id := strings.TrimSpace(a.ID)
+	if err := t.mgr.Remove(t.sessionID, id); err != nil {
+		if errors.Is(err, watch.ErrNotFound) {
+			return tool.ToolResult{Error: fmt.Sprintf("监控 %q 不存在（可用 watch_list 查看）", id)}, nil
+		}
+		return tool.ToolResult{Error: err.Error()}, nil
+	}
+	return tool.ToolResult{Output: fmt.Sprintf("✅ 已移除监控 %s", id)}, nil
+}
@@ -0,0 +1,61 @@
+package builtin
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/watch"
+)
+
+func runWatchTool(t *testing.T, tl tool.Tool, args string) tool.ToolResult {
+	t.Helper()
+	res, err := tl.Execute(context.Background(), json.RawMessage(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestWatchTools(t *testing.T) {
+	mgr, err := watch.NewManager(watch.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mgr.Close()
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "logs"), 0o755)
+	os.WriteFile(filepath.Join(ws, "logs", "error.log"), []byte("boot: OOM\n"), 0o644)
+
+	tools := NewWatchTools(mgr, "s1", ws)
+	add, list, remove := tools[0], tools[1], tools[2]
+
+	res := runWatchTool(t, add, `{"path":"logs/error.log","pattern":"OOM"}`)
+	if res.Error != "" || !strings.Contains(res.Output, "w1") || !strings.Contains(res.Output, "boot: OOM") {
+		t.Errorf("watch_add = %+v", res)
+	}
+	if res := runWatchTool(t, add, `{"path":"../outside.log","pattern":"x"}`); !strings.Contains(res.Error, "安全限制") {
+		t.Errorf("path outside workspace should be refused, got %+v", res)
+	}
+
+	if res := runWatchTool(t, list, `{}`); !strings.Contains(res.Output, "logs/error.log") {
+		t.Errorf("watch_list = %+v", res)
+	}
+	other := NewWatchTools(mgr, "s2", ws)
+	if res := runWatchTool(t, other[1], `{}`); strings.Contains(res.Output, "w1") {
+		t.Error("watches must be scoped to their session")
+	}
+	if res := runWatchTool(t, other[2], `{"id":"w1"}`); res.Error == "" {
+		t.Error("another session must not remove the watch")
+	}
+	if res := runWatchTool(t, remove, `{"id":"w1"}`); res.Error != "" {
+		t.Errorf("watch_remove = %+v", res)
+	}
+	if len(mgr.List("")) != 0 {
+		t.Error("watch should be gone")
+	}
+}
@@ -0,0 +1,211 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	webRenderTimeout      = 20 * time.Second        // one render: new tab, load, settle and DOM dump
+	webRenderSettle       = 1500 * time.Millisecond // after the load event, for content filled in by XHR
+	webBrowserLaunch      = 10 * time.Second        // browser start-up
+	webBrowserIdle        = 5 * time.Minute         // an idle browser process is shut down
+	webRenderAutoMinRunes = 50                      // static pages with less text are rendered when a renderer is set
+	webRenderMinTime      = 5 * time.Second         // an automatic render is skipped with less time left on the call
+)
+
+// PageRenderer loads a page in a browser and returns its DOM, as HTML,
+// after the page's scripts ran.
+type PageRenderer interface {
+	Render(ctx context.Context, url string) (string, error)
+}
+
+// BrowserPool is a PageRenderer backed by one headless Chrome/Chromium
+// process, started on first use and shut down after webBrowserIdle, that
+// renders at most size pages at a time. The browser runs with a throwaway
+// profile, without images or extensions, and keeps Chrome's own sandbox
+// unless WithNoSandbox (which containers running as root need). Each render
+// is bounded by webRenderTimeout.
+type BrowserPool struct {
+	execPath  string
+	userAgent string
+	noSandbox bool
+	tabs      chan struct{} // render slots
+
+	mu      sync.Mutex
+	browser context.Context // nil = not running
+	cancel  context.CancelFunc
+	active  int
+	idle    *time.Timer
+}
+
+// NewBrowserPool creates a pool for the browser at execPath ("" = let
+// chromedp look for one) rendering at most size pages at a time.
+func NewBrowserPool(execPath string, size int) *BrowserPool {
+	return &BrowserPool{execPath: execPath, userAgent: webReaderUserAgent, tabs: make(chan struct{}, max(size, 1))}
+}
+
+// WithUserAgent sets the User-Agent the browser sends; "" keeps the default.
+func (p *BrowserPool) WithUserAgent(ua string) *BrowserPool {
+	if ua != "" {
+		p.userAgent = ua
+	}
+	return p
+}
+
+// WithNoSandbox disables Chrome's sandbox, which fails to start as root.
+func (p *BrowserPool) WithNoSandbox(on bool) *BrowserPool {
+	p.noSandbox = on
+	return p
+}
+
+// FindBrowser returns the path of an installed Chrome or Chromium, "" when
+// none is found.
+func FindBrowser() string {
+	var names []string
+	switch runtime.GOOS {
+	case "darwin":
+		names = []string{"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome", "/Applications/Chromium.app/Contents/MacOS/Chromium"}
+	case "windows":
+		names = []string{"chrome", `C:\Program Files\Google\Chrome\Application\chrome.exe`, `C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`, "msedge"}
+	default:
+		names = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "headless-shell", "chrome"}
+	}
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Check reports an error when the pool's browser is not installed.
+func (p *BrowserPool) Check() error {
+	if p.execPath == "" {
+		if FindBrowser() == "" {
+			return fmt.Errorf("未找到 Chrome/Chromium")
+		}
+		return nil
+	}
+	if _, err := exec.LookPath(p.execPath); err != nil {
+		return fmt.Errorf("浏览器不可用: %w", err)
+	}
+	return nil
+}
+
+// Render implements PageRenderer.
+func (p *BrowserPool) Render(ctx context.Context, url string) (string, error) {
+	select {
+	case p.tabs <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-p.tabs }()
+
+	browser, err := p.acquire()
+	if err != nil {
+		return "", fmt.Errorf("启动浏览器失败: %w", err)
+	}
+	defer p.release()
+
+	tab, closeTab := chromedp.NewContext(browser)
+	defer closeTab()
+	stop := context.AfterFunc(ctx, closeTab) // the caller gave up: close the tab
+	defer stop()
+	tab, cancel := context.WithTimeout(tab, webRenderTimeout)
+	defer cancel()
+
+	var html string
+	err = chromedp.Run(tab,
+		chromedp.Navigate(url),
+		chromedp.Sleep(webRenderSettle),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	return html, nil
+}
+
+// acquire returns the browser context, starting the browser when it is
+// not running (or has crashed).
+func (p *BrowserPool) acquire() (context.Context, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.idle != nil {
+		p.idle.Stop()
+	}
+	if p.browser == nil || p.browser.Err() != nil {
+		opts := append(chromedp.DefaultExecAllocatorOptions[:],
+			chromedp.UserAgent(p.userAgent),
+			chromedp.WSURLReadTimeout(webBrowserLaunch),
+			chromedp.Flag("blink-settings", "imagesEnabled=false"),
+			chromedp.Flag("mute-audio", true),
+		)
+		if p.execPath != "" {
+			opts = append(opts, chromedp.ExecPath(p.execPath))
+		}
+		if p.noSandbox {
+			opts = append(opts, chromedp.NoSandbox)
+		}
+		allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+		browser, cancelBrowser := chromedp.NewContext(allocCtx)
+		if err := chromedp.Run(browser); err != nil { // starts the process
+			cancelBrowser()
+			cancelAlloc()
+			return nil, err
+		}
+		p.browser = browser
+		p.cancel = func() { cancelBrowser(); cancelAlloc() }
+		log.Printf("[WebReader] Headless browser started")
+	}
+	p.active++
+	return p.browser, nil
+}
+
+// release ends a render and schedules the idle shutdown.
+func (p *BrowserPool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active--
+	if p.active == 0 {
+		p.idle = time.AfterFunc(webBrowserIdle, p.shutdownIdle)
+	}
+}
+
+func (p *BrowserPool) shutdownIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active == 0 && p.browser != nil {
+		p.closeLocked()
+		log.Printf("[WebReader] Headless browser stopped after %v idle", webBrowserIdle)
+	}
+}
+
+func (p *BrowserPool) closeLocked() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.browser, p.cancel = nil, nil
+}
+
+// Close stops the browser.
+func (p *BrowserPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.idle != nil {
+		p.idle.Stop()
+	}
+	p.closeLocked()
+	return nil
+}
@@ -0,0 +1,158 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// fakeRenderer returns a fixed DOM and records the rendered URLs.
+type fakeRenderer struct {
+	html string
+	err  error
+	urls []string
+}
+
+func (r *fakeRenderer) Render(_ context.Context, url string) (string, error) {
+	r.urls = append(r.urls, url)
+	return r.html, r.err
+}
+
+const renderedSPA = `<html><head><title>应用</title></head><body><div id="app"><p>` +
+	`这是由 JavaScript 渲染出来的正文内容，静态抓取时页面只有一个空的挂载点，需要浏览器执行脚本后才能看到。</p></div></body></html>`
+
+// TestWebReaderRender 验证正文为空的单页应用外壳会自动渲染、render=true 强制渲染，
+// 渲染失败时保留静态结果，未配置渲染器时 render=true 报错。
+func TestWebReaderRender(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/spa" {
+			fmt.Fprint(w, `<html><head><title>应用</title></head><body><div id="app"></div><script src="/app.js"></script></body></html>`)
+			return
+		}
+		fmt.Fprint(w, "<html><head><title>静态</title></head><body><article><p>"+strings.Repeat("静态页面的正文内容。", 20)+"</p></article></body></html>")
+	}))
+	defer server.Close()
+	args := func(path string, render bool) []byte {
+		return []byte(fmt.Sprintf(`{"url":%q,"render":%v}`, server.URL+path, render))
+	}
+
+	r := &fakeRenderer{html: renderedSPA}
+	wr := NewWebReaderTool().WithHostInterval(0).WithRenderer(r)
+	result, _ := wr.Execute(context.Background(), args("/spa", false))
+	if result.Error != "" || !strings.Contains(result.Output, "由 JavaScript 渲染出来的正文") || !strings.Contains(result.Output, "无头浏览器渲染") {
+		t.Errorf("SPA shell: %+v", result)
+	}
+
+	result, _ = wr.Execute(context.Background(), args("/static", false))
+	if len(r.urls) != 1 || !strings.Contains(result.Output, "静态页面的正文") {
+		t.Errorf("static page rendered (%v): %+v", r.urls, result)
+	}
+	result, _ = wr.Execute(context.Background(), args("/static", true))
+	if len(r.urls) != 2 || !strings.Contains(result.Output, "由 JavaScript 渲染出来的正文") {
+		t.Errorf("render=true not rendered (%v): %+v", r.urls, result)
+	}
+
+	r.err = errors.New("browser crashed")
+	result, _ = wr.Execute(context.Background(), args("/spa", false))
+	if result.Error != "" || !strings.Contains(result.Output, "未能提取到正文内容") ||
+		!strings.Contains(result.Output, "browser crashed") || !result.NoCache {
+		t.Errorf("failed auto render should keep the static page with a note: %+v", result)
+	}
+	result, _ = wr.Execute(context.Background(), args("/spa", true))
+	if !strings.Contains(result.Error, "浏览器渲染失败") {
+		t.Errorf("failed render=true: %+v", result)
+	}
+
+	result, _ = NewWebReaderTool().Execute(context.Background(), args("/spa", true))
+	if !strings.Contains(result.Error, "未启用浏览器渲染") {
+		t.Errorf("render without renderer: %+v", result)
+	}
+}
+
+// TestWebReaderRenderSkippedWithoutTime 验证静态抓取很慢、调用剩余时间不足时
+// 不再自动渲染，而是在静态结果后说明原因，且不进入缓存。
+func TestWebReaderRenderSkippedWithoutTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><title>应用</title></head><body><div id="app"></div></body></html>`)
+	}))
+	defer server.Close()
+
+	r := &fakeRenderer{html: renderedSPA}
+	wr := NewWebReaderTool().WithHostInterval(0).WithRenderer(r)
+	ctx, cancel := context.WithTimeout(context.Background(), webRenderMinTime)
+	defer cancel()
+	result, _ := wr.Execute(ctx, []byte(fmt.Sprintf(`{"url":%q}`, server.URL+"/spa")))
+	if len(r.urls) != 0 {
+		t.Errorf("rendered with too little time left: %v", r.urls)
+	}
+	if result.Error != "" || !strings.Contains(result.Output, "未用浏览器渲染") || !strings.Contains(result.Output, "render=true") || !result.NoCache {
+		t.Errorf("skipped render: %+v", result)
+	}
+
+	result, _ = wr.Execute(context.Background(), []byte(fmt.Sprintf(`{"url":%q}`, server.URL+"/spa")))
+	if len(r.urls) != 1 || !strings.Contains(result.Output, "由 JavaScript 渲染出来的正文") {
+		t.Errorf("render with time left (%v): %+v", r.urls, result)
+	}
+}
+
+func TestWebReaderSchemaOffersRenderWithRenderer(t *testing.T) {
+	if strings.Contains(string(NewWebReaderTool().InputSchema()), "render") {
+		t.Error("render parameter offered without a renderer")
+	}
+	if !strings.Contains(string(NewWebReaderTool().WithRenderer(&fakeRenderer{}).InputSchema()), `"render"`) {
+		t.Error("render parameter missing with a renderer")
+	}
+}
+
+func TestBrowserPoolRender(t *testing.T) {
+	path := FindBrowser()
+	if path == "" || testing.Short() {
+		t.Skip("no Chrome/Chromium installed")
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div id="app"></div><script>document.getElementById("app").textContent = "rendered by script"</script></body></html>`)
+	}))
+	defer server.Close()
+
+	pool := NewBrowserPool(path, 1).WithNoSandbox(true)
+	defer pool.Close()
+	page, err := pool.Render(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, "rendered by script") {
+		t.Errorf("page = %q", page)
+	}
+}
+
+func TestWebReaderInitDegradesWithoutBrowser(t *testing.T) {
+	wr := NewWebReaderTool().WithRenderer(NewBrowserPool("/nonexistent/chrome", 1))
+	err := wr.Init(context.Background())
+	if !errors.Is(err, tool.ErrDegraded) {
+		t.Fatalf("Init = %v, want degraded", err)
+	}
+	if strings.Contains(string(wr.InputSchema()), "render") {
+		t.Error("render parameter still offered after the browser check failed")
+	}
+	if err := NewWebReaderTool().WithRenderer(&fakeRenderer{}).Init(context.Background()); err != nil {
+		t.Errorf("custom renderer: %v", err)
+	}
+}
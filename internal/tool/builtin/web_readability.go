@@ -0,0 +1,157 @@
+package builtin
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Main-content selection for web_reader, after the Readability algorithm:
+// paragraphs award points to their parent and grandparent containers, the
+// containers are weighted by class/id hints and link density, and the best
+// one (plus similarly scored siblings) is kept. Short pages are returned whole.
+
+const (
+	readableMinBlockRunes = 25  // shorter blocks (captions, buttons) do not score
+	readableMinRunes      = 250 // below this the page is returned whole
+	readableSiblingRatio  = 0.2 // siblings scoring this fraction of the best are kept
+)
+
+var (
+	positiveClassRe = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|text|blog|story`)
+	negativeClassRe = regexp.MustCompile(`(?i)comment|contact|foot|masthead|promo|related|share|sidebar|sponsor|widget|advert|\bads?\b|breadcrumb|menu|nav|recommend|social|banner|popup|cookie`)
+)
+
+// voidElements have no end tag and never hold text.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// domNode is an open or closed element as seen by the streaming tokenizer.
+type domNode struct {
+	tag       string
+	parent    *domNode
+	weight    float64 // tag and class/id hints
+	score     float64 // points awarded by descendant paragraphs
+	textRunes int     // extracted text below this node
+	linkRunes int     // of which inside <a>
+}
+
+// textBlock is one line of extracted text and the element it started in.
+type textBlock struct {
+	node *domNode
+	text strings.Builder
+}
+
+// tagWeight is the content prior of an element.
+func tagWeight(tag string) float64 {
+	switch tag {
+	case "article", "main":
+		return 10
+	case "div", "section":
+		return 5
+	case "pre", "td", "blockquote":
+		return 3
+	case "ol", "ul", "dl", "dd", "dt", "li", "address":
+		return -3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		return -5
+	}
+	return 0
+}
+
+// classWeight scores a class or id attribute value.
+func classWeight(v string) float64 {
+	var w float64
+	if negativeClassRe.MatchString(v) {
+		w -= 25
+	}
+	if positiveClassRe.MatchString(v) {
+		w += 25
+	}
+	return w
+}
+
+// finalScore is the container score adjusted for hints and link density:
+// a block of mostly link text is navigation, whatever its length.
+func (n *domNode) finalScore() float64 {
+	s := n.score + n.weight
+	if n.textRunes > 0 {
+		s *= 1 - float64(n.linkRunes)/float64(n.textRunes)
+	}
+	return s
+}
+
+// selectReadable joins the blocks of the page's main content, one per line.
+// Pages too short to judge are returned whole.
+func selectReadable(blocks []*textBlock) string {
+	var candidates []*domNode
+	scored := make(map[*domNode]bool)
+	award := func(n *domNode, points float64) {
+		if n == nil {
+			return
+		}
+		if !scored[n] {
+			scored[n] = true
+			candidates = append(candidates, n)
+		}
+		n.score += points
+	}
+	for _, b := range blocks {
+		text := b.text.String()
+		n := utf8.RuneCountInString(strings.TrimSpace(text))
+		if n < readableMinBlockRunes {
+			continue
+		}
+		points := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")+strings.Count(text, "、")) + min(float64(n)/100, 3)
+		// Text directly inside a container scores the container itself;
+		// paragraph-like elements score their parent.
+		container := b.node
+		if isBlockElement(container.tag) && container.tag != "div" && container.tag != "section" &&
+			container.tag != "article" && container.tag != "main" && container.parent != nil {
+			container = container.parent
+		}
+		award(container, points)
+		award(container.parent, points/2)
+	}
+
+	var best *domNode
+	for _, c := range candidates {
+		if best == nil || c.finalScore() > best.finalScore() {
+			best = c
+		}
+	}
+	keep := make(map[*domNode]bool)
+	if best != nil && best.textRunes >= readableMinRunes && best.parent != nil {
+		keep[best] = true
+		threshold := max(10, best.finalScore()*readableSiblingRatio)
+		for _, c := range candidates {
+			if c.parent == best.parent && c.finalScore() >= threshold {
+				keep[c] = true
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if len(keep) > 0 && !hasAncestorIn(b.node, keep) {
+			continue
+		}
+		if line := strings.TrimSpace(b.text.String()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hasAncestorIn reports whether n or one of its ancestors is in set.
+func hasAncestorIn(n *domNode, set map[*domNode]bool) bool {
+	for ; n != nil; n = n.parent {
+		if set[n] {
+			return true
+		}
+	}
+	return false
+}
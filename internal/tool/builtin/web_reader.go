@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	neturl "net/url"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"golang.org/x/net/html"
 	"golang.org/x/net/html/charset"
@@ -17,17 +22,19 @@ import (
 )
 
 const (
-	webReaderTimeout      = 15 * time.Second
-	webReaderMaxBody      = 2 << 20 // 2MB
-	webReaderMaxRunes     = 8000    // 截断到 8000 字符，避免 LLM context 溢出
+	webReaderTimeout      = 15 * time.Second // whole fetch; a page still streaming is cut off, not failed
+	webReaderMaxBody      = 2 << 20          // 2MB; larger pages are cut off
+	webReaderMaxRunes     = 8000             // 截断到 8000 字符，避免 LLM context 溢出
 	webReaderUserAgent    = "PocketOmega/0.2 (Web Reader Bot)"
 	webReaderMaxRedirects = 10
+	webReaderHeadTimeout  = 5 * time.Second // cache validation request
 )
 
 // httpClient is a dedicated HTTP client for WebReaderTool.
-// Safer than http.DefaultClient: explicit timeout + redirect limit.
+// Safer than http.DefaultClient: explicit redirect limit. The timeout is a
+// request context deadline rather than Client.Timeout, so that a body cut
+// off by it keeps what was read.
 var httpClient = &http.Client{
-	Timeout: webReaderTimeout,
 	CheckRedirect: func(req *http.Request, via []*http.Request) error {
 		if len(via) >= webReaderMaxRedirects {
 			return fmt.Errorf("超过最大重定向次数 (%d)", webReaderMaxRedirects)
@@ -36,34 +43,127 @@ var httpClient = &http.Client{
 	},
 }
 
-// WebReaderTool reads and extracts text content from web pages.
-type WebReaderTool struct{}
+// WebReaderTool reads and extracts text content from web pages. It
+// follows robots.txt and spaces out requests to the same host (see
+// politeness).
+type WebReaderTool struct {
+	pages        *PageStore // nil = truncate to webReaderMaxRunes; otherwise paginate via fetch_more
+	timeout      time.Duration
+	maxBody      int64
+	userAgent    string
+	robots       bool          // honour robots.txt
+	hostInterval time.Duration // minimum gap between requests to one host; 0 = none
+	renderer     PageRenderer  // nil = no JavaScript rendering
+
+	mu    sync.Mutex
+	hosts map[string]*webHost
+}
+
+func NewWebReaderTool() *WebReaderTool {
+	return &WebReaderTool{
+		timeout:      webReaderTimeout,
+		maxBody:      webReaderMaxBody,
+		userAgent:    webReaderUserAgent,
+		robots:       true,
+		hostInterval: webReaderHostInterval,
+	}
+}
+
+// WithUserAgent sets the User-Agent header; its product token ("Name" of
+// "Name/1.0 ...") selects the robots.txt group. "" keeps the default.
+func (t *WebReaderTool) WithUserAgent(ua string) *WebReaderTool {
+	if ua = strings.TrimSpace(ua); ua != "" {
+		t.userAgent = ua
+	}
+	return t
+}
+
+// WithRobots enables or disables robots.txt checks (enabled by default).
+func (t *WebReaderTool) WithRobots(enabled bool) *WebReaderTool {
+	t.robots = enabled
+	return t
+}
+
+// WithHostInterval sets the minimum gap between requests to one host;
+// 0 disables it. A longer robots.txt Crawl-delay takes precedence.
+func (t *WebReaderTool) WithHostInterval(d time.Duration) *WebReaderTool {
+	t.hostInterval = max(d, 0)
+	return t
+}
+
+// WithRenderer enables rendering pages in a browser: on request
+// (render=true) and for HTML pages whose static text is near empty, such as
+// single-page app shells.
+func (t *WebReaderTool) WithRenderer(r PageRenderer) *WebReaderTool {
+	t.renderer = r
+	return t
+}
 
-func NewWebReaderTool() *WebReaderTool { return &WebReaderTool{} }
+// WithMaxBody sets how many bytes of a page are read; larger pages are
+// cut off with a note. Non-positive values keep the default.
+func (t *WebReaderTool) WithMaxBody(n int64) *WebReaderTool {
+	if n > 0 {
+		t.maxBody = n
+	}
+	return t
+}
+
+// WithPageStore enables paginated page content instead of truncation.
+func (t *WebReaderTool) WithPageStore(pages *PageStore) *WebReaderTool {
+	t.pages = pages
+	return t
+}
 
 func (t *WebReaderTool) Name() string { return "web_reader" }
 func (t *WebReaderTool) Description() string {
-	return "读取指定 URL 的网页正文内容。适用于阅读文章、文档、新闻页面等。返回页面标题和主要文字内容。"
+	return "读取指定 URL 的网页正文内容。适用于阅读文章、文档、新闻页面等。返回页面标题和主要文字内容；超大或加载过慢的页面返回已读取的部分并标注。"
 }
 
 func (t *WebReaderTool) InputSchema() json.RawMessage {
-	return tool.BuildSchema(
-		tool.SchemaParam{
-			Name:        "url",
-			Type:        "string",
-			Description: "要读取的网页 URL（必须以 http:// 或 https:// 开头）",
-			Required:    true,
-		},
-	)
+	params := []tool.SchemaParam{{
+		Name:        "url",
+		Type:        "string",
+		Description: "要读取的网页 URL（必须以 http:// 或 https:// 开头）",
+		Required:    true,
+	}}
+	if t.renderer != nil {
+		params = append(params, tool.SchemaParam{
+			Name:        "render",
+			Type:        "boolean",
+			Description: "用无头浏览器执行页面的 JavaScript 后再读取，适用于单页应用等直接读取为空的页面（较慢；正文为空时会自动渲染）",
+			Required:    false,
+		})
+	}
+	return tool.BuildSchema(params...)
 }
 
-func (t *WebReaderTool) Init(_ context.Context) error { return nil }
-func (t *WebReaderTool) Close() error                 { return nil }
+// Init checks the renderer's browser. Without one web_reader still reads
+// static pages: the renderer is dropped and the tool reported degraded.
+func (t *WebReaderTool) Init(_ context.Context) error {
+	pool, ok := t.renderer.(*BrowserPool)
+	if !ok {
+		return nil
+	}
+	if err := pool.Check(); err != nil {
+		t.renderer = nil
+		return fmt.Errorf("%w: JavaScript 渲染已关闭: %v", tool.ErrDegraded, err)
+	}
+	return nil
+}
+
+// Close stops the renderer's browser, if any.
+func (t *WebReaderTool) Close() error {
+	if c, ok := t.renderer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
 
 // Execute fetches the given URL, extracts the page title and main text content.
 func (t *WebReaderTool) Execute(ctx context.Context, args json.RawMessage) (tool.ToolResult, error) {
 	var a struct {
-		URL string `json:"url"`
+		URL    string `json:"url"`
+		Render bool   `json:"render"`
 	}
 	if err := json.Unmarshal(args, &a); err != nil {
 		return tool.ToolResult{Error: fmt.Sprintf("参数解析失败: %v", err)}, nil
@@ -75,13 +175,28 @@ func (t *WebReaderTool) Execute(ctx context.Context, args json.RawMessage) (tool
 	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
 		return tool.ToolResult{Error: "URL 必须以 http:// 或 https:// 开头"}, nil
 	}
+	u, err := neturl.Parse(url)
+	if err != nil || u.Host == "" {
+		return tool.ToolResult{Error: fmt.Sprintf("URL 无效: %s", url)}, nil
+	}
+	if msg := t.politeness(ctx, u); msg != "" {
+		return tool.ToolResult{Error: msg}, nil
+	}
+	if a.Render {
+		if t.renderer == nil {
+			return tool.ToolResult{Error: "未启用浏览器渲染（TOOL_WEB_READER_BROWSER），请去掉 render 参数"}, nil
+		}
+		return t.rendered(ctx, url), nil
+	}
 
-	// HTTP request using custom client (explicit timeout + redirect limit)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	// HTTP request using custom client (redirect limit) under the fetch deadline
+	fetchCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(fetchCtx, http.MethodGet, url, nil)
 	if err != nil {
 		return tool.ToolResult{Error: fmt.Sprintf("请求创建失败: %v", err)}, nil
 	}
-	req.Header.Set("User-Agent", webReaderUserAgent)
+	req.Header.Set("User-Agent", t.userAgent)
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 
 	resp, err := httpClient.Do(req)
@@ -96,8 +211,9 @@ func (t *WebReaderTool) Execute(ctx context.Context, args json.RawMessage) (tool
 		return tool.ToolResult{Error: fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status)}, nil
 	}
 
-	// Limit body read size
-	limitedReader := io.LimitReader(resp.Body, webReaderMaxBody)
+	// Stream the body within the size and time budget; hitting either ends
+	// the stream early and the content read so far is returned as partial
+	limitedReader := &budgetReader{r: resp.Body, remaining: t.maxBody, ctx: ctx, timeout: t.timeout}
 
 	// Content-Type dispatch: only parse HTML through extractContent
 	contentType := resp.Header.Get("Content-Type")
@@ -108,13 +224,13 @@ func (t *WebReaderTool) Execute(ctx context.Context, args json.RawMessage) (tool
 		raw, _ := io.ReadAll(limitedReader)
 		var prettyBuf bytes.Buffer
 		if err := json.Indent(&prettyBuf, raw, "", "  "); err == nil {
-			return tool.ToolResult{Output: truncateContent(prettyBuf.String())}, nil
+			return t.result(prettyBuf.String(), limitedReader), nil
 		}
-		return tool.ToolResult{Output: truncateContent(string(raw))}, nil
+		return t.result(string(raw)+limitedReader.partialNote(), limitedReader), nil
 	}
 	if strings.Contains(ctLower, "text/plain") {
 		raw, _ := io.ReadAll(limitedReader)
-		return tool.ToolResult{Output: truncateContent(string(raw))}, nil
+		return t.result(string(raw)+limitedReader.partialNote(), limitedReader), nil
 	}
 	if !strings.Contains(ctLower, "text/html") && !strings.Contains(ctLower, "application/xhtml") {
 		// Unsupported content type (PDF, image, etc.)
@@ -139,7 +255,55 @@ func (t *WebReaderTool) Execute(ctx context.Context, args json.RawMessage) (tool
 		return tool.ToolResult{Error: fmt.Sprintf("内容解析失败: %v", err)}, nil
 	}
 
-	// Format output
+	// Near-empty text is usually a page built by JavaScript: render it
+	// (another request to the host, but no politeness wait: the browser
+	// loads the page's resources anyway). The render shares the call's
+	// deadline with the static fetch, so it is skipped when too little time
+	// is left. A skipped or failed render keeps the static result, with a
+	// note pointing to render=true, and out of the cache.
+	var renderNote string
+	if t.renderer != nil && limitedReader.stopped == "" && utf8.RuneCountInString(content) < webRenderAutoMinRunes {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < webRenderMinTime {
+			log.Printf("[WebReader] Not rendering %s: %v left on the call", url, time.Until(deadline).Round(time.Millisecond))
+			renderNote = "\n\n（正文很少，页面可能由 JavaScript 生成；静态抓取用尽了本次调用的时间，未用浏览器渲染，可用 render=true 重试）"
+		} else {
+			result := t.rendered(ctx, url)
+			if result.Error == "" {
+				return result, nil
+			}
+			log.Printf("[WebReader] Rendering %s failed, keeping the static page: %s", url, result.Error)
+			renderNote = fmt.Sprintf("\n\n（正文很少，页面可能由 JavaScript 生成；%s，以上为静态抓取结果，可用 render=true 重试）", result.Error)
+		}
+	}
+
+	partial := limitedReader.stopped != "" || renderNote != ""
+	return t.pageResult(title, description, content, limitedReader.partialNote()+renderNote, partial), nil
+}
+
+// rendered reads url through the renderer; pages larger than maxBody are
+// cut off with a note.
+func (t *WebReaderTool) rendered(ctx context.Context, url string) tool.ToolResult {
+	page, err := t.renderer.Render(ctx, url)
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("浏览器渲染失败: %v", err)}
+	}
+	note := "\n\n（已用无头浏览器渲染）"
+	partial := int64(len(page)) > t.maxBody
+	if partial {
+		page = page[:t.maxBody]
+		note = fmt.Sprintf("\n\n...(部分内容：超过 %d KB 大小上限，已用无头浏览器渲染)", t.maxBody/1024)
+	}
+	title, description, content, err := extractContent(strings.NewReader(page))
+	if err != nil {
+		return tool.ToolResult{Error: fmt.Sprintf("内容解析失败: %v", err)}
+	}
+	return t.pageResult(title, description, content, note, partial)
+}
+
+// pageResult formats the extracted parts of an HTML page; note (a partial
+// read or skipped render marker) follows the content. Partial pages are kept out of the
+// result cache.
+func (t *WebReaderTool) pageResult(title, description, content, note string, partial bool) tool.ToolResult {
 	var sb strings.Builder
 	if title != "" {
 		sb.WriteString(fmt.Sprintf("📄 标题：%s\n\n", title))
@@ -149,11 +313,126 @@ func (t *WebReaderTool) Execute(ctx context.Context, args json.RawMessage) (tool
 	}
 	if content == "" {
 		sb.WriteString("⚠️ 未能提取到正文内容。")
+		sb.WriteString(note)
+		return tool.ToolResult{Output: sb.String(), NoCache: partial}
+	}
+	sb.WriteString(content)
+	sb.WriteString(note)
+	out := t.limitContent(sb.String())
+	return tool.ToolResult{Output: out, NoCache: partial || out != sb.String()}
+}
+
+// budgetReader streams a response body until it ends or the size or time
+// budget runs out. Running out ends the stream with io.EOF and records why,
+// so readers keep the partial content instead of failing. Cancellation of
+// the caller's context (e.g. the user stopped the run) is still an error.
+type budgetReader struct {
+	r         io.Reader
+	remaining int64
+	read      int64
+	ctx       context.Context // caller's context, without the fetch deadline
+	timeout   time.Duration
+	stopped   string // why the body was cut off; "" = read completely
+}
+
+func (b *budgetReader) Read(p []byte) (int, error) {
+	if b.stopped != "" {
+		return 0, io.EOF
+	}
+	if b.remaining <= 0 {
+		// Probe for one more byte: a body of exactly the limit is complete.
+		var probe [1]byte
+		if n, _ := b.r.Read(probe[:]); n > 0 {
+			b.stopped = fmt.Sprintf("超过 %d KB 大小上限", (b.read+1023)/1024)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.r.Read(p)
+	b.read += int64(n)
+	b.remaining -= int64(n)
+	if err == nil || err == io.EOF {
+		return n, err
+	}
+	if b.ctx.Err() != nil {
+		return n, err
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		b.stopped = fmt.Sprintf("读取超时 (%v)", b.timeout)
 	} else {
-		sb.WriteString(truncateContent(content))
+		b.stopped = fmt.Sprintf("连接中断: %v", err)
 	}
+	return n, io.EOF
+}
 
-	return tool.ToolResult{Output: sb.String()}, nil
+// partialNote is the continuation marker appended to content cut off by the
+// budget; "" when the body was read completely.
+func (b *budgetReader) partialNote() string {
+	if b.stopped == "" {
+		return ""
+	}
+	return fmt.Sprintf("\n\n...(部分内容：%s，已读取 %d KB，页面其余部分未读取)", b.stopped, b.read/1024)
+}
+
+// result builds the tool result of the extracted content. Partial reads
+// and paginated content (whose fetch_more token is single-use) are kept
+// out of the result cache.
+func (t *WebReaderTool) result(content string, body *budgetReader) tool.ToolResult {
+	out := t.limitContent(content)
+	return tool.ToolResult{Output: out, NoCache: body.stopped != "" || out != content}
+}
+
+// CacheFingerprint implements tool.Cacheable with the page's ETag or
+// Last-Modified header from a HEAD request; servers that send neither are
+// cached for the TTL alone. A failed HEAD request skips the cache, and
+// pages robots.txt disallows get no HEAD request (Execute refuses them).
+func (t *WebReaderTool) CacheFingerprint(ctx context.Context, args json.RawMessage) (string, bool) {
+	var a struct {
+		URL string `json:"url"`
+	}
+	if json.Unmarshal(args, &a) != nil {
+		return "", false
+	}
+	url := strings.TrimSpace(a.URL)
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "", false
+	}
+	if u, err := neturl.Parse(url); err != nil || (t.robots && !t.robotsFor(ctx, u).allowed(robotsPath(u))) {
+		return "", false
+	}
+	headCtx, cancel := context.WithTimeout(ctx, webReaderHeadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(headCtx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", false
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", false
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return "etag:" + etag, true
+	}
+	if lm := resp.Header.Get("Last-Modified"); lm != "" {
+		return "modified:" + lm, true
+	}
+	return "", true
+}
+
+// limitContent paginates content when a PageStore is configured,
+// otherwise truncates it to webReaderMaxRunes.
+func (t *WebReaderTool) limitContent(content string) string {
+	if t.pages != nil {
+		return t.pages.Paginate(t.Name(), content)
+	}
+	return truncateContent(content)
 }
 
 // truncateContent limits content to webReaderMaxRunes to avoid LLM context overflow.
@@ -168,13 +447,22 @@ func truncateContent(content string) string {
 // extractContent parses HTML and extracts the <title>, <meta description>, and body text.
 // It skips non-content elements like <script>, <style>, <nav>, <footer>, <form>.
 // <header> is only skipped at page level (depth 0), preserved inside <article>.
+//
+// The document is tokenized as it streams in, so a reader that stops early
+// (size or time limit) still yields everything extracted up to that point.
+// The body text is narrowed to the main content block by selectReadable.
 func extractContent(r io.Reader) (title string, description string, content string, err error) {
 	tokenizer := html.NewTokenizer(r)
 
-	var sb strings.Builder
 	var inTitle, inSkip bool
 	skipDepth := 0
 	articleDepth := 0 // tracks nesting inside <article>
+	linkDepth := 0    // tracks nesting inside <a>
+
+	root := &domNode{tag: "#root"}
+	stack := []*domNode{root} // open elements, innermost last
+	var blocks []*textBlock
+	var cur *textBlock // block receiving text; nil after a block boundary
 
 	// Tags to skip (non-content areas)
 	skipTags := map[string]bool{
@@ -188,7 +476,7 @@ func extractContent(r io.Reader) (title string, description string, content stri
 		switch tt {
 		case html.ErrorToken:
 			parseErr := tokenizer.Err()
-			result := collapseBlankLines(strings.TrimSpace(sb.String()))
+			result := collapseBlankLines(strings.TrimSpace(selectReadable(blocks)))
 			if parseErr == io.EOF {
 				return title, description, result, nil
 			}
@@ -224,16 +512,36 @@ func extractContent(r io.Reader) (title string, description string, content stri
 				continue
 			}
 
-			if tt == html.SelfClosingTagToken {
+			if tt == html.SelfClosingTagToken || voidElements[tagName] {
+				if tagName == "br" || tagName == "hr" {
+					cur = nil
+				}
 				continue
 			}
 
+			node := &domNode{tag: tagName, parent: stack[len(stack)-1], weight: tagWeight(tagName)}
+			if hasAttr {
+				for {
+					key, val, more := tokenizer.TagAttr()
+					if k := string(key); k == "class" || k == "id" {
+						node.weight += classWeight(string(val))
+					}
+					if !more {
+						break
+					}
+				}
+			}
+			stack = append(stack, node)
+
 			if tagName == "title" {
 				inTitle = true
 			}
 			if tagName == "article" {
 				articleDepth++
 			}
+			if tagName == "a" {
+				linkDepth++
+			}
 			// Skip <header> only at page level (not inside <article>)
 			if tagName == "header" && articleDepth == 0 {
 				inSkip = true
@@ -243,18 +551,15 @@ func extractContent(r io.Reader) (title string, description string, content stri
 				inSkip = true
 				skipDepth++
 			}
-			// Add newline before block-level elements, but only outside skip zones
-			if !inSkip && isBlockElement(tagName) && sb.Len() > 0 {
-				s := sb.String()
-				if s[len(s)-1] != '\n' {
-					sb.WriteString("\n")
-				}
+			// Start a new line at block-level elements
+			if isBlockElement(tagName) {
+				cur = nil
 			}
 			// Add cell separator for table cells (only outside skip zones)
-			if !inSkip && (tagName == "td" || tagName == "th") && sb.Len() > 0 {
-				s := sb.String()
-				if s[len(s)-1] != '\n' && s[len(s)-1] != '|' {
-					sb.WriteString(" | ")
+			if !inSkip && (tagName == "td" || tagName == "th") && cur != nil {
+				s := cur.text.String()
+				if s[len(s)-1] != '|' {
+					cur.text.WriteString(" | ")
 				}
 			}
 
@@ -262,12 +567,25 @@ func extractContent(r io.Reader) (title string, description string, content stri
 			tn, _ := tokenizer.TagName()
 			tagName := string(tn)
 
+			// Close the innermost matching element; stray end tags are ignored
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == tagName {
+					stack = stack[:i]
+					break
+				}
+			}
 			if tagName == "title" {
 				inTitle = false
 			}
 			if tagName == "article" && articleDepth > 0 {
 				articleDepth--
 			}
+			if tagName == "a" && linkDepth > 0 {
+				linkDepth--
+			}
+			if isBlockElement(tagName) {
+				cur = nil
+			}
 			// Match closing for page-level <header>
 			isPageHeader := tagName == "header" && articleDepth == 0
 			if (skipTags[tagName] || isPageHeader) && skipDepth > 0 {
@@ -287,8 +605,19 @@ func extractContent(r io.Reader) (title string, description string, content stri
 				continue
 			}
 			if !inSkip {
-				sb.WriteString(text)
-				sb.WriteString(" ")
+				if cur == nil {
+					cur = &textBlock{node: stack[len(stack)-1]}
+					blocks = append(blocks, cur)
+				}
+				cur.text.WriteString(text)
+				cur.text.WriteString(" ")
+				n := utf8.RuneCountInString(text)
+				for p := cur.node; p != nil; p = p.parent {
+					p.textRunes += n
+					if linkDepth > 0 {
+						p.linkRunes += n
+					}
+				}
 			}
 		}
 	}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestExtractContentBasic(t *testing.T) {
@@ -493,3 +494,113 @@ func TestWebReaderHTMLEndToEnd(t *testing.T) {
 		}
 	}
 }
+
+// TestExtractContentReadable 验证正文块识别：长段落所在容器胜出，
+// 链接密集的侧栏与推荐列表被剔除。
+func TestExtractContentReadable(t *testing.T) {
+	para := strings.Repeat("正文段落包含足够的文字，用于评分，并且带有若干逗号、顿号。", 4)
+	htmlStr := `<html><body>
+	<div class="menu"><a href="/a">首页链接一二三四五六七八九十一二三四五六七八九十一二三四五</a></div>
+	<div class="post-content">
+		<h2>小节标题</h2>
+		<p>` + para + `</p><p>` + para + `</p><p>` + para + `</p>
+	</div>
+	<div class="related"><p>` + strings.Repeat("相关推荐文章标题", 5) + `</p></div>
+	</body></html>`
+
+	_, _, content, err := extractContent(strings.NewReader(htmlStr))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(content, "小节标题") || strings.Count(content, "正文段落") != 12 {
+		t.Errorf("main content incomplete: %q", content)
+	}
+	if strings.Contains(content, "首页链接") || strings.Contains(content, "相关推荐") {
+		t.Errorf("boilerplate should be dropped: %q", content)
+	}
+}
+
+// TestWebReaderPartialContent 验证超过大小或时间上限时返回已提取的部分正文与续读标记，
+// 而不是整体失败；调用方取消仍然报错。
+func TestWebReaderPartialContent(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><head><title>慢页面</title></head><body><p>先到达的正文</p>")
+		if r.URL.Path == "/big" {
+			fmt.Fprint(w, strings.Repeat("<p>填充内容</p>", 2000))
+			return
+		}
+		w.(http.Flusher).Flush()
+		select { // never finishes the page
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	wr := NewWebReaderTool()
+	wr.timeout = 300 * time.Millisecond
+	result, _ := wr.Execute(context.Background(), []byte(fmt.Sprintf(`{"url":%q}`, server.URL+"/slow")))
+	if result.Error != "" || !strings.Contains(result.Output, "先到达的正文") || !strings.Contains(result.Output, "读取超时") {
+		t.Errorf("slow page: %+v", result)
+	}
+	if !result.NoCache {
+		t.Error("partial page should not be cached")
+	}
+
+	wr = NewWebReaderTool()
+	wr.maxBody = 4096
+	result, _ = wr.Execute(context.Background(), []byte(fmt.Sprintf(`{"url":%q}`, server.URL+"/big")))
+	if result.Error != "" || !strings.Contains(result.Output, "先到达的正文") || !strings.Contains(result.Output, "大小上限") {
+		t.Errorf("big page: %+v", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	result, _ = NewWebReaderTool().Execute(ctx, []byte(fmt.Sprintf(`{"url":%q}`, server.URL+"/slow")))
+	if result.Error == "" {
+		t.Errorf("cancelled read should fail, got: %q", result.Output)
+	}
+}
+
+// TestWebReaderCacheFingerprint 验证缓存指纹取自 ETag / Last-Modified。
+func TestWebReaderCacheFingerprint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		switch r.URL.Path {
+		case "/etag":
+			w.Header().Set("ETag", `"v1"`)
+		case "/modified":
+			w.Header().Set("Last-Modified", "Sat, 17 Oct 2026 08:00:00 GMT")
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	wr := NewWebReaderTool()
+	for path, want := range map[string]string{"/etag": `etag:"v1"`, "/modified": "modified:Sat, 17 Oct 2026 08:00:00 GMT", "/plain": ""} {
+		fp, ok := wr.CacheFingerprint(context.Background(), []byte(fmt.Sprintf(`{"url":%q}`, server.URL+path)))
+		if !ok || fp != want {
+			t.Errorf("%s: fingerprint = %q, %v; want %q", path, fp, ok, want)
+		}
+	}
+	if _, ok := wr.CacheFingerprint(context.Background(), []byte(fmt.Sprintf(`{"url":%q}`, server.URL+"/missing"))); ok {
+		t.Error("404 page should skip the cache")
+	}
+	if _, ok := wr.CacheFingerprint(context.Background(), []byte(`{"url":"ftp://x"}`)); ok {
+		t.Error("invalid URL should skip the cache")
+	}
+}
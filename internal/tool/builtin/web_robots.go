@@ -0,0 +1,261 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+const (
+	webReaderHostInterval = time.Second      // default gap between requests to one host
+	webReaderMaxHostWait  = 10 * time.Second // longer waits for a host are refused, not slept
+	robotsTimeout         = 5 * time.Second
+	robotsMaxBody         = 512 << 10
+	robotsTTL             = time.Hour
+	robotsRetryTTL        = 5 * time.Minute // unreachable robots.txt is retried sooner
+	webReaderMaxHosts     = 1024            // host table size before it is reset
+)
+
+// webHost is the crawl state of one host: its robots.txt rules and when
+// the next request may start.
+type webHost struct {
+	rules   *robotsRules // nil = not fetched yet
+	expires time.Time    // when rules are fetched again
+	next    time.Time
+}
+
+// robotsRules are the robots.txt rules that apply to our user agent.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// politeness applies WebReaderTool's crawl etiquette before a request to
+// u: it refuses paths robots.txt disallows for our user agent, then waits
+// for the host's turn (the larger of the configured interval and the
+// site's Crawl-delay). Returns the error message for the tool result, ""
+// when the request may go ahead.
+func (t *WebReaderTool) politeness(ctx context.Context, u *neturl.URL) string {
+	delay := t.hostInterval
+	if t.robots {
+		rules := t.robotsFor(ctx, u)
+		if !rules.allowed(robotsPath(u)) {
+			return fmt.Sprintf("%s 的 robots.txt 禁止抓取该页面，请改用其他来源", u.Host)
+		}
+		delay = max(delay, rules.crawlDelay)
+	}
+	if delay <= 0 {
+		return ""
+	}
+
+	t.mu.Lock()
+	h := t.host(u.Host)
+	now := time.Now()
+	start := now
+	if h.next.After(now) {
+		start = h.next
+	}
+	wait := start.Sub(now)
+	if wait > webReaderMaxHostWait {
+		t.mu.Unlock()
+		return fmt.Sprintf("访问 %s 过于频繁（站点要求间隔 %v），请 %d 秒后再试", u.Host, delay, int(wait.Seconds())+1)
+	}
+	h.next = start.Add(delay)
+	t.mu.Unlock()
+
+	if wait <= 0 {
+		return ""
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return ""
+	case <-ctx.Done():
+		return fmt.Sprintf("等待访问 %s 时被取消: %v", u.Host, ctx.Err())
+	}
+}
+
+// host returns the state of a host, creating it. Callers hold t.mu.
+func (t *WebReaderTool) host(name string) *webHost {
+	if t.hosts == nil || len(t.hosts) >= webReaderMaxHosts {
+		t.hosts = make(map[string]*webHost)
+	}
+	h := t.hosts[name]
+	if h == nil {
+		h = &webHost{}
+		t.hosts[name] = h
+	}
+	return h
+}
+
+// robotsFor returns the rules of u's host, fetching robots.txt when they
+// are not cached. A missing robots.txt (4xx) allows everything; so does
+// an unreachable one, which is retried after robotsRetryTTL.
+func (t *WebReaderTool) robotsFor(ctx context.Context, u *neturl.URL) robotsRules {
+	t.mu.Lock()
+	h := t.host(u.Host)
+	if h.rules != nil && time.Now().Before(h.expires) {
+		rules := *h.rules
+		t.mu.Unlock()
+		return rules
+	}
+	t.mu.Unlock()
+
+	rules, ttl := t.fetchRobots(ctx, u)
+	t.mu.Lock()
+	h = t.host(u.Host)
+	h.rules, h.expires = &rules, time.Now().Add(ttl)
+	t.mu.Unlock()
+	return rules
+}
+
+func (t *WebReaderTool) fetchRobots(ctx context.Context, u *neturl.URL) (robotsRules, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, robotsTimeout)
+	defer cancel()
+	robotsURL := (&neturl.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return robotsRules{}, robotsRetryTTL
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return robotsRules{}, robotsRetryTTL
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		body, err := io.ReadAll(io.LimitReader(resp.Body, robotsMaxBody))
+		if err != nil {
+			return robotsRules{}, robotsRetryTTL
+		}
+		return parseRobots(string(body), robotsAgent(t.userAgent)), robotsTTL
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return robotsRules{}, robotsTTL
+	default:
+		return robotsRules{}, robotsRetryTTL
+	}
+}
+
+// robotsAgent is the product token robots.txt groups are matched against:
+// "pocketomega" for "PocketOmega/0.2 (Web Reader Bot)".
+func robotsAgent(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	token, _, _ = strings.Cut(token, " ")
+	return strings.ToLower(token)
+}
+
+// robotsPath is the part of u robots.txt rules match: path and query.
+func robotsPath(u *neturl.URL) string {
+	p := u.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+	if u.RawQuery != "" {
+		p += "?" + u.RawQuery
+	}
+	return p
+}
+
+// parseRobots parses a robots.txt (RFC 9309) and returns the rules of the
+// groups naming agent, or of the "*" groups when none does.
+func parseRobots(body, agent string) robotsRules {
+	var named, wildcard robotsRules
+	var agents []string
+	hasNamed := false
+	inRules := false // a rule line ended the group's user-agent lines
+	for _, line := range strings.Split(body, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		if key == "user-agent" {
+			if inRules {
+				agents, inRules = nil, false
+			}
+			agents = append(agents, strings.ToLower(value))
+			hasNamed = hasNamed || strings.ToLower(value) == agent
+			continue
+		}
+		inRules = true
+		for _, a := range agents {
+			var target *robotsRules
+			switch a {
+			case agent:
+				target = &named
+			case "*":
+				target = &wildcard
+			default:
+				continue
+			}
+			switch key {
+			case "allow", "disallow":
+				if value != "" {
+					target.rules = append(target.rules, robotsRule{allow: key == "allow", pattern: value})
+				}
+			case "crawl-delay":
+				var secs float64
+				if _, err := fmt.Sscanf(value, "%g", &secs); err == nil && secs > 0 {
+					target.crawlDelay = time.Duration(secs * float64(time.Second))
+				}
+			}
+		}
+	}
+	if hasNamed {
+		return named
+	}
+	return wildcard
+}
+
+// allowed reports whether path may be fetched: the longest matching rule
+// decides, Allow winning ties; no match allows.
+func (r robotsRules) allowed(path string) bool {
+	if path == "/robots.txt" {
+		return true
+	}
+	allow, best := true, -1
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			allow, best = rule.allow, n
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches path against a rule pattern, where "*" matches any
+// run of characters and a trailing "$" anchors the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	return !anchored || pos == len(path)
+}
@@ -0,0 +1,155 @@
+package builtin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testRobots = `# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/ok
+Crawl-delay: 2
+
+User-agent: PocketOmega
+User-agent: OtherBot
+Disallow: /*.pdf$
+Disallow: /search?
+Crawl-delay: 0.5
+`
+
+func TestParseRobots(t *testing.T) {
+	ours := parseRobots(testRobots, "pocketomega")
+	if ours.crawlDelay != 500*time.Millisecond {
+		t.Errorf("crawl delay = %v, want 500ms", ours.crawlDelay)
+	}
+	for path, want := range map[string]bool{
+		"/private/x":     true, // only the "*" group disallows it
+		"/doc/a.pdf":     false,
+		"/doc/a.pdf?x=1": true,
+		"/search?q=go":   false,
+		"/robots.txt":    true,
+	} {
+		if got := ours.allowed(path); got != want {
+			t.Errorf("pocketomega allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	others := parseRobots(testRobots, "somebot")
+	if others.crawlDelay != 2*time.Second {
+		t.Errorf("wildcard crawl delay = %v", others.crawlDelay)
+	}
+	for path, want := range map[string]bool{"/private/x": false, "/private/ok/1": true, "/doc/a.pdf": true} {
+		if got := others.allowed(path); got != want {
+			t.Errorf("wildcard allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	// An empty Disallow in our group allows everything, whatever "*" says
+	open := parseRobots("User-agent: *\nDisallow: /\n\nUser-agent: pocketomega\nDisallow:\n", "pocketomega")
+	if !open.allowed("/anything") {
+		t.Error("empty Disallow in our group should allow everything")
+	}
+}
+
+func TestRobotsMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/a", "/a/b", true},
+		{"/a$", "/a", true},
+		{"/a$", "/a/b", false},
+		{"/*/edit", "/x/y/edit", true},
+		{"/*.php$", "/x.php", true},
+		{"/*.php$", "/x.php5", false},
+		{"/b", "/a", false},
+	} {
+		if got := robotsMatch(tc.pattern, tc.path); got != tc.want {
+			t.Errorf("robotsMatch(%q, %q) = %v", tc.pattern, tc.path, got)
+		}
+	}
+}
+
+func TestRobotsAgent(t *testing.T) {
+	for ua, want := range map[string]string{
+		"PocketOmega/0.2 (Web Reader Bot)": "pocketomega",
+		"MyCrawler":                        "mycrawler",
+		" Acme Bot/1.0":                    "acme",
+	} {
+		if got := robotsAgent(ua); got != want {
+			t.Errorf("robotsAgent(%q) = %q, want %q", ua, got, want)
+		}
+	}
+}
+
+// TestWebReaderRobotsAndHostInterval 验证 robots.txt 禁止的页面被拒绝、User-Agent 可配置，
+// 以及同一主机的请求按间隔排队、等待过长时直接拒绝。
+func TestWebReaderRobotsAndHostInterval(t *testing.T) {
+	var robotsFetches, pageFetches atomic.Int32
+	var lastUA atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastUA.Store(r.UserAgent())
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches.Add(1)
+			fmt.Fprint(w, "User-agent: testbot\nDisallow: /private\n")
+			return
+		}
+		pageFetches.Add(1)
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "正文")
+	}))
+	defer server.Close()
+
+	wr := NewWebReaderTool().WithUserAgent("TestBot/1.0").WithHostInterval(200 * time.Millisecond)
+	read := func(path string) (string, string) {
+		result, _ := wr.Execute(context.Background(), []byte(fmt.Sprintf(`{"url":%q}`, server.URL+path)))
+		return result.Output, result.Error
+	}
+
+	if _, errMsg := read("/private/a"); !strings.Contains(errMsg, "robots.txt") {
+		t.Errorf("disallowed page: error = %q", errMsg)
+	}
+	if pageFetches.Load() != 0 {
+		t.Error("disallowed page was fetched")
+	}
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if out, errMsg := read("/public"); errMsg != "" || out != "正文" {
+			t.Fatalf("public page: %q, %q", out, errMsg)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("two requests to one host took %v, want ≥ host interval", elapsed)
+	}
+	if robotsFetches.Load() != 1 {
+		t.Errorf("robots.txt fetched %d times, want 1 (cached)", robotsFetches.Load())
+	}
+	if ua := lastUA.Load(); ua != "TestBot/1.0" {
+		t.Errorf("User-Agent = %v", ua)
+	}
+
+	// A wait beyond webReaderMaxHostWait is refused at once
+	wr.WithHostInterval(time.Minute)
+	read("/public")
+	start = time.Now()
+	if _, errMsg := read("/public"); !strings.Contains(errMsg, "过于频繁") {
+		t.Errorf("over-long wait: error = %q", errMsg)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("over-long wait should not sleep")
+	}
+
+	// Disabled robots checks fetch the page
+	wr = NewWebReaderTool().WithUserAgent("TestBot/1.0").WithRobots(false).WithHostInterval(0)
+	if _, errMsg := read("/private/a"); errMsg != "" {
+		t.Errorf("robots disabled: error = %q", errMsg)
+	}
+}
@@ -0,0 +1,251 @@
+package builtin
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// xlsxMaxPartBytes bounds the uncompressed size of the XML parts read from
+// an .xlsx file, so that a small zip cannot expand without limit.
+const xlsxMaxPartBytes = 256 << 20
+
+// xlsxText is a string item (sharedStrings <si>, inline <is>): plain text
+// in <t>, or rich text runs <r><t>.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (x xlsxText) String() string {
+	if len(x.Runs) == 0 {
+		return x.T
+	}
+	var sb strings.Builder
+	for _, r := range x.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxCell struct {
+	Ref    string    `xml:"r,attr"`
+	Type   string    `xml:"t,attr"`
+	Value  string    `xml:"v"`
+	Inline *xlsxText `xml:"is"`
+}
+
+// readXLSX returns the rows of one worksheet of an .xlsx file: the first
+// sheet, or the one sheet names or numbers from 1. Only stored values are
+// read: formulas give their cached result and dates their serial number.
+// At most maxRows rows are returned; truncated reports whether there were
+// more.
+func readXLSX(filePath, sheet string, maxRows int) (rows [][]string, truncated bool, err error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("不是有效的 XLSX 文件: %v", err)
+	}
+	defer zr.Close()
+	parts := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		parts[f.Name] = f
+	}
+
+	sheetPart, err := xlsxSheetPart(parts, sheet)
+	if err != nil {
+		return nil, false, err
+	}
+	var shared []string
+	if f := parts["xl/sharedStrings.xml"]; f != nil {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := xlsxDecode(f, &sst); err != nil {
+			return nil, false, err
+		}
+		shared = make([]string, len(sst.Items))
+		for i, si := range sst.Items {
+			shared[i] = si.String()
+		}
+	}
+
+	f := parts[sheetPart]
+	if f == nil {
+		return nil, false, fmt.Errorf("XLSX 缺少工作表 %s", sheetPart)
+	}
+	if f.UncompressedSize64 > xlsxMaxPartBytes {
+		return nil, false, fmt.Errorf("工作表过大（%d MB）", f.UncompressedSize64>>20)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, false, err
+	}
+	defer rc.Close()
+
+	// Stream the rows: a sheet can be far larger than its parsed form needs
+	dec := xml.NewDecoder(io.LimitReader(rc, xlsxMaxPartBytes))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("解析工作表失败: %v", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row struct {
+			Cells []xlsxCell `xml:"c"`
+		}
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return nil, false, fmt.Errorf("解析工作表失败: %v", err)
+		}
+		values := xlsxRowValues(row.Cells, shared)
+		if len(values) == 0 {
+			continue
+		}
+		if len(rows) == maxRows {
+			return rows, true, nil
+		}
+		rows = append(rows, values)
+	}
+	return rows, false, nil
+}
+
+// xlsxSheetPart resolves sheet (name, 1-based number or "" for the first)
+// to the zip path of its worksheet through the workbook and its relations.
+func xlsxSheetPart(parts map[string]*zip.File, sheet string) (string, error) {
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			RID  string `xml:"id,attr"` // r:id
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	wbFile, relsFile := parts["xl/workbook.xml"], parts["xl/_rels/workbook.xml.rels"]
+	if wbFile == nil || relsFile == nil {
+		return "", fmt.Errorf("不是有效的 XLSX 文件：缺少 workbook")
+	}
+	if err := xlsxDecode(wbFile, &wb); err != nil {
+		return "", err
+	}
+	if err := xlsxDecode(relsFile, &rels); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) == 0 {
+		return "", fmt.Errorf("XLSX 中没有工作表")
+	}
+
+	idx := -1
+	names := make([]string, len(wb.Sheets))
+	for i, s := range wb.Sheets {
+		names[i] = s.Name
+		if sheet != "" && strings.EqualFold(s.Name, sheet) {
+			idx = i
+		}
+	}
+	switch n, err := strconv.Atoi(sheet); {
+	case sheet == "":
+		idx = 0
+	case idx < 0 && err == nil && n >= 1 && n <= len(wb.Sheets):
+		idx = n - 1
+	case idx < 0:
+		return "", fmt.Errorf("工作表 %q 不存在，可选：%s", sheet, strings.Join(names, ", "))
+	}
+
+	for _, r := range rels.Rels {
+		if r.ID != wb.Sheets[idx].RID {
+			continue
+		}
+		if strings.HasPrefix(r.Target, "/") {
+			return strings.TrimPrefix(r.Target, "/"), nil
+		}
+		return path.Join("xl", r.Target), nil
+	}
+	return "", fmt.Errorf("XLSX 中找不到工作表 %q 的数据", wb.Sheets[idx].Name)
+}
+
+func xlsxDecode(f *zip.File, v any) error {
+	if f.UncompressedSize64 > xlsxMaxPartBytes {
+		return fmt.Errorf("XLSX 部件 %s 过大", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("解析 %s 失败: %v", f.Name, err)
+	}
+	return nil
+}
+
+// xlsxRowValues places the cells of a row at their columns (cells of empty
+// values are omitted from the file). Rows without any value return nil.
+func xlsxRowValues(cells []xlsxCell, shared []string) []string {
+	var values []string
+	empty := true
+	for i, c := range cells {
+		col := xlsxColumn(c.Ref)
+		if col < 0 {
+			col = max(i, len(values))
+		}
+		var v string
+		switch c.Type {
+		case "s":
+			if n, err := strconv.Atoi(c.Value); err == nil && n >= 0 && n < len(shared) {
+				v = shared[n]
+			}
+		case "inlineStr":
+			if c.Inline != nil {
+				v = c.Inline.String()
+			}
+		case "b":
+			v = map[string]string{"1": "TRUE", "0": "FALSE"}[c.Value]
+		default: // n, str (formula result), e (error), d (ISO date)
+			v = c.Value
+		}
+		for len(values) <= col {
+			values = append(values, "")
+		}
+		values[col] = v
+		if v != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return nil
+	}
+	return values
+}
+
+// xlsxColumn returns the 0-based column of a cell reference like "AB12";
+// -1 when ref has no column letters.
+func xlsxColumn(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 || n > 3 {
+		return -1
+	}
+	return col - 1
+}
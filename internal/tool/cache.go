@@ -0,0 +1,251 @@
+package tool
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cacheable is implemented by tools whose successful results may be served
+// from a ResultCache. CacheFingerprint returns a version stamp of the state
+// the result depends on besides the arguments — a file's mtime and size, a
+// URL's ETag — so a changed source misses the cache even within the TTL.
+// "" means the TTL alone bounds staleness; ok=false skips the cache for
+// this call.
+type Cacheable interface {
+	CacheFingerprint(ctx context.Context, args json.RawMessage) (fingerprint string, ok bool)
+}
+
+// cacheMaxResultBytes keeps unusually large outputs out of the cache.
+const cacheMaxResultBytes = 256 << 10
+
+// ResultCache memoizes successful results of read-only tool calls across
+// runs and sessions, keyed by tool name, arguments and the tool's
+// fingerprint. Only tools that implement Cacheable and have a TTL are
+// cached; the least recently used entry is evicted beyond maxEntries.
+// Safe for concurrent use.
+type ResultCache struct {
+	ttls       map[string]time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // key → element holding *cacheEntry
+	lru     *list.List               // front = most recently used
+	bytes   int64
+	stats   map[string]*toolCacheCounters
+	now     func() time.Time
+}
+
+type cacheEntry struct {
+	key     string
+	tool    string
+	result  ToolResult
+	expires time.Time
+}
+
+type toolCacheCounters struct {
+	hits, misses, skipped, evictions int64
+}
+
+// NewResultCache creates a cache holding up to maxEntries results, with the
+// per-tool TTLs in ttls (tools without a TTL are not cached).
+func NewResultCache(maxEntries int, ttls map[string]time.Duration) *ResultCache {
+	if maxEntries <= 0 {
+		maxEntries = 256
+	}
+	return &ResultCache{
+		ttls:       ttls,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		stats:      make(map[string]*toolCacheCounters),
+		now:        time.Now,
+	}
+}
+
+// TTL returns the cache lifetime of name's results; 0 = not cached.
+func (c *ResultCache) TTL(name string) time.Duration {
+	if c == nil {
+		return 0
+	}
+	return c.ttls[name]
+}
+
+func cacheKey(name string, args json.RawMessage, fingerprint string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s", name, args, fingerprint)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// counters returns name's counters; c.mu must be held.
+func (c *ResultCache) counters(name string) *toolCacheCounters {
+	s := c.stats[name]
+	if s == nil {
+		s = &toolCacheCounters{}
+		c.stats[name] = s
+	}
+	return s
+}
+
+func (c *ResultCache) get(name, key string) (ToolResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*cacheEntry)
+		if c.now().Before(e.expires) {
+			c.lru.MoveToFront(el)
+			c.counters(name).hits++
+			return e.result, true
+		}
+		c.removeLocked(el)
+	}
+	c.counters(name).misses++
+	return ToolResult{}, false
+}
+
+func (c *ResultCache) put(name, key string, result ToolResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.removeLocked(el)
+	}
+	e := &cacheEntry{key: key, tool: name, result: result, expires: c.now().Add(c.ttls[name])}
+	c.entries[key] = c.lru.PushFront(e)
+	c.bytes += int64(len(result.Output))
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.counters(oldest.Value.(*cacheEntry).tool).evictions++
+		c.removeLocked(oldest)
+	}
+}
+
+func (c *ResultCache) skip(name string) {
+	c.mu.Lock()
+	c.counters(name).skipped++
+	c.mu.Unlock()
+}
+
+func (c *ResultCache) removeLocked(el *list.Element) {
+	e := c.lru.Remove(el).(*cacheEntry)
+	delete(c.entries, e.key)
+	c.bytes -= int64(len(e.result.Output))
+}
+
+// Clear drops all cached results; the counters are kept.
+func (c *ResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.bytes = 0
+}
+
+// CacheStats is a snapshot of a ResultCache, served by the cache_stats
+// debug endpoint.
+type CacheStats struct {
+	Entries    int              `json:"entries"`
+	MaxEntries int              `json:"max_entries"`
+	Bytes      int64            `json:"bytes"`
+	Tools      []ToolCacheStats `json:"tools"`
+}
+
+// ToolCacheStats counts one tool's cache lookups since startup.
+type ToolCacheStats struct {
+	Name       string  `json:"name"`
+	TTLSeconds float64 `json:"ttl_seconds"`
+	Entries    int     `json:"entries"`
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	Skipped    int64   `json:"skipped"` // calls that bypassed the cache (no fingerprint, failed or uncacheable result)
+	Evictions  int64   `json:"evictions"`
+	HitRate    float64 `json:"hit_rate"` // hits / (hits + misses)
+}
+
+// Stats returns the cache size and per-tool counters, sorted by tool name.
+func (c *ResultCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := CacheStats{Entries: c.lru.Len(), MaxEntries: c.maxEntries, Bytes: c.bytes}
+	entries := make(map[string]int)
+	for el := c.lru.Front(); el != nil; el = el.Next() {
+		entries[el.Value.(*cacheEntry).tool]++
+	}
+	names := make(map[string]bool)
+	for name := range c.ttls {
+		names[name] = true
+	}
+	for name := range c.stats {
+		names[name] = true
+	}
+	for name := range names {
+		ts := ToolCacheStats{Name: name, TTLSeconds: c.ttls[name].Seconds(), Entries: entries[name]}
+		if n := c.stats[name]; n != nil {
+			ts.Hits, ts.Misses, ts.Skipped, ts.Evictions = n.hits, n.misses, n.skipped, n.evictions
+			if lookups := n.hits + n.misses; lookups > 0 {
+				ts.HitRate = float64(n.hits) / float64(lookups)
+			}
+		}
+		s.Tools = append(s.Tools, ts)
+	}
+	sort.Slice(s.Tools, func(i, j int) bool { return s.Tools[i].Name < s.Tools[j].Name })
+	return s
+}
+
+// cachedTool serves a Cacheable tool's results from the cache. Returned by
+// Registry.Get for tools with a cache TTL.
+type cachedTool struct {
+	Tool
+	fp    Cacheable
+	cache *ResultCache
+}
+
+func (t *cachedTool) Execute(ctx context.Context, args json.RawMessage) (ToolResult, error) {
+	name := t.Name()
+	fingerprint, ok := t.fp.CacheFingerprint(ctx, args)
+	if !ok {
+		t.cache.skip(name)
+		return t.Tool.Execute(ctx, args)
+	}
+	key := cacheKey(name, args, fingerprint)
+	if res, hit := t.cache.get(name, key); hit {
+		return res, nil
+	}
+	res, err := t.Tool.Execute(ctx, args)
+	if err != nil || res.Error != "" || res.NoCache || res.RetryAfterSec > 0 ||
+		len(res.Images) > 0 || len(res.Output) > cacheMaxResultBytes {
+		t.cache.skip(name)
+		return res, err
+	}
+	t.cache.put(name, key, res)
+	return res, nil
+}
+
+// ParseCacheTTLs parses a TOOL_CACHE_TTLS spec: comma-separated entries
+// "name=DURATION", e.g. "file_read=10m,web_reader=5m".
+func ParseCacheTTLs(spec string) (map[string]time.Duration, error) {
+	ttls := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid cache TTL entry %q (want name=duration)", entry)
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(val))
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("invalid cache TTL in %q", entry)
+		}
+		ttls[name] = ttl
+	}
+	return ttls, nil
+}
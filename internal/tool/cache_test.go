@@ -0,0 +1,131 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// versionedTool counts executions and fingerprints with a settable version.
+type versionedTool struct {
+	dummyTool
+	calls   int
+	version string
+	result  ToolResult
+}
+
+func (c *versionedTool) Execute(_ context.Context, _ json.RawMessage) (ToolResult, error) {
+	c.calls++
+	return c.result, nil
+}
+
+func (c *versionedTool) CacheFingerprint(_ context.Context, _ json.RawMessage) (string, bool) {
+	return c.version, c.version != "racy"
+}
+
+func TestResultCache_HitsMissesAndFingerprint(t *testing.T) {
+	ct := &versionedTool{dummyTool: dummyTool{name: "file_read"}, version: "v1", result: ToolResult{Output: "data"}}
+	r := NewRegistry()
+	r.Register(ct)
+	cache := NewResultCache(10, map[string]time.Duration{"file_read": time.Minute})
+	r.SetResultCache(cache)
+
+	run := func(args string) ToolResult {
+		t.Helper()
+		tl, _ := r.Get("file_read")
+		res, err := tl.Execute(context.Background(), json.RawMessage(args))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	run(`{"path":"a"}`)
+	if res := run(`{"path":"a"}`); res.Output != "data" || ct.calls != 1 {
+		t.Fatalf("second call: output=%q calls=%d, want cache hit", res.Output, ct.calls)
+	}
+	run(`{"path":"b"}`)
+	ct.version = "v2"
+	run(`{"path":"a"}`)
+	ct.version = "racy"
+	run(`{"path":"a"}`)
+	if ct.calls != 4 {
+		t.Errorf("calls = %d, want 4", ct.calls)
+	}
+
+	s := cache.Stats()
+	if s.Entries != 3 || len(s.Tools) != 1 {
+		t.Fatalf("stats = %+v", s)
+	}
+	if ts := s.Tools[0]; ts.Hits != 1 || ts.Misses != 3 || ts.Skipped != 1 || ts.HitRate != 0.25 {
+		t.Errorf("tool stats = %+v", ts)
+	}
+}
+
+func TestResultCache_TTLEvictionAndUncacheable(t *testing.T) {
+	ct := &versionedTool{dummyTool: dummyTool{name: "web_reader"}, result: ToolResult{Output: "page"}}
+	cache := NewResultCache(2, map[string]time.Duration{"web_reader": time.Minute})
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	tl := &cachedTool{Tool: ct, fp: ct, cache: cache}
+	exec := func(args string) {
+		tl.Execute(context.Background(), json.RawMessage(args))
+	}
+
+	exec(`1`)
+	now = now.Add(2 * time.Minute)
+	exec(`1`)
+	if ct.calls != 2 {
+		t.Errorf("expired entry served: calls = %d", ct.calls)
+	}
+
+	exec(`2`)
+	exec(`3`) // evicts 1
+	exec(`1`)
+	if ct.calls != 5 {
+		t.Errorf("evicted entry served: calls = %d", ct.calls)
+	}
+	if s := cache.Stats(); s.Entries != 2 || s.Tools[0].Evictions != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+
+	for _, res := range []ToolResult{{Error: "boom"}, {Output: "partial", NoCache: true}, {Error: "rate limited", RetryAfterSec: 3}} {
+		ct.result = res
+		before := ct.calls
+		exec(`4`)
+		exec(`4`)
+		if ct.calls != before+2 {
+			t.Errorf("result %+v was cached", res)
+		}
+	}
+}
+
+func TestRegistry_ResultCacheOnlyForCacheableTools(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&dummyTool{name: "shell_exec"})
+	r.SetResultCache(NewResultCache(0, map[string]time.Duration{"shell_exec": time.Minute}))
+	if tl, _ := r.Get("shell_exec"); !isDummy(tl) {
+		t.Error("non-cacheable tool wrapped")
+	}
+	var nilReg *ResultCache
+	if nilReg.TTL("file_read") != 0 {
+		t.Error("nil cache has a TTL")
+	}
+}
+
+func TestParseCacheTTLs(t *testing.T) {
+	ttls, err := ParseCacheTTLs("file_read=10m, web_reader=30s,")
+	if err != nil || ttls["file_read"] != 10*time.Minute || ttls["web_reader"] != 30*time.Second {
+		t.Errorf("ParseCacheTTLs = %v, %v", ttls, err)
+	}
+	for _, bad := range []string{"file_read", "=1m", "file_read=soon", "file_read=-1m"} {
+		if _, err := ParseCacheTTLs(bad); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
+
+func isDummy(t Tool) bool {
+	_, ok := t.(*dummyTool)
+	return ok
+}
@@ -0,0 +1,118 @@
+package tool
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Permissions limits the tools of a run by the role of the user who started
+// it. Roles map to tool names or path.Match patterns, like Profiles; a run
+// with a tool profile gets the tools both allow.
+type Permissions struct {
+	Roles       map[string][]string `yaml:"roles"`        // role → allowed tools
+	Users       map[string]string   `yaml:"users"`        // user → role
+	DefaultRole string              `yaml:"default_role"` // role of unlisted users and anonymous requests
+}
+
+// DefaultRoles returns the built-in roles:
+//   - admin: every tool, including shell, config_edit, env_set and MCP server management
+//   - member: workspace files, search and the web, without shell, Python,
+//     git_ops, config edits or MCP server management
+//   - viewer: the tools that never modify anything (see readOnlyTools)
+func DefaultRoles() map[string][]string {
+	profiles := DefaultProfiles()
+	return map[string][]string{
+		"admin": {"*"},
+		"member": {
+			"file_*", "archive_tool", "find", "git_info", "todo_scan", "project_map", "code_search", "csv_query", "doc_read", "image_read",
+			"web_search", "brave_search", "web_reader", "http_request", "fetch_more", "output_read",
+			"journal_append", "get_time",
+		},
+		"viewer": profiles["readonly"],
+	}
+}
+
+// defaultRole is the role of users the file does not list, unless it sets
+// default_role: least privilege.
+const defaultRole = "viewer"
+
+// LoadPermissions returns the built-in roles overlaid with the YAML file at
+// filePath (roles, users and default_role). A role in the file replaces the
+// built-in role of the same name. Unlike profiles the file is required: it
+// names the users, and a typo must not silently grant the default role.
+func LoadPermissions(filePath string) (*Permissions, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var file Permissions
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filePath, err)
+	}
+	p := &Permissions{Roles: DefaultRoles(), Users: file.Users, DefaultRole: file.DefaultRole}
+	if p.DefaultRole == "" {
+		p.DefaultRole = defaultRole
+	}
+	for role, tools := range file.Roles {
+		for _, pattern := range tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: role %q: bad pattern %q", filePath, role, pattern)
+			}
+		}
+		p.Roles[role] = tools
+	}
+	if _, ok := p.Roles[p.DefaultRole]; !ok {
+		return nil, fmt.Errorf("%s: unknown default_role %q", filePath, p.DefaultRole)
+	}
+	for user, role := range p.Users {
+		if _, ok := p.Roles[role]; !ok {
+			return nil, fmt.Errorf("%s: user %q has unknown role %q", filePath, user, role)
+		}
+	}
+	return p, nil
+}
+
+// Role returns the role of user ("" = anonymous).
+func (p *Permissions) Role(user string) string {
+	if role, ok := p.Users[user]; ok && user != "" {
+		return role
+	}
+	return p.DefaultRole
+}
+
+// Tools returns the tools role may use; an unknown role may use none.
+func (p *Permissions) Tools(role string) []string {
+	return p.Roles[role]
+}
+
+// Allows reports whether role may use the tool name.
+func (p *Permissions) Allows(role, name string) bool {
+	for _, pattern := range p.Roles[role] {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Admin reports whether role may use every tool ("*"). Only admins may
+// change what the whole server runs with: prompts, /reload and the like.
+func (p *Permissions) Admin(role string) bool {
+	for _, pattern := range p.Roles[role] {
+		if pattern == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// OnDenied makes a filtering view (see WithOnly) call fn with the name of
+// every lookup it refuses for a tool that exists, e.g. to log permission
+// denials. It returns r.
+func (r *Registry) OnDenied(fn func(name string)) *Registry {
+	r.denied = fn
+	return r
+}
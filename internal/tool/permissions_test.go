@@ -0,0 +1,72 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPermissions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(doc string) string {
+		t.Helper()
+		p := filepath.Join(dir, "permissions.yaml")
+		if err := os.WriteFile(p, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	p, err := LoadPermissions(write("users:\n  alice: admin\n  bob: analyst\nroles:\n  analyst: [file_read, \"mcp_github__*\"]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for user, want := range map[string]string{"alice": "admin", "bob": "analyst", "carol": "viewer", "": "viewer"} {
+		if got := p.Role(user); got != want {
+			t.Errorf("Role(%q) = %q, want %q", user, got, want)
+		}
+	}
+	if len(p.Tools("member")) == 0 || len(p.Tools("unknown")) != 0 {
+		t.Error("built-in roles should remain; unknown roles get no tools")
+	}
+
+	if !p.Allows("analyst", "mcp_github__search") || p.Allows("analyst", "file_write") || !p.Allows("admin", "file_write") {
+		t.Error("Allows should match the role's tool patterns")
+	}
+	if !p.Admin("admin") || p.Admin("member") || p.Admin("analyst") || p.Admin("unknown") {
+		t.Error("only roles with every tool (\"*\") are admins")
+	}
+
+	for name, doc := range map[string]string{
+		"unknown user role":    "users:\n  bob: owner\n",
+		"unknown default role": "default_role: guest\n",
+		"bad pattern":          "roles:\n  x: [\"[\"]\n",
+	} {
+		if _, err := LoadPermissions(write(doc)); err == nil {
+			t.Errorf("%s: LoadPermissions should fail", name)
+		}
+	}
+	if _, err := LoadPermissions(filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("a missing file should be an error")
+	}
+}
+
+func TestRegistry_OnDenied(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"file_read", "shell_exec", "config_edit"} {
+		r.Register(&dummyTool{name: name})
+	}
+	var denied []string
+	view := r.WithOnly(DefaultRoles()["viewer"]).OnDenied(func(name string) { denied = append(denied, name) })
+
+	if _, ok := view.Get("file_read"); !ok {
+		t.Error("viewer should get file_read")
+	}
+	view.Get("shell_exec")
+	view.Get("config_edit")
+	view.Get("no_such_tool") // not a denial: the tool does not exist
+	if got := strings.Join(denied, ","); got != "shell_exec,config_edit" {
+		t.Errorf("denied = %s", got)
+	}
+}
@@ -0,0 +1,117 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profiles maps a tool profile name to the tools a run with that profile
+// may use. Entries are tool names or path.Match patterns ("file_*",
+// "mcp_github__*"). Runs without a profile see every tool.
+type Profiles map[string][]string
+
+// DefaultProfiles returns the built-in profiles:
+//   - readonly: the tools that never modify anything (see readOnlyTools),
+//     so a run has zero write access
+//   - researcher: web search and reading, workspace reads, notes
+//   - coder: workspace files, shell, Python and git, without MCP server
+//     management or config edits
+func DefaultProfiles() Profiles {
+	readonly := make([]string, 0, len(readOnlyTools))
+	for name := range readOnlyTools {
+		readonly = append(readonly, name)
+	}
+	sort.Strings(readonly)
+	return Profiles{
+		"readonly": readonly,
+		"researcher": {
+			"web_search", "brave_search", "web_reader", "http_request", "fetch_more", "output_read",
+			"file_read", "file_list", "file_grep", "find", "csv_query", "doc_read", "image_read", "file_write", "journal_append", "get_time",
+		},
+		"coder": {
+			"file_*", "find", "shell_exec", "python_exec", "git_info", "git_ops", "todo_scan", "project_map", "code_search", "csv_query", "doc_read", "archive_tool",
+			"fetch_more", "output_read", "image_read", "web_search", "brave_search", "web_reader", "http_request", "get_time",
+		},
+	}
+}
+
+// LoadProfiles returns the built-in profiles overlaid with the profiles
+// defined in the YAML file at filePath (profile name → list of tool names).
+// A profile in the file replaces the built-in profile of the same name. A
+// missing file is not an error.
+func LoadProfiles(filePath string) (Profiles, error) {
+	profiles := DefaultProfiles()
+	if filePath == "" {
+		return profiles, nil
+	}
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var custom map[string][]string
+	if err := yaml.Unmarshal(data, &custom); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filePath, err)
+	}
+	for name, tools := range custom {
+		if len(tools) == 0 {
+			return nil, fmt.Errorf("%s: profile %q lists no tools", filePath, name)
+		}
+		for _, pattern := range tools {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: profile %q: bad pattern %q", filePath, name, pattern)
+			}
+		}
+		profiles[name] = tools
+	}
+	return profiles, nil
+}
+
+// Names returns the profile names, sorted.
+func (p Profiles) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether p defines the profile name.
+func (p Profiles) Has(name string) bool {
+	_, ok := p[name]
+	return ok
+}
+
+// WithOnly returns a view of this Registry that exposes only the tools
+// matching patterns (tool names or path.Match patterns); no patterns hide
+// every tool. Like WithExtra it delegates to the parent, so tools
+// registered later (e.g. after MCP reload) are filtered as well. Extras
+// added on top of the view with WithExtra are not filtered.
+func (r *Registry) WithOnly(patterns []string) *Registry {
+	return &Registry{
+		parent: r,
+		tools:  make(map[string]Tool),
+		allow:  append([]string{}, patterns...), // non-nil even when empty: no patterns, no tools
+	}
+}
+
+// allows reports whether a filtering view lets the tool name through.
+func (r *Registry) allows(name string) bool {
+	if r.allow == nil {
+		return true
+	}
+	for _, pattern := range r.allow {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WithOnly(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"file_read", "file_write", "shell_exec"} {
+		r.Register(&dummyTool{name: name})
+	}
+	view := r.WithOnly([]string{"file_*"}).WithExtra(&dummyTool{name: "update_plan"})
+
+	if _, ok := view.Get("shell_exec"); ok {
+		t.Error("filtered view should hide shell_exec")
+	}
+	var names []string
+	for _, tl := range view.List() {
+		names = append(names, tl.Name())
+	}
+	if got := strings.Join(names, ","); got != "file_read,file_write,update_plan" {
+		t.Errorf("List() = %s", got)
+	}
+
+	// Tools registered later are filtered too
+	r.Register(&dummyTool{name: "file_move"})
+	r.Register(&dummyTool{name: "mcp_x__y"})
+	if _, ok := view.Get("file_move"); !ok {
+		t.Error("file_move registered after WithOnly should be visible")
+	}
+	if _, ok := view.Get("mcp_x__y"); ok {
+		t.Error("mcp_x__y should be hidden")
+	}
+	if _, ok := r.Get("shell_exec"); !ok {
+		t.Error("root registry should be unaffected")
+	}
+}
+
+func TestDefaultProfiles_ReadonlyHasNoWriters(t *testing.T) {
+	for _, name := range DefaultProfiles()["readonly"] {
+		if !readOnlyTools[name] {
+			t.Errorf("readonly profile includes mutating tool %q", name)
+		}
+	}
+}
+
+func TestLoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+	p, err := LoadProfiles(filepath.Join(dir, "missing.yaml"))
+	if err != nil || !p.Has("readonly") || !p.Has("coder") || !p.Has("researcher") {
+		t.Fatalf("missing file: %v, %v", p.Names(), err)
+	}
+
+	path := filepath.Join(dir, "profiles.yaml")
+	os.WriteFile(path, []byte("coder: [file_read, shell_exec]\nreviewer:\n  - file_*\n  - git_info\n"), 0o644)
+	p, err = LoadProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(p.Names(), ","); got != "coder,readonly,researcher,reviewer" {
+		t.Errorf("Names() = %s", got)
+	}
+	if got := strings.Join(p["coder"], ","); got != "file_read,shell_exec" {
+		t.Errorf("coder = %s, want the file's definition", got)
+	}
+
+	for _, bad := range []string{"empty: []\n", "x: [\"[\"]\n", "x: file_read\n"} {
+		os.WriteFile(path, []byte(bad), 0o644)
+		if _, err := LoadProfiles(path); err == nil {
+			t.Errorf("%q accepted", bad)
+		}
+	}
+}
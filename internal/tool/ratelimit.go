@@ -0,0 +1,138 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitMaxWait bounds how long a call waits for a free concurrency slot
+// before it is rejected as rate limited.
+const rateLimitMaxWait = 10 * time.Second
+
+// RateLimit configures per-tool call limits. Zero values mean unlimited.
+type RateLimit struct {
+	PerMinute     int // max calls started within any 60s window
+	MaxConcurrent int // max calls in flight at once
+}
+
+// limiter enforces one tool's RateLimit. Calls are admitted against a
+// sliding 60s window; concurrency is a counting semaphore.
+type limiter struct {
+	limit RateLimit
+	slots chan struct{} // nil when MaxConcurrent == 0
+
+	mu     sync.Mutex
+	starts []time.Time // call start times within the window, oldest first
+	now    func() time.Time
+}
+
+func newLimiter(l RateLimit) *limiter {
+	lim := &limiter{limit: l, now: time.Now}
+	if l.MaxConcurrent > 0 {
+		lim.slots = make(chan struct{}, l.MaxConcurrent)
+	}
+	return lim
+}
+
+// admit records a call start, or returns how long to wait when the
+// per-minute budget is exhausted.
+func (l *limiter) admit() (time.Duration, bool) {
+	if l.limit.PerMinute <= 0 {
+		return 0, true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	cutoff := now.Add(-time.Minute)
+	i := 0
+	for i < len(l.starts) && !l.starts[i].After(cutoff) {
+		i++
+	}
+	l.starts = l.starts[i:]
+	if len(l.starts) >= l.limit.PerMinute {
+		return l.starts[0].Add(time.Minute).Sub(now), false
+	}
+	l.starts = append(l.starts, now)
+	return 0, true
+}
+
+// rateLimitedTool decorates a Tool with a limiter. Returned by Registry.Get
+// for tools that have a configured RateLimit.
+type rateLimitedTool struct {
+	Tool
+	lim *limiter
+}
+
+func (t *rateLimitedTool) Execute(ctx context.Context, args json.RawMessage) (ToolResult, error) {
+	if t.lim.slots != nil {
+		wait, cancel := context.WithTimeout(ctx, rateLimitMaxWait)
+		select {
+		case t.lim.slots <- struct{}{}:
+			cancel()
+		case <-wait.Done():
+			cancel()
+			if ctx.Err() != nil {
+				return ToolResult{Error: "调用被取消"}, nil
+			}
+			return rateLimitedResult(t.Name(),
+				fmt.Sprintf("并发调用已达上限 %d", t.lim.limit.MaxConcurrent), time.Second), nil
+		}
+		defer func() { <-t.lim.slots }()
+	}
+
+	if retry, ok := t.lim.admit(); !ok {
+		return rateLimitedResult(t.Name(),
+			fmt.Sprintf("每分钟最多调用 %d 次", t.lim.limit.PerMinute), retry), nil
+	}
+	return t.Tool.Execute(ctx, args)
+}
+
+// rateLimitedResult builds the structured rate-limit ToolResult.
+func rateLimitedResult(name, reason string, retry time.Duration) ToolResult {
+	secs := int(math.Ceil(retry.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return ToolResult{
+		Error: fmt.Sprintf("rate limited, retry after %ds — %s: %s。请先利用已有结果继续，或改用其他工具，不要立即重复调用",
+			secs, name, reason),
+		RetryAfterSec: secs,
+	}
+}
+
+// ParseRateLimits parses a TOOL_RATE_LIMITS spec: comma-separated entries
+// "name=PER_MINUTE[:MAX_CONCURRENT]", e.g. "web_search=20:2,http_request=60".
+// Use 0 for an unlimited dimension ("http_request=0:4").
+func ParseRateLimits(spec string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid rate limit entry %q (want name=per_minute[:max_concurrent])", entry)
+		}
+		perMin, conc, hasConc := strings.Cut(strings.TrimSpace(val), ":")
+		var l RateLimit
+		var err error
+		if l.PerMinute, err = strconv.Atoi(perMin); err != nil || l.PerMinute < 0 {
+			return nil, fmt.Errorf("invalid per-minute limit in %q", entry)
+		}
+		if hasConc {
+			if l.MaxConcurrent, err = strconv.Atoi(conc); err != nil || l.MaxConcurrent < 0 {
+				return nil, fmt.Errorf("invalid concurrency limit in %q", entry)
+			}
+		}
+		limits[name] = l
+	}
+	return limits, nil
+}
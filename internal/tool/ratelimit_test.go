@@ -0,0 +1,134 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingTool blocks in Execute until release is closed.
+type blockingTool struct {
+	dummyTool
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingTool) Execute(_ context.Context, _ json.RawMessage) (ToolResult, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return ToolResult{Output: "ok"}, nil
+}
+
+func TestRateLimit_PerMinute(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&dummyTool{name: "web_search"})
+	r.SetRateLimit("web_search", RateLimit{PerMinute: 2})
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	r.limiters["web_search"].now = func() time.Time { return now }
+
+	tl, _ := r.Get("web_search")
+	for i := 0; i < 2; i++ {
+		if res, _ := tl.Execute(context.Background(), nil); res.Error != "" {
+			t.Fatalf("call %d unexpectedly limited: %s", i+1, res.Error)
+		}
+	}
+
+	now = now.Add(20 * time.Second)
+	res, _ := tl.Execute(context.Background(), nil)
+	if res.RetryAfterSec != 40 {
+		t.Errorf("RetryAfterSec = %d, want 40", res.RetryAfterSec)
+	}
+	if !strings.Contains(res.Error, "rate limited, retry after 40s") {
+		t.Errorf("Error = %q", res.Error)
+	}
+
+	// Window slides: the first call expires after 60s.
+	now = now.Add(41 * time.Second)
+	if res, _ := tl.Execute(context.Background(), nil); res.Error != "" {
+		t.Errorf("call after window should pass, got %q", res.Error)
+	}
+}
+
+func TestRateLimit_MaxConcurrent(t *testing.T) {
+	r := NewRegistry()
+	bt := &blockingTool{dummyTool: dummyTool{name: "http_request"}, started: make(chan struct{}, 2), release: make(chan struct{})}
+	r.Register(bt)
+	r.SetRateLimit("http_request", RateLimit{MaxConcurrent: 1})
+	tl, _ := r.Get("http_request")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tl.Execute(context.Background(), nil)
+	}()
+	<-bt.started
+
+	// Second call cannot get a slot; a cancelled context ends the wait early.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	res, _ := tl.Execute(ctx, nil)
+	if res.Error == "" || res.Output != "" {
+		t.Errorf("second concurrent call should not run, got %+v", res)
+	}
+
+	close(bt.release)
+	wg.Wait()
+	if res, _ := tl.Execute(context.Background(), nil); res.Output != "ok" {
+		t.Errorf("call after slot freed = %+v", res)
+	}
+}
+
+func TestRateLimit_ViewAndRemoval(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&dummyTool{name: "a"})
+	view := r.WithExtra(&dummyTool{name: "b"})
+	view.SetRateLimit("b", RateLimit{PerMinute: 1})
+
+	if _, ok := r.limiters["b"]; !ok {
+		t.Fatal("limit set through a view should be stored on the root")
+	}
+	if tl, _ := view.Get("b"); !isRateLimited(tl) {
+		t.Error("extras in a view should be rate limited too")
+	}
+	if tl, _ := view.Get("a"); isRateLimited(tl) {
+		t.Error("tools without a limit should not be wrapped")
+	}
+
+	r.SetRateLimit("b", RateLimit{})
+	if tl, _ := view.Get("b"); isRateLimited(tl) {
+		t.Error("zero RateLimit should remove the limit")
+	}
+}
+
+func TestParseRateLimits(t *testing.T) {
+	got, err := ParseRateLimits(" web_search=20:2, http_request=60 ,brave_search=0:1")
+	if err != nil {
+		t.Fatalf("ParseRateLimits: %v", err)
+	}
+	want := map[string]RateLimit{
+		"web_search":   {PerMinute: 20, MaxConcurrent: 2},
+		"http_request": {PerMinute: 60},
+		"brave_search": {MaxConcurrent: 1},
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %+v, want %+v", k, got[k], v)
+		}
+	}
+
+	for _, bad := range []string{"web_search", "=5", "x=abc", "x=5:-1", "x=-1"} {
+		if _, err := ParseRateLimits(bad); err == nil {
+			t.Errorf("ParseRateLimits(%q) should fail", bad)
+		}
+	}
+}
+
+func isRateLimited(t Tool) bool {
+	_, ok := t.(*rateLimitedTool)
+	return ok
+}
@@ -0,0 +1,95 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// readOnlyTools never modify the workspace, the host or remote state and
+// therefore run normally in read-only mode. Every other tool (including all
+// MCP tools) is replaced by a dry-run unless it implements ReadOnlyCaller.
+// ⚠️ Only add tools here whose every call is side-effect free.
+var readOnlyTools = map[string]bool{
+	"file_read":       true,
+	"file_list":       true,
+	"file_grep":       true,
+	"find":            true,
+	"fetch_more":      true,
+	"output_read":     true,
+	"git_info":        true,
+	"project_map":     true,
+	"csv_query":       true,
+	"doc_read":        true, // TOOL_DOC_OCR_COMMAND must only print its text
+	"get_time":        true,
+	"web_reader":      true,
+	"web_search":      true,
+	"brave_search":    true,
+	"mcp_server_list": true,
+	"watch_list":      true,
+	"update_plan":     true, // per-request, in-memory
+	"walkthrough":     true, // per-request, in-memory
+}
+
+// ReadOnlyCaller is implemented by tools whose calls are read-only for some
+// arguments only (e.g. http_request with GET). In read-only mode such a
+// call runs when ReadOnlyCall reports true and is dry-run otherwise;
+// ReadOnlyHint tells the model which calls remain available.
+type ReadOnlyCaller interface {
+	ReadOnlyCall(args json.RawMessage) bool
+	ReadOnlyHint() string
+}
+
+// SetReadOnly switches the registry into read-only mode: Get and List return
+// mutating tools wrapped so that Execute reports what would have happened
+// without doing it. Applies to the root, so views created by WithExtra and
+// tools registered later (e.g. after MCP reload) are covered as well.
+func (r *Registry) SetReadOnly(on bool) {
+	root := r.root()
+	root.mu.Lock()
+	root.readOnly = on
+	root.mu.Unlock()
+}
+
+// ReadOnly reports whether the registry is in read-only mode.
+func (r *Registry) ReadOnly() bool {
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	return root.readOnly
+}
+
+// wrapReadOnly returns t unchanged unless read-only mode (ro) requires a dry-run.
+func wrapReadOnly(t Tool, ro bool) Tool {
+	if !ro || readOnlyTools[t.Name()] {
+		return t
+	}
+	return &dryRunTool{Tool: t}
+}
+
+// dryRunTool replaces mutating calls with a report in read-only mode.
+type dryRunTool struct {
+	Tool
+}
+
+func (t *dryRunTool) Description() string {
+	if c, ok := t.Tool.(ReadOnlyCaller); ok {
+		return "[只读模式：" + c.ReadOnlyHint() + "] " + t.Tool.Description()
+	}
+	return "[只读模式：不会执行，仅返回预演结果] " + t.Tool.Description()
+}
+
+func (t *dryRunTool) Execute(ctx context.Context, args json.RawMessage) (ToolResult, error) {
+	hint := ""
+	if c, ok := t.Tool.(ReadOnlyCaller); ok {
+		if c.ReadOnlyCall(args) {
+			return t.Tool.Execute(ctx, args)
+		}
+		hint = "（" + c.ReadOnlyHint() + "）"
+	}
+	params := string(args)
+	if runes := []rune(params); len(runes) > 300 {
+		params = string(runes[:300]) + "..."
+	}
+	return ToolResult{Error: fmt.Sprintf("只读模式：%s 未执行（dry-run），实例当前禁止任何修改%s。请求参数: %s", t.Name(), hint, params)}, nil
+}
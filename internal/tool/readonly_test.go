@@ -0,0 +1,103 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// countingTool counts Execute calls.
+type countingTool struct {
+	name  string
+	calls int
+}
+
+func (c *countingTool) Name() string                 { return c.name }
+func (c *countingTool) Description() string          { return "desc" }
+func (c *countingTool) InputSchema() json.RawMessage { return nil }
+func (c *countingTool) Execute(_ context.Context, _ json.RawMessage) (ToolResult, error) {
+	c.calls++
+	return ToolResult{Output: "done"}, nil
+}
+func (c *countingTool) Init(_ context.Context) error { return nil }
+func (c *countingTool) Close() error                 { return nil }
+
+// getOnlyTool is read-only when args contain "get".
+type getOnlyTool struct{ countingTool }
+
+func (g *getOnlyTool) ReadOnlyCall(args json.RawMessage) bool {
+	return strings.Contains(string(args), "get")
+}
+func (g *getOnlyTool) ReadOnlyHint() string { return "仅 get" }
+
+func execTool(t *testing.T, r *Registry, name, args string) ToolResult {
+	t.Helper()
+	tl, ok := r.Get(name)
+	if !ok {
+		t.Fatalf("tool %s not found", name)
+	}
+	res, err := tl.Execute(context.Background(), json.RawMessage(args))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestReadOnly_DryRunsMutatingTools(t *testing.T) {
+	r := NewRegistry()
+	write := &countingTool{name: "file_write"}
+	read := &countingTool{name: "file_read"}
+	r.Register(write)
+	r.Register(read)
+	r.SetReadOnly(true)
+
+	res := execTool(t, r, "file_write", `{"path":"a.txt"}`)
+	if write.calls != 0 || !strings.Contains(res.Error, "只读模式") || !strings.Contains(res.Error, "a.txt") {
+		t.Errorf("file_write should be dry-run, calls=%d result=%+v", write.calls, res)
+	}
+	if res := execTool(t, r, "file_read", `{}`); read.calls != 1 || res.Output != "done" {
+		t.Errorf("file_read should run normally, calls=%d", read.calls)
+	}
+
+	r.SetReadOnly(false)
+	execTool(t, r, "file_write", `{}`)
+	if write.calls != 1 {
+		t.Error("leaving read-only mode should restore normal execution")
+	}
+}
+
+func TestReadOnly_ReadOnlyCaller(t *testing.T) {
+	r := NewRegistry()
+	g := &getOnlyTool{countingTool{name: "http_request"}}
+	r.Register(g)
+	r.SetReadOnly(true)
+
+	execTool(t, r, "http_request", `{"method":"get"}`)
+	if g.calls != 1 {
+		t.Error("read-only call should execute")
+	}
+	res := execTool(t, r, "http_request", `{"method":"post"}`)
+	if g.calls != 1 || !strings.Contains(res.Error, "仅 get") {
+		t.Errorf("mutating call should be dry-run with hint, got %+v", res)
+	}
+}
+
+func TestReadOnly_CoversViewsAndList(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&countingTool{name: "shell_exec"})
+	view := r.WithExtra(&countingTool{name: "mcp_db_insert"})
+	view.SetReadOnly(true) // applies to the root
+
+	if !r.ReadOnly() {
+		t.Fatal("SetReadOnly on a view must switch the root")
+	}
+	for _, tl := range view.List() {
+		if !strings.HasPrefix(tl.Description(), "[只读模式") {
+			t.Errorf("%s description should announce read-only mode: %q", tl.Name(), tl.Description())
+		}
+	}
+	if res := execTool(t, view, "mcp_db_insert", `{}`); res.Error == "" {
+		t.Error("MCP tools are not allowlisted and must be dry-run")
+	}
+}
@@ -2,11 +2,13 @@ package tool
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pocketomega/pocket-omega/internal/llm"
 )
@@ -22,9 +24,72 @@ import (
 // mcp_reload modifies the root registry. Without delegation, unregistered
 // tools would remain visible to the agent.
 type Registry struct {
-	mu     sync.RWMutex
-	tools  map[string]Tool
-	parent *Registry // non-nil → view mode; tools map holds extras only
+	mu       sync.RWMutex
+	tools    map[string]Tool
+	parent   *Registry                // non-nil → view mode; tools map holds extras only
+	allow    []string                 // view only; non-nil → parent tools limited to these patterns (see WithOnly)
+	denied   func(name string)        // view only; called for lookups the allow list refuses (see OnDenied)
+	limiters map[string]*limiter      // root only; per-tool rate limits applied by Get
+	readOnly bool                     // root only; mutating tools are dry-run (see SetReadOnly)
+	cache    *ResultCache             // root only; nil = results are never cached
+	timeouts map[string]time.Duration // root only; nil = DefaultTimeouts (see SetTimeouts)
+	inits    map[string]*toolInit     // root only; Init state of each registered tool
+}
+
+// Tool init states reported by Registry.Health.
+const (
+	HealthPending  = "pending"  // registered; Init runs on first use
+	HealthOK       = "ok"       // Init succeeded
+	HealthDegraded = "degraded" // Init returned ErrDegraded: usable with reduced function
+	HealthFailed   = "failed"   // Init failed: hidden from List and Get
+)
+
+// ErrDegraded marks an Init error after which the tool still works, with
+// reduced function (wrap it: fmt.Errorf("%w: no browser", tool.ErrDegraded)).
+var ErrDegraded = errors.New("degraded")
+
+// toolInitTimeout bounds the lazy Init of a tool on first use.
+const toolInitTimeout = 30 * time.Second
+
+// ToolHealth is the Init state of a registered tool.
+type ToolHealth struct {
+	Status string `json:"status"` // HealthPending, HealthOK, HealthDegraded or HealthFailed
+	Error  string `json:"error,omitempty"`
+}
+
+// toolInit runs a tool's Init once and records the outcome.
+type toolInit struct {
+	once   sync.Once
+	mu     sync.Mutex
+	health ToolHealth
+}
+
+func newToolInit() *toolInit {
+	return &toolInit{health: ToolHealth{Status: HealthPending}}
+}
+
+// run initializes t unless that already happened; false when Init failed.
+func (ti *toolInit) run(ctx context.Context, name string, t Tool) bool {
+	ti.once.Do(func() {
+		h := ToolHealth{Status: HealthOK}
+		if err := t.Init(ctx); err != nil {
+			h = ToolHealth{Status: HealthFailed, Error: err.Error()}
+			if errors.Is(err, ErrDegraded) {
+				h.Status = HealthDegraded
+			}
+			log.Printf("[Registry] Tool %s %s: %v", name, h.Status, err)
+		}
+		ti.mu.Lock()
+		ti.health = h
+		ti.mu.Unlock()
+	})
+	return ti.state().Status != HealthFailed
+}
+
+func (ti *toolInit) state() ToolHealth {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	return ti.health
 }
 
 // NewRegistry creates an empty root tool registry.
@@ -35,7 +100,8 @@ func NewRegistry() *Registry {
 }
 
 // Register adds a tool to the registry. If a tool with the same name already
-// exists, it is overwritten and a warning is logged.
+// exists, it is overwritten and a warning is logged. The tool's Init runs
+// on first use (Get) or in InitAll.
 func (r *Registry) Register(t Tool) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -43,6 +109,18 @@ func (r *Registry) Register(t Tool) {
 		log.Printf("[Registry] WARNING: overwriting existing tool %q", t.Name())
 	}
 	r.tools[t.Name()] = t
+	r.trackInit(t.Name())
+}
+
+// trackInit resets the Init state of a root tool. Callers hold r.mu.
+func (r *Registry) trackInit(name string) {
+	if r.parent != nil {
+		return // view extras are per-request tools without Init
+	}
+	if r.inits == nil {
+		r.inits = make(map[string]*toolInit)
+	}
+	r.inits[name] = newToolInit()
 }
 
 // Unregister removes a tool from the registry (for hot-reload).
@@ -50,36 +128,165 @@ func (r *Registry) Unregister(name string) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	delete(r.tools, name)
+	delete(r.inits, name)
 	log.Printf("[Registry] Unregistered tool: %s", name)
 }
 
+// Replace removes the tools named in remove and registers add in one step,
+// so that no caller sees the registry with only part of the change (config
+// reload). Tools in both sets are simply replaced.
+func (r *Registry) Replace(remove []string, add ...Tool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range remove {
+		delete(r.tools, name)
+		delete(r.inits, name)
+	}
+	for _, t := range add {
+		r.tools[t.Name()] = t
+		r.trackInit(t.Name())
+	}
+}
+
+// SetRateLimit configures a per-tool rate limit (requests/minute and max
+// concurrent calls). Limits are keyed by name, so they also apply to tools
+// registered later (e.g. MCP tools after reload) and to extras in views.
+// A zero RateLimit removes the limit. Limits set on a view apply to its root.
+func (r *Registry) SetRateLimit(name string, l RateLimit) {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	if l.PerMinute <= 0 && l.MaxConcurrent <= 0 {
+		delete(root.limiters, name)
+		return
+	}
+	if root.limiters == nil {
+		root.limiters = make(map[string]*limiter)
+	}
+	root.limiters[name] = newLimiter(l)
+}
+
+// SetResultCache enables caching of Cacheable tools' results (nil disables
+// it). Set on a view, it applies to the root.
+func (r *Registry) SetResultCache(c *ResultCache) {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.cache = c
+}
+
+// ResultCache returns the registry's result cache, or nil.
+func (r *Registry) ResultCache() *ResultCache {
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	return root.cache
+}
+
 // Get retrieves a tool by name.
 // For view registries: checks extras first, then delegates to parent.
+// Tools with a configured rate limit are returned wrapped by their limiter;
+// in read-only mode mutating tools are returned as dry-runs. Cacheable
+// tools with a cache TTL are served from the result cache, in front of the
+// limiter so that cache hits do not count against the rate limit. A tool
+// is initialized on its first Get; one whose Init failed is not found.
 func (r *Registry) Get(name string) (Tool, bool) {
+	orig, ok := r.lookup(name)
+	if !ok || !r.ready(name, orig) {
+		return nil, false
+	}
+	ro := r.ReadOnly()
+	t := wrapReadOnly(orig, ro)
+	root := r.root()
+	root.mu.RLock()
+	lim := root.limiters[name]
+	cache := root.cache
+	root.mu.RUnlock()
+	if lim != nil {
+		t = &rateLimitedTool{Tool: t, lim: lim}
+	}
+	if fp, ok := orig.(Cacheable); ok && cache.TTL(name) > 0 && (!ro || readOnlyTools[name]) {
+		t = &cachedTool{Tool: t, fp: fp, cache: cache}
+	}
+	return t, true
+}
+
+// lookup resolves name through the view chain without rate-limit wrapping.
+func (r *Registry) lookup(name string) (Tool, bool) {
 	r.mu.RLock()
 	t, ok := r.tools[name]
 	r.mu.RUnlock()
 	if ok {
 		return t, true
 	}
-	if r.parent != nil {
-		return r.parent.Get(name)
+	if r.parent == nil {
+		return nil, false
+	}
+	if r.allows(name) {
+		return r.parent.lookup(name)
+	}
+	if r.denied != nil {
+		if _, exists := r.parent.lookup(name); exists {
+			r.denied(name)
+		}
 	}
 	return nil, false
 }
 
-// List returns all registered tools sorted by name.
+// ready runs the lazy Init of the root tool t; false when it failed.
+// Extras of views are not tracked.
+func (r *Registry) ready(name string, t Tool) bool {
+	for v := r; v.parent != nil; v = v.parent {
+		v.mu.RLock()
+		_, extra := v.tools[name]
+		v.mu.RUnlock()
+		if extra {
+			return true
+		}
+	}
+	root := r.root()
+	root.mu.RLock()
+	ti := root.inits[name]
+	root.mu.RUnlock()
+	if ti == nil {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), toolInitTimeout)
+	defer cancel()
+	return ti.run(ctx, name, t)
+}
+
+// failed reports whether a root tool's Init failed. Callers hold r.mu.
+func (r *Registry) failed(name string) bool {
+	ti := r.inits[name]
+	return ti != nil && ti.state().Status == HealthFailed
+}
+
+// root returns the root registry of a view chain.
+func (r *Registry) root() *Registry {
+	for r.parent != nil {
+		r = r.parent
+	}
+	return r
+}
+
+// List returns all registered tools sorted by name, without the tools
+// whose Init failed, so prompts and tool definitions never offer them.
 // For view registries: merges parent tools with extras (extras override parent).
 func (r *Registry) List() []Tool {
 	if r.parent != nil {
 		return r.listView()
 	}
+	ro := r.ReadOnly() // read before r.mu: recursive RLock can deadlock
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	result := make([]Tool, 0, len(r.tools))
-	for _, t := range r.tools {
-		result = append(result, t)
+	for name, t := range r.tools {
+		if r.failed(name) {
+			continue
+		}
+		result = append(result, wrapReadOnly(t, ro))
 	}
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Name() < result[j].Name()
@@ -91,6 +298,7 @@ func (r *Registry) List() []Tool {
 // Extras take precedence over parent tools with the same name.
 func (r *Registry) listView() []Tool {
 	parentTools := r.parent.List()
+	ro := r.ReadOnly()
 
 	r.mu.RLock()
 	extras := make(map[string]Tool, len(r.tools))
@@ -102,12 +310,12 @@ func (r *Registry) listView() []Tool {
 	// Build merged list: parent tools (excluding overridden) + extras
 	result := make([]Tool, 0, len(parentTools)+len(extras))
 	for _, t := range parentTools {
-		if _, overridden := extras[t.Name()]; !overridden {
+		if _, overridden := extras[t.Name()]; !overridden && r.allows(t.Name()) {
 			result = append(result, t)
 		}
 	}
 	for _, t := range extras {
-		result = append(result, t)
+		result = append(result, wrapReadOnly(t, ro))
 	}
 	sort.Slice(result, func(i, j int) bool {
 		return result[i].Name() < result[j].Name()
@@ -150,18 +358,46 @@ func (r *Registry) GenerateToolDefinitions() []llm.ToolDefinition {
 	return defs
 }
 
-// InitAll initializes all registered tools.
-func (r *Registry) InitAll(ctx context.Context) error {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+// InitAll runs the Init of every registered tool that has not run yet,
+// so failed tools drop out of prompts before the first run, and returns
+// the health report. A failing tool no longer stops the others: it is
+// reported and hidden. Tools registered later are initialized on first use.
+func (r *Registry) InitAll(ctx context.Context) map[string]ToolHealth {
+	root := r.root()
+	root.mu.RLock()
+	pending := make(map[string]Tool, len(root.tools))
+	inits := make(map[string]*toolInit, len(root.tools))
+	for name, t := range root.tools {
+		if ti := root.inits[name]; ti != nil {
+			pending[name], inits[name] = t, ti
+		}
+	}
+	root.mu.RUnlock()
 
-	for name, t := range r.tools {
-		if err := t.Init(ctx); err != nil {
-			return fmt.Errorf("init tool %q: %w", name, err)
+	for name, t := range pending {
+		inits[name].run(ctx, name, t)
+	}
+	report := r.Health()
+	failed := 0
+	for _, h := range report {
+		if h.Status == HealthFailed {
+			failed++
 		}
 	}
-	log.Printf("[Registry] Initialized %d tools", len(r.tools))
-	return nil
+	log.Printf("[Registry] Initialized %d tools (%d failed)", len(report)-failed, failed)
+	return report
+}
+
+// Health returns the Init state of every registered tool by name.
+func (r *Registry) Health() map[string]ToolHealth {
+	root := r.root()
+	root.mu.RLock()
+	defer root.mu.RUnlock()
+	report := make(map[string]ToolHealth, len(root.inits))
+	for name, ti := range root.inits {
+		report[name] = ti.state()
+	}
+	return report
 }
 
 // CloseAll closes all registered tools, logging errors but not failing.
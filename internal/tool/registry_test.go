@@ -3,6 +3,9 @@ package tool
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -200,3 +203,96 @@ func TestRegistry_WithExtra_ChainedDelegation(t *testing.T) {
 		t.Error("grandchild should still see its own extras")
 	}
 }
+
+func TestRegistry_Replace(t *testing.T) {
+	r := NewRegistry()
+	r.Register(&dummyTool{name: "web_search"})
+	r.Register(&dummyTool{name: "file_read"})
+	view := r.WithExtra(&dummyTool{name: "extra"})
+
+	brave := &dummyTool{name: "brave_search"}
+	r.Replace([]string{"web_search", "brave_search"}, brave)
+
+	if _, ok := view.Get("web_search"); ok {
+		t.Error("removed tool still visible through a view")
+	}
+	if got, ok := view.Get("brave_search"); !ok || got != brave {
+		t.Error("added tool not visible through a view")
+	}
+	if _, ok := r.Get("file_read"); !ok {
+		t.Error("unrelated tool removed")
+	}
+}
+
+// initTool counts Init calls and fails Init with err.
+type initTool struct {
+	dummyTool
+	err   error
+	inits int
+}
+
+func (d *initTool) Init(_ context.Context) error {
+	d.inits++
+	return d.err
+}
+
+func TestRegistry_InitAllReportsHealth(t *testing.T) {
+	r := NewRegistry()
+	ok := &initTool{dummyTool: dummyTool{name: "ok"}}
+	broken := &initTool{dummyTool: dummyTool{name: "search"}, err: errors.New("API key 未配置")}
+	degraded := &initTool{dummyTool: dummyTool{name: "reader"}, err: fmt.Errorf("%w: no browser", ErrDegraded)}
+	r.Register(ok)
+	r.Register(broken)
+	r.Register(degraded)
+
+	if h := r.Health()["ok"]; h.Status != HealthPending {
+		t.Errorf("before init: %+v, want pending", h)
+	}
+	report := r.InitAll(context.Background())
+	if report["ok"].Status != HealthOK || report["reader"].Status != HealthDegraded {
+		t.Errorf("report = %+v", report)
+	}
+	if h := report["search"]; h.Status != HealthFailed || !strings.Contains(h.Error, "API key") {
+		t.Errorf("search = %+v, want failed with the error", h)
+	}
+
+	if _, found := r.Get("search"); found {
+		t.Error("failed tool should not be returned by Get")
+	}
+	if _, found := r.Get("reader"); !found {
+		t.Error("degraded tool should stay usable")
+	}
+	for _, tl := range r.List() {
+		if tl.Name() == "search" {
+			t.Error("failed tool should be left out of List")
+		}
+	}
+	r.InitAll(context.Background())
+	if ok.inits != 1 || broken.inits != 1 {
+		t.Errorf("Init ran %d/%d times, want once", ok.inits, broken.inits)
+	}
+}
+
+func TestRegistry_LazyInitOnGet(t *testing.T) {
+	r := NewRegistry()
+	lazy := &initTool{dummyTool: dummyTool{name: "lazy"}}
+	r.Register(lazy)
+	if _, found := r.WithExtra(&dummyTool{name: "extra"}).Get("lazy"); !found || lazy.inits != 1 {
+		t.Fatalf("Get through a view: found=%v inits=%d", found, lazy.inits)
+	}
+	r.Get("lazy")
+	if lazy.inits != 1 || r.Health()["lazy"].Status != HealthOK {
+		t.Errorf("inits = %d, health = %+v", lazy.inits, r.Health()["lazy"])
+	}
+
+	// Re-registering resets the state so the new instance is initialized
+	again := &initTool{dummyTool: dummyTool{name: "lazy"}, err: errors.New("boom")}
+	r.Register(again)
+	if _, found := r.Get("lazy"); found || again.inits != 1 {
+		t.Errorf("replacement: found=%v inits=%d", found, again.inits)
+	}
+	r.Unregister("lazy")
+	if _, tracked := r.Health()["lazy"]; tracked {
+		t.Error("unregistered tool still in the health report")
+	}
+}
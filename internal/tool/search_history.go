@@ -0,0 +1,135 @@
+package tool
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// searchTitleMinRunes is the shortest normalized title compared across
+// results; shorter ones ("Home", "Docs") are too generic to mean "same page".
+const searchTitleMinRunes = 12
+
+// SearchHistory remembers the search results shown during one agent run so
+// search tools can drop results the model has already seen. Results count
+// as the same when their normalized URL or title matches. Safe for
+// concurrent use; a nil *SearchHistory remembers nothing.
+type SearchHistory struct {
+	mu     sync.Mutex
+	calls  int
+	urls   map[string]int // normalized URL → call that first showed it
+	titles map[string]int // normalized title → call that first showed it
+}
+
+// NewSearchHistory creates an empty history.
+func NewSearchHistory() *SearchHistory {
+	return &SearchHistory{urls: make(map[string]int), titles: make(map[string]int)}
+}
+
+type searchHistoryKey struct{}
+
+// WithSearchHistory returns ctx carrying h for the search tools.
+func WithSearchHistory(ctx context.Context, h *SearchHistory) context.Context {
+	return context.WithValue(ctx, searchHistoryKey{}, h)
+}
+
+// SearchHistoryFrom returns the history carried by ctx, or nil.
+func SearchHistoryFrom(ctx context.Context) *SearchHistory {
+	h, _ := ctx.Value(searchHistoryKey{}).(*SearchHistory)
+	return h
+}
+
+// BeginCall starts a search call and returns its 1-based number.
+func (h *SearchHistory) BeginCall() int {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	return h.calls
+}
+
+// Seen records a result shown by call and reports the call that showed the
+// same result first, with dup set when that was this or an earlier call.
+func (h *SearchHistory) Seen(call int, rawURL, title string) (first int, dup bool) {
+	if h == nil {
+		return 0, false
+	}
+	u, t := normalizeSearchURL(rawURL), normalizeSearchTitle(title)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c, ok := h.urls[u]; ok && u != "" {
+		return c, true
+	}
+	if c, ok := h.titles[t]; ok && t != "" {
+		return c, true
+	}
+	if u != "" {
+		h.urls[u] = call
+	}
+	if t != "" {
+		h.titles[t] = call
+	}
+	return call, false
+}
+
+// searchTrackingParams are query parameters that never change the page.
+var searchTrackingParams = map[string]bool{
+	"ref": true, "fbclid": true, "gclid": true, "msclkid": true, "spm": true,
+}
+
+// normalizeSearchURL reduces a result URL to what identifies the page:
+// no scheme, fragment, "www."/"m." host prefix, trailing slash or tracking
+// parameters (utm_*, fbclid, …), and sorted query parameters.
+func normalizeSearchURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.ToLower(strings.TrimSpace(raw))
+	}
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "www."), "m.")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	var params []string
+	for k, vs := range u.Query() {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "utm_") || searchTrackingParams[lk] {
+			continue
+		}
+		for _, v := range vs {
+			params = append(params, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	sort.Strings(params)
+	key := host + path
+	if len(params) > 0 {
+		key += "?" + strings.Join(params, "&")
+	}
+	return key
+}
+
+// normalizeSearchTitle lowercases a title to its letters and digits,
+// dropping a trailing " - Site" / " | Site" suffix; "" when too short to
+// compare.
+func normalizeSearchTitle(title string) string {
+	for _, sep := range []string{" | ", " - ", " — ", " – "} {
+		if i := strings.LastIndex(title, sep); i > 0 {
+			title = title[:i]
+		}
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+			n++
+		}
+	}
+	if n < searchTitleMinRunes {
+		return ""
+	}
+	return sb.String()
+}
@@ -0,0 +1,46 @@
+package tool
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearchHistory(t *testing.T) {
+	h := NewSearchHistory()
+	if SearchHistoryFrom(context.Background()) != nil {
+		t.Error("history from a bare context")
+	}
+	if SearchHistoryFrom(WithSearchHistory(context.Background(), h)) != h {
+		t.Error("history not carried by the context")
+	}
+
+	c1 := h.BeginCall()
+	if _, dup := h.Seen(c1, "https://www.example.com/docs/?utm_source=a&b=2&a=1#intro", "Example docs"); dup {
+		t.Error("first result reported as a repeat")
+	}
+	if first, dup := h.Seen(c1, "http://example.com/docs?a=1&b=2", "Other title"); !dup || first != c1 {
+		t.Errorf("normalized URL repeat = %d, %v", first, dup)
+	}
+	if _, dup := h.Seen(c1, "https://example.com/docs?a=2&b=2", "Example docs"); dup {
+		t.Error("short titles must not match across different URLs")
+	}
+	if _, dup := h.Seen(c1, "https://a.example/post", "Understanding Go generics - Blog A"); dup {
+		t.Error("new long title reported as a repeat")
+	}
+
+	c2 := h.BeginCall()
+	if c2 != c1+1 {
+		t.Errorf("call numbers %d, %d", c1, c2)
+	}
+	if first, dup := h.Seen(c2, "https://b.example/mirror", "Understanding Go Generics | Mirror B"); !dup || first != c1 {
+		t.Errorf("title repeat across sites = %d, %v", first, dup)
+	}
+
+	var nilHistory *SearchHistory
+	if nilHistory.BeginCall() != 0 {
+		t.Error("nil history counted a call")
+	}
+	if _, dup := nilHistory.Seen(1, "https://example.com", "Example"); dup {
+		t.Error("nil history reported a repeat")
+	}
+}
@@ -0,0 +1,100 @@
+package tool
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// CodeTimeout marks a ToolResult whose call was abandoned at its deadline.
+const CodeTimeout = "TIMEOUT"
+
+// DefaultTimeouts bound a single tool call unless TOOL_TIMEOUTS overrides
+// them. Keys are tool names or path.Match patterns ("mcp_*"); "*" covers
+// every other tool. Tools with an internal limit of their own (shell_exec,
+// python_exec, git_ops) get a margin above it, so the deadline only fires
+// when the tool itself hangs.
+var DefaultTimeouts = map[string]time.Duration{
+	"*":            2 * time.Minute,
+	"mcp_*":        90 * time.Second,
+	"shell_exec":   time.Minute,
+	"python_exec":  150 * time.Second,
+	"http_request": 45 * time.Second,
+	"web_reader":   30 * time.Second,
+	"web_search":   30 * time.Second,
+	"brave_search": 30 * time.Second,
+	"git_ops":      90 * time.Second,
+	"code_search":  3 * time.Minute,
+	"update_plan":  0, // waits for the user when plans need confirmation, with a limit of its own
+}
+
+// SetTimeouts replaces the per-tool timeout table (see DefaultTimeouts for
+// the keys). A zero duration means no deadline; an empty table disables
+// timeouts, nil restores the defaults. Set on a view, it applies to its root.
+func (r *Registry) SetTimeouts(timeouts map[string]time.Duration) {
+	root := r.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.timeouts = timeouts
+}
+
+// Timeout returns the deadline of one call of the named tool, or 0 for
+// none. An exact name wins over patterns, the longest matching pattern
+// over shorter ones, and "*" applies last.
+func (r *Registry) Timeout(name string) time.Duration {
+	root := r.root()
+	root.mu.RLock()
+	table := root.timeouts
+	root.mu.RUnlock()
+	if table == nil {
+		table = DefaultTimeouts
+	}
+	if d, ok := table[name]; ok {
+		return d
+	}
+	best := ""
+	for pattern := range table {
+		if pattern == "*" || len(pattern) <= len(best) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			best = pattern
+		}
+	}
+	if best != "" {
+		return table[best]
+	}
+	return table["*"]
+}
+
+// ParseTimeouts parses a TOOL_TIMEOUTS spec: comma-separated entries
+// "name=DURATION", e.g. "shell_exec=5m,mcp_*=30s,*=3m". Use 0 for no
+// deadline ("code_search=0").
+func ParseTimeouts(spec string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, val, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid timeout entry %q (want name=duration)", entry)
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("invalid tool pattern in %q", entry)
+		}
+		val = strings.TrimSpace(val)
+		var d time.Duration
+		if val != "0" {
+			var err error
+			if d, err = time.ParseDuration(val); err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid duration in %q", entry)
+			}
+		}
+		timeouts[name] = d
+	}
+	return timeouts, nil
+}
@@ -0,0 +1,59 @@
+package tool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeouts(t *testing.T) {
+	got, err := ParseTimeouts(" shell_exec=5m, mcp_*=30s ,code_search=0,*=3m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]time.Duration{"shell_exec": 5 * time.Minute, "mcp_*": 30 * time.Second, "code_search": 0, "*": 3 * time.Minute}
+	for name, d := range want {
+		if got[name] != d {
+			t.Errorf("%s = %v, want %v", name, got[name], d)
+		}
+	}
+	for _, bad := range []string{"shell_exec", "=5m", "shell_exec=fast", "shell_exec=-1s", "[=1s"} {
+		if _, err := ParseTimeouts(bad); err == nil {
+			t.Errorf("ParseTimeouts(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := NewRegistry()
+	if got := r.Timeout("shell_exec"); got != DefaultTimeouts["shell_exec"] {
+		t.Errorf("default shell_exec = %v", got)
+	}
+	if got := r.Timeout("mcp_fs__read"); got != DefaultTimeouts["mcp_*"] {
+		t.Errorf("default mcp pattern = %v", got)
+	}
+	if got := r.Timeout("file_read"); got != DefaultTimeouts["*"] {
+		t.Errorf("default fallback = %v", got)
+	}
+
+	view := r.WithExtra(&dummyTool{name: "update_plan"})
+	view.SetTimeouts(map[string]time.Duration{
+		"mcp_*":         time.Minute,
+		"mcp_slow__*":   5 * time.Minute,
+		"mcp_slow__run": 0,
+	})
+	for name, want := range map[string]time.Duration{
+		"mcp_fs__read":   time.Minute,
+		"mcp_slow__scan": 5 * time.Minute, // longest pattern wins
+		"mcp_slow__run":  0,               // exact name wins
+		"file_read":      0,               // no "*": no deadline
+	} {
+		if got := r.Timeout(name); got != want {
+			t.Errorf("Timeout(%s) = %v, want %v", name, got, want)
+		}
+	}
+
+	r.SetTimeouts(nil)
+	if got := r.Timeout("file_read"); got != DefaultTimeouts["*"] {
+		t.Errorf("after reset = %v", got)
+	}
+}
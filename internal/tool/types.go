@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
 )
 
 // Tool is the unified interface for all tools.
@@ -34,8 +36,23 @@ type Tool interface {
 type ToolResult struct {
 	Output string `json:"output"`
 	Error  string `json:"error,omitempty"`
+	// RetryAfterSec is set when the call was rejected by a rate limit;
+	// the tool may be retried after this many seconds.
+	RetryAfterSec int `json:"retry_after_sec,omitempty"`
+	// Code classifies a failure for the agent, e.g. CodeTimeout.
+	Code string `json:"code,omitempty"`
+	// Images are attached to the next LLM request (e.g. image_read);
+	// dropped with a note for models without vision support.
+	Images []llm.ContentPart `json:"-"`
+	// NoCache keeps the result out of the ResultCache, e.g. a partial read
+	// or a first page whose fetch_more token can only be used once.
+	NoCache bool `json:"-"`
 }
 
+// CodeNotFound marks a call of a tool the run's registry does not offer:
+// unknown, or hidden by a tool profile or the user's role.
+const CodeNotFound = "NOT_FOUND"
+
 // SchemaParam describes a single parameter for the SchemaBuilder helper.
 type SchemaParam struct {
 	Name        string   `json:"name"`
@@ -0,0 +1,205 @@
+package walkthrough
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// archiveTimeLayout is the timestamp prefix of archived memo file names.
+const archiveTimeLayout = "20060102-150405"
+
+// ErrNotFound is returned by Archive.Get for an unknown memo ID.
+var ErrNotFound = errors.New("walkthrough: memo not found")
+
+// archiveIDPattern matches the IDs produced by Archive.Save: timestamp,
+// session hash and an optional counter for same-second runs.
+var archiveIDPattern = regexp.MustCompile(`^\d{8}-\d{6}-[0-9a-f]{8}(-\d+)?$`)
+
+// Archive persists the memos of finished runs as timestamped markdown
+// files (YYYYMMDD-HHMMSS-<session hash>.md) with a small YAML front matter,
+// so the user can review what the agent learned in past runs. The files
+// are plain markdown and stay readable without Pocket-Omega.
+type Archive struct {
+	dir string
+	now func() time.Time // overridden in tests
+}
+
+// Memo is one archived run: its metadata and, from Get, its entries.
+type Memo struct {
+	ID        string    `json:"id"`
+	Time      time.Time `json:"time"`
+	SessionID string    `json:"session_id,omitempty"`
+	Problem   string    `json:"problem"`
+	Count     int       `json:"count"`             // number of entries
+	Entries   []Entry   `json:"entries,omitempty"` // filled by Get only
+}
+
+// memoFrontMatter is the YAML header of an archived memo file.
+type memoFrontMatter struct {
+	Session string    `yaml:"session,omitempty"`
+	Time    time.Time `yaml:"time"`
+	Problem string    `yaml:"problem"`
+	Count   int       `yaml:"count"`
+}
+
+// NewArchive creates an archive in dir. The directory is created on the
+// first Save.
+func NewArchive(dir string) *Archive {
+	return &Archive{dir: dir, now: time.Now}
+}
+
+// Dir returns the archive directory.
+func (a *Archive) Dir() string { return a.dir }
+
+// Save writes the memo of a finished run and returns its ID. Runs without
+// entries are not archived (ID ""). Session IDs come from clients, so the
+// file name carries a hash rather than the ID itself.
+func (a *Archive) Save(sessionID, problem string, entries []Entry) (string, error) {
+	if len(entries) == 0 {
+		return "", nil
+	}
+	if err := os.MkdirAll(a.dir, 0o755); err != nil {
+		return "", fmt.Errorf("walkthrough: %w", err)
+	}
+	now := a.now()
+	sum := sha256.Sum256([]byte(sessionID))
+	base := now.Format(archiveTimeLayout) + "-" + hex.EncodeToString(sum[:4])
+
+	header, err := yaml.Marshal(memoFrontMatter{Session: sessionID, Time: now, Problem: problem, Count: len(entries)})
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "---\n%s---\n\n# 备忘录 %s\n\n", header, now.Format("2006-01-02 15:04:05"))
+	for _, e := range entries {
+		sb.WriteString(strings.ReplaceAll(entryLine(e), "\n", "\n  ") + "\n")
+	}
+
+	// O_EXCL: two runs finishing in the same second get distinct files
+	for n := 1; ; n++ {
+		id := base
+		if n > 1 {
+			id += "-" + strconv.Itoa(n)
+		}
+		f, err := os.OpenFile(filepath.Join(a.dir, id+".md"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("walkthrough: %w", err)
+		}
+		_, err = f.WriteString(sb.String())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return "", fmt.Errorf("walkthrough: %w", err)
+		}
+		return id, nil
+	}
+}
+
+// List returns the archived memos without their entries, newest first.
+// Files that cannot be parsed are skipped.
+func (a *Archive) List() ([]Memo, error) {
+	files, err := os.ReadDir(a.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("walkthrough: %w", err)
+	}
+	var memos []Memo
+	for _, f := range files {
+		id, ok := strings.CutSuffix(f.Name(), ".md")
+		if !ok || f.IsDir() || !archiveIDPattern.MatchString(id) {
+			continue
+		}
+		m, err := a.load(id, false)
+		if err != nil {
+			continue
+		}
+		memos = append(memos, *m)
+	}
+	sort.Slice(memos, func(i, j int) bool { return memos[i].ID > memos[j].ID })
+	return memos, nil
+}
+
+// Get returns the archived memo id with its entries, or ErrNotFound.
+func (a *Archive) Get(id string) (*Memo, error) {
+	if !archiveIDPattern.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	return a.load(id, true)
+}
+
+// load parses the memo file id; the entries only when withEntries is set.
+func (a *Archive) load(id string, withEntries bool) (*Memo, error) {
+	data, err := os.ReadFile(filepath.Join(a.dir, id+".md"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("walkthrough: %w", err)
+	}
+	rest, ok := strings.CutPrefix(string(data), "---\n")
+	if !ok {
+		return nil, fmt.Errorf("walkthrough: %s: missing front matter", id)
+	}
+	header, body, ok := strings.Cut(rest, "\n---\n")
+	if !ok {
+		return nil, fmt.Errorf("walkthrough: %s: missing front matter", id)
+	}
+	var fm memoFrontMatter
+	if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+		return nil, fmt.Errorf("walkthrough: %s: %w", id, err)
+	}
+	m := &Memo{ID: id, Time: fm.Time, SessionID: fm.Session, Problem: fm.Problem, Count: fm.Count}
+	if withEntries {
+		m.Entries = parseEntries(body)
+	}
+	return m, nil
+}
+
+// parseEntries reads back the entry list written by Save: "- 📌 text"
+// (manual) and "- [步骤N] text" (auto) items with indented continuation
+// lines.
+func parseEntries(body string) []Entry {
+	var entries []Entry
+	sc := bufio.NewScanner(strings.NewReader(body))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if cont, ok := strings.CutPrefix(line, "  "); ok && len(entries) > 0 {
+			entries[len(entries)-1].Content += "\n" + cont
+			continue
+		}
+		item, ok := strings.CutPrefix(line, "- ")
+		if !ok {
+			continue
+		}
+		if text, ok := strings.CutPrefix(item, "📌 "); ok {
+			entries = append(entries, Entry{Source: SourceManual, Content: text})
+			continue
+		}
+		if rest, ok := strings.CutPrefix(item, "[步骤"); ok {
+			num, text, ok := strings.Cut(rest, "] ")
+			if n, err := strconv.Atoi(num); ok && err == nil {
+				entries = append(entries, Entry{StepNumber: n, Source: SourceAuto, Content: text})
+			}
+		}
+	}
+	return entries
+}
@@ -0,0 +1,72 @@
+package walkthrough
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestArchive_SaveListGet(t *testing.T) {
+	a := NewArchive(filepath.Join(t.TempDir(), "walkthroughs"))
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.Local)
+	a.now = func() time.Time { return now }
+
+	if id, err := a.Save("s1", "nothing learned", nil); id != "" || err != nil {
+		t.Fatalf("empty run: id %q, err %v", id, err)
+	}
+	entries := []Entry{
+		{StepNumber: 2, Source: SourceAuto, Content: "file_read: config.yaml has 3 services"},
+		{Source: SourceManual, Content: "port 8080 is taken\nuse 8081"},
+	}
+	first, err := a.Save("s1", "deploy: the service", entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := a.Save("s2", "second run", entries[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first, "20260301-093000-") || first == second {
+		t.Errorf("ids = %q, %q", first, second)
+	}
+	data, _ := os.ReadFile(filepath.Join(a.Dir(), first+".md"))
+	if !strings.Contains(string(data), "- 📌 port 8080 is taken\n  use 8081\n") {
+		t.Errorf("file:\n%s", data)
+	}
+
+	memos, err := a.List()
+	if err != nil || len(memos) != 2 {
+		t.Fatalf("List() = %+v, %v", memos, err)
+	}
+	if memos[0].Entries != nil || memos[0].Count == 0 {
+		t.Errorf("list entry = %+v", memos[0])
+	}
+
+	m, err := a.Get(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.SessionID != "s1" || m.Problem != "deploy: the service" || !m.Time.Equal(now) || m.Count != 2 {
+		t.Errorf("memo = %+v", m)
+	}
+	if !reflect.DeepEqual(m.Entries, entries) {
+		t.Errorf("entries = %+v", m.Entries)
+	}
+
+	for _, id := range []string{"../secret", "20260301-093000-00000000", ""} {
+		if _, err := a.Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) err = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestArchive_ListMissingDir(t *testing.T) {
+	memos, err := NewArchive(filepath.Join(t.TempDir(), "none")).List()
+	if memos != nil || err != nil {
+		t.Errorf("List() = %v, %v", memos, err)
+	}
+}
@@ -76,6 +76,20 @@ func (s *Store) Get(sessionID string) []Entry {
 	return cp
 }
 
+// Copy replaces the entries of session to with those of session from
+// (session fork). Returns the number of entries copied.
+func (s *Store) Copy(from, to string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := s.entries[from]
+	if len(entries) == 0 {
+		delete(s.entries, to)
+		return 0
+	}
+	s.entries[to] = append([]Entry(nil), entries...)
+	return len(entries)
+}
+
 // Delete removes all entries for a session (cleanup on request end).
 func (s *Store) Delete(sessionID string) {
 	s.mu.Lock()
@@ -94,11 +108,16 @@ func (s *Store) Render(sessionID string) string {
 	var sb strings.Builder
 	sb.WriteString("## 备忘录\n")
 	for _, e := range entries {
-		if e.Source == SourceManual {
-			sb.WriteString(fmt.Sprintf("- 📌 %s\n", e.Content))
-		} else {
-			sb.WriteString(fmt.Sprintf("- [步骤%d] %s\n", e.StepNumber, e.Content))
-		}
+		sb.WriteString(entryLine(e) + "\n")
 	}
 	return sb.String()
 }
+
+// entryLine formats one entry as a markdown list item: pinned manual
+// entries with 📌, auto entries with their step number.
+func entryLine(e Entry) string {
+	if e.Source == SourceManual {
+		return "- 📌 " + e.Content
+	}
+	return fmt.Sprintf("- [步骤%d] %s", e.StepNumber, e.Content)
+}
@@ -0,0 +1,96 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// webhookPayload is the JSON body posted by PostWebhook.
+type webhookPayload struct {
+	WatchID   string    `json:"watch_id"`
+	SessionID string    `json:"session_id"`
+	Path      string    `json:"path"`
+	Pattern   string    `json:"pattern"`
+	Line      string    `json:"line"`
+	Count     int       `json:"count"`
+	Time      time.Time `json:"time"`
+	Message   string    `json:"message"`
+	Text      string    `json:"text"` // same as Message; Slack-compatible incoming webhooks read "text"
+}
+
+// PostWebhook posts ev as JSON to url.
+func PostWebhook(ctx context.Context, url string, ev Event, message string) error {
+	body, err := json.Marshal(webhookPayload{
+		WatchID:   ev.Watch.ID,
+		SessionID: ev.Watch.SessionID,
+		Path:      ev.Watch.Display,
+		Pattern:   ev.Watch.Pattern,
+		Line:      ev.Line,
+		Count:     ev.Count,
+		Time:      ev.Time,
+		Message:   message,
+		Text:      message,
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("watch webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("watch webhook: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// DesktopNotify shows a desktop notification using the platform's CLI
+// (notify-send, osascript or PowerShell). Fails when none is available.
+func DesktopNotify(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd":
+		cmd = exec.Command("notify-send", title, message)
+	case "darwin":
+		cmd = exec.Command("osascript", "-e",
+			fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title)))
+	case "windows":
+		script := `[void][System.Reflection.Assembly]::LoadWithPartialName('System.Windows.Forms');` +
+			`$n=New-Object System.Windows.Forms.NotifyIcon;$n.Icon=[System.Drawing.SystemIcons]::Information;` +
+			`$n.Visible=$true;$n.ShowBalloonTip(10000,$env:OMEGA_TITLE,$env:OMEGA_MSG,'Info');Start-Sleep -s 10;$n.Dispose()`
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", script)
+		cmd.Env = append(cmd.Environ(), "OMEGA_TITLE="+title, "OMEGA_MSG="+message)
+		// Do not block: the balloon needs the process alive for a while.
+		if err := cmd.Start(); err != nil {
+			return fmt.Errorf("desktop notify: %w", err)
+		}
+		go cmd.Wait()
+		return nil
+	default:
+		return fmt.Errorf("desktop notifications not supported on %s", runtime.GOOS)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("desktop notify: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,377 @@
+// Package watch notifies chat sessions when a file gains content matching a
+// pattern ("tell me when logs/error.log contains OOM"). Files are followed
+// like `tail -F`: only content appended after the watch was added is
+// matched, truncation and rotation restart from the beginning of the file.
+package watch
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// pollInterval rescans all watched files in case the platform drops
+	// fsnotify events (network filesystems, some container mounts).
+	pollInterval = 30 * time.Second
+
+	// maxScanBytes bounds how much new content one scan reads; a file that
+	// grows faster than this between events is skipped ahead.
+	maxScanBytes = 4 << 20
+
+	// maxPartialLine caps a pending line without newline before it is matched anyway.
+	maxPartialLine = 64 << 10
+
+	// existingScanBytes is how much of the file's tail Add checks for a match
+	// that is already present.
+	existingScanBytes = 256 << 10
+)
+
+// ErrNotFound is returned by Remove for unknown (or foreign) watch IDs.
+var ErrNotFound = errors.New("watch not found")
+
+// Watch describes one file condition.
+type Watch struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"session_id"`
+	Path      string    `json:"path"`    // absolute path of the watched file
+	Display   string    `json:"display"` // path as given by the user, used in messages
+	Pattern   string    `json:"pattern"`
+	Regex     bool      `json:"regex"`  // false = case-sensitive substring
+	Repeat    bool      `json:"repeat"` // false = removed after the first trigger
+	CreatedAt time.Time `json:"created_at"`
+	Triggers  int       `json:"triggers"`
+}
+
+// Event is delivered to Options.OnTrigger when a watch matches.
+type Event struct {
+	Watch Watch
+	Line  string // first matching line
+	Count int    // matching lines in this batch of new content
+	Time  time.Time
+}
+
+// Options configures a Manager.
+type Options struct {
+	MaxWatches int           // 0 = unlimited; total across all sessions
+	Cooldown   time.Duration // minimum gap between triggers of a repeating watch
+	OnTrigger  func(Event)   // called without locks held, from the manager goroutine
+}
+
+// Manager owns the fsnotify watcher and all watches. Safe for concurrent use.
+type Manager struct {
+	opts Options
+	fsw  *fsnotify.Watcher
+
+	mu      sync.Mutex
+	watches map[string]*entry
+	dirs    map[string]int // watched directory → number of watches in it
+	nextID  int
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// entry is a Watch plus its scan state.
+type entry struct {
+	Watch
+	re      *regexp.Regexp // nil for substring patterns
+	offset  int64
+	partial []byte // trailing content without newline, carried to the next scan
+	last    time.Time
+}
+
+// NewManager starts the event loop. Call Close to stop it.
+func NewManager(opts Options) (*Manager, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("watch: %w", err)
+	}
+	m := &Manager{
+		opts:    opts,
+		fsw:     fsw,
+		watches: make(map[string]*entry),
+		dirs:    make(map[string]int),
+		done:    make(chan struct{}),
+	}
+	m.wg.Add(1)
+	go m.loop()
+	return m, nil
+}
+
+// Add registers w (ID, CreatedAt and Triggers are assigned). The file does
+// not need to exist yet; its directory does. The returned string is a line
+// from the current end of the file that already matches, so the caller can
+// tell the user the condition holds right now ("" when none).
+func (m *Manager) Add(w Watch) (Watch, string, error) {
+	if strings.TrimSpace(w.Pattern) == "" {
+		return Watch{}, "", fmt.Errorf("watch: empty pattern")
+	}
+	e := &entry{Watch: w}
+	if w.Regex {
+		re, err := regexp.Compile(w.Pattern)
+		if err != nil {
+			return Watch{}, "", fmt.Errorf("watch: invalid regex: %w", err)
+		}
+		e.re = re
+	}
+	if info, err := os.Stat(w.Path); err == nil {
+		if info.IsDir() {
+			return Watch{}, "", fmt.Errorf("watch: %s is a directory", w.Display)
+		}
+		e.offset = info.Size()
+	}
+	dir := filepath.Dir(w.Path)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return Watch{}, "", fmt.Errorf("watch: directory %s does not exist", filepath.Dir(w.Display))
+	}
+
+	m.mu.Lock()
+	if m.opts.MaxWatches > 0 && len(m.watches) >= m.opts.MaxWatches {
+		m.mu.Unlock()
+		return Watch{}, "", fmt.Errorf("watch: limit of %d watches reached", m.opts.MaxWatches)
+	}
+	if m.dirs[dir] == 0 {
+		if err := m.fsw.Add(dir); err != nil {
+			m.mu.Unlock()
+			return Watch{}, "", fmt.Errorf("watch: %w", err)
+		}
+	}
+	m.dirs[dir]++
+	m.nextID++
+	e.ID = fmt.Sprintf("w%d", m.nextID)
+	e.CreatedAt = time.Now()
+	e.Triggers = 0
+	m.watches[e.ID] = e
+	w = e.Watch
+	m.mu.Unlock()
+
+	return w, e.existingMatch(), nil
+}
+
+// existingMatch returns the last line in the file's tail matching e.
+func (e *entry) existingMatch() string {
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if e.offset > existingScanBytes {
+		f.Seek(e.offset-existingScanBytes, io.SeekStart)
+	}
+	data, _ := io.ReadAll(io.LimitReader(f, existingScanBytes))
+	var found string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64<<10), maxPartialLine)
+	for sc.Scan() {
+		if e.match(sc.Text()) {
+			found = sc.Text()
+		}
+	}
+	return found
+}
+
+// List returns the watches of sessionID ("" = all), oldest first.
+func (m *Manager) List(sessionID string) []Watch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Watch
+	for _, e := range m.watches {
+		if sessionID == "" || e.SessionID == sessionID {
+			out = append(out, e.Watch)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Remove deletes watch id if it belongs to sessionID ("" = any session).
+func (m *Manager) Remove(sessionID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.watches[id]
+	if !ok || (sessionID != "" && e.SessionID != sessionID) {
+		return ErrNotFound
+	}
+	m.removeLocked(e)
+	return nil
+}
+
+func (m *Manager) removeLocked(e *entry) {
+	delete(m.watches, e.ID)
+	dir := filepath.Dir(e.Path)
+	m.dirs[dir]--
+	if m.dirs[dir] <= 0 {
+		delete(m.dirs, dir)
+		_ = m.fsw.Remove(dir)
+	}
+}
+
+// Close stops the event loop and releases the fsnotify watcher.
+func (m *Manager) Close() error {
+	select {
+	case <-m.done:
+		return nil
+	default:
+	}
+	close(m.done)
+	err := m.fsw.Close()
+	m.wg.Wait()
+	return err
+}
+
+func (m *Manager) loop() {
+	defer m.wg.Done()
+	poll := time.NewTicker(pollInterval)
+	defer poll.Stop()
+	for {
+		select {
+		case <-m.done:
+			return
+		case ev, ok := <-m.fsw.Events:
+			if !ok {
+				return
+			}
+			m.handle(ev)
+		case err, ok := <-m.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("[Watch] fsnotify error: %v", err)
+		case <-poll.C:
+			m.scanAll()
+		}
+	}
+}
+
+// handle scans the watches on the file an event refers to.
+func (m *Manager) handle(ev fsnotify.Event) {
+	path := filepath.Clean(ev.Name)
+	rotated := ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename)
+	if !rotated && !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Create) {
+		return
+	}
+	m.mu.Lock()
+	var events []Event
+	for _, e := range m.watches {
+		if e.Path != path {
+			continue
+		}
+		if rotated {
+			// The next file at this path is new: read it from the start.
+			e.offset, e.partial = 0, nil
+			continue
+		}
+		if ev.Has(fsnotify.Create) {
+			e.offset, e.partial = 0, nil
+		}
+		events = append(events, m.scanLocked(e)...)
+	}
+	m.mu.Unlock()
+	m.fire(events)
+}
+
+// scanAll rescans every watch (polling fallback).
+func (m *Manager) scanAll() {
+	m.mu.Lock()
+	var events []Event
+	for _, e := range m.watches {
+		events = append(events, m.scanLocked(e)...)
+	}
+	m.mu.Unlock()
+	m.fire(events)
+}
+
+// scanLocked reads content appended since the last scan and returns the
+// trigger event, if any. One-shot watches are removed when they trigger.
+func (m *Manager) scanLocked(e *entry) []Event {
+	f, err := os.Open(e.Path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil
+	}
+	size := info.Size()
+	if size < e.offset { // truncated
+		e.offset, e.partial = 0, nil
+	}
+	if size == e.offset {
+		return nil
+	}
+	if size-e.offset > maxScanBytes {
+		e.offset, e.partial = size-maxScanBytes, nil
+	}
+	if _, err := f.Seek(e.offset, io.SeekStart); err != nil {
+		return nil
+	}
+	data, err := io.ReadAll(io.LimitReader(f, size-e.offset))
+	if err != nil {
+		return nil
+	}
+	e.offset += int64(len(data))
+
+	data = append(e.partial, data...)
+	e.partial = nil
+	lines := bytes.Split(data, []byte{'\n'})
+	if tail := lines[len(lines)-1]; len(tail) > 0 && len(tail) < maxPartialLine {
+		e.partial = append([]byte(nil), tail...)
+		lines = lines[:len(lines)-1]
+	}
+
+	var first string
+	count := 0
+	for _, l := range lines {
+		line := strings.TrimRight(string(l), "\r")
+		if line != "" && e.match(line) {
+			if count == 0 {
+				first = line
+			}
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	now := time.Now()
+	if e.Repeat && m.opts.Cooldown > 0 && !e.last.IsZero() && now.Sub(e.last) < m.opts.Cooldown {
+		return nil
+	}
+	e.last = now
+	e.Triggers++
+	ev := Event{Watch: e.Watch, Line: first, Count: count, Time: now}
+	if !e.Repeat {
+		m.removeLocked(e)
+	}
+	return []Event{ev}
+}
+
+func (e *entry) match(line string) bool {
+	if e.re != nil {
+		return e.re.MatchString(line)
+	}
+	return strings.Contains(line, e.Pattern)
+}
+
+func (m *Manager) fire(events []Event) {
+	if m.opts.OnTrigger == nil {
+		return
+	}
+	for _, ev := range events {
+		m.opts.OnTrigger(ev)
+	}
+}
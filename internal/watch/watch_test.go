@@ -0,0 +1,153 @@
+package watch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestManager(t *testing.T, opts Options) (*Manager, chan Event) {
+	t.Helper()
+	events := make(chan Event, 10)
+	opts.OnTrigger = func(ev Event) { events <- ev }
+	m, err := NewManager(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m, events
+}
+
+func appendFile(t *testing.T, path, text string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitEvent(t *testing.T, events chan Event) Event {
+	t.Helper()
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no trigger within 5s")
+		return Event{}
+	}
+}
+
+func TestManager_TriggersOnAppendedContentOnce(t *testing.T) {
+	m, events := newTestManager(t, Options{})
+	path := filepath.Join(t.TempDir(), "error.log")
+	appendFile(t, path, "old OOM line\n")
+
+	w, existing, err := m.Add(Watch{SessionID: "s1", Path: path, Display: "error.log", Pattern: "OOM"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if existing != "old OOM line" {
+		t.Errorf("existing = %q, want the pre-existing match", existing)
+	}
+
+	appendFile(t, path, "ok\nkilled: OOM\nOOM again\n")
+	ev := waitEvent(t, events)
+	if ev.Watch.ID != w.ID || ev.Line != "killed: OOM" || ev.Count != 2 || ev.Watch.SessionID != "s1" {
+		t.Errorf("event = %+v", ev)
+	}
+	if len(m.List("")) != 0 {
+		t.Error("one-shot watch should be removed after triggering")
+	}
+}
+
+func TestManager_RepeatCooldownAndTruncation(t *testing.T) {
+	m, events := newTestManager(t, Options{Cooldown: time.Hour})
+	path := filepath.Join(t.TempDir(), "app.log")
+	if _, _, err := m.Add(Watch{SessionID: "s", Path: path, Display: "app.log", Pattern: `ERR\d+`, Regex: true, Repeat: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	appendFile(t, path, "ERR1\n")
+	waitEvent(t, events)
+	appendFile(t, path, "ERR2\n")
+	m.scanAll()
+	select {
+	case ev := <-events:
+		t.Fatalf("cooldown should suppress second trigger, got %+v", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Truncation restarts from the beginning of the file.
+	m.mu.Lock()
+	for _, e := range m.watches {
+		e.last = time.Time{}
+	}
+	m.mu.Unlock()
+	if err := os.WriteFile(path, []byte("ERR3\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m.scanAll()
+	if ev := waitEvent(t, events); ev.Line != "ERR3" {
+		t.Errorf("after truncation line = %q, want ERR3", ev.Line)
+	}
+}
+
+func TestManager_PartialLineWaitsForNewline(t *testing.T) {
+	m, events := newTestManager(t, Options{})
+	path := filepath.Join(t.TempDir(), "x.log")
+	m.Add(Watch{SessionID: "s", Path: path, Display: "x.log", Pattern: "DONE"})
+
+	appendFile(t, path, "build DO")
+	m.scanAll()
+	appendFile(t, path, "NE\n")
+	m.scanAll()
+	if ev := waitEvent(t, events); ev.Line != "build DONE" {
+		t.Errorf("line = %q, want the completed line", ev.Line)
+	}
+}
+
+func TestManager_SessionScopingAndLimits(t *testing.T) {
+	m, _ := newTestManager(t, Options{MaxWatches: 2})
+	dir := t.TempDir()
+	a, _, err := m.Add(Watch{SessionID: "a", Path: filepath.Join(dir, "1"), Pattern: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Add(Watch{SessionID: "b", Path: filepath.Join(dir, "2"), Pattern: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Add(Watch{SessionID: "b", Path: filepath.Join(dir, "3"), Pattern: "x"}); err == nil {
+		t.Error("MaxWatches should be enforced")
+	}
+	if got := m.List("b"); len(got) != 1 {
+		t.Errorf("List(b) = %d watches, want 1", len(got))
+	}
+	if err := m.Remove("b", a.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("removing another session's watch: err = %v, want ErrNotFound", err)
+	}
+	if err := m.Remove("a", a.ID); err != nil {
+		t.Errorf("Remove() error: %v", err)
+	}
+}
+
+func TestManager_AddValidation(t *testing.T) {
+	m, _ := newTestManager(t, Options{})
+	dir := t.TempDir()
+	cases := []Watch{
+		{Path: filepath.Join(dir, "f"), Pattern: ""},
+		{Path: filepath.Join(dir, "f"), Pattern: "(", Regex: true},
+		{Path: filepath.Join(dir, "missing", "f"), Pattern: "x"},
+		{Path: dir, Pattern: "x"},
+	}
+	for _, w := range cases {
+		if _, _, err := m.Add(w); err == nil {
+			t.Errorf("Add(%+v) should fail", w)
+		}
+	}
+}
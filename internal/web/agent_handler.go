@@ -1,23 +1,38 @@
 package web
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/audit"
 	"github.com/pocketomega/pocket-omega/internal/core"
+	"github.com/pocketomega/pocket-omega/internal/editor"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
+	"github.com/pocketomega/pocket-omega/internal/journal"
 	"github.com/pocketomega/pocket-omega/internal/llm"
 	"github.com/pocketomega/pocket-omega/internal/plan"
 	"github.com/pocketomega/pocket-omega/internal/prompt"
 	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/storage"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
 	"github.com/pocketomega/pocket-omega/internal/tool"
 	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+	"github.com/pocketomega/pocket-omega/internal/util"
 	"github.com/pocketomega/pocket-omega/internal/walkthrough"
+	"github.com/pocketomega/pocket-omega/internal/watch"
 )
 
 const (
@@ -44,6 +59,16 @@ func loadAgentTimeout() time.Duration {
 	return time.Duration(n) * time.Minute
 }
 
+// withLLMSession tags ctx with the session used for LLM scheduler fairness.
+// Requests without a session ID are keyed by client address instead, so
+// anonymous clients do not share a single fairness bucket.
+func withLLMSession(ctx context.Context, sessionID, clientAddr string) context.Context {
+	if sessionID == "" {
+		sessionID = "addr:" + clientAddr
+	}
+	return llm.WithSession(ctx, sessionID)
+}
+
 // ── Agent Handler (Phase 2) ──
 
 // AgentHandlerOptions groups all configuration for AgentHandler.
@@ -54,18 +79,47 @@ type AgentHandlerOptions struct {
 	Registry            *tool.Registry
 	WorkspaceDir        string
 	ExecLogger          *agent.ExecLogger
+	ReplayRecorder      *agent.ReplayRecorder // optional — structured JSONL per run for `omega replay`
 	ThinkingMode        string
 	ToolCallMode        string
 	ContextWindowTokens int
+	CompactRatio        float64 // fraction of ContextWindowTokens that triggers auto-compaction; 0 = agent.DefaultCompactRatio
 	Store               *session.Store
-	Loader              *prompt.PromptLoader // optional — falls back to hardcoded defaults
-	OSName              string               // e.g. "Windows" — for runtime info line
-	ShellCmd            string               // e.g. "cmd.exe /c" — for runtime info line
-	ModelName           string               // e.g. "gemini-2.5-pro" — for runtime info line
-	PlanStore           *plan.PlanStore      // optional — enables update_plan tool
-	MaxAgentTokens      int64                // 0 = disabled; CostGuard token budget
-	MaxAgentDuration    time.Duration        // 0 = disabled; CostGuard time limit
-	WalkthroughStore    *walkthrough.Store   // optional — enables walkthrough tool + auto-write
+	Loader              *prompt.PromptLoader   // optional — falls back to hardcoded defaults
+	OSName              string                 // e.g. "Windows" — for runtime info line
+	ShellCmd            string                 // e.g. "cmd.exe /c" — for runtime info line
+	ModelName           string                 // e.g. "gemini-2.5-pro" — for runtime info line
+	PlanStore           *plan.PlanStore        // optional — enables update_plan tool
+	PlanConfirm         bool                   // AGENT_PLAN_CONFIRM: new plans wait for the user's confirmation via /api/plan/{sid}
+	MaxAgentTokens      int64                  // 0 = disabled; CostGuard token budget
+	MaxAgentDuration    time.Duration          // 0 = disabled; CostGuard time limit
+	DownshiftProvider   llm.LLMProvider        // optional — cheaper decide model used near MaxAgentTokens
+	DownshiftModel      string                 // display name of DownshiftProvider's model
+	DownshiftRatio      float64                // fraction of MaxAgentTokens that triggers the downshift
+	WalkthroughStore    *walkthrough.Store     // optional — enables walkthrough tool + auto-write
+	Translator          *i18n.Translator       // optional — translates tool results into the working language
+	OutputProcessor     *agent.OutputProcessor // optional — summarizes oversized tool outputs
+	UILocale            string                 // e.g. "zh", "en" — locale for user-facing status strings
+	MaxConcurrentRuns   int                    // 0 = unlimited; agent runs beyond this wait in a queue
+	MaxQueuedRuns       int                    // 0 = unlimited; further runs are rejected with 503
+	WatchManager        *watch.Manager         // optional — enables session-scoped watch_add/list/remove tools
+	OutcomeLog          *agent.OutcomeLog      // optional — per-run outcome records behind /api/agent/stats
+	OutcomeJudge        *agent.OutcomeJudge    // optional — LLM judge refining runs the rules consider successful
+	BriefKeeper         *agent.BriefKeeper     // optional — merges durable facts of successful runs into PROJECT_BRIEF.md
+	Editor              *editor.Editor         // optional — "open in editor" links on file steps
+	SelfReviewRetries   int                    // AGENT_SELF_REVIEW: failed answer reviews that send the run back; 0 = off
+	ChangeSummary       agent.ChangeMode       // AGENT_CHANGE_SUMMARY: files-changed section of answers; zero = off
+	Verify              agent.VerifyConfig     // AGENT_VERIFY: checks run before answers of runs that edited code; zero = off
+	Checkpoints         *agent.CheckpointStore // optional — persists plans of running sessions for resume
+	Journal             *journal.Journal       // optional — one daily-note line per finished run
+	Storage             *storage.Manager       // optional — warns at run start when the workspace volume is low
+	Profiles            tool.Profiles          // optional — tool profiles a run can select with the profile form field
+	WalkthroughArchive  *walkthrough.Archive   // optional — memos of finished runs are saved here
+	AskTimeout          time.Duration          // AGENT_ASK_TIMEOUT_SECONDS: wait for a reply to an "ask" decision; 0 = the agent cannot ask
+	Permissions         *tool.Permissions      // optional — limits each run's tools by the role of its user
+	Audit               *audit.Log             // optional — every tool call of a run is recorded here
+	// optional — authenticated user of a request; nil = every request is anonymous
+	UserOf func(*http.Request) string
 }
 
 // AgentHandler handles agent requests with tool usage capability.
@@ -75,18 +129,64 @@ type AgentHandler struct {
 	toolRegistry        *tool.Registry
 	workspaceDir        string
 	execLogger          *agent.ExecLogger
+	replayRecorder      *agent.ReplayRecorder
 	thinkingMode        string
 	toolCallMode        string
 	contextWindowTokens int
+	compactRatio        float64
 	sessionStore        *session.Store
 	loader              *prompt.PromptLoader
 	osName              string
 	shellCmd            string
 	modelName           string
 	planStore           *plan.PlanStore
+	planConfirm         bool
 	maxAgentTokens      int64
 	maxAgentDuration    time.Duration
+	downshiftProvider   llm.LLMProvider
+	downshiftModel      string
+	downshiftRatio      float64
 	walkthroughStore    *walkthrough.Store
+	translator          *i18n.Translator
+	outputProcessor     *agent.OutputProcessor
+	uiLocale            string
+	runs                *runLimiter
+	watchManager        *watch.Manager
+	outcomeLog          *agent.OutcomeLog
+	outcomeJudge        *agent.OutcomeJudge
+	briefKeeper         *agent.BriefKeeper
+	editor              *editor.Editor
+	selfReviewRetries   int
+	changeSummary       agent.ChangeMode
+	verify              agent.VerifyConfig
+	checkpoints         *agent.CheckpointStore
+	journal             *journal.Journal
+	storage             *storage.Manager
+	profiles            tool.Profiles
+	walkthroughArchive  *walkthrough.Archive
+	askTimeout          time.Duration
+	permissions         *tool.Permissions
+	audit               *audit.Log
+	userOf              func(*http.Request) string
+
+	// Settings a config reload can change (see ApplyReload)
+	settingsMu sync.RWMutex
+
+	annotationsMu sync.Mutex
+	annotations   map[string]*agent.AnnotationQueue // session ID → running run's queue
+
+	repliesMu sync.Mutex
+	replies   map[string]chan string // session ID → run waiting for a reply
+
+	planWaitsMu sync.Mutex
+	planWaits   map[string]*planWait // session ID → run waiting for a plan confirmation
+
+	activeMu sync.Mutex
+	active   map[string]context.CancelCauseFunc // run ID → cancel of the running run
+
+	scratchpadsMu sync.Mutex
+	scratchpads   map[string]runScratchpad // run ID → scratchpad of a running or recent run
+	scratchOrder  []string                 // run IDs of scratchpads, oldest first
 }
 
 // NewAgentHandler creates a new agent handler from AgentHandlerOptions.
@@ -97,162 +197,597 @@ func NewAgentHandler(opts AgentHandlerOptions) *AgentHandler {
 		toolRegistry:        opts.Registry,
 		workspaceDir:        opts.WorkspaceDir,
 		execLogger:          opts.ExecLogger,
+		replayRecorder:      opts.ReplayRecorder,
 		thinkingMode:        opts.ThinkingMode,
 		toolCallMode:        opts.ToolCallMode,
 		contextWindowTokens: opts.ContextWindowTokens,
+		compactRatio:        opts.CompactRatio,
 		sessionStore:        opts.Store,
 		loader:              opts.Loader,
 		osName:              opts.OSName,
 		shellCmd:            opts.ShellCmd,
 		modelName:           opts.ModelName,
 		planStore:           opts.PlanStore,
+		planConfirm:         opts.PlanConfirm,
 		maxAgentTokens:      opts.MaxAgentTokens,
 		maxAgentDuration:    opts.MaxAgentDuration,
+		downshiftProvider:   opts.DownshiftProvider,
+		downshiftModel:      opts.DownshiftModel,
+		downshiftRatio:      opts.DownshiftRatio,
 		walkthroughStore:    opts.WalkthroughStore,
+		translator:          opts.Translator,
+		outputProcessor:     opts.OutputProcessor,
+		uiLocale:            i18n.NormalizeLocale(opts.UILocale),
+		runs:                newRunLimiter(opts.MaxConcurrentRuns, opts.MaxQueuedRuns),
+		watchManager:        opts.WatchManager,
+		outcomeLog:          opts.OutcomeLog,
+		outcomeJudge:        opts.OutcomeJudge,
+		briefKeeper:         opts.BriefKeeper,
+		editor:              opts.Editor,
+		selfReviewRetries:   opts.SelfReviewRetries,
+		changeSummary:       opts.ChangeSummary,
+		verify:              opts.Verify,
+		checkpoints:         opts.Checkpoints,
+		journal:             opts.Journal,
+		storage:             opts.Storage,
+		profiles:            opts.Profiles,
+		walkthroughArchive:  opts.WalkthroughArchive,
+		askTimeout:          opts.AskTimeout,
+		permissions:         opts.Permissions,
+		audit:               opts.Audit,
+		userOf:              opts.UserOf,
+		annotations:         make(map[string]*agent.AnnotationQueue),
+		replies:             make(map[string]chan string),
+		planWaits:           make(map[string]*planWait),
+		active:              make(map[string]context.CancelCauseFunc),
+		scratchpads:         make(map[string]runScratchpad),
+	}
+}
+
+// AnswerStyles returns the answer style profiles a run can select with the
+// answer_style form field; nil without a prompt loader.
+func (h *AgentHandler) AnswerStyles() []string {
+	if h.loader == nil {
+		return nil
+	}
+	return h.loader.AnswerStyles()
+}
+
+// ToolProfiles returns the tool profiles a run can select with the profile
+// form field; nil when none are configured.
+func (h *AgentHandler) ToolProfiles() []string {
+	if len(h.profiles) == 0 {
+		return nil
+	}
+	return h.profiles.Names()
+}
+
+// RunStats returns agent run concurrency stats for /api/health.
+func (h *AgentHandler) RunStats() AgentRunStats {
+	return h.runs.stats()
+}
+
+// agentRequest is one agent task, from POST /api/agent or the gRPC RunTask
+// call.
+type agentRequest struct {
+	Message     string
+	SessionID   string
+	AnswerStyle string
+	Profile     string // tool profile; "" = all tools
+	MaxSteps    int    // step budget; 0 = AGENT_MAX_STEPS
+	Images      []llm.ContentPart
+	ClientAddr  string // LLM scheduler fairness key when SessionID is empty
+	User        string // authenticated user, whose role limits the tools; "" = anonymous
+	Resume      bool   // continue the session's interrupted run (/resume); Message is ignored
+}
+
+// eventSink receives the events of an agent run: the SSE stream of
+// /api/agent or the RunEvent stream of the gRPC RunTask call. Send returns
+// false once the client is gone.
+type eventSink interface {
+	Send(event string, data interface{}) bool
+}
+
+var (
+	errEmptyMessage       = errors.New("empty message")
+	errMessageTooLong     = errors.New("message too long")
+	errUnknownAnswerStyle = errors.New("unknown answer style")
+	errUnknownProfile     = errors.New("unknown tool profile")
+	errInvalidMaxSteps    = errors.New("invalid max_steps")
+	errNothingToResume    = errors.New("no interrupted run to resume")
+)
+
+// checkRequest normalizes and validates an agent request.
+func (h *AgentHandler) checkRequest(req *agentRequest) error {
+	req.Message = strings.TrimSpace(req.Message)
+	req.SessionID = strings.TrimSpace(req.SessionID)
+	req.AnswerStyle = strings.TrimSpace(req.AnswerStyle)
+	req.Profile = strings.TrimSpace(req.Profile)
+	if req.Resume {
+		if req.SessionID == "" || h.checkpoints == nil || h.planStore == nil {
+			return errNothingToResume
+		}
+		if cp, _ := h.checkpoints.Load(req.SessionID); cp == nil {
+			return errNothingToResume
+		}
+		req.Message, req.AnswerStyle, req.Profile = "/resume", "", ""
+		return nil
+	}
+	if req.Message == "" {
+		return errEmptyMessage
+	}
+	if len([]rune(req.Message)) > maxMessageRunes {
+		return errMessageTooLong
+	}
+	if req.AnswerStyle == prompt.DefaultAnswerStyle {
+		req.AnswerStyle = ""
+	}
+	if req.AnswerStyle != "" && (h.loader == nil || !h.loader.HasAnswerStyle(req.AnswerStyle)) {
+		return errUnknownAnswerStyle
+	}
+	if req.Profile != "" && !h.profiles.Has(req.Profile) {
+		return errUnknownProfile
+	}
+	if req.MaxSteps != 0 && (req.MaxSteps < 5 || req.MaxSteps > agent.MaxStepsLimit) {
+		return errInvalidMaxSteps
 	}
+	return nil
 }
 
 // HandleAgent processes agent requests using SSE streaming with tool calls.
+// The optional events and min_severity form values subscribe the client to
+// a subset of the events (see sseFilter).
 func (h *AgentHandler) HandleAgent(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	limitRequestBody(w, r)
+	images, err := parseImageUploads(r, h.llmProvider)
+	if err != nil {
+		handleUploadError(w, err)
+		return
+	}
 
-	userMsg := strings.TrimSpace(r.FormValue("message"))
-	if userMsg == "" {
-		http.Error(w, "Empty message", http.StatusBadRequest)
+	var maxSteps int
+	if v := r.FormValue("max_steps"); v != "" {
+		if maxSteps, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid max_steps", http.StatusBadRequest)
+			return
+		}
+	}
+	req := agentRequest{
+		Message:     r.FormValue("message"),
+		SessionID:   r.FormValue("session_id"),
+		AnswerStyle: r.FormValue("answer_style"),
+		Profile:     r.FormValue("profile"),
+		MaxSteps:    maxSteps,
+		Images:      images,
+		ClientAddr:  r.RemoteAddr,
+		Resume:      r.FormValue("resume") == "true",
+	}
+	if h.userOf != nil {
+		req.User = h.userOf(r)
+	}
+	if err := h.checkRequest(&req); err != nil {
+		switch {
+		case errors.Is(err, errMessageTooLong):
+			http.Error(w, "Message too long", http.StatusRequestEntityTooLarge)
+		case errors.Is(err, errUnknownAnswerStyle):
+			http.Error(w, "Unknown answer style", http.StatusBadRequest)
+		case errors.Is(err, errUnknownProfile):
+			http.Error(w, "Unknown tool profile", http.StatusBadRequest)
+		case errors.Is(err, errInvalidMaxSteps):
+			http.Error(w, fmt.Sprintf("max_steps must be 5-%d", agent.MaxStepsLimit), http.StatusBadRequest)
+		case errors.Is(err, errNothingToResume):
+			http.Error(w, "No interrupted run to resume", http.StatusNotFound)
+		default:
+			http.Error(w, "Empty message", http.StatusBadRequest)
+		}
 		return
 	}
-	if len([]rune(userMsg)) > maxMessageRunes {
-		http.Error(w, "Message too long", http.StatusRequestEntityTooLarge)
+	// Subscription: dashboards may ask for a subset of the events
+	filter, err := parseSSEFilter(r.FormValue("events"), r.FormValue("min_severity"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	log.Printf("[Agent] Received: %s", req.Message)
 
-	log.Printf("[Agent] Received: %s", userMsg)
-	startTime := time.Now()
-
-	// Session history lookup
-	sessionID := strings.TrimSpace(r.FormValue("session_id"))
-	var historyPrefix string
-	if sessionID != "" && h.sessionStore != nil {
-		turns, summary := h.sessionStore.GetSessionContext(sessionID)
-		// allocate 30% of context window (in chars) to conversation history
-		budget := h.contextWindowTokens * 2 * 30 / 100
-		historyPrefix = session.ToProblemPrefix(turns, budget, summary)
+	// Backpressure: reject before opening the stream when the queue is full
+	ticket, err := h.runs.enter(req.SessionID)
+	if err != nil {
+		log.Printf("[Agent] Rejected: %v", err)
+		w.Header().Set("Retry-After", "30")
+		http.Error(w, i18n.T(h.uiLocale, "agent.busy"), http.StatusServiceUnavailable)
+		return
 	}
+	defer ticket.release()
 
 	sse := newSSEWriter(w, r)
 	if sse == nil {
 		return
 	}
+	var sink eventSink = sse
+	if filter != nil {
+		sink = filteredSink{sink: sse, filter: filter}
+	}
+	h.runAgent(r.Context(), ticket, req, sink)
+}
+
+// runAgent waits for ticket, runs the agent flow for req and streams its
+// events to sink. ctx ends with the client connection. Shared by the HTTP
+// handler and the gRPC service.
+func (h *AgentHandler) runAgent(ctx context.Context, ticket *runTicket, req agentRequest, sink eventSink) {
+	userMsg, sessionID, answerStyle, profile, images := req.Message, req.SessionID, req.AnswerStyle, req.Profile, req.Images
+
+	// Wait for this session's previous run and for a free run slot.
+	// Queue time does not count against agentTimeout.
+	if err := ticket.wait(ctx, func(position int) {
+		msg := i18n.T(h.uiLocale, "agent.session_busy")
+		if position > 0 {
+			msg = fmt.Sprintf(i18n.T(h.uiLocale, "agent.queued"), position)
+		}
+		sink.Send(sseEventQueue, sseQueueEvent{Position: position, Message: msg})
+	}); err != nil {
+		log.Printf("[Agent] Client left while queued: %v", err)
+		return
+	}
+	startTime := time.Now()
+	trace.SpanFromContext(ctx).AddEvent("agent.dequeued")
+
+	// Session history lookup — after the session gate, so the previous run's
+	// turn is already persisted
+	historyPrefix := h.conversationHistory(sessionID)
+
+	// /resume: load the checkpoint after the session gate, so it reflects
+	// the end of any run that was still going when the request came in
+	var resumed *agent.Checkpoint
+	if req.Resume {
+		if resumed, _ = h.checkpoints.Load(sessionID); resumed == nil {
+			sink.Send(sseEventDone, sseDoneEvent{Solution: i18n.T(h.uiLocale, "agent.resume_none")})
+			return
+		}
+		userMsg, answerStyle, profile = resumed.Problem, resumed.AnswerStyle, resumed.ToolProfile
+		log.Printf("[Agent] Resuming interrupted run of session %s: %s", sessionID, userMsg)
+	}
 
 	// Global timeout for the entire agent flow
-	ctx, cancel := context.WithTimeout(r.Context(), agentTimeout)
+	ctx, cancel := context.WithTimeout(withLLMSession(ctx, sessionID, req.ClientAddr), agentTimeout)
 	defer cancel()
 
+	// Transient LLM failures: show each retry or failover instead of a stall
+	ctx = llm.WithRetryNotifier(ctx, func(n llm.RetryNotice) {
+		sink.Send(sseEventNotice, sseNoticeEvent{Kind: "retrying", Message: retryNoticeMessage(h.uiLocale, n)})
+	})
+
+	// Register the run so that /api/agent/{runID}/cancel can abort it
+	runID, ctx := h.openRun(ctx)
+	defer h.closeRun(runID)
+	sink.Send(sseEventRun, sseRunEvent{RunID: runID})
+
 	// Send immediate status so user sees instant feedback
-	sse.Send("status", map[string]string{"message": "🤔 正在分析问题..."})
+	sink.Send(sseEventStatus, sseStatusEvent{Message: i18n.T(h.uiLocale, "agent.analyzing")})
+
+	// PROMPTS_WATCH: tell the user that this run uses freshly edited prompts
+	if h.loader != nil {
+		if changed := h.loader.TakeHotReloaded(); len(changed) > 0 {
+			sink.Send(sseEventNotice, sseNoticeEvent{
+				Kind:    "prompts_reloaded",
+				Message: fmt.Sprintf(i18n.T(h.uiLocale, "agent.prompts_reloaded"), strings.Join(changed, ", ")),
+			})
+		}
+	}
+
+	// Warn before tools start writing to a nearly full volume
+	if h.storage != nil {
+		if low, free := h.storage.LowSpace(); low {
+			sink.Send(sseEventNotice, sseNoticeEvent{
+				Kind:    "low_disk",
+				Message: fmt.Sprintf(i18n.T(h.uiLocale, "agent.low_disk"), storage.FormatSize(int64(free))),
+			})
+		}
+	}
 
 	// Start execution log session
 	if h.execLogger != nil {
 		h.execLogger.StartSession(userMsg)
 	}
+	replayRun := h.replayRecorder.StartRun(sessionID, userMsg,
+		fmt.Sprintf("thinking=%s toolcall=%s style=%s profile=%s", h.thinkingMode, h.toolCallMode, cmp.Or(answerStyle, prompt.DefaultAnswerStyle), cmp.Or(profile, "all")))
+
+	// Tool profile: filter the tools of this run. The per-request tools added
+	// below only touch the run's own plan, walkthrough and watches, so every
+	// profile keeps them. A resumed run whose profile has since been removed
+	// gets no other tools rather than all of them.
+	reqRegistry := h.toolRegistry
+	if profile != "" {
+		reqRegistry = reqRegistry.WithOnly(h.profiles[profile])
+	}
+	// Permissions: the user's role limits the tools on top of the profile
+	if h.permissions != nil {
+		role := h.permissions.Role(req.User)
+		reqRegistry = reqRegistry.WithOnly(h.permissions.Tools(role)).OnDenied(func(name string) {
+			log.Printf("[Permissions] Denied tool %s to user %q (role %s), session=%s", name, req.User, role, sessionID)
+		})
+	}
 
 	// Per-request: create update_plan tool with session context + SSE callback.
 	// Uses WithExtra to create a request-scoped registry copy — no mutation of global registry.
-	reqRegistry := h.toolRegistry
 	if h.planStore != nil {
 		planTool := builtin.NewUpdatePlanTool(h.planStore, sessionID, func(steps []plan.PlanStep) {
-			sse.Send(sseEventPlan, ssePlanEvent{Steps: steps})
+			sink.Send(sseEventPlan, ssePlanEvent{Steps: steps})
 		})
-		reqRegistry = h.toolRegistry.WithExtra(planTool)
+		// Plan preview: a new plan waits for /api/plan/{sid} before execution
+		if h.planConfirm && sessionID != "" {
+			planTool.WithConfirm(h.confirmPlan(sessionID, sink))
+		}
+		reqRegistry = reqRegistry.WithExtra(planTool)
 		// Clean up plan data after agent completes (synchronous — safe with current design).
 		// If agent is ever moved to goroutine, move Delete to agent completion callback.
 		defer h.planStore.Delete(sessionID)
 	}
 
 	// Walkthrough: same per-request lifecycle as PlanStore.
-	// defer Delete ensures cleanup when request ends.
+	// defer Delete ensures cleanup when request ends; the memo is archived first.
 	if h.walkthroughStore != nil {
 		wtTool := builtin.NewWalkthroughTool(h.walkthroughStore, sessionID)
 		reqRegistry = reqRegistry.WithExtra(wtTool)
-		defer h.walkthroughStore.Delete(sessionID)
+		defer func() {
+			h.archiveWalkthrough(sessionID, userMsg)
+			h.walkthroughStore.Delete(sessionID)
+		}()
+	}
+
+	// File watches notify the session that created them, so they need one.
+	if h.watchManager != nil && sessionID != "" {
+		reqRegistry = reqRegistry.WithExtra(builtin.NewWatchTools(h.watchManager, sessionID, h.workspaceDir)...)
 	}
 
 	// Build agent state with SSE callback
 	state := &agent.AgentState{
 		Problem:             userMsg,
 		ConversationHistory: historyPrefix,
+		AnswerStyle:         answerStyle,
+		ToolProfile:         profile,
+		MaxSteps:            req.MaxSteps,
+		Images:              images,
 		WorkspaceDir:        h.workspaceDir,
 		ToolRegistry:        reqRegistry,
 		ThinkingMode:        h.thinkingMode,
 		ToolCallMode:        h.toolCallMode,
 		ContextWindowTokens: h.contextWindowTokens,
+		CompactRatio:        h.compactRatio,
 		OSName:              h.osName,
 		ShellCmd:            h.shellCmd,
-		ModelName:           h.modelName,
+		ModelName:           h.currentModel(),
 		WalkthroughStore:    h.walkthroughStore,
 		WalkthroughSID:      sessionID,
 		PlanStore:           h.planStore,
 		PlanSID:             sessionID,
 		ReadCache:           agent.NewReadCache(),
+		SearchHistory:       tool.NewSearchHistory(),
+		Translator:          h.translator,
+		OutputProcessor:     h.outputProcessor,
+		Replay:              replayRun,
+		SelfReviewRetries:   h.selfReviewRetries,
+		Changes:             agent.NewChangeTracker(h.workspaceDir, h.changeSummary),
+		Audit:               h.audit.Run(sessionID, runID, req.User),
+		Scratchpad:          h.openScratchpad(runID, userMsg),
+		Verify:              agent.NewVerification(h.verify, h.workspaceDir),
 		OnStepComplete: func(step agent.StepRecord) {
 			// Write to execution log
 			if h.execLogger != nil {
 				h.execLogger.LogStep(step)
 			}
+			replayRun.RecordStep(step)
 			switch step.Type {
 			case "decide":
-				sse.Send("step", step)
+				sink.Send(sseEventStep, step)
 			case "tool":
-				sse.Send("tool", step)
-			case "think":
-				sse.Send("step", step)
+				sink.Send(sseEventTool, step)
+				if link := h.editorLink(step); link != nil {
+					sink.Send(sseEventEditorLink, link)
+				}
+				if step.Diff != nil {
+					sink.Send(sseEventFileDiff, sseFileDiffEvent{StepNumber: step.StepNumber, FileDiff: *step.Diff})
+				}
+			case "think", "compact", "review", "ask", "verify":
+				sink.Send(sseEventStep, step)
 			}
 		},
 		OnStreamChunk: func(chunk string) {
-			sse.Send("chunk", map[string]string{"text": chunk})
+			sink.Send(sseEventChunk, sseChunkEvent{Text: chunk})
 		},
 		OnPlanUpdate: func(steps []plan.PlanStep) {
-			sse.Send(sseEventPlan, ssePlanEvent{Steps: steps})
+			sink.Send(sseEventPlan, ssePlanEvent{Steps: steps})
 		},
 	}
 
-	// CostGuard: inject if configured
-	if h.maxAgentTokens > 0 || h.maxAgentDuration > 0 {
-		state.CostGuard = agent.NewCostGuard(h.maxAgentTokens, h.maxAgentDuration)
+	// Resume: restore the plan and summarize the work done before the interruption
+	if resumed != nil {
+		resumed.Restore(state)
+		done := 0
+		for _, s := range h.planStore.Get(sessionID) {
+			if s.Status == "done" {
+				done++
+			}
+		}
+		msg := fmt.Sprintf(i18n.T(h.uiLocale, "agent.resumed"), done, len(resumed.Plan))
+		if len(resumed.Plan) == 0 {
+			msg = fmt.Sprintf(i18n.T(h.uiLocale, "agent.resumed_steps"), len(resumed.Steps))
+		}
+		sink.Send(sseEventNotice, sseNoticeEvent{Kind: "resumed", Message: msg})
+		sink.Send(sseEventPlan, ssePlanEvent{Steps: h.planStore.Get(sessionID)})
+	}
+
+	// Checkpoints: persist the plan, step history and cost counters after
+	// every step, so an interrupted run (timeout, cancel, restart, crash) can
+	// be resumed. A new run without a plan does not replace the checkpoint of
+	// an earlier interrupted run of the session, which stays resumable.
+	var pending *agent.Checkpoint
+	if h.checkpoints != nil && h.planStore != nil && sessionID != "" {
+		if resumed == nil {
+			if pending, _ = h.checkpoints.Load(sessionID); pending != nil && !pending.Unfinished() {
+				pending = nil
+			}
+		}
+		if pending != nil && pending.Crashed {
+			sink.Send(sseEventNotice, sseNoticeEvent{Kind: "resumable", Message: i18n.T(h.uiLocale, "agent.crashed_run")})
+		}
+		onStep := state.OnStepComplete
+		state.OnStepComplete = func(step agent.StepRecord) {
+			onStep(step)
+			cp := agent.NewCheckpoint(sessionID, state)
+			if cp == nil || (pending != nil && len(cp.Plan) == 0) {
+				return
+			}
+			cp.Running = true
+			if err := h.checkpoints.Save(cp); err != nil {
+				log.Printf("[Checkpoint] Save failed: %v", err)
+			}
+		}
+	}
+
+	// Clarifying questions: an "ask" decision pauses the run until
+	// /api/agent/reply answers it
+	if sessionID != "" && h.askTimeout > 0 {
+		state.AskUser = h.askUser(sessionID, sink)
+	}
+
+	// Step annotations: /api/agent/annotate steers this run while it is ongoing
+	if sessionID != "" {
+		state.Annotations = h.openAnnotations(sessionID)
+		defer h.closeAnnotations(sessionID, state.Annotations)
+		state.OnCorrections = func(fresh []agent.StepAnnotation) {
+			for _, a := range fresh {
+				sink.Send(sseEventNotice, sseNoticeEvent{
+					Kind:    "correction",
+					Message: fmt.Sprintf(i18n.T(h.uiLocale, "agent.correction_applied"), a.Note),
+				})
+			}
+		}
+	}
+
+	// CostGuard: inject if configured; a run resumed after a crash keeps
+	// counting from where it stopped
+	h.settingsMu.RLock()
+	maxTokens, maxDuration := h.maxAgentTokens, h.maxAgentDuration
+	h.settingsMu.RUnlock()
+	if maxTokens > 0 || maxDuration > 0 {
+		state.CostGuard = agent.NewCostGuard(maxTokens, maxDuration)
+		if resumed != nil {
+			resumed.RestoreCost(state.CostGuard)
+		}
+	}
+
+	// Usage: report the budget use after each step
+	if guard := state.CostGuard; guard != nil {
+		onStep := state.OnStepComplete
+		state.OnStepComplete = func(step agent.StepRecord) {
+			onStep(step)
+			sink.Send(sseEventUsage, sseUsageEvent{
+				TokensUsed: guard.UsedTokens(),
+				MaxTokens:  guard.MaxTokens(),
+				ElapsedMs:  guard.Elapsed().Milliseconds(),
+			})
+		}
+	}
+
+	// Downshift: near the token budget, remaining decide steps use the cheaper model
+	if state.CostGuard != nil && h.downshiftProvider != nil {
+		state.CostGuard.EnableDownshift(h.downshiftRatio)
+		state.DownshiftProvider = h.downshiftProvider
+		state.DownshiftModel = h.downshiftModel
+		state.OnDownshift = func(d agent.DownshiftInfo) {
+			sink.Send(sseEventNotice, sseNoticeEvent{
+				Kind:    "downshift",
+				Message: fmt.Sprintf(i18n.T(h.uiLocale, "agent.downshift"), d.UsedTokens, d.MaxTokens, d.ToModel),
+			})
+		}
+	}
+
+	// Step budget: tell the user when a planned run gets extra steps
+	state.OnStepsExtended = func(e agent.StepBudgetExtension) {
+		sink.Send(sseEventNotice, sseNoticeEvent{
+			Kind:    "steps_extended",
+			Message: fmt.Sprintf(i18n.T(h.uiLocale, "agent.steps_extended"), e.RemainingPlan, e.From, e.To),
+		})
 	}
 
-	// ContextGuard: inject OnContextOverflow callback for auto-compact
+	// ContextGuard: inject OnContextOverflow callback for the CompactNode
 	if sessionID != "" && h.sessionStore != nil && h.llmProvider != nil {
 		sessID := sessionID // capture for closure
-		state.OnContextOverflow = func(ctx context.Context) error {
+		state.OnContextOverflow = func(ctx context.Context) (string, int, error) {
 			turns, existing := h.sessionStore.GetSessionContext(sessID)
 			if len(turns) <= defaultCompactKeepN {
-				return nil
+				return "", 0, nil
 			}
 			summary, err := buildCompactSummary(ctx, h.llmProvider, turns, existing, defaultCompactKeepN)
 			if err != nil {
-				return err
+				return "", 0, err
 			}
 			h.sessionStore.Compact(sessID, summary, defaultCompactKeepN)
 			log.Printf("[ContextGuard] Auto-compact done for session=%s", sessID)
-			return nil
+			return h.conversationHistory(sessID), len(turns) - defaultCompactKeepN, nil
 		}
 	}
 
 	// Run the agent flow with timeout context
-	h.agentFlow.Run(ctx, state)
+	runCtx, span := telemetry.Start(ctx, "agent.run",
+		attribute.String("session.id", sessionID),
+		attribute.String("agent.thinking_mode", h.thinkingMode),
+		attribute.String("agent.tool_call_mode", h.toolCallMode))
+	h.agentFlow.Run(runCtx, state)
+	span.SetAttributes(
+		attribute.Int("agent.steps", len(state.StepHistory)),
+		attribute.Int("agent.tool_calls", countToolSteps(state.StepHistory)))
+	if state.CostGuard != nil {
+		span.SetAttributes(attribute.Int64("agent.tokens_used", state.CostGuard.UsedTokens()))
+	}
+	telemetry.End(span, ctx.Err())
+
+	// User cancellation: record a terminal step so logs and replays show why
+	// the run stopped, and answer with a notice instead of a partial solution
+	if errors.Is(context.Cause(ctx), errCancelledByUser) {
+		state.Solution = i18n.T(h.uiLocale, "agent.cancelled")
+		step := agent.StepRecord{
+			StepNumber: len(state.StepHistory) + 1,
+			Type:       "answer",
+			Action:     "cancelled",
+			Output:     errCancelledByUser.Error(),
+		}
+		state.StepHistory = append(state.StepHistory, step)
+		state.OnStepComplete(step)
+	}
+
+	// Keep the checkpoint of an interrupted run with an unfinished plan for
+	// /resume; a run that ended on its own no longer needs one. A run
+	// without a plan leaves an earlier interrupted run resumable.
+	if h.checkpoints != nil && h.planStore != nil && sessionID != "" {
+		switch cp := agent.NewCheckpoint(sessionID, state); {
+		case pending != nil && (cp == nil || len(cp.Plan) == 0):
+		case cp != nil && ctx.Err() != nil && cp.Unfinished():
+			if err := h.checkpoints.Save(cp); err != nil {
+				log.Printf("[Checkpoint] Save failed: %v", err)
+			}
+			sink.Send(sseEventNotice, sseNoticeEvent{Kind: "checkpoint", Message: i18n.T(h.uiLocale, "agent.checkpoint_kept")})
+		default:
+			h.checkpoints.Delete(sessionID)
+		}
+	}
+
+	// Rule-based outcome now (shown in the done event); the optional LLM
+	// judge runs after the answer is delivered
+	outcome := agent.ClassifyRun(state, context.Cause(ctx))
 
 	// AnswerNode already synthesizes a polished answer with LLM.
 	// Skip formatSolution here to avoid a redundant LLM round-trip
 	// that adds 3-5s of latency with no visible benefit.
 	solution := strings.TrimSpace(state.Solution)
 	if solution == "" {
-		solution = "抱歉，未能生成回答。请重试。"
+		solution = i18n.T(h.uiLocale, "agent.no_answer")
 	}
 
 	// Build execution stats for done event
@@ -264,26 +799,108 @@ func (h *AgentHandler) HandleAgent(w http.ResponseWriter, r *http.Request) {
 	if state.CostGuard != nil {
 		stats.TokensUsed = state.CostGuard.UsedTokens()
 	}
+	stats.Downshift = state.Downshift
+	stats.Outcome = outcome.Outcome
 
-	sse.Send("done", sseDoneEvent{Solution: solution, Stats: stats})
+	if runErr := h.runError(state, context.Cause(ctx)); runErr != nil {
+		sink.Send(sseEventError, *runErr)
+	}
+	sink.Send(sseEventDone, sseDoneEvent{Solution: solution, Stats: stats})
 	log.Printf("[Agent] Done: %d steps, solution %d chars", len(state.StepHistory), len(solution))
 
+	outcome = h.outcomeJudge.Judge(ctx, state, outcome)
+	state.Outcome = &outcome
+	log.Printf("[Agent] Outcome: %s %v", outcome.Outcome, outcome.Reasons)
+
 	// Write execution log summary
 	if h.execLogger != nil {
 		h.execLogger.EndSession(state)
 	}
+	replayRun.End(state)
+	if h.outcomeLog != nil {
+		rec := agent.NewRunRecord(state, outcome)
+		rec.RunID, rec.SessionID, rec.ElapsedMs = runID, sessionID, stats.ElapsedMs
+		if err := h.outcomeLog.Append(rec); err != nil {
+			log.Printf("[Agent] Outcome log write failed: %v", err)
+		}
+	}
+
+	// The brief update is a summarize-model call; run it in the background
+	// so the session turn below is saved without waiting for it
+	if h.briefKeeper != nil {
+		go h.briefKeeper.UpdateLogged(context.WithoutCancel(ctx), state, outcome)
+	}
+
+	if h.journal != nil {
+		if err := h.journal.Append(journalEntry(userMsg, solution, outcome.Outcome, stats)); err != nil {
+			log.Printf("[Agent] Journal write failed: %v", err)
+		}
+	}
 
 	// Persist this turn to session history
 	if sessionID != "" && h.sessionStore != nil {
 		h.sessionStore.AppendTurn(sessionID, session.Turn{
-			UserMsg:   userMsg,
+			UserMsg:   userMsg + imageMarker(images),
 			Assistant: solution,
 			IsAgent:   true,
 		})
 	}
 }
 
+// conversationHistory formats the session's turns and summary as the
+// problem prefix of the next agent run.
+func (h *AgentHandler) conversationHistory(sessionID string) string {
+	if sessionID == "" || h.sessionStore == nil {
+		return ""
+	}
+	turns, summary := h.sessionStore.GetSessionContext(sessionID)
+	// allocate 30% of context window (in chars) to conversation history
+	budget := h.contextWindowTokens * 2 * 30 / 100
+	return session.ToProblemPrefix(turns, budget, summary)
+}
+
+// archiveWalkthrough persists the memo of a finished run, if archiving is
+// enabled and the run wrote any entries.
+func (h *AgentHandler) archiveWalkthrough(sessionID, problem string) {
+	if h.walkthroughArchive == nil {
+		return
+	}
+	id, err := h.walkthroughArchive.Save(sessionID, problem, h.walkthroughStore.Get(sessionID))
+	if err != nil {
+		log.Printf("[Agent] Walkthrough archive failed: %v", err)
+		return
+	}
+	if id != "" {
+		log.Printf("[Agent] Walkthrough archived: %s", id)
+	}
+}
+
+// journalEntry is the daily-note line of a finished run: outcome, task and
+// the first line of the answer.
+func journalEntry(problem, solution string, outcome agent.Outcome, stats *agentStats) string {
+	mark := map[agent.Outcome]string{agent.OutcomeSuccess: "✅", agent.OutcomePartial: "⚠️", agent.OutcomeFailure: "❌"}[outcome]
+	answer, _, _ := strings.Cut(strings.TrimSpace(solution), "\n")
+	return fmt.Sprintf("%s %s → %s（%d 步，%d 次工具调用）", mark,
+		util.TruncateRunes(strings.Join(strings.Fields(problem), " "), 120),
+		util.TruncateRunes(answer, 160), stats.Steps, stats.ToolCalls)
+}
+
 // countToolSteps counts the number of tool execution steps in the history.
+// runError describes why a run stopped before it finished, or nil when it
+// ended on its own. A client that left gets no events, so its cause is not
+// reported.
+func (h *AgentHandler) runError(state *agent.AgentState, cause error) *sseErrorEvent {
+	switch {
+	case errors.Is(cause, errCancelledByUser):
+		return &sseErrorEvent{Code: "cancelled", Message: i18n.T(h.uiLocale, "agent.cancelled")}
+	case errors.Is(cause, context.DeadlineExceeded):
+		return &sseErrorEvent{Code: "timeout", Message: i18n.T(h.uiLocale, "agent.timed_out")}
+	case state.CostGuard != nil && state.CostGuard.IsExceeded():
+		return &sseErrorEvent{Code: "budget_exceeded", Message: i18n.T(h.uiLocale, "agent.budget_exceeded")}
+	}
+	return nil
+}
+
 func countToolSteps(steps []agent.StepRecord) int {
 	n := 0
 	for _, s := range steps {
@@ -0,0 +1,145 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/plan"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestHandleAgent_RejectsUnknownAnswerStyle(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{
+		Registry:     tool.NewRegistry(),
+		WorkspaceDir: t.TempDir(),
+		Loader:       prompt.NewPromptLoader("", "", ""),
+	})
+	if got := h.AnswerStyles(); !reflect.DeepEqual(got, []string{"default", "bilingual", "concise", "tutorial"}) {
+		t.Errorf("AnswerStyles() = %v", got)
+	}
+
+	form := url.Values{"message": {"hi"}, "answer_style": {"nope"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/agent", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.HandleAgent(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestHandleAgent_RejectsUnknownProfile(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{
+		Registry:     tool.NewRegistry(),
+		WorkspaceDir: t.TempDir(),
+		Profiles:     tool.DefaultProfiles(),
+	})
+	if got := h.ToolProfiles(); !reflect.DeepEqual(got, []string{"coder", "readonly", "researcher"}) {
+		t.Errorf("ToolProfiles() = %v", got)
+	}
+
+	req := agentRequest{Message: "hi", Profile: " readonly "}
+	if err := h.checkRequest(&req); err != nil || req.Profile != "readonly" {
+		t.Errorf("readonly: err = %v, profile = %q", err, req.Profile)
+	}
+	form := url.Values{"message": {"hi"}, "profile": {"nope"}}
+	r := httptest.NewRequest(http.MethodPost, "/api/agent", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.HandleAgent(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestHandleAgent_RejectsInvalidMaxSteps(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{Registry: tool.NewRegistry(), WorkspaceDir: t.TempDir()})
+	for _, v := range []string{"abc", "2", "500"} {
+		form := url.Values{"message": {"hi"}, "max_steps": {v}}
+		r := httptest.NewRequest(http.MethodPost, "/api/agent", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.HandleAgent(w, r)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("max_steps=%s: status = %d, want 400", v, w.Code)
+		}
+	}
+	req := agentRequest{Message: "hi", MaxSteps: 100}
+	if err := h.checkRequest(&req); err != nil {
+		t.Errorf("max_steps=100: %v", err)
+	}
+}
+
+func TestHandleAgent_ResumeInterruptedRun(t *testing.T) {
+	checkpoints, err := agent.NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkpoints.Save(&agent.Checkpoint{
+		SessionID: "s1",
+		Problem:   "migrate the config",
+		Plan: []plan.PlanStep{
+			{ID: "read", Title: "Read config", Status: "done"},
+			{ID: "write", Title: "Write new config", Status: "in_progress"},
+		},
+		Steps: []agent.StepRecord{{StepNumber: 1, Type: "tool", ToolName: "file_read", Output: "old"}},
+	})
+	h := NewAgentHandler(AgentHandlerOptions{
+		Provider:     blockingLLMProvider{},
+		Registry:     tool.NewRegistry(),
+		WorkspaceDir: t.TempDir(),
+		PlanStore:    plan.NewPlanStore(),
+		Checkpoints:  checkpoints,
+		ThinkingMode: "native",
+		ToolCallMode: "yaml",
+	})
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/agent", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.HandleAgent(w, req)
+		return w
+	}
+
+	if w := post(url.Values{"resume": {"true"}, "session_id": {"other"}}); w.Code != http.StatusNotFound {
+		t.Fatalf("session without checkpoint: status = %d, want 404", w.Code)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post(url.Values{"resume": {"true"}, "session_id": {"s1"}}) }()
+	var runID string
+	for deadline := time.Now().Add(5 * time.Second); runID == "" && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		h.activeMu.Lock()
+		for id := range h.active {
+			runID = id
+		}
+		h.activeMu.Unlock()
+	}
+	if runID == "" || !h.cancelRun(runID) {
+		t.Fatal("resumed run was not registered")
+	}
+	var w *httptest.ResponseRecorder
+	select {
+	case w = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("run did not stop after cancel")
+	}
+
+	body := w.Body.String()
+	for _, want := range []string{`"kind":"resumed"`, `"title":"Write new config","status":"in_progress"`, `"kind":"checkpoint"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("stream lacks %s:\n%s", want, body)
+		}
+	}
+	// Interrupted again with the plan unfinished: still resumable
+	if cp, _ := checkpoints.Load("s1"); cp == nil || cp.Problem != "migrate the config" || !cp.Unfinished() {
+		t.Errorf("checkpoint after interrupted resume = %+v", cp)
+	}
+}
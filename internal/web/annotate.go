@@ -0,0 +1,82 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+)
+
+// maxAnnotationRunes bounds one step annotation; it is a steering note, not
+// a new task.
+const maxAnnotationRunes = 1000
+
+// openAnnotations registers the annotation queue of a session's running
+// agent. The run limiter allows one run per session, so the session ID
+// identifies the run.
+func (h *AgentHandler) openAnnotations(sessionID string) *agent.AnnotationQueue {
+	q := agent.NewAnnotationQueue()
+	h.annotationsMu.Lock()
+	h.annotations[sessionID] = q
+	h.annotationsMu.Unlock()
+	return q
+}
+
+// closeAnnotations unregisters q when its run ends.
+func (h *AgentHandler) closeAnnotations(sessionID string, q *agent.AnnotationQueue) {
+	h.annotationsMu.Lock()
+	if h.annotations[sessionID] == q {
+		delete(h.annotations, sessionID)
+	}
+	h.annotationsMu.Unlock()
+}
+
+// HandleAnnotate attaches a user note to a step of the session's running
+// agent (POST /api/agent/annotate with session_id, step and note). The note
+// reaches the model at its next decision as a high-priority correction —
+// lighter than cancelling and rephrasing the whole task.
+// Returns 409 when the session has no run in progress.
+func (h *AgentHandler) HandleAnnotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+
+	sessionID := strings.TrimSpace(r.FormValue("session_id"))
+	note := strings.TrimSpace(r.FormValue("note"))
+	if sessionID == "" || note == "" {
+		http.Error(w, "session_id and note are required", http.StatusBadRequest)
+		return
+	}
+	if len([]rune(note)) > maxAnnotationRunes {
+		http.Error(w, "Note too long", http.StatusRequestEntityTooLarge)
+		return
+	}
+	step := 0
+	if v := strings.TrimSpace(r.FormValue("step")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid step", http.StatusBadRequest)
+			return
+		}
+		step = n
+	}
+
+	h.annotationsMu.Lock()
+	q := h.annotations[sessionID]
+	h.annotationsMu.Unlock()
+	if q == nil {
+		http.Error(w, "no agent run in progress for this session", http.StatusConflict)
+		return
+	}
+	q.Add(agent.StepAnnotation{Step: step, Note: note})
+	log.Printf("[Agent] Annotation for session=%s step=%d: %s", sessionID, step, note)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]bool{"queued": true})
+}
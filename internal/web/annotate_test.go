@@ -0,0 +1,46 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func postAnnotate(h *AgentHandler, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/agent/annotate", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.HandleAnnotate(w, req)
+	return w
+}
+
+func TestHandleAnnotate(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{})
+	form := url.Values{"session_id": {"s1"}, "step": {"3"}, "note": {"wrong file"}}
+
+	if w := postAnnotate(h, form); w.Code != http.StatusConflict {
+		t.Fatalf("no run in progress: status = %d, want 409", w.Code)
+	}
+
+	q := h.openAnnotations("s1")
+	if w := postAnnotate(h, form); w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202: %s", w.Code, w.Body)
+	}
+	h.closeAnnotations("s1", q)
+	if w := postAnnotate(h, form); w.Code != http.StatusConflict {
+		t.Errorf("after run end: status = %d, want 409", w.Code)
+	}
+
+	for name, bad := range map[string]url.Values{
+		"missing note": {"session_id": {"s1"}},
+		"bad step":     {"session_id": {"s1"}, "step": {"-1"}, "note": {"x"}},
+		"too long":     {"session_id": {"s1"}, "note": {strings.Repeat("长", maxAnnotationRunes+1)}},
+	} {
+		h.openAnnotations("s1")
+		if w := postAnnotate(h, bad); w.Code < 400 || w.Code == http.StatusConflict {
+			t.Errorf("%s: status = %d, want a 4xx validation error", name, w.Code)
+		}
+	}
+}
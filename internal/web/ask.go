@@ -0,0 +1,90 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+)
+
+// maxReplyRunes bounds one reply to a clarifying question.
+const maxReplyRunes = 4000
+
+// errNoReply ends the wait for a reply after AskTimeout.
+var errNoReply = errors.New("no reply in time")
+
+// askUser returns the AgentState.AskUser of a session's run: it sends the
+// question as an awaiting_input event and waits for POST /api/agent/reply.
+// The run limiter allows one run per session, so the session ID identifies
+// the waiting run.
+func (h *AgentHandler) askUser(sessionID string, sink eventSink) agent.AskFunc {
+	return func(ctx context.Context, question string) (string, error) {
+		ch := make(chan string, 1)
+		h.repliesMu.Lock()
+		h.replies[sessionID] = ch
+		h.repliesMu.Unlock()
+		defer func() {
+			h.repliesMu.Lock()
+			if h.replies[sessionID] == ch {
+				delete(h.replies, sessionID)
+			}
+			h.repliesMu.Unlock()
+		}()
+
+		sink.Send(sseEventAwaitingInput, sseAwaitingInputEvent{Question: question, TimeoutSeconds: int(h.askTimeout.Seconds())})
+		log.Printf("[Agent] Awaiting reply for session=%s: %s", sessionID, question)
+		timer := time.NewTimer(h.askTimeout)
+		defer timer.Stop()
+		select {
+		case reply := <-ch:
+			return reply, nil
+		case <-ctx.Done():
+			return "", context.Cause(ctx)
+		case <-timer.C:
+			return "", errNoReply
+		}
+	}
+}
+
+// HandleReply answers the clarifying question a session's run is waiting
+// on (POST /api/agent/reply with session_id and reply). The run continues
+// with the reply added to its conversation history.
+// Returns 409 when the session's run is not waiting for a reply.
+func (h *AgentHandler) HandleReply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+
+	sessionID := strings.TrimSpace(r.FormValue("session_id"))
+	reply := strings.TrimSpace(r.FormValue("reply"))
+	if sessionID == "" || reply == "" {
+		http.Error(w, "session_id and reply are required", http.StatusBadRequest)
+		return
+	}
+	if len([]rune(reply)) > maxReplyRunes {
+		http.Error(w, "Reply too long", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	h.repliesMu.Lock()
+	ch := h.replies[sessionID]
+	delete(h.replies, sessionID) // one reply per question
+	h.repliesMu.Unlock()
+	if ch == nil {
+		http.Error(w, "no agent run is waiting for a reply in this session", http.StatusConflict)
+		return
+	}
+	ch <- reply
+	log.Printf("[Agent] Reply for session=%s: %s", sessionID, reply)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]bool{"delivered": true})
+}
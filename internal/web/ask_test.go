@@ -0,0 +1,70 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postReply(h *AgentHandler, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/agent/reply", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	h.HandleReply(w, req)
+	return w
+}
+
+func TestHandleReply(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{AskTimeout: time.Minute})
+	form := url.Values{"session_id": {"s1"}, "reply": {"main 分支"}}
+
+	if w := postReply(h, form); w.Code != http.StatusConflict {
+		t.Fatalf("no run waiting: status = %d, want 409", w.Code)
+	}
+
+	sink := &recordingSink{}
+	done := make(chan string, 1)
+	go func() {
+		reply, _ := h.askUser("s1", sink)(context.Background(), "用哪个分支？")
+		done <- reply
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h.repliesMu.Lock()
+		waiting := h.replies["s1"] != nil
+		h.repliesMu.Unlock()
+		if waiting || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if w := postReply(h, form); w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202: %s", w.Code, w.Body)
+	}
+	if got := <-done; got != "main 分支" {
+		t.Errorf("reply = %q", got)
+	}
+	if w := postReply(h, form); w.Code != http.StatusConflict {
+		t.Errorf("second reply: status = %d, want 409", w.Code)
+	}
+
+	for name, bad := range map[string]url.Values{
+		"missing reply": {"session_id": {"s1"}},
+		"too long":      {"session_id": {"s1"}, "reply": {strings.Repeat("长", maxReplyRunes+1)}},
+	} {
+		if w := postReply(h, bad); w.Code < 400 || w.Code == http.StatusConflict {
+			t.Errorf("%s: status = %d, want a 4xx validation error", name, w.Code)
+		}
+	}
+}
+
+func TestAskUser_Timeout(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{AskTimeout: 20 * time.Millisecond})
+	if _, err := h.askUser("s1", &recordingSink{})(context.Background(), "q"); err != errNoReply {
+		t.Errorf("err = %v, want errNoReply", err)
+	}
+}
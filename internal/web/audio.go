@@ -0,0 +1,114 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/audio"
+)
+
+const (
+	maxAudioUpload = 25 << 20        // 25MB, the OpenAI transcription limit
+	maxSpeechRunes = 4096            // longest text the speech API accepts
+	audioTimeout   = 2 * time.Minute // per transcription / synthesis call
+)
+
+// AudioHandler serves voice input (/api/stt) and spoken answers (/api/tts).
+type AudioHandler struct {
+	stt audio.Transcriber // nil = speech-to-text disabled
+	tts audio.Synthesizer // nil = text-to-speech disabled
+}
+
+// NewAudioHandler creates a handler for the given backends; either may be nil.
+func NewAudioHandler(stt audio.Transcriber, tts audio.Synthesizer) *AudioHandler {
+	return &AudioHandler{stt: stt, tts: tts}
+}
+
+// HandleSTT serves POST /api/stt: a multipart "audio" recording in,
+// {"text": "..."} out.
+func (h *AudioHandler) HandleSTT(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.stt == nil {
+		http.Error(w, "Speech-to-text disabled", http.StatusNotFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxAudioUpload)
+	f, fh, err := r.FormFile("audio")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Recording too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Missing audio file", http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil || len(data) == 0 {
+		http.Error(w, "Cannot read audio file", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), audioTimeout)
+	defer cancel()
+	text, err := h.stt.Transcribe(ctx, data, fh.Filename)
+	if err != nil {
+		log.Printf("[Audio] STT failed: %v", err)
+		http.Error(w, "Transcription failed", http.StatusBadGateway)
+		return
+	}
+	log.Printf("[Audio] STT: %d bytes → %d chars", len(data), len(text))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"text": text})
+}
+
+// HandleTTS serves POST /api/tts: form field "text" in, audio out.
+func (h *AudioHandler) HandleTTS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.tts == nil {
+		http.Error(w, "Text-to-speech disabled", http.StatusNotFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	text := strings.TrimSpace(r.FormValue("text"))
+	if text == "" {
+		http.Error(w, "Empty text", http.StatusBadRequest)
+		return
+	}
+	if runes := []rune(text); len(runes) > maxSpeechRunes {
+		text = string(runes[:maxSpeechRunes]) // read the beginning of long answers
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), audioTimeout)
+	defer cancel()
+	speech, err := h.tts.Synthesize(ctx, text)
+	if err != nil {
+		log.Printf("[Audio] TTS failed: %v", err)
+		http.Error(w, "Speech synthesis failed", http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", speech.MIMEType)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(speech.Data)
+}
+
+// STTEnabled and TTSEnabled tell the UI which voice controls to offer.
+func (h *AudioHandler) STTEnabled() bool { return h != nil && h.stt != nil }
+func (h *AudioHandler) TTSEnabled() bool { return h != nil && h.tts != nil }
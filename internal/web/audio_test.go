@@ -0,0 +1,119 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/audio"
+)
+
+type fakeTranscriber struct {
+	text     string
+	err      error
+	filename string
+}
+
+func (f *fakeTranscriber) Transcribe(_ context.Context, _ []byte, filename string) (string, error) {
+	f.filename = filename
+	return f.text, f.err
+}
+
+type fakeSynthesizer struct{ text string }
+
+func (f *fakeSynthesizer) Synthesize(_ context.Context, text string) (audio.Speech, error) {
+	f.text = text
+	return audio.Speech{Data: []byte("ID3"), MIMEType: "audio/mpeg"}, nil
+}
+
+func postRecording(h *AudioHandler, data []byte) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if data != nil {
+		fw, _ := mw.CreateFormFile("audio", "voice.webm")
+		fw.Write(data)
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/stt", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	h.HandleSTT(w, req)
+	return w
+}
+
+func TestAudioHandler_STT(t *testing.T) {
+	stt := &fakeTranscriber{text: "列出文件"}
+	h := NewAudioHandler(stt, nil)
+
+	w := postRecording(h, []byte("webm"))
+	var resp map[string]string
+	json.NewDecoder(w.Body).Decode(&resp)
+	if w.Code != http.StatusOK || resp["text"] != "列出文件" || stt.filename != "voice.webm" {
+		t.Errorf("status = %d, resp = %v, filename = %q", w.Code, resp, stt.filename)
+	}
+	if w := postRecording(h, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("missing file: status = %d, want 400", w.Code)
+	}
+	stt.err = errors.New("upstream down")
+	if w := postRecording(h, []byte("webm")); w.Code != http.StatusBadGateway {
+		t.Errorf("backend error: status = %d, want 502", w.Code)
+	}
+	if w := postRecording(NewAudioHandler(nil, nil), []byte("webm")); w.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want 404", w.Code)
+	}
+}
+
+func TestAudioHandler_TTS(t *testing.T) {
+	tts := &fakeSynthesizer{}
+	h := NewAudioHandler(nil, tts)
+	post := func(text string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/tts", strings.NewReader(url.Values{"text": {text}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.HandleTTS(w, req)
+		return w
+	}
+
+	w := post("你好")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "audio/mpeg" || w.Body.String() != "ID3" {
+		t.Errorf("status = %d, type = %q, body = %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+	post(strings.Repeat("长", maxSpeechRunes+10))
+	if n := len([]rune(tts.text)); n != maxSpeechRunes {
+		t.Errorf("synthesized %d runes, want %d", n, maxSpeechRunes)
+	}
+	if w := post("  "); w.Code != http.StatusBadRequest {
+		t.Errorf("empty text: status = %d, want 400", w.Code)
+	}
+}
+
+func TestIndex_VoiceControls(t *testing.T) {
+	render := func(a *AudioHandler) string {
+		s, err := NewServer(&ChatHandler{}, nil, nil, nil, nil, nil, nil, a, HealthInfo{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return strings.Join(strings.Fields(w.Body.String()), " ") // html/template pads JS values
+	}
+
+	page := render(NewAudioHandler(&fakeTranscriber{}, nil))
+	if !strings.Contains(page, `id="mic-btn"`) || !strings.Contains(page, "SPEAK_ANSWERS = false") {
+		t.Error("STT-only page should offer the mic button but not read-aloud")
+	}
+	page = render(nil)
+	if strings.Contains(page, `id="mic-btn"`) || !strings.Contains(page, "SPEAK_ANSWERS = false") {
+		t.Error("page without audio should offer no voice controls")
+	}
+	if page := render(NewAudioHandler(nil, &fakeSynthesizer{})); !strings.Contains(page, "SPEAK_ANSWERS = true") {
+		t.Error("TTS page should enable read-aloud")
+	}
+}
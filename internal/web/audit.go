@@ -0,0 +1,74 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/audit"
+)
+
+// AuditHandler serves the audit log of tool calls.
+type AuditHandler struct {
+	log *audit.Log
+}
+
+// NewAuditHandler creates a handler querying l.
+func NewAuditHandler(l *audit.Log) *AuditHandler {
+	return &AuditHandler{log: l}
+}
+
+// auditResponse is the body of GET /api/audit.
+type auditResponse struct {
+	Entries []audit.Entry `json:"entries"`
+}
+
+// HandleAudit serves GET /api/audit: the most recent tool calls, newest
+// first. Query parameters session, user, tool and outcome match exactly;
+// since and until (RFC 3339) bound the time; limit caps the entries
+// (default 100, at most 1000).
+func (h *AuditHandler) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	f := audit.Filter{
+		SessionID: q.Get("session"),
+		User:      q.Get("user"),
+		Tool:      q.Get("tool"),
+		Outcome:   q.Get("outcome"),
+	}
+	for name, dst := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				http.Error(w, "Invalid "+name+": want RFC 3339 time", http.StatusBadRequest)
+				return
+			}
+			*dst = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		f.Limit = n
+	}
+
+	entries, err := h.log.Query(f)
+	if err != nil {
+		log.Printf("[Audit] Query failed: %v", err)
+		http.Error(w, "Failed to read audit log", http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auditResponse{Entries: entries})
+}
@@ -0,0 +1,49 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/audit"
+)
+
+func TestAuditHandler(t *testing.T) {
+	l, err := audit.Open(t.TempDir(), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Run("s1", "r1", "alice").Record("file_read", []byte(`{"path":"a"}`), audit.OutcomeOK, 2)
+	l.Run("s1", "r1", "alice").Record("shell_exec", []byte(`{"command":"ls"}`), audit.OutcomeError, 30)
+	l.Run("s2", "r2", "bob").Record("file_read", []byte(`{"path":"b"}`), audit.OutcomeOK, 1)
+
+	s := &Server{mux: http.NewServeMux()}
+	s.EnableAudit(NewAuditHandler(l))
+	get := func(path string) (*httptest.ResponseRecorder, []audit.Entry) {
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var resp auditResponse
+		json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp.Entries
+	}
+
+	if w, entries := get("/api/audit"); w.Code != http.StatusOK || len(entries) != 3 || entries[0].User != "bob" {
+		t.Errorf("all: status %d, entries %+v", w.Code, entries)
+	}
+	if _, entries := get("/api/audit?user=alice&tool=file_read"); len(entries) != 1 || entries[0].SessionID != "s1" {
+		t.Errorf("filtered = %+v", entries)
+	}
+	if _, entries := get("/api/audit?outcome=error&limit=5"); len(entries) != 1 || entries[0].Tool != "shell_exec" {
+		t.Errorf("outcome = %+v", entries)
+	}
+	if _, entries := get("/api/audit?until=2000-01-01T00:00:00Z"); entries == nil || len(entries) != 0 {
+		t.Errorf("until = %+v, want an empty list", entries)
+	}
+	for _, q := range []string{"since=yesterday", "limit=0", "limit=x"} {
+		if w, _ := get("/api/audit?" + q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", q, w.Code)
+		}
+	}
+}
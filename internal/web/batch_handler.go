@@ -0,0 +1,274 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/batch"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// maxBatchJobs is how many jobs (finished or not) the handler remembers;
+// the oldest finished job is forgotten first.
+const maxBatchJobs = 20
+
+// BatchHandlerOptions configures the batch API.
+type BatchHandlerOptions struct {
+	Runner *batch.Runner
+	// Roots limits workspaces to these directories (and below). Required:
+	// the API must not let callers point the agent at arbitrary paths.
+	Roots []string
+	// MaxParallel caps the parallelism a request may ask for (default 4).
+	MaxParallel int
+	ReadOnly    bool // read-only mirror mode: new jobs are refused
+	// Permissions, optional, limit each job's tools by the role of the user
+	// who submitted it, like agent runs. UserOf may be nil (every request
+	// is anonymous).
+	Permissions *tool.Permissions
+	UserOf      func(*http.Request) string
+}
+
+// BatchHandler runs batch jobs in the background:
+//
+//	POST   /api/batch          {"prompt", "workspaces": [...], "parallel"} → {"id"}
+//	GET    /api/batch          → list of jobs
+//	GET    /api/batch?id=b1    → job status and report (&format=markdown for text)
+//	DELETE /api/batch?id=b1    → cancel a running job
+type BatchHandler struct {
+	runner      *batch.Runner
+	roots       []string
+	maxParallel int
+	readOnly    bool
+	permissions *tool.Permissions
+	userOf      func(*http.Request) string
+
+	mu     sync.Mutex
+	jobs   map[string]*batchJob
+	nextID int
+}
+
+// batchJob is one submitted job and its progress.
+type batchJob struct {
+	ID         string        `json:"id"`
+	Status     string        `json:"status"` // "running", "done" or "cancelled"
+	Total      int           `json:"total"`
+	Done       int           `json:"done"`
+	Failed     int           `json:"failed"`
+	Created    time.Time     `json:"created"`
+	Report     *batch.Report `json:"report,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	cancel     context.CancelFunc
+	cancelling bool
+}
+
+// NewBatchHandler creates the batch API handler.
+func NewBatchHandler(opts BatchHandlerOptions) *BatchHandler {
+	if opts.MaxParallel <= 0 {
+		opts.MaxParallel = 4
+	}
+	roots := make([]string, 0, len(opts.Roots))
+	for _, r := range opts.Roots {
+		if r = strings.TrimSpace(r); r == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(r); err == nil {
+			roots = append(roots, abs)
+		}
+	}
+	return &BatchHandler{
+		runner:      opts.Runner,
+		roots:       roots,
+		maxParallel: opts.MaxParallel,
+		readOnly:    opts.ReadOnly,
+		permissions: opts.Permissions,
+		userOf:      opts.UserOf,
+		jobs:        make(map[string]*batchJob),
+	}
+}
+
+// HandleBatch dispatches on the HTTP method.
+func (h *BatchHandler) HandleBatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.submit(w, r)
+	case http.MethodGet:
+		if id := r.URL.Query().Get("id"); id != "" {
+			h.get(w, r, id)
+			return
+		}
+		h.list(w)
+	case http.MethodDelete:
+		h.cancel(w, r.URL.Query().Get("id"))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *BatchHandler) submit(w http.ResponseWriter, r *http.Request) {
+	if h.readOnly {
+		http.Error(w, "batch runs are disabled in read-only mode", http.StatusForbidden)
+		return
+	}
+	var job batch.Job
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&job); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(job.Prompt) == "" || len(job.Workspaces) == 0 {
+		http.Error(w, "prompt and workspaces are required", http.StatusBadRequest)
+		return
+	}
+	for i, ws := range job.Workspaces {
+		abs, err := h.allowed(ws)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job.Workspaces[i] = abs
+	}
+	job.Parallel = min(max(job.Parallel, 1), h.maxParallel)
+	if h.permissions != nil {
+		// Non-nil even for a role without tools: nil would mean no limit
+		job.Tools = append([]string{}, h.permissions.Tools(roleOf(h.permissions, h.userOf, r))...)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background()) // outlives the request
+	h.mu.Lock()
+	h.nextID++
+	j := &batchJob{
+		ID:      fmt.Sprintf("b%d", h.nextID),
+		Status:  "running",
+		Total:   len(job.Workspaces),
+		Created: time.Now(),
+		cancel:  cancel,
+	}
+	h.jobs[j.ID] = j
+	h.pruneLocked()
+	h.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		rep, err := h.runner.Run(ctx, job, func(res batch.Result) {
+			h.mu.Lock()
+			j.Done++
+			if !res.OK {
+				j.Failed++
+			}
+			h.mu.Unlock()
+		})
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		j.Report = rep
+		j.Status = "done"
+		if j.cancelling {
+			j.Status = "cancelled"
+		}
+		if err != nil {
+			j.Error = err.Error()
+			log.Printf("[Batch] Job %s failed: %v", j.ID, err)
+		}
+	}()
+
+	log.Printf("[Batch] Job %s started: %d workspace(s), parallel %d", j.ID, j.Total, job.Parallel)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"id": j.ID})
+}
+
+func (h *BatchHandler) get(w http.ResponseWriter, r *http.Request, id string) {
+	h.mu.Lock()
+	j, ok := h.jobs[id]
+	var snapshot batchJob
+	if ok {
+		snapshot = *j
+	}
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	if r.URL.Query().Get("format") == "markdown" {
+		if snapshot.Report == nil {
+			http.Error(w, "job has no report yet", http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		snapshot.Report.WriteMarkdown(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&snapshot)
+}
+
+func (h *BatchHandler) list(w http.ResponseWriter) {
+	h.mu.Lock()
+	jobs := make([]batchJob, 0, len(h.jobs))
+	for _, j := range h.jobs {
+		s := *j
+		s.Report = nil // summaries only
+		jobs = append(jobs, s)
+	}
+	h.mu.Unlock()
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].Created.Before(jobs[b].Created) })
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]batchJob{"jobs": jobs})
+}
+
+func (h *BatchHandler) cancel(w http.ResponseWriter, id string) {
+	h.mu.Lock()
+	j, ok := h.jobs[id]
+	if ok && j.Status == "running" {
+		j.cancelling = true
+		j.cancel()
+	}
+	h.mu.Unlock()
+	if !ok {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowed resolves ws and checks that it lies inside one of the roots.
+func (h *BatchHandler) allowed(ws string) (string, error) {
+	abs, err := filepath.Abs(strings.TrimSpace(ws))
+	if err != nil || strings.TrimSpace(ws) == "" {
+		return "", fmt.Errorf("invalid workspace path %q", ws)
+	}
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+	for _, root := range h.roots {
+		if realRoot, err := filepath.EvalSymlinks(root); err == nil {
+			root = realRoot
+		}
+		if rel, err := filepath.Rel(root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return abs, nil
+		}
+	}
+	return "", fmt.Errorf("workspace %q is outside the allowed batch roots", ws)
+}
+
+// pruneLocked forgets the oldest finished jobs beyond maxBatchJobs.
+func (h *BatchHandler) pruneLocked() {
+	for len(h.jobs) > maxBatchJobs {
+		var oldest *batchJob
+		for _, j := range h.jobs {
+			if j.Status != "running" && (oldest == nil || j.Created.Before(oldest.Created)) {
+				oldest = j
+			}
+		}
+		if oldest == nil {
+			return // all running
+		}
+		delete(h.jobs, oldest.ID)
+	}
+}
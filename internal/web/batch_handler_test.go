@@ -0,0 +1,176 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/batch"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func newTestBatchHandler(root string, readOnly bool) *BatchHandler {
+	return NewBatchHandler(BatchHandlerOptions{
+		Runner: batch.NewRunner(batch.Options{
+			Agent: batch.Agent{
+				Provider:     &mockLLMProvider{response: llm.Message{Role: llm.RoleAssistant, Content: `{"action":"answer","reason":"ok","answer":"done"}`}},
+				ThinkingMode: "native",
+				ToolCallMode: "json",
+			},
+			Tools: func(string) *tool.Registry { return tool.NewRegistry() },
+		}),
+		Roots:    []string{root},
+		ReadOnly: readOnly,
+	})
+}
+
+func TestBatchHandler_SubmitAndReport(t *testing.T) {
+	root := t.TempDir()
+	ws := filepath.Join(root, "svc-a")
+	os.Mkdir(ws, 0o755)
+	h := newTestBatchHandler(root, false)
+
+	w := httptest.NewRecorder()
+	h.HandleBatch(w, httptest.NewRequest(http.MethodPost, "/api/batch",
+		strings.NewReader(`{"prompt":"检查 README","workspaces":["`+ws+`"],"parallel":99}`)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("submit: %d %s", w.Code, w.Body)
+	}
+	var created struct{ ID string }
+	json.NewDecoder(w.Body).Decode(&created)
+
+	var job batchJob
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Status != "done" && time.Now().Before(deadline) {
+		w = httptest.NewRecorder()
+		h.HandleBatch(w, httptest.NewRequest(http.MethodGet, "/api/batch?id="+created.ID, nil))
+		json.NewDecoder(w.Body).Decode(&job)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Status != "done" || job.Done != 1 || job.Failed != 0 || job.Report == nil || job.Report.Parallel != 4 {
+		t.Fatalf("job = %+v", job)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleBatch(w, httptest.NewRequest(http.MethodGet, "/api/batch?id="+created.ID+"&format=markdown", nil))
+	if !strings.Contains(w.Body.String(), "1 succeeded, 0 failed") {
+		t.Errorf("markdown report = %s", w.Body)
+	}
+}
+
+func TestBatchHandler_RejectsOutsideRootsAndReadOnly(t *testing.T) {
+	root := t.TempDir()
+	h := newTestBatchHandler(root, false)
+	for _, ws := range []string{t.TempDir(), root + "/../escape"} {
+		w := httptest.NewRecorder()
+		h.HandleBatch(w, httptest.NewRequest(http.MethodPost, "/api/batch",
+			strings.NewReader(`{"prompt":"p","workspaces":["`+ws+`"]}`)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", ws, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	newTestBatchHandler(root, true).HandleBatch(w, httptest.NewRequest(http.MethodPost, "/api/batch",
+		strings.NewReader(`{"prompt":"p","workspaces":["`+root+`"]}`)))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("read-only submit: status %d, want 403", w.Code)
+	}
+}
+
+// shellCallProvider asks for shell_exec once, then answers.
+type shellCallProvider struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *shellCallProvider) CallLLM(context.Context, []llm.Message) (llm.Message, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	content := `{"action":"answer","reason":"完成","answer":"done"}`
+	if p.calls == 1 {
+		content = `{"action":"tool","reason":"执行命令","tool_name":"shell_exec","tool_params":{"command":"rm -rf ."}}`
+	}
+	return llm.Message{Role: llm.RoleAssistant, Content: content}, nil
+}
+func (p *shellCallProvider) CallLLMStream(ctx context.Context, msgs []llm.Message, _ llm.StreamCallback) (llm.Message, error) {
+	return p.CallLLM(ctx, msgs)
+}
+func (p *shellCallProvider) CallLLMWithTools(ctx context.Context, msgs []llm.Message, _ []llm.ToolDefinition) (llm.Message, error) {
+	return p.CallLLM(ctx, msgs)
+}
+func (p *shellCallProvider) IsToolCallingEnabled() bool { return false }
+
+// fakeShellTool counts the commands it would have run.
+type fakeShellTool struct{ runs *atomic.Int32 }
+
+func (fakeShellTool) Name() string                 { return "shell_exec" }
+func (fakeShellTool) Description() string          { return "run a shell command" }
+func (fakeShellTool) InputSchema() json.RawMessage { return json.RawMessage(`{"type":"object"}`) }
+func (t fakeShellTool) Execute(context.Context, json.RawMessage) (tool.ToolResult, error) {
+	t.runs.Add(1)
+	return tool.ToolResult{Output: "ok"}, nil
+}
+func (fakeShellTool) Init(context.Context) error { return nil }
+func (fakeShellTool) Close() error               { return nil }
+
+func TestBatchHandler_PermissionsLimitTools(t *testing.T) {
+	root := t.TempDir()
+	perms := &tool.Permissions{Roles: tool.DefaultRoles(), Users: map[string]string{"alice": "admin"}, DefaultRole: "member"}
+
+	run := func(user string) int32 {
+		t.Helper()
+		var runs atomic.Int32
+		h := NewBatchHandler(BatchHandlerOptions{
+			Runner: batch.NewRunner(batch.Options{
+				Agent: batch.Agent{Provider: &shellCallProvider{}, ThinkingMode: "native", ToolCallMode: "json"},
+				Tools: func(string) *tool.Registry {
+					reg := tool.NewRegistry()
+					reg.Register(fakeShellTool{runs: &runs})
+					return reg
+				},
+			}),
+			Roots:       []string{root},
+			Permissions: perms,
+			UserOf:      func(r *http.Request) string { return r.Header.Get("X-User") },
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/batch", strings.NewReader(`{"prompt":"清理","workspaces":["`+root+`"]}`))
+		req.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		h.HandleBatch(w, req)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("%s submit: %d %s", user, w.Code, w.Body)
+		}
+		var created struct{ ID string }
+		json.NewDecoder(w.Body).Decode(&created)
+		var job batchJob
+		deadline := time.Now().Add(5 * time.Second)
+		for job.Status != "done" && time.Now().Before(deadline) {
+			w = httptest.NewRecorder()
+			h.HandleBatch(w, httptest.NewRequest(http.MethodGet, "/api/batch?id="+created.ID, nil))
+			json.NewDecoder(w.Body).Decode(&job)
+			time.Sleep(10 * time.Millisecond)
+		}
+		if job.Status != "done" {
+			t.Fatalf("%s job = %+v", user, job)
+		}
+		return runs.Load()
+	}
+
+	if n := run("bob"); n != 0 {
+		t.Errorf("member ran shell_exec %d time(s) through /api/batch", n)
+	}
+	if n := run("alice"); n != 1 {
+		t.Errorf("admin shell_exec runs = %d, want 1", n)
+	}
+}
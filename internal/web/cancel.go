@@ -0,0 +1,72 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// errCancelledByUser is the cancellation cause of runs stopped through
+// /api/agent/{runID}/cancel, as opposed to timeouts and client disconnects.
+var errCancelledByUser = errors.New("cancelled by user")
+
+// openRun registers a cancellable run and returns its ID and context. The ID
+// is sent to the client in the run event so that it can cancel the run.
+func (h *AgentHandler) openRun(ctx context.Context) (string, context.Context) {
+	b := make([]byte, 8)
+	rand.Read(b)
+	runID := "r" + hex.EncodeToString(b)
+	ctx, cancel := context.WithCancelCause(ctx)
+	h.activeMu.Lock()
+	h.active[runID] = cancel
+	h.activeMu.Unlock()
+	return runID, ctx
+}
+
+// closeRun unregisters a finished run and releases its context.
+func (h *AgentHandler) closeRun(runID string) {
+	h.activeMu.Lock()
+	cancel := h.active[runID]
+	delete(h.active, runID)
+	h.activeMu.Unlock()
+	if cancel != nil {
+		cancel(nil)
+	}
+}
+
+// cancelRun aborts the run with runID; false when no such run is active.
+func (h *AgentHandler) cancelRun(runID string) bool {
+	h.activeMu.Lock()
+	cancel := h.active[runID]
+	h.activeMu.Unlock()
+	if cancel == nil {
+		return false
+	}
+	cancel(errCancelledByUser)
+	log.Printf("[Agent] Run %s cancelled by user", runID)
+	return true
+}
+
+// HandleCancel aborts a running agent (POST /api/agent/{runID}/cancel).
+// Cancelling the run's context stops the flow at the next node transition
+// and interrupts in-flight tool executions: shell commands are killed and
+// HTTP requests aborted. The run then ends with a "cancelled by user" step.
+// Returns 404 when no such run is in progress.
+func (h *AgentHandler) HandleCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.cancelRun(r.PathValue("runID")) {
+		http.Error(w, "no agent run in progress with this ID", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]bool{"cancelled": true})
+}
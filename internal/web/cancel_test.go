@@ -0,0 +1,143 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
+	"github.com/pocketomega/pocket-omega/internal/journal"
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// blockingLLMProvider never answers; calls return when ctx is cancelled.
+type blockingLLMProvider struct{}
+
+func (blockingLLMProvider) CallLLM(ctx context.Context, _ []llm.Message) (llm.Message, error) {
+	<-ctx.Done()
+	return llm.Message{}, ctx.Err()
+}
+func (p blockingLLMProvider) CallLLMStream(ctx context.Context, msgs []llm.Message, _ llm.StreamCallback) (llm.Message, error) {
+	return p.CallLLM(ctx, msgs)
+}
+func (p blockingLLMProvider) CallLLMWithTools(ctx context.Context, msgs []llm.Message, _ []llm.ToolDefinition) (llm.Message, error) {
+	return p.CallLLM(ctx, msgs)
+}
+func (blockingLLMProvider) IsToolCallingEnabled() bool { return false }
+
+func postCancel(h *AgentHandler, runID string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/agent/{runID}/cancel", h.HandleCancel)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/agent/"+runID+"/cancel", nil))
+	return w
+}
+
+func TestHandleCancel(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{})
+	if w := postCancel(h, "r123"); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown run: status = %d, want 404", w.Code)
+	}
+
+	runID, ctx := h.openRun(context.Background())
+	if w := postCancel(h, runID); w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202: %s", w.Code, w.Body)
+	}
+	if context.Cause(ctx) != errCancelledByUser {
+		t.Errorf("cause = %v, want errCancelledByUser", context.Cause(ctx))
+	}
+	h.closeRun(runID)
+	if w := postCancel(h, runID); w.Code != http.StatusNotFound {
+		t.Errorf("after run end: status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandleAgent_CancelStopsRun(t *testing.T) {
+	replayDir := t.TempDir()
+	recorder, err := agent.NewReplayRecorder(replayDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcomes, err := agent.NewOutcomeLog(filepath.Join(t.TempDir(), "outcomes.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	notes := journal.New(t.TempDir())
+	h := NewAgentHandler(AgentHandlerOptions{
+		Provider:       blockingLLMProvider{},
+		ReplayRecorder: recorder,
+		OutcomeLog:     outcomes,
+		Journal:        notes,
+		Registry:       tool.NewRegistry(),
+		WorkspaceDir:   t.TempDir(),
+		ThinkingMode:   "native",
+		ToolCallMode:   "yaml",
+	})
+
+	form := url.Values{"message": {"loop forever"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/agent", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		h.HandleAgent(w, req)
+		close(done)
+	}()
+
+	var runID string
+	for deadline := time.Now().Add(5 * time.Second); runID == "" && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		h.activeMu.Lock()
+		for id := range h.active {
+			runID = id
+		}
+		h.activeMu.Unlock()
+	}
+	if runID == "" {
+		t.Fatal("run was not registered")
+	}
+	if c := postCancel(h, runID); c.Code != http.StatusAccepted {
+		t.Fatalf("cancel status = %d", c.Code)
+	}
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("run did not stop after cancel")
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, `"run_id":"`+runID+`"`) {
+		t.Errorf("run event missing from stream: %s", body)
+	}
+	if !strings.Contains(body, i18n.T(i18n.DefaultLocale, "agent.cancelled")) {
+		t.Errorf("done event does not report the cancellation: %s", body)
+	}
+	runs, _ := filepath.Glob(filepath.Join(replayDir, "run-*.jsonl"))
+	if len(runs) != 1 {
+		t.Fatalf("replay runs = %v", runs)
+	}
+	if data, _ := os.ReadFile(runs[0]); !strings.Contains(string(data), `"action":"cancelled","tool_name":"","input":"","output":"cancelled by user"`) {
+		t.Errorf("replay lacks the terminal cancelled step:\n%s", data)
+	}
+	if !strings.Contains(body, `"outcome":"failure"`) {
+		t.Errorf("done event lacks the outcome: %s", body)
+	}
+	recs, err := outcomes.Recent(10)
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("outcome records = %+v, %v", recs, err)
+	}
+	if rec := recs[0]; rec.RunID != runID || rec.Outcome != agent.OutcomeFailure ||
+		len(rec.Reasons) != 1 || rec.Reasons[0] != agent.ReasonCancelled || rec.Replay != filepath.Base(runs[0]) {
+		t.Errorf("outcome record = %+v", rec)
+	}
+	if note, _ := notes.Read(time.Now()); !strings.Contains(note, "❌ loop forever → "+i18n.T(i18n.DefaultLocale, "agent.cancelled")) {
+		t.Errorf("journal note = %q", note)
+	}
+}
@@ -7,9 +7,13 @@ import (
 	"net/http"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pocketomega/pocket-omega/internal/i18n"
 	"github.com/pocketomega/pocket-omega/internal/llm"
 	"github.com/pocketomega/pocket-omega/internal/prompt"
 	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
 	"github.com/pocketomega/pocket-omega/internal/thinking"
 )
 
@@ -125,7 +129,12 @@ func (h *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	limitRequestBody(w, r)
+	images, err := parseImageUploads(r, h.llmProvider)
+	if err != nil {
+		handleUploadError(w, err)
+		return
+	}
 
 	userMsg := strings.TrimSpace(r.FormValue("message"))
 	if userMsg == "" {
@@ -137,7 +146,7 @@ func (h *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("[Chat] Received: %s", userMsg)
+	log.Printf("[Chat] Received: %s%s", userMsg, imageMarker(images))
 
 	// Session history lookup
 	sessionID := strings.TrimSpace(r.FormValue("session_id"))
@@ -156,8 +165,14 @@ func (h *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Global timeout for the chat flow
-	ctx, cancel := context.WithTimeout(r.Context(), chatTimeout)
+	// Global timeout for the chat flow. Chat calls may be answered from the
+	// LLM response cache: re-asking the same question in the same context
+	// replays the stored thoughts and answer.
+	llmCtx := llm.WithCachePolicy(withLLMSession(r.Context(), sessionID, r.RemoteAddr), llm.CacheAlways)
+	llmCtx = llm.WithRetryNotifier(llmCtx, func(n llm.RetryNotice) {
+		sse.Send(sseEventStatus, sseStatusEvent{Message: retryNoticeMessage(i18n.DefaultLocale, n)})
+	})
+	ctx, cancel := context.WithTimeout(llmCtx, chatTimeout)
 	defer cancel()
 
 	// Build and run the CoT flow with streaming callback
@@ -165,15 +180,18 @@ func (h *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 	state := &thinking.ThinkingState{
 		Problem:             userMsg,
 		ConversationHistory: historyMsgs,
+		Images:              images,
 		OnThoughtComplete: func(thought thinking.ThoughtData) {
-			sse.Send("thought", sseThoughtEvent{
+			sse.Send(sseEventThought, sseThoughtEvent{
 				ThoughtNumber:   thought.ThoughtNumber,
 				CurrentThinking: strings.TrimSpace(thought.CurrentThinking),
 				PlanText:        thinking.FormatPlan(thought.Planning, 0),
 			})
 		},
 	}
-	flow.Run(ctx, state)
+	runCtx, span := telemetry.Start(ctx, "chat.run", attribute.String("session.id", sessionID))
+	flow.Run(runCtx, state)
+	telemetry.End(span, ctx.Err())
 
 	solution := strings.TrimSpace(state.Solution)
 	if solution == "" {
@@ -191,13 +209,13 @@ func (h *ChatHandler) HandleChat(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	sse.Send("done", sseDoneEvent{Solution: solution})
+	sse.Send(sseEventDone, sseDoneEvent{Solution: solution})
 	log.Printf("[Chat] Done: %d thoughts, solution %d chars", len(state.Thoughts), len(solution))
 
 	// Persist this turn to session history
 	if sessionID != "" && h.sessionStore != nil {
 		h.sessionStore.AppendTurn(sessionID, session.Turn{
-			UserMsg:   userMsg,
+			UserMsg:   userMsg + imageMarker(images),
 			Assistant: solution,
 			IsAgent:   false,
 		})
@@ -3,16 +3,26 @@ package web
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/config"
+	"github.com/pocketomega/pocket-omega/internal/journal"
 	"github.com/pocketomega/pocket-omega/internal/llm"
 	"github.com/pocketomega/pocket-omega/internal/prompt"
 	"github.com/pocketomega/pocket-omega/internal/session"
 	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/util"
+	"github.com/pocketomega/pocket-omega/internal/walkthrough"
 )
 
 // CommandHandlerOptions configures the slash command handler.
@@ -20,11 +30,31 @@ type CommandHandlerOptions struct {
 	Loader       *prompt.PromptLoader
 	MCPReload    func() // nil = no MCP; /reload only reloads prompts
 	Store        *session.Store
-	LLMProvider  llm.LLMProvider // used by /compact for summary generation
-	ToolRegistry *tool.Registry  // used by /stats for tool count
-	ModelName    string          // used by /stats
-	ThinkingMode string          // used by /stats
-	ToolCallMode string          // used by /stats
+	LLMProvider  llm.LLMProvider        // used by /compact for summary generation
+	ToolRegistry *tool.Registry         // used by /stats for tool count
+	ModelName    string                 // used by /stats
+	ThinkingMode string                 // used by /stats
+	ToolCallMode string                 // used by /stats
+	ReplayDir    string                 // used by /replay; "" = replay logging disabled
+	ReadOnly     bool                   // read-only mirror mode: state-changing commands are refused
+	Checkpoints  *agent.CheckpointStore // used by /resume; nil = plan persistence disabled
+	Journal      *journal.Journal       // used by /journal; nil = daily notes disabled
+	Walkthroughs *walkthrough.Archive   // used by /walkthrough; nil = memo archive disabled
+	WorkspaceDir string                 // used by /help to list playbooks/
+	// ForkSession of the agent handler, used by /fork; nil = agent mode disabled
+	Fork func(sessionID string) (ForkResult, bool)
+	// omega.yaml applied at startup, shown by /config; nil = no config file
+	Config *config.Loaded
+	// Re-reads .env and omega.yaml and applies them, used by /reload; nil = prompts and MCP only
+	ConfigReload func() (ReloadResult, error)
+	// Limits shown by /help; zero = unlimited
+	MaxAgentTokens    int64
+	MaxAgentDuration  time.Duration
+	MaxConcurrentRuns int
+	// Permissions, optional, limit adminCommands to admins. UserOf may be
+	// nil (every request is anonymous).
+	Permissions *tool.Permissions
+	UserOf      func(*http.Request) string
 }
 
 // commandResult is the JSON response from a slash command.
@@ -32,6 +62,8 @@ type commandResult struct {
 	OK      bool   `json:"ok"`
 	Message string `json:"message"`
 	Action  string `json:"action,omitempty"` // optional frontend action (e.g. "clear_chat")
+	URL     string `json:"url,omitempty"`    // page opened by the "open_fork" action
+	Prompt  string `json:"prompt,omitempty"` // message sent to the agent by the "send_prompt" action
 }
 
 // commandFunc handles a single slash command.
@@ -47,7 +79,38 @@ type CommandHandler struct {
 	modelName    string
 	thinkingMode string
 	toolCallMode string
+	replayDir    string
+	readOnly     bool
+	checkpoints  *agent.CheckpointStore
+	journal      *journal.Journal
+	walkthroughs *walkthrough.Archive
+	workspaceDir string
+	fork         func(string) (ForkResult, bool)
+	config       *config.Loaded
+	configReload func() (ReloadResult, error)
 	commands     map[string]commandFunc
+	permissions  *tool.Permissions
+	userOf       func(*http.Request) string
+
+	maxAgentTokens    int64
+	maxAgentDuration  time.Duration
+	maxConcurrentRuns int
+
+	// Guards modelName, config and the agent limits (see ApplyReload)
+	settingsMu sync.RWMutex
+}
+
+// mutatingCommands change server or session state and are refused in
+// read-only mode. /clear only resets the caller's own tab and stays allowed.
+var mutatingCommands = map[string]bool{
+	"reload":  true,
+	"compact": true,
+}
+
+// adminCommands change what every user's runs use (prompts, MCP servers,
+// configuration) and are limited to admins when permissions are set.
+var adminCommands = map[string]bool{
+	"reload": true,
 }
 
 // NewCommandHandler creates a command handler with built-in commands.
@@ -61,13 +124,35 @@ func NewCommandHandler(opts CommandHandlerOptions) *CommandHandler {
 		modelName:    opts.ModelName,
 		thinkingMode: opts.ThinkingMode,
 		toolCallMode: opts.ToolCallMode,
+		replayDir:    opts.ReplayDir,
+		readOnly:     opts.ReadOnly,
+		checkpoints:  opts.Checkpoints,
+		journal:      opts.Journal,
+		walkthroughs: opts.Walkthroughs,
+		workspaceDir: opts.WorkspaceDir,
+		fork:         opts.Fork,
+		config:       opts.Config,
+		configReload: opts.ConfigReload,
+		permissions:  opts.Permissions,
+		userOf:       opts.UserOf,
+
+		maxAgentTokens:    opts.MaxAgentTokens,
+		maxAgentDuration:  opts.MaxAgentDuration,
+		maxConcurrentRuns: opts.MaxConcurrentRuns,
 	}
 	h.commands = map[string]commandFunc{
-		"reload":  h.cmdReload,
-		"clear":   h.cmdClear,
-		"help":    h.cmdHelp,
-		"compact": h.cmdCompact,
-		"stats":   h.cmdStats,
+		"reload":      h.cmdReload,
+		"clear":       h.cmdClear,
+		"help":        h.cmdHelp,
+		"compact":     h.cmdCompact,
+		"stats":       h.cmdStats,
+		"replay":      h.cmdReplay,
+		"resume":      h.cmdResume,
+		"journal":     h.cmdJournal,
+		"walkthrough": h.cmdWalkthrough,
+		"fork":        h.cmdFork,
+		"config":      h.cmdConfig,
+		"template":    h.cmdTemplate,
 	}
 	return h
 }
@@ -102,6 +187,16 @@ func (h *CommandHandler) HandleCommand(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.readOnly && mutatingCommands[req.Command] {
+		json.NewEncoder(w).Encode(commandResult{OK: false, Message: "只读模式下不可使用 /" + req.Command})
+		return
+	}
+
+	if adminCommands[req.Command] && !isAdmin(h.permissions, h.userOf, r) {
+		json.NewEncoder(w).Encode(commandResult{OK: false, Message: "仅管理员可使用 /" + req.Command})
+		return
+	}
+
 	result := fn(r.Context(), req.Args, req.SessionID)
 	json.NewEncoder(w).Encode(result)
 }
@@ -116,7 +211,14 @@ func (h *CommandHandler) cmdReload(ctx context.Context, args, sessionID string)
 		h.mcpReload()
 	}
 	log.Printf("[Command] /reload executed")
-	return commandResult{OK: true, Message: "✅ 提示词和 MCP 配置已重载"}
+	if h.configReload == nil {
+		return commandResult{OK: true, Message: "✅ 提示词和 MCP 配置已重载"}
+	}
+	res, err := h.configReload()
+	if err != nil {
+		return commandResult{OK: false, Message: "⚠️ 提示词和 MCP 配置已重载，但配置未更新：" + err.Error()}
+	}
+	return commandResult{OK: true, Message: "✅ 提示词和 MCP 配置已重载\n" + res.Summary()}
 }
 
 func (h *CommandHandler) cmdClear(ctx context.Context, args, sessionID string) commandResult {
@@ -127,18 +229,6 @@ func (h *CommandHandler) cmdClear(ctx context.Context, args, sessionID string) c
 	return commandResult{OK: true, Message: "✅ 对话已清空", Action: "clear_chat"}
 }
 
-func (h *CommandHandler) cmdHelp(ctx context.Context, args, sessionID string) commandResult {
-	return commandResult{
-		OK: true,
-		Message: "可用命令:\n" +
-			"/reload — 重载提示词和 MCP 配置\n" +
-			"/clear — 清空当前对话\n" +
-			"/compact [N] — 压缩历史对话为摘要（保留最近 N 轮，默认 2）\n" +
-			"/stats — 显示当前会话状态和系统信息\n" +
-			"/help — 显示此帮助",
-	}
-}
-
 func (h *CommandHandler) cmdStats(ctx context.Context, args, sessionID string) commandResult {
 	var sb strings.Builder
 	sb.WriteString("📊 当前会话状态\n")
@@ -174,8 +264,11 @@ func (h *CommandHandler) cmdStats(ctx context.Context, args, sessionID string) c
 	}
 
 	// Model info
-	if h.modelName != "" {
-		sb.WriteString(fmt.Sprintf("• 模型：%s\n", h.modelName))
+	h.settingsMu.RLock()
+	modelName := h.modelName
+	h.settingsMu.RUnlock()
+	if modelName != "" {
+		sb.WriteString(fmt.Sprintf("• 模型：%s\n", modelName))
 	}
 	sb.WriteString(fmt.Sprintf("• 思维模式：%s | 工具调用：%s\n", h.thinkingMode, h.toolCallMode))
 
@@ -225,3 +318,367 @@ func (h *CommandHandler) cmdCompact(ctx context.Context, args, sessionID string)
 			compacted, len([]rune(summary))),
 	}
 }
+
+// replayListMax caps the runs listed by /replay; replayRenderMaxRunes caps
+// the rendered run so a long replay does not flood the chat.
+const (
+	replayListMax        = 20
+	replayRenderMaxRunes = 20000
+)
+
+func (h *CommandHandler) cmdReplay(ctx context.Context, args, sessionID string) commandResult {
+	if h.replayDir == "" {
+		return commandResult{OK: false, Message: "回放记录未启用（AGENT_REPLAY_LOG=false）"}
+	}
+	runs, err := agent.ListReplays(h.replayDir)
+	if err != nil {
+		return commandResult{OK: false, Message: "读取回放记录失败: " + err.Error()}
+	}
+	if len(runs) == 0 {
+		return commandResult{OK: true, Message: "ℹ️ 暂无运行记录"}
+	}
+
+	args = strings.TrimSpace(args)
+	if args == "" {
+		var sb strings.Builder
+		sb.WriteString("🎞️ 最近的运行记录（/replay N 查看详情）\n")
+		for i, run := range runs {
+			if i >= replayListMax {
+				sb.WriteString(fmt.Sprintf("… 另有 %d 条\n", len(runs)-replayListMax))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("%d. %s  %s\n", i+1, run.Time.Local().Format("01-02 15:04"), util.TruncateRunes(run.Problem, 60)))
+		}
+		return commandResult{OK: true, Message: sb.String()}
+	}
+
+	n, err := strconv.Atoi(args)
+	if err != nil || n < 1 || n > len(runs) {
+		return commandResult{OK: false, Message: fmt.Sprintf("无效的序号 %q，可选 1-%d", args, len(runs))}
+	}
+	events, err := agent.LoadReplay(filepath.Join(h.replayDir, runs[n-1].Name))
+	if err != nil {
+		return commandResult{OK: false, Message: "读取回放记录失败: " + err.Error()}
+	}
+	var sb strings.Builder
+	if _, err := agent.RenderReplay(ctx, &sb, events, agent.ReplayOptions{MaxOutputRunes: 300}); err != nil {
+		return commandResult{OK: false, Message: "回放失败: " + err.Error()}
+	}
+	return commandResult{OK: true, Message: util.TruncateRunes(sb.String(), replayRenderMaxRunes)}
+}
+
+// cmdResume reports the session's interrupted run and tells the UI to
+// continue it: the frontend answers the resume_run action with an agent
+// request carrying resume=true.
+func (h *CommandHandler) cmdResume(ctx context.Context, args, sessionID string) commandResult {
+	if h.checkpoints == nil {
+		return commandResult{OK: false, Message: "运行检查点未启用（AGENT_CHECKPOINTS=false）"}
+	}
+	if sessionID == "" {
+		return commandResult{OK: false, Message: "当前没有会话"}
+	}
+	cp, err := h.checkpoints.Load(sessionID)
+	if err != nil {
+		return commandResult{OK: false, Message: "读取中断记录失败: " + err.Error()}
+	}
+	if cp == nil || !cp.Unfinished() {
+		return commandResult{OK: false, Message: "没有可恢复的中断运行"}
+	}
+
+	var sb strings.Builder
+	done := 0
+	for _, s := range cp.Plan {
+		if s.Status == "done" {
+			done++
+		}
+	}
+	progress := fmt.Sprintf("已执行 %d 步，计划完成 %d/%d", len(cp.Steps), done, len(cp.Plan))
+	if len(cp.Plan) == 0 {
+		progress = fmt.Sprintf("已执行 %d 步", len(cp.Steps))
+	}
+	if cp.Crashed {
+		progress = "进程退出时中断，" + progress
+	}
+	fmt.Fprintf(&sb, "♻️ 继续中断的任务（%s 中断，%s）：%s\n",
+		cp.UpdatedAt.Format("01-02 15:04"), progress, util.TruncateRunes(cp.Problem, 80))
+	for _, s := range cp.Plan {
+		if s.Status == "pending" || s.Status == "in_progress" {
+			fmt.Fprintf(&sb, "• 待完成 %s: %s\n", s.ID, s.Title)
+		}
+	}
+	log.Printf("[Command] /resume executed, session=%s", sessionID)
+	return commandResult{OK: true, Message: strings.TrimSpace(sb.String()), Action: "resume_run"}
+}
+
+// cmdFork branches the conversation into a new session (see
+// AgentHandler.ForkSession); the frontend opens the fork in a new tab.
+func (h *CommandHandler) cmdFork(ctx context.Context, args, sessionID string) commandResult {
+	if h.fork == nil {
+		return commandResult{OK: false, Message: "分支会话需要启用 Agent 模式"}
+	}
+	if sessionID == "" {
+		return commandResult{OK: false, Message: "当前没有会话"}
+	}
+	res, ok := h.fork(sessionID)
+	if !ok {
+		return commandResult{OK: false, Message: "当前会话还没有可复制的内容"}
+	}
+	msg := fmt.Sprintf("⑂ 已创建分支会话（%d 轮对话", res.Turns)
+	if res.PlanSteps > 0 {
+		msg += fmt.Sprintf("，%d 步计划可用 /resume 继续", res.PlanSteps)
+	}
+	msg += "），已在新标签页打开；原会话不受影响"
+	log.Printf("[Command] /fork executed, session=%s → %s", sessionID, res.SessionID)
+	return commandResult{OK: true, Message: msg, Action: "open_fork", URL: res.URL}
+}
+
+// cmdConfig lists the settings that differ from the defaults and where
+// each comes from (the full list is GET /api/config).
+func (h *CommandHandler) cmdConfig(ctx context.Context, args, sessionID string) commandResult {
+	h.settingsMu.RLock()
+	loaded := h.config
+	h.settingsMu.RUnlock()
+	var sb strings.Builder
+	if loaded != nil {
+		fmt.Fprintf(&sb, "⚙️ 配置文件：%s（环境变量优先）\n", loaded.Path)
+	} else {
+		sb.WriteString("⚙️ 未使用配置文件（omega.yaml），设置来自环境变量\n")
+	}
+	n := 0
+	for _, s := range config.Effective(loaded) {
+		if s.Source == "default" {
+			continue
+		}
+		n++
+		source := "环境变量"
+		if s.Source == "file" {
+			source = "配置文件"
+		}
+		fmt.Fprintf(&sb, "• %s = %s（%s %s）\n", s.Key, util.TruncateRunes(s.Value, 80), source, s.Env)
+	}
+	if n == 0 {
+		sb.WriteString("• 全部使用默认值\n")
+	}
+	return commandResult{OK: true, Message: strings.TrimSpace(sb.String())}
+}
+
+// journalListMax caps the days listed by /journal list and the lines found
+// by /journal find.
+const journalListMax = 30
+
+// cmdJournal shows a daily note (today by default, "yesterday" or a
+// YYYY-MM-DD date), lists the days with notes, or searches all notes.
+func (h *CommandHandler) cmdJournal(ctx context.Context, args, sessionID string) commandResult {
+	if h.journal == nil {
+		return commandResult{OK: false, Message: "工作日志未启用（JOURNAL_ENABLED=true 开启）"}
+	}
+	verb, rest, _ := strings.Cut(strings.TrimSpace(args), " ")
+	now := time.Now()
+	var day time.Time
+	switch verb {
+	case "", "today":
+		day = now
+	case "yesterday":
+		day = now.AddDate(0, 0, -1)
+	case "list":
+		days, err := h.journal.Days()
+		if err != nil {
+			return commandResult{OK: false, Message: "读取工作日志失败: " + err.Error()}
+		}
+		if len(days) == 0 {
+			return commandResult{OK: true, Message: "ℹ️ 暂无工作日志"}
+		}
+		var sb strings.Builder
+		sb.WriteString("📓 有记录的日期（/journal 日期 查看）\n")
+		for i, d := range days {
+			if i >= journalListMax {
+				fmt.Fprintf(&sb, "… 另有 %d 天\n", len(days)-journalListMax)
+				break
+			}
+			sb.WriteString(d.Format(journal.DayLayout) + "\n")
+		}
+		return commandResult{OK: true, Message: sb.String()}
+	case "find":
+		if strings.TrimSpace(rest) == "" {
+			return commandResult{OK: false, Message: "用法: /journal find 关键词"}
+		}
+		hits, err := h.journal.Search(rest, journalListMax)
+		if err != nil {
+			return commandResult{OK: false, Message: "读取工作日志失败: " + err.Error()}
+		}
+		if len(hits) == 0 {
+			return commandResult{OK: true, Message: fmt.Sprintf("ℹ️ 工作日志中没有 %q", strings.TrimSpace(rest))}
+		}
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "🔎 %q 的记录\n", strings.TrimSpace(rest))
+		for _, hit := range hits {
+			fmt.Fprintf(&sb, "%s %s\n", hit.Day.Format(journal.DayLayout), util.TruncateRunes(hit.Line, 200))
+		}
+		return commandResult{OK: true, Message: sb.String()}
+	default:
+		d, err := time.ParseInLocation(journal.DayLayout, verb, time.Local)
+		if err != nil {
+			return commandResult{OK: false, Message: fmt.Sprintf("无效的日期 %q，格式为 YYYY-MM-DD", verb)}
+		}
+		day = d
+	}
+
+	note, err := h.journal.Read(day)
+	if err != nil {
+		return commandResult{OK: false, Message: "读取工作日志失败: " + err.Error()}
+	}
+	if note == "" {
+		return commandResult{OK: true, Message: fmt.Sprintf("ℹ️ %s 没有工作日志", day.Format(journal.DayLayout))}
+	}
+	return commandResult{OK: true, Message: util.TruncateRunes(strings.TrimSpace(note), replayRenderMaxRunes)}
+}
+
+// cmdWalkthrough lists the memos archived by past runs (newest first) or
+// shows one of them by list number or ID.
+func (h *CommandHandler) cmdWalkthrough(ctx context.Context, args, sessionID string) commandResult {
+	if h.walkthroughs == nil {
+		return commandResult{OK: false, Message: "备忘录存档未启用（WALKTHROUGH_ARCHIVE=false）"}
+	}
+	memos, err := h.walkthroughs.List()
+	if err != nil {
+		return commandResult{OK: false, Message: "读取备忘录失败: " + err.Error()}
+	}
+	if len(memos) == 0 {
+		return commandResult{OK: true, Message: "ℹ️ 暂无备忘录存档"}
+	}
+
+	args = strings.TrimSpace(args)
+	if args == "" || args == "list" {
+		var sb strings.Builder
+		sb.WriteString("🧭 过去运行的备忘录（/walkthrough N 查看）\n")
+		for i, m := range memos {
+			if i >= replayListMax {
+				fmt.Fprintf(&sb, "… 另有 %d 条\n", len(memos)-replayListMax)
+				break
+			}
+			fmt.Fprintf(&sb, "%d. %s  %s（%d 条）\n", i+1, m.Time.Local().Format("01-02 15:04"), util.TruncateRunes(m.Problem, 60), m.Count)
+		}
+		return commandResult{OK: true, Message: sb.String()}
+	}
+
+	id := args
+	if n, err := strconv.Atoi(args); err == nil {
+		if n < 1 || n > len(memos) {
+			return commandResult{OK: false, Message: fmt.Sprintf("无效的序号 %q，可选 1-%d", args, len(memos))}
+		}
+		id = memos[n-1].ID
+	}
+	memo, err := h.walkthroughs.Get(id)
+	if errors.Is(err, walkthrough.ErrNotFound) {
+		return commandResult{OK: false, Message: fmt.Sprintf("没有备忘录 %q", args)}
+	}
+	if err != nil {
+		return commandResult{OK: false, Message: "读取备忘录失败: " + err.Error()}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🧭 %s  %s\n", memo.Time.Local().Format("2006-01-02 15:04"), memo.Problem)
+	for _, e := range memo.Entries {
+		if e.Source == walkthrough.SourceManual {
+			fmt.Fprintf(&sb, "📌 %s\n", e.Content)
+		} else {
+			fmt.Fprintf(&sb, "[步骤%d] %s\n", e.StepNumber, e.Content)
+		}
+	}
+	return commandResult{OK: true, Message: util.TruncateRunes(strings.TrimSpace(sb.String()), replayRenderMaxRunes)}
+}
+
+// cmdTemplate lists the task templates under prompts/templates/, or expands
+// one with name=value arguments; the frontend sends the result to the agent.
+func (h *CommandHandler) cmdTemplate(ctx context.Context, args, sessionID string) commandResult {
+	if h.loader == nil {
+		return commandResult{OK: false, Message: "提示词加载器未启用"}
+	}
+	fields, err := splitCommandArgs(args)
+	if err != nil {
+		return commandResult{OK: false, Message: err.Error()}
+	}
+	if len(fields) == 0 || fields[0] == "list" {
+		templates := h.loader.Templates()
+		if len(templates) == 0 {
+			return commandResult{OK: true, Message: "ℹ️ 还没有任务模板：把含 {{变量}} 占位符的 .md 文件放进 prompts/templates/ 即可用 /template <名称> 变量=值 调用"}
+		}
+		var sb strings.Builder
+		sb.WriteString("📝 任务模板（/template <名称> 变量=值）\n")
+		for _, t := range templates {
+			fmt.Fprintf(&sb, "• %s", t.Name)
+			for _, v := range t.Vars {
+				if v.Required {
+					fmt.Fprintf(&sb, " %s=…", v.Name)
+				} else {
+					fmt.Fprintf(&sb, " [%s=%s]", v.Name, v.Default)
+				}
+			}
+			if t.Description != "" {
+				fmt.Fprintf(&sb, " — %s", t.Description)
+			}
+			sb.WriteString("\n")
+		}
+		return commandResult{OK: true, Message: strings.TrimRight(sb.String(), "\n")}
+	}
+
+	t, err := h.loader.Template(fields[0])
+	if errors.Is(err, prompt.ErrTemplateNotFound) {
+		return commandResult{OK: false, Message: fmt.Sprintf("没有任务模板 %q，输入 /template 查看全部", fields[0])}
+	}
+	if err != nil {
+		return commandResult{OK: false, Message: "读取任务模板失败: " + err.Error()}
+	}
+	vars := make(map[string]string, len(fields)-1)
+	for _, f := range fields[1:] {
+		name, value, ok := strings.Cut(f, "=")
+		if !ok || name == "" {
+			return commandResult{OK: false, Message: fmt.Sprintf("参数 %q 应写作 变量=值", f)}
+		}
+		vars[name] = value
+	}
+	text, err := t.Expand(vars)
+	if err != nil {
+		return commandResult{OK: false, Message: fmt.Sprintf("任务模板 %s 展开失败: %v", t.Name, err)}
+	}
+	switch text = strings.TrimSpace(text); {
+	case text == "":
+		return commandResult{OK: false, Message: fmt.Sprintf("任务模板 %s 内容为空", t.Name)}
+	case strings.HasPrefix(text, "/"):
+		// The message would be run as another slash command
+		return commandResult{OK: false, Message: fmt.Sprintf("任务模板 %s 不能以 / 开头", t.Name)}
+	}
+	return commandResult{OK: true, Message: fmt.Sprintf("📝 已展开任务模板 %s", t.Name), Action: "send_prompt", Prompt: text}
+}
+
+// splitCommandArgs splits command arguments on whitespace; double quotes
+// group a value with spaces, e.g. topic="release notes".
+func splitCommandArgs(args string) ([]string, error) {
+	var (
+		fields  []string
+		cur     strings.Builder
+		quoted  bool
+		pending bool // cur holds a field, possibly an empty quoted one
+	)
+	for _, r := range args {
+		switch {
+		case r == '"':
+			quoted, pending = !quoted, true
+		case !quoted && unicode.IsSpace(r):
+			if pending {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				pending = false
+			}
+		default:
+			cur.WriteRune(r)
+			pending = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("引号未闭合")
+	}
+	if pending {
+		fields = append(fields, cur.String())
+	}
+	return fields, nil
+}
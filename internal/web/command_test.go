@@ -6,12 +6,20 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/journal"
 	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/plan"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
 	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/tool"
 )
 
 // mockLLMProvider implements llm.LLMProvider for testing cmdCompact.
@@ -273,3 +281,176 @@ func TestCmdCompact_KeepZero(t *testing.T) {
 		t.Errorf("unexpected summary: %q", summary)
 	}
 }
+
+func decodeResult(t *testing.T, w *httptest.ResponseRecorder) commandResult {
+	t.Helper()
+	var result commandResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return result
+}
+
+func TestHandleCommand_ReadOnlyRefusesMutatingCommands(t *testing.T) {
+	h := NewCommandHandler(CommandHandlerOptions{Store: session.NewStore(time.Minute, 10), ReadOnly: true})
+	t.Cleanup(func() { h.store.Close() })
+
+	for _, cmd := range []string{"reload", "compact"} {
+		res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: cmd, SessionID: "s"}))
+		if res.OK || !strings.Contains(res.Message, "只读模式") {
+			t.Errorf("/%s should be refused in read-only mode, got %+v", cmd, res)
+		}
+	}
+	if res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "stats"})); !res.OK {
+		t.Errorf("/stats should stay available, got %+v", res)
+	}
+}
+
+func TestHandleCommand_ReloadLimitedToAdmins(t *testing.T) {
+	var user string
+	h := NewCommandHandler(CommandHandlerOptions{
+		Store:       session.NewStore(time.Minute, 10),
+		Permissions: &tool.Permissions{Roles: tool.DefaultRoles(), Users: map[string]string{"alice": "admin"}, DefaultRole: "member"},
+		UserOf:      func(*http.Request) string { return user },
+	})
+	t.Cleanup(func() { h.store.Close() })
+
+	if res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "reload"})); res.OK || !strings.Contains(res.Message, "仅管理员") {
+		t.Errorf("/reload by a member should be refused, got %+v", res)
+	}
+	if res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "stats"})); !res.OK {
+		t.Errorf("/stats should stay available to members, got %+v", res)
+	}
+	user = "alice"
+	if res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "reload"})); !res.OK {
+		t.Errorf("/reload by an admin should run, got %+v", res)
+	}
+}
+
+func TestHandleCommand_Replay(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := agent.NewReplayRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := rec.StartRun("s1", "统计 go 文件数量", "thinking=app toolcall=fc")
+	run.RecordStep(agent.StepRecord{StepNumber: 1, Type: "tool", ToolName: "find", Output: "a.go\nb.go"})
+	run.End(&agent.AgentState{Solution: "共 2 个", StepHistory: make([]agent.StepRecord, 1)})
+
+	h := NewCommandHandler(CommandHandlerOptions{ReplayDir: dir})
+	list := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "replay"}))
+	if !list.OK || !strings.Contains(list.Message, "1.") || !strings.Contains(list.Message, "统计 go 文件数量") {
+		t.Errorf("listing = %+v", list)
+	}
+	detail := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "replay", Args: "1"}))
+	if !detail.OK || !strings.Contains(detail.Message, "b.go") || !strings.Contains(detail.Message, "共 2 个") {
+		t.Errorf("detail = %+v", detail)
+	}
+	if bad := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "replay", Args: "9"})); bad.OK {
+		t.Error("out-of-range index should fail")
+	}
+}
+
+func TestHandleCommand_Resume(t *testing.T) {
+	checkpoints, err := agent.NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewCommandHandler(CommandHandlerOptions{Checkpoints: checkpoints})
+	if none := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "resume", SessionID: "s1"})); none.OK {
+		t.Errorf("no checkpoint: %+v", none)
+	}
+
+	checkpoints.Save(&agent.Checkpoint{
+		SessionID: "s1",
+		Problem:   "整理 docs 目录",
+		Plan: []plan.PlanStep{
+			{ID: "list", Title: "列出文件", Status: "done"},
+			{ID: "move", Title: "移动旧文档", Status: "pending"},
+		},
+	})
+	res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "resume", SessionID: "s1"}))
+	if !res.OK || res.Action != "resume_run" || !strings.Contains(res.Message, "1/2") ||
+		!strings.Contains(res.Message, "移动旧文档") || strings.Contains(res.Message, "列出文件") {
+		t.Errorf("resume = %+v", res)
+	}
+
+	// A run without a plan is resumable only after a crash
+	checkpoints.Save(&agent.Checkpoint{SessionID: "s2", Problem: "跑测试", Steps: []agent.StepRecord{{StepNumber: 1}}})
+	if res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "resume", SessionID: "s2"})); res.OK {
+		t.Errorf("finished run without plan: %+v", res)
+	}
+	checkpoints.Save(&agent.Checkpoint{SessionID: "s2", Problem: "跑测试", Steps: []agent.StepRecord{{StepNumber: 1}}, Crashed: true})
+	res = decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "resume", SessionID: "s2"}))
+	if !res.OK || res.Action != "resume_run" || !strings.Contains(res.Message, "进程退出时中断，已执行 1 步") {
+		t.Errorf("crashed run = %+v", res)
+	}
+}
+
+func TestHandleCommand_Journal(t *testing.T) {
+	if res := decodeResult(t, doCommand(t, NewCommandHandler(CommandHandlerOptions{}), http.MethodPost, commandRequest{Command: "journal"})); res.OK {
+		t.Errorf("journal disabled: %+v", res)
+	}
+
+	j := journal.New(t.TempDir())
+	h := NewCommandHandler(CommandHandlerOptions{Journal: j})
+	journalCmd := func(args string) commandResult {
+		return decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "journal", Args: args}))
+	}
+	if res := journalCmd(""); !res.OK || !strings.Contains(res.Message, "没有工作日志") {
+		t.Errorf("empty today = %+v", res)
+	}
+	j.Append("✅ 升级依赖 → 已完成")
+	today := time.Now().Format(journal.DayLayout)
+	for _, args := range []string{"", "today", today} {
+		if res := journalCmd(args); !res.OK || !strings.Contains(res.Message, "升级依赖") {
+			t.Errorf("/journal %s = %+v", args, res)
+		}
+	}
+	if res := journalCmd("list"); !res.OK || !strings.Contains(res.Message, today) {
+		t.Errorf("list = %+v", res)
+	}
+	if res := journalCmd("find 依赖"); !res.OK || !strings.Contains(res.Message, today+" - ") {
+		t.Errorf("find = %+v", res)
+	}
+	if res := journalCmd("2026-13-01"); res.OK {
+		t.Errorf("invalid date = %+v", res)
+	}
+}
+
+func TestCmdTemplate(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "templates"), 0o755)
+	os.WriteFile(filepath.Join(dir, "templates", "triage.md"), []byte("---\ndescription: 分诊\n---\n分析 {{issue}} 的日志，输出 {{format|表格}}。\n第二行"), 0o644)
+	os.WriteFile(filepath.Join(dir, "templates", "loop.md"), []byte("/template loop"), 0o644)
+	h := NewCommandHandler(CommandHandlerOptions{Loader: prompt.NewPromptLoader(dir, "", "")})
+
+	res := h.cmdTemplate(context.Background(), "", "s1")
+	if !res.OK || !strings.Contains(res.Message, "triage issue=… [format=表格] — 分诊") {
+		t.Errorf("list = %+v", res)
+	}
+
+	res = h.cmdTemplate(context.Background(), `triage issue="#42 crash" format=列表`, "s1")
+	if !res.OK || res.Action != "send_prompt" || res.Prompt != "分析 #42 crash 的日志，输出 列表。\n第二行" {
+		t.Errorf("expand = %+v", res)
+	}
+
+	for args, want := range map[string]string{
+		"triage":             "issue",
+		"triage issue":       "变量=值",
+		`triage issue="open`: "引号未闭合",
+		"missing":            "没有任务模板",
+		"loop":               "不能以 / 开头",
+	} {
+		if res := h.cmdTemplate(context.Background(), args, "s1"); res.OK || !strings.Contains(res.Message, want) {
+			t.Errorf("/template %s = %+v, want error containing %q", args, res, want)
+		}
+	}
+}
+
+func TestSplitCommandArgs(t *testing.T) {
+	got, err := splitCommandArgs(`  a  b="c d" ""  e="" `)
+	if err != nil || !reflect.DeepEqual(got, []string{"a", "b=c d", "", "e="}) {
+		t.Errorf("splitCommandArgs = %q, %v", got, err)
+	}
+}
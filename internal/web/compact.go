@@ -44,9 +44,9 @@ func buildCompactSummary(
 	}
 
 	// Apply 60s timeout for LLM call. When called from OnContextOverflow the outer
-	// ctx already has a 60s timeout (set in Post()), but cmdCompact passes r.Context()
-	// which may have no deadline — so this inner timeout is the primary safeguard.
-	llmCtx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	// ctx is the agent run's (bounded by agentTimeout), but cmdCompact passes
+	// r.Context() which may have no deadline — so this inner timeout is the primary safeguard.
+	llmCtx, cancel := context.WithTimeout(llm.WithModelRole(ctx, llm.ModelSummarize), 60*time.Second)
 	defer cancel()
 
 	resp, err := provider.CallLLM(llmCtx, []llm.Message{
@@ -0,0 +1,46 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/pocketomega/pocket-omega/internal/config"
+)
+
+// ConfigHandler serves the effective settings of the process.
+type ConfigHandler struct {
+	mu     sync.RWMutex
+	loaded *config.Loaded // nil = no omega.yaml
+}
+
+// NewConfigHandler creates a handler reporting the settings of loaded,
+// the applied config file (nil when there is none).
+func NewConfigHandler(loaded *config.Loaded) *ConfigHandler {
+	return &ConfigHandler{loaded: loaded}
+}
+
+// configResponse is the body of GET /api/config.
+type configResponse struct {
+	File     string           `json:"file,omitempty"` // path of the applied omega.yaml
+	Settings []config.Setting `json:"settings"`
+}
+
+// HandleConfig serves GET /api/config: every setting of the omega.yaml
+// schema with its effective value and source (file, env or default).
+// Secrets are redacted.
+func (h *ConfigHandler) HandleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h.mu.RLock()
+	loaded := h.loaded
+	h.mu.RUnlock()
+	resp := configResponse{Settings: config.Effective(loaded)}
+	if loaded != nil {
+		resp.File = loaded.Path
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
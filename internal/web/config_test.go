@@ -0,0 +1,37 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleConfig(t *testing.T) {
+	t.Setenv("LLM_MODEL", "gpt-4o")
+	t.Setenv("LLM_API_KEY", "sk-live-secret")
+	h := NewConfigHandler(nil)
+
+	w := httptest.NewRecorder()
+	h.HandleConfig(w, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	var resp configResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, s := range resp.Settings {
+		got[s.Env] = s.Value
+		if s.Source == "file" {
+			t.Errorf("%s: source file without a config file", s.Key)
+		}
+	}
+	if got["LLM_MODEL"] != "gpt-4o" || got["LLM_API_KEY"] != "[REDACTED]" {
+		t.Errorf("settings = %v", got)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandleConfig(w, httptest.NewRequest(http.MethodPost, "/api/config", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d", w.Code)
+	}
+}
@@ -0,0 +1,114 @@
+package web
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/editor"
+)
+
+// sseEventEditorLink follows a tool step that touched a workspace file; the
+// UI shows it as an "open in editor" link on that step.
+const sseEventEditorLink = "editor_link"
+
+type sseEditorLinkEvent struct {
+	StepNumber int    `json:"step_number"`
+	Path       string `json:"path"` // workspace-relative
+	Line       int    `json:"line,omitempty"`
+	URL        string `json:"url,omitempty"` // empty for command editors: POST /api/editor/open
+}
+
+// editorLinkTools are the tools whose "path" argument names a file worth
+// opening; file_patch also carries the edited line.
+var editorLinkTools = map[string]bool{
+	"file_read":  true,
+	"file_write": true,
+	"file_patch": true,
+	"file_open":  true,
+}
+
+// editorLink returns the link for a completed step, or nil when the step
+// touched no existing workspace file or no editor is configured.
+func (h *AgentHandler) editorLink(step agent.StepRecord) *sseEditorLinkEvent {
+	if h.editor == nil || step.Type != "tool" || !editorLinkTools[step.ToolName] {
+		return nil
+	}
+	var args struct {
+		Path      string `json:"path"`
+		Line      int    `json:"line"`
+		StartLine int    `json:"start_line"`
+	}
+	if json.Unmarshal([]byte(step.Input), &args) != nil || args.Path == "" {
+		return nil
+	}
+	abs, rel, ok := h.workspaceFile(args.Path)
+	if !ok {
+		return nil
+	}
+	line := args.Line
+	if line == 0 {
+		line = args.StartLine
+	}
+	return &sseEditorLinkEvent{StepNumber: step.StepNumber, Path: rel, Line: line, URL: h.editor.URL(abs, line)}
+}
+
+// workspaceFile resolves path to an existing regular file inside the
+// workspace, following symlinks.
+func (h *AgentHandler) workspaceFile(path string) (abs, rel string, ok bool) {
+	if h.workspaceDir == "" {
+		return "", "", false
+	}
+	root, err := filepath.EvalSymlinks(h.workspaceDir)
+	if err != nil {
+		return "", "", false
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(h.workspaceDir, path)
+	}
+	abs, err = filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", "", false
+	}
+	rel, err = filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", false
+	}
+	if info, err := os.Stat(abs); err != nil || !info.Mode().IsRegular() {
+		return "", "", false
+	}
+	return abs, filepath.ToSlash(rel), true
+}
+
+// HandleEditorOpen serves POST /api/editor/open (form: path, line) for
+// command editors, which run on the server host. URL editors are opened by
+// the browser and get 404 here.
+func (h *AgentHandler) HandleEditorOpen(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.editor == nil || h.editor.Kind != editor.KindCommand {
+		http.Error(w, "Editor command not configured", http.StatusNotFound)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	abs, rel, ok := h.workspaceFile(strings.TrimSpace(r.FormValue("path")))
+	if !ok {
+		http.Error(w, "File not found in workspace", http.StatusBadRequest)
+		return
+	}
+	line, _ := strconv.Atoi(r.FormValue("line"))
+	if err := h.editor.Open(abs, line); err != nil {
+		log.Printf("[Editor] Open %s failed: %v", rel, err)
+		http.Error(w, "Cannot start editor", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[Editor] Opened %s:%d", rel, line)
+	w.WriteHeader(http.StatusNoContent)
+}
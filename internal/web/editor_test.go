@@ -0,0 +1,78 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/editor"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func newEditorTestHandler(t *testing.T, e *editor.Editor) (*AgentHandler, string) {
+	t.Helper()
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "src"), 0o755)
+	os.WriteFile(filepath.Join(ws, "src", "main.go"), []byte("package main\n"), 0o644)
+	return NewAgentHandler(AgentHandlerOptions{Registry: tool.NewRegistry(), WorkspaceDir: ws, Editor: e}), ws
+}
+
+func TestEditorLink(t *testing.T) {
+	h, ws := newEditorTestHandler(t, &editor.Editor{Kind: editor.KindVSCode})
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(outside, []byte("x"), 0o644)
+
+	link := h.editorLink(agent.StepRecord{StepNumber: 3, Type: "tool", ToolName: "file_patch",
+		Input: `{"path":"src/main.go","start_line":12,"end_line":14,"content":""}`})
+	if link == nil || link.StepNumber != 3 || link.Path != "src/main.go" || link.Line != 12 ||
+		!strings.HasPrefix(link.URL, "vscode://file/") || !strings.HasSuffix(link.URL, "/src/main.go:12") {
+		t.Fatalf("link = %+v", link)
+	}
+
+	for name, step := range map[string]agent.StepRecord{
+		"missing file":   {Type: "tool", ToolName: "file_read", Input: `{"path":"src/gone.go"}`},
+		"outside":        {Type: "tool", ToolName: "file_read", Input: `{"path":"` + outside + `"}`},
+		"traversal":      {Type: "tool", ToolName: "file_read", Input: `{"path":"../` + filepath.Base(ws) + `x/a"}`},
+		"directory":      {Type: "tool", ToolName: "file_read", Input: `{"path":"src"}`},
+		"unlinked tool":  {Type: "tool", ToolName: "shell_exec", Input: `{"path":"src/main.go"}`},
+		"not a tool":     {Type: "think", ToolName: "file_read", Input: `{"path":"src/main.go"}`},
+		"malformed args": {Type: "tool", ToolName: "file_read", Input: `{"path":`},
+	} {
+		if link := h.editorLink(step); link != nil {
+			t.Errorf("%s: link = %+v, want none", name, link)
+		}
+	}
+
+	noEditor, _ := newEditorTestHandler(t, nil)
+	if link := noEditor.editorLink(agent.StepRecord{Type: "tool", ToolName: "file_read", Input: `{"path":"src/main.go"}`}); link != nil {
+		t.Errorf("no editor: link = %+v", link)
+	}
+}
+
+func TestHandleEditorOpen(t *testing.T) {
+	post := func(h *AgentHandler, form url.Values) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/editor/open", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		h.HandleEditorOpen(w, req)
+		return w.Code
+	}
+
+	vscode, _ := newEditorTestHandler(t, &editor.Editor{Kind: editor.KindVSCode})
+	if code := post(vscode, url.Values{"path": {"src/main.go"}}); code != http.StatusNotFound {
+		t.Errorf("URL editor: status = %d, want 404", code)
+	}
+
+	h, _ := newEditorTestHandler(t, &editor.Editor{Kind: editor.KindCommand, Command: []string{"true", "{file}"}})
+	if code := post(h, url.Values{"path": {"src/main.go"}, "line": {"4"}}); code != http.StatusNoContent {
+		t.Errorf("open: status = %d, want 204", code)
+	}
+	if code := post(h, url.Values{"path": {"../etc/passwd"}}); code != http.StatusBadRequest {
+		t.Errorf("outside workspace: status = %d, want 400", code)
+	}
+}
@@ -0,0 +1,478 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+)
+
+// Limits of the files API.
+const (
+	maxFileUpload   = 50 << 20 // 50MB body of POST /api/files/upload
+	maxListEntries  = 1000     // entries returned by GET /api/files
+	maxPreviewBytes = 64 << 10 // content returned by GET /api/files/preview
+)
+
+// FilesHandler serves the workspace to the browser: users browse it next to
+// the conversation, drop input files in and fetch what the agent produced,
+// without access to the host. Paths are sandboxed like those of the file
+// tools, and changes honor protected files and read-only mode.
+type FilesHandler struct {
+	workspaceDir string
+	readOnly     bool
+	permissions  *tool.Permissions
+	userOf       func(*http.Request) string
+}
+
+// NewFilesHandler creates a handler for workspaceDir. In read-only mode
+// uploads, renames and deletes are refused.
+func NewFilesHandler(workspaceDir string, readOnly bool) *FilesHandler {
+	return &FilesHandler{workspaceDir: workspaceDir, readOnly: readOnly}
+}
+
+// WithPermissions limits each endpoint to users whose role may use the
+// matching file tool (file_list, file_read, file_write, file_move,
+// file_delete). userOf may be nil (every request is anonymous).
+func (h *FilesHandler) WithPermissions(p *tool.Permissions, userOf func(*http.Request) string) *FilesHandler {
+	h.permissions = p
+	h.userOf = userOf
+	return h
+}
+
+// allowed reports whether the user of r may use toolName.
+func (h *FilesHandler) allowed(r *http.Request, toolName string) bool {
+	if h.permissions == nil {
+		return true
+	}
+	return h.permissions.Allows(roleOf(h.permissions, h.userOf, r), toolName)
+}
+
+// uploadedFile is one saved file in the response of POST /api/files/upload.
+type uploadedFile struct {
+	Path string `json:"path"` // workspace-relative
+	Size int64  `json:"size"`
+}
+
+type uploadResponse struct {
+	Files []uploadedFile `json:"files"`
+}
+
+// fileEntry is one entry of a directory listing.
+type fileEntry struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"` // workspace-relative
+	Dir     bool      `json:"dir"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+type listResponse struct {
+	Path      string      `json:"path"` // workspace-relative; "" for the root
+	Entries   []fileEntry `json:"entries"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+type previewResponse struct {
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Content   string    `json:"content,omitempty"`
+	Binary    bool      `json:"binary,omitempty"` // no content: not UTF-8 text
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// resolve returns the sandboxed absolute path of the workspace path p and
+// its workspace-relative form. On failure the error has been written.
+func (h *FilesHandler) resolve(w http.ResponseWriter, p string) (abs, rel string, ok bool) {
+	abs, err := builtin.SafeResolvePath(p, h.workspaceDir)
+	if err != nil {
+		http.Error(w, "Path outside the workspace", http.StatusForbidden)
+		return "", "", false
+	}
+	root, _ := filepath.Abs(h.workspaceDir)
+	full, _ := filepath.Abs(abs)
+	rel, err = filepath.Rel(root, full)
+	if err != nil {
+		http.Error(w, "Path outside the workspace", http.StatusForbidden)
+		return "", "", false
+	}
+	if rel == "." {
+		rel = ""
+	}
+	return abs, filepath.ToSlash(rel), true
+}
+
+// HandleList serves GET /api/files?path=: the entries of a workspace
+// directory (default the root), directories first, then by name.
+func (h *FilesHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.allowed(r, "file_list") {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	abs, rel, ok := h.resolve(w, strings.TrimSpace(r.URL.Query().Get("path")))
+	if !ok {
+		return
+	}
+	dirEntries, err := os.ReadDir(abs)
+	if err != nil {
+		if info, serr := os.Stat(abs); serr == nil && !info.IsDir() {
+			http.Error(w, "Not a directory", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Directory not found", http.StatusNotFound)
+		return
+	}
+	resp := listResponse{Path: rel, Entries: make([]fileEntry, 0, min(len(dirEntries), maxListEntries))}
+	for _, d := range dirEntries {
+		if len(resp.Entries) == maxListEntries {
+			resp.Truncated = true
+			break
+		}
+		info, err := os.Stat(filepath.Join(abs, d.Name())) // follows links, like the file tools
+		if err != nil {
+			continue
+		}
+		resp.Entries = append(resp.Entries, fileEntry{
+			Name:    d.Name(),
+			Path:    path.Join(rel, d.Name()),
+			Dir:     info.IsDir(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(resp.Entries, func(i, j int) bool {
+		a, b := resp.Entries[i], resp.Entries[j]
+		if a.Dir != b.Dir {
+			return a.Dir
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// HandlePreview serves GET /api/files/preview?path=: the start of a text
+// file; binary files only report their size.
+func (h *FilesHandler) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.allowed(r, "file_read") {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	abs, rel, ok := h.resolve(w, strings.TrimSpace(r.URL.Query().Get("path")))
+	if !ok {
+		return
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		http.Error(w, "Not a file", http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(f, maxPreviewBytes))
+	if err != nil {
+		http.Error(w, "Cannot read file", http.StatusInternalServerError)
+		return
+	}
+	resp := previewResponse{Path: rel, Size: info.Size(), ModTime: info.ModTime(), Truncated: info.Size() > int64(len(data))}
+	if resp.Truncated {
+		// Cut at a rune boundary so a split character does not look binary.
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	if bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data) {
+		resp.Binary = true
+	} else {
+		resp.Content = string(data)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkWritable refuses changes in read-only mode and to users whose role
+// may not use toolName. On refusal the error has been written.
+func (h *FilesHandler) checkWritable(w http.ResponseWriter, r *http.Request, toolName string) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if h.readOnly {
+		http.Error(w, "Read-only mode: file changes disabled", http.StatusForbidden)
+		return false
+	}
+	if !h.allowed(r, toolName) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// resolveChangeable resolves a path for rename or delete: it must exist,
+// be neither the workspace root nor a protected file.
+func (h *FilesHandler) resolveChangeable(w http.ResponseWriter, p string) (abs, rel string, ok bool) {
+	if p == "" {
+		http.Error(w, "Missing path", http.StatusBadRequest)
+		return "", "", false
+	}
+	if abs, rel, ok = h.resolve(w, p); !ok {
+		return "", "", false
+	}
+	if rel == "" {
+		http.Error(w, "The workspace root cannot be changed", http.StatusForbidden)
+		return "", "", false
+	}
+	if msg := builtin.CheckProtectedFile(abs, h.workspaceDir); msg != "" {
+		http.Error(w, msg, http.StatusForbidden)
+		return "", "", false
+	}
+	if _, err := os.Lstat(abs); err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return "", "", false
+	}
+	return abs, rel, true
+}
+
+// HandleRename serves POST /api/files/rename (form: path, to): moves a file
+// or directory within the workspace. An existing target is never replaced.
+func (h *FilesHandler) HandleRename(w http.ResponseWriter, r *http.Request) {
+	if !h.checkWritable(w, r, "file_move") {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	from, fromRel, ok := h.resolveChangeable(w, strings.TrimSpace(r.FormValue("path")))
+	if !ok {
+		return
+	}
+	toPath := strings.TrimSpace(r.FormValue("to"))
+	if toPath == "" {
+		http.Error(w, "Missing to", http.StatusBadRequest)
+		return
+	}
+	to, toRel, ok := h.resolve(w, toPath)
+	if !ok {
+		return
+	}
+	if msg := builtin.CheckProtectedFile(to, h.workspaceDir); msg != "" {
+		http.Error(w, msg, http.StatusForbidden)
+		return
+	}
+	if _, err := os.Lstat(to); err == nil {
+		http.Error(w, toRel+" already exists", http.StatusConflict)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		http.Error(w, "Cannot create "+path.Dir(toRel), http.StatusInternalServerError)
+		return
+	}
+	if err := os.Rename(from, to); err != nil {
+		log.Printf("[Files] Rename %s → %s failed: %v", fromRel, toRel, err)
+		http.Error(w, "Cannot rename "+fromRel, http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[Files] Renamed %s → %s", fromRel, toRel)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleDelete serves POST /api/files/delete (form: path): removes a file or
+// an empty directory. Protected files and the workspace root are refused.
+func (h *FilesHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if !h.checkWritable(w, r, "file_delete") {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	abs, rel, ok := h.resolveChangeable(w, strings.TrimSpace(r.FormValue("path")))
+	if !ok {
+		return
+	}
+	if err := os.Remove(abs); err != nil {
+		if info, serr := os.Lstat(abs); serr == nil && info.IsDir() {
+			http.Error(w, rel+" is not empty", http.StatusConflict)
+			return
+		}
+		log.Printf("[Files] Delete %s failed: %v", rel, err)
+		http.Error(w, "Cannot delete "+rel, http.StatusInternalServerError)
+		return
+	}
+	log.Printf("[Files] Deleted %s", rel)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleDownload serves GET /api/files/download?path=: a workspace file as
+// an attachment (Range requests supported).
+func (h *FilesHandler) HandleDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !h.allowed(r, "file_read") {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	p := strings.TrimSpace(r.URL.Query().Get("path"))
+	if p == "" {
+		http.Error(w, "Missing path", http.StatusBadRequest)
+		return
+	}
+	abs, err := builtin.SafeResolvePath(p, h.workspaceDir)
+	if err != nil {
+		http.Error(w, "Path outside the workspace", http.StatusForbidden)
+		return
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		http.Error(w, "Not a file", http.StatusBadRequest)
+		return
+	}
+	name := filepath.Base(abs)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+// HandleUpload serves POST /api/files/upload: multipart "file" parts are
+// saved under the workspace directory dir (form field, default the
+// workspace root). Existing files are only replaced with overwrite=true;
+// otherwise nothing is written and the answer is 409.
+func (h *FilesHandler) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	if !h.checkWritable(w, r, "file_write") {
+		return
+	}
+	if !isMultipart(r) {
+		http.Error(w, "Expected multipart/form-data", http.StatusBadRequest)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxFileUpload)
+	if err := r.ParseMultipartForm(maxRequestBody); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, fmt.Sprintf("Upload too large (max %d MB)", maxFileUpload>>20), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid multipart form", http.StatusBadRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 {
+		http.Error(w, "No file", http.StatusBadRequest)
+		return
+	}
+	dir := strings.TrimSpace(r.FormValue("dir"))
+	overwrite := r.FormValue("overwrite") == "true"
+
+	// Check every target before writing any.
+	type target struct {
+		fh  *multipart.FileHeader
+		abs string
+		rel string
+	}
+	targets := make([]target, 0, len(files))
+	for _, fh := range files {
+		// Browsers send a base name; strip any client path either way.
+		name := filepath.Base(filepath.FromSlash(strings.ReplaceAll(fh.Filename, `\`, "/")))
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			http.Error(w, fmt.Sprintf("Invalid file name %q", fh.Filename), http.StatusBadRequest)
+			return
+		}
+		rel := filepath.Join(dir, name)
+		abs, err := builtin.SafeResolvePath(rel, h.workspaceDir)
+		if err != nil {
+			http.Error(w, "Path outside the workspace", http.StatusForbidden)
+			return
+		}
+		if msg := builtin.CheckProtectedFile(abs, h.workspaceDir); msg != "" {
+			http.Error(w, msg, http.StatusForbidden)
+			return
+		}
+		rel = filepath.ToSlash(rel)
+		if info, err := os.Stat(abs); err == nil {
+			if info.IsDir() {
+				http.Error(w, rel+" is a directory", http.StatusConflict)
+				return
+			}
+			if !overwrite {
+				http.Error(w, rel+" already exists (send overwrite=true to replace it)", http.StatusConflict)
+				return
+			}
+		}
+		targets = append(targets, target{fh: fh, abs: abs, rel: rel})
+	}
+
+	resp := uploadResponse{Files: make([]uploadedFile, 0, len(targets))}
+	for _, t := range targets {
+		n, err := saveUpload(t.fh, t.abs)
+		if err != nil {
+			log.Printf("[Files] Upload of %s failed: %v", t.rel, err)
+			http.Error(w, "Cannot save "+t.rel, http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[Files] Uploaded %s (%d bytes)", t.rel, n)
+		resp.Files = append(resp.Files, uploadedFile{Path: t.rel, Size: n})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// saveUpload writes fh to path through a temporary file in the same
+// directory, so a failed upload never leaves a partial file behind.
+func saveUpload(fh *multipart.FileHeader, path string) (int64, error) {
+	src, err := fh.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+	n, err := io.Copy(tmp, src)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,292 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// uploadRequest builds an upload form with the given files (name → content)
+// and extra form fields.
+func uploadRequest(t *testing.T, files map[string]string, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	for name, content := range files {
+		fw, err := mw.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(content))
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/files/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func filesServer(h *FilesHandler) *Server {
+	s := &Server{mux: http.NewServeMux()}
+	s.EnableFiles(h)
+	return s
+}
+
+func TestFilesHandler_UploadAndDownload(t *testing.T) {
+	ws := t.TempDir()
+	s := filesServer(NewFilesHandler(ws, false))
+
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, uploadRequest(t, map[string]string{`C:\Users\me\data.csv`: "a,b\n1,2\n"}, map[string]string{"dir": "inputs"}))
+	var resp uploadResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("upload: status = %d, err = %v", w.Code, err)
+	}
+	if len(resp.Files) != 1 || resp.Files[0].Path != "inputs/data.csv" || resp.Files[0].Size != 8 {
+		t.Errorf("upload response = %+v", resp)
+	}
+
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/files/download?path=inputs/data.csv", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "a,b\n1,2\n" {
+		t.Fatalf("download: status = %d, body = %q", w.Code, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename=data.csv` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+
+	// A second upload of the same name conflicts unless overwrite is set.
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, uploadRequest(t, map[string]string{"data.csv": "new"}, map[string]string{"dir": "inputs"}))
+	if w.Code != http.StatusConflict {
+		t.Errorf("re-upload: status = %d, want 409", w.Code)
+	}
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, uploadRequest(t, map[string]string{"data.csv": "new"}, map[string]string{"dir": "inputs", "overwrite": "true"}))
+	if data, _ := os.ReadFile(filepath.Join(ws, "inputs", "data.csv")); w.Code != http.StatusOK || string(data) != "new" {
+		t.Errorf("overwrite: status = %d, content = %q", w.Code, data)
+	}
+}
+
+func TestFilesHandler_Sandbox(t *testing.T) {
+	ws := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0o644)
+	os.Symlink(outside, filepath.Join(ws, "escape"))
+	os.Mkdir(filepath.Join(ws, "sub"), 0o755)
+	s := filesServer(NewFilesHandler(ws, false))
+
+	for target, want := range map[string]int{
+		"/api/files/download?path=../x.txt":          http.StatusForbidden,
+		"/api/files/download?path=escape/secret.txt": http.StatusForbidden,
+		"/api/files/download?path=" + outside:        http.StatusForbidden,
+		"/api/files/download?path=sub":               http.StatusBadRequest,
+		"/api/files/download?path=missing.txt":       http.StatusNotFound,
+		"/api/files/download":                        http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: status = %d, want %d", target, w.Code, want)
+		}
+	}
+
+	for name, c := range map[string]struct {
+		files  map[string]string
+		fields map[string]string
+		want   int
+	}{
+		"dir outside":       {map[string]string{"a.txt": "x"}, map[string]string{"dir": "../up"}, http.StatusForbidden},
+		"dir through link":  {map[string]string{"a.txt": "x"}, map[string]string{"dir": "escape"}, http.StatusForbidden},
+		"protected file":    {map[string]string{"mcp.json": "{}"}, nil, http.StatusForbidden},
+		"onto a directory":  {map[string]string{"sub": "x"}, nil, http.StatusConflict},
+		"no file":           {nil, map[string]string{"dir": "x"}, http.StatusBadRequest},
+		"path in file name": {map[string]string{"../../evil.txt": "x"}, nil, http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, uploadRequest(t, c.files, c.fields))
+		if w.Code != c.want {
+			t.Errorf("%s: status = %d, want %d (%s)", name, w.Code, c.want, strings.TrimSpace(w.Body.String()))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(ws, "evil.txt")); err != nil {
+		t.Error("a client path in the file name should be reduced to its base name")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "a.txt")); err == nil {
+		t.Error("nothing may be written outside the workspace")
+	}
+}
+
+func TestFilesHandler_ReadOnlyAndPermissions(t *testing.T) {
+	ws := t.TempDir()
+	os.WriteFile(filepath.Join(ws, "report.md"), []byte("# done"), 0o644)
+
+	w := httptest.NewRecorder()
+	filesServer(NewFilesHandler(ws, true)).mux.ServeHTTP(w, uploadRequest(t, map[string]string{"a.txt": "x"}, nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("read-only upload: status = %d, want 403", w.Code)
+	}
+
+	perms := &tool.Permissions{
+		Roles:       map[string][]string{"viewer": {"file_read"}, "admin": {"*"}},
+		Users:       map[string]string{"alice": "admin"},
+		DefaultRole: "viewer",
+	}
+	userOf := func(r *http.Request) string { return r.Header.Get("X-User") }
+	s := filesServer(NewFilesHandler(ws, false).WithPermissions(perms, userOf))
+
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, uploadRequest(t, map[string]string{"a.txt": "x"}, nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("viewer upload: status = %d, want 403", w.Code)
+	}
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/files/download?path=report.md", nil))
+	if body, _ := io.ReadAll(w.Body); w.Code != http.StatusOK || string(body) != "# done" {
+		t.Errorf("viewer download: status = %d, body = %q", w.Code, body)
+	}
+	req := uploadRequest(t, map[string]string{"a.txt": "x"}, nil)
+	req.Header.Set("X-User", "alice")
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("admin upload: status = %d, want 200", w.Code)
+	}
+}
+
+func TestFilesHandler_ListAndPreview(t *testing.T) {
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "docs"), 0o755)
+	os.WriteFile(filepath.Join(ws, "b.txt"), []byte("hello"), 0o644)
+	os.WriteFile(filepath.Join(ws, "A.md"), []byte("# A"), 0o644)
+	os.WriteFile(filepath.Join(ws, "docs", "big.txt"), []byte(strings.Repeat("é", maxPreviewBytes)), 0o644)
+	os.WriteFile(filepath.Join(ws, "img.bin"), []byte{0x89, 'P', 'N', 'G', 0, 1}, 0o644)
+	s := filesServer(NewFilesHandler(ws, false))
+
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/files", nil))
+	var list listResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("list: status = %d, err = %v", w.Code, err)
+	}
+	var names []string
+	for _, e := range list.Entries {
+		names = append(names, e.Path)
+	}
+	if got := strings.Join(names, ","); list.Path != "" || got != "docs,A.md,b.txt,img.bin" || !list.Entries[0].Dir || list.Entries[2].Size != 5 {
+		t.Errorf("list = %q %+v", got, list)
+	}
+
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/files?path=docs", nil))
+	if json.NewDecoder(w.Body).Decode(&list); list.Path != "docs" || len(list.Entries) != 1 || list.Entries[0].Path != "docs/big.txt" {
+		t.Errorf("list docs = %+v", list)
+	}
+	for target, want := range map[string]int{
+		"/api/files?path=b.txt":             http.StatusBadRequest,
+		"/api/files?path=nope":              http.StatusNotFound,
+		"/api/files?path=..":                http.StatusForbidden,
+		"/api/files/preview?path=docs":      http.StatusBadRequest,
+		"/api/files/preview?path=../secret": http.StatusForbidden,
+	} {
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != want {
+			t.Errorf("GET %s: status = %d, want %d", target, w.Code, want)
+		}
+	}
+
+	preview := func(p string) previewResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/files/preview?path="+p, nil))
+		var resp previewResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("preview %s: status = %d, err = %v", p, w.Code, err)
+		}
+		return resp
+	}
+	if p := preview("b.txt"); p.Content != "hello" || p.Truncated || p.Binary {
+		t.Errorf("preview b.txt = %+v", p)
+	}
+	if p := preview("docs/big.txt"); !p.Truncated || p.Binary || len(p.Content) != maxPreviewBytes {
+		t.Errorf("preview big.txt: truncated=%v binary=%v len=%d", p.Truncated, p.Binary, len(p.Content))
+	}
+	if p := preview("img.bin"); !p.Binary || p.Content != "" || p.Size != 6 {
+		t.Errorf("preview img.bin = %+v", p)
+	}
+}
+
+func TestFilesHandler_RenameAndDelete(t *testing.T) {
+	ws := t.TempDir()
+	os.WriteFile(filepath.Join(ws, "a.txt"), []byte("a"), 0o644)
+	os.WriteFile(filepath.Join(ws, "b.txt"), []byte("b"), 0o644)
+	os.WriteFile(filepath.Join(ws, "mcp.json"), []byte("{}"), 0o644)
+	os.MkdirAll(filepath.Join(ws, "full"), 0o755)
+	os.WriteFile(filepath.Join(ws, "full", "x"), []byte("x"), 0o644)
+	s := filesServer(NewFilesHandler(ws, false))
+	post := func(target, form string) int {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post("/api/files/rename", "path=a.txt&to=notes/a2.txt"); code != http.StatusNoContent {
+		t.Fatalf("rename: status = %d", code)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "notes", "a2.txt")); string(data) != "a" {
+		t.Errorf("renamed file content = %q", data)
+	}
+	for form, want := range map[string]int{
+		"path=b.txt&to=notes/a2.txt": http.StatusConflict,
+		"path=b.txt&to=../b.txt":     http.StatusForbidden,
+		"path=b.txt&to=mcp.json":     http.StatusForbidden,
+		"path=mcp.json&to=m.json":    http.StatusForbidden,
+		"path=.&to=x":                http.StatusForbidden,
+		"path=gone.txt&to=x":         http.StatusNotFound,
+		"path=b.txt":                 http.StatusBadRequest,
+	} {
+		if code := post("/api/files/rename", form); code != want {
+			t.Errorf("rename %s: status = %d, want %d", form, code, want)
+		}
+	}
+
+	if code := post("/api/files/delete", "path=b.txt"); code != http.StatusNoContent {
+		t.Errorf("delete: status = %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "b.txt")); err == nil {
+		t.Error("b.txt should be deleted")
+	}
+	for form, want := range map[string]int{
+		"path=mcp.json": http.StatusForbidden,
+		"path=full":     http.StatusConflict,
+		"path=":         http.StatusBadRequest,
+		"path=../x":     http.StatusForbidden,
+	} {
+		if code := post("/api/files/delete", form); code != want {
+			t.Errorf("delete %s: status = %d, want %d", form, code, want)
+		}
+	}
+
+	ro := filesServer(NewFilesHandler(ws, true))
+	req := httptest.NewRequest(http.MethodPost, "/api/files/delete", strings.NewReader("path=notes/a2.txt"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	ro.mux.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("read-only delete: status = %d, want 403", w.Code)
+	}
+}
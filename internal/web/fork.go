@@ -0,0 +1,95 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ForkResult describes a forked session; it is the response of
+// POST /api/sessions/{id}/fork.
+type ForkResult struct {
+	SessionID   string `json:"session_id"`
+	URL         string `json:"url"` // opens the chat page on the fork
+	Turns       int    `json:"turns"`
+	PlanSteps   int    `json:"plan_steps"` // steps of the checkpointed plan, resumable with /resume
+	Walkthrough int    `json:"walkthrough_entries"`
+}
+
+// newSessionID returns a random UUIDv4, the format the chat page uses for
+// its own session IDs.
+func newSessionID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// ForkSession branches a conversation: the session's turns and summary,
+// its run checkpoint (plan and step history) and the memo of a run in
+// progress are copied to a new session ID, so the user can try another
+// approach without touching the original thread. Returns false when the
+// session has nothing to copy.
+func (h *AgentHandler) ForkSession(src string) (ForkResult, bool) {
+	res := ForkResult{SessionID: newSessionID()}
+	found := false
+	if h.sessionStore != nil {
+		res.Turns, found = h.sessionStore.Fork(src, res.SessionID)
+	}
+	if h.checkpoints != nil {
+		cp, err := h.checkpoints.Load(src)
+		if err != nil {
+			log.Printf("[Agent] Fork %s: load checkpoint: %v", src, err)
+		}
+		if cp != nil {
+			// A run still in progress is forked as a snapshot: the copy is
+			// not running, so it is resumable rather than flagged crashed.
+			cp.SessionID, cp.Running = res.SessionID, false
+			if err := h.checkpoints.Save(cp); err != nil {
+				log.Printf("[Agent] Fork %s: save checkpoint: %v", src, err)
+			} else {
+				res.PlanSteps = len(cp.Plan)
+				found = true
+			}
+		}
+	}
+	if h.walkthroughStore != nil {
+		if res.Walkthrough = h.walkthroughStore.Copy(src, res.SessionID); res.Walkthrough > 0 {
+			found = true
+		}
+	}
+	if !found {
+		return ForkResult{}, false
+	}
+	res.URL = "/?session=" + url.QueryEscape(res.SessionID)
+	log.Printf("[Agent] Session %s forked into %s (%d turns, %d plan steps)", src, res.SessionID, res.Turns, res.PlanSteps)
+	return res, true
+}
+
+// HandleFork forks a session (POST /api/sessions/{id}/fork, see
+// ForkSession). Returns 201 with the new session, or 404 when the session
+// has nothing to copy.
+func (h *AgentHandler) HandleFork(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	src := strings.TrimSpace(r.PathValue("id"))
+	if src == "" {
+		http.Error(w, "session id is required", http.StatusBadRequest)
+		return
+	}
+	res, ok := h.ForkSession(src)
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
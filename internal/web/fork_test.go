@@ -0,0 +1,79 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/plan"
+	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/walkthrough"
+)
+
+func postFork(h *AgentHandler, id string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/sessions/{id}/fork", h.HandleFork)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/sessions/"+id+"/fork", nil))
+	return w
+}
+
+func TestHandleFork(t *testing.T) {
+	store := session.NewStore(time.Minute, 10)
+	defer store.Close()
+	checkpoints, err := agent.NewCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	memos := walkthrough.NewStore()
+	h := NewAgentHandler(AgentHandlerOptions{Store: store, Checkpoints: checkpoints, WalkthroughStore: memos})
+
+	if w := postFork(h, "s1"); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown session: status = %d, want 404", w.Code)
+	}
+
+	store.AppendTurn("s1", session.Turn{UserMsg: "用方案 A 重构", Assistant: "已完成一半", IsAgent: true})
+	checkpoints.Save(&agent.Checkpoint{
+		SessionID: "s1",
+		Problem:   "用方案 A 重构",
+		Plan:      []plan.PlanStep{{ID: "a", Title: "A", Status: "done"}, {ID: "b", Title: "B", Status: "pending"}},
+		Running:   true,
+	})
+	memos.Append("s1", walkthrough.Entry{Source: walkthrough.SourceManual, Content: "入口在 main.go"})
+
+	w := postFork(h, "s1")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", w.Code, w.Body)
+	}
+	var res ForkResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+	if res.SessionID == "" || res.SessionID == "s1" || res.Turns != 1 || res.PlanSteps != 2 || res.Walkthrough != 1 {
+		t.Fatalf("fork = %+v", res)
+	}
+	if res.URL != "/?session="+res.SessionID {
+		t.Errorf("url = %q", res.URL)
+	}
+
+	if turns, _ := store.GetSessionContext(res.SessionID); len(turns) != 1 {
+		t.Errorf("fork has %d turns, want 1", len(turns))
+	}
+	cp, _ := checkpoints.Load(res.SessionID)
+	if cp == nil || cp.Running || !cp.Unfinished() {
+		t.Errorf("fork checkpoint = %+v, want a resumable snapshot", cp)
+	}
+	if orig, _ := checkpoints.Load("s1"); orig == nil || !orig.Running {
+		t.Errorf("original checkpoint changed: %+v", orig)
+	}
+	if got := memos.Get(res.SessionID); len(got) != 1 {
+		t.Errorf("fork walkthrough = %v", got)
+	}
+
+	if w := postFork(h, "s1"); w.Code != http.StatusCreated {
+		t.Errorf("second fork: status = %d", w.Code)
+	}
+}
@@ -0,0 +1,272 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	omegav1 "github.com/pocketomega/pocket-omega/api/omega/v1"
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
+	"github.com/pocketomega/pocket-omega/internal/plan"
+)
+
+// GRPCService implements the gRPC management API (api/omega/v1) on top of
+// AgentHandler. RunTask shares runAgent with POST /api/agent, so both
+// transports get the same run limits, sessions, plans, logs and replays.
+type GRPCService struct {
+	omegav1.UnimplementedOmegaServiceServer
+	agent *AgentHandler
+}
+
+// NewGRPCService creates the gRPC service for agent.
+func NewGRPCService(agent *AgentHandler) *GRPCService {
+	return &GRPCService{agent: agent}
+}
+
+// NewGRPCServer returns a gRPC server with the OmegaService and the
+// standard health service (grpc.health.v1) registered.
+func NewGRPCServer(agent *AgentHandler) *grpc.Server {
+	srv, _ := newGRPCServer(agent)
+	return srv
+}
+
+// newGRPCServer is NewGRPCServer that also returns the health service, so
+// that shutdown can report NOT_SERVING while runs drain. Health checks
+// answer for the whole server ("") and for omega.v1.OmegaService.
+func newGRPCServer(agent *AgentHandler) (*grpc.Server, *health.Server) {
+	srv := grpc.NewServer()
+	omegav1.RegisterOmegaServiceServer(srv, NewGRPCService(agent))
+	hs := health.NewServer()
+	hs.SetServingStatus(omegav1.OmegaService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	return srv, hs
+}
+
+// RunTask runs one agent task and streams its events.
+func (s *GRPCService) RunTask(req *omegav1.RunTaskRequest, stream omegav1.OmegaService_RunTaskServer) error {
+	ctx := stream.Context()
+	r := agentRequest{
+		Message:     req.GetMessage(),
+		SessionID:   req.GetSessionId(),
+		AnswerStyle: req.GetAnswerStyle(),
+		Resume:      req.GetResume(),
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.ClientAddr = p.Addr.String()
+	}
+	if err := s.agent.checkRequest(&r); err != nil {
+		if errors.Is(err, errNothingToResume) {
+			return status.Error(codes.NotFound, err.Error())
+		}
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Printf("[gRPC] RunTask: %s", r.Message)
+
+	ticket, err := s.agent.runs.enter(r.SessionID)
+	if err != nil {
+		log.Printf("[gRPC] Rejected: %v", err)
+		return status.Error(codes.ResourceExhausted, i18n.T(s.agent.uiLocale, "agent.busy"))
+	}
+	defer ticket.release()
+
+	sink := &grpcEventSink{stream: stream}
+	s.agent.runAgent(ctx, ticket, r, sink)
+	if sink.err != nil {
+		return sink.err
+	}
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	return nil
+}
+
+// CancelRun aborts a running task.
+func (s *GRPCService) CancelRun(ctx context.Context, req *omegav1.CancelRunRequest) (*omegav1.CancelRunResponse, error) {
+	if !s.agent.cancelRun(req.GetRunId()) {
+		return nil, status.Error(codes.NotFound, "no agent run in progress with this ID")
+	}
+	return &omegav1.CancelRunResponse{}, nil
+}
+
+// ListSessions lists the sessions held in memory, most recently used first.
+func (s *GRPCService) ListSessions(ctx context.Context, req *omegav1.ListSessionsRequest) (*omegav1.ListSessionsResponse, error) {
+	if s.agent.sessionStore == nil {
+		return nil, errNoSessions
+	}
+	resp := &omegav1.ListSessionsResponse{}
+	for _, info := range s.agent.sessionStore.List() {
+		resp.Sessions = append(resp.Sessions, &omegav1.SessionInfo{
+			Id:             info.ID,
+			Turns:          int32(info.Turns),
+			HasSummary:     info.HasSummary,
+			LastUsedUnixMs: info.LastUsed.UnixMilli(),
+		})
+	}
+	return resp, nil
+}
+
+// GetSession returns the turns and summary of one session.
+func (s *GRPCService) GetSession(ctx context.Context, req *omegav1.GetSessionRequest) (*omegav1.Session, error) {
+	if s.agent.sessionStore == nil {
+		return nil, errNoSessions
+	}
+	turns, summary := s.agent.sessionStore.GetSessionContext(req.GetId())
+	if turns == nil && summary == "" {
+		return nil, status.Error(codes.NotFound, "no session with this ID")
+	}
+	resp := &omegav1.Session{Id: req.GetId(), Summary: summary}
+	for _, t := range turns {
+		resp.Turns = append(resp.Turns, &omegav1.Turn{UserMessage: t.UserMsg, Assistant: t.Assistant, IsAgent: t.IsAgent})
+	}
+	return resp, nil
+}
+
+// DeleteSession forgets a session's history, like /clear in the web UI.
+func (s *GRPCService) DeleteSession(ctx context.Context, req *omegav1.DeleteSessionRequest) (*omegav1.DeleteSessionResponse, error) {
+	if s.agent.sessionStore == nil {
+		return nil, errNoSessions
+	}
+	s.agent.sessionStore.Delete(req.GetId())
+	log.Printf("[gRPC] Session %s deleted", req.GetId())
+	return &omegav1.DeleteSessionResponse{}, nil
+}
+
+// ListTools lists the tools available to agent runs.
+func (s *GRPCService) ListTools(ctx context.Context, req *omegav1.ListToolsRequest) (*omegav1.ListToolsResponse, error) {
+	resp := &omegav1.ListToolsResponse{}
+	for _, t := range s.agent.toolRegistry.List() {
+		resp.Tools = append(resp.Tools, &omegav1.Tool{
+			Name:        t.Name(),
+			Description: t.Description(),
+			InputSchema: string(t.InputSchema()),
+		})
+	}
+	return resp, nil
+}
+
+// GetWorkspace describes the workspace and agent configuration.
+func (s *GRPCService) GetWorkspace(ctx context.Context, req *omegav1.GetWorkspaceRequest) (*omegav1.Workspace, error) {
+	return &omegav1.Workspace{
+		Dir:          s.agent.workspaceDir,
+		ReadOnly:     s.agent.toolRegistry.ReadOnly(),
+		Model:        s.agent.modelName,
+		AnswerStyles: s.agent.AnswerStyles(),
+	}, nil
+}
+
+var errNoSessions = status.Error(codes.FailedPrecondition, "sessions are not enabled")
+
+// grpcEventSink converts the agent run events to RunEvent messages.
+type grpcEventSink struct {
+	mu     sync.Mutex
+	stream omegav1.OmegaService_RunTaskServer
+	err    error // first send error; later events are dropped
+}
+
+func (g *grpcEventSink) Send(event string, data interface{}) bool {
+	ev := toRunEvent(event, data)
+	if ev == nil {
+		return true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.err != nil {
+		return false
+	}
+	if err := g.stream.Send(ev); err != nil {
+		log.Printf("[gRPC] Send error (client disconnected?): %v", err)
+		g.err = err
+		return false
+	}
+	return true
+}
+
+// toRunEvent maps an SSE event of runAgent to its RunEvent; nil for events
+// the API does not carry.
+func toRunEvent(event string, data interface{}) *omegav1.RunEvent {
+	switch d := data.(type) {
+	case sseQueueEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_Queued{Queued: &omegav1.Queued{Position: int32(d.Position), Message: d.Message}}}
+	case sseRunEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_RunStarted{RunStarted: &omegav1.RunStarted{RunId: d.RunID}}}
+	case agent.StepRecord:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_Step{Step: toStep(d)}}
+	case ssePlanEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_Plan{Plan: toPlan(d.Steps)}}
+	case sseNoticeEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_Notice{Notice: &omegav1.Notice{Kind: d.Kind, Message: d.Message}}}
+	case *sseEditorLinkEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_EditorLink{EditorLink: &omegav1.EditorLink{
+			StepNumber: int32(d.StepNumber), Path: d.Path, Line: int32(d.Line), Url: d.URL,
+		}}}
+	case sseFileDiffEvent:
+		return nil // the diff is not part of the v1 API; Step.output describes the edit
+	case sseUsageEvent:
+		return nil // Done.stats carries the final token count
+	case sseErrorEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_Notice{Notice: &omegav1.Notice{Kind: "error_" + d.Code, Message: d.Message}}}
+	case sseDoneEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_Done{Done: &omegav1.Done{Solution: d.Solution, Stats: toRunStats(d.Stats)}}}
+	case sseStatusEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_Status{Status: &omegav1.Status{Message: d.Message}}}
+	case sseChunkEvent:
+		return &omegav1.RunEvent{Event: &omegav1.RunEvent_Chunk{Chunk: &omegav1.Chunk{Text: d.Text}}}
+	}
+	log.Printf("[gRPC] Dropped unmapped %q event (%T)", event, data)
+	return nil
+}
+
+func toStep(s agent.StepRecord) *omegav1.Step {
+	return &omegav1.Step{
+		StepNumber: int32(s.StepNumber),
+		Type:       s.Type,
+		Action:     s.Action,
+		ToolName:   s.ToolName,
+		Input:      s.Input,
+		Output:     s.Output,
+		ToolCallId: s.ToolCallID,
+		IsError:    s.IsError,
+		DurationMs: s.DurationMs,
+		OutputRef:  s.OutputRef,
+	}
+}
+
+func toPlan(steps []plan.PlanStep) *omegav1.Plan {
+	p := &omegav1.Plan{}
+	for _, s := range steps {
+		p.Steps = append(p.Steps, &omegav1.PlanStep{Id: s.ID, Title: s.Title, Status: s.Status, Detail: s.Detail})
+	}
+	return p
+}
+
+func toRunStats(s *agentStats) *omegav1.RunStats {
+	if s == nil {
+		return nil
+	}
+	stats := &omegav1.RunStats{
+		Steps:      int32(s.Steps),
+		ToolCalls:  int32(s.ToolCalls),
+		ElapsedMs:  s.ElapsedMs,
+		TokensUsed: s.TokensUsed,
+		Outcome:    string(s.Outcome),
+	}
+	if d := s.Downshift; d != nil {
+		stats.Downshift = &omegav1.Downshift{
+			FromModel:  d.FromModel,
+			ToModel:    d.ToModel,
+			Step:       int32(d.Step),
+			UsedTokens: d.UsedTokens,
+			MaxTokens:  d.MaxTokens,
+		}
+	}
+	return stats
+}
@@ -0,0 +1,165 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	omegav1 "github.com/pocketomega/pocket-omega/api/omega/v1"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
+	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+)
+
+// grpcClient serves h over an in-memory connection and returns a client.
+func grpcClient(t *testing.T, h *AgentHandler) omegav1.OmegaServiceClient {
+	t.Helper()
+	return omegav1.NewOmegaServiceClient(grpcConn(t, NewGRPCServer(h)))
+}
+
+// grpcConn serves srv over an in-memory connection and dials it.
+func grpcConn(t *testing.T, srv *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPC_RunTaskStreamsAndCancels(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{
+		Provider:     blockingLLMProvider{},
+		Registry:     tool.NewRegistry(),
+		WorkspaceDir: t.TempDir(),
+		ThinkingMode: "native",
+		ToolCallMode: "yaml",
+	})
+	client := grpcClient(t, h)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Server-streaming errors surface on the first Recv
+	stream, err := client.RunTask(ctx, &omegav1.RunTaskRequest{Message: "  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("empty message: err = %v, want InvalidArgument", err)
+	}
+
+	stream, err = client.RunTask(ctx, &omegav1.RunTaskRequest{Message: "loop forever"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var done *omegav1.Done
+	for {
+		ev, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		switch e := ev.Event.(type) {
+		case *omegav1.RunEvent_RunStarted:
+			if _, err := client.CancelRun(ctx, &omegav1.CancelRunRequest{RunId: e.RunStarted.RunId}); err != nil {
+				t.Fatalf("CancelRun: %v", err)
+			}
+		case *omegav1.RunEvent_Done:
+			done = e.Done
+		}
+	}
+	if done == nil {
+		t.Fatal("stream ended without a done event")
+	}
+	if done.Solution != i18n.T(i18n.DefaultLocale, "agent.cancelled") || done.Stats.GetOutcome() != "failure" {
+		t.Errorf("done = %v", done)
+	}
+
+	if _, err := client.CancelRun(ctx, &omegav1.CancelRunRequest{RunId: "r123"}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown run: err = %v, want NotFound", err)
+	}
+}
+
+func TestGRPC_SessionsAndTools(t *testing.T) {
+	store := session.NewStore(time.Minute, 10)
+	defer store.Close()
+	store.AppendTurn("s1", session.Turn{UserMsg: "hi", Assistant: "hello", IsAgent: true})
+	registry := tool.NewRegistry()
+	registry.Register(builtin.NewFileReadTool("/ws"))
+	registry.SetReadOnly(true)
+	h := NewAgentHandler(AgentHandlerOptions{
+		Registry:     registry,
+		Store:        store,
+		WorkspaceDir: "/ws",
+		ModelName:    "test-model",
+	})
+	client := grpcClient(t, h)
+	ctx := context.Background()
+
+	list, err := client.ListSessions(ctx, &omegav1.ListSessionsRequest{})
+	if err != nil || len(list.Sessions) != 1 || list.Sessions[0].Id != "s1" || list.Sessions[0].Turns != 1 {
+		t.Fatalf("ListSessions = %v, %v", list, err)
+	}
+	sess, err := client.GetSession(ctx, &omegav1.GetSessionRequest{Id: "s1"})
+	if err != nil || len(sess.Turns) != 1 || sess.Turns[0].Assistant != "hello" || !sess.Turns[0].IsAgent {
+		t.Fatalf("GetSession = %v, %v", sess, err)
+	}
+	if _, err := client.DeleteSession(ctx, &omegav1.DeleteSessionRequest{Id: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetSession(ctx, &omegav1.GetSessionRequest{Id: "s1"}); status.Code(err) != codes.NotFound {
+		t.Errorf("deleted session: err = %v, want NotFound", err)
+	}
+
+	tools, err := client.ListTools(ctx, &omegav1.ListToolsRequest{})
+	if err != nil || len(tools.Tools) != 1 || tools.Tools[0].Name != "file_read" || !strings.Contains(tools.Tools[0].InputSchema, `"path"`) {
+		t.Errorf("ListTools = %v, %v", tools, err)
+	}
+	ws, err := client.GetWorkspace(ctx, &omegav1.GetWorkspaceRequest{})
+	if err != nil || ws.Dir != "/ws" || !ws.ReadOnly || ws.Model != "test-model" {
+		t.Errorf("GetWorkspace = %v, %v", ws, err)
+	}
+}
+
+func TestGRPC_Health(t *testing.T) {
+	srv, hs := newGRPCServer(NewAgentHandler(AgentHandlerOptions{Registry: tool.NewRegistry()}))
+	client := healthpb.NewHealthClient(grpcConn(t, srv))
+	ctx := context.Background()
+
+	for _, service := range []string{"", "omega.v1.OmegaService"} {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Check(%q) = %v, %v; want SERVING", service, resp, err)
+		}
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "other.Service"}); status.Code(err) != codes.NotFound {
+		t.Errorf("unknown service: err = %v, want NotFound", err)
+	}
+
+	// Shutting down: probes see NOT_SERVING while runs drain
+	hs.Shutdown()
+	if resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("after shutdown: %v, %v; want NOT_SERVING", resp, err)
+	}
+}
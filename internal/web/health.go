@@ -4,14 +4,24 @@ import (
 	"encoding/json"
 	"net/http"
 	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
 )
 
 // HealthInfo holds runtime status for the health endpoint.
 type HealthInfo struct {
-	LLMModel       string     // from config
-	ToolCount      int        // registry.List() length
-	MCPServerCount int        // from MCP manager
-	SessionCount   func() int // callback to session store
+	LLMModel       string                            // from config
+	ToolCount      int                               // registry.List() length
+	ToolHealth     func() map[string]tool.ToolHealth // optional; Init state of each tool
+	MCPServerCount int                               // from MCP manager
+	SessionCount   func() int                        // callback to session store
+	LLMScheduler   *llm.Scheduler                    // optional; nil when LLM_MAX_CONCURRENCY is unset
+	LLMCache       *llm.ResponseCache                // optional; nil unless LLM_CACHE=true
+	AgentRuns      func() AgentRunStats              // optional; agent run queue snapshot
+	CodeIndex      *builtin.CodeIndex                // optional; nil unless CODE_SEARCH_ENABLED
+	ReadOnly       bool                              // read-only mirror mode; also shows the UI banner
 }
 
 // HealthHandler serves GET /api/health.
@@ -27,23 +37,30 @@ func NewHealthHandler(info HealthInfo) *HealthHandler {
 
 type healthResponse struct {
 	Status     string           `json:"status"`
+	ReadOnly   bool             `json:"read_only,omitempty"`
 	UptimeSecs int64            `json:"uptime_seconds"`
 	Components healthComponents `json:"components"`
 }
 
 type healthComponents struct {
-	LLM      healthLLM      `json:"llm"`
-	Tools    healthTools    `json:"tools"`
-	MCP      healthMCP      `json:"mcp"`
-	Sessions healthSessions `json:"sessions"`
+	LLM       healthLLM                  `json:"llm"`
+	Tools     healthTools                `json:"tools"`
+	MCP       healthMCP                  `json:"mcp"`
+	Sessions  healthSessions             `json:"sessions"`
+	Agent     *AgentRunStats             `json:"agent,omitempty"`
+	CodeIndex *builtin.CodeIndexProgress `json:"code_index,omitempty"`
 }
 
 type healthLLM struct {
-	Status string `json:"status"`
-	Model  string `json:"model"`
+	Status    string              `json:"status"`
+	Model     string              `json:"model"`
+	Scheduler *llm.SchedulerStats `json:"scheduler,omitempty"`
+	Cache     *llm.CacheStats     `json:"cache,omitempty"`
 }
 type healthTools struct {
-	Registered int `json:"registered"`
+	Status     string                     `json:"status"` // degraded when a tool failed or degraded
+	Registered int                        `json:"registered"`
+	Health     map[string]tool.ToolHealth `json:"health,omitempty"`
 }
 type healthMCP struct {
 	Servers int `json:"servers"`
@@ -69,19 +86,56 @@ func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		sessionCount = h.info.SessionCount()
 	}
 
+	tools := healthTools{Status: "ok", Registered: h.info.ToolCount}
+	if h.info.ToolHealth != nil {
+		tools.Health = h.info.ToolHealth()
+		for _, th := range tools.Health {
+			if th.Status == tool.HealthFailed || th.Status == tool.HealthDegraded {
+				tools.Status = "degraded"
+			}
+		}
+	}
+
 	status := "ok"
-	if llmStatus == "degraded" {
+	if llmStatus == "degraded" || tools.Status == "degraded" {
 		status = "degraded"
 	}
 
+	var schedStats *llm.SchedulerStats
+	if h.info.LLMScheduler != nil {
+		st := h.info.LLMScheduler.Stats()
+		schedStats = &st
+	}
+
+	var cacheStats *llm.CacheStats
+	if h.info.LLMCache != nil {
+		st := h.info.LLMCache.Stats()
+		cacheStats = &st
+	}
+
+	var agentRuns *AgentRunStats
+	if h.info.AgentRuns != nil {
+		st := h.info.AgentRuns()
+		agentRuns = &st
+	}
+
+	var codeIndex *builtin.CodeIndexProgress
+	if h.info.CodeIndex != nil {
+		p := h.info.CodeIndex.Progress()
+		codeIndex = &p
+	}
+
 	resp := healthResponse{
 		Status:     status,
+		ReadOnly:   h.info.ReadOnly,
 		UptimeSecs: int64(time.Since(h.startTime).Seconds()),
 		Components: healthComponents{
-			LLM:      healthLLM{Status: llmStatus, Model: h.info.LLMModel},
-			Tools:    healthTools{Registered: h.info.ToolCount},
-			MCP:      healthMCP{Servers: h.info.MCPServerCount},
-			Sessions: healthSessions{Active: sessionCount},
+			LLM:       healthLLM{Status: llmStatus, Model: h.info.LLMModel, Scheduler: schedStats, Cache: cacheStats},
+			Tools:     tools,
+			MCP:       healthMCP{Servers: h.info.MCPServerCount},
+			Sessions:  healthSessions{Active: sessionCount},
+			Agent:     agentRuns,
+			CodeIndex: codeIndex,
 		},
 	}
 
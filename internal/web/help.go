@@ -0,0 +1,447 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+// helpPlaybookDir is the workspace directory /help lists playbooks from:
+// markdown task files for `omega run` and `omega batch -file`.
+const helpPlaybookDir = "playbooks"
+
+// helpCommand documents one slash command for /help.
+type helpCommand struct {
+	usage string
+	desc  string
+}
+
+// helpCommands lists the slash commands in display order.
+// ⚠️ Update this list when adding a command to NewCommandHandler.
+var helpCommands = []helpCommand{
+	{"/help [主题|工具名|关键词]", "显示帮助；主题：commands、tools、modes、limits、playbooks"},
+	{"/reload", "重载提示词、MCP 配置以及 .env / omega.yaml 中的设置（也可向进程发送 SIGHUP）"},
+	{"/clear", "清空当前对话"},
+	{"/compact [N]", "压缩历史对话为摘要（保留最近 N 轮，默认 2）"},
+	{"/stats", "显示当前会话状态和系统信息"},
+	{"/replay [N]", "列出最近的运行记录，或回放第 N 条"},
+	{"/resume", "从中断处继续上次未完成的计划"},
+	{"/fork", "复制当前对话为新会话，在新标签页尝试另一种方案"},
+	{"/config", "显示生效的配置及其来源（配置文件或环境变量）"},
+	{"/journal [日期|yesterday|list|find 关键词]", "查看工作日志"},
+	{"/walkthrough [N]", "列出过去运行的备忘录，或查看第 N 条"},
+	{"/template [名称 变量=值…]", "列出 prompts/templates/ 下的任务模板，或填入变量后发送给 Agent"},
+}
+
+// helpToolExamples are example requests that make the agent use a tool,
+// phrased the way a user would ask.
+var helpToolExamples = map[string]string{
+	"file_read":      "读一下 README.md，总结这个项目是做什么的",
+	"file_write":     "新建 notes/todo.md，写入今天的三件待办",
+	"file_patch":     "把 config.yaml 里的端口改成 8080",
+	"file_list":      "看看 src 目录下有哪些文件",
+	"file_grep":      "在代码里找出所有调用 NewServer 的地方",
+	"find":           "找出所有名字里带 test 的 Go 文件",
+	"file_move":      "把 draft.md 移到 docs/ 下",
+	"archive_tool":   "把 release.zip 解压到 vendor/ 下",
+	"file_delete":    "删掉 tmp/ 里的 old.log",
+	"file_open":      "在编辑器里打开 main.go 的第 42 行",
+	"shell_exec":     "运行 go test ./...，告诉我哪些测试失败",
+	"python_exec":    "用 Python 算一下 2 的 100 次方",
+	"git_info":       "最近 5 次提交都改了什么？",
+	"git_ops":        "把当前修改提交，说明写“修复登录”",
+	"web_search":     "搜索 Go 1.24 有哪些新特性（最近一个月）",
+	"brave_search":   "搜索 Go 1.24 有哪些新特性（最近一个月）",
+	"web_reader":     "打开 https://go.dev/blog 并总结最新一篇文章",
+	"http_request":   "请求 https://api.github.com/repos/golang/go，只要 stargazers_count",
+	"get_time":       "现在几点？下周一是几号？",
+	"image_read":     "看看 screenshot.png 里报了什么错",
+	"todo_scan":      "列出代码里所有的 TODO 和 FIXME",
+	"project_map":    "这个仓库的整体结构是怎样的？主要用什么语言？",
+	"code_search":    "重试逻辑是在哪里实现的？",
+	"csv_query":      "sales.csv 里按城市汇总销售额，取前 10",
+	"doc_read":       "总结 contract.pdf 第 3 到 5 页的内容",
+	"journal_append": "把“完成了登录重构”记到今天的日志里",
+	"watch_add":      "README.md 一有变化就提醒我",
+	"config_edit":    "把 .env 里的 AGENT_MAX_STEPS 改成 100",
+	"env_set":        "把 MCP_GITHUB_TOKEN 设为 ghp_xxx",
+	"mcp_server_add": "帮我接入一个查询天气的 MCP 服务",
+	"skill_install":  "安装 https://github.com/someone/weather-skill 这个 skill",
+}
+
+func (h *CommandHandler) cmdHelp(ctx context.Context, args, sessionID string) commandResult {
+	topic := strings.TrimSpace(args)
+	var sb strings.Builder
+	switch strings.ToLower(topic) {
+	case "":
+		sb.WriteString("👋 我是 Pocket-Omega：可以读写工作区文件、运行命令、搜索和阅读网页，并按计划一步步完成任务。直接用自然语言描述你要做的事即可。\n\n")
+		h.writeHelpCommands(&sb)
+		h.writeHelpModes(&sb)
+		h.writeHelpLimits(&sb)
+		h.writeHelpToolSummary(&sb)
+		h.writeHelpPlaybooks(&sb)
+		sb.WriteString("输入 /help tools 查看工具和示例提问，/help <工具名> 查看参数，/help <关键词> 搜索。")
+	case "commands", "命令":
+		h.writeHelpCommands(&sb)
+	case "tools", "工具":
+		h.writeHelpTools(&sb)
+	case "modes", "模式":
+		h.writeHelpModes(&sb)
+	case "limits", "限制":
+		h.writeHelpLimits(&sb)
+	case "playbooks", "playbook":
+		if !h.writeHelpPlaybooks(&sb) {
+			sb.WriteString(fmt.Sprintf("📒 Playbook：工作区的 %s/ 下还没有 .md 文件。把常做的任务写成 markdown 放进去，即可用 omega run / omega batch -file 反复执行。", helpPlaybookDir))
+		}
+	default:
+		if t := h.helpTool(topic); t != nil {
+			writeHelpToolDetail(&sb, t)
+		} else {
+			h.writeHelpSearch(&sb, topic)
+		}
+	}
+	return commandResult{OK: true, Message: strings.TrimRight(sb.String(), "\n")}
+}
+
+func (h *CommandHandler) writeHelpCommands(sb *strings.Builder) {
+	sb.WriteString("⌨️ 可用命令:\n")
+	for _, c := range helpCommands {
+		sb.WriteString(fmt.Sprintf("%s — %s\n", c.usage, c.desc))
+	}
+	sb.WriteString("\n")
+}
+
+func (h *CommandHandler) writeHelpModes(sb *strings.Builder) {
+	sb.WriteString("⚙️ 当前模式:\n")
+	h.settingsMu.RLock()
+	modelName := h.modelName
+	h.settingsMu.RUnlock()
+	if modelName != "" {
+		sb.WriteString(fmt.Sprintf("• 模型：%s\n", modelName))
+	}
+	sb.WriteString(fmt.Sprintf("• 思维模式：%s | 工具调用：%s\n", orDash(h.thinkingMode), orDash(h.toolCallMode)))
+	if h.readOnly {
+		sb.WriteString("• 只读模式：修改类工具仅预演不执行\n")
+	}
+	if h.loader != nil {
+		if styles := h.loader.AnswerStyles(); len(styles) > 0 {
+			sb.WriteString(fmt.Sprintf("• 回答风格：%s（在输入框旁选择）\n", strings.Join(styles, "、")))
+		}
+	}
+	var features []string
+	if h.replayDir != "" {
+		features = append(features, "运行回放 /replay")
+	}
+	if h.checkpoints != nil {
+		features = append(features, "断点续跑 /resume")
+	}
+	if h.journal != nil {
+		features = append(features, "工作日志 /journal")
+	}
+	if len(features) > 0 {
+		sb.WriteString(fmt.Sprintf("• 已启用：%s\n", strings.Join(features, "、")))
+	}
+	sb.WriteString("\n")
+}
+
+func (h *CommandHandler) writeHelpLimits(sb *strings.Builder) {
+	sb.WriteString("📏 当前限制:\n")
+	sb.WriteString(fmt.Sprintf("• 每次任务最多 %d 步，超时 %s\n", agent.MaxAgentSteps, formatHelpDuration(agentTimeout)))
+	if agent.StepExtension > 0 {
+		sb.WriteString(fmt.Sprintf("• 计划未完成而步数不够时，可自动延长 %d 步（每次任务一次）\n", agent.StepExtension))
+	}
+	h.settingsMu.RLock()
+	maxTokens, maxDuration := h.maxAgentTokens, h.maxAgentDuration
+	h.settingsMu.RUnlock()
+	if maxTokens > 0 {
+		sb.WriteString(fmt.Sprintf("• Token 预算：每次任务 %d\n", maxTokens))
+	}
+	if maxDuration > 0 {
+		sb.WriteString(fmt.Sprintf("• 时长预算：每次任务 %s\n", formatHelpDuration(maxDuration)))
+	}
+	if h.maxConcurrentRuns > 0 {
+		sb.WriteString(fmt.Sprintf("• 同时运行的任务：最多 %d 个，其余排队\n", h.maxConcurrentRuns))
+	}
+	if h.store != nil {
+		sb.WriteString(fmt.Sprintf("• 会话：保留最近 %d 轮，闲置 %s 后清除\n", h.store.MaxTurns(), formatHelpDuration(h.store.TTL())))
+	}
+	sb.WriteString("\n")
+}
+
+// helpTools returns the registered tools sorted by name, built-in tools
+// first and MCP tools (mcp_ prefix) after them.
+func (h *CommandHandler) helpTools() (builtins, mcp []tool.Tool) {
+	if h.toolRegistry == nil {
+		return nil, nil
+	}
+	tools := h.toolRegistry.List()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name() < tools[j].Name() })
+	for _, t := range tools {
+		if strings.HasPrefix(t.Name(), "mcp_") && !strings.HasPrefix(t.Name(), "mcp_server_") {
+			mcp = append(mcp, t)
+		} else {
+			builtins = append(builtins, t)
+		}
+	}
+	return builtins, mcp
+}
+
+func (h *CommandHandler) writeHelpToolSummary(sb *strings.Builder) {
+	builtins, mcp := h.helpTools()
+	if len(builtins)+len(mcp) == 0 {
+		return
+	}
+	names := make([]string, len(builtins))
+	for i, t := range builtins {
+		names[i] = t.Name()
+	}
+	sb.WriteString(fmt.Sprintf("🧰 可用工具（%d 个）: %s\n", len(builtins), strings.Join(names, ", ")))
+	if len(mcp) > 0 {
+		sb.WriteString(fmt.Sprintf("🔌 MCP 工具: %d 个\n", len(mcp)))
+	}
+	sb.WriteString("\n")
+}
+
+func (h *CommandHandler) writeHelpTools(sb *strings.Builder) {
+	builtins, mcp := h.helpTools()
+	if len(builtins)+len(mcp) == 0 {
+		sb.WriteString("🧰 当前没有可用工具")
+		return
+	}
+	sb.WriteString(fmt.Sprintf("🧰 可用工具（%d 个）:\n", len(builtins)))
+	for _, t := range builtins {
+		writeHelpToolLine(sb, t)
+	}
+	if len(mcp) > 0 {
+		sb.WriteString(fmt.Sprintf("\n🔌 MCP 工具（%d 个）:\n", len(mcp)))
+		for _, t := range mcp {
+			writeHelpToolLine(sb, t)
+		}
+	}
+}
+
+func writeHelpToolLine(sb *strings.Builder, t tool.Tool) {
+	sb.WriteString(fmt.Sprintf("• %s — %s\n", t.Name(), firstSentence(t.Description())))
+	if ex := helpToolExamples[t.Name()]; ex != "" {
+		sb.WriteString(fmt.Sprintf("    例：「%s」\n", ex))
+	}
+}
+
+// helpTool resolves a tool by exact (case-insensitive) name.
+func (h *CommandHandler) helpTool(name string) tool.Tool {
+	if h.toolRegistry == nil {
+		return nil
+	}
+	for _, t := range h.toolRegistry.List() {
+		if strings.EqualFold(t.Name(), name) {
+			return t
+		}
+	}
+	return nil
+}
+
+// helpSchema is the part of a tool input schema /help shows.
+type helpSchema struct {
+	Properties map[string]struct {
+		Type        string `json:"type"`
+		Description string `json:"description"`
+		Enum        []any  `json:"enum"`
+	} `json:"properties"`
+	Required []string `json:"required"`
+}
+
+func writeHelpToolDetail(sb *strings.Builder, t tool.Tool) {
+	sb.WriteString(fmt.Sprintf("🔧 %s\n%s\n", t.Name(), t.Description()))
+	var schema helpSchema
+	json.Unmarshal(t.InputSchema(), &schema)
+	required := make(map[string]bool)
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	// required parameters first, each group by name
+	sort.Slice(names, func(i, j int) bool {
+		if required[names[i]] != required[names[j]] {
+			return required[names[i]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > 0 {
+		sb.WriteString("\n参数:\n")
+	}
+	call := make(map[string]any)
+	for _, name := range names {
+		p := schema.Properties[name]
+		need := "可选"
+		if required[name] {
+			need = "必填"
+			call[name] = helpPlaceholder(name, p.Type)
+		}
+		sb.WriteString(fmt.Sprintf("• %s（%s，%s）— %s\n", name, orDash(p.Type), need, p.Description))
+		if len(p.Enum) > 0 {
+			sb.WriteString(fmt.Sprintf("    可选值：%v\n", p.Enum))
+		}
+	}
+	if ex := helpToolExamples[t.Name()]; ex != "" {
+		sb.WriteString(fmt.Sprintf("\n示例提问：「%s」\n", ex))
+	}
+	var example strings.Builder
+	enc := json.NewEncoder(&example)
+	enc.SetEscapeHTML(false)
+	enc.Encode(call)
+	sb.WriteString(fmt.Sprintf("调用示例：%s %s", t.Name(), example.String()))
+}
+
+// helpPlaceholder is the example value of a required parameter.
+func helpPlaceholder(name, typ string) any {
+	switch typ {
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		return []any{}
+	case "object":
+		return map[string]any{}
+	}
+	return "<" + name + ">"
+}
+
+// helpPlaybook is a markdown task file under playbooks/.
+type helpPlaybook struct {
+	path  string // relative to the workspace, slash-separated
+	title string
+}
+
+func (h *CommandHandler) helpPlaybooks() []helpPlaybook {
+	if h.workspaceDir == "" {
+		return nil
+	}
+	dir := filepath.Join(h.workspaceDir, helpPlaybookDir)
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	sort.Strings(matches)
+	var books []helpPlaybook
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		meta, body := prompt.SplitFrontmatter(string(data))
+		title := meta["description"]
+		if title == "" {
+			for _, line := range strings.Split(body, "\n") {
+				if line = strings.TrimSpace(strings.TrimLeft(line, "# ")); line != "" {
+					title = line
+					break
+				}
+			}
+		}
+		books = append(books, helpPlaybook{
+			path:  helpPlaybookDir + "/" + filepath.Base(path),
+			title: util.TruncateRunes(title, 80),
+		})
+	}
+	return books
+}
+
+// writeHelpPlaybooks lists the workspace playbooks; false when there are none.
+func (h *CommandHandler) writeHelpPlaybooks(sb *strings.Builder) bool {
+	books := h.helpPlaybooks()
+	if len(books) == 0 {
+		return false
+	}
+	sb.WriteString(fmt.Sprintf("📒 Playbook（%d 个，可直接让我“按 %s 执行”）:\n", len(books), books[0].path))
+	for _, b := range books {
+		sb.WriteString(fmt.Sprintf("• %s — %s\n", b.path, orDash(b.title)))
+	}
+	sb.WriteString("\n")
+	return true
+}
+
+// writeHelpSearch lists the commands, tools and playbooks mentioning query.
+func (h *CommandHandler) writeHelpSearch(sb *strings.Builder, query string) {
+	q := strings.ToLower(query)
+	var cmds []string
+	for _, c := range helpCommands {
+		if strings.Contains(strings.ToLower(c.usage+" "+c.desc), q) {
+			cmds = append(cmds, fmt.Sprintf("%s — %s", c.usage, c.desc))
+		}
+	}
+	var tools []tool.Tool
+	builtins, mcp := h.helpTools()
+	for _, t := range append(builtins, mcp...) {
+		text := t.Name() + " " + t.Description() + " " + helpToolExamples[t.Name()]
+		if strings.Contains(strings.ToLower(text), q) {
+			tools = append(tools, t)
+		}
+	}
+	var books []helpPlaybook
+	for _, b := range h.helpPlaybooks() {
+		if strings.Contains(strings.ToLower(b.path+" "+b.title), q) {
+			books = append(books, b)
+		}
+	}
+	found := len(cmds) + len(tools) + len(books)
+	if found == 0 {
+		sb.WriteString(fmt.Sprintf("没有找到与「%s」相关的命令、工具或 playbook。输入 /help 查看全部。", query))
+		return
+	}
+	sb.WriteString(fmt.Sprintf("🔍 与「%s」相关（%d 项）:\n", query, found))
+	if len(cmds) > 0 {
+		sb.WriteString("\n命令:\n")
+		for _, c := range cmds {
+			sb.WriteString(c + "\n")
+		}
+	}
+	if len(tools) > 0 {
+		sb.WriteString("\n工具:\n")
+		for _, t := range tools {
+			writeHelpToolLine(sb, t)
+		}
+	}
+	if len(books) > 0 {
+		sb.WriteString("\nPlaybook:\n")
+		for _, b := range books {
+			sb.WriteString(fmt.Sprintf("• %s — %s\n", b.path, orDash(b.title)))
+		}
+	}
+}
+
+// firstSentence returns the first sentence of a tool description, at most
+// 60 runes.
+func firstSentence(s string) string {
+	if i := strings.IndexAny(s, "。\n"); i >= 0 {
+		s = s[:i]
+	}
+	return util.TruncateRunes(strings.TrimSpace(s), 60)
+}
+
+func formatHelpDuration(d time.Duration) string {
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%d 小时", d/time.Hour)
+	case d >= time.Minute:
+		return fmt.Sprintf("%d 分钟", d/time.Minute)
+	}
+	return d.String()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package web
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+	"github.com/pocketomega/pocket-omega/internal/tool/builtin"
+)
+
+func newTestHelpHandler(t *testing.T) *CommandHandler {
+	t.Helper()
+	ws := t.TempDir()
+	os.MkdirAll(filepath.Join(ws, "playbooks"), 0o755)
+	os.WriteFile(filepath.Join(ws, "playbooks", "release.md"), []byte("# 发布新版本\n\n1. 更新 CHANGELOG"), 0o644)
+	os.WriteFile(filepath.Join(ws, "playbooks", "bump-deps.md"), []byte("---\ndescription: 升级依赖并跑测试\n---\n升级所有依赖"), 0o644)
+	registry := tool.NewRegistry()
+	registry.Register(builtin.NewTimeTool())
+	registry.Register(builtin.NewHTTPRequestTool(false))
+	h := NewCommandHandler(CommandHandlerOptions{
+		Store:             session.NewStore(45*time.Minute, 7),
+		ToolRegistry:      registry,
+		ModelName:         "test-model",
+		ReadOnly:          true,
+		WorkspaceDir:      ws,
+		MaxAgentTokens:    50000,
+		MaxConcurrentRuns: 2,
+	})
+	t.Cleanup(func() { h.store.Close() })
+	return h
+}
+
+func TestCmdHelp_Overview(t *testing.T) {
+	msg := newTestHelpHandler(t).cmdHelp(context.Background(), "", "").Message
+	for _, want := range []string{
+		"/journal", "模型：test-model", "只读模式",
+		"Token 预算：每次任务 50000", "最多 2 个", "保留最近 7 轮，闲置 45 分钟 后清除",
+		"可用工具（2 个）: get_time, http_request",
+		"playbooks/bump-deps.md — 升级依赖并跑测试", "playbooks/release.md — 发布新版本",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("help missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestCmdHelp_TopicsToolsAndSearch(t *testing.T) {
+	h := newTestHelpHandler(t)
+	tools := h.cmdHelp(context.Background(), "tools", "").Message
+	if !strings.Contains(tools, "• http_request — 发送 HTTP 请求并返回响应") || !strings.Contains(tools, "例：「现在几点？") {
+		t.Errorf("/help tools:\n%s", tools)
+	}
+	if strings.Contains(tools, "/reload") {
+		t.Errorf("/help tools should only list tools:\n%s", tools)
+	}
+
+	detail := h.cmdHelp(context.Background(), "HTTP_Request", "").Message
+	for _, want := range []string{"🔧 http_request", "• url（string，必填）", "• method（string，可选）", `调用示例：http_request {"url":"<url>"}`} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("tool detail missing %q:\n%s", want, detail)
+		}
+	}
+	if strings.Index(detail, "• url") > strings.Index(detail, "• body") {
+		t.Errorf("required parameters should come first:\n%s", detail)
+	}
+
+	search := h.cmdHelp(context.Background(), "依赖", "").Message
+	if !strings.Contains(search, "与「依赖」相关（1 项）") || !strings.Contains(search, "bump-deps.md") {
+		t.Errorf("/help 依赖:\n%s", search)
+	}
+	search = h.cmdHelp(context.Background(), "日志", "").Message
+	if !strings.Contains(search, "/journal") {
+		t.Errorf("/help 日志:\n%s", search)
+	}
+	if msg := h.cmdHelp(context.Background(), "zzz-nothing", "").Message; !strings.Contains(msg, "没有找到") {
+		t.Errorf("no match: %s", msg)
+	}
+}
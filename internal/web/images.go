@@ -0,0 +1,107 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+const (
+	maxUploadImages    = 4
+	maxUploadImageSize = 5 << 20 // 5MB per image
+	maxUploadBody      = maxRequestBody + maxUploadImages*maxUploadImageSize
+)
+
+// uploadError is a rejected image upload with its HTTP status.
+type uploadError struct {
+	status int
+	msg    string
+}
+
+func (e *uploadError) Error() string { return e.msg }
+
+// limitRequestBody caps the request body: multipart forms may carry images,
+// everything else keeps the plain form limit.
+func limitRequestBody(w http.ResponseWriter, r *http.Request) {
+	limit := int64(maxRequestBody)
+	if isMultipart(r) {
+		limit = maxUploadBody
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+}
+
+func isMultipart(r *http.Request) bool {
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mt == "multipart/form-data"
+}
+
+// parseImageUploads reads the "images" files of a multipart form as content
+// parts. Plain forms have no images. Uploads are rejected when provider
+// cannot see them, rather than silently dropped.
+func parseImageUploads(r *http.Request, provider llm.LLMProvider) ([]llm.ContentPart, error) {
+	if !isMultipart(r) {
+		return nil, nil
+	}
+	if err := r.ParseMultipartForm(maxRequestBody); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, &uploadError{http.StatusRequestEntityTooLarge, "Request too large"}
+		}
+		return nil, &uploadError{http.StatusBadRequest, "Invalid multipart form"}
+	}
+	files := r.MultipartForm.File["images"]
+	if len(files) == 0 {
+		return nil, nil
+	}
+	if len(files) > maxUploadImages {
+		return nil, &uploadError{http.StatusBadRequest, fmt.Sprintf("Too many images (max %d)", maxUploadImages)}
+	}
+	if !llm.SupportsVision(provider) {
+		return nil, &uploadError{http.StatusBadRequest, "Model does not support image input"}
+	}
+
+	parts := make([]llm.ContentPart, 0, len(files))
+	for _, fh := range files {
+		if fh.Size > maxUploadImageSize {
+			return nil, &uploadError{http.StatusRequestEntityTooLarge, fmt.Sprintf("Image %q too large (max %d MB)", fh.Filename, maxUploadImageSize>>20)}
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, &uploadError{http.StatusBadRequest, "Cannot read image"}
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxUploadImageSize))
+		f.Close()
+		if err != nil {
+			return nil, &uploadError{http.StatusBadRequest, "Cannot read image"}
+		}
+		mimeType, ok := llm.DetectImageType(data)
+		if !ok {
+			return nil, &uploadError{http.StatusBadRequest, fmt.Sprintf("Unsupported image type %q", mimeType)}
+		}
+		parts = append(parts, llm.NewImagePart(mimeType, data))
+	}
+	return parts, nil
+}
+
+// handleUploadError writes err from parseImageUploads.
+func handleUploadError(w http.ResponseWriter, err error) {
+	var ue *uploadError
+	if errors.As(err, &ue) {
+		http.Error(w, ue.msg, ue.status)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
+
+// imageMarker notes attached images in the session history, which keeps
+// text only.
+func imageMarker(images []llm.ContentPart) string {
+	if len(images) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [附图 %d 张]", len(images))
+}
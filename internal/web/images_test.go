@@ -0,0 +1,87 @@
+package web
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+)
+
+// visionLLMProvider is a mock provider whose model accepts images.
+type visionLLMProvider struct{ mockLLMProvider }
+
+func (*visionLLMProvider) SupportsVision() bool { return true }
+
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// multipartRequest builds a chat form with the given image files.
+func multipartRequest(t *testing.T, target string, files ...[]byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("message", "what is this?")
+	for _, data := range files {
+		fw, _ := mw.CreateFormFile("images", "shot.png")
+		fw.Write(data)
+	}
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestParseImageUploads(t *testing.T) {
+	img := pngBytes(t)
+	vision := &visionLLMProvider{}
+
+	parts, err := parseImageUploads(multipartRequest(t, "/api/chat", img, img), vision)
+	if err != nil || len(parts) != 2 {
+		t.Fatalf("parts = %d, err = %v", len(parts), err)
+	}
+	if parts, err := parseImageUploads(multipartRequest(t, "/api/chat"), &mockLLMProvider{}); err != nil || parts != nil {
+		t.Errorf("no files: parts = %v, err = %v", parts, err)
+	}
+
+	cases := []struct {
+		name     string
+		req      *http.Request
+		provider llm.LLMProvider
+		status   int
+	}{
+		{"text-only model", multipartRequest(t, "/api/chat", img), &mockLLMProvider{}, http.StatusBadRequest},
+		{"too many", multipartRequest(t, "/api/chat", img, img, img, img, img), vision, http.StatusBadRequest},
+		{"not an image", multipartRequest(t, "/api/chat", []byte("hello")), vision, http.StatusBadRequest},
+		{"too large", multipartRequest(t, "/api/chat", append(img, make([]byte, maxUploadImageSize)...)), vision, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseImageUploads(tc.req, tc.provider)
+			var ue *uploadError
+			if !errors.As(err, &ue) || ue.status != tc.status {
+				t.Errorf("err = %v, want status %d", err, tc.status)
+			}
+		})
+	}
+}
+
+func TestHandleChat_RejectsImagesForTextOnlyModel(t *testing.T) {
+	h := NewChatHandler(&mockLLMProvider{}, 1, 0, nil, nil)
+	w := httptest.NewRecorder()
+	h.HandleChat(w, multipartRequest(t, "/api/chat", pngBytes(t)))
+	if w.Code != http.StatusBadRequest || !bytes.Contains(w.Body.Bytes(), []byte("does not support image")) {
+		t.Errorf("status = %d, body = %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/mcp"
+)
+
+// mcpPromptTimeout bounds one prompts/list or prompts/get round-trip
+// (per_call servers are started for it).
+const mcpPromptTimeout = 30 * time.Second
+
+// MCPPromptSource is the part of mcp.Manager the prompt picker needs.
+type MCPPromptSource interface {
+	ListPrompts(ctx context.Context) ([]mcp.ServerPrompt, error)
+	GetPrompt(ctx context.Context, server, name string, args map[string]string) (string, error)
+}
+
+// MCPPromptHandler exposes the prompt templates of connected MCP servers to
+// the web UI, which offers them as selectable message templates:
+//
+//	GET  /api/mcp/prompts  → {"prompts": [{"server", "name", "description", "arguments"}]}
+//	POST /api/mcp/prompts  {"server", "name", "arguments": {...}} → {"text"}
+type MCPPromptHandler struct {
+	source MCPPromptSource
+}
+
+// NewMCPPromptHandler creates the prompt picker API handler.
+func NewMCPPromptHandler(source MCPPromptSource) *MCPPromptHandler {
+	return &MCPPromptHandler{source: source}
+}
+
+// HandlePrompts dispatches on the HTTP method.
+func (h *MCPPromptHandler) HandlePrompts(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), mcpPromptTimeout)
+	defer cancel()
+
+	switch r.Method {
+	case http.MethodGet:
+		prompts, err := h.source.ListPrompts(ctx)
+		if err != nil {
+			// Partial list: one server failing must not hide the others.
+			log.Printf("[MCP] List prompts: %v", err)
+		}
+		if prompts == nil {
+			prompts = []mcp.ServerPrompt{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string][]mcp.ServerPrompt{"prompts": prompts})
+
+	case http.MethodPost:
+		var req struct {
+			Server    string            `json:"server"`
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.Server) == "" || strings.TrimSpace(req.Name) == "" {
+			http.Error(w, "server and name are required", http.StatusBadRequest)
+			return
+		}
+		text, err := h.source.GetPrompt(ctx, req.Server, req.Name, req.Arguments)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"text": text})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/mcp"
+)
+
+type fakePromptSource struct {
+	gotArgs map[string]string
+}
+
+func (f *fakePromptSource) ListPrompts(context.Context) ([]mcp.ServerPrompt, error) {
+	return []mcp.ServerPrompt{{Server: "docs", PromptInfo: mcp.PromptInfo{Name: "review"}}},
+		errors.New(`server "broken": not connected`)
+}
+
+func (f *fakePromptSource) GetPrompt(_ context.Context, server, name string, args map[string]string) (string, error) {
+	if server != "docs" || name != "review" {
+		return "", errors.New("unknown prompt")
+	}
+	f.gotArgs = args
+	return "Review " + args["file"], nil
+}
+
+func TestMCPPromptHandler(t *testing.T) {
+	src := &fakePromptSource{}
+	h := NewMCPPromptHandler(src)
+
+	// A failing server does not hide the others.
+	w := httptest.NewRecorder()
+	h.HandlePrompts(w, httptest.NewRequest(http.MethodGet, "/api/mcp/prompts", nil))
+	var list struct {
+		Prompts []mcp.ServerPrompt `json:"prompts"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil || len(list.Prompts) != 1 || list.Prompts[0].Name != "review" {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandlePrompts(w, httptest.NewRequest(http.MethodPost, "/api/mcp/prompts",
+		strings.NewReader(`{"server":"docs","name":"review","arguments":{"file":"main.go"}}`)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Review main.go") {
+		t.Errorf("get: %d %s", w.Code, w.Body)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandlePrompts(w, httptest.NewRequest(http.MethodPost, "/api/mcp/prompts", strings.NewReader(`{"server":"docs","name":"nope"}`)))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("unknown prompt: status = %d, want 502", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.HandlePrompts(w, httptest.NewRequest(http.MethodPost, "/api/mcp/prompts", strings.NewReader(`{"name":"review"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("missing server: status = %d, want 400", w.Code)
+	}
+}
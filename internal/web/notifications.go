@@ -0,0 +1,77 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxPendingNotifications caps the undelivered notifications kept per session.
+	maxPendingNotifications = 50
+	// notificationTTL drops undelivered notifications of sessions that stopped polling.
+	notificationTTL = 24 * time.Hour
+)
+
+// Notification is a message pushed to a session outside of an agent run
+// (e.g. a file watch trigger). The page polls GET /api/notifications.
+type Notification struct {
+	Kind    string    `json:"kind"` // e.g. "watch"
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+// NotificationHandler is a per-session inbox of pending notifications.
+type NotificationHandler struct {
+	mu      sync.Mutex
+	pending map[string][]Notification
+}
+
+// NewNotificationHandler creates an empty inbox.
+func NewNotificationHandler() *NotificationHandler {
+	return &NotificationHandler{pending: make(map[string][]Notification)}
+}
+
+// Push queues n for sessionID; the oldest entries are dropped beyond
+// maxPendingNotifications.
+func (h *NotificationHandler) Push(sessionID string, n Notification) {
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for id, list := range h.pending {
+		if len(list) > 0 && n.Time.Sub(list[len(list)-1].Time) > notificationTTL {
+			delete(h.pending, id)
+		}
+	}
+	list := append(h.pending[sessionID], n)
+	if len(list) > maxPendingNotifications {
+		list = list[len(list)-maxPendingNotifications:]
+	}
+	h.pending[sessionID] = list
+}
+
+// HandleNotifications returns and clears the pending notifications of the
+// session given by ?session_id=.
+func (h *NotificationHandler) HandleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sessionID := strings.TrimSpace(r.URL.Query().Get("session_id"))
+
+	h.mu.Lock()
+	list := h.pending[sessionID]
+	delete(h.pending, sessionID)
+	h.mu.Unlock()
+
+	if list == nil {
+		list = []Notification{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string][]Notification{"notifications": list})
+}
@@ -0,0 +1,48 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func getNotifications(t *testing.T, h *NotificationHandler, sessionID string) []Notification {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.HandleNotifications(w, httptest.NewRequest(http.MethodGet, "/api/notifications?session_id="+sessionID, nil))
+	var body struct {
+		Notifications []Notification `json:"notifications"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return body.Notifications
+}
+
+func TestNotificationHandler_DeliversOncePerSession(t *testing.T) {
+	h := NewNotificationHandler()
+	h.Push("a", Notification{Kind: "watch", Message: "hit"})
+	h.Push("b", Notification{Kind: "watch", Message: "other"})
+
+	got := getNotifications(t, h, "a")
+	if len(got) != 1 || got[0].Message != "hit" || got[0].Time.IsZero() {
+		t.Fatalf("first poll = %+v", got)
+	}
+	if got := getNotifications(t, h, "a"); len(got) != 0 {
+		t.Errorf("second poll should be empty, got %+v", got)
+	}
+	if got := getNotifications(t, h, "b"); len(got) != 1 {
+		t.Errorf("session b = %+v", got)
+	}
+}
+
+func TestNotificationHandler_CapsPending(t *testing.T) {
+	h := NewNotificationHandler()
+	for i := 0; i < maxPendingNotifications+5; i++ {
+		h.Push("a", Notification{Message: "m"})
+	}
+	if got := getNotifications(t, h, "a"); len(got) != maxPendingNotifications {
+		t.Errorf("pending = %d, want %d", len(got), maxPendingNotifications)
+	}
+}
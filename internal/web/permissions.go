@@ -0,0 +1,23 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// roleOf returns the role of the user of r under p. userOf may be nil
+// (every request is anonymous).
+func roleOf(p *tool.Permissions, userOf func(*http.Request) string, r *http.Request) string {
+	var user string
+	if userOf != nil {
+		user = userOf(r)
+	}
+	return p.Role(user)
+}
+
+// isAdmin reports whether the user of r may use the server-level endpoints
+// (prompt edits, /reload); without permissions everyone may.
+func isAdmin(p *tool.Permissions, userOf func(*http.Request) string, r *http.Request) bool {
+	return p == nil || p.Admin(roleOf(p, userOf, r))
+}
@@ -0,0 +1,175 @@
+package web
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/plan"
+)
+
+// defaultPlanConfirmTimeout bounds the wait for a plan confirmation when
+// clarifying questions are off (AGENT_ASK_TIMEOUT_SECONDS=0).
+const defaultPlanConfirmTimeout = 5 * time.Minute
+
+// planDecision is the user's answer to a plan awaiting confirmation.
+type planDecision struct {
+	steps  []plan.PlanStep // confirmed plan; nil when rejected
+	reason string          // why the plan was rejected
+}
+
+// planWait is a run waiting for its plan to be confirmed.
+type planWait struct {
+	ch   chan planDecision
+	sink eventSink // the run's stream, for edits made through the API
+}
+
+// confirmPlan returns the plan.ConfirmFunc of a session's run
+// (AGENT_PLAN_CONFIRM): it sends the plan as an awaiting_plan event and
+// waits for /api/plan/{sid} to confirm or reject it. The run limiter allows
+// one run per session, so the session ID identifies the waiting run.
+func (h *AgentHandler) confirmPlan(sessionID string, sink eventSink) plan.ConfirmFunc {
+	return func(ctx context.Context, steps []plan.PlanStep) ([]plan.PlanStep, error) {
+		wait := &planWait{ch: make(chan planDecision, 1), sink: sink}
+		h.planWaitsMu.Lock()
+		h.planWaits[sessionID] = wait
+		h.planWaitsMu.Unlock()
+		defer func() {
+			h.planWaitsMu.Lock()
+			if h.planWaits[sessionID] == wait {
+				delete(h.planWaits, sessionID)
+			}
+			h.planWaitsMu.Unlock()
+		}()
+
+		timeout := h.askTimeout
+		if timeout <= 0 {
+			timeout = defaultPlanConfirmTimeout
+		}
+		sink.Send(sseEventAwaitingPlan, sseAwaitingPlanEvent{Steps: steps, TimeoutSeconds: int(timeout.Seconds())})
+		log.Printf("[Agent] Awaiting plan confirmation for session=%s: %d steps", sessionID, len(steps))
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case d := <-wait.ch:
+			if d.steps == nil {
+				return nil, fmt.Errorf("%w: %s", plan.ErrRejected, cmp.Or(d.reason, "no reason given"))
+			}
+			return d.steps, nil
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-timer.C:
+			return nil, errNoReply
+		}
+	}
+}
+
+// planEditRequest is the body of PUT and POST /api/plan/{sid}.
+type planEditRequest struct {
+	Steps  []plan.PlanStep `json:"steps"`
+	Reason string          `json:"reason"` // DELETE: why the plan is rejected
+}
+
+// HandlePlan serves /api/plan/{sid}, the review of a plan awaiting
+// confirmation:
+//
+//   - GET returns the session's plan and whether it awaits confirmation
+//   - PUT {"steps": [...]} replaces the pending plan (reorder, delete, edit)
+//   - POST confirms it, optionally with edited steps in the same body
+//   - DELETE rejects it, with an optional {"reason": "..."}
+//
+// The changing methods return 409 when the session's run is not waiting
+// for a plan confirmation.
+func (h *AgentHandler) HandlePlan(w http.ResponseWriter, r *http.Request) {
+	sessionID := strings.TrimSpace(r.PathValue("sid"))
+	if sessionID == "" || h.planStore == nil {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	h.planWaitsMu.Lock()
+	wait := h.planWaits[sessionID]
+	h.planWaitsMu.Unlock()
+
+	if r.Method == http.MethodGet {
+		writePlanJSON(w, http.StatusOK, map[string]any{
+			"steps":                 h.planStore.Get(sessionID),
+			"awaiting_confirmation": wait != nil,
+		})
+		return
+	}
+	if r.Method != http.MethodPut && r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req planEditRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if wait == nil {
+		http.Error(w, "no agent run is waiting for a plan confirmation in this session", http.StatusConflict)
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		if !h.decidePlan(sessionID, wait, planDecision{reason: strings.TrimSpace(req.Reason)}) {
+			http.Error(w, "the plan was already decided", http.StatusConflict)
+			return
+		}
+		log.Printf("[Agent] Plan rejected for session=%s: %s", sessionID, req.Reason)
+		writePlanJSON(w, http.StatusAccepted, map[string]bool{"rejected": true})
+		return
+	}
+
+	steps := h.planStore.Get(sessionID)
+	if req.Steps != nil {
+		var err error
+		if steps, err = plan.ValidateSteps(req.Steps); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.planStore.Set(sessionID, steps)
+		wait.sink.Send(sseEventPlan, ssePlanEvent{Steps: steps})
+	}
+	if len(steps) == 0 {
+		http.Error(w, "the plan has no steps", http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodPut {
+		writePlanJSON(w, http.StatusOK, map[string]any{"steps": steps})
+		return
+	}
+	if !h.decidePlan(sessionID, wait, planDecision{steps: steps}) {
+		http.Error(w, "the plan was already decided", http.StatusConflict)
+		return
+	}
+	log.Printf("[Agent] Plan confirmed for session=%s: %d steps", sessionID, len(steps))
+	writePlanJSON(w, http.StatusAccepted, map[string]any{"confirmed": true, "steps": steps})
+}
+
+// decidePlan delivers d to the waiting run, once.
+func (h *AgentHandler) decidePlan(sessionID string, wait *planWait, d planDecision) bool {
+	h.planWaitsMu.Lock()
+	defer h.planWaitsMu.Unlock()
+	if h.planWaits[sessionID] != wait {
+		return false
+	}
+	delete(h.planWaits, sessionID)
+	wait.ch <- d
+	return true
+}
+
+func writePlanJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,109 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/plan"
+)
+
+// lockedSink records events from the run and the API goroutines.
+type lockedSink struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (s *lockedSink) Send(event string, _ interface{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return true
+}
+
+func planRequest(h *AgentHandler, method, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/api/plan/s1", strings.NewReader(body))
+	req.SetPathValue("sid", "s1")
+	w := httptest.NewRecorder()
+	h.HandlePlan(w, req)
+	return w
+}
+
+// startPlanWait runs the confirmation of a three-step plan in the
+// background and returns once it waits.
+func startPlanWait(t *testing.T, h *AgentHandler, sink eventSink) chan error {
+	t.Helper()
+	proposed := []plan.PlanStep{{ID: "a", Title: "读配置"}, {ID: "b", Title: "改代码"}, {ID: "c", Title: "跑测试"}}
+	h.planStore.Set("s1", proposed)
+	done := make(chan error, 1)
+	go func() {
+		steps, err := h.confirmPlan("s1", sink)(context.Background(), h.planStore.Get("s1"))
+		if err == nil {
+			h.planStore.Set("s1", steps)
+		}
+		done <- err
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h.planWaitsMu.Lock()
+		waiting := h.planWaits["s1"] != nil
+		h.planWaitsMu.Unlock()
+		if waiting || time.Now().After(deadline) {
+			return done
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHandlePlan_EditAndConfirm(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{PlanStore: plan.NewPlanStore(), PlanConfirm: true, AskTimeout: time.Minute})
+	if w := planRequest(h, http.MethodPost, ""); w.Code != http.StatusConflict {
+		t.Fatalf("no run waiting: status = %d, want 409", w.Code)
+	}
+
+	sink := &lockedSink{}
+	done := startPlanWait(t, h, sink)
+	if w := planRequest(h, http.MethodGet, ""); !strings.Contains(w.Body.String(), `"awaiting_confirmation":true`) {
+		t.Errorf("GET = %s", w.Body)
+	}
+	if w := planRequest(h, http.MethodPut, `{"steps":[{"id":"a","title":"x"},{"id":"a","title":"y"}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("duplicate ids: status = %d, want 400", w.Code)
+	}
+	// Reorder and delete, then confirm the stored edit
+	if w := planRequest(h, http.MethodPut, `{"steps":[{"id":"c","title":"跑测试"},{"id":"a","title":"读配置"}]}`); w.Code != http.StatusOK {
+		t.Fatalf("PUT: status = %d: %s", w.Code, w.Body)
+	}
+	if w := planRequest(h, http.MethodPost, ""); w.Code != http.StatusAccepted {
+		t.Fatalf("POST: status = %d: %s", w.Code, w.Body)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("confirm error: %v", err)
+	}
+	if steps := h.planStore.Get("s1"); len(steps) != 2 || steps[0].ID != "c" || steps[1].ID != "a" {
+		t.Errorf("confirmed plan = %+v", steps)
+	}
+	sink.mu.Lock()
+	if strings.Join(sink.events, ",") != "awaiting_plan,plan" {
+		t.Errorf("events = %v", sink.events)
+	}
+	sink.mu.Unlock()
+	if w := planRequest(h, http.MethodPost, ""); w.Code != http.StatusConflict {
+		t.Errorf("second confirm: status = %d, want 409", w.Code)
+	}
+}
+
+func TestHandlePlan_Reject(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{PlanStore: plan.NewPlanStore(), PlanConfirm: true, AskTimeout: time.Minute})
+	done := startPlanWait(t, h, &lockedSink{})
+	if w := planRequest(h, http.MethodDelete, `{"reason":"先别改代码"}`); w.Code != http.StatusAccepted {
+		t.Fatalf("DELETE: status = %d: %s", w.Code, w.Body)
+	}
+	if err := <-done; !errors.Is(err, plan.ErrRejected) || !strings.Contains(err.Error(), "先别改代码") {
+		t.Errorf("err = %v, want the rejection with its reason", err)
+	}
+}
@@ -0,0 +1,356 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/prompt"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+// SystemPromptPreviewer assembles the system prompt an agent run would use.
+// AgentHandler implements it with its own model, tools and runtime info.
+type SystemPromptPreviewer interface {
+	PreviewSystemPrompt(problem string) string
+}
+
+// PromptsHandlerOptions configures NewPromptsHandler.
+type PromptsHandlerOptions struct {
+	Loader   *prompt.PromptLoader
+	ReadOnly bool                  // read-only mirror mode: writes are refused
+	Preview  SystemPromptPreviewer // optional — enables /api/prompts/preview
+	// Permissions, optional, limit writes to admins: prompts apply to every
+	// user's runs. UserOf may be nil (every request is anonymous).
+	Permissions *tool.Permissions
+	UserOf      func(*http.Request) string
+}
+
+// PromptsHandler manages the prompt, rules and soul files for an editing
+// panel, replacing on-disk edits followed by /reload:
+//
+//	GET  /api/prompts                 → {"files": [promptFileView...]}
+//	GET  /api/prompts?name=x.md       → promptFileView with "content"
+//	PUT  /api/prompts {"name", "content", "dry_run"} → promptFileView with "issues"
+//	GET  /api/prompts/preview?problem → {"prompt", "chars", "tokens"}
+//	GET  /api/prompts/drift           → {"files": [prompt.Drift...]}
+//	POST /api/prompts/drift {"name", "action"} → resolve one drifted file
+//	GET  /api/templates               → {"templates": [prompt.Template...]}
+//	GET  /api/templates?name=x        → prompt.Template
+//
+// Writes with validation errors are rejected with 422; dry_run only validates.
+// Drift lists overrides whose built-in default changed in an upgrade, with
+// the old default, new default, user file and their three-way merge; the
+// actions are "keep" (keep the override as is), "merge" (write the merge,
+// 409 while it has conflicts) and "default" (delete the override).
+type PromptsHandler struct {
+	loader      *prompt.PromptLoader
+	readOnly    bool
+	preview     SystemPromptPreviewer
+	permissions *tool.Permissions
+	userOf      func(*http.Request) string
+}
+
+// promptFileView is a prompt.FileInfo with its token estimate and the
+// validation issues of its content.
+type promptFileView struct {
+	prompt.FileInfo
+	Tokens  int            `json:"tokens"`
+	Issues  []prompt.Issue `json:"issues"`
+	Content *string        `json:"content,omitempty"`
+}
+
+// NewPromptsHandler creates the prompt management API handler.
+func NewPromptsHandler(opts PromptsHandlerOptions) *PromptsHandler {
+	return &PromptsHandler{
+		loader:      opts.Loader,
+		readOnly:    opts.ReadOnly,
+		preview:     opts.Preview,
+		permissions: opts.Permissions,
+		userOf:      opts.UserOf,
+	}
+}
+
+// writable reports whether r may change prompt files, answering 403 when
+// it may not.
+func (h *PromptsHandler) writable(w http.ResponseWriter, r *http.Request) bool {
+	if h.readOnly {
+		http.Error(w, "prompt editing is disabled in read-only mode", http.StatusForbidden)
+		return false
+	}
+	if !isAdmin(h.permissions, h.userOf, r) {
+		http.Error(w, "prompt editing is limited to admins", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// HandlePrompts dispatches /api/prompts on the HTTP method.
+func (h *PromptsHandler) HandlePrompts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if name := r.URL.Query().Get("name"); name != "" {
+			h.read(w, name)
+			return
+		}
+		files := h.loader.Files()
+		views := make([]promptFileView, 0, len(files))
+		for _, info := range files {
+			content, _, err := h.loader.ReadFile(info.Name)
+			if err != nil {
+				log.Printf("[Prompt] Read %s: %v", info.Name, err)
+			}
+			views = append(views, h.view(info, content, false))
+		}
+		writePromptsJSON(w, http.StatusOK, map[string][]promptFileView{"files": views})
+
+	case http.MethodPut, http.MethodPost:
+		h.write(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PromptsHandler) read(w http.ResponseWriter, name string) {
+	content, info, err := h.loader.ReadFile(name)
+	if err != nil {
+		promptError(w, err)
+		return
+	}
+	writePromptsJSON(w, http.StatusOK, h.view(info, content, true))
+}
+
+func (h *PromptsHandler) write(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name    string `json:"name"`
+		Content string `json:"content"`
+		DryRun  bool   `json:"dry_run"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if req.DryRun {
+		_, info, err := h.loader.ReadFile(req.Name)
+		if err != nil {
+			promptError(w, err)
+			return
+		}
+		writePromptsJSON(w, http.StatusOK, h.view(sized(info, req.Content), req.Content, false))
+		return
+	}
+	if !h.writable(w, r) {
+		return
+	}
+
+	issues, err := h.loader.WriteFile(req.Name, req.Content)
+	if err != nil {
+		promptError(w, err)
+		return
+	}
+	_, info, err := h.loader.ReadFile(req.Name)
+	if err != nil {
+		promptError(w, err)
+		return
+	}
+	status := http.StatusOK
+	if prompt.HasErrors(issues) {
+		status = http.StatusUnprocessableEntity // not written; info describes the file on disk
+		info = sized(info, req.Content)
+	} else {
+		log.Printf("[Prompt] %s updated via API (%d bytes)", req.Name, len(req.Content))
+	}
+	writePromptsJSON(w, status, h.view(info, req.Content, false))
+}
+
+// HandlePreview returns the assembled system prompt for an optional
+// problem text (GET /api/prompts/preview?problem=...), which selects the
+// conditionally loaded guides.
+func (h *PromptsHandler) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.preview == nil {
+		http.Error(w, "preview is not available", http.StatusNotFound)
+		return
+	}
+	text := h.preview.PreviewSystemPrompt(r.URL.Query().Get("problem"))
+	writePromptsJSON(w, http.StatusOK, map[string]any{
+		"prompt": text,
+		"chars":  utf8.RuneCountInString(text),
+		"tokens": agent.EstimateTokens(text),
+	})
+}
+
+// HandleTemplates lists the task templates under prompts/templates/, or
+// returns one with ?name=; /template expands them into messages.
+func (h *PromptsHandler) HandleTemplates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if name := r.URL.Query().Get("name"); name != "" {
+		t, err := h.loader.Template(name)
+		if errors.Is(err, prompt.ErrTemplateNotFound) {
+			http.Error(w, "template not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			promptError(w, err)
+			return
+		}
+		writePromptsJSON(w, http.StatusOK, t)
+		return
+	}
+	templates := h.loader.Templates()
+	if templates == nil {
+		templates = []prompt.Template{}
+	}
+	writePromptsJSON(w, http.StatusOK, map[string][]prompt.Template{"templates": templates})
+}
+
+// HandleDrift serves /api/prompts/drift.
+func (h *PromptsHandler) HandleDrift(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		drifts, err := h.loader.Drifts()
+		if err != nil {
+			promptError(w, err)
+			return
+		}
+		if drifts == nil {
+			drifts = []prompt.Drift{}
+		}
+		writePromptsJSON(w, http.StatusOK, map[string][]prompt.Drift{"files": drifts})
+
+	case http.MethodPost:
+		h.resolveDrift(w, r)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *PromptsHandler) resolveDrift(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string `json:"name"`
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if !h.writable(w, r) {
+		return
+	}
+	drifts, err := h.loader.Drifts()
+	if err != nil {
+		promptError(w, err)
+		return
+	}
+	var drift *prompt.Drift
+	for i := range drifts {
+		if drifts[i].Name == req.Name {
+			drift = &drifts[i]
+		}
+	}
+	if drift == nil {
+		http.Error(w, "no pending default changes for "+req.Name, http.StatusNotFound)
+		return
+	}
+
+	switch req.Action {
+	case "keep":
+		err = h.loader.ResolveDrift(req.Name)
+	case "merge":
+		if drift.Conflicts > 0 {
+			http.Error(w, "merge has conflicts; edit the merged content and PUT /api/prompts", http.StatusConflict)
+			return
+		}
+		var issues []prompt.Issue
+		issues, err = h.loader.WriteFile(req.Name, drift.Merged)
+		if err == nil && prompt.HasErrors(issues) {
+			writePromptsJSON(w, http.StatusUnprocessableEntity, map[string][]prompt.Issue{"issues": issues})
+			return
+		}
+	case "default":
+		err = h.loader.RemoveOverride(req.Name)
+	default:
+		http.Error(w, `action must be "keep", "merge" or "default"`, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		promptError(w, err)
+		return
+	}
+	log.Printf("[Prompt] %s: default changes resolved (%s)", req.Name, req.Action)
+	h.read(w, req.Name)
+}
+
+// view builds the response for one file; content is included when withContent.
+func (h *PromptsHandler) view(info prompt.FileInfo, content string, withContent bool) promptFileView {
+	v := promptFileView{
+		FileInfo: info,
+		Tokens:   agent.EstimateTokens(content),
+		Issues:   nonNilIssues(h.loader.Validate(info.Name, content)),
+	}
+	if withContent {
+		v.Content = &content
+	}
+	return v
+}
+
+// sized returns info with its size fields describing content instead.
+func sized(info prompt.FileInfo, content string) prompt.FileInfo {
+	info.Bytes = len(content)
+	info.Runes = utf8.RuneCountInString(content)
+	return info
+}
+
+func nonNilIssues(issues []prompt.Issue) []prompt.Issue {
+	if issues == nil {
+		return []prompt.Issue{}
+	}
+	return issues
+}
+
+// promptError maps loader errors to HTTP statuses.
+func promptError(w http.ResponseWriter, err error) {
+	if errors.Is(err, prompt.ErrInvalidName) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("[Prompt] %v", err)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+func writePromptsJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// PreviewSystemPrompt implements SystemPromptPreviewer with the settings
+// this handler starts runs with.
+func (h *AgentHandler) PreviewSystemPrompt(problem string) string {
+	return agent.PreviewSystemPrompt(h.llmProvider, h.loader, &agent.AgentState{
+		Problem:             problem,
+		ToolRegistry:        h.toolRegistry,
+		ThinkingMode:        h.thinkingMode,
+		ToolCallMode:        h.toolCallMode,
+		ContextWindowTokens: h.contextWindowTokens,
+		OSName:              h.osName,
+		ShellCmd:            h.shellCmd,
+		ModelName:           h.modelName,
+	})
+}
@@ -0,0 +1,88 @@
+package web
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/config"
+)
+
+// ReloadResult describes a config reload (/reload or SIGHUP): the settings
+// that changed and the values the handlers switch to. Runs already in
+// progress keep the limits they started with.
+type ReloadResult struct {
+	Config           *config.Loaded // omega.yaml after the reload; nil = no config file
+	Changed          []string       // environment variables whose value changed
+	ModelName        string
+	MaxAgentTokens   int64
+	MaxAgentDuration time.Duration
+	ToolsAdded       []string // conditional tools enabled by the reload
+	ToolsRemoved     []string // conditional tools disabled by the reload
+	Warnings         []string // parts of the reload that kept the old state
+}
+
+// Summary describes the reload for /reload.
+func (r ReloadResult) Summary() string {
+	var sb strings.Builder
+	if len(r.Changed) == 0 {
+		sb.WriteString("• 配置无变化")
+	} else {
+		fmt.Fprintf(&sb, "• 已更新的设置：%s", strings.Join(r.Changed, ", "))
+	}
+	fmt.Fprintf(&sb, "\n• 模型：%s", orDash(r.ModelName))
+	var budgets []string
+	if r.MaxAgentTokens > 0 {
+		budgets = append(budgets, fmt.Sprintf("%d tokens", r.MaxAgentTokens))
+	}
+	if r.MaxAgentDuration > 0 {
+		budgets = append(budgets, formatHelpDuration(r.MaxAgentDuration))
+	}
+	if len(budgets) > 0 {
+		fmt.Fprintf(&sb, "\n• 每次任务预算：%s（新任务生效）", strings.Join(budgets, "，"))
+	}
+	if len(r.ToolsAdded) > 0 {
+		fmt.Fprintf(&sb, "\n• 新增工具：%s", strings.Join(r.ToolsAdded, ", "))
+	}
+	if len(r.ToolsRemoved) > 0 {
+		fmt.Fprintf(&sb, "\n• 移除工具：%s", strings.Join(r.ToolsRemoved, ", "))
+	}
+	for _, w := range r.Warnings {
+		fmt.Fprintf(&sb, "\n⚠️ %s", w)
+	}
+	return sb.String()
+}
+
+// ApplyReload switches new runs to the reloaded model name and budget
+// limits. The provider itself is swapped by the caller.
+func (h *AgentHandler) ApplyReload(r ReloadResult) {
+	h.settingsMu.Lock()
+	defer h.settingsMu.Unlock()
+	h.modelName = r.ModelName
+	h.maxAgentTokens = r.MaxAgentTokens
+	h.maxAgentDuration = r.MaxAgentDuration
+}
+
+// currentModel returns the model name runs are started with.
+func (h *AgentHandler) currentModel() string {
+	h.settingsMu.RLock()
+	defer h.settingsMu.RUnlock()
+	return h.modelName
+}
+
+// ApplyReload updates what /stats, /help and /config report.
+func (h *CommandHandler) ApplyReload(r ReloadResult) {
+	h.settingsMu.Lock()
+	defer h.settingsMu.Unlock()
+	h.modelName = r.ModelName
+	h.maxAgentTokens = r.MaxAgentTokens
+	h.maxAgentDuration = r.MaxAgentDuration
+	h.config = r.Config
+}
+
+// ApplyReload makes /api/config report the reloaded config file.
+func (h *ConfigHandler) ApplyReload(r ReloadResult) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.loaded = r.Config
+}
@@ -0,0 +1,69 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/session"
+)
+
+func TestHandleCommand_ReloadConfig(t *testing.T) {
+	var h *CommandHandler
+	var reloadErr error
+	h = NewCommandHandler(CommandHandlerOptions{
+		Store:          session.NewStore(time.Minute, 10),
+		ModelName:      "gpt-4o",
+		MaxAgentTokens: 1000,
+		ConfigReload: func() (ReloadResult, error) {
+			if reloadErr != nil {
+				return ReloadResult{}, reloadErr
+			}
+			res := ReloadResult{
+				Changed:          []string{"AGENT_MAX_TOKENS", "BRAVE_API_KEY", "LLM_MODEL"},
+				ModelName:        "gpt-4.1",
+				MaxAgentTokens:   5000,
+				MaxAgentDuration: 10 * time.Minute,
+				ToolsAdded:       []string{"brave_search"},
+			}
+			h.ApplyReload(res)
+			return res, nil
+		},
+	})
+	t.Cleanup(func() { h.store.Close() })
+
+	run := func(cmd string) commandResult {
+		t.Helper()
+		w := doCommand(t, h, http.MethodPost, commandRequest{Command: cmd})
+		var result commandResult
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	res := run("reload")
+	if !res.OK || !strings.Contains(res.Message, "LLM_MODEL") || !strings.Contains(res.Message, "brave_search") {
+		t.Errorf("/reload = %+v", res)
+	}
+	help := run("help").Message
+	if !strings.Contains(help, "gpt-4.1") || !strings.Contains(help, "5000") {
+		t.Errorf("/help does not show the reloaded settings:\n%s", help)
+	}
+
+	reloadErr = errors.New("config omega.yaml: budgets.max_steps: 2 is below 5")
+	if res := run("reload"); res.OK || !strings.Contains(res.Message, "budgets.max_steps") {
+		t.Errorf("failed reload = %+v", res)
+	}
+}
+
+func TestAgentHandler_ApplyReload(t *testing.T) {
+	h := &AgentHandler{modelName: "gpt-4o"}
+	h.ApplyReload(ReloadResult{ModelName: "gpt-4.1", MaxAgentTokens: 5000})
+	if h.currentModel() != "gpt-4.1" || h.maxAgentTokens != 5000 {
+		t.Errorf("model=%q tokens=%d", h.currentModel(), h.maxAgentTokens)
+	}
+}
@@ -0,0 +1,229 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// queueKeepAlive is how often a queued run re-reports its position so the
+// browser's SSE heartbeat (90s) does not abort a request that is only waiting.
+const queueKeepAlive = 15 * time.Second
+
+// errRunQueueFull is returned by runLimiter.enter when the wait queue is full.
+var errRunQueueFull = errors.New("agent run queue is full")
+
+// AgentRunStats is a snapshot of agent run concurrency for /api/health.
+type AgentRunStats struct {
+	MaxConcurrent int `json:"max_concurrent"` // 0 = unlimited
+	MaxQueued     int `json:"max_queued"`     // 0 = unlimited
+	Active        int `json:"active"`
+	Queued        int `json:"queued"`
+}
+
+// runLimiter provides backpressure for agent runs: at most maxActive runs
+// execute at once and the rest wait in a FIFO queue (bounded by maxQueued).
+// Independently, runs of the same session are serialised so two browser
+// tabs sharing a session cannot mutate its history concurrently.
+type runLimiter struct {
+	maxActive int // 0 = unlimited
+	maxQueued int // 0 = unlimited
+
+	mu       sync.Mutex
+	active   int
+	queue    []*runTicket
+	sessions map[string]*sessionGate
+}
+
+// sessionGate is a ref-counted per-session mutex; a channel (capacity 1)
+// instead of sync.Mutex so waiting honours context cancellation.
+type sessionGate struct {
+	ch   chan struct{}
+	refs int
+}
+
+// runTicket tracks one agent run through the session gate and the queue.
+type runTicket struct {
+	l       *runLimiter
+	session string
+	gate    *sessionGate  // nil for anonymous runs
+	ready   chan struct{} // closed when a global slot is granted
+	moved   chan struct{} // signalled when the queue position changes
+	granted bool          // guarded by l.mu
+	gated   bool          // session gate held
+	done    bool
+}
+
+func newRunLimiter(maxActive, maxQueued int) *runLimiter {
+	if maxActive < 0 {
+		maxActive = 0
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	return &runLimiter{maxActive: maxActive, maxQueued: maxQueued, sessions: make(map[string]*sessionGate)}
+}
+
+// enter registers a run without blocking. It fails fast with errRunQueueFull
+// when the run would have to queue behind maxQueued others, so the handler
+// can answer 503 before opening the SSE stream.
+func (l *runLimiter) enter(sessionID string) (*runTicket, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.maxActive > 0 && l.maxQueued > 0 && l.active >= l.maxActive && len(l.queue) >= l.maxQueued {
+		return nil, errRunQueueFull
+	}
+	t := &runTicket{l: l, session: sessionID, ready: make(chan struct{}), moved: make(chan struct{}, 1)}
+	if sessionID != "" {
+		g := l.sessions[sessionID]
+		if g == nil {
+			g = &sessionGate{ch: make(chan struct{}, 1)}
+			l.sessions[sessionID] = g
+		}
+		g.refs++
+		t.gate = g
+	}
+	return t, nil
+}
+
+// wait blocks until the run may start: first the session gate, then a global
+// slot. onWait is called with position 0 while another run of the same
+// session is active, and with the 1-based queue position while queued
+// (again on every change and every queueKeepAlive). On error the ticket is
+// released.
+func (t *runTicket) wait(ctx context.Context, onWait func(position int)) error {
+	if t.gate != nil {
+		select {
+		case t.gate.ch <- struct{}{}:
+		default:
+			onWait(0)
+			if err := t.waitGate(ctx, onWait); err != nil {
+				t.release()
+				return err
+			}
+		}
+		t.gated = true
+	}
+
+	l := t.l
+	l.mu.Lock()
+	if l.maxActive == 0 || (l.active < l.maxActive && len(l.queue) == 0) {
+		l.active++
+		t.granted = true
+		l.mu.Unlock()
+		return nil
+	}
+	l.queue = append(l.queue, t)
+	pos := len(l.queue)
+	l.mu.Unlock()
+	onWait(pos)
+
+	keepAlive := time.NewTicker(queueKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-t.ready:
+			return nil
+		case <-t.moved:
+			if p := l.position(t); p > 0 {
+				onWait(p)
+			}
+		case <-keepAlive.C:
+			if p := l.position(t); p > 0 {
+				onWait(p)
+			}
+		case <-ctx.Done():
+			t.release()
+			return ctx.Err()
+		}
+	}
+}
+
+// waitGate blocks on the session gate, re-reporting position 0 periodically.
+func (t *runTicket) waitGate(ctx context.Context, onWait func(position int)) error {
+	keepAlive := time.NewTicker(queueKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case t.gate.ch <- struct{}{}:
+			return nil
+		case <-keepAlive.C:
+			onWait(0)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release frees the ticket's slot, queue entry and session gate. Idempotent.
+func (t *runTicket) release() {
+	l := t.l
+	l.mu.Lock()
+	if t.done {
+		l.mu.Unlock()
+		return
+	}
+	t.done = true
+
+	var moved []*runTicket
+	if t.granted {
+		if l.maxActive > 0 {
+			l.active--
+			for l.active < l.maxActive && len(l.queue) > 0 {
+				next := l.queue[0]
+				l.queue = l.queue[1:]
+				next.granted = true
+				l.active++
+				close(next.ready)
+			}
+			moved = append(moved, l.queue...)
+		} else {
+			l.active--
+		}
+	} else if i := l.indexLocked(t); i >= 0 {
+		l.queue = append(l.queue[:i], l.queue[i+1:]...)
+		moved = append(moved, l.queue[i:]...)
+	}
+
+	if t.gate != nil {
+		if t.gated {
+			<-t.gate.ch
+		}
+		t.gate.refs--
+		if t.gate.refs == 0 {
+			delete(l.sessions, t.session)
+		}
+	}
+	l.mu.Unlock()
+
+	for _, m := range moved {
+		select {
+		case m.moved <- struct{}{}:
+		default: // an update is already pending
+		}
+	}
+}
+
+// position returns t's 1-based queue position, or 0 when it is not queued.
+func (l *runLimiter) position(t *runTicket) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.indexLocked(t) + 1
+}
+
+func (l *runLimiter) indexLocked(t *runTicket) int {
+	for i, q := range l.queue {
+		if q == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// stats returns a snapshot for /api/health.
+func (l *runLimiter) stats() AgentRunStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return AgentRunStats{MaxConcurrent: l.maxActive, MaxQueued: l.maxQueued, Active: l.active, Queued: len(l.queue)}
+}
@@ -0,0 +1,175 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// positions records onWait callbacks.
+type positions struct {
+	mu  sync.Mutex
+	got []int
+}
+
+func (p *positions) add(n int) {
+	p.mu.Lock()
+	p.got = append(p.got, n)
+	p.mu.Unlock()
+}
+
+func (p *positions) last() (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.got) == 0 {
+		return 0, false
+	}
+	return p.got[len(p.got)-1], true
+}
+
+func mustEnter(t *testing.T, l *runLimiter, session string) *runTicket {
+	t.Helper()
+	tk, err := l.enter(session)
+	if err != nil {
+		t.Fatalf("enter(%q): %v", session, err)
+	}
+	return tk
+}
+
+// waitAsync runs tk.wait in a goroutine; the returned channel yields its error.
+func waitAsync(tk *runTicket, ctx context.Context, p *positions) chan error {
+	done := make(chan error, 1)
+	go func() { done <- tk.wait(ctx, p.add) }()
+	return done
+}
+
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestRunLimiter_QueuesBeyondLimit(t *testing.T) {
+	l := newRunLimiter(1, 0)
+	first := mustEnter(t, l, "a")
+	if err := first.wait(context.Background(), func(int) { t.Error("first run must not wait") }); err != nil {
+		t.Fatal(err)
+	}
+
+	second := mustEnter(t, l, "b")
+	var p positions
+	done := waitAsync(second, context.Background(), &p)
+	eventually(t, func() bool { n, ok := p.last(); return ok && n == 1 })
+	if st := l.stats(); st.Active != 1 || st.Queued != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+
+	first.release()
+	if err := <-done; err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	second.release()
+	if st := l.stats(); st.Active != 0 || st.Queued != 0 {
+		t.Errorf("stats after release = %+v", st)
+	}
+}
+
+func TestRunLimiter_QueueFull(t *testing.T) {
+	l := newRunLimiter(1, 1)
+	first := mustEnter(t, l, "")
+	_ = first.wait(context.Background(), func(int) {})
+	second := mustEnter(t, l, "")
+	var p positions
+	done := waitAsync(second, context.Background(), &p)
+	eventually(t, func() bool { return l.stats().Queued == 1 })
+
+	if _, err := l.enter(""); !errors.Is(err, errRunQueueFull) {
+		t.Fatalf("expected errRunQueueFull, got %v", err)
+	}
+	first.release()
+	<-done
+	second.release()
+}
+
+func TestRunLimiter_CancelWhileQueuedMovesOthersUp(t *testing.T) {
+	l := newRunLimiter(1, 0)
+	first := mustEnter(t, l, "")
+	_ = first.wait(context.Background(), func(int) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaving := mustEnter(t, l, "")
+	var p1 positions
+	leaveDone := waitAsync(leaving, ctx, &p1)
+	eventually(t, func() bool { return l.stats().Queued == 1 })
+
+	staying := mustEnter(t, l, "")
+	var p2 positions
+	stayDone := waitAsync(staying, context.Background(), &p2)
+	eventually(t, func() bool { n, ok := p2.last(); return ok && n == 2 })
+
+	cancel()
+	if err := <-leaveDone; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled run: %v", err)
+	}
+	eventually(t, func() bool { n, _ := p2.last(); return n == 1 })
+
+	first.release()
+	if err := <-stayDone; err != nil {
+		t.Fatal(err)
+	}
+	staying.release()
+	if st := l.stats(); st.Active != 0 || st.Queued != 0 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestRunLimiter_SerialisesSameSession(t *testing.T) {
+	l := newRunLimiter(0, 0) // no global limit: only the session gate applies
+	first := mustEnter(t, l, "s1")
+	_ = first.wait(context.Background(), func(int) {})
+
+	other := mustEnter(t, l, "s2")
+	if err := other.wait(context.Background(), func(int) { t.Error("other sessions must not wait") }); err != nil {
+		t.Fatal(err)
+	}
+	other.release()
+
+	second := mustEnter(t, l, "s1")
+	var p positions
+	done := waitAsync(second, context.Background(), &p)
+	eventually(t, func() bool { n, ok := p.last(); return ok && n == 0 })
+	select {
+	case <-done:
+		t.Fatal("second run of the same session started while the first is active")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	first.release()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	second.release()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.sessions) != 0 {
+		t.Errorf("session gates leaked: %d", len(l.sessions))
+	}
+}
+
+func TestRunLimiter_ReleaseIsIdempotent(t *testing.T) {
+	l := newRunLimiter(2, 0)
+	tk := mustEnter(t, l, "s")
+	_ = tk.wait(context.Background(), func(int) {})
+	tk.release()
+	tk.release()
+	if st := l.stats(); st.Active != 0 {
+		t.Errorf("active = %d after double release", st.Active)
+	}
+}
@@ -0,0 +1,77 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+)
+
+// maxKeptScratchpads bounds the scratchpads of finished runs kept for
+// download; the oldest runs are forgotten first.
+const maxKeptScratchpads = 50
+
+// runScratchpad is the scratchpad of one run with the problem it worked on.
+type runScratchpad struct {
+	problem string
+	pad     *agent.Scratchpad
+}
+
+// openScratchpad creates the scratchpad of a run and keeps it for
+// /api/runs/{runID}/scratchpad, during the run and after it ends.
+func (h *AgentHandler) openScratchpad(runID, problem string) *agent.Scratchpad {
+	pad := agent.NewScratchpad()
+	h.scratchpadsMu.Lock()
+	defer h.scratchpadsMu.Unlock()
+	h.scratchpads[runID] = runScratchpad{problem: problem, pad: pad}
+	h.scratchOrder = append(h.scratchOrder, runID)
+	if over := len(h.scratchOrder) - maxKeptScratchpads; over > 0 {
+		for _, id := range h.scratchOrder[:over] {
+			delete(h.scratchpads, id)
+		}
+		h.scratchOrder = append([]string(nil), h.scratchOrder[over:]...)
+	}
+	return pad
+}
+
+// scratchpadResponse is the JSON body of GET /api/runs/{runID}/scratchpad.
+type scratchpadResponse struct {
+	RunID   string               `json:"run_id"`
+	Problem string               `json:"problem"`
+	Entries []agent.ScratchEntry `json:"entries"`
+}
+
+// HandleScratchpad serves GET /api/runs/{runID}/scratchpad: the hypotheses,
+// decisions and open questions of a running or recent run, as a Markdown
+// download, or as JSON with format=json. Returns 404 for unknown runs and
+// runs older than the last maxKeptScratchpads.
+func (h *AgentHandler) HandleScratchpad(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	runID := r.PathValue("runID")
+	h.scratchpadsMu.Lock()
+	run, ok := h.scratchpads[runID]
+	h.scratchpadsMu.Unlock()
+	if !ok {
+		http.Error(w, "no scratchpad for this run ID", http.StatusNotFound)
+		return
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "json":
+		entries := run.pad.Entries()
+		if entries == nil {
+			entries = []agent.ScratchEntry{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scratchpadResponse{RunID: runID, Problem: run.problem, Entries: entries})
+	case "", "md":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="scratchpad-`+runID+`.md"`)
+		w.Write([]byte(run.pad.Markdown(run.problem)))
+	default:
+		http.Error(w, "format must be md or json", http.StatusBadRequest)
+	}
+}
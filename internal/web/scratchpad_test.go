@@ -0,0 +1,51 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+)
+
+func TestHandleScratchpad(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{})
+	pad := h.openScratchpad("r1", "why does the build fail?")
+	pad.Add(agent.ScratchEntry{Step: 2, Kind: agent.ScratchHypothesis, Text: "CGO is disabled"})
+	get := func(path string) *httptest.ResponseRecorder {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/api/runs/{runID}/scratchpad", h.HandleScratchpad)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/api/runs/r1/scratchpad")
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Disposition"), "scratchpad-r1.md") ||
+		!strings.Contains(w.Body.String(), "- CGO is disabled _(step 2)_") {
+		t.Errorf("markdown: status %d, headers %v\n%s", w.Code, w.Header(), w.Body)
+	}
+	var resp scratchpadResponse
+	if w := get("/api/runs/r1/scratchpad?format=json"); w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&resp) != nil {
+		t.Fatalf("json: status %d", w.Code)
+	}
+	if resp.Problem != "why does the build fail?" || len(resp.Entries) != 1 || resp.Entries[0].Kind != agent.ScratchHypothesis {
+		t.Errorf("json = %+v", resp)
+	}
+	if w := get("/api/runs/r1/scratchpad?format=pdf"); w.Code != http.StatusBadRequest {
+		t.Errorf("bad format: status %d, want 400", w.Code)
+	}
+
+	for i := 0; i < maxKeptScratchpads; i++ {
+		h.openScratchpad(fmt.Sprintf("r%d", i+2), "p")
+	}
+	if w := get("/api/runs/r1/scratchpad"); w.Code != http.StatusNotFound {
+		t.Errorf("evicted run: status %d, want 404", w.Code)
+	}
+	if w := get(fmt.Sprintf("/api/runs/r%d/scratchpad", maxKeptScratchpads+1)); w.Code != http.StatusOK {
+		t.Errorf("recent run: status %d, want 200", w.Code)
+	}
+}
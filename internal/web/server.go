@@ -5,11 +5,18 @@ import (
 	"embed"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/telemetry"
 )
 
 //go:embed templates/index.html
@@ -20,13 +27,43 @@ type Server struct {
 	tmpl           *template.Template
 	mux            *http.ServeMux
 	chatHandler    *ChatHandler
-	agentHandler   *AgentHandler   // Phase 2: Agent with tools
-	commandHandler *CommandHandler // Slash command handler
-	healthHandler  *HealthHandler  // GET /api/health
+	agentHandler   *AgentHandler        // Phase 2: Agent with tools
+	commandHandler *CommandHandler      // Slash command handler
+	notifications  *NotificationHandler // optional — GET /api/notifications
+	batchHandler   *BatchHandler        // optional — /api/batch
+	mcpPrompts     *MCPPromptHandler    // optional — /api/mcp/prompts
+	prompts        *PromptsHandler      // optional — /api/prompts, /api/templates
+	audio          *AudioHandler        // optional — /api/stt, /api/tts
+	healthHandler  *HealthHandler       // GET /api/health
+	readOnly       bool                 // shows the read-only banner
+	grpcAddr       string               // optional — gRPC management API, see EnableGRPC
+	storage        *StorageHandler      // optional — /api/storage, see EnableStorage
+	files          *FilesHandler        // optional — /api/files/*, see EnableFiles
+	sessions       *SessionsHandler     // optional — /api/sessions, see EnableSessions
+}
+
+// indexData is the template data for index.html.
+type indexData struct {
+	ReadOnly      bool
+	Notifications bool     // poll /api/notifications
+	MCPPrompts    bool     // offer the MCP prompt template picker
+	AnswerStyles  []string // answer style profiles for the style selector
+	ToolProfiles  []string // tool profiles for the profile selector
+	Vision        bool     // offer image attachments
+	VoiceInput    bool     // offer the microphone button (/api/stt)
+	SpeakAnswers  bool     // offer read-aloud on answers (/api/tts)
+	Storage       bool     // poll /api/storage for the low disk space banner
+	Files         bool     // offer the workspace file browser (/api/files)
+	FileUpload    bool     // offer the workspace upload button (/api/files/upload)
+	Sessions      bool     // offer the conversation sidebar (/api/sessions)
 }
 
 // NewServer creates a new web server with the given handlers.
-func NewServer(chatHandler *ChatHandler, agentHandler *AgentHandler, commandHandler *CommandHandler, healthInfo HealthInfo) (*Server, error) {
+// notifications may be nil (no out-of-run messages, e.g. file watches disabled);
+// batchHandler may be nil (batch API not configured); mcpPrompts may be nil
+// (no MCP manager); prompts may be nil (prompt editing API not offered);
+// audio may be nil (AUDIO_ENABLED not set).
+func NewServer(chatHandler *ChatHandler, agentHandler *AgentHandler, commandHandler *CommandHandler, notifications *NotificationHandler, batchHandler *BatchHandler, mcpPrompts *MCPPromptHandler, prompts *PromptsHandler, audio *AudioHandler, healthInfo HealthInfo) (*Server, error) {
 	tmpl, err := template.ParseFS(content, "templates/index.html")
 	if err != nil {
 		return nil, err
@@ -38,7 +75,13 @@ func NewServer(chatHandler *ChatHandler, agentHandler *AgentHandler, commandHand
 		chatHandler:    chatHandler,
 		agentHandler:   agentHandler,
 		commandHandler: commandHandler,
+		notifications:  notifications,
+		batchHandler:   batchHandler,
+		mcpPrompts:     mcpPrompts,
+		prompts:        prompts,
+		audio:          audio,
 		healthHandler:  NewHealthHandler(healthInfo),
+		readOnly:       healthInfo.ReadOnly,
 	}
 	s.registerRoutes()
 	return s, nil
@@ -50,11 +93,41 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/api/chat", s.chatHandler.HandleChat)
 	if s.agentHandler != nil {
 		s.mux.HandleFunc("/api/agent", s.agentHandler.HandleAgent)
+		s.mux.HandleFunc("/api/agent/annotate", s.agentHandler.HandleAnnotate)
+		s.mux.HandleFunc("/api/agent/reply", s.agentHandler.HandleReply)
+		s.mux.HandleFunc("/api/plan/{sid}", s.agentHandler.HandlePlan)
+		s.mux.HandleFunc("/api/agent/{runID}/cancel", s.agentHandler.HandleCancel)
+		s.mux.HandleFunc("/api/runs/{runID}/scratchpad", s.agentHandler.HandleScratchpad)
+		s.mux.HandleFunc("/api/sessions/{id}/fork", s.agentHandler.HandleFork)
+		s.mux.HandleFunc("/api/agent/stats", s.agentHandler.HandleStats)
+		s.mux.HandleFunc("/api/debug/cache_stats", s.agentHandler.HandleCacheStats)
+		s.mux.HandleFunc("/api/editor/open", s.agentHandler.HandleEditorOpen)
+		s.mux.HandleFunc("/stats", s.handleStatsPage)
 	}
 	if s.commandHandler != nil {
 		s.mux.HandleFunc("/api/command", s.commandHandler.HandleCommand)
 	}
+	if s.notifications != nil {
+		s.mux.HandleFunc("/api/notifications", s.notifications.HandleNotifications)
+	}
+	if s.batchHandler != nil {
+		s.mux.HandleFunc("/api/batch", s.batchHandler.HandleBatch)
+	}
+	if s.mcpPrompts != nil {
+		s.mux.HandleFunc("/api/mcp/prompts", s.mcpPrompts.HandlePrompts)
+	}
+	if s.prompts != nil {
+		s.mux.HandleFunc("/api/prompts", s.prompts.HandlePrompts)
+		s.mux.HandleFunc("/api/prompts/preview", s.prompts.HandlePreview)
+		s.mux.HandleFunc("/api/prompts/drift", s.prompts.HandleDrift)
+		s.mux.HandleFunc("/api/templates", s.prompts.HandleTemplates)
+	}
+	if s.audio != nil {
+		s.mux.HandleFunc("/api/stt", s.audio.HandleSTT)
+		s.mux.HandleFunc("/api/tts", s.audio.HandleTTS)
+	}
 	s.mux.HandleFunc("/api/health", s.healthHandler.ServeHTTP)
+	s.mux.HandleFunc("/api/events/schema", handleEventSchema)
 }
 
 // handleIndex serves the main page.
@@ -63,12 +136,81 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		http.NotFound(w, r)
 		return
 	}
-	if err := s.tmpl.Execute(w, nil); err != nil {
+	data := indexData{
+		ReadOnly:      s.readOnly,
+		Notifications: s.notifications != nil,
+		MCPPrompts:    s.mcpPrompts != nil,
+		VoiceInput:    s.audio.STTEnabled(),
+		SpeakAnswers:  s.audio.TTSEnabled(),
+		Storage:       s.storage != nil,
+		Files:         s.files != nil,
+		FileUpload:    s.files != nil && !s.readOnly,
+		Sessions:      s.sessions != nil,
+	}
+	if s.agentHandler != nil {
+		data.AnswerStyles = s.agentHandler.AnswerStyles()
+		data.ToolProfiles = s.agentHandler.ToolProfiles()
+		data.Vision = llm.SupportsVision(s.agentHandler.llmProvider)
+	}
+	if err := s.tmpl.Execute(w, data); err != nil {
 		log.Printf("[Web] Template render error: %v", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 	}
 }
 
+// EnableGRPC makes Start also serve the gRPC management API (api/omega/v1)
+// on addr. Requires an agent handler.
+func (s *Server) EnableGRPC(addr string) {
+	s.grpcAddr = addr
+}
+
+// EnableStorage serves the workspace storage report (GET /api/storage) and
+// manual GC (POST /api/storage/gc), and shows a banner on the page while
+// the workspace volume is low on space.
+func (s *Server) EnableStorage(h *StorageHandler) {
+	s.storage = h
+	s.mux.HandleFunc("/api/storage", h.HandleStorage)
+	s.mux.HandleFunc("/api/storage/gc", h.HandleGC)
+}
+
+// EnableWalkthroughs serves the archived walkthrough memos of past runs
+// (GET /api/walkthroughs and /api/walkthroughs/{id}).
+func (s *Server) EnableWalkthroughs(h *WalkthroughHandler) {
+	s.mux.HandleFunc("/api/walkthroughs", h.HandleList)
+	s.mux.HandleFunc("/api/walkthroughs/{id}", h.HandleGet)
+}
+
+// EnableConfig serves the effective settings (GET /api/config).
+func (s *Server) EnableConfig(h *ConfigHandler) {
+	s.mux.HandleFunc("/api/config", h.HandleConfig)
+}
+
+// EnableAudit serves the audit log of tool calls (GET /api/audit).
+func (s *Server) EnableAudit(h *AuditHandler) {
+	s.mux.HandleFunc("/api/audit", h.HandleAudit)
+}
+
+// EnableFiles serves the workspace files API — listing (GET /api/files),
+// preview, download, upload, rename and delete under /api/files/ — and
+// offers the file browser panel and an upload button on the page.
+func (s *Server) EnableFiles(h *FilesHandler) {
+	s.files = h
+	s.mux.HandleFunc("/api/files", h.HandleList)
+	s.mux.HandleFunc("/api/files/preview", h.HandlePreview)
+	s.mux.HandleFunc("/api/files/rename", h.HandleRename)
+	s.mux.HandleFunc("/api/files/delete", h.HandleDelete)
+	s.mux.HandleFunc("/api/files/download", h.HandleDownload)
+	s.mux.HandleFunc("/api/files/upload", h.HandleUpload)
+}
+
+// EnableSessions serves the session list (GET /api/sessions) and the
+// session detail and delete routes, and offers the conversation sidebar.
+func (s *Server) EnableSessions(h *SessionsHandler) {
+	s.sessions = h
+	s.mux.HandleFunc("/api/sessions", h.HandleList)
+	s.mux.HandleFunc("/api/sessions/{id}", h.HandleSession)
+}
+
 // Start begins listening on the configured port with graceful shutdown.
 // On SIGINT/SIGTERM, it waits up to 10s for in-flight requests to complete,
 // ensuring deferred cleanup (e.g. registry.CloseAll) runs reliably.
@@ -85,14 +227,34 @@ func (s *Server) Start() error {
 		host = "127.0.0.1"
 	}
 	addr := host + ":" + port
+	var handler http.Handler = s.mux
+	if telemetry.Enabled() {
+		handler = telemetry.HTTPMiddleware(handler)
+	}
 	srv := &http.Server{
 		Addr:              addr,
-		Handler:           s.mux,
+		Handler:           handler,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
 		IdleTimeout:       120 * time.Second,
 	}
 
+	var grpcSrv *grpc.Server
+	var grpcHealth *health.Server
+	if s.grpcAddr != "" && s.agentHandler != nil {
+		lis, err := net.Listen("tcp", s.grpcAddr)
+		if err != nil {
+			return err
+		}
+		grpcSrv, grpcHealth = newGRPCServer(s.agentHandler)
+		go func() {
+			if err := grpcSrv.Serve(lis); err != nil {
+				log.Printf("⚠️  gRPC server error: %v", err)
+			}
+		}()
+		log.Printf("🛰️ gRPC API listening on %s", lis.Addr())
+	}
+
 	// Graceful shutdown goroutine
 	go func() {
 		sigCh := make(chan os.Signal, 1)
@@ -103,6 +265,9 @@ func (s *Server) Start() error {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		if grpcSrv != nil {
+			stopGRPC(shutdownCtx, grpcSrv, grpcHealth)
+		}
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			log.Printf("⚠️  Graceful shutdown error: %v", err)
 		}
@@ -116,3 +281,19 @@ func (s *Server) Start() error {
 	}
 	return err
 }
+
+// stopGRPC stops srv gracefully; streams still open when ctx ends (e.g. a
+// long agent run) are cut off. Health checks report NOT_SERVING meanwhile.
+func stopGRPC(ctx context.Context, srv *grpc.Server, hs *health.Server) {
+	hs.Shutdown()
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
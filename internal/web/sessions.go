@@ -0,0 +1,163 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+const (
+	sessionTitleMaxRunes = 30
+	sessionTitleTimeout  = 30 * time.Second
+	sessionTitleQuotes   = "\"'“”‘’「」『』《》*`"
+)
+
+// SessionTitler names new sessions after their first message: a truncated
+// copy at once, then a short title written by the summarize model. Register
+// Title with session.Store.OnNewSession.
+type SessionTitler struct {
+	store    *session.Store
+	provider llm.LLMProvider // nil = keep the truncated message
+}
+
+// NewSessionTitler creates a titler; provider may be nil.
+func NewSessionTitler(store *session.Store, provider llm.LLMProvider) *SessionTitler {
+	return &SessionTitler{store: store, provider: provider}
+}
+
+// Title names session id after its first user message.
+func (t *SessionTitler) Title(id, firstMsg string) {
+	fallback := sessionTitle(firstMsg)
+	if fallback == "" || !t.store.SetTitle(id, fallback) || t.provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(llm.WithModelRole(context.Background(), llm.ModelSummarize), sessionTitleTimeout)
+	defer cancel()
+	resp, err := t.provider.CallLLM(ctx, []llm.Message{{
+		Role:    llm.RoleUser,
+		Content: "为下面这条对话请求起一个简短的标题（不超过 15 个字，使用请求的语言，不要引号和结尾标点），只输出标题：\n\n" + util.TruncateRunes(firstMsg, 500),
+	}})
+	if err != nil {
+		log.Printf("[Session] Title generation failed for %s: %v", id, err)
+		return
+	}
+	if title := sessionTitle(resp.Content); title != "" {
+		t.store.SetTitle(id, title)
+	}
+}
+
+// sessionTitle reduces text to its first line, without markdown emphasis,
+// quotes or trailing punctuation, within sessionTitleMaxRunes.
+func sessionTitle(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "#*-> "))
+		line = strings.TrimPrefix(strings.TrimPrefix(line, "标题："), "Title:")
+		line = strings.Trim(strings.TrimSpace(line), sessionTitleQuotes)
+		line = strings.Trim(strings.TrimRight(line, "。.！!？?，,；;：:"), sessionTitleQuotes)
+		if line != "" {
+			return util.TruncateRunes(line, sessionTitleMaxRunes)
+		}
+	}
+	return ""
+}
+
+// SessionsHandler lists, shows and deletes the conversations of the session
+// store, for the sidebar that switches between them:
+//
+//	GET    /api/sessions      → {"sessions": [sessionView...]}, most recent first
+//	GET    /api/sessions/{id} → sessionDetail with the turns
+//	DELETE /api/sessions/{id} → 204
+//
+// Session IDs are the only access control on conversations; the list shows
+// them to everyone who can reach the server, like the rest of the API.
+type SessionsHandler struct {
+	store    *session.Store
+	readOnly bool
+}
+
+// sessionView is one entry of the session list.
+type sessionView struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	Turns      int       `json:"turns"`
+	HasSummary bool      `json:"has_summary"`
+	LastUsed   time.Time `json:"last_used"`
+}
+
+// sessionDetail is a session with its turns.
+type sessionDetail struct {
+	ID       string        `json:"id"`
+	Title    string        `json:"title"`
+	Summary  string        `json:"summary,omitempty"` // older turns folded by /compact
+	Turns    []sessionTurn `json:"turns"`
+	LastUsed time.Time     `json:"last_used"`
+}
+
+type sessionTurn struct {
+	User      string `json:"user"`
+	Assistant string `json:"assistant"`
+	Agent     bool   `json:"agent"`
+}
+
+// NewSessionsHandler creates the sessions API handler; deletion is refused
+// in read-only mode.
+func NewSessionsHandler(store *session.Store, readOnly bool) *SessionsHandler {
+	return &SessionsHandler{store: store, readOnly: readOnly}
+}
+
+// HandleList serves GET /api/sessions.
+func (h *SessionsHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	infos := h.store.List()
+	views := make([]sessionView, 0, len(infos))
+	for _, info := range infos {
+		views = append(views, sessionView{ID: info.ID, Title: info.Title, Turns: info.Turns, HasSummary: info.HasSummary, LastUsed: info.LastUsed})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]sessionView{"sessions": views})
+}
+
+// HandleSession serves GET and DELETE /api/sessions/{id}.
+func (h *SessionsHandler) HandleSession(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(r.PathValue("id"))
+	switch r.Method {
+	case http.MethodGet:
+		sess, ok := h.store.Get(id)
+		if !ok {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		detail := sessionDetail{ID: sess.ID, Title: sess.Title, Summary: sess.Summary, Turns: make([]sessionTurn, 0, len(sess.History)), LastUsed: sess.LastUsed}
+		for _, t := range sess.History {
+			detail.Turns = append(detail.Turns, sessionTurn{User: t.UserMsg, Assistant: t.Assistant, Agent: t.IsAgent})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(detail)
+
+	case http.MethodDelete:
+		if h.readOnly {
+			http.Error(w, "deleting sessions is disabled in read-only mode", http.StatusForbidden)
+			return
+		}
+		if _, ok := h.store.Get(id); !ok {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		h.store.Delete(id)
+		log.Printf("[Session] %s deleted via API", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,96 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/llm"
+	"github.com/pocketomega/pocket-omega/internal/session"
+	"github.com/pocketomega/pocket-omega/internal/util"
+)
+
+func TestSessionsHandler(t *testing.T) {
+	store := session.NewStore(time.Minute, 10)
+	defer store.Close()
+	store.AppendTurn("old", session.Turn{UserMsg: "q0", Assistant: "a0"})
+	store.AppendTurn("s1", session.Turn{UserMsg: "部署应用", Assistant: "已部署", IsAgent: true})
+	store.SetTitle("s1", "部署应用")
+
+	s := &Server{mux: http.NewServeMux()}
+	s.EnableSessions(NewSessionsHandler(store, false))
+	do := func(method, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+		return w
+	}
+
+	var list struct{ Sessions []sessionView }
+	if err := json.NewDecoder(do(http.MethodGet, "/api/sessions").Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Sessions) != 2 || list.Sessions[0].ID != "s1" || list.Sessions[0].Title != "部署应用" || list.Sessions[0].Turns != 1 {
+		t.Fatalf("list = %+v", list.Sessions)
+	}
+
+	var detail sessionDetail
+	json.NewDecoder(do(http.MethodGet, "/api/sessions/s1").Body).Decode(&detail)
+	if detail.Title != "部署应用" || len(detail.Turns) != 1 || detail.Turns[0] != (sessionTurn{User: "部署应用", Assistant: "已部署", Agent: true}) {
+		t.Errorf("detail = %+v", detail)
+	}
+	if w := do(http.MethodGet, "/api/sessions/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown session status = %d", w.Code)
+	}
+
+	if w := do(http.MethodDelete, "/api/sessions/old"); w.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d", w.Code)
+	}
+	if store.Count() != 1 {
+		t.Errorf("sessions after delete = %d", store.Count())
+	}
+	if w := do(http.MethodDelete, "/api/sessions/old"); w.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/sessions"); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/sessions status = %d", w.Code)
+	}
+
+	ro := &Server{mux: http.NewServeMux()}
+	ro.EnableSessions(NewSessionsHandler(store, true))
+	w := httptest.NewRecorder()
+	ro.mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/sessions/s1", nil))
+	if w.Code != http.StatusForbidden || store.Count() != 1 {
+		t.Errorf("read-only delete status = %d, sessions = %d", w.Code, store.Count())
+	}
+}
+
+func TestSessionTitler(t *testing.T) {
+	store := session.NewStore(time.Minute, 10)
+	defer store.Close()
+	store.AppendTurn("s1", session.Turn{UserMsg: "x"})
+
+	provider := &mockLLMProvider{response: llm.Message{Content: "标题：「重构登录模块」。\n"}}
+	NewSessionTitler(store, provider).Title("s1", "请帮我把 auth 包里的登录逻辑拆成独立模块，并补上单元测试，顺便检查一下会话过期的处理")
+	if sess, _ := store.Get("s1"); sess.Title != "重构登录模块" {
+		t.Errorf("title = %q", sess.Title)
+	}
+	if prompt := provider.lastMsgs[0].Content; !strings.Contains(prompt, "auth 包") {
+		t.Errorf("prompt = %q", prompt)
+	}
+
+	// Without a model, or when it fails, the first message names the session
+	store.AppendTurn("s2", session.Turn{UserMsg: "x"})
+	NewSessionTitler(store, &mockLLMProvider{err: errors.New("down")}).Title("s2", "## 查看  磁盘占用？\n详细说明")
+	if sess, _ := store.Get("s2"); sess.Title != "查看  磁盘占用" {
+		t.Errorf("fallback title = %q", sess.Title)
+	}
+	store.AppendTurn("s3", session.Turn{UserMsg: "x"})
+	NewSessionTitler(store, nil).Title("s3", strings.Repeat("长", 50))
+	if sess, _ := store.Get("s3"); sess.Title != util.TruncateRunes(strings.Repeat("长", 50), sessionTitleMaxRunes) {
+		t.Errorf("title not truncated: %q", sess.Title)
+	}
+}
@@ -6,8 +6,14 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/i18n"
+	"github.com/pocketomega/pocket-omega/internal/llm"
 	"github.com/pocketomega/pocket-omega/internal/plan"
+	"github.com/pocketomega/pocket-omega/internal/redact"
 )
 
 // ── SSE Writer ──
@@ -49,7 +55,9 @@ func (s *sseWriter) Send(event string, data interface{}) bool {
 		log.Printf("[SSE] JSON marshal error: %v", err)
 		return false
 	}
-	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, string(jsonBytes)); err != nil {
+	// Masks only alphanumeric token text, so the JSON stays valid.
+	payload := redact.String(string(withSchemaVersion(jsonBytes)))
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
 		log.Printf("[SSE] Write error (client disconnected?): %v", err)
 		return false
 	}
@@ -57,7 +65,118 @@ func (s *sseWriter) Send(event string, data interface{}) bool {
 	return true
 }
 
+// ── SSE Subscriptions ──
+
+// Event severities for the min_severity subscription parameter, lowest
+// first: token chunks and status lines are debug, a failed tool call or a
+// notice is warn, everything else info.
+const (
+	sseSeverityDebug = iota
+	sseSeverityInfo
+	sseSeverityWarn
+)
+
+var sseSeverityNames = map[string]int{"debug": sseSeverityDebug, "info": sseSeverityInfo, "warn": sseSeverityWarn}
+
+// sseFilter is a client's subscription to a subset of the agent events:
+// the events form value ("plan,done") selects event types, min_severity
+// drops less important events. The run and done events frame every run,
+// so min_severity never drops them.
+type sseFilter struct {
+	events      map[string]bool // nil = all events
+	minSeverity int
+}
+
+// parseSSEFilter parses the subscription parameters; nil when they ask for
+// the full stream. Event names may be catalog aliases (sseEventAliases).
+func parseSSEFilter(events, minSeverity string) (*sseFilter, error) {
+	f := &sseFilter{}
+	for _, name := range strings.Split(events, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if wire, ok := sseEventAliases[name]; ok {
+			name = wire
+		}
+		if !sseAgentEvents[name] {
+			return nil, fmt.Errorf("unknown event type %q", name)
+		}
+		if f.events == nil {
+			f.events = make(map[string]bool)
+		}
+		f.events[name] = true
+	}
+	if minSeverity = strings.TrimSpace(minSeverity); minSeverity != "" {
+		level, ok := sseSeverityNames[minSeverity]
+		if !ok {
+			return nil, fmt.Errorf("unknown severity %q (want debug, info or warn)", minSeverity)
+		}
+		f.minSeverity = level
+	}
+	if f.events == nil && f.minSeverity == sseSeverityDebug {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// allows reports whether the subscription includes the event.
+func (f *sseFilter) allows(event string, data interface{}) bool {
+	if f.events != nil && !f.events[event] {
+		return false
+	}
+	if event == sseEventRun || event == sseEventDone {
+		return true
+	}
+	return sseSeverity(event, data) >= f.minSeverity
+}
+
+// sseSeverity returns the catalog severity of the event (see sseCatalog);
+// a failed tool step is raised to warn.
+func sseSeverity(event string, data interface{}) int {
+	if step, ok := data.(agent.StepRecord); ok && event == sseEventTool && step.IsError {
+		return sseSeverityWarn
+	}
+	if spec, ok := sseEventSpecs[event]; ok {
+		return spec.Severity
+	}
+	return sseSeverityInfo
+}
+
+// filteredSink forwards the events a subscription allows to sink.
+type filteredSink struct {
+	sink   eventSink
+	filter *sseFilter
+}
+
+func (s filteredSink) Send(event string, data interface{}) bool {
+	if !s.filter.allows(event, data) {
+		return true
+	}
+	return s.sink.Send(event, data)
+}
+
 // ── SSE Event Types ──
+//
+// sseCatalog (sse_schema.go) lists every event with its payload type.
+
+// Events without a payload type of their own.
+const (
+	sseEventStatus  = "status"
+	sseEventThought = "thought"
+	sseEventStep    = "step" // agent.StepRecord
+	sseEventTool    = "tool" // agent.StepRecord
+	sseEventChunk   = "chunk"
+	sseEventDone    = "done"
+)
+
+type sseStatusEvent struct {
+	Message string `json:"message"`
+}
+
+type sseChunkEvent struct {
+	Text string `json:"text"`
+}
 
 type sseThoughtEvent struct {
 	ThoughtNumber   int    `json:"thought_number"`
@@ -72,14 +191,98 @@ type sseDoneEvent struct {
 
 // agentStats holds execution statistics returned in the done event.
 type agentStats struct {
-	Steps      int   `json:"steps"`
-	ToolCalls  int   `json:"tool_calls"`
-	ElapsedMs  int64 `json:"elapsed_ms"`
-	TokensUsed int64 `json:"tokens_used"` // 0 if CostGuard disabled
+	Steps      int                  `json:"steps"`
+	ToolCalls  int                  `json:"tool_calls"`
+	ElapsedMs  int64                `json:"elapsed_ms"`
+	TokensUsed int64                `json:"tokens_used"`         // 0 if CostGuard disabled
+	Downshift  *agent.DownshiftInfo `json:"downshift,omitempty"` // set when decide steps switched models
+	Outcome    agent.Outcome        `json:"outcome,omitempty"`   // rule-based run classification
 }
 
 const sseEventPlan = "plan"
 
+// sseEventNotice carries one-off run notices (e.g. model downshift) that the
+// UI shows inline in the agent step box.
+const sseEventNotice = "notice"
+
+type sseNoticeEvent struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// retryNoticeMessage renders an LLM retry or failover as a notice.
+func retryNoticeMessage(locale string, n llm.RetryNotice) string {
+	if n.Failover != "" {
+		return fmt.Sprintf(i18n.T(locale, "llm.failover"), n.Failover)
+	}
+	return fmt.Sprintf(i18n.T(locale, "llm.retrying"), n.Wait.Round(100*time.Millisecond), n.Attempt, n.MaxRetries)
+}
+
+// sseEventFileDiff follows a successful edit step (file_write, file_patch)
+// with the unified diff of the change; the UI renders it collapsible under
+// the step.
+const sseEventFileDiff = "file_diff"
+
+type sseFileDiffEvent struct {
+	StepNumber int `json:"step_number"`
+	agent.FileDiff
+}
+
+// sseEventQueue reports that an agent run is waiting: Position is the
+// 1-based place in the run queue, or 0 while another run of the same
+// session is still active.
+const sseEventQueue = "queue"
+
+type sseQueueEvent struct {
+	Position int    `json:"position"`
+	Message  string `json:"message"`
+}
+
+// sseEventRun is the first event of a started agent run; RunID is the
+// handle for POST /api/agent/{runID}/cancel.
+const sseEventRun = "run"
+
+type sseRunEvent struct {
+	RunID string `json:"run_id"`
+}
+
+// sseEventAwaitingInput pauses an agent run on a clarifying question; the
+// run continues once POST /api/agent/reply delivers the user's answer.
+const sseEventAwaitingInput = "awaiting_input"
+
+type sseAwaitingInputEvent struct {
+	Question       string `json:"question"`
+	TimeoutSeconds int    `json:"timeout_seconds"` // the run continues without a reply after this
+}
+
+// sseEventAwaitingPlan pauses an agent run on a new plan (AGENT_PLAN_CONFIRM);
+// the run continues once /api/plan/{sid} confirms or rejects it.
+const sseEventAwaitingPlan = "awaiting_plan"
+
+type sseAwaitingPlanEvent struct {
+	Steps          []plan.PlanStep `json:"steps"`
+	TimeoutSeconds int             `json:"timeout_seconds"` // the plan counts as not confirmed after this
+}
+
 type ssePlanEvent struct {
 	Steps []plan.PlanStep `json:"steps"`
 }
+
+// sseEventUsage reports the budget use of a run after each step; sent only
+// when the run has a CostGuard.
+const sseEventUsage = "usage"
+
+type sseUsageEvent struct {
+	TokensUsed int64 `json:"tokens_used"`
+	MaxTokens  int64 `json:"max_tokens,omitempty"` // 0 = no token budget
+	ElapsedMs  int64 `json:"elapsed_ms"`
+}
+
+// sseEventError reports why a run stopped before it finished; the done
+// event still follows with whatever answer the run produced.
+const sseEventError = "error"
+
+type sseErrorEvent struct {
+	Code    string `json:"code"` // timeout, budget_exceeded, cancelled
+	Message string `json:"message"`
+}
@@ -0,0 +1,226 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+)
+
+// sseSchemaVersion is the version of the SSE event contract. Every payload
+// carries it as "v". New events and new fields keep the version; it
+// changes only when an event or field is removed or changes meaning.
+const sseSchemaVersion = 1
+
+// Streams an event can appear on.
+const (
+	sseStreamAgent = "agent" // POST /api/agent (and the gRPC RunTask mapping)
+	sseStreamChat  = "chat"  // POST /api/chat
+)
+
+// sseEventSpec describes one event of the catalog.
+type sseEventSpec struct {
+	Name        string
+	Streams     []string
+	Severity    int         // for min_severity; a failed tool step is warn
+	Payload     interface{} // zero value of the payload type
+	Description string
+}
+
+// sseCatalog is the typed contract of the SSE streams: every event a
+// handler sends is listed here with its payload type, in the order a run
+// usually produces them. GET /api/events/schema publishes it.
+var sseCatalog = []sseEventSpec{
+	{sseEventQueue, []string{sseStreamAgent}, sseSeverityInfo, sseQueueEvent{},
+		"The run waits: position in the run queue, or 0 while another run of the session is active."},
+	{sseEventRun, []string{sseStreamAgent}, sseSeverityInfo, sseRunEvent{},
+		"First event of a started run; run_id is the handle for POST /api/agent/{run_id}/cancel."},
+	{sseEventStatus, []string{sseStreamAgent, sseStreamChat}, sseSeverityDebug, sseStatusEvent{},
+		"Transient status line."},
+	{sseEventThought, []string{sseStreamChat}, sseSeverityInfo, sseThoughtEvent{},
+		"One chain-of-thought step of a chat answer."},
+	{sseEventPlan, []string{sseStreamAgent}, sseSeverityInfo, ssePlanEvent{},
+		"The run's plan changed; steps is the whole plan, empty when it was dropped."},
+	{sseEventAwaitingPlan, []string{sseStreamAgent}, sseSeverityInfo, sseAwaitingPlanEvent{},
+		"A new plan needs approval through /api/plan/{session_id} before the run continues."},
+	{sseEventAwaitingInput, []string{sseStreamAgent}, sseSeverityInfo, sseAwaitingInputEvent{},
+		"A clarifying question; POST /api/agent/reply answers it."},
+	{sseEventStep, []string{sseStreamAgent}, sseSeverityInfo, agent.StepRecord{},
+		"A completed decide, think, compact, review, ask or verify step."},
+	{sseEventTool, []string{sseStreamAgent}, sseSeverityInfo, agent.StepRecord{},
+		"A completed tool call; warn severity when is_error is set."},
+	{sseEventEditorLink, []string{sseStreamAgent}, sseSeverityInfo, sseEditorLinkEvent{},
+		"Open-in-editor link for the file a tool step touched."},
+	{sseEventFileDiff, []string{sseStreamAgent}, sseSeverityInfo, sseFileDiffEvent{},
+		"Unified diff of a successful file edit."},
+	{sseEventUsage, []string{sseStreamAgent}, sseSeverityDebug, sseUsageEvent{},
+		"Tokens and time used so far, after each step of a run with a budget."},
+	{sseEventChunk, []string{sseStreamAgent}, sseSeverityDebug, sseChunkEvent{},
+		"A streamed token chunk of the final answer."},
+	{sseEventNotice, []string{sseStreamAgent}, sseSeverityWarn, sseNoticeEvent{},
+		"One-off run notice (retrying, downshift, checkpoint, correction, ...)."},
+	{sseEventError, []string{sseStreamAgent}, sseSeverityWarn, sseErrorEvent{},
+		"The run stopped early (timeout, budget, cancellation); done follows."},
+	{sseEventDone, []string{sseStreamAgent, sseStreamChat}, sseSeverityInfo, sseDoneEvent{},
+		"Last event: the answer, with run statistics on the agent stream."},
+}
+
+// sseEventAliases maps the contract names of the event catalog to the names
+// sent on the wire. The streams kept the names the web UI already handled
+// (plan, chunk, awaiting_plan); the schema lists the contract names as
+// aliases and event subscriptions accept either.
+var sseEventAliases = map[string]string{
+	"plan_update":       sseEventPlan,
+	"token":             sseEventChunk,
+	"approval_required": sseEventAwaitingPlan,
+}
+
+// sseEventSpecs indexes sseCatalog by event name.
+var sseEventSpecs = func() map[string]sseEventSpec {
+	m := make(map[string]sseEventSpec, len(sseCatalog))
+	for _, spec := range sseCatalog {
+		m[spec.Name] = spec
+	}
+	return m
+}()
+
+// sseAgentEvents lists the events of an /api/agent stream.
+var sseAgentEvents = func() map[string]bool {
+	m := make(map[string]bool)
+	for _, spec := range sseCatalog {
+		for _, stream := range spec.Streams {
+			if stream == sseStreamAgent {
+				m[spec.Name] = true
+			}
+		}
+	}
+	return m
+}()
+
+// withSchemaVersion adds the "v" field to a JSON object payload.
+func withSchemaVersion(payload []byte) []byte {
+	if len(payload) < 2 || payload[0] != '{' {
+		return payload
+	}
+	out := make([]byte, 0, len(payload)+8)
+	out = strconv.AppendInt(append(out, `{"v":`...), sseSchemaVersion, 10)
+	if rest := payload[1:]; rest[0] != '}' {
+		out = append(out, ',')
+	}
+	return append(out, payload[1:]...)
+}
+
+// sseFieldSchema describes one payload field.
+type sseFieldSchema struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"` // JSON type: string, integer, number, boolean, array, object
+	Optional bool   `json:"optional,omitempty"`
+}
+
+type sseEventSchema struct {
+	Name        string           `json:"name"`              // as sent on the stream
+	Aliases     []string         `json:"aliases,omitempty"` // other names of the event, see sseEventAliases
+	Streams     []string         `json:"streams"`
+	Severity    string           `json:"severity"`
+	Description string           `json:"description"`
+	Fields      []sseFieldSchema `json:"fields"`
+}
+
+// sseSchema is the body of GET /api/events/schema.
+type sseSchema struct {
+	Version int              `json:"version"`
+	Events  []sseEventSchema `json:"events"`
+}
+
+func buildSSESchema() sseSchema {
+	severities := make(map[int]string, len(sseSeverityNames))
+	for name, level := range sseSeverityNames {
+		severities[level] = name
+	}
+	aliases := make(map[string][]string, len(sseEventAliases))
+	for alias, name := range sseEventAliases {
+		aliases[name] = append(aliases[name], alias)
+	}
+	s := sseSchema{Version: sseSchemaVersion}
+	for _, spec := range sseCatalog {
+		fields := []sseFieldSchema{{Name: "v", Type: "integer"}}
+		sort.Strings(aliases[spec.Name])
+		s.Events = append(s.Events, sseEventSchema{
+			Name:        spec.Name,
+			Aliases:     aliases[spec.Name],
+			Streams:     spec.Streams,
+			Severity:    severities[spec.Severity],
+			Description: spec.Description,
+			Fields:      appendPayloadFields(fields, reflect.TypeOf(spec.Payload)),
+		})
+	}
+	return s
+}
+
+// appendPayloadFields lists the JSON fields of struct type t; embedded
+// structs contribute their fields, as encoding/json flattens them.
+func appendPayloadFields(fields []sseFieldSchema, t reflect.Type) []sseFieldSchema {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			fields = appendPayloadFields(fields, f.Type)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, sseFieldSchema{
+			Name:     name,
+			Type:     jsonTypeName(f.Type),
+			Optional: strings.Contains(opts, "omitempty") || f.Type.Kind() == reflect.Pointer,
+		})
+	}
+	return fields
+}
+
+func jsonTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(reflect.TypeOf((*json.Marshaler)(nil)).Elem()) && t.Kind() == reflect.Struct {
+		return "string" // time.Time and friends
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
+
+// handleEventSchema serves GET /api/events/schema: the version and event
+// catalog of the SSE streams, for clients that validate what they receive.
+// Events are named as sent; plan_update, token and approval_required are
+// listed as aliases of plan, chunk and awaiting_plan.
+func handleEventSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildSSESchema())
+}
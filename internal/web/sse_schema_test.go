@@ -0,0 +1,115 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+)
+
+func TestWithSchemaVersion(t *testing.T) {
+	for in, want := range map[string]string{
+		`{"text":"x"}`: `{"v":1,"text":"x"}`,
+		`{}`:           `{"v":1}`,
+		`["a"]`:        `["a"]`,
+		`null`:         `null`,
+	} {
+		if got := string(withSchemaVersion([]byte(in))); got != want {
+			t.Errorf("withSchemaVersion(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestEventSchema(t *testing.T) {
+	w := httptest.NewRecorder()
+	handleEventSchema(w, httptest.NewRequest(http.MethodGet, "/api/events/schema", nil))
+	var schema sseSchema
+	if err := json.Unmarshal(w.Body.Bytes(), &schema); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status %d, %v: %s", w.Code, err, w.Body.String())
+	}
+	if schema.Version != sseSchemaVersion || len(schema.Events) != len(sseCatalog) {
+		t.Fatalf("version %d with %d events", schema.Version, len(schema.Events))
+	}
+
+	fields := make(map[string]string) // "event.field" → type
+	aliases := make(map[string]string)
+	for _, ev := range schema.Events {
+		for _, alias := range ev.Aliases {
+			aliases[alias] = ev.Name
+		}
+		if ev.Severity == "" || len(ev.Streams) == 0 || ev.Description == "" {
+			t.Errorf("incomplete event %+v", ev)
+		}
+		for _, f := range ev.Fields {
+			fields[ev.Name+"."+f.Name] = f.Type
+		}
+	}
+	for key, typ := range map[string]string{
+		"done.v":              "integer",
+		"done.solution":       "string",
+		"done.stats":          "object",
+		"plan.steps":          "array",
+		"file_diff.hunks":     "string", // embedded agent.FileDiff
+		"tool.is_error":       "boolean",
+		"usage.tokens_used":   "integer",
+		"error.code":          "string",
+		"awaiting_plan.steps": "array",
+	} {
+		if fields[key] != typ {
+			t.Errorf("%s: type %q, want %q", key, fields[key], typ)
+		}
+	}
+
+	for alias, name := range map[string]string{"plan_update": "plan", "token": "chunk", "approval_required": "awaiting_plan"} {
+		if aliases[alias] != name {
+			t.Errorf("alias %s of %q, want %s", alias, aliases[alias], name)
+		}
+	}
+
+	// Subscriptions accept every agent event of the catalog, and only those
+	for _, name := range []string{"awaiting_plan", "usage", "error"} {
+		if _, err := parseSSEFilter(name, ""); err != nil {
+			t.Errorf("subscription to %s: %v", name, err)
+		}
+	}
+	if f, err := parseSSEFilter("plan_update,token", ""); err != nil || !f.events["plan"] || !f.events["chunk"] {
+		t.Errorf("subscription by alias: %+v, %v", f, err)
+	}
+	if _, err := parseSSEFilter("thought", ""); err == nil {
+		t.Error("chat-only event accepted on the agent stream")
+	}
+
+	w = httptest.NewRecorder()
+	handleEventSchema(w, httptest.NewRequest(http.MethodPost, "/api/events/schema", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d", w.Code)
+	}
+}
+
+func TestRunError(t *testing.T) {
+	h := &AgentHandler{uiLocale: "en"}
+	guard := agent.NewCostGuard(10, 0)
+	guard.RecordTokens(20)
+	for _, tc := range []struct {
+		state *agent.AgentState
+		cause error
+		want  string
+	}{
+		{&agent.AgentState{}, nil, ""},
+		{&agent.AgentState{}, fmt.Errorf("run: %w", context.DeadlineExceeded), "timeout"},
+		{&agent.AgentState{}, errCancelledByUser, "cancelled"},
+		{&agent.AgentState{CostGuard: guard}, nil, "budget_exceeded"},
+	} {
+		code := ""
+		if got := h.runError(tc.state, tc.cause); got != nil {
+			code = got.Code
+		}
+		if code != tc.want {
+			t.Errorf("%v: code %q, want %q", tc.cause, code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package web
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+//go:embed templates/stats.html
+var statsContent embed.FS
+
+// statsTmpl renders the /stats page.
+var statsTmpl = template.Must(template.New("stats.html").Funcs(template.FuncMap{
+	"percent": func(n, total int) string {
+		if total == 0 {
+			return "0%"
+		}
+		return fmt.Sprintf("%.0f%%", float64(n)*100/float64(total))
+	},
+	"seconds": func(ms float64) string { return fmt.Sprintf("%.1fs", ms/1000) },
+}).ParseFS(statsContent, "templates/stats.html"))
+
+// OutcomeStats aggregates the recent run outcomes; ok is false when the
+// outcome log is disabled.
+func (h *AgentHandler) OutcomeStats() (stats agent.OutcomeStats, ok bool, err error) {
+	if h.outcomeLog == nil {
+		return agent.OutcomeStats{}, false, nil
+	}
+	stats, err = h.outcomeLog.Stats()
+	return stats, true, err
+}
+
+// HandleStats serves GET /api/agent/stats: outcome counts, top failure
+// reasons and tools, and the latest non-successful runs.
+func (h *AgentHandler) HandleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	stats, ok, err := h.OutcomeStats()
+	if !ok {
+		http.Error(w, "Outcome log disabled", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[Stats] %v", err)
+		http.Error(w, "Cannot read outcome log", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// HandleCacheStats serves GET /api/debug/cache_stats: size of the tool
+// result cache and per-tool hit/miss counters.
+func (h *AgentHandler) HandleCacheStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var cache *tool.ResultCache
+	if h.toolRegistry != nil {
+		cache = h.toolRegistry.ResultCache()
+	}
+	if cache == nil {
+		http.Error(w, "Tool cache disabled", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cache.Stats())
+}
+
+// handleStatsPage serves the /stats page.
+func (s *Server) handleStatsPage(w http.ResponseWriter, r *http.Request) {
+	stats, ok, err := s.agentHandler.OutcomeStats()
+	if !ok {
+		http.Error(w, "Outcome log disabled", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[Stats] %v", err)
+		http.Error(w, "Cannot read outcome log", http.StatusInternalServerError)
+		return
+	}
+	if err := statsTmpl.Execute(w, stats); err != nil {
+		log.Printf("[Web] Template render error: %v", err)
+	}
+}
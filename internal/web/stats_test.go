@@ -0,0 +1,90 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/agent"
+	"github.com/pocketomega/pocket-omega/internal/tool"
+)
+
+func TestHandleStats(t *testing.T) {
+	outcomes, err := agent.NewOutcomeLog(filepath.Join(t.TempDir(), "outcomes.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outcomes.Append(agent.RunRecord{Problem: "ok", RunOutcome: agent.RunOutcome{Outcome: agent.OutcomeSuccess}})
+	outcomes.Append(agent.RunRecord{Problem: "<script>构建失败</script>", ToolErrors: map[string]int{"shell_exec": 2},
+		RunOutcome: agent.RunOutcome{Outcome: agent.OutcomePartial, Reasons: []string{agent.ReasonMaxSteps}, Judge: "遗漏了测试"}})
+	h := NewAgentHandler(AgentHandlerOptions{OutcomeLog: outcomes})
+
+	w := httptest.NewRecorder()
+	h.HandleStats(w, httptest.NewRequest(http.MethodGet, "/api/agent/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var stats agent.OutcomeStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Runs != 2 || stats.Outcomes["partial"] != 1 || len(stats.Recent) != 1 ||
+		len(stats.ToolErrors) != 1 || stats.ToolErrors[0].Name != "shell_exec" {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	s := &Server{mux: http.NewServeMux(), agentHandler: h}
+	s.registerRoutes()
+	page := httptest.NewRecorder()
+	s.mux.ServeHTTP(page, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	body := page.Body.String()
+	if page.Code != http.StatusOK {
+		t.Fatalf("page status = %d: %s", page.Code, body)
+	}
+	for _, want := range []string{"50%", agent.ReasonMaxSteps, "遗漏了测试", "&lt;script&gt;"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+}
+
+func TestHandleStats_Disabled(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{})
+	w := httptest.NewRecorder()
+	h.HandleStats(w, httptest.NewRequest(http.MethodGet, "/api/agent/stats", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+	w = httptest.NewRecorder()
+	h.HandleStats(w, httptest.NewRequest(http.MethodPost, "/api/agent/stats", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", w.Code)
+	}
+}
+
+func TestHandleCacheStats(t *testing.T) {
+	h := NewAgentHandler(AgentHandlerOptions{Registry: tool.NewRegistry()})
+	w := httptest.NewRecorder()
+	h.HandleCacheStats(w, httptest.NewRequest(http.MethodGet, "/api/debug/cache_stats", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("disabled: status = %d, want 404", w.Code)
+	}
+
+	registry := tool.NewRegistry()
+	registry.SetResultCache(tool.NewResultCache(8, map[string]time.Duration{"file_read": time.Minute}))
+	s := &Server{mux: http.NewServeMux(), agentHandler: NewAgentHandler(AgentHandlerOptions{Registry: registry})}
+	s.registerRoutes()
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/debug/cache_stats", nil))
+	var stats tool.CacheStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status = %d, err = %v", w.Code, err)
+	}
+	if stats.MaxEntries != 8 || len(stats.Tools) != 1 || stats.Tools[0].Name != "file_read" || stats.Tools[0].TTLSeconds != 60 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
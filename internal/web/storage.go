@@ -0,0 +1,46 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/pocketomega/pocket-omega/internal/storage"
+)
+
+// StorageHandler serves the workspace storage report and manual garbage
+// collection.
+type StorageHandler struct {
+	manager  *storage.Manager
+	readOnly bool
+}
+
+// NewStorageHandler creates a handler for m. In read-only mode GC is refused.
+func NewStorageHandler(m *storage.Manager, readOnly bool) *StorageHandler {
+	return &StorageHandler{manager: m, readOnly: readOnly}
+}
+
+// HandleStorage serves GET /api/storage: per-category usage and quotas,
+// the free space of the workspace volume and the last GC pass.
+func (h *StorageHandler) HandleStorage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.manager.Report())
+}
+
+// HandleGC serves POST /api/storage/gc: collects every category over its
+// quota now and returns what was removed.
+func (h *StorageHandler) HandleGC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.readOnly {
+		http.Error(w, "Read-only mode: garbage collection disabled", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.manager.GC())
+}
@@ -0,0 +1,64 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pocketomega/pocket-omega/internal/storage"
+)
+
+func TestStorageHandler(t *testing.T) {
+	ws := t.TempDir()
+	dir := filepath.Join(ws, ".omega", "tool_outputs")
+	os.MkdirAll(dir, 0o755)
+	os.WriteFile(filepath.Join(dir, "old.txt"), make([]byte, 100), 0o644)
+	os.WriteFile(filepath.Join(dir, "new.txt"), make([]byte, 100), 0o644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, "old.txt"), old, old)
+	m := storage.New(ws, storage.DefaultCategories(ws, map[string]int64{"tool_outputs": 150}), 0)
+
+	s := &Server{mux: http.NewServeMux()}
+	s.EnableStorage(NewStorageHandler(m, false))
+	w := httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/storage", nil))
+	var report storage.Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("status = %d, err = %v", w.Code, err)
+	}
+	var found bool
+	for _, c := range report.Categories {
+		if c.Name == "tool_outputs" {
+			found = c.Bytes == 200 && c.OverQuota
+		}
+	}
+	if !found || report.LowSpace {
+		t.Errorf("report = %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/storage/gc", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET gc: status = %d, want 405", w.Code)
+	}
+	w = httptest.NewRecorder()
+	s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/storage/gc", nil))
+	var res storage.GCResult
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil || res.Removed != 1 {
+		t.Errorf("gc = %+v, %v", res, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Error("old.txt survived GC")
+	}
+
+	ro := NewStorageHandler(m, true)
+	w = httptest.NewRecorder()
+	ro.HandleGC(w, httptest.NewRequest(http.MethodPost, "/api/storage/gc", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("read-only gc: status = %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,61 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/pocketomega/pocket-omega/internal/walkthrough"
+)
+
+// WalkthroughHandler serves the memos archived by past agent runs.
+type WalkthroughHandler struct {
+	archive *walkthrough.Archive
+}
+
+// NewWalkthroughHandler creates a handler browsing archive.
+func NewWalkthroughHandler(archive *walkthrough.Archive) *WalkthroughHandler {
+	return &WalkthroughHandler{archive: archive}
+}
+
+// HandleList serves GET /api/walkthroughs: the archived memos without
+// their entries, newest first.
+func (h *WalkthroughHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	memos, err := h.archive.List()
+	if err != nil {
+		log.Printf("[Walkthrough] List failed: %v", err)
+		http.Error(w, "Failed to list walkthroughs", http.StatusInternalServerError)
+		return
+	}
+	if memos == nil {
+		memos = []walkthrough.Memo{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(memos)
+}
+
+// HandleGet serves GET /api/walkthroughs/{id}: one archived memo with its
+// entries.
+func (h *WalkthroughHandler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	memo, err := h.archive.Get(r.PathValue("id"))
+	if errors.Is(err, walkthrough.ErrNotFound) {
+		http.Error(w, "Walkthrough not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("[Walkthrough] Get failed: %v", err)
+		http.Error(w, "Failed to read walkthrough", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(memo)
+}
@@ -0,0 +1,63 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pocketomega/pocket-omega/internal/walkthrough"
+)
+
+func TestWalkthroughHandler(t *testing.T) {
+	archive := walkthrough.NewArchive(t.TempDir())
+	id, err := archive.Save("s1", "fix the build", []walkthrough.Entry{
+		{StepNumber: 3, Source: walkthrough.SourceAuto, Content: "shell_exec: go build failed in pkg/x"},
+		{Source: walkthrough.SourceManual, Content: "CGO is disabled on this host"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{mux: http.NewServeMux()}
+	s.EnableWalkthroughs(NewWalkthroughHandler(archive))
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	var memos []walkthrough.Memo
+	if w := get("/api/walkthroughs"); w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&memos) != nil {
+		t.Fatalf("list: status %d", w.Code)
+	}
+	if len(memos) != 1 || memos[0].ID != id || memos[0].Count != 2 {
+		t.Errorf("list = %+v", memos)
+	}
+	var memo walkthrough.Memo
+	if w := get("/api/walkthroughs/" + id); w.Code != http.StatusOK || json.NewDecoder(w.Body).Decode(&memo) != nil {
+		t.Fatalf("get: status %d", w.Code)
+	}
+	if memo.Problem != "fix the build" || len(memo.Entries) != 2 {
+		t.Errorf("memo = %+v", memo)
+	}
+	if w := get("/api/walkthroughs/20200101-000000-deadbeef"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown id: status %d, want 404", w.Code)
+	}
+
+	h := NewCommandHandler(CommandHandlerOptions{Walkthroughs: archive})
+	res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "walkthrough"}))
+	if !res.OK || !strings.Contains(res.Message, "1. ") || !strings.Contains(res.Message, "fix the build（2 条）") {
+		t.Errorf("/walkthrough = %+v", res)
+	}
+	res = decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "walkthrough", Args: "1"}))
+	if !res.OK || !strings.Contains(res.Message, "[步骤3] shell_exec") || !strings.Contains(res.Message, "📌 CGO is disabled") {
+		t.Errorf("/walkthrough 1 = %+v", res)
+	}
+	if res := decodeResult(t, doCommand(t, h, http.MethodPost, commandRequest{Command: "walkthrough", Args: "2"})); res.OK {
+		t.Errorf("/walkthrough 2 = %+v", res)
+	}
+	if res := decodeResult(t, doCommand(t, NewCommandHandler(CommandHandlerOptions{}), http.MethodPost, commandRequest{Command: "walkthrough"})); res.OK {
+		t.Errorf("archive disabled: %+v", res)
+	}
+}
@@ -0,0 +1,85 @@
+// Package workspace manages the per-workspace .omega metadata directory:
+// a versioned meta.json and the startup migrations that upgrade older
+// layouts. Persistent stores (todo snapshots, sessions, artifacts, memory)
+// live under .omega/, so a format change bumps SchemaVersion and registers
+// a Migration instead of silently breaking existing workspaces.
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DirName is the metadata directory inside the workspace root.
+const DirName = ".omega"
+
+// metaFile is the schema descriptor inside DirName.
+const metaFile = "meta.json"
+
+// Meta is the content of .omega/meta.json.
+type Meta struct {
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// History records every migration applied to this workspace, oldest first.
+	History []MigrationRecord `json:"history,omitempty"`
+}
+
+// MigrationRecord is one applied migration step.
+type MigrationRecord struct {
+	From      int       `json:"from"`
+	To        int       `json:"to"`
+	AppliedAt time.Time `json:"applied_at"`
+	Backup    string    `json:"backup,omitempty"` // workspace-relative backup dir
+}
+
+// Dir returns the .omega directory for workspaceDir.
+func Dir(workspaceDir string) string {
+	return filepath.Join(workspaceDir, DirName)
+}
+
+// ReadMeta loads .omega/meta.json. A missing file returns (nil, nil):
+// the workspace predates schema versioning (or has no .omega yet).
+func ReadMeta(workspaceDir string) (*Meta, error) {
+	data, err := os.ReadFile(filepath.Join(Dir(workspaceDir), metaFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("workspace: read %s: %w", metaFile, err)
+	}
+	var m Meta
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("workspace: parse %s: %w", metaFile, err)
+	}
+	if m.SchemaVersion < 1 {
+		return nil, fmt.Errorf("workspace: %s has invalid schema_version %d", metaFile, m.SchemaVersion)
+	}
+	return &m, nil
+}
+
+// writeMeta stores m atomically (temp file + rename) so a crash mid-write
+// never leaves a truncated meta.json behind.
+func writeMeta(workspaceDir string, m *Meta) error {
+	dir := Dir(workspaceDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("workspace: create %s: %w", DirName, err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, metaFile+".tmp")
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("workspace: write %s: %w", metaFile, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, metaFile)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("workspace: write %s: %w", metaFile, err)
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CurrentVersion is the .omega schema version this build reads and writes.
+// Bump it together with a new entry in migrations whenever a persistent
+// store under .omega changes format.
+const CurrentVersion = 1
+
+// backupsDir holds pre-migration snapshots inside .omega. It is never
+// migrated or copied into newer backups.
+const backupsDir = "backups"
+
+// ErrFutureVersion is returned when the workspace was written by a newer
+// release. Running against it could corrupt data the newer release relies on.
+var ErrFutureVersion = errors.New("workspace: .omega schema is newer than this build")
+
+// Migration upgrades the .omega directory from version To-1 to To.
+type Migration struct {
+	To          int
+	Description string
+	// Apply rewrites files inside omegaDir. nil means the step only bumps
+	// the version (the layout is unchanged).
+	Apply func(omegaDir string) error
+}
+
+// migrations must be ordered by To with no gaps, ending at CurrentVersion.
+var migrations = []Migration{
+	{To: 1, Description: "introduce meta.json (existing todos.json kept as-is)"},
+}
+
+// Result describes what Migrate did.
+type Result struct {
+	From    int      // version found on disk (0 = no meta.json)
+	To      int      // version after Migrate
+	Created bool     // meta.json was created for a workspace without prior .omega data
+	Backup  string   // workspace-relative backup dir; empty when nothing was backed up
+	Applied []string // descriptions of the migrations that ran
+}
+
+// Migrate brings the workspace's .omega directory up to CurrentVersion.
+// Existing data is copied to .omega/backups/v<from>-<timestamp> before the
+// first migration runs, and meta.json is rewritten after every step so an
+// interrupted upgrade resumes where it stopped. A workspace written by a
+// newer release yields ErrFutureVersion and is left untouched.
+func Migrate(workspaceDir string) (Result, error) {
+	return migrate(workspaceDir, migrations, CurrentVersion, time.Now())
+}
+
+// Check reports the on-disk schema version without writing anything, for
+// read-only mode. Like Migrate it refuses workspaces from a newer release;
+// an older schema is returned as Result.From < CurrentVersion for the
+// caller to warn about.
+func Check(workspaceDir string) (Result, error) {
+	meta, err := ReadMeta(workspaceDir)
+	if err != nil {
+		return Result{}, err
+	}
+	res := Result{}
+	if meta != nil {
+		res.From = meta.SchemaVersion
+	}
+	res.To = res.From
+	if res.From > CurrentVersion {
+		return res, fmt.Errorf("%w: found v%d, supports up to v%d", ErrFutureVersion, res.From, CurrentVersion)
+	}
+	return res, nil
+}
+
+func migrate(workspaceDir string, steps []Migration, current int, now time.Time) (Result, error) {
+	meta, err := ReadMeta(workspaceDir)
+	if err != nil {
+		return Result{}, err
+	}
+	res := Result{}
+	if meta != nil {
+		res.From = meta.SchemaVersion
+	}
+	res.To = res.From
+	if res.From > current {
+		return res, fmt.Errorf("%w: found v%d, supports up to v%d — upgrade Pocket-Omega or restore %s from a backup",
+			ErrFutureVersion, res.From, current, filepath.Join(DirName, metaFile))
+	}
+	if res.From == current {
+		return res, nil
+	}
+
+	dir := Dir(workspaceDir)
+	hasData, err := hasUserData(dir)
+	if err != nil {
+		return res, err
+	}
+	if meta == nil {
+		meta = &Meta{CreatedAt: now}
+		if !hasData {
+			// Fresh workspace: nothing to upgrade, just stamp the current version.
+			meta.SchemaVersion = current
+			meta.UpdatedAt = now
+			if err := writeMeta(workspaceDir, meta); err != nil {
+				return res, err
+			}
+			res.To, res.Created = current, true
+			return res, nil
+		}
+	}
+
+	if hasData {
+		rel := filepath.Join(DirName, backupsDir, fmt.Sprintf("v%d-%s", res.From, now.Format("20060102-150405")))
+		if err := copyTree(dir, filepath.Join(workspaceDir, rel)); err != nil {
+			return res, fmt.Errorf("workspace: backup before migration: %w", err)
+		}
+		res.Backup = rel
+		log.Printf("[Workspace] Backed up %s to %s", DirName, rel)
+	}
+
+	version := res.From
+	for _, m := range steps {
+		if m.To <= version {
+			continue
+		}
+		if m.To > current {
+			break
+		}
+		if m.To != version+1 {
+			return res, fmt.Errorf("workspace: no migration from v%d to v%d", version, version+1)
+		}
+		if m.Apply != nil {
+			if err := m.Apply(dir); err != nil {
+				return res, fmt.Errorf("workspace: migration v%d→v%d (%s) failed: %w; backup: %s",
+					version, m.To, m.Description, err, orNone(res.Backup))
+			}
+		}
+		meta.SchemaVersion = m.To
+		meta.UpdatedAt = now
+		meta.History = append(meta.History, MigrationRecord{From: version, To: m.To, AppliedAt: now, Backup: res.Backup})
+		if err := writeMeta(workspaceDir, meta); err != nil {
+			return res, err
+		}
+		version = m.To
+		res.To = version
+		res.Applied = append(res.Applied, m.Description)
+		log.Printf("[Workspace] Migrated %s v%d→v%d: %s", DirName, m.To-1, m.To, m.Description)
+	}
+	if version != current {
+		return res, fmt.Errorf("workspace: no migration from v%d to v%d", version, version+1)
+	}
+	return res, nil
+}
+
+// hasUserData reports whether dir exists and holds anything besides
+// meta.json and the backups directory.
+func hasUserData(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("workspace: read %s: %w", DirName, err)
+	}
+	for _, e := range entries {
+		if e.Name() != metaFile && e.Name() != backupsDir {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// copyTree copies src into dst recursively, skipping src/backups and
+// symlinks (which may point outside the workspace).
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == backupsDir && d.IsDir() {
+			return filepath.SkipDir
+		}
+		if d.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	sf, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer sf.Close()
+	info, err := sf.Stat()
+	if err != nil {
+		return err
+	}
+	df, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_EXCL, info.Mode())
+	if err != nil {
+		return err
+	}
+	_, copyErr := io.Copy(df, sf)
+	closeErr := df.Close()
+	if copyErr != nil {
+		return copyErr
+	}
+	return closeErr
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
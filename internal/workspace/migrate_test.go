@@ -0,0 +1,191 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrate_FreshWorkspace(t *testing.T) {
+	ws := t.TempDir()
+	res, err := Migrate(ws)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if !res.Created || res.To != CurrentVersion || res.Backup != "" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	meta, err := ReadMeta(ws)
+	if err != nil || meta == nil || meta.SchemaVersion != CurrentVersion {
+		t.Fatalf("meta = %+v, err = %v", meta, err)
+	}
+
+	// Second start is a no-op.
+	res, err = Migrate(ws)
+	if err != nil || res.Created || res.From != CurrentVersion || len(res.Applied) != 0 {
+		t.Errorf("second Migrate: %+v, %v", res, err)
+	}
+}
+
+func TestMigrate_LegacyDataIsBackedUp(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, DirName, "todos.json"), `{"items":[]}`)
+
+	res, err := Migrate(ws)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if res.From != 0 || res.To != CurrentVersion || res.Created {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if res.Backup == "" {
+		t.Fatal("legacy data should be backed up")
+	}
+	data, err := os.ReadFile(filepath.Join(ws, res.Backup, "todos.json"))
+	if err != nil || string(data) != `{"items":[]}` {
+		t.Errorf("backup content = %q, %v", data, err)
+	}
+	meta, _ := ReadMeta(ws)
+	if len(meta.History) != 1 || meta.History[0].Backup != res.Backup {
+		t.Errorf("history = %+v", meta.History)
+	}
+}
+
+func TestMigrate_RunsStepsInOrder(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, DirName, "data.txt"), "a")
+	if err := writeMeta(ws, &Meta{SchemaVersion: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var order []int
+	steps := []Migration{
+		{To: 1, Description: "one", Apply: func(string) error { order = append(order, 1); return nil }},
+		{To: 2, Description: "two", Apply: func(dir string) error {
+			order = append(order, 2)
+			return os.Rename(filepath.Join(dir, "data.txt"), filepath.Join(dir, "data.v2.txt"))
+		}},
+		{To: 3, Description: "three", Apply: func(string) error { order = append(order, 3); return nil }},
+	}
+	res, err := migrate(ws, steps, 3, testNow)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(order) != 2 || order[0] != 2 || order[1] != 3 {
+		t.Errorf("applied steps = %v, want [2 3]", order)
+	}
+	if res.From != 1 || res.To != 3 || !strings.Contains(res.Backup, "v1-20260102-030405") {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(ws, res.Backup, "data.txt")); err != nil {
+		t.Errorf("backup should hold the pre-migration layout: %v", err)
+	}
+	meta, _ := ReadMeta(ws)
+	if meta.SchemaVersion != 3 || len(meta.History) != 2 {
+		t.Errorf("meta = %+v", meta)
+	}
+}
+
+func TestMigrate_FailureKeepsLastGoodVersion(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, DirName, "data.txt"), "a")
+	if err := writeMeta(ws, &Meta{SchemaVersion: 1}); err != nil {
+		t.Fatal(err)
+	}
+	steps := []Migration{
+		{To: 2, Description: "ok"},
+		{To: 3, Description: "boom", Apply: func(string) error { return errors.New("disk full") }},
+	}
+	_, err := migrate(ws, steps, 3, testNow)
+	if err == nil || !strings.Contains(err.Error(), "disk full") || !strings.Contains(err.Error(), "backups") {
+		t.Fatalf("expected failure mentioning cause and backup, got %v", err)
+	}
+	meta, _ := ReadMeta(ws)
+	if meta.SchemaVersion != 2 {
+		t.Errorf("schema_version = %d, want 2 (last completed step)", meta.SchemaVersion)
+	}
+}
+
+func TestMigrate_FutureVersionRefused(t *testing.T) {
+	ws := t.TempDir()
+	if err := writeMeta(ws, &Meta{SchemaVersion: CurrentVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Migrate(ws)
+	if !errors.Is(err, ErrFutureVersion) {
+		t.Fatalf("expected ErrFutureVersion, got %v", err)
+	}
+	meta, _ := ReadMeta(ws)
+	if meta.SchemaVersion != CurrentVersion+1 {
+		t.Error("future meta.json must be left untouched")
+	}
+}
+
+func TestCheck_DoesNotWrite(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, DirName, "todos.json"), "{}")
+	res, err := Check(ws)
+	if err != nil || res.From != 0 {
+		t.Fatalf("Check = %+v, %v", res, err)
+	}
+	if meta, _ := ReadMeta(ws); meta != nil {
+		t.Error("Check must not create meta.json")
+	}
+
+	if err := writeMeta(ws, &Meta{SchemaVersion: CurrentVersion + 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Check(ws); !errors.Is(err, ErrFutureVersion) {
+		t.Errorf("expected ErrFutureVersion, got %v", err)
+	}
+}
+
+func TestMigrate_GapInMigrations(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, DirName, "data.txt"), "a")
+	if err := writeMeta(ws, &Meta{SchemaVersion: 1}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := migrate(ws, []Migration{{To: 3}}, 3, testNow)
+	if err == nil || !strings.Contains(err.Error(), "no migration from v1 to v2") {
+		t.Fatalf("expected gap error, got %v", err)
+	}
+}
+
+func TestReadMeta_Invalid(t *testing.T) {
+	ws := t.TempDir()
+	writeFile(t, filepath.Join(ws, DirName, metaFile), `{"schema_version":0}`)
+	if _, err := ReadMeta(ws); err == nil {
+		t.Error("schema_version 0 should be rejected")
+	}
+	writeFile(t, filepath.Join(ws, DirName, metaFile), `not json`)
+	if _, err := ReadMeta(ws); err == nil {
+		t.Error("malformed meta.json should be rejected")
+	}
+}
+
+func TestMigrationsAreContiguous(t *testing.T) {
+	for i, m := range migrations {
+		if m.To != i+1 {
+			t.Fatalf("migrations[%d].To = %d, want %d", i, m.To, i+1)
+		}
+	}
+	if migrations[len(migrations)-1].To != CurrentVersion {
+		t.Error("last migration must end at CurrentVersion")
+	}
+}